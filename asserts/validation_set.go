@@ -80,6 +80,11 @@ type ValidationSetSnap struct {
 
 	Revision   int
 	Components map[string]ValidationSetComponent
+
+	// SecurityRevisions lists revisions of the snap that are known to
+	// carry security fixes, an update that moves past any of them is
+	// considered security relevant.
+	SecurityRevisions []int
 }
 
 type ValidationSetComponent struct {
@@ -135,15 +140,47 @@ func checkValidationSetSnap(snap map[string]any) (*ValidationSetSnap, error) {
 		return nil, err
 	}
 
+	securityRevisions, err := checkValidationSetSecurityRevisions(snap, what)
+	if err != nil {
+		return nil, err
+	}
+	if len(securityRevisions) != 0 && presence == PresenceInvalid {
+		return nil, fmt.Errorf(`cannot specify security revisions %s at the same time as stating its presence is invalid`, what)
+	}
+
 	return &ValidationSetSnap{
-		Name:       snapName,
-		SnapID:     snapID,
-		Presence:   presence,
-		Revision:   snapRevision,
-		Components: components,
+		Name:              snapName,
+		SnapID:            snapID,
+		Presence:          presence,
+		Revision:          snapRevision,
+		Components:        components,
+		SecurityRevisions: securityRevisions,
 	}, nil
 }
 
+func checkValidationSetSecurityRevisions(snap map[string]any, what string) ([]int, error) {
+	const name = "security-revisions"
+	revs, err := checkStringListInMap(snap, name, fmt.Sprintf("%q %s", name, what), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) == 0 {
+		return nil, nil
+	}
+	res := make([]int, 0, len(revs))
+	for _, revStr := range revs {
+		rev, err := atoi(revStr, "%q %s", name, what)
+		if err != nil {
+			return nil, err
+		}
+		if rev < 1 {
+			return nil, fmt.Errorf(`%q %s must contain revisions >=1: %d`, name, what, rev)
+		}
+		res = append(res, rev)
+	}
+	return res, nil
+}
+
 func checkValidationSetComponents(snapName string, snap map[string]any, snapRevision int) (map[string]ValidationSetComponent, error) {
 	mapping, err := checkMapWhat(snap, "components", fmt.Sprintf("of snap %q", snapName))
 	if err != nil {
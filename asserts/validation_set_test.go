@@ -136,6 +136,10 @@ func (vss *validationSetSuite) TestDecodeInvalid(c *C) {
 		{"OTHER", "    components: some-string", `"components" field in "snaps" header must be a map`},
 		{"OTHER", "    components:\n      comp:\n        presence: optional\n", `must specify revision of component "baz-linux\+comp" since its associated snap specifies a revision`},
 		{"OTHER", "  -\n    name: foo-linux\n    id: foolinuxidididididididididididid\n    components:\n      comp:\n        revision: 1\n        presence: optional\n", `cannot specify revision of component "foo-linux\+comp" if its associated snap does not specify a revision`},
+		{"OTHER", "    security-revisions: 1\n", `"security-revisions" of snap "baz-linux" must be a list of strings`},
+		{"OTHER", "    security-revisions:\n      - one\n", `"security-revisions" of snap "baz-linux" is not an integer: one`},
+		{"OTHER", "    security-revisions:\n      - 0\n", `"security-revisions" of snap "baz-linux" must contain revisions >=1: 0`},
+		{"OTHER", "  -\n    name: foo-linux\n    id: foolinuxidididididididididididid\n    presence: invalid\n    security-revisions:\n      - 10\n", `cannot specify security revisions of snap "foo-linux" at the same time as stating its presence is invalid`},
 	}
 
 	for _, test := range invalidTests {
@@ -223,6 +227,18 @@ func (vss *validationSetSuite) TestSnapComponents(c *C) {
 	})
 }
 
+func (vss *validationSetSuite) TestSnapSecurityRevisions(c *C) {
+	encoded := strings.Replace(validationSetExample, "TSLINE", vss.tsLine, 1)
+	encoded = strings.Replace(encoded, "OTHER", "    security-revisions:\n      - 90\n      - 95\n", 1)
+
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	valset := a.(*asserts.ValidationSet)
+	snaps := valset.Snaps()
+	c.Assert(snaps, HasLen, 1)
+	c.Check(snaps[0].SecurityRevisions, DeepEquals, []int{90, 95})
+}
+
 func (vss *validationSetSuite) TestIsValidValidationSetName(c *C) {
 	names := []struct {
 		name  string
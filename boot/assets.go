@@ -492,6 +492,7 @@ type TrustedAssetsUpdateObserver struct {
 
 	modeenv       *Modeenv
 	modeenvLocked bool
+	resealed      bool
 }
 
 func doUpdateBootEntry(efiBl bootloader.UefiBootloader, updatedAssets []string) error {
@@ -793,9 +794,16 @@ func (o *TrustedAssetsUpdateObserver) BeforeWrite() error {
 	if err := resealKeyToModeenv(dirs.GlobalRootDir, o.modeenv, opts, nil); err != nil {
 		return err
 	}
+	o.resealed = true
 	return nil
 }
 
+// Resealed returns whether the observed update touched trusted boot assets
+// and the keys of the encrypted disks were resealed before writing it.
+func (o *TrustedAssetsUpdateObserver) Resealed() bool {
+	return o.resealed
+}
+
 func (o *TrustedAssetsUpdateObserver) canceledUpdate(recovery bool) {
 	trustedAssets := &o.modeenv.CurrentTrustedBootAssets
 	otherTrustedAssets := o.modeenv.CurrentTrustedRecoveryBootAssets
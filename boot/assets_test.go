@@ -959,10 +959,12 @@ func (s *assetsSuite) TestUpdateObserverUpdateNothingTrackedMocked(c *C) {
 	})
 
 	// reseal does nothing
+	c.Check(obs.Resealed(), Equals, false)
 	err = obs.BeforeWrite()
 	c.Assert(err, IsNil)
 	c.Check(tab.RecoveryBootChainCalls, HasLen, 0)
 	c.Check(tab.BootChainKernelPath, HasLen, 0)
+	c.Check(obs.Resealed(), Equals, true)
 }
 
 func (s *assetsSuite) TestUpdateObserverUpdateOtherRoleStructMocked(c *C) {
@@ -985,6 +987,10 @@ func (s *assetsSuite) TestUpdateObserverUpdateOtherRoleStructMocked(c *C) {
 		&gadget.ContentChange{After: filepath.Join(d, "foobar")})
 	c.Assert(err, IsNil)
 	c.Check(res, Equals, gadget.ChangeApply)
+
+	// no trusted assets were touched, so nothing is resealed
+	c.Assert(obs.BeforeWrite(), IsNil)
+	c.Check(obs.Resealed(), Equals, false)
 }
 
 func (s *assetsSuite) TestUpdateObserverUpdateTrivialErr(c *C) {
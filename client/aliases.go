@@ -22,6 +22,7 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 )
 
 // aliasAction represents an action performed on aliases.
@@ -32,6 +33,14 @@ type aliasAction struct {
 	Snap   string `json:"snap,omitempty"`
 	App    string `json:"app,omitempty"`
 	Alias  string `json:"alias,omitempty"`
+
+	Targets []AppAlias `json:"targets,omitempty"`
+}
+
+// AppAlias is a manual alias for an app of a snap.
+type AppAlias struct {
+	Alias string `json:"alias"`
+	App   string `json:"app,omitempty"`
 }
 
 // performAliasAction performs a single action on aliases.
@@ -53,7 +62,38 @@ func (client *Client) Alias(snapName, app, alias string) (changeID string, err e
 	})
 }
 
-// // DisableAllAliases disables all aliases of a snap, removing all manual ones.
+// EnableAliases sets up the given manual aliases for apps of snapName in a
+// single change.
+func (client *Client) EnableAliases(snapName string, aliases []AppAlias) (changeID string, err error) {
+	if len(aliases) == 0 {
+		return "", fmt.Errorf("cannot enable aliases of snap %q: no aliases given", snapName)
+	}
+	return client.performAliasAction(&aliasAction{
+		Action:  "alias",
+		Snap:    snapName,
+		Targets: aliases,
+	})
+}
+
+// DisableAliases removes the given manual aliases of snapName in a single
+// change. If no aliases are given all the aliases of the snap are disabled,
+// removing the manual ones, as with DisableAllAliases.
+func (client *Client) DisableAliases(snapName string, aliases []string) (changeID string, err error) {
+	if len(aliases) == 0 {
+		return client.DisableAllAliases(snapName)
+	}
+	targets := make([]AppAlias, len(aliases))
+	for i, alias := range aliases {
+		targets[i] = AppAlias{Alias: alias}
+	}
+	return client.performAliasAction(&aliasAction{
+		Action:  "unalias",
+		Snap:    snapName,
+		Targets: targets,
+	})
+}
+
+// DisableAllAliases disables all aliases of a snap, removing all manual ones.
 func (client *Client) DisableAllAliases(snapName string) (changeID string, err error) {
 	return client.performAliasAction(&aliasAction{
 		Action: "unalias",
@@ -87,12 +127,31 @@ func (client *Client) Prefer(snapName string) (changeID string, err error) {
 	})
 }
 
+// AliasStatusKind is the status of an alias.
+type AliasStatusKind string
+
+const (
+	// AliasStatusAuto is the status of an enabled automatic alias.
+	AliasStatusAuto AliasStatusKind = "auto"
+	// AliasStatusManual is the status of a manual alias, possibly
+	// overriding an automatic one.
+	AliasStatusManual AliasStatusKind = "manual"
+	// AliasStatusDisabled is the status of a disabled automatic alias.
+	AliasStatusDisabled AliasStatusKind = "disabled"
+)
+
 // AliasStatus represents the status of an alias.
 type AliasStatus struct {
-	Command string `json:"command"`
-	Status  string `json:"status"`
-	Manual  string `json:"manual,omitempty"`
-	Auto    string `json:"auto,omitempty"`
+	Command string          `json:"command"`
+	Status  AliasStatusKind `json:"status"`
+	Manual  string          `json:"manual,omitempty"`
+	Auto    string          `json:"auto,omitempty"`
+}
+
+// Overrides returns whether the alias is a manual alias overriding an
+// automatic one.
+func (as AliasStatus) Overrides() bool {
+	return as.Status == AliasStatusManual && as.Auto != ""
 }
 
 // Aliases returns a map snap -> alias -> AliasStatus for all snaps and aliases in the system.
@@ -100,3 +159,17 @@ func (client *Client) Aliases() (allStatuses map[string]map[string]AliasStatus,
 	_, err = client.doSync("GET", "/v2/aliases", nil, nil, nil, &allStatuses)
 	return
 }
+
+// SnapAliases returns a map alias -> AliasStatus for the aliases of the
+// given snap.
+func (client *Client) SnapAliases(snapName string) (map[string]AliasStatus, error) {
+	allStatuses, err := client.Aliases()
+	if err != nil {
+		return nil, err
+	}
+	statuses := allStatuses[snapName]
+	if statuses == nil {
+		statuses = map[string]AliasStatus{}
+	}
+	return statuses, nil
+}
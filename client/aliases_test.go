@@ -198,3 +198,98 @@ func (cs *clientSuite) TestClientAliases(c *check.C) {
 		},
 	})
 }
+
+func (cs *clientSuite) TestClientSnapAliases(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+                    "foo": {
+                        "foo0": {"command": "foo", "status": "auto", "auto": "foo"},
+                        "foo_reset": {"command": "foo.reset", "manual": "reset", "auto": "reset", "status": "manual"}
+                    },
+                    "bar": {
+                        "bar_dump.1": {"command": "bar.dump", "status": "disabled", "auto": "dump"}
+                    }
+		}
+	}`
+	statuses, err := cs.cli.SnapAliases("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(statuses, check.DeepEquals, map[string]client.AliasStatus{
+		"foo0":      {Command: "foo", Status: client.AliasStatusAuto, Auto: "foo"},
+		"foo_reset": {Command: "foo.reset", Status: client.AliasStatusManual, Manual: "reset", Auto: "reset"},
+	})
+	c.Check(statuses["foo0"].Overrides(), check.Equals, false)
+	c.Check(statuses["foo_reset"].Overrides(), check.Equals, true)
+
+	statuses, err = cs.cli.SnapAliases("baz")
+	c.Assert(err, check.IsNil)
+	c.Check(statuses, check.HasLen, 0)
+}
+
+func (cs *clientSuite) TestClientEnableAliases(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "chgid"
+	}`
+	id, err := cs.cli.EnableAliases("alias-snap", []client.AppAlias{
+		{Alias: "alias1", App: "app"},
+		{Alias: "alias2", App: "app2"},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "chgid")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/aliases")
+	var body map[string]any
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]any{
+		"action": "alias",
+		"snap":   "alias-snap",
+		"targets": []any{
+			map[string]any{"alias": "alias1", "app": "app"},
+			map[string]any{"alias": "alias2", "app": "app2"},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientEnableAliasesNone(c *check.C) {
+	_, err := cs.cli.EnableAliases("alias-snap", nil)
+	c.Check(err, check.ErrorMatches, `cannot enable aliases of snap "alias-snap": no aliases given`)
+	c.Check(cs.req, check.IsNil)
+}
+
+func (cs *clientSuite) TestClientDisableAliases(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "chgid"
+	}`
+	id, err := cs.cli.DisableAliases("alias-snap", []string{"alias1", "alias2"})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "chgid")
+	var body map[string]any
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]any{
+		"action": "unalias",
+		"snap":   "alias-snap",
+		"targets": []any{
+			map[string]any{"alias": "alias1"},
+			map[string]any{"alias": "alias2"},
+		},
+	})
+
+	// without aliases all of them are disabled
+	id, err = cs.cli.DisableAliases("alias-snap", nil)
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "chgid")
+	body = nil
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]any{
+		"action": "unalias",
+		"snap":   "alias-snap",
+	})
+}
@@ -22,7 +22,6 @@ package client
 import (
 	"bufio"
 	"bytes"
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -146,7 +145,7 @@ func (client *Client) Logs(names []string, opts LogOptions) (<-chan Log, error)
 		query.Set("follow", strconv.FormatBool(opts.Follow))
 	}
 
-	rsp, err := client.raw(context.Background(), "GET", "/v2/logs", query, nil, nil)
+	rsp, err := client.raw(client.requestContext(), "GET", "/v2/logs", query, nil, nil)
 	if err != nil {
 		return nil, err
 	}
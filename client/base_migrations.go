@@ -0,0 +1,65 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+// BaseMigrationOption describes what would change when switching a snap
+// to a store channel offering a revision built on a supported base.
+type BaseMigrationOption struct {
+	Channel  string        `json:"channel"`
+	Revision snap.Revision `json:"revision"`
+	Version  string        `json:"version"`
+	Base     string        `json:"base"`
+	// InstallBase is set if the base is not installed yet and would
+	// be installed as part of switching.
+	InstallBase bool `json:"install-base,omitempty"`
+}
+
+// BaseMigration holds an installed snap built on a base that reached its
+// end of life and the options for moving it to a supported base.
+type BaseMigration struct {
+	Snap            string                `json:"snap"`
+	Base            string                `json:"base"`
+	BaseEOL         time.Time             `json:"base-eol"`
+	TrackingChannel string                `json:"tracking-channel,omitempty"`
+	Options         []BaseMigrationOption `json:"options,omitempty"`
+}
+
+// BaseMigrations returns the installed snaps, restricted to the given
+// ones if any, that are built on a base that reached its end of life.
+func (client *Client) BaseMigrations(snaps []string) ([]*BaseMigration, error) {
+	q := url.Values{}
+	if len(snaps) > 0 {
+		q.Set("snaps", strings.Join(snaps, ","))
+	}
+	var migrations []*BaseMigration
+	if _, err := client.doSync("GET", "/v2/base-migrations", q, nil, nil, &migrations); err != nil {
+		return nil, fmt.Errorf("cannot list base migrations: %v", err)
+	}
+	return migrations, nil
+}
@@ -0,0 +1,65 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (cs *clientSuite) TestClientBaseMigrations(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": [{
+			"snap": "foo",
+			"base": "core18",
+			"base-eol": "2023-05-31T00:00:00Z",
+			"tracking-channel": "latest/stable",
+			"options": [{"channel": "2.x/stable", "revision": "7", "version": "2.0", "base": "core24", "install-base": true}]
+		}]
+	}`
+	migrations, err := cs.cli.BaseMigrations([]string{"foo", "bar"})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/base-migrations")
+	c.Check(cs.req.URL.Query().Get("snaps"), check.Equals, "foo,bar")
+	c.Check(migrations, check.DeepEquals, []*client.BaseMigration{{
+		Snap:            "foo",
+		Base:            "core18",
+		BaseEOL:         time.Date(2023, 5, 31, 0, 0, 0, 0, time.UTC),
+		TrackingChannel: "latest/stable",
+		Options: []client.BaseMigrationOption{
+			{Channel: "2.x/stable", Revision: snap.R(7), Version: "2.0", Base: "core24", InstallBase: true},
+		},
+	}})
+}
+
+func (cs *clientSuite) TestClientBaseMigrationsError(c *check.C) {
+	cs.err = fmt.Errorf("boom")
+	_, err := cs.cli.BaseMigrations(nil)
+	c.Check(err, check.ErrorMatches, `cannot list base migrations: .*boom`)
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+}
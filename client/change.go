@@ -39,6 +39,15 @@ type Change struct {
 
 	SpawnTime time.Time `json:"spawn-time,omitzero"`
 	ReadyTime time.Time `json:"ready-time,omitzero"`
+	// LastActivityTime is the last time any of the tasks of a change in
+	// progress made progress, it is zero for ready changes.
+	LastActivityTime time.Time `json:"last-activity-time,omitzero"`
+	// Deadline is the time after which the change is aborted if it is
+	// not ready yet.
+	Deadline time.Time `json:"deadline,omitzero"`
+	// ScheduledTime is the time at which a change requested to start
+	// later is kicked off.
+	ScheduledTime time.Time `json:"scheduled-time,omitzero"`
 
 	data map[string]*json.RawMessage
 }
@@ -65,6 +74,18 @@ type Task struct {
 
 	SpawnTime time.Time `json:"spawn-time,omitzero"`
 	ReadyTime time.Time `json:"ready-time,omitzero"`
+
+	// Retries is the number of times the task was retried after a
+	// transient error, NextRetry is when it will be retried next.
+	Retries   int       `json:"retries,omitempty"`
+	NextRetry time.Time `json:"next-retry,omitzero"`
+
+	// WaitTasks are the IDs of the tasks this task waits for, HaltTasks
+	// those of the tasks waiting for it, and Lanes the lanes it belongs
+	// to. They are only set by ChangeGraph.
+	WaitTasks []string `json:"wait-tasks,omitempty"`
+	HaltTasks []string `json:"halt-tasks,omitempty"`
+	Lanes     []int    `json:"lanes,omitempty"`
 }
 
 type TaskProgress struct {
@@ -80,8 +101,38 @@ type changeAndData struct {
 
 // Change fetches information about a Change given its ID.
 func (client *Client) Change(id string) (*Change, error) {
+	return client.change(id, nil)
+}
+
+// ChangeGraph fetches information about a Change given its ID, including
+// the dependencies between its tasks and their lanes.
+func (client *Client) ChangeGraph(id string) (*Change, error) {
+	return client.change(id, url.Values{"include": []string{"graph"}})
+}
+
+// WaitChange waits up to timeout for the change with the given ID to be
+// ready and returns it. The change is returned as it is when it is still
+// not ready once the timeout elapses.
+func (client *Client) WaitChange(id string, timeout time.Duration) (*Change, error) {
+	q := url.Values{
+		"wait":    []string{"true"},
+		"timeout": []string{timeout.String()},
+	}
+	opts := &doOptions{
+		// leave time to the daemon to respond once the wait is over
+		Timeout: timeout + doTimeout,
+		Retry:   doRetry,
+	}
+	return client.changeWithOpts(id, q, opts)
+}
+
+func (client *Client) change(id string, query url.Values) (*Change, error) {
+	return client.changeWithOpts(id, query, nil)
+}
+
+func (client *Client) changeWithOpts(id string, query url.Values, opts *doOptions) (*Change, error) {
 	var chgd changeAndData
-	_, err := client.doSync("GET", "/v2/changes/"+id, nil, nil, nil, &chgd)
+	_, err := client.doSyncWithOpts("GET", "/v2/changes/"+id, query, nil, nil, &chgd, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +161,55 @@ func (client *Client) Abort(id string) (*Change, error) {
 	return &chg, nil
 }
 
+// AbortChangesOptions selects the changes to abort with AbortChanges. Either
+// IDs or at least one of the filters must be set, but not both.
+type AbortChangesOptions struct {
+	// IDs lists the changes to abort.
+	IDs []string
+	// Kind, if set, selects pending changes of the given kind.
+	Kind string
+	// SnapName, if set, selects pending changes affecting the given snap.
+	SnapName string
+}
+
+// AbortChanges attempts to abort several changes that are not yet ready in
+// one request. It returns the changes which were aborted.
+func (client *Client) AbortChanges(opts *AbortChangesOptions) ([]*Change, error) {
+	if opts == nil {
+		opts = &AbortChangesOptions{}
+	}
+	postData := struct {
+		Action  string   `json:"action"`
+		IDs     []string `json:"ids,omitempty"`
+		Kind    string   `json:"kind,omitempty"`
+		ForSnap string   `json:"for-snap,omitempty"`
+	}{
+		Action:  "abort",
+		IDs:     opts.IDs,
+		Kind:    opts.Kind,
+		ForSnap: opts.SnapName,
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(postData); err != nil {
+		return nil, err
+	}
+
+	var chgds []changeAndData
+	if _, err := client.doSync("POST", "/v2/changes", nil, nil, &body, &chgds); err != nil {
+		return nil, err
+	}
+
+	chgs := make([]*Change, 0, len(chgds))
+	for i := range chgds {
+		chgd := &chgds[i]
+		chgd.Change.data = chgd.Data
+		chgs = append(chgs, &chgd.Change)
+	}
+
+	return chgs, nil
+}
+
 type ChangeSelector uint8
 
 func (c ChangeSelector) String() string {
@@ -162,3 +262,24 @@ func (client *Client) Changes(opts *ChangesOptions) ([]*Change, error) {
 
 	return chgs, err
 }
+
+// ChangeKind describes a kind of change snapd can create.
+type ChangeKind struct {
+	Kind string `json:"kind"`
+	// Template is the change kind template the kind is a variant of,
+	// if any.
+	Template    string `json:"template,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Tasks is the typical sequence of task kinds of changes of the
+	// kind, if known.
+	Tasks []string `json:"tasks,omitempty"`
+}
+
+// ChangeKinds lists the kinds of changes snapd can create.
+func (client *Client) ChangeKinds() ([]ChangeKind, error) {
+	var kinds []ChangeKind
+	if _, err := client.doSync("GET", "/v2/debug/change-kinds", nil, nil, nil, &kinds); err != nil {
+		return nil, fmt.Errorf("cannot list change kinds: %v", err)
+	}
+	return kinds, nil
+}
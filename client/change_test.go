@@ -21,6 +21,7 @@ package client_test
 
 import (
 	"io"
+	"net/url"
 	"time"
 
 	"gopkg.in/check.v1"
@@ -61,6 +62,64 @@ func (cs *clientSuite) TestClientChange(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientWaitChange(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": {"id": "uno", "kind": "foo", "summary": "...", "status": "Done", "ready": true}}`
+
+	chg, err := cs.cli.WaitChange("uno", 30*time.Second)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/changes/uno")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"wait":    []string{"true"},
+		"timeout": []string{"30s"},
+	})
+	c.Check(chg.ID, check.Equals, "uno")
+	c.Check(chg.Ready, check.Equals, true)
+}
+
+func (cs *clientSuite) TestClientChangeGraph(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": {
+  "id":   "uno",
+  "kind": "foo",
+  "summary": "...",
+  "status": "Doing",
+  "tasks": [
+    {"id": "1", "kind": "bar", "summary": "...", "status": "Done", "progress": {"done": 1, "total": 1}, "halt-tasks": ["2"], "lanes": [1]},
+    {"id": "2", "kind": "baz", "summary": "...", "status": "Do", "progress": {"done": 0, "total": 1}, "wait-tasks": ["1"], "lanes": [1]}
+  ]
+}}`
+
+	chg, err := cs.cli.ChangeGraph("uno")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/changes/uno")
+	c.Check(cs.req.URL.Query().Get("include"), check.Equals, "graph")
+	c.Assert(chg.Tasks, check.HasLen, 2)
+	c.Check(chg.Tasks[0].HaltTasks, check.DeepEquals, []string{"2"})
+	c.Check(chg.Tasks[0].WaitTasks, check.HasLen, 0)
+	c.Check(chg.Tasks[1].WaitTasks, check.DeepEquals, []string{"1"})
+	c.Check(chg.Tasks[1].Lanes, check.DeepEquals, []int{1})
+}
+
+func (cs *clientSuite) TestClientChangeLiveness(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": {
+  "id":   "uno",
+  "kind": "foo",
+  "summary": "...",
+  "status": "Doing",
+  "ready": false,
+  "spawn-time": "2016-04-21T01:02:03Z",
+  "last-activity-time": "2016-04-21T01:03:03Z",
+  "deadline": "2016-04-21T02:02:03Z"
+}}`
+
+	chg, err := cs.cli.Change("uno")
+	c.Assert(err, check.IsNil)
+	c.Check(chg.SpawnTime, check.Equals, time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
+	c.Check(chg.LastActivityTime, check.Equals, time.Date(2016, 04, 21, 1, 3, 3, 0, time.UTC))
+	c.Check(chg.Deadline, check.Equals, time.Date(2016, 04, 21, 2, 2, 3, 0, time.UTC))
+}
+
 func (cs *clientSuite) TestClientChangeData(c *check.C) {
 	cs.rsp = `{"type": "sync", "result": {
   "id":   "uno",
@@ -232,3 +291,67 @@ func (cs *clientSuite) TestClientAbort(c *check.C) {
 
 	c.Assert(string(body), check.Equals, "{\"action\":\"abort\"}\n")
 }
+
+func (cs *clientSuite) TestClientAbortChanges(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": [{
+  "id":   "uno",
+  "kind": "refresh-snap",
+  "summary": "...",
+  "status": "Hold",
+  "ready": true,
+  "spawn-time": "2016-04-21T01:02:03Z",
+  "ready-time": "2016-04-21T01:02:04Z"
+}]}`
+
+	for _, tc := range []struct {
+		opts *client.AbortChangesOptions
+		body string
+	}{
+		{&client.AbortChangesOptions{IDs: []string{"uno", "dos"}}, `{"action":"abort","ids":["uno","dos"]}`},
+		{&client.AbortChangesOptions{Kind: "refresh-snap"}, `{"action":"abort","kind":"refresh-snap"}`},
+		{&client.AbortChangesOptions{Kind: "refresh-snap", SnapName: "foo"}, `{"action":"abort","kind":"refresh-snap","for-snap":"foo"}`},
+	} {
+		chgs, err := cs.cli.AbortChanges(tc.opts)
+		c.Assert(err, check.IsNil)
+		c.Check(cs.req.Method, check.Equals, "POST")
+		c.Check(cs.req.URL.Path, check.Equals, "/v2/changes")
+		c.Check(chgs, check.DeepEquals, []*client.Change{{
+			ID:      "uno",
+			Kind:    "refresh-snap",
+			Summary: "...",
+			Status:  "Hold",
+			Ready:   true,
+
+			SpawnTime: time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC),
+			ReadyTime: time.Date(2016, 04, 21, 1, 2, 4, 0, time.UTC),
+		}})
+
+		body, err := io.ReadAll(cs.req.Body)
+		c.Assert(err, check.IsNil)
+		c.Check(string(body), check.Equals, tc.body+"\n")
+	}
+}
+
+func (cs *clientSuite) TestClientChangeKinds(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": [
+  {"kind": "hotplug-add-slot-foo", "template": "hotplug-add-slot-%s"},
+  {"kind": "install-snap", "description": "Install snaps", "tasks": ["prerequisites", "download-snap"]}
+]}`
+
+	kinds, err := cs.cli.ChangeKinds()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/debug/change-kinds")
+	c.Check(kinds, check.DeepEquals, []client.ChangeKind{
+		{Kind: "hotplug-add-slot-foo", Template: "hotplug-add-slot-%s"},
+		{Kind: "install-snap", Description: "Install snaps", Tasks: []string{"prerequisites", "download-snap"}},
+	})
+}
+
+func (cs *clientSuite) TestClientChangeKindsError(c *check.C) {
+	cs.status = 500
+	cs.rsp = `{"type": "error", "result": {"message": "boom"}}`
+
+	_, err := cs.cli.ChangeKinds()
+	c.Check(err, check.ErrorMatches, "cannot list change kinds: boom")
+}
@@ -32,6 +32,7 @@ import (
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/dirs"
@@ -49,6 +50,13 @@ func unixDialer(socketPath string) func(string, string) (net.Conn, error) {
 	}
 }
 
+const (
+	// defaultMaxIdleConns is the number of idle connections to the
+	// daemon kept for reuse.
+	defaultMaxIdleConns = 16
+	idleConnTimeout     = 90 * time.Second
+)
+
 type doer interface {
 	Do(*http.Request) (*http.Response, error)
 }
@@ -75,8 +83,37 @@ type Config struct {
 	// alive for later reuse
 	DisableKeepAlive bool
 
+	// MaxConnections limits the number of connections opened to the
+	// daemon at the same time, 0 means no limit. Concurrent requests
+	// share a pool of kept alive connections.
+	MaxConnections int
+
 	// User-Agent to sent to the snapd daemon
 	UserAgent string
+
+	// RetryPolicy controls how idempotent requests are retried. If
+	// unset, requests that could not reach the daemon are retried at
+	// a constant interval until they time out.
+	RetryPolicy *RetryPolicy
+
+	// SocketWaitWindow, if set, is how long requests wait for the snapd
+	// socket to come back when it is missing or refuses connections, as
+	// happens while snapd restarts during a refresh of itself. The
+	// requests are then sent again, which is safe for any request as
+	// they never reached the daemon in the first place. Requests still
+	// time out as usual.
+	SocketWaitWindow time.Duration
+
+	// RecordTo, if set, is the path of a file where the requests sent
+	// to the daemon are recorded together with their responses, to be
+	// replayed later with ReplayFrom.
+	RecordTo string
+
+	// ReplayFrom, if set, is the path of a file written with RecordTo.
+	// The client then answers requests with the recorded responses,
+	// matched on method, path and body, instead of talking to the
+	// daemon. RecordTo is ignored when this is set.
+	ReplayFrom string
 }
 
 // A Client knows how to talk to the snappy daemon.
@@ -92,8 +129,16 @@ type Client struct {
 	warningCount     int
 	warningTimestamp time.Time
 
+	apiFeatures []string
+
 	userAgent string
 
+	retryPolicy *RetryPolicy
+
+	socketWaitWindow time.Duration
+
+	ctx context.Context
+
 	// SetMayLogBody controls whether a request or response's body may be logged
 	// if the appropriate environment variable is set
 	SetMayLogBody func(bool)
@@ -123,26 +168,70 @@ func New(config *Config) *Client {
 		}
 	}
 
+	maxIdleConns := defaultMaxIdleConns
+	if config.MaxConnections > maxIdleConns {
+		maxIdleConns = config.MaxConnections
+	}
 	transport := &httputil.LoggedTransport{
 		Transport: &http.Transport{
 			Dial:              dial,
 			DisableKeepAlives: config.DisableKeepAlive,
+			// keep enough idle connections around so that tools issuing
+			// many requests in parallel do not reconnect for each of
+			// them, the default keeps only 2 per host
+			MaxIdleConns:        maxIdleConns,
+			MaxIdleConnsPerHost: maxIdleConns,
+			MaxConnsPerHost:     config.MaxConnections,
+			IdleConnTimeout:     idleConnTimeout,
 		},
 		Key:        "SNAP_CLIENT_DEBUG_HTTP",
 		MayLogBody: true,
 	}
+	var d doer = &http.Client{Transport: transport}
+	switch {
+	case config.ReplayFrom != "":
+		d = newReplayingDoer(config.ReplayFrom)
+	case config.RecordTo != "":
+		d = newRecordingDoer(d, config.RecordTo)
+	}
 	return &Client{
 		baseURL:     *baseURL,
-		doer:        &http.Client{Transport: transport},
+		doer:        d,
 		disableAuth: config.DisableAuth,
 		interactive: config.Interactive,
 		userAgent:   config.UserAgent,
+		retryPolicy: config.RetryPolicy,
+
+		socketWaitWindow: config.SocketWaitWindow,
 		SetMayLogBody: func(logBody bool) {
 			transport.MayLogBody = logBody
 		},
 	}
 }
 
+// WithContext returns a shallow copy of the client whose requests are bound
+// to the given context, so that callers can cancel them or set deadlines.
+// The copy shares the connection and configuration of the original client,
+// but status information such as Maintenance() and WarningsSummary() is
+// only updated on the copy by the requests made through it.
+func (client *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *client
+	c.ctx = ctx
+	return &c
+}
+
+// requestContext returns the context requests made by the client are
+// bound to.
+func (client *Client) requestContext() context.Context {
+	if client.ctx != nil {
+		return client.ctx
+	}
+	return context.Background()
+}
+
 // Maintenance returns an error reflecting the daemon maintenance status or nil.
 func (client *Client) Maintenance() error {
 	return client.maintenance
@@ -155,6 +244,24 @@ func (client *Client) WarningsSummary() (count int, timestamp time.Time) {
 	return client.warningCount, client.warningTimestamp
 }
 
+// APIFeatures returns the optional API features advertised by the daemon in
+// its most recent response, or nil if no request has been made yet or the
+// daemon does not advertise any.
+func (client *Client) APIFeatures() []string {
+	return client.apiFeatures
+}
+
+// HasAPIFeature returns whether the daemon advertised support for the given
+// optional API feature in its most recent response.
+func (client *Client) HasAPIFeature(feature string) bool {
+	for _, f := range client.apiFeatures {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
 func (client *Client) WhoAmI() (string, error) {
 	user, err := readAuthData()
 	if os.IsNotExist(err) {
@@ -282,7 +389,7 @@ func (client *Client) raw(ctx context.Context, method, urlpath string, query url
 		req = req.WithContext(ctx)
 	}
 
-	rsp, err := client.doer.Do(req)
+	rsp, err := client.doWaitingForSocket(req)
 	if err != nil {
 		return nil, ConnectionError{err}
 	}
@@ -381,7 +488,7 @@ func (client *Client) do(method, path string, query url.Values, headers map[stri
 	client.checkMaintenanceJSON()
 
 	var rsp *http.Response
-	ctx := context.Background()
+	ctx := client.requestContext()
 	if opts.Timeout <= 0 {
 		// no timeout and retries
 		rsp, err = client.raw(ctx, method, path, query, headers, body)
@@ -389,29 +496,43 @@ func (client *Client) do(method, path string, query url.Values, headers map[stri
 		if opts.Retry <= 0 {
 			return 0, InternalClientError{fmt.Errorf("retry setting %s invalid", opts.Retry)}
 		}
-		retry := time.NewTicker(opts.Retry)
-		defer retry.Stop()
+		policy := client.retryPolicy
+		if policy == nil {
+			policy = &defaultRetryPolicy
+		}
 		timeout := time.NewTimer(opts.Timeout)
 		defer timeout.Stop()
 
-		for {
+		for attempt := 1; ; attempt++ {
 			var cancel context.CancelFunc
 			// use the same timeout as for the whole of the retry
 			// loop to error out the whole do() call when a single
 			// request exceeds the deadline
 			rsp, cancel, err = client.rawWithTimeout(ctx, method, path, query, headers, body, opts)
+			retry := ctx.Err() == nil && client.shouldRetry(policy, method, attempt, rsp, err)
+			if retry {
+				wait := time.NewTimer(policy.delay(attempt, opts.Retry))
+				select {
+				case <-wait.C:
+				case <-timeout.C:
+					retry = false
+				case <-ctx.Done():
+					retry = false
+				}
+				wait.Stop()
+			}
 			if err == nil {
-				defer cancel()
+				if !retry {
+					defer cancel()
+					break
+				}
+				// discard the response that is being retried
+				rsp.Body.Close()
+				cancel()
 			}
-			if err == nil || shouldNotRetryError(err) || method != "GET" {
+			if !retry {
 				break
 			}
-			select {
-			case <-retry.C:
-				continue
-			case <-timeout.C:
-			}
-			break
 		}
 	}
 	if err != nil {
@@ -419,6 +540,8 @@ func (client *Client) do(method, path string, query url.Values, headers map[stri
 	}
 	defer rsp.Body.Close()
 
+	client.apiFeatures = parseAPIFeatures(rsp.Header.Get("X-Snapd-API-Features"))
+
 	if v != nil {
 		if err := decodeInto(rsp.Body, v); err != nil {
 			return rsp.StatusCode, err
@@ -428,9 +551,21 @@ func (client *Client) do(method, path string, query url.Values, headers map[stri
 	return rsp.StatusCode, nil
 }
 
+func parseAPIFeatures(header string) []string {
+	if header == "" {
+		return nil
+	}
+	features := strings.Split(header, ",")
+	for i := range features {
+		features[i] = strings.TrimSpace(features[i])
+	}
+	return features
+}
+
 func shouldNotRetryError(err error) bool {
 	return errors.Is(err, AuthorizationError{}) ||
-		errors.Is(err, InternalClientError{})
+		errors.Is(err, InternalClientError{}) ||
+		errors.As(err, &replayError{})
 }
 
 func decodeInto(reader io.Reader, v any) error {
@@ -554,6 +689,57 @@ func (client *Client) doAsyncFull(method, path string, query url.Values, headers
 	return rsp.Result, rsp.Change, nil
 }
 
+// Response is a response of the API, as returned by Do.
+type Response struct {
+	StatusCode int
+	// Type is the type of the response, "sync" or "async".
+	Type string
+	// Result is the result of the response, still encoded.
+	Result json.RawMessage
+	// Change is the id of the change of an async response.
+	Change string
+
+	ResultInfo
+}
+
+// Decode decodes the result of the response into v, producing
+// json.Numbers instead of float64 for numbers.
+func (rsp *Response) Decode(v any) error {
+	if err := jsonutil.DecodeWithNumber(bytes.NewReader(rsp.Result), v); err != nil {
+		return fmt.Errorf("cannot unmarshal: %v", err)
+	}
+	return nil
+}
+
+// Do performs a request to an endpoint of the API that returns JSON, for
+// endpoints the client does not provide a method for. As with the other
+// methods the request carries the authorization of the user and
+// maintenance is detected; error responses are returned as *Error.
+func (client *Client) Do(method, path string, query url.Values, headers map[string]string, body io.Reader) (*Response, error) {
+	client.checkMaintenanceJSON()
+
+	var rsp response
+	statusCode, err := client.do(method, path, query, headers, body, &rsp, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := rsp.err(client, statusCode); err != nil {
+		return nil, err
+	}
+	if rsp.Type == "sync" {
+		client.warningCount = rsp.WarningCount
+		client.warningTimestamp = rsp.WarningTimestamp
+	}
+
+	return &Response{
+		StatusCode: statusCode,
+		Type:       rsp.Type,
+		Result:     rsp.Result,
+		Change:     rsp.Change,
+		ResultInfo: rsp.ResultInfo,
+	}, nil
+}
+
 type ServerVersion struct {
 	Version     string
 	Series      string
@@ -672,6 +858,124 @@ type RefreshInfo struct {
 	Next     string `json:"next,omitempty"`
 }
 
+// CPUCapabilities describes the processors of the system.
+type CPUCapabilities struct {
+	Architecture       string   `json:"architecture"`
+	KernelArchitecture string   `json:"kernel-architecture,omitempty"`
+	Model              string   `json:"model,omitempty"`
+	Count              int      `json:"count,omitempty"`
+	Flags              []string `json:"flags,omitempty"`
+}
+
+// KernelCapabilities describes the features offered by the running kernel
+// that are relevant to snaps and their confinement.
+type KernelCapabilities struct {
+	Version string `json:"version"`
+	// CgroupVersion is 1 or 2, or 0 if it could not be determined.
+	CgroupVersion int `json:"cgroup-version,omitempty"`
+	// AppArmorLevel is one of "none", "unusable", "partial" or "full".
+	AppArmorLevel    string   `json:"apparmor-level"`
+	AppArmorFeatures []string `json:"apparmor-features,omitempty"`
+	// SquashfsCompressions lists the compression algorithms the kernel
+	// can read squashfs images with, if known.
+	SquashfsCompressions []string `json:"squashfs-compressions,omitempty"`
+}
+
+// SystemCapabilities holds a report of the hardware and kernel capabilities
+// of the system, useful to predict which snaps and confinement modes will
+// work on it.
+type SystemCapabilities struct {
+	CPU    CPUCapabilities    `json:"cpu"`
+	Kernel KernelCapabilities `json:"kernel"`
+	TPM    bool               `json:"tpm"`
+}
+
+// AppArmorDetails describes the AppArmor support detected on the system.
+type AppArmorDetails struct {
+	// Level is one of "none", "unusable", "partial" or "full".
+	Level          string   `json:"level"`
+	Summary        string   `json:"summary,omitempty"`
+	KernelFeatures []string `json:"kernel-features,omitempty"`
+	ParserFeatures []string `json:"parser-features,omitempty"`
+}
+
+// SeccompDetails describes the seccomp support detected on the system.
+type SeccompDetails struct {
+	Actions []string `json:"actions,omitempty"`
+}
+
+// UserNamespacesDetails describes the availability of user namespaces to
+// unprivileged processes.
+type UserNamespacesDetails struct {
+	Available bool `json:"available"`
+	// RestrictedByAppArmor is set when the creation of user namespaces by
+	// unprivileged processes is mediated by AppArmor.
+	RestrictedByAppArmor bool `json:"restricted-by-apparmor,omitempty"`
+}
+
+// DegradedFeature is a snapd feature which is not fully functional due to
+// the sandbox support of the system.
+type DegradedFeature struct {
+	Feature string `json:"feature"`
+	Reason  string `json:"reason"`
+}
+
+// SandboxDetails holds the full sandbox feature matrix detected on the
+// system along with the snapd features degraded as a result.
+type SandboxDetails struct {
+	Confinement    string                `json:"confinement"`
+	AppArmor       AppArmorDetails       `json:"apparmor"`
+	Seccomp        SeccompDetails        `json:"seccomp"`
+	CgroupVersion  int                   `json:"cgroup-version,omitempty"`
+	UserNamespaces UserNamespacesDetails `json:"user-namespaces"`
+	Degraded       []DegradedFeature     `json:"degraded,omitempty"`
+}
+
+// HardwareCPU summarizes the processors of the system.
+type HardwareCPU struct {
+	Model string `json:"model,omitempty"`
+	// Cores is the number of logical processors.
+	Cores int `json:"cores,omitempty"`
+}
+
+// HardwareStorageDevice describes a block device backed by hardware.
+type HardwareStorageDevice struct {
+	Name       string `json:"name"`
+	Model      string `json:"model,omitempty"`
+	Size       uint64 `json:"size,omitempty"`
+	Removable  bool   `json:"removable,omitempty"`
+	Rotational bool   `json:"rotational,omitempty"`
+}
+
+// HardwareTPM describes the TPM of the system.
+type HardwareTPM struct {
+	Present bool   `json:"present"`
+	Version string `json:"version,omitempty"`
+}
+
+// HardwareInfo is a summary of the hardware of the system.
+type HardwareInfo struct {
+	CPU HardwareCPU `json:"cpu"`
+	// Memory is the total usable memory in bytes.
+	Memory  uint64                  `json:"memory,omitempty"`
+	Storage []HardwareStorageDevice `json:"storage,omitempty"`
+	TPM     HardwareTPM             `json:"tpm"`
+	// SecureBoot is one of "enabled", "disabled" or "unsupported", or
+	// empty if unknown.
+	SecureBoot string `json:"secure-boot,omitempty"`
+}
+
+// WatchdogInfo describes the hardware watchdog settings managed by snapd.
+type WatchdogInfo struct {
+	Enabled         bool   `json:"enabled"`
+	RuntimeTimeout  string `json:"runtime-timeout,omitempty"`
+	ShutdownTimeout string `json:"shutdown-timeout,omitempty"`
+	// ConflictingDropIns lists the other systemd drop-ins which also set
+	// watchdog settings. They are overridden when the watchdog is
+	// explicitly enabled or disabled through snapd.
+	ConflictingDropIns []string `json:"conflicting-drop-ins,omitempty"`
+}
+
 // SysInfo holds system information
 type SysInfo struct {
 	Series    string    `json:"series,omitempty"`
@@ -690,6 +994,16 @@ type SysInfo struct {
 	SandboxFeatures map[string][]string `json:"sandbox-features,omitempty"`
 
 	Features map[string]features.FeatureInfo `json:"features,omitempty"`
+
+	Capabilities *SystemCapabilities `json:"capabilities,omitempty"`
+
+	// ModelGradeOverride is set when the device simulates a model grade
+	// other than the one of its model for testing.
+	ModelGradeOverride string `json:"model-grade-override,omitempty"`
+
+	// Watchdog is set when the hardware watchdog is configured through
+	// snapd.
+	Watchdog *WatchdogInfo `json:"watchdog,omitempty"`
 }
 
 func (rsp *response) err(cli *Client, statusCode int) error {
@@ -748,6 +1062,30 @@ func (client *Client) SysInfo() (*SysInfo, error) {
 	return &sysInfo, nil
 }
 
+// SandboxDetails gets the detailed sandbox feature matrix of the system.
+func (client *Client) SandboxDetails() (*SandboxDetails, error) {
+	var details SandboxDetails
+
+	q := url.Values{"select": []string{"sandbox-details"}}
+	if _, err := client.doSync("GET", "/v2/system-info", q, nil, nil, &details); err != nil {
+		return nil, fmt.Errorf("cannot obtain sandbox details: %v", err)
+	}
+
+	return &details, nil
+}
+
+// HardwareInfo gets a summary of the hardware of the system.
+func (client *Client) HardwareInfo() (*HardwareInfo, error) {
+	var info HardwareInfo
+
+	q := url.Values{"select": []string{"hardware"}}
+	if _, err := client.doSync("GET", "/v2/system-info", q, nil, nil, &info); err != nil {
+		return nil, fmt.Errorf("cannot obtain hardware information: %v", err)
+	}
+
+	return &info, nil
+}
+
 type debugAction struct {
 	Action string `json:"action"`
 	Params any    `json:"params,omitempty"`
@@ -20,6 +20,7 @@
 package client_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,6 +32,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -58,6 +60,7 @@ type clientSuite struct {
 	doCalls       int
 	header        http.Header
 	status        int
+	statuses      []int
 	contentLength int64
 
 	countingCloser *countingCloser
@@ -79,6 +82,7 @@ func (cs *clientSuite) SetUpTest(c *C) {
 	cs.req = nil
 	cs.header = nil
 	cs.status = 200
+	cs.statuses = nil
 	cs.doCalls = 0
 	cs.contentLength = 0
 	cs.countingCloser = nil
@@ -106,11 +110,15 @@ func (cs *clientSuite) Do(req *http.Request) (*http.Response, error) {
 	if cs.doCalls < len(cs.rsps) {
 		body = cs.rsps[cs.doCalls]
 	}
+	status := cs.status
+	if cs.doCalls < len(cs.statuses) {
+		status = cs.statuses[cs.doCalls]
+	}
 	cs.countingCloser = &countingCloser{Reader: strings.NewReader(body)}
 	rsp := &http.Response{
 		Body:          cs.countingCloser,
 		Header:        cs.header,
-		StatusCode:    cs.status,
+		StatusCode:    status,
 		ContentLength: cs.contentLength,
 	}
 	cs.doCalls++
@@ -125,7 +133,7 @@ func (cs *clientSuite) TestNewPanics(c *C) {
 
 func (cs *clientSuite) TestClientDoReportsErrors(c *C) {
 	cs.err = errors.New("ouchie")
-	_, err := cs.cli.Do("GET", "/", nil, nil, nil, nil)
+	_, err := cs.cli.DoWithOpts("GET", "/", nil, nil, nil, nil)
 	c.Check(err, ErrorMatches, "cannot communicate with server: ouchie")
 	if cs.doCalls < 2 {
 		c.Fatalf("do did not retry")
@@ -136,7 +144,7 @@ func (cs *clientSuite) TestClientWorks(c *C) {
 	var v []int
 	cs.rsp = `[1,2]`
 	reqBody := io.NopCloser(strings.NewReader(""))
-	statusCode, err := cs.cli.Do("GET", "/this", nil, reqBody, &v, nil)
+	statusCode, err := cs.cli.DoWithOpts("GET", "/this", nil, reqBody, &v, nil)
 	c.Check(err, IsNil)
 	c.Check(statusCode, Equals, 200)
 	c.Check(v, DeepEquals, []int{1, 2})
@@ -147,6 +155,72 @@ func (cs *clientSuite) TestClientWorks(c *C) {
 	c.Check(cs.req.URL.Path, Equals, "/this")
 }
 
+func (cs *clientSuite) TestClientAPIFeatures(c *C) {
+	// nothing known before the first request
+	c.Check(cs.cli.APIFeatures(), IsNil)
+	c.Check(cs.cli.HasAPIFeature("foo"), Equals, false)
+
+	cs.rsp = `{"type": "sync", "result": {}}`
+	cs.header = http.Header{}
+	cs.header.Set("X-Snapd-API-Features", "bar, foo")
+	_, err := cs.cli.SysInfo()
+	c.Assert(err, IsNil)
+	c.Check(cs.cli.APIFeatures(), DeepEquals, []string{"bar", "foo"})
+	c.Check(cs.cli.HasAPIFeature("foo"), Equals, true)
+	c.Check(cs.cli.HasAPIFeature("baz"), Equals, false)
+
+	// features are refreshed with every response
+	cs.header = nil
+	_, err = cs.cli.SysInfo()
+	c.Assert(err, IsNil)
+	c.Check(cs.cli.APIFeatures(), IsNil)
+	c.Check(cs.cli.HasAPIFeature("foo"), Equals, false)
+}
+
+type ctxKey struct{}
+
+func (cs *clientSuite) TestClientWithContext(c *C) {
+	cs.rsp = `{"type": "sync", "result": {"systems": []}}`
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	cli := cs.cli.WithContext(ctx)
+	c.Assert(cli, Not(Equals), cs.cli)
+	_, err := cli.ListSystems()
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Context().Value(ctxKey{}), Equals, "value")
+
+	// the original client is not affected
+	_, err = cs.cli.ListSystems()
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Context().Value(ctxKey{}), IsNil)
+}
+
+func (cs *clientSuite) TestClientWithContextCanceledNoRetry(c *C) {
+	cs.err = errors.New("connection refused")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cs.cli.WithContext(ctx).ListSystems()
+	c.Check(err, ErrorMatches, `cannot list recovery systems: cannot communicate with server: request canceled`)
+	// a canceled request is not retried
+	c.Check(cs.doCalls, Equals, 1)
+}
+
+func (cs *clientSuite) TestClientWithContextAsync(c *C) {
+	cs.status = 202
+	cs.rsp = `{"type": "async", "status-code": 202, "change": "42"}`
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	chgID, err := cs.cli.WithContext(ctx).InstallSystem("1234", nil)
+	c.Assert(err, IsNil)
+	c.Check(chgID, Equals, "42")
+	c.Check(cs.req.Context().Value(ctxKey{}), Equals, "value")
+}
+
+func (cs *clientSuite) TestClientWithContextNilPanics(c *C) {
+	c.Check(func() { cs.cli.WithContext(nil) }, PanicMatches, "nil context")
+}
+
 func makeMaintenanceFile(c *C, b []byte) {
 	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapdMaintenanceFile), 0755), IsNil)
 	c.Assert(os.WriteFile(dirs.SnapdMaintenanceFile, b, 0644), IsNil)
@@ -164,7 +238,7 @@ func (cs *clientSuite) TestClientSetMaintenanceForMaintenanceJSON(c *C) {
 
 	// now after a Do(), we will have maintenance set to what we wrote
 	// originally
-	_, err = cs.cli.Do("GET", "/this", nil, nil, nil, nil)
+	_, err = cs.cli.DoWithOpts("GET", "/this", nil, nil, nil, nil)
 	c.Check(err, IsNil)
 
 	returnedErr := cs.cli.Maintenance()
@@ -176,7 +250,7 @@ func (cs *clientSuite) TestClientIgnoresGarbageMaintenanceJSON(c *C) {
 	makeMaintenanceFile(c, []byte("blah blah blah not json"))
 
 	// after a Do(), no maintenance set and also no error returned from Do()
-	_, err := cs.cli.Do("GET", "/this", nil, nil, nil, nil)
+	_, err := cs.cli.DoWithOpts("GET", "/this", nil, nil, nil, nil)
 	c.Check(err, IsNil)
 
 	returnedErr := cs.cli.Maintenance()
@@ -193,7 +267,7 @@ func (cs *clientSuite) TestClientDoNoTimeoutIgnoresRetry(c *C) {
 		// once even though there is an error
 		Retry: time.Duration(time.Second),
 	}
-	_, err := cs.cli.Do("GET", "/this", nil, reqBody, &v, doOpts)
+	_, err := cs.cli.DoWithOpts("GET", "/this", nil, reqBody, &v, doOpts)
 	c.Check(err, ErrorMatches, "cannot communicate with server: borken")
 	c.Assert(cs.doCalls, Equals, 1)
 }
@@ -206,7 +280,7 @@ func (cs *clientSuite) TestClientDoRetryValidation(c *C) {
 		Retry:   time.Duration(-1),
 		Timeout: time.Duration(time.Minute),
 	}
-	_, err := cs.cli.Do("GET", "/this", nil, reqBody, &v, doOpts)
+	_, err := cs.cli.DoWithOpts("GET", "/this", nil, reqBody, &v, doOpts)
 	c.Check(err, ErrorMatches, "internal error: retry setting.*invalid")
 	c.Assert(cs.req, IsNil)
 }
@@ -218,7 +292,7 @@ func (cs *clientSuite) TestClientDoRetryWorks(c *C) {
 		Retry:   time.Duration(time.Millisecond),
 		Timeout: time.Duration(time.Second),
 	}
-	_, err := cs.cli.Do("GET", "/this", nil, reqBody, nil, doOpts)
+	_, err := cs.cli.DoWithOpts("GET", "/this", nil, reqBody, nil, doOpts)
 	c.Check(err, ErrorMatches, "cannot communicate with server: borken")
 	// best effort checking given that execution could be slow
 	// on some machines
@@ -240,7 +314,7 @@ func (cs *clientSuite) TestClientOnlyRetryAppropriateErrors(c *C) {
 		cs.doCalls = 0
 		cs.err = t.error
 
-		_, err := cs.cli.Do("GET", "/this", nil, reqBody, nil, doOpts)
+		_, err := cs.cli.DoWithOpts("GET", "/this", nil, reqBody, nil, doOpts)
 		c.Check(err, ErrorMatches, fmt.Sprintf(".*%s", t.error.Error()))
 		c.Assert(cs.doCalls, Equals, 1)
 	}
@@ -251,7 +325,7 @@ func (cs *clientSuite) TestClientUnderstandsStatusCode(c *C) {
 	cs.status = 202
 	cs.rsp = `[1,2]`
 	reqBody := io.NopCloser(strings.NewReader(""))
-	statusCode, err := cs.cli.Do("GET", "/this", nil, reqBody, &v, nil)
+	statusCode, err := cs.cli.DoWithOpts("GET", "/this", nil, reqBody, &v, nil)
 	c.Check(err, IsNil)
 	c.Check(statusCode, Equals, 202)
 	c.Check(v, DeepEquals, []int{1, 2})
@@ -267,7 +341,7 @@ func (cs *clientSuite) TestClientDefaultsToNoAuthorization(c *C) {
 	defer os.Unsetenv(client.TestAuthFileEnvKey)
 
 	var v string
-	_, _ = cs.cli.Do("GET", "/this", nil, nil, &v, nil)
+	_, _ = cs.cli.DoWithOpts("GET", "/this", nil, nil, &v, nil)
 	c.Assert(cs.req, NotNil)
 	authorization := cs.req.Header.Get("Authorization")
 	c.Check(authorization, Equals, "")
@@ -285,7 +359,7 @@ func (cs *clientSuite) TestClientSetsAuthorization(c *C) {
 	c.Assert(err, IsNil)
 
 	var v string
-	_, _ = cs.cli.Do("GET", "/this", nil, nil, &v, nil)
+	_, _ = cs.cli.DoWithOpts("GET", "/this", nil, nil, &v, nil)
 	authorization := cs.req.Header.Get("Authorization")
 	c.Check(authorization, Equals, `Macaroon root="macaroon", discharge="discharge"`)
 }
@@ -304,7 +378,7 @@ func (cs *clientSuite) TestClientHonorsDisableAuth(c *C) {
 	var v string
 	cli := client.New(&client.Config{DisableAuth: true})
 	cli.SetDoer(cs)
-	_, _ = cli.Do("GET", "/this", nil, nil, &v, nil)
+	_, _ = cli.DoWithOpts("GET", "/this", nil, nil, &v, nil)
 	authorization := cs.req.Header.Get("Authorization")
 	c.Check(authorization, Equals, "")
 }
@@ -313,13 +387,13 @@ func (cs *clientSuite) TestClientHonorsInteractive(c *C) {
 	var v string
 	cli := client.New(&client.Config{Interactive: false})
 	cli.SetDoer(cs)
-	_, _ = cli.Do("GET", "/this", nil, nil, &v, nil)
+	_, _ = cli.DoWithOpts("GET", "/this", nil, nil, &v, nil)
 	interactive := cs.req.Header.Get(client.AllowInteractionHeader)
 	c.Check(interactive, Equals, "")
 
 	cli = client.New(&client.Config{Interactive: true})
 	cli.SetDoer(cs)
-	_, _ = cli.Do("GET", "/this", nil, nil, &v, nil)
+	_, _ = cli.DoWithOpts("GET", "/this", nil, nil, &v, nil)
 	interactive = cs.req.Header.Get(client.AllowInteractionHeader)
 	c.Check(interactive, Equals, "true")
 }
@@ -349,6 +423,69 @@ func (cs *clientSuite) TestClientWhoAmISomebody(c *C) {
 	c.Check(email, Equals, "foo@example.com")
 }
 
+func (cs *clientSuite) TestClientSandboxDetails(c *C) {
+	cs.rsp = `{"type": "sync", "result":
+                     {"confinement": "partial",
+                      "apparmor": {"level": "partial", "summary": "apparmor is enabled but some kernel features are missing: dbus", "kernel-features": ["caps"], "parser-features": ["unsafe"]},
+                      "seccomp": {"actions": ["allow", "errno"]},
+                      "cgroup-version": 2,
+                      "user-namespaces": {"available": true, "restricted-by-apparmor": true},
+                      "degraded": [{"feature": "strict-confinement", "reason": "apparmor support is partial, snaps are forced into devmode"}]}}`
+	details, err := cs.cli.SandboxDetails()
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/system-info")
+	c.Check(cs.req.URL.Query().Get("select"), Equals, "sandbox-details")
+	c.Check(details, DeepEquals, &client.SandboxDetails{
+		Confinement: "partial",
+		AppArmor: client.AppArmorDetails{
+			Level:          "partial",
+			Summary:        "apparmor is enabled but some kernel features are missing: dbus",
+			KernelFeatures: []string{"caps"},
+			ParserFeatures: []string{"unsafe"},
+		},
+		Seccomp:       client.SeccompDetails{Actions: []string{"allow", "errno"}},
+		CgroupVersion: 2,
+		UserNamespaces: client.UserNamespacesDetails{
+			Available:            true,
+			RestrictedByAppArmor: true,
+		},
+		Degraded: []client.DegradedFeature{
+			{Feature: "strict-confinement", Reason: "apparmor support is partial, snaps are forced into devmode"},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientHardwareInfo(c *C) {
+	cs.rsp = `{"type": "sync", "result":
+                     {"cpu": {"model": "Some CPU", "cores": 4},
+                      "memory": 8589934592,
+                      "storage": [{"name": "nvme0n1", "model": "Fast Disk", "size": 512110190592}],
+                      "tpm": {"present": true, "version": "2"},
+                      "secure-boot": "enabled"}}`
+	info, err := cs.cli.HardwareInfo()
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/system-info")
+	c.Check(cs.req.URL.Query().Get("select"), Equals, "hardware")
+	c.Check(info, DeepEquals, &client.HardwareInfo{
+		CPU:    client.HardwareCPU{Model: "Some CPU", Cores: 4},
+		Memory: 8589934592,
+		Storage: []client.HardwareStorageDevice{
+			{Name: "nvme0n1", Model: "Fast Disk", Size: 512110190592},
+		},
+		TPM:        client.HardwareTPM{Present: true, Version: "2"},
+		SecureBoot: "enabled",
+	})
+}
+
+func (cs *clientSuite) TestClientHardwareInfoError(c *C) {
+	cs.status = 400
+	cs.rsp = `{"type": "error", "result": {"message": "invalid select parameter: \"hardware\""}}`
+	_, err := cs.cli.HardwareInfo()
+	c.Assert(err, ErrorMatches, `cannot obtain hardware information: invalid select parameter: "hardware"`)
+}
+
 func (cs *clientSuite) TestClientSysInfo(c *C) {
 	cs.rsp = `{
   "type": "sync",
@@ -367,6 +504,11 @@ func (cs *clientSuite) TestClientSysInfo(c *C) {
       "bar": {"supported": false, "unsupported-reason": "not bar enough", "enabled": true},
       "baz": {"supported": true, "enabled": false},
       "buzz": {"supported": true, "enabled": true}
+    },
+    "capabilities": {
+      "cpu": {"architecture": "amd64", "kernel-architecture": "amd64", "model": "Fancy CPU", "count": 4, "flags": ["aes", "sse2"]},
+      "kernel": {"version": "6.8.0", "cgroup-version": 2, "apparmor-level": "full", "apparmor-features": ["dbus", "network"], "squashfs-compressions": ["gzip", "xz"]},
+      "tpm": true
     }
   }
 }`
@@ -393,6 +535,23 @@ func (cs *clientSuite) TestClientSysInfo(c *C) {
 			"baz":  {Supported: true, Enabled: false},
 			"buzz": {Supported: true, Enabled: true},
 		},
+		Capabilities: &client.SystemCapabilities{
+			CPU: client.CPUCapabilities{
+				Architecture:       "amd64",
+				KernelArchitecture: "amd64",
+				Model:              "Fancy CPU",
+				Count:              4,
+				Flags:              []string{"aes", "sse2"},
+			},
+			Kernel: client.KernelCapabilities{
+				Version:              "6.8.0",
+				CgroupVersion:        2,
+				AppArmorLevel:        "full",
+				AppArmorFeatures:     []string{"dbus", "network"},
+				SquashfsCompressions: []string{"gzip", "xz"},
+			},
+			TPM: true,
+		},
 	})
 }
 
@@ -443,6 +602,110 @@ func (cs *clientSuite) TestSnapdClientIntegration(c *C) {
 	c.Check(si.Series, Equals, "42")
 }
 
+func (cs *clientSuite) TestSnapdClientPoolsConnections(c *C) {
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapdSocket), 0755), IsNil)
+	l, err := net.Listen("unix", dirs.SnapdSocket)
+	if err != nil {
+		c.Fatalf("unable to listen on %q: %v", dirs.SnapdSocket, err)
+	}
+
+	const parallel = 8
+	var mu sync.Mutex
+	newConns := 0
+	var arrived sync.WaitGroup
+	f := func(w http.ResponseWriter, r *http.Request) {
+		// hold all requests until they are all in flight so that
+		// each of them needs its own connection
+		arrived.Done()
+		arrived.Wait()
+		fmt.Fprintln(w, `{"type":"sync", "result":{"series":"42"}}`)
+	}
+
+	srv := &httptest.Server{
+		Listener: l,
+		Config: &http.Server{
+			Handler: http.HandlerFunc(f),
+			ConnState: func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					mu.Lock()
+					newConns++
+					mu.Unlock()
+				}
+			},
+		},
+	}
+	srv.Start()
+	defer srv.Close()
+
+	cli := client.New(nil)
+	for round := 0; round < 2; round++ {
+		arrived.Add(parallel)
+		var done sync.WaitGroup
+		for i := 0; i < parallel; i++ {
+			done.Add(1)
+			go func() {
+				defer done.Done()
+				// use a copy per goroutine, sharing the connections
+				_, err := cli.WithContext(context.Background()).SysInfo()
+				c.Check(err, IsNil)
+			}()
+		}
+		done.Wait()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// the connections of the first round were reused
+	c.Check(newConns, Equals, parallel)
+}
+
+func (cs *clientSuite) TestSnapdClientMaxConnections(c *C) {
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapdSocket), 0755), IsNil)
+	l, err := net.Listen("unix", dirs.SnapdSocket)
+	if err != nil {
+		c.Fatalf("unable to listen on %q: %v", dirs.SnapdSocket, err)
+	}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	f := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		fmt.Fprintln(w, `{"type":"sync", "result":{"series":"42"}}`)
+	}
+
+	srv := &httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: http.HandlerFunc(f)},
+	}
+	srv.Start()
+	defer srv.Close()
+
+	cli := client.New(&client.Config{MaxConnections: 2})
+	var done sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			_, err := cli.WithContext(context.Background()).SysInfo()
+			c.Check(err, IsNil)
+		}()
+	}
+	done.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.Check(maxInFlight <= 2, Equals, true)
+}
+
 func (cs *clientSuite) TestSnapClientIntegration(c *C) {
 	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapSocket), 0755), IsNil)
 	l, err := net.Listen("unix", dirs.SnapSocket)
@@ -530,6 +793,61 @@ func (cs *clientSuite) TestClientMaintenance(c *C) {
 	c.Check(cs.cli.Maintenance(), Equals, error(nil))
 }
 
+func (cs *clientSuite) TestClientRawDoSync(c *C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": {"foo": 42}, "resource-version": "7", "warning-count": 2, "warning-timestamp": "2024-01-02T03:04:05Z"}`
+
+	rsp, err := cs.cli.Do("GET", "/v2/not-wrapped", url.Values{"q": {"v"}}, map[string]string{"X-Foo": "bar"}, nil)
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/not-wrapped")
+	c.Check(cs.req.URL.RawQuery, Equals, "q=v")
+	c.Check(cs.req.Header.Get("X-Foo"), Equals, "bar")
+
+	c.Check(rsp.StatusCode, Equals, 200)
+	c.Check(rsp.Type, Equals, "sync")
+	c.Check(rsp.Change, Equals, "")
+	c.Check(rsp.ResourceVersion, Equals, "7")
+	var v map[string]any
+	c.Assert(rsp.Decode(&v), IsNil)
+	c.Check(v, DeepEquals, map[string]any{"foo": json.Number("42")})
+
+	count, stamp := cs.cli.WarningsSummary()
+	c.Check(count, Equals, 2)
+	c.Check(stamp.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)), Equals, true)
+}
+
+func (cs *clientSuite) TestClientRawDoAsync(c *C) {
+	cs.status = 202
+	cs.rsp = `{"type": "async", "status-code": 202, "change": "42", "maintenance": {"kind": "system-restart", "message": "system is restarting"}}`
+
+	rsp, err := cs.cli.Do("POST", "/v2/not-wrapped", nil, nil, strings.NewReader(`{"action": "do"}`))
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "POST")
+	c.Check(rsp.StatusCode, Equals, 202)
+	c.Check(rsp.Type, Equals, "async")
+	c.Check(rsp.Change, Equals, "42")
+	c.Check(cs.cli.Maintenance().(*client.Error), DeepEquals, &client.Error{
+		Kind:    client.ErrorKindSystemRestart,
+		Message: "system is restarting",
+	})
+}
+
+func (cs *clientSuite) TestClientRawDoError(c *C) {
+	cs.status = 404
+	cs.rsp = `{"type": "error", "status-code": 404, "result": {"message": "snap not installed", "kind": "snap-not-installed", "value": "foo"}}`
+
+	rsp, err := cs.cli.Do("GET", "/v2/not-wrapped", nil, nil, nil)
+	c.Check(rsp, IsNil)
+	var cliErr *client.Error
+	c.Assert(errors.As(err, &cliErr), Equals, true)
+	c.Check(cliErr, DeepEquals, &client.Error{
+		Kind:       client.ErrorKindSnapNotInstalled,
+		Value:      "foo",
+		Message:    "snap not installed",
+		StatusCode: 404,
+	})
+}
+
 func (cs *clientSuite) TestClientAsyncOpMaintenance(c *C) {
 	cs.status = 202
 	cs.rsp = `{"type":"async", "status-code": 202, "change": "42", "maintenance": {"kind": "system-restart", "message": "system is restarting"}}`
@@ -596,12 +914,85 @@ func (cs *clientSuite) TestIsRetryable(c *C) {
 	c.Check(client.IsRetryable(&client.Error{Kind: client.ErrorKindSnapChangeConflict}), Equals, true)
 }
 
+func (cs *clientSuite) TestErrorClasses(c *C) {
+	for _, tc := range []struct {
+		err   *client.Error
+		class error
+	}{
+		{&client.Error{Kind: client.ErrorKindSnapNotFound}, client.ErrSnapNotFound},
+		{&client.Error{Kind: client.ErrorKindSnapNotInstalled}, client.ErrSnapNotFound},
+		{&client.Error{Kind: client.ErrorKindSnapChangeConflict}, client.ErrConflict},
+		{&client.Error{Kind: client.ErrorKindQuotaChangeConflict}, client.ErrConflict},
+		{&client.Error{Kind: client.ErrorKindResourceVersionMismatch}, client.ErrConflict},
+		{&client.Error{Kind: client.ErrorKindInsufficientDiskSpace}, client.ErrInsufficientSpace},
+		{&client.Error{Kind: client.ErrorKindLoginRequired}, client.ErrAuthRequired},
+		{&client.Error{Kind: client.ErrorKindTwoFactorRequired}, client.ErrAuthRequired},
+		{&client.Error{StatusCode: 401}, client.ErrAuthRequired},
+		{&client.Error{Kind: "something-else"}, nil},
+		{&client.Error{Kind: client.ErrorKindTwoFactorFailed, StatusCode: 401}, nil},
+	} {
+		for _, class := range []error{client.ErrSnapNotFound, client.ErrConflict, client.ErrInsufficientSpace, client.ErrAuthRequired} {
+			c.Check(errors.Is(tc.err, class), Equals, class == tc.class, Commentf("%q %v", tc.err.Kind, class))
+		}
+	}
+}
+
+func (cs *clientSuite) TestErrorClassesFromResponse(c *C) {
+	cs.status = 404
+	cs.rsp = `{
+		"type": "error",
+		"status-code": 404,
+		"result": {"message": "snap not installed", "kind": "snap-not-installed"}
+	}`
+
+	_, _, err := cs.cli.Snap("foo")
+	c.Assert(err, ErrorMatches, `cannot retrieve snap "foo": snap not installed`)
+	c.Check(errors.Is(err, client.ErrSnapNotFound), Equals, true)
+	c.Check(errors.Is(err, client.ErrConflict), Equals, false)
+
+	var e *client.Error
+	c.Assert(errors.As(err, &e), Equals, true)
+	c.Check(e.Kind, Equals, client.ErrorKindSnapNotInstalled)
+}
+
+func (cs *clientSuite) TestErrorKindsCatalog(c *C) {
+	kinds := client.ErrorKinds()
+	c.Assert(len(kinds) > 0, Equals, true)
+
+	seen := make(map[client.ErrorKind]client.ErrorKindInfo, len(kinds))
+	for _, k := range kinds {
+		c.Check(k.Description, Not(Equals), "", Commentf("%s", k.Kind))
+		_, dup := seen[k.Kind]
+		c.Check(dup, Equals, false, Commentf("%s", k.Kind))
+		seen[k.Kind] = k
+	}
+
+	for _, kind := range []client.ErrorKind{
+		client.ErrorKindSnapMissingBase,
+		client.ErrorKindValidationSetConstraintsUnmet,
+		client.ErrorKindAliasConflict,
+		client.ErrorKindSnapChangeConflict,
+	} {
+		k, ok := seen[kind]
+		c.Check(ok, Equals, true, Commentf("%s", kind))
+		c.Check(k.Maintenance, Equals, false)
+	}
+	c.Check(seen[client.ErrorKindDaemonRestart].Maintenance, Equals, true)
+	// deprecated kinds are not documented
+	_, ok := seen[client.ErrorKindTermsNotAccepted]
+	c.Check(ok, Equals, false)
+
+	// the catalog cannot be modified through the returned slice
+	kinds[0].Kind = "mangled"
+	c.Check(client.ErrorKinds()[0].Kind, Not(Equals), client.ErrorKind("mangled"))
+}
+
 func (cs *clientSuite) TestUserAgent(c *C) {
 	cli := client.New(&client.Config{UserAgent: "some-agent/9.87"})
 	cli.SetDoer(cs)
 
 	var v string
-	_, _ = cli.Do("GET", "/", nil, nil, &v, nil)
+	_, _ = cli.DoWithOpts("GET", "/", nil, nil, &v, nil)
 	c.Assert(cs.req, NotNil)
 	c.Check(cs.req.Header.Get("User-Agent"), Equals, "some-agent/9.87")
 }
@@ -677,10 +1068,10 @@ func (cs *integrationSuite) TestClientTimeoutLP1837804(c *C) {
 	defer testServer.Close()
 
 	cli := client.New(&client.Config{BaseURL: testServer.URL})
-	_, err := cli.Do("GET", "/", nil, nil, nil, nil)
+	_, err := cli.DoWithOpts("GET", "/", nil, nil, nil, nil)
 	c.Assert(err, ErrorMatches, `.*timeout.*`)
 
-	_, err = cli.Do("POST", "/", nil, nil, nil, nil)
+	_, err = cli.DoWithOpts("POST", "/", nil, nil, nil, nil)
 	c.Assert(err, ErrorMatches, `.*timeout.*`)
 }
 
@@ -718,7 +1109,7 @@ func (cs *clientSuite) TestClientDebugEnvVar(c *C) {
 
 	cli := client.New(&client.Config{BaseURL: srv.URL})
 	c.Assert(cli, NotNil)
-	_, err := cli.Do("GET", "/", nil, strings.NewReader("foo"), nil, nil)
+	_, err := cli.DoWithOpts("GET", "/", nil, strings.NewReader("foo"), nil, nil)
 	c.Assert(err, IsNil)
 
 	// check request
@@ -122,7 +122,9 @@ func (*cmdSuite) TestClientSnapFromSnapInfo(c *C) {
 		"GatingHold",
 		"RefreshInhibit",
 		"RefreshFailures",
+		"RefreshClassification",
 		"Components",
+		"NameResolution",
 	}
 	var checker func(string, reflect.Value)
 	checker = func(pfx string, x reflect.Value) {
@@ -28,25 +28,45 @@ import (
 
 // SetConf requests a snap to apply the provided patch to the configuration.
 func (client *Client) SetConf(snapName string, patch map[string]any) (changeID string, err error) {
+	return client.SetConfIfMatch(snapName, patch, "")
+}
+
+// SetConfIfMatch is like SetConf but the patch is only applied if the
+// configuration of the snap still has the given version, as returned by
+// ConfWithVersion. Otherwise an error of kind
+// ErrorKindResourceVersionMismatch is returned. An empty version applies the
+// patch unconditionally.
+func (client *Client) SetConfIfMatch(snapName string, patch map[string]any, version string) (changeID string, err error) {
 	b, err := json.Marshal(patch)
 	if err != nil {
 		return "", err
 	}
-	return client.doAsync("PUT", "/v2/snaps/"+snapName+"/conf", nil, nil, bytes.NewReader(b))
+	var headers map[string]string
+	if version != "" {
+		headers = map[string]string{"If-Match": `"` + version + `"`}
+	}
+	return client.doAsync("PUT", "/v2/snaps/"+snapName+"/conf", nil, headers, bytes.NewReader(b))
 }
 
 // Conf asks for a snap's current configuration.
 //
 // Note that the configuration may include json.Numbers.
 func (client *Client) Conf(snapName string, keys []string) (configuration map[string]any, err error) {
+	configuration, _, err = client.ConfWithVersion(snapName, keys)
+	return configuration, err
+}
+
+// ConfWithVersion is like Conf but also returns the version of the whole
+// configuration of the snap, for use with SetConfIfMatch.
+func (client *Client) ConfWithVersion(snapName string, keys []string) (configuration map[string]any, version string, err error) {
 	// Prepare query
 	query := url.Values{}
 	query.Set("keys", strings.Join(keys, ","))
 
-	_, err = client.doSync("GET", "/v2/snaps/"+snapName+"/conf", query, nil, nil, &configuration)
+	info, err := client.doSync("GET", "/v2/snaps/"+snapName+"/conf", query, nil, nil, &configuration)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return configuration, nil
+	return configuration, info.ResourceVersion, nil
 }
@@ -23,6 +23,8 @@ import (
 	"encoding/json"
 
 	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
 )
 
 func (cs *clientSuite) TestClientSetConfCallsEndpoint(c *check.C) {
@@ -103,3 +105,52 @@ func (cs *clientSuite) TestClientGetConfMultipleKeys(c *check.C) {
 		"test-key2": "test-value2",
 	})
 }
+
+func (cs *clientSuite) TestClientGetConfWithVersion(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"test-key": "test-value"},
+		"resource-version": "abc123"
+	}`
+	value, version, err := cs.cli.ConfWithVersion("snap-name", []string{"test-key"})
+	c.Assert(err, check.IsNil)
+	c.Check(value, check.DeepEquals, map[string]any{"test-key": "test-value"})
+	c.Check(version, check.Equals, "abc123")
+}
+
+func (cs *clientSuite) TestClientSetConfIfMatch(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "foo"
+	}`
+	id, err := cs.cli.SetConfIfMatch("snap-name", map[string]any{"key": "value"}, "abc123")
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "foo")
+	c.Check(cs.req.Header.Get("If-Match"), check.Equals, `"abc123"`)
+
+	id, err = cs.cli.SetConf("snap-name", map[string]any{"key": "value"})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "foo")
+	c.Check(cs.req.Header.Get("If-Match"), check.Equals, "")
+}
+
+func (cs *clientSuite) TestClientSetConfIfMatchMismatch(c *check.C) {
+	cs.status = 412
+	cs.rsp = `{
+		"type": "error",
+		"status-code": 412,
+		"result": {
+			"message": "resource was modified concurrently: version does not match",
+			"kind": "resource-version-mismatch",
+			"value": {"current-version": "def456"}
+		}
+	}`
+	_, err := cs.cli.SetConfIfMatch("snap-name", map[string]any{"key": "value"}, "abc123")
+	c.Assert(err, check.FitsTypeOf, &client.Error{})
+	c.Check(err.(*client.Error).Kind, check.Equals, client.ErrorKindResourceVersionMismatch)
+	c.Check(err.(*client.Error).Value, check.DeepEquals, map[string]any{"current-version": "def456"})
+}
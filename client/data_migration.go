@@ -0,0 +1,261 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// MigrateDataOptions controls how MigrateSnapData moves the data of a snap.
+type MigrateDataOptions struct {
+	// StopServices stops the active services of the snap on the source
+	// device while its data is saved, so that the copy is consistent.
+	// After a successful migration they stay stopped as the target device
+	// takes over, on failure they are started again.
+	StopServices bool
+	// KeepSnapshots keeps the snapshots used to move the data on both
+	// devices, otherwise they are forgotten once the data is restored.
+	KeepSnapshots bool
+	// Timeout bounds the time waited for each of the changes the
+	// migration runs on either device, it defaults to an hour.
+	Timeout time.Duration
+}
+
+// MigrateDataResult describes a completed data migration.
+type MigrateDataResult struct {
+	// SourceSetID is the snapshot set saved on the source device.
+	SourceSetID uint64
+	// TargetSetID is the snapshot set imported on the target device.
+	TargetSetID uint64
+	// StoppedServices are the services stopped on the source device.
+	StoppedServices []string
+}
+
+var (
+	migrateDataPollInterval   = 500 * time.Millisecond
+	migrateDataDefaultTimeout = time.Hour
+)
+
+// waitChange waits for the given change to be ready and returns an error
+// if it did not succeed. A change not ready within the timeout is aborted.
+func (client *Client) waitChange(id string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		chg, err := client.Change(id)
+		if err != nil {
+			return err
+		}
+		if chg.Ready {
+			if chg.Status != "Done" {
+				return fmt.Errorf("change %s (%q) did not succeed: %s", id, chg.Summary, chg.Err)
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			// best effort, the caller reports the timeout
+			client.Abort(id)
+			return fmt.Errorf("change %s (%q) not ready after %v", id, chg.Summary, timeout)
+		}
+		time.Sleep(migrateDataPollInterval)
+	}
+}
+
+func activeServices(cli *Client, snapName string) ([]string, error) {
+	apps, err := cli.Apps([]string{snapName}, AppOptions{Service: true})
+	if err != nil {
+		if e, ok := err.(*Error); ok && e.Kind == ErrorKindAppNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var active []string
+	for _, app := range apps {
+		if app.Active {
+			active = append(active, app.Snap+"."+app.Name)
+		}
+	}
+	return active, nil
+}
+
+// stopServices stops the active services of the snap and returns them,
+// also on error once their stopping was requested.
+func stopServices(cli *Client, snapName string, timeout time.Duration) ([]string, error) {
+	active, err := activeServices(cli, snapName)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) == 0 {
+		return nil, nil
+	}
+	id, err := cli.Stop(active, nil, UserSelector{}, StopOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return active, cli.waitChange(id, timeout)
+}
+
+func startServices(cli *Client, services []string, timeout time.Duration) error {
+	if len(services) == 0 {
+		return nil
+	}
+	id, err := cli.Start(services, nil, UserSelector{}, StartOptions{})
+	if err != nil {
+		return err
+	}
+	return cli.waitChange(id, timeout)
+}
+
+func snapshotHashes(cli *Client, setID uint64, snapName string) (map[string]string, error) {
+	sets, err := cli.SnapshotSets(setID, []string{snapName})
+	if err != nil {
+		return nil, err
+	}
+	for _, set := range sets {
+		for _, sh := range set.Snapshots {
+			if sh.Snap == snapName {
+				return sh.SHA3_384, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot find snapshot of %q in set %d", snapName, setID)
+}
+
+func sameHashes(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// MigrateSnapData moves the current data of a snap from the device managed
+// by from to the device managed by to, where the snap must be installed
+// already. The data is saved to a snapshot on the source device, streamed to
+// the target device, verified there against the checksums of the source and
+// then restored, with the services of the snap on the target device stopped
+// for the time of the restore. On failure the stopped services are started
+// again on both devices.
+func MigrateSnapData(from, to *Client, snapName string, opts *MigrateDataOptions) (res *MigrateDataResult, err error) {
+	if opts == nil {
+		opts = &MigrateDataOptions{}
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = migrateDataDefaultTimeout
+	}
+	res = &MigrateDataResult{}
+
+	if opts.StopServices {
+		stopped, stopErr := stopServices(from, snapName, timeout)
+		defer func() {
+			if err == nil {
+				return
+			}
+			// no cutover, give the services back to the source
+			if startErr := startServices(from, stopped, timeout); startErr != nil {
+				err = fmt.Errorf("%v (and cannot restart services on the source device: %v)", err, startErr)
+			}
+		}()
+		if stopErr != nil {
+			return nil, fmt.Errorf("cannot stop services on the source device: %v", stopErr)
+		}
+		res.StoppedServices = stopped
+	}
+
+	setID, id, err := from.SnapshotMany([]string{snapName}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot save snapshot on the source device: %v", err)
+	}
+	if err := from.waitChange(id, timeout); err != nil {
+		return nil, fmt.Errorf("cannot save snapshot on the source device: %v", err)
+	}
+	res.SourceSetID = setID
+	sourceHashes, err := snapshotHashes(from, setID, snapName)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, size, err := from.SnapshotExport(setID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot export snapshot from the source device: %v", err)
+	}
+	importSet, err := to.SnapshotImport(stream, size)
+	stream.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cannot import snapshot on the target device: %v", err)
+	}
+	res.TargetSetID = importSet.ID
+
+	targetHashes, err := snapshotHashes(to, importSet.ID, snapName)
+	if err != nil {
+		return nil, err
+	}
+	if !sameHashes(sourceHashes, targetHashes) {
+		return nil, fmt.Errorf("cannot migrate data of %q: imported snapshot does not match the source", snapName)
+	}
+	id, err = to.CheckSnapshots(importSet.ID, []string{snapName}, nil)
+	if err == nil {
+		err = to.waitChange(id, timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify snapshot on the target device: %v", err)
+	}
+
+	targetServices, err := stopServices(to, snapName, timeout)
+	if err == nil {
+		id, err = to.RestoreSnapshots(importSet.ID, []string{snapName}, nil)
+		if err == nil {
+			err = to.waitChange(id, timeout)
+		}
+		if err != nil {
+			err = fmt.Errorf("cannot restore snapshot on the target device: %v", err)
+		}
+	} else {
+		err = fmt.Errorf("cannot stop services on the target device: %v", err)
+	}
+	// the services of the target are started again whether the restore
+	// succeeded or not
+	if startErr := startServices(to, targetServices, timeout); startErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("%v (and cannot restart services on the target device: %v)", err, startErr)
+		}
+		return nil, fmt.Errorf("cannot start services on the target device: %v", startErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.KeepSnapshots {
+		// best effort, the data has been migrated already
+		if id, err := from.ForgetSnapshots(setID, nil); err == nil {
+			from.waitChange(id, timeout)
+		}
+		if id, err := to.ForgetSnapshots(importSet.ID, nil); err == nil {
+			to.waitChange(id, timeout)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,241 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/testutil"
+)
+
+// fakeDevice serves the parts of the API used by MigrateSnapData.
+type fakeDevice struct {
+	c *C
+
+	services map[string]bool
+	setID    uint64
+	hashes   map[string]string
+	export   string
+	failing  string
+	pending  string
+
+	imported string
+	ops      []string
+}
+
+func (d *fakeDevice) async(w http.ResponseWriter, result any) {
+	rsp := map[string]any{"type": "async", "status-code": 202, "change": "1"}
+	if result != nil {
+		rsp["result"] = result
+	}
+	w.WriteHeader(202)
+	json.NewEncoder(w).Encode(rsp)
+}
+
+func (d *fakeDevice) sync(w http.ResponseWriter, result any) {
+	json.NewEncoder(w).Encode(map[string]any{"type": "sync", "status-code": 200, "result": result})
+}
+
+func (d *fakeDevice) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c := d.c
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/v2/changes/1":
+		status, ready := "Done", true
+		if len(d.ops) > 0 && d.ops[len(d.ops)-1] == d.failing {
+			status = "Error"
+		}
+		if len(d.ops) > 0 && d.ops[len(d.ops)-1] == d.pending {
+			status, ready = "Doing", false
+		}
+		d.sync(w, map[string]any{"id": "1", "status": status, "ready": ready, "err": "boom"})
+	case r.Method == "POST" && r.URL.Path == "/v2/changes/1":
+		d.ops = append(d.ops, "abort")
+		d.sync(w, map[string]any{"id": "1", "status": "Hold", "ready": true})
+	case r.Method == "GET" && r.URL.Path == "/v2/apps":
+		c.Check(r.URL.Query().Get("names"), Equals, "foo")
+		c.Check(r.URL.Query().Get("select"), Equals, "service")
+		var apps []map[string]any
+		for _, name := range []string{"svc1", "svc2"} {
+			if active, ok := d.services[name]; ok {
+				apps = append(apps, map[string]any{"snap": "foo", "name": name, "daemon": "simple", "active": active})
+			}
+		}
+		d.sync(w, apps)
+	case r.Method == "POST" && r.URL.Path == "/v2/apps":
+		var inst struct {
+			Action string   `json:"action"`
+			Names  []string `json:"names"`
+		}
+		c.Assert(json.NewDecoder(r.Body).Decode(&inst), IsNil)
+		d.ops = append(d.ops, fmt.Sprintf("%s %v", inst.Action, inst.Names))
+		d.async(w, nil)
+	case r.Method == "POST" && r.URL.Path == "/v2/snaps":
+		d.ops = append(d.ops, "snapshot")
+		d.async(w, map[string]any{"set-id": d.setID})
+	case r.Method == "GET" && r.URL.Path == "/v2/snapshots":
+		c.Check(r.URL.Query().Get("set"), Equals, fmt.Sprint(d.setID))
+		d.sync(w, []map[string]any{{
+			"id":        d.setID,
+			"snapshots": []map[string]any{{"set": d.setID, "snap": "foo", "sha3-384": d.hashes}},
+		}})
+	case r.Method == "GET" && r.URL.Path == fmt.Sprintf("/v2/snapshots/%d/export", d.setID):
+		d.ops = append(d.ops, "export")
+		w.Header().Set("Content-Type", client.SnapshotExportMediaType)
+		w.Header().Set("Content-Length", fmt.Sprint(len(d.export)))
+		io.WriteString(w, d.export)
+	case r.Method == "POST" && r.URL.Path == "/v2/snapshots" && r.Header.Get("Content-Type") == client.SnapshotExportMediaType:
+		data, err := io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		d.imported = string(data)
+		d.ops = append(d.ops, "import")
+		d.sync(w, map[string]any{"set-id": d.setID, "snaps": []string{"foo"}})
+	case r.Method == "POST" && r.URL.Path == "/v2/snapshots":
+		var action struct {
+			Action string `json:"action"`
+			SetID  uint64 `json:"set"`
+		}
+		c.Assert(json.NewDecoder(r.Body).Decode(&action), IsNil)
+		c.Check(action.SetID, Equals, d.setID)
+		d.ops = append(d.ops, action.Action)
+		d.async(w, nil)
+	default:
+		c.Errorf("unexpected request %s %s", r.Method, r.URL)
+		w.WriteHeader(500)
+	}
+}
+
+type migrateDataSuite struct {
+	testutil.BaseTest
+
+	source, target *fakeDevice
+	from, to       *client.Client
+}
+
+var _ = Suite(&migrateDataSuite{})
+
+func (s *migrateDataSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	os.Setenv(client.TestAuthFileEnvKey, filepath.Join(c.MkDir(), "auth.json"))
+	s.AddCleanup(func() { os.Unsetenv(client.TestAuthFileEnvKey) })
+	s.AddCleanup(client.MockMigrateDataPollInterval(time.Millisecond))
+
+	s.source = &fakeDevice{
+		c:        c,
+		services: map[string]bool{"svc1": true, "svc2": false},
+		setID:    42,
+		hashes:   map[string]string{"archive.tgz": "abc"},
+		export:   "exported-data",
+	}
+	s.target = &fakeDevice{
+		c:        c,
+		services: map[string]bool{"svc1": true},
+		setID:    7,
+		hashes:   map[string]string{"archive.tgz": "abc"},
+	}
+	srcSrv := httptest.NewServer(s.source)
+	s.AddCleanup(srcSrv.Close)
+	dstSrv := httptest.NewServer(s.target)
+	s.AddCleanup(dstSrv.Close)
+
+	s.from = client.New(&client.Config{BaseURL: srcSrv.URL})
+	s.to = client.New(&client.Config{BaseURL: dstSrv.URL})
+}
+
+func (s *migrateDataSuite) TestMigrateSnapData(c *C) {
+	res, err := client.MigrateSnapData(s.from, s.to, "foo", &client.MigrateDataOptions{StopServices: true})
+	c.Assert(err, IsNil)
+	c.Check(res, DeepEquals, &client.MigrateDataResult{
+		SourceSetID:     42,
+		TargetSetID:     7,
+		StoppedServices: []string{"foo.svc1"},
+	})
+
+	// the source services stay stopped after the cutover
+	c.Check(s.source.ops, DeepEquals, []string{"stop [foo.svc1]", "snapshot", "export", "forget"})
+	c.Check(s.target.ops, DeepEquals, []string{"import", "check", "stop [foo.svc1]", "restore", "start [foo.svc1]", "forget"})
+	c.Check(s.target.imported, Equals, "exported-data")
+}
+
+func (s *migrateDataSuite) TestMigrateSnapDataKeepSnapshotsServicesRunning(c *C) {
+	res, err := client.MigrateSnapData(s.from, s.to, "foo", &client.MigrateDataOptions{KeepSnapshots: true})
+	c.Assert(err, IsNil)
+	c.Check(res.StoppedServices, HasLen, 0)
+
+	c.Check(s.source.ops, DeepEquals, []string{"snapshot", "export"})
+	c.Check(s.target.ops, DeepEquals, []string{"import", "check", "stop [foo.svc1]", "restore", "start [foo.svc1]"})
+}
+
+func (s *migrateDataSuite) TestMigrateSnapDataHashMismatch(c *C) {
+	s.target.hashes = map[string]string{"archive.tgz": "other"}
+
+	_, err := client.MigrateSnapData(s.from, s.to, "foo", &client.MigrateDataOptions{StopServices: true})
+	c.Assert(err, ErrorMatches, `cannot migrate data of "foo": imported snapshot does not match the source`)
+
+	// no cutover, the source services are started again
+	c.Check(s.source.ops, DeepEquals, []string{"stop [foo.svc1]", "snapshot", "export", "start [foo.svc1]"})
+	c.Check(s.target.ops, DeepEquals, []string{"import"})
+}
+
+func (s *migrateDataSuite) TestMigrateSnapDataRestoreFails(c *C) {
+	s.target.failing = "restore"
+
+	_, err := client.MigrateSnapData(s.from, s.to, "foo", &client.MigrateDataOptions{StopServices: true})
+	c.Assert(err, ErrorMatches, `cannot restore snapshot on the target device: change 1 \(""\) did not succeed: boom`)
+
+	c.Check(s.source.ops, DeepEquals, []string{"stop [foo.svc1]", "snapshot", "export", "start [foo.svc1]"})
+	// the target services are started again
+	c.Check(s.target.ops, DeepEquals, []string{"import", "check", "stop [foo.svc1]", "restore", "start [foo.svc1]"})
+}
+
+func (s *migrateDataSuite) TestMigrateSnapDataTargetStopFails(c *C) {
+	s.target.failing = "stop [foo.svc1]"
+
+	_, err := client.MigrateSnapData(s.from, s.to, "foo", &client.MigrateDataOptions{StopServices: true})
+	c.Assert(err, ErrorMatches, `cannot stop services on the target device: change 1 \(""\) did not succeed: boom`)
+
+	c.Check(s.source.ops, DeepEquals, []string{"stop [foo.svc1]", "snapshot", "export", "start [foo.svc1]"})
+	c.Check(s.target.ops, DeepEquals, []string{"import", "check", "stop [foo.svc1]", "start [foo.svc1]"})
+}
+
+func (s *migrateDataSuite) TestMigrateSnapDataRestoreTimeout(c *C) {
+	s.target.pending = "restore"
+
+	_, err := client.MigrateSnapData(s.from, s.to, "foo", &client.MigrateDataOptions{
+		StopServices: true,
+		Timeout:      10 * time.Millisecond,
+	})
+	c.Assert(err, ErrorMatches, `cannot restore snapshot on the target device: change 1 \(""\) not ready after 10ms`)
+
+	// the change is aborted and the services are started again on both
+	// devices
+	c.Check(s.source.ops, DeepEquals, []string{"stop [foo.svc1]", "snapshot", "export", "start [foo.svc1]"})
+	c.Check(s.target.ops, DeepEquals, []string{"import", "check", "stop [foo.svc1]", "restore", "abort", "start [foo.svc1]"})
+}
@@ -19,9 +19,33 @@
 
 package client
 
+import (
+	"errors"
+)
+
+//go:generate go run $GOINVOKEFLAGS ./generrorkinds -in errors.go -out errorkinds_catalog.go
+
 // ErrorKind distinguishes kind of errors.
 type ErrorKind string
 
+// ErrorKindInfo describes an error kind as documented in the error kind
+// catalog.
+type ErrorKindInfo struct {
+	Kind        ErrorKind `json:"kind"`
+	Description string    `json:"description"`
+	// Maintenance is set for kinds used only in the maintenance field of
+	// responses.
+	Maintenance bool `json:"maintenance,omitempty"`
+}
+
+// ErrorKinds returns the catalog of documented error kinds, generated from
+// the error kind definitions.
+func ErrorKinds() []ErrorKindInfo {
+	kinds := make([]ErrorKindInfo, len(errorKindCatalog))
+	copy(kinds, errorKindCatalog)
+	return kinds
+}
+
 // error kind const value doc comments here have a non-default,
 // specialized style (to help docs/error-kind.go):
 //
@@ -98,6 +122,11 @@ const (
 	// `snap-channel-not-available`.
 	ErrorKindSnapArchitectureNotAvailable ErrorKind = "snap-architecture-not-available"
 
+	// ErrorKindSnapMissingBase: the base required by the snap is not
+	// installed and cannot be installed as part of the operation. The
+	// error `value` is an object with fields `snap-name` and `base`.
+	ErrorKindSnapMissingBase ErrorKind = "snap-missing-base"
+
 	// ErrorKindSnapChangeConflict: the requested operation would
 	// conflict with currently ongoing change. This is a temporary
 	// error. The error `value` is an object with optional fields
@@ -150,6 +179,18 @@ const (
 	// ErrorKindValidationSetNotFound: validation set cannot be found.
 	ErrorKindValidationSetNotFound ErrorKind = "validation-set-not-found"
 
+	// ErrorKindValidationSetConstraintsUnmet: the operation would leave
+	// snaps not meeting the constraints of the enforced validation
+	// sets. The error `value` is an object with optional fields
+	// `missing-snaps`, `invalid-snaps` and `wrong-revision-snaps`.
+	ErrorKindValidationSetConstraintsUnmet ErrorKind = "validation-set-constraints-unmet"
+
+	// ErrorKindAliasConflict: the requested aliases conflict with
+	// aliases of other snaps. The error `value` is an object with
+	// fields `snap-name`, optional `alias` and `conflicts` mapping
+	// snap names to their conflicting aliases.
+	ErrorKindAliasConflict ErrorKind = "alias-conflict"
+
 	// ErrorKindAppArmorPromptingNotRunning: AppArmor Prompting is not running.
 	ErrorKindAppArmorPromptingNotRunning ErrorKind = "apparmor-prompting-not-running"
 
@@ -171,6 +212,9 @@ const (
 	// ErrorKindInterfacesRequestsRuleConflict: a rule with conflicting path pattern and permissions already exists.
 	ErrorKindInterfacesRequestsRuleConflict ErrorKind = "interfaces-requests-rule-conflict"
 
+	// ErrorKindInterfacesRequestsNoUserSession: a rule with lifespan "session" cannot be created since the user has no active session.
+	ErrorKindInterfacesRequestsNoUserSession ErrorKind = "interfaces-requests-no-user-session"
+
 	// ErrorKindMissingSnapResourcePair: cannot find a snap-resource-pair when attempting to sideload a component
 	ErrorKindMissingSnapResourcePair ErrorKind = "missing-snap-resource-pair"
 
@@ -186,6 +230,16 @@ const (
 	// ErrorKindSystemKeyVersionUnsupported: snapd does not support the system
 	// key version sent by the client
 	ErrorKindSystemKeyVersionUnsupported ErrorKind = "unsupported-system-key-version"
+
+	// ErrorKindTooManyRequests: the request was rejected because of the
+	// configured API request rate or in-flight changes limits, it can be
+	// retried after the time given in the Retry-After header.
+	ErrorKindTooManyRequests ErrorKind = "too-many-requests"
+
+	// ErrorKindResourceVersionMismatch: the resource version given via
+	// If-Match does not match the current version of the resource, which was
+	// modified concurrently. The value holds the current version.
+	ErrorKindResourceVersionMismatch ErrorKind = "resource-version-mismatch"
 )
 
 // Maintenance error kinds.
@@ -197,3 +251,43 @@ const (
 	// ErrorKindSystemRestart: system is restarting.
 	ErrorKindSystemRestart ErrorKind = "system-restart"
 )
+
+// Error classes, *Error values returned by the client match the class of
+// their kind when checked with errors.Is, so that callers do not need to know
+// about every individual error kind.
+var (
+	// ErrSnapNotFound is the class of errors about snaps that cannot be
+	// found or are not installed.
+	ErrSnapNotFound = errors.New("snap not found")
+	// ErrConflict is the class of errors about operations conflicting with
+	// ongoing changes or with concurrent modifications.
+	ErrConflict = errors.New("operation conflicts with ongoing changes")
+	// ErrInsufficientSpace is the class of errors about not having enough
+	// disk space to perform an operation.
+	ErrInsufficientSpace = errors.New("insufficient disk space")
+	// ErrAuthRequired is the class of errors about operations that require
+	// an (additional) authentication.
+	ErrAuthRequired = errors.New("authentication required")
+)
+
+var errorKindClasses = map[ErrorKind]error{
+	ErrorKindSnapNotFound:            ErrSnapNotFound,
+	ErrorKindSnapNotInstalled:        ErrSnapNotFound,
+	ErrorKindSnapChangeConflict:      ErrConflict,
+	ErrorKindQuotaChangeConflict:     ErrConflict,
+	ErrorKindResourceVersionMismatch: ErrConflict,
+	ErrorKindInsufficientDiskSpace:   ErrInsufficientSpace,
+	ErrorKindLoginRequired:           ErrAuthRequired,
+	ErrorKindTwoFactorRequired:       ErrAuthRequired,
+}
+
+// Is returns whether the error belongs to the target error class, it is
+// used by errors.Is.
+func (e *Error) Is(target error) bool {
+	class, ok := errorKindClasses[e.Kind]
+	if !ok && e.Kind == "" && e.StatusCode == 401 {
+		// unauthorized responses without a more specific kind
+		class, ok = ErrAuthRequired, true
+	}
+	return ok && class == target
+}
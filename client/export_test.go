@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/url"
+	"time"
 )
 
 // SetDoer sets the client's doer to the given one
@@ -32,8 +33,8 @@ func (client *Client) SetDoer(d doer) {
 
 type DoOptions = doOptions
 
-// Do does do.
-func (client *Client) Do(method, path string, query url.Values, body io.Reader, v any, opts *DoOptions) (statusCode int, err error) {
+// DoWithOpts does do.
+func (client *Client) DoWithOpts(method, path string, query url.Values, body io.Reader, v any, opts *DoOptions) (statusCode int, err error) {
 	return client.do(method, path, query, nil, body, v, opts)
 }
 
@@ -61,3 +62,42 @@ func MockStdinReadLimit(new int64) (restore func()) {
 		stdinReadLimit = oldStdinReadLimit
 	}
 }
+
+func (p *RetryPolicy) Delay(retry int, defaultBackoff time.Duration) time.Duration {
+	return p.delay(retry, defaultBackoff)
+}
+
+func MockSocketWaitInterval(d time.Duration) (restore func()) {
+	old := socketWaitInterval
+	socketWaitInterval = d
+	return func() {
+		socketWaitInterval = old
+	}
+}
+
+func MockDownloadProgressInterval(d time.Duration) (restore func()) {
+	old := downloadProgressInterval
+	downloadProgressInterval = d
+	return func() {
+		downloadProgressInterval = old
+	}
+}
+
+func MockNoticesWatchTimings(timeout, retry time.Duration) (restore func()) {
+	oldTimeout := noticesWatchTimeout
+	oldRetry := noticesWatchRetry
+	noticesWatchTimeout = timeout
+	noticesWatchRetry = retry
+	return func() {
+		noticesWatchTimeout = oldTimeout
+		noticesWatchRetry = oldRetry
+	}
+}
+
+func MockMigrateDataPollInterval(d time.Duration) (restore func()) {
+	old := migrateDataPollInterval
+	migrateDataPollInterval = d
+	return func() {
+		migrateDataPollInterval = old
+	}
+}
@@ -0,0 +1,166 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var catalogTemplateText = `// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+// Code generated from {{ .InputFileName }} DO NOT EDIT
+
+var errorKindCatalog = []ErrorKindInfo{
+{{ range .Kinds }}	{Kind: {{ .Name }}, Description: {{ .Description }}{{ if .Maintenance }}, Maintenance: true{{ end }}},
+{{ end }}}
+`
+
+var inFile = flag.String("in", "", "error kinds input file")
+var outFile = flag.String("out", "", "catalog output file")
+var catalogTemplate = template.Must(template.New("catalog").Parse(catalogTemplateText))
+
+type errorKind struct {
+	Name        string
+	Description string
+	Maintenance bool
+}
+
+// description extracts the description from a doc comment in the
+// "// ErrorKindFoo: DESCRIPTION." style used for error kinds.
+func description(doc string) string {
+	if idx := strings.Index(doc, ":"); idx >= 0 {
+		doc = doc[idx+1:]
+	}
+	return strings.Join(strings.Fields(doc), " ")
+}
+
+func collectKinds(inputFile string) ([]errorKind, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, inputFile, nil, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse input file: %v", err)
+	}
+
+	var kinds []errorKind
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		maintenance := strings.HasPrefix(gd.Doc.Text(), "Maintenance error kinds")
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			typ, ok := vs.Type.(*ast.Ident)
+			if !ok || typ.Name != "ErrorKind" {
+				continue
+			}
+			desc := description(vs.Doc.Text())
+			if strings.Contains(desc, "do not document") {
+				continue
+			}
+			for _, name := range vs.Names {
+				kinds = append(kinds, errorKind{
+					Name:        name.Name,
+					Description: strconv.Quote(desc),
+					Maintenance: maintenance,
+				})
+			}
+		}
+	}
+	if len(kinds) == 0 {
+		return nil, fmt.Errorf("cannot find any error kinds in %s", inputFile)
+	}
+	return kinds, nil
+}
+
+func run(inputFile, outputFile string) error {
+	kinds, err := collectKinds(inputFile)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	templateData := struct {
+		InputFileName string
+		Kinds         []errorKind
+	}{
+		InputFileName: inputFile,
+		Kinds:         kinds,
+	}
+	if err := catalogTemplate.Execute(&buf, &templateData); err != nil {
+		return fmt.Errorf("cannot generate content: %v", err)
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("cannot format generated content: %v", err)
+	}
+	return os.WriteFile(outputFile, out, 0644)
+}
+
+func parseArgs() error {
+	flag.Parse()
+	if *inFile == "" {
+		return fmt.Errorf("input file not provided")
+	}
+	if *outFile == "" {
+		return fmt.Errorf("output file not provided")
+	}
+	return nil
+}
+
+func main() {
+	if err := parseArgs(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := run(*inFile, *outFile); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -20,10 +20,11 @@
 package client
 
 import (
-	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"regexp"
+	"strconv"
 
 	"golang.org/x/xerrors"
 )
@@ -36,11 +37,37 @@ type Icon struct {
 
 var contentDispositionMatcher = regexp.MustCompile(`attachment; filename=(.+)`).FindStringSubmatch
 
+// IconOptions select the variant of the icon to retrieve.
+type IconOptions struct {
+	// Size asks for the icon to be scaled to fit a square of this many
+	// pixels. Vector icons are returned as they are.
+	Size int
+	// Theme is the desktop icon theme to look up first.
+	Theme string
+}
+
 // Icon returns the Icon belonging to an installed snap
 func (c *Client) Icon(pkgID string) (*Icon, error) {
+	return c.IconWithOptions(pkgID, nil)
+}
+
+// IconWithOptions returns the Icon belonging to an installed snap,
+// resolved and scaled by snapd according to the options.
+func (c *Client) IconWithOptions(pkgID string, opts *IconOptions) (*Icon, error) {
 	const errPrefix = "cannot retrieve icon"
 
-	response, cancel, err := c.rawWithTimeout(context.Background(), "GET", fmt.Sprintf("/v2/icons/%s/icon", pkgID), nil, nil, nil, nil)
+	var query url.Values
+	if opts != nil {
+		query = url.Values{}
+		if opts.Size != 0 {
+			query.Set("size", strconv.Itoa(opts.Size))
+		}
+		if opts.Theme != "" {
+			query.Set("theme", opts.Theme)
+		}
+	}
+
+	response, cancel, err := c.rawWithTimeout(c.requestContext(), "GET", fmt.Sprintf("/v2/icons/%s/icon", pkgID), query, nil, nil, nil)
 	if err != nil {
 		fmt := "%s: failed to communicate with server: %w"
 		return nil, xerrors.Errorf(fmt, errPrefix, err)
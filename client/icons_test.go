@@ -23,9 +23,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"golang.org/x/xerrors"
 	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
 )
 
 const (
@@ -71,3 +74,13 @@ func (cs *clientSuite) TestClientIconErrIsWrapped(c *C) {
 	var e xerrors.Wrapper
 	c.Assert(err, Implements, &e)
 }
+
+func (cs *clientSuite) TestClientIconWithOptions(c *C) {
+	cs.rsp = "pixels"
+	cs.header = http.Header{"Content-Disposition": {"attachment; filename=myicon.png"}}
+	icon, err := cs.cli.IconWithOptions(pkgID, &client.IconOptions{Size: 48, Theme: "Yaru"})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.URL.Path, Equals, fmt.Sprintf("/v2/icons/%s/icon", pkgID))
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{"size": {"48"}, "theme": {"Yaru"}})
+	c.Check(icon.Content, DeepEquals, []byte("pixels"))
+}
@@ -75,6 +75,12 @@ type InterfaceAction struct {
 	Forget bool   `json:"forget,omitempty"`
 	Plugs  []Plug `json:"plugs,omitempty"`
 	Slots  []Slot `json:"slots,omitempty"`
+	// To is the new slot provider for the "migrate-connections" action.
+	To *Slot `json:"to,omitempty"`
+	// Pattern limits the slots considered by the "connect-all" and
+	// "disconnect-all" actions to the ones whose "<snap>:<slot>" name
+	// match the glob pattern.
+	Pattern string `json:"pattern,omitempty"`
 }
 
 // InterfaceOptions represents opt-in elements include in responses.
@@ -138,6 +144,42 @@ func (client *Client) Connect(plugSnapName, plugName, slotSnapName, slotName str
 	})
 }
 
+// MigrateConnections moves the connections of the slots of one snap over to
+// the matching slots of another snap providing the same interfaces, e.g. when
+// replacing the snap providing a service. If fromSlot is not empty only the
+// connections of that slot are migrated and if toSlot is not empty they are
+// migrated to that slot.
+func (client *Client) MigrateConnections(fromSnapName, fromSlot, toSnapName, toSlot string) (changeID string, err error) {
+	return client.performInterfaceAction(&InterfaceAction{
+		Action: "migrate-connections",
+		Slots:  []Slot{{Snap: fromSnapName, Name: fromSlot}},
+		To:     &Slot{Snap: toSnapName, Name: toSlot},
+	})
+}
+
+// ConnectAll connects every plug of the snap which is not connected yet to
+// its only candidate slot, optionally limited to the slots whose
+// "<snap>:<slot>" name match slotPattern. The plugs left alone are reported
+// along with the reason in the "skipped" entry of the data of the change.
+func (client *Client) ConnectAll(snapName, slotPattern string) (changeID string, err error) {
+	return client.performInterfaceAction(&InterfaceAction{
+		Action:  "connect-all",
+		Plugs:   []Plug{{Snap: snapName}},
+		Pattern: slotPattern,
+	})
+}
+
+// DisconnectAll disconnects every connected plug of the snap, optionally
+// limited to the connections to the slots whose "<snap>:<slot>" name match
+// slotPattern.
+func (client *Client) DisconnectAll(snapName, slotPattern string) (changeID string, err error) {
+	return client.performInterfaceAction(&InterfaceAction{
+		Action:  "disconnect-all",
+		Plugs:   []Plug{{Snap: snapName}},
+		Pattern: slotPattern,
+	})
+}
+
 // Disconnect breaks the connection between a plug and a slot.
 func (client *Client) Disconnect(plugSnapName, plugName, slotSnapName, slotName string, opts *DisconnectOptions) (changeID string, err error) {
 	return client.performInterfaceAction(&InterfaceAction{
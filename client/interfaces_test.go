@@ -233,6 +233,86 @@ func (cs *clientSuite) TestClientDisconnect(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientMigrateConnections(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+	id, err := cs.cli.MigrateConnections("pulseaudio", "", "pipewire", "")
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "42")
+	var body map[string]any
+	decoder := json.NewDecoder(cs.req.Body)
+	err = decoder.Decode(&body)
+	c.Check(err, check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]any{
+		"action": "migrate-connections",
+		"slots": []any{
+			map[string]any{
+				"snap": "pulseaudio",
+				"slot": "",
+			},
+		},
+		"to": map[string]any{
+			"snap": "pipewire",
+			"slot": "",
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientConnectAll(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+	id, err := cs.cli.ConnectAll("gateway", "pi:*")
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "42")
+	var body map[string]any
+	decoder := json.NewDecoder(cs.req.Body)
+	err = decoder.Decode(&body)
+	c.Check(err, check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]any{
+		"action": "connect-all",
+		"plugs": []any{
+			map[string]any{
+				"snap": "gateway",
+				"plug": "",
+			},
+		},
+		"pattern": "pi:*",
+	})
+}
+
+func (cs *clientSuite) TestClientDisconnectAll(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+	id, err := cs.cli.DisconnectAll("gateway", "")
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "42")
+	var body map[string]any
+	decoder := json.NewDecoder(cs.req.Body)
+	err = decoder.Decode(&body)
+	c.Check(err, check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]any{
+		"action": "disconnect-all",
+		"plugs": []any{
+			map[string]any{
+				"snap": "gateway",
+				"plug": "",
+			},
+		},
+	})
+}
+
 func (cs *clientSuite) TestClientDisconnectForget(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
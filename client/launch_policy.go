@@ -0,0 +1,87 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// LaunchPolicyRule allows launching an app, or all the apps of a snap, by
+// the listed users and members of the listed groups. A rule with no users
+// and no groups applies to everyone.
+type LaunchPolicyRule struct {
+	App    string   `json:"app"`
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// LaunchPolicy restricts which snap apps can be launched and by whom. In
+// "audit" mode decisions are only logged, in "enforce" mode launches not
+// allowed by any rule are denied.
+type LaunchPolicy struct {
+	Mode  string             `json:"mode"`
+	Rules []LaunchPolicyRule `json:"rules,omitempty"`
+}
+
+// LaunchPolicy returns the launch policy of the system, or nil if there is
+// none in place.
+func (client *Client) LaunchPolicy() (*LaunchPolicy, error) {
+	var policy *LaunchPolicy
+	if _, err := client.doSync("GET", "/v2/launch-policy", nil, nil, nil, &policy); err != nil {
+		return nil, fmt.Errorf("cannot get launch policy: %v", err)
+	}
+	return policy, nil
+}
+
+type postLaunchPolicyData struct {
+	Action string        `json:"action"`
+	Policy *LaunchPolicy `json:"policy,omitempty"`
+}
+
+func (client *Client) postLaunchPolicy(data *postLaunchPolicyData) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(data); err != nil {
+		return err
+	}
+	_, err := client.doSync("POST", "/v2/launch-policy", nil, nil, &body, nil)
+	return err
+}
+
+// SetLaunchPolicy replaces the launch policy of the system.
+func (client *Client) SetLaunchPolicy(policy *LaunchPolicy) error {
+	if policy == nil {
+		return fmt.Errorf("cannot set launch policy: no policy provided")
+	}
+	if err := client.postLaunchPolicy(&postLaunchPolicyData{Action: "set", Policy: policy}); err != nil {
+		return fmt.Errorf("cannot set launch policy: %v", err)
+	}
+	return nil
+}
+
+// RemoveLaunchPolicy removes the launch policy of the system, allowing all
+// apps to be launched again.
+func (client *Client) RemoveLaunchPolicy() error {
+	if err := client.postLaunchPolicy(&postLaunchPolicyData{Action: "remove"}); err != nil {
+		return fmt.Errorf("cannot remove launch policy: %v", err)
+	}
+	return nil
+}
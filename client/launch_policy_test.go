@@ -0,0 +1,100 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestClientLaunchPolicy(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"mode": "enforce", "rules": [{"app": "kiosk", "groups": ["operators"]}, {"app": "editor.edit", "users": ["alice"]}]}
+	}`
+	policy, err := cs.cli.LaunchPolicy()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/launch-policy")
+	c.Check(policy, check.DeepEquals, &client.LaunchPolicy{
+		Mode: "enforce",
+		Rules: []client.LaunchPolicyRule{
+			{App: "kiosk", Groups: []string{"operators"}},
+			{App: "editor.edit", Users: []string{"alice"}},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientLaunchPolicyNone(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": null}`
+	policy, err := cs.cli.LaunchPolicy()
+	c.Assert(err, check.IsNil)
+	c.Check(policy, check.IsNil)
+}
+
+func (cs *clientSuite) TestClientSetLaunchPolicy(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": null}`
+	err := cs.cli.SetLaunchPolicy(&client.LaunchPolicy{
+		Mode:  "audit",
+		Rules: []client.LaunchPolicyRule{{App: "kiosk"}},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/launch-policy")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var data map[string]any
+	c.Assert(json.Unmarshal(body, &data), check.IsNil)
+	c.Check(data, check.DeepEquals, map[string]any{
+		"action": "set",
+		"policy": map[string]any{
+			"mode":  "audit",
+			"rules": []any{map[string]any{"app": "kiosk"}},
+		},
+	})
+
+	err = cs.cli.SetLaunchPolicy(nil)
+	c.Check(err, check.ErrorMatches, "cannot set launch policy: no policy provided")
+}
+
+func (cs *clientSuite) TestClientRemoveLaunchPolicy(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": null}`
+	err := cs.cli.RemoveLaunchPolicy()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/launch-policy")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(string(body), check.Equals, "{\"action\":\"remove\"}\n")
+}
+
+func (cs *clientSuite) TestClientSetLaunchPolicyError(c *check.C) {
+	cs.status = 400
+	cs.rsp = `{"type": "error", "status-code": 400, "result": {"message": "invalid launch policy mode \"maybe\""}}`
+	err := cs.cli.SetLaunchPolicy(&client.LaunchPolicy{Mode: "maybe"})
+	c.Check(err, check.ErrorMatches, `cannot set launch policy: invalid launch policy mode "maybe"`)
+}
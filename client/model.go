@@ -21,7 +21,6 @@ package client
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -77,6 +76,7 @@ func (client *Client) RemodelWithLocalSnaps(
 	}
 	assertsFiles, err := checkAndOpenFiles(assertPaths)
 	if err != nil {
+		closeFiles(snapFiles)
 		return "", err
 	}
 
@@ -97,9 +97,7 @@ func checkAndOpenFiles(paths []string) ([]*os.File, error) {
 	for _, path := range paths {
 		f, err := os.Open(path)
 		if err != nil {
-			for _, openFile := range files {
-				openFile.Close()
-			}
+			closeFiles(files)
 			return nil, fmt.Errorf("cannot open %q: %w", path, err)
 		}
 
@@ -109,6 +107,12 @@ func checkAndOpenFiles(paths []string) ([]*os.File, error) {
 	return files, nil
 }
 
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
 func createAssertionPart(name string, mw *multipart.Writer) (io.Writer, error) {
 	h := make(textproto.MIMEHeader)
 	h.Set("Content-Disposition",
@@ -119,9 +123,8 @@ func createAssertionPart(name string, mw *multipart.Writer) (io.Writer, error) {
 
 func sendRemodelFiles(model []byte, paths []string, files, assertFiles []*os.File, pw *io.PipeWriter, mw *multipart.Writer) {
 	defer func() {
-		for _, f := range files {
-			f.Close()
-		}
+		closeFiles(files)
+		closeFiles(assertFiles)
 	}()
 
 	w, err := createAssertionPart("new-model", mw)
@@ -203,7 +206,7 @@ func (client *Client) CurrentSerialAssertion() (*asserts.Serial, error) {
 func currentAssertion(client *Client, path string) (asserts.Assertion, error) {
 	q := url.Values{}
 
-	response, cancel, err := client.rawWithTimeout(context.Background(), "GET", path, q, nil, nil, nil)
+	response, cancel, err := client.rawWithTimeout(client.requestContext(), "GET", path, q, nil, nil, nil)
 	if err != nil {
 		fmt := "failed to query current assertion: %w"
 		return nil, xerrors.Errorf(fmt, err)
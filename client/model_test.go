@@ -198,6 +198,30 @@ func (cs *clientSuite) TestClientGetSerialHappy(c *C) {
 	c.Assert(serialAssertion, DeepEquals, expectedAssert)
 }
 
+func (cs *clientSuite) TestClientCurrentAssertionEndpoints(c *C) {
+	cs.rsp = happyModelAssertionResponse
+	_, err := cs.cli.CurrentModelAssertion()
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/model")
+
+	cs.rsp = happySerialAssertionResponse
+	_, err = cs.cli.CurrentSerialAssertion()
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/model/serial")
+}
+
+func (cs *clientSuite) TestClientCurrentAssertionUnexpectedType(c *C) {
+	cs.rsp = happyModelAssertionResponse
+	_, err := cs.cli.CurrentSerialAssertion()
+	c.Check(err, ErrorMatches, `unexpected assertion type \(model\) returned`)
+
+	cs.rsp = happySerialAssertionResponse
+	_, err = cs.cli.CurrentModelAssertion()
+	c.Check(err, ErrorMatches, `unexpected assertion type \(serial\) returned`)
+}
+
 func (cs *clientSuite) TestClientCurrentModelAssertionErrIsWrapped(c *C) {
 	cs.err = errors.New("boom")
 	_, err := cs.cli.CurrentModelAssertion()
@@ -290,3 +314,45 @@ func (cs *clientSuite) TestClientOfflineRemodelNoFile(c *C) {
 	c.Assert(err, ErrorMatches, `cannot open .*: no such file or directory`)
 	c.Assert(id, Equals, "")
 }
+
+// openFiles returns which of the given paths are open by the process.
+func openFiles(c *C, paths []string) []string {
+	fds, err := os.ReadDir("/proc/self/fd")
+	c.Assert(err, IsNil)
+	var open []string
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name()))
+		if err != nil {
+			// the fd used to read the directory is gone
+			continue
+		}
+		for _, path := range paths {
+			if target == path {
+				open = append(open, path)
+			}
+		}
+	}
+	return open
+}
+
+func (cs *clientSuite) TestClientOfflineRemodelNoAssertionFileClosesSnapFiles(c *C) {
+	rawModel := []byte(`some-model`)
+
+	snapPaths := []string{
+		filepath.Join(dirs.GlobalRootDir, "snap1.snap"),
+		filepath.Join(dirs.GlobalRootDir, "snap2.snap"),
+	}
+	for _, path := range snapPaths {
+		c.Assert(os.WriteFile(path, []byte("snap"), 0644), IsNil)
+	}
+	assertsPaths := []string{
+		filepath.Join(dirs.GlobalRootDir, "f1.asserts"),
+		filepath.Join(dirs.GlobalRootDir, "f2.asserts"),
+	}
+	c.Assert(os.WriteFile(assertsPaths[0], []byte("asserts1"), 0644), IsNil)
+
+	id, err := cs.cli.RemodelWithLocalSnaps(rawModel, snapPaths, assertsPaths)
+	c.Assert(err, ErrorMatches, `cannot open .*/f2.asserts: no such file or directory`)
+	c.Check(id, Equals, "")
+	c.Check(openFiles(c, append(snapPaths, assertsPaths...)), HasLen, 0)
+}
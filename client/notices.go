@@ -16,7 +16,17 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/osutil"
 )
 
 type NotifyOptions struct {
@@ -59,6 +69,326 @@ func (client *Client) Notify(opts *NotifyOptions) (string, error) {
 type NoticeType string
 
 const (
+	// ChangeUpdateNotice is recorded when a change's status is updated.
+	ChangeUpdateNotice NoticeType = "change-update"
+
+	// WarningNotice is recorded when a warning is added.
+	WarningNotice NoticeType = "warning"
+
+	// RefreshInhibitNotice is recorded when refreshes of snaps are
+	// inhibited because they are running. The key is "-" when the set of
+	// inhibited snaps changes, or the name of a single inhibited snap.
+	RefreshInhibitNotice NoticeType = "refresh-inhibit"
+
 	// SnapRunInhibitNotice is recorded when "snap run" is inhibited due refresh.
 	SnapRunInhibitNotice NoticeType = "snap-run-inhibit"
+
+	// InterfacesRequestsPromptNotice is recorded when a prompt is added
+	// or updated.
+	InterfacesRequestsPromptNotice NoticeType = "interfaces-requests-prompt"
+
+	// InterfacesRequestsRuleUpdateNotice is recorded when a prompting
+	// rule is added, modified or removed.
+	InterfacesRequestsRuleUpdateNotice NoticeType = "interfaces-requests-rule-update"
+
+	// SnapDownloadedNotice is recorded when a snap revision was downloaded
+	// ahead of a refresh.
+	SnapDownloadedNotice NoticeType = "snap-downloaded"
+)
+
+// Notice is a notice recorded by snapd, as returned by the API.
+type Notice struct {
+	ID     string
+	UserID *uint32
+	Type   NoticeType
+	Key    string
+
+	FirstOccurred time.Time
+	LastOccurred  time.Time
+	LastRepeated  time.Time
+	Occurrences   int
+	LastData      map[string]string
+
+	RepeatAfter time.Duration
+	ExpireAfter time.Duration
+}
+
+type jsonNotice struct {
+	ID            string            `json:"id"`
+	UserID        *uint32           `json:"user-id"`
+	Type          NoticeType        `json:"type"`
+	Key           string            `json:"key"`
+	FirstOccurred time.Time         `json:"first-occurred"`
+	LastOccurred  time.Time         `json:"last-occurred"`
+	LastRepeated  time.Time         `json:"last-repeated"`
+	Occurrences   int               `json:"occurrences"`
+	LastData      map[string]string `json:"last-data,omitempty"`
+	RepeatAfter   string            `json:"repeat-after,omitempty"`
+	ExpireAfter   string            `json:"expire-after,omitempty"`
+}
+
+func (n *Notice) UnmarshalJSON(data []byte) error {
+	var jn jsonNotice
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return err
+	}
+	*n = Notice{
+		ID:            jn.ID,
+		UserID:        jn.UserID,
+		Type:          jn.Type,
+		Key:           jn.Key,
+		FirstOccurred: jn.FirstOccurred,
+		LastOccurred:  jn.LastOccurred,
+		LastRepeated:  jn.LastRepeated,
+		Occurrences:   jn.Occurrences,
+		LastData:      jn.LastData,
+	}
+	var err error
+	if jn.RepeatAfter != "" {
+		if n.RepeatAfter, err = time.ParseDuration(jn.RepeatAfter); err != nil {
+			return fmt.Errorf("invalid repeat-after duration: %v", err)
+		}
+	}
+	if jn.ExpireAfter != "" {
+		if n.ExpireAfter, err = time.ParseDuration(jn.ExpireAfter); err != nil {
+			return fmt.Errorf("invalid expire-after duration: %v", err)
+		}
+	}
+	return nil
+}
+
+// NoticesFilter selects the notices to return. All notices visible to
+// the requesting user are returned if it is nil or empty.
+type NoticesFilter struct {
+	// Types, if set, selects notices of one of the given types.
+	Types []NoticeType
+	// Keys, if set, selects notices with one of the given keys.
+	Keys []string
+	// After, if set, selects notices last repeated after the given time.
+	After time.Time
+	// UserID, if set, selects notices of the given user and public
+	// ones, instead of those of the requesting user. Admin only.
+	UserID *uint32
+	// AllUsers selects the notices of all users. Admin only.
+	AllUsers bool
+}
+
+func (f *NoticesFilter) query() url.Values {
+	q := make(url.Values)
+	if f == nil {
+		return q
+	}
+	if len(f.Types) > 0 {
+		types := make([]string, len(f.Types))
+		for i, t := range f.Types {
+			types[i] = string(t)
+		}
+		q.Set("types", strings.Join(types, ","))
+	}
+	if len(f.Keys) > 0 {
+		q.Set("keys", strings.Join(f.Keys, ","))
+	}
+	if !f.After.IsZero() {
+		q.Set("after", f.After.Format(time.RFC3339Nano))
+	}
+	if f.UserID != nil {
+		q.Set("user-id", strconv.FormatUint(uint64(*f.UserID), 10))
+	}
+	if f.AllUsers {
+		q.Set("users", "all")
+	}
+	return q
+}
+
+// Notices returns the notices selected by the filter.
+func (client *Client) Notices(filter *NoticesFilter) ([]*Notice, error) {
+	var notices []*Notice
+	if _, err := client.doSync("GET", "/v2/notices", filter.query(), nil, nil, &notices); err != nil {
+		return nil, err
+	}
+	return notices, nil
+}
+
+// WaitNotices waits up to timeout for notices selected by the filter to
+// occur and returns them, it returns no notices if none occurred. To wait
+// for the notices which occur next, the After time of the filter should be
+// set to the LastRepeated time of the last notice returned, see also
+// WatchNotices.
+func (client *Client) WaitNotices(filter *NoticesFilter, timeout time.Duration) ([]*Notice, error) {
+	q := filter.query()
+	q.Set("timeout", timeout.String())
+	opts := &doOptions{
+		// leave time to the daemon to respond once the wait is over
+		Timeout: timeout + doTimeout,
+		Retry:   doRetry,
+	}
+	var notices []*Notice
+	if _, err := client.doSyncWithOpts("GET", "/v2/notices", q, nil, nil, &notices, opts); err != nil {
+		return nil, err
+	}
+	return notices, nil
+}
+
+var (
+	// noticesWatchTimeout is how long a single request waits for
+	// notices while watching them.
+	noticesWatchTimeout = 30 * time.Second
+	// noticesWatchRetry is how long to wait before trying again when
+	// snapd could not be reached while watching notices.
+	noticesWatchRetry = 5 * time.Second
 )
+
+// NoticeWatcher delivers notices as they occur, see WatchNotices.
+type NoticeWatcher struct {
+	// C delivers the notices, it is closed once watching stops.
+	C <-chan *Notice
+
+	err error
+}
+
+// Err returns the error that stopped watching, nil if it was stopped by
+// the context being done. It must only be called once C is closed.
+func (w *NoticeWatcher) Err() error {
+	return w.err
+}
+
+// WatchNotices delivers the notices selected by the filter as they occur,
+// until the context is done or an error other than failing to reach snapd
+// happens. A notice is delivered again each time it is repeated. If the
+// filter has no After time, notices which already occurred are delivered
+// first.
+func (client *Client) WatchNotices(ctx context.Context, filter *NoticesFilter) *NoticeWatcher {
+	ch := make(chan *Notice)
+	w := &NoticeWatcher{C: ch}
+
+	var f NoticesFilter
+	if filter != nil {
+		f = *filter
+	}
+	cli := client.WithContext(ctx)
+
+	go func() {
+		defer close(ch)
+		for {
+			notices, err := cli.WaitNotices(&f, noticesWatchTimeout)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				var connErr ConnectionError
+				if !errors.As(err, &connErr) {
+					w.err = err
+					return
+				}
+				select {
+				case <-time.After(noticesWatchRetry):
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			for _, n := range notices {
+				select {
+				case ch <- n:
+				case <-ctx.Done():
+					return
+				}
+				if n.LastRepeated.After(f.After) {
+					f.After = n.LastRepeated
+				}
+			}
+		}
+	}()
+
+	return w
+}
+
+// NoticeCursor persists the position of a notices consumer, which is the
+// LastRepeated time of the last notice it handled.
+type NoticeCursor interface {
+	// Load returns the saved position, or the zero time if none was
+	// saved yet.
+	Load() (time.Time, error)
+	// Save records the given position.
+	Save(t time.Time) error
+}
+
+type fileNoticeCursor struct {
+	path string
+}
+
+// FileNoticeCursor returns a NoticeCursor which saves the position in the
+// file at the given path.
+func FileNoticeCursor(path string) NoticeCursor {
+	return &fileNoticeCursor{path: path}
+}
+
+func (fc *fileNoticeCursor) Load() (time.Time, error) {
+	data, err := os.ReadFile(fc.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot read notice cursor: %v", err)
+	}
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse notice cursor: %v", err)
+	}
+	return t, nil
+}
+
+func (fc *fileNoticeCursor) Save(t time.Time) error {
+	data := []byte(t.Format(time.RFC3339Nano) + "\n")
+	if err := osutil.AtomicWriteFile(fc.path, data, 0644, 0); err != nil {
+		return fmt.Errorf("cannot save notice cursor: %v", err)
+	}
+	return nil
+}
+
+// ConsumeNotices calls handle for each notice selected by the filter as it
+// occurs, resuming after the position saved by the cursor, which is
+// advanced once handle returns successfully. Notices are thus handled at
+// least once across restarts of the consumer: if handle fails, or the
+// consumer stops before the cursor is saved, the notice is delivered again
+// the next time.
+//
+// Failing to reach snapd is retried, so ConsumeNotices only returns once
+// the context is done, with a nil error, or when handling a notice, saving
+// the cursor or watching notices fails.
+func (client *Client) ConsumeNotices(ctx context.Context, filter *NoticesFilter, cursor NoticeCursor, handle func(n *Notice) error) error {
+	var f NoticesFilter
+	if filter != nil {
+		f = *filter
+	}
+	after, err := cursor.Load()
+	if err != nil {
+		return err
+	}
+	if after.After(f.After) {
+		f.After = after
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := client.WatchNotices(ctx, &f)
+	stop := func(err error) error {
+		cancel()
+		for range w.C {
+		}
+		return err
+	}
+	for n := range w.C {
+		if err := handle(n); err != nil {
+			return stop(err)
+		}
+		if !n.LastRepeated.After(f.After) {
+			continue
+		}
+		f.After = n.LastRepeated
+		if err := cursor.Save(f.After); err != nil {
+			return stop(err)
+		}
+	}
+	return w.Err()
+}
@@ -20,8 +20,14 @@
 package client_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/snapcore/snapd/client"
 	. "gopkg.in/check.v1"
@@ -48,3 +54,213 @@ func (cs *clientSuite) TestNotify(c *C) {
 		"key":    "snap-name",
 	})
 }
+
+const noticesJSON = `{"type": "sync", "result": [{
+	"id": "1",
+	"user-id": null,
+	"type": "change-update",
+	"key": "42",
+	"first-occurred": "2024-01-02T03:04:05Z",
+	"last-occurred": "2024-01-02T03:04:06Z",
+	"last-repeated": "2024-01-02T03:04:06Z",
+	"occurrences": 2,
+	"last-data": {"kind": "install-snap"},
+	"expire-after": "168h0m0s"
+}, {
+	"id": "2",
+	"user-id": 1000,
+	"type": "warning",
+	"key": "danger",
+	"first-occurred": "2024-01-02T03:04:07Z",
+	"last-occurred": "2024-01-02T03:04:07Z",
+	"last-repeated": "2024-01-02T03:04:07.5Z",
+	"occurrences": 1,
+	"repeat-after": "24h0m0s",
+	"expire-after": "672h0m0s"
+}]}`
+
+func (cs *clientSuite) TestNotices(c *C) {
+	cs.rsp = noticesJSON
+	uid := uint32(1000)
+	notices, err := cs.cli.Notices(&client.NoticesFilter{
+		Types:  []client.NoticeType{client.ChangeUpdateNotice, client.WarningNotice},
+		Keys:   []string{"42", "danger"},
+		After:  time.Date(2024, 1, 2, 3, 4, 0, 500, time.UTC),
+		UserID: &uid,
+	})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/notices")
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{
+		"types":   {"change-update,warning"},
+		"keys":    {"42,danger"},
+		"after":   {"2024-01-02T03:04:00.0000005Z"},
+		"user-id": {"1000"},
+	})
+
+	c.Assert(notices, HasLen, 2)
+	c.Check(notices[0], DeepEquals, &client.Notice{
+		ID:            "1",
+		Type:          client.ChangeUpdateNotice,
+		Key:           "42",
+		FirstOccurred: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		LastOccurred:  time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC),
+		LastRepeated:  time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC),
+		Occurrences:   2,
+		LastData:      map[string]string{"kind": "install-snap"},
+		ExpireAfter:   7 * 24 * time.Hour,
+	})
+	c.Check(*notices[1].UserID, Equals, uint32(1000))
+	c.Check(notices[1].RepeatAfter, Equals, 24*time.Hour)
+	c.Check(notices[1].ExpireAfter, Equals, 28*24*time.Hour)
+}
+
+func (cs *clientSuite) TestNoticesNoFilter(c *C) {
+	cs.rsp = `{"type": "sync", "result": []}`
+	notices, err := cs.cli.Notices(nil)
+	c.Assert(err, IsNil)
+	c.Check(notices, HasLen, 0)
+	c.Check(cs.req.URL.RawQuery, Equals, "")
+
+	_, err = cs.cli.Notices(&client.NoticesFilter{AllUsers: true})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.URL.RawQuery, Equals, "users=all")
+}
+
+func (cs *clientSuite) TestNoticesInvalidDuration(c *C) {
+	cs.rsp = `{"type": "sync", "result": [{"id": "1", "type": "warning", "repeat-after": "soon"}]}`
+	_, err := cs.cli.Notices(nil)
+	c.Assert(err, ErrorMatches, `.*invalid repeat-after duration: .*`)
+}
+
+func (cs *clientSuite) TestWatchNotices(c *C) {
+	defer client.MockNoticesWatchTimings(time.Minute, time.Millisecond)()
+
+	cs.rsps = []string{
+		noticesJSON,
+		`{"type": "sync", "result": []}`,
+		`{"type": "error", "status-code": 403, "result": {"message": "snap cannot access specified notice types"}}`,
+	}
+	cs.statuses = []int{200, 200, 403}
+
+	w := cs.cli.WatchNotices(context.Background(), &client.NoticesFilter{
+		Types: []client.NoticeType{client.ChangeUpdateNotice, client.WarningNotice},
+	})
+	var ids []string
+	for n := range w.C {
+		ids = append(ids, n.ID)
+	}
+	c.Check(ids, DeepEquals, []string{"1", "2"})
+	c.Check(w.Err(), ErrorMatches, "snap cannot access specified notice types")
+
+	c.Assert(cs.reqs, HasLen, 3)
+	c.Check(cs.reqs[0].URL.Query(), DeepEquals, url.Values{
+		"types":   {"change-update,warning"},
+		"timeout": {"1m0s"},
+	})
+	// the following requests are for notices repeated after the last
+	// one seen
+	for _, req := range cs.reqs[1:] {
+		c.Check(req.URL.Query(), DeepEquals, url.Values{
+			"types":   {"change-update,warning"},
+			"after":   {"2024-01-02T03:04:07.5Z"},
+			"timeout": {"1m0s"},
+		})
+	}
+}
+
+func (cs *clientSuite) TestWatchNoticesCanceled(c *C) {
+	cs.rsp = noticesJSON
+	ctx, cancel := context.WithCancel(context.Background())
+	w := cs.cli.WatchNotices(ctx, nil)
+
+	n := <-w.C
+	c.Check(n.ID, Equals, "1")
+	cancel()
+	for range w.C {
+	}
+	c.Check(w.Err(), IsNil)
+}
+
+func (cs *clientSuite) TestWaitNotices(c *C) {
+	cs.rsp = noticesJSON
+	notices, err := cs.cli.WaitNotices(&client.NoticesFilter{
+		Types: []client.NoticeType{client.WarningNotice},
+		Keys:  []string{"danger"},
+	}, 10*time.Second)
+	c.Assert(err, IsNil)
+	c.Check(notices, HasLen, 2)
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{
+		"types":   {"warning"},
+		"keys":    {"danger"},
+		"timeout": {"10s"},
+	})
+}
+
+func (cs *clientSuite) TestConsumeNotices(c *C) {
+	defer client.MockNoticesWatchTimings(time.Minute, time.Millisecond)()
+
+	cursorPath := filepath.Join(c.MkDir(), "cursor")
+	c.Assert(os.WriteFile(cursorPath, []byte("2024-01-01T00:00:00Z\n"), 0644), IsNil)
+	cursor := client.FileNoticeCursor(cursorPath)
+
+	cs.rsps = []string{
+		noticesJSON,
+		`{"type": "error", "status-code": 403, "result": {"message": "snap cannot access specified notice types"}}`,
+	}
+	cs.statuses = []int{200, 403}
+
+	var ids []string
+	err := cs.cli.ConsumeNotices(context.Background(), nil, cursor, func(n *client.Notice) error {
+		ids = append(ids, n.ID)
+		return nil
+	})
+	c.Check(err, ErrorMatches, "snap cannot access specified notice types")
+	c.Check(ids, DeepEquals, []string{"1", "2"})
+
+	// consumption resumed after the saved position
+	c.Assert(cs.reqs, HasLen, 2)
+	c.Check(cs.reqs[0].URL.Query().Get("after"), Equals, "2024-01-01T00:00:00Z")
+	c.Check(cs.reqs[1].URL.Query().Get("after"), Equals, "2024-01-02T03:04:07.5Z")
+
+	// and the position of the last handled notice was saved
+	t, err := cursor.Load()
+	c.Assert(err, IsNil)
+	c.Check(t.Equal(time.Date(2024, 1, 2, 3, 4, 7, 500000000, time.UTC)), Equals, true)
+}
+
+func (cs *clientSuite) TestConsumeNoticesHandleError(c *C) {
+	cs.rsp = noticesJSON
+	cursor := client.FileNoticeCursor(filepath.Join(c.MkDir(), "cursor"))
+
+	err := cs.cli.ConsumeNotices(context.Background(), nil, cursor, func(n *client.Notice) error {
+		if n.ID == "2" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	c.Check(err, ErrorMatches, "boom")
+
+	// only the position of the notice handled successfully was saved
+	t, err := cursor.Load()
+	c.Assert(err, IsNil)
+	c.Check(t.Equal(time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC)), Equals, true)
+}
+
+func (cs *clientSuite) TestFileNoticeCursorErrors(c *C) {
+	cursorPath := filepath.Join(c.MkDir(), "cursor")
+	cursor := client.FileNoticeCursor(cursorPath)
+	t, err := cursor.Load()
+	c.Assert(err, IsNil)
+	c.Check(t.IsZero(), Equals, true)
+
+	c.Assert(os.WriteFile(cursorPath, []byte("yesterday"), 0644), IsNil)
+	_, err = cursor.Load()
+	c.Check(err, ErrorMatches, `cannot parse notice cursor: .*`)
+
+	err = client.FileNoticeCursor(filepath.Join(cursorPath, "sub")).Save(time.Now())
+	c.Check(err, ErrorMatches, `cannot save notice cursor: .*`)
+
+	err = cs.cli.ConsumeNotices(context.Background(), nil, cursor, func(n *client.Notice) error { return nil })
+	c.Check(err, ErrorMatches, `cannot parse notice cursor: .*`)
+}
@@ -0,0 +1,283 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package ops offers composite snap operations built on top of the
+// client package. Each operation checks the current state of the system,
+// performs the action needed to reach the requested one, if any, and
+// waits for it to complete.
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap/channel"
+)
+
+// Action is the action performed by an operation.
+type Action string
+
+const (
+	// ActionNone is reported when the system was already in the
+	// requested state.
+	ActionNone    Action = ""
+	ActionInstall Action = "install"
+	ActionRefresh Action = "refresh"
+	ActionRemove  Action = "remove"
+)
+
+// Result is the outcome of an operation.
+type Result struct {
+	// Action is the action that was performed.
+	Action Action
+	// Change is the change that performed the action, nil if no
+	// action was needed.
+	Change *client.Change
+	// Snap is the snap after the operation, nil if it is not
+	// installed anymore.
+	Snap *client.Snap
+}
+
+// ChangeError is returned when the change performing an operation
+// did not succeed.
+type ChangeError struct {
+	Change *client.Change
+}
+
+func (e *ChangeError) Error() string {
+	return fmt.Sprintf("change %s (%s) failed: %s", e.Change.ID, e.Change.Kind, e.Change.Err)
+}
+
+const (
+	// defaultWaitTimeout is how long a single request waits for a
+	// change to be ready, the progress is reported between requests.
+	defaultWaitTimeout = 10 * time.Second
+	// defaultPollInterval is the minimum interval between two requests
+	// for the status of a change, for daemons that do not support
+	// waiting for changes.
+	defaultPollInterval = 250 * time.Millisecond
+)
+
+// Ops performs composite operations using a client.
+type Ops struct {
+	cli *client.Client
+
+	// WaitTimeout is how long a single request waits for a change to
+	// be ready.
+	WaitTimeout time.Duration
+	// PollInterval is the minimum interval between two requests for
+	// the status of a change.
+	PollInterval time.Duration
+	// Progress, if set, is called with the progress of the change
+	// being waited for each time its status is received.
+	Progress client.ProgressFunc
+}
+
+// New returns an Ops performing operations with the given client.
+func New(cli *client.Client) *Ops {
+	return &Ops{
+		cli:          cli,
+		WaitTimeout:  defaultWaitTimeout,
+		PollInterval: defaultPollInterval,
+	}
+}
+
+func isErrorKind(err error, kind client.ErrorKind) bool {
+	var e *client.Error
+	return errors.As(err, &e) && e.Kind == kind
+}
+
+// installedSnap returns the installed snap with the given name, or nil if
+// it is not installed.
+func (o *Ops) installedSnap(ctx context.Context, name string) (*client.Snap, error) {
+	snap, _, err := o.cli.WithContext(ctx).Snap(name)
+	if isErrorKind(err, client.ErrorKindSnapNotInstalled) {
+		return nil, nil
+	}
+	return snap, err
+}
+
+// needsChannelSwitch returns whether a snap tracking the given channel
+// needs to be switched to the requested one. Risk-only requests keep
+// the tracked track, as snapd does.
+func needsChannelSwitch(tracking, requested string) (bool, error) {
+	if requested == "" {
+		return false, nil
+	}
+	resolved, err := channel.Resolve(tracking, requested)
+	if err != nil {
+		return false, err
+	}
+	want, err := channel.Full(resolved)
+	if err != nil {
+		return false, err
+	}
+	have, err := channel.Full(tracking)
+	if err != nil {
+		return false, err
+	}
+	return want != have, nil
+}
+
+// EnsureInstalled makes sure the snap with the given name is installed
+// and, if channel is not empty, that it tracks the given channel.
+func (o *Ops) EnsureInstalled(ctx context.Context, name, channel string) (*Result, error) {
+	snap, err := o.installedSnap(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &client.SnapOptions{Channel: channel}
+	cli := o.cli.WithContext(ctx)
+	var action Action
+	var changeID string
+	switch {
+	case snap == nil:
+		action = ActionInstall
+		changeID, err = cli.Install(name, nil, opts)
+	default:
+		var switchNeeded bool
+		switchNeeded, err = needsChannelSwitch(snap.TrackingChannel, channel)
+		if err != nil {
+			return nil, fmt.Errorf("cannot ensure snap %q is installed: %v", name, err)
+		}
+		if !switchNeeded {
+			return &Result{Action: ActionNone, Snap: snap}, nil
+		}
+		action = ActionRefresh
+		changeID, err = cli.Refresh(name, nil, opts)
+		if isErrorKind(err, client.ErrorKindSnapNoUpdateAvailable) {
+			// tracking a new channel does not need an update
+			changeID, err = cli.Switch(name, opts)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return o.complete(ctx, name, action, changeID)
+}
+
+// EnsureRefreshed makes sure the snap with the given name is installed and
+// up to date, tracking the given channel if not empty.
+func (o *Ops) EnsureRefreshed(ctx context.Context, name, channel string) (*Result, error) {
+	snap, err := o.installedSnap(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return o.EnsureInstalled(ctx, name, channel)
+	}
+
+	changeID, err := o.cli.WithContext(ctx).Refresh(name, nil, &client.SnapOptions{Channel: channel})
+	if isErrorKind(err, client.ErrorKindSnapNoUpdateAvailable) {
+		return &Result{Action: ActionNone, Snap: snap}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return o.complete(ctx, name, ActionRefresh, changeID)
+}
+
+// EnsureRemoved makes sure the snap with the given name is not installed.
+func (o *Ops) EnsureRemoved(ctx context.Context, name string) (*Result, error) {
+	snap, err := o.installedSnap(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return &Result{Action: ActionNone}, nil
+	}
+
+	changeID, err := o.cli.WithContext(ctx).Remove(name, nil, nil)
+	if isErrorKind(err, client.ErrorKindSnapNotInstalled) {
+		return &Result{Action: ActionNone}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chg, err := o.Wait(ctx, changeID)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Action: ActionRemove, Change: chg}, nil
+}
+
+// complete waits for the change performing the given action on the snap
+// and returns the result of the operation.
+func (o *Ops) complete(ctx context.Context, name string, action Action, changeID string) (*Result, error) {
+	chg, err := o.Wait(ctx, changeID)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := o.installedSnap(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Action: action, Change: chg, Snap: snap}, nil
+}
+
+// Wait waits for the change with the given id to be ready. It returns a
+// *ChangeError if the change did not succeed.
+func (o *Ops) Wait(ctx context.Context, changeID string) (*client.Change, error) {
+	cli := o.cli.WithContext(ctx)
+	timeout := o.WaitTimeout
+	if timeout <= 0 {
+		timeout = defaultWaitTimeout
+	}
+	interval := o.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		chg, err := cli.WaitChange(changeID, timeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if o.Progress != nil {
+			if p, ok := chg.Progress(); ok {
+				o.Progress(p)
+			}
+		}
+		if chg.Ready {
+			if chg.Err != "" {
+				return chg, &ChangeError{Change: chg}
+			}
+			return chg, nil
+		}
+
+		// do not hammer daemons which return without waiting
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
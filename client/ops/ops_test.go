@@ -0,0 +1,297 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ops_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/client/ops"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type opsSuite struct {
+	srv *httptest.Server
+	ops *ops.Ops
+
+	// snap is the JSON of the installed snap, or empty if not installed
+	snap string
+	// opRsp and opStatus are the response to snap operations
+	opRsp    string
+	opStatus int
+	// changes are the consecutive responses to change requests
+	changes []string
+
+	reqs  []string
+	posts []string
+}
+
+var _ = check.Suite(&opsSuite{})
+
+const notInstalled = `{"type": "error", "status-code": 404, "result": {"message": "snap not installed", "kind": "snap-not-installed"}}`
+
+func (s *opsSuite) SetUpTest(c *check.C) {
+	s.snap = ""
+	s.opRsp = `{"type": "async", "status-code": 202, "change": "42"}`
+	s.opStatus = 202
+	s.changes = nil
+	s.reqs = nil
+	s.posts = nil
+
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.reqs = append(s.reqs, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/v2/snaps/foo" && r.Method == "GET":
+			if s.snap == "" {
+				w.WriteHeader(404)
+				fmt.Fprint(w, notInstalled)
+				return
+			}
+			fmt.Fprintf(w, `{"type": "sync", "result": %s}`, s.snap)
+		case r.URL.Path == "/v2/snaps/foo" && r.Method == "POST":
+			body, err := io.ReadAll(r.Body)
+			c.Assert(err, check.IsNil)
+			s.posts = append(s.posts, string(body))
+			w.WriteHeader(s.opStatus)
+			fmt.Fprint(w, s.opRsp)
+		case r.URL.Path == "/v2/changes/42":
+			c.Check(r.URL.Query().Get("wait"), check.Equals, "true")
+			c.Check(r.URL.Query().Get("timeout"), check.Equals, "10s")
+			c.Assert(s.changes, check.Not(check.HasLen), 0)
+			rsp := s.changes[0]
+			if len(s.changes) > 1 {
+				s.changes = s.changes[1:]
+			}
+			fmt.Fprintf(w, `{"type": "sync", "result": %s}`, rsp)
+		default:
+			c.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	s.ops = ops.New(client.New(&client.Config{BaseURL: s.srv.URL}))
+	s.ops.PollInterval = time.Millisecond
+}
+
+func (s *opsSuite) TearDownTest(c *check.C) {
+	s.srv.Close()
+}
+
+const (
+	chgDoing = `{"id": "42", "kind": "install-snap", "status": "Doing", "ready": false}`
+	chgDone  = `{"id": "42", "kind": "install-snap", "status": "Done", "ready": true}`
+	chgError = `{"id": "42", "kind": "install-snap", "status": "Error", "ready": true, "err": "boom"}`
+)
+
+func (s *opsSuite) TestEnsureInstalledInstalls(c *check.C) {
+	s.changes = []string{chgDoing, chgDone}
+	s.srv.Config.Handler = s.installingHandler(s.srv.Config.Handler)
+
+	res, err := s.ops.EnsureInstalled(context.Background(), "foo", "beta")
+	c.Assert(err, check.IsNil)
+	c.Check(res.Action, check.Equals, ops.ActionInstall)
+	c.Check(res.Change.ID, check.Equals, "42")
+	c.Check(res.Change.Status, check.Equals, "Done")
+	c.Assert(res.Snap, check.NotNil)
+	c.Check(res.Snap.TrackingChannel, check.Equals, "latest/beta")
+	c.Check(s.posts, check.DeepEquals, []string{`{"action":"install","channel":"beta"}`})
+	c.Check(s.reqs, check.DeepEquals, []string{
+		"GET /v2/snaps/foo",
+		"POST /v2/snaps/foo",
+		"GET /v2/changes/42",
+		"GET /v2/changes/42",
+		"GET /v2/snaps/foo",
+	})
+}
+
+// installingHandler makes the snap installed once an operation was posted.
+func (s *opsSuite) installingHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+		if r.Method == "POST" {
+			s.snap = `{"name": "foo", "tracking-channel": "latest/beta"}`
+		}
+	})
+}
+
+func (s *opsSuite) TestEnsureInstalledAlreadyInstalled(c *check.C) {
+	s.snap = `{"name": "foo", "tracking-channel": "latest/stable"}`
+
+	for _, ch := range []string{"", "stable", "latest/stable", "latest"} {
+		s.reqs = nil
+		res, err := s.ops.EnsureInstalled(context.Background(), "foo", ch)
+		c.Assert(err, check.IsNil, check.Commentf("channel %q", ch))
+		c.Check(res.Action, check.Equals, ops.ActionNone)
+		c.Check(res.Change, check.IsNil)
+		c.Check(res.Snap.Name, check.Equals, "foo")
+		c.Check(s.reqs, check.DeepEquals, []string{"GET /v2/snaps/foo"})
+	}
+	c.Check(s.posts, check.HasLen, 0)
+}
+
+func (s *opsSuite) TestEnsureInstalledSwitchesChannel(c *check.C) {
+	s.snap = `{"name": "foo", "tracking-channel": "2.0/stable"}`
+	s.changes = []string{chgDone}
+
+	// risk-only channels keep the tracked track
+	res, err := s.ops.EnsureInstalled(context.Background(), "foo", "stable")
+	c.Assert(err, check.IsNil)
+	c.Check(res.Action, check.Equals, ops.ActionNone)
+
+	res, err = s.ops.EnsureInstalled(context.Background(), "foo", "2.0/edge")
+	c.Assert(err, check.IsNil)
+	c.Check(res.Action, check.Equals, ops.ActionRefresh)
+	c.Check(res.Change.ID, check.Equals, "42")
+	c.Check(s.posts, check.DeepEquals, []string{`{"action":"refresh","channel":"2.0/edge"}`})
+}
+
+func (s *opsSuite) TestEnsureInstalledSwitchesWithoutUpdate(c *check.C) {
+	s.snap = `{"name": "foo", "tracking-channel": "latest/stable"}`
+	s.changes = []string{chgDone}
+	noUpdate := `{"type": "error", "status-code": 400, "result": {"message": "no update", "kind": "snap-no-update-available"}}`
+	s.srv.Config.Handler = s.firstOpHandler(s.srv.Config.Handler, noUpdate)
+
+	res, err := s.ops.EnsureInstalled(context.Background(), "foo", "edge")
+	c.Assert(err, check.IsNil)
+	c.Check(res.Action, check.Equals, ops.ActionRefresh)
+	c.Check(s.posts, check.DeepEquals, []string{
+		`{"action":"refresh","channel":"edge"}`,
+		`{"action":"switch","channel":"edge"}`,
+	})
+}
+
+// firstOpHandler responds to the first operation with rsp.
+func (s *opsSuite) firstOpHandler(h http.Handler, rsp string) http.Handler {
+	first := true
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if first && r.Method == "POST" {
+			first = false
+			body, _ := io.ReadAll(r.Body)
+			s.posts = append(s.posts, string(body))
+			w.WriteHeader(400)
+			fmt.Fprint(w, rsp)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (s *opsSuite) TestEnsureInstalledChangeError(c *check.C) {
+	s.changes = []string{chgError}
+
+	res, err := s.ops.EnsureInstalled(context.Background(), "foo", "")
+	c.Assert(err, check.ErrorMatches, `change 42 \(install-snap\) failed: boom`)
+	c.Check(res, check.IsNil)
+	var chgErr *ops.ChangeError
+	c.Assert(errors.As(err, &chgErr), check.Equals, true)
+	c.Check(chgErr.Change.Status, check.Equals, "Error")
+}
+
+func (s *opsSuite) TestEnsureInstalledOpError(c *check.C) {
+	s.opRsp = `{"type": "error", "status-code": 400, "result": {"message": "snap not found", "kind": "snap-not-found"}}`
+	s.opStatus = 400
+
+	_, err := s.ops.EnsureInstalled(context.Background(), "foo", "")
+	c.Assert(err, check.ErrorMatches, "snap not found")
+	var cliErr *client.Error
+	c.Assert(errors.As(err, &cliErr), check.Equals, true)
+	c.Check(cliErr.Kind, check.Equals, client.ErrorKindSnapNotFound)
+}
+
+func (s *opsSuite) TestEnsureRefreshed(c *check.C) {
+	s.snap = `{"name": "foo", "tracking-channel": "latest/stable"}`
+	s.changes = []string{chgDone}
+
+	res, err := s.ops.EnsureRefreshed(context.Background(), "foo", "")
+	c.Assert(err, check.IsNil)
+	c.Check(res.Action, check.Equals, ops.ActionRefresh)
+	c.Check(res.Snap.Name, check.Equals, "foo")
+	c.Check(s.posts, check.DeepEquals, []string{`{"action":"refresh"}`})
+}
+
+func (s *opsSuite) TestEnsureRefreshedNoUpdate(c *check.C) {
+	s.snap = `{"name": "foo", "tracking-channel": "latest/stable"}`
+	s.opRsp = `{"type": "error", "status-code": 400, "result": {"message": "no update", "kind": "snap-no-update-available"}}`
+	s.opStatus = 400
+
+	res, err := s.ops.EnsureRefreshed(context.Background(), "foo", "")
+	c.Assert(err, check.IsNil)
+	c.Check(res.Action, check.Equals, ops.ActionNone)
+	c.Check(res.Change, check.IsNil)
+	c.Check(res.Snap.Name, check.Equals, "foo")
+}
+
+func (s *opsSuite) TestEnsureRemoved(c *check.C) {
+	s.snap = `{"name": "foo", "tracking-channel": "latest/stable"}`
+	s.changes = []string{chgDone}
+
+	res, err := s.ops.EnsureRemoved(context.Background(), "foo")
+	c.Assert(err, check.IsNil)
+	c.Check(res.Action, check.Equals, ops.ActionRemove)
+	c.Check(res.Change.ID, check.Equals, "42")
+	c.Check(res.Snap, check.IsNil)
+	c.Check(s.posts, check.DeepEquals, []string{`{"action":"remove"}`})
+}
+
+func (s *opsSuite) TestEnsureRemovedNotInstalled(c *check.C) {
+	res, err := s.ops.EnsureRemoved(context.Background(), "foo")
+	c.Assert(err, check.IsNil)
+	c.Check(res.Action, check.Equals, ops.ActionNone)
+	c.Check(s.reqs, check.DeepEquals, []string{"GET /v2/snaps/foo"})
+}
+
+func (s *opsSuite) TestWaitCanceled(c *check.C) {
+	s.changes = []string{chgDoing}
+	s.ops.PollInterval = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	chg, err := s.ops.Wait(ctx, "42")
+	c.Check(err, check.Equals, context.DeadlineExceeded)
+	c.Check(chg, check.IsNil)
+}
+
+func (s *opsSuite) TestWaitProgress(c *check.C) {
+	s.changes = []string{
+		`{"id": "42", "kind": "install-snap", "status": "Doing", "ready": false, "tasks": [{"summary": "Download snap \"foo\"", "status": "Doing", "progress": {"label": "foo", "done": 10, "total": 100}}]}`,
+		`{"id": "42", "kind": "install-snap", "status": "Doing", "ready": false, "tasks": [{"summary": "Download snap \"foo\"", "status": "Done", "progress": {"label": "foo", "done": 100, "total": 100}}, {"summary": "Mount snap \"foo\"", "status": "Doing", "progress": {"done": 0, "total": 1}}]}`,
+		chgDone,
+	}
+	var reports []client.Progress
+	s.ops.Progress = func(p client.Progress) {
+		reports = append(reports, p)
+	}
+
+	chg, err := s.ops.Wait(context.Background(), "42")
+	c.Assert(err, check.IsNil)
+	c.Check(chg.Status, check.Equals, "Done")
+	c.Check(reports, check.DeepEquals, []client.Progress{
+		{Summary: `Download snap "foo"`, Label: "foo", Done: 10, Total: 100},
+		{Summary: `Mount snap "foo"`, Total: 1},
+	})
+}
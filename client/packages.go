@@ -93,6 +93,13 @@ type Snap struct {
 	RefreshInhibit *SnapRefreshInhibit `json:"refresh-inhibit,omitempty"`
 	// RefreshFailures tracks information about snap failed refreshes.
 	RefreshFailures *snap.RefreshFailuresInfo `json:"refresh-failures,omitempty"`
+	// RefreshClassification is set for available updates and is either
+	// "security" or "feature", see the refresh.policy option.
+	RefreshClassification string `json:"refresh-classification,omitempty"`
+
+	// NameResolution is set if the snap does not use the name resolution
+	// settings of the system, see the name-resolution.<snap> options.
+	NameResolution *SnapNameResolution `json:"name-resolution,omitempty"`
 
 	// Components is a list of the snap components
 	Components []Component `json:"components,omitempty"`
@@ -115,6 +122,14 @@ type SnapRefreshInhibit struct {
 	ProceedTime time.Time `json:"proceed-time"`
 }
 
+// SnapNameResolution holds the custom name resolution settings of a snap.
+type SnapNameResolution struct {
+	// Nameservers replace the DNS servers of the system.
+	Nameservers []string `json:"nameservers,omitempty"`
+	// Hosts maps extra host names to their address.
+	Hosts map[string]string `json:"hosts,omitempty"`
+}
+
 // Statuses and types a snap may have.
 const (
 	StatusAvailable = "available"
@@ -135,6 +150,9 @@ const (
 
 type ResultInfo struct {
 	SuggestedCurrency string `json:"suggested-currency"`
+	// ResourceVersion is the version of the returned resource, for mutable
+	// resources, which can be passed back via If-Match when modifying it.
+	ResourceVersion string `json:"resource-version"`
 }
 
 // FindOptions supports exactly one of the following options:
@@ -299,3 +317,21 @@ func (client *Client) Snap(name string) (*Snap, *ResultInfo, error) {
 	}
 	return snap, ri, nil
 }
+
+// Health returns the health of the given installed snap, as last set by
+// its check-health hook. If the snap never set its health the returned
+// status is "unknown".
+func (client *Client) Health(snapName string) (*SnapHealth, error) {
+	snap, _, err := client.Snap(snapName)
+	if err != nil {
+		return nil, err
+	}
+	if snap.Health == nil {
+		return &SnapHealth{
+			Revision: snap.Revision,
+			Status:   "unknown",
+			Message:  "health has not been set",
+		}, nil
+	}
+	return snap.Health, nil
+}
@@ -374,6 +374,54 @@ func (cs *clientSuite) TestClientSnap(c *check.C) {
 	cs.testClientSnap(c, refreshInhibited)
 }
 
+func (cs *clientSuite) TestClientHealth(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"name": "foo",
+			"revision": "29",
+			"health": {
+				"revision": "29",
+				"timestamp": "2019-05-13T16:27:01.475851677+01:00",
+				"status": "blocked",
+				"message": "waiting for the network",
+				"code": "no-network"
+			}
+		}
+	}`
+	health, err := cs.cli.Health("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo")
+	timestamp, err := time.Parse(time.RFC3339Nano, "2019-05-13T16:27:01.475851677+01:00")
+	c.Assert(err, check.IsNil)
+	c.Check(health, check.DeepEquals, &client.SnapHealth{
+		Revision:  snap.R(29),
+		Timestamp: timestamp,
+		Status:    "blocked",
+		Message:   "waiting for the network",
+		Code:      "no-network",
+	})
+}
+
+func (cs *clientSuite) TestClientHealthUnset(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": {"name": "foo", "revision": "29"}}`
+	health, err := cs.cli.Health("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(health, check.DeepEquals, &client.SnapHealth{
+		Revision: snap.R(29),
+		Status:   "unknown",
+		Message:  "health has not been set",
+	})
+}
+
+func (cs *clientSuite) TestClientHealthError(c *check.C) {
+	cs.status = 404
+	cs.rsp = `{"type": "error", "status-code": 404, "result": {"message": "snap not installed", "kind": "snap-not-found", "value": "foo"}}`
+	_, err := cs.cli.Health("foo")
+	c.Check(err, check.ErrorMatches, `cannot retrieve snap "foo": snap not installed`)
+}
+
 func (cs *clientSuite) TestClientSnapRefreshInhibited(c *check.C) {
 	const refreshInhibited = true
 	cs.testClientSnap(c, refreshInhibited)
@@ -0,0 +1,101 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"io"
+	"time"
+)
+
+// Progress describes how far along an operation is.
+type Progress struct {
+	// Summary describes what is currently being done.
+	Summary string
+	// Label is the label of the current progress, if any.
+	Label string
+	// Done and Total are the units of work done and to be done, bytes
+	// for downloads. Total is 0 if unknown.
+	Done  int64
+	Total int64
+}
+
+// ProgressFunc is called periodically with the progress of an operation.
+type ProgressFunc func(Progress)
+
+// Progress returns the progress of the task of the change currently being
+// done, or undone, and whether there is one. Of several such tasks, the
+// first one reporting a total is preferred.
+func (c *Change) Progress() (Progress, bool) {
+	var current *Task
+	for _, t := range c.Tasks {
+		if t.Status != "Doing" && t.Status != "Undoing" {
+			continue
+		}
+		if current == nil || (current.Progress.Total <= 1 && t.Progress.Total > 1) {
+			current = t
+		}
+	}
+	if current == nil {
+		return Progress{}, false
+	}
+	return Progress{
+		Summary: current.Summary,
+		Label:   current.Progress.Label,
+		Done:    int64(current.Progress.Done),
+		Total:   int64(current.Progress.Total),
+	}, true
+}
+
+// downloadProgressInterval is the minimum interval between progress
+// reports while downloading.
+var downloadProgressInterval = 200 * time.Millisecond
+
+// progressReader reports the progress of reading from the wrapped
+// reader.
+type progressReader struct {
+	io.ReadCloser
+
+	progress Progress
+	report   ProgressFunc
+	last     time.Time
+}
+
+func newProgressReader(rc io.ReadCloser, summary string, done, total int64, report ProgressFunc) *progressReader {
+	return &progressReader{
+		ReadCloser: rc,
+		progress: Progress{
+			Summary: summary,
+			Done:    done,
+			Total:   total,
+		},
+		report: report,
+	}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.progress.Done += int64(n)
+	now := time.Now()
+	if err == io.EOF || (n > 0 && now.Sub(r.last) >= downloadProgressInterval) {
+		r.last = now
+		r.report(r.progress)
+	}
+	return n, err
+}
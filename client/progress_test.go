@@ -0,0 +1,128 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"io"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestChangeProgress(c *check.C) {
+	chg := &client.Change{Tasks: []*client.Task{
+		{Summary: "done", Status: "Done", Progress: client.TaskProgress{Done: 1, Total: 1}},
+		{Summary: "mount", Status: "Doing", Progress: client.TaskProgress{Done: 0, Total: 1}},
+		{Summary: "download", Status: "Doing", Progress: client.TaskProgress{Label: "foo", Done: 512, Total: 2048}},
+		{Summary: "waiting", Status: "Do", Progress: client.TaskProgress{Done: 0, Total: 4096}},
+	}}
+	p, ok := chg.Progress()
+	c.Assert(ok, check.Equals, true)
+	c.Check(p, check.DeepEquals, client.Progress{
+		Summary: "download",
+		Label:   "foo",
+		Done:    512,
+		Total:   2048,
+	})
+
+	chg.Tasks[2].Status = "Done"
+	p, ok = chg.Progress()
+	c.Assert(ok, check.Equals, true)
+	c.Check(p.Summary, check.Equals, "mount")
+
+	chg.Tasks[1].Status = "Undoing"
+	p, ok = chg.Progress()
+	c.Assert(ok, check.Equals, true)
+	c.Check(p.Summary, check.Equals, "mount")
+
+	chg.Tasks[1].Status = "Undone"
+	_, ok = chg.Progress()
+	c.Check(ok, check.Equals, false)
+}
+
+func (cs *clientSuite) TestClientOpDownloadProgress(c *check.C) {
+	defer client.MockDownloadProgressInterval(0)()
+
+	cs.status = 200
+	cs.header = http.Header{
+		"Content-Disposition": {"attachment; filename=foo_2.snap"},
+	}
+	cs.rsp = `lots-of-foo-data`
+	cs.contentLength = int64(len(cs.rsp))
+
+	var reports []client.Progress
+	_, rc, err := cs.cli.Download("foo", &client.DownloadOptions{
+		Progress: func(p client.Progress) {
+			reports = append(reports, p)
+		},
+	})
+	c.Assert(err, check.IsNil)
+
+	buf := make([]byte, 10)
+	n, err := io.ReadFull(rc, buf)
+	c.Assert(err, check.IsNil)
+	c.Check(n, check.Equals, 10)
+	rest, err := io.ReadAll(rc)
+	c.Assert(err, check.IsNil)
+	c.Check(string(buf)+string(rest), check.Equals, cs.rsp)
+	c.Check(rc.Close(), check.IsNil)
+
+	c.Assert(len(reports) >= 2, check.Equals, true)
+	c.Check(reports[0], check.DeepEquals, client.Progress{
+		Summary: `Download snap "foo"`,
+		Done:    10,
+		Total:   16,
+	})
+	c.Check(reports[len(reports)-1], check.DeepEquals, client.Progress{
+		Summary: `Download snap "foo"`,
+		Done:    16,
+		Total:   16,
+	})
+}
+
+func (cs *clientSuite) TestClientOpDownloadProgressResume(c *check.C) {
+	cs.status = 200
+	cs.header = http.Header{
+		"Content-Disposition": {"attachment; filename=foo_2.snap"},
+	}
+	cs.rsp = `lots-of-foo-data`
+	cs.contentLength = int64(len(cs.rsp))
+
+	var reports []client.Progress
+	_, rc, err := cs.cli.Download("foo", &client.DownloadOptions{
+		Resume: 64,
+		Progress: func(p client.Progress) {
+			reports = append(reports, p)
+		},
+	})
+	c.Assert(err, check.IsNil)
+	_, err = io.ReadAll(rc)
+	c.Assert(err, check.IsNil)
+
+	// the final report is always sent
+	c.Assert(len(reports) > 0, check.Equals, true)
+	c.Check(reports[len(reports)-1], check.DeepEquals, client.Progress{
+		Summary: `Download snap "foo"`,
+		Done:    64 + 16,
+		Total:   64 + 16,
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/snapcore/snapd/gadget/quantity"
@@ -45,6 +46,19 @@ type QuotaGroupResult struct {
 	Services    []string     `json:"services,omitempty"`
 	Constraints *QuotaValues `json:"constraints,omitempty"`
 	Current     *QuotaValues `json:"current,omitempty"`
+	Usage       *QuotaUsage  `json:"usage,omitempty"`
+	// Assignments maps the snaps that were placed in the group by default,
+	// rather than explicitly, to where the assignment comes from, e.g.
+	// "gadget".
+	Assignments map[string]string `json:"assignments,omitempty"`
+}
+
+// QuotaUsage holds the resources used by a quota group at the time it
+// was sampled, regardless of the limits set on the group.
+type QuotaUsage struct {
+	Memory  quantity.Size `json:"memory"`
+	CPUTime time.Duration `json:"cpu-time"`
+	Tasks   int           `json:"tasks"`
 }
 
 type QuotaCPUValues struct {
@@ -133,6 +147,63 @@ func (client *Client) GetQuotaGroup(groupName string) (*QuotaGroupResult, error)
 	return res, nil
 }
 
+// GetQuotaGroupWithUsage is like GetQuotaGroup but also samples the
+// live resource usage of the group.
+func (client *Client) GetQuotaGroupWithUsage(groupName string) (*QuotaGroupResult, error) {
+	if groupName == "" {
+		return nil, fmt.Errorf("cannot get quota group without a name")
+	}
+
+	var res *QuotaGroupResult
+	path := fmt.Sprintf("/v2/quotas/%s", groupName)
+	q := url.Values{"usage": []string{"live"}}
+	if _, err := client.doSync("GET", path, q, nil, nil, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// QuotaUsage samples the live resource usage of the quota group.
+func (client *Client) QuotaUsage(groupName string) (*QuotaUsage, error) {
+	grp, err := client.GetQuotaGroupWithUsage(groupName)
+	if err != nil {
+		return nil, err
+	}
+	if grp.Usage == nil {
+		// snapd too old to sample the usage of groups
+		return nil, fmt.Errorf("cannot get usage of quota group %q: not reported by snapd", groupName)
+	}
+	return grp.Usage, nil
+}
+
+// MoveSnapsToQuota moves the given snaps into the quota group, taking
+// them out of the groups they are currently in.
+func (client *Client) MoveSnapsToQuota(groupName string, snaps []string) (changeID string, err error) {
+	if groupName == "" {
+		return "", fmt.Errorf("cannot move snaps to quota group without a name")
+	}
+	if len(snaps) == 0 {
+		return "", fmt.Errorf("cannot move snaps to quota group without any snaps")
+	}
+	data := &postQuotaData{
+		Action:    "move",
+		GroupName: groupName,
+		Snaps:     snaps,
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(data); err != nil {
+		return "", err
+	}
+	chgID, err := client.doAsync("POST", "/v2/quotas", nil, nil, &body)
+	if err != nil {
+		return "", fmt.Errorf("cannot move snaps to quota group: %w", err)
+	}
+
+	return chgID, nil
+}
+
 func (client *Client) RemoveQuotaGroup(groupName string) (changeID string, err error) {
 	if groupName == "" {
 		return "", fmt.Errorf("cannot remove quota group without a name")
@@ -162,3 +233,15 @@ func (client *Client) Quotas() ([]*QuotaGroupResult, error) {
 
 	return res, nil
 }
+
+// QuotasWithUsage is like Quotas but also samples the live resource
+// usage of every group.
+func (client *Client) QuotasWithUsage() ([]*QuotaGroupResult, error) {
+	var res []*QuotaGroupResult
+	q := url.Values{"usage": []string{"live"}}
+	if _, err := client.doSync("GET", "/v2/quotas", q, nil, nil, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -192,3 +192,113 @@ func (cs *clientSuite) TestRemoveQuotaGroupError(c *check.C) {
 	_, err := cs.cli.RemoveQuotaGroup("foo")
 	c.Check(err, check.ErrorMatches, `cannot remove quota group: server error: "Internal Server Error"`)
 }
+
+func (cs *clientSuite) TestGetQuotaGroupWithUsage(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"group-name":"foo",
+			"snaps":["snap-a"],
+			"constraints": { "memory": 999 },
+			"current": { "memory": 450 },
+			"usage": { "memory": 450, "cpu-time": 2000000000, "tasks": 3 }
+		}
+	}`
+
+	grp, err := cs.cli.GetQuotaGroupWithUsage("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/quotas/foo")
+	c.Check(cs.req.URL.Query().Get("usage"), check.Equals, "live")
+	c.Check(grp.Usage, check.DeepEquals, &client.QuotaUsage{
+		Memory:  quantity.Size(450),
+		CPUTime: 2 * time.Second,
+		Tasks:   3,
+	})
+}
+
+func (cs *clientSuite) TestQuotaUsage(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"group-name":"foo",
+			"usage": { "memory": 450, "cpu-time": 2000000000, "tasks": 3 }
+		}
+	}`
+
+	usage, err := cs.cli.QuotaUsage("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/quotas/foo")
+	c.Check(cs.req.URL.Query().Get("usage"), check.Equals, "live")
+	c.Check(usage, check.DeepEquals, &client.QuotaUsage{
+		Memory:  quantity.Size(450),
+		CPUTime: 2 * time.Second,
+		Tasks:   3,
+	})
+}
+
+func (cs *clientSuite) TestQuotaUsageNotReported(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": {"group-name":"foo"}}`
+
+	_, err := cs.cli.QuotaUsage("foo")
+	c.Check(err, check.ErrorMatches, `cannot get usage of quota group "foo": not reported by snapd`)
+
+	_, err = cs.cli.QuotaUsage("")
+	c.Check(err, check.ErrorMatches, "cannot get quota group without a name")
+}
+
+func (cs *clientSuite) TestQuotasWithUsage(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": [{
+			"group-name":"foo",
+			"usage": { "memory": 450, "cpu-time": 1000, "tasks": 1 }
+		}]
+	}`
+
+	grps, err := cs.cli.QuotasWithUsage()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/quotas")
+	c.Check(cs.req.URL.Query().Get("usage"), check.Equals, "live")
+	c.Assert(grps, check.HasLen, 1)
+	c.Check(grps[0].Usage, check.DeepEquals, &client.QuotaUsage{
+		Memory:  quantity.Size(450),
+		CPUTime: 1000,
+		Tasks:   1,
+	})
+}
+
+func (cs *clientSuite) TestMoveSnapsToQuota(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+
+	chgID, err := cs.cli.MoveSnapsToQuota("foo", []string{"snap-a", "snap-b"})
+	c.Assert(err, check.IsNil)
+	c.Assert(chgID, check.Equals, "42")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/quotas")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]any
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]any{
+		"action":     "move",
+		"group-name": "foo",
+		"snaps":      []any{"snap-a", "snap-b"},
+	})
+}
+
+func (cs *clientSuite) TestMoveSnapsToQuotaInvalid(c *check.C) {
+	_, err := cs.cli.MoveSnapsToQuota("", []string{"snap-a"})
+	c.Check(err, check.ErrorMatches, `cannot move snaps to quota group without a name`)
+	_, err = cs.cli.MoveSnapsToQuota("foo", nil)
+	c.Check(err, check.ErrorMatches, `cannot move snaps to quota group without any snaps`)
+}
@@ -0,0 +1,253 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Exchange is a request sent to the daemon together with the response it
+// got, as recorded by a client configured with Config.RecordTo. A
+// recording is a sequence of JSON encoded exchanges, one per line.
+type Exchange struct {
+	Method string `json:"method"`
+	// Path is the path of the request, including the encoded query.
+	Path        string      `json:"path"`
+	RequestBody []byte      `json:"request-body,omitempty"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	// Body is the part of the response body read by the client.
+	Body []byte `json:"body,omitempty"`
+}
+
+// ErrNoRecordedResponse is returned, wrapped, by a client configured with
+// Config.ReplayFrom when no recorded response matches a request.
+var ErrNoRecordedResponse = errors.New("no matching recorded response")
+
+// replayError is an error replaying a recording, trying again does not
+// help.
+type replayError struct{ err error }
+
+func (e replayError) Error() string {
+	return e.err.Error()
+}
+
+func (e replayError) Unwrap() error {
+	return e.err
+}
+
+// readRequestBody reads the body of the request, leaving it in place to
+// be sent.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// recordingDoer records the requests and responses going through the
+// wrapped doer. Each exchange is appended to the recording file once the
+// response body is closed, so that streamed responses are passed through
+// as they come and the recording is complete whenever the session ends.
+type recordingDoer struct {
+	doer doer
+	path string
+
+	mu      sync.Mutex
+	started bool
+}
+
+func newRecordingDoer(d doer, path string) *recordingDoer {
+	return &recordingDoer{doer: d, path: path}
+}
+
+func (r *recordingDoer) Do(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := r.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	rsp.Body = &recordingBody{
+		ReadCloser: rsp.Body,
+		recorder:   r,
+		exchange: &Exchange{
+			Method:      req.Method,
+			Path:        req.URL.RequestURI(),
+			RequestBody: reqBody,
+			Status:      rsp.StatusCode,
+			Header:      rsp.Header.Clone(),
+		},
+	}
+	return rsp, nil
+}
+
+func (r *recordingDoer) record(ex *Exchange) error {
+	data, err := json.Marshal(ex)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !r.started {
+		// a recording covers a single session
+		flags |= os.O_TRUNC
+	}
+	// responses may carry sensitive data
+	f, err := os.OpenFile(r.path, flags, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot record response: %v", err)
+	}
+	r.started = true
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot record response: %v", err)
+	}
+	return nil
+}
+
+// recordingBody keeps what is read of a response body and records the
+// exchange when the body is closed.
+type recordingBody struct {
+	io.ReadCloser
+	recorder *recordingDoer
+	exchange *Exchange
+	body     bytes.Buffer
+	once     sync.Once
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.body.Write(p[:n])
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.exchange.Body = b.body.Bytes()
+		if rerr := b.recorder.record(b.exchange); err == nil {
+			err = rerr
+		}
+	})
+	return err
+}
+
+// replayingDoer answers requests with recorded responses. Identical
+// requests get the responses recorded for them in order, the last one
+// being repeated once they are used up, as happens when polling a change.
+type replayingDoer struct {
+	exchanges []*Exchange
+	err       error
+
+	mu     sync.Mutex
+	served map[int]bool
+}
+
+func newReplayingDoer(path string) *replayingDoer {
+	r := &replayingDoer{served: make(map[int]bool)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r.err = replayError{fmt.Errorf("cannot read recording: %v", err)}
+		return r
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var ex Exchange
+		if err := dec.Decode(&ex); err != nil {
+			if err == io.EOF {
+				break
+			}
+			r.err = replayError{fmt.Errorf("cannot decode recording %q: %v", path, err)}
+			return r
+		}
+		r.exchanges = append(r.exchanges, &ex)
+	}
+	return r
+}
+
+func (r *replayingDoer) Do(req *http.Request) (*http.Response, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	path := req.URL.RequestURI()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found := -1
+	for i, ex := range r.exchanges {
+		if ex.Method != req.Method || ex.Path != path || !bytes.Equal(ex.RequestBody, reqBody) {
+			continue
+		}
+		found = i
+		if !r.served[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, replayError{fmt.Errorf("cannot replay %s %s: %w", req.Method, path, ErrNoRecordedResponse)}
+	}
+	r.served[found] = true
+
+	ex := r.exchanges[found]
+	header := ex.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", ex.Status, http.StatusText(ex.Status)),
+		StatusCode:    ex.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(ex.Body)),
+		ContentLength: int64(len(ex.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,195 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) record(c *C, path string) {
+	changeStatuses := []string{"Doing", "Done"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/changes/1":
+			status := changeStatuses[0]
+			if len(changeStatuses) > 1 {
+				changeStatuses = changeStatuses[1:]
+			}
+			w.Write([]byte(`{"type": "sync", "result": {"id": "1", "kind": "foo", "status": "` + status + `"}}`))
+		case "/v2/assertions":
+			body, err := io.ReadAll(r.Body)
+			c.Check(err, IsNil)
+			if string(body) == "bad" {
+				w.WriteHeader(400)
+				w.Write([]byte(`{"type": "error", "status-code": 400, "result": {"message": "bad assertion"}}`))
+				return
+			}
+			w.Write([]byte(`{"type": "sync", "result": {}}`))
+		default:
+			c.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+
+	cli := client.New(&client.Config{BaseURL: server.URL, RecordTo: path})
+	chg, err := cli.Change("1")
+	c.Assert(err, IsNil)
+	c.Check(chg.Status, Equals, "Doing")
+	chg, err = cli.Change("1")
+	c.Assert(err, IsNil)
+	c.Check(chg.Status, Equals, "Done")
+	c.Assert(cli.Ack([]byte("good")), IsNil)
+	c.Assert(cli.Ack([]byte("bad")), ErrorMatches, "bad assertion")
+}
+
+func readRecording(c *C, path string) []*client.Exchange {
+	f, err := os.Open(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+	var exchanges []*client.Exchange
+	dec := json.NewDecoder(f)
+	for {
+		var ex client.Exchange
+		if err := dec.Decode(&ex); err == io.EOF {
+			break
+		} else {
+			c.Assert(err, IsNil)
+		}
+		exchanges = append(exchanges, &ex)
+	}
+	return exchanges
+}
+
+func (cs *clientSuite) TestRecord(c *C) {
+	path := filepath.Join(c.MkDir(), "recording.json")
+	cs.record(c, path)
+
+	fi, err := os.Stat(path)
+	c.Assert(err, IsNil)
+	c.Check(fi.Mode().Perm(), Equals, os.FileMode(0600))
+	exchanges := readRecording(c, path)
+	c.Assert(exchanges, HasLen, 4)
+	for _, ex := range exchanges {
+		c.Check(ex.Header.Get("Content-Type"), Equals, "application/json")
+		ex.Header = nil
+		ex.Body = nil
+	}
+	c.Check(exchanges, DeepEquals, []*client.Exchange{
+		{Method: "GET", Path: "/v2/changes/1", Status: 200},
+		{Method: "GET", Path: "/v2/changes/1", Status: 200},
+		{Method: "POST", Path: "/v2/assertions", RequestBody: []byte("good"), Status: 200},
+		{Method: "POST", Path: "/v2/assertions", RequestBody: []byte("bad"), Status: 400},
+	})
+}
+
+func (cs *clientSuite) TestRecordNewSession(c *C) {
+	path := filepath.Join(c.MkDir(), "recording.json")
+	cs.record(c, path)
+	cs.record(c, path)
+
+	// the recording of the first session is replaced
+	c.Check(readRecording(c, path), HasLen, 4)
+}
+
+func (cs *clientSuite) TestRecordStreamed(c *C) {
+	first := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json-seq")
+		w.Write([]byte("\x1e{\"message\": \"one\"}\n"))
+		w.(http.Flusher).Flush()
+		// the second log is only sent once the first one got through
+		<-first
+		w.Write([]byte("\x1e{\"message\": \"two\"}\n"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(c.MkDir(), "recording.json")
+	cli := client.New(&client.Config{BaseURL: server.URL, RecordTo: path})
+	ch, err := cli.Logs(nil, client.LogOptions{N: -1, Follow: true})
+	c.Assert(err, IsNil)
+	log := <-ch
+	c.Check(log.Message, Equals, "one")
+	close(first)
+	log = <-ch
+	c.Check(log.Message, Equals, "two")
+	_, ok := <-ch
+	c.Check(ok, Equals, false)
+
+	// the body is closed right after the logs channel
+	for i := 0; i < 100; i++ {
+		if fi, err := os.Stat(path); err == nil && fi.Size() > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	exchanges := readRecording(c, path)
+	c.Assert(exchanges, HasLen, 1)
+	c.Check(exchanges[0].Path, Equals, "/v2/logs?follow=true&n=-1")
+	c.Check(string(exchanges[0].Body), Equals, "\x1e{\"message\": \"one\"}\n\x1e{\"message\": \"two\"}\n")
+
+	// and the logs can be replayed
+	cli = client.New(&client.Config{ReplayFrom: path})
+	ch, err = cli.Logs(nil, client.LogOptions{N: -1, Follow: true})
+	c.Assert(err, IsNil)
+	var messages []string
+	for log := range ch {
+		messages = append(messages, log.Message)
+	}
+	c.Check(messages, DeepEquals, []string{"one", "two"})
+}
+
+func (cs *clientSuite) TestReplay(c *C) {
+	path := filepath.Join(c.MkDir(), "recording.json")
+	cs.record(c, path)
+
+	// nothing listens there, the responses come from the recording
+	cli := client.New(&client.Config{BaseURL: "http://localhost:1", ReplayFrom: path})
+	c.Assert(cli.Ack([]byte("bad")), ErrorMatches, "bad assertion")
+	c.Assert(cli.Ack([]byte("good")), IsNil)
+	for _, status := range []string{"Doing", "Done", "Done"} {
+		chg, err := cli.Change("1")
+		c.Assert(err, IsNil)
+		c.Check(chg.Status, Equals, status)
+	}
+
+	_, err := cli.Change("2")
+	c.Check(err, ErrorMatches, `cannot communicate with server: cannot replay GET /v2/changes/2: no matching recorded response`)
+	c.Check(errors.Is(err, client.ErrNoRecordedResponse), Equals, true)
+	err = cli.Ack([]byte("other"))
+	c.Check(errors.Is(err, client.ErrNoRecordedResponse), Equals, true)
+}
+
+func (cs *clientSuite) TestReplayNoRecording(c *C) {
+	cli := client.New(&client.Config{ReplayFrom: filepath.Join(c.MkDir(), "missing.json")})
+	_, err := cli.Change("1")
+	c.Check(err, ErrorMatches, `cannot communicate with server: cannot read recording: .*`)
+}
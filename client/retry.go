@@ -0,0 +1,173 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// RetryOn is a set of classes of failed requests that can be retried.
+type RetryOn uint
+
+const (
+	// RetryOnConnectionError retries requests that could not reach the
+	// daemon at all.
+	RetryOnConnectionError RetryOn = 1 << iota
+	// RetryOnEOF retries requests whose connection was closed by the
+	// daemon before a response was received.
+	RetryOnEOF
+	// RetryOnMaintenance retries requests that could not reach the
+	// daemon while it is known to be down for maintenance, e.g. when
+	// it is restarting after a refresh.
+	RetryOnMaintenance
+	// RetryOnServiceUnavailable retries requests answered with "503
+	// Service Unavailable".
+	RetryOnServiceUnavailable
+)
+
+// RetryPolicy controls how idempotent requests (GET and HEAD) are retried
+// by the client. Requests are never retried past the overall timeout of
+// the request nor after the client context is done.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the
+	// first one. Zero means no limit other than the overall timeout.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. If zero the
+	// default retry interval of the client is used.
+	Backoff time.Duration
+	// Multiplier is applied to the delay after each retry, values
+	// below 1 keep the delay constant.
+	Multiplier float64
+	// MaxBackoff caps the delay between retries, if set.
+	MaxBackoff time.Duration
+	// RetryOn are the classes of failures that are retried.
+	RetryOn RetryOn
+}
+
+// defaultRetryPolicy retries idempotent requests that could not reach the
+// daemon at a constant interval.
+var defaultRetryPolicy = RetryPolicy{
+	RetryOn: RetryOnConnectionError,
+}
+
+// delay returns how long to wait before the given retry, counting from 1.
+func (p *RetryPolicy) delay(retry int, defaultBackoff time.Duration) time.Duration {
+	d := p.Backoff
+	if d <= 0 {
+		d = defaultBackoff
+	}
+	if p.Multiplier > 1 {
+		for i := 1; i < retry; i++ {
+			d = time.Duration(float64(d) * p.Multiplier)
+			if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+				break
+			}
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// shouldRetry returns whether a request that resulted in the given
+// response or error should be attempted again after the given number of
+// attempts.
+func (client *Client) shouldRetry(p *RetryPolicy, method string, attempts int, rsp *http.Response, err error) bool {
+	if method != "GET" && method != "HEAD" {
+		return false
+	}
+	if p.MaxAttempts > 0 && attempts >= p.MaxAttempts {
+		return false
+	}
+	if err == nil {
+		return p.RetryOn&RetryOnServiceUnavailable != 0 && rsp.StatusCode == http.StatusServiceUnavailable
+	}
+	if shouldNotRetryError(err) || !errors.As(err, &ConnectionError{}) {
+		return false
+	}
+	if p.RetryOn&RetryOnConnectionError != 0 {
+		return true
+	}
+	if p.RetryOn&RetryOnEOF != 0 && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+		return true
+	}
+	if p.RetryOn&RetryOnMaintenance != 0 {
+		client.checkMaintenanceJSON()
+		if client.maintenance != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// socketWaitInterval is how often the socket of the daemon is checked
+// while waiting for it to come back.
+var socketWaitInterval = 250 * time.Millisecond
+
+// socketUnavailable returns whether the request failed with the given error
+// because the socket of the daemon is missing or refuses connections, in
+// which case the request did not reach the daemon.
+func socketUnavailable(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT)
+}
+
+// doWaitingForSocket performs the request, and if the socket of the daemon
+// is unavailable and the client is configured to do so, waits for the
+// socket to come back and performs the request again.
+func (client *Client) doWaitingForSocket(req *http.Request) (*http.Response, error) {
+	rsp, err := client.doer.Do(req)
+	if err == nil || client.socketWaitWindow <= 0 || !socketUnavailable(err) {
+		return rsp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// the body cannot be sent again
+		return rsp, err
+	}
+
+	deadline := time.Now().Add(client.socketWaitWindow)
+	for time.Now().Before(deadline) {
+		wait := time.NewTimer(socketWaitInterval)
+		select {
+		case <-wait.C:
+		case <-req.Context().Done():
+			wait.Stop()
+			return nil, err
+		}
+
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, err
+			}
+			retry.Body = body
+		}
+		rsp, err = client.doer.Do(retry)
+		if err == nil || !socketUnavailable(err) {
+			return rsp, err
+		}
+	}
+	return rsp, err
+}
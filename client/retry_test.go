@@ -0,0 +1,203 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+)
+
+func (cs *clientSuite) clientWithRetryPolicy(policy *client.RetryPolicy) *client.Client {
+	cli := client.New(&client.Config{RetryPolicy: policy})
+	cli.SetDoer(cs)
+	return cli
+}
+
+func (cs *clientSuite) TestRetryPolicyDelay(c *C) {
+	p := &client.RetryPolicy{}
+	c.Check(p.Delay(1, time.Second), Equals, time.Second)
+	c.Check(p.Delay(5, time.Second), Equals, time.Second)
+
+	p = &client.RetryPolicy{Backoff: 100 * time.Millisecond, Multiplier: 2, MaxBackoff: time.Second}
+	c.Check(p.Delay(1, time.Minute), Equals, 100*time.Millisecond)
+	c.Check(p.Delay(2, time.Minute), Equals, 200*time.Millisecond)
+	c.Check(p.Delay(4, time.Minute), Equals, 800*time.Millisecond)
+	c.Check(p.Delay(5, time.Minute), Equals, time.Second)
+	c.Check(p.Delay(100, time.Minute), Equals, time.Second)
+}
+
+func (cs *clientSuite) TestRetryPolicyMaxAttempts(c *C) {
+	cli := cs.clientWithRetryPolicy(&client.RetryPolicy{
+		MaxAttempts: 3,
+		RetryOn:     client.RetryOnConnectionError,
+	})
+	cs.err = errors.New("connection refused")
+
+	_, err := cli.DoWithOpts("GET", "/this", nil, nil, nil, nil)
+	c.Check(err, ErrorMatches, "cannot communicate with server: connection refused")
+	c.Check(cs.doCalls, Equals, 3)
+}
+
+func (cs *clientSuite) TestRetryPolicyServiceUnavailable(c *C) {
+	cli := cs.clientWithRetryPolicy(&client.RetryPolicy{
+		MaxAttempts: 5,
+		RetryOn:     client.RetryOnServiceUnavailable,
+	})
+	cs.statuses = []int{503, 503, 200}
+	cs.rsp = `{"type": "sync", "result": {"systems": []}}`
+
+	systems, err := cli.ListSystems()
+	c.Assert(err, IsNil)
+	c.Check(systems, HasLen, 0)
+	c.Check(cs.doCalls, Equals, 3)
+}
+
+func (cs *clientSuite) TestRetryPolicyServiceUnavailableGivesUp(c *C) {
+	cli := cs.clientWithRetryPolicy(&client.RetryPolicy{
+		MaxAttempts: 2,
+		RetryOn:     client.RetryOnServiceUnavailable,
+	})
+	cs.status = 503
+	cs.rsp = `{"type": "error", "status-code": 503, "result": {"message": "busy"}}`
+
+	_, err := cli.ListSystems()
+	c.Check(err, ErrorMatches, "cannot list recovery systems: busy")
+	c.Check(cs.doCalls, Equals, 2)
+}
+
+func (cs *clientSuite) TestRetryPolicyOnlyIdempotent(c *C) {
+	cli := cs.clientWithRetryPolicy(&client.RetryPolicy{
+		RetryOn: client.RetryOnServiceUnavailable | client.RetryOnConnectionError,
+	})
+	cs.err = errors.New("connection refused")
+
+	_, err := cli.DoWithOpts("POST", "/this", nil, nil, nil, nil)
+	c.Check(err, ErrorMatches, "cannot communicate with server: connection refused")
+	c.Check(cs.doCalls, Equals, 1)
+}
+
+func (cs *clientSuite) TestRetryPolicyEOF(c *C) {
+	cli := cs.clientWithRetryPolicy(&client.RetryPolicy{
+		MaxAttempts: 2,
+		RetryOn:     client.RetryOnEOF,
+	})
+
+	cs.err = errors.New("connection refused")
+	_, err := cli.DoWithOpts("GET", "/this", nil, nil, nil, nil)
+	c.Check(err, NotNil)
+	c.Check(cs.doCalls, Equals, 1)
+
+	cs.doCalls = 0
+	cs.err = io.ErrUnexpectedEOF
+	_, err = cli.DoWithOpts("GET", "/this", nil, nil, nil, nil)
+	c.Check(err, NotNil)
+	c.Check(cs.doCalls, Equals, 2)
+}
+
+func (cs *clientSuite) TestRetryPolicyMaintenance(c *C) {
+	cli := cs.clientWithRetryPolicy(&client.RetryPolicy{
+		MaxAttempts: 2,
+		RetryOn:     client.RetryOnMaintenance,
+	})
+	cs.err = errors.New("connection refused")
+
+	_, err := cli.DoWithOpts("GET", "/this", nil, nil, nil, nil)
+	c.Check(err, NotNil)
+	c.Check(cs.doCalls, Equals, 1)
+
+	b, err := json.Marshal(&client.Error{
+		Kind:    client.ErrorKindDaemonRestart,
+		Message: "daemon is restarting",
+	})
+	c.Assert(err, IsNil)
+	makeMaintenanceFile(c, b)
+
+	cs.doCalls = 0
+	_, err = cli.DoWithOpts("GET", "/this", nil, nil, nil, nil)
+	c.Check(err, NotNil)
+	c.Check(cs.doCalls, Equals, 2)
+}
+
+func (cs *clientSuite) TestSocketWaitWindowResendsRequest(c *C) {
+	defer client.MockSocketWaitInterval(10 * time.Millisecond)()
+
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapdSocket), 0755), IsNil)
+
+	var body string
+	f := func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "POST")
+		data, err := io.ReadAll(r.Body)
+		c.Check(err, IsNil)
+		body = string(data)
+		w.WriteHeader(202)
+		fmt.Fprintln(w, `{"type": "async", "status-code": 202, "change": "42"}`)
+	}
+	srv := &httptest.Server{
+		Config: &http.Server{Handler: http.HandlerFunc(f)},
+	}
+	defer srv.Close()
+
+	// the socket only comes back after a while, as when snapd restarts
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l, err := net.Listen("unix", dirs.SnapdSocket)
+		if err != nil {
+			c.Errorf("unable to listen on %q: %v", dirs.SnapdSocket, err)
+			return
+		}
+		srv.Listener = l
+		srv.Start()
+	}()
+
+	cli := client.New(&client.Config{SocketWaitWindow: 10 * time.Second})
+	id, err := cli.Install("foo", nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(id, Equals, "42")
+	c.Check(body, Equals, `{"action":"install"}`)
+}
+
+func (cs *clientSuite) TestSocketWaitWindowGivesUp(c *C) {
+	defer client.MockSocketWaitInterval(10 * time.Millisecond)()
+
+	cli := client.New(&client.Config{SocketWaitWindow: 50 * time.Millisecond})
+	start := time.Now()
+	_, err := cli.Install("foo", nil, nil)
+	c.Check(err, ErrorMatches, "cannot communicate with server: .*no such file or directory")
+	c.Check(time.Since(start) >= 50*time.Millisecond, Equals, true)
+}
+
+func (cs *clientSuite) TestSocketUnavailableNoWaitByDefault(c *C) {
+	cli := client.New(nil)
+	_, err := cli.Install("foo", nil, nil)
+	c.Check(err, ErrorMatches, "cannot communicate with server: .*no such file or directory")
+}
@@ -0,0 +1,111 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+// LocalRevision describes a revision of a snap that is retained on the
+// system.
+type LocalRevision struct {
+	Revision snap.Revision `json:"revision"`
+	Version  string        `json:"version,omitempty"`
+	// Channel is the channel the revision was installed from.
+	Channel     string     `json:"channel,omitempty"`
+	Size        int64      `json:"size,omitempty"`
+	InstallDate *time.Time `json:"install-date,omitempty"`
+	Current     bool       `json:"current,omitempty"`
+}
+
+// StoreRevision describes the revision of a snap currently released
+// to a store channel.
+type StoreRevision struct {
+	Channel    string        `json:"channel"`
+	Revision   snap.Revision `json:"revision"`
+	Version    string        `json:"version"`
+	Size       int64         `json:"size,omitempty"`
+	ReleasedAt time.Time     `json:"released-at"`
+}
+
+// SnapRevisions holds the revisions of a snap known locally and,
+// if requested, in the store.
+type SnapRevisions struct {
+	Local []LocalRevision `json:"local"`
+	Store []StoreRevision `json:"store,omitempty"`
+}
+
+// SnapRevisionsOptions holds options for SnapRevisions.
+type SnapRevisionsOptions struct {
+	// Store asks for the revisions available in the store channels
+	// to be included as well.
+	Store bool
+}
+
+// SnapRevisions returns the revisions retained on the system for the
+// given snap and optionally those available in the store.
+func (client *Client) SnapRevisions(name string, opts *SnapRevisionsOptions) (*SnapRevisions, error) {
+	if opts == nil {
+		opts = &SnapRevisionsOptions{}
+	}
+
+	q := url.Values{}
+	if opts.Store {
+		q.Set("store", "true")
+	}
+
+	var revs SnapRevisions
+	path := fmt.Sprintf("/v2/snaps/%s/revisions", name)
+	if _, err := client.doSync("GET", path, q, nil, nil, &revs); err != nil {
+		return nil, fmt.Errorf("cannot get revisions of snap %q: %v", name, err)
+	}
+	return &revs, nil
+}
+
+// RefreshHistoryEntry describes a change of the current revision of a
+// snap as recorded by snapd.
+type RefreshHistoryEntry struct {
+	// Time is when the refresh completed.
+	Time         time.Time     `json:"time"`
+	FromRevision snap.Revision `json:"from-revision"`
+	ToRevision   snap.Revision `json:"to-revision"`
+	Channel      string        `json:"channel,omitempty"`
+	// Initiator is "auto" for auto-refreshes and "user" otherwise.
+	Initiator string `json:"initiator"`
+	// Outcome is "success", or "failed" if the new revision was undone.
+	Outcome  string        `json:"outcome"`
+	Duration time.Duration `json:"duration"`
+}
+
+// SnapRefreshHistory returns the refresh history of the given snap,
+// oldest first. The history is kept after the changes that performed
+// the refreshes are pruned.
+func (client *Client) SnapRefreshHistory(name string) ([]RefreshHistoryEntry, error) {
+	var history []RefreshHistoryEntry
+	path := fmt.Sprintf("/v2/snaps/%s/history", name)
+	if _, err := client.doSync("GET", path, nil, nil, nil, &history); err != nil {
+		return nil, fmt.Errorf("cannot get refresh history of snap %q: %v", name, err)
+	}
+	return history, nil
+}
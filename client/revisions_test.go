@@ -0,0 +1,130 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (cs *clientSuite) TestClientSnapRevisions(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"local": [
+				{"revision": "1", "version": "1.0", "channel": "stable", "size": 1024, "install-date": "2025-01-02T03:04:05Z"},
+				{"revision": "2", "version": "2.0", "channel": "candidate", "size": 2048, "install-date": "2025-02-03T04:05:06Z", "current": true}
+			]
+		}
+	}`
+	revs, err := cs.cli.SnapRevisions("foo", nil)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo/revisions")
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+
+	date1 := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	date2 := time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC)
+	c.Check(revs, check.DeepEquals, &client.SnapRevisions{
+		Local: []client.LocalRevision{
+			{Revision: snap.R(1), Version: "1.0", Channel: "stable", Size: 1024, InstallDate: &date1},
+			{Revision: snap.R(2), Version: "2.0", Channel: "candidate", Size: 2048, InstallDate: &date2, Current: true},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientSnapRevisionsStore(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"local": [{"revision": "1", "version": "1.0", "current": true}],
+			"store": [
+				{"channel": "latest/stable", "revision": "1", "version": "1.0", "released-at": "2025-01-01T00:00:00Z"},
+				{"channel": "latest/edge", "revision": "3", "version": "3.0", "size": 4096, "released-at": "2025-03-01T00:00:00Z"}
+			]
+		}
+	}`
+	revs, err := cs.cli.SnapRevisions("foo", &client.SnapRevisionsOptions{Store: true})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo/revisions")
+	c.Check(cs.req.URL.Query().Get("store"), check.Equals, "true")
+
+	c.Check(revs.Local, check.DeepEquals, []client.LocalRevision{
+		{Revision: snap.R(1), Version: "1.0", Current: true},
+	})
+	c.Check(revs.Store, check.DeepEquals, []client.StoreRevision{
+		{Channel: "latest/stable", Revision: snap.R(1), Version: "1.0", ReleasedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Channel: "latest/edge", Revision: snap.R(3), Version: "3.0", Size: 4096, ReleasedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	})
+}
+
+func (cs *clientSuite) TestClientSnapRevisionsError(c *check.C) {
+	cs.rsp = `{
+		"type": "error",
+		"status-code": 404,
+		"result": {"message": "snap not installed", "kind": "snap-not-found", "value": "foo"}
+	}`
+	_, err := cs.cli.SnapRevisions("foo", nil)
+	c.Check(err, check.ErrorMatches, `cannot get revisions of snap "foo": snap not installed`)
+}
+
+func (cs *clientSuite) TestClientSnapRefreshHistory(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": [
+			{"time": "2025-01-02T03:04:05Z", "from-revision": "1", "to-revision": "2", "channel": "latest/stable", "initiator": "auto", "outcome": "success", "duration": 5000000000},
+			{"time": "2025-02-03T04:05:06Z", "from-revision": "2", "to-revision": "3", "initiator": "user", "outcome": "failed", "duration": 1000000000}
+		]
+	}`
+	history, err := cs.cli.SnapRefreshHistory("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo/history")
+	c.Check(history, check.DeepEquals, []client.RefreshHistoryEntry{{
+		Time:         time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		FromRevision: snap.R(1),
+		ToRevision:   snap.R(2),
+		Channel:      "latest/stable",
+		Initiator:    "auto",
+		Outcome:      "success",
+		Duration:     5 * time.Second,
+	}, {
+		Time:         time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC),
+		FromRevision: snap.R(2),
+		ToRevision:   snap.R(3),
+		Initiator:    "user",
+		Outcome:      "failed",
+		Duration:     time.Second,
+	}})
+}
+
+func (cs *clientSuite) TestClientSnapRefreshHistoryError(c *check.C) {
+	cs.status = 404
+	cs.rsp = `{"type": "error", "status-code": 404, "result": {"message": "snap not installed", "kind": "snap-not-found"}}`
+	_, err := cs.cli.SnapRefreshHistory("foo")
+	c.Check(err, check.ErrorMatches, `cannot get refresh history of snap "foo": snap not installed`)
+}
@@ -21,13 +21,17 @@ package client
 
 import (
 	"bytes"
-	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/sha3"
 )
 
 // TransactionType says whether we want to treat each snap separately
@@ -64,6 +68,34 @@ type SnapOptions struct {
 	Time             string          `json:"time,omitempty"`
 	HoldLevel        string          `json:"hold-level,omitempty"`
 	Users            []string        `json:"users,omitempty"`
+	// URL, if set, is an https url snapd downloads the snap to install
+	// from. The snap must still be covered by store assertions.
+	URL string `json:"url,omitempty"`
+	// ScheduleAt, if set, is the time in RFC3339 format at which the
+	// change started by a multi-snap request is kicked off.
+	ScheduleAt string `json:"schedule-at,omitempty"`
+	// Queue, if set, makes snapd queue the operation until the changes it
+	// conflicts with are done, instead of failing with a
+	// snap-change-conflict error. It is supported for installs, refreshes
+	// and removals.
+	Queue bool `json:"queue,omitempty"`
+	// Architecture, if set, installs the revision of the snap for the
+	// given architecture instead of the one of the system. Only snaps
+	// without apps or hooks, such as content snaps, can be installed for
+	// a foreign architecture.
+	Architecture string `json:"architecture,omitempty"`
+	// ChangeTimeout, if set, bounds the duration of the change started
+	// by the request, after which snapd aborts and undoes it.
+	ChangeTimeout time.Duration `json:"-"`
+}
+
+// changeTimeoutHeader is the request header carrying the change timeout.
+const changeTimeoutHeader = "X-Snapd-Change-Timeout"
+
+func (opts *SnapOptions) addChangeTimeoutHeader(headers map[string]string) {
+	if opts != nil && opts.ChangeTimeout > 0 {
+		headers[changeTimeoutHeader] = opts.ChangeTimeout.String()
+	}
 }
 
 func writeFieldBool(mw *multipart.Writer, key string, val bool) error {
@@ -136,6 +168,9 @@ type multiActionData struct {
 	Time           string              `json:"time,omitempty"`
 	HoldLevel      string              `json:"hold-level,omitempty"`
 	Components     map[string][]string `json:"components,omitempty"`
+	ScheduleAt     string              `json:"schedule-at,omitempty"`
+	Queue          bool                `json:"queue,omitempty"`
+	Architecture   string              `json:"architecture,omitempty"`
 }
 
 // Install adds the snap with the given name from the given channel (or
@@ -186,6 +221,12 @@ func (client *Client) UnholdRefreshesMany(names []string, options *SnapOptions)
 	return client.doMultiSnapAction("unhold", names, nil, options)
 }
 
+// ProceedRefresh proceeds right away with an auto-refresh of the snap that is
+// inhibited because its apps are running.
+func (client *Client) ProceedRefresh(name string, options *SnapOptions) (changeID string, err error) {
+	return client.doSnapAction("proceed", name, nil, options)
+}
+
 func (client *Client) Enable(name string, options *SnapOptions) (changeID string, err error) {
 	return client.doSnapAction("enable", name, nil, options)
 }
@@ -243,6 +284,7 @@ func (client *Client) doSnapAction(actionName string, snapName string, component
 	headers := map[string]string{
 		"Content-Type": "application/json",
 	}
+	options.addChangeTimeoutHeader(headers)
 
 	return client.doAsync("POST", path, nil, headers, bytes.NewBuffer(data))
 }
@@ -269,6 +311,9 @@ func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, c
 		action.ValidationSets = options.ValidationSets
 		action.Time = options.Time
 		action.HoldLevel = options.HoldLevel
+		action.ScheduleAt = options.ScheduleAt
+		action.Queue = options.Queue
+		action.Architecture = options.Architecture
 	}
 
 	data, err := json.Marshal(&action)
@@ -279,6 +324,7 @@ func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, c
 	headers := map[string]string{
 		"Content-Type": "application/json",
 	}
+	options.addChangeTimeoutHeader(headers)
 
 	return client.doAsyncFull("POST", "/v2/snaps", nil, headers, bytes.NewBuffer(data), nil)
 }
@@ -333,6 +379,7 @@ func (client *Client) sendLocalSnaps(paths []string, files []*os.File, action ac
 	headers := map[string]string{
 		"Content-Type": mw.FormDataContentType(),
 	}
+	action.SnapOptions.addChangeTimeoutHeader(headers)
 
 	_, changeID, err := client.doAsyncFull("POST", "/v2/snaps", nil, headers, pr, doNoTimeoutAndRetry)
 	return changeID, err
@@ -357,6 +404,7 @@ func (client *Client) Try(path string, options *SnapOptions) (changeID string, e
 	headers := map[string]string{
 		"Content-Type": mw.FormDataContentType(),
 	}
+	options.addChangeTimeoutHeader(headers)
 
 	return client.doAsync("POST", "/v2/snaps", nil, headers, buf)
 }
@@ -452,10 +500,21 @@ type DownloadOptions struct {
 	HeaderPeek  bool
 	ResumeToken string
 	Resume      int64
+
+	// Progress, if set, is called periodically while the returned
+	// reader is read from, with the bytes downloaded so far.
+	Progress ProgressFunc
 }
 
 // Download will stream the given snap to the client
 func (client *Client) Download(name string, options *DownloadOptions) (dlInfo *DownloadInfo, r io.ReadCloser, err error) {
+	dlInfo, r, _, err = client.download(name, options)
+	return dlInfo, r, err
+}
+
+// download streams the given snap, resumed is true if the server honoured the
+// requested resume position.
+func (client *Client) download(name string, options *DownloadOptions) (dlInfo *DownloadInfo, r io.ReadCloser, resumed bool, err error) {
 	if options == nil {
 		options = &DownloadOptions{}
 	}
@@ -471,33 +530,34 @@ func (client *Client) Download(name string, options *DownloadOptions) (dlInfo *D
 	}
 	data, err := json.Marshal(&action)
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot marshal snap action: %s", err)
+		return nil, nil, false, fmt.Errorf("cannot marshal snap action: %s", err)
 	}
 	headers := map[string]string{
 		"Content-Type": "application/json",
 	}
 	if options.Resume > 0 {
-		headers["range"] = fmt.Sprintf("bytes: %d-", options.Resume)
+		headers["range"] = fmt.Sprintf("bytes=%d-", options.Resume)
 	}
 
-	// no deadline for downloads
-	ctx := context.Background()
+	// no deadline for downloads, other than the one of the client context
+	ctx := client.requestContext()
 	rsp, err := client.raw(ctx, "POST", "/v2/download", nil, headers, bytes.NewBuffer(data))
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, false, err
 	}
 
-	if rsp.StatusCode != 200 {
+	if rsp.StatusCode != 200 && rsp.StatusCode != 206 {
 		var r response
 		defer rsp.Body.Close()
 		if err := decodeInto(rsp.Body, &r); err != nil {
-			return nil, nil, err
+			return nil, nil, false, err
 		}
-		return nil, nil, r.err(client, rsp.StatusCode)
+		return nil, nil, false, r.err(client, rsp.StatusCode)
 	}
 	matches := contentDispositionMatcher(rsp.Header.Get("Content-Disposition"))
 	if matches == nil || matches[1] == "" {
-		return nil, nil, fmt.Errorf("cannot determine filename")
+		rsp.Body.Close()
+		return nil, nil, false, fmt.Errorf("cannot determine filename")
 	}
 
 	dlInfo = &DownloadInfo{
@@ -506,6 +566,118 @@ func (client *Client) Download(name string, options *DownloadOptions) (dlInfo *D
 		Sha3_384:          rsp.Header.Get("Snap-Sha3-384"),
 		ResumeToken:       rsp.Header.Get("Snap-Download-Token"),
 	}
+	resumed = rsp.StatusCode == 206
+
+	if options.Progress != nil {
+		total := int64(0)
+		if rsp.ContentLength >= 0 {
+			total = options.Resume + rsp.ContentLength
+		}
+		summary := fmt.Sprintf("Download snap %q", name)
+		return dlInfo, newProgressReader(rsp.Body, summary, options.Resume, total, options.Progress), resumed, nil
+	}
+
+	return dlInfo, rsp.Body, resumed, nil
+}
+
+// DownloadTo downloads the given snap to targetPath. The snap is written to a
+// partial file next to targetPath first, with the resume token kept alongside
+// it, so that calling DownloadTo again for the same target after an
+// interruption resumes the download. The sha3-384 of the downloaded snap is
+// verified before it is renamed into place.
+func (client *Client) DownloadTo(name, targetPath string, options *DownloadOptions) (*DownloadInfo, error) {
+	if options == nil {
+		options = &DownloadOptions{}
+	}
+	if options.HeaderPeek {
+		return nil, fmt.Errorf("cannot download snap %q to a file with a header-only peek", name)
+	}
+
+	partialPath := targetPath + ".partial"
+	tokenPath := partialPath + ".token"
+
+	opts := *options
+	opts.Resume = 0
+	opts.ResumeToken = ""
+	if fi, err := os.Stat(partialPath); err == nil && fi.Size() > 0 {
+		if tok, err := os.ReadFile(tokenPath); err == nil && len(tok) > 0 {
+			opts.Resume = fi.Size()
+			opts.ResumeToken = string(tok)
+		}
+	}
+
+	dlInfo, r, resumed, err := client.download(name, &opts)
+	if err != nil && opts.ResumeToken != "" {
+		// the resume token may not be valid anymore (e.g. because it was
+		// issued by a different snapd), start over
+		opts.Resume = 0
+		opts.ResumeToken = ""
+		dlInfo, r, resumed, err = client.download(name, &opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
 
-	return dlInfo, rsp.Body, nil
+	if dlInfo.ResumeToken != "" {
+		if err := os.WriteFile(tokenPath, []byte(dlInfo.ResumeToken), 0600); err != nil {
+			return nil, fmt.Errorf("cannot store download resume token: %v", err)
+		}
+	}
+
+	h := sha3.New384()
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resumed {
+		// the server continues where we left off, account for what we
+		// already have
+		if err := hashFile(h, partialPath); err != nil {
+			return nil, err
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(partialPath, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open partial download: %v", err)
+	}
+	size, err := io.Copy(io.MultiWriter(f, h), r)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot download snap %q: %v", name, err)
+	}
+
+	if digest := hex.EncodeToString(h.Sum(nil)); digest != dlInfo.Sha3_384 {
+		// the partial file is of no use anymore
+		os.Remove(partialPath)
+		os.Remove(tokenPath)
+		return nil, fmt.Errorf("cannot verify downloaded snap %q: sha3-384 mismatch (got %s, expected %s)", name, digest, dlInfo.Sha3_384)
+	}
+
+	if err := os.Rename(partialPath, targetPath); err != nil {
+		return nil, fmt.Errorf("cannot move downloaded snap into place: %v", err)
+	}
+	os.Remove(tokenPath)
+
+	if resumed {
+		size += opts.Resume
+	}
+	dlInfo.Size = size
+	return dlInfo, nil
+}
+
+func hashFile(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open partial download: %v", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("cannot read partial download: %v", err)
+	}
+	return nil
 }
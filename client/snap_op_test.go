@@ -20,6 +20,7 @@
 package client_test
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,7 +30,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
+	"golang.org/x/crypto/sha3"
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
@@ -66,6 +69,7 @@ var ops = []struct {
 	{(*client.Client).Switch, "switch"},
 	{(*client.Client).HoldRefreshes, "hold"},
 	{(*client.Client).UnholdRefreshes, "unhold"},
+	{(*client.Client).ProceedRefresh, "proceed"},
 }
 
 var multiOps = []struct {
@@ -645,6 +649,34 @@ func (cs *clientSuite) TestClientOpInstallTransactional(c *check.C) {
 		"(?s).*Content-Disposition: form-data; name=\"transaction\"\r\n\r\nall-snaps\r\n.*")
 }
 
+func (cs *clientSuite) TestClientOpInstallFromURL(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "66b3",
+		"status-code": 202,
+		"type": "async"
+	}`
+
+	opts := client.SnapOptions{
+		URL: "https://artifacts.example.com/foo_1.snap",
+	}
+	id, err := cs.cli.Install("foo", nil, &opts)
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "66b3")
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var jsonBody map[string]any
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, check.IsNil, check.Commentf("body: %v", string(body)))
+	c.Check(jsonBody, check.DeepEquals, map[string]any{
+		"action": "install",
+		"url":    "https://artifacts.example.com/foo_1.snap",
+	})
+}
+
 func (cs *clientSuite) TestClientOpInstallPrefer(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
@@ -696,6 +728,32 @@ func formToMap(c *check.C, mr *multipart.Reader) map[string]string {
 	return formData
 }
 
+func (cs *clientSuite) TestClientOpChangeTimeout(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+
+	_, err := cs.cli.Install("foo", nil, &client.SnapOptions{ChangeTimeout: 90 * time.Minute})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Header.Get("X-Snapd-Change-Timeout"), check.Equals, "1h30m0s")
+	// not sent as part of the action
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(string(body), check.Not(testutil.Contains), "timeout")
+
+	_, err = cs.cli.RefreshMany([]string{"foo", "bar"}, nil, &client.SnapOptions{ChangeTimeout: time.Minute})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Header.Get("X-Snapd-Change-Timeout"), check.Equals, "1m0s")
+
+	// no timeout, no header
+	_, err = cs.cli.Install("foo", nil, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Header.Get("X-Snapd-Change-Timeout"), check.Equals, "")
+}
+
 func (cs *clientSuite) TestClientOpTryMode(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
@@ -855,7 +913,7 @@ func (cs *clientSuite) TestClientOpDownloadResume(c *check.C) {
 
 	// check we posted the right stuff
 	c.Assert(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
-	c.Assert(cs.req.Header.Get("range"), check.Equals, "bytes: 64-")
+	c.Assert(cs.req.Header.Get("range"), check.Equals, "bytes=64-")
 	body, err := io.ReadAll(cs.req.Body)
 	c.Assert(err, check.IsNil)
 	var jsonBody client.DownloadAction
@@ -875,6 +933,138 @@ func (cs *clientSuite) TestClientOpDownloadResume(c *check.C) {
 	c.Check(rc.Close(), check.IsNil)
 }
 
+func sha3_384Hex(data string) string {
+	h := sha3.Sum384([]byte(data))
+	return hex.EncodeToString(h[:])
+}
+
+func (cs *clientSuite) TestClientDownloadTo(c *check.C) {
+	cs.status = 200
+	cs.header = http.Header{
+		"Content-Disposition": {"attachment; filename=foo_2.snap"},
+		"Snap-Sha3-384":       {sha3_384Hex("snap-data")},
+		"Snap-Download-Token": {"some-token"},
+	}
+	cs.rsp = "snap-data"
+	cs.contentLength = int64(len(cs.rsp))
+
+	target := filepath.Join(c.MkDir(), "foo.snap")
+	dlInfo, err := cs.cli.DownloadTo("foo", target, &client.DownloadOptions{
+		SnapOptions: client.SnapOptions{Channel: "edge"},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(dlInfo, check.DeepEquals, &client.DownloadInfo{
+		SuggestedFileName: "foo_2.snap",
+		Size:              9,
+		Sha3_384:          sha3_384Hex("snap-data"),
+		ResumeToken:       "some-token",
+	})
+	c.Check(target, testutil.FileEquals, "snap-data")
+	c.Check(target+".partial", testutil.FileAbsent)
+	c.Check(target+".partial.token", testutil.FileAbsent)
+
+	c.Check(cs.req.Header.Get("range"), check.Equals, "")
+	var jsonBody client.DownloadAction
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&jsonBody), check.IsNil)
+	c.Check(jsonBody.SnapName, check.Equals, "foo")
+	c.Check(jsonBody.Channel, check.Equals, "edge")
+	c.Check(jsonBody.ResumeToken, check.Equals, "")
+}
+
+func (cs *clientSuite) TestClientDownloadToResume(c *check.C) {
+	cs.status = 206
+	cs.header = http.Header{
+		"Content-Disposition": {"attachment; filename=foo_2.snap"},
+		"Snap-Sha3-384":       {sha3_384Hex("snap-data")},
+		"Snap-Download-Token": {"some-token"},
+	}
+	cs.rsp = "data"
+	cs.contentLength = int64(len(cs.rsp))
+
+	target := filepath.Join(c.MkDir(), "foo.snap")
+	c.Assert(os.WriteFile(target+".partial", []byte("snap-"), 0644), check.IsNil)
+	c.Assert(os.WriteFile(target+".partial.token", []byte("some-token"), 0600), check.IsNil)
+
+	dlInfo, err := cs.cli.DownloadTo("foo", target, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(dlInfo.Size, check.Equals, int64(9))
+	c.Check(target, testutil.FileEquals, "snap-data")
+	c.Check(target+".partial", testutil.FileAbsent)
+	c.Check(target+".partial.token", testutil.FileAbsent)
+
+	c.Check(cs.req.Header.Get("range"), check.Equals, "bytes=5-")
+	var jsonBody client.DownloadAction
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&jsonBody), check.IsNil)
+	c.Check(jsonBody.ResumeToken, check.Equals, "some-token")
+}
+
+func (cs *clientSuite) TestClientDownloadToResumeNotHonoured(c *check.C) {
+	// the server sends the full snap again
+	cs.status = 200
+	cs.header = http.Header{
+		"Content-Disposition": {"attachment; filename=foo_2.snap"},
+		"Snap-Sha3-384":       {sha3_384Hex("snap-data")},
+	}
+	cs.rsp = "snap-data"
+	cs.contentLength = int64(len(cs.rsp))
+
+	target := filepath.Join(c.MkDir(), "foo.snap")
+	c.Assert(os.WriteFile(target+".partial", []byte("junk-"), 0644), check.IsNil)
+	c.Assert(os.WriteFile(target+".partial.token", []byte("some-token"), 0600), check.IsNil)
+
+	_, err := cs.cli.DownloadTo("foo", target, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(target, testutil.FileEquals, "snap-data")
+	c.Check(cs.req.Header.Get("range"), check.Equals, "bytes=5-")
+}
+
+func (cs *clientSuite) TestClientDownloadToResumeTokenRejected(c *check.C) {
+	cs.statuses = []int{400, 200}
+	cs.rsps = []string{
+		`{"type": "error", "status-code": 400, "result": {"message": "download token is invalid"}}`,
+		"snap-data",
+	}
+	cs.header = http.Header{
+		"Content-Disposition": {"attachment; filename=foo_2.snap"},
+		"Snap-Sha3-384":       {sha3_384Hex("snap-data")},
+	}
+
+	target := filepath.Join(c.MkDir(), "foo.snap")
+	c.Assert(os.WriteFile(target+".partial", []byte("snap-"), 0644), check.IsNil)
+	c.Assert(os.WriteFile(target+".partial.token", []byte("stale-token"), 0600), check.IsNil)
+
+	_, err := cs.cli.DownloadTo("foo", target, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(target, testutil.FileEquals, "snap-data")
+
+	c.Assert(cs.reqs, check.HasLen, 2)
+	c.Check(cs.reqs[0].Header.Get("range"), check.Equals, "bytes=5-")
+	c.Check(cs.reqs[1].Header.Get("range"), check.Equals, "")
+}
+
+func (cs *clientSuite) TestClientDownloadToDigestMismatch(c *check.C) {
+	cs.status = 200
+	cs.header = http.Header{
+		"Content-Disposition": {"attachment; filename=foo_2.snap"},
+		"Snap-Sha3-384":       {sha3_384Hex("other-data")},
+		"Snap-Download-Token": {"some-token"},
+	}
+	cs.rsp = "snap-data"
+
+	target := filepath.Join(c.MkDir(), "foo.snap")
+	_, err := cs.cli.DownloadTo("foo", target, nil)
+	c.Assert(err, check.ErrorMatches, `cannot verify downloaded snap "foo": sha3-384 mismatch \(got [0-9a-f]+, expected [0-9a-f]+\)`)
+	c.Check(target, testutil.FileAbsent)
+	c.Check(target+".partial", testutil.FileAbsent)
+	c.Check(target+".partial.token", testutil.FileAbsent)
+}
+
+func (cs *clientSuite) TestClientDownloadToHeaderPeek(c *check.C) {
+	_, err := cs.cli.DownloadTo("foo", filepath.Join(c.MkDir(), "foo.snap"), &client.DownloadOptions{HeaderPeek: true})
+	c.Assert(err, check.ErrorMatches, `cannot download snap "foo" to a file with a header-only peek`)
+	c.Check(cs.req, check.IsNil)
+}
+
 func (cs *clientSuite) TestClientRefreshWithValidationSets(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
@@ -945,6 +1135,86 @@ func (cs *clientSuite) TestClientHoldMany(c *check.C) {
 	c.Check(cs.req.Header["Content-Type"], check.DeepEquals, []string{"application/json"})
 }
 
+func (cs *clientSuite) TestClientRefreshManyScheduleAt(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "12",
+		"status-code": 202,
+		"type": "async"
+	}`
+
+	chgID, err := cs.cli.RefreshMany(nil, nil, &client.SnapOptions{
+		ScheduleAt: "2025-06-07T03:00:00Z",
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "12")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var decodedBody map[string]any
+	c.Assert(json.Unmarshal(body, &decodedBody), check.IsNil)
+	c.Check(decodedBody, check.DeepEquals, map[string]any{
+		"action":      "refresh",
+		"schedule-at": "2025-06-07T03:00:00Z",
+	})
+}
+
+func (cs *clientSuite) TestClientQueue(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "12",
+		"status-code": 202,
+		"type": "async"
+	}`
+
+	chgID, err := cs.cli.Remove("foo", nil, &client.SnapOptions{Queue: true})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "12")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var decodedBody map[string]any
+	c.Assert(json.Unmarshal(body, &decodedBody), check.IsNil)
+	c.Check(decodedBody, check.DeepEquals, map[string]any{
+		"action": "remove",
+		"queue":  true,
+	})
+
+	_, err = cs.cli.RemoveMany([]string{"foo", "bar"}, nil, &client.SnapOptions{Queue: true})
+	c.Assert(err, check.IsNil)
+	body, err = io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	decodedBody = nil
+	c.Assert(json.Unmarshal(body, &decodedBody), check.IsNil)
+	c.Check(decodedBody, check.DeepEquals, map[string]any{
+		"action": "remove",
+		"snaps":  []any{"foo", "bar"},
+		"queue":  true,
+	})
+}
+
+func (cs *clientSuite) TestClientInstallArchitecture(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "12",
+		"status-code": 202,
+		"type": "async"
+	}`
+
+	chgID, err := cs.cli.Install("foo-data", nil, &client.SnapOptions{Architecture: "armhf"})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "12")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var decodedBody map[string]any
+	c.Assert(json.Unmarshal(body, &decodedBody), check.IsNil)
+	c.Check(decodedBody, check.DeepEquals, map[string]any{
+		"action":       "install",
+		"architecture": "armhf",
+	})
+}
+
 func (cs *clientSuite) testClientOpWithComponents(c *check.C, action func(name string, components []string, options *client.SnapOptions) (changeID string, err error)) {
 	cs.status = 202
 	cs.rsp = `{
@@ -0,0 +1,72 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+// SnapTracking describes how an installed snap relates to the channel it
+// tracks and to the enforced validation sets constraining it.
+type SnapTracking struct {
+	Name            string        `json:"name"`
+	TrackingChannel string        `json:"tracking-channel,omitempty"`
+	Revision        snap.Revision `json:"revision"`
+	// LatestRevision is the latest revision the store offers in the
+	// tracked channel, regardless of the enforced validation sets. It is
+	// unset if the store could not be reached or the snap is not from
+	// the store.
+	LatestRevision *snap.Revision `json:"latest-revision,omitempty"`
+	// Constraint is set if any enforced validation set mentions the snap.
+	Constraint *ValidationSetsConstraint `json:"validation-sets-constraint,omitempty"`
+}
+
+// ValidationSetsConstraint is the combined constraint enforced
+// validation sets put on a snap.
+type ValidationSetsConstraint struct {
+	// Presence is one of "required", "optional" or "invalid".
+	Presence string `json:"presence"`
+	// Revision is set if the snap is pinned to a specific revision.
+	Revision *snap.Revision `json:"revision,omitempty"`
+	// ValidationSets lists the validation sets the constraint stems from.
+	ValidationSets []string `json:"validation-sets"`
+}
+
+// SnapsTracking returns for each installed snap with a name in the given
+// list (or all snaps if the list is empty) its tracked channel, installed
+// and latest revisions and any validation sets constraint.
+func (client *Client) SnapsTracking(names []string) ([]*SnapTracking, error) {
+	q := make(url.Values)
+	q.Add("select", "tracking")
+	if len(names) > 0 {
+		q.Add("snaps", strings.Join(names, ","))
+	}
+
+	var tracking []*SnapTracking
+	if _, err := client.doSync("GET", "/v2/snaps", q, nil, nil, &tracking); err != nil {
+		return nil, xerrors.Errorf("cannot get snaps tracking information: %w", err)
+	}
+	return tracking, nil
+}
@@ -0,0 +1,82 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (cs *clientSuite) TestClientSnapsTracking(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [{
+			"name": "bar",
+			"tracking-channel": "2.0/edge",
+			"revision": "5",
+			"latest-revision": "7"
+		}, {
+			"name": "foo",
+			"tracking-channel": "latest/stable",
+			"revision": "10",
+			"latest-revision": "10",
+			"validation-sets-constraint": {
+				"presence": "required",
+				"revision": "10",
+				"validation-sets": ["16/acme/my-set/3"]
+			}
+		}]
+	}`
+	tracking, err := cs.cli.SnapsTracking([]string{"foo", "bar"})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+	c.Check(cs.req.URL.Query().Get("select"), check.Equals, "tracking")
+	c.Check(cs.req.URL.Query().Get("snaps"), check.Equals, "foo,bar")
+
+	latestBar := snap.R(7)
+	latestFoo := snap.R(10)
+	pinned := snap.R(10)
+	c.Check(tracking, check.DeepEquals, []*client.SnapTracking{{
+		Name:            "bar",
+		TrackingChannel: "2.0/edge",
+		Revision:        snap.R(5),
+		LatestRevision:  &latestBar,
+	}, {
+		Name:            "foo",
+		TrackingChannel: "latest/stable",
+		Revision:        snap.R(10),
+		LatestRevision:  &latestFoo,
+		Constraint: &client.ValidationSetsConstraint{
+			Presence:       "required",
+			Revision:       &pinned,
+			ValidationSets: []string{"16/acme/my-set/3"},
+		},
+	}})
+}
+
+func (cs *clientSuite) TestClientSnapsTrackingError(c *check.C) {
+	cs.status = 500
+	cs.rsp = `{"type": "error", "result": {"message": "cannot list updates: boom"}}`
+	_, err := cs.cli.SnapsTracking(nil)
+	c.Check(err, check.ErrorMatches, "cannot get snaps tracking information: cannot list updates: boom")
+}
@@ -21,12 +21,12 @@ package client
 
 import (
 	"bytes"
-	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
@@ -77,6 +77,9 @@ type Snapshot struct {
 	SHA3_384 map[string]string `json:"sha3-384"`
 	// the sum of the archive sizes
 	Size int64 `json:"size,omitempty"`
+	// the codec the archives are compressed with ("gzip", "zstd" or
+	// "none"); unset for older snapshots, which are gzip compressed
+	Compression string `json:"compression,omitempty"`
 
 	// dynamic snapshot options
 	Options *snap.SnapshotOptions `json:"options,omitempty"`
@@ -235,11 +238,63 @@ func (client *Client) snapshotAction(action *snapshotAction) (changeID string, e
 //
 // The return value includes the length of the returned stream.
 func (client *Client) SnapshotExport(setID uint64) (stream io.ReadCloser, contentLength int64, err error) {
-	rsp, err := client.raw(context.Background(), "GET", fmt.Sprintf("/v2/snapshots/%v/export", setID), nil, nil, nil)
+	rsp, err := client.snapshotExport(setID, nil)
 	if err != nil {
 		return nil, 0, err
 	}
-	if rsp.StatusCode != 200 {
+	return rsp.Body, rsp.ContentLength, nil
+}
+
+// SnapshotExportStream is a possibly partial stream of a snapshot export.
+type SnapshotExportStream struct {
+	io.ReadCloser
+	// Session identifies the exported archive and allows to resume an
+	// interrupted transfer of it.
+	Session string
+	// Offset is the offset in the archive of the first byte of the
+	// stream.
+	Offset int64
+	// Size is the size of the whole archive.
+	Size int64
+}
+
+// SnapshotExportResume resumes an interrupted export of the requested
+// snapshot set, identified by its session, streaming the archive from the
+// given offset. If the export cannot be resumed, for example because the
+// snapshot set changed since, the whole archive is streamed again under a
+// new session, with an Offset of 0.
+func (client *Client) SnapshotExportResume(setID uint64, session string, offset int64) (*SnapshotExportStream, error) {
+	headers := map[string]string{
+		"Range":    fmt.Sprintf("bytes=%d-", offset),
+		"If-Range": strconv.Quote(session),
+	}
+	rsp, err := client.snapshotExport(setID, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &SnapshotExportStream{
+		ReadCloser: rsp.Body,
+		Session:    strings.Trim(rsp.Header.Get("ETag"), `"`),
+		Size:       rsp.ContentLength,
+	}
+	if rsp.StatusCode == 206 {
+		var first, last int64
+		if _, err := fmt.Sscanf(rsp.Header.Get("Content-Range"), "bytes %d-%d/%d", &first, &last, &stream.Size); err != nil {
+			rsp.Body.Close()
+			return nil, fmt.Errorf("cannot parse snapshot export content range %q: %v", rsp.Header.Get("Content-Range"), err)
+		}
+		stream.Offset = first
+	}
+	return stream, nil
+}
+
+func (client *Client) snapshotExport(setID uint64, headers map[string]string) (*http.Response, error) {
+	rsp, err := client.raw(client.requestContext(), "GET", fmt.Sprintf("/v2/snapshots/%v/export", setID), nil, headers, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != 200 && rsp.StatusCode != 206 {
 		defer rsp.Body.Close()
 
 		var r response
@@ -247,17 +302,18 @@ func (client *Client) SnapshotExport(setID uint64) (stream io.ReadCloser, conten
 		if err := dec.Decode(&r); err == nil {
 			specificErr := r.err(client, rsp.StatusCode)
 			if specificErr != nil {
-				return nil, 0, specificErr
+				return nil, specificErr
 			}
 		}
-		return nil, 0, fmt.Errorf("unexpected status code: %v", rsp.Status)
+		return nil, fmt.Errorf("unexpected status code: %v", rsp.Status)
 	}
 	contentType := rsp.Header.Get("Content-Type")
 	if contentType != SnapshotExportMediaType {
-		return nil, 0, fmt.Errorf("unexpected snapshot export content type %q", contentType)
+		rsp.Body.Close()
+		return nil, fmt.Errorf("unexpected snapshot export content type %q", contentType)
 	}
 
-	return rsp.Body, rsp.ContentLength, nil
+	return rsp, nil
 }
 
 // SnapshotImportSet is a snapshot import created by a "snap import-snapshot".
@@ -192,6 +192,59 @@ func (cs *clientSuite) TestClientExportSnapshot(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientExportSnapshotResume(c *check.C) {
+	cs.contentLength = 5
+	cs.header = http.Header{
+		"Content-Type":  []string{client.SnapshotExportMediaType},
+		"Etag":          []string{`"1234-abcd"`},
+		"Content-Range": []string{"bytes 95-99/100"},
+	}
+	cs.rsp = "12345"
+	cs.status = 206
+
+	stream, err := cs.cli.SnapshotExportResume(42, "1234-abcd", 95)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snapshots/42/export")
+	c.Check(cs.req.Header.Get("Range"), check.Equals, "bytes=95-")
+	c.Check(cs.req.Header.Get("If-Range"), check.Equals, `"1234-abcd"`)
+	c.Check(stream.Session, check.Equals, "1234-abcd")
+	c.Check(stream.Offset, check.Equals, int64(95))
+	c.Check(stream.Size, check.Equals, int64(100))
+	buf, err := io.ReadAll(stream)
+	c.Assert(err, check.IsNil)
+	c.Check(string(buf), check.Equals, "12345")
+	c.Check(stream.Close(), check.IsNil)
+}
+
+func (cs *clientSuite) TestClientExportSnapshotResumeRestarted(c *check.C) {
+	// the snapshot set changed, the whole archive is sent again
+	cs.contentLength = 10
+	cs.header = http.Header{
+		"Content-Type": []string{client.SnapshotExportMediaType},
+		"Etag":         []string{`"5678-ef01"`},
+	}
+	cs.rsp = "0123456789"
+	cs.status = 200
+
+	stream, err := cs.cli.SnapshotExportResume(42, "1234-abcd", 5)
+	c.Assert(err, check.IsNil)
+	c.Check(stream.Session, check.Equals, "5678-ef01")
+	c.Check(stream.Offset, check.Equals, int64(0))
+	c.Check(stream.Size, check.Equals, int64(10))
+}
+
+func (cs *clientSuite) TestClientExportSnapshotResumeBadContentRange(c *check.C) {
+	cs.header = http.Header{
+		"Content-Type":  []string{client.SnapshotExportMediaType},
+		"Content-Range": []string{"bytes */100"},
+	}
+	cs.status = 206
+
+	_, err := cs.cli.SnapshotExportResume(42, "1234-abcd", 5)
+	c.Check(err, check.ErrorMatches, `cannot parse snapshot export content range "bytes \*/100": .*`)
+	c.Check(cs.countingCloser.closeCalled, check.Equals, 1)
+}
+
 func (cs *clientSuite) TestClientSnapshotImport(c *check.C) {
 	type tableT struct {
 		rsp    string
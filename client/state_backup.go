@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// StateBackupMediaType is the media type of a state backup.
+const StateBackupMediaType = "application/x-tar"
+
+// StateBackup describes a backup of the state and the assertion database
+// of snapd.
+type StateBackup struct {
+	Format       int       `json:"format"`
+	Time         time.Time `json:"time"`
+	SnapdVersion string    `json:"snapd-version"`
+	Brand        string    `json:"brand,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	Serial       string    `json:"serial,omitempty"`
+}
+
+// DebugStateExport returns a stream of a backup of the state and the
+// assertion database of snapd.
+func (client *Client) DebugStateExport() (io.ReadCloser, error) {
+	rsp, err := client.raw(client.requestContext(), "GET", "/v2/debug/state", nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != 200 {
+		defer rsp.Body.Close()
+
+		var r response
+		dec := json.NewDecoder(rsp.Body)
+		if err := dec.Decode(&r); err == nil {
+			if specificErr := r.err(client, rsp.StatusCode); specificErr != nil {
+				return nil, specificErr
+			}
+		}
+		return nil, fmt.Errorf("unexpected status code: %v", rsp.Status)
+	}
+	if contentType := rsp.Header.Get("Content-Type"); contentType != StateBackupMediaType {
+		rsp.Body.Close()
+		return nil, fmt.Errorf("unexpected state backup content type %q", contentType)
+	}
+	return rsp.Body, nil
+}
+
+// DebugStateImport checks the given state backup and stages it to be
+// restored, snapd then restarts to restore it.
+func (client *Client) DebugStateImport(backup io.Reader) (*StateBackup, error) {
+	headers := map[string]string{
+		"Content-Type": StateBackupMediaType,
+	}
+	var info StateBackup
+	if _, err := client.doSync("POST", "/v2/debug/state", nil, headers, backup, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
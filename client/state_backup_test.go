@@ -0,0 +1,78 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestDebugStateExport(c *C) {
+	cs.header = http.Header{"Content-Type": []string{client.StateBackupMediaType}}
+	cs.rsp = "tarball"
+
+	stream, err := cs.cli.DebugStateExport()
+	c.Assert(err, IsNil)
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	c.Assert(err, IsNil)
+	c.Check(string(data), Equals, "tarball")
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/debug/state")
+}
+
+func (cs *clientSuite) TestDebugStateExportErrors(c *C) {
+	cs.status = 403
+	cs.rsp = `{"type": "error", "status-code": 403, "result": {"message": "access denied", "kind": "login-required"}}`
+	_, err := cs.cli.DebugStateExport()
+	c.Check(err, ErrorMatches, "access denied")
+
+	cs.status = 200
+	cs.header = http.Header{"Content-Type": []string{"text/plain"}}
+	cs.rsp = "nope"
+	_, err = cs.cli.DebugStateExport()
+	c.Check(err, ErrorMatches, `unexpected state backup content type "text/plain"`)
+}
+
+func (cs *clientSuite) TestDebugStateImport(c *C) {
+	cs.rsp = `{"type": "sync", "result": {"format": 1, "time": "2025-06-01T10:00:00Z", "snapd-version": "2.70", "brand": "my-brand", "model": "my-model"}}`
+
+	info, err := cs.cli.DebugStateImport(strings.NewReader("tarball"))
+	c.Assert(err, IsNil)
+	c.Check(info, DeepEquals, &client.StateBackup{
+		Format:       1,
+		Time:         time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+		SnapdVersion: "2.70",
+		Brand:        "my-brand",
+		Model:        "my-model",
+	})
+	c.Check(cs.req.Method, Equals, "POST")
+	c.Check(cs.req.URL.Path, Equals, "/v2/debug/state")
+	c.Check(cs.req.Header.Get("Content-Type"), Equals, client.StateBackupMediaType)
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, IsNil)
+	c.Check(string(body), Equals, "tarball")
+}
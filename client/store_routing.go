@@ -0,0 +1,84 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SnapStoreRoute holds the store a snap resolves to.
+type SnapStoreRoute struct {
+	Snap  string `json:"snap"`
+	Store string `json:"store"`
+	// Routed is set if the snap is explicitly routed to the store,
+	// instead of resolving through the store of the device.
+	Routed bool `json:"routed,omitempty"`
+}
+
+// StoreRouting describes the stores snaps resolve to on the device. The
+// global store is reported as "global".
+type StoreRouting struct {
+	DeviceStore    string           `json:"device-store,omitempty"`
+	FriendlyStores []string         `json:"friendly-stores,omitempty"`
+	Snaps          []SnapStoreRoute `json:"snaps"`
+}
+
+// StoreRouting returns the store routing of the device, listing the
+// store each installed or explicitly routed snap resolves to.
+func (client *Client) StoreRouting() (*StoreRouting, error) {
+	var routing StoreRouting
+	if _, err := client.doSync("GET", "/v2/store-routing", nil, nil, nil, &routing); err != nil {
+		return nil, fmt.Errorf("cannot get store routing: %v", err)
+	}
+	return &routing, nil
+}
+
+type postStoreRoutingData struct {
+	Action string `json:"action"`
+	Snap   string `json:"snap"`
+	Store  string `json:"store,omitempty"`
+}
+
+func (client *Client) postStoreRouting(data *postStoreRoutingData) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(data); err != nil {
+		return err
+	}
+	_, err := client.doSync("POST", "/v2/store-routing", nil, nil, &body, nil)
+	return err
+}
+
+// RouteSnapToStore routes the store requests concerning the given snap to
+// the given store, which must be the device store, a friendly store of
+// it, or "global" for the global store.
+func (client *Client) RouteSnapToStore(snapName, storeID string) error {
+	return client.postStoreRouting(&postStoreRoutingData{Action: "set", Snap: snapName, Store: storeID})
+}
+
+// RemoveSnapStoreRoute removes the explicit store route of the given
+// snap, which then resolves through the store of the device again.
+func (client *Client) RemoveSnapStoreRoute(snapName string) error {
+	if err := client.postStoreRouting(&postStoreRoutingData{Action: "remove", Snap: snapName}); err != nil {
+		return fmt.Errorf("cannot remove store route of snap %q: %v", snapName, err)
+	}
+	return nil
+}
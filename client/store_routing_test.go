@@ -0,0 +1,93 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestClientStoreRouting(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"device-store": "brand-store",
+			"friendly-stores": ["friendly-store"],
+			"snaps": [
+				{"snap": "bar", "store": "brand-store"},
+				{"snap": "foo", "store": "friendly-store", "routed": true}
+			]
+		}
+	}`
+	routing, err := cs.cli.StoreRouting()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/store-routing")
+	c.Check(routing, check.DeepEquals, &client.StoreRouting{
+		DeviceStore:    "brand-store",
+		FriendlyStores: []string{"friendly-store"},
+		Snaps: []client.SnapStoreRoute{
+			{Snap: "bar", Store: "brand-store"},
+			{Snap: "foo", Store: "friendly-store", Routed: true},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientRouteSnapToStore(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": null}`
+	err := cs.cli.RouteSnapToStore("foo", "friendly-store")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/store-routing")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var data map[string]any
+	c.Assert(json.Unmarshal(body, &data), check.IsNil)
+	c.Check(data, check.DeepEquals, map[string]any{
+		"action": "set",
+		"snap":   "foo",
+		"store":  "friendly-store",
+	})
+}
+
+func (cs *clientSuite) TestClientRemoveSnapStoreRoute(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": null}`
+	err := cs.cli.RemoveSnapStoreRoute("foo")
+	c.Assert(err, check.IsNil)
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var data map[string]any
+	c.Assert(json.Unmarshal(body, &data), check.IsNil)
+	c.Check(data, check.DeepEquals, map[string]any{
+		"action": "remove",
+		"snap":   "foo",
+	})
+
+	cs.rsp = `{"type": "error", "status-code": 400, "result": {"message": "boom"}}`
+	err = cs.cli.RemoveSnapStoreRoute("foo")
+	c.Check(err, check.ErrorMatches, `cannot remove store route of snap "foo": boom`)
+}
@@ -20,6 +20,14 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"golang.org/x/xerrors"
+
 	"github.com/snapcore/snapd/gadget/device"
 )
 
@@ -58,3 +66,300 @@ type ChangePassphraseOptions struct {
 	OldPassphrase string `json:"old-passphrase"`
 	NewPassphrase string `json:"new-passphrase"`
 }
+
+type ChangePINOptions struct {
+	OldPIN string `json:"old-pin"`
+	NewPIN string `json:"new-pin"`
+}
+
+// AuthQuality describes the quality of a passphrase or PIN.
+type AuthQuality struct {
+	EntropyBits        uint32 `json:"entropy-bits"`
+	MinEntropyBits     uint32 `json:"min-entropy-bits"`
+	OptimalEntropyBits uint32 `json:"optimal-entropy-bits"`
+}
+
+// KeyslotRef identifies a key slot by its container role and name.
+type KeyslotRef struct {
+	ContainerRole string `json:"container-role"`
+	Name          string `json:"name"`
+}
+
+// ListKeyslots returns the encryption state and key slots of the system
+// volumes, grouped by container role.
+func (client *Client) ListKeyslots(opts *SystemVolumesOptions) (*SystemVolumesResult, error) {
+	if opts == nil {
+		opts = &SystemVolumesOptions{}
+	}
+	if len(opts.ContainerRoles) > 0 && opts.ByContainerRole {
+		return nil, fmt.Errorf("cannot list key slots: container roles cannot be combined with grouping by container role")
+	}
+
+	query := url.Values{}
+	for _, role := range opts.ContainerRoles {
+		query.Add("container-role", role)
+	}
+	if opts.ByContainerRole {
+		query.Set("by-container-role", "true")
+	}
+
+	var res SystemVolumesResult
+	if _, err := client.doSync("GET", "/v2/system-volumes", query, nil, nil, &res); err != nil {
+		fmt := "cannot list key slots: %w"
+		return nil, xerrors.Errorf(fmt, err)
+	}
+	return &res, nil
+}
+
+type systemVolumesActionRequest struct {
+	Action   string       `json:"action"`
+	KeyID    string       `json:"key-id,omitempty"`
+	Keyslots []KeyslotRef `json:"keyslots,omitempty"`
+
+	VolumeName  string `json:"volume-name,omitempty"`
+	ReadOnly    bool   `json:"read-only,omitempty"`
+	RelockAfter string `json:"relock-after,omitempty"`
+
+	*QualityCheckOptions
+	*ChangePassphraseOptions
+	*ChangePINOptions
+}
+
+func (client *Client) doSystemVolumesAction(req *systemVolumesActionRequest, v any) (changeID string, err error) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(req); err != nil {
+		return "", err
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if v != nil {
+		_, err = client.doSync("POST", "/v2/system-volumes", nil, headers, &body, v)
+		return "", err
+	}
+	return client.doAsync("POST", "/v2/system-volumes", nil, headers, &body)
+}
+
+// GenerateRecoveryKey generates a new recovery key which can then be
+// enrolled with AddRecoveryKey or ReplaceRecoveryKey by referring to
+// the returned key ID.
+func (client *Client) GenerateRecoveryKey() (recoveryKey, keyID string, err error) {
+	var rsp struct {
+		RecoveryKey string `json:"recovery-key"`
+		KeyID       string `json:"key-id"`
+	}
+	req := &systemVolumesActionRequest{Action: "generate-recovery-key"}
+	if _, err := client.doSystemVolumesAction(req, &rsp); err != nil {
+		fmt := "cannot generate recovery key: %w"
+		return "", "", xerrors.Errorf(fmt, err)
+	}
+	return rsp.RecoveryKey, rsp.KeyID, nil
+}
+
+// AddRecoveryKey adds new recovery key slots using the recovery key
+// identified by keyID. The key slots must not exist yet.
+func (client *Client) AddRecoveryKey(keyID string, keyslots []KeyslotRef) (changeID string, err error) {
+	if keyID == "" {
+		return "", fmt.Errorf("cannot add recovery key without a key ID")
+	}
+	if len(keyslots) == 0 {
+		return "", fmt.Errorf("cannot add recovery key without key slots")
+	}
+
+	req := &systemVolumesActionRequest{
+		Action:   "add-recovery-key",
+		KeyID:    keyID,
+		Keyslots: keyslots,
+	}
+	chgID, err := client.doSystemVolumesAction(req, nil)
+	if err != nil {
+		fmt := "cannot add recovery key: %w"
+		return "", xerrors.Errorf(fmt, err)
+	}
+	return chgID, nil
+}
+
+// ReplaceRecoveryKey replaces the recovery key of the given key slots
+// with the recovery key identified by keyID. If no key slots are given
+// the default recovery key slots are replaced.
+func (client *Client) ReplaceRecoveryKey(keyID string, keyslots []KeyslotRef) (changeID string, err error) {
+	if keyID == "" {
+		return "", fmt.Errorf("cannot replace recovery key without a key ID")
+	}
+
+	req := &systemVolumesActionRequest{
+		Action:   "replace-recovery-key",
+		KeyID:    keyID,
+		Keyslots: keyslots,
+	}
+	chgID, err := client.doSystemVolumesAction(req, nil)
+	if err != nil {
+		fmt := "cannot replace recovery key: %w"
+		return "", xerrors.Errorf(fmt, err)
+	}
+	return chgID, nil
+}
+
+// RemoveKeyslot removes the given key slots. Only recovery key slots
+// that are not managed by snapd itself can be removed.
+func (client *Client) RemoveKeyslot(keyslots []KeyslotRef) (changeID string, err error) {
+	if len(keyslots) == 0 {
+		return "", fmt.Errorf("cannot remove key slots: no key slots given")
+	}
+
+	req := &systemVolumesActionRequest{
+		Action:   "remove-keyslots",
+		Keyslots: keyslots,
+	}
+	chgID, err := client.doSystemVolumesAction(req, nil)
+	if err != nil {
+		fmt := "cannot remove key slots: %w"
+		return "", xerrors.Errorf(fmt, err)
+	}
+	return chgID, nil
+}
+
+// CheckVolumesAuthQuality checks the quality of the passphrase or PIN set in
+// opts, exactly one of them must be set. If the quality checks fail, the
+// returned error is a *Error of kind ErrorKindInvalidPassphrase or
+// ErrorKindInvalidPIN.
+func (client *Client) CheckVolumesAuthQuality(opts QualityCheckOptions) (*AuthQuality, error) {
+	var action string
+	switch {
+	case opts.Passphrase != "" && opts.PIN != "":
+		return nil, fmt.Errorf("cannot check passphrase and PIN at the same time")
+	case opts.Passphrase != "":
+		action = "check-passphrase"
+	case opts.PIN != "":
+		action = "check-pin"
+	default:
+		return nil, fmt.Errorf("cannot check quality without a passphrase or PIN")
+	}
+
+	req := &systemVolumesActionRequest{
+		Action:              action,
+		QualityCheckOptions: &opts,
+	}
+	var quality AuthQuality
+	if _, err := client.doSystemVolumesAction(req, &quality); err != nil {
+		return nil, err
+	}
+	return &quality, nil
+}
+
+// ChangeVolumesPassphrase changes the passphrase of the passphrase protected
+// key slots of the system volumes. The quality of the new passphrase is
+// checked first.
+func (client *Client) ChangeVolumesPassphrase(oldPassphrase, newPassphrase string) (changeID string, err error) {
+	if oldPassphrase == "" || newPassphrase == "" {
+		return "", fmt.Errorf("cannot change passphrase: old and new passphrase must be provided")
+	}
+	if _, err := client.CheckVolumesAuthQuality(QualityCheckOptions{Passphrase: newPassphrase}); err != nil {
+		return "", err
+	}
+
+	req := &systemVolumesActionRequest{
+		Action: "change-passphrase",
+		ChangePassphraseOptions: &ChangePassphraseOptions{
+			OldPassphrase: oldPassphrase,
+			NewPassphrase: newPassphrase,
+		},
+	}
+	chgID, err := client.doSystemVolumesAction(req, nil)
+	if err != nil {
+		fmt := "cannot change passphrase: %w"
+		return "", xerrors.Errorf(fmt, err)
+	}
+	return chgID, nil
+}
+
+// ChangeVolumesPIN changes the PIN of the PIN protected key slots of the
+// system volumes. The quality of the new PIN is checked first.
+func (client *Client) ChangeVolumesPIN(oldPIN, newPIN string) (changeID string, err error) {
+	if oldPIN == "" || newPIN == "" {
+		return "", fmt.Errorf("cannot change PIN: old and new PIN must be provided")
+	}
+	if _, err := client.CheckVolumesAuthQuality(QualityCheckOptions{PIN: newPIN}); err != nil {
+		return "", err
+	}
+
+	req := &systemVolumesActionRequest{
+		Action: "change-pin",
+		ChangePINOptions: &ChangePINOptions{
+			OldPIN: oldPIN,
+			NewPIN: newPIN,
+		},
+	}
+	chgID, err := client.doSystemVolumesAction(req, nil)
+	if err != nil {
+		fmt := "cannot change PIN: %w"
+		return "", xerrors.Errorf(fmt, err)
+	}
+	return chgID, nil
+}
+
+// MountVolumeOptions holds options for MountSystemVolume.
+type MountVolumeOptions struct {
+	// ReadOnly mounts the volume read-only.
+	ReadOnly bool
+	// RelockAfter is the time after which the volume is unmounted
+	// again, snapd picks a default if unset.
+	RelockAfter time.Duration
+}
+
+// SystemVolumeMount describes a system volume mounted on demand.
+type SystemVolumeMount struct {
+	Name       string    `json:"name"`
+	Role       string    `json:"role,omitempty"`
+	Device     string    `json:"device"`
+	MountPoint string    `json:"mount-point"`
+	ReadOnly   bool      `json:"read-only,omitempty"`
+	RelockTime time.Time `json:"relock-time"`
+}
+
+// MountSystemVolume mounts ubuntu-save, identified by its gadget structure
+// name. The volume is unmounted automatically once its relock time is
+// reached, mounting it again extends the relock time. If the volume is
+// mounted already by the system its existing mount point is returned
+// without a relock time.
+func (client *Client) MountSystemVolume(name string, opts *MountVolumeOptions) (*SystemVolumeMount, error) {
+	if name == "" {
+		return nil, fmt.Errorf("cannot mount system volume without a volume name")
+	}
+	if opts == nil {
+		opts = &MountVolumeOptions{}
+	}
+
+	req := &systemVolumesActionRequest{
+		Action:     "mount-volume",
+		VolumeName: name,
+		ReadOnly:   opts.ReadOnly,
+	}
+	if opts.RelockAfter != 0 {
+		req.RelockAfter = opts.RelockAfter.String()
+	}
+	var mnt SystemVolumeMount
+	if _, err := client.doSystemVolumesAction(req, &mnt); err != nil {
+		fmt := "cannot mount system volume: %w"
+		return nil, xerrors.Errorf(fmt, err)
+	}
+	return &mnt, nil
+}
+
+// UnmountSystemVolume unmounts a system volume previously mounted with
+// MountSystemVolume.
+func (client *Client) UnmountSystemVolume(name string) error {
+	if name == "" {
+		return fmt.Errorf("cannot unmount system volume without a volume name")
+	}
+
+	req := &systemVolumesActionRequest{
+		Action:     "unmount-volume",
+		VolumeName: name,
+	}
+	if _, err := client.doSystemVolumesAction(req, &struct{}{}); err != nil {
+		fmt := "cannot unmount system volume: %w"
+		return xerrors.Errorf(fmt, err)
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/gadget/device"
+)
+
+func (cs *clientSuite) TestListKeyslots(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"by-container-role": {
+				"system-data": {
+					"volume-name": "pc",
+					"name": "ubuntu-data",
+					"encrypted": true,
+					"keyslots": {
+						"default": {"type": "platform", "roles": ["run"], "platform-name": "tpm2", "auth-mode": "passphrase"},
+						"default-recovery": {"type": "recovery"}
+					}
+				}
+			}
+		}
+	}`
+	res, err := cs.cli.ListKeyslots(&client.SystemVolumesOptions{ContainerRoles: []string{"system-data"}})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/system-volumes")
+	c.Check(cs.req.URL.RawQuery, check.Equals, "container-role=system-data")
+	c.Check(res, check.DeepEquals, &client.SystemVolumesResult{
+		ByContainerRole: map[string]client.SystemVolumesStructureInfo{
+			"system-data": {
+				VolumeName: "pc",
+				Name:       "ubuntu-data",
+				Encrypted:  true,
+				Keyslots: map[string]client.KeyslotInfo{
+					"default": {
+						Type:         client.KeyslotTypePlatform,
+						Roles:        []string{"run"},
+						PlatformName: "tpm2",
+						AuthMode:     device.AuthModePassphrase,
+					},
+					"default-recovery": {Type: client.KeyslotTypeRecovery},
+				},
+			},
+		},
+	})
+
+	_, err = cs.cli.ListKeyslots(&client.SystemVolumesOptions{ByContainerRole: true})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.RawQuery, check.Equals, "by-container-role=true")
+
+	_, err = cs.cli.ListKeyslots(nil)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+}
+
+func (cs *clientSuite) TestListKeyslotsErrors(c *check.C) {
+	_, err := cs.cli.ListKeyslots(&client.SystemVolumesOptions{ContainerRoles: []string{"system-data"}, ByContainerRole: true})
+	c.Assert(err, check.ErrorMatches, "cannot list key slots: container roles cannot be combined with grouping by container role")
+	c.Check(cs.req, check.IsNil)
+
+	cs.status = 500
+	cs.rsp = `{"type": "error", "status-code": 500, "result": {"message": "boom"}}`
+	_, err = cs.cli.ListKeyslots(nil)
+	c.Assert(err, check.ErrorMatches, "cannot list key slots: boom")
+}
+
+func (cs *clientSuite) TestGenerateRecoveryKey(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"recovery-key": "11111-22222", "key-id": "some-key-id"}
+	}`
+	rkey, keyID, err := cs.cli.GenerateRecoveryKey()
+	c.Assert(err, check.IsNil)
+	c.Check(rkey, check.Equals, "11111-22222")
+	c.Check(keyID, check.Equals, "some-key-id")
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/system-volumes")
+	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
+	var req map[string]any
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&req), check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]any{"action": "generate-recovery-key"})
+}
+
+func (cs *clientSuite) testKeyslotsAction(c *check.C, action func() (string, error), expectedBody map[string]any) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+	chgID, err := action()
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/system-volumes")
+	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]any
+	c.Assert(json.Unmarshal(body, &req), check.IsNil)
+	c.Check(req, check.DeepEquals, expectedBody)
+}
+
+func (cs *clientSuite) TestAddRecoveryKey(c *check.C) {
+	keyslots := []client.KeyslotRef{{ContainerRole: "system-data", Name: "extra-recovery"}}
+	cs.testKeyslotsAction(c, func() (string, error) {
+		return cs.cli.AddRecoveryKey("some-key-id", keyslots)
+	}, map[string]any{
+		"action": "add-recovery-key",
+		"key-id": "some-key-id",
+		"keyslots": []any{
+			map[string]any{"container-role": "system-data", "name": "extra-recovery"},
+		},
+	})
+}
+
+func (cs *clientSuite) TestReplaceRecoveryKey(c *check.C) {
+	cs.testKeyslotsAction(c, func() (string, error) {
+		return cs.cli.ReplaceRecoveryKey("some-key-id", nil)
+	}, map[string]any{
+		"action": "replace-recovery-key",
+		"key-id": "some-key-id",
+	})
+}
+
+func (cs *clientSuite) TestRemoveKeyslot(c *check.C) {
+	keyslots := []client.KeyslotRef{{ContainerRole: "system-save", Name: "extra-recovery"}}
+	cs.testKeyslotsAction(c, func() (string, error) {
+		return cs.cli.RemoveKeyslot(keyslots)
+	}, map[string]any{
+		"action": "remove-keyslots",
+		"keyslots": []any{
+			map[string]any{"container-role": "system-save", "name": "extra-recovery"},
+		},
+	})
+}
+
+func (cs *clientSuite) TestKeyslotsActionsErrors(c *check.C) {
+	_, err := cs.cli.AddRecoveryKey("", []client.KeyslotRef{{ContainerRole: "system-data", Name: "foo"}})
+	c.Check(err, check.ErrorMatches, "cannot add recovery key without a key ID")
+	_, err = cs.cli.AddRecoveryKey("some-key-id", nil)
+	c.Check(err, check.ErrorMatches, "cannot add recovery key without key slots")
+	_, err = cs.cli.ReplaceRecoveryKey("", nil)
+	c.Check(err, check.ErrorMatches, "cannot replace recovery key without a key ID")
+	_, err = cs.cli.RemoveKeyslot(nil)
+	c.Check(err, check.ErrorMatches, "cannot remove key slots: no key slots given")
+	// no request was performed
+	c.Check(cs.req, check.IsNil)
+
+	cs.status = 400
+	cs.rsp = `{"type": "error", "status-code": 400, "result": {"message": "boom"}}`
+	_, err = cs.cli.RemoveKeyslot([]client.KeyslotRef{{ContainerRole: "system-data", Name: "foo"}})
+	c.Check(err, check.ErrorMatches, "cannot remove key slots: boom")
+}
+
+func (cs *clientSuite) TestCheckVolumesAuthQuality(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"entropy-bits": 50, "min-entropy-bits": 40, "optimal-entropy-bits": 60}
+	}`
+	quality, err := cs.cli.CheckVolumesAuthQuality(client.QualityCheckOptions{PIN: "1234"})
+	c.Assert(err, check.IsNil)
+	c.Check(quality, check.DeepEquals, &client.AuthQuality{
+		EntropyBits:        50,
+		MinEntropyBits:     40,
+		OptimalEntropyBits: 60,
+	})
+	var req map[string]any
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&req), check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]any{"action": "check-pin", "pin": "1234"})
+}
+
+func (cs *clientSuite) TestCheckVolumesAuthQualityErrors(c *check.C) {
+	_, err := cs.cli.CheckVolumesAuthQuality(client.QualityCheckOptions{})
+	c.Check(err, check.ErrorMatches, "cannot check quality without a passphrase or PIN")
+	_, err = cs.cli.CheckVolumesAuthQuality(client.QualityCheckOptions{Passphrase: "foo", PIN: "1234"})
+	c.Check(err, check.ErrorMatches, "cannot check passphrase and PIN at the same time")
+	c.Check(cs.req, check.IsNil)
+
+	cs.status = 400
+	cs.rsp = `{
+		"type": "error",
+		"status-code": 400,
+		"result": {
+			"message": "passphrase did not pass quality checks",
+			"kind": "invalid-passphrase",
+			"value": {"reasons": ["low-entropy"], "entropy-bits": 10, "min-entropy-bits": 40, "optimal-entropy-bits": 60}
+		}
+	}`
+	_, err = cs.cli.CheckVolumesAuthQuality(client.QualityCheckOptions{Passphrase: "foo"})
+	c.Assert(err, check.ErrorMatches, "passphrase did not pass quality checks")
+	c.Check(err.(*client.Error).Kind, check.Equals, client.ErrorKindInvalidPassphrase)
+}
+
+func (cs *clientSuite) TestChangeVolumesPassphrase(c *check.C) {
+	cs.rsps = []string{
+		`{"type": "sync", "status-code": 200, "result": {"entropy-bits": 50, "min-entropy-bits": 40, "optimal-entropy-bits": 60}}`,
+		`{"type": "async", "status-code": 202, "change": "42"}`,
+	}
+	cs.statuses = []int{200, 202}
+
+	chgID, err := cs.cli.ChangeVolumesPassphrase("old", "new-passphrase")
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	c.Assert(cs.reqs, check.HasLen, 2)
+
+	var req map[string]any
+	c.Assert(json.NewDecoder(cs.reqs[0].Body).Decode(&req), check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]any{"action": "check-passphrase", "passphrase": "new-passphrase"})
+	req = nil
+	c.Assert(json.NewDecoder(cs.reqs[1].Body).Decode(&req), check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]any{
+		"action":         "change-passphrase",
+		"old-passphrase": "old",
+		"new-passphrase": "new-passphrase",
+	})
+}
+
+func (cs *clientSuite) TestChangeVolumesPassphraseQualityError(c *check.C) {
+	cs.status = 400
+	cs.rsp = `{
+		"type": "error",
+		"status-code": 400,
+		"result": {"message": "passphrase did not pass quality checks", "kind": "invalid-passphrase"}
+	}`
+	_, err := cs.cli.ChangeVolumesPassphrase("old", "new")
+	c.Assert(err, check.ErrorMatches, "passphrase did not pass quality checks")
+	c.Check(err.(*client.Error).Kind, check.Equals, client.ErrorKindInvalidPassphrase)
+	// no change was requested
+	c.Check(cs.reqs, check.HasLen, 1)
+
+	_, err = cs.cli.ChangeVolumesPassphrase("", "new")
+	c.Check(err, check.ErrorMatches, "cannot change passphrase: old and new passphrase must be provided")
+}
+
+func (cs *clientSuite) TestChangeVolumesPIN(c *check.C) {
+	cs.rsps = []string{
+		`{"type": "sync", "status-code": 200, "result": {"entropy-bits": 13, "min-entropy-bits": 13, "optimal-entropy-bits": 20}}`,
+		`{"type": "async", "status-code": 202, "change": "42"}`,
+	}
+	cs.statuses = []int{200, 202}
+
+	chgID, err := cs.cli.ChangeVolumesPIN("1234", "5678")
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	c.Assert(cs.reqs, check.HasLen, 2)
+
+	var req map[string]any
+	c.Assert(json.NewDecoder(cs.reqs[0].Body).Decode(&req), check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]any{"action": "check-pin", "pin": "5678"})
+	req = nil
+	c.Assert(json.NewDecoder(cs.reqs[1].Body).Decode(&req), check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]any{
+		"action":  "change-pin",
+		"old-pin": "1234",
+		"new-pin": "5678",
+	})
+
+	_, err = cs.cli.ChangeVolumesPIN("1234", "")
+	c.Check(err, check.ErrorMatches, "cannot change PIN: old and new PIN must be provided")
+}
+
+func (cs *clientSuite) TestMountSystemVolume(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"name": "factory-data",
+			"device": "/dev/disk/by-label/factory-data",
+			"mount-point": "/run/snapd/system-volumes/factory-data",
+			"read-only": true,
+			"relock-time": "2025-06-01T10:05:00Z"
+		}
+	}`
+	mnt, err := cs.cli.MountSystemVolume("factory-data", &client.MountVolumeOptions{
+		ReadOnly:    true,
+		RelockAfter: 5 * time.Minute,
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(mnt, check.DeepEquals, &client.SystemVolumeMount{
+		Name:       "factory-data",
+		Device:     "/dev/disk/by-label/factory-data",
+		MountPoint: "/run/snapd/system-volumes/factory-data",
+		ReadOnly:   true,
+		RelockTime: time.Date(2025, 6, 1, 10, 5, 0, 0, time.UTC),
+	})
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/system-volumes")
+	var req map[string]any
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&req), check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]any{
+		"action":       "mount-volume",
+		"volume-name":  "factory-data",
+		"read-only":    true,
+		"relock-after": "5m0s",
+	})
+}
+
+func (cs *clientSuite) TestUnmountSystemVolume(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": null}`
+	err := cs.cli.UnmountSystemVolume("ubuntu-save")
+	c.Assert(err, check.IsNil)
+
+	var req map[string]any
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&req), check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]any{
+		"action":      "unmount-volume",
+		"volume-name": "ubuntu-save",
+	})
+}
+
+func (cs *clientSuite) TestMountSystemVolumeErrors(c *check.C) {
+	_, err := cs.cli.MountSystemVolume("", nil)
+	c.Check(err, check.ErrorMatches, "cannot mount system volume without a volume name")
+	err = cs.cli.UnmountSystemVolume("")
+	c.Check(err, check.ErrorMatches, "cannot unmount system volume without a volume name")
+	c.Check(cs.req, check.IsNil)
+
+	cs.status = 400
+	cs.rsp = `{"type": "error", "result": {"message": "system volume is not mounted"}}`
+	_, err = cs.cli.MountSystemVolume("ubuntu-save", nil)
+	c.Check(err, check.ErrorMatches, "cannot mount system volume: system volume is not mounted")
+	err = cs.cli.UnmountSystemVolume("ubuntu-save")
+	c.Check(err, check.ErrorMatches, "cannot unmount system volume: system volume is not mounted")
+}
@@ -23,9 +23,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"golang.org/x/xerrors"
 
+	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/gadget"
 	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/secboot"
@@ -75,7 +82,8 @@ func (client *Client) ListSystems() ([]System, error) {
 	var rsp systemsResponse
 
 	if _, err := client.doSync("GET", "/v2/systems", nil, nil, nil, &rsp); err != nil {
-		return nil, xerrors.Errorf("cannot list recovery systems: %v", err)
+		fmt := "cannot list recovery systems: %w"
+		return nil, xerrors.Errorf(fmt, err)
 	}
 	return rsp.Systems, nil
 }
@@ -104,7 +112,8 @@ func (client *Client) DoSystemAction(systemLabel string, action *SystemAction) e
 		return err
 	}
 	if _, err := client.doSync("POST", "/v2/systems/"+systemLabel, nil, nil, &body, nil); err != nil {
-		return xerrors.Errorf("cannot request system action: %v", err)
+		fmt := "cannot request system action: %w"
+		return xerrors.Errorf(fmt, err)
 	}
 	return nil
 }
@@ -137,13 +146,55 @@ func (client *Client) RebootToSystem(systemLabel, mode string) error {
 	}
 	if _, err := client.doSync("POST", "/v2/systems/"+systemLabel, nil, nil, &body, nil); err != nil {
 		if systemLabel != "" {
-			return xerrors.Errorf("cannot request system reboot into %q: %v", systemLabel, err)
+			fmt := "cannot request system reboot into %q: %w"
+			return xerrors.Errorf(fmt, systemLabel, err)
 		}
-		return xerrors.Errorf("cannot request system reboot: %v", err)
+		fmt := "cannot request system reboot: %w"
+		return xerrors.Errorf(fmt, err)
 	}
 	return nil
 }
 
+// FactoryResetOptions carries options for FactoryReset.
+type FactoryResetOptions struct {
+	// SystemLabel is the recovery system to reset from. If empty, the
+	// default recovery system is used, or the current one if there is no
+	// default.
+	SystemLabel string
+}
+
+// FactoryReset requests a factory reset of the device using a recovery
+// system and returns the label of that system. Snapd reboots into the
+// factory-reset mode of the recovery system right away, there is no change
+// to wait for. In that mode the data partition, that is all snap data and
+// user homes, is recreated from scratch while the save partition is kept.
+func (client *Client) FactoryReset(opts *FactoryResetOptions) (systemLabel string, err error) {
+	if opts != nil {
+		systemLabel = opts.SystemLabel
+	}
+	if systemLabel == "" {
+		systems, err := client.ListSystems()
+		if err != nil {
+			fmt := "cannot factory reset: %w"
+			return "", xerrors.Errorf(fmt, err)
+		}
+		for _, sys := range systems {
+			if sys.DefaultRecoverySystem || (sys.Current && systemLabel == "") {
+				systemLabel = sys.Label
+			}
+		}
+		if systemLabel == "" {
+			return "", fmt.Errorf("cannot factory reset: no recovery system found")
+		}
+	}
+
+	action := &SystemAction{Mode: "factory-reset"}
+	if err := client.DoSystemAction(systemLabel, action); err != nil {
+		return "", err
+	}
+	return systemLabel, nil
+}
+
 type StorageEncryptionSupport string
 
 const (
@@ -222,7 +273,8 @@ func (client *Client) SystemDetails(systemLabel string) (*SystemDetails, error)
 	var rsp SystemDetails
 
 	if _, err := client.doSync("GET", "/v2/systems/"+systemLabel, nil, nil, nil, &rsp); err != nil {
-		return nil, xerrors.Errorf("cannot get details for system %q: %v", systemLabel, err)
+		fmt := "cannot get details for system %q: %w"
+		return nil, xerrors.Errorf(fmt, systemLabel, err)
 	}
 	gadget.SetEnclosingVolumeInStructs(rsp.Volumes)
 	return &rsp, nil
@@ -296,7 +348,8 @@ func (client *Client) InstallSystem(systemLabel string, opts *InstallSystemOptio
 	}
 	chgID, err := client.doAsync("POST", "/v2/systems/"+systemLabel, nil, nil, &body)
 	if err != nil {
-		return "", xerrors.Errorf("cannot request system install for %q: %v", systemLabel, err)
+		fmt := "cannot request system install for %q: %w"
+		return "", xerrors.Errorf(fmt, systemLabel, err)
 	}
 	return chgID, nil
 }
@@ -330,7 +383,8 @@ func (client *Client) GeneratePreInstallRecoveryKey(systemLabel string) (recover
 		RecoveryKey string `json:"recovery-key"`
 	}
 	if _, err := client.doSync("POST", "/v2/systems/"+systemLabel, nil, nil, &body, &rsp); err != nil {
-		return "", xerrors.Errorf("cannot generate recovery key for system %q: %v", systemLabel, err)
+		fmt := "cannot generate recovery key for system %q: %w"
+		return "", xerrors.Errorf(fmt, systemLabel, err)
 	}
 	return rsp.RecoveryKey, nil
 }
@@ -352,6 +406,133 @@ type CreateSystemOptions struct {
 	// the store. In the JSON variant of the API, only pre-installed
 	// snaps/assertions will be considered.
 	Offline bool `json:"offline,omitempty"`
+	// LocalSnaps is a list of paths to snap files that are uploaded and
+	// considered when creating the system. Requires Offline to be set.
+	LocalSnaps []string `json:"-"`
+	// LocalAssertions is a list of paths to files containing assertions or
+	// assertion bundles that are uploaded and added to the assertion database
+	// before creating the system. Requires Offline to be set.
+	LocalAssertions []string `json:"-"`
+}
+
+// CreateSystem creates a new recovery system with the given options. If local
+// snaps or assertions are provided, they are streamed to snapd as a
+// multipart/form-data request, which allows creating recovery systems without
+// any access to the store.
+func (client *Client) CreateSystem(opts *CreateSystemOptions) (changeID string, err error) {
+	if opts == nil {
+		opts = &CreateSystemOptions{}
+	}
+
+	if len(opts.LocalSnaps) == 0 && len(opts.LocalAssertions) == 0 {
+		req := struct {
+			Action string `json:"action"`
+			*CreateSystemOptions
+		}{
+			Action:              "create",
+			CreateSystemOptions: opts,
+		}
+
+		var body bytes.Buffer
+		if err := json.NewEncoder(&body).Encode(&req); err != nil {
+			return "", err
+		}
+
+		headers := map[string]string{
+			"Content-Type": "application/json",
+		}
+
+		return client.doAsync("POST", "/v2/systems", nil, headers, &body)
+	}
+
+	if !opts.Offline {
+		return "", fmt.Errorf("cannot upload local snaps or assertions when not creating the system offline")
+	}
+
+	// check if all files exist before starting the go routine
+	snapFiles, err := checkAndOpenFiles(opts.LocalSnaps)
+	if err != nil {
+		return "", err
+	}
+	assertFiles, err := checkAndOpenFiles(opts.LocalAssertions)
+	if err != nil {
+		closeFiles(snapFiles)
+		return "", err
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go sendCreateSystemForm(opts, snapFiles, assertFiles, pw, mw)
+
+	headers := map[string]string{
+		"Content-Type": mw.FormDataContentType(),
+	}
+
+	_, changeID, err = client.doAsyncFull("POST", "/v2/systems", nil, headers, pr, doNoTimeoutAndRetry)
+	return changeID, err
+}
+
+func (opts *CreateSystemOptions) writeFormFields(mw *multipart.Writer) error {
+	if err := mw.WriteField("action", "create"); err != nil {
+		return err
+	}
+	if err := mw.WriteField("label", opts.Label); err != nil {
+		return err
+	}
+	if len(opts.ValidationSets) > 0 {
+		if err := mw.WriteField("validation-sets", strings.Join(opts.ValidationSets, ",")); err != nil {
+			return err
+		}
+	}
+	return writeFields(mw, []field{
+		{"test-system", opts.TestSystem},
+		{"mark-default", opts.MarkDefault},
+	})
+}
+
+func sendCreateSystemForm(opts *CreateSystemOptions, snapFiles, assertFiles []*os.File, pw *io.PipeWriter, mw *multipart.Writer) {
+	defer func() {
+		closeFiles(snapFiles)
+		closeFiles(assertFiles)
+	}()
+
+	if err := opts.writeFormFields(mw); err != nil {
+		pw.CloseWithError(err)
+		return
+	}
+
+	// assertions are sent as file parts, so that large bundles are not
+	// limited by the size of the in-memory form values on the snapd side
+	for i, file := range assertFiles {
+		if err := sendPartFromFile(file,
+			func() (io.Writer, error) {
+				return createAssertionFilePart("assertion", filepath.Base(opts.LocalAssertions[i]), mw)
+			}); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+	}
+
+	for i, file := range snapFiles {
+		if err := sendPartFromFile(file,
+			func() (io.Writer, error) {
+				return mw.CreateFormFile("snap", filepath.Base(opts.LocalSnaps[i]))
+			}); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+	}
+
+	mw.Close()
+	pw.Close()
+}
+
+func createAssertionFilePart(name, filename string, mw *multipart.Writer) (io.Writer, error) {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition",
+		fmt.Sprintf(`form-data; name="%s"; filename="%s"`, name, filename))
+	h.Set("Content-Type", asserts.MediaType)
+	return mw.CreatePart(h)
 }
 
 // QualityCheckOptions contains the passphrase or PIN whose quality should be checked.
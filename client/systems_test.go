@@ -22,6 +22,10 @@ package client_test
 import (
 	"encoding/json"
 	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
 	"time"
 
 	"gopkg.in/check.v1"
@@ -173,6 +177,71 @@ func (cs *clientSuite) TestRequestSystemActionInvalid(c *check.C) {
 	c.Assert(err, check.ErrorMatches, "cannot request an action without one")
 }
 
+func (cs *clientSuite) TestFactoryResetWithLabel(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": {}}`
+	label, err := cs.cli.FactoryReset(&client.FactoryResetOptions{SystemLabel: "1234"})
+	c.Assert(err, check.IsNil)
+	c.Check(label, check.Equals, "1234")
+	c.Check(cs.doCalls, check.Equals, 1)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/systems/1234")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]any
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]any{
+		"action": "do",
+		"mode":   "factory-reset",
+	})
+}
+
+func (cs *clientSuite) TestFactoryResetDefaultRecoverySystem(c *check.C) {
+	cs.rsps = []string{
+		`{"type": "sync", "status-code": 200, "result": {"systems": [
+			{"current": true, "label": "20200101"},
+			{"label": "20210101", "default-recovery-system": true},
+			{"label": "20220101"}
+		]}}`,
+		`{"type": "sync", "status-code": 200, "result": {}}`,
+	}
+	label, err := cs.cli.FactoryReset(nil)
+	c.Assert(err, check.IsNil)
+	c.Check(label, check.Equals, "20210101")
+	c.Assert(cs.reqs, check.HasLen, 2)
+	c.Check(cs.reqs[0].URL.Path, check.Equals, "/v2/systems")
+	c.Check(cs.reqs[1].URL.Path, check.Equals, "/v2/systems/20210101")
+}
+
+func (cs *clientSuite) TestFactoryResetCurrentSystem(c *check.C) {
+	cs.rsps = []string{
+		`{"type": "sync", "status-code": 200, "result": {"systems": [
+			{"label": "20200101"},
+			{"current": true, "label": "20210101"}
+		]}}`,
+		`{"type": "sync", "status-code": 200, "result": {}}`,
+	}
+	label, err := cs.cli.FactoryReset(&client.FactoryResetOptions{})
+	c.Assert(err, check.IsNil)
+	c.Check(label, check.Equals, "20210101")
+	c.Assert(cs.reqs, check.HasLen, 2)
+	c.Check(cs.reqs[1].URL.Path, check.Equals, "/v2/systems/20210101")
+}
+
+func (cs *clientSuite) TestFactoryResetNoSystem(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": {}}`
+	_, err := cs.cli.FactoryReset(nil)
+	c.Assert(err, check.ErrorMatches, "cannot factory reset: no recovery system found")
+	c.Check(cs.doCalls, check.Equals, 1)
+}
+
+func (cs *clientSuite) TestFactoryResetError(c *check.C) {
+	cs.rsp = `{"type": "error", "status-code": 500, "result": {"message": "failed"}}`
+	_, err := cs.cli.FactoryReset(&client.FactoryResetOptions{SystemLabel: "1234"})
+	c.Assert(err, check.ErrorMatches, "cannot request system action: failed")
+}
+
 func (cs *clientSuite) TestRequestSystemRebootHappy(c *check.C) {
 	cs.rsp = `{
 	    "type": "sync",
@@ -476,3 +545,120 @@ func (cs *clientSuite) TestRequestGeneratePreInstallRecoveryKeyError(c *check.C)
 	c.Check(cs.req.Method, check.Equals, "POST")
 	c.Check(cs.req.URL.Path, check.Equals, "/v2/systems/1234")
 }
+
+func (cs *clientSuite) TestCreateSystemJSON(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+
+	chgID, err := cs.cli.CreateSystem(&client.CreateSystemOptions{
+		Label:          "1234",
+		ValidationSets: []string{"acme/set-1"},
+		MarkDefault:    true,
+		Offline:        true,
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/systems")
+	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]any
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]any{
+		"action":          "create",
+		"label":           "1234",
+		"validation-sets": []any{"acme/set-1"},
+		"mark-default":    true,
+		"offline":         true,
+	})
+}
+
+func (cs *clientSuite) TestCreateSystemWithLocalFiles(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+
+	dir := c.MkDir()
+	snapPath := filepath.Join(dir, "snap1.snap")
+	c.Assert(os.WriteFile(snapPath, []byte("snap1"), 0644), check.IsNil)
+	assertPath := filepath.Join(dir, "bundle.assert")
+	c.Assert(os.WriteFile(assertPath, []byte("asserts1"), 0644), check.IsNil)
+
+	chgID, err := cs.cli.CreateSystem(&client.CreateSystemOptions{
+		Label:           "1234",
+		ValidationSets:  []string{"acme/set-1", "acme/set-2"},
+		TestSystem:      true,
+		Offline:         true,
+		LocalSnaps:      []string{snapPath},
+		LocalAssertions: []string{assertPath},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/systems")
+
+	mediaType, params, err := mime.ParseMediaType(cs.req.Header.Get("Content-Type"))
+	c.Assert(err, check.IsNil)
+	c.Assert(mediaType, check.Equals, "multipart/form-data")
+
+	type part struct {
+		name, filename, contentType, content string
+	}
+	var parts []part
+	mr := multipart.NewReader(cs.req.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.IsNil)
+		content, err := io.ReadAll(p)
+		c.Assert(err, check.IsNil)
+		parts = append(parts, part{
+			name:        p.FormName(),
+			filename:    p.FileName(),
+			contentType: p.Header.Get("Content-Type"),
+			content:     string(content),
+		})
+	}
+
+	c.Check(parts, check.DeepEquals, []part{
+		{name: "action", content: "create"},
+		{name: "label", content: "1234"},
+		{name: "validation-sets", content: "acme/set-1,acme/set-2"},
+		{name: "test-system", content: "true"},
+		{name: "assertion", filename: "bundle.assert", contentType: "application/x.ubuntu.assertion", content: "asserts1"},
+		{name: "snap", filename: "snap1.snap", contentType: "application/octet-stream", content: "snap1"},
+	})
+}
+
+func (cs *clientSuite) TestCreateSystemWithLocalFilesNotOffline(c *check.C) {
+	_, err := cs.cli.CreateSystem(&client.CreateSystemOptions{
+		Label:      "1234",
+		LocalSnaps: []string{"/some/snap.snap"},
+	})
+	c.Assert(err, check.ErrorMatches, "cannot upload local snaps or assertions when not creating the system offline")
+	c.Check(cs.req, check.IsNil)
+}
+
+func (cs *clientSuite) TestCreateSystemWithLocalFilesMissing(c *check.C) {
+	_, err := cs.cli.CreateSystem(&client.CreateSystemOptions{
+		Label:           "1234",
+		Offline:         true,
+		LocalAssertions: []string{filepath.Join(c.MkDir(), "missing.assert")},
+	})
+	c.Assert(err, check.ErrorMatches, `cannot open ".*/missing.assert": .*`)
+	c.Check(cs.req, check.IsNil)
+}
@@ -134,6 +134,84 @@ func (client *Client) RemoveUser(options *RemoveUserOptions) (removed []*User, e
 	return result.Removed, nil
 }
 
+// SystemUserMode selects where CreateSystemUser takes the details of the
+// users to create from.
+type SystemUserMode string
+
+const (
+	// SystemUserFromStore queries the store for the details of the
+	// account with the given email. This is the default.
+	SystemUserFromStore SystemUserMode = ""
+	// SystemUserKnown creates users described by the system-user
+	// assertions known to the device, restricted to the given email if
+	// any.
+	SystemUserKnown SystemUserMode = "known"
+	// SystemUserAutomatic creates the users described by the system-user
+	// assertions known to the device, as done automatically at first
+	// boot. It implies SystemUserKnown and sudoer users.
+	SystemUserAutomatic SystemUserMode = "automatic"
+)
+
+// SystemUserOptions holds options for CreateSystemUser.
+type SystemUserOptions struct {
+	Mode         SystemUserMode
+	Sudoer       bool
+	ForceManaged bool
+}
+
+// SystemUserResult describes a user created by CreateSystemUser.
+type SystemUserResult struct {
+	Username string
+	// SSHKeyCount is the number of SSH keys set up for the user.
+	SSHKeyCount int
+}
+
+// CreateSystemUser creates the local system users for the given email, as
+// described by the mode in opts, and returns them. An empty email is only
+// accepted with SystemUserKnown or SystemUserAutomatic in which case all
+// the matching system-user assertions are considered.
+func (client *Client) CreateSystemUser(email string, opts *SystemUserOptions) ([]*SystemUserResult, error) {
+	if opts == nil {
+		opts = &SystemUserOptions{}
+	}
+	createOpts := &CreateUserOptions{
+		Email:        email,
+		Sudoer:       opts.Sudoer,
+		ForceManaged: opts.ForceManaged,
+	}
+	switch opts.Mode {
+	case SystemUserFromStore:
+		if email == "" {
+			return nil, fmt.Errorf("cannot create user from store details without an email to query for")
+		}
+	case SystemUserKnown:
+		createOpts.Known = true
+	case SystemUserAutomatic:
+		createOpts.Automatic = true
+	default:
+		return nil, fmt.Errorf("cannot create system user: unknown mode %q", opts.Mode)
+	}
+
+	var created []*CreateUserResult
+	if err := client.doUserAction(&userAction{Action: "create", CreateUserOptions: createOpts}, &created); err != nil {
+		return nil, fmt.Errorf("cannot create system user: %w", err)
+	}
+	results := make([]*SystemUserResult, len(created))
+	for i, u := range created {
+		results[i] = &SystemUserResult{
+			Username:    u.Username,
+			SSHKeyCount: len(u.SSHKeys),
+		}
+	}
+	return results, nil
+}
+
+// RemoveSystemUser removes the local system user with the given username
+// and returns the removed users.
+func (client *Client) RemoveSystemUser(username string) ([]*User, error) {
+	return client.RemoveUser(&RemoveUserOptions{Username: username})
+}
+
 // Users returns the local users.
 func (client *Client) Users() ([]*User, error) {
 	var result []*User
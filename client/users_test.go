@@ -196,3 +196,71 @@ func (cs *clientSuite) TestUsers(c *C) {
 		{Username: "bar", Email: "bar@example.com"},
 	})
 }
+
+func (cs *clientSuite) TestClientCreateSystemUser(c *C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [{
+			"username": "karl",
+			"ssh-keys": ["one", "two"]
+		}]
+	}`
+	results, err := cs.cli.CreateSystemUser("one@email.com", &client.SystemUserOptions{Sudoer: true})
+	c.Assert(err, IsNil)
+	c.Check(results, DeepEquals, []*client.SystemUserResult{{Username: "karl", SSHKeyCount: 2}})
+	c.Assert(cs.req.Method, Equals, "POST")
+	c.Assert(cs.req.URL.Path, Equals, "/v2/users")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, IsNil)
+	c.Check(string(body), Equals, `{"action":"create","email":"one@email.com","sudoer":true}`)
+}
+
+func (cs *clientSuite) TestClientCreateSystemUserModes(c *C) {
+	for _, t := range []struct {
+		email string
+		mode  client.SystemUserMode
+		body  string
+	}{
+		{"", client.SystemUserKnown, `{"action":"create","known":true}`},
+		{"one@email.com", client.SystemUserKnown, `{"action":"create","email":"one@email.com","known":true}`},
+		{"", client.SystemUserAutomatic, `{"action":"create","automatic":true}`},
+	} {
+		cs.req = nil
+		cs.rsp = `{"type": "sync", "result": [{"username": "karl"}, {"username": "bob", "ssh-keys": ["one"]}]}`
+		results, err := cs.cli.CreateSystemUser(t.email, &client.SystemUserOptions{Mode: t.mode})
+		c.Assert(err, IsNil)
+		c.Check(results, DeepEquals, []*client.SystemUserResult{
+			{Username: "karl", SSHKeyCount: 0},
+			{Username: "bob", SSHKeyCount: 1},
+		})
+		body, err := io.ReadAll(cs.req.Body)
+		c.Assert(err, IsNil)
+		c.Check(string(body), Equals, t.body)
+	}
+}
+
+func (cs *clientSuite) TestClientCreateSystemUserErrors(c *C) {
+	_, err := cs.cli.CreateSystemUser("", nil)
+	c.Check(err, ErrorMatches, "cannot create user from store details without an email to query for")
+	_, err = cs.cli.CreateSystemUser("one@email.com", &client.SystemUserOptions{Mode: "other"})
+	c.Check(err, ErrorMatches, `cannot create system user: unknown mode "other"`)
+	c.Check(cs.req, IsNil)
+
+	cs.status = 500
+	cs.rsp = `{"type": "error", "result": {"message": "boom"}}`
+	_, err = cs.cli.CreateSystemUser("one@email.com", nil)
+	c.Check(err, ErrorMatches, "cannot create system user: boom")
+}
+
+func (cs *clientSuite) TestClientRemoveSystemUser(c *C) {
+	cs.rsp = `{"type": "sync", "result": {"removed": [{"username": "karl"}]}}`
+	removed, err := cs.cli.RemoveSystemUser("karl")
+	c.Assert(err, IsNil)
+	c.Check(removed, DeepEquals, []*client.User{{Username: "karl"}})
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, IsNil)
+	c.Check(string(body), Equals, `{"action":"remove","username":"karl"}`)
+
+	_, err = cs.cli.RemoveSystemUser("")
+	c.Check(err, ErrorMatches, "cannot remove a user without providing a username")
+}
@@ -28,9 +28,23 @@ import (
 	"golang.org/x/xerrors"
 )
 
+// Modes a validation set can be tracked in.
+const (
+	// ValidationSetModeMonitor only reports whether the installed snaps
+	// comply with the validation set.
+	ValidationSetModeMonitor = "monitor"
+	// ValidationSetModeEnforce makes snapd keep the installed snaps
+	// compliant with the validation set.
+	ValidationSetModeEnforce = "enforce"
+)
+
 // ValidateApplyOptions carries options for ApplyValidationSet.
 type ValidateApplyOptions struct {
-	Mode     string
+	// Mode is one of ValidationSetModeMonitor or ValidationSetModeEnforce.
+	Mode string
+	// Sequence, if non-zero, pins the validation set at the given
+	// sequence. Applying again with another sequence moves the pin, while
+	// a zero sequence tracks the latest sequence.
 	Sequence int
 }
 
@@ -84,6 +98,9 @@ func (client *Client) ApplyValidationSet(accountID, name string, opts *ValidateA
 	if accountID == "" || name == "" {
 		return nil, xerrors.Errorf("cannot apply validation set without account ID and name")
 	}
+	if opts == nil || opts.Mode == "" {
+		return nil, xerrors.Errorf("cannot apply validation set without a mode")
+	}
 
 	data := &postValidationSetData{
 		Action:   "apply",
@@ -105,7 +122,14 @@ func (client *Client) ApplyValidationSet(accountID, name string, opts *ValidateA
 }
 
 // ListValidationsSets queries all validation sets.
+//
+// Deprecated: use ValidationSets.
 func (client *Client) ListValidationsSets() ([]*ValidationSetResult, error) {
+	return client.ValidationSets()
+}
+
+// ValidationSets queries all the tracked validation sets.
+func (client *Client) ValidationSets() ([]*ValidationSetResult, error) {
 	var res []*ValidationSetResult
 	if _, err := client.doSync("GET", "/v2/validation-sets", nil, nil, nil, &res); err != nil {
 		fmt := "cannot list validation sets: %w"
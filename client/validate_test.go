@@ -64,7 +64,7 @@ func (cs *clientSuite) TestListValidationsSets(c *check.C) {
 		"status-code": 200,
 		"result": [
 			{"account-id": "abc", "name": "def", "mode": "monitor", "sequence": 0},
-			{"account-id": "ghi", "name": "jkl", "mode": "enforce", "sequence": 2}
+			{"account-id": "ghi", "name": "jkl", "mode": "enforce", "sequence": 2, "pinned-at": 2}
 		]
 	}`
 
@@ -74,7 +74,25 @@ func (cs *clientSuite) TestListValidationsSets(c *check.C) {
 	c.Check(cs.req.URL.Path, check.Equals, "/v2/validation-sets")
 	c.Check(vsets, check.DeepEquals, []*client.ValidationSetResult{
 		{AccountID: "abc", Name: "def", Mode: "monitor", Sequence: 0, Valid: false},
-		{AccountID: "ghi", Name: "jkl", Mode: "enforce", Sequence: 2, Valid: false},
+		{AccountID: "ghi", Name: "jkl", Mode: "enforce", Sequence: 2, PinnedAt: 2, Valid: false},
+	})
+}
+
+func (cs *clientSuite) TestValidationSets(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": [
+			{"account-id": "abc", "name": "def", "mode": "monitor", "sequence": 1, "valid": true}
+		]
+	}`
+
+	vsets, err := cs.cli.ValidationSets()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/validation-sets")
+	c.Check(vsets, check.DeepEquals, []*client.ValidationSetResult{
+		{AccountID: "abc", Name: "def", Mode: "monitor", Sequence: 1, Valid: true},
 	})
 }
 
@@ -84,7 +102,7 @@ func (cs *clientSuite) TestApplyValidationSetMonitor(c *check.C) {
 		"status-code": 200,
 		"result": {"account-id": "foo", "name": "bar", "mode": "monitor", "sequence": 3, "valid": true}
 	}`
-	opts := &client.ValidateApplyOptions{Mode: "monitor", Sequence: 3}
+	opts := &client.ValidateApplyOptions{Mode: client.ValidationSetModeMonitor, Sequence: 3}
 	vs, err := cs.cli.ApplyValidationSet("foo", "bar", opts)
 	c.Assert(err, check.IsNil)
 	c.Check(vs, check.DeepEquals, &client.ValidationSetResult{
@@ -114,7 +132,7 @@ func (cs *clientSuite) TestApplyValidationSetEnforce(c *check.C) {
 		"status-code": 200,
         "result": {"account-id": "foo", "name": "bar", "mode": "enforce", "sequence": 3, "valid": true}
 	}`
-	opts := &client.ValidateApplyOptions{Mode: "enforce", Sequence: 3}
+	opts := &client.ValidateApplyOptions{Mode: client.ValidationSetModeEnforce, Sequence: 3}
 	vs, err := cs.cli.ApplyValidationSet("foo", "bar", opts)
 	c.Assert(err, check.IsNil)
 	c.Check(vs, check.DeepEquals, &client.ValidationSetResult{
@@ -154,6 +172,10 @@ func (cs *clientSuite) TestApplyValidationSetInvalidArgs(c *check.C) {
 	c.Assert(err, check.ErrorMatches, `cannot apply validation set without account ID and name`)
 	_, err = cs.cli.ApplyValidationSet("", "bar", opts)
 	c.Assert(err, check.ErrorMatches, `cannot apply validation set without account ID and name`)
+	_, err = cs.cli.ApplyValidationSet("foo", "bar", opts)
+	c.Assert(err, check.ErrorMatches, `cannot apply validation set without a mode`)
+	_, err = cs.cli.ApplyValidationSet("foo", "bar", nil)
+	c.Assert(err, check.ErrorMatches, `cannot apply validation set without a mode`)
 }
 
 func (cs *clientSuite) TestForgetValidationSet(c *check.C) {
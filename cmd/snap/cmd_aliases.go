@@ -104,7 +104,7 @@ func (x *cmdAliases) Execute(args []string) error {
 				Snap:    snapName,
 				Command: aliasStatus.Command,
 				Alias:   alias,
-				Status:  aliasStatus.Status,
+				Status:  string(aliasStatus.Status),
 				Auto:    aliasStatus.Auto,
 			})
 		}
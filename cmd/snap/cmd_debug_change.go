@@ -0,0 +1,118 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdDebugChange struct {
+	changeIDMixin
+	DotOutput bool `long:"dot"`
+}
+
+func init() {
+	addDebugCommand("change",
+		i18n.G("Show the dependencies between the tasks of a change"),
+		i18n.G(`The change command displays the tasks of a change along with the
+tasks each of them waits for and the lanes they belong to, to help finding
+out why a change is not making progress.`),
+		func() flags.Commander {
+			return &cmdDebugChange{}
+		}, changeIDMixinOptDesc.also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"dot": i18n.G("Dot (graphviz) output"),
+		}), changeIDMixinArgDesc)
+}
+
+func (x *cmdDebugChange) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	chgID, err := x.GetChangeID()
+	if err != nil {
+		if err == noChangeFoundOK {
+			return nil
+		}
+		return err
+	}
+
+	chg, err := x.client.ChangeGraph(chgID)
+	if err != nil {
+		return err
+	}
+
+	if x.DotOutput {
+		writeChangeDot(chg)
+		return nil
+	}
+
+	w := tabWriter()
+	fmt.Fprint(w, i18n.G("ID\tStatus\tLanes\tWaits for\tSummary\n"))
+	for _, t := range chg.Tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.ID, t.Status, formatLanes(t.Lanes), formatTaskIDs(t.WaitTasks), t.Summary)
+	}
+	w.Flush()
+
+	return nil
+}
+
+// writeChangeDot writes the tasks of the change and the tasks they wait for
+// as a graphviz digraph, in the format used by "snap debug state --dot".
+func writeChangeDot(chg *client.Change) {
+	fmt.Fprintf(Stdout, "digraph D{\n")
+	for _, t := range chg.Tasks {
+		label := fmt.Sprintf("%s (%s)", t.Kind, t.Status)
+		if len(t.Lanes) > 0 {
+			label += "\nlanes: " + formatLanes(t.Lanes)
+		}
+		fmt.Fprintf(Stdout, "  %s [label=%q];\n", t.ID, label)
+		for _, id := range t.WaitTasks {
+			fmt.Fprintf(Stdout, "  %s -> %s;\n", t.ID, id)
+		}
+	}
+	fmt.Fprintf(Stdout, "}\n")
+}
+
+func formatLanes(lanes []int) string {
+	if len(lanes) == 0 {
+		return "-"
+	}
+	strs := make([]string, len(lanes))
+	for i, lane := range lanes {
+		strs[i] = strconv.Itoa(lane)
+	}
+	return strings.Join(strs, ",")
+}
+
+func formatTaskIDs(ids []string) string {
+	if len(ids) == 0 {
+		return "-"
+	}
+	return strings.Join(ids, ",")
+}
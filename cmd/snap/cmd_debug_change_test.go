@@ -0,0 +1,84 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) mockChangeGraphAPI(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+		c.Check(r.URL.Query().Get("include"), check.Equals, "graph")
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+  "id": "42",
+  "kind": "install-snap",
+  "summary": "Install \"foo\" snap",
+  "status": "Doing",
+  "tasks": [
+    {"id": "1", "kind": "download-snap", "summary": "Download snap \"foo\"", "status": "Done", "progress": {"done": 1, "total": 1}, "halt-tasks": ["2"], "lanes": [1]},
+    {"id": "2", "kind": "mount-snap", "summary": "Mount snap \"foo\"", "status": "Do", "progress": {"done": 0, "total": 1}, "wait-tasks": ["1"], "lanes": [1]},
+    {"id": "3", "kind": "check-rerefresh", "summary": "Monitoring", "status": "Do", "progress": {"done": 0, "total": 1}, "wait-tasks": ["1", "2"]}
+  ]
+}}`)
+	})
+}
+
+func (s *SnapSuite) TestDebugChange(c *check.C) {
+	s.mockChangeGraphAPI(c)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "change", "42"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `
+ID   Status  Lanes  Waits for  Summary
+1    Done    1      -          Download snap "foo"
+2    Do      1      1          Mount snap "foo"
+3    Do      -      1,2        Monitoring
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestDebugChangeDot(c *check.C) {
+	s.mockChangeGraphAPI(c)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "change", "--dot", "42"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `digraph D{
+  1 [label="download-snap (Done)\nlanes: 1"];
+  2 [label="mount-snap (Do)\nlanes: 1"];
+  2 -> 1;
+  3 [label="check-rerefresh (Do)"];
+  3 -> 1;
+  3 -> 2;
+}
+`)
+}
+
+func (s *SnapSuite) TestDebugChangeNoID(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "change"})
+	c.Assert(err, check.ErrorMatches, "please provide change ID or type with --last=<type>")
+}
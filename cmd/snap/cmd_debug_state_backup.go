@@ -0,0 +1,138 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortDebugStateExportHelp = i18n.G("Export a backup of the snapd state")
+var longDebugStateExportHelp = i18n.G(`
+The state-export command writes a consistent backup of the state of snapd and
+of its assertion database to the given file. The backup can be restored with
+'snap debug state-import'.
+`)
+
+var shortDebugStateImportHelp = i18n.G("Restore a backup of the snapd state")
+var longDebugStateImportHelp = i18n.G(`
+The state-import command restores a backup written by 'snap debug state-export'.
+The backup must come from the same device and no change can be in progress.
+snapd restarts to restore the backup, keeping the replaced state and assertion
+database aside with a .pre-restore suffix.
+`)
+
+type cmdDebugStateExport struct {
+	clientMixin
+	Positional struct {
+		Filename string
+	} `positional-args:"yes" required:"yes"`
+}
+
+type cmdDebugStateImport struct {
+	clientMixin
+	Positional struct {
+		Filename string
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addDebugCommand("state-export", shortDebugStateExportHelp, longDebugStateExportHelp, func() flags.Commander {
+		return &cmdDebugStateExport{}
+	}, nil, []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<filename>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("The filename of the backup"),
+	}})
+	addDebugCommand("state-import", shortDebugStateImportHelp, longDebugStateImportHelp, func() flags.Commander {
+		return &cmdDebugStateImport{}
+	}, nil, []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<filename>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("The filename of the backup"),
+	}})
+}
+
+func (x *cmdDebugStateExport) Execute(args []string) (err error) {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	r, err := x.client.DebugStateExport()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	filename := x.Positional.Filename
+	f, err := os.OpenFile(filename+".part", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer func() {
+		if err != nil {
+			os.Remove(filename + ".part")
+		}
+	}()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf(i18n.G("cannot export state: %v"), err)
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := os.Rename(filename+".part", filename); err != nil {
+		return err
+	}
+
+	// TRANSLATORS: the argument is a file name
+	fmt.Fprintf(Stdout, i18n.G("Exported state into %q\n"), filename)
+	return nil
+}
+
+func (x *cmdDebugStateImport) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	f, err := os.Open(x.Positional.Filename)
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot open state backup: %v"), err)
+	}
+	defer f.Close()
+
+	info, err := x.client.DebugStateImport(f)
+	if err != nil {
+		return err
+	}
+
+	// TRANSLATORS: the argument is the time the backup was taken
+	fmt.Fprintf(Stdout, i18n.G("Restoring state from %s, snapd is restarting\n"), info.Time.Format(time.RFC3339))
+	return nil
+}
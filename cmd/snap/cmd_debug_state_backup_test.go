@@ -0,0 +1,93 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *SnapSuite) TestDebugStateExport(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/debug/state")
+		w.Header().Set("Content-Type", "application/x-tar")
+		fmt.Fprint(w, "tarball")
+		n++
+	})
+
+	filename := filepath.Join(c.MkDir(), "backup.tar")
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "state-export", filename})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, fmt.Sprintf("Exported state into %q\n", filename))
+	c.Check(filename, testutil.FileEquals, "tarball")
+	c.Check(filename+".part", testutil.FileAbsent)
+}
+
+func (s *SnapSuite) TestDebugStateExportError(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		fmt.Fprint(w, `{"type": "error", "status-code": 500, "result": {"message": "boom"}}`)
+	})
+
+	filename := filepath.Join(c.MkDir(), "backup.tar")
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "state-export", filename})
+	c.Assert(err, check.ErrorMatches, "boom")
+	c.Check(filename, testutil.FileAbsent)
+	c.Check(filename+".part", testutil.FileAbsent)
+}
+
+func (s *SnapSuite) TestDebugStateImport(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/debug/state")
+		c.Check(r.Header.Get("Content-Type"), check.Equals, "application/x-tar")
+		data, err := io.ReadAll(r.Body)
+		c.Check(err, check.IsNil)
+		c.Check(string(data), check.Equals, "tarball")
+		fmt.Fprint(w, `{"type": "sync", "result": {"format": 1, "time": "2025-06-01T10:00:00Z"}}`)
+		n++
+	})
+
+	filename := filepath.Join(c.MkDir(), "backup.tar")
+	c.Assert(os.WriteFile(filename, []byte("tarball"), 0600), check.IsNil)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "state-import", filename})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, "Restoring state from 2025-06-01T10:00:00Z, snapd is restarting\n")
+}
+
+func (s *SnapSuite) TestDebugStateImportMissingFile(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "state-import", "/does/not/exist"})
+	c.Assert(err, check.ErrorMatches, "cannot open state backup: .*")
+}
@@ -53,6 +53,7 @@ import (
 	"github.com/snapcore/snapd/sandbox/cgroup"
 	"github.com/snapcore/snapd/sandbox/selinux"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/launchpolicy"
 	"github.com/snapcore/snapd/snap/snapenv"
 	"github.com/snapcore/snapd/strutil/shlex"
 	"github.com/snapcore/snapd/systemd"
@@ -66,7 +67,9 @@ import (
 var (
 	syscallExec              = syscall.Exec
 	userCurrent              = user.Current
+	userLookupGroup          = user.LookupGroup
 	osGetenv                 = os.Getenv
+	osGetgroups              = os.Getgroups
 	timeNow                  = time.Now
 	selinuxIsEnabled         = selinux.IsEnabled
 	selinuxVerifyPathContext = selinux.VerifyPathContext
@@ -597,11 +600,99 @@ func checkSnapRunInhibitionConflict(app *snap.AppInfo) error {
 	return nil
 }
 
+// logLaunchDecision records a decision of the launch policy in the system
+// log, so that launches can be audited.
+var logLaunchDecision = func(msg string) {
+	w, err := syslog.New(syslog.LOG_AUTH|syslog.LOG_NOTICE, "snap")
+	if err != nil {
+		logger.Debugf("cannot log launch policy decision %q: %v", msg, err)
+		return
+	}
+	defer w.Close()
+	w.Notice(msg)
+}
+
+// launchPolicyGroups returns the groups named in the rules of the launch
+// policy which the current process is a member of.
+func launchPolicyGroups(policy *launchpolicy.Policy) ([]string, error) {
+	gids, err := osGetgroups()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get groups of the current process: %v", err)
+	}
+	gids = append(gids, os.Getgid())
+
+	var groups []string
+	seen := make(map[string]bool)
+	for _, rule := range policy.Rules {
+		for _, name := range rule.Groups {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			group, err := userLookupGroup(name)
+			if err != nil {
+				// unknown groups cannot have any members
+				logger.Debugf("cannot look up group %q of launch policy: %v", name, err)
+				continue
+			}
+			gid, err := strconv.Atoi(group.Gid)
+			if err != nil {
+				continue
+			}
+			for _, g := range gids {
+				if g == gid {
+					groups = append(groups, name)
+					break
+				}
+			}
+		}
+	}
+	return groups, nil
+}
+
+// checkLaunchPolicy checks whether the current user can launch snapApp
+// according to the launch policy of the system, if any. Decisions are logged
+// in both audit and enforce mode, but launches are only denied in the latter.
+func checkLaunchPolicy(snapApp string) error {
+	policy, err := launchpolicy.Load()
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return nil
+	}
+
+	usr, err := userCurrent()
+	if err != nil {
+		return err
+	}
+	groups, err := launchPolicyGroups(policy)
+	if err != nil {
+		return err
+	}
+
+	if policy.Allowed(snapApp, usr.Username, groups) {
+		logLaunchDecision(fmt.Sprintf("launch policy: allowed %q for user %q", snapApp, usr.Username))
+		return nil
+	}
+	if policy.Mode == launchpolicy.ModeAudit {
+		logLaunchDecision(fmt.Sprintf("launch policy: would deny %q for user %q (audit mode)", snapApp, usr.Username))
+		return nil
+	}
+	logLaunchDecision(fmt.Sprintf("launch policy: denied %q for user %q", snapApp, usr.Username))
+	return fmt.Errorf(i18n.G("cannot run %q: not allowed by the launch policy of the system"), snapApp)
+}
+
 func (x *cmdRun) snapRunApp(snapApp string, args []string) error {
 	if x.DebugLog {
 		os.Setenv("SNAPD_DEBUG", "1")
 		logger.Debugf("enabled debug logging of early snap startup")
 	}
+
+	if err := checkLaunchPolicy(snapApp); err != nil {
+		return err
+	}
+
 	snapName, appName := snap.SplitSnapApp(snapApp)
 
 	var retryCnt int
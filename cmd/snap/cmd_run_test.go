@@ -44,6 +44,7 @@ import (
 	"github.com/snapcore/snapd/sandbox/cgroup"
 	"github.com/snapcore/snapd/sandbox/selinux"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/launchpolicy"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testtime"
 	"github.com/snapcore/snapd/testutil"
@@ -250,6 +251,106 @@ func (s *RunSuite) TestSnapRunAppIntegration(c *check.C) {
 	c.Check(execEnv, testutil.Contains, fmt.Sprintf("TMPDIR=%s", tmpdir))
 }
 
+func (s *RunSuite) mockLaunchPolicyUser(c *check.C, username string) {
+	u, err := user.Current()
+	c.Assert(err, check.IsNil)
+	s.AddCleanup(snaprun.MockUserCurrent(func() (*user.User, error) {
+		return &user.User{Uid: u.Uid, Username: username, HomeDir: s.fakeHome}, nil
+	}))
+}
+
+func (s *RunSuite) TestSnapRunAppLaunchPolicyDenied(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{Revision: snap.R("x2")})
+	s.mockLaunchPolicyUser(c, "operator")
+
+	c.Assert(launchpolicy.Save(&launchpolicy.Policy{
+		Mode:  launchpolicy.ModeEnforce,
+		Rules: []launchpolicy.Rule{{App: "other-snap"}, {App: "snapname.app", Users: []string{"admin"}}},
+	}), check.IsNil)
+
+	var logged []string
+	restore := snaprun.MockLogLaunchDecision(func(msg string) {
+		logged = append(logged, msg)
+	})
+	defer restore()
+	restore = snaprun.MockSyscallExec(func(arg0 string, args []string, envv []string) error {
+		c.Fatalf("unexpected exec of %q", arg0)
+		return nil
+	})
+	defer restore()
+
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app", "--arg1"})
+	c.Assert(err, check.ErrorMatches, `cannot run "snapname.app": not allowed by the launch policy of the system`)
+	c.Check(logged, check.DeepEquals, []string{`launch policy: denied "snapname.app" for user "operator"`})
+}
+
+func (s *RunSuite) TestSnapRunAppLaunchPolicyAudit(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{Revision: snap.R("x2")})
+	s.mockLaunchPolicyUser(c, "operator")
+
+	c.Assert(launchpolicy.Save(&launchpolicy.Policy{Mode: launchpolicy.ModeAudit}), check.IsNil)
+
+	var logged []string
+	restore := snaprun.MockLogLaunchDecision(func(msg string) {
+		logged = append(logged, msg)
+	})
+	defer restore()
+	execCalled := false
+	restore = snaprun.MockSyscallExec(func(arg0 string, args []string, envv []string) error {
+		execCalled = true
+		return nil
+	})
+	defer restore()
+
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app"})
+	c.Assert(err, check.IsNil)
+	c.Check(execCalled, check.Equals, true)
+	c.Check(logged, check.DeepEquals, []string{`launch policy: would deny "snapname.app" for user "operator" (audit mode)`})
+}
+
+func (s *RunSuite) TestSnapRunAppLaunchPolicyAllowedByGroup(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{Revision: snap.R("x2")})
+	s.mockLaunchPolicyUser(c, "operator")
+
+	c.Assert(launchpolicy.Save(&launchpolicy.Policy{
+		Mode: launchpolicy.ModeEnforce,
+		Rules: []launchpolicy.Rule{
+			{App: "snapname", Groups: []string{"unknown", "kiosk"}},
+		},
+	}), check.IsNil)
+
+	restore := snaprun.MockUserLookupGroup(func(name string) (*user.Group, error) {
+		if name == "kiosk" {
+			return &user.Group{Name: "kiosk", Gid: "4242"}, nil
+		}
+		return nil, user.UnknownGroupError(name)
+	})
+	defer restore()
+	restore = snaprun.MockOsGetgroups(func() ([]int, error) {
+		return []int{100, 4242}, nil
+	})
+	defer restore()
+	var logged []string
+	restore = snaprun.MockLogLaunchDecision(func(msg string) {
+		logged = append(logged, msg)
+	})
+	defer restore()
+	execCalled := false
+	restore = snaprun.MockSyscallExec(func(arg0 string, args []string, envv []string) error {
+		execCalled = true
+		return nil
+	})
+	defer restore()
+
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app"})
+	c.Assert(err, check.IsNil)
+	c.Check(execCalled, check.Equals, true)
+	c.Check(logged, check.DeepEquals, []string{`launch policy: allowed "snapname.app" for user "operator"`})
+}
+
 func checkHintFileNotLocked(c *check.C, snapName string) {
 	flock, err := openHintFileLock(snapName)
 	c.Assert(err, check.IsNil)
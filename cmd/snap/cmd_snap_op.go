@@ -1435,6 +1435,9 @@ and will use the original data that was associated with that revision,
 discarding any data changes that were done by the latest revision. As
 an exception, data which the snap explicitly chooses to share across
 revisions is not touched by the revert process.
+
+With --revision, the snap is reverted to any of its revisions still
+on disk instead of the previous one, as listed by 'snap list --all'.
 `)
 
 func (x *cmdRevert) Execute(args []string) error {
@@ -177,7 +177,7 @@ func (cmd *cmdValidate) Execute(args []string) error {
 
 	// no validation set argument, print list with extended info
 	if cmd.Positional.ValidationSet == "" {
-		vsets, err := cmd.client.ListValidationsSets()
+		vsets, err := cmd.client.ValidationSets()
 		if err != nil {
 			return err
 		}
@@ -192,6 +192,18 @@ func MockUserCurrent(f func() (*user.User, error)) (restore func()) {
 	}
 }
 
+func MockUserLookupGroup(f func(string) (*user.Group, error)) (restore func()) {
+	return testutil.Mock(&userLookupGroup, f)
+}
+
+func MockOsGetgroups(f func() ([]int, error)) (restore func()) {
+	return testutil.Mock(&osGetgroups, f)
+}
+
+func MockLogLaunchDecision(f func(msg string)) (restore func()) {
+	return testutil.Mock(&logLaunchDecision, f)
+}
+
 func MockStoreNew(f func(*store.Config, store.DeviceAndAuthContext) *store.Store) (restore func()) {
 	storeNewOrig := storeNew
 	storeNew = f
@@ -157,6 +157,11 @@ func checkAccess(d *Daemon, r *http.Request, ucred *ucrednet, user *auth.UserSta
 		return nil
 	}
 
+	if opts.AccessLevel == accessLevelAuthenticated && apiTokenFromContext(r.Context()) != nil {
+		// the request was authenticated with an API token covering it
+		return nil
+	}
+
 	if ucred.Uid == 0 {
 		return nil
 	}
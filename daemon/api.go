@@ -20,10 +20,12 @@
 package daemon
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -46,6 +48,8 @@ var api = []*Command{
 	findCmd,
 	snapsCmd,
 	snapCmd,
+	snapRevisionsCmd,
+	snapHistoryCmd,
 	snapFileCmd,
 	snapDownloadCmd,
 	snapConfCmd,
@@ -67,6 +71,8 @@ var api = []*Command{
 	warningsCmd,
 	debugPprofCmd,
 	debugCmd,
+	debugHistoryCmd,
+	debugStateCmd,
 	snapshotCmd,
 	snapshotExportCmd,
 	connectionsCmd,
@@ -91,8 +97,21 @@ var api = []*Command{
 	requestsPromptCmd,
 	requestsRulesCmd,
 	requestsRuleCmd,
+	requestsMetricsCmd,
 	systemSecurebootCmd,
 	systemVolumesCmd,
+	launchPolicyCmd,
+	baseMigrationsCmd,
+	storeRoutingCmd,
+	debugChangeKindsCmd,
+	debugSeedManifestCmd,
+	capabilitiesCmd,
+	apiTokensCmd,
+	safeModeCmd,
+	maintenanceCalendarCmd,
+	deviceAccessCmd,
+	janitorCmd,
+	systemCmd,
 }
 
 type featureEndpoint struct {
@@ -190,9 +209,11 @@ var (
 	snapstateInstallWithGoal                = snapstate.InstallWithGoal
 	snapstateInstallPath                    = snapstate.InstallPath
 	snapstateInstallPathMany                = snapstate.InstallPathMany
+	snapstateInstallFromURL                 = snapstate.InstallFromURL
 	snapstateInstallComponentPath           = snapstate.InstallComponentPath
 	snapstateInstallComponents              = snapstate.InstallComponents
 	snapstateRefreshCandidates              = snapstate.RefreshCandidates
+	snapstateClassifyRefreshes              = snapstate.ClassifyRefreshes
 	snapstateTryPath                        = snapstate.TryPath
 	snapstateStoreUpdateGoal                = snapstate.StoreUpdateGoal
 	snapstateUpdateWithGoal                 = snapstate.UpdateWithGoal
@@ -203,7 +224,9 @@ var (
 	snapstateRevert                         = snapstate.Revert
 	snapstateRevertToRevision               = snapstate.RevertToRevision
 	snapstateSwitch                         = snapstate.Switch
+	snapstateDownloadRefresh                = snapstate.DownloadRefresh
 	snapstateProceedWithRefresh             = snapstate.ProceedWithRefresh
+	snapstateProceedWithInhibitedRefresh    = snapstate.ProceedWithInhibitedRefresh
 	snapstateHoldRefreshesBySystem          = snapstate.HoldRefreshesBySystem
 	snapstateLongestGatingHold              = snapstate.LongestGatingHold
 	snapstateSystemHold                     = snapstate.SystemHold
@@ -231,8 +254,13 @@ var ensureStateSoon = ensureStateSoonImpl
 
 var newChange = newChangeImpl
 
-func newChangeImpl(st *state.State, kind, summary string, tsets []*state.TaskSet, snapNames []string) *state.Change {
+// newChangeImpl creates a change with the given task sets. The deadline of
+// the change is set if the request asked for a change timeout.
+func newChangeImpl(ctx context.Context, st *state.State, kind, summary string, tsets []*state.TaskSet, snapNames []string) *state.Change {
 	chg := st.NewChange(kind, summary)
+	if timeout := changeTimeoutFromContext(ctx); timeout > 0 {
+		chg.SetDeadline(time.Now().Add(timeout))
+	}
 	for _, ts := range tsets {
 		chg.AddAll(ts)
 	}
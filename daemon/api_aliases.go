@@ -20,6 +20,7 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/overlord/swfeats"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var (
@@ -41,6 +43,7 @@ var (
 		Actions:     []string{"alias", "unalias", "prefer"},
 		ReadAccess:  openAccess{},
 		WriteAccess: authenticatedAccess{},
+		Throttled:   true,
 	}
 )
 
@@ -58,6 +61,14 @@ type aliasAction struct {
 	Alias  string `json:"alias"`
 	// old now unsupported api
 	Aliases []string `json:"aliases"`
+	// Targets allows to set up or remove several manual aliases of
+	// the snap at once.
+	Targets []aliasTarget `json:"targets"`
+}
+
+type aliasTarget struct {
+	Alias string `json:"alias"`
+	App   string `json:"app"`
 }
 
 func changeAliases(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -77,6 +88,10 @@ func changeAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 	st.Lock()
 	defer st.Unlock()
 
+	if len(a.Targets) != 0 {
+		return changeManyAliases(r.Context(), st, &a)
+	}
+
 	switch a.Action {
 	default:
 		return BadRequest("unsupported alias action: %q", a.Action)
@@ -127,7 +142,7 @@ func changeAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 		changeKind = preferChangeKind
 	}
 
-	change := newChange(st, changeKind, summary, []*state.TaskSet{taskset}, []string{a.Snap})
+	change := newChange(r.Context(), st, changeKind, summary, []*state.TaskSet{taskset}, []string{a.Snap})
 	st.EnsureBefore(0)
 
 	return AsyncResponse(nil, change.ID())
@@ -180,3 +195,79 @@ func getAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 
 	return SyncResponse(res)
 }
+
+// changeManyAliases sets up or removes several manual aliases of a snap
+// in a single change.
+func changeManyAliases(ctx context.Context, st *state.State, a *aliasAction) Response {
+	if a.Snap == "" {
+		return BadRequest("cannot %s multiple aliases without a snap", a.Action)
+	}
+	if a.Alias != "" || a.App != "" {
+		return BadRequest("cannot use alias or app together with targets")
+	}
+
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, a.Snap, &snapst); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return errToResponse(&snap.NotInstalledError{Snap: a.Snap}, nil, BadRequest, "%v")
+		}
+		return InternalError("%v", err)
+	}
+
+	aliases := make([]string, 0, len(a.Targets))
+	seen := make(map[string]bool, len(a.Targets))
+	for _, target := range a.Targets {
+		if target.Alias == "" {
+			return BadRequest("cannot %s: alias name is empty", a.Action)
+		}
+		if seen[target.Alias] {
+			return BadRequest("cannot %s: alias %q given more than once", a.Action, target.Alias)
+		}
+		seen[target.Alias] = true
+		aliases = append(aliases, target.Alias)
+	}
+
+	var tss []*state.TaskSet
+	var changeKind, summary string
+	switch a.Action {
+	case "alias":
+		for _, target := range a.Targets {
+			if target.App == "" {
+				return BadRequest("cannot alias %q: app name is empty", target.Alias)
+			}
+			ts, err := snapstate.Alias(st, a.Snap, target.App, target.Alias)
+			if err != nil {
+				return errToResponse(err, nil, BadRequest, "%v")
+			}
+			tss = append(tss, ts)
+		}
+		summary = fmt.Sprintf(i18n.G("Setup aliases %s for snap %q"), strutil.Quoted(aliases), a.Snap)
+		changeKind = aliasChangeKind
+	case "unalias":
+		for _, target := range a.Targets {
+			if t := snapst.Aliases[target.Alias]; t == nil || t.Manual == "" {
+				return BadRequest("cannot remove alias %q: not a manual alias of snap %q", target.Alias, a.Snap)
+			}
+			ts, _, err := snapstate.RemoveManualAlias(st, target.Alias)
+			if err != nil {
+				return errToResponse(err, nil, BadRequest, "%v")
+			}
+			tss = append(tss, ts)
+		}
+		summary = fmt.Sprintf(i18n.G("Remove manual aliases %s for snap %q"), strutil.Quoted(aliases), a.Snap)
+		changeKind = unaliasChangeKind
+	default:
+		return BadRequest("unsupported alias action with targets: %q", a.Action)
+	}
+
+	// the alias tasks of a snap operate on the same snap state, so run
+	// them one after the other
+	for i := 1; i < len(tss); i++ {
+		tss[i].WaitAll(tss[i-1])
+	}
+
+	change := newChange(ctx, st, changeKind, summary, tss, []string{a.Snap})
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, change.ID())
+}
@@ -187,6 +187,118 @@ func (s *aliasesSuite) TestAliasErrors(c *check.C) {
 	}
 }
 
+func (s *aliasesSuite) postAliasAction(c *check.C, st *state.State, action *daemon.AliasAction) *state.Change {
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/aliases", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	return chg
+}
+
+func (s *aliasesSuite) TestAliasManySuccess(c *check.C) {
+	err := os.MkdirAll(dirs.SnapBinariesDir, 0755)
+	c.Assert(err, check.IsNil)
+	d := s.daemon(c)
+
+	s.mockSnap(c, aliasYaml)
+
+	oldAutoAliases := snapstate.AutoAliases
+	snapstate.AutoAliases = func(*state.State, *snap.Info) (map[string]string, error) {
+		return nil, nil
+	}
+	defer func() { snapstate.AutoAliases = oldAutoAliases }()
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	st := d.Overlord().State()
+	chg := s.postAliasAction(c, st, &daemon.AliasAction{
+		Action: "alias",
+		Snap:   "alias-snap",
+		Targets: []daemon.AliasTarget{
+			{Alias: "alias1", App: "app"},
+			{Alias: "alias2", App: "app2"},
+		},
+	})
+	st.Lock()
+	c.Check(chg.Kind(), check.Equals, "alias")
+	c.Check(chg.Summary(), check.Equals, `Setup aliases "alias1", "alias2" for snap "alias-snap"`)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	c.Check(tasks[1].WaitTasks(), check.DeepEquals, []*state.Task{tasks[0]})
+	st.Unlock()
+
+	<-chg.Ready()
+
+	st.Lock()
+	err = chg.Err()
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	c.Check(osutil.IsSymlink(filepath.Join(dirs.SnapBinariesDir, "alias1")), check.Equals, true)
+	c.Check(osutil.IsSymlink(filepath.Join(dirs.SnapBinariesDir, "alias2")), check.Equals, true)
+
+	// and remove both
+	chg = s.postAliasAction(c, st, &daemon.AliasAction{
+		Action: "unalias",
+		Snap:   "alias-snap",
+		Targets: []daemon.AliasTarget{
+			{Alias: "alias1"},
+			{Alias: "alias2"},
+		},
+	})
+	st.Lock()
+	c.Check(chg.Kind(), check.Equals, "unalias")
+	c.Check(chg.Summary(), check.Equals, `Remove manual aliases "alias1", "alias2" for snap "alias-snap"`)
+	st.Unlock()
+
+	<-chg.Ready()
+
+	st.Lock()
+	err = chg.Err()
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	c.Check(osutil.FileExists(filepath.Join(dirs.SnapBinariesDir, "alias1")), check.Equals, false)
+	c.Check(osutil.FileExists(filepath.Join(dirs.SnapBinariesDir, "alias2")), check.Equals, false)
+}
+
+func (s *aliasesSuite) TestAliasManyErrors(c *check.C) {
+	s.daemon(c)
+
+	s.mockSnap(c, aliasYaml)
+
+	for _, scen := range []struct {
+		action *daemon.AliasAction
+		err    string
+	}{
+		{&daemon.AliasAction{Action: "alias", Targets: []daemon.AliasTarget{{Alias: "a", App: "app"}}}, `cannot alias multiple aliases without a snap`},
+		{&daemon.AliasAction{Action: "alias", Snap: "alias-snap", Alias: "b", Targets: []daemon.AliasTarget{{Alias: "a", App: "app"}}}, `cannot use alias or app together with targets`},
+		{&daemon.AliasAction{Action: "alias", Snap: "lalala", Targets: []daemon.AliasTarget{{Alias: "a", App: "app"}}}, `snap "lalala" is not installed`},
+		{&daemon.AliasAction{Action: "alias", Snap: "alias-snap", Targets: []daemon.AliasTarget{{App: "app"}}}, `cannot alias: alias name is empty`},
+		{&daemon.AliasAction{Action: "alias", Snap: "alias-snap", Targets: []daemon.AliasTarget{{Alias: "a", App: "app"}, {Alias: "a", App: "app2"}}}, `cannot alias: alias "a" given more than once`},
+		{&daemon.AliasAction{Action: "alias", Snap: "alias-snap", Targets: []daemon.AliasTarget{{Alias: "a"}}}, `cannot alias "a": app name is empty`},
+		{&daemon.AliasAction{Action: "alias", Snap: "alias-snap", Targets: []daemon.AliasTarget{{Alias: ".a", App: "app"}}}, `invalid alias name: ".a"`},
+		{&daemon.AliasAction{Action: "unalias", Snap: "alias-snap", Targets: []daemon.AliasTarget{{Alias: "a"}}}, `cannot remove alias "a": not a manual alias of snap "alias-snap"`},
+		{&daemon.AliasAction{Action: "prefer", Snap: "alias-snap", Targets: []daemon.AliasTarget{{Alias: "a"}}}, `unsupported alias action with targets: "prefer"`},
+	} {
+		text, err := json.Marshal(scen.action)
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/aliases", bytes.NewBuffer(text))
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Matches, scen.err)
+	}
+}
+
 func (s *aliasesSuite) TestUnaliasSnapSuccess(c *check.C) {
 	err := os.MkdirAll(dirs.SnapBinariesDir, 0755)
 	c.Assert(err, check.IsNil)
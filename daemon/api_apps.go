@@ -49,6 +49,7 @@ var (
 		Actions:     []string{"start", "stop", "restart"},
 		ReadAccess:  interfaceOpenAccess{Interfaces: []string{"ros-snapd-support"}},
 		WriteAccess: interfaceAuthenticatedAccess{Interfaces: []string{"ros-snapd-support"}, Polkit: polkitActionManage},
+		Throttled:   true,
 	}
 
 	logsCmd = &Command{
@@ -332,7 +333,7 @@ func postApps(c *Command, r *http.Request, user *auth.UserState) Response {
 	}
 	// names received in the request can be snap or snap.app, we need to
 	// extract the actual snap names before associating them with a change
-	chg := newChange(st, serviceControlChangeKind, "Running service command", tss, namesToSnapNames(inst))
+	chg := newChange(r.Context(), st, serviceControlChangeKind, "Running service command", tss, namesToSnapNames(inst))
 	st.EnsureBefore(0)
 	return AsyncResponse(nil, chg.ID())
 }
@@ -0,0 +1,130 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var baseMigrationsCmd = &Command{
+	Path:       "/v2/base-migrations",
+	GET:        getBaseMigrations,
+	ReadAccess: openAccess{},
+}
+
+var _ = registerAPIFeature("base-migrations")
+
+// getBaseMigrations lists the installed snaps built on bases that
+// reached their end of life, together with the store channels of each
+// snap that offer a revision built on a supported base.
+func getBaseMigrations(c *Command, r *http.Request, user *auth.UserState) Response {
+	wanted := strutil.CommaSeparatedList(r.URL.Query().Get("snaps"))
+
+	st := c.d.overlord.State()
+	st.Lock()
+	byBase, err := snapstate.SnapsOnEOLBases(st)
+	if err != nil {
+		st.Unlock()
+		return InternalError("cannot list snaps on end of life bases: %v", err)
+	}
+	installed, err := snapstate.All(st)
+	if err != nil {
+		st.Unlock()
+		return InternalError("cannot list snaps on end of life bases: %v", err)
+	}
+	migrations := []*client.BaseMigration{}
+	for base, names := range byBase {
+		eol, _ := snapstate.BaseEOL(base)
+		for _, name := range names {
+			if len(wanted) > 0 && !strutil.ListContains(wanted, name) {
+				continue
+			}
+			migrations = append(migrations, &client.BaseMigration{
+				Snap:            name,
+				Base:            base,
+				BaseEOL:         eol,
+				TrackingChannel: installed[name].TrackingChannel,
+			})
+		}
+	}
+	st.Unlock()
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Snap < migrations[j].Snap
+	})
+
+	ctx := store.WithClientUserAgent(r.Context(), r)
+	sto := storeFrom(c.d)
+	for _, m := range migrations {
+		info, err := sto.SnapInfo(ctx, store.SnapSpec{Name: snap.InstanceSnap(m.Snap)}, user)
+		switch err {
+		case nil:
+			// pass
+		case store.ErrInvalidCredentials:
+			return Unauthorized("%v", err)
+		case store.ErrSnapNotFound:
+			// the snap is not (or no longer) in the store
+			continue
+		default:
+			return InternalError("cannot get store information of snap %q: %v", m.Snap, err)
+		}
+		m.Options = baseMigrationOptions(info, m.Base, func(base string) bool {
+			_, ok := installed[base]
+			return ok
+		})
+	}
+
+	return SyncResponse(migrations)
+}
+
+// baseMigrationOptions returns the store channels, ordered by track and
+// then by risk, that offer a revision built on a base other than the
+// given one which did not reach its end of life.
+func baseMigrationOptions(info *snap.Info, base string, isInstalled func(base string) bool) []client.BaseMigrationOption {
+	var opts []client.BaseMigrationOption
+	for _, track := range info.Tracks {
+		for _, risk := range storeChannelRisks {
+			name := track + "/" + risk
+			ch := info.Channels[name]
+			if ch == nil || ch.Base == "" || ch.Base == base {
+				continue
+			}
+			if _, eol := snapstate.BaseEOL(ch.Base); eol {
+				continue
+			}
+			opts = append(opts, client.BaseMigrationOption{
+				Channel:     name,
+				Revision:    ch.Revision,
+				Version:     ch.Version,
+				Base:        ch.Base,
+				InstallBase: !isInstalled(ch.Base),
+			})
+		}
+	}
+	return opts
+}
@@ -0,0 +1,114 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+)
+
+var _ = check.Suite(&baseMigrationsSuite{})
+
+type baseMigrationsSuite struct {
+	apiBaseSuite
+}
+
+func (s *baseMigrationsSuite) TestGetBaseMigrations(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "base: core18\n")
+	s.mkInstalledInState(c, d, "bar", "", "v1", snap.R(1), true, "base: core22\n")
+	s.mkInstalledInState(c, d, "core22", "", "v1", snap.R(1), true, "type: base\n")
+
+	s.rsnaps = []*snap.Info{{
+		Tracks: []string{"latest", "2.x", "3.x"},
+		Channels: map[string]*snap.ChannelSnapInfo{
+			"latest/stable": {Revision: snap.R(1), Version: "v1", Base: "core18"},
+			"2.x/stable":    {Revision: snap.R(3), Version: "v3", Base: "core20"},
+			"3.x/candidate": {Revision: snap.R(6), Version: "v6", Base: "core24"},
+			"3.x/stable":    {Revision: snap.R(5), Version: "v5", Base: "core22"},
+		},
+	}}
+
+	req, err := http.NewRequest("GET", "/v2/base-migrations", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	migrations := rsp.Result.([]*client.BaseMigration)
+	c.Assert(migrations, check.HasLen, 1)
+	c.Check(migrations[0].Snap, check.Equals, "foo")
+	c.Check(migrations[0].Base, check.Equals, "core18")
+	c.Check(migrations[0].BaseEOL.Equal(time.Date(2023, 5, 31, 0, 0, 0, 0, time.UTC)), check.Equals, true)
+	c.Check(migrations[0].Options, check.DeepEquals, []client.BaseMigrationOption{
+		{Channel: "3.x/stable", Revision: snap.R(5), Version: "v5", Base: "core22"},
+		{Channel: "3.x/candidate", Revision: snap.R(6), Version: "v6", Base: "core24", InstallBase: true},
+	})
+}
+
+func (s *baseMigrationsSuite) TestGetBaseMigrationsFiltered(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "base: core18\n")
+	s.mkInstalledInState(c, d, "baz", "", "v1", snap.R(1), true, "base: core20\n")
+	s.err = store.ErrSnapNotFound
+
+	req, err := http.NewRequest("GET", "/v2/base-migrations?snaps=baz", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	migrations := rsp.Result.([]*client.BaseMigration)
+	c.Assert(migrations, check.HasLen, 1)
+	c.Check(migrations[0].Snap, check.Equals, "baz")
+	c.Check(migrations[0].Base, check.Equals, "core20")
+	c.Check(migrations[0].Options, check.HasLen, 0)
+}
+
+func (s *baseMigrationsSuite) TestGetBaseMigrationsNone(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "bar", "", "v1", snap.R(1), true, "base: core22\n")
+
+	req, err := http.NewRequest("GET", "/v2/base-migrations", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	c.Check(rsp.Result, check.DeepEquals, []*client.BaseMigration{})
+	// the store was not asked
+	c.Check(s.ctx, check.IsNil)
+}
+
+func (s *baseMigrationsSuite) TestGetBaseMigrationsStoreErrors(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "base: core18\n")
+
+	s.err = store.ErrInvalidCredentials
+	req, err := http.NewRequest("GET", "/v2/base-migrations", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 401)
+
+	s.err = store.ErrBadQuery
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 500)
+	c.Check(rspe.Message, check.Matches, `cannot get store information of snap "foo": .*`)
+}
@@ -0,0 +1,51 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/auth"
+)
+
+var capabilitiesCmd = &Command{
+	Path:       "/v2/capabilities",
+	GET:        getCapabilities,
+	ReadAccess: openAccess{},
+}
+
+var _ = registerAPIFeature("capabilities")
+
+// capabilities describes what this snapd supports so that clients can rely
+// on machine-readable data instead of parsing messages or comparing versions.
+type capabilities struct {
+	// ErrorKinds is the catalog of error kinds that can be returned in
+	// the kind field of error responses.
+	ErrorKinds  []client.ErrorKindInfo `json:"error-kinds"`
+	APIFeatures []string               `json:"api-features"`
+}
+
+func getCapabilities(c *Command, r *http.Request, user *auth.UserState) Response {
+	return SyncResponse(&capabilities{
+		ErrorKinds:  client.ErrorKinds(),
+		APIFeatures: knownAPIFeatures(),
+	})
+}
@@ -0,0 +1,72 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/testutil"
+)
+
+var _ = check.Suite(&capabilitiesSuite{})
+
+type capabilitiesSuite struct {
+	apiBaseSuite
+}
+
+func (s *capabilitiesSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+}
+
+func (s *capabilitiesSuite) TestGetCapabilities(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/capabilities", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, check.Equals, 200)
+
+	// check the wire format
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, check.IsNil)
+	var res struct {
+		ErrorKinds  []client.ErrorKindInfo `json:"error-kinds"`
+		APIFeatures []string               `json:"api-features"`
+	}
+	c.Assert(json.Unmarshal(data, &res), check.IsNil)
+
+	c.Check(res.ErrorKinds, check.DeepEquals, client.ErrorKinds())
+	kinds := make(map[client.ErrorKind]bool, len(res.ErrorKinds))
+	for _, k := range res.ErrorKinds {
+		kinds[k.Kind] = true
+	}
+	c.Check(kinds[client.ErrorKindSnapMissingBase], check.Equals, true)
+	c.Check(kinds[client.ErrorKindAliasConflict], check.Equals, true)
+	c.Check(kinds[client.ErrorKindValidationSetConstraintsUnmet], check.Equals, true)
+
+	c.Check(res.APIFeatures, testutil.Contains, "capabilities")
+}
@@ -273,6 +273,17 @@ func getConnections(c *Command, r *http.Request, user *auth.UserState) Response
 		}
 	}
 
+	// The version is computed before collecting the connections, so that if
+	// the connections change in between, the version is outdated rather than
+	// newer than the returned connections.
+	st := c.d.overlord.State()
+	st.Lock()
+	version, err := connectionsVersion(st)
+	st.Unlock()
+	if err != nil {
+		return InternalError("cannot compute connections version: %v", err)
+	}
+
 	connsjson, err := collectConnections(c.d.overlord.InterfaceManager(), collectFilter{
 		snapName:  snapName,
 		ifaceName: ifaceName,
@@ -283,6 +294,9 @@ func getConnections(c *Command, r *http.Request, user *auth.UserState) Response
 	}
 	sort.Sort(byCrefConnJSON(connsjson.Established))
 	sort.Sort(byCrefConnJSON(connsjson.Undesired))
+	if shouldRedactAttrs(r) {
+		redactConnections(connsjson)
+	}
 
-	return SyncResponse(connsjson)
+	return syncResponseWithVersion(connsjson, version)
 }
@@ -0,0 +1,92 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+)
+
+// redactedAttrValue replaces the value of attributes that the requester is
+// not allowed to see.
+const redactedAttrValue = "<redacted>"
+
+// attrRedactionRules lists, per interface, the plug and slot attributes
+// which expose host paths or other sensitive details and are therefore
+// only shown in full to root.
+var attrRedactionRules = map[string][]string{
+	"content":        {"read", "write", "source", "target"},
+	"custom-device":  {"devices", "read-devices", "files"},
+	"hidraw":         {"path"},
+	"i2c":            {"path", "sysfs-name"},
+	"mount-control":  {"mount"},
+	"personal-files": {"read", "write"},
+	"serial-port":    {"path"},
+	"spi":            {"path"},
+	"system-files":   {"read", "write"},
+}
+
+// shouldRedactAttrs returns whether interface attributes must be redacted
+// for the requester of r, that is when the requester is not root.
+func shouldRedactAttrs(r *http.Request) bool {
+	uid, err := uidFromRequest(r)
+	return err != nil || uid != 0
+}
+
+// redactAttrs returns the attributes of a plug or slot of the given
+// interface with the values of sensitive attributes replaced. The given map
+// is never modified, a copy is returned if anything needs redacting.
+func redactAttrs(iface string, attrs map[string]any) map[string]any {
+	var redacted map[string]any
+	for _, name := range attrRedactionRules[iface] {
+		if _, ok := attrs[name]; !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = mergeAttrs(attrs, nil)
+		}
+		redacted[name] = redactedAttrValue
+	}
+	if redacted == nil {
+		return attrs
+	}
+	return redacted
+}
+
+// redactConnections redacts the sensitive attributes of all the plugs,
+// slots and connections in connsjson.
+func redactConnections(connsjson *connectionsJSON) {
+	for i := range connsjson.Established {
+		redactConnection(&connsjson.Established[i])
+	}
+	for i := range connsjson.Undesired {
+		redactConnection(&connsjson.Undesired[i])
+	}
+	for _, pj := range connsjson.Plugs {
+		pj.Attrs = redactAttrs(pj.Interface, pj.Attrs)
+	}
+	for _, sj := range connsjson.Slots {
+		sj.Attrs = redactAttrs(sj.Interface, sj.Attrs)
+	}
+}
+
+func redactConnection(cj *connectionJSON) {
+	cj.PlugAttrs = redactAttrs(cj.Interface, cj.PlugAttrs)
+	cj.SlotAttrs = redactAttrs(cj.Interface, cj.SlotAttrs)
+}
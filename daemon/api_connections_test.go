@@ -28,6 +28,7 @@ import (
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
@@ -81,6 +82,10 @@ func (s *interfacesSuite) testConnections(c *check.C, query string, expected map
 	var body map[string]any
 	err = json.Unmarshal(rec.Body.Bytes(), &body)
 	c.Check(err, check.IsNil)
+	// the version depends on the connections state, and is checked separately
+	c.Check(body["resource-version"], check.Matches, "[0-9a-f]{24}")
+	c.Check(rec.Header().Get("ETag"), check.Equals, `"`+body["resource-version"].(string)+`"`)
+	delete(body, "resource-version")
 	c.Check(body, check.DeepEquals, expected)
 }
 
@@ -1042,3 +1047,86 @@ slots:
 		"type":        "sync",
 	})
 }
+
+func (s *interfacesSuite) testConnectionsAsUser(c *check.C, uid int) map[string]any {
+	req, err := http.NewRequest("GET", "/v2/connections", nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", uid, dirs.SnapdSocket)
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil, actionIsExpected).ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	var body map[string]any
+	err = json.Unmarshal(rec.Body.Bytes(), &body)
+	c.Assert(err, check.IsNil)
+	return body["result"].(map[string]any)
+}
+
+func (s *interfacesSuite) TestConnectionsRedactsAttrsForNonRoot(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	restore = daemon.MockAttrRedactionRules(map[string][]string{
+		"test": {"key", "slot-dynamic"},
+	})
+	defer restore()
+
+	d := s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	connRef := &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}
+	_, err := repo.Connect(connRef, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("conns", map[string]any{
+		"consumer:plug producer:slot": map[string]any{
+			"interface":    "test",
+			"plug-static":  map[string]any{"key": "value"},
+			"slot-static":  map[string]any{"key": "value", "other": "visible"},
+			"slot-dynamic": map[string]any{"slot-dynamic": "secret"},
+		},
+	})
+	st.Unlock()
+
+	result := s.testConnectionsAsUser(c, 1000)
+	established := result["established"].([]any)
+	c.Assert(established, check.HasLen, 1)
+	conn := established[0].(map[string]any)
+	c.Check(conn["plug-attrs"], check.DeepEquals, map[string]any{"key": "<redacted>"})
+	c.Check(conn["slot-attrs"], check.DeepEquals, map[string]any{
+		"key":          "<redacted>",
+		"other":        "visible",
+		"slot-dynamic": "<redacted>",
+	})
+	plugs := result["plugs"].([]any)
+	c.Assert(plugs, check.HasLen, 1)
+	c.Check(plugs[0].(map[string]any)["attrs"], check.DeepEquals, map[string]any{"key": "<redacted>"})
+	slots := result["slots"].([]any)
+	c.Assert(slots, check.HasLen, 1)
+	c.Check(slots[0].(map[string]any)["attrs"], check.DeepEquals, map[string]any{"key": "<redacted>"})
+
+	// the attributes in the repository were not modified
+	c.Check(repo.Plug("consumer", "plug").Attrs, check.DeepEquals, map[string]any{"key": "value"})
+	c.Check(repo.Slot("producer", "slot").Attrs, check.DeepEquals, map[string]any{"key": "value"})
+
+	// root gets the full details
+	result = s.testConnectionsAsUser(c, 0)
+	established = result["established"].([]any)
+	c.Assert(established, check.HasLen, 1)
+	conn = established[0].(map[string]any)
+	c.Check(conn["plug-attrs"], check.DeepEquals, map[string]any{"key": "value"})
+	c.Check(conn["slot-attrs"], check.DeepEquals, map[string]any{
+		"key":          "value",
+		"other":        "visible",
+		"slot-dynamic": "secret",
+	})
+	plugs = result["plugs"].([]any)
+	c.Assert(plugs, check.HasLen, 1)
+	c.Check(plugs[0].(map[string]any)["attrs"], check.DeepEquals, map[string]any{"key": "value"})
+}
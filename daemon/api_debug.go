@@ -45,7 +45,8 @@ var debugCmd = &Command{
 	Actions: []string{
 		"add-warning", "unshow-warnings", "ensure-state-soon",
 		"can-manage-refreshes", "prune", "stacktraces",
-		"create-recovery-system", "migrate-home",
+		"create-recovery-system", "migrate-home", "set-log-level",
+		"set-model-grade-override",
 	},
 	ReadAccess:  openAccess{},
 	WriteAccess: rootAccess{},
@@ -58,8 +59,14 @@ type debugAction struct {
 		ChgID string `json:"chg-id"`
 
 		RecoverySystemLabel string `json:"recovery-system-label"`
+
+		ModelGrade string `json:"model-grade"`
 	} `json:"params"`
 	Snaps []string `json:"snaps"`
+
+	Level      string   `json:"level"`
+	Subsystems []string `json:"subsystems"`
+	Duration   string   `json:"duration"`
 }
 
 type connectivityStatus struct {
@@ -405,6 +412,12 @@ func getDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return getRAAInfo(st)
 	case "features":
 		return getFeatures(c)
+	case "model-grade":
+		return getModelGrade(st)
+	case "device-session":
+		return getDeviceSession(st)
+	case "boot-assets":
+		return getBootAssetsHistory(st)
 	default:
 		return BadRequest("unknown debug aspect %q", aspect)
 	}
@@ -446,6 +459,10 @@ func postDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return createRecovery(st, a.Params.RecoverySystemLabel)
 	case "migrate-home":
 		return migrateHome(st, a.Snaps)
+	case "set-model-grade-override":
+		return setModelGradeOverride(st, a.Params.ModelGrade)
+	case "set-log-level":
+		return setLogLevel(a.Level, a.Subsystems, a.Duration)
 	default:
 		return BadRequest("unknown debug action: %v", a.Action)
 	}
@@ -0,0 +1,71 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = check.Suite(&debugBootAssetsSuite{})
+
+type debugBootAssetsSuite struct {
+	apiBaseSuite
+}
+
+func (s *debugBootAssetsSuite) getBootAssets(c *check.C) any {
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=boot-assets", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	return rsp.Result
+}
+
+func (s *debugBootAssetsSuite) TestGetBootAssetsEmpty(c *check.C) {
+	s.daemon(c)
+
+	c.Check(s.getBootAssets(c), check.DeepEquals, []devicestate.BootAssetUpdate{})
+}
+
+func (s *debugBootAssetsSuite) TestGetBootAssets(c *check.C) {
+	d := s.daemon(c)
+
+	history := []devicestate.BootAssetUpdate{{
+		Asset:    "EFI/boot/grubx64.efi",
+		Role:     "system-seed",
+		OldHash:  "old-hash",
+		NewHash:  "new-hash",
+		Snap:     "pc",
+		Revision: snap.R(12),
+		ChangeID: "3",
+		Resealed: true,
+		Time:     time.Date(2025, 6, 3, 10, 0, 0, 0, time.UTC),
+	}}
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("boot-assets-history", history)
+	st.Unlock()
+
+	c.Check(s.getBootAssets(c), check.DeepEquals, history)
+}
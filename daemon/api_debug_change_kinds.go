@@ -0,0 +1,41 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/swfeats"
+)
+
+var debugChangeKindsCmd = &Command{
+	Path:       "/v2/debug/change-kinds",
+	GET:        getDebugChangeKinds,
+	ReadAccess: openAccess{},
+}
+
+var _ = registerAPIFeature("change-kinds")
+
+// getDebugChangeKinds lists the change kinds snapd can create, with
+// their description and typical sequence of task kinds when known.
+func getDebugChangeKinds(c *Command, r *http.Request, user *auth.UserState) Response {
+	return SyncResponse(swfeats.ChangeKinds())
+}
@@ -0,0 +1,73 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/swfeats"
+)
+
+var _ = check.Suite(&debugChangeKindsSuite{})
+
+type debugChangeKindsSuite struct {
+	apiBaseSuite
+}
+
+func (s *debugChangeKindsSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+}
+
+func (s *debugChangeKindsSuite) TestGetChangeKinds(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug/change-kinds", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	kinds := rsp.Result.([]swfeats.ChangeKind)
+	byKind := make(map[string]swfeats.ChangeKind, len(kinds))
+	for i, k := range kinds {
+		if i > 0 {
+			c.Check(kinds[i-1].Kind < k.Kind, check.Equals, true)
+		}
+		byKind[k.Kind] = k
+	}
+	c.Check(kinds, check.HasLen, len(swfeats.KnownChangeKinds()))
+
+	install, ok := byKind["install-snap"]
+	c.Assert(ok, check.Equals, true)
+	c.Check(install.Description, check.Equals, "Install snaps")
+	c.Check(install.Tasks[0], check.Equals, "prerequisites")
+
+	connect, ok := byKind["connect-snap"]
+	c.Assert(ok, check.Equals, true)
+	c.Check(connect.Description, check.Equals, "Connect interfaces")
+	c.Check(connect.Tasks, check.DeepEquals, []string{"run-hook", "run-hook", "connect", "run-hook", "run-hook"})
+
+	// kinds without a description are listed too
+	_, ok = byKind["alias"]
+	c.Check(ok, check.Equals, true)
+}
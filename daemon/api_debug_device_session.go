@@ -0,0 +1,70 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"time"
+
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var devicestateDeviceSessionStatusInfo = devicestate.DeviceSessionStatusInfo
+
+type deviceSessionInfo struct {
+	HasSession bool `json:"has-session"`
+	// Age is the time since the current session was obtained.
+	Age              string     `json:"age,omitempty"`
+	Obtained         *time.Time `json:"obtained,omitempty"`
+	Expiry           *time.Time `json:"expiry,omitempty"`
+	RenewAfter       *time.Time `json:"renew-after,omitempty"`
+	LastRenewal      *time.Time `json:"last-renewal,omitempty"`
+	LastRenewalError string     `json:"last-renewal-error,omitempty"`
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func getDeviceSession(st *state.State) Response {
+	status, err := devicestateDeviceSessionStatusInfo(st)
+	if err != nil {
+		return InternalError("cannot get device session status: %v", err)
+	}
+	if status == nil {
+		return SyncResponse(&deviceSessionInfo{})
+	}
+
+	info := &deviceSessionInfo{
+		HasSession:       true,
+		Obtained:         timeOrNil(status.Obtained),
+		Expiry:           timeOrNil(status.Expiry),
+		RenewAfter:       timeOrNil(status.RenewAfter),
+		LastRenewal:      timeOrNil(status.LastRenewal),
+		LastRenewalError: status.LastRenewalError,
+	}
+	if !status.Obtained.IsZero() {
+		info.Age = timeNow().Sub(status.Obtained).Truncate(time.Second).String()
+	}
+	return SyncResponse(info)
+}
@@ -0,0 +1,98 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = check.Suite(&debugDeviceSessionSuite{})
+
+type debugDeviceSessionSuite struct {
+	apiBaseSuite
+}
+
+func (s *debugDeviceSessionSuite) getDeviceSession(c *check.C) map[string]any {
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=device-session", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	return resultAsMap(c, rsp.Result)
+}
+
+func (s *debugDeviceSessionSuite) TestGetDeviceSession(c *check.C) {
+	s.daemon(c)
+
+	now := time.Date(2025, 6, 3, 10, 0, 0, 0, time.UTC)
+	defer daemon.MockTimeNow(func() time.Time { return now })()
+	obtained := now.Add(-48 * time.Hour)
+	defer daemon.MockDevicestateDeviceSessionStatusInfo(func(st *state.State) (*devicestate.DeviceSessionStatus, error) {
+		return &devicestate.DeviceSessionStatus{
+			Obtained:         obtained,
+			Expiry:           obtained.Add(7 * 24 * time.Hour),
+			RenewAfter:       obtained.Add(6 * 24 * time.Hour),
+			LastRenewal:      now.Add(-time.Hour),
+			LastRenewalError: "network down",
+		}, nil
+	})()
+
+	c.Check(s.getDeviceSession(c), check.DeepEquals, map[string]any{
+		"has-session":        true,
+		"age":                "48h0m0s",
+		"obtained":           "2025-06-01T10:00:00Z",
+		"expiry":             "2025-06-08T10:00:00Z",
+		"renew-after":        "2025-06-07T10:00:00Z",
+		"last-renewal":       "2025-06-03T09:00:00Z",
+		"last-renewal-error": "network down",
+	})
+}
+
+func (s *debugDeviceSessionSuite) TestGetDeviceSessionNoSession(c *check.C) {
+	s.daemon(c)
+
+	defer daemon.MockDevicestateDeviceSessionStatusInfo(func(st *state.State) (*devicestate.DeviceSessionStatus, error) {
+		return nil, nil
+	})()
+
+	c.Check(s.getDeviceSession(c), check.DeepEquals, map[string]any{
+		"has-session": false,
+	})
+}
+
+func (s *debugDeviceSessionSuite) TestGetDeviceSessionError(c *check.C) {
+	s.daemon(c)
+
+	defer daemon.MockDevicestateDeviceSessionStatusInfo(func(st *state.State) (*devicestate.DeviceSessionStatus, error) {
+		return nil, errors.New("boom")
+	})()
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=device-session", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 500)
+	c.Check(rspe.Message, check.Equals, "cannot get device session status: boom")
+}
@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/historystate"
+)
+
+var debugHistoryCmd = &Command{
+	Path:       "/v2/debug/history",
+	GET:        getDebugHistory,
+	ReadAccess: rootAccess{},
+}
+
+var _ = registerAPIFeature("operation-history")
+
+var historyCSVHeader = []string{
+	"time", "operation", "status", "summary", "snaps", "user",
+	"change-id", "change-kind", "spawn-time",
+}
+
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func getDebugHistory(c *Command, r *http.Request, user *auth.UserState) Response {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	switch format {
+	case "", "json", "csv":
+	default:
+		return BadRequest("invalid format %q, expected json or csv", format)
+	}
+	from, err := parseHistoryTime(query.Get("from"))
+	if err != nil {
+		return BadRequest("invalid from parameter: %v", err)
+	}
+	to, err := parseHistoryTime(query.Get("to"))
+	if err != nil {
+		return BadRequest("invalid to parameter: %v", err)
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return BadRequest("invalid period: to is before from")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	entries, err := historystate.Entries(st, from, to)
+	st.Unlock()
+	if err != nil {
+		return InternalError("cannot get operation history: %v", err)
+	}
+
+	if format == "csv" {
+		return historyCSVResponse(entries)
+	}
+	return SyncResponse(entries)
+}
+
+// historyCSVResponse serves the operation history as CSV.
+type historyCSVResponse []*historystate.Entry
+
+func formatHistoryTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (entries historyCSVResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="snapd-history.csv"`)
+	w.WriteHeader(200)
+
+	cw := csv.NewWriter(w)
+	cw.Write(historyCSVHeader)
+	for _, e := range entries {
+		cw.Write([]string{
+			formatHistoryTime(e.Time),
+			e.Operation,
+			e.Status,
+			e.Summary,
+			strings.Join(e.Snaps, " "),
+			e.User,
+			e.ChangeID,
+			e.ChangeKind,
+			formatHistoryTime(e.SpawnTime),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		logger.Noticef("cannot write operation history: %v", err)
+	}
+}
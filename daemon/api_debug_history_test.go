@@ -0,0 +1,119 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/historystate"
+)
+
+var _ = check.Suite(&debugHistorySuite{})
+
+type debugHistorySuite struct {
+	apiBaseSuite
+}
+
+func (s *debugHistorySuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.expectRootAccess()
+}
+
+func (s *debugHistorySuite) mockHistory(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	for _, e := range []*historystate.Entry{{
+		Time:       time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+		Operation:  historystate.OperationInstall,
+		Summary:    `Install "foo" snap`,
+		Status:     "Done",
+		Snaps:      []string{"foo"},
+		ChangeID:   "1",
+		ChangeKind: "install-snap",
+		SpawnTime:  time.Date(2025, 6, 1, 9, 59, 0, 0, time.UTC),
+	}, {
+		Time:      time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC),
+		Operation: historystate.OperationCreateUser,
+		Summary:   `Create user "bob"`,
+		Status:    "Done",
+		User:      "bob",
+	}} {
+		c.Assert(historystate.Record(st, e), check.IsNil)
+	}
+}
+
+func (s *debugHistorySuite) TestGetHistoryJSON(c *check.C) {
+	s.daemon(c)
+	s.mockHistory(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug/history?from=2025-06-02T00:00:00Z", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsUnexpected)
+	c.Check(rsp.Result, check.DeepEquals, []*historystate.Entry{{
+		Time:      time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC),
+		Operation: historystate.OperationCreateUser,
+		Summary:   `Create user "bob"`,
+		Status:    "Done",
+		User:      "bob",
+	}})
+}
+
+func (s *debugHistorySuite) TestGetHistoryCSV(c *check.C) {
+	s.daemon(c)
+	s.mockHistory(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug/history?format=csv", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.req(c, req, nil, actionIsUnexpected)
+
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Header().Get("Content-Type"), check.Equals, "text/csv")
+	c.Check(rec.Body.String(), check.Equals, `time,operation,status,summary,snaps,user,change-id,change-kind,spawn-time
+2025-06-01T10:00:00Z,install,Done,"Install ""foo"" snap",foo,,1,install-snap,2025-06-01T09:59:00Z
+2025-06-02T10:00:00Z,create-user,Done,"Create user ""bob""",,bob,,,
+`)
+}
+
+func (s *debugHistorySuite) TestGetHistoryErrors(c *check.C) {
+	s.daemon(c)
+
+	for _, tc := range []struct {
+		query string
+		msg   string
+	}{
+		{"format=xml", `invalid format "xml", expected json or csv`},
+		{"from=yesterday", `invalid from parameter: .*`},
+		{"to=tomorrow", `invalid to parameter: .*`},
+		{"from=2025-06-02T00:00:00Z&to=2025-06-01T00:00:00Z", `invalid period: to is before from`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/debug/history?"+tc.query, nil)
+		c.Assert(err, check.IsNil)
+		rsp := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rsp.Status, check.Equals, 400)
+		c.Check(rsp.Message, check.Matches, tc.msg)
+	}
+}
@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+)
+
+const (
+	// defaultLogLevelDuration is how long a log level override lasts if
+	// the request does not specify a duration.
+	defaultLogLevelDuration = 30 * time.Minute
+	// maxLogLevelDuration is the longest a log level override can last.
+	maxLogLevelDuration = 24 * time.Hour
+)
+
+type logLevelOverrideJSON struct {
+	Subsystem string    `json:"subsystem"`
+	Level     string    `json:"level"`
+	Until     time.Time `json:"until"`
+}
+
+func logLevelOverrides() []logLevelOverrideJSON {
+	overrides := logger.DebugOverrides()
+	if len(overrides) == 0 {
+		return nil
+	}
+	res := make([]logLevelOverrideJSON, 0, len(overrides))
+	for _, o := range overrides {
+		res = append(res, logLevelOverrideJSON{
+			Subsystem: o.Subsystem,
+			Level:     "debug",
+			Until:     o.Until,
+		})
+	}
+	return res
+}
+
+// setLogLevel temporarily raises the log level of the given subsystems, or
+// of all of them if none are given, to debug. The "default" level drops
+// the overrides again before they expire.
+func setLogLevel(level string, subsystems []string, durationStr string) Response {
+	for _, subsystem := range subsystems {
+		if subsystem == "" {
+			return BadRequest("cannot use an empty subsystem name")
+		}
+	}
+
+	switch level {
+	case "debug":
+		duration := defaultLogLevelDuration
+		if durationStr != "" {
+			var err error
+			duration, err = time.ParseDuration(durationStr)
+			if err != nil {
+				return BadRequest("cannot parse log level duration: %v", err)
+			}
+		}
+		if duration <= 0 || duration > maxLogLevelDuration {
+			return BadRequest("log level duration must be positive and at most %v", maxLogLevelDuration)
+		}
+		until := timeNow().Add(duration)
+		logger.SetDebugOverrides(subsystems, until)
+		logger.Noticef("debug logging enabled for %s until %s", logLevelSubsystemsStr(subsystems), until.Format(time.RFC3339))
+	case "default":
+		if durationStr != "" {
+			return BadRequest(`cannot use a duration with the "default" log level`)
+		}
+		logger.ClearDebugOverrides(subsystems)
+		logger.Noticef("debug logging reset for %s", logLevelSubsystemsStr(subsystems))
+	case "":
+		return BadRequest("log level not specified")
+	default:
+		return BadRequest("unsupported log level: %q", level)
+	}
+
+	overrides := logLevelOverrides()
+	if overrides == nil {
+		overrides = []logLevelOverrideJSON{}
+	}
+	return SyncResponse(overrides)
+}
+
+func logLevelSubsystemsStr(subsystems []string) string {
+	if len(subsystems) == 0 {
+		return "all subsystems"
+	}
+	return strings.Join(subsystems, ", ")
+}
@@ -0,0 +1,120 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/logger"
+)
+
+var _ = check.Suite(&debugLogLevelSuite{})
+
+type debugLogLevelSuite struct {
+	apiBaseSuite
+}
+
+func (s *debugLogLevelSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.AddCleanup(func() { logger.ClearDebugOverrides(nil) })
+
+	s.expectRootAccess()
+}
+
+func (s *debugLogLevelSuite) postSetLogLevel(c *check.C, body string) *http.Request {
+	req, err := http.NewRequest("POST", "/v2/debug", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	return req
+}
+
+func (s *debugLogLevelSuite) TestSetLogLevelSubsystems(c *check.C) {
+	s.daemon(c)
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	before := time.Now()
+	req := s.postSetLogLevel(c, `{"action": "set-log-level", "level": "debug", "subsystems": ["store", "ifacestate"], "duration": "10m"}`)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	after := time.Now()
+
+	overrides := rsp.Result.([]daemon.LogLevelOverrideJSON)
+	c.Assert(overrides, check.HasLen, 2)
+	c.Check(overrides[0].Subsystem, check.Equals, "ifacestate")
+	c.Check(overrides[1].Subsystem, check.Equals, "store")
+	for _, o := range overrides {
+		c.Check(o.Level, check.Equals, "debug")
+		c.Check(o.Until.Before(before.Add(10*time.Minute)), check.Equals, false)
+		c.Check(o.Until.After(after.Add(10*time.Minute)), check.Equals, false)
+	}
+	c.Check(logbuf.String(), check.Matches, `(?s).*debug logging enabled for store, ifacestate until .*`)
+
+	// and reset one of them
+	req = s.postSetLogLevel(c, `{"action": "set-log-level", "level": "default", "subsystems": ["store"]}`)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	overrides = rsp.Result.([]daemon.LogLevelOverrideJSON)
+	c.Assert(overrides, check.HasLen, 1)
+	c.Check(overrides[0].Subsystem, check.Equals, "ifacestate")
+}
+
+func (s *debugLogLevelSuite) TestSetLogLevelAllDefaultDuration(c *check.C) {
+	s.daemon(c)
+
+	before := time.Now()
+	req := s.postSetLogLevel(c, `{"action": "set-log-level", "level": "debug"}`)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	overrides := rsp.Result.([]daemon.LogLevelOverrideJSON)
+	c.Assert(overrides, check.HasLen, 1)
+	c.Check(overrides[0].Subsystem, check.Equals, "all")
+	c.Check(overrides[0].Until.Before(before.Add(30*time.Minute)), check.Equals, false)
+
+	req = s.postSetLogLevel(c, `{"action": "set-log-level", "level": "default"}`)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, []daemon.LogLevelOverrideJSON{})
+	c.Check(logger.DebugOverrides(), check.HasLen, 0)
+}
+
+func (s *debugLogLevelSuite) TestSetLogLevelErrors(c *check.C) {
+	s.daemon(c)
+
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "set-log-level"}`, `log level not specified`},
+		{`{"action": "set-log-level", "level": "trace"}`, `unsupported log level: "trace"`},
+		{`{"action": "set-log-level", "level": "debug", "duration": "soon"}`, `cannot parse log level duration: .*`},
+		{`{"action": "set-log-level", "level": "debug", "duration": "-1m"}`, `log level duration must be positive and at most 24h0m0s`},
+		{`{"action": "set-log-level", "level": "debug", "duration": "25h"}`, `log level duration must be positive and at most 24h0m0s`},
+		{`{"action": "set-log-level", "level": "default", "duration": "1h"}`, `cannot use a duration with the "default" log level`},
+		{`{"action": "set-log-level", "level": "debug", "subsystems": [""]}`, `cannot use an empty subsystem name`},
+	} {
+		req := s.postSetLogLevel(c, tc.body)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(tc.body))
+		c.Check(rspe.Message, check.Matches, tc.err, check.Commentf(tc.body))
+	}
+	c.Check(logger.DebugOverrides(), check.HasLen, 0)
+}
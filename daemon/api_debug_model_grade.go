@@ -0,0 +1,72 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+)
+
+type modelGradeBehavior struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Grades      []asserts.ModelGrade `json:"grades"`
+	Overridable bool                 `json:"overridable,omitempty"`
+	Active      bool                 `json:"active"`
+}
+
+type modelGradeInfo struct {
+	Grade asserts.ModelGrade `json:"grade"`
+	// Override is set while a testing override of the grade is active.
+	Override  asserts.ModelGrade   `json:"override,omitempty"`
+	Behaviors []modelGradeBehavior `json:"behaviors"`
+}
+
+func getModelGrade(st *state.State) Response {
+	status, err := devicestate.GetModelGradeStatus(st)
+	if err != nil {
+		return InternalError("cannot get model grade: %v", err)
+	}
+
+	info := &modelGradeInfo{
+		Grade:     status.Grade,
+		Override:  status.Override,
+		Behaviors: make([]modelGradeBehavior, 0, len(status.Behaviors)),
+	}
+	for _, b := range status.Behaviors {
+		info.Behaviors = append(info.Behaviors, modelGradeBehavior{
+			Name:        b.Name,
+			Description: b.Description,
+			Grades:      b.Grades,
+			Overridable: b.Overridable,
+			Active:      strutil.ListContains(status.Active, b.Name),
+		})
+	}
+	return SyncResponse(info)
+}
+
+func setModelGradeOverride(st *state.State, grade string) Response {
+	if err := devicestate.SetModelGradeOverride(st, asserts.ModelGrade(grade)); err != nil {
+		return BadRequest("%v", err)
+	}
+	return getModelGrade(st)
+}
@@ -0,0 +1,159 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/snapdenv"
+)
+
+var _ = check.Suite(&debugModelGradeSuite{})
+
+type debugModelGradeSuite struct {
+	apiBaseSuite
+}
+
+func (s *debugModelGradeSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	// the model grade can only be overridden when testing
+	s.AddCleanup(snapdenv.MockTesting(true))
+}
+
+func (s *debugModelGradeSuite) mockDangerousModel(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+
+	// the daemon is set up with a "pc" model already
+	s.mockModel(st, s.Brands.Model("can0nical", "pc-dangerous", map[string]any{
+		"architecture": "amd64",
+		"grade":        "dangerous",
+		"base":         "core20",
+		"snaps": []any{
+			map[string]any{
+				"name":            "pc-kernel",
+				"id":              snaptest.AssertedSnapID("pc-kernel"),
+				"type":            "kernel",
+				"default-channel": "20",
+			},
+			map[string]any{
+				"name":            "pc",
+				"id":              snaptest.AssertedSnapID("pc"),
+				"type":            "gadget",
+				"default-channel": "20",
+			},
+		},
+	}))
+}
+
+func resultAsMap(c *check.C, result any) map[string]any {
+	b, err := json.Marshal(result)
+	c.Assert(err, check.IsNil)
+	var m map[string]any
+	c.Assert(json.Unmarshal(b, &m), check.IsNil)
+	return m
+}
+
+func activeBehaviors(info map[string]any) []string {
+	var active []string
+	for _, b := range info["behaviors"].([]any) {
+		behavior := b.(map[string]any)
+		if behavior["active"].(bool) {
+			active = append(active, behavior["name"].(string))
+		}
+	}
+	return active
+}
+
+func (s *debugModelGradeSuite) TestGetModelGrade(c *check.C) {
+	s.mockDangerousModel(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=model-grade", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	info := resultAsMap(c, rsp.Result)
+	c.Check(info["grade"], check.Equals, "dangerous")
+	c.Check(info["override"], check.IsNil)
+	c.Check(activeBehaviors(info), check.DeepEquals, []string{
+		"unasserted-device-snaps",
+		"dangerous-kernel-cmdline",
+		"unasserted-seed-snaps",
+	})
+}
+
+func (s *debugModelGradeSuite) TestSetModelGradeOverride(c *check.C) {
+	s.mockDangerousModel(c)
+	s.expectRootAccess()
+
+	buf := bytes.NewBufferString(`{"action": "set-model-grade-override", "params": {"model-grade": "signed"}}`)
+	req, err := http.NewRequest("POST", "/v2/debug", buf)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	info := resultAsMap(c, rsp.Result)
+	c.Check(info["grade"], check.Equals, "dangerous")
+	c.Check(info["override"], check.Equals, "signed")
+	// the seed is still validated with the real grade
+	c.Check(activeBehaviors(info), check.DeepEquals, []string{"unasserted-seed-snaps"})
+
+	// and back again
+	buf = bytes.NewBufferString(`{"action": "set-model-grade-override", "params": {"model-grade": ""}}`)
+	req, err = http.NewRequest("POST", "/v2/debug", buf)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+
+	info = resultAsMap(c, rsp.Result)
+	c.Check(info["override"], check.IsNil)
+	c.Check(activeBehaviors(info), check.HasLen, 3)
+}
+
+func (s *debugModelGradeSuite) TestSetModelGradeOverrideInvalid(c *check.C) {
+	s.mockDangerousModel(c)
+	s.expectRootAccess()
+
+	buf := bytes.NewBufferString(`{"action": "set-model-grade-override", "params": {"model-grade": "foo"}}`)
+	req, err := http.NewRequest("POST", "/v2/debug", buf)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `cannot override the model grade with invalid grade "foo"`)
+}
+
+func (s *debugModelGradeSuite) TestSetModelGradeOverrideNotTesting(c *check.C) {
+	s.AddCleanup(snapdenv.MockTesting(false))
+	s.mockDangerousModel(c)
+	s.expectRootAccess()
+
+	buf := bytes.NewBufferString(`{"action": "set-model-grade-override", "params": {"model-grade": "signed"}}`)
+	req, err := http.NewRequest("POST", "/v2/debug", buf)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot override the model grade outside of testing")
+}
@@ -26,6 +26,8 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
 )
 
 var debugPprofCmd = &Command{
@@ -34,7 +36,15 @@ var debugPprofCmd = &Command{
 	ReadAccess: rootAccess{},
 }
 
+// getPprof serves the runtime profiling data of snapd, as provided by
+// net/http/pprof. Since profiles expose internal details of the daemon and
+// collecting them has a cost, the endpoints are only available when the
+// debug.snapd.profiling system option is set to true.
 func getPprof(c *Command, r *http.Request, user *auth.UserState) Response {
+	if rsp := checkProfilingEnabled(c.d.state); rsp != nil {
+		return rsp
+	}
+
 	router := mux.NewRouter()
 	router.HandleFunc("/v2/debug/pprof/cmdline", pprof.Cmdline)
 	router.HandleFunc("/v2/debug/pprof/profile", pprof.Profile)
@@ -45,3 +55,17 @@ func getPprof(c *Command, r *http.Request, user *auth.UserState) Response {
 	}
 	return router
 }
+
+func checkProfilingEnabled(st *state.State) *apiError {
+	st.Lock()
+	defer st.Unlock()
+	tr := config.NewTransaction(st)
+	var enabled bool
+	if err := tr.Get("core", "debug.snapd.profiling", &enabled); err != nil && !config.IsNoOption(err) {
+		return InternalError("cannot check whether profiling is enabled: %v", err)
+	}
+	if !enabled {
+		return Forbidden("profiling is disabled: set 'core.debug.snapd.profiling' to true")
+	}
+	return nil
+}
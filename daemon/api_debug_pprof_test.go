@@ -27,6 +27,8 @@ import (
 	"os"
 
 	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
 )
 
 var _ = check.Suite(&pprofDebugSuite{})
@@ -35,8 +37,18 @@ type pprofDebugSuite struct {
 	apiBaseSuite
 }
 
+func (s *pprofDebugSuite) enableProfiling(c *check.C, enabled bool) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "debug.snapd.profiling", enabled), check.IsNil)
+	tr.Commit()
+}
+
 func (s *pprofDebugSuite) TestGetPprofCmdline(c *check.C) {
 	s.daemon(c)
+	s.enableProfiling(c, true)
 
 	req, err := http.NewRequest("GET", "/v2/debug/pprof/cmdline", nil)
 	c.Assert(err, check.IsNil)
@@ -58,3 +70,37 @@ func (s *pprofDebugSuite) TestGetPprofCmdline(c *check.C) {
 	cmdline = bytes.TrimRight(cmdline, "\x00")
 	c.Assert(string(data), check.DeepEquals, string(cmdline))
 }
+
+func (s *pprofDebugSuite) TestGetPprofDisabled(c *check.C) {
+	s.daemon(c)
+	s.expectRootAccess()
+
+	for _, enabled := range []*bool{nil, new(bool)} {
+		if enabled != nil {
+			s.enableProfiling(c, *enabled)
+		}
+
+		for _, profile := range []string{"heap", "goroutine", "profile", "trace"} {
+			req, err := http.NewRequest("GET", "/v2/debug/pprof/"+profile, nil)
+			c.Assert(err, check.IsNil)
+
+			rspe := s.errorReq(c, req, nil, actionIsExpected)
+			c.Check(rspe.Status, check.Equals, 403)
+			c.Check(rspe.Message, check.Equals, "profiling is disabled: set 'core.debug.snapd.profiling' to true")
+		}
+	}
+}
+
+func (s *pprofDebugSuite) TestGetPprofHeap(c *check.C) {
+	s.daemon(c)
+	s.enableProfiling(c, true)
+
+	req, err := http.NewRequest("GET", "/v2/debug/pprof/heap?debug=1", nil)
+	c.Assert(err, check.IsNil)
+	s.asRootAuth(req)
+
+	rr := httptest.NewRecorder()
+	s.serveHTTP(c, rr, req)
+	c.Check(rr.Code, check.Equals, 200)
+	c.Check(rr.Body.String(), check.Matches, "(?s)heap profile: .*")
+}
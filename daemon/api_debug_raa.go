@@ -39,10 +39,11 @@ type monitoredSnapInfo struct {
 }
 
 type refreshCandidateInfo struct {
-	Revision  snap.Revision `json:"revision,omitzero"`
-	Version   string        `json:"version,omitempty"`
-	Channel   string        `json:"channel,omitempty"`
-	Monitored bool          `json:"monitored,omitempty"`
+	Revision   snap.Revision `json:"revision,omitzero"`
+	Version    string        `json:"version,omitempty"`
+	Channel    string        `json:"channel,omitempty"`
+	Monitored  bool          `json:"monitored,omitempty"`
+	Prefetched bool          `json:"prefetched,omitempty"`
 }
 
 // refreshCandidate is a subset of refreshCandidate defined by snapstate and
@@ -53,6 +54,8 @@ type refreshCandidate struct {
 	SideInfo *snap.SideInfo `json:"side-info,omitempty"`
 	// This is the persistent variant of "monitored-snaps" in the in-memory cache.
 	Monitored bool `json:"monitored,omitempty"`
+	// Prefetched is set once the blob was downloaded ahead of the refresh.
+	Prefetched bool `json:"prefetched,omitempty"`
 }
 
 func getMonitoringAborts(st *state.State) (map[string]context.CancelFunc, error) {
@@ -87,10 +90,11 @@ func getRAAInfo(st *state.State) Response {
 	}
 	for snapName, candidate := range candidates {
 		info := refreshCandidateInfo{
-			Revision:  candidate.SideInfo.Revision,
-			Version:   candidate.Version,
-			Channel:   candidate.Channel,
-			Monitored: candidate.Monitored,
+			Revision:   candidate.SideInfo.Revision,
+			Version:    candidate.Version,
+			Channel:    candidate.Channel,
+			Monitored:  candidate.Monitored,
+			Prefetched: candidate.Prefetched,
 		}
 		data.RefreshCandidates[snapName] = info
 	}
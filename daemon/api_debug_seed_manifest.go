@@ -0,0 +1,153 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/snap"
+)
+
+var debugSeedManifestCmd = &Command{
+	Path:       "/v2/debug/seed-manifest",
+	GET:        getDebugSeedManifest,
+	ReadAccess: openAccess{},
+}
+
+var _ = registerAPIFeature("seed-manifest")
+
+var deviceManagerSeedManifest = (*devicestate.DeviceManager).SeedManifest
+
+type seedManifestSnap struct {
+	Name       string        `json:"name"`
+	SnapID     string        `json:"snap-id,omitempty"`
+	Revision   snap.Revision `json:"revision"`
+	Channel    string        `json:"channel,omitempty"`
+	Essential  bool          `json:"essential,omitempty"`
+	Required   bool          `json:"required,omitempty"`
+	Components []string      `json:"components,omitempty"`
+}
+
+type seedManifestAssertion struct {
+	Type       string   `json:"type"`
+	PrimaryKey []string `json:"primary-key"`
+}
+
+type seedSnapDrift struct {
+	Name            string `json:"name"`
+	Change          string `json:"change"`
+	SeedRevision    string `json:"seed-revision,omitempty"`
+	SeedChannel     string `json:"seed-channel,omitempty"`
+	CurrentRevision string `json:"current-revision,omitempty"`
+	CurrentChannel  string `json:"current-channel,omitempty"`
+}
+
+type seedDrift struct {
+	// ModelChanged is true when the device was remodeled since seeding.
+	ModelChanged bool            `json:"model-changed"`
+	Snaps        []seedSnapDrift `json:"snaps"`
+}
+
+type seedManifest struct {
+	// System is the label of the recovery system the device was seeded
+	// from, if any.
+	System        string                  `json:"system,omitempty"`
+	BrandID       string                  `json:"brand-id"`
+	Model         string                  `json:"model"`
+	ModelRevision int                     `json:"model-revision"`
+	Snaps         []seedManifestSnap      `json:"snaps"`
+	Assertions    []seedManifestAssertion `json:"assertions"`
+	// Drift describes the differences between the seed and the current
+	// state of the device.
+	Drift seedDrift `json:"drift"`
+}
+
+func revisionOrEmpty(rev snap.Revision) string {
+	if rev.Unset() {
+		return ""
+	}
+	return rev.String()
+}
+
+// getDebugSeedManifest returns the composition of the seed the device was
+// originally seeded from, together with how the device drifted from it.
+func getDebugSeedManifest(c *Command, r *http.Request, user *auth.UserState) Response {
+	// the seed is loaded without holding the state lock
+	manifest, err := deviceManagerSeedManifest(c.d.overlord.DeviceManager())
+	if err != nil {
+		if errors.Is(err, devicestate.ErrNotSeeded) {
+			return BadRequest("cannot get seed manifest: %v", err)
+		}
+		return InternalError("cannot get seed manifest: %v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	drift, err := devicestate.ComputeSeedDrift(st, manifest)
+	st.Unlock()
+	if err != nil {
+		return InternalError("cannot compare seed with current state: %v", err)
+	}
+
+	data := &seedManifest{
+		System:        manifest.System,
+		BrandID:       manifest.BrandID,
+		Model:         manifest.Model,
+		ModelRevision: manifest.ModelRevision,
+		Snaps:         make([]seedManifestSnap, 0, len(manifest.Snaps)),
+		Assertions:    make([]seedManifestAssertion, 0, len(manifest.Assertions)),
+		Drift: seedDrift{
+			ModelChanged: drift.ModelChanged,
+			Snaps:        make([]seedSnapDrift, 0, len(drift.Snaps)),
+		},
+	}
+	for _, sn := range manifest.Snaps {
+		data.Snaps = append(data.Snaps, seedManifestSnap{
+			Name:       sn.Name,
+			SnapID:     sn.SnapID,
+			Revision:   sn.Revision,
+			Channel:    sn.Channel,
+			Essential:  sn.Essential,
+			Required:   sn.Required,
+			Components: sn.Components,
+		})
+	}
+	for _, ref := range manifest.Assertions {
+		data.Assertions = append(data.Assertions, seedManifestAssertion{
+			Type:       ref.Type.Name,
+			PrimaryKey: ref.PrimaryKey,
+		})
+	}
+	for _, d := range drift.Snaps {
+		data.Drift.Snaps = append(data.Drift.Snaps, seedSnapDrift{
+			Name:            d.Name,
+			Change:          string(d.Change),
+			SeedRevision:    revisionOrEmpty(d.SeedRevision),
+			SeedChannel:     d.SeedChannel,
+			CurrentRevision: revisionOrEmpty(d.CurrentRevision),
+			CurrentChannel:  d.CurrentChannel,
+		})
+	}
+
+	return SyncResponse(data)
+}
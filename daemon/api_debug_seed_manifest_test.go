@@ -0,0 +1,154 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"errors"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = check.Suite(&debugSeedManifestSuite{})
+
+type debugSeedManifestSuite struct {
+	apiBaseSuite
+}
+
+func (s *debugSeedManifestSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+}
+
+func (s *debugSeedManifestSuite) TestGetSeedManifest(c *check.C) {
+	d := s.daemon(c)
+
+	s.AddCleanup(daemon.MockDeviceManagerSeedManifest(func(*devicestate.DeviceManager) (*devicestate.SeedManifest, error) {
+		return &devicestate.SeedManifest{
+			System: "20250101",
+			// the model the daemon is set up with
+			BrandID: "can0nical",
+			Model:   "pc",
+			Snaps: []devicestate.SeedManifestSnap{
+				{Name: "hello", SnapID: "hello-id", Revision: snap.R(1), Channel: "latest/stable"},
+				{Name: "pc", SnapID: "pc-id", Revision: snap.R(2), Channel: "20/stable", Essential: true, Required: true},
+			},
+			Assertions: []*asserts.Ref{
+				{Type: asserts.ModelType, PrimaryKey: []string{"16", "can0nical", "pc"}},
+			},
+		}, nil
+	}))
+
+	st := d.Overlord().State()
+	st.Lock()
+	for _, sn := range []struct {
+		name string
+		rev  snap.Revision
+	}{
+		{"pc", snap.R(3)},
+		{"other", snap.R(4)},
+	} {
+		si := &snap.SideInfo{RealName: sn.name, Revision: sn.rev}
+		snapstate.Set(st, sn.name, &snapstate.SnapState{
+			Active:          true,
+			Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+			Current:         sn.rev,
+			TrackingChannel: "20/stable",
+		})
+	}
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/debug/seed-manifest", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	manifest := rsp.Result.(*daemon.SeedManifest)
+	c.Check(manifest.System, check.Equals, "20250101")
+	c.Check(manifest.BrandID, check.Equals, "can0nical")
+	c.Check(manifest.Model, check.Equals, "pc")
+	c.Check(manifest.Snaps, check.DeepEquals, []daemon.SeedManifestSnap{
+		{Name: "hello", SnapID: "hello-id", Revision: snap.R(1), Channel: "latest/stable"},
+		{Name: "pc", SnapID: "pc-id", Revision: snap.R(2), Channel: "20/stable", Essential: true, Required: true},
+	})
+	c.Check(manifest.Assertions, check.DeepEquals, []daemon.SeedManifestAssertion{
+		{Type: "model", PrimaryKey: []string{"16", "can0nical", "pc"}},
+	})
+	// the model did not change since seeding
+	c.Check(manifest.Drift.ModelChanged, check.Equals, false)
+	c.Check(manifest.Drift.Snaps, check.DeepEquals, []daemon.SeedSnapDrift{
+		{Name: "hello", Change: "removed", SeedRevision: "1", SeedChannel: "latest/stable"},
+		{Name: "pc", Change: "revision-changed", SeedRevision: "2", SeedChannel: "20/stable", CurrentRevision: "3", CurrentChannel: "20/stable"},
+		{Name: "other", Change: "added", CurrentRevision: "4", CurrentChannel: "20/stable"},
+	})
+}
+
+func (s *debugSeedManifestSuite) TestGetSeedManifestModelChanged(c *check.C) {
+	s.daemon(c)
+
+	s.AddCleanup(daemon.MockDeviceManagerSeedManifest(func(*devicestate.DeviceManager) (*devicestate.SeedManifest, error) {
+		return &devicestate.SeedManifest{
+			System:  "20250101",
+			BrandID: "my-brand",
+			Model:   "my-model",
+		}, nil
+	}))
+
+	req, err := http.NewRequest("GET", "/v2/debug/seed-manifest", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	manifest := rsp.Result.(*daemon.SeedManifest)
+	// the device was remodeled since seeding
+	c.Check(manifest.Drift.ModelChanged, check.Equals, true)
+}
+
+func (s *debugSeedManifestSuite) TestGetSeedManifestErrors(c *check.C) {
+	s.daemon(c)
+
+	var seedErr error
+	s.AddCleanup(daemon.MockDeviceManagerSeedManifest(func(*devicestate.DeviceManager) (*devicestate.SeedManifest, error) {
+		return nil, seedErr
+	}))
+
+	for _, tc := range []struct {
+		err    error
+		status int
+		msg    string
+	}{
+		{devicestate.ErrNotSeeded, 400, `cannot get seed manifest: device is not seeded yet`},
+		{errors.New("boom"), 500, `cannot get seed manifest: boom`},
+	} {
+		seedErr = tc.err
+
+		req, err := http.NewRequest("GET", "/v2/debug/seed-manifest", nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, tc.status)
+		c.Check(rspe.Message, check.Equals, tc.msg)
+	}
+}
@@ -0,0 +1,97 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/restart"
+)
+
+var debugStateCmd = &Command{
+	Path:        "/v2/debug/state",
+	GET:         getDebugState,
+	POST:        postDebugState,
+	ReadAccess:  rootAccess{},
+	WriteAccess: rootAccess{},
+}
+
+var _ = registerAPIFeature("state-backup")
+
+// getDebugState serves a backup of the state and of the assertion
+// database.
+func getDebugState(c *Command, r *http.Request, user *auth.UserState) Response {
+	var buf bytes.Buffer
+	if err := overlord.ExportState(c.d.overlord.State(), &buf); err != nil {
+		return InternalError("%v", err)
+	}
+	return stateBackupResponse(buf.Bytes())
+}
+
+// postDebugState stages the restore of a backup of the state and of the
+// assertion database, snapd restarts to restore it.
+func postDebugState(c *Command, r *http.Request, user *auth.UserState) Response {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != client.StateBackupMediaType {
+		return BadRequest("unexpected content type %q", r.Header.Get("Content-Type"))
+	}
+
+	st := c.d.overlord.State()
+	meta, err := overlord.StageStateRestore(st, r.Body)
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+
+	logger.Noticef("Restarting to restore state backup from %s", meta.Time.Format(time.RFC3339))
+	st.Lock()
+	restart.Request(st, restart.RestartDaemon, nil)
+	st.Unlock()
+
+	return SyncResponse(&client.StateBackup{
+		Format:       meta.Format,
+		Time:         meta.Time,
+		SnapdVersion: meta.SnapdVersion,
+		Brand:        meta.Brand,
+		Model:        meta.Model,
+		Serial:       meta.Serial,
+	})
+}
+
+// stateBackupResponse serves a state backup as a tar archive.
+type stateBackupResponse []byte
+
+// ServeHTTP from the Response interface
+func (b stateBackupResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", client.StateBackupMediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=state-%s.tar", time.Now().UTC().Format("20060102T150405Z")))
+	if _, err := w.Write(b); err != nil {
+		logger.Debugf("cannot write state backup: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/restart"
+)
+
+var _ = check.Suite(&debugStateSuite{})
+
+type debugStateSuite struct {
+	apiBaseSuite
+}
+
+func (s *debugStateSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.RootAccess{})
+	s.expectWriteAccess(daemon.RootAccess{})
+}
+
+func (s *debugStateSuite) TestGetDebugState(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("some", "data")
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/debug/state", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.req(c, req, nil, actionIsExpected)
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+	c.Assert(rec.Code, check.Equals, 200)
+	c.Check(rec.Header().Get("Content-Type"), check.Equals, client.StateBackupMediaType)
+	c.Check(rec.Header().Get("Content-Disposition"), check.Matches, `attachment; filename=state-.*\.tar`)
+
+	var names []string
+	tr := tar.NewReader(rec.Body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.IsNil)
+		names = append(names, hdr.Name)
+	}
+	c.Assert(len(names) >= 2, check.Equals, true)
+	c.Check(names[:2], check.DeepEquals, []string{"meta.json", "state.json"})
+}
+
+func (s *debugStateSuite) TestPostDebugState(c *check.C) {
+	d := s.daemon(c)
+	var buf bytes.Buffer
+	c.Assert(overlord.ExportState(d.Overlord().State(), &buf), check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/debug/state", &buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", client.StateBackupMediaType)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	info, ok := rsp.Result.(*client.StateBackup)
+	c.Assert(ok, check.Equals, true)
+	c.Check(info.Format, check.Equals, overlord.StateBackupFormat)
+	c.Check(d.RequestedRestart(), check.Equals, restart.RestartDaemon)
+}
+
+func (s *debugStateSuite) TestPostDebugStateErrors(c *check.C) {
+	d := s.daemon(c)
+
+	req, err := http.NewRequest("POST", "/v2/debug/state", bytes.NewBufferString("{}"))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `unexpected content type "application/json"`)
+
+	req, err = http.NewRequest("POST", "/v2/debug/state", bytes.NewBufferString("garbage"))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", client.StateBackupMediaType)
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Matches, `cannot restore state backup: .*`)
+	c.Check(d.RequestedRestart(), check.Equals, restart.RestartUnset)
+}
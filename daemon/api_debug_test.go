@@ -353,6 +353,12 @@ func (s *postDebugSuite) TestRefreshAppAwarenessHappy(c *check.C) {
 			SideInfo:  &snap.SideInfo{Revision: snap.R(14)},
 			Monitored: true,
 		},
+		"snap-b": {
+			Version:    "0.2",
+			Channel:    "stable",
+			SideInfo:   &snap.SideInfo{Revision: snap.R(3)},
+			Prefetched: true,
+		},
 	}
 	st.Set("refresh-candidates", &candidates)
 	st.Unlock()
@@ -383,6 +389,12 @@ func (s *postDebugSuite) TestRefreshAppAwarenessHappy(c *check.C) {
 				Revision:  snap.R(14),
 				Monitored: true,
 			},
+			"snap-b": {
+				Version:    "0.2",
+				Channel:    "stable",
+				Revision:   snap.R(3),
+				Prefetched: true,
+			},
 		},
 	})
 }
@@ -0,0 +1,190 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/snap"
+)
+
+var deviceAccessCmd = &Command{
+	Path:        "/v2/device-access",
+	GET:         getDeviceAccess,
+	POST:        postDeviceAccess,
+	Actions:     []string{"grant", "revoke"},
+	ReadAccess:  authenticatedAccess{},
+	WriteAccess: rootAccess{},
+}
+
+var (
+	grantDeviceAccessChangeKind  = swfeats.RegisterChangeKind("grant-device-access")
+	revokeDeviceAccessChangeKind = swfeats.RegisterChangeKind("revoke-device-access")
+)
+
+var udevSnapAppliedDeviceCgroup = udev.SnapAppliedDeviceCgroup
+
+// deviceAccessInfo describes the device access of a snap as currently
+// enforced by its device cgroup.
+type deviceAccessInfo struct {
+	Snap string `json:"snap"`
+	// Rules are the udev rules tagging the devices the snap can access.
+	Rules       []string `json:"rules,omitempty"`
+	SelfManaged bool     `json:"self-managed,omitempty"`
+	NonStrict   bool     `json:"non-strict,omitempty"`
+	// Grants are the temporary device access grants of the snap.
+	Grants []*ifacestate.DeviceAccessGrant `json:"grants,omitempty"`
+}
+
+func getDeviceAccess(c *Command, r *http.Request, user *auth.UserState) Response {
+	name := r.URL.Query().Get("snap")
+	if name == "" {
+		return BadRequest("snap name is required")
+	}
+	if err := snap.ValidateInstanceName(name); err != nil {
+		return BadRequest("%v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, name, &snapst); err != nil && !errors.Is(err, state.ErrNoState) {
+		return InternalError("cannot get state of snap %q: %v", name, err)
+	}
+	if !snapst.IsInstalled() {
+		return SnapNotFound(name, &snap.NotInstalledError{Snap: name})
+	}
+	grants, err := ifacestate.DeviceAccessGrants(st, name)
+	if err != nil {
+		return InternalError("cannot get device access grants: %v", err)
+	}
+
+	info := deviceAccessInfo{
+		Snap:   name,
+		Grants: grants,
+	}
+	applied, err := udevSnapAppliedDeviceCgroup(name)
+	switch {
+	case err == nil:
+		info.Rules = applied.Rules
+		info.SelfManaged = applied.SelfManaged
+		info.NonStrict = applied.NonStrict
+	case errors.Is(err, fs.ErrNotExist):
+		// device access is not mediated for the snap
+	default:
+		return InternalError("cannot read device cgroup setup of snap %q: %v", name, err)
+	}
+
+	return SyncResponse(info)
+}
+
+type deviceAccessAction struct {
+	Action   string `json:"action"`
+	Snap     string `json:"snap"`
+	Rule     string `json:"rule,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	ID       string `json:"id,omitempty"`
+}
+
+func postDeviceAccess(c *Command, r *http.Request, user *auth.UserState) Response {
+	var a deviceAccessAction
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&a); err != nil {
+		return BadRequest("cannot decode request body into device access action: %v", err)
+	}
+	if decoder.More() {
+		return BadRequest("extra content found in request body")
+	}
+	if a.Snap == "" {
+		return BadRequest("snap name is required")
+	}
+
+	var userID int
+	if user != nil {
+		userID = user.ID
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var ts *state.TaskSet
+	var kind, summary string
+	var apiData map[string]any
+	var err error
+	switch a.Action {
+	case "grant":
+		if a.ID != "" {
+			return BadRequest(`cannot use "id" with action "grant"`)
+		}
+		if a.Rule == "" || a.Duration == "" {
+			return BadRequest(`action "grant" requires a rule and a duration`)
+		}
+		duration, err := time.ParseDuration(a.Duration)
+		if err != nil {
+			return BadRequest("invalid duration %q: %v", a.Duration, err)
+		}
+		var grant *ifacestate.DeviceAccessGrant
+		ts, grant, err = ifacestate.GrantDeviceAccess(st, a.Snap, a.Rule, a.Reason, duration, userID)
+		if err != nil {
+			return errToResponse(err, []string{a.Snap}, BadRequest, "cannot grant device access: %v")
+		}
+		kind = grantDeviceAccessChangeKind
+		summary = fmt.Sprintf("Grant snap %q access to devices matching %s for %s", a.Snap, a.Rule, duration)
+		// let the client know which grant to revoke
+		apiData = map[string]any{"id": grant.ID}
+	case "revoke":
+		if a.ID == "" {
+			return BadRequest(`action "revoke" requires the id of a grant`)
+		}
+		if a.Rule != "" || a.Duration != "" {
+			return BadRequest(`cannot use "rule" or "duration" with action "revoke"`)
+		}
+		ts, err = ifacestate.RevokeDeviceAccess(st, a.Snap, a.ID, userID)
+		if err != nil {
+			return errToResponse(err, []string{a.Snap}, BadRequest, "cannot revoke device access: %v")
+		}
+		kind = revokeDeviceAccessChangeKind
+		summary = fmt.Sprintf("Revoke device access grant %s of snap %q", a.ID, a.Snap)
+	default:
+		return BadRequest("unknown device access action %q", a.Action)
+	}
+
+	chg := newChange(r.Context(), st, kind, summary, []*state.TaskSet{ts}, []string{a.Snap})
+	if apiData != nil {
+		chg.Set("api-data", apiData)
+	}
+	ensureStateSoon(st)
+	return AsyncResponse(nil, chg.ID())
+}
@@ -0,0 +1,220 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = check.Suite(&deviceAccessSuite{})
+
+type deviceAccessSuite struct {
+	apiBaseSuite
+}
+
+func (s *deviceAccessSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.AuthenticatedAccess{})
+	s.expectWriteAccess(daemon.RootAccess{})
+}
+
+func (s *deviceAccessSuite) TestGetDeviceAccess(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+
+	grant := &ifacestate.DeviceAccessGrant{
+		ID:      "3",
+		Snap:    "foo",
+		Rule:    `SUBSYSTEM=="tty", KERNEL=="ttyUSB0"`,
+		Reason:  "debugging",
+		Granted: time.Now(),
+		Expires: time.Now().Add(time.Hour),
+	}
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("device-access-grants", map[string][]*ifacestate.DeviceAccessGrant{
+		"foo": {grant},
+	})
+	st.Unlock()
+
+	restore := daemon.MockUdevSnapAppliedDeviceCgroup(func(snapName string) (*udev.AppliedDeviceCgroup, error) {
+		c.Check(snapName, check.Equals, "foo")
+		return &udev.AppliedDeviceCgroup{
+			Rules: []string{`SUBSYSTEM=="tty", KERNEL=="ttyUSB0", TAG+="snap_foo_app"`},
+		}, nil
+	})
+	defer restore()
+
+	req, err := http.NewRequest("GET", "/v2/device-access?snap=foo", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	info, ok := rsp.Result.(daemon.DeviceAccessInfo)
+	c.Assert(ok, check.Equals, true)
+	c.Check(info.Snap, check.Equals, "foo")
+	c.Check(info.Rules, check.DeepEquals, []string{`SUBSYSTEM=="tty", KERNEL=="ttyUSB0", TAG+="snap_foo_app"`})
+	c.Check(info.SelfManaged, check.Equals, false)
+	c.Check(info.NonStrict, check.Equals, false)
+	c.Assert(info.Grants, check.HasLen, 1)
+	c.Check(info.Grants[0].ID, check.Equals, "3")
+	c.Check(info.Grants[0].Reason, check.Equals, "debugging")
+}
+
+func (s *deviceAccessSuite) TestGetDeviceAccessNotMediated(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+
+	restore := daemon.MockUdevSnapAppliedDeviceCgroup(func(snapName string) (*udev.AppliedDeviceCgroup, error) {
+		return nil, fs.ErrNotExist
+	})
+	defer restore()
+
+	req, err := http.NewRequest("GET", "/v2/device-access?snap=foo", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, daemon.DeviceAccessInfo{Snap: "foo"})
+}
+
+func (s *deviceAccessSuite) TestGetDeviceAccessErrors(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/device-access", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "snap name is required")
+
+	req, err = http.NewRequest("GET", "/v2/device-access?snap=foo", nil)
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 404)
+}
+
+func (s *deviceAccessSuite) TestPostGrantDeviceAccess(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	body := bytes.NewBufferString(`{"action": "grant", "snap": "foo", "rule": "SUBSYSTEM==\"tty\"", "duration": "2h", "reason": "serial port debugging"}`)
+	req, err := http.NewRequest("POST", "/v2/device-access", body)
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "grant-device-access")
+	c.Check(chg.Summary(), check.Equals, `Grant snap "foo" access to devices matching SUBSYSTEM=="tty" for 2h0m0s`)
+	var kinds []string
+	for _, t := range chg.Tasks() {
+		kinds = append(kinds, t.Kind())
+	}
+	c.Check(kinds, check.DeepEquals, []string{"grant-device-access", "setup-profiles"})
+
+	var apiData map[string]any
+	c.Assert(chg.Get("api-data", &apiData), check.IsNil)
+	c.Check(apiData, check.DeepEquals, map[string]any{"id": "1"})
+
+	var grant ifacestate.DeviceAccessGrant
+	c.Assert(chg.Tasks()[0].Get("device-access-grant", &grant), check.IsNil)
+	c.Check(grant.Reason, check.Equals, "serial port debugging")
+	c.Check(grant.Expires.Sub(grant.Granted), check.Equals, 2*time.Hour)
+}
+
+func (s *deviceAccessSuite) TestPostRevokeDeviceAccess(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("device-access-grants", map[string][]*ifacestate.DeviceAccessGrant{
+		"foo": {{ID: "7", Snap: "foo", Rule: `SUBSYSTEM=="tty"`, Expires: time.Now().Add(time.Hour)}},
+	})
+	st.Unlock()
+
+	body := bytes.NewBufferString(`{"action": "revoke", "snap": "foo", "id": "7"}`)
+	req, err := http.NewRequest("POST", "/v2/device-access", body)
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "revoke-device-access")
+	c.Check(chg.Summary(), check.Equals, `Revoke device access grant 7 of snap "foo"`)
+	var kinds []string
+	for _, t := range chg.Tasks() {
+		kinds = append(kinds, t.Kind())
+	}
+	c.Check(kinds, check.DeepEquals, []string{"revoke-device-access", "setup-profiles"})
+}
+
+func (s *deviceAccessSuite) TestPostDeviceAccessErrors(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+
+	for _, t := range []struct {
+		body   string
+		status int
+		err    string
+	}{
+		{`{`, 400, `cannot decode request body into device access action: .*`},
+		{`{"action": "grant"}`, 400, `snap name is required`},
+		{`{"action": "grant", "snap": "foo", "rule": "SUBSYSTEM==\"tty\""}`, 400, `action "grant" requires a rule and a duration`},
+		{`{"action": "grant", "snap": "foo", "rule": "SUBSYSTEM==\"tty\"", "duration": "1h", "id": "1"}`, 400, `cannot use "id" with action "grant"`},
+		{`{"action": "grant", "snap": "foo", "rule": "SUBSYSTEM==\"tty\"", "duration": "soon"}`, 400, `invalid duration "soon": .*`},
+		{`{"action": "grant", "snap": "foo", "rule": "SUBSYSTEM==\"tty\"", "duration": "48h"}`, 400, `cannot grant device access: cannot grant device access for 48h0m0s: .*`},
+		{`{"action": "grant", "snap": "foo", "rule": "RUN+=\"/bin/sh\"", "duration": "1h"}`, 400, `cannot grant device access: invalid device access rule .*`},
+		{`{"action": "grant", "snap": "bar", "rule": "SUBSYSTEM==\"tty\"", "duration": "1h"}`, 400, `snap "bar" is not installed`},
+		{`{"action": "revoke", "snap": "foo"}`, 400, `action "revoke" requires the id of a grant`},
+		{`{"action": "revoke", "snap": "foo", "id": "1", "duration": "1h"}`, 400, `cannot use "rule" or "duration" with action "revoke"`},
+		{`{"action": "revoke", "snap": "foo", "id": "1"}`, 400, `cannot revoke device access: cannot find device access grant "1" of snap "foo"`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/device-access", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, t.status, check.Commentf("%s", t.body))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf("%s", t.body))
+	}
+
+	req, err := http.NewRequest("POST", "/v2/device-access", bytes.NewBufferString(`{"action": "frobnicate", "snap": "foo"}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `unknown device access action "frobnicate"`)
+}
@@ -0,0 +1,58 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// apiFeaturesHeader is the response header used to advertise the optional
+// API features supported by this snapd, so that clients can feature-detect
+// instead of comparing version strings.
+const apiFeaturesHeader = "X-Snapd-API-Features"
+
+var apiFeatures = make(map[string]bool)
+
+// registerAPIFeature adds feature to the set of API features advertised to
+// clients and returns it. It is meant to be called at package initialization
+// next to the code implementing the feature.
+func registerAPIFeature(feature string) string {
+	apiFeatures[feature] = true
+	return feature
+}
+
+// knownAPIFeatures returns the sorted list of registered API features.
+func knownAPIFeatures() []string {
+	features := make([]string, 0, len(apiFeatures))
+	for feature := range apiFeatures {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+	return features
+}
+
+func addAPIFeaturesHeader(w http.ResponseWriter) {
+	if len(apiFeatures) == 0 {
+		return
+	}
+	w.Header().Set(apiFeaturesHeader, strings.Join(knownAPIFeatures(), ","))
+}
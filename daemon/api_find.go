@@ -157,7 +157,7 @@ func searchStore(c *Command, r *http.Request, user *auth.UserState) Response {
 		SuggestedCurrency: theStore.SuggestedCurrency(),
 	}
 
-	return sendStorePackages(route, found, fresp)
+	return sendStorePackages(route, found, fresp, nil)
 }
 
 func findOne(c *Command, r *http.Request, user *auth.UserState, name string) Response {
@@ -217,15 +217,24 @@ func storeUpdates(c *Command, r *http.Request, user *auth.UserState) Response {
 
 	state.Lock()
 	updates, err := snapstateRefreshCandidates(state, user)
-	state.Unlock()
 	if err != nil {
+		state.Unlock()
 		return InternalError("cannot list updates: %v", err)
 	}
+	classes, err := snapstateClassifyRefreshes(state, updates)
+	state.Unlock()
+	if err != nil {
+		return InternalError("cannot classify updates: %v", err)
+	}
 
-	return sendStorePackages(route, updates, nil)
+	return sendStorePackages(route, updates, nil, func(info *snap.Info, result *client.Snap) {
+		result.RefreshClassification = string(classes[info.InstanceName()])
+	})
 }
 
-func sendStorePackages(route *mux.Route, found []*snap.Info, resp *findResponse) StructuredResponse {
+// sendStorePackages builds a response listing the given store snaps, if
+// not nil decorate is called to complete the result for each snap.
+func sendStorePackages(route *mux.Route, found []*snap.Info, resp *findResponse, decorate func(*snap.Info, *client.Snap)) StructuredResponse {
 	results := make([]*json.RawMessage, 0, len(found))
 	for _, x := range found {
 		url, err := route.URL("name", x.InstanceName())
@@ -234,7 +243,11 @@ func sendStorePackages(route *mux.Route, found []*snap.Info, resp *findResponse)
 			continue
 		}
 
-		data, err := json.Marshal(webify(mapRemote(x), url.String()))
+		result := mapRemote(x)
+		if decorate != nil {
+			decorate(x, result)
+		}
+		data, err := json.Marshal(webify(result, url.String()))
 		if err != nil {
 			return InternalError("%v", err)
 		}
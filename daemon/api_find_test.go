@@ -117,6 +117,74 @@ func (s *findSuite) TestFindRefreshes(c *check.C) {
 	c.Check(fetchedValidationSets, check.Equals, true)
 }
 
+func (s *findSuite) TestFindRefreshClassification(c *check.C) {
+	s.daemon(c)
+
+	restore := daemon.MockAssertstateFetchAllValidationSets(func(*state.State, int, *assertstate.RefreshAssertionsOptions) error {
+		return nil
+	})
+	defer restore()
+
+	var classified []string
+	restore = daemon.MockSnapstateClassifyRefreshes(func(st *state.State, updates []*snap.Info) (map[string]snapstate.RefreshClassification, error) {
+		for _, info := range updates {
+			classified = append(classified, info.InstanceName())
+		}
+		return map[string]snapstate.RefreshClassification{
+			"store": snapstate.RefreshSecurity,
+		}, nil
+	})
+	defer restore()
+
+	s.rsnaps = []*snap.Info{{
+		SideInfo: snap.SideInfo{
+			RealName: "store",
+		},
+		Architectures: []string{"all"},
+		Publisher: snap.StoreAccount{
+			ID:          "foo-id",
+			Username:    "foo",
+			DisplayName: "Foo",
+			Validation:  "unproven",
+		},
+	}}
+	s.mockSnap(c, "name: store\nversion: 1.0")
+
+	req, err := http.NewRequest("GET", "/v2/find?select=refresh", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	snaps := snapList(rsp.Result)
+	c.Assert(snaps, check.HasLen, 1)
+	c.Check(snaps[0]["name"], check.Equals, "store")
+	c.Check(snaps[0]["refresh-classification"], check.Equals, "security")
+	c.Check(classified, check.DeepEquals, []string{"store"})
+}
+
+func (s *findSuite) TestFindRefreshClassificationError(c *check.C) {
+	s.daemon(c)
+
+	restore := daemon.MockAssertstateFetchAllValidationSets(func(*state.State, int, *assertstate.RefreshAssertionsOptions) error {
+		return nil
+	})
+	defer restore()
+
+	restore = daemon.MockSnapstateClassifyRefreshes(func(st *state.State, updates []*snap.Info) (map[string]snapstate.RefreshClassification, error) {
+		return nil, errors.New("boom")
+	})
+	defer restore()
+
+	s.rsnaps = []*snap.Info{}
+
+	req, err := http.NewRequest("GET", "/v2/find?select=refresh", nil)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 500)
+	c.Check(rspe.Message, check.Equals, "cannot classify updates: boom")
+}
+
 func (s *findSuite) TestFindRefreshSideloaded(c *check.C) {
 	d := s.daemon(c)
 
@@ -20,11 +20,17 @@
 package daemon
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/arch"
@@ -34,15 +40,20 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/hwinfo"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/sandbox"
+	"github.com/snapcore/snapd/sandbox/apparmor"
+	"github.com/snapcore/snapd/sandbox/cgroup"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var (
@@ -72,9 +83,12 @@ var (
 	}
 
 	stateChangesCmd = &Command{
-		Path:       "/v2/changes",
-		GET:        getChanges,
-		ReadAccess: interfaceOpenAccess{Interfaces: []string{"snap-refresh-observe"}},
+		Path:        "/v2/changes",
+		GET:         getChanges,
+		POST:        abortChanges,
+		Actions:     []string{"abort"},
+		ReadAccess:  interfaceOpenAccess{Interfaces: []string{"snap-refresh-observe"}},
+		WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
 	}
 
 	warningsCmd = &Command{
@@ -108,7 +122,19 @@ func tbd(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse([]string{"TBD"})
 }
 
+var sysInfoSandboxDetails = sysInfoSandboxDetailsImpl
+
 func sysInfo(c *Command, r *http.Request, user *auth.UserState) Response {
+	switch sel := r.URL.Query().Get("select"); sel {
+	case "":
+	case "sandbox-details":
+		return SyncResponse(sysInfoSandboxDetails())
+	case "hardware":
+		return SyncResponse(sysInfoHardware())
+	default:
+		return BadRequest("invalid select parameter: %q", sel)
+	}
+
 	st := c.d.overlord.State()
 	snapMgr := c.d.overlord.SnapManager()
 	deviceMgr := c.d.overlord.DeviceManager()
@@ -158,6 +184,21 @@ func sysInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	if systemdVirt != "" {
 		m["virtualization"] = systemdVirt
 	}
+	// make it obvious that the device does not behave as its model says
+	if override, err := devicestate.ModelGradeOverride(st); err != nil {
+		return InternalError("cannot get model grade override: %v", err)
+	} else if override != "" {
+		m["model-grade-override"] = override
+	}
+	if ws, err := configcore.CurrentWatchdogState(tr); err != nil {
+		return InternalError("cannot get watchdog state: %v", err)
+	} else if ws != nil {
+		m["watchdog"] = watchdogInfo(ws)
+	}
+	// show temporarily raised log levels, they affect the daemon's output
+	if overrides := logLevelOverrides(); overrides != nil {
+		m["log-level-overrides"] = overrides
+	}
 
 	// NOTE: Right now we don't have a good way to differentiate if we
 	// only have partial confinement (ala AppArmor disabled and Seccomp
@@ -175,9 +216,150 @@ func sysInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 		m["sandbox-features"] = features
 	}
 
+	m["capabilities"] = sysInfoCapabilities()
+
 	return SyncResponse(m)
 }
 
+func watchdogInfo(ws *configcore.WatchdogState) *client.WatchdogInfo {
+	info := &client.WatchdogInfo{
+		Enabled:            ws.Enabled,
+		ConflictingDropIns: ws.ConflictingDropIns,
+	}
+	if ws.RuntimeTimeout > 0 {
+		info.RuntimeTimeout = ws.RuntimeTimeout.String()
+	}
+	if ws.ShutdownTimeout > 0 {
+		info.ShutdownTimeout = ws.ShutdownTimeout.String()
+	}
+	return info
+}
+
+var sysInfoCapabilities = sysInfoCapabilitiesImpl
+
+// sysInfoCapabilitiesImpl reports what the hardware and the running kernel
+// can offer to snaps. Probing is best effort, details that cannot be
+// determined are left empty.
+func sysInfoCapabilitiesImpl() *client.SystemCapabilities {
+	caps := &client.SystemCapabilities{
+		CPU: client.CPUCapabilities{
+			Architecture:       arch.DpkgArchitecture(),
+			KernelArchitecture: arch.DpkgKernelArchitecture(),
+		},
+		Kernel: client.KernelCapabilities{
+			Version:       osutil.KernelVersion(),
+			AppArmorLevel: apparmor.ProbedLevel().String(),
+		},
+		TPM: hasTPM(),
+	}
+
+	if cpuInfo, err := osutil.ReadCPUInfo(); err != nil {
+		logger.Debugf("cannot read cpu information: %v", err)
+	} else {
+		caps.CPU.Model = cpuInfo.ModelName
+		caps.CPU.Count = cpuInfo.Count
+		caps.CPU.Flags = cpuInfo.Flags
+	}
+
+	if ver, err := cgroup.Version(); err == nil {
+		caps.Kernel.CgroupVersion = ver
+	}
+	if apparmor.ProbedLevel() != apparmor.Unsupported {
+		if features, err := apparmor.KernelFeatures(); err == nil {
+			caps.Kernel.AppArmorFeatures = features
+		}
+	}
+	caps.Kernel.SquashfsCompressions = squashfsCompressions(caps.Kernel.Version)
+
+	return caps
+}
+
+var _ = registerAPIFeature("hardware-info")
+
+var (
+	sysInfoHardware = sysInfoHardwareImpl
+	hwinfoGet       = hwinfo.Get
+)
+
+// sysInfoHardwareImpl reports a summary of the hardware of the system for
+// inventory purposes. The probed information is cached for a while.
+func sysInfoHardwareImpl() *client.HardwareInfo {
+	hw := hwinfoGet()
+	info := &client.HardwareInfo{
+		CPU: client.HardwareCPU{
+			Model: hw.CPU.Model,
+			Cores: hw.CPU.Cores,
+		},
+		Memory: hw.Memory,
+		TPM: client.HardwareTPM{
+			Present: hw.TPM.Present,
+			Version: hw.TPM.Version,
+		},
+		SecureBoot: string(hw.SecureBoot),
+	}
+	for _, dev := range hw.Storage {
+		info.Storage = append(info.Storage, client.HardwareStorageDevice{
+			Name:       dev.Name,
+			Model:      dev.Model,
+			Size:       dev.Size,
+			Removable:  dev.Removable,
+			Rotational: dev.Rotational,
+		})
+	}
+	return info
+}
+
+// hasTPM returns whether the kernel exposes at least one TPM device.
+func hasTPM() bool {
+	matches, err := filepath.Glob(filepath.Join(dirs.GlobalRootDir, "/sys/class/tpm/tpm[0-9]*"))
+	return err == nil && len(matches) > 0
+}
+
+// squashfsKernelConfigCompressions maps the kernel configuration options
+// enabling squashfs decompressors to the matching mksquashfs compressor
+// names.
+var squashfsKernelConfigCompressions = []struct {
+	option      string
+	compression string
+}{
+	{"CONFIG_SQUASHFS_ZLIB", "gzip"},
+	{"CONFIG_SQUASHFS_LZ4", "lz4"},
+	{"CONFIG_SQUASHFS_LZO", "lzo"},
+	{"CONFIG_SQUASHFS_XZ", "xz"},
+	{"CONFIG_SQUASHFS_ZSTD", "zstd"},
+}
+
+// squashfsCompressions returns the compressions the given kernel can read
+// squashfs images with, as found in its configuration under /boot. Nil is
+// returned if the kernel configuration is not available.
+func squashfsCompressions(kernelVersion string) []string {
+	f, err := os.Open(filepath.Join(dirs.GlobalRootDir, "/boot", "config-"+kernelVersion))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	enabled := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		option, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok && value == "y" && strings.HasPrefix(option, "CONFIG_SQUASHFS") {
+			enabled[option] = true
+		}
+	}
+	if scanner.Err() != nil || !enabled["CONFIG_SQUASHFS"] {
+		return nil
+	}
+
+	compressions := make([]string, 0, len(squashfsKernelConfigCompressions))
+	for _, comp := range squashfsKernelConfigCompressions {
+		if enabled[comp.option] {
+			compressions = append(compressions, comp.compression)
+		}
+	}
+	return compressions
+}
+
 func sysInfoPost(c *Command, r *http.Request, user *auth.UserState) Response {
 	var d struct {
 		Action    string `json:"action"`
@@ -260,8 +442,40 @@ func sandboxFeatures(backends []interfaces.SecurityBackend) map[string][]string
 	return result
 }
 
+var _ = registerAPIFeature("change-wait")
+
 func getChange(c *Command, r *http.Request, user *auth.UserState) Response {
 	chID := muxVars(r)["id"]
+	query := r.URL.Query()
+
+	var wait bool
+	if s := query.Get("wait"); s != "" {
+		var err error
+		wait, err = strconv.ParseBool(s)
+		if err != nil {
+			return BadRequest("invalid wait parameter: %q", s)
+		}
+	}
+	timeout, err := parseOptionalDuration(query.Get("timeout"))
+	if err != nil {
+		return BadRequest("invalid timeout: %v", err)
+	}
+	if timeout < 0 {
+		return BadRequest("invalid timeout: must not be negative")
+	}
+	if timeout != 0 && !wait {
+		return BadRequest("timeout can only be used together with wait")
+	}
+	var includeGraph bool
+	for _, include := range strutil.CommaSeparatedList(query.Get("include")) {
+		switch include {
+		case "graph":
+			includeGraph = true
+		default:
+			return BadRequest("invalid include parameter: %q", include)
+		}
+	}
+
 	state := c.d.overlord.State()
 	state.Lock()
 	defer state.Unlock()
@@ -270,7 +484,60 @@ func getChange(c *Command, r *http.Request, user *auth.UserState) Response {
 		return NotFound("cannot find change with id %q", chID)
 	}
 
-	return SyncResponse(change2changeInfo(chg))
+	if wait && !chg.IsReady() {
+		// Wait for the change to become ready, for the timeout to
+		// elapse or for the request to be canceled, whichever comes
+		// first. Use daemon's tomb context so that the request will
+		// get canceled as well when the daemon is shutting down.
+		ctx := c.d.tomb.Context(r.Context())
+		if timeout != 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		ready := chg.Ready()
+		state.Unlock()
+		select {
+		case <-ready:
+		case <-ctx.Done():
+		}
+		state.Lock()
+
+		// the change may have been pruned while the state was unlocked
+		if state.Change(chID) == nil {
+			return NotFound("cannot find change with id %q", chID)
+		}
+		// when the timeout elapses the current state of the change is
+		// returned, as it would have been without waiting
+		if !chg.IsReady() && errors.Is(ctx.Err(), context.Canceled) {
+			return InternalError("request canceled")
+		}
+	}
+
+	chgInfo := change2changeInfo(chg)
+	if includeGraph {
+		addTaskGraph(chgInfo, chg)
+	}
+	return SyncResponse(chgInfo)
+}
+
+// addTaskGraph adds the wait and halt edges between the tasks of the change,
+// as well as the lanes of each task, to the given change information.
+func addTaskGraph(chgInfo *changeInfo, chg *state.Change) {
+	taskIDs := func(tasks []*state.Task) []string {
+		ids := make([]string, 0, len(tasks))
+		for _, t := range tasks {
+			ids = append(ids, t.ID())
+		}
+		return ids
+	}
+	for i, t := range chg.Tasks() {
+		taskInfo := chgInfo.Tasks[i]
+		taskInfo.WaitTasks = taskIDs(t.WaitTasks())
+		taskInfo.HaltTasks = taskIDs(t.HaltTasks())
+		taskInfo.Lanes = t.Lanes()
+	}
 }
 
 func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -294,27 +561,7 @@ func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
 	if wantedName := query.Get("for"); wantedName != "" {
 		outerFilter := filter
 		filter = func(chg *state.Change) bool {
-			if !outerFilter(chg) {
-				return false
-			}
-
-			var snapNames []string
-			if err := chg.Get("snap-names", &snapNames); err != nil {
-				logger.Noticef("Cannot get snap-name for change %v", chg.ID())
-				return false
-			}
-
-			for _, name := range snapNames {
-				// due to
-				// https://bugs.launchpad.net/snapd/+bug/1880560
-				// the snap-names in service-control changes
-				// could have included <snap>.<app>
-				snapName, _ := snap.SplitSnapApp(name)
-				if snapName == wantedName {
-					return true
-				}
-			}
-			return false
+			return outerFilter(chg) && changeAffectsSnap(chg, wantedName)
 		}
 	}
 
@@ -368,6 +615,105 @@ func abortChange(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(change2changeInfo(chg))
 }
 
+// changeAffectsSnap returns whether the given snap is among the snaps affected
+// by the given change.
+func changeAffectsSnap(chg *state.Change, wantedName string) bool {
+	var snapNames []string
+	if err := chg.Get("snap-names", &snapNames); err != nil {
+		logger.Noticef("Cannot get snap-name for change %v", chg.ID())
+		return false
+	}
+
+	for _, name := range snapNames {
+		// due to
+		// https://bugs.launchpad.net/snapd/+bug/1880560
+		// the snap-names in service-control changes
+		// could have included <snap>.<app>
+		snapName, _ := snap.SplitSnapApp(name)
+		if snapName == wantedName {
+			return true
+		}
+	}
+	return false
+}
+
+type abortChangesRequest struct {
+	Action string `json:"action"`
+	// IDs lists the changes to abort, it cannot be combined with the filters
+	// below.
+	IDs []string `json:"ids"`
+	// Kind and ForSnap select the changes which are not yet ready to abort.
+	Kind    string `json:"kind"`
+	ForSnap string `json:"for-snap"`
+}
+
+// abortChanges aborts several changes at once, either given explicitly by ID
+// or selected via a filter. When changes are given by ID, either all of them
+// are aborted or, if any of them cannot be aborted, none of them are.
+func abortChanges(c *Command, r *http.Request, user *auth.UserState) Response {
+	var reqData abortChangesRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&reqData); err != nil {
+		return BadRequest("cannot decode data from request body: %v", err)
+	}
+
+	if reqData.Action != "abort" {
+		return BadRequest("change action %q is unsupported", reqData.Action)
+	}
+
+	hasFilter := reqData.Kind != "" || reqData.ForSnap != ""
+	switch {
+	case len(reqData.IDs) > 0 && hasFilter:
+		return BadRequest("cannot abort changes: cannot use change IDs together with a filter")
+	case len(reqData.IDs) == 0 && !hasFilter:
+		return BadRequest("cannot abort changes: change IDs or a filter must be specified")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var toAbort []*state.Change
+	if len(reqData.IDs) > 0 {
+		for _, chID := range reqData.IDs {
+			chg := st.Change(chID)
+			if chg == nil {
+				return NotFound("cannot find change with id %q", chID)
+			}
+			if chg.IsReady() {
+				return BadRequest("cannot abort change %s with nothing pending", chID)
+			}
+			toAbort = append(toAbort, chg)
+		}
+	} else {
+		for _, chg := range st.Changes() {
+			if chg.IsReady() {
+				continue
+			}
+			if reqData.Kind != "" && chg.Kind() != reqData.Kind {
+				continue
+			}
+			if reqData.ForSnap != "" && !changeAffectsSnap(chg, reqData.ForSnap) {
+				continue
+			}
+			toAbort = append(toAbort, chg)
+		}
+	}
+
+	chgInfos := make([]*changeInfo, 0, len(toAbort))
+	for _, chg := range toAbort {
+		chg.Abort()
+		chgInfos = append(chgInfos, change2changeInfo(chg))
+	}
+
+	if len(toAbort) > 0 {
+		// actually ask to proceed with the abort
+		ensureStateSoon(st)
+	}
+
+	return SyncResponse(chgInfos)
+}
+
 type changeInfo struct {
 	ID      string      `json:"id"`
 	Kind    string      `json:"kind"`
@@ -379,6 +725,10 @@ type changeInfo struct {
 
 	SpawnTime time.Time  `json:"spawn-time,omitzero"`
 	ReadyTime *time.Time `json:"ready-time,omitempty"`
+	// LastActivityTime is only reported for changes in progress.
+	LastActivityTime *time.Time `json:"last-activity-time,omitempty"`
+	Deadline         *time.Time `json:"deadline,omitempty"`
+	ScheduledTime    *time.Time `json:"scheduled-time,omitempty"`
 
 	Data map[string]*json.RawMessage `json:"data,omitempty"`
 }
@@ -394,6 +744,15 @@ type taskInfo struct {
 	SpawnTime time.Time  `json:"spawn-time,omitzero"`
 	ReadyTime *time.Time `json:"ready-time,omitempty"`
 
+	Retries   int        `json:"retries,omitempty"`
+	NextRetry *time.Time `json:"next-retry,omitempty"`
+
+	// WaitTasks, HaltTasks and Lanes are only reported when the
+	// dependency graph of the change is requested.
+	WaitTasks []string `json:"wait-tasks,omitempty"`
+	HaltTasks []string `json:"halt-tasks,omitempty"`
+	Lanes     []int    `json:"lanes,omitempty"`
+
 	Data map[string]*json.RawMessage `json:"data,omitempty"`
 }
 
@@ -418,6 +777,16 @@ func change2changeInfo(chg *state.Change) *changeInfo {
 	if !readyTime.IsZero() {
 		chgInfo.ReadyTime = &readyTime
 	}
+	if !status.Ready() {
+		lastActivityTime := chg.LastActivityTime()
+		chgInfo.LastActivityTime = &lastActivityTime
+	}
+	if deadline := chg.Deadline(); !deadline.IsZero() {
+		chgInfo.Deadline = &deadline
+	}
+	if scheduledTime := chg.ScheduledTime(); !scheduledTime.IsZero() {
+		chgInfo.ScheduledTime = &scheduledTime
+	}
 	if err := chg.Err(); err != nil {
 		chgInfo.Err = err.Error()
 	}
@@ -444,6 +813,13 @@ func change2changeInfo(chg *state.Change) *changeInfo {
 		if !readyTime.IsZero() {
 			taskInfo.ReadyTime = &readyTime
 		}
+		if retries := t.Retries(); retries > 0 {
+			taskInfo.Retries = retries
+			// the task is waiting for its next retry
+			if atTime := t.AtTime(); !atTime.IsZero() && !t.Status().Ready() {
+				taskInfo.NextRetry = &atTime
+			}
+		}
 		if data, err := taskApiData(t); err == nil {
 			taskInfo.Data = data
 		}
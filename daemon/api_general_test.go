@@ -22,28 +22,42 @@ package daemon_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/arch"
 	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/dirs/dirstest"
 	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/hwinfo"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/sandbox"
+	"github.com/snapcore/snapd/sandbox/apparmor"
+	"github.com/snapcore/snapd/sandbox/cgroup"
+	"github.com/snapcore/snapd/sandbox/seccomp"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/systemd"
 )
 
@@ -53,6 +67,23 @@ type generalSuite struct {
 	apiBaseSuite
 }
 
+func (s *generalSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.AddCleanup(daemon.MockSysInfoCapabilities(func() *client.SystemCapabilities {
+		return &client.SystemCapabilities{
+			CPU:    client.CPUCapabilities{Architecture: "amd64"},
+			Kernel: client.KernelCapabilities{Version: "6.8.0", AppArmorLevel: "none"},
+		}
+	}))
+}
+
+var mockedSysInfoCapabilities = map[string]any{
+	"cpu":    map[string]any{"architecture": "amd64"},
+	"kernel": map[string]any{"version": "6.8.0", "apparmor-level": "none"},
+	"tpm":    false,
+}
+
 func (s *generalSuite) expectSystemInfoReadAccess() {
 	s.expectReadAccess(daemon.InterfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}})
 }
@@ -159,6 +190,7 @@ func (s *generalSuite) TestSysInfo(c *check.C) {
 		"architecture":     arch.DpkgArchitecture(),
 		"virtualization":   "magic",
 		"system-mode":      "run",
+		"capabilities":     mockedSysInfoCapabilities,
 	}
 	var rsp daemon.RespJSON
 	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
@@ -289,6 +321,7 @@ func (s *generalSuite) TestSysInfoLegacyRefresh(c *check.C) {
 		"architecture":   arch.DpkgArchitecture(),
 		"virtualization": "kvm",
 		"system-mode":    "run",
+		"capabilities":   mockedSysInfoCapabilities,
 	}
 	var rsp daemon.RespJSON
 	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
@@ -370,6 +403,7 @@ func (s *generalSuite) testSysInfoSystemMode(c *check.C, mode string) {
 		},
 		"architecture": arch.DpkgArchitecture(),
 		"system-mode":  mode,
+		"capabilities": mockedSysInfoCapabilities,
 	}
 	var rsp daemon.RespJSON
 	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
@@ -415,6 +449,50 @@ func (s *generalSuite) TestSysInfoIsManaged(c *check.C) {
 	c.Check(rsp.Result.(map[string]any)["managed"], check.Equals, true)
 }
 
+func (s *generalSuite) TestSysInfoLogLevelOverrides(c *check.C) {
+	s.expectSystemInfoReadAccess()
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/system-info", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result.(map[string]any)["log-level-overrides"], check.IsNil)
+
+	until := time.Now().Add(time.Hour)
+	logger.SetDebugOverrides([]string{"store"}, until)
+	defer logger.ClearDebugOverrides(nil)
+
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result.(map[string]any)["log-level-overrides"], check.DeepEquals, []daemon.LogLevelOverrideJSON{
+		{Subsystem: "store", Level: "debug", Until: until},
+	})
+}
+
+func (s *generalSuite) TestSysInfoWatchdog(c *check.C) {
+	s.expectSystemInfoReadAccess()
+	d := s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/system-info", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result.(map[string]any)["watchdog"], check.IsNil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "watchdog.runtime-timeout", "30s"), check.IsNil)
+	c.Assert(tr.Set("core", "watchdog.shutdown-timeout", "10m"), check.IsNil)
+	tr.Commit()
+	st.Unlock()
+
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result.(map[string]any)["watchdog"], check.DeepEquals, &client.WatchdogInfo{
+		Enabled:         true,
+		RuntimeTimeout:  "30s",
+		ShutdownTimeout: "10m0s",
+	})
+}
+
 func (s *generalSuite) TestSysInfoWorksDegraded(c *check.C) {
 	s.expectSystemInfoReadAccess()
 	d := s.daemon(c)
@@ -428,6 +506,218 @@ func (s *generalSuite) TestSysInfoWorksDegraded(c *check.C) {
 	c.Check(rsp.Status, check.Equals, 200)
 }
 
+func (s *generalSuite) TestSysInfoCapabilities(c *check.C) {
+	root := c.MkDir()
+	dirs.SetRootDir(root)
+
+	cpuinfo := filepath.Join(root, "cpuinfo")
+	c.Assert(os.WriteFile(cpuinfo, []byte(`processor	: 0
+model name	: Fancy CPU
+flags		: sse2 aes
+processor	: 1
+model name	: Fancy CPU
+flags		: sse2 aes
+`), 0644), check.IsNil)
+	s.AddCleanup(osutil.MockProcCpuinfo(cpuinfo))
+	s.AddCleanup(osutil.MockKernelVersion("6.8.0-generic"))
+	// MockLevel also mocks the kernel features
+	s.AddCleanup(apparmor.MockLevel(apparmor.Full))
+	s.AddCleanup(cgroup.MockVersion(cgroup.V2, nil))
+
+	c.Assert(os.MkdirAll(filepath.Join(root, "/boot"), 0755), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(root, "/boot/config-6.8.0-generic"), []byte(`
+CONFIG_SQUASHFS=y
+CONFIG_SQUASHFS_ZLIB=y
+# CONFIG_SQUASHFS_LZ4 is not set
+CONFIG_SQUASHFS_LZO=y
+CONFIG_SQUASHFS_XZ=y
+CONFIG_SQUASHFS_ZSTD=m
+`), 0644), check.IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(root, "/sys/class/tpm/tpm0"), 0755), check.IsNil)
+
+	caps := daemon.SysInfoCapabilities()
+	c.Check(caps, check.DeepEquals, &client.SystemCapabilities{
+		CPU: client.CPUCapabilities{
+			Architecture:       arch.DpkgArchitecture(),
+			KernelArchitecture: arch.DpkgKernelArchitecture(),
+			Model:              "Fancy CPU",
+			Count:              2,
+			Flags:              []string{"aes", "sse2"},
+		},
+		Kernel: client.KernelCapabilities{
+			Version:              "6.8.0-generic",
+			CgroupVersion:        cgroup.V2,
+			AppArmorLevel:        "full",
+			AppArmorFeatures:     []string{"mocked-kernel-feature"},
+			SquashfsCompressions: []string{"gzip", "lzo", "xz"},
+		},
+		TPM: true,
+	})
+}
+
+func (s *generalSuite) TestSysInfoCapabilitiesMinimal(c *check.C) {
+	root := c.MkDir()
+	dirs.SetRootDir(root)
+
+	s.AddCleanup(osutil.MockProcCpuinfo(filepath.Join(root, "missing")))
+	s.AddCleanup(osutil.MockKernelVersion("6.8.0-generic"))
+	s.AddCleanup(apparmor.MockLevel(apparmor.Unsupported))
+	s.AddCleanup(cgroup.MockVersion(0, errors.New("boom")))
+
+	caps := daemon.SysInfoCapabilities()
+	c.Check(caps, check.DeepEquals, &client.SystemCapabilities{
+		CPU: client.CPUCapabilities{
+			Architecture:       arch.DpkgArchitecture(),
+			KernelArchitecture: arch.DpkgKernelArchitecture(),
+		},
+		Kernel: client.KernelCapabilities{
+			Version:       "6.8.0-generic",
+			AppArmorLevel: "none",
+		},
+	})
+}
+
+func (s *generalSuite) mockProcSys(c *check.C, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dirs.GlobalRootDir, "/proc/sys", name)
+		c.Assert(os.MkdirAll(filepath.Dir(p), 0755), check.IsNil)
+		c.Assert(os.WriteFile(p, []byte(content), 0644), check.IsNil)
+	}
+}
+
+func (s *generalSuite) TestSysInfoSandboxDetails(c *check.C) {
+	dirs.SetRootDir(c.MkDir())
+
+	s.AddCleanup(apparmor.MockLevel(apparmor.Full))
+	s.AddCleanup(seccomp.MockActions([]string{"allow", "errno", "kill_process", "log"}))
+	s.AddCleanup(cgroup.MockVersion(cgroup.V2, nil))
+	s.AddCleanup(sandbox.MockForceDevMode(false))
+	s.AddCleanup(daemon.MockApparmorPromptingSupported(func() (bool, string) { return true, "" }))
+	s.mockProcSys(c, map[string]string{
+		"user/max_user_namespaces":                     "63439\n",
+		"kernel/apparmor_restrict_unprivileged_userns": "1\n",
+	})
+
+	details := daemon.SysInfoSandboxDetails()
+	c.Check(details, check.DeepEquals, &client.SandboxDetails{
+		Confinement: "strict",
+		AppArmor: client.AppArmorDetails{
+			Level:          "full",
+			Summary:        "mocked apparmor level: full",
+			KernelFeatures: []string{"mocked-kernel-feature"},
+			ParserFeatures: []string{"mocked-parser-feature"},
+		},
+		Seccomp: client.SeccompDetails{
+			Actions: []string{"allow", "errno", "kill_process", "log"},
+		},
+		CgroupVersion: cgroup.V2,
+		UserNamespaces: client.UserNamespacesDetails{
+			Available:            true,
+			RestrictedByAppArmor: true,
+		},
+	})
+}
+
+func (s *generalSuite) TestSysInfoSandboxDetailsDegraded(c *check.C) {
+	dirs.SetRootDir(c.MkDir())
+
+	s.AddCleanup(apparmor.MockLevel(apparmor.Unsupported))
+	s.AddCleanup(seccomp.MockActions([]string{"allow", "errno"}))
+	s.AddCleanup(cgroup.MockVersion(cgroup.V1, nil))
+	s.AddCleanup(sandbox.MockForceDevMode(true))
+	s.AddCleanup(daemon.MockApparmorPromptingSupported(func() (bool, string) {
+		return false, "apparmor kernel features do not support prompting"
+	}))
+	s.mockProcSys(c, map[string]string{
+		"user/max_user_namespaces":         "63439",
+		"kernel/unprivileged_userns_clone": "0",
+	})
+
+	details := daemon.SysInfoSandboxDetails()
+	c.Check(details, check.DeepEquals, &client.SandboxDetails{
+		Confinement: "partial",
+		AppArmor: client.AppArmorDetails{
+			Level:   "none",
+			Summary: "mocked apparmor level: none",
+		},
+		Seccomp: client.SeccompDetails{
+			Actions: []string{"allow", "errno"},
+		},
+		CgroupVersion: cgroup.V1,
+		Degraded: []client.DegradedFeature{
+			{Feature: "strict-confinement", Reason: "apparmor support is none, snaps are forced into devmode"},
+			{Feature: "apparmor-prompting", Reason: "apparmor kernel features do not support prompting"},
+			{Feature: "devmode-seccomp-logging", Reason: "kernel does not support the seccomp log action"},
+			{Feature: "quota-cpu-set", Reason: "CPU set quotas require cgroup version 2"},
+		},
+	})
+}
+
+func (s *generalSuite) TestSysInfoSelectSandboxDetails(c *check.C) {
+	s.expectSystemInfoReadAccess()
+	s.daemon(c)
+	s.AddCleanup(daemon.MockSysInfoSandboxDetails(func() *client.SandboxDetails {
+		return &client.SandboxDetails{Confinement: "strict", CgroupVersion: 2}
+	}))
+
+	req, err := http.NewRequest("GET", "/v2/system-info?select=sandbox-details", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, &client.SandboxDetails{Confinement: "strict", CgroupVersion: 2})
+
+	req, err = http.NewRequest("GET", "/v2/system-info?select=foo", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid select parameter: "foo"`)
+}
+
+func (s *generalSuite) TestSysInfoSelectHardware(c *check.C) {
+	s.expectSystemInfoReadAccess()
+	s.daemon(c)
+	s.AddCleanup(daemon.MockSysInfoHardware(func() *client.HardwareInfo {
+		return &client.HardwareInfo{
+			CPU:        client.HardwareCPU{Model: "Some CPU", Cores: 4},
+			TPM:        client.HardwareTPM{Present: true, Version: "2"},
+			SecureBoot: "disabled",
+		}
+	}))
+
+	req, err := http.NewRequest("GET", "/v2/system-info?select=hardware", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, &client.HardwareInfo{
+		CPU:        client.HardwareCPU{Model: "Some CPU", Cores: 4},
+		TPM:        client.HardwareTPM{Present: true, Version: "2"},
+		SecureBoot: "disabled",
+	})
+}
+
+func (s *generalSuite) TestSysInfoHardware(c *check.C) {
+	s.AddCleanup(daemon.MockHwinfoGet(func() *hwinfo.Info {
+		return &hwinfo.Info{
+			CPU:    hwinfo.CPU{Model: "Some CPU", Cores: 2},
+			Memory: 1024,
+			Storage: []hwinfo.StorageDevice{
+				{Name: "sda", Model: "Some Disk", Size: 2048 * 512, Rotational: true},
+			},
+			TPM:        hwinfo.TPM{Present: true, Version: "2"},
+			SecureBoot: hwinfo.SecureBootUnsupported,
+		}
+	}))
+
+	info := daemon.SysInfoHardware()
+	c.Check(info, check.DeepEquals, &client.HardwareInfo{
+		CPU:    client.HardwareCPU{Model: "Some CPU", Cores: 2},
+		Memory: 1024,
+		Storage: []client.HardwareStorageDevice{
+			{Name: "sda", Model: "Some Disk", Size: 2048 * 512, Rotational: true},
+		},
+		TPM:        client.HardwareTPM{Present: true, Version: "2"},
+		SecureBoot: "unsupported",
+	})
+}
+
 func (s *generalSuite) TestSysInfoClientAdviceProceedMatchingKey(c *check.C) {
 	s.expectSystemInfoWriteAccess()
 	s.daemon(c)
@@ -778,7 +1068,7 @@ func (s *generalSuite) TestStateChangesInProgress(c *check.C) {
 	c.Assert(rec.Code, check.Equals, 200)
 	res := rec.Body.Bytes()
 
-	c.Check(string(res), check.Matches, `.*{"id":"\w+","kind":"install","summary":"install...","status":"Do","tasks":\[{"id":"\w+","kind":"download","summary":"1...","status":"Do","log":\["2016-04-21T01:02:03Z INFO l11","2016-04-21T01:02:03Z INFO l12"],"progress":{"label":"","done":0,"total":1},"spawn-time":"2016-04-21T01:02:03Z"}.*],"ready":false,"spawn-time":"2016-04-21T01:02:03Z"}.*`)
+	c.Check(string(res), check.Matches, `.*{"id":"\w+","kind":"install","summary":"install...","status":"Do","tasks":\[{"id":"\w+","kind":"download","summary":"1...","status":"Do","log":\["2016-04-21T01:02:03Z INFO l11","2016-04-21T01:02:03Z INFO l12"],"progress":{"label":"","done":0,"total":1},"spawn-time":"2016-04-21T01:02:03Z"}.*],"ready":false,"spawn-time":"2016-04-21T01:02:03Z","last-activity-time":"2016-04-21T01:02:03Z"}.*`)
 }
 
 func (s *generalSuite) TestStateChangesAll(c *check.C) {
@@ -807,7 +1097,7 @@ func (s *generalSuite) TestStateChangesAll(c *check.C) {
 	c.Assert(rec.Code, check.Equals, 200)
 	res := rec.Body.Bytes()
 
-	c.Check(string(res), check.Matches, `.*{"id":"\w+","kind":"install","summary":"install...","status":"Do","tasks":\[{"id":"\w+","kind":"download","summary":"1...","status":"Do","log":\["2016-04-21T01:02:03Z INFO l11","2016-04-21T01:02:03Z INFO l12"],"progress":{"label":"","done":0,"total":1},"spawn-time":"2016-04-21T01:02:03Z"}.*],"ready":false,"spawn-time":"2016-04-21T01:02:03Z"}.*`)
+	c.Check(string(res), check.Matches, `.*{"id":"\w+","kind":"install","summary":"install...","status":"Do","tasks":\[{"id":"\w+","kind":"download","summary":"1...","status":"Do","log":\["2016-04-21T01:02:03Z INFO l11","2016-04-21T01:02:03Z INFO l12"],"progress":{"label":"","done":0,"total":1},"spawn-time":"2016-04-21T01:02:03Z"}.*],"ready":false,"spawn-time":"2016-04-21T01:02:03Z","last-activity-time":"2016-04-21T01:02:03Z"}.*`)
 	c.Check(string(res), check.Matches, `.*{"id":"\w+","kind":"remove","summary":"remove..","status":"Error","tasks":\[{"id":"\w+","kind":"unlink","summary":"1...","status":"Error","log":\["2016-04-21T01:02:03Z ERROR rm failed"],"progress":{"label":"","done":1,"total":1},"spawn-time":"2016-04-21T01:02:03Z","ready-time":"2016-04-21T01:02:03Z"}.*],"ready":true,"err":"[^"]+".*`)
 }
 
@@ -906,6 +1196,92 @@ func (s *generalSuite) TestStateChangesForSnapNameWithApp(c *check.C) {
 	c.Assert(rec.Code, check.Equals, 200)
 }
 
+func (s *generalSuite) TestStateChangeRetries(c *check.C) {
+	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
+	defer restore()
+
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	runner := d.Overlord().TaskRunner()
+	runner.AddHandler("flaky", func(t *state.Task, _ *tomb.Tomb) error {
+		return errors.New("network is flaky")
+	}, nil)
+	runner.SetRetryPolicy("flaky", func(error) bool { return true }, func(*state.Task) state.RetryPolicy {
+		return state.RetryPolicy{MaxRetries: 3, InitialBackoff: time.Hour}
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "install...")
+	t := st.NewTask("flaky", "1...")
+	chg.AddTask(t)
+	st.Unlock()
+
+	// scheduling the retry requires the overlord loop
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+	for i := 0; ; i++ {
+		st.Lock()
+		retries := t.Retries()
+		st.Unlock()
+		if retries > 0 {
+			break
+		}
+		if i > 500 {
+			c.Fatal("task not retried")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	req, err := http.NewRequest("GET", "/v2/changes/"+chg.ID(), nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, nil)
+	c.Assert(rec.Code, check.Equals, 200)
+	c.Check(rec.Body.String(), check.Matches, `.*"status":"Doing".*"retries":1,"next-retry":"2016-04-21T02:02:03Z".*`)
+}
+
+func (s *generalSuite) TestStateChangeGraph(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	chg := st.NewChange("install", "install...")
+	t1 := st.NewTask("download", "1...")
+	t2 := st.NewTask("activate", "2...")
+	t2.WaitFor(t1)
+	lane := st.NewLane()
+	t1.JoinLane(lane)
+	t2.JoinLane(lane)
+	chg.AddTask(t1)
+	chg.AddTask(t2)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/changes/"+chg.ID()+"?include=graph", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	chgInfo, ok := rsp.Result.(*daemon.ChangeInfo)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(chgInfo.Tasks, check.HasLen, 2)
+	c.Check(chgInfo.Tasks[0].WaitTasks, check.DeepEquals, []string{})
+	c.Check(chgInfo.Tasks[0].HaltTasks, check.DeepEquals, []string{t2.ID()})
+	c.Check(chgInfo.Tasks[0].Lanes, check.DeepEquals, []int{lane})
+	c.Check(chgInfo.Tasks[1].WaitTasks, check.DeepEquals, []string{t1.ID()})
+	c.Check(chgInfo.Tasks[1].HaltTasks, check.DeepEquals, []string{})
+	c.Check(chgInfo.Tasks[1].Lanes, check.DeepEquals, []int{lane})
+
+	// without asking for it, the graph is not reported
+	req, err = http.NewRequest("GET", "/v2/changes/"+chg.ID(), nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	chgInfo = rsp.Result.(*daemon.ChangeInfo)
+	c.Check(chgInfo.Tasks[1].WaitTasks, check.IsNil)
+	c.Check(chgInfo.Tasks[1].Lanes, check.IsNil)
+}
+
 func (s *generalSuite) TestStateChange(c *check.C) {
 	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
 	defer restore()
@@ -956,6 +1332,8 @@ func (s *generalSuite) TestStateChange(c *check.C) {
 		"status":     "Do",
 		"ready":      false,
 		"spawn-time": "2016-04-21T01:02:03Z",
+
+		"last-activity-time": "2016-04-21T01:02:03Z",
 		"tasks": []any{
 			map[string]any{
 				"id":         ids[2],
@@ -982,6 +1360,100 @@ func (s *generalSuite) TestStateChange(c *check.C) {
 	})
 }
 
+func (s *generalSuite) TestStateChangeWait(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		st.Lock()
+		defer st.Unlock()
+		for _, t := range st.Change(ids[0]).Tasks() {
+			t.SetStatus(state.DoneStatus)
+		}
+	}()
+
+	req, err := http.NewRequest("GET", "/v2/changes/"+ids[0]+"?wait=true&timeout=10s", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	res, ok := rsp.Result.(*daemon.ChangeInfo)
+	c.Assert(ok, check.Equals, true)
+	c.Check(res.ID, check.Equals, ids[0])
+	c.Check(res.Status, check.Equals, "Done")
+	c.Check(res.Ready, check.Equals, true)
+}
+
+func (s *generalSuite) TestStateChangeWaitTimeout(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/changes/"+ids[0]+"?wait=true&timeout=10ms", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	res, ok := rsp.Result.(*daemon.ChangeInfo)
+	c.Assert(ok, check.Equals, true)
+	c.Check(res.ID, check.Equals, ids[0])
+	c.Check(res.Status, check.Equals, "Do")
+	c.Check(res.Ready, check.Equals, false)
+}
+
+func (s *generalSuite) TestStateChangeWaitAlreadyReady(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	// the second change is in error already, no timeout means no bound on
+	// the wait, so this would hang if the ready state was not detected
+	req, err := http.NewRequest("GET", "/v2/changes/"+ids[1]+"?wait=true", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	res, ok := rsp.Result.(*daemon.ChangeInfo)
+	c.Assert(ok, check.Equals, true)
+	c.Check(res.ID, check.Equals, ids[1])
+	c.Check(res.Status, check.Equals, "Error")
+	c.Check(res.Ready, check.Equals, true)
+}
+
+func (s *generalSuite) TestStateChangeWaitBadParams(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	for _, t := range []struct {
+		query string
+		err   string
+	}{
+		{"wait=maybe", `invalid wait parameter: "maybe"`},
+		{"wait=true&timeout=soon", `invalid timeout: .*`},
+		{"wait=true&timeout=-1s", `invalid timeout: must not be negative`},
+		{"timeout=1s", `timeout can only be used together with wait`},
+		{"include=everything", `invalid include parameter: "everything"`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/changes/"+ids[0]+"?"+t.query, nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%s", t.query))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf("%s", t.query))
+	}
+}
+
 func (s *generalSuite) expectManageAccess() {
 	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage"})
 }
@@ -1094,6 +1566,141 @@ func (s *generalSuite) TestStateChangeAbortIsReady(c *check.C) {
 	})
 }
 
+func setupRefreshChanges(st *state.State) []string {
+	var ids []string
+	for _, snapName := range []string{"foo", "bar", "foo"} {
+		chg := st.NewChange("refresh-snap", "refresh...")
+		chg.Set("snap-names", []string{snapName})
+		chg.AddTask(st.NewTask("download", "1..."))
+		ids = append(ids, chg.ID())
+	}
+	return ids
+}
+
+func (s *generalSuite) testStateChangesAbort(c *check.C, body string, expectedAborted func(installID string, refreshIDs []string) []string) {
+	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
+	defer restore()
+
+	soon := 0
+	_, restore = daemon.MockEnsureStateSoon(func(st *state.State) {
+		soon++
+	})
+	defer restore()
+
+	// Setup
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	refreshIDs := setupRefreshChanges(st)
+	st.Unlock()
+
+	s.expectManageAccess()
+
+	expected := expectedAborted(ids[0], refreshIDs)
+	body = strings.NewReplacer(
+		"$INSTALL", ids[0],
+		"$REFRESH0", refreshIDs[0],
+		"$REFRESH1", refreshIDs[1],
+		"$REFRESH2", refreshIDs[2],
+	).Replace(body)
+
+	// Execute
+	req, err := http.NewRequest("POST", "/v2/changes", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	// Verify
+	c.Check(rsp.Status, check.Equals, 200)
+	c.Assert(rsp.Result, check.FitsTypeOf, []*daemon.ChangeInfo(nil))
+	res := rsp.Result.([]*daemon.ChangeInfo)
+	var abortedIDs []string
+	for _, chgInfo := range res {
+		abortedIDs = append(abortedIDs, chgInfo.ID)
+	}
+	sort.Strings(abortedIDs)
+	sort.Strings(expected)
+	c.Check(abortedIDs, check.DeepEquals, expected)
+
+	if len(expected) > 0 {
+		c.Check(soon, check.Equals, 1)
+	} else {
+		c.Check(soon, check.Equals, 0)
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	for _, chg := range st.Changes() {
+		if chg.ID() == ids[1] {
+			// the remove change was already in error
+			continue
+		}
+		aborted := strutil.ListContains(expected, chg.ID())
+		c.Check(chg.Status() == state.HoldStatus, check.Equals, aborted, check.Commentf("change %s", chg.ID()))
+	}
+}
+
+func (s *generalSuite) TestStateChangesAbortByID(c *check.C) {
+	s.testStateChangesAbort(c, `{"action": "abort", "ids": ["$INSTALL", "$REFRESH1"]}`, func(installID string, refreshIDs []string) []string {
+		return []string{installID, refreshIDs[1]}
+	})
+}
+
+func (s *generalSuite) TestStateChangesAbortByKind(c *check.C) {
+	s.testStateChangesAbort(c, `{"action": "abort", "kind": "refresh-snap"}`, func(installID string, refreshIDs []string) []string {
+		return refreshIDs
+	})
+}
+
+func (s *generalSuite) TestStateChangesAbortForSnap(c *check.C) {
+	s.testStateChangesAbort(c, `{"action": "abort", "for-snap": "foo"}`, func(installID string, refreshIDs []string) []string {
+		return []string{refreshIDs[0], refreshIDs[2]}
+	})
+}
+
+func (s *generalSuite) TestStateChangesAbortByKindForSnap(c *check.C) {
+	s.testStateChangesAbort(c, `{"action": "abort", "kind": "install", "for-snap": "foo"}`, func(installID string, refreshIDs []string) []string {
+		return nil
+	})
+}
+
+func (s *generalSuite) TestStateChangesAbortErrors(c *check.C) {
+	// Setup
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	s.expectManageAccess()
+
+	for _, tc := range []struct {
+		body    string
+		status  int
+		message string
+	}{
+		{`{"action": "abort"`, 400, "cannot decode data from request body: .*"},
+		{`{"action": "foo", "ids": ["1"]}`, 400, `change action "foo" is unsupported`},
+		{`{"action": "abort"}`, 400, "cannot abort changes: change IDs or a filter must be specified"},
+		{`{"action": "abort", "ids": ["1"], "kind": "install"}`, 400, "cannot abort changes: cannot use change IDs together with a filter"},
+		{`{"action": "abort", "ids": ["` + ids[0] + `", "999"]}`, 404, `cannot find change with id "999"`},
+		{`{"action": "abort", "ids": ["` + ids[0] + `", "` + ids[1] + `"]}`, 400, fmt.Sprintf("cannot abort change %s with nothing pending", ids[1])},
+	} {
+		req, err := http.NewRequest("POST", "/v2/changes", bytes.NewBufferString(tc.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, tc.status, check.Commentf("body: %s", tc.body))
+		c.Check(rspe.Message, check.Matches, tc.message, check.Commentf("body: %s", tc.body))
+	}
+
+	// No change was aborted
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Change(ids[0]).Status(), check.Equals, state.DoStatus)
+}
+
 func (s *generalSuite) testWarnings(c *check.C, all bool, body io.Reader) (calls string, result any) {
 	s.daemon(c)
 
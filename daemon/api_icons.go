@@ -23,6 +23,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -37,14 +38,40 @@ var (
 	}
 )
 
+var _ = registerAPIFeature("icon-size-theme")
+
 func snapIconGet(c *Command, r *http.Request, user *auth.UserState) Response {
 	vars := muxVars(r)
 	name := vars["name"]
 
-	return iconGet(c.d.overlord.State(), name)
+	opts, err := parseIconOptions(r.URL.Query())
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+
+	return iconGet(c.d.overlord.State(), name, opts)
+}
+
+func iconGet(st *state.State, name string, opts *iconOptions) Response {
+	icon, rsp := snapIconPath(st, name, opts)
+	if rsp != nil {
+		return rsp
+	}
+
+	// scaling is done without holding the state lock
+	scaled, err := scaledIcon(icon, name, opts.Size)
+	if err != nil {
+		// serve the icon as it is rather than none at all
+		logger.Noticef("cannot scale icon of snap %q: %v", name, err)
+		return fileResponse(icon)
+	}
+
+	return fileResponse(scaled)
 }
 
-func iconGet(st *state.State, name string) Response {
+// snapIconPath returns the path of the icon of the current revision of
+// the snap best matching the options.
+func snapIconPath(st *state.State, name string, opts *iconOptions) (string, Response) {
 	st.Lock()
 	defer st.Unlock()
 
@@ -52,20 +79,27 @@ func iconGet(st *state.State, name string) Response {
 	err := snapstate.Get(st, name, &snapst)
 	if err != nil {
 		if errors.Is(err, state.ErrNoState) {
-			return SnapNotFound(name, err)
+			return "", SnapNotFound(name, err)
 		}
-		return InternalError("cannot consult state: %v", err)
+		return "", InternalError("cannot consult state: %v", err)
 	}
 	sideInfo := snapst.CurrentSideInfo()
 	if sideInfo == nil {
-		return NotFound("snap has no current revision")
+		return "", NotFound("snap has no current revision")
 	}
 
-	icon := snapIcon(snap.MinimalPlaceInfo(name, sideInfo.Revision), sideInfo.SnapID)
+	info := snap.MinimalPlaceInfo(name, sideInfo.Revision)
 
+	var icon string
+	if opts.Size != 0 || opts.Theme != "" {
+		icon = themedSnapIcon(info, opts)
+	}
 	if icon == "" {
-		return NotFound("local snap has no icon")
+		icon = snapIcon(info, sideInfo.SnapID)
 	}
 
-	return fileResponse(icon)
+	if icon == "" {
+		return "", NotFound("local snap has no icon")
+	}
+	return icon, nil
 }
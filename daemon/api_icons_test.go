@@ -20,7 +20,11 @@
 package daemon_test
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -29,6 +33,7 @@ import (
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/snapstate/backend"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
@@ -211,3 +216,158 @@ func (s *iconsSuite) TestNotInstalledSnapIcon(c *check.C) {
 	iconfile := daemon.SnapIcon(info, "notInstalledSnapID")
 	c.Check(iconfile, check.Equals, "")
 }
+
+func mockPNG(c *check.C, path string, w, h int, col color.Color) {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, col)
+		}
+	}
+	var buf bytes.Buffer
+	c.Assert(png.Encode(&buf, img), check.IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0o755), check.IsNil)
+	c.Assert(os.WriteFile(path, buf.Bytes(), 0o644), check.IsNil)
+}
+
+func (s *iconsSuite) getIcon(c *check.C, url string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("GET", url, nil)
+	c.Assert(err, check.IsNil)
+
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil, actionIsExpected).ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *iconsSuite) TestSnapIconGetSized(c *check.C) {
+	s.expectIconsReadAccess()
+	d := s.daemon(c)
+
+	info := s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+	icondir := filepath.Join(info.MountDir(), "meta", "gui")
+	c.Assert(os.Remove(filepath.Join(icondir, "icon.svg")), check.IsNil)
+	mockPNG(c, filepath.Join(icondir, "icon.png"), 64, 32, color.NRGBA{R: 255, A: 255})
+
+	rec := s.getIcon(c, "/v2/icons/foo/icon?size=16")
+	c.Assert(rec.Code, check.Equals, 200)
+	img, err := png.Decode(rec.Body)
+	c.Assert(err, check.IsNil)
+	// the aspect ratio is kept
+	c.Check(img.Bounds(), check.Equals, image.Rect(0, 0, 16, 8))
+	c.Check(color.NRGBAModel.Convert(img.At(3, 3)), check.Equals, color.NRGBA{R: 255, A: 255})
+
+	// the scaled icon was cached
+	cached, err := filepath.Glob(filepath.Join(dirs.SnapIconsScaledDir, "foo", "*.png"))
+	c.Assert(err, check.IsNil)
+	c.Check(cached, check.HasLen, 1)
+
+	rec = s.getIcon(c, "/v2/icons/foo/icon?size=16")
+	c.Assert(rec.Code, check.Equals, 200)
+	cached, err = filepath.Glob(filepath.Join(dirs.SnapIconsScaledDir, "foo", "*.png"))
+	c.Assert(err, check.IsNil)
+	c.Check(cached, check.HasLen, 1)
+}
+
+func (s *iconsSuite) TestSnapIconGetSizedVectorOrBroken(c *check.C) {
+	s.expectIconsReadAccess()
+	d := s.daemon(c)
+
+	info := s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+
+	// svg icons are not scaled
+	rec := s.getIcon(c, "/v2/icons/foo/icon?size=16")
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Body.String(), check.Equals, "yadda icon")
+
+	// icons that cannot be decoded are served as they are
+	icondir := filepath.Join(info.MountDir(), "meta", "gui")
+	c.Assert(os.Remove(filepath.Join(icondir, "icon.svg")), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(icondir, "icon.png"), []byte("I'm a png"), 0o644), check.IsNil)
+	rec = s.getIcon(c, "/v2/icons/foo/icon?size=16")
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Body.String(), check.Equals, "I'm a png")
+}
+
+func (s *iconsSuite) TestSnapIconGetSizedTooBig(c *check.C) {
+	s.expectIconsReadAccess()
+	d := s.daemon(c)
+
+	info := s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+	icondir := filepath.Join(info.MountDir(), "meta", "gui")
+	c.Assert(os.Remove(filepath.Join(icondir, "icon.svg")), check.IsNil)
+	mockPNG(c, filepath.Join(icondir, "icon.png"), 4097, 1, color.NRGBA{R: 255, A: 255})
+
+	// icons too big to be decoded are served as they are
+	rec := s.getIcon(c, "/v2/icons/foo/icon?size=16")
+	c.Assert(rec.Code, check.Equals, 200)
+	img, err := png.Decode(rec.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(img.Bounds(), check.Equals, image.Rect(0, 0, 4097, 1))
+	c.Check(filepath.Join(dirs.SnapIconsScaledDir, "foo"), testutil.FileAbsent)
+}
+
+func (s *iconsSuite) TestSnapIconGetThemed(c *check.C) {
+	s.expectIconsReadAccess()
+	d := s.daemon(c)
+
+	info := s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+	themes := filepath.Join(info.MountDir(), "meta", "gui", "icons")
+	mockPNG(c, filepath.Join(themes, "hicolor", "16x16", "apps", "snap.foo.foo.png"), 16, 16, color.NRGBA{R: 255, A: 255})
+	mockPNG(c, filepath.Join(themes, "hicolor", "64x64", "apps", "snap.foo.foo.png"), 64, 64, color.NRGBA{G: 255, A: 255})
+	mockPNG(c, filepath.Join(themes, "hicolor", "128x128", "apps", "snap.foo.foo.png"), 128, 128, color.NRGBA{B: 255, A: 255})
+	mockPNG(c, filepath.Join(themes, "Yaru", "32x32", "apps", "snap.foo.other.png"), 32, 32, color.NRGBA{R: 255, G: 255, A: 255})
+
+	checkIcon := func(url string, size int, col color.NRGBA) {
+		rec := s.getIcon(c, url)
+		c.Assert(rec.Code, check.Equals, 200, check.Commentf(url))
+		img, err := png.Decode(rec.Body)
+		c.Assert(err, check.IsNil, check.Commentf(url))
+		c.Check(img.Bounds(), check.Equals, image.Rect(0, 0, size, size), check.Commentf(url))
+		c.Check(color.NRGBAModel.Convert(img.At(0, 0)), check.Equals, col, check.Commentf(url))
+	}
+
+	// exact size
+	checkIcon("/v2/icons/foo/icon?size=16", 16, color.NRGBA{R: 255, A: 255})
+	// the next bigger icon is scaled down
+	checkIcon("/v2/icons/foo/icon?size=48", 48, color.NRGBA{G: 255, A: 255})
+	// the biggest icon is scaled up
+	checkIcon("/v2/icons/foo/icon?size=256", 256, color.NRGBA{B: 255, A: 255})
+	// the requested theme goes first
+	checkIcon("/v2/icons/foo/icon?theme=Yaru&size=32", 32, color.NRGBA{R: 255, G: 255, A: 255})
+	// unknown themes fall back to hicolor
+	checkIcon("/v2/icons/foo/icon?theme=Other&size=64", 64, color.NRGBA{G: 255, A: 255})
+
+	// scalable icons win
+	svg := filepath.Join(themes, "hicolor", "scalable", "apps", "snap.foo.foo.svg")
+	c.Assert(os.MkdirAll(filepath.Dir(svg), 0o755), check.IsNil)
+	c.Assert(os.WriteFile(svg, []byte("I'm scalable"), 0o644), check.IsNil)
+	rec := s.getIcon(c, "/v2/icons/foo/icon?size=64")
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Body.String(), check.Equals, "I'm scalable")
+
+	// without options the main icon is used
+	rec = s.getIcon(c, "/v2/icons/foo/icon")
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Body.String(), check.Equals, "yadda icon")
+}
+
+func (s *iconsSuite) TestSnapIconGetInvalidOptions(c *check.C) {
+	s.expectIconsReadAccess()
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", snap.R(10), true, "")
+
+	for _, t := range []struct {
+		query, err string
+	}{
+		{"size=0", `invalid icon size "0": must be a number between 1 and 1024`},
+		{"size=2000", `invalid icon size "2000": must be a number between 1 and 1024`},
+		{"size=big", `invalid icon size "big": must be a number between 1 and 1024`},
+		{"theme=../../etc", `invalid icon theme "../../etc"`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/icons/foo/icon?"+t.query, nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, t.err)
+	}
+}
@@ -40,15 +40,26 @@ var (
 		Path:        "/v2/interfaces",
 		GET:         interfacesConnectionsMultiplexer,
 		POST:        changeInterfaces,
-		Actions:     []string{"connect", "disconnect"},
+		Actions:     []string{"connect", "disconnect", "migrate-connections", "connect-all", "disconnect-all"},
 		ReadAccess:  openAccess{},
 		WriteAccess: authenticatedAccess{Polkit: polkitActionManageInterfaces},
+		Throttled:   true,
 	}
 )
 
 var (
-	connectSnapChangeKind    = swfeats.RegisterChangeKind("connect-snap")
-	disconnectSnapChangeKind = swfeats.RegisterChangeKind("disconnect-snap")
+	connectSnapChangeKind        = swfeats.RegisterChangeKind("connect-snap")
+	disconnectSnapChangeKind     = swfeats.RegisterChangeKind("disconnect-snap")
+	migrateConnectionsChangeKind = swfeats.RegisterChangeKind("migrate-connections")
+	connectAllChangeKind         = swfeats.RegisterChangeKind("connect-all")
+	disconnectAllChangeKind      = swfeats.RegisterChangeKind("disconnect-all")
+)
+
+var (
+	_ = swfeats.DescribeChangeKind(connectSnapChangeKind, "Connect interfaces",
+		"run-hook", "run-hook", "connect", "run-hook", "run-hook")
+	_ = swfeats.DescribeChangeKind(disconnectSnapChangeKind, "Disconnect interfaces",
+		"run-hook", "run-hook", "disconnect")
 )
 
 // interfacesConnectionsMultiplexer multiplexes to either legacy (connection) or modern behavior (interfaces).
@@ -84,24 +95,33 @@ func getInterfaces(c *Command, r *http.Request, user *auth.UserState) Response {
 	// Query the interface repository (this returns []*interface.Info).
 	infos := c.d.overlord.InterfaceManager().Repository().Info(opts)
 	infoJSONs := make([]*interfaceJSON, 0, len(infos))
+	redact := shouldRedactAttrs(r)
 
 	for _, info := range infos {
 		// Convert interfaces.Info into interfaceJSON
 		plugs := make([]*plugJSON, 0, len(info.Plugs))
 		for _, plug := range info.Plugs {
+			attrs := plug.Attrs
+			if redact {
+				attrs = redactAttrs(info.Name, attrs)
+			}
 			plugs = append(plugs, &plugJSON{
 				Snap:  plug.Snap.InstanceName(),
 				Name:  plug.Name,
-				Attrs: plug.Attrs,
+				Attrs: attrs,
 				Label: plug.Label,
 			})
 		}
 		slots := make([]*slotJSON, 0, len(info.Slots))
 		for _, slot := range info.Slots {
+			attrs := slot.Attrs
+			if redact {
+				attrs = redactAttrs(info.Name, attrs)
+			}
 			slots = append(slots, &slotJSON{
 				Snap:  slot.Snap.InstanceName(),
 				Name:  slot.Name,
-				Attrs: slot.Attrs,
+				Attrs: attrs,
 				Label: slot.Label,
 			})
 		}
@@ -121,6 +141,9 @@ func getLegacyConnections(c *Command, r *http.Request, user *auth.UserState) Res
 	if err != nil {
 		return InternalError("collecting connection information failed: %v", err)
 	}
+	if shouldRedactAttrs(r) {
+		redactConnections(connsjson)
+	}
 	legacyconnsjson := legacyConnectionsJSON{
 		Plugs: connsjson.Plugs,
 		Slots: connsjson.Slots,
@@ -139,6 +162,12 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 	if a.Action == "" {
 		return BadRequest("interface action not specified")
 	}
+	if a.Action == "migrate-connections" {
+		return migrateConnections(c, r, &a)
+	}
+	if a.Action == "connect-all" || a.Action == "disconnect-all" {
+		return bulkChangeInterfaces(c, r, &a)
+	}
 	if len(a.Plugs) > 1 || len(a.Slots) > 1 {
 		return NotImplemented("many-to-many operations are not implemented")
 	}
@@ -159,6 +188,14 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 	st.Lock()
 	defer st.Unlock()
 
+	version, err := connectionsVersion(st)
+	if err != nil {
+		return InternalError("cannot compute connections version: %v", err)
+	}
+	if rspe := checkIfMatch(st, r, connectionsResource, version); rspe != nil {
+		return rspe
+	}
+
 	checkInstalled := func(snapName string) error {
 		// empty snap name is fine, ResolveConnect/ResolveDisconnect handles it.
 		if snapName == "" {
@@ -200,7 +237,7 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 			summary = fmt.Sprintf("Connect %s:%s to %s:%s", connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
 			ts, err = ifacestate.Connect(st, connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
 			if _, ok := err.(*ifacestate.ErrAlreadyConnected); ok {
-				change := newChange(st, connectSnapChangeKind, summary, nil, affected)
+				change := newChange(r.Context(), st, connectSnapChangeKind, summary, nil, affected)
 				change.SetStatus(state.DoneStatus)
 				return AsyncResponse(nil, change.ID())
 			}
@@ -245,12 +282,155 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 		return errToResponse(err, nil, BadRequest, "%v")
 	}
 
-	change := newChange(st, changeKind, summary, tasksets, affected)
+	change := newChange(r.Context(), st, changeKind, summary, tasksets, affected)
+	markResourceChange(change, connectionsResource)
 	st.EnsureBefore(0)
 
 	return AsyncResponse(nil, change.ID())
 }
 
+// migrateConnections moves the connections of the slots of the snap in
+// a.Slots over to the snap in a.To.
+func migrateConnections(c *Command, r *http.Request, a *interfaceAction) Response {
+	if len(a.Plugs) != 0 {
+		return BadRequest("cannot use plugs with action %q", a.Action)
+	}
+	if len(a.Slots) != 1 || a.To == nil {
+		return BadRequest("action %q requires exactly one slot and a target", a.Action)
+	}
+	if a.Slots[0].Snap == "" || a.To.Snap == "" {
+		return BadRequest("action %q requires the snap of the slot and of the target", a.Action)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	version, err := connectionsVersion(st)
+	if err != nil {
+		return InternalError("cannot compute connections version: %v", err)
+	}
+	if rspe := checkIfMatch(st, r, connectionsResource, version); rspe != nil {
+		return rspe
+	}
+
+	from := ifacestate.RemapSnapFromRequest(a.Slots[0].Snap)
+	to := ifacestate.RemapSnapFromRequest(a.To.Snap)
+	for _, snapName := range []string{from, to} {
+		var snapst snapstate.SnapState
+		err := snapstate.Get(st, snapName, &snapst)
+		if (err == nil && !snapst.IsInstalled()) || errors.Is(err, state.ErrNoState) {
+			return BadRequest("snap %q is not installed", snapName)
+		}
+		if err != nil {
+			return InternalError("cannot get state of snap %q: %v", snapName, err)
+		}
+	}
+
+	repo := c.d.overlord.InterfaceManager().Repository()
+	tasksets, affected, err := ifacestate.MigrateConnections(st, repo, from, a.Slots[0].Name, to, a.To.Name)
+	if err != nil {
+		return errToResponse(err, nil, BadRequest, "%v")
+	}
+	if len(tasksets) == 0 {
+		return InterfacesUnchanged("nothing to do")
+	}
+
+	summary := fmt.Sprintf("Migrate connections of %s to %s", from, to)
+	change := newChange(r.Context(), st, migrateConnectionsChangeKind, summary, tasksets, affected)
+	markResourceChange(change, connectionsResource)
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, change.ID())
+}
+
+// bulkChangeInterfaces connects or disconnects all the plugs of the snap in
+// a.Plugs in a single change, reporting the plugs that were left alone in the
+// "api-data" of the change.
+func bulkChangeInterfaces(c *Command, r *http.Request, a *interfaceAction) Response {
+	if len(a.Slots) != 0 || a.To != nil {
+		return BadRequest("cannot use slots with action %q", a.Action)
+	}
+	if len(a.Plugs) != 1 || a.Plugs[0].Snap == "" || a.Plugs[0].Name != "" {
+		return BadRequest("action %q requires exactly one snap and no plug name", a.Action)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	version, err := connectionsVersion(st)
+	if err != nil {
+		return InternalError("cannot compute connections version: %v", err)
+	}
+	if rspe := checkIfMatch(st, r, connectionsResource, version); rspe != nil {
+		return rspe
+	}
+
+	plugSnap := ifacestate.RemapSnapFromRequest(a.Plugs[0].Snap)
+	var snapst snapstate.SnapState
+	err = snapstate.Get(st, plugSnap, &snapst)
+	if (err == nil && !snapst.IsInstalled()) || errors.Is(err, state.ErrNoState) {
+		return BadRequest("snap %q is not installed", plugSnap)
+	}
+	if err != nil {
+		return InternalError("cannot get state of snap %q: %v", plugSnap, err)
+	}
+
+	repo := c.d.overlord.InterfaceManager().Repository()
+	var tasksets []*state.TaskSet
+	var report *ifacestate.BulkReport
+	var changeKind, summary string
+	if a.Action == "connect-all" {
+		tasksets, report, err = ifacestate.ConnectAll(st, repo, plugSnap, a.Pattern)
+		changeKind = connectAllChangeKind
+		summary = fmt.Sprintf("Connect plugs of %s", plugSnap)
+	} else {
+		tasksets, report, err = ifacestate.DisconnectAll(st, repo, plugSnap, a.Pattern)
+		changeKind = disconnectAllChangeKind
+		summary = fmt.Sprintf("Disconnect plugs of %s", plugSnap)
+	}
+	if err != nil {
+		return errToResponse(err, nil, BadRequest, "%v")
+	}
+	if a.Pattern != "" {
+		summary += fmt.Sprintf(" from slots matching %q", a.Pattern)
+	}
+
+	affected := report.AffectedSnaps()
+	if len(affected) == 0 {
+		affected = []string{plugSnap}
+	}
+	change := newChange(r.Context(), st, changeKind, summary, tasksets, affected)
+	change.Set("api-data", bulkInterfacesReport(report))
+	markResourceChange(change, connectionsResource)
+	if len(tasksets) == 0 {
+		// nothing to do, the report still tells why
+		change.SetStatus(state.DoneStatus)
+	}
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, change.ID())
+}
+
+func bulkInterfacesReport(report *ifacestate.BulkReport) *bulkInterfacesReportJSON {
+	reportJSON := &bulkInterfacesReportJSON{}
+	for _, ref := range report.Connected {
+		reportJSON.Connected = append(reportJSON.Connected, bulkConnectionJSON{Plug: ref.PlugRef, Slot: ref.SlotRef})
+	}
+	for _, ref := range report.Disconnected {
+		reportJSON.Disconnected = append(reportJSON.Disconnected, bulkConnectionJSON{Plug: ref.PlugRef, Slot: ref.SlotRef})
+	}
+	for _, skipped := range report.Skipped {
+		reportJSON.Skipped = append(reportJSON.Skipped, skippedPlugJSON{
+			Plug:       skipped.Plug,
+			Reason:     skipped.Reason,
+			Candidates: skipped.Candidates,
+		})
+	}
+	return reportJSON
+}
+
 func snapNamesFromConns(conns []*interfaces.ConnRef) []string {
 	m := make(map[string]bool)
 	for _, conn := range conns {
@@ -31,6 +31,7 @@ import (
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
@@ -99,6 +100,16 @@ slots:
   label: label
 `
 
+	producer2Yaml = `
+name: producer2
+version: 1
+apps:
+ app:
+slots:
+ slot:
+  interface: test
+`
+
 	coreProducerYaml = `
 name: core
 version: 1
@@ -177,6 +188,62 @@ func (s *interfacesSuite) TestConnectPlugSuccess(c *check.C) {
 	}})
 }
 
+func (s *interfacesSuite) TestConnectIfMatch(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+
+	d := s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	// Get the current version of the connections
+	req, err := http.NewRequest("GET", "/v2/connections", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	version := rsp.ResourceVersion
+	c.Assert(version, check.Not(check.Equals), "")
+
+	action := &client.InterfaceAction{
+		Action: "connect",
+		Plugs:  []client.Plug{{Snap: "consumer", Name: "plug"}},
+		Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+	}
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+
+	// Connections change concurrently
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("conns", map[string]any{
+		"other:plug core:slot": map[string]any{"interface": "test", "auto": true},
+	})
+	st.Unlock()
+
+	req, err = http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("If-Match", `"`+version+`"`)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 412)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindResourceVersionMismatch)
+	c.Check(rspe.Message, check.Equals, "resource was modified concurrently: version does not match")
+	value, ok := rspe.Value.(map[string]any)
+	c.Assert(ok, check.Equals, true)
+	currentVersion, ok := value["current-version"].(string)
+	c.Assert(ok, check.Equals, true)
+	c.Check(currentVersion, check.Not(check.Equals), version)
+
+	// With the current version the change is accepted
+	req, err = http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("If-Match", `"`+currentVersion+`"`)
+	rsp = s.asyncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Change, check.Not(check.Equals), "")
+}
+
 func (s *interfacesSuite) TestConnectPlugFailureInterfaceMismatch(c *check.C) {
 	d := s.daemon(c)
 
@@ -565,6 +632,288 @@ func (s *interfacesSuite) testDisconnect(c *check.C, plugSnap, plugName, slotSna
 	c.Assert(ifaces.Connections, check.HasLen, 0)
 }
 
+func (s *interfacesSuite) TestMigrateConnections(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	d := s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+	s.mockSnap(c, producer2Yaml)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	connRef := &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}
+	_, err := repo.Connect(connRef, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("conns", map[string]any{
+		"consumer:plug producer:slot": map[string]any{
+			"interface": "test",
+		},
+	})
+	st.Unlock()
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	action := &client.InterfaceAction{
+		Action: "migrate-connections",
+		Slots:  []client.Slot{{Snap: "producer"}},
+		To:     &client.Slot{Snap: "producer2"},
+	}
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	st.Unlock()
+	c.Assert(chg, check.NotNil)
+
+	<-chg.Ready()
+
+	st.Lock()
+	c.Check(chg.Kind(), check.Equals, "migrate-connections")
+	c.Check(chg.Summary(), check.Equals, "Migrate connections of producer to producer2")
+	err = chg.Err()
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	ifaces := repo.Interfaces()
+	c.Assert(ifaces.Connections, check.HasLen, 1)
+	c.Check(ifaces.Connections[0].SlotRef, check.Equals, interfaces.SlotRef{Snap: "producer2", Name: "slot"})
+	c.Check(ifaces.Connections[0].PlugRef, check.Equals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
+}
+
+func (s *interfacesSuite) TestMigrateConnectionsErrors(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	s.daemon(c)
+
+	s.mockSnap(c, producerYaml)
+
+	for _, tc := range []struct {
+		action *client.InterfaceAction
+		err    string
+	}{{
+		action: &client.InterfaceAction{Action: "migrate-connections", Slots: []client.Slot{{Snap: "producer"}}},
+		err:    `action "migrate-connections" requires exactly one slot and a target`,
+	}, {
+		action: &client.InterfaceAction{Action: "migrate-connections", Plugs: []client.Plug{{Snap: "consumer"}}, Slots: []client.Slot{{Snap: "producer"}}, To: &client.Slot{Snap: "producer2"}},
+		err:    `cannot use plugs with action "migrate-connections"`,
+	}, {
+		action: &client.InterfaceAction{Action: "migrate-connections", Slots: []client.Slot{{Snap: "producer"}}, To: &client.Slot{}},
+		err:    `action "migrate-connections" requires the snap of the slot and of the target`,
+	}, {
+		action: &client.InterfaceAction{Action: "migrate-connections", Slots: []client.Slot{{Snap: "producer"}}, To: &client.Slot{Snap: "producer2"}},
+		err:    `snap "producer2" is not installed`,
+	}} {
+		text, err := json.Marshal(tc.action)
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, tc.err)
+	}
+}
+
+func (s *interfacesSuite) TestConnectAll(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	d := s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	action := &client.InterfaceAction{
+		Action: "connect-all",
+		Plugs:  []client.Plug{{Snap: "consumer"}},
+	}
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	st.Unlock()
+	c.Assert(chg, check.NotNil)
+
+	<-chg.Ready()
+
+	st.Lock()
+	c.Check(chg.Kind(), check.Equals, "connect-all")
+	c.Check(chg.Summary(), check.Equals, "Connect plugs of consumer")
+	var report map[string]any
+	c.Check(chg.Get("api-data", &report), check.IsNil)
+	err = chg.Err()
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(report, check.DeepEquals, map[string]any{
+		"connected": []any{
+			map[string]any{
+				"plug": map[string]any{"snap": "consumer", "plug": "plug"},
+				"slot": map[string]any{"snap": "producer", "slot": "slot"},
+			},
+		},
+	})
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	ifaces := repo.Interfaces()
+	c.Assert(ifaces.Connections, check.HasLen, 1)
+	c.Check(ifaces.Connections[0].SlotRef, check.Equals, interfaces.SlotRef{Snap: "producer", Name: "slot"})
+	c.Check(ifaces.Connections[0].PlugRef, check.Equals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
+}
+
+func (s *interfacesSuite) TestConnectAllNothingToDo(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	d := s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+	s.mockSnap(c, producer2Yaml)
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	action := &client.InterfaceAction{
+		Action:  "connect-all",
+		Plugs:   []client.Plug{{Snap: "consumer"}},
+		Pattern: "producer*:slot",
+	}
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Status(), check.Equals, state.DoneStatus)
+	c.Check(chg.Summary(), check.Equals, `Connect plugs of consumer from slots matching "producer*:slot"`)
+	var report map[string]any
+	c.Assert(chg.Get("api-data", &report), check.IsNil)
+	c.Check(report, check.DeepEquals, map[string]any{
+		"skipped": []any{
+			map[string]any{
+				"plug":   map[string]any{"snap": "consumer", "plug": "plug"},
+				"reason": "multiple candidate slots",
+				"candidates": []any{
+					map[string]any{"snap": "producer", "slot": "slot"},
+					map[string]any{"snap": "producer2", "slot": "slot"},
+				},
+			},
+		},
+	})
+}
+
+func (s *interfacesSuite) TestDisconnectAll(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	d := s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	connRef := &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}
+	_, err := repo.Connect(connRef, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("conns", map[string]any{
+		"consumer:plug producer:slot": map[string]any{
+			"interface": "test",
+		},
+	})
+	st.Unlock()
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	action := &client.InterfaceAction{
+		Action: "disconnect-all",
+		Plugs:  []client.Plug{{Snap: "consumer"}},
+	}
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	st.Unlock()
+	c.Assert(chg, check.NotNil)
+
+	<-chg.Ready()
+
+	st.Lock()
+	c.Check(chg.Kind(), check.Equals, "disconnect-all")
+	err = chg.Err()
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	c.Check(repo.Interfaces().Connections, check.HasLen, 0)
+}
+
+func (s *interfacesSuite) TestBulkInterfacesErrors(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+
+	for _, tc := range []struct {
+		action *client.InterfaceAction
+		err    string
+	}{{
+		action: &client.InterfaceAction{Action: "connect-all"},
+		err:    `action "connect-all" requires exactly one snap and no plug name`,
+	}, {
+		action: &client.InterfaceAction{Action: "connect-all", Plugs: []client.Plug{{Snap: "consumer", Name: "plug"}}},
+		err:    `action "connect-all" requires exactly one snap and no plug name`,
+	}, {
+		action: &client.InterfaceAction{Action: "disconnect-all", Plugs: []client.Plug{{Snap: "consumer"}}, Slots: []client.Slot{{Snap: "producer"}}},
+		err:    `cannot use slots with action "disconnect-all"`,
+	}, {
+		action: &client.InterfaceAction{Action: "connect-all", Plugs: []client.Plug{{Snap: "producer"}}},
+		err:    `snap "producer" is not installed`,
+	}, {
+		action: &client.InterfaceAction{Action: "connect-all", Plugs: []client.Plug{{Snap: "consumer"}}, Pattern: "["},
+		err:    `invalid slot pattern "[": syntax error in pattern`,
+	}} {
+		text, err := json.Marshal(tc.action)
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, tc.err)
+	}
+}
+
 func (s *interfacesSuite) TestDisconnectPlugSuccess(c *check.C) {
 	s.testDisconnect(c, "CONSUMER", "plug", "PRODUCER", "slot")
 }
@@ -1187,6 +1536,47 @@ func (s *interfacesSuite) TestInterfacesModern(c *check.C) {
 	})
 }
 
+func (s *interfacesSuite) TestInterfacesModernRedactsAttrsForNonRoot(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	restore = daemon.MockAttrRedactionRules(map[string][]string{
+		"test": {"key"},
+	})
+	defer restore()
+
+	s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	for _, tc := range []struct {
+		uid   int
+		value string
+	}{
+		{uid: 1000, value: "<redacted>"},
+		{uid: 0, value: "value"},
+	} {
+		req, err := http.NewRequest("GET", "/v2/interfaces?select=all&names=test&plugs=true&slots=true", nil)
+		c.Assert(err, check.IsNil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", tc.uid, dirs.SnapdSocket)
+		rec := httptest.NewRecorder()
+		s.req(c, req, nil, actionIsExpected).ServeHTTP(rec, req)
+		c.Check(rec.Code, check.Equals, 200)
+		var body map[string]any
+		err = json.Unmarshal(rec.Body.Bytes(), &body)
+		c.Assert(err, check.IsNil)
+		result := body["result"].([]any)
+		c.Assert(result, check.HasLen, 1)
+		info := result[0].(map[string]any)
+		plugs := info["plugs"].([]any)
+		c.Assert(plugs, check.HasLen, 1)
+		c.Check(plugs[0].(map[string]any)["attrs"], check.DeepEquals, map[string]any{"key": tc.value}, check.Commentf("uid %d", tc.uid))
+		slots := info["slots"].([]any)
+		c.Assert(slots, check.HasLen, 1)
+		c.Check(slots[0].(map[string]any)["attrs"], check.DeepEquals, map[string]any{"key": tc.value}, check.Commentf("uid %d", tc.uid))
+	}
+}
+
 func (s *interfacesSuite) TestInterfacesAllDefaultDocURL(c *check.C) {
 	_ = s.daemon(c)
 
@@ -0,0 +1,101 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/janitorstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+)
+
+var janitorCmd = &Command{
+	Path:        "/v2/janitor",
+	GET:         getJanitor,
+	POST:        postJanitor,
+	Actions:     []string{"scan", "clean"},
+	ReadAccess:  authenticatedAccess{},
+	WriteAccess: rootAccess{},
+}
+
+var _ = registerAPIFeature("janitor")
+
+var cleanLeftoversChangeKind = swfeats.RegisterChangeKind("clean-leftovers")
+
+// getJanitor returns the leftovers found by the last periodic scan.
+func getJanitor(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	report, err := janitorstate.CurrentReport(st)
+	if err != nil {
+		return InternalError("cannot get leftovers: %v", err)
+	}
+	if report == nil {
+		report = &janitorstate.Report{Leftovers: []*janitorstate.Leftover{}}
+	}
+	return SyncResponse(report)
+}
+
+type postJanitorData struct {
+	Action string   `json:"action"`
+	Paths  []string `json:"paths"`
+}
+
+// postJanitor scans for leftovers right away, or removes the given ones
+// once approved by the administrator.
+func postJanitor(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postJanitorData
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&data); err != nil {
+		return BadRequest("cannot decode janitor request body: %v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	switch data.Action {
+	case "scan":
+		if len(data.Paths) > 0 {
+			return BadRequest("cannot scan for leftovers: paths cannot be specified")
+		}
+		report, err := janitorstate.Scan(st)
+		if err != nil {
+			return InternalError("cannot scan for leftovers: %v", err)
+		}
+		return SyncResponse(report)
+	case "clean":
+		ts, err := janitorstate.Clean(st, data.Paths)
+		if err != nil {
+			return BadRequest("%v", err)
+		}
+		chg := newChange(r.Context(), st, cleanLeftoversChangeKind, "Remove leftovers", []*state.TaskSet{ts}, nil)
+		chg.Set("api-data", map[string]any{"paths": data.Paths})
+		ensureStateSoon(st)
+		return AsyncResponse(nil, chg.ID())
+	default:
+		return BadRequest("unknown janitor action %q", data.Action)
+	}
+}
@@ -0,0 +1,131 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/janitorstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = check.Suite(&janitorSuite{})
+
+type janitorSuite struct {
+	apiBaseSuite
+}
+
+func (s *janitorSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.AuthenticatedAccess{})
+	s.expectWriteAccess(daemon.RootAccess{})
+}
+
+func (s *janitorSuite) mkOrphanedData(c *check.C, name string) string {
+	dir := filepath.Join(dirs.SnapDataDir, name)
+	c.Assert(os.MkdirAll(dir, 0755), check.IsNil)
+	old := time.Now().AddDate(-1, 0, 0)
+	c.Assert(os.Chtimes(dir, old, old), check.IsNil)
+	return dir
+}
+
+func (s *janitorSuite) postJanitor(c *check.C, body string) *http.Request {
+	req, err := http.NewRequest("POST", "/v2/janitor", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	return req
+}
+
+func (s *janitorSuite) TestGetJanitorNoScan(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/janitor", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, &janitorstate.Report{Leftovers: []*janitorstate.Leftover{}})
+}
+
+func (s *janitorSuite) TestScanAndClean(c *check.C) {
+	soon := 0
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {
+		soon++
+	})
+	defer restore()
+
+	d := s.daemon(c)
+	dir := s.mkOrphanedData(c, "removed")
+
+	rsp := s.syncReq(c, s.postJanitor(c, `{"action": "scan"}`), nil, actionIsExpected)
+	report, ok := rsp.Result.(*janitorstate.Report)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(report.Leftovers, check.HasLen, 1)
+	c.Check(report.Leftovers[0].Kind, check.Equals, janitorstate.OrphanedData)
+	c.Check(report.Leftovers[0].Path, check.Equals, dir)
+
+	req, err := http.NewRequest("GET", "/v2/janitor", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	report, ok = rsp.Result.(*janitorstate.Report)
+	c.Assert(ok, check.Equals, true)
+	c.Check(report.Leftovers, check.HasLen, 1)
+
+	rsp = s.asyncReq(c, s.postJanitor(c, `{"action": "clean", "paths": ["`+dir+`"]}`), nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "clean-leftovers")
+	c.Assert(chg.Tasks(), check.HasLen, 1)
+	c.Check(chg.Tasks()[0].Kind(), check.Equals, "janitor-clean")
+	var data map[string]any
+	c.Assert(chg.Get("api-data", &data), check.IsNil)
+	c.Check(data, check.DeepEquals, map[string]any{"paths": []any{dir}})
+	c.Check(soon, check.Equals, 1)
+}
+
+func (s *janitorSuite) TestPostJanitorErrors(c *check.C) {
+	s.daemon(c)
+	dir := s.mkOrphanedData(c, "removed")
+
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "clean", "paths": ["` + dir + `"]}`, `cannot clean ".*/removed": not a leftover found by the last scan`},
+		{`{"action": "clean"}`, `cannot clean leftovers: no paths given`},
+		{`{"action": "scan", "paths": ["/etc"]}`, `cannot scan for leftovers: paths cannot be specified`},
+		{`{"action": "foo"}`, `unknown janitor action "foo"`},
+		{`{"action": `, `cannot decode janitor request body: .*`},
+	} {
+		rspe := s.errorReq(c, s.postJanitor(c, tc.body), nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(tc.body))
+		c.Check(rspe.Message, check.Matches, tc.err, check.Commentf(tc.body))
+	}
+}
@@ -62,6 +62,35 @@ type interfaceAction struct {
 	Forget bool       `json:"forget,omitempty"`
 	Plugs  []plugJSON `json:"plugs,omitempty"`
 	Slots  []slotJSON `json:"slots,omitempty"`
+	// To is the new slot provider for the "migrate-connections" action.
+	To *slotJSON `json:"to,omitempty"`
+	// Pattern limits the slots considered by the "connect-all" and
+	// "disconnect-all" actions to the ones whose "<snap>:<slot>" name
+	// match the glob pattern.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// bulkConnectionJSON aids in marshalling a connection made or removed by a
+// bulk interface action.
+type bulkConnectionJSON struct {
+	Plug interfaces.PlugRef `json:"plug"`
+	Slot interfaces.SlotRef `json:"slot"`
+}
+
+// skippedPlugJSON aids in marshalling a plug left alone by a bulk interface
+// action.
+type skippedPlugJSON struct {
+	Plug       interfaces.PlugRef   `json:"plug"`
+	Reason     string               `json:"reason"`
+	Candidates []interfaces.SlotRef `json:"candidates,omitempty"`
+}
+
+// bulkInterfacesReportJSON aids in marshalling the outcome of a bulk
+// interface action, it is stored as the "api-data" of the change.
+type bulkInterfacesReportJSON struct {
+	Connected    []bulkConnectionJSON `json:"connected,omitempty"`
+	Disconnected []bulkConnectionJSON `json:"disconnected,omitempty"`
+	Skipped      []skippedPlugJSON    `json:"skipped,omitempty"`
 }
 
 // connectionsJSON aids in marshalling information about a single connection
@@ -0,0 +1,84 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/snap/launchpolicy"
+)
+
+var launchPolicyCmd = &Command{
+	Path:        "/v2/launch-policy",
+	GET:         getLaunchPolicy,
+	POST:        postLaunchPolicy,
+	Actions:     []string{"set", "remove"},
+	ReadAccess:  openAccess{},
+	WriteAccess: rootAccess{},
+}
+
+var _ = registerAPIFeature("launch-policy")
+
+func getLaunchPolicy(c *Command, r *http.Request, user *auth.UserState) Response {
+	policy, err := launchpolicy.Load()
+	if err != nil {
+		return InternalError("cannot get launch policy: %v", err)
+	}
+	return SyncResponse(policy)
+}
+
+type postLaunchPolicyData struct {
+	Action string               `json:"action"`
+	Policy *launchpolicy.Policy `json:"policy,omitempty"`
+}
+
+func postLaunchPolicy(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postLaunchPolicyData
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&data); err != nil {
+		return BadRequest("cannot decode launch policy request body: %v", err)
+	}
+
+	switch data.Action {
+	case "set":
+		if data.Policy == nil {
+			return BadRequest("missing launch policy")
+		}
+		if err := data.Policy.Validate(); err != nil {
+			return BadRequest("%v", err)
+		}
+		if err := launchpolicy.Save(data.Policy); err != nil {
+			return InternalError("cannot set launch policy: %v", err)
+		}
+	case "remove":
+		if data.Policy != nil {
+			return BadRequest("unexpected launch policy for %q action", data.Action)
+		}
+		if err := launchpolicy.Save(nil); err != nil {
+			return InternalError("cannot remove launch policy: %v", err)
+		}
+	default:
+		return BadRequest("unknown launch policy action %q", data.Action)
+	}
+
+	return SyncResponse(nil)
+}
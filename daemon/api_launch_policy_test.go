@@ -0,0 +1,101 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/snap/launchpolicy"
+	"github.com/snapcore/snapd/testutil"
+)
+
+var _ = check.Suite(&launchPolicySuite{})
+
+type launchPolicySuite struct {
+	apiBaseSuite
+}
+
+func (s *launchPolicySuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.expectWriteAccess(daemon.RootAccess{})
+}
+
+func (s *launchPolicySuite) TestGetLaunchPolicyNone(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/launch-policy", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.IsNil)
+}
+
+func (s *launchPolicySuite) TestSetGetRemoveLaunchPolicy(c *check.C) {
+	s.daemon(c)
+
+	body := bytes.NewBufferString(`{"action": "set", "policy": {"mode": "enforce", "rules": [{"app": "kiosk", "groups": ["operators"]}]}}`)
+	req, err := http.NewRequest("POST", "/v2/launch-policy", body)
+	c.Assert(err, check.IsNil)
+	s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(dirs.SnapLaunchPolicyFile, testutil.FilePresent)
+
+	req, err = http.NewRequest("GET", "/v2/launch-policy", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, &launchpolicy.Policy{
+		Mode:  launchpolicy.ModeEnforce,
+		Rules: []launchpolicy.Rule{{App: "kiosk", Groups: []string{"operators"}}},
+	})
+
+	body = bytes.NewBufferString(`{"action": "remove"}`)
+	req, err = http.NewRequest("POST", "/v2/launch-policy", body)
+	c.Assert(err, check.IsNil)
+	s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(dirs.SnapLaunchPolicyFile, testutil.FileAbsent)
+}
+
+func (s *launchPolicySuite) TestPostLaunchPolicyErrors(c *check.C) {
+	s.daemon(c)
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{`, `cannot decode launch policy request body: .*`},
+		{`{"action": "set"}`, `missing launch policy`},
+		{`{"action": "set", "policy": {"mode": "maybe"}}`, `invalid launch policy mode "maybe"`},
+		{`{"action": "set", "policy": {"mode": "audit", "rules": [{"app": "Foo"}]}}`, `invalid launch policy rule for "Foo": .*`},
+		{`{"action": "remove", "policy": {"mode": "audit"}}`, `unexpected launch policy for "remove" action`},
+		{`{"action": "frobnicate"}`, `unknown launch policy action "frobnicate"`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/launch-policy", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%s", t.body))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf("%s", t.body))
+	}
+	c.Check(dirs.SnapLaunchPolicyFile, testutil.FileAbsent)
+}
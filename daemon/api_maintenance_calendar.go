@@ -0,0 +1,91 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/snapstate"
+)
+
+var maintenanceCalendarCmd = &Command{
+	Path:        "/v2/maintenance-calendar",
+	GET:         getMaintenanceCalendar,
+	POST:        postMaintenanceCalendar,
+	Actions:     []string{"set", "clear"},
+	ReadAccess:  openAccess{},
+	WriteAccess: rootAccess{},
+}
+
+var _ = registerAPIFeature("maintenance-calendar")
+
+// getMaintenanceCalendar returns the maintenance calendar auto-refreshes
+// are confined to, or null if there is none.
+func getMaintenanceCalendar(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	cal, err := snapstate.MaintenanceCalendarOf(st)
+	if err != nil {
+		return InternalError("cannot get maintenance calendar: %v", err)
+	}
+	return SyncResponse(cal)
+}
+
+type postMaintenanceCalendarData struct {
+	Action   string                         `json:"action"`
+	Calendar *snapstate.MaintenanceCalendar `json:"calendar,omitempty"`
+}
+
+func postMaintenanceCalendar(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postMaintenanceCalendarData
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&data); err != nil {
+		return BadRequest("cannot decode maintenance calendar request body: %v", err)
+	}
+
+	switch data.Action {
+	case "set":
+		if data.Calendar == nil {
+			return BadRequest("missing calendar")
+		}
+	case "clear":
+		if data.Calendar != nil {
+			return BadRequest("unexpected calendar for %q action", data.Action)
+		}
+	default:
+		return BadRequest("unknown maintenance calendar action %q", data.Action)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if err := snapstate.SetMaintenanceCalendar(st, data.Calendar); err != nil {
+		return BadRequest("cannot set maintenance calendar: %v", err)
+	}
+	// let auto-refresh pick up the new schedule
+	ensureStateSoon(st)
+
+	return SyncResponse(nil)
+}
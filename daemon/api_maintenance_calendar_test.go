@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = check.Suite(&maintenanceCalendarSuite{})
+
+type maintenanceCalendarSuite struct {
+	apiBaseSuite
+}
+
+func (s *maintenanceCalendarSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.expectWriteAccess(daemon.RootAccess{})
+}
+
+func (s *maintenanceCalendarSuite) TestSetGetClearMaintenanceCalendar(c *check.C) {
+	soon := 0
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {
+		soon++
+	})
+	defer restore()
+
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	req, err := http.NewRequest("GET", "/v2/maintenance-calendar", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.IsNil)
+
+	body := bytes.NewBufferString(`{"action": "set", "calendar": {"windows": ["tue2,02:00-04:00"], "blackouts": [{"start": "2026-12-20", "end": "2027-01-03"}]}}`)
+	req, err = http.NewRequest("POST", "/v2/maintenance-calendar", body)
+	c.Assert(err, check.IsNil)
+	s.syncReq(c, req, nil, actionIsExpected)
+
+	expected := &snapstate.MaintenanceCalendar{
+		Windows:   []string{"tue2,02:00-04:00"},
+		Blackouts: []snapstate.BlackoutPeriod{{Start: "2026-12-20", End: "2027-01-03"}},
+	}
+	st.Lock()
+	cal, err := snapstate.MaintenanceCalendarOf(st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(cal, check.DeepEquals, expected)
+	c.Check(soon, check.Equals, 1)
+
+	req, err = http.NewRequest("GET", "/v2/maintenance-calendar", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, expected)
+
+	body = bytes.NewBufferString(`{"action": "clear"}`)
+	req, err = http.NewRequest("POST", "/v2/maintenance-calendar", body)
+	c.Assert(err, check.IsNil)
+	s.syncReq(c, req, nil, actionIsExpected)
+
+	st.Lock()
+	cal, err = snapstate.MaintenanceCalendarOf(st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(cal, check.IsNil)
+	c.Check(soon, check.Equals, 2)
+}
+
+func (s *maintenanceCalendarSuite) TestPostMaintenanceCalendarErrors(c *check.C) {
+	s.daemon(c)
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{`, `cannot decode maintenance calendar request body: .*`},
+		{`{"action": "set"}`, `missing calendar`},
+		{`{"action": "clear", "calendar": {"windows": ["02:00-04:00"]}}`, `unexpected calendar for "clear" action`},
+		{`{"action": "frobnicate"}`, `unknown maintenance calendar action "frobnicate"`},
+		{`{"action": "set", "calendar": {"windows": []}}`, `cannot set maintenance calendar: maintenance calendar must have at least one window`},
+		{`{"action": "set", "calendar": {"windows": ["02:00"]}}`, `cannot set maintenance calendar: cannot use maintenance window "02:00": times must be ranges`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/maintenance-calendar", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%s", t.body))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf("%s", t.body))
+	}
+}
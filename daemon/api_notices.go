@@ -34,6 +34,7 @@ var noticeReadInterfaces = map[state.NoticeType][]string{
 	state.ChangeUpdateNotice:                 {"snap-refresh-observe"},
 	state.RefreshInhibitNotice:               {"snap-refresh-observe"},
 	state.SnapRunInhibitNotice:               {"snap-refresh-observe"},
+	state.SnapDownloadedNotice:               {"snap-refresh-observe"},
 	state.InterfacesRequestsPromptNotice:     {"snap-interfaces-requests-control"},
 	state.InterfacesRequestsRuleUpdateNotice: {"snap-interfaces-requests-control"},
 }
@@ -69,6 +69,12 @@ var (
 		ReadAccess:  interfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}},
 		WriteAccess: interfaceAuthenticatedAccess{Interfaces: []string{"snap-interfaces-requests-control"}, Polkit: polkitActionManage},
 	}
+
+	requestsMetricsCmd = &Command{
+		Path:       "/v2/interfaces/requests/metrics",
+		GET:        getPromptingMetrics,
+		ReadAccess: rootAccess{},
+	}
 )
 
 // getUserID returns the UID specified by the user-id parameter of the query,
@@ -251,6 +257,9 @@ func promptingError(err error) *apiError {
 	case errors.Is(err, prompting_errors.ErrPatchedRuleHasNoPerms):
 		apiErr.Status = 400
 		apiErr.Kind = client.ErrorKindInterfacesRequestsPatchedRuleHasNoPermissions
+	case errors.Is(err, prompting_errors.ErrNewSessionRuleNoSession):
+		apiErr.Status = 400
+		apiErr.Kind = client.ErrorKindInterfacesRequestsNoUserSession
 	case errors.Is(err, prompting_errors.ErrReplyNotMatchRequestedPath):
 		apiErr.Status = 400
 		apiErr.Kind = client.ErrorKindInterfacesRequestsReplyNotMatchRequest
@@ -351,6 +360,20 @@ func getPrompts(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(prompts)
 }
 
+// getPromptingMetrics returns metrics about the outstanding prompts of all
+// users and the prompts which expired without being answered.
+func getPromptingMetrics(c *Command, r *http.Request, user *auth.UserState) Response {
+	if !getInterfaceManager(c).AppArmorPromptingRunning() {
+		return promptingNotRunningError()
+	}
+
+	metrics, err := getInterfaceManager(c).InterfacesRequestsManager().Metrics()
+	if err != nil {
+		return promptingError(err)
+	}
+	return SyncResponse(metrics)
+}
+
 func getPrompt(c *Command, r *http.Request, user *auth.UserState) Response {
 	vars := muxVars(r)
 	id := vars["id"]
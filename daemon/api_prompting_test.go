@@ -49,6 +49,7 @@ type fakeInterfacesRequestsManager struct {
 	prompt       *requestprompts.Prompt
 	rule         *requestrules.Rule
 	satisfiedIDs []prompting.IDType
+	metrics      *requestprompts.BacklogMetrics
 	err          error
 
 	// Store most recent received values
@@ -130,6 +131,10 @@ func (m *fakeInterfacesRequestsManager) RemoveRule(userID uint32, ruleID prompti
 	return m.rule, m.err
 }
 
+func (m *fakeInterfacesRequestsManager) Metrics() (*requestprompts.BacklogMetrics, error) {
+	return m.metrics, m.err
+}
+
 type promptingSuite struct {
 	apiBaseSuite
 
@@ -550,6 +555,18 @@ func (s *promptingSuite) TestPromptingError(c *C) {
 				"type":        "error",
 			},
 		},
+		{
+			err: prompting_errors.ErrNewSessionRuleNoSession,
+			body: map[string]any{
+				"result": map[string]any{
+					"message": `cannot create rule with lifespan "session" when user session is not present`,
+					"kind":    "interfaces-requests-no-user-session",
+				},
+				"status":      "Bad Request",
+				"status-code": 400.0,
+				"type":        "error",
+			},
+		},
 		{
 			err: &prompting_errors.RequestedPathNotMatchedError{
 				Requested: "foo",
@@ -671,6 +688,35 @@ func (s *promptingSuite) TestGetPromptsHappy(c *C) {
 	c.Check(prompts, DeepEquals, s.manager.prompts)
 }
 
+func (s *promptingSuite) TestGetPromptingMetrics(c *C) {
+	s.expectReadAccess(daemon.RootAccess{})
+	s.daemon(c)
+
+	s.manager.metrics = &requestprompts.BacklogMetrics{
+		Outstanding:  2,
+		PerInterface: map[string]int{"home": 2},
+		Expired:      1,
+	}
+
+	rsp := s.makeSyncReq(c, "GET", "/v2/interfaces/requests/metrics", 0, nil)
+	metrics, ok := rsp.Result.(*requestprompts.BacklogMetrics)
+	c.Assert(ok, Equals, true)
+	c.Check(metrics, DeepEquals, s.manager.metrics)
+}
+
+func (s *promptingSuite) TestGetPromptingMetricsNotRunning(c *C) {
+	s.expectReadAccess(daemon.RootAccess{})
+	s.daemon(c)
+	s.appArmorPromptingRunning = false
+
+	req, err := http.NewRequest("GET", "/v2/interfaces/requests/metrics", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=0;socket=;"
+	rsp := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Status, Equals, 500)
+	c.Check(rsp.Message, Equals, "AppArmor Prompting is not running")
+}
+
 func (s *promptingSuite) makeSyncReq(c *C, method string, path string, uid uint32, data []byte) *daemon.RespJSON {
 	body := &bytes.Reader{}
 	if len(data) > 0 {
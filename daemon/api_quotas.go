@@ -20,6 +20,7 @@
 package daemon
 
 import (
+	"fmt"
 	"net/http"
 	"sort"
 
@@ -38,9 +39,10 @@ var (
 		Path:        "/v2/quotas",
 		GET:         getQuotaGroups,
 		POST:        postQuotaGroup,
-		Actions:     []string{"ensure", "remove"},
+		Actions:     []string{"ensure", "remove", "move"},
 		WriteAccess: rootAccess{},
 		ReadAccess:  openAccess{},
+		Throttled:   true,
 	}
 	quotaGroupInfoCmd = &Command{
 		Path:       "/v2/quotas/{group}",
@@ -50,7 +52,7 @@ var (
 )
 
 type postQuotaGroupData struct {
-	// Action can be "ensure" or "remove"
+	// Action can be "ensure", "remove" or "move"
 	Action      string             `json:"action"`
 	GroupName   string             `json:"group-name"`
 	Parent      string             `json:"parent,omitempty"`
@@ -63,10 +65,17 @@ var (
 	servicestateCreateQuota = servicestate.CreateQuota
 	servicestateUpdateQuota = servicestate.UpdateQuota
 	servicestateRemoveQuota = servicestate.RemoveQuota
+
+	servicestateMoveSnapsToQuota = servicestate.MoveSnapsToQuota
 )
 
 var quoteControlChangeKind = swfeats.RegisterChangeKind("quota-control")
 
+var (
+	_ = registerAPIFeature("quota-move")
+	_ = registerAPIFeature("quota-live-usage")
+)
+
 var getQuotaUsage = func(grp *quota.Group) (*client.QuotaValues, error) {
 	var currentUsage client.QuotaValues
 
@@ -86,9 +95,54 @@ var getQuotaUsage = func(grp *quota.Group) (*client.QuotaValues, error) {
 		currentUsage.Threads = threads
 	}
 
+	if grp.JournalLimit != nil {
+		size, err := grp.CurrentJournalUsage()
+		if err != nil {
+			return nil, err
+		}
+		currentUsage.Journal = &client.QuotaJournalValues{
+			Size: size,
+		}
+	}
+
 	return &currentUsage, nil
 }
 
+// getQuotaLiveUsage samples the resources used by the group regardless
+// of the limits that are set on it.
+var getQuotaLiveUsage = func(grp *quota.Group) (*client.QuotaUsage, error) {
+	mem, err := grp.CurrentMemoryUsage()
+	if err != nil {
+		return nil, err
+	}
+	cpu, err := grp.CurrentCPUUsage()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := grp.CurrentTaskUsage()
+	if err != nil {
+		return nil, err
+	}
+	return &client.QuotaUsage{
+		Memory:  mem,
+		CPUTime: cpu,
+		Tasks:   tasks,
+	}, nil
+}
+
+// wantsLiveUsage returns whether the request asked for the live resource
+// usage of the groups to be included.
+func wantsLiveUsage(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("usage") {
+	case "":
+		return false, nil
+	case "live":
+		return true, nil
+	default:
+		return false, fmt.Errorf(`invalid "usage" parameter`)
+	}
+}
+
 func createQuotaValues(grp *quota.Group) *client.QuotaValues {
 	var constraints client.QuotaValues
 	constraints.Memory = grp.MemoryLimit
@@ -119,6 +173,11 @@ func createQuotaValues(grp *quota.Group) *client.QuotaValues {
 
 // getQuotaGroups returns all quota groups sorted by name.
 func getQuotaGroups(c *Command, r *http.Request, _ *auth.UserState) Response {
+	liveUsage, err := wantsLiveUsage(r)
+	if err != nil {
+		return BadRequest("cannot list quota groups: %v", err)
+	}
+
 	st := c.d.overlord.State()
 	st.Lock()
 	defer st.Unlock()
@@ -154,6 +213,16 @@ func getQuotaGroups(c *Command, r *http.Request, _ *auth.UserState) Response {
 			Constraints: createQuotaValues(group),
 			Current:     currentUsage,
 		}
+		results[i].Assignments, err = servicestate.QuotaGroupAssignments(st, group)
+		if err != nil {
+			return InternalError(err.Error())
+		}
+		if liveUsage {
+			results[i].Usage, err = getQuotaLiveUsage(group)
+			if err != nil {
+				return InternalError(err.Error())
+			}
+		}
 	}
 	return SyncResponse(results)
 }
@@ -165,6 +234,10 @@ func getQuotaGroupInfo(c *Command, r *http.Request, _ *auth.UserState) Response
 	if err := naming.ValidateQuotaGroup(groupName); err != nil {
 		return BadRequest(err.Error())
 	}
+	liveUsage, err := wantsLiveUsage(r)
+	if err != nil {
+		return BadRequest("cannot get quota group %q: %v", groupName, err)
+	}
 
 	st := c.d.overlord.State()
 	st.Lock()
@@ -183,6 +256,11 @@ func getQuotaGroupInfo(c *Command, r *http.Request, _ *auth.UserState) Response
 		return InternalError(err.Error())
 	}
 
+	version, err := quotaGroupVersion(group)
+	if err != nil {
+		return InternalError(err.Error())
+	}
+
 	res := client.QuotaGroupResult{
 		GroupName:   group.Name,
 		Parent:      group.ParentGroup,
@@ -192,7 +270,17 @@ func getQuotaGroupInfo(c *Command, r *http.Request, _ *auth.UserState) Response
 		Constraints: createQuotaValues(group),
 		Current:     currentUsage,
 	}
-	return SyncResponse(res)
+	res.Assignments, err = servicestate.QuotaGroupAssignments(st, group)
+	if err != nil {
+		return InternalError(err.Error())
+	}
+	if liveUsage {
+		res.Usage, err = getQuotaLiveUsage(group)
+		if err != nil {
+			return InternalError(err.Error())
+		}
+	}
+	return syncResponseWithVersion(res, version)
 }
 
 func quotaValuesToResources(values client.QuotaValues) quota.Resources {
@@ -242,6 +330,20 @@ func postQuotaGroup(c *Command, r *http.Request, _ *auth.UserState) Response {
 	st.Lock()
 	defer st.Unlock()
 
+	if r.Header.Get("If-Match") != "" {
+		group, err := servicestate.GetQuota(st, data.GroupName)
+		if err != nil && err != servicestate.ErrQuotaNotFound {
+			return InternalError(err.Error())
+		}
+		version, err := quotaGroupVersion(group)
+		if err != nil {
+			return InternalError(err.Error())
+		}
+		if rspe := checkIfMatch(st, r, quotaGroupResource(data.GroupName), version); rspe != nil {
+			return rspe
+		}
+	}
+
 	chgSummary := ""
 
 	var ts *state.TaskSet
@@ -290,11 +392,23 @@ func postQuotaGroup(c *Command, r *http.Request, _ *auth.UserState) Response {
 			return errToResponse(err, nil, BadRequest, "cannot remove quota group: %v")
 		}
 		chgSummary = "Remove quota group"
+
+	case "move":
+		if len(data.Services) != 0 || data.Parent != "" || data.Constraints != (client.QuotaValues{}) {
+			return BadRequest("cannot move snaps to quota group: only snaps can be specified")
+		}
+		var err error
+		ts, err = servicestateMoveSnapsToQuota(st, data.GroupName, data.Snaps)
+		if err != nil {
+			return errToResponse(err, data.Snaps, BadRequest, "cannot move snaps to quota group: %v")
+		}
+		chgSummary = "Move snaps to quota group"
 	default:
 		return BadRequest("unknown quota action %q", data.Action)
 	}
 
-	chg := newChange(st, quoteControlChangeKind, chgSummary, []*state.TaskSet{ts}, data.Snaps)
+	chg := newChange(r.Context(), st, quoteControlChangeKind, chgSummary, []*state.TaskSet{ts}, data.Snaps)
+	markResourceChange(chg, quotaGroupResource(data.GroupName))
 	ensureStateSoon(st)
 	return AsyncResponse(nil, chg.ID())
 }
@@ -25,12 +25,15 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"time"
 
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
@@ -846,6 +849,102 @@ func (s *apiQuotaSuite) TestGetQuota(c *check.C) {
 	c.Check(s.ensureSoonCalled, check.Equals, 0)
 }
 
+func (s *apiQuotaSuite) TestGetQuotaDefaultAssignments(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	mockQuotas(st, c)
+	st.Set("default-quota-assignments", map[string]string{
+		"test-snap": "foo",
+		// stale entry for a snap no longer in the group
+		"other-snap": "foo",
+	})
+	st.Unlock()
+
+	r := daemon.MockGetQuotaUsage(func(grp *quota.Group) (*client.QuotaValues, error) {
+		return &client.QuotaValues{}, nil
+	})
+	defer r()
+
+	req, err := http.NewRequest("GET", "/v2/quotas/foo", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, check.Equals, 200)
+	res := rsp.Result.(client.QuotaGroupResult)
+	c.Check(res.Snaps, check.DeepEquals, []string{"test-snap"})
+	c.Check(res.Assignments, check.DeepEquals, map[string]string{"test-snap": "gadget"})
+
+	req, err = http.NewRequest("GET", "/v2/quotas/bar", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, check.Equals, 200)
+	res = rsp.Result.(client.QuotaGroupResult)
+	c.Check(res.Assignments, check.IsNil)
+}
+
+func (s *apiQuotaSuite) TestPostQuotaIfMatch(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	mockQuotas(st, c)
+	st.Unlock()
+
+	r := daemon.MockGetQuotaUsage(func(grp *quota.Group) (*client.QuotaValues, error) {
+		return &client.QuotaValues{}, nil
+	})
+	defer r()
+
+	r = daemon.MockServicestateUpdateQuota(func(st *state.State, name string, opts servicestate.UpdateQuotaOptions) (*state.TaskSet, error) {
+		return state.NewTaskSet(st.NewTask("foo-quota", "...")), nil
+	})
+	defer r()
+
+	req, err := http.NewRequest("GET", "/v2/quotas/bar", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	version := rsp.ResourceVersion
+	c.Assert(version, check.Not(check.Equals), "")
+
+	for _, tc := range []struct {
+		group   string
+		ifMatch string
+		status  int
+	}{
+		{"bar", `"` + version + `"`, 202},
+		{"bar", `"other", "` + version + `"`, 202},
+		{"bar", "*", 202},
+		{"bar", `"other"`, 412},
+		// the group does not exist
+		{"unknown", "*", 412},
+		{"unknown", `"` + version + `"`, 412},
+	} {
+		data, err := json.Marshal(daemon.PostQuotaGroupData{
+			Action:    "ensure",
+			GroupName: tc.group,
+		})
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+		c.Assert(err, check.IsNil)
+		req.Header.Set("If-Match", tc.ifMatch)
+		if tc.status == 202 {
+			rsp := s.asyncReq(c, req, nil, actionIsExpected)
+			// the group cannot be modified conditionally again until
+			// the change is ready
+			req, err = http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+			c.Assert(err, check.IsNil)
+			req.Header.Set("If-Match", tc.ifMatch)
+			rspe := s.errorReq(c, req, nil, actionIsExpected)
+			c.Check(rspe.Status, check.Equals, 412)
+			c.Check(rspe.Message, check.Equals, "resource is being modified by change "+rsp.Change)
+			st.Lock()
+			st.Change(rsp.Change).SetStatus(state.DoneStatus)
+			st.Unlock()
+			continue
+		}
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, tc.status, check.Commentf("%s %s", tc.group, tc.ifMatch))
+		c.Check(rspe.Kind, check.Equals, client.ErrorKindResourceVersionMismatch)
+	}
+}
+
 func (s *apiQuotaSuite) TestGetQuotaInvalidName(c *check.C) {
 	st := s.d.Overlord().State()
 	st.Lock()
@@ -868,3 +967,132 @@ func (s *apiQuotaSuite) TestGetQuotaNotFound(c *check.C) {
 	c.Check(rspe.Message, check.Matches, `cannot find quota group "unknown"`)
 	c.Check(s.ensureSoonCalled, check.Equals, 0)
 }
+
+func (s *apiQuotaSuite) TestListQuotasLiveUsage(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	mockQuotas(st, c)
+	st.Unlock()
+
+	r := daemon.MockGetQuotaUsage(func(grp *quota.Group) (*client.QuotaValues, error) {
+		return &client.QuotaValues{Memory: quantity.Size(500)}, nil
+	})
+	defer r()
+	var sampled []string
+	r = daemon.MockGetQuotaLiveUsage(func(grp *quota.Group) (*client.QuotaUsage, error) {
+		sampled = append(sampled, grp.Name)
+		return &client.QuotaUsage{
+			Memory:  quantity.Size(500),
+			CPUTime: time.Second,
+			Tasks:   len(sampled),
+		}, nil
+	})
+	defer r()
+
+	req, err := http.NewRequest("GET", "/v2/quotas?usage=live", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	res := rsp.Result.([]client.QuotaGroupResult)
+	c.Assert(res, check.HasLen, 3)
+	c.Check(sampled, check.DeepEquals, []string{"bar", "baz", "foo"})
+	for i, grp := range res {
+		c.Check(grp.Usage, check.DeepEquals, &client.QuotaUsage{
+			Memory:  quantity.Size(500),
+			CPUTime: time.Second,
+			Tasks:   i + 1,
+		})
+	}
+
+	// usage is only sampled when asked for
+	sampled = nil
+	req, err = http.NewRequest("GET", "/v2/quotas/bar", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result.(client.QuotaGroupResult).Usage, check.IsNil)
+	c.Check(sampled, check.HasLen, 0)
+
+	req, err = http.NewRequest("GET", "/v2/quotas/bar?usage=live", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result.(client.QuotaGroupResult).Usage, check.NotNil)
+	c.Check(sampled, check.DeepEquals, []string{"bar"})
+
+	req, err = http.NewRequest("GET", "/v2/quotas/bar?usage=maybe", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Message, check.Equals, `cannot get quota group "bar": invalid "usage" parameter`)
+}
+
+func (s *apiQuotaSuite) TestPostMoveSnapsToQuota(c *check.C) {
+	var moveCalled int
+	r := daemon.MockServicestateMoveSnapsToQuota(func(st *state.State, name string, snaps []string) (*state.TaskSet, error) {
+		moveCalled++
+		c.Check(name, check.Equals, "booze")
+		c.Check(snaps, check.DeepEquals, []string{"some-snap"})
+		ts := state.NewTaskSet(st.NewTask("foo-quota", "..."))
+		return ts, nil
+	})
+	defer r()
+
+	data, err := json.Marshal(daemon.PostQuotaGroupData{
+		Action:    "move",
+		GroupName: "booze",
+		Snaps:     []string{"some-snap"},
+	})
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, check.Equals, 202)
+	c.Check(moveCalled, check.Equals, 1)
+	c.Check(s.ensureSoonCalled, check.Equals, 1)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	st.Unlock()
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Summary(), check.Equals, "Move snaps to quota group")
+}
+
+func (s *apiQuotaSuite) TestPostMoveSnapsToQuotaUnhappy(c *check.C) {
+	r := daemon.MockServicestateMoveSnapsToQuota(func(st *state.State, name string, snaps []string) (*state.TaskSet, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	defer r()
+
+	for _, t := range []struct {
+		data daemon.PostQuotaGroupData
+		err  string
+	}{{
+		data: daemon.PostQuotaGroupData{Action: "move", GroupName: "booze", Snaps: []string{"some-snap"}, Parent: "foo"},
+		err:  `cannot move snaps to quota group: only snaps can be specified`,
+	}, {
+		data: daemon.PostQuotaGroupData{Action: "move", GroupName: "booze", Snaps: []string{"some-snap"}},
+		err:  `cannot move snaps to quota group: boom`,
+	}} {
+		data, err := json.Marshal(t.data)
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, t.err)
+	}
+}
+
+func (s *apiQuotaSuite) TestQuotaUsageJournal(c *check.C) {
+	grp, err := quota.NewGroup("foo", quota.NewResourcesBuilder().WithJournalSize(64*quantity.SizeMiB).Build())
+	c.Assert(err, check.IsNil)
+
+	journal := filepath.Join(dirs.GlobalRootDir, "/var/log/journal/1234.snap-foo/system.journal")
+	c.Assert(os.MkdirAll(filepath.Dir(journal), 0755), check.IsNil)
+	c.Assert(os.WriteFile(journal, make([]byte, 4096), 0644), check.IsNil)
+
+	usage, err := daemon.GetQuotaUsage(grp)
+	c.Assert(err, check.IsNil)
+	c.Check(usage, check.DeepEquals, &client.QuotaValues{
+		Journal: &client.QuotaJournalValues{Size: 4 * quantity.SizeKiB},
+	})
+}
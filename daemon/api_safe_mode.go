@@ -0,0 +1,344 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/client/clientutil"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var safeModeCmd = &Command{
+	Path:        "/v2/safe-mode",
+	GET:         getSafeMode,
+	POST:        postSafeMode,
+	Actions:     []string{"enter", "leave"},
+	ReadAccess:  authenticatedAccess{},
+	WriteAccess: rootAccess{},
+}
+
+var _ = registerAPIFeature("safe-mode")
+
+var (
+	enterSafeModeChangeKind = swfeats.RegisterChangeKind("enter-safe-mode")
+	leaveSafeModeChangeKind = swfeats.RegisterChangeKind("leave-safe-mode")
+)
+
+// defaultSafeModeInterfaces are the interfaces whose connections are
+// dropped when entering safe mode, unless configured otherwise with
+// safe-mode.disconnect-interfaces.
+var defaultSafeModeInterfaces = []string{"network", "network-bind"}
+
+// safeModeReport records what entering safe mode did, so that it can be
+// reported and reverted when leaving safe mode.
+type safeModeReport = devicestate.SafeModeState
+
+func currentSafeModeReport(st *state.State) (*safeModeReport, error) {
+	return devicestate.SafeMode(st)
+}
+
+// safeModeChangeInProgress returns whether a change entering or leaving
+// safe mode is still in progress.
+func safeModeChangeInProgress(st *state.State) bool {
+	for _, chg := range st.Changes() {
+		if chg.IsReady() {
+			continue
+		}
+		switch chg.Kind() {
+		case enterSafeModeChangeKind, leaveSafeModeChangeKind:
+			return true
+		}
+	}
+	return false
+}
+
+func safeModeInterfaces(st *state.State) ([]string, error) {
+	tr := config.NewTransaction(st)
+	var ifaces string
+	if err := tr.Get("core", "safe-mode.disconnect-interfaces", &ifaces); err != nil {
+		if config.IsNoOption(err) {
+			return defaultSafeModeInterfaces, nil
+		}
+		return nil, err
+	}
+	return strutil.CommaSeparatedList(ifaces), nil
+}
+
+// getSafeMode reports whether safe mode is active and what entering it
+// did.
+func getSafeMode(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	report, err := currentSafeModeReport(st)
+	if err != nil {
+		return InternalError("cannot get safe mode state: %v", err)
+	}
+	return SyncResponse(report)
+}
+
+type postSafeModeData struct {
+	Action string `json:"action"`
+}
+
+func postSafeMode(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postSafeModeData
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&data); err != nil {
+		return BadRequest("cannot decode safe mode request body: %v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	report, err := currentSafeModeReport(st)
+	if err != nil {
+		return InternalError("cannot get safe mode state: %v", err)
+	}
+
+	if safeModeChangeInProgress(st) {
+		return BadRequest("safe mode is being entered or left")
+	}
+
+	switch data.Action {
+	case "enter":
+		if report.Active {
+			return BadRequest("safe mode is already active")
+		}
+		return enterSafeMode(r.Context(), c, st)
+	case "leave":
+		if !report.Active {
+			return BadRequest("safe mode is not active")
+		}
+		return leaveSafeMode(r.Context(), c, st, report)
+	default:
+		return BadRequest("unknown safe mode action %q", data.Action)
+	}
+}
+
+// enterSafeMode stops and disables the enabled or running system services
+// of application snaps, drops the connections of the configured network
+// facing interfaces and holds the refreshes of all snaps. Safe mode is
+// reported as active once the services are stopped and the connections
+// dropped.
+func enterSafeMode(ctx context.Context, c *Command, st *state.State) Response {
+	ifaces, err := safeModeInterfaces(st)
+	if err != nil {
+		return InternalError("cannot get safe mode interfaces: %v", err)
+	}
+
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return InternalError("cannot get installed snaps: %v", err)
+	}
+	held, err := snapstate.HeldSnaps(st, snapstate.HoldGeneral)
+	if err != nil {
+		return InternalError("cannot get held snaps: %v", err)
+	}
+
+	report := &safeModeReport{Active: true}
+	var candidates, services []*snap.AppInfo
+	for name, snapst := range snapStates {
+		if !strutil.ListContains(held[name], "system") {
+			report.HeldSnaps = append(report.HeldSnaps, name)
+		}
+		if !snapst.Active {
+			continue
+		}
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			return InternalError("cannot get information about snap %q: %v", name, err)
+		}
+		// only application snaps are not essential to the system
+		if info.Type() != snap.TypeApp {
+			continue
+		}
+		for _, app := range info.Services() {
+			if app.DaemonScope == snap.SystemDaemon {
+				candidates = append(candidates, app)
+			}
+		}
+	}
+	// only the services that are enabled or running are stopped, so that
+	// leaving safe mode does not enable or start any others
+	appInfos, err := clientutil.ClientAppInfosFromSnapAppInfos(candidates, newStatusDecorator(ctx, true, ""))
+	if err != nil {
+		return InternalError("cannot get status of services: %v", err)
+	}
+	for i, appInfo := range appInfos {
+		if !appInfo.Enabled && !appInfo.Active {
+			continue
+		}
+		services = append(services, candidates[i])
+		report.StoppedServices = append(report.StoppedServices, appInfo.Snap+"."+appInfo.Name)
+	}
+	sort.Strings(report.HeldSnaps)
+	sort.Strings(report.StoppedServices)
+
+	var tasksets []*state.TaskSet
+	if len(services) > 0 {
+		// disable the services too, so that they are not started again
+		// when the system reboots while in safe mode
+		inst := &servicestate.Instruction{
+			Action:      "stop",
+			Names:       report.StoppedServices,
+			Scope:       client.ScopeSelector{"system"},
+			StopOptions: client.StopOptions{Disable: true},
+		}
+		tss, err := servicestateControl(st, services, inst, nil, nil, nil)
+		if err != nil {
+			return errToResponse(err, nil, InternalError, "cannot stop services: %v")
+		}
+		tasksets = append(tasksets, tss...)
+	}
+
+	connStates, err := ifacestate.ConnectionStates(st)
+	if err != nil {
+		return InternalError("cannot get connections: %v", err)
+	}
+	for id, connState := range connStates {
+		if connState.Active() && strutil.ListContains(ifaces, connState.Interface) {
+			report.Disconnected = append(report.Disconnected, id)
+		}
+	}
+	sort.Strings(report.Disconnected)
+	repo := c.d.overlord.InterfaceManager().Repository()
+	for _, id := range report.Disconnected {
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return InternalError("%v", err)
+		}
+		conn, err := repo.Connection(connRef)
+		if err != nil {
+			return InternalError("cannot disconnect %s: %v", id, err)
+		}
+		ts, err := ifacestate.Disconnect(st, conn)
+		if err != nil {
+			return errToResponse(err, nil, InternalError, "cannot disconnect: %v")
+		}
+		ts.JoinLane(st.NewLane())
+		tasksets = append(tasksets, ts)
+	}
+
+	// the refreshes are held by the task recording the safe mode state,
+	// so that they are not held if stopping or disconnecting fails
+	setSafeMode := devicestate.SetSafeModeTask(st, report)
+	for _, ts := range tasksets {
+		setSafeMode.WaitAll(ts)
+	}
+	tasksets = append(tasksets, state.NewTaskSet(setSafeMode))
+	chg := newChange(ctx, st, enterSafeModeChangeKind, "Enter safe mode", tasksets, nil)
+	st.EnsureBefore(0)
+
+	logger.Noticef("Entering safe mode: stopping %d services, dropping %d connections, holding refreshes of %d snaps",
+		len(report.StoppedServices), len(report.Disconnected), len(report.HeldSnaps))
+
+	return AsyncResponse(nil, chg.ID())
+}
+
+// leaveSafeMode reverts what entering safe mode did, as far as the snaps
+// involved are still installed.
+func leaveSafeMode(ctx context.Context, c *Command, st *state.State, report *safeModeReport) Response {
+	var tasksets []*state.TaskSet
+
+	var services []*snap.AppInfo
+	var names []string
+	for _, name := range report.StoppedServices {
+		snapName, appName := snap.SplitSnapApp(name)
+		info, err := snapstate.CurrentInfo(st, snapName)
+		if err != nil {
+			logger.Noticef("Cannot start service %s when leaving safe mode: %v", name, err)
+			continue
+		}
+		app, ok := info.Apps[appName]
+		if !ok || !app.IsService() {
+			continue
+		}
+		services = append(services, app)
+		names = append(names, name)
+	}
+	if len(services) > 0 {
+		inst := &servicestate.Instruction{
+			Action:       "start",
+			Names:        names,
+			Scope:        client.ScopeSelector{"system"},
+			StartOptions: client.StartOptions{Enable: true},
+		}
+		tss, err := servicestateControl(st, services, inst, nil, nil, nil)
+		if err != nil {
+			return errToResponse(err, nil, InternalError, "cannot start services: %v")
+		}
+		tasksets = append(tasksets, tss...)
+	}
+
+	for _, id := range report.Disconnected {
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return InternalError("%v", err)
+		}
+		ts, err := ifacestate.Connect(st, connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
+		if err != nil {
+			if _, ok := err.(*ifacestate.ErrAlreadyConnected); ok {
+				continue
+			}
+			var conflictErr *snapstate.ChangeConflictError
+			if errors.As(err, &conflictErr) {
+				return errToResponse(err, nil, InternalError, "%v")
+			}
+			logger.Noticef("Cannot reconnect %s when leaving safe mode: %v", id, err)
+			continue
+		}
+		ts.JoinLane(st.NewLane())
+		tasksets = append(tasksets, ts)
+	}
+
+	// the refresh holds are removed by the task recording that safe mode
+	// was left
+	setSafeMode := devicestate.SetSafeModeTask(st, &safeModeReport{Active: false})
+	for _, ts := range tasksets {
+		setSafeMode.WaitAll(ts)
+	}
+	tasksets = append(tasksets, state.NewTaskSet(setSafeMode))
+	chg := newChange(ctx, st, leaveSafeModeChangeKind, "Leave safe mode", tasksets, nil)
+	st.EnsureBefore(0)
+
+	logger.Noticef("Leaving safe mode")
+
+	return AsyncResponse(nil, chg.ID())
+}
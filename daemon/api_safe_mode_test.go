@@ -0,0 +1,230 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os/user"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/client/clientutil"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = check.Suite(&safeModeSuite{})
+
+type safeModeSuite struct {
+	apiBaseSuite
+
+	serviceInsts []*servicestate.Instruction
+	// services are the enabled or active services
+	services map[string]bool
+}
+
+func (s *safeModeSuite) DecorateWithStatus(appInfo *client.AppInfo, snapApp *snap.AppInfo) error {
+	name := snapApp.Snap.InstanceName() + "." + snapApp.Name
+	appInfo.Enabled = s.services[name]
+	appInfo.Active = s.services[name]
+	return nil
+}
+
+func (s *safeModeSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.AuthenticatedAccess{})
+	s.expectWriteAccess(daemon.RootAccess{})
+
+	s.serviceInsts = nil
+	s.services = nil
+
+	s.AddCleanup(daemon.MockServicestateControl(func(st *state.State, appInfos []*snap.AppInfo, inst *servicestate.Instruction, cu *user.User, flags *servicestate.Flags, context *hookstate.Context) ([]*state.TaskSet, error) {
+		c.Check(cu, check.IsNil)
+		c.Check(appInfos, check.HasLen, len(inst.Names))
+		s.serviceInsts = append(s.serviceInsts, inst)
+		t := st.NewTask("service-control", "...")
+		return []*state.TaskSet{state.NewTaskSet(t)}, nil
+	}))
+	s.AddCleanup(daemon.MockNewStatusDecorator(func(ctx context.Context, isGlobal bool, uid string) clientutil.StatusDecorator {
+		c.Check(isGlobal, check.Equals, true)
+		return s
+	}))
+}
+
+func (s *safeModeSuite) daemonWithLoop(c *check.C) *daemon.Daemon {
+	d := s.daemon(c)
+	d.Overlord().Loop()
+	s.AddCleanup(func() { d.Overlord().Stop() })
+	return d
+}
+
+func (s *safeModeSuite) postSafeMode(c *check.C, action string) *daemon.RespJSON {
+	body := bytes.NewBufferString(`{"action": "` + action + `"}`)
+	req, err := http.NewRequest("POST", "/v2/safe-mode", body)
+	c.Assert(err, check.IsNil)
+	return s.asyncReq(c, req, nil, actionIsExpected)
+}
+
+func (s *safeModeSuite) getSafeMode(c *check.C) *daemon.SafeModeReport {
+	req, err := http.NewRequest("GET", "/v2/safe-mode", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	report, ok := rsp.Result.(*daemon.SafeModeReport)
+	c.Assert(ok, check.Equals, true)
+	return report
+}
+
+func (s *safeModeSuite) TestEnterLeaveSafeMode(c *check.C) {
+	d := s.daemonWithLoop(c)
+	s.mkInstalledInState(c, d, "core", "", "v1", snap.R(1), true, "type: os")
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "apps: {svc1: {daemon: simple}, svc2: {daemon: simple, daemon-scope: user}, cmd: {}}")
+	s.mkInstalledInState(c, d, "bar", "", "v1", snap.R(1), true, "apps: {svc: {daemon: simple}, disabled: {daemon: simple}}")
+	s.mkInstalledInState(c, d, "inactive", "", "v1", snap.R(1), false, "apps: {svc: {daemon: simple}}")
+	s.services = map[string]bool{
+		"foo.svc1": true,
+		"bar.svc":  true,
+	}
+
+	c.Check(s.getSafeMode(c).Active, check.Equals, false)
+
+	rsp := s.postSafeMode(c, "enter")
+
+	c.Assert(s.serviceInsts, check.HasLen, 1)
+	c.Check(s.serviceInsts[0].Action, check.Equals, "stop")
+	c.Check(s.serviceInsts[0].Disable, check.Equals, true)
+	c.Check(s.serviceInsts[0].Names, check.DeepEquals, []string{"bar.svc", "foo.svc1"})
+	c.Check(s.serviceInsts[0].Scope, check.DeepEquals, client.ScopeSelector{"system"})
+
+	st := d.Overlord().State()
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "enter-safe-mode")
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	c.Check(tasks[0].Kind(), check.Equals, "service-control")
+	// safe mode is recorded as active once the services are stopped
+	c.Check(tasks[1].Kind(), check.Equals, "set-safe-mode")
+	c.Check(tasks[1].WaitTasks(), check.DeepEquals, []*state.Task{tasks[0]})
+	var report daemon.SafeModeReport
+	c.Assert(tasks[1].Get("safe-mode", &report), check.IsNil)
+	c.Check(report.Active, check.Equals, true)
+	c.Check(report.StoppedServices, check.DeepEquals, []string{"bar.svc", "foo.svc1"})
+	c.Check(report.HeldSnaps, check.DeepEquals, []string{"bar", "core", "foo", "inactive"})
+	c.Check(report.Disconnected, check.HasLen, 0)
+	st.Unlock()
+
+	c.Check(s.getSafeMode(c).Active, check.Equals, false)
+
+	// entering or leaving again is refused while the change is in progress
+	req, err := http.NewRequest("POST", "/v2/safe-mode", bytes.NewBufferString(`{"action": "leave"}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `safe mode is being entered or left`)
+
+	// pretend the change ran
+	st.Lock()
+	for _, t := range tasks {
+		t.SetStatus(state.DoneStatus)
+	}
+	st.Set("safe-mode", &report)
+	st.Unlock()
+
+	c.Check(s.getSafeMode(c).Active, check.Equals, true)
+
+	rsp = s.postSafeMode(c, "leave")
+
+	c.Assert(s.serviceInsts, check.HasLen, 2)
+	c.Check(s.serviceInsts[1].Action, check.Equals, "start")
+	c.Check(s.serviceInsts[1].Enable, check.Equals, true)
+	c.Check(s.serviceInsts[1].Names, check.DeepEquals, []string{"bar.svc", "foo.svc1"})
+
+	st.Lock()
+	chg = st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "leave-safe-mode")
+	tasks = chg.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	c.Check(tasks[1].Kind(), check.Equals, "set-safe-mode")
+	c.Assert(tasks[1].Get("safe-mode", &report), check.IsNil)
+	c.Check(report.Active, check.Equals, false)
+	st.Unlock()
+}
+
+func (s *safeModeSuite) TestEnterSafeModeNothingToDo(c *check.C) {
+	d := s.daemonWithLoop(c)
+	st := d.Overlord().State()
+
+	st.Lock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "safe-mode.disconnect-interfaces", ""), check.IsNil)
+	tr.Commit()
+	st.Unlock()
+
+	rsp := s.postSafeMode(c, "enter")
+	c.Check(s.serviceInsts, check.HasLen, 0)
+
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "set-safe-mode")
+	st.Unlock()
+}
+
+func (s *safeModeSuite) TestPostSafeModeErrors(c *check.C) {
+	d := s.daemon(c)
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{`, `cannot decode safe mode request body: .*`},
+		{`{"action": "frobnicate"}`, `unknown safe mode action "frobnicate"`},
+		{`{"action": "leave"}`, `safe mode is not active`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/safe-mode", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%s", t.body))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf("%s", t.body))
+	}
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("safe-mode", &daemon.SafeModeReport{Active: true})
+	st.Unlock()
+
+	req, err := http.NewRequest("POST", "/v2/safe-mode", bytes.NewBufferString(`{"action": "enter"}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `safe mode is already active`)
+}
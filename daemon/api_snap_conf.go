@@ -42,6 +42,7 @@ var (
 		PUT:         setSnapConf,
 		ReadAccess:  authenticatedAccess{Polkit: polkitActionManageConfiguration},
 		WriteAccess: authenticatedAccess{Polkit: polkitActionManageConfiguration},
+		Throttled:   true,
 	}
 )
 
@@ -56,7 +57,11 @@ func getSnapConf(c *Command, r *http.Request, user *auth.UserState) Response {
 	s := c.d.overlord.State()
 	s.Lock()
 	tr := config.NewTransaction(s)
+	version, err := snapConfVersion(s, snapName)
 	s.Unlock()
+	if err != nil {
+		return InternalError("%v", err)
+	}
 
 	currentConfValues := make(map[string]any)
 	// Special case - return root document
@@ -92,13 +97,13 @@ func getSnapConf(c *Command, r *http.Request, user *auth.UserState) Response {
 			if len(keys) > 1 {
 				return BadRequest("keys contains zero-length string")
 			}
-			return SyncResponse(value)
+			return syncResponseWithVersion(value, version)
 		}
 
 		currentConfValues[key] = value
 	}
 
-	return SyncResponse(currentConfValues)
+	return syncResponseWithVersion(currentConfValues, version)
 }
 
 // pruneExperimentalFlags returns a copy of val with unsupported experimental
@@ -158,6 +163,14 @@ func setSnapConf(c *Command, r *http.Request, user *auth.UserState) Response {
 	st.Lock()
 	defer st.Unlock()
 
+	version, err := snapConfVersion(st, snapName)
+	if err != nil {
+		return InternalError("%v", err)
+	}
+	if rspe := checkIfMatch(st, r, snapConfResource(snapName), version); rspe != nil {
+		return rspe
+	}
+
 	taskset, err := configstate.ConfigureInstalled(st, snapName, patchValues, 0)
 	if err != nil {
 		// TODO: just return snap-not-installed instead ?
@@ -168,9 +181,10 @@ func setSnapConf(c *Command, r *http.Request, user *auth.UserState) Response {
 	}
 
 	summary := fmt.Sprintf("Change configuration of %q snap", snapName)
-	change := newChange(st, configureSnapChangeKind, summary, []*state.TaskSet{taskset}, []string{snapName})
+	change := newChange(r.Context(), st, configureSnapChangeKind, summary, []*state.TaskSet{taskset}, []string{snapName})
+	markResourceChange(change, snapConfResource(snapName))
 
-	st.EnsureBefore(0)
+	ensureStateSoon(st)
 
 	return AsyncResponse(nil, change.ID())
 }
@@ -30,10 +30,12 @@ import (
 
 	"gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -401,3 +403,80 @@ func (s *snapConfSuite) TestSetConfChangeConflict(c *check.C) {
 		},
 		"type": "error"})
 }
+
+func (s *snapConfSuite) TestSetConfIfMatch(c *check.C) {
+	// the configure changes are kept in progress
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {})
+	defer restore()
+
+	d := s.daemon(c)
+	s.mockSnap(c, configYaml)
+
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("config-snap", "key", "value")
+	tr.Commit()
+	st.Unlock()
+
+	// GET returns the version of the whole configuration of the snap,
+	// regardless of the requested keys
+	req, err := http.NewRequest("GET", "/v2/snaps/config-snap/conf?keys=key", nil)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil, actionIsExpected).ServeHTTP(rec, req)
+	c.Assert(rec.Code, check.Equals, 200)
+	var body map[string]any
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), check.IsNil)
+	version, ok := body["resource-version"].(string)
+	c.Assert(ok, check.Equals, true)
+	c.Check(rec.Header().Get("ETag"), check.Equals, `"`+version+`"`)
+
+	req, err = http.NewRequest("GET", "/v2/snaps/config-snap/conf", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.ResourceVersion, check.Equals, version)
+
+	// Configuration changes concurrently
+	st.Lock()
+	tr = config.NewTransaction(st)
+	tr.Set("config-snap", "other-key", "other-value")
+	tr.Commit()
+	st.Unlock()
+
+	req, err = http.NewRequest("PUT", "/v2/snaps/config-snap/conf", bytes.NewBufferString(`{"key": "new-value"}`))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("If-Match", `"`+version+`"`)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 412)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindResourceVersionMismatch)
+
+	// Any version is accepted when using a wildcard
+	req, err = http.NewRequest("PUT", "/v2/snaps/config-snap/conf", bytes.NewBufferString(`{"key": "new-value"}`))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("If-Match", "*")
+	rsp = s.asyncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Change, check.Not(check.Equals), "")
+
+	// While that change is in progress, the version cannot be relied upon
+	req, err = http.NewRequest("GET", "/v2/snaps/config-snap/conf", nil)
+	c.Assert(err, check.IsNil)
+	version = s.syncReq(c, req, nil, actionIsExpected).ResourceVersion
+	req, err = http.NewRequest("PUT", "/v2/snaps/config-snap/conf", bytes.NewBufferString(`{"key": "other-value"}`))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("If-Match", `"`+version+`"`)
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 412)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindResourceVersionMismatch)
+	c.Check(rspe.Message, check.Equals, "resource is being modified by change "+rsp.Change)
+
+	// Once it is ready, the version is checked again
+	st.Lock()
+	st.Change(rsp.Change).SetStatus(state.DoneStatus)
+	st.Unlock()
+	req, err = http.NewRequest("PUT", "/v2/snaps/config-snap/conf", bytes.NewBufferString(`{"key": "other-value"}`))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("If-Match", `"`+version+`"`)
+	rsp = s.asyncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Change, check.Not(check.Equals), "")
+}
@@ -0,0 +1,108 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = check.Suite(&snapHistorySuite{})
+
+type snapHistorySuite struct {
+	apiBaseSuite
+}
+
+func (s *snapHistorySuite) TestGetSnapHistory(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v2", snap.R(2), true, "")
+
+	when := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("refresh-history", map[string][]*snapstate.RefreshHistoryEntry{
+		"foo": {{
+			Time:         when,
+			FromRevision: snap.R(1),
+			ToRevision:   snap.R(2),
+			Channel:      "latest/stable",
+			Initiator:    "auto",
+			Outcome:      "success",
+			Duration:     time.Minute,
+		}},
+	})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/history", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	c.Check(rsp.Result, check.DeepEquals, []client.RefreshHistoryEntry{{
+		Time:         when,
+		FromRevision: snap.R(1),
+		ToRevision:   snap.R(2),
+		Channel:      "latest/stable",
+		Initiator:    "auto",
+		Outcome:      "success",
+		Duration:     time.Minute,
+	}})
+}
+
+func (s *snapHistorySuite) TestGetSnapHistoryEmpty(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "")
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/history", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, []client.RefreshHistoryEntry{})
+}
+
+func (s *snapHistorySuite) TestGetSnapHistoryRemovedSnap(c *check.C) {
+	d := s.daemon(c)
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("refresh-history", map[string][]*snapstate.RefreshHistoryEntry{
+		"foo": {{FromRevision: snap.R(1), ToRevision: snap.R(2), Initiator: "user", Outcome: "success"}},
+	})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/history", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.HasLen, 1)
+}
+
+func (s *snapHistorySuite) TestGetSnapHistoryNotFound(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/history", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Status, check.Equals, 404)
+	c.Check(rsp.Kind, check.Equals, client.ErrorKindSnapNotFound)
+}
@@ -0,0 +1,161 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+)
+
+var snapRevisionsCmd = &Command{
+	Path:       "/v2/snaps/{name}/revisions",
+	GET:        getSnapRevisions,
+	ReadAccess: openAccess{},
+}
+
+var _ = registerAPIFeature("snap-revisions")
+
+var storeChannelRisks = []string{"stable", "candidate", "beta", "edge"}
+
+func getSnapRevisions(c *Command, r *http.Request, user *auth.UserState) Response {
+	name := muxVars(r)["name"]
+
+	withStore := false
+	switch r.URL.Query().Get("store") {
+	case "", "false":
+	case "true":
+		withStore = true
+	default:
+		return BadRequest(`cannot get revisions of snap %q: invalid "store" parameter`, name)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	local, err := localSnapRevisions(st, name)
+	st.Unlock()
+	if err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return SnapNotFound(name, err)
+		}
+		return InternalError("cannot get revisions of snap %q: %v", name, err)
+	}
+
+	revs := client.SnapRevisions{Local: local}
+	if !withStore {
+		return SyncResponse(revs)
+	}
+
+	ctx := store.WithClientUserAgent(r.Context(), r)
+	info, err := storeFrom(c.d).SnapInfo(ctx, store.SnapSpec{Name: snap.InstanceSnap(name)}, user)
+	switch err {
+	case nil:
+		// pass
+	case store.ErrInvalidCredentials:
+		return Unauthorized("%v", err)
+	case store.ErrSnapNotFound:
+		// the snap is not (or no longer) in the store
+		return SyncResponse(revs)
+	default:
+		return InternalError("cannot get store revisions of snap %q: %v", name, err)
+	}
+	revs.Store = storeSnapRevisions(info)
+
+	return SyncResponse(revs)
+}
+
+// localSnapRevisions returns the revisions of the snap retained on the
+// system, oldest first. The install date of a revision is approximated
+// by the modification time of its snap file.
+func localSnapRevisions(st *state.State, name string) ([]client.LocalRevision, error) {
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, name, &snapst); err != nil {
+		return nil, err
+	}
+
+	sideInfos := snapst.Sequence.SideInfos()
+	revs := make([]client.LocalRevision, 0, len(sideInfos))
+	for _, si := range sideInfos {
+		rev := client.LocalRevision{
+			Revision: si.Revision,
+			Channel:  si.Channel,
+			Current:  si.Revision == snapst.Current,
+		}
+		info, err := snap.ReadInfo(name, si)
+		if err == nil {
+			rev.Version = info.Version
+		}
+		if fi, err := os.Stat(snap.MountFile(name, si.Revision)); err == nil {
+			modTime := fi.ModTime()
+			rev.Size = fi.Size()
+			rev.InstallDate = &modTime
+		}
+		revs = append(revs, rev)
+	}
+	return revs, nil
+}
+
+// storeSnapRevisions returns the revisions released to the store
+// channels of the snap, ordered by track and then by risk. Channels
+// outside of that scheme (i.e. branches) are listed last.
+func storeSnapRevisions(info *snap.Info) []client.StoreRevision {
+	var revs []client.StoreRevision
+	seen := make(map[string]bool, len(info.Channels))
+	add := func(name string, ch *snap.ChannelSnapInfo) {
+		seen[name] = true
+		revs = append(revs, client.StoreRevision{
+			Channel:    name,
+			Revision:   ch.Revision,
+			Version:    ch.Version,
+			Size:       ch.Size,
+			ReleasedAt: ch.ReleasedAt,
+		})
+	}
+
+	for _, track := range info.Tracks {
+		for _, risk := range storeChannelRisks {
+			name := track + "/" + risk
+			if ch := info.Channels[name]; ch != nil {
+				add(name, ch)
+			}
+		}
+	}
+
+	var rest []string
+	for name := range info.Channels {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		add(name, info.Channels[name])
+	}
+
+	return revs
+}
@@ -0,0 +1,140 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+)
+
+var _ = check.Suite(&snapRevisionsSuite{})
+
+type snapRevisionsSuite struct {
+	apiBaseSuite
+}
+
+func (s *snapRevisionsSuite) TestGetRevisionsLocal(c *check.C) {
+	d := s.daemon(c)
+
+	info1 := s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), false, "")
+	info2 := s.mkInstalledInState(c, d, "foo", "", "v2", snap.R(2), true, "")
+	when := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.Assert(os.Chtimes(info1.MountFile(), when, when), check.IsNil)
+	fi, err := os.Stat(info2.MountFile())
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/revisions", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	revs, ok := rsp.Result.(client.SnapRevisions)
+	c.Assert(ok, check.Equals, true)
+	c.Check(revs.Store, check.HasLen, 0)
+	c.Assert(revs.Local, check.HasLen, 2)
+
+	c.Check(revs.Local[0].Revision, check.Equals, snap.R(1))
+	c.Check(revs.Local[0].Version, check.Equals, "v1")
+	c.Check(revs.Local[0].Channel, check.Equals, "stable")
+	c.Check(revs.Local[0].Current, check.Equals, false)
+	c.Assert(revs.Local[0].InstallDate, check.NotNil)
+	c.Check(revs.Local[0].InstallDate.Equal(when), check.Equals, true)
+
+	c.Check(revs.Local[1].Revision, check.Equals, snap.R(2))
+	c.Check(revs.Local[1].Version, check.Equals, "v2")
+	c.Check(revs.Local[1].Current, check.Equals, true)
+	c.Check(revs.Local[1].Size, check.Equals, fi.Size())
+
+	// the store was not asked
+	c.Check(s.ctx, check.IsNil)
+}
+
+func (s *snapRevisionsSuite) TestGetRevisionsWithStore(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "")
+
+	released := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.rsnaps = []*snap.Info{{
+		Tracks: []string{"latest", "2.x"},
+		Channels: map[string]*snap.ChannelSnapInfo{
+			"latest/edge":          {Revision: snap.R(5), Version: "v5", Size: 50, ReleasedAt: released},
+			"latest/stable":        {Revision: snap.R(1), Version: "v1", Size: 10, ReleasedAt: released},
+			"2.x/stable":           {Revision: snap.R(3), Version: "v3", Size: 30, ReleasedAt: released},
+			"latest/stable/hotfix": {Revision: snap.R(4), Version: "v4", Size: 40, ReleasedAt: released},
+		},
+	}}
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/revisions?store=true", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	revs := rsp.Result.(client.SnapRevisions)
+	c.Check(revs.Local, check.HasLen, 1)
+	c.Check(revs.Store, check.DeepEquals, []client.StoreRevision{
+		{Channel: "latest/stable", Revision: snap.R(1), Version: "v1", Size: 10, ReleasedAt: released},
+		{Channel: "latest/edge", Revision: snap.R(5), Version: "v5", Size: 50, ReleasedAt: released},
+		{Channel: "2.x/stable", Revision: snap.R(3), Version: "v3", Size: 30, ReleasedAt: released},
+		{Channel: "latest/stable/hotfix", Revision: snap.R(4), Version: "v4", Size: 40, ReleasedAt: released},
+	})
+}
+
+func (s *snapRevisionsSuite) TestGetRevisionsStoreNotFound(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(-1), true, "")
+	s.err = store.ErrSnapNotFound
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/revisions?store=true", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	revs := rsp.Result.(client.SnapRevisions)
+	c.Check(revs.Local, check.HasLen, 1)
+	c.Check(revs.Store, check.HasLen, 0)
+}
+
+func (s *snapRevisionsSuite) TestGetRevisionsErrors(c *check.C) {
+	d := s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/revisions", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 404)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapNotFound)
+
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "")
+
+	req, err = http.NewRequest("GET", "/v2/snaps/foo/revisions?store=maybe", nil)
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `cannot get revisions of snap "foo": invalid "store" parameter`)
+
+	s.err = store.ErrInvalidCredentials
+	req, err = http.NewRequest("GET", "/v2/snaps/foo/revisions?store=true", nil)
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 401)
+}
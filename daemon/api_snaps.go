@@ -57,6 +57,8 @@ const (
 	removeCmdAction   = "remove"
 	enableCmdAction   = "enable"
 	disableCmdAction  = "disable"
+	downloadCmdAction = "download"
+	proceedCmdAction  = "proceed"
 )
 
 var (
@@ -69,9 +71,11 @@ var (
 			installCmdAction, refreshCmdAction, revertCmdAction,
 			switchCmdAction, holdCmdAction, unholdCmdAction,
 			removeCmdAction, enableCmdAction, disableCmdAction,
+			downloadCmdAction, proceedCmdAction,
 		},
 		ReadAccess:  interfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control", "snap-refresh-observe", "desktop-launch"}},
 		WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
+		Throttled:   true,
 	}
 
 	snapsCmd = &Command{
@@ -86,6 +90,7 @@ var (
 		},
 		ReadAccess:  interfaceOpenAccess{Interfaces: []string{"snap-refresh-observe", "desktop-launch"}},
 		WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
+		Throttled:   true,
 	}
 )
 
@@ -100,6 +105,36 @@ var (
 	revertSnapChangeKind   = swfeats.RegisterChangeKind(revertCmdAction + "-snap")
 	enableSnapChangeKind   = swfeats.RegisterChangeKind(enableCmdAction + "-snap")
 	disableSnapChangeKind  = swfeats.RegisterChangeKind(disableCmdAction + "-snap")
+	downloadSnapChangeKind = swfeats.RegisterChangeKind(downloadCmdAction + "-snap")
+	proceedSnapChangeKind  = swfeats.RegisterChangeKind(proceedCmdAction + "-snap")
+)
+
+var (
+	_ = swfeats.DescribeChangeKind(installSnapChangeKind, "Install snaps",
+		"prerequisites", "download-snap", "validate-snap", "mount-snap", "copy-snap-data",
+		"setup-profiles", "link-snap", "auto-connect", "set-auto-aliases", "setup-aliases",
+		"run-hook", "start-snap-services", "run-hook", "run-hook")
+	_ = swfeats.DescribeChangeKind(refreshSnapChangeKind, "Refresh snaps",
+		"prerequisites", "download-snap", "validate-snap", "mount-snap", "run-hook",
+		"stop-snap-services", "remove-aliases", "unlink-current-snap", "copy-snap-data",
+		"setup-profiles", "link-snap", "auto-connect", "set-auto-aliases", "setup-aliases",
+		"run-hook", "start-snap-services", "cleanup", "run-hook", "run-hook", "check-rerefresh")
+	_ = swfeats.DescribeChangeKind(removeSnapChangeKind, "Remove snaps",
+		"run-hook", "stop-snap-services", "run-hook", "remove-aliases", "unlink-snap",
+		"remove-profiles", "clear-snap", "discard-snap")
+	_ = swfeats.DescribeChangeKind(revertSnapChangeKind, "Revert snaps to a previous revision",
+		"prerequisites", "prepare-snap", "stop-snap-services", "remove-aliases",
+		"unlink-current-snap", "setup-profiles", "link-snap", "auto-connect",
+		"set-auto-aliases", "setup-aliases", "start-snap-services", "run-hook")
+	_ = swfeats.DescribeChangeKind(switchSnapChangeKind, "Switch the channel snaps track",
+		"switch-snap")
+	_ = swfeats.DescribeChangeKind(downloadSnapChangeKind, "Download a snap refresh without installing it",
+		"download-snap", "validate-snap", "mark-snap-downloaded")
+	_ = swfeats.DescribeChangeKind(proceedSnapChangeKind, "Proceed with a snap refresh inhibited by running apps",
+		"prerequisites", "download-snap", "validate-snap", "mount-snap", "run-hook",
+		"stop-snap-services", "remove-aliases", "unlink-current-snap", "copy-snap-data",
+		"setup-profiles", "link-snap", "auto-connect", "set-auto-aliases", "setup-aliases",
+		"run-hook", "start-snap-services", "cleanup", "run-hook", "run-hook", "check-rerefresh")
 )
 
 func getSnapInfo(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -172,6 +207,10 @@ func changeKind(action string) (string, bool) {
 		return enableSnapChangeKind, true
 	case disableCmdAction:
 		return disableSnapChangeKind, true
+	case downloadCmdAction:
+		return downloadSnapChangeKind, true
+	case proceedCmdAction:
+		return proceedSnapChangeKind, true
 	}
 	return "", false
 }
@@ -188,10 +227,6 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("cannot decode request body into snap instruction: %v", err)
 	}
 
-	st := c.d.overlord.State()
-	st.Lock()
-	defer st.Unlock()
-
 	if user != nil {
 		inst.userID = user.ID
 	}
@@ -210,12 +245,24 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("%s", err)
 	}
 
+	if inst.URL != "" {
+		// the snap is downloaded without holding the state lock
+		return snapInstallFromURL(c, r, &inst)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
 	impl := inst.dispatch()
 	if impl == nil {
 		return BadRequest("unknown action %s", inst.Action)
 	}
 
 	res, err := impl(r.Context(), &inst, st)
+	if inst.Queue && errors.Is(err, &snapstate.ChangeConflictError{}) {
+		return queueSnapOp(r.Context(), st, &inst)
+	}
 	if err != nil {
 		return inst.errToResponse(err)
 	}
@@ -225,7 +272,7 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("unknown action %s", inst.Action)
 	}
 
-	chg := newChange(st, changeKind, res.Summary, res.Tasksets, res.Affected)
+	chg := newChange(r.Context(), st, changeKind, res.Summary, res.Tasksets, res.Affected)
 	if len(res.Tasksets) == 0 {
 		chg.SetStatus(state.DoneStatus)
 	}
@@ -302,9 +349,15 @@ type snapInstruction struct {
 	QuotaGroupName         string                           `json:"quota-group"`
 	Time                   string                           `json:"time"`
 	HoldLevel              string                           `json:"hold-level"`
+	ScheduleAt             string                           `json:"schedule-at"`
+	Queue                  bool                             `json:"queue"`
+	Architecture           string                           `json:"architecture"`
+	URL                    string                           `json:"url"`
 
 	// The fields below should not be unmarshalled into. Do not export them.
 	userID int
+	// multiSnap is set for operations on /v2/snaps
+	multiSnap bool
 }
 
 func (inst *snapInstruction) setCompsFromRawList() error {
@@ -323,10 +376,11 @@ func (inst *snapInstruction) setCompsFromRawMap() error {
 
 func (inst *snapInstruction) revnoOpts() *snapstate.RevisionOptions {
 	return &snapstate.RevisionOptions{
-		Channel:     inst.Channel,
-		Revision:    inst.Revision,
-		CohortKey:   inst.CohortKey,
-		LeaveCohort: inst.LeaveCohort,
+		Channel:      inst.Channel,
+		Revision:     inst.Revision,
+		CohortKey:    inst.CohortKey,
+		LeaveCohort:  inst.LeaveCohort,
+		Architecture: inst.Architecture,
 	}
 }
 
@@ -404,15 +458,36 @@ func (inst *snapInstruction) validateSnapshotOptions() error {
 	return nil
 }
 
+func (inst *snapInstruction) validateScheduleAt() error {
+	if !inst.multiSnap {
+		return errors.New("schedule-at can only be specified for multi-snap operations")
+	}
+	switch inst.Action {
+	case installCmdAction, refreshCmdAction, removeCmdAction:
+	default:
+		return errors.New("schedule-at can only be specified for install, refresh, or remove")
+	}
+	when, err := time.Parse(time.RFC3339, inst.ScheduleAt)
+	if err != nil {
+		return fmt.Errorf("schedule-at must be in RFC3339 format: %v", err)
+	}
+	if !when.After(timeNow()) {
+		return fmt.Errorf("cannot schedule change in the past: %s", inst.ScheduleAt)
+	}
+	return nil
+}
+
+// validate checks the instruction on its own. It must not access the
+// state, as it is called without holding the state lock.
 func (inst *snapInstruction) validate() error {
 	if inst.CohortKey != "" {
-		if inst.Action != installCmdAction && inst.Action != refreshCmdAction && inst.Action != switchCmdAction {
-			return fmt.Errorf("cohort-key can only be specified for install, refresh, or switch")
+		if inst.Action != installCmdAction && inst.Action != refreshCmdAction && inst.Action != switchCmdAction && inst.Action != downloadCmdAction {
+			return fmt.Errorf("cohort-key can only be specified for install, refresh, download, or switch")
 		}
 	}
 	if inst.LeaveCohort {
-		if inst.Action != refreshCmdAction && inst.Action != switchCmdAction {
-			return fmt.Errorf("leave-cohort can only be specified for refresh or switch")
+		if inst.Action != refreshCmdAction && inst.Action != switchCmdAction && inst.Action != downloadCmdAction {
+			return fmt.Errorf("leave-cohort can only be specified for refresh, download, or switch")
 		}
 	}
 	if inst.Action == installCmdAction {
@@ -427,7 +502,9 @@ func (inst *snapInstruction) validate() error {
 	switch inst.Transaction {
 	case "":
 	case client.TransactionPerSnap, client.TransactionAllSnaps:
-		if inst.Action != installCmdAction && inst.Action != refreshCmdAction {
+		// removals can be transactional only when removing several snaps
+		removeMany := inst.multiSnap && inst.Action == removeCmdAction
+		if inst.Action != installCmdAction && inst.Action != refreshCmdAction && !removeMany {
 			return fmt.Errorf(`transaction type is unsupported for %q actions`, inst.Action)
 		}
 	default:
@@ -461,6 +538,33 @@ func (inst *snapInstruction) validate() error {
 		}
 	}
 
+	if inst.ScheduleAt != "" {
+		if err := inst.validateScheduleAt(); err != nil {
+			return err
+		}
+	}
+
+	if inst.Queue {
+		switch inst.Action {
+		case installCmdAction, refreshCmdAction, removeCmdAction:
+		default:
+			return errors.New("queue can only be specified for install, refresh, or remove")
+		}
+		if inst.URL != "" {
+			return errors.New("queue cannot be specified when installing from a url")
+		}
+	}
+
+	if inst.Architecture != "" && (inst.Action != installCmdAction || inst.URL != "") {
+		return errors.New("architecture can only be specified when installing from the store")
+	}
+
+	if inst.URL != "" {
+		if err := inst.validateURL(); err != nil {
+			return err
+		}
+	}
+
 	if inst.Unaliased && inst.Prefer {
 		return errUnaliasedPreferConflict
 	}
@@ -565,6 +669,9 @@ func installRefreshMessage(snapName string, inst *snapInstruction) string {
 	if inst.CohortKey != "" {
 		fmt.Fprintf(&b, i18n.G(" from %q cohort"), strutil.ElliptLeft(inst.CohortKey, 10))
 	}
+	if inst.Architecture != "" {
+		fmt.Fprintf(&b, i18n.G(" for %q architecture"), inst.Architecture)
+	}
 
 	if comps := inst.CompsForSnaps[snapName]; len(comps) > 0 {
 		if len(comps) > 1 {
@@ -794,18 +901,51 @@ func snapSwitch(_ context.Context, inst *snapInstruction, st *state.State) (*sna
 	}, nil
 }
 
+func snapDownload(ctx context.Context, inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
+	// we need refreshed snap-declarations to enforce refresh-control as best as we can
+	if err := assertstateRefreshSnapAssertions(st, inst.userID, nil); err != nil {
+		return nil, err
+	}
+
+	ts, info, err := snapstateDownloadRefresh(ctx, st, inst.Snaps[0], inst.revnoOpts(), inst.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapInstructionResult{
+		Summary:  fmt.Sprintf(i18n.G("Download refresh of %q snap to revision %s"), inst.Snaps[0], info.Revision),
+		Tasksets: []*state.TaskSet{ts},
+		Affected: inst.Snaps,
+	}, nil
+}
+
+func snapProceed(_ context.Context, inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
+	tss, err := snapstateProceedWithInhibitedRefresh(st, inst.Snaps[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapInstructionResult{
+		Summary:  fmt.Sprintf(i18n.G("Proceed with inhibited refresh of %q snap"), inst.Snaps[0]),
+		Tasksets: tss,
+		Affected: inst.Snaps,
+	}, nil
+}
+
 type snapActionFunc func(context.Context, *snapInstruction, *state.State) (*snapInstructionResult, error)
 
 var snapInstructionDispTable = map[string]snapActionFunc{
-	installCmdAction: snapInstall,
-	refreshCmdAction: snapUpdate,
-	removeCmdAction:  snapRemove,
-	revertCmdAction:  snapRevert,
-	enableCmdAction:  snapEnable,
-	disableCmdAction: snapDisable,
-	switchCmdAction:  snapSwitch,
-	holdCmdAction:    snapHoldMany,
-	unholdCmdAction:  snapUnholdMany,
+	installCmdAction:  snapInstall,
+	refreshCmdAction:  snapUpdate,
+	removeCmdAction:   snapRemove,
+	revertCmdAction:   snapRevert,
+	enableCmdAction:   snapEnable,
+	disableCmdAction:  snapDisable,
+	switchCmdAction:   snapSwitch,
+	holdCmdAction:     snapHoldMany,
+	unholdCmdAction:   snapUnholdMany,
+	downloadCmdAction: snapDownload,
+	proceedCmdAction:  snapProceed,
 }
 
 func (inst *snapInstruction) dispatch() snapActionFunc {
@@ -823,6 +963,149 @@ func (inst *snapInstruction) errToResponse(err error) *apiError {
 	return errToResponse(err, inst.Snaps, BadRequest, "cannot %s %s: %v", inst.Action, strutil.Quoted(inst.Snaps))
 }
 
+func init() {
+	snapstate.QueuedOpTasks = queuedSnapOpTasks
+}
+
+// queuedOp returns the description of the operation of the instruction
+// persisted while it is queued.
+func (inst *snapInstruction) queuedOp() (*snapstate.QueuedOp, error) {
+	flags, err := inst.installFlags()
+	if err != nil {
+		return nil, err
+	}
+	flags.Amend = inst.Amend
+	flags.Transaction = inst.Transaction
+	return &snapstate.QueuedOp{
+		Action:                 inst.Action,
+		Snaps:                  inst.Snaps,
+		Components:             inst.CompsForSnaps,
+		Many:                   inst.multiSnap,
+		UserID:                 inst.userID,
+		Channel:                inst.Channel,
+		Revision:               inst.Revision,
+		CohortKey:              inst.CohortKey,
+		LeaveCohort:            inst.LeaveCohort,
+		Architecture:           inst.Architecture,
+		ValidationSets:         inst.ValidationSets,
+		Flags:                  flags,
+		Purge:                  inst.Purge,
+		Terminate:              inst.Terminate,
+		SystemRestartImmediate: inst.SystemRestartImmediate,
+	}, nil
+}
+
+// queuedOpInstruction returns the instruction for a queued operation.
+func queuedOpInstruction(op *snapstate.QueuedOp) *snapInstruction {
+	return &snapInstruction{
+		Action: op.Action,
+		Amend:  op.Flags.Amend,
+		snapRevisionOptions: snapRevisionOptions{
+			Channel:     op.Channel,
+			Revision:    op.Revision,
+			CohortKey:   op.CohortKey,
+			LeaveCohort: op.LeaveCohort,
+		},
+		CompsForSnaps:          op.Components,
+		DevMode:                op.Flags.DevMode,
+		JailMode:               op.Flags.JailMode,
+		Classic:                op.Flags.Classic,
+		IgnoreValidation:       op.Flags.IgnoreValidation,
+		IgnoreRunning:          op.Flags.IgnoreRunning,
+		Unaliased:              op.Flags.Unaliased,
+		Prefer:                 op.Flags.Prefer,
+		Purge:                  op.Purge,
+		Terminate:              op.Terminate,
+		SystemRestartImmediate: op.SystemRestartImmediate,
+		Transaction:            op.Flags.Transaction,
+		Snaps:                  op.Snaps,
+		ValidationSets:         op.ValidationSets,
+		QuotaGroupName:         op.Flags.QuotaGroupName,
+		Architecture:           op.Architecture,
+		userID:                 op.UserID,
+		multiSnap:              op.Many,
+	}
+}
+
+// queueSnapOp creates a change for the given instruction which starts the
+// operation once the changes it conflicts with are done, or once its
+// scheduled time is reached.
+func queueSnapOp(ctx context.Context, st *state.State, inst *snapInstruction) Response {
+	changeKind, ok := changeKind(inst.Action)
+	if !ok {
+		return BadRequest("unknown action %s", inst.Action)
+	}
+
+	var summary string
+	switch {
+	case inst.ScheduleAt != "" && len(inst.Snaps) == 0:
+		summary = fmt.Sprintf(i18n.G("Scheduled %s of all snaps"), inst.Action)
+	case inst.ScheduleAt != "":
+		summary = fmt.Sprintf(i18n.G("Scheduled %s of %s"), inst.Action, strutil.Quoted(inst.Snaps))
+	case len(inst.Snaps) == 0:
+		summary = fmt.Sprintf(i18n.G("Queued %s of all snaps"), inst.Action)
+	default:
+		summary = fmt.Sprintf(i18n.G("Queued %s of %s"), inst.Action, strutil.Quoted(inst.Snaps))
+	}
+	op, err := inst.queuedOp()
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+	t := snapstate.NewQueuedOpTask(st, summary, op)
+
+	chg := newChange(ctx, st, changeKind, summary, []*state.TaskSet{state.NewTaskSet(t)}, inst.Snaps)
+	if inst.ScheduleAt != "" {
+		// already validated
+		when, _ := time.Parse(time.RFC3339, inst.ScheduleAt)
+		chg.ScheduleAt(when)
+	}
+	if inst.SystemRestartImmediate {
+		chg.Set("system-restart-immediate", true)
+	}
+	apiData := map[string]any{}
+	if len(inst.Snaps) > 0 {
+		apiData["snap-names"] = inst.Snaps
+	}
+	chg.Set("api-data", apiData)
+
+	ensureStateSoon(st)
+
+	return AsyncResponse(nil, chg.ID())
+}
+
+// queuedSnapOpTasks creates the task sets of the given operation carried
+// by a run-queued-op task, and records the snaps and components it affects
+// in the API data of the change.
+func queuedSnapOpTasks(t *state.Task, op *snapstate.QueuedOp) ([]*state.TaskSet, error) {
+	inst := queuedOpInstruction(op)
+
+	var impl snapManyActionFunc
+	if inst.multiSnap {
+		impl = inst.dispatchForMany()
+	} else {
+		impl = snapManyActionFunc(inst.dispatch())
+	}
+	if impl == nil {
+		return nil, fmt.Errorf("cannot run queued operation: unknown action %s", inst.Action)
+	}
+
+	res, err := impl(context.Background(), inst, t.State())
+	if err != nil {
+		return nil, err
+	}
+
+	apiData := map[string]any{}
+	if len(res.Affected) > 0 {
+		apiData["snap-names"] = res.Affected
+	}
+	if len(res.AffectedComponents) > 0 {
+		apiData["components"] = res.AffectedComponents
+	}
+	t.Change().Set("api-data", apiData)
+
+	return res.Tasksets, nil
+}
+
 func postSnaps(c *Command, r *http.Request, user *auth.UserState) Response {
 	contentType := r.Header.Get("Content-Type")
 
@@ -869,6 +1152,7 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 		}
 	}
 
+	inst.multiSnap = true
 	if err := inst.validate(); err != nil {
 		return BadRequest("%v", err)
 	}
@@ -886,7 +1170,16 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("unsupported multi-snap operation %q", inst.Action)
 	}
 
+	if inst.ScheduleAt != "" {
+		// the tasks are only created at the scheduled time, against
+		// the state of the system then
+		return queueSnapOp(r.Context(), st, &inst)
+	}
+
 	res, err := op(r.Context(), &inst, st)
+	if inst.Queue && errors.Is(err, &snapstate.ChangeConflictError{}) {
+		return queueSnapOp(r.Context(), st, &inst)
+	}
 	if err != nil {
 		return inst.errToResponse(err)
 	}
@@ -896,7 +1189,7 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("unknown action %s", inst.Action)
 	}
 
-	chg := newChange(st, changeKind, res.Summary, res.Tasksets, res.Affected)
+	chg := newChange(r.Context(), st, changeKind, res.Summary, res.Tasksets, res.Affected)
 	if len(res.Tasksets) == 0 {
 		chg.SetStatus(state.DoneStatus)
 	}
@@ -962,7 +1255,7 @@ func installationTaskSets(ctx context.Context, st *state.State, inst *snapInstru
 		opts.Flags.Transaction = inst.Transaction
 	}
 
-	revOpts := snapstate.RevisionOptions{}
+	revOpts := snapstate.RevisionOptions{Architecture: inst.Architecture}
 	if expectOneSnap {
 		revOpts = *inst.revnoOpts()
 	}
@@ -1215,6 +1508,9 @@ func snapRemoveMany(_ context.Context, inst *snapInstruction, st *state.State) (
 	var snapsMsg, compsMsg string
 	var err error
 	if len(inst.CompsForSnaps) > 0 {
+		if inst.Transaction == client.TransactionAllSnaps {
+			return nil, fmt.Errorf("cannot remove components as part of an %q transaction", client.TransactionAllSnaps)
+		}
 		removedComponents = inst.CompsForSnaps
 		for snap := range inst.CompsForSnaps {
 			if strutil.ListContains(inst.Snaps, snap) {
@@ -1227,7 +1523,11 @@ func snapRemoveMany(_ context.Context, inst *snapInstruction, st *state.State) (
 		}
 	}
 	if len(inst.Snaps) > 0 {
-		flags := &snapstate.RemoveFlags{Purge: inst.Purge, Terminate: inst.Terminate}
+		flags := &snapstate.RemoveFlags{
+			Purge:       inst.Purge,
+			Terminate:   inst.Terminate,
+			Transaction: inst.Transaction,
+		}
 		removedSnaps, snapsTaskSets, err = snapstateRemoveMany(st, inst.Snaps, flags)
 		if err != nil {
 			return nil, err
@@ -1277,6 +1577,8 @@ func getSnapsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	query := r.URL.Query()
 	var sel snapSelect
 	switch query.Get("select") {
+	case "tracking":
+		return getSnapsTracking(c, r, user)
 	case "":
 		sel = snapSelectNone
 	case "all":
@@ -44,6 +44,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/healthstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/sequence"
@@ -575,6 +576,135 @@ func (s *snapsSuite) TestPostSnapsRemoveWithTerminate(c *check.C) {
 	c.Assert(snapstateRemoveCalled, check.Equals, 1)
 }
 
+// runQueuedOpTasks creates the tasks of the operation carried by the given
+// run-queued-op task as the task would.
+func runQueuedOpTasks(c *check.C, t *state.Task) ([]*state.TaskSet, error) {
+	var op snapstate.QueuedOp
+	c.Assert(t.Get("queued-op", &op), check.IsNil)
+	return snapstate.QueuedOpTasks(t, &op)
+}
+
+func (s *snapsSuite) TestPostSnapRemoveQueuedOnConflict(c *check.C) {
+	d := s.daemonWithOverlordMockAndStore()
+
+	conflicting := true
+	defer daemon.MockSnapstateRemove(func(st *state.State, name string, revision snap.Revision, flags *snapstate.RemoveFlags) (*state.TaskSet, error) {
+		c.Check(name, check.Equals, "foo")
+		c.Check(flags.Purge, check.Equals, true)
+		if conflicting {
+			return nil, &snapstate.ChangeConflictError{Snap: "foo", ChangeKind: "refresh"}
+		}
+		t := st.NewTask("fake-remove", "Remove one")
+		return state.NewTaskSet(t), nil
+	})()
+
+	buf := strings.NewReader(`{"action": "remove", "purge": true, "queue": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "remove-snap")
+	c.Check(chg.Summary(), check.Equals, `Queued remove of "foo"`)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "run-queued-op")
+
+	// the operation is still conflicting
+	_, err = runQueuedOpTasks(c, tasks[0])
+	c.Check(errors.Is(err, &snapstate.ChangeConflictError{}), check.Equals, true)
+
+	// until the conflict clears
+	conflicting = false
+	tss, err := runQueuedOpTasks(c, tasks[0])
+	c.Assert(err, check.IsNil)
+	c.Assert(tss, check.HasLen, 1)
+	c.Check(tss[0].Tasks()[0].Kind(), check.Equals, "fake-remove")
+
+	var apiData map[string]any
+	c.Assert(chg.Get("api-data", &apiData), check.IsNil)
+	c.Check(apiData, check.DeepEquals, map[string]any{"snap-names": []any{"foo"}})
+}
+
+func (s *snapsSuite) TestPostSnapsRemoveManyQueuedOnConflict(c *check.C) {
+	d := s.daemonWithOverlordMockAndStore()
+
+	conflicting := true
+	defer daemon.MockSnapstateRemoveMany(func(s *state.State, names []string, opts *snapstate.RemoveFlags) ([]string, []*state.TaskSet, error) {
+		c.Check(names, check.DeepEquals, []string{"foo", "bar"})
+		if conflicting {
+			return nil, nil, &snapstate.ChangeConflictError{Snap: "bar", ChangeKind: "install"}
+		}
+		t := s.NewTask("fake-remove-2", "Remove two")
+		return names, []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})()
+
+	buf := strings.NewReader(`{"action": "remove", "snaps": ["foo", "bar"], "queue": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Summary(), check.Equals, `Queued remove of "foo", "bar"`)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+
+	conflicting = false
+	tss, err := runQueuedOpTasks(c, tasks[0])
+	c.Assert(err, check.IsNil)
+	c.Assert(tss, check.HasLen, 1)
+	c.Check(tss[0].Tasks()[0].Kind(), check.Equals, "fake-remove-2")
+}
+
+func (s *snapsSuite) TestPostSnapQueueErrors(c *check.C) {
+	s.daemon(c)
+
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "enable", "queue": true}`, `queue can only be specified for install, refresh, or remove`},
+		{`{"action": "install", "url": "https://example.com/foo.snap", "queue": true}`, `queue cannot be specified when installing from a url`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps/foo", strings.NewReader(tc.body))
+		c.Assert(err, check.IsNil)
+		req.Header.Set("Content-Type", "application/json")
+
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(tc.body))
+		c.Check(rspe.Message, check.Matches, tc.err, check.Commentf(tc.body))
+	}
+}
+
+func (s *snapsSuite) TestPostSnapRemoveConflictNotQueued(c *check.C) {
+	s.daemonWithOverlordMockAndStore()
+
+	defer daemon.MockSnapstateRemove(func(st *state.State, name string, revision snap.Revision, flags *snapstate.RemoveFlags) (*state.TaskSet, error) {
+		return nil, &snapstate.ChangeConflictError{Snap: "foo", ChangeKind: "refresh"}
+	})()
+
+	buf := strings.NewReader(`{"action": "remove"}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 409)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapChangeConflict)
+}
+
 func (s *snapsSuite) TestPostSnapsRemoveManyWithTerminate(c *check.C) {
 	d := s.daemonWithOverlordMockAndStore()
 
@@ -604,6 +734,49 @@ func (s *snapsSuite) TestPostSnapsRemoveManyWithTerminate(c *check.C) {
 	c.Assert(snapstateRemoveManyCalled, check.Equals, 1)
 }
 
+func (s *snapsSuite) TestPostSnapsRemoveManyTransactionally(c *check.C) {
+	d := s.daemonWithOverlordMockAndStore()
+
+	var snapstateRemoveManyCalled int
+	defer daemon.MockSnapstateRemoveMany(func(s *state.State, names []string, opts *snapstate.RemoveFlags) ([]string, []*state.TaskSet, error) {
+		snapstateRemoveManyCalled++
+		c.Check(names, check.DeepEquals, []string{"foo", "bar"})
+		c.Check(opts.Transaction, check.Equals, client.TransactionAllSnaps)
+		t := s.NewTask("fake-remove-2", "Remove two")
+		return names, []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})()
+
+	buf := strings.NewReader(`{"action": "remove", "snaps":["foo", "bar"], "transaction":"all-snaps"}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.jsonReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Status, check.Equals, 202)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Check(chg.Summary(), check.Equals, `Remove snaps "foo", "bar"`)
+
+	c.Assert(snapstateRemoveManyCalled, check.Equals, 1)
+}
+
+func (s *snapsSuite) TestRemoveManyTransactionallyWithComponents(c *check.C) {
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{
+		Action:        "remove",
+		Transaction:   client.TransactionAllSnaps,
+		CompsForSnaps: map[string][]string{"foo": {"comp"}},
+	}
+	st := d.Overlord().State()
+	st.Lock()
+	_, err := inst.DispatchForMany()(context.Background(), inst, st)
+	st.Unlock()
+	c.Assert(err, check.ErrorMatches, `cannot remove components as part of an "all-snaps" transaction`)
+}
+
 func (s *snapsSuite) TestPostSnapsRemoveManyWithPurge(c *check.C) {
 	d := s.daemonWithOverlordMockAndStore()
 
@@ -708,6 +881,71 @@ func (s *snapsSuite) testPostSnapsOp(c *check.C, extraJSON, contentType string)
 	return systemRestartImmediate
 }
 
+func (s *snapsSuite) TestPostSnapsOpScheduleAt(c *check.C) {
+	defer daemon.MockAssertstateRefreshSnapAssertions(func(*state.State, int, *assertstate.RefreshAssertionsOptions) error { return nil })()
+	updateCalls := 0
+	defer daemon.MockSnapstateUpdateWithGoal(func(_ context.Context, s *state.State, g snapstate.UpdateGoal, filter func(*snap.Info, *snapstate.SnapState) bool, opts snapstate.Options) ([]string, *snapstate.UpdateTaskSets, error) {
+		updateCalls++
+		t := s.NewTask("fake-refresh-all", "Refreshing everything")
+		return []string{"fake1", "fake2"}, &snapstate.UpdateTaskSets{Refresh: []*state.TaskSet{state.NewTaskSet(t)}}, nil
+	})()
+	now := time.Date(2025, 6, 5, 12, 0, 0, 0, time.UTC)
+	defer daemon.MockTimeNow(func() time.Time { return now })()
+
+	d := s.daemonWithOverlordMockAndStore()
+
+	buf := bytes.NewBufferString(`{"action": "refresh", "schedule-at": "2025-06-07T03:00:00Z"}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Check(chg.Status(), check.Equals, state.ScheduledStatus)
+	c.Check(chg.ScheduledTime().Equal(time.Date(2025, 6, 7, 3, 0, 0, 0, time.UTC)), check.Equals, true)
+	c.Check(chg.Summary(), check.Equals, "Scheduled refresh of all snaps")
+
+	// the tasks are only created at the scheduled time
+	c.Check(updateCalls, check.Equals, 0)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "run-queued-op")
+
+	tss, err := runQueuedOpTasks(c, tasks[0])
+	c.Assert(err, check.IsNil)
+	c.Check(updateCalls, check.Equals, 1)
+	c.Assert(tss, check.HasLen, 1)
+	c.Check(tss[0].Tasks()[0].Kind(), check.Equals, "fake-refresh-all")
+}
+
+func (s *snapsSuite) TestPostSnapsOpScheduleAtErrors(c *check.C) {
+	now := time.Date(2025, 6, 5, 12, 0, 0, 0, time.UTC)
+	defer daemon.MockTimeNow(func() time.Time { return now })()
+
+	s.daemon(c)
+
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "refresh", "schedule-at": "saturday"}`, `schedule-at must be in RFC3339 format: .*`},
+		{`{"action": "refresh", "schedule-at": "2025-06-05T11:00:00Z"}`, `cannot schedule change in the past: 2025-06-05T11:00:00Z`},
+		{`{"action": "hold", "snaps": ["foo"], "time": "forever", "hold-level": "general", "schedule-at": "2025-06-07T03:00:00Z"}`, `schedule-at can only be specified for install, refresh, or remove`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps", strings.NewReader(tc.body))
+		c.Assert(err, check.IsNil)
+		req.Header.Set("Content-Type", "application/json")
+
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(tc.body))
+		c.Check(rspe.Message, check.Matches, tc.err, check.Commentf(tc.body))
+	}
+}
+
 func (s *snapsSuite) TestPostSnapsOpInvalidCharset(c *check.C) {
 	s.daemon(c)
 
@@ -1426,6 +1664,65 @@ func (s *snapsSuite) TestSnapManyInfosReturnsHolds(c *check.C) {
 	}
 }
 
+func (s *snapsSuite) TestSnapInfoReturnsNameResolution(c *check.C) {
+	s.expectSnapsNameReadAccess()
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v0", snap.R(5), true, "")
+
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "name-resolution.foo.nameservers", "10.0.0.1"), check.IsNil)
+	c.Assert(tr.Set("core", "name-resolution.foo.hosts", "db.internal=10.0.0.5"), check.IsNil)
+	tr.Commit()
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	c.Assert(rsp.Result, check.FitsTypeOf, &client.Snap{})
+	snapInfo := rsp.Result.(*client.Snap)
+	c.Check(snapInfo.NameResolution, check.DeepEquals, &client.SnapNameResolution{
+		Nameservers: []string{"10.0.0.1"},
+		Hosts:       map[string]string{"db.internal": "10.0.0.5"},
+	})
+}
+
+func (s *snapsSuite) TestSnapManyInfosReturnsNameResolution(c *check.C) {
+	s.expectSnapsReadAccess()
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "snap-a", "bar", "v0", snap.R(5), true, "")
+	s.mkInstalledInState(c, d, "snap-b", "bar", "v0", snap.R(5), true, "")
+
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "name-resolution.snap-a.nameservers", "10.0.0.1"), check.IsNil)
+	tr.Commit()
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.jsonReq(c, req, nil, actionIsExpected)
+	snaps := snapList(rsp.Result)
+	c.Assert(snaps, check.HasLen, 2)
+
+	for _, snap := range snaps {
+		switch snap["name"] {
+		case "snap-a":
+			c.Check(snap["name-resolution"], check.DeepEquals, map[string]any{
+				"nameservers": []any{"10.0.0.1"},
+			})
+		case "snap-b":
+			_, ok := snap["name-resolution"]
+			c.Check(ok, check.Equals, false)
+		}
+	}
+}
+
 func (s *snapsSuite) TestSnapInfoReturnsRefreshInhibitProceedTime(c *check.C) {
 	s.expectSnapsNameReadAccess()
 	d := s.daemon(c)
@@ -2112,7 +2409,7 @@ func (s *snapsSuite) TestPostSnapVerifySnapInstruction(c *check.C) {
 
 func (s *snapsSuite) TestPostSnapCohortUnsupportedAction(c *check.C) {
 	s.daemonWithOverlordMock()
-	const expectedErr = "cohort-key can only be specified for install, refresh, or switch"
+	const expectedErr = "cohort-key can only be specified for install, refresh, download, or switch"
 
 	for _, action := range []string{"remove", "revert", "enable", "disable", "xyzzy"} {
 		buf := strings.NewReader(fmt.Sprintf(`{"action": "%s", "cohort-key": "32"}`, action))
@@ -2140,9 +2437,24 @@ func (s *snapsSuite) TestPostSnapQuotaGroupWrongAction(c *check.C) {
 	}
 }
 
+func (s *snapsSuite) TestPostSnapArchitectureWrongAction(c *check.C) {
+	s.daemonWithOverlordMock()
+	const expectedErr = "architecture can only be specified when installing from the store"
+
+	for _, action := range []string{"remove", "refresh", "revert", "enable", "disable", "xyzzy"} {
+		buf := strings.NewReader(fmt.Sprintf(`{"action": "%s", "architecture": "armhf"}`, action))
+		req, err := http.NewRequest("POST", "/v2/snaps/some-snap", buf)
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil, action != "xyzzy")
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%q", action))
+		c.Check(rspe.Message, check.Equals, expectedErr, check.Commentf("%q", action))
+	}
+}
+
 func (s *snapsSuite) TestPostSnapLeaveCohortUnsupportedAction(c *check.C) {
 	s.daemonWithOverlordMock()
-	const expectedErr = "leave-cohort can only be specified for refresh or switch"
+	const expectedErr = "leave-cohort can only be specified for refresh, download, or switch"
 
 	for _, action := range []string{"install", "remove", "revert", "enable", "disable", "xyzzy"} {
 		buf := strings.NewReader(fmt.Sprintf(`{"action": "%s", "leave-cohort": true}`, action))
@@ -2472,6 +2784,36 @@ func (s *snapsSuite) TestInstallCohort(c *check.C) {
 	c.Check(res.Summary, check.Equals, `Install "fake" snap from "…e damned." cohort`)
 }
 
+func (s *snapsSuite) TestInstallForeignArchitecture(c *check.C) {
+	var calledArch string
+
+	defer daemon.MockSnapstateInstallWithGoal(func(ctx context.Context, st *state.State, g snapstate.InstallGoal, opts snapstate.Options) ([]*snap.Info, []*state.TaskSet, error) {
+		goal, ok := g.(*storeInstallGoalRecorder)
+		c.Assert(ok, check.Equals, true, check.Commentf("unexpected InstallGoal type %T", g))
+		c.Assert(goal.snaps, check.HasLen, 1)
+
+		calledArch = goal.snaps[0].RevOpts.Architecture
+
+		t := st.NewTask("fake-install-snap", "Doing a fake install")
+		return []*snap.Info{{}}, []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})()
+
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{
+		Action:       "install",
+		Snaps:        []string{"fake-data"},
+		Architecture: "armhf",
+	}
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	res, err := inst.Dispatch()(context.Background(), inst, st)
+	c.Check(err, check.IsNil)
+	c.Check(calledArch, check.Equals, "armhf")
+	c.Check(res.Summary, check.Equals, `Install "fake-data" snap for "armhf" architecture`)
+}
+
 func (s *snapsSuite) TestInstallIgnoreValidation(c *check.C) {
 	var calledFlags snapstate.Flags
 	installQueue := []string{}
@@ -2959,6 +3301,110 @@ func (s *snapsSuite) TestSwitchInstruction(c *check.C) {
 	}
 }
 
+func (s *snapsSuite) TestPostSnapDownload(c *check.C) {
+	d := s.daemonWithOverlordMock()
+
+	assertionsRefreshed := false
+	defer daemon.MockAssertstateRefreshSnapAssertions(func(s *state.State, userID int, opts *assertstate.RefreshAssertionsOptions) error {
+		assertionsRefreshed = true
+		return nil
+	})()
+
+	var gotOpts *snapstate.RevisionOptions
+	defer daemon.MockSnapstateDownloadRefresh(func(ctx context.Context, st *state.State, name string, opts *snapstate.RevisionOptions, userID int) (*state.TaskSet, *snap.Info, error) {
+		c.Check(name, check.Equals, "foo")
+		gotOpts = opts
+		t := st.NewTask("fake-download", "Doing a fake download")
+		return state.NewTaskSet(t), &snap.Info{SideInfo: snap.SideInfo{Revision: snap.R(7)}}, nil
+	})()
+
+	buf := bytes.NewBufferString(`{"action": "download", "channel": "candidate", "cohort-key": "some-cohort"}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	c.Check(assertionsRefreshed, check.Equals, true)
+	c.Assert(gotOpts, check.NotNil)
+	c.Check(gotOpts.Channel, check.Equals, "candidate")
+	c.Check(gotOpts.CohortKey, check.Equals, "some-cohort")
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "download-snap")
+	c.Check(chg.Summary(), check.Equals, `Download refresh of "foo" snap to revision 7`)
+	c.Check(chg.Tasks()[0].Summary(), check.Equals, "Doing a fake download")
+
+	var apiData map[string]any
+	c.Check(chg.Get("api-data", &apiData), check.IsNil)
+	c.Check(apiData["snap-names"], check.DeepEquals, []any{"foo"})
+}
+
+func (s *snapsSuite) TestPostSnapDownloadError(c *check.C) {
+	s.daemonWithOverlordMock()
+
+	defer daemon.MockAssertstateRefreshSnapAssertions(func(*state.State, int, *assertstate.RefreshAssertionsOptions) error { return nil })()
+	defer daemon.MockSnapstateDownloadRefresh(func(ctx context.Context, st *state.State, name string, opts *snapstate.RevisionOptions, userID int) (*state.TaskSet, *snap.Info, error) {
+		return nil, nil, &snap.NotInstalledError{Snap: name}
+	})()
+
+	buf := bytes.NewBufferString(`{"action": "download"}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapNotInstalled)
+}
+
+func (s *snapsSuite) TestPostSnapProceed(c *check.C) {
+	d := s.daemonWithOverlordMock()
+
+	defer daemon.MockSnapstateProceedWithInhibitedRefresh(func(st *state.State, name string) ([]*state.TaskSet, error) {
+		c.Check(name, check.Equals, "foo")
+		t := st.NewTask("fake-refresh", "Doing a fake refresh")
+		return []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})()
+
+	buf := bytes.NewBufferString(`{"action": "proceed"}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "proceed-snap")
+	c.Check(chg.Summary(), check.Equals, `Proceed with inhibited refresh of "foo" snap`)
+	c.Check(chg.Tasks()[0].Summary(), check.Equals, "Doing a fake refresh")
+
+	var apiData map[string]any
+	c.Check(chg.Get("api-data", &apiData), check.IsNil)
+	c.Check(apiData["snap-names"], check.DeepEquals, []any{"foo"})
+}
+
+func (s *snapsSuite) TestPostSnapProceedError(c *check.C) {
+	s.daemonWithOverlordMock()
+
+	defer daemon.MockSnapstateProceedWithInhibitedRefresh(func(st *state.State, name string) ([]*state.TaskSet, error) {
+		return nil, fmt.Errorf(`cannot proceed with refresh of snap %q: refresh is not inhibited`, name)
+	})()
+
+	buf := bytes.NewBufferString(`{"action": "proceed"}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `cannot proceed "foo": cannot proceed with refresh of snap "foo": refresh is not inhibited`)
+}
+
 func (s *snapsSuite) testRevertSnap(inst *daemon.SnapInstruction, c *check.C) {
 	queue := []string{}
 
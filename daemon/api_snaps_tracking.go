@@ -0,0 +1,175 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var assertstateTrackedEnforcedValidationSets = assertstate.TrackedEnforcedValidationSets
+
+// getSnapsTracking reports for each installed snap the tracked channel and
+// installed revision next to the latest revision available in that channel
+// and the constraint enforced validation sets put on the snap, if any.
+func getSnapsTracking(c *Command, r *http.Request, user *auth.UserState) Response {
+	var wanted []string
+	if ns := r.URL.Query().Get("snaps"); len(ns) > 0 {
+		wanted = strutil.CommaSeparatedList(ns)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		st.Unlock()
+		return InternalError("cannot list local snaps: %v", err)
+	}
+	vsets, err := assertstateTrackedEnforcedValidationSets(st)
+	if err != nil {
+		st.Unlock()
+		return InternalError("cannot get enforced validation sets: %v", err)
+	}
+	st.Unlock()
+
+	results := make([]*client.SnapTracking, 0, len(snapStates))
+	var current []*store.CurrentSnap
+	var actions []*store.SnapAction
+	for name, snapst := range snapStates {
+		if len(wanted) > 0 && !strutil.ListContains(wanted, name) {
+			continue
+		}
+		si := snapst.CurrentSideInfo()
+		if si == nil {
+			continue
+		}
+
+		res := &client.SnapTracking{
+			Name:            name,
+			TrackingChannel: snapst.TrackingChannel,
+			Revision:        si.Revision,
+		}
+		pres, err := vsets.Presence(naming.NewSnapRef(si.RealName, si.SnapID))
+		if err != nil {
+			return InternalError("cannot check snap %q against validation sets: %v", name, err)
+		}
+		if len(pres.Sets) > 0 {
+			res.Constraint = validationSetsConstraint(pres.PresenceConstraint)
+		}
+		results = append(results, res)
+
+		if si.SnapID == "" || snapst.TrackingChannel == "" {
+			// not from the store
+			continue
+		}
+		current = append(current, &store.CurrentSnap{
+			InstanceName:    name,
+			SnapID:          si.SnapID,
+			Revision:        si.Revision,
+			TrackingChannel: snapst.TrackingChannel,
+			CohortKey:       snapst.CohortKey,
+		})
+		// the validation sets are left out on purpose, the latest
+		// revision of the channel is reported next to the constraint
+		actions = append(actions, &store.SnapAction{
+			Action:       "refresh",
+			InstanceName: name,
+			SnapID:       si.SnapID,
+			Channel:      snapst.TrackingChannel,
+			CohortKey:    snapst.CohortKey,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	if len(actions) > 0 {
+		latest := latestChannelRevisions(r.Context(), storeFrom(c.d), current, actions, user)
+		for _, res := range results {
+			if rev, ok := latest[res.Name]; ok {
+				res.LatestRevision = &rev
+			}
+		}
+	}
+
+	return SyncResponse(results)
+}
+
+// latestChannelRevisions asks the store for the latest revisions of the
+// tracked channels of the given snaps. The snaps for which the store could
+// not be asked are left out, so that the rest of the report is still
+// available while offline.
+func latestChannelRevisions(ctx context.Context, theStore snapstate.StoreService, current []*store.CurrentSnap, actions []*store.SnapAction, user *auth.UserState) map[string]snap.Revision {
+	latest := make(map[string]snap.Revision, len(current))
+	sars, _, err := theStore.SnapAction(ctx, current, actions, nil, user, nil)
+	for _, sar := range sars {
+		latest[sar.Info.InstanceName()] = sar.Info.Revision
+	}
+	if err == nil {
+		return latest
+	}
+	var saErr *store.SnapActionError
+	if !errors.As(err, &saErr) {
+		logger.Noticef("cannot get the latest revisions of the tracked channels: %v", err)
+		return latest
+	}
+	for _, cur := range current {
+		if refreshErr, ok := saErr.Refresh[cur.InstanceName]; ok {
+			if errors.Is(refreshErr, store.ErrNoUpdateAvailable) {
+				latest[cur.InstanceName] = cur.Revision
+			} else {
+				logger.Debugf("cannot get the latest revision of the tracked channel of %q: %v", cur.InstanceName, refreshErr)
+			}
+		}
+	}
+	if len(saErr.Other) > 0 {
+		logger.Noticef("cannot get the latest revisions of the tracked channels: %v", err)
+	}
+	return latest
+}
+
+func validationSetsConstraint(pres snapasserts.PresenceConstraint) *client.ValidationSetsConstraint {
+	cstr := &client.ValidationSetsConstraint{
+		Presence:       string(pres.Presence),
+		ValidationSets: make([]string, 0, len(pres.Sets)),
+	}
+	for _, key := range pres.Sets {
+		cstr.ValidationSets = append(cstr.ValidationSets, key.String())
+	}
+	// invalid snaps carry a placeholder revision
+	if pres.Revision.N > 0 {
+		rev := pres.Revision
+		cstr.Revision = &rev
+	}
+	return cstr
+}
@@ -0,0 +1,220 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/store"
+)
+
+var _ = check.Suite(&snapsTrackingSuite{})
+
+type snapsTrackingSuite struct {
+	apiBaseSuite
+}
+
+func (s *snapsTrackingSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.InterfaceOpenAccess{Interfaces: []string{"snap-refresh-observe", "desktop-launch"}})
+}
+
+func (s *snapsTrackingSuite) setInstalled(st *state.State, name, channel string, rev snap.Revision) {
+	si := &snap.SideInfo{
+		RealName: name,
+		SnapID:   snaptest.AssertedSnapID(name),
+		Revision: rev,
+	}
+	snapstate.Set(st, name, &snapstate.SnapState{
+		Active:          true,
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:         rev,
+		TrackingChannel: channel,
+	})
+}
+
+func (s *snapsTrackingSuite) mockValidationSets(c *check.C) {
+	vs, err := s.StoreSigning.Sign(asserts.ValidationSetType, map[string]any{
+		"authority-id": s.StoreSigning.AuthorityID,
+		"account-id":   s.StoreSigning.AuthorityID,
+		"series":       "16",
+		"name":         "my-set",
+		"sequence":     "3",
+		"timestamp":    "2030-11-06T09:16:26Z",
+		"snaps": []any{
+			map[string]any{
+				"id":       snaptest.AssertedSnapID("foo"),
+				"name":     "foo",
+				"presence": "required",
+				"revision": "10",
+			},
+			map[string]any{
+				"id":       snaptest.AssertedSnapID("baz"),
+				"name":     "baz",
+				"presence": "invalid",
+			},
+		},
+	}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	s.AddCleanup(daemon.MockAssertstateTrackedEnforcedValidationSets(func(*state.State, ...*asserts.ValidationSet) (*snapasserts.ValidationSets, error) {
+		vsets := snapasserts.NewValidationSets()
+		c.Assert(vsets.Add(vs.(*asserts.ValidationSet)), check.IsNil)
+		return vsets, nil
+	}))
+}
+
+func (s *snapsTrackingSuite) TestSnapsTracking(c *check.C) {
+	d := s.daemon(c)
+	s.mockValidationSets(c)
+
+	st := d.Overlord().State()
+	st.Lock()
+	s.setInstalled(st, "foo", "latest/stable", snap.R(10))
+	s.setInstalled(st, "bar", "2.0/edge", snap.R(5))
+	st.Unlock()
+
+	// the store offers a revision of foo beyond the one the validation
+	// set pins it to
+	s.rsnaps = []*snap.Info{{
+		SideInfo: snap.SideInfo{RealName: "bar", Revision: snap.R(7)},
+	}, {
+		SideInfo: snap.SideInfo{RealName: "foo", Revision: snap.R(11)},
+	}}
+
+	req, err := http.NewRequest("GET", "/v2/snaps?select=tracking", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	c.Assert(s.actions, check.HasLen, 2)
+	sort.Slice(s.actions, func(i, j int) bool {
+		return s.actions[i].InstanceName < s.actions[j].InstanceName
+	})
+	c.Check(s.actions, check.DeepEquals, []*store.SnapAction{{
+		Action:       "refresh",
+		InstanceName: "bar",
+		SnapID:       snaptest.AssertedSnapID("bar"),
+		Channel:      "2.0/edge",
+	}, {
+		Action:       "refresh",
+		InstanceName: "foo",
+		SnapID:       snaptest.AssertedSnapID("foo"),
+		Channel:      "latest/stable",
+	}})
+	c.Check(s.currentSnaps, check.HasLen, 2)
+	c.Check(s.user, check.IsNil)
+
+	latestBar := snap.R(7)
+	latestFoo := snap.R(11)
+	pinned := snap.R(10)
+	c.Check(rsp.Result, check.DeepEquals, []*client.SnapTracking{{
+		Name:            "bar",
+		TrackingChannel: "2.0/edge",
+		Revision:        snap.R(5),
+		LatestRevision:  &latestBar,
+	}, {
+		Name:            "foo",
+		TrackingChannel: "latest/stable",
+		Revision:        snap.R(10),
+		LatestRevision:  &latestFoo,
+		Constraint: &client.ValidationSetsConstraint{
+			Presence:       "required",
+			Revision:       &pinned,
+			ValidationSets: []string{"16/" + s.StoreSigning.AuthorityID + "/my-set/3"},
+		},
+	}})
+}
+
+func (s *snapsTrackingSuite) TestSnapsTrackingFiltered(c *check.C) {
+	d := s.daemon(c)
+	s.mockValidationSets(c)
+
+	st := d.Overlord().State()
+	st.Lock()
+	s.setInstalled(st, "foo", "latest/stable", snap.R(10))
+	s.setInstalled(st, "baz", "latest/stable", snap.R(1))
+	st.Unlock()
+
+	s.err = &store.SnapActionError{Refresh: map[string]error{
+		"baz": store.ErrNoUpdateAvailable,
+	}}
+
+	req, err := http.NewRequest("GET", "/v2/snaps?select=tracking&snaps=baz", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	c.Assert(s.actions, check.HasLen, 1)
+	c.Check(s.actions[0].InstanceName, check.Equals, "baz")
+
+	latest := snap.R(1)
+	c.Check(rsp.Result, check.DeepEquals, []*client.SnapTracking{{
+		Name:            "baz",
+		TrackingChannel: "latest/stable",
+		Revision:        snap.R(1),
+		LatestRevision:  &latest,
+		Constraint: &client.ValidationSetsConstraint{
+			Presence:       "invalid",
+			ValidationSets: []string{"16/" + s.StoreSigning.AuthorityID + "/my-set/3"},
+		},
+	}})
+}
+
+func (s *snapsTrackingSuite) TestSnapsTrackingStoreError(c *check.C) {
+	d := s.daemon(c)
+	s.mockValidationSets(c)
+
+	st := d.Overlord().State()
+	st.Lock()
+	s.setInstalled(st, "foo", "latest/stable", snap.R(10))
+	st.Unlock()
+
+	s.err = errors.New("network is down")
+
+	req, err := http.NewRequest("GET", "/v2/snaps?select=tracking", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	// the rest of the report is still available
+	pinned := snap.R(10)
+	c.Check(rsp.Result, check.DeepEquals, []*client.SnapTracking{{
+		Name:            "foo",
+		TrackingChannel: "latest/stable",
+		Revision:        snap.R(10),
+		Constraint: &client.ValidationSetsConstraint{
+			Presence:       "required",
+			Revision:       &pinned,
+			ValidationSets: []string{"16/" + s.StoreSigning.AuthorityID + "/my-set/3"},
+		},
+	}})
+}
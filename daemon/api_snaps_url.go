@@ -0,0 +1,92 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (inst *snapInstruction) validateURL() error {
+	if inst.multiSnap {
+		return errors.New("url can only be specified for single snap operations")
+	}
+	if inst.Action != installCmdAction {
+		return errors.New("url can only be specified for install")
+	}
+	u, err := url.Parse(inst.URL)
+	if err != nil {
+		return fmt.Errorf("cannot parse url: %v", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("url must be an https url: %q", inst.URL)
+	}
+	if inst.Channel != "" || !inst.Revision.Unset() || inst.CohortKey != "" {
+		return errors.New("url cannot be combined with channel, revision or cohort-key")
+	}
+	if len(inst.CompsRaw) > 0 {
+		return errors.New("url cannot be combined with components")
+	}
+	return nil
+}
+
+// snapInstallFromURL installs a snap downloaded from an arbitrary https
+// url. The download, and the verification of the downloaded file against
+// the store assertions, happens in the install change.
+func snapInstallFromURL(c *Command, r *http.Request, inst *snapInstruction) Response {
+	if err := snap.ValidateInstanceName(inst.Snaps[0]); err != nil {
+		return BadRequest(err.Error())
+	}
+	instanceName := inst.Snaps[0]
+
+	flags, err := inst.installFlags()
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+
+	logger.Noticef("Installing snap %q from %q", instanceName, inst.URL)
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	tset, err := snapstateInstallFromURL(st, instanceName, inst.URL, inst.userID, flags)
+	if err != nil {
+		return errToResponse(err, []string{instanceName}, InternalError, "cannot install snap from url: %v")
+	}
+
+	msg := fmt.Sprintf(i18n.G("Install %q snap from %q"), instanceName, inst.URL)
+	chg := newChange(r.Context(), st, installSnapChangeKind, msg, []*state.TaskSet{tset}, []string{instanceName})
+	chg.Set("api-data", map[string]any{
+		"snap-name":  instanceName,
+		"snap-names": []string{instanceName},
+	})
+
+	ensureStateSoon(st)
+
+	return AsyncResponse(nil, chg.ID())
+}
@@ -0,0 +1,126 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = check.Suite(&snapURLSuite{})
+
+type snapURLSuite struct {
+	apiBaseSuite
+}
+
+func (s *snapURLSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage"})
+}
+
+func (s *snapURLSuite) urlInstallReq(c *check.C, snapName, body string) *http.Request {
+	req, err := http.NewRequest("POST", "/v2/snaps/"+snapName, bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func (s *snapURLSuite) TestInstallFromURL(c *check.C) {
+	d := s.daemonWithOverlordMockAndStore()
+
+	url := "https://example.com/foo.snap"
+	defer daemon.MockSnapstateInstallFromURL(func(st *state.State, name, rawURL string, userID int, flags snapstate.Flags) (*state.TaskSet, error) {
+		c.Check(name, check.Equals, "foo")
+		c.Check(rawURL, check.Equals, url)
+		c.Check(userID, check.Equals, 0)
+		c.Check(flags, check.Equals, snapstate.Flags{DevMode: true})
+		t := st.NewTask("download-snap-from-url", "...")
+		return state.NewTaskSet(t), nil
+	})()
+
+	req := s.urlInstallReq(c, "foo", fmt.Sprintf(`{"action": "install", "url": %q, "devmode": true}`, url))
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "install-snap")
+	c.Check(chg.Summary(), check.Equals, fmt.Sprintf(`Install "foo" snap from %q`, url))
+	// the snap is downloaded by the change
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "download-snap-from-url")
+	var apiData map[string]any
+	c.Assert(chg.Get("api-data", &apiData), check.IsNil)
+	c.Check(apiData, check.DeepEquals, map[string]any{
+		"snap-name":  "foo",
+		"snap-names": []any{"foo"},
+	})
+}
+
+func (s *snapURLSuite) TestInstallFromURLConflict(c *check.C) {
+	s.daemonWithOverlordMockAndStore()
+
+	defer daemon.MockSnapstateInstallFromURL(func(st *state.State, name, rawURL string, userID int, flags snapstate.Flags) (*state.TaskSet, error) {
+		return nil, &snapstate.ChangeConflictError{Snap: "foo", ChangeKind: "install-snap"}
+	})()
+
+	req := s.urlInstallReq(c, "foo", `{"action": "install", "url": "https://example.com/foo.snap"}`)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 409)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapChangeConflict)
+}
+
+func (s *snapURLSuite) TestInstallFromURLValidation(c *check.C) {
+	s.daemonWithOverlordMockAndStore()
+
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{{
+		body: `{"action": "refresh", "url": "https://example.com/foo.snap"}`,
+		err:  "url can only be specified for install",
+	}, {
+		body: `{"action": "install", "url": "http://example.com/foo.snap"}`,
+		err:  `url must be an https url: "http://example.com/foo.snap"`,
+	}, {
+		body: `{"action": "install", "url": "https://example.com/foo.snap", "channel": "edge"}`,
+		err:  "url cannot be combined with channel, revision or cohort-key",
+	}, {
+		body: `{"action": "install", "url": "https://example.com/foo.snap", "components": ["comp"]}`,
+		err:  "url cannot be combined with components",
+	}} {
+		req := s.urlInstallReq(c, "foo", tc.body)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(tc.body))
+		c.Check(rspe.Message, check.Equals, tc.err, check.Commentf(tc.body))
+	}
+}
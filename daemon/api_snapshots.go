@@ -30,6 +30,7 @@ import (
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/snapshotstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -45,6 +46,7 @@ var snapshotCmd = &Command{
 	Actions:     []string{"check", "restore", "forget"},
 	ReadAccess:  openAccess{},
 	WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
+	Throttled:   true,
 }
 
 var snapshotExportCmd = &Command{
@@ -170,17 +172,23 @@ func changeSnapshots(c *Command, r *http.Request, user *auth.UserState) Response
 		return InternalError("%v", err)
 	}
 
-	chg := newChange(st, changeKind, action.String(), []*state.TaskSet{ts}, affected)
+	chg := newChange(r.Context(), st, changeKind, action.String(), []*state.TaskSet{ts}, affected)
 	chg.Set("api-data", map[string]any{"snap-names": affected})
 	ensureStateSoon(st)
 
 	return AsyncResponse(nil, chg.ID())
 }
 
+var _ = registerAPIFeature("snapshot-export-resume")
+
 // getSnapshotExport streams an archive containing an export of existing snapshots.
 //
 // The snapshots are re-packaged into a single uncompressed tar archive and
 // internally contain multiple zip files.
+//
+// Every export is identified by a session, returned as its ETag. Passing the
+// session back via If-Range together with a Range header resumes an
+// interrupted transfer, as long as the snapshot set did not change.
 func getSnapshotExport(c *Command, r *http.Request, user *auth.UserState) Response {
 	st := c.d.overlord.State()
 	st.Lock()
@@ -197,6 +205,20 @@ func getSnapshotExport(c *Command, r *http.Request, user *auth.UserState) Respon
 	if err != nil {
 		return BadRequest("cannot export %v: %v", setID, err)
 	}
+
+	// ranges are only honored when resuming a known session, as otherwise
+	// the bytes would not match those of any previous transfer
+	resume := false
+	if r.Header.Get("Range") != "" {
+		if session := strings.Trim(r.Header.Get("If-Range"), `"`); session != "" {
+			if err := export.ResumeSession(session); err != nil {
+				logger.Debugf("serving whole snapshot export: %v", err)
+			} else {
+				resume = true
+			}
+		}
+	}
+
 	// init (size calculation) can be slow so drop the lock
 	st.Unlock()
 	err = export.Init()
@@ -205,7 +227,7 @@ func getSnapshotExport(c *Command, r *http.Request, user *auth.UserState) Respon
 		return BadRequest("cannot calculate size of exported snapshot %v: %v", setID, err)
 	}
 
-	return &snapshotExportResponse{SnapshotExport: export, setID: setID, st: st}
+	return &snapshotExportResponse{SnapshotExport: export, setID: setID, st: st, resume: resume}
 }
 
 func doSnapshotImport(c *Command, r *http.Request, user *auth.UserState) Response {
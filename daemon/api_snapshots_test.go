@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
 
@@ -372,6 +373,62 @@ func (s *snapshotSuite) TestExportSnapshots(c *check.C) {
 	c.Check(snapshotExportCalled, check.Equals, 1)
 }
 
+func (s *snapshotSuite) TestExportSnapshotsResume(c *check.C) {
+	defer daemon.MockSnapshotExport(func(ctx context.Context, st *state.State, setID uint64) (*snapshotstate.SnapshotExport, error) {
+		return &snapshotstate.SnapshotExport{}, nil
+	})()
+
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/v2/snapshots/1/export", nil)
+		c.Assert(err, check.IsNil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rsp := s.req(c, req, nil, actionIsExpected)
+		rec := httptest.NewRecorder()
+		rsp.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get(nil)
+	c.Assert(rec.Code, check.Equals, 200)
+	c.Check(rec.Header().Get("Accept-Ranges"), check.Equals, "bytes")
+	c.Check(rec.Header().Get("Content-Type"), check.Equals, client.SnapshotExportMediaType)
+	etag := rec.Header().Get("ETag")
+	c.Check(etag, check.Not(check.Equals), "")
+	full := rec.Body.Bytes()
+	c.Check(rec.Header().Get("Content-Length"), check.Equals, strconv.Itoa(len(full)))
+
+	// resuming the session
+	rec = get(map[string]string{"Range": "bytes=100-", "If-Range": etag})
+	c.Assert(rec.Code, check.Equals, 206)
+	c.Check(rec.Header().Get("ETag"), check.Equals, etag)
+	c.Check(rec.Header().Get("Content-Range"), check.Equals, fmt.Sprintf("bytes 100-%d/%d", len(full)-1, len(full)))
+	c.Check(rec.Header().Get("Content-Length"), check.Equals, strconv.Itoa(len(full)-100))
+	c.Check(rec.Body.Bytes(), check.DeepEquals, full[100:])
+
+	// bounded range
+	rec = get(map[string]string{"Range": "bytes=10-19", "If-Range": etag})
+	c.Assert(rec.Code, check.Equals, 206)
+	c.Check(rec.Header().Get("Content-Range"), check.Equals, fmt.Sprintf("bytes 10-19/%d", len(full)))
+	c.Check(rec.Body.Bytes(), check.DeepEquals, full[10:20])
+
+	// unsatisfiable range
+	rec = get(map[string]string{"Range": fmt.Sprintf("bytes=%d-", len(full)), "If-Range": etag})
+	c.Check(rec.Code, check.Equals, 416)
+	c.Check(rec.Header().Get("Content-Range"), check.Equals, fmt.Sprintf("bytes */%d", len(full)))
+
+	// ranges without a session, or with an unknown one, get the whole export
+	for _, headers := range []map[string]string{
+		{"Range": "bytes=100-"},
+		{"Range": "bytes=100-", "If-Range": `"1234-abcd"`},
+	} {
+		rec = get(headers)
+		c.Check(rec.Code, check.Equals, 200)
+		c.Check(rec.Body.Len(), check.Equals, len(full))
+	}
+}
+
 func (s *snapshotSuite) TestExportSnapshotsBadRequestOnNonNumericID(c *check.C) {
 	req, err := http.NewRequest("GET", "/v2/snapshots/xxx/export", nil)
 	c.Assert(err, check.IsNil)
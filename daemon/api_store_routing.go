@@ -0,0 +1,136 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+)
+
+var storeRoutingCmd = &Command{
+	Path:        "/v2/store-routing",
+	GET:         getStoreRouting,
+	POST:        postStoreRouting,
+	Actions:     []string{"set", "remove"},
+	ReadAccess:  openAccess{},
+	WriteAccess: rootAccess{},
+}
+
+var _ = registerAPIFeature("store-routing")
+
+// getStoreRouting reports the store each installed or explicitly routed
+// snap resolves to.
+func getStoreRouting(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	routing, err := devicestate.SnapStoreRouting(st)
+	if err != nil {
+		return InternalError("cannot get store routing: %v", err)
+	}
+	installed, err := snapstate.All(st)
+	if err != nil {
+		return InternalError("cannot get store routing: %v", err)
+	}
+
+	names := make(map[string]bool, len(installed)+len(routing.Routes))
+	for _, snapst := range installed {
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			continue
+		}
+		if info.SnapID == "" {
+			// not from a store
+			continue
+		}
+		names[info.SnapName()] = true
+	}
+	for name := range routing.Routes {
+		names[name] = true
+	}
+
+	result := client.StoreRouting{
+		DeviceStore:    routing.DeviceStore,
+		FriendlyStores: routing.FriendlyStores,
+		Snaps:          make([]client.SnapStoreRoute, 0, len(names)),
+	}
+	for name := range names {
+		storeID, routed := routing.SnapStore(name)
+		result.Snaps = append(result.Snaps, client.SnapStoreRoute{
+			Snap:   name,
+			Store:  storeID,
+			Routed: routed,
+		})
+	}
+	sort.Slice(result.Snaps, func(i, j int) bool {
+		return result.Snaps[i].Snap < result.Snaps[j].Snap
+	})
+
+	return SyncResponse(result)
+}
+
+type postStoreRoutingData struct {
+	Action string `json:"action"`
+	Snap   string `json:"snap"`
+	Store  string `json:"store,omitempty"`
+}
+
+func postStoreRouting(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postStoreRoutingData
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&data); err != nil {
+		return BadRequest("cannot decode store routing request body: %v", err)
+	}
+	if data.Snap == "" {
+		return BadRequest("missing snap name")
+	}
+
+	var storeID string
+	switch data.Action {
+	case "set":
+		if data.Store == "" {
+			return BadRequest("missing store")
+		}
+		storeID = data.Store
+	case "remove":
+		if data.Store != "" {
+			return BadRequest("unexpected store for %q action", data.Action)
+		}
+	default:
+		return BadRequest("unknown store routing action %q", data.Action)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if err := devicestate.RouteSnapToStore(st, data.Snap, storeID); err != nil {
+		return BadRequest("%v", err)
+	}
+
+	return SyncResponse(nil)
+}
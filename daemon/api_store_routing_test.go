@@ -0,0 +1,117 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/storecontext"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = check.Suite(&storeRoutingSuite{})
+
+type storeRoutingSuite struct {
+	apiBaseSuite
+}
+
+func (s *storeRoutingSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.expectWriteAccess(daemon.RootAccess{})
+}
+
+func (s *storeRoutingSuite) TestGetStoreRouting(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), true, "")
+	// local snaps do not resolve to any store
+	s.mkInstalledInState(c, d, "local", "", "v1", snap.R(-1), true, "")
+
+	st := d.Overlord().State()
+	st.Lock()
+	c.Assert(storecontext.SetSnapStoreRoute(st, "bar", "friendly-store"), check.IsNil)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/store-routing", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, client.StoreRouting{
+		Snaps: []client.SnapStoreRoute{
+			{Snap: "bar", Store: "friendly-store", Routed: true},
+			{Snap: "foo", Store: "global"},
+		},
+	})
+}
+
+func (s *storeRoutingSuite) TestSetRemoveStoreRoute(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	body := bytes.NewBufferString(`{"action": "set", "snap": "foo", "store": "global"}`)
+	req, err := http.NewRequest("POST", "/v2/store-routing", body)
+	c.Assert(err, check.IsNil)
+	s.syncReq(c, req, nil, actionIsExpected)
+
+	st.Lock()
+	routes, err := storecontext.SnapStoreRoutes(st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(routes, check.DeepEquals, map[string]string{"foo": "global"})
+
+	body = bytes.NewBufferString(`{"action": "remove", "snap": "foo"}`)
+	req, err = http.NewRequest("POST", "/v2/store-routing", body)
+	c.Assert(err, check.IsNil)
+	s.syncReq(c, req, nil, actionIsExpected)
+
+	st.Lock()
+	routes, err = storecontext.SnapStoreRoutes(st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(routes, check.HasLen, 0)
+}
+
+func (s *storeRoutingSuite) TestPostStoreRoutingErrors(c *check.C) {
+	s.daemon(c)
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{`, `cannot decode store routing request body: .*`},
+		{`{"action": "set", "store": "global"}`, `missing snap name`},
+		{`{"action": "set", "snap": "foo"}`, `missing store`},
+		{`{"action": "remove", "snap": "foo", "store": "global"}`, `unexpected store for "remove" action`},
+		{`{"action": "frobnicate", "snap": "foo"}`, `unknown store routing action "frobnicate"`},
+		{`{"action": "set", "snap": "foo", "store": "other-store"}`, `cannot route snap "foo" to store "other-store": .*`},
+		{`{"action": "set", "snap": "Foo!", "store": "global"}`, `invalid snap name: "Foo!"`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/store-routing", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%s", t.body))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf("%s", t.body))
+	}
+}
@@ -0,0 +1,106 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/janitorstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+)
+
+var systemCmd = &Command{
+	Path:        "/v2/system",
+	POST:        postSystem,
+	Actions:     []string{"cleanup"},
+	WriteAccess: rootAccess{},
+}
+
+var _ = registerAPIFeature("cleanup")
+
+var cleanupChangeKind = swfeats.RegisterChangeKind("cleanup")
+
+type postSystemData struct {
+	Action  string                       `json:"action"`
+	Targets []janitorstate.CleanupTarget `json:"targets"`
+	DryRun  bool                         `json:"dry-run"`
+}
+
+type cleanupResult struct {
+	// Reclaimable is the disk space, in bytes, cleaning up each of the
+	// targets reclaims.
+	Reclaimable map[janitorstate.CleanupTarget]int64 `json:"reclaimable"`
+}
+
+func postSystem(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postSystemData
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&data); err != nil {
+		return BadRequest("cannot decode system request body: %v", err)
+	}
+
+	switch data.Action {
+	case "cleanup":
+		return postSystemCleanup(c, r, &data)
+	default:
+		return BadRequest("unknown system action %q", data.Action)
+	}
+}
+
+// postSystemCleanup reports how much disk space cleaning up the requested
+// targets, or all of them if none is given, reclaims and, unless this is a
+// dry run, cleans them up as a change.
+func postSystemCleanup(c *Command, r *http.Request, data *postSystemData) Response {
+	targets := data.Targets
+	if len(targets) == 0 {
+		targets = janitorstate.CleanupTargets
+	}
+	if err := janitorstate.ValidateCleanupTargets(targets); err != nil {
+		return BadRequest("cannot clean up: %v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	reclaimable, err := janitorstate.Reclaimable(r.Context(), st, targets)
+	if err != nil {
+		return InternalError("cannot compute reclaimable disk space: %v", err)
+	}
+	result := &cleanupResult{Reclaimable: reclaimable}
+	if data.DryRun {
+		return SyncResponse(result)
+	}
+
+	tss, err := janitorstate.Cleanup(r.Context(), st, targets)
+	if err != nil {
+		return errToResponse(err, nil, InternalError, "cannot clean up: %v")
+	}
+	chg := newChange(r.Context(), st, cleanupChangeKind, "Reclaim disk space", tss, nil)
+	chg.Set("api-data", result)
+	if len(tss) == 0 {
+		chg.SetStatus(state.DoneStatus)
+	}
+	ensureStateSoon(st)
+	return AsyncResponse(nil, chg.ID())
+}
@@ -0,0 +1,130 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/janitorstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = check.Suite(&systemSuite{})
+
+type systemSuite struct {
+	apiBaseSuite
+}
+
+func (s *systemSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectWriteAccess(daemon.RootAccess{})
+
+	c.Assert(os.MkdirAll(dirs.SnapDownloadCacheDir, 0755), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapDownloadCacheDir, "blob"), []byte("1234"), 0644), check.IsNil)
+}
+
+func (s *systemSuite) postSystem(c *check.C, body string) *http.Request {
+	req, err := http.NewRequest("POST", "/v2/system", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	return req
+}
+
+func (s *systemSuite) TestCleanupDryRun(c *check.C) {
+	d := s.daemon(c)
+
+	rsp := s.syncReq(c, s.postSystem(c, `{"action": "cleanup", "dry-run": true}`), nil, actionIsExpected)
+	res, ok := rsp.Result.(*daemon.CleanupResult)
+	c.Assert(ok, check.Equals, true)
+	c.Check(res.Reclaimable, check.DeepEquals, map[janitorstate.CleanupTarget]int64{
+		janitorstate.OldRevisions:     0,
+		janitorstate.ExpiredSnapshots: 0,
+		janitorstate.DownloadCache:    4,
+		janitorstate.ChangesArchive:   0,
+	})
+
+	rsp = s.syncReq(c, s.postSystem(c, `{"action": "cleanup", "targets": ["download-cache"], "dry-run": true}`), nil, actionIsExpected)
+	res, ok = rsp.Result.(*daemon.CleanupResult)
+	c.Assert(ok, check.Equals, true)
+	c.Check(res.Reclaimable, check.DeepEquals, map[janitorstate.CleanupTarget]int64{
+		janitorstate.DownloadCache: 4,
+	})
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+}
+
+func (s *systemSuite) TestCleanup(c *check.C) {
+	soon := 0
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {
+		soon++
+	})
+	defer restore()
+
+	d := s.daemon(c)
+
+	rsp := s.asyncReq(c, s.postSystem(c, `{"action": "cleanup", "targets": ["download-cache", "changes-archive"]}`), nil, actionIsExpected)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "cleanup")
+	c.Check(chg.Summary(), check.Equals, "Reclaim disk space")
+	c.Assert(chg.Tasks(), check.HasLen, 2)
+	c.Check(chg.Tasks()[0].Kind(), check.Equals, "janitor-clear-download-cache")
+	c.Check(chg.Tasks()[1].Kind(), check.Equals, "janitor-prune-changes")
+	var data map[string]any
+	c.Assert(chg.Get("api-data", &data), check.IsNil)
+	c.Check(data, check.DeepEquals, map[string]any{
+		"reclaimable": map[string]any{
+			"download-cache":  4.0,
+			"changes-archive": 0.0,
+		},
+	})
+	c.Check(soon, check.Equals, 1)
+}
+
+func (s *systemSuite) TestPostSystemErrors(c *check.C) {
+	s.daemon(c)
+
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "cleanup", "targets": ["foo"]}`, `cannot clean up: unknown cleanup target "foo"`},
+		{`{"action": "foo"}`, `unknown system action "foo"`},
+		{`{"action": `, `cannot decode system request body: .*`},
+	} {
+		rspe := s.errorReq(c, s.postSystem(c, tc.body), nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(tc.body))
+		c.Check(rspe.Message, check.Matches, tc.err, check.Commentf(tc.body))
+	}
+}
@@ -20,10 +20,12 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/gadget/device"
@@ -42,7 +44,9 @@ var systemVolumesCmd = &Command{
 	POST: postSystemVolumesAction,
 	Actions: []string{
 		"generate-recovery-key", "check-recovery-key", "replace-recovery-key",
-		"check-passphrase", "check-pin", "change-passphrase"},
+		"add-recovery-key", "remove-keyslots",
+		"check-passphrase", "check-pin", "change-passphrase", "change-pin",
+		"mount-volume", "unmount-volume"},
 	// anyone can enumerate key slots.
 	ReadAccess: interfaceOpenAccess{Interfaces: []string{"snap-fde-control"}},
 	WriteAccess: byActionAccess{
@@ -53,6 +57,7 @@ var systemVolumesCmd = &Command{
 			// anyone can change passphrase given they know the old passphrase
 			// TODO:FDEM: rate limiting is needed to avoid DA lockout.
 			"change-passphrase": interfaceOpenAccess{Interfaces: []string{"snap-fde-control"}},
+			"change-pin":        interfaceOpenAccess{Interfaces: []string{"snap-fde-control"}},
 			// only root and admins (authenticated via Polkit) can do recovery key
 			// related actions.
 			"check-recovery-key": interfaceRootAccess{
@@ -70,6 +75,23 @@ var systemVolumesCmd = &Command{
 				Interfaces: []string{"snap-fde-control"},
 				Polkit:     polkitActionManageFDE,
 			},
+			"add-recovery-key": interfaceRootAccess{
+				Interfaces: []string{"snap-fde-control"},
+				Polkit:     polkitActionManageFDE,
+			},
+			"remove-keyslots": interfaceRootAccess{
+				Interfaces: []string{"snap-fde-control"},
+				Polkit:     polkitActionManageFDE,
+			},
+			// only root and admins can mount ubuntu-save on demand.
+			"mount-volume": interfaceRootAccess{
+				Interfaces: []string{"snap-fde-control"},
+				Polkit:     polkitActionManageFDE,
+			},
+			"unmount-volume": interfaceRootAccess{
+				Interfaces: []string{"snap-fde-control"},
+				Polkit:     polkitActionManageFDE,
+			},
 		},
 		// by default, all actions are only allowed for root.
 		Default: rootAccess{},
@@ -78,14 +100,20 @@ var systemVolumesCmd = &Command{
 
 var fdeReplaceRecoveryKeyChangeKind = swfeats.RegisterChangeKind("fde-replace-recovery-key")
 var fdeChangePassphraseChangeKind = swfeats.RegisterChangeKind("fde-change-passphrase")
+var fdeAddRecoveryKeyChangeKind = swfeats.RegisterChangeKind("fde-add-recovery-key")
+var fdeRemoveKeyslotsChangeKind = swfeats.RegisterChangeKind("fde-remove-keyslots")
 
 var (
 	fdestateReplaceRecoveryKey = fdestate.ReplaceRecoveryKey
 	fdestateChangeAuth         = fdestate.ChangeAuth
+	fdestateAddRecoveryKey     = fdestate.AddRecoveryKey
+	fdestateRemoveKeyslots     = fdestate.RemoveKeyslots
 	fdeMgrGenerateRecoveryKey  = (*fdestate.FDEManager).GenerateRecoveryKey
 	fdeMgrCheckRecoveryKey     = (*fdestate.FDEManager).CheckRecoveryKey
 
 	devicestateGetVolumeStructuresWithKeyslots = devicestate.GetVolumeStructuresWithKeyslots
+	devicestateMountSystemVolume               = devicestate.MountSystemVolume
+	devicestateUnmountSystemVolume             = devicestate.UnmountSystemVolume
 )
 
 func parseSystemVolumesOptionsFromURL(q url.Values) (opts *client.SystemVolumesOptions, err error) {
@@ -196,8 +224,17 @@ type systemVolumesActionRequest struct {
 	// KeyID is the recovery key id.
 	KeyID string `json:"key-id"`
 
+	// VolumeName is the gadget structure name of the volume to mount
+	// or unmount.
+	VolumeName string `json:"volume-name"`
+	ReadOnly   bool   `json:"read-only"`
+	// RelockAfter is the duration after which a mounted volume is
+	// unmounted again.
+	RelockAfter string `json:"relock-after"`
+
 	client.QualityCheckOptions
 	client.ChangePassphraseOptions
+	client.ChangePINOptions
 }
 
 func postSystemVolumesAction(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -230,13 +267,23 @@ func postSystemVolumesActionJSON(c *Command, r *http.Request) Response {
 	case "check-recovery-key":
 		return postSystemVolumesActionCheckRecoveryKey(c, &req)
 	case "replace-recovery-key":
-		return postSystemVolumesActionReplaceRecoveryKey(c, &req)
+		return postSystemVolumesActionReplaceRecoveryKey(r.Context(), c, &req)
+	case "add-recovery-key":
+		return postSystemVolumesActionAddRecoveryKey(r.Context(), c, &req)
+	case "remove-keyslots":
+		return postSystemVolumesActionRemoveKeyslots(r.Context(), c, &req)
 	case "check-passphrase":
 		return postSystemVolumesCheckPassphrase(&req)
 	case "check-pin":
 		return postSystemVolumesCheckPIN(&req)
 	case "change-passphrase":
-		return postSystemVolumesActionChangePassphrase(c, &req)
+		return postSystemVolumesActionChangePassphrase(r.Context(), c, &req)
+	case "change-pin":
+		return postSystemVolumesActionChangePIN(c, &req)
+	case "mount-volume":
+		return postSystemVolumesActionMountVolume(c, &req)
+	case "unmount-volume":
+		return postSystemVolumesActionUnmountVolume(c, &req)
 	default:
 		return BadRequest("unsupported system volumes action %q", req.Action)
 	}
@@ -280,7 +327,7 @@ func postSystemVolumesActionCheckRecoveryKey(c *Command, req *systemVolumesActio
 	return SyncResponse(nil)
 }
 
-func postSystemVolumesActionReplaceRecoveryKey(c *Command, req *systemVolumesActionRequest) Response {
+func postSystemVolumesActionReplaceRecoveryKey(ctx context.Context, c *Command, req *systemVolumesActionRequest) Response {
 	if req.KeyID == "" {
 		return BadRequest("system volume action requires key-id to be provided")
 	}
@@ -294,7 +341,52 @@ func postSystemVolumesActionReplaceRecoveryKey(c *Command, req *systemVolumesAct
 		return errToResponse(err, nil, BadRequest, "cannot replace recovery key: %v")
 	}
 
-	chg := newChange(st, fdeReplaceRecoveryKeyChangeKind, "Replace recovery key", []*state.TaskSet{ts}, nil)
+	chg := newChange(ctx, st, fdeReplaceRecoveryKeyChangeKind, "Replace recovery key", []*state.TaskSet{ts}, nil)
+
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, chg.ID())
+}
+
+func postSystemVolumesActionAddRecoveryKey(ctx context.Context, c *Command, req *systemVolumesActionRequest) Response {
+	if req.KeyID == "" {
+		return BadRequest("system volume action requires key-id to be provided")
+	}
+	if len(req.Keyslots) == 0 {
+		return BadRequest("system volume action requires keyslots to be provided")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	ts, err := fdestateAddRecoveryKey(st, req.KeyID, req.Keyslots)
+	if err != nil {
+		return errToResponse(err, nil, BadRequest, "cannot add recovery key: %v")
+	}
+
+	chg := newChange(ctx, st, fdeAddRecoveryKeyChangeKind, "Add recovery key", []*state.TaskSet{ts}, nil)
+
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, chg.ID())
+}
+
+func postSystemVolumesActionRemoveKeyslots(ctx context.Context, c *Command, req *systemVolumesActionRequest) Response {
+	if len(req.Keyslots) == 0 {
+		return BadRequest("system volume action requires keyslots to be provided")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	ts, err := fdestateRemoveKeyslots(st, req.Keyslots)
+	if err != nil {
+		return errToResponse(err, nil, BadRequest, "cannot remove key slots: %v")
+	}
+
+	chg := newChange(ctx, st, fdeRemoveKeyslotsChangeKind, "Remove key slots", []*state.TaskSet{ts}, nil)
 
 	st.EnsureBefore(0)
 
@@ -317,7 +409,7 @@ func postSystemVolumesCheckPIN(req *systemVolumesActionRequest) Response {
 	return postValidatePassphrase(device.AuthModePIN, req.PIN)
 }
 
-func postSystemVolumesActionChangePassphrase(c *Command, req *systemVolumesActionRequest) Response {
+func postSystemVolumesActionChangePassphrase(ctx context.Context, c *Command, req *systemVolumesActionRequest) Response {
 	// TODO:FDEM: allow root to reset passphrase without providing old passphrase.
 	if req.OldPassphrase == "" {
 		return BadRequest("system volume action requires old-passphrase to be provided")
@@ -335,9 +427,70 @@ func postSystemVolumesActionChangePassphrase(c *Command, req *systemVolumesActio
 		return errToResponse(err, nil, BadRequest, "cannot change passphrase: %v")
 	}
 
-	chg := newChange(st, fdeChangePassphraseChangeKind, "Change passphrase", []*state.TaskSet{ts}, nil)
+	chg := newChange(ctx, st, fdeChangePassphraseChangeKind, "Change passphrase", []*state.TaskSet{ts}, nil)
 
 	st.EnsureBefore(0)
 
 	return AsyncResponse(nil, chg.ID())
 }
+
+func postSystemVolumesActionChangePIN(c *Command, req *systemVolumesActionRequest) Response {
+	if req.OldPIN == "" {
+		return BadRequest("system volume action requires old-pin to be provided")
+	}
+	if req.NewPIN == "" {
+		return BadRequest("system volume action requires new-pin to be provided")
+	}
+
+	// TODO:FDEM: create the change with fdestate.ChangeAuth once it supports
+	// changing PINs.
+	return &apiError{
+		Status:  400,
+		Kind:    client.ErrorKindUnsupportedByTargetSystem,
+		Message: "cannot change PIN: changing PINs is not supported yet",
+	}
+}
+
+func postSystemVolumesActionMountVolume(c *Command, req *systemVolumesActionRequest) Response {
+	if req.VolumeName == "" {
+		return BadRequest("system volume action requires volume-name to be provided")
+	}
+	opts := &devicestate.MountSystemVolumeOptions{ReadOnly: req.ReadOnly}
+	if req.RelockAfter != "" {
+		relockAfter, err := time.ParseDuration(req.RelockAfter)
+		if err != nil {
+			return BadRequest("cannot parse relock-after: %v", err)
+		}
+		opts.RelockAfter = relockAfter
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	mnt, err := devicestateMountSystemVolume(st, req.VolumeName, opts)
+	if err != nil {
+		return BadRequest("cannot mount system volume: %v", err)
+	}
+
+	return SyncResponse(mnt)
+}
+
+func postSystemVolumesActionUnmountVolume(c *Command, req *systemVolumesActionRequest) Response {
+	if req.VolumeName == "" {
+		return BadRequest("system volume action requires volume-name to be provided")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if err := devicestateUnmountSystemVolume(st, req.VolumeName); err != nil {
+		if errors.Is(err, devicestate.ErrSystemVolumeNotMounted) {
+			return BadRequest("cannot unmount system volume %q: %v", req.VolumeName, err)
+		}
+		return InternalError("cannot unmount system volume: %v", err)
+	}
+
+	return SyncResponse(nil)
+}
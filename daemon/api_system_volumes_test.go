@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -55,6 +56,7 @@ func (s *systemVolumesSuite) SetUpTest(c *C) {
 			"check-passphrase":  daemon.InterfaceOpenAccess{Interfaces: []string{"snap-fde-control"}},
 			"check-pin":         daemon.InterfaceOpenAccess{Interfaces: []string{"snap-fde-control"}},
 			"change-passphrase": daemon.InterfaceOpenAccess{Interfaces: []string{"snap-fde-control"}},
+			"change-pin":        daemon.InterfaceOpenAccess{Interfaces: []string{"snap-fde-control"}},
 			"check-recovery-key": daemon.InterfaceRootAccess{
 				Interfaces: []string{"snap-fde-control", "firmware-updater-support"},
 				Polkit:     "io.snapcraft.snapd.manage-fde",
@@ -67,6 +69,22 @@ func (s *systemVolumesSuite) SetUpTest(c *C) {
 				Interfaces: []string{"snap-fde-control"},
 				Polkit:     "io.snapcraft.snapd.manage-fde",
 			},
+			"add-recovery-key": daemon.InterfaceRootAccess{
+				Interfaces: []string{"snap-fde-control"},
+				Polkit:     "io.snapcraft.snapd.manage-fde",
+			},
+			"remove-keyslots": daemon.InterfaceRootAccess{
+				Interfaces: []string{"snap-fde-control"},
+				Polkit:     "io.snapcraft.snapd.manage-fde",
+			},
+			"mount-volume": daemon.InterfaceRootAccess{
+				Interfaces: []string{"snap-fde-control"},
+				Polkit:     "io.snapcraft.snapd.manage-fde",
+			},
+			"unmount-volume": daemon.InterfaceRootAccess{
+				Interfaces: []string{"snap-fde-control"},
+				Polkit:     "io.snapcraft.snapd.manage-fde",
+			},
 		},
 		Default: daemon.RootAccess{},
 	}
@@ -324,6 +342,142 @@ func (s *systemVolumesSuite) TestSystemVolumesActionReplaceRecoveryKeyMissingKey
 	c.Assert(rsp.Message, Equals, "system volume action requires key-id to be provided")
 }
 
+func (s *systemVolumesSuite) TestSystemVolumesActionAddRecoveryKey(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	called := 0
+	s.AddCleanup(daemon.MockFdestateAddRecoveryKey(func(st *state.State, recoveryKeyID string, keyslots []fdestate.KeyslotRef) (*state.TaskSet, error) {
+		called++
+		c.Check(recoveryKeyID, Equals, "some-key-id")
+		c.Check(keyslots, DeepEquals, []fdestate.KeyslotRef{
+			{ContainerRole: "some-container-role", Name: "some-name"},
+		})
+
+		return state.NewTaskSet(st.NewTask("some-task", "")), nil
+	}))
+
+	body := strings.NewReader(`
+{
+	"action": "add-recovery-key",
+	"key-id": "some-key-id",
+	"keyslots": [
+		{"container-role": "some-container-role", "name": "some-name"}
+	]
+}`)
+	req, err := http.NewRequest("POST", "/v2/system-volumes", body)
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-Type", "application/json")
+
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, Equals, 202)
+
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	tsks := chg.Tasks()
+	st.Unlock()
+	c.Check(chg.Kind(), Equals, "fde-add-recovery-key")
+	c.Assert(tsks, HasLen, 1)
+	c.Check(tsks[0].Kind(), Equals, "some-task")
+	c.Check(called, Equals, 1)
+}
+
+func (s *systemVolumesSuite) TestSystemVolumesActionAddRecoveryKeyErrors(c *C) {
+	s.daemon(c)
+
+	s.AddCleanup(daemon.MockFdestateAddRecoveryKey(func(st *state.State, recoveryKeyID string, keyslots []fdestate.KeyslotRef) (*state.TaskSet, error) {
+		return nil, errors.New("boom!")
+	}))
+
+	for _, tc := range []struct {
+		body, expectedErr string
+	}{
+		{`{"action": "add-recovery-key"}`, "system volume action requires key-id to be provided"},
+		{`{"action": "add-recovery-key", "key-id": "some-key-id"}`, "system volume action requires keyslots to be provided"},
+		{`{"action": "add-recovery-key", "key-id": "some-key-id", "keyslots": [{"container-role": "system-data", "name": "some-name"}]}`, "cannot add recovery key: boom!"},
+	} {
+		req, err := http.NewRequest("POST", "/v2/system-volumes", strings.NewReader(tc.body))
+		c.Assert(err, IsNil)
+		req.Header.Add("Content-Type", "application/json")
+
+		rsp := s.errorReq(c, req, nil, actionIsExpected)
+		c.Assert(rsp.Status, Equals, 400)
+		c.Check(rsp.Message, Equals, tc.expectedErr)
+	}
+}
+
+func (s *systemVolumesSuite) TestSystemVolumesActionRemoveKeyslots(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	called := 0
+	s.AddCleanup(daemon.MockFdestateRemoveKeyslots(func(st *state.State, keyslots []fdestate.KeyslotRef) (*state.TaskSet, error) {
+		called++
+		c.Check(keyslots, DeepEquals, []fdestate.KeyslotRef{
+			{ContainerRole: "some-container-role", Name: "some-name"},
+		})
+
+		return state.NewTaskSet(st.NewTask("some-task", "")), nil
+	}))
+
+	body := strings.NewReader(`
+{
+	"action": "remove-keyslots",
+	"keyslots": [
+		{"container-role": "some-container-role", "name": "some-name"}
+	]
+}`)
+	req, err := http.NewRequest("POST", "/v2/system-volumes", body)
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-Type", "application/json")
+
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, Equals, 202)
+
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	tsks := chg.Tasks()
+	st.Unlock()
+	c.Check(chg.Kind(), Equals, "fde-remove-keyslots")
+	c.Assert(tsks, HasLen, 1)
+	c.Check(tsks[0].Kind(), Equals, "some-task")
+	c.Check(called, Equals, 1)
+}
+
+func (s *systemVolumesSuite) TestSystemVolumesActionRemoveKeyslotsErrors(c *C) {
+	s.daemon(c)
+
+	var mockErr error
+	s.AddCleanup(daemon.MockFdestateRemoveKeyslots(func(st *state.State, keyslots []fdestate.KeyslotRef) (*state.TaskSet, error) {
+		return nil, mockErr
+	}))
+
+	body := strings.NewReader(`{"action": "remove-keyslots"}`)
+	req, err := http.NewRequest("POST", "/v2/system-volumes", body)
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-Type", "application/json")
+
+	rsp := s.errorReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, Equals, 400)
+	c.Check(rsp.Message, Equals, "system volume action requires keyslots to be provided")
+
+	mockErr = &fdestate.KeyslotRefsNotFoundError{KeyslotRefs: []fdestate.KeyslotRef{{ContainerRole: "system-data", Name: "some-name"}}}
+	body = strings.NewReader(`{"action": "remove-keyslots", "keyslots": [{"container-role": "system-data", "name": "some-name"}]}`)
+	req, err = http.NewRequest("POST", "/v2/system-volumes", body)
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-Type", "application/json")
+
+	rsp = s.errorReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, Equals, 400)
+	c.Check(rsp.Message, Equals, `cannot remove key slots: key slot reference (container-role: "system-data", name: "some-name") not found`)
+}
+
 func (s *systemVolumesSuite) TestSystemVolumesActionChangePassphrase(c *C) {
 	d := s.daemon(c)
 	st := d.Overlord().State()
@@ -841,3 +995,134 @@ func (s *systemVolumesSuite) TestSystemVolumesActionCheckPINError(c *C) {
 		c.Check(rspe.Value, DeepEquals, tc.expectedErrValue)
 	}
 }
+
+func (s *systemVolumesSuite) TestSystemVolumesActionChangePIN(c *C) {
+	s.daemon(c)
+
+	s.AddCleanup(daemon.MockFdestateChangeAuth(func(st *state.State, authMode device.AuthMode, old, new string, keyslotRefs []fdestate.KeyslotRef) (*state.TaskSet, error) {
+		c.Fatal("unexpected call")
+		return nil, nil
+	}))
+
+	for _, tc := range []struct {
+		body, expectedErr string
+		expectedKind      client.ErrorKind
+	}{
+		{`{"action": "change-pin", "new-pin": "1234"}`, "system volume action requires old-pin to be provided", ""},
+		{`{"action": "change-pin", "old-pin": "1234"}`, "system volume action requires new-pin to be provided", ""},
+		{`{"action": "change-pin", "old-pin": "1234", "new-pin": "5678"}`, "cannot change PIN: changing PINs is not supported yet", client.ErrorKindUnsupportedByTargetSystem},
+	} {
+		req, err := http.NewRequest("POST", "/v2/system-volumes", strings.NewReader(tc.body))
+		c.Assert(err, IsNil)
+		req.Header.Add("Content-Type", "application/json")
+
+		rsp := s.errorReq(c, req, nil, actionIsExpected)
+		c.Assert(rsp.Status, Equals, 400)
+		c.Check(rsp.Message, Equals, tc.expectedErr)
+		c.Check(rsp.Kind, Equals, tc.expectedKind)
+	}
+}
+
+func (s *systemVolumesSuite) TestSystemVolumesActionMountVolume(c *C) {
+	s.daemon(c)
+
+	relockTime := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	called := 0
+	s.AddCleanup(daemon.MockDevicestateMountSystemVolume(func(st *state.State, name string, opts *devicestate.MountSystemVolumeOptions) (*devicestate.SystemVolumeMount, error) {
+		called++
+		c.Check(name, Equals, "ubuntu-save")
+		c.Check(opts, DeepEquals, &devicestate.MountSystemVolumeOptions{ReadOnly: true, RelockAfter: 5 * time.Minute})
+		return &devicestate.SystemVolumeMount{
+			Name:       "ubuntu-save",
+			Device:     "/dev/disk/by-label/ubuntu-save",
+			MountPoint: "/run/snapd/system-volumes/ubuntu-save",
+			ReadOnly:   true,
+			RelockTime: relockTime,
+		}, nil
+	}))
+
+	body := strings.NewReader(`{"action": "mount-volume", "volume-name": "ubuntu-save", "read-only": true, "relock-after": "5m"}`)
+	req, err := http.NewRequest("POST", "/v2/system-volumes", body)
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-Type", "application/json")
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, Equals, 200)
+	c.Check(rsp.Result, DeepEquals, &devicestate.SystemVolumeMount{
+		Name:       "ubuntu-save",
+		Device:     "/dev/disk/by-label/ubuntu-save",
+		MountPoint: "/run/snapd/system-volumes/ubuntu-save",
+		ReadOnly:   true,
+		RelockTime: relockTime,
+	})
+	c.Check(called, Equals, 1)
+}
+
+func (s *systemVolumesSuite) TestSystemVolumesActionMountVolumeErrors(c *C) {
+	s.daemon(c)
+
+	s.AddCleanup(daemon.MockDevicestateMountSystemVolume(func(st *state.State, name string, opts *devicestate.MountSystemVolumeOptions) (*devicestate.SystemVolumeMount, error) {
+		return nil, fmt.Errorf("system volume %q cannot be mounted on demand", name)
+	}))
+
+	for _, tc := range []struct {
+		body string
+		msg  string
+	}{
+		{`{"action": "mount-volume"}`, "system volume action requires volume-name to be provided"},
+		{`{"action": "mount-volume", "volume-name": "ubuntu-save", "relock-after": "soon"}`, `cannot parse relock-after: time: invalid duration "soon"`},
+		{`{"action": "mount-volume", "volume-name": "ubuntu-data"}`, `cannot mount system volume: system volume "ubuntu-data" cannot be mounted on demand`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/system-volumes", strings.NewReader(tc.body))
+		c.Assert(err, IsNil)
+		req.Header.Add("Content-Type", "application/json")
+
+		rsp := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rsp.Status, Equals, 400)
+		c.Check(rsp.Message, Equals, tc.msg)
+	}
+}
+
+func (s *systemVolumesSuite) TestSystemVolumesActionUnmountVolume(c *C) {
+	s.daemon(c)
+
+	var mockErr error
+	called := 0
+	s.AddCleanup(daemon.MockDevicestateUnmountSystemVolume(func(st *state.State, name string) error {
+		called++
+		c.Check(name, Equals, "ubuntu-save")
+		return mockErr
+	}))
+
+	body := `{"action": "unmount-volume", "volume-name": "ubuntu-save"}`
+	req, err := http.NewRequest("POST", "/v2/system-volumes", strings.NewReader(body))
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-Type", "application/json")
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, Equals, 200)
+	c.Check(called, Equals, 1)
+
+	mockErr = devicestate.ErrSystemVolumeNotMounted
+	req, err = http.NewRequest("POST", "/v2/system-volumes", strings.NewReader(body))
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-Type", "application/json")
+	errRsp := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(errRsp.Status, Equals, 400)
+	c.Check(errRsp.Message, Equals, `cannot unmount system volume "ubuntu-save": system volume is not mounted`)
+
+	mockErr = errors.New("boom")
+	req, err = http.NewRequest("POST", "/v2/system-volumes", strings.NewReader(body))
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-Type", "application/json")
+	errRsp = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(errRsp.Status, Equals, 500)
+	c.Check(errRsp.Message, Equals, "cannot unmount system volume: boom")
+
+	req, err = http.NewRequest("POST", "/v2/system-volumes", strings.NewReader(`{"action": "unmount-volume"}`))
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-Type", "application/json")
+	errRsp = s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(errRsp.Status, Equals, 400)
+	c.Check(errRsp.Message, Equals, "system volume action requires volume-name to be provided")
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/gadget"
 	"github.com/snapcore/snapd/gadget/device"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/devicestate"
@@ -498,6 +499,11 @@ func postSystemActionCreateOffline(c *Command, form *Form) Response {
 		}
 	}
 
+	// large assertion bundles can also be uploaded as file parts
+	if errRsp := addAssertionFilesToBatch(batch, form); errRsp != nil {
+		return errRsp
+	}
+
 	st := c.d.overlord.State()
 	st.Lock()
 	defer st.Unlock()
@@ -551,7 +557,7 @@ func postSystemActionCreateOffline(c *Command, form *Form) Response {
 		Offline: true,
 	})
 	if err != nil {
-		return InternalError("cannot create recovery system %q: %v", label[0], err)
+		return InternalError("cannot create recovery system %q: %v", label, err)
 	}
 
 	ensureStateSoon(st)
@@ -559,6 +565,34 @@ func postSystemActionCreateOffline(c *Command, form *Form) Response {
 	return AsyncResponse(nil, chg.ID())
 }
 
+// addAssertionFilesToBatch adds the assertions from the files uploaded as
+// "assertion" form parts to the batch. The uploaded files are removed
+// afterwards, since they are not needed once their content is in the batch.
+func addAssertionFilesToBatch(batch *asserts.Batch, form *Form) *apiError {
+	refs := form.FileRefs["assertion"]
+	defer func() {
+		for _, ref := range refs {
+			if err := os.Remove(ref.TmpPath); err != nil {
+				logger.Noticef("cannot remove temporary file: %v", err)
+			}
+		}
+		delete(form.FileRefs, "assertion")
+	}()
+
+	for _, ref := range refs {
+		f, err := os.Open(ref.TmpPath)
+		if err != nil {
+			return InternalError("cannot open uploaded assertion file: %v", err)
+		}
+		_, err = batch.AddStream(f)
+		f.Close()
+		if err != nil {
+			return BadRequest("cannot decode assertion file %q: %v", ref.Filename, err)
+		}
+	}
+	return nil
+}
+
 func postSystemActionCreate(c *Command, req *systemActionRequest) Response {
 	st := c.d.overlord.State()
 	st.Lock()
@@ -2731,3 +2731,97 @@ func (s *systemsCreateSuite) TestCreateSystemActionOfflineJustValidationSets(c *
 
 	c.Check(st.Change(res.Change), check.NotNil)
 }
+
+func (s *systemsCreateSuite) TestCreateSystemActionOfflineAssertionFiles(c *check.C) {
+	accountID := s.dev1acct.AccountID()
+
+	const (
+		validationSet = "validation-set-1"
+		expectedLabel = "1234"
+	)
+
+	vsetAssert := s.mockDevAssertion(c, asserts.ValidationSetType, map[string]any{
+		"name":     validationSet,
+		"sequence": "1",
+		"snaps": []any{
+			map[string]any{
+				"name":     "pc-kernel",
+				"id":       snaptest.AssertedSnapID("pc-kernel"),
+				"revision": "10",
+				"presence": "required",
+			},
+		},
+	})
+
+	var bundle bytes.Buffer
+	enc := asserts.NewEncoder(&bundle)
+	for _, a := range []asserts.Assertion{vsetAssert, s.acct1Key, s.dev1acct} {
+		c.Assert(enc.Encode(a), check.IsNil)
+	}
+
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	c.Assert(w.WriteField("action", "create"), check.IsNil)
+	c.Assert(w.WriteField("label", expectedLabel), check.IsNil)
+	c.Assert(w.WriteField("validation-sets", accountID+"/"+validationSet), check.IsNil)
+	part, err := w.CreateFormFile("assertion", "bundle.assert")
+	c.Assert(err, check.IsNil)
+	_, err = part.Write(bundle.Bytes())
+	c.Assert(err, check.IsNil)
+	c.Assert(w.Close(), check.IsNil)
+
+	daemon.MockDevicestateCreateRecoverySystem(func(st *state.State, label string, opts devicestate.CreateRecoverySystemOptions) (*state.Change, error) {
+		c.Check(expectedLabel, check.Equals, label)
+		c.Check(opts.ValidationSets, check.HasLen, 1)
+		c.Check(opts.ValidationSets[0].Body(), check.DeepEquals, vsetAssert.Body())
+		c.Check(opts.LocalSnaps, check.HasLen, 0)
+		c.Check(opts.Offline, check.Equals, true)
+
+		return st.NewChange("change", "..."), nil
+	})
+
+	req, err := http.NewRequest("POST", "/v2/systems", &form)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	res := s.asyncReq(c, req, nil, actionIsExpected)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	c.Check(st.Change(res.Change), check.NotNil)
+	st.Unlock()
+
+	// the uploaded assertion file is not kept around
+	files, err := filepath.Glob(filepath.Join(dirs.SnapBlobDir, dirs.LocalInstallBlobTempPrefix+"*"))
+	c.Assert(err, check.IsNil)
+	c.Check(files, check.HasLen, 0)
+}
+
+func (s *systemsCreateSuite) TestCreateSystemActionOfflineBadAssertionFile(c *check.C) {
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	c.Assert(w.WriteField("action", "create"), check.IsNil)
+	c.Assert(w.WriteField("label", "1234"), check.IsNil)
+	part, err := w.CreateFormFile("assertion", "bad.assert")
+	c.Assert(err, check.IsNil)
+	_, err = part.Write([]byte("not an assertion"))
+	c.Assert(err, check.IsNil)
+	part, err = w.CreateFormFile("snap", "snap-1")
+	c.Assert(err, check.IsNil)
+	_, err = part.Write([]byte("snap-1 contents"))
+	c.Assert(err, check.IsNil)
+	c.Assert(w.Close(), check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/systems", &form)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe, check.ErrorMatches, `cannot decode assertion file "bad.assert": .* \(api\)`)
+
+	// all uploaded files are removed on failure
+	files, err := filepath.Glob(filepath.Join(dirs.SnapBlobDir, dirs.LocalInstallBlobTempPrefix+"*"))
+	c.Assert(err, check.IsNil)
+	c.Check(files, check.HasLen, 0)
+}
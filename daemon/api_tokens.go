@@ -0,0 +1,276 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+const apiTokensPath = "/v2/api-tokens"
+
+var apiTokensCmd = &Command{
+	Path:        apiTokensPath,
+	GET:         getAPITokens,
+	POST:        postAPITokens,
+	Actions:     []string{"create", "revoke"},
+	ReadAccess:  rootAccess{},
+	WriteAccess: rootAccess{},
+}
+
+var _ = registerAPIFeature("api-tokens")
+
+const (
+	apiTokenReadScope  = "read:"
+	apiTokenWriteScope = "write:"
+)
+
+// defaultAPITokenLifetime is how long API tokens are valid when created
+// without an explicit expires-in.
+const defaultAPITokenLifetime = 30 * 24 * time.Hour
+
+// validateAPITokenScope checks that scope is of the form read:<path> or
+// write:<path> for an endpoint supporting reads or writes respectively.
+// Tokens cannot be granted access to the API tokens endpoint itself.
+func validateAPITokenScope(scope string) error {
+	var methods []string
+	var path string
+	switch {
+	case strings.HasPrefix(scope, apiTokenReadScope):
+		path = scope[len(apiTokenReadScope):]
+		methods = []string{"GET"}
+	case strings.HasPrefix(scope, apiTokenWriteScope):
+		path = scope[len(apiTokenWriteScope):]
+		methods = []string{"POST", "PUT"}
+	default:
+		return fmt.Errorf("invalid scope %q: must start with %q or %q", scope, apiTokenReadScope, apiTokenWriteScope)
+	}
+	if path == apiTokensPath {
+		return fmt.Errorf("invalid scope %q: cannot grant access to API tokens", scope)
+	}
+	for _, endpoint := range featureList {
+		if endpoint.Path != path {
+			continue
+		}
+		for _, m := range methods {
+			if endpoint.Method == m {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("invalid scope %q: unknown endpoint", scope)
+}
+
+// apiTokenAllows returns whether the token grants access to the given
+// method on the endpoint served by c.
+func apiTokenAllows(tok *auth.APIToken, method string, c *Command) bool {
+	path := c.Path
+	if path == "" {
+		path = c.PathPrefix
+	}
+	want := apiTokenWriteScope + path
+	if method == "GET" {
+		want = apiTokenReadScope + path
+	}
+	for _, scope := range tok.Scopes {
+		if scope == want {
+			return true
+		}
+	}
+	return false
+}
+
+type apiTokenKey struct{}
+
+// withAPIToken returns a context recording that the request was
+// authenticated with the given API token.
+func withAPIToken(ctx context.Context, tok *auth.APIToken) context.Context {
+	return context.WithValue(ctx, apiTokenKey{}, tok)
+}
+
+// apiTokenFromContext returns the API token which authenticated the
+// request, if any.
+func apiTokenFromContext(ctx context.Context) *auth.APIToken {
+	tok, _ := ctx.Value(apiTokenKey{}).(*auth.APIToken)
+	return tok
+}
+
+// apiTokenFromRequest returns the API token presented in the request as a
+// bearer token in the Authorization header, if any.
+//
+// Locks state to check the token, so the caller must not hold the state
+// lock.
+func apiTokenFromRequest(st *state.State, req *http.Request) (*auth.APIToken, error) {
+	header := req.Header.Get("Authorization")
+	authorizationData := strings.SplitN(header, " ", 2)
+	if len(authorizationData) != 2 || authorizationData[0] != "Bearer" {
+		return nil, nil
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	return auth.CheckAPIToken(st, strings.TrimSpace(authorizationData[1]))
+}
+
+type apiTokenJSON struct {
+	ID      string     `json:"id"`
+	Label   string     `json:"label,omitempty"`
+	Scopes  []string   `json:"scopes"`
+	UID     uint32     `json:"uid"`
+	Created time.Time  `json:"created"`
+	Expires *time.Time `json:"expires,omitempty"`
+	// Token is only set when creating the token.
+	Token string `json:"token,omitempty"`
+}
+
+func apiTokenToJSON(tok *auth.APIToken) apiTokenJSON {
+	res := apiTokenJSON{
+		ID:      tok.ID,
+		Label:   tok.Label,
+		Scopes:  tok.Scopes,
+		UID:     tok.UID,
+		Created: tok.Created,
+	}
+	if !tok.Expires.IsZero() {
+		expires := tok.Expires
+		res.Expires = &expires
+	}
+	return res
+}
+
+// getAPITokens lists the API tokens which have not expired, without their
+// secrets.
+func getAPITokens(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	tokens, err := auth.APITokens(st)
+	if err != nil {
+		return InternalError("cannot list API tokens: %v", err)
+	}
+	res := make([]apiTokenJSON, 0, len(tokens))
+	for _, tok := range tokens {
+		res = append(res, apiTokenToJSON(tok))
+	}
+	return SyncResponse(res)
+}
+
+type postAPITokensData struct {
+	Action string `json:"action"`
+	// for create
+	Label     string   `json:"label,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	UID       *uint32  `json:"uid,omitempty"`
+	ExpiresIn string   `json:"expires-in,omitempty"`
+	// for revoke
+	ID string `json:"id,omitempty"`
+}
+
+func postAPITokens(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postAPITokensData
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&data); err != nil {
+		return BadRequest("cannot decode API tokens request body: %v", err)
+	}
+
+	switch data.Action {
+	case "create":
+		return createAPIToken(c, &data)
+	case "revoke":
+		return revokeAPIToken(c, &data)
+	default:
+		return BadRequest("unknown API tokens action %q", data.Action)
+	}
+}
+
+func createAPIToken(c *Command, data *postAPITokensData) Response {
+	if data.ID != "" {
+		return BadRequest("unexpected id for %q action", data.Action)
+	}
+	if len(data.Scopes) == 0 {
+		return BadRequest("missing scopes")
+	}
+	for _, scope := range data.Scopes {
+		if err := validateAPITokenScope(scope); err != nil {
+			return BadRequest("%v", err)
+		}
+	}
+	if data.UID == nil {
+		return BadRequest("missing uid")
+	}
+	lifetime := defaultAPITokenLifetime
+	if data.ExpiresIn != "" {
+		d, err := time.ParseDuration(data.ExpiresIn)
+		if err != nil {
+			return BadRequest("cannot parse expires-in: %v", err)
+		}
+		if d <= 0 {
+			return BadRequest("expires-in must be positive")
+		}
+		lifetime = d
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	tok, secret, err := auth.NewAPIToken(st, auth.NewAPITokenParams{
+		Label:   data.Label,
+		Scopes:  data.Scopes,
+		UID:     *data.UID,
+		Expires: timeNow().Add(lifetime),
+	})
+	if err != nil {
+		return InternalError("cannot create API token: %v", err)
+	}
+	res := apiTokenToJSON(tok)
+	res.Token = secret
+	return SyncResponse(res)
+}
+
+func revokeAPIToken(c *Command, data *postAPITokensData) Response {
+	if data.ID == "" {
+		return BadRequest("missing id")
+	}
+	if len(data.Scopes) != 0 || data.Label != "" || data.UID != nil || data.ExpiresIn != "" {
+		return BadRequest("unexpected token details for %q action", data.Action)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if err := auth.RevokeAPIToken(st, data.ID); err != nil {
+		if errors.Is(err, auth.ErrInvalidAPIToken) {
+			return NotFound("cannot find API token %q", data.ID)
+		}
+		return InternalError("cannot revoke API token: %v", err)
+	}
+	return SyncResponse(nil)
+}
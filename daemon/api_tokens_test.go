@@ -0,0 +1,143 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/auth"
+)
+
+var _ = check.Suite(&apiTokensSuite{})
+
+type apiTokensSuite struct {
+	apiBaseSuite
+}
+
+func (s *apiTokensSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectRootAccess()
+}
+
+func (s *apiTokensSuite) TestCreateListRevokeAPIToken(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	body := bytes.NewBufferString(`{"action": "create", "label": "fleet-agent", "scopes": ["read:/v2/snaps", "write:/v2/snaps"], "uid": 1000, "expires-in": "1h"}`)
+	req, err := http.NewRequest("POST", "/v2/api-tokens", body)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	created, ok := rsp.Result.(daemon.APITokenJSON)
+	c.Assert(ok, check.Equals, true)
+	c.Check(created.ID, check.Not(check.Equals), "")
+	c.Check(created.Label, check.Equals, "fleet-agent")
+	c.Check(created.Scopes, check.DeepEquals, []string{"read:/v2/snaps", "write:/v2/snaps"})
+	c.Check(created.UID, check.Equals, uint32(1000))
+	c.Assert(created.Expires, check.NotNil)
+	c.Check(created.Expires.Sub(created.Created) > 59*time.Minute, check.Equals, true)
+	c.Check(created.Expires.Sub(created.Created) <= time.Hour, check.Equals, true)
+	c.Check(strings.HasPrefix(created.Token, "snapd-api-token-"), check.Equals, true)
+
+	st.Lock()
+	tok, err := auth.CheckAPIToken(st, created.Token)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(tok.ID, check.Equals, created.ID)
+
+	// the secret is not part of the listing
+	req, err = http.NewRequest("GET", "/v2/api-tokens", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	listed, ok := rsp.Result.([]daemon.APITokenJSON)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(listed, check.HasLen, 1)
+	c.Check(listed[0].ID, check.Equals, created.ID)
+	c.Check(listed[0].Token, check.Equals, "")
+
+	body = bytes.NewBufferString(`{"action": "revoke", "id": "` + created.ID + `"}`)
+	req, err = http.NewRequest("POST", "/v2/api-tokens", body)
+	c.Assert(err, check.IsNil)
+	s.syncReq(c, req, nil, actionIsExpected)
+
+	st.Lock()
+	_, err = auth.CheckAPIToken(st, created.Token)
+	st.Unlock()
+	c.Check(err, check.Equals, auth.ErrInvalidAPIToken)
+
+	req, err = http.NewRequest("GET", "/v2/api-tokens", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.HasLen, 0)
+}
+
+func (s *apiTokensSuite) TestCreateAPITokenDefaultExpiry(c *check.C) {
+	s.daemon(c)
+	now := time.Now()
+	defer daemon.MockTimeNow(func() time.Time { return now })()
+
+	body := bytes.NewBufferString(`{"action": "create", "scopes": ["read:/v2/changes"], "uid": 1000}`)
+	req, err := http.NewRequest("POST", "/v2/api-tokens", body)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	created, ok := rsp.Result.(daemon.APITokenJSON)
+	c.Assert(ok, check.Equals, true)
+	// tokens always expire
+	c.Assert(created.Expires, check.NotNil)
+	c.Check(created.Expires.Equal(now.Add(30*24*time.Hour)), check.Equals, true)
+}
+
+func (s *apiTokensSuite) TestPostAPITokensErrors(c *check.C) {
+	s.daemon(c)
+
+	for _, t := range []struct {
+		body   string
+		status int
+		err    string
+	}{
+		{`{`, 400, `cannot decode API tokens request body: .*`},
+		{`{"action": "frobnicate"}`, 400, `unknown API tokens action "frobnicate"`},
+		{`{"action": "create"}`, 400, `missing scopes`},
+		{`{"action": "create", "id": "foo", "scopes": ["read:/v2/snaps"]}`, 400, `unexpected id for "create" action`},
+		{`{"action": "create", "scopes": ["/v2/snaps"]}`, 400, `invalid scope "/v2/snaps": must start with "read:" or "write:"`},
+		{`{"action": "create", "scopes": ["read:/v2/nope"]}`, 400, `invalid scope "read:/v2/nope": unknown endpoint`},
+		{`{"action": "create", "scopes": ["write:/v2/sections"]}`, 400, `invalid scope "write:/v2/sections": unknown endpoint`},
+		{`{"action": "create", "scopes": ["write:/v2/api-tokens"]}`, 400, `invalid scope "write:/v2/api-tokens": cannot grant access to API tokens`},
+		{`{"action": "create", "scopes": ["read:/v2/snaps"]}`, 400, `missing uid`},
+		{`{"action": "create", "scopes": ["read:/v2/snaps"], "uid": 1000, "expires-in": "soon"}`, 400, `cannot parse expires-in: .*`},
+		{`{"action": "create", "scopes": ["read:/v2/snaps"], "uid": 1000, "expires-in": "-1h"}`, 400, `expires-in must be positive`},
+		{`{"action": "revoke"}`, 400, `missing id`},
+		{`{"action": "revoke", "id": "foo", "label": "bar"}`, 400, `unexpected token details for "revoke" action`},
+		{`{"action": "revoke", "id": "foo", "uid": 1000}`, 400, `unexpected token details for "revoke" action`},
+		{`{"action": "revoke", "id": "foo"}`, 404, `cannot find API token "foo"`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/api-tokens", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsUnexpected)
+		c.Check(rspe.Status, check.Equals, t.status, check.Commentf("%s", t.body))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf("%s", t.body))
+	}
+}
@@ -405,6 +405,12 @@ func enforceValidationSet(st *state.State, accountID, name string, sequence, use
 	}
 	tr, err := assertstateFetchAndApplyEnforcedValidationSet(st, accountID, name, sequence, userID, snaps, ignoreValidation)
 	if err != nil {
+		var verr *snapasserts.ValidationSetsValidationError
+		if errors.As(err, &verr) {
+			rspe := ValidationSetConstraintsUnmet(verr)
+			rspe.Message = fmt.Sprintf("cannot enforce validation set: %v", err)
+			return rspe
+		}
 		// XXX: provide more specific error kinds? This would probably require
 		// assertstate.ValidationSetAssertionForEnforce tuning too.
 		return BadRequest("cannot enforce validation set: %v", err)
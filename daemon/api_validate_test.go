@@ -31,6 +31,7 @@ import (
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/assertstest"
 	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/assertstate/assertstatetest"
@@ -962,3 +963,24 @@ func (s *apiValidationSetsSuite) TestApplyValidationSetEnforceModeError(c *check
 	c.Assert(rspe.Status, check.Equals, 400)
 	c.Check(string(rspe.Message), check.Equals, "cannot enforce validation set: boom")
 }
+
+func (s *apiValidationSetsSuite) TestApplyValidationSetEnforceModeConstraintsUnmet(c *check.C) {
+	restore := daemon.MockAssertstateFetchEnforceValidationSet(func(st *state.State, accountID, name string, sequence int, userID int, snaps []*snapasserts.InstalledSnap, ignoreValidation map[string]bool) (*assertstate.ValidationSetTracking, error) {
+		return nil, &snapasserts.ValidationSetsValidationError{
+			InvalidSnaps: map[string][]string{"snap-b": {"foo/bar"}},
+		}
+	})
+	defer restore()
+
+	body := `{"action":"apply","mode":"enforce"}`
+	req, err := http.NewRequest("POST", fmt.Sprintf("/v2/validation-sets/%s/bar", s.dev1acct.AccountID()), strings.NewReader(body))
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Assert(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindValidationSetConstraintsUnmet)
+	c.Check(rspe.Message, check.Matches, `(?s)cannot enforce validation set: validation sets assertions are not met:\n.*`)
+	c.Check(rspe.Value, check.DeepEquals, map[string]any{
+		"invalid-snaps": map[string][]string{"snap-b": {"foo/bar"}},
+	})
+}
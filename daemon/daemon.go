@@ -78,6 +78,7 @@ type Daemon struct {
 	tomb            tomb.Tomb
 	router          *mux.Router
 	standbyOpinions *standby.StandbyOpinions
+	requestThrottle *requestThrottle
 
 	// set to what kind of restart was requested (if any)
 	requestedRestart restart.RestartType
@@ -113,11 +114,18 @@ type Command struct {
 	ReadAccess  accessChecker
 	WriteAccess accessChecker
 
+	// Throttled marks commands whose write requests can start
+	// changes, they are subject to the configured api.rate-limit and
+	// api.max-changes limits.
+	Throttled bool
+
 	d *Daemon
 }
 
 func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	st := c.d.state
+	addAPIFeaturesHeader(w)
+
 	// userFromRequest locks the state internally when checking authentication.
 	// TODO Look at the error and fail if there's an attempt to authenticate with invalid data.
 	user, _ := userFromRequest(st, r)
@@ -158,11 +166,45 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tok, err := apiTokenFromRequest(st, r)
+	if err != nil {
+		Unauthorized("cannot use API token: %v", err).ServeHTTP(w, r)
+		return
+	}
+
+	// a valid API token authenticates the requests of the user it was
+	// created for within its scopes, the usual access checks still apply
+	if tok != nil {
+		if ucred == nil || ucred.Uid != tok.UID {
+			Unauthorized("cannot use API token: token is not valid for this user").ServeHTTP(w, r)
+			return
+		}
+		if apiTokenAllows(tok, r.Method, c) {
+			r = r.WithContext(withAPIToken(r.Context(), tok))
+		}
+	}
+
 	if rspe := access.CheckAccess(c.d, r, ucred, user); rspe != nil {
 		rspe.ServeHTTP(w, r)
 		return
 	}
 
+	if c.Throttled && r.Method != "GET" {
+		if rspe := c.d.throttle(ucred); rspe != nil {
+			rspe.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	changeTimeout, err := changeTimeoutFromRequest(r)
+	if err != nil {
+		BadRequest("%v", err).ServeHTTP(w, r)
+		return
+	}
+	if changeTimeout > 0 {
+		r = r.WithContext(withChangeTimeout(r.Context(), changeTimeout))
+	}
+
 	traceSnapdAPI(c, w, r)
 
 	rsp := rspf(c, r, user)
@@ -185,6 +227,43 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	rsp.ServeHTTP(w, r)
 }
 
+// changeTimeoutHeader is the request header through which clients can bound
+// the duration of the change started by the request, after which snapd
+// aborts and undoes it.
+const changeTimeoutHeader = "X-Snapd-Change-Timeout"
+
+var _ = registerAPIFeature("change-deadline")
+
+func changeTimeoutFromRequest(r *http.Request) (time.Duration, error) {
+	v := r.Header.Get(changeTimeoutHeader)
+	if v == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s header: %v", changeTimeoutHeader, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s header: timeout must be positive", changeTimeoutHeader)
+	}
+	return timeout, nil
+}
+
+type changeTimeoutKey struct{}
+
+// withChangeTimeout returns a context recording that the request asked for
+// the change it starts to be done within the given timeout.
+func withChangeTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, changeTimeoutKey{}, timeout)
+}
+
+// changeTimeoutFromContext returns the change timeout the request asked
+// for, if any.
+func changeTimeoutFromContext(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(changeTimeoutKey{}).(time.Duration)
+	return timeout
+}
+
 func traceSnapdAPI(c *Command, w http.ResponseWriter, r *http.Request) {
 	if osutil.GetenvBool("SNAPD_TRACE") {
 		loggedWithAction := false
@@ -735,7 +814,7 @@ func (d *Daemon) RebootDidNotHappen(st *state.State) error {
 
 // New Daemon
 func New() (*Daemon, error) {
-	d := &Daemon{}
+	d := &Daemon{requestThrottle: newRequestThrottle()}
 	ovld, err := overlord.New(d)
 	if err == errExpectedReboot {
 		// we proceed without overlord until we reach Stop
@@ -29,6 +29,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"syscall"
 	"testing"
@@ -309,6 +310,94 @@ func (s *daemonSuite) TestCommandRestartingState(c *check.C) {
 	}
 }
 
+func (s *daemonSuite) TestCommandAPIFeaturesHeader(c *check.C) {
+	oldAPIFeatures := apiFeatures
+	apiFeatures = make(map[string]bool)
+	defer func() { apiFeatures = oldAPIFeatures }()
+
+	c.Check(registerAPIFeature("foo"), check.Equals, "foo")
+	registerAPIFeature("bar")
+	// registering more than once is harmless
+	registerAPIFeature("foo")
+
+	d := s.newTestDaemon(c)
+	cmd := &Command{d: d}
+	cmd.GET = func(*Command, *http.Request, *auth.UserState) Response {
+		return SyncResponse(nil)
+	}
+	cmd.ReadAccess = openAccess{}
+	req, err := http.NewRequest("GET", "", nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=42;socket=%s;", dirs.SnapdSocket)
+
+	rec := httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Header().Get("X-Snapd-API-Features"), check.Equals, "bar,foo")
+
+	// also set on errors
+	rec = httptest.NewRecorder()
+	req.Method = "POST"
+	cmd.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 405)
+	c.Check(rec.Header().Get("X-Snapd-API-Features"), check.Equals, "bar,foo")
+}
+
+func (s *daemonSuite) TestKnownAPIFeatures(c *check.C) {
+	features := knownAPIFeatures()
+	c.Check(features, testutil.DeepContains, "change-wait")
+	c.Check(features, testutil.DeepContains, "if-match")
+	c.Check(sort.StringsAreSorted(features), check.Equals, true)
+}
+
+func (s *daemonSuite) TestChangeTimeoutHeader(c *check.C) {
+	d := s.newTestDaemon(c)
+	st := d.overlord.State()
+
+	var chgID string
+	cmd := &Command{d: d}
+	cmd.POST = func(_ *Command, r *http.Request, _ *auth.UserState) Response {
+		st.Lock()
+		defer st.Unlock()
+		// the deadline is set when the change is created
+		chg := newChange(r.Context(), st, "foo", "...", nil, nil)
+		chgID = chg.ID()
+		return AsyncResponse(nil, chgID)
+	}
+	cmd.WriteAccess = openAccess{}
+
+	req, err := http.NewRequest("POST", "", nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=42;socket=%s;", dirs.SnapdSocket)
+
+	// no header, no deadline
+	rec := httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 202)
+	st.Lock()
+	c.Check(st.Change(chgID).Deadline().IsZero(), check.Equals, true)
+	st.Unlock()
+
+	before := time.Now()
+	req.Header.Set("X-Snapd-Change-Timeout", "10m")
+	rec = httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 202)
+	st.Lock()
+	deadline := st.Change(chgID).Deadline()
+	st.Unlock()
+	c.Check(deadline.Before(before.Add(10*time.Minute)), check.Equals, false)
+	c.Check(deadline.After(time.Now().Add(10*time.Minute)), check.Equals, false)
+
+	for _, v := range []string{"potato", "-1m", "0s"} {
+		req.Header.Set("X-Snapd-Change-Timeout", v)
+		rec = httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		c.Check(rec.Code, check.Equals, 400, check.Commentf("%q", v))
+		c.Check(rec.Body.String(), testutil.Contains, "invalid X-Snapd-Change-Timeout header")
+	}
+}
+
 func (s *daemonSuite) TestMaintenanceJsonDeletedOnStart(c *check.C) {
 	// write a maintenance.json file that has that the system is restarting
 	maintErr := &errorResult{
@@ -502,6 +591,63 @@ func (s *daemonSuite) TestWriteAccessWithUser(c *check.C) {
 	c.Check(accessCalled, check.Equals, true)
 }
 
+func (s *daemonSuite) TestAccessWithAPIToken(c *check.C) {
+	d := s.newTestDaemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	_, secret, err := auth.NewAPIToken(st, auth.NewAPITokenParams{
+		Scopes:  []string{"read:/v2/foo", "read:/v2/root-only"},
+		UID:     1001,
+		Expires: time.Now().Add(time.Hour),
+	})
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	newCmd := func(path string, access accessChecker) *Command {
+		cmd := &Command{d: d, Path: path}
+		cmd.GET = func(*Command, *http.Request, *auth.UserState) Response {
+			return SyncResponse(nil)
+		}
+		cmd.POST = cmd.GET
+		cmd.ReadAccess = access
+		cmd.WriteAccess = access
+		return cmd
+	}
+	cmd := newCmd("/v2/foo", authenticatedAccess{})
+	rootCmd := newCmd("/v2/root-only", rootAccess{})
+
+	serve := func(cmd *Command, method string, uid int, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, cmd.Path, nil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", uid, dirs.SnapdSocket)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// the token authenticates reads of the user it was created for
+	rec := serve(cmd, "GET", 1001, secret)
+	c.Check(rec.Code, check.Equals, 200)
+
+	// but not writes, which are not in its scopes
+	rec = serve(cmd, "POST", 1001, secret)
+	c.Check(rec.Code, check.Equals, 401)
+
+	// and it does not grant root access
+	rec = serve(rootCmd, "GET", 1001, secret)
+	c.Check(rec.Code, check.Equals, 403)
+
+	// other users cannot use it
+	rec = serve(cmd, "GET", 1002, secret)
+	c.Check(rec.Code, check.Equals, 401)
+	c.Check(rec.Body.String(), testutil.Contains, "cannot use API token: token is not valid for this user")
+
+	// unknown tokens are rejected
+	rec = serve(cmd, "GET", 1001, "snapd-api-token-bogus")
+	c.Check(rec.Code, check.Equals, 401)
+	c.Check(rec.Body.String(), testutil.Contains, "cannot use API token: invalid API token")
+}
+
 func (s *daemonSuite) TestPolkitAccessPath(c *check.C) {
 	cmd := &Command{d: s.newTestDaemon(c)}
 	cmd.POST = func(*Command, *http.Request, *auth.UserState) Response {
@@ -0,0 +1,199 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package daemontest runs the snapd API in-process on top of a temporary
+// root directory. It allows end-to-end testing of tooling built on top of
+// the API, through the client package, without a VM.
+//
+// The daemon runs with an overlord without managers apart from the
+// assertions one, managers needed by the tested scenario can be added
+// with Options.Managers. Changes are only run by Settle.
+package daemontest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// Options control how the daemon is set up.
+type Options struct {
+	// RootDir is the root directory the daemon works with, a temporary
+	// one removed on Close is used if empty.
+	RootDir string
+	// UID is the user id requests are made as, root by default.
+	UID uint32
+	// Managers are additional managers to add to the overlord, they
+	// are created with the state and task runner of the overlord.
+	Managers []func(st *state.State, runner *state.TaskRunner) (overlord.StateManager, error)
+	// Store, if set, is the store used by the daemon.
+	Store snapstate.StoreService
+}
+
+// Daemon is a daemon serving the snapd API in-process.
+type Daemon struct {
+	// RootDir is the root directory the daemon works with.
+	RootDir string
+
+	o   *overlord.Overlord
+	d   *daemon.Daemon
+	srv *httptest.Server
+
+	oldRootDir    string
+	removeRootDir bool
+	closed        bool
+}
+
+// New sets up and starts serving a daemon. The global root directory is
+// changed to the one of the daemon until Close is called, as such only
+// one daemon can run at a time.
+func New(opts *Options) (*Daemon, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	rootDir := opts.RootDir
+	removeRootDir := false
+	if rootDir == "" {
+		tmpDir, err := os.MkdirTemp("", "daemontest-")
+		if err != nil {
+			return nil, fmt.Errorf("cannot create root directory: %v", err)
+		}
+		rootDir = tmpDir
+		removeRootDir = true
+	}
+
+	d := &Daemon{
+		RootDir:       rootDir,
+		oldRootDir:    dirs.GlobalRootDir,
+		removeRootDir: removeRootDir,
+	}
+	dirs.SetRootDir(rootDir)
+
+	if err := d.setup(opts); err != nil {
+		d.restore()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Daemon) setup(opts *Options) error {
+	if err := os.MkdirAll(dirs.SnapdStateDir(dirs.GlobalRootDir), 0755); err != nil {
+		return fmt.Errorf("cannot create state directory: %v", err)
+	}
+
+	o := overlord.Mock()
+	st := o.State()
+	runner := o.TaskRunner()
+
+	assertMgr, err := assertstate.Manager(st, runner)
+	if err != nil {
+		return fmt.Errorf("cannot create assertions manager: %v", err)
+	}
+	o.AddManager(assertMgr)
+	for _, newMgr := range opts.Managers {
+		mgr, err := newMgr(st, runner)
+		if err != nil {
+			return fmt.Errorf("cannot create manager: %v", err)
+		}
+		o.AddManager(mgr)
+	}
+	o.AddManager(runner)
+
+	if opts.Store != nil {
+		st.Lock()
+		snapstate.ReplaceStore(st, opts.Store)
+		st.Unlock()
+	}
+
+	d.o = o
+	d.d = daemon.NewEmbedded(o)
+
+	handler := d.d.Handler()
+	remoteAddr := fmt.Sprintf("pid=%d;uid=%d;socket=%s;", os.Getpid(), opts.UID, dirs.SnapdSocket)
+	d.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// requests are made as if over the snapd socket by the
+		// configured user
+		r.RemoteAddr = remoteAddr
+		handler.ServeHTTP(w, r)
+	}))
+	return nil
+}
+
+// restore restores the global root directory and removes the one of the
+// daemon if it was created by New.
+func (d *Daemon) restore() error {
+	dirs.SetRootDir(d.oldRootDir)
+	if d.removeRootDir {
+		return os.RemoveAll(d.RootDir)
+	}
+	return nil
+}
+
+// URL returns the base URL to make requests to the daemon.
+func (d *Daemon) URL() string {
+	return d.srv.URL
+}
+
+// Client returns a client making requests to the daemon.
+func (d *Daemon) Client() *client.Client {
+	return client.New(&client.Config{BaseURL: d.srv.URL})
+}
+
+// Overlord returns the overlord of the daemon.
+func (d *Daemon) Overlord() *overlord.Overlord {
+	return d.o
+}
+
+// State returns the state of the daemon.
+func (d *Daemon) State() *state.State {
+	return d.o.State()
+}
+
+// Settle runs the managers of the daemon until there are no pending
+// changes, or the timeout expires.
+func (d *Daemon) Settle(timeout time.Duration) error {
+	return d.o.Settle(timeout)
+}
+
+// Close stops serving requests, stops the managers and restores the
+// global root directory.
+func (d *Daemon) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	d.srv.Close()
+	stopErr := d.o.Stop()
+	if err := d.restore(); err != nil {
+		return err
+	}
+	return stopErr
+}
@@ -0,0 +1,133 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemontest_test
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon/daemontest"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type daemontestSuite struct{}
+
+var _ = check.Suite(&daemontestSuite{})
+
+func (s *daemontestSuite) TearDownTest(c *check.C) {
+	dirs.SetRootDir("")
+}
+
+type nopManager struct{}
+
+func (nopManager) Ensure() error { return nil }
+
+func (s *daemontestSuite) TestChanges(c *check.C) {
+	d, err := daemontest.New(nil)
+	c.Assert(err, check.IsNil)
+	defer d.Close()
+
+	st := d.State()
+	st.Lock()
+	chg := st.NewChange("foo", "Foo the bar")
+	chg.AddTask(st.NewTask("nop", "Do nothing"))
+	st.Unlock()
+
+	cli := d.Client()
+	chgs, err := cli.Changes(&client.ChangesOptions{Selector: client.ChangesAll})
+	c.Assert(err, check.IsNil)
+	c.Assert(chgs, check.HasLen, 1)
+	c.Check(chgs[0].ID, check.Equals, chg.ID())
+	c.Check(chgs[0].Kind, check.Equals, "foo")
+	c.Check(chgs[0].Summary, check.Equals, "Foo the bar")
+	c.Check(chgs[0].Ready, check.Equals, false)
+
+	// requests are made as root by default
+	aborted, err := cli.Abort(chg.ID())
+	c.Assert(err, check.IsNil)
+	c.Check(aborted.ID, check.Equals, chg.ID())
+}
+
+func (s *daemontestSuite) TestManagersAndSettle(c *check.C) {
+	var ran bool
+	d, err := daemontest.New(&daemontest.Options{
+		Managers: []func(*state.State, *state.TaskRunner) (overlord.StateManager, error){
+			func(st *state.State, runner *state.TaskRunner) (overlord.StateManager, error) {
+				runner.AddHandler("foo", func(*state.Task, *tomb.Tomb) error {
+					ran = true
+					return nil
+				}, nil)
+				return nopManager{}, nil
+			},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	defer d.Close()
+
+	st := d.State()
+	st.Lock()
+	chg := st.NewChange("foo", "Foo the bar")
+	chg.AddTask(st.NewTask("foo", "Foo"))
+	st.Unlock()
+
+	c.Assert(d.Settle(5*time.Second), check.IsNil)
+	c.Check(ran, check.Equals, true)
+
+	got, err := d.Client().Change(chg.ID())
+	c.Assert(err, check.IsNil)
+	c.Check(got.Status, check.Equals, "Done")
+	c.Check(got.Ready, check.Equals, true)
+}
+
+func (s *daemontestSuite) TestRootDir(c *check.C) {
+	oldRootDir := dirs.GlobalRootDir
+
+	d, err := daemontest.New(nil)
+	c.Assert(err, check.IsNil)
+	c.Check(dirs.GlobalRootDir, check.Equals, d.RootDir)
+	c.Check(dirs.SnapdStateDir(d.RootDir), testutil.FilePresent)
+
+	c.Assert(d.Close(), check.IsNil)
+	c.Check(dirs.GlobalRootDir, check.Equals, oldRootDir)
+	c.Check(d.RootDir, testutil.FileAbsent)
+	// closing again does nothing
+	c.Check(d.Close(), check.IsNil)
+}
+
+func (s *daemontestSuite) TestGivenRootDirIsKept(c *check.C) {
+	rootDir := c.MkDir()
+
+	d, err := daemontest.New(&daemontest.Options{RootDir: rootDir})
+	c.Assert(err, check.IsNil)
+	c.Check(d.RootDir, check.Equals, rootDir)
+	c.Check(d.URL(), check.Matches, "http://127.0.0.1:[0-9]+")
+
+	c.Assert(d.Close(), check.IsNil)
+	c.Check(rootDir, testutil.FilePresent)
+}
@@ -0,0 +1,44 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+
+	"github.com/snapcore/snapd/overlord"
+)
+
+// NewEmbedded returns a daemon serving the API on top of the given
+// overlord without listening on any socket, its requests are served
+// in-process through Handler. As the API routes are global, only one
+// daemon can serve requests in a process at a time.
+//
+// It is meant for testing tooling built on top of the API, see the
+// daemontest package.
+func NewEmbedded(o *overlord.Overlord) *Daemon {
+	d := &Daemon{overlord: o, state: o.State(), requestThrottle: newRequestThrottle()}
+	d.addRoutes()
+	return d
+}
+
+// Handler returns the handler serving the API requests of the daemon.
+func (d *Daemon) Handler() http.Handler {
+	return d.router
+}
@@ -26,6 +26,7 @@ import (
 	"net/http"
 
 	"github.com/snapcore/snapd/arch"
+	"github.com/snapcore/snapd/asserts/snapasserts"
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/overlord/servicestate"
@@ -267,6 +268,84 @@ func InsufficientSpace(dserr *snapstate.InsufficientSpaceError) *apiError {
 	}
 }
 
+// SnapMissingBase is an error responder used when a snap cannot be
+// installed because its base is not available.
+func SnapMissingBase(mbe *snapstate.MissingBaseError) *apiError {
+	return &apiError{
+		Status:  400,
+		Message: mbe.Error(),
+		Kind:    client.ErrorKindSnapMissingBase,
+		Value: map[string]any{
+			"snap-name": mbe.Snap,
+			"base":      mbe.Base,
+		},
+	}
+}
+
+// revisionsToSets converts a map of expected revisions to the validation sets
+// requiring them into a JSON friendly form.
+func revisionsToSets(revs map[snap.Revision][]string) map[string][]string {
+	res := make(map[string][]string, len(revs))
+	for rev, sets := range revs {
+		key := ""
+		if !rev.Unset() {
+			key = rev.String()
+		}
+		res[key] = sets
+	}
+	return res
+}
+
+// ValidationSetConstraintsUnmet is an error responder used when an
+// operation would leave the system not meeting the constraints of the
+// enforced validation sets.
+func ValidationSetConstraintsUnmet(verr *snapasserts.ValidationSetsValidationError) *apiError {
+	value := map[string]any{}
+	if len(verr.MissingSnaps) > 0 {
+		missing := make(map[string]map[string][]string, len(verr.MissingSnaps))
+		for name, revs := range verr.MissingSnaps {
+			missing[name] = revisionsToSets(revs)
+		}
+		value["missing-snaps"] = missing
+	}
+	if len(verr.InvalidSnaps) > 0 {
+		value["invalid-snaps"] = verr.InvalidSnaps
+	}
+	if len(verr.WrongRevisionSnaps) > 0 {
+		wrong := make(map[string]map[string][]string, len(verr.WrongRevisionSnaps))
+		for name, revs := range verr.WrongRevisionSnaps {
+			wrong[name] = revisionsToSets(revs)
+		}
+		value["wrong-revision-snaps"] = wrong
+	}
+	return &apiError{
+		Status:  400,
+		Message: verr.Error(),
+		Kind:    client.ErrorKindValidationSetConstraintsUnmet,
+		Value:   value,
+	}
+}
+
+// AliasConflict is an error responder used when aliases cannot be enabled
+// because they conflict with existing aliases or commands.
+func AliasConflict(ace *snapstate.AliasConflictError) *apiError {
+	value := map[string]any{
+		"snap-name": ace.Snap,
+	}
+	if ace.Alias != "" {
+		value["alias"] = ace.Alias
+	}
+	if len(ace.Conflicts) > 0 {
+		value["conflicts"] = ace.Conflicts
+	}
+	return &apiError{
+		Status:  409,
+		Message: ace.Error(),
+		Kind:    client.ErrorKindAliasConflict,
+		Value:   value,
+	}
+}
+
 // AppNotFound is an error responder used when an operation is
 // requested on a app that doesn't exist.
 func AppNotFound(format string, v ...any) *apiError {
@@ -355,6 +434,12 @@ func errToResponse(err error, snaps []string, fallback errorResponder, format st
 			snapName = err.Snap
 		case *snapstate.InsufficientSpaceError:
 			return InsufficientSpace(err)
+		case *snapstate.MissingBaseError:
+			return SnapMissingBase(err)
+		case *snapstate.AliasConflictError:
+			return AliasConflict(err)
+		case *snapasserts.ValidationSetsValidationError:
+			return ValidationSetConstraintsUnmet(err)
 		case net.Error:
 			if err.Timeout() {
 				kind = client.ErrorKindNetworkTimeout
@@ -378,6 +463,18 @@ func errToResponse(err error, snaps []string, fallback errorResponder, format st
 					return SnapChangeConflict(conflErr)
 				}
 			}
+			var mbErr *snapstate.MissingBaseError
+			if errors.As(err, &mbErr) {
+				return SnapMissingBase(mbErr)
+			}
+			var aliasErr *snapstate.AliasConflictError
+			if errors.As(err, &aliasErr) {
+				return AliasConflict(aliasErr)
+			}
+			var vsErr *snapasserts.ValidationSetsValidationError
+			if errors.As(err, &vsErr) {
+				return ValidationSetConstraintsUnmet(vsErr)
+			}
 
 			handled = false
 		}
@@ -22,11 +22,13 @@ package daemon_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/asserts/snapasserts"
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -242,6 +244,73 @@ func (s *errorsSuite) TestErrToResponseInsufficentSpace(c *C) {
 	})
 }
 
+func (s *errorsSuite) TestErrToResponseMissingBase(c *C) {
+	err := &snapstate.MissingBaseError{Snap: "foo", Base: "core22"}
+	expected := &daemon.APIError{
+		Status:  400,
+		Message: `cannot find required base "core22"`,
+		Kind:    client.ErrorKindSnapMissingBase,
+		Value: map[string]any{
+			"snap-name": "foo",
+			"base":      "core22",
+		},
+	}
+	rspe := daemon.ErrToResponse(err, []string{"foo"}, daemon.BadRequest, "%s: %v", "ERR")
+	c.Check(rspe, DeepEquals, expected)
+
+	// also when wrapped
+	rspe = daemon.ErrToResponse(fmt.Errorf("cannot install: %w", err), []string{"foo"}, daemon.BadRequest, "%s: %v", "ERR")
+	c.Check(rspe, DeepEquals, expected)
+}
+
+func (s *errorsSuite) TestErrToResponseAliasConflict(c *C) {
+	err := &snapstate.AliasConflictError{
+		Snap:      "foo",
+		Conflicts: map[string][]string{"bar": {"baz"}},
+	}
+	rspe := daemon.ErrToResponse(err, []string{"foo"}, daemon.BadRequest, "%s: %v", "ERR")
+	c.Check(rspe, DeepEquals, &daemon.APIError{
+		Status:  409,
+		Message: err.Error(),
+		Kind:    client.ErrorKindAliasConflict,
+		Value: map[string]any{
+			"snap-name": "foo",
+			"conflicts": map[string][]string{"bar": {"baz"}},
+		},
+	})
+}
+
+func (s *errorsSuite) TestErrToResponseValidationSetConstraintsUnmet(c *C) {
+	err := &snapasserts.ValidationSetsValidationError{
+		MissingSnaps: map[string]map[snap.Revision][]string{
+			"foo": {snap.R(0): {"acme/one"}},
+		},
+		InvalidSnaps: map[string][]string{
+			"bar": {"acme/two"},
+		},
+		WrongRevisionSnaps: map[string]map[snap.Revision][]string{
+			"baz": {snap.R(3): {"acme/one", "acme/two"}},
+		},
+	}
+	rspe := daemon.ErrToResponse(err, nil, daemon.BadRequest, "%s: %v", "ERR")
+	c.Check(rspe, DeepEquals, &daemon.APIError{
+		Status:  400,
+		Message: err.Error(),
+		Kind:    client.ErrorKindValidationSetConstraintsUnmet,
+		Value: map[string]any{
+			"missing-snaps": map[string]map[string][]string{
+				"foo": {"": {"acme/one"}},
+			},
+			"invalid-snaps": map[string][]string{
+				"bar": {"acme/two"},
+			},
+			"wrong-revision-snaps": map[string]map[string][]string{
+				"baz": {"3": {"acme/one", "acme/two"}},
+			},
+		},
+	})
+}
+
 func (s *errorsSuite) TestAuthCancelled(c *C) {
 	c.Check(daemon.AuthCancelled("auth cancelled"), DeepEquals, &daemon.APIError{
 		Status:  403,
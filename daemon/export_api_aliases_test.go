@@ -22,4 +22,5 @@ package daemon
 type (
 	AliasAction = aliasAction
 	AliasStatus = aliasStatus
+	AliasTarget = aliasTarget
 )
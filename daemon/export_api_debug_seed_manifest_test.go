@@ -0,0 +1,38 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type (
+	SeedManifest          = seedManifest
+	SeedSnapDrift         = seedSnapDrift
+	SeedManifestSnap      = seedManifestSnap
+	SeedManifestAssertion = seedManifestAssertion
+)
+
+func MockDeviceManagerSeedManifest(f func(*devicestate.DeviceManager) (*devicestate.SeedManifest, error)) (restore func()) {
+	restore = testutil.Backup(&deviceManagerSeedManifest)
+	deviceManagerSeedManifest = f
+	return restore
+}
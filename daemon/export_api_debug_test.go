@@ -19,7 +19,13 @@
 
 package daemon
 
-import "github.com/snapcore/snapd/testutil"
+import (
+	"time"
+
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
 
 type (
 	ConnectivityStatus = connectivityStatus
@@ -29,6 +35,8 @@ type (
 	RefreshCandidateInfo = refreshCandidateInfo
 	RefreshCandidate     = refreshCandidate
 	FeatureResponse      = featureResponse
+
+	LogLevelOverrideJSON = logLevelOverrideJSON
 )
 
 var (
@@ -38,3 +46,11 @@ var (
 func MockCgroupPidsOfSnap(f func(instanceName string) (map[string][]int, error)) (restore func()) {
 	return testutil.Mock(&cgroupPidsOfSnap, f)
 }
+
+func MockDevicestateDeviceSessionStatusInfo(f func(st *state.State) (*devicestate.DeviceSessionStatus, error)) (restore func()) {
+	return testutil.Mock(&devicestateDeviceSessionStatusInfo, f)
+}
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	return testutil.Mock(&timeNow, f)
+}
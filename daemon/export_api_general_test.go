@@ -22,6 +22,8 @@ package daemon
 import (
 	"time"
 
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/osutil/hwinfo"
 	"github.com/snapcore/snapd/overlord/state"
 )
 
@@ -39,6 +41,52 @@ func MockSystemdVirt(newVirt string) (restore func()) {
 	return func() { systemdVirt = oldVirt }
 }
 
+func MockSysInfoCapabilities(f func() *client.SystemCapabilities) (restore func()) {
+	old := sysInfoCapabilities
+	sysInfoCapabilities = f
+	return func() {
+		sysInfoCapabilities = old
+	}
+}
+
+var SysInfoCapabilities = sysInfoCapabilitiesImpl
+
+func MockSysInfoSandboxDetails(f func() *client.SandboxDetails) (restore func()) {
+	old := sysInfoSandboxDetails
+	sysInfoSandboxDetails = f
+	return func() {
+		sysInfoSandboxDetails = old
+	}
+}
+
+var SysInfoSandboxDetails = sysInfoSandboxDetailsImpl
+
+func MockSysInfoHardware(f func() *client.HardwareInfo) (restore func()) {
+	old := sysInfoHardware
+	sysInfoHardware = f
+	return func() {
+		sysInfoHardware = old
+	}
+}
+
+var SysInfoHardware = sysInfoHardwareImpl
+
+func MockHwinfoGet(f func() *hwinfo.Info) (restore func()) {
+	old := hwinfoGet
+	hwinfoGet = f
+	return func() {
+		hwinfoGet = old
+	}
+}
+
+func MockApparmorPromptingSupported(f func() (bool, string)) (restore func()) {
+	old := apparmorPromptingSupported
+	apparmorPromptingSupported = f
+	return func() {
+		apparmorPromptingSupported = old
+	}
+}
+
 func MockWarningsAccessors(okay func(*state.State, time.Time) int, all func(*state.State) []*state.Warning, pending func(*state.State) ([]*state.Warning, time.Time)) (restore func()) {
 	oldOK := stateOkayWarnings
 	oldAll := stateAllWarnings
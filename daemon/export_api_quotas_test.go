@@ -61,3 +61,23 @@ func MockGetQuotaUsage(f func(grp *quota.Group) (*client.QuotaValues, error)) (r
 		getQuotaUsage = old
 	}
 }
+
+func MockServicestateMoveSnapsToQuota(f func(st *state.State, name string, snaps []string) (*state.TaskSet, error)) func() {
+	old := servicestateMoveSnapsToQuota
+	servicestateMoveSnapsToQuota = f
+	return func() {
+		servicestateMoveSnapsToQuota = old
+	}
+}
+
+func MockGetQuotaLiveUsage(f func(grp *quota.Group) (*client.QuotaUsage, error)) (restore func()) {
+	old := getQuotaLiveUsage
+	getQuotaLiveUsage = f
+	return func() {
+		getQuotaLiveUsage = old
+	}
+}
+
+func GetQuotaUsage(grp *quota.Group) (*client.QuotaValues, error) {
+	return getQuotaUsage(grp)
+}
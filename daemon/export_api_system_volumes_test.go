@@ -40,6 +40,14 @@ func MockFdestateReplaceRecoveryKey(f func(st *state.State, recoveryKeyID string
 	return testutil.Mock(&fdestateReplaceRecoveryKey, f)
 }
 
+func MockFdestateAddRecoveryKey(f func(st *state.State, recoveryKeyID string, keyslots []fdestate.KeyslotRef) (*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&fdestateAddRecoveryKey, f)
+}
+
+func MockFdestateRemoveKeyslots(f func(st *state.State, keyslots []fdestate.KeyslotRef) (*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&fdestateRemoveKeyslots, f)
+}
+
 func MockFdestateChangeAuth(f func(st *state.State, authMode device.AuthMode, old string, new string, keyslotRefs []fdestate.KeyslotRef) (*state.TaskSet, error)) (restore func()) {
 	return testutil.Mock(&fdestateChangeAuth, f)
 }
@@ -47,3 +55,11 @@ func MockFdestateChangeAuth(f func(st *state.State, authMode device.AuthMode, ol
 func MockDevicestateGetVolumeStructuresWithKeyslots(f func(st *state.State) ([]devicestate.VolumeStructureWithKeyslots, error)) (restore func()) {
 	return testutil.Mock(&devicestateGetVolumeStructuresWithKeyslots, f)
 }
+
+func MockDevicestateMountSystemVolume(f func(st *state.State, name string, opts *devicestate.MountSystemVolumeOptions) (*devicestate.SystemVolumeMount, error)) (restore func()) {
+	return testutil.Mock(&devicestateMountSystemVolume, f)
+}
+
+func MockDevicestateUnmountSystemVolume(f func(st *state.State, name string) error) (restore func()) {
+	return testutil.Mock(&devicestateUnmountSystemVolume, f)
+}
@@ -64,9 +64,7 @@ func NewAndAddRoutes() (*Daemon, error) {
 }
 
 func NewWithOverlord(o *overlord.Overlord) *Daemon {
-	d := &Daemon{overlord: o, state: o.State()}
-	d.addRoutes()
-	return d
+	return NewEmbedded(o)
 }
 
 func (d *Daemon) RouterMatch(req *http.Request, m *mux.RouteMatch) bool {
@@ -85,9 +83,9 @@ type Ucrednet = ucrednet
 
 func BeforeNewChange(beforeNewChange func(st *state.State, kind, summary string, tsets []*state.TaskSet, snapNames []string)) (restore func()) {
 	oldNewChange := newChange
-	newChange = func(st *state.State, kind, summary string, tsets []*state.TaskSet, snapNames []string) *state.Change {
+	newChange = func(ctx context.Context, st *state.State, kind, summary string, tsets []*state.TaskSet, snapNames []string) *state.Change {
 		beforeNewChange(st, kind, summary, tsets, snapNames)
-		return newChangeImpl(st, kind, summary, tsets, snapNames)
+		return newChangeImpl(ctx, st, kind, summary, tsets, snapNames)
 	}
 	return func() {
 		newChange = oldNewChange
@@ -196,6 +194,14 @@ func MockSnapstateStoreInstallGoal(mock func(snaps ...snapstate.StoreSnap) snaps
 	}
 }
 
+func MockSnapstateClassifyRefreshes(mock func(*state.State, []*snap.Info) (map[string]snapstate.RefreshClassification, error)) (restore func()) {
+	old := snapstateClassifyRefreshes
+	snapstateClassifyRefreshes = mock
+	return func() {
+		snapstateClassifyRefreshes = old
+	}
+}
+
 func MockSnapstateStoreUpdateGoal(mock func(snaps ...snapstate.StoreUpdate) snapstate.UpdateGoal) (restore func()) {
 	old := snapstateStoreUpdateGoal
 	snapstateStoreUpdateGoal = mock
@@ -212,6 +218,10 @@ func MockSnapstateInstallPath(mock func(*state.State, *snap.SideInfo, string, st
 	}
 }
 
+func MockSnapstateInstallFromURL(mock func(*state.State, string, string, int, snapstate.Flags) (*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&snapstateInstallFromURL, mock)
+}
+
 func MockSnapstateTryPath(mock func(*state.State, string, string, snapstate.Flags) (*state.TaskSet, error)) (restore func()) {
 	oldSnapstateTryPath := snapstateTryPath
 	snapstateTryPath = mock
@@ -220,6 +230,14 @@ func MockSnapstateTryPath(mock func(*state.State, string, string, snapstate.Flag
 	}
 }
 
+func MockSnapstateDownloadRefresh(mock func(context.Context, *state.State, string, *snapstate.RevisionOptions, int) (*state.TaskSet, *snap.Info, error)) (restore func()) {
+	return testutil.Mock(&snapstateDownloadRefresh, mock)
+}
+
+func MockSnapstateProceedWithInhibitedRefresh(mock func(*state.State, string) ([]*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&snapstateProceedWithInhibitedRefresh, mock)
+}
+
 func MockSnapstateSwitch(mock func(*state.State, string, *snapstate.RevisionOptions, snapstate.PrereqTracker) (*state.TaskSet, error)) (restore func()) {
 	oldSnapstateSwitch := snapstateSwitch
 	snapstateSwitch = mock
@@ -439,6 +457,10 @@ func MockConfdbstateSetViaView(f func(confdb.Databag, *confdb.View, map[string]a
 	return testutil.Mock(&confdbstateSetViaView, f)
 }
 
+func MockAssertstateTrackedEnforcedValidationSets(f func(*state.State, ...*asserts.ValidationSet) (*snapasserts.ValidationSets, error)) (restore func()) {
+	return testutil.Mock(&assertstateTrackedEnforcedValidationSets, f)
+}
+
 func MockAssertstateFetchAllValidationSets(f func(*state.State, int, *assertstate.RefreshAssertionsOptions) error) (restore func()) {
 	return testutil.Mock(&assertstateFetchAllValidationSets, f)
 }
@@ -0,0 +1,251 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	// register the decoders for the icon formats that can be scaled
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+)
+
+const (
+	// the theme desktop shells fall back to when an icon is missing
+	// from the current one
+	fallbackIconTheme = "hicolor"
+
+	maxIconSize = 1024
+	// icons bigger than this are served as they are rather than
+	// decoded to be scaled
+	maxSourceIconSize = 4096
+)
+
+var validIconTheme = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// iconOptions describe the icon a client asked for.
+type iconOptions struct {
+	// Size is the size in pixels of the (square) icon, 0 for the icon
+	// as shipped.
+	Size int
+	// Theme is the desktop icon theme to look up first.
+	Theme string
+}
+
+func parseIconOptions(query map[string][]string) (*iconOptions, error) {
+	var opts iconOptions
+	get := func(key string) string {
+		if vs := query[key]; len(vs) > 0 {
+			return vs[0]
+		}
+		return ""
+	}
+
+	if size := get("size"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 || n > maxIconSize {
+			return nil, fmt.Errorf("invalid icon size %q: must be a number between 1 and %d", size, maxIconSize)
+		}
+		opts.Size = n
+	}
+	if theme := get("theme"); theme != "" {
+		if !validIconTheme.MatchString(theme) {
+			return nil, fmt.Errorf("invalid icon theme %q", theme)
+		}
+		opts.Theme = theme
+	}
+	return &opts, nil
+}
+
+// themedSnapIcon looks for an icon of the snap in the desktop icon
+// themes shipped in its meta/gui/icons directory, trying the requested
+// theme first and then the fallback theme. It prefers a scalable icon,
+// then the smallest icon at least as big as requested, then the biggest
+// one.
+func themedSnapIcon(info snap.PlaceInfo, opts *iconOptions) string {
+	themes := []string{fallbackIconTheme}
+	if opts.Theme != "" && opts.Theme != fallbackIconTheme {
+		themes = []string{opts.Theme, fallbackIconTheme}
+	}
+
+	// icons for the app named like the snap go first
+	snapName := snap.InstanceSnap(info.InstanceName())
+	prefixes := []string{fmt.Sprintf("snap.%s.%s.", snapName, snapName), fmt.Sprintf("snap.%s.", snapName)}
+
+	for _, theme := range themes {
+		themeDir := filepath.Join(info.MountDir(), "meta", "gui", "icons", theme)
+		for _, prefix := range prefixes {
+			if svgs, _ := filepath.Glob(filepath.Join(themeDir, "scalable", "apps", prefix+"*svg")); len(svgs) > 0 {
+				sort.Strings(svgs)
+				return svgs[0]
+			}
+
+			pngs, _ := filepath.Glob(filepath.Join(themeDir, "*x*", "apps", prefix+"*png"))
+			if len(pngs) == 0 {
+				continue
+			}
+			sizeOf := func(path string) int {
+				dim := filepath.Base(filepath.Dir(filepath.Dir(path)))
+				n, _ := strconv.Atoi(strings.SplitN(dim, "x", 2)[0])
+				return n
+			}
+			sort.Slice(pngs, func(i, j int) bool {
+				si, sj := sizeOf(pngs[i]), sizeOf(pngs[j])
+				if si != sj {
+					return si < sj
+				}
+				return pngs[i] < pngs[j]
+			})
+			for _, p := range pngs {
+				if opts.Size != 0 && sizeOf(p) >= opts.Size {
+					return p
+				}
+			}
+			return pngs[len(pngs)-1]
+		}
+	}
+	return ""
+}
+
+// scaledIcon returns the path of a copy of the icon scaled so that it
+// fits in a square of the requested size. Scaled copies are cached on
+// disk. Vector icons and icons already of the right size are returned
+// as they are.
+func scaledIcon(icon, instanceName string, size int) (string, error) {
+	if size == 0 || strings.HasSuffix(icon, ".svg") {
+		return icon, nil
+	}
+
+	fi, err := os.Stat(icon)
+	if err != nil {
+		return "", err
+	}
+	// the source path includes the snap revision, the modification time
+	// covers icons from the store being updated in place
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d", icon, fi.ModTime().UnixNano(), size)))
+	cached := filepath.Join(dirs.SnapIconsScaledDir, instanceName, hex.EncodeToString(h[:16])+".png")
+	if osutil.FileExists(cached) {
+		return cached, nil
+	}
+
+	f, err := os.Open(icon)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	// look at the dimensions before decoding the whole image
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return "", fmt.Errorf("cannot decode icon %q: %v", icon, err)
+	}
+	if cfg.Width == size && cfg.Height == size {
+		return icon, nil
+	}
+	if cfg.Width > maxSourceIconSize || cfg.Height > maxSourceIconSize {
+		return "", fmt.Errorf("cannot scale icon %q: %dx%d is too big", icon, cfg.Width, cfg.Height)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	src, _, err := image.Decode(f)
+	if err != nil {
+		return "", fmt.Errorf("cannot decode icon %q: %v", icon, err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaleImage(src, size)); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err != nil {
+		return "", err
+	}
+	if err := osutil.AtomicWriteFile(cached, buf.Bytes(), 0644, 0); err != nil {
+		return "", err
+	}
+	return cached, nil
+}
+
+// scaleImage scales the image, keeping its aspect ratio, so that its
+// longest side is size pixels. Each destination pixel is the average
+// of the source pixels it covers, which is good enough for icons.
+func scaleImage(src image.Image, size int) *image.NRGBA {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	dw, dh := size, size
+	if sw > sh {
+		dh = maxInt(1, sh*size/sw)
+	} else if sh > sw {
+		dw = maxInt(1, sw*size/sh)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := sb.Min.Y + y*sh/dh
+		y1 := maxInt(y0+1, sb.Min.Y+(y+1)*sh/dh)
+		for x := 0; x < dw; x++ {
+			x0 := sb.Min.X + x*sw/dw
+			x1 := maxInt(x0+1, sb.Min.X+(x+1)*sw/dw)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					// premultiplied 16-bit components
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,176 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap/quota"
+)
+
+var _ = registerAPIFeature("if-match")
+
+// resourceVersion returns an opaque version for the given representation of
+// a mutable resource, which changes whenever the resource does. Versions are
+// returned by GET requests and can be passed back via If-Match on mutating
+// requests so that concurrent modifications are detected.
+func resourceVersion(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("cannot compute resource version: %v", err)
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:12]), nil
+}
+
+// checkIfMatch checks the If-Match header of the request, if any, against the
+// current version of the given resource, an empty current version meaning
+// that the resource does not exist. It returns a resource-version-mismatch
+// error if the resource was modified since the client retrieved it, or if a
+// change which is about to modify it is still in progress, as the version
+// cannot be relied upon until that change is ready.
+//
+// The caller must ensure that the state lock is held.
+func checkIfMatch(st *state.State, r *http.Request, resource, current string) *apiError {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return nil
+	}
+	if chg := pendingResourceChange(st, resource); chg != nil {
+		return &apiError{
+			Status:  412,
+			Message: fmt.Sprintf("resource is being modified by change %s", chg.ID()),
+			Kind:    client.ErrorKindResourceVersionMismatch,
+			Value: map[string]any{
+				"current-version": current,
+				"change":          chg.ID(),
+			},
+		}
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" && current != "" {
+			return nil
+		}
+		if strings.Trim(tag, `"`) == current && current != "" {
+			return nil
+		}
+	}
+	return &apiError{
+		Status:  412,
+		Message: "resource was modified concurrently: version does not match",
+		Kind:    client.ErrorKindResourceVersionMismatch,
+		Value: map[string]any{
+			"current-version": current,
+		},
+	}
+}
+
+// markResourceChange records that the given change modifies the given
+// resource, so that requests conditional on the version of the resource are
+// refused until the change is ready.
+func markResourceChange(chg *state.Change, resource string) {
+	chg.Set("modified-resource", resource)
+}
+
+// pendingResourceChange returns the change which is not ready yet and
+// modifies the given resource, if any.
+//
+// The caller must ensure that the state lock is held.
+func pendingResourceChange(st *state.State, resource string) *state.Change {
+	for _, chg := range st.Changes() {
+		if chg.IsReady() {
+			continue
+		}
+		var modified string
+		if err := chg.Get("modified-resource", &modified); err != nil {
+			continue
+		}
+		if modified == resource {
+			return chg
+		}
+	}
+	return nil
+}
+
+// syncResponseWithVersion builds a "sync" response for the given result
+// which carries the given resource version, both in the response body and in
+// the ETag header.
+func syncResponseWithVersion(result any, version string) Response {
+	return &respJSON{
+		Type:            ResponseTypeSync,
+		Status:          200,
+		Result:          result,
+		ResourceVersion: version,
+	}
+}
+
+const connectionsResource = "connections"
+
+func snapConfResource(snapName string) string {
+	return "conf/" + snapName
+}
+
+func quotaGroupResource(group string) string {
+	return "quota-group/" + group
+}
+
+// snapConfVersion returns the version of the whole configuration document of
+// the given snap.
+//
+// The caller must ensure that the state lock is held.
+func snapConfVersion(st *state.State, snapName string) (string, error) {
+	tr := config.NewTransaction(st)
+	var conf any
+	if err := tr.Get(snapName, "", &conf); err != nil && !config.IsNoOption(err) {
+		return "", err
+	}
+	return resourceVersion(conf)
+}
+
+// quotaGroupVersion returns the version of the given quota group, or an empty
+// version if the group does not exist.
+func quotaGroupVersion(grp *quota.Group) (string, error) {
+	if grp == nil {
+		return "", nil
+	}
+	return resourceVersion(grp)
+}
+
+// connectionsVersion returns the version of the set of interface connections
+// in the system.
+//
+// The caller must ensure that the state lock is held.
+func connectionsVersion(st *state.State) (string, error) {
+	var conns map[string]any
+	if err := st.Get("conns", &conns); err != nil && !errors.Is(err, state.ErrNoState) {
+		return "", err
+	}
+	return resourceVersion(conns)
+}
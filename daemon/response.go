@@ -28,6 +28,7 @@ import (
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/asserts"
@@ -79,6 +80,9 @@ type respJSON struct {
 	Sources []string `json:"sources,omitempty"`
 	// XXX SuggestedCurrency is part of unsupported paid snap code.
 	SuggestedCurrency string `json:"suggested-currency,omitempty"`
+	// ResourceVersion is the version of the returned mutable resource, to
+	// be passed back via If-Match when modifying it.
+	ResourceVersion string `json:"resource-version,omitempty"`
 	// Maintenance...  are filled as needed by the serving pipeline.
 	WarningTimestamp *time.Time   `json:"warning-timestamp,omitempty"`
 	WarningCount     int          `json:"warning-count,omitempty"`
@@ -160,6 +164,10 @@ func (r *respJSON) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
 		}
 	}
 
+	if r.ResourceVersion != "" {
+		hdr.Set("ETag", `"`+r.ResourceVersion+`"`)
+	}
+
 	hdr.Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	w.Write(bs)
@@ -245,19 +253,116 @@ type snapshotExportResponse struct {
 	*snapshotstate.SnapshotExport
 	setID uint64
 	st    *state.State
+	// resume is set when the Range of the request can be honored
+	resume bool
+}
+
+// parseByteRange parses a single byte range as found in a Range header
+// and returns the offsets of its first and last bytes.
+func parseByteRange(header string, size int64) (first, last int64, err error) {
+	if !strings.HasPrefix(header, "bytes=") {
+		return 0, 0, fmt.Errorf("unsupported range %q", header)
+	}
+	spec := strings.TrimPrefix(header, "bytes=")
+	if strings.Contains(spec, ",") {
+		return 0, 0, fmt.Errorf("unsupported range %q", header)
+	}
+	firstStr, lastStr, ok := strings.Cut(spec, "-")
+	if !ok || firstStr == "" {
+		return 0, 0, fmt.Errorf("unsupported range %q", header)
+	}
+	first, err = strconv.ParseInt(firstStr, 10, 64)
+	if err != nil || first < 0 {
+		return 0, 0, fmt.Errorf("invalid range %q", header)
+	}
+	last = size - 1
+	if lastStr != "" {
+		last, err = strconv.ParseInt(lastStr, 10, 64)
+		if err != nil || last < first {
+			return 0, 0, fmt.Errorf("invalid range %q", header)
+		}
+		if last > size-1 {
+			last = size - 1
+		}
+	}
+	if first >= size {
+		return 0, 0, fmt.Errorf("range %q not satisfiable", header)
+	}
+	return first, last, nil
+}
+
+var errRangeWritten = fmt.Errorf("range written")
+
+// rangeWriter writes only the bytes in the [skip, skip+remaining) range of
+// what is written to it.
+type rangeWriter struct {
+	w         io.Writer
+	skip      int64
+	remaining int64
+}
+
+func (rw *rangeWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if rw.skip >= int64(len(p)) {
+		rw.skip -= int64(len(p))
+		return n, nil
+	}
+	p = p[rw.skip:]
+	rw.skip = 0
+	if int64(len(p)) > rw.remaining {
+		p = p[:rw.remaining]
+	}
+	if _, err := rw.w.Write(p); err != nil {
+		return 0, err
+	}
+	rw.remaining -= int64(len(p))
+	if rw.remaining == 0 {
+		// stop the production of the rest of the stream
+		return n, errRangeWritten
+	}
+	return n, nil
 }
 
 // ServeHTTP from the Response interface
 func (s snapshotExportResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Length", strconv.FormatInt(s.Size(), 10))
+	defer func() {
+		s.Close()
+		s.st.Lock()
+		defer s.st.Unlock()
+		snapshotstate.UnsetSnapshotOpInProgress(s.st, s.setID)
+	}()
+
+	size := s.Size()
 	w.Header().Add("Content-Type", client.SnapshotExportMediaType)
-	if err := s.StreamTo(w); err != nil {
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("ETag", fmt.Sprintf("%q", s.SessionID()))
+
+	first, last := int64(0), size-1
+	if s.resume {
+		var err error
+		first, last, err = parseByteRange(r.Header.Get("Range"), size)
+		if err != nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+	}
+
+	w.Header().Add("Content-Length", strconv.FormatInt(last-first+1, 10))
+	if !s.resume {
+		if err := s.StreamTo(w); err != nil {
+			logger.Debugf("cannot export snapshot: %v", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", first, last, size))
+	w.WriteHeader(http.StatusPartialContent)
+	rw := &rangeWriter{w: w, skip: first, remaining: last - first + 1}
+	// the error stopping the stream once the range is written is expected
+	if err := s.StreamTo(rw); err != nil && rw.remaining > 0 {
 		logger.Debugf("cannot export snapshot: %v", err)
 	}
-	s.Close()
-	s.st.Lock()
-	defer s.st.Unlock()
-	snapshotstate.UnsetSnapshotOpInProgress(s.st, s.setID)
 }
 
 // A fileResponse 's ServeHTTP method serves the file
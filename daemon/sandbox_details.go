@@ -0,0 +1,134 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/sandbox"
+	"github.com/snapcore/snapd/sandbox/apparmor"
+	"github.com/snapcore/snapd/sandbox/cgroup"
+	"github.com/snapcore/snapd/sandbox/seccomp"
+)
+
+var (
+	apparmorPromptingSupported = apparmor.PromptingSupported
+)
+
+var _ = registerAPIFeature("sandbox-details")
+
+// sysInfoSandboxDetailsImpl reports the full sandbox feature matrix detected on the
+// system and which snapd features are degraded as a result. It is meant to
+// help triaging issues across the variety of kernels snapd runs on.
+func sysInfoSandboxDetailsImpl() *client.SandboxDetails {
+	details := &client.SandboxDetails{
+		Confinement: "strict",
+		AppArmor: client.AppArmorDetails{
+			Level:   apparmor.ProbedLevel().String(),
+			Summary: apparmor.Summary(),
+		},
+		Seccomp: client.SeccompDetails{
+			Actions: seccomp.Actions(),
+		},
+		UserNamespaces: probeUserNamespaces(),
+	}
+
+	if apparmor.ProbedLevel() != apparmor.Unsupported {
+		if features, err := apparmor.KernelFeatures(); err != nil {
+			logger.Debugf("cannot get apparmor kernel features: %v", err)
+		} else {
+			details.AppArmor.KernelFeatures = features
+		}
+		if features, err := apparmor.ParserFeatures(); err != nil {
+			logger.Debugf("cannot get apparmor parser features: %v", err)
+		} else {
+			details.AppArmor.ParserFeatures = features
+		}
+	}
+
+	if ver, err := cgroup.Version(); err == nil {
+		details.CgroupVersion = ver
+	}
+
+	if sandbox.ForceDevMode() {
+		details.Confinement = "partial"
+		details.Degraded = append(details.Degraded, client.DegradedFeature{
+			Feature: "strict-confinement",
+			Reason:  fmt.Sprintf("apparmor support is %s, snaps are forced into devmode", details.AppArmor.Level),
+		})
+	}
+	if supported, reason := apparmorPromptingSupported(); !supported {
+		details.Degraded = append(details.Degraded, client.DegradedFeature{
+			Feature: "apparmor-prompting",
+			Reason:  reason,
+		})
+	}
+	if !seccomp.SupportsAction("log") {
+		details.Degraded = append(details.Degraded, client.DegradedFeature{
+			Feature: "devmode-seccomp-logging",
+			Reason:  "kernel does not support the seccomp log action",
+		})
+	}
+	if details.CgroupVersion == 1 {
+		details.Degraded = append(details.Degraded, client.DegradedFeature{
+			Feature: "quota-cpu-set",
+			Reason:  "CPU set quotas require cgroup version 2",
+		})
+	}
+
+	return details
+}
+
+// readProcSysInt reads an integer from the given file under /proc/sys.
+func readProcSysInt(name string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dirs.GlobalRootDir, "/proc/sys", name))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// probeUserNamespaces checks whether unprivileged processes can create user
+// namespaces and whether that is mediated by AppArmor.
+func probeUserNamespaces() client.UserNamespacesDetails {
+	var userns client.UserNamespacesDetails
+
+	maxUserNamespaces, err := readProcSysInt("user/max_user_namespaces")
+	if err != nil {
+		logger.Debugf("cannot determine user namespaces support: %v", err)
+		return userns
+	}
+	userns.Available = maxUserNamespaces > 0
+	// the knob is only present on some distribution kernels
+	if clone, err := readProcSysInt("kernel/unprivileged_userns_clone"); err == nil && clone == 0 {
+		userns.Available = false
+	}
+	if restrict, err := readProcSysInt("kernel/apparmor_restrict_unprivileged_userns"); err == nil && restrict != 0 {
+		userns.RestrictedByAppArmor = true
+	}
+	return userns
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/healthstate"
+	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
@@ -46,6 +47,7 @@ type aboutSnap struct {
 	snapst         *snapstate.SnapState
 	health         *client.SnapHealth
 	refreshInhibit *client.SnapRefreshInhibit
+	nameResolution *client.SnapNameResolution
 
 	hold       time.Time
 	gatingHold time.Time
@@ -88,16 +90,33 @@ func localSnapInfo(st *state.State, name string) (aboutSnap, error) {
 
 	refreshInhibit := clientSnapRefreshInhibit(st, &snapst, name)
 
+	nameResolution, err := clientSnapNameResolution(st, name)
+	if err != nil {
+		return aboutSnap{}, err
+	}
+
 	return aboutSnap{
 		info:           info,
 		snapst:         &snapst,
 		health:         clientHealthFromHealthstate(health),
 		refreshInhibit: refreshInhibit,
+		nameResolution: nameResolution,
 		hold:           userHold,
 		gatingHold:     gatingHold,
 	}, nil
 }
 
+func clientSnapNameResolution(st *state.State, name string) (*client.SnapNameResolution, error) {
+	nr, err := ifacestate.SnapNameResolution(st, name)
+	if err != nil || nr == nil {
+		return nil, err
+	}
+	return &client.SnapNameResolution{
+		Nameservers: nr.Nameservers,
+		Hosts:       nr.Hosts,
+	}, nil
+}
+
 func getUserAndGatingHolds(st *state.State, name string) (userHold, gatingHold time.Time, err error) {
 	userHold, err = snapstateSystemHold(st, name)
 	if err != nil {
@@ -154,6 +173,11 @@ func allLocalSnapInfos(st *state.State, sel snapSelect, wanted map[string]bool)
 			continue
 		}
 
+		nameResolution, err := clientSnapNameResolution(st, name)
+		if err != nil {
+			return nil, err
+		}
+
 		var aboutThis []aboutSnap
 		var info *snap.Info
 		if sel == snapSelectAll {
@@ -179,6 +203,7 @@ func allLocalSnapInfos(st *state.State, sel snapSelect, wanted map[string]bool)
 					snapst:         snapst,
 					health:         health,
 					refreshInhibit: refreshInhibit,
+					nameResolution: nameResolution,
 					hold:           userHold,
 					gatingHold:     gatingHold,
 				}
@@ -200,6 +225,7 @@ func allLocalSnapInfos(st *state.State, sel snapSelect, wanted map[string]bool)
 				snapst:         snapst,
 				health:         health,
 				refreshInhibit: refreshInhibit,
+				nameResolution: nameResolution,
 				hold:           userHold,
 				gatingHold:     gatingHold,
 			}
@@ -273,6 +299,7 @@ func mapLocal(about aboutSnap, sd clientutil.StatusDecorator) *client.Snap {
 	}
 	result.Health = about.health
 	result.RefreshInhibit = about.refreshInhibit
+	result.NameResolution = about.nameResolution
 
 	if !about.hold.IsZero() {
 		result.Hold = &about.hold
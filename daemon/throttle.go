@@ -0,0 +1,209 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// maxChangesRetryAfter is the delay suggested to clients when the limit of
+// in-flight changes is reached, there is no way to know when a change
+// will be done so this is a guess.
+const maxChangesRetryAfter = 10 * time.Second
+
+var timeNow = time.Now
+
+// maxThrottledClients bounds the number of clients tracked by the request
+// throttle.
+var maxThrottledClients = 1000
+
+// requestThrottle limits the rate of write requests of each client (user).
+// It implements the generic cell rate algorithm: for each user it tracks
+// the theoretical arrival time of the next request were requests arriving
+// at exactly the allowed rate, bursts are allowed as long as that time is
+// not more than a minute ahead.
+type requestThrottle struct {
+	mu  sync.Mutex
+	tat map[uint32]time.Time
+	// lastExpire is when entries in the past were last dropped from tat
+	lastExpire time.Time
+}
+
+func newRequestThrottle() *requestThrottle {
+	return &requestThrottle{tat: make(map[uint32]time.Time)}
+}
+
+// take accounts for a request of the given user, allowing for perMinute
+// requests per minute. It returns zero if the request can proceed, or
+// otherwise how long the user needs to wait before retrying.
+func (rt *requestThrottle) take(uid uint32, perMinute int, now time.Time) time.Duration {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.expire(now, false)
+
+	interval := time.Minute / time.Duration(perMinute)
+	tat := rt.tat[uid]
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(interval)
+	if allowAt := newTat.Add(-time.Minute); allowAt.After(now) {
+		return allowAt.Sub(now)
+	}
+	if _, ok := rt.tat[uid]; !ok && len(rt.tat) >= maxThrottledClients {
+		rt.evict(now)
+	}
+	rt.tat[uid] = newTat
+	return 0
+}
+
+// expire drops the entries of users whose theoretical arrival time is in
+// the past, those are equivalent to users without an entry. Unless forced
+// this is done at most once a minute so that the map only tracks recently
+// active users.
+func (rt *requestThrottle) expire(now time.Time, force bool) {
+	if !force && now.Sub(rt.lastExpire) < time.Minute {
+		return
+	}
+	for uid, tat := range rt.tat {
+		if !tat.After(now) {
+			delete(rt.tat, uid)
+		}
+	}
+	rt.lastExpire = now
+}
+
+// evict makes room for a new entry once maxThrottledClients are tracked,
+// dropping the entries in the past or failing that the entry that is the
+// closest to be in the past.
+func (rt *requestThrottle) evict(now time.Time) {
+	rt.expire(now, true)
+	if len(rt.tat) < maxThrottledClients {
+		return
+	}
+	var oldestUID uint32
+	var oldest time.Time
+	for uid, tat := range rt.tat {
+		if oldest.IsZero() || tat.Before(oldest) {
+			oldestUID, oldest = uid, tat
+		}
+	}
+	delete(rt.tat, oldestUID)
+}
+
+// apiLimits returns the configured maximum of in-flight changes and of
+// write requests per minute per client, zero meaning no limit.
+func apiLimits(st *state.State) (maxChanges, rateLimit int) {
+	tr := config.NewTransaction(st)
+	return apiLimit(tr, "api.max-changes"), apiLimit(tr, "api.rate-limit")
+}
+
+func apiLimit(tr *config.Transaction, opt string) int {
+	var v any
+	if err := tr.GetMaybe("core", opt, &v); err != nil {
+		logger.Noticef("cannot get %s system option: %v", opt, err)
+		return 0
+	}
+	if v == nil {
+		return 0
+	}
+	// the value could have been set either as a number or as a string
+	n, err := strconv.Atoi(fmt.Sprintf("%v", v))
+	if err != nil || n < 0 {
+		logger.Noticef("internal error: %s system option has unexpected value: %v", opt, v)
+		return 0
+	}
+	return n
+}
+
+func inFlightChanges(st *state.State) int {
+	n := 0
+	for _, chg := range st.Changes() {
+		if !chg.IsReady() {
+			n++
+		}
+	}
+	return n
+}
+
+// throttle checks whether the given write request to a throttled command
+// can proceed according to the configured limits, returning an error
+// response otherwise.
+func (d *Daemon) throttle(ucred *ucrednet) Response {
+	st := d.state
+	st.Lock()
+	maxChanges, rateLimit := apiLimits(st)
+	var inFlight int
+	if maxChanges > 0 {
+		inFlight = inFlightChanges(st)
+	}
+	st.Unlock()
+
+	if rateLimit > 0 && ucred != nil {
+		if wait := d.requestThrottle.take(ucred.Uid, rateLimit, timeNow()); wait > 0 {
+			return TooManyRequests(wait, "too many requests, limit is %d per minute", rateLimit)
+		}
+	}
+	if maxChanges > 0 && inFlight >= maxChanges {
+		return TooManyRequests(maxChangesRetryAfter, "too many changes in progress, limit is %d", maxChanges)
+	}
+	return nil
+}
+
+// tooManyRequestsResponse is an error response telling the client to
+// retry the request later.
+type tooManyRequestsResponse struct {
+	err        *apiError
+	retryAfter time.Duration
+}
+
+// TooManyRequests is an error responder used when a request is rejected
+// because of the configured API limits, the client is told to retry after
+// the given duration.
+func TooManyRequests(retryAfter time.Duration, format string, v ...any) Response {
+	return &tooManyRequestsResponse{
+		err: &apiError{
+			Status:  429,
+			Message: fmt.Sprintf(format, v...),
+			Kind:    client.ErrorKindTooManyRequests,
+		},
+		retryAfter: retryAfter,
+	}
+}
+
+func (r *tooManyRequestsResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	secs := int(math.Ceil(r.retryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	r.err.ServeHTTP(w, req)
+}
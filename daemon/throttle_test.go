@@ -0,0 +1,195 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *daemonSuite) TestRequestThrottleTake(c *check.C) {
+	rt := newRequestThrottle()
+	now := time.Now()
+
+	// a full minute worth of requests can be done in a burst
+	c.Check(rt.take(1000, 2, now), check.Equals, time.Duration(0))
+	c.Check(rt.take(1000, 2, now), check.Equals, time.Duration(0))
+	c.Check(rt.take(1000, 2, now), check.Equals, 30*time.Second)
+	c.Check(rt.take(1000, 2, now.Add(10*time.Second)), check.Equals, 20*time.Second)
+	// other users are not affected
+	c.Check(rt.take(0, 2, now), check.Equals, time.Duration(0))
+
+	// capacity is regained at the configured rate
+	now = now.Add(30 * time.Second)
+	c.Check(rt.take(1000, 2, now), check.Equals, time.Duration(0))
+	c.Check(rt.take(1000, 2, now), check.Equals, 30*time.Second)
+	now = now.Add(time.Hour)
+	c.Check(rt.take(1000, 2, now), check.Equals, time.Duration(0))
+	c.Check(rt.take(1000, 2, now), check.Equals, time.Duration(0))
+	c.Check(rt.take(1000, 2, now), check.Equals, 30*time.Second)
+}
+
+func (s *daemonSuite) TestRequestThrottleExpiresEntries(c *check.C) {
+	rt := newRequestThrottle()
+	now := time.Now()
+
+	for uid := uint32(0); uid < 100; uid++ {
+		c.Check(rt.take(uid, 2, now), check.Equals, time.Duration(0))
+	}
+	c.Check(rt.tat, check.HasLen, 100)
+
+	// entries are not dropped while they still matter
+	c.Check(rt.take(1000, 2, now.Add(10*time.Second)), check.Equals, time.Duration(0))
+	c.Check(rt.tat, check.HasLen, 101)
+
+	// but once they are in the past
+	c.Check(rt.take(1000, 2, now.Add(2*time.Minute)), check.Equals, time.Duration(0))
+	c.Check(rt.tat, check.HasLen, 1)
+}
+
+func (s *daemonSuite) TestRequestThrottleBoundsEntries(c *check.C) {
+	defer testutil.Mock(&maxThrottledClients, 3)()
+	rt := newRequestThrottle()
+	now := time.Now()
+
+	c.Check(rt.take(0, 2, now), check.Equals, time.Duration(0))
+	c.Check(rt.take(1, 2, now.Add(time.Second)), check.Equals, time.Duration(0))
+	c.Check(rt.take(2, 2, now.Add(2*time.Second)), check.Equals, time.Duration(0))
+	c.Check(rt.tat, check.HasLen, 3)
+
+	// known clients do not need room
+	c.Check(rt.take(2, 2, now.Add(3*time.Second)), check.Equals, time.Duration(0))
+	c.Check(rt.tat, check.HasLen, 3)
+
+	// the entry that is the closest to be in the past makes room
+	c.Check(rt.take(3, 2, now.Add(4*time.Second)), check.Equals, time.Duration(0))
+	c.Check(rt.tat, check.HasLen, 3)
+	c.Check(rt.tat[0].IsZero(), check.Equals, true)
+
+	// entries in the past are dropped first
+	c.Check(rt.take(4, 2, now.Add(40*time.Second)), check.Equals, time.Duration(0))
+	c.Check(rt.tat, check.HasLen, 2)
+	c.Check(rt.tat[1].IsZero(), check.Equals, true)
+	c.Check(rt.tat[2].IsZero(), check.Equals, false)
+}
+
+func (s *daemonSuite) newThrottledTestCommand(c *check.C) *Command {
+	d := s.newTestDaemon(c)
+	cmd := &Command{d: d, Throttled: true}
+	cmd.GET = func(*Command, *http.Request, *auth.UserState) Response {
+		return SyncResponse(nil)
+	}
+	cmd.POST = func(*Command, *http.Request, *auth.UserState) Response {
+		return SyncResponse(nil)
+	}
+	cmd.ReadAccess = openAccess{}
+	cmd.WriteAccess = rootAccess{}
+	return cmd
+}
+
+func (s *daemonSuite) setAPILimit(c *check.C, d *Daemon, opt string, value any) {
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", opt, value), check.IsNil)
+	tr.Commit()
+}
+
+func checkTooManyRequests(c *check.C, code int, header http.Header, body []byte, retryAfter string) {
+	c.Check(code, check.Equals, 429)
+	c.Check(header.Get("Retry-After"), check.Equals, retryAfter)
+	var v struct{ Result errorResult }
+	c.Assert(json.Unmarshal(body, &v), check.IsNil)
+	c.Check(v.Result.Kind, check.Equals, client.ErrorKindTooManyRequests)
+}
+
+func (s *daemonSuite) TestThrottledCommandRateLimit(c *check.C) {
+	now := time.Now()
+	oldTimeNow := timeNow
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = oldTimeNow }()
+
+	cmd := s.newThrottledTestCommand(c)
+	s.setAPILimit(c, cmd.d, "api.rate-limit", 2)
+
+	rec := doTestReq(c, cmd, "POST")
+	c.Check(rec.Code, check.Equals, 200)
+	rec = doTestReq(c, cmd, "POST")
+	c.Check(rec.Code, check.Equals, 200)
+	rec = doTestReq(c, cmd, "POST")
+	checkTooManyRequests(c, rec.Code, rec.Header(), rec.Body.Bytes(), "30")
+
+	// reads are not throttled
+	rec = doTestReq(c, cmd, "GET")
+	c.Check(rec.Code, check.Equals, 200)
+
+	// neither are commands which are not marked
+	cmd.Throttled = false
+	rec = doTestReq(c, cmd, "POST")
+	c.Check(rec.Code, check.Equals, 200)
+	cmd.Throttled = true
+
+	now = now.Add(30 * time.Second)
+	rec = doTestReq(c, cmd, "POST")
+	c.Check(rec.Code, check.Equals, 200)
+}
+
+func (s *daemonSuite) TestThrottledCommandMaxChanges(c *check.C) {
+	cmd := s.newThrottledTestCommand(c)
+	s.setAPILimit(c, cmd.d, "api.max-changes", "1")
+
+	rec := doTestReq(c, cmd, "POST")
+	c.Check(rec.Code, check.Equals, 200)
+
+	st := cmd.d.Overlord().State()
+	st.Lock()
+	chg := st.NewChange("foo", "...")
+	chg.AddTask(st.NewTask("bar", "..."))
+	st.Unlock()
+
+	rec = doTestReq(c, cmd, "POST")
+	checkTooManyRequests(c, rec.Code, rec.Header(), rec.Body.Bytes(), "10")
+
+	// ready changes do not count
+	st.Lock()
+	chg.SetStatus(state.DoneStatus)
+	st.Unlock()
+	rec = doTestReq(c, cmd, "POST")
+	c.Check(rec.Code, check.Equals, 200)
+}
+
+func (s *daemonSuite) TestThrottledCommandNoLimits(c *check.C) {
+	cmd := s.newThrottledTestCommand(c)
+
+	for i := 0; i < 100; i++ {
+		rec := doTestReq(c, cmd, "POST")
+		c.Assert(rec.Code, check.Equals, 200)
+	}
+}
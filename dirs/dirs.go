@@ -86,6 +86,8 @@ var (
 	SnapStateLockFile string
 	SnapSystemKeyFile string
 
+	SnapLaunchPolicyFile string
+
 	SnapRepairConfigFile string
 	SnapRepairDir        string
 	SnapRepairStateFile  string
@@ -95,6 +97,8 @@ var (
 
 	SnapRollbackDir string
 
+	SnapNameResolutionDir string
+
 	SnapCacheDir        string
 	SnapNamesFile       string
 	SnapSectionsFile    string
@@ -102,6 +106,7 @@ var (
 	SnapAuxStoreInfoDir string
 	SnapIconsPoolDir    string
 	SnapIconsDir        string
+	SnapIconsScaledDir  string
 
 	SnapBinariesDir        string
 	SnapServicesDir        string
@@ -565,6 +570,7 @@ func SetRootDir(rootdir string) {
 	SnapSeccompDir = filepath.Join(SnapSeccompBase, "bpf")
 	SnapMountPolicyDir = filepath.Join(rootdir, snappyDir, "mount")
 	SnapCgroupPolicyDir = filepath.Join(rootdir, snappyDir, "cgroup")
+	SnapNameResolutionDir = filepath.Join(rootdir, snappyDir, "name-resolution")
 	SnapdMaintenanceFile = filepath.Join(rootdir, snappyDir, "maintenance.json")
 	SnapBlobDir = SnapBlobDirUnder(rootdir)
 	SnapVoidDir = filepath.Join(rootdir, snappyDir, "void")
@@ -598,6 +604,8 @@ func SetRootDir(rootdir string) {
 	SnapStateLockFile = SnapStateLockFileUnder(rootdir)
 	SnapSystemKeyFile = filepath.Join(rootdir, snappyDir, "system-key")
 
+	SnapLaunchPolicyFile = filepath.Join(rootdir, snappyDir, "launch-policy.json")
+
 	SnapCacheDir = filepath.Join(rootdir, "/var/cache/snapd")
 	SnapNamesFile = filepath.Join(SnapCacheDir, "names")
 	SnapSectionsFile = filepath.Join(SnapCacheDir, "sections")
@@ -605,6 +613,7 @@ func SetRootDir(rootdir string) {
 	SnapAuxStoreInfoDir = filepath.Join(SnapCacheDir, "aux")
 	SnapIconsPoolDir = filepath.Join(SnapCacheDir, "icons-pool")
 	SnapIconsDir = filepath.Join(SnapCacheDir, "icons")
+	SnapIconsScaledDir = filepath.Join(SnapCacheDir, "icons-scaled")
 
 	SnapSeedDir = SnapSeedDirUnder(rootdir)
 	SnapDeviceDir = SnapDeviceDirUnder(rootdir)
@@ -135,6 +135,40 @@ type Info struct {
 	Connections []Connection `yaml:"connections"`
 
 	KernelCmdline KernelCmdline `yaml:"kernel-cmdline"`
+
+	// Quotas are the quota groups snaps are placed in by default when
+	// they are installed.
+	Quotas []QuotaGroup `yaml:"quotas,omitempty"`
+}
+
+// QuotaGroup is a quota group declared by the gadget. The snaps listed in it
+// are placed in the group when they are installed, unless a quota group was
+// requested explicitly.
+type QuotaGroup struct {
+	Name string `yaml:"name"`
+	// Memory is the memory limit of the group.
+	Memory quantity.Size `yaml:"memory,omitempty"`
+	// CPUPercentage is the CPU usage limit of the group, as a percentage
+	// of a single CPU.
+	CPUPercentage int `yaml:"cpu-percentage,omitempty"`
+	// Threads is the limit on the number of threads of the group.
+	Threads int `yaml:"threads,omitempty"`
+	// Snaps are the IDs of the snaps placed in the group by default.
+	Snaps []string `yaml:"snaps"`
+}
+
+// QuotaGroupForSnap returns the quota group the snap with the given ID is
+// placed in by default, or nil if there is none.
+func (i *Info) QuotaGroupForSnap(snapID string) *QuotaGroup {
+	if snapID == "" {
+		return nil
+	}
+	for idx := range i.Quotas {
+		if strutil.ListContains(i.Quotas[idx].Snaps, snapID) {
+			return &i.Quotas[idx]
+		}
+	}
+	return nil
 }
 
 // HasRole returns true if any of the volume structures in this Info has the
@@ -1023,6 +1057,36 @@ func systemOrSnapID(s string) bool {
 	return true
 }
 
+func validateQuotaGroups(groups []QuotaGroup) error {
+	seenGroups := make(map[string]bool, len(groups))
+	seenSnaps := make(map[string]string)
+	for _, grp := range groups {
+		if err := naming.ValidateQuotaGroup(grp.Name); err != nil {
+			return err
+		}
+		if seenGroups[grp.Name] {
+			return fmt.Errorf("quota group %q is declared more than once", grp.Name)
+		}
+		seenGroups[grp.Name] = true
+		if grp.Memory == 0 && grp.CPUPercentage == 0 && grp.Threads == 0 {
+			return fmt.Errorf("quota group %q must declare at least one limit", grp.Name)
+		}
+		if grp.CPUPercentage < 0 || grp.Threads < 0 {
+			return fmt.Errorf("quota group %q limits cannot be negative", grp.Name)
+		}
+		for _, snapID := range grp.Snaps {
+			if err := naming.ValidateSnapID(snapID); err != nil {
+				return fmt.Errorf("quota group %q: %v", grp.Name, err)
+			}
+			if other, ok := seenSnaps[snapID]; ok {
+				return fmt.Errorf("snap %q cannot be in both quota groups %q and %q", snapID, other, grp.Name)
+			}
+			seenSnaps[snapID] = grp.Name
+		}
+	}
+	return nil
+}
+
 // Model carries characteristics about the model that are relevant to gadget.
 // Note *asserts.Model implements this, and that's the expected use case.
 type Model interface {
@@ -1198,6 +1262,10 @@ func InfoFromGadgetYaml(gadgetYaml []byte, model Model) (*Info, error) {
 		}
 	}
 
+	if err := validateQuotaGroups(gi.Quotas); err != nil {
+		return nil, err
+	}
+
 	if len(gi.Volumes) == 0 && classicOrUndetermined(model) {
 		// volumes can be left out on classic
 		// can still specify defaults though
@@ -785,6 +785,69 @@ func (s *gadgetYamlTestSuite) TestReadGadgetDefaultsMultiline(c *C) {
 	})
 }
 
+func (s *gadgetYamlTestSuite) TestReadGadgetQuotas(c *C) {
+	err := os.WriteFile(s.gadgetYamlPath, []byte(`
+quotas:
+  - name: apps
+    memory: 512M
+    cpu-percentage: 50
+    snaps:
+      - snapidsnapidsnapidsnapidsnapid01
+      - snapidsnapidsnapidsnapidsnapid02
+  - name: tools
+    threads: 64
+    snaps:
+      - snapidsnapidsnapidsnapidsnapid03
+`), 0644)
+	c.Assert(err, IsNil)
+
+	ginfo, err := gadget.ReadInfo(s.dir, classicMod)
+	c.Assert(err, IsNil)
+	c.Assert(ginfo.Quotas, DeepEquals, []gadget.QuotaGroup{{
+		Name:          "apps",
+		Memory:        512 * quantity.SizeMiB,
+		CPUPercentage: 50,
+		Snaps:         []string{"snapidsnapidsnapidsnapidsnapid01", "snapidsnapidsnapidsnapidsnapid02"},
+	}, {
+		Name:    "tools",
+		Threads: 64,
+		Snaps:   []string{"snapidsnapidsnapidsnapidsnapid03"},
+	}})
+
+	c.Check(ginfo.QuotaGroupForSnap("snapidsnapidsnapidsnapidsnapid02"), Equals, &ginfo.Quotas[0])
+	c.Check(ginfo.QuotaGroupForSnap("snapidsnapidsnapidsnapidsnapid03"), Equals, &ginfo.Quotas[1])
+	c.Check(ginfo.QuotaGroupForSnap("snapidsnapidsnapidsnapidsnapid04"), IsNil)
+	c.Check(ginfo.QuotaGroupForSnap(""), IsNil)
+}
+
+func (s *gadgetYamlTestSuite) TestReadGadgetQuotasInvalid(c *C) {
+	for _, tc := range []struct {
+		yaml string
+		err  string
+	}{{
+		yaml: "quotas:\n  - name: x\n    threads: 1\n",
+		err:  `invalid quota group name: must be between 2 and 40 characters long`,
+	}, {
+		yaml: "quotas:\n  - name: apps\n    threads: 1\n  - name: apps\n    threads: 2\n",
+		err:  `quota group "apps" is declared more than once`,
+	}, {
+		yaml: "quotas:\n  - name: apps\n    snaps: [snapidsnapidsnapidsnapidsnapid01]\n",
+		err:  `quota group "apps" must declare at least one limit`,
+	}, {
+		yaml: "quotas:\n  - name: apps\n    threads: -1\n",
+		err:  `quota group "apps" limits cannot be negative`,
+	}, {
+		yaml: "quotas:\n  - name: apps\n    threads: 1\n    snaps: [foo]\n",
+		err:  `quota group "apps": invalid snap-id: "foo"`,
+	}, {
+		yaml: "quotas:\n  - name: apps\n    threads: 1\n    snaps: [snapidsnapidsnapidsnapidsnapid01]\n  - name: tools\n    threads: 1\n    snaps: [snapidsnapidsnapidsnapidsnapid01]\n",
+		err:  `snap "snapidsnapidsnapidsnapidsnapid01" cannot be in both quota groups "apps" and "tools"`,
+	}} {
+		_, err := gadget.InfoFromGadgetYaml([]byte(tc.yaml), classicMod)
+		c.Check(err, ErrorMatches, tc.err, Commentf("%s", tc.yaml))
+	}
+}
+
 func asOffsetPtr(offs quantity.Offset) *quantity.Offset {
 	goff := offs
 	return &goff
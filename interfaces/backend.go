@@ -75,6 +75,14 @@ type ConfinementOptions struct {
 	// KernelSnap is the name of the kernel snap in the system
 	// (empty for classic systems).
 	KernelSnap string
+	// NameResolution holds the name resolution settings of the snap
+	// maintained by the mount backend, if the snap does not use the ones
+	// of the system. The matching layouts are part of ExtraLayouts.
+	NameResolution *NameResolution
+	// DeviceAccessRules are udev match rules of devices the snap was
+	// temporarily granted access to, on top of the ones of its connected
+	// interfaces.
+	DeviceAccessRules []string
 }
 
 // SecurityBackendOptions carries extra flags that affect initialization of the
@@ -32,9 +32,13 @@
 package mount
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
@@ -73,6 +77,11 @@ func (b *Backend) Setup(appSet *interfaces.SnapAppSet, opts interfaces.Confineme
 	spec.(*Specification).AddLayout(snapInfo)
 	spec.(*Specification).AddExtraLayouts(opts.ExtraLayouts)
 	content := deriveContent(spec.(*Specification), snapInfo)
+	// the name resolution files must be in place before the layouts
+	// referring to them get applied
+	if err := ensureNameResolutionFiles(snapName, opts.NameResolution); err != nil {
+		return err
+	}
 	// synchronize the content with the filesystem
 	glob := fmt.Sprintf("snap.%s.*fstab", snapName)
 	dir := dirs.SnapMountPolicyDir
@@ -108,9 +117,107 @@ func (b *Backend) Remove(snapName string) error {
 	if err != nil {
 		return fmt.Errorf("cannot synchronize mount configuration files for snap %q: %s", snapName, err)
 	}
+	if err := ensureNameResolutionFiles(snapName, nil); err != nil {
+		return err
+	}
 	return DiscardSnapNamespace(snapName)
 }
 
+// ensureNameResolutionFiles writes the resolv.conf and hosts files bind
+// mounted into the mount namespace of a snap with custom name resolution, and
+// removes the ones that are no longer needed.
+func ensureNameResolutionFiles(snapName string, nr *interfaces.NameResolution) error {
+	content := make(map[string]osutil.FileState, 2)
+	if nr != nil && len(nr.Nameservers) > 0 {
+		var buffer bytes.Buffer
+		buffer.WriteString("# generated by snapd, do not edit\n")
+		for _, ns := range nr.Nameservers {
+			fmt.Fprintf(&buffer, "nameserver %s\n", ns)
+		}
+		content[filepath.Base(interfaces.ResolvConfFile(snapName))] = &osutil.MemoryFileState{Content: buffer.Bytes(), Mode: 0644}
+	}
+	if nr != nil && len(nr.Hosts) > 0 {
+		names := make([]string, 0, len(nr.Hosts))
+		for name := range nr.Hosts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		hostHosts, err := hostHostsEntries(nr.Hosts)
+		if err != nil {
+			return err
+		}
+		var buffer bytes.Buffer
+		buffer.Write(hostHosts)
+		buffer.WriteString("# added by snapd, do not edit\n")
+		for _, name := range names {
+			fmt.Fprintf(&buffer, "%s %s\n", nr.Hosts[name], name)
+		}
+		content[filepath.Base(interfaces.HostsFile(snapName))] = &osutil.MemoryFileState{Content: buffer.Bytes(), Mode: 0644}
+	}
+
+	dir := dirs.SnapNameResolutionDir
+	if len(content) == 0 && !osutil.IsDirectory(dir) {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory for name resolution files %q: %s", dir, err)
+	}
+	glob := fmt.Sprintf("snap.%s.*", snapName)
+	if _, _, err := osutil.EnsureDirState(dir, glob, content); err != nil {
+		return fmt.Errorf("cannot synchronize name resolution files for snap %q: %s", snapName, err)
+	}
+	return nil
+}
+
+// hostHostsEntries returns the content of the hosts file of the host, which
+// the hosts file of a snap with custom hosts entries starts from. Names that
+// are overridden for the snap are dropped from it, so that the configured
+// addresses take precedence. The host file is only read when the profiles
+// of the snap are set up.
+func hostHostsEntries(overridden map[string]string) ([]byte, error) {
+	f, err := os.Open(filepath.Join(dirs.GlobalRootDir, "/etc/hosts"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []byte("127.0.0.1 localhost\n::1 localhost ip6-localhost ip6-loopback\n"), nil
+		}
+		return nil, fmt.Errorf("cannot read hosts file of the host: %v", err)
+	}
+	defer f.Close()
+
+	var buffer bytes.Buffer
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		entry := line
+		if idx := strings.IndexByte(entry, '#'); idx >= 0 {
+			entry = entry[:idx]
+		}
+		fields := strings.Fields(entry)
+		if len(fields) < 2 {
+			// comments and empty or malformed lines are kept as is
+			buffer.WriteString(line + "\n")
+			continue
+		}
+		names := make([]string, 0, len(fields)-1)
+		for _, name := range fields[1:] {
+			if _, ok := overridden[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		switch {
+		case len(names) == len(fields)-1:
+			buffer.WriteString(line + "\n")
+		case len(names) > 0:
+			fmt.Fprintf(&buffer, "%s %s\n", fields[0], strings.Join(names, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read hosts file of the host: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
 // addMountProfile adds a mount profile with the given name, based on the given entries.
 //
 // If there are no entries no profile is generated.
@@ -179,6 +179,89 @@ func (s *backendSuite) TestSetupSetsupWithoutDir(c *C) {
 	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", mockSnapYaml, 0)
 }
 
+func (s *backendSuite) TestSetupWithNameResolution(c *C) {
+	c.Assert(os.MkdirAll(filepath.Join(dirs.GlobalRootDir, "/etc"), 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.GlobalRootDir, "/etc/hosts"), []byte(`127.0.0.1 localhost
+127.0.1.1 myhost
+# the build server
+10.0.0.9 build.internal db.internal # old database
+10.0.0.7 db.internal
+`), 0644), IsNil)
+
+	nr := &interfaces.NameResolution{
+		Nameservers: []string{"10.0.0.1", "10.0.0.2"},
+		Hosts: map[string]string{
+			"db.internal":  "10.0.0.5",
+			"api.internal": "fd00::6",
+		},
+	}
+	opts := interfaces.ConfinementOptions{
+		NameResolution: nr,
+		ExtraLayouts:   nr.Layouts("snap-name"),
+	}
+	snapInfo := s.InstallSnap(c, opts, "", mockSnapYaml, 0)
+
+	resolvConf := filepath.Join(dirs.SnapNameResolutionDir, "snap.snap-name.resolv.conf")
+	c.Check(resolvConf, testutil.FileEquals, `# generated by snapd, do not edit
+nameserver 10.0.0.1
+nameserver 10.0.0.2
+`)
+	hosts := filepath.Join(dirs.SnapNameResolutionDir, "snap.snap-name.hosts")
+	// the entries of the host are kept, except for the overridden names
+	c.Check(hosts, testutil.FileEquals, `127.0.0.1 localhost
+127.0.1.1 myhost
+# the build server
+10.0.0.9 build.internal
+# added by snapd, do not edit
+fd00::6 api.internal
+10.0.0.5 db.internal
+`)
+
+	fn := filepath.Join(dirs.SnapMountPolicyDir, "snap.snap-name.fstab")
+	c.Check(fn, testutil.FileEquals, fmt.Sprintf(`%s /etc/resolv.conf none bind,rw,x-snapd.kind=file,x-snapd.mode=0644,x-snapd.origin=layout 0 0
+%s /etc/hosts none bind,rw,x-snapd.kind=file,x-snapd.mode=0644,x-snapd.origin=layout 0 0
+`, resolvConf, hosts))
+
+	// dropping the nameservers removes the resolv.conf file
+	nr.Nameservers = nil
+	opts.ExtraLayouts = nr.Layouts("snap-name")
+	s.UpdateSnap(c, snapInfo, opts, mockSnapYaml, 0)
+	c.Check(resolvConf, testutil.FileAbsent)
+	c.Check(hosts, testutil.FilePresent)
+	c.Check(fn, testutil.FileEquals, fmt.Sprintf(`%s /etc/hosts none bind,rw,x-snapd.kind=file,x-snapd.mode=0644,x-snapd.origin=layout 0 0
+`, hosts))
+}
+
+func (s *backendSuite) TestSetupWithNameResolutionNoHostHosts(c *C) {
+	nr := &interfaces.NameResolution{
+		Hosts: map[string]string{"db.internal": "10.0.0.5"},
+	}
+	opts := interfaces.ConfinementOptions{
+		NameResolution: nr,
+		ExtraLayouts:   nr.Layouts("snap-name"),
+	}
+	s.InstallSnap(c, opts, "", mockSnapYaml, 0)
+
+	hosts := filepath.Join(dirs.SnapNameResolutionDir, "snap.snap-name.hosts")
+	c.Check(hosts, testutil.FileEquals, `127.0.0.1 localhost
+::1 localhost ip6-localhost ip6-loopback
+# added by snapd, do not edit
+10.0.0.5 db.internal
+`)
+}
+
+func (s *backendSuite) TestRemoveNameResolutionFiles(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapNameResolutionDir, 0755), IsNil)
+	toGo := filepath.Join(dirs.SnapNameResolutionDir, "snap.hello-world.hosts")
+	c.Assert(os.WriteFile(toGo, nil, 0644), IsNil)
+	toStay := filepath.Join(dirs.SnapNameResolutionDir, "snap.hello-world_foo.hosts")
+	c.Assert(os.WriteFile(toStay, nil, 0644), IsNil)
+
+	c.Assert(s.Backend.Remove("hello-world"), IsNil)
+	c.Check(toGo, testutil.FileAbsent)
+	c.Check(toStay, testutil.FilePresent)
+}
+
 func (s *backendSuite) TestParallelInstanceSetup(c *C) {
 	old := dirs.SnapDataDir
 	defer func() {
@@ -0,0 +1,74 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package interfaces
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/snap"
+)
+
+// NameResolution describes how a snap resolves host names when it should not
+// use the settings of the system, for instance in split-horizon DNS setups.
+type NameResolution struct {
+	// Nameservers are the addresses of the DNS servers used by the snap
+	// instead of the ones of the system.
+	Nameservers []string
+	// Hosts maps extra host names to the address they resolve to.
+	Hosts map[string]string
+}
+
+// ResolvConfFile returns the path of the resolv.conf file maintained for the
+// given snap instance.
+func ResolvConfFile(instanceName string) string {
+	return filepath.Join(dirs.SnapNameResolutionDir, fmt.Sprintf("snap.%s.resolv.conf", instanceName))
+}
+
+// HostsFile returns the path of the hosts file maintained for the given snap
+// instance.
+func HostsFile(instanceName string) string {
+	return filepath.Join(dirs.SnapNameResolutionDir, fmt.Sprintf("snap.%s.hosts", instanceName))
+}
+
+// Layouts returns the layouts bind mounting the files maintained for the
+// given snap instance over /etc/resolv.conf and /etc/hosts.
+func (nr *NameResolution) Layouts(instanceName string) []snap.Layout {
+	if nr == nil {
+		return nil
+	}
+	var layouts []snap.Layout
+	if len(nr.Nameservers) > 0 {
+		layouts = append(layouts, snap.Layout{
+			BindFile: ResolvConfFile(instanceName),
+			Path:     "/etc/resolv.conf",
+			Mode:     0644,
+		})
+	}
+	if len(nr.Hosts) > 0 {
+		layouts = append(layouts, snap.Layout{
+			BindFile: HostsFile(instanceName),
+			Path:     "/etc/hosts",
+			Mode:     0644,
+		})
+	}
+	return layouts
+}
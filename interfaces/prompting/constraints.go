@@ -29,6 +29,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify"
 	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 // Constraints hold information about the applicability of a new rule to
@@ -371,10 +372,18 @@ func (pm RulePermissionMap) Expired(at At) bool {
 //
 // PermissionEntry is used when replying to a prompt, creating a new rule, or
 // modifying an existing rule.
+//
+// The entry may additionally be restricted by conditions: if MaxUses is
+// non-zero, the entry expires after it has been used to match that many
+// requests, and if Schedule is set, the entry only applies during the given
+// weekday/hour windows, using the same format as the refresh.timer option
+// (e.g. "mon-fri,9:00-17:00").
 type PermissionEntry struct {
 	Outcome  OutcomeType  `json:"outcome"`
 	Lifespan LifespanType `json:"lifespan"`
 	Duration string       `json:"duration,omitempty"`
+	MaxUses  int          `json:"max-uses,omitempty"`
+	Schedule string       `json:"schedule,omitempty"`
 }
 
 // toRulePermissionEntry validates the receiving PermissionEntry and converts
@@ -384,6 +393,10 @@ type PermissionEntry struct {
 // for a rule (i.e. not LifespanSingle), and that it has an appropriate
 // duration for that lifespan. If the lifespan is LifespanTimespan, then the
 // expiration is computed as the entry's duration after the given point in time.
+// If the lifespan is LifespanSession, then the entry is associated with the
+// user session of the given point in time, which must be present.
+//
+// Also checks that the conditions of the entry, if any, are valid.
 func (e *PermissionEntry) toRulePermissionEntry(at At) (*RulePermissionEntry, error) {
 	if _, err := e.Outcome.AsBool(); err != nil {
 		return nil, err
@@ -396,14 +409,41 @@ func (e *PermissionEntry) toRulePermissionEntry(at At) (*RulePermissionEntry, er
 	if err != nil {
 		return nil, err
 	}
+	var sessionID IDType
+	if e.Lifespan == LifespanSession {
+		if at.SessionID == 0 {
+			return nil, prompting_errors.ErrNewSessionRuleNoSession
+		}
+		sessionID = at.SessionID
+	}
+	if err := validateConditions(e.MaxUses, e.Schedule); err != nil {
+		return nil, err
+	}
 	rulePermissionEntry := &RulePermissionEntry{
 		Outcome:    e.Outcome,
 		Lifespan:   e.Lifespan,
 		Expiration: expiration,
+		SessionID:  sessionID,
+		MaxUses:    e.MaxUses,
+		Schedule:   e.Schedule,
 	}
 	return rulePermissionEntry, nil
 }
 
+// validateConditions checks that the given maximum number of uses and
+// schedule are valid conditions for a permission entry.
+func validateConditions(maxUses int, schedule string) error {
+	if maxUses < 0 {
+		return prompting_errors.NewInvalidMaxUsesError(maxUses, "cannot have negative number of uses")
+	}
+	if schedule != "" {
+		if _, err := timeutil.ParseSchedule(schedule); err != nil {
+			return prompting_errors.NewInvalidScheduleError(schedule, fmt.Sprintf("cannot parse schedule: %v", err))
+		}
+	}
+	return nil
+}
+
 // RulePermissionEntry holds the outcome associated with a particular permission
 // and the lifespan for which that outcome is applicable.
 //
@@ -413,31 +453,72 @@ func (e *PermissionEntry) toRulePermissionEntry(at At) (*RulePermissionEntry, er
 // rule.
 //
 // If the entry has a lifespan of LifespanTimespan, the expiration time should
-// be non-zero and stores the time at which the entry expires.
+// be non-zero and stores the time at which the entry expires. If the entry has
+// a lifespan of LifespanSession, the session ID should be non-zero and stores
+// the ID of the user session in which the entry was created.
+//
+// If MaxUses is non-zero, Uses records how many times the entry has been used
+// to match a request.
 type RulePermissionEntry struct {
 	Outcome    OutcomeType  `json:"outcome"`
 	Lifespan   LifespanType `json:"lifespan"`
 	Expiration time.Time    `json:"expiration,omitzero"`
+	SessionID  IDType       `json:"session-id,omitzero"`
+	MaxUses    int          `json:"max-uses,omitempty"`
+	Uses       int          `json:"uses,omitempty"`
+	Schedule   string       `json:"schedule,omitempty"`
 }
 
 // Expired returns true if the receiving permission entry has expired and
 // should no longer be considered when matching requests.
 //
 // This is the case if the permission has a lifespan of timespan and the
-// expiration time has passed at the given point in time.
+// expiration time has passed at the given point in time, if the permission
+// has a lifespan of session and the user session at the given point in time
+// is not the one in which the entry was created, or if the permission has
+// been used its maximum number of times.
 func (e *RulePermissionEntry) Expired(at At) bool {
 	switch e.Lifespan {
 	case LifespanTimespan:
 		if !at.Time.Before(e.Expiration) {
 			return true
 		}
-		// TODO: add lifespan session
-		//case LifespanSession:
-		// TODO: return true if the user session has changed
+	case LifespanSession:
+		if e.SessionID != at.SessionID {
+			return true
+		}
+	}
+	if e.MaxUses > 0 && e.Uses >= e.MaxUses {
+		return true
 	}
 	return false
 }
 
+// Active returns true if the receiving permission entry has not expired and
+// its schedule, if any, includes the given point in time.
+func (e *RulePermissionEntry) Active(at At) bool {
+	if e.Expired(at) {
+		return false
+	}
+	if e.Schedule == "" {
+		return true
+	}
+	schedule, err := timeutil.ParseSchedule(e.Schedule)
+	if err != nil {
+		// Should not occur, since the schedule is validated when the entry
+		// is created or loaded
+		logger.Noticef("cannot parse schedule of rule permission entry: %v", err)
+		return false
+	}
+	return timeutil.Includes(schedule, at.Time)
+}
+
+// hasConditions returns true if the entry is restricted by a maximum number
+// of uses or by a schedule.
+func (e *RulePermissionEntry) hasConditions() bool {
+	return e.MaxUses > 0 || e.Schedule != ""
+}
+
 // validate checks that the entry has a valid outcome, and that its lifespan
 // is valid for a rule (i.e. not LifespanSingle), and has an appropriate
 // expiration information for that lifespan.
@@ -456,16 +537,25 @@ func (e *RulePermissionEntry) validate() error {
 		// We don't check whether the entry has expired as part of validation.
 		return err
 	}
-	return nil
+	if (e.Lifespan == LifespanSession) != (e.SessionID != 0) {
+		return fmt.Errorf("invalid session ID for lifespan %q: %s", e.Lifespan, e.SessionID)
+	}
+	return validateConditions(e.MaxUses, e.Schedule)
 }
 
 // Supersedes returns true if the receiver e has a lifespan which supersedes
 // that of given other entry.
 //
-// LifespanForever supersedes all other lifespans. LifespanTimespan supersedes
+// An entry without conditions supersedes an entry with conditions, and an
+// entry with conditions never supersedes one without. Otherwise,
+// LifespanForever supersedes all other lifespans. LifespanSession supersedes
+// LifespanTimespan and LifespanSingle. LifespanTimespan supersedes
 // LifespanSingle. If the entries are both LifespanTimespan, then whichever
 // entry has a later expiration timestamp supersedes the other entry.
 func (e *RulePermissionEntry) Supersedes(other *RulePermissionEntry) bool {
+	if e.hasConditions() != other.hasConditions() {
+		return other.hasConditions()
+	}
 	if other.Lifespan == LifespanForever {
 		// Nothing supersedes LifespanForever
 		return false
@@ -475,6 +565,15 @@ func (e *RulePermissionEntry) Supersedes(other *RulePermissionEntry) bool {
 		return true
 	}
 	// Neither lifespan is LifespanForever
+	if other.Lifespan == LifespanSession {
+		// Only LifespanForever supersedes LifespanSession
+		return false
+	}
+	if e.Lifespan == LifespanSession {
+		// LifespanSession supersedes LifespanTimespan and LifespanSingle
+		return true
+	}
+	// Neither lifespan is LifespanSession
 	if other.Lifespan == LifespanTimespan {
 		if e.Lifespan == LifespanSingle {
 			// LifespanSingle does not supersede LifespanTimespan
@@ -274,6 +274,35 @@ func (s *constraintsSuite) TestConstraintsToRuleConstraintsUnhappy(c *C) {
 			},
 			errStr: joinErrorsUnordered(`invalid duration: cannot have unspecified duration when lifespan is "timespan": ""`, `invalid permissions for home interface: "create"`),
 		},
+		{
+			perms: prompting.PermissionMap{
+				"read": &prompting.PermissionEntry{
+					Outcome:  prompting.OutcomeAllow,
+					Lifespan: prompting.LifespanSession,
+				},
+			},
+			errStr: `cannot create rule with lifespan "session" when user session is not present`,
+		},
+		{
+			perms: prompting.PermissionMap{
+				"read": &prompting.PermissionEntry{
+					Outcome:  prompting.OutcomeAllow,
+					Lifespan: prompting.LifespanForever,
+					MaxUses:  -1,
+				},
+			},
+			errStr: `invalid max-uses: cannot have negative number of uses: -1`,
+		},
+		{
+			perms: prompting.PermissionMap{
+				"read": &prompting.PermissionEntry{
+					Outcome:  prompting.OutcomeAllow,
+					Lifespan: prompting.LifespanForever,
+					Schedule: "whenever",
+				},
+			},
+			errStr: `invalid schedule: cannot parse schedule: .*: "whenever"`,
+		},
 	} {
 		constraints := &prompting.Constraints{
 			PathPattern: mustParsePathPattern(c, "/path/to/foo"),
@@ -925,6 +954,111 @@ func (s *constraintsSuite) TestPatchRuleConstraintsUnhappy(c *C) {
 	c.Check(result, IsNil)
 }
 
+func (s *constraintsSuite) TestConstraintsToRuleConstraintsConditions(c *C) {
+	at := prompting.At{
+		Time:      time.Now(),
+		SessionID: prompting.IDType(0x1234),
+	}
+	constraints := &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/path/to/foo"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanSession,
+			},
+			"write": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+				MaxUses:  3,
+				Schedule: "mon-fri,9:00-17:00",
+			},
+		},
+	}
+	result, err := constraints.ToRuleConstraints("home", at)
+	c.Assert(err, IsNil)
+	c.Check(result.Permissions, DeepEquals, prompting.RulePermissionMap{
+		"read": &prompting.RulePermissionEntry{
+			Outcome:   prompting.OutcomeAllow,
+			Lifespan:  prompting.LifespanSession,
+			SessionID: prompting.IDType(0x1234),
+		},
+		"write": &prompting.RulePermissionEntry{
+			Outcome:  prompting.OutcomeAllow,
+			Lifespan: prompting.LifespanForever,
+			MaxUses:  3,
+			Schedule: "mon-fri,9:00-17:00",
+		},
+	})
+}
+
+func (s *constraintsSuite) TestRulePermissionEntryExpiredSession(c *C) {
+	entry := &prompting.RulePermissionEntry{
+		Outcome:   prompting.OutcomeAllow,
+		Lifespan:  prompting.LifespanSession,
+		SessionID: prompting.IDType(0x1234),
+	}
+	at := prompting.At{
+		Time:      time.Now(),
+		SessionID: prompting.IDType(0x1234),
+	}
+	c.Check(entry.Expired(at), Equals, false)
+	c.Check(entry.Active(at), Equals, true)
+
+	// The user session restarted
+	at.SessionID = prompting.IDType(0x5678)
+	c.Check(entry.Expired(at), Equals, true)
+	c.Check(entry.Active(at), Equals, false)
+
+	// The user session ended
+	at.SessionID = 0
+	c.Check(entry.Expired(at), Equals, true)
+}
+
+func (s *constraintsSuite) TestRulePermissionEntryExpiredMaxUses(c *C) {
+	entry := &prompting.RulePermissionEntry{
+		Outcome:  prompting.OutcomeAllow,
+		Lifespan: prompting.LifespanForever,
+		MaxUses:  2,
+	}
+	at := prompting.At{
+		Time: time.Now(),
+	}
+	c.Check(entry.Expired(at), Equals, false)
+	entry.Uses = 1
+	c.Check(entry.Expired(at), Equals, false)
+	entry.Uses = 2
+	c.Check(entry.Expired(at), Equals, true)
+	c.Check(entry.Active(at), Equals, false)
+}
+
+func (s *constraintsSuite) TestRulePermissionEntryActiveSchedule(c *C) {
+	entry := &prompting.RulePermissionEntry{
+		Outcome:  prompting.OutcomeAllow,
+		Lifespan: prompting.LifespanForever,
+		Schedule: "mon-fri,9:00-17:00",
+	}
+	for _, testCase := range []struct {
+		time   time.Time
+		active bool
+	}{
+		// Monday morning
+		{time.Date(2025, time.March, 3, 10, 0, 0, 0, time.Local), true},
+		// Monday evening
+		{time.Date(2025, time.March, 3, 18, 0, 0, 0, time.Local), false},
+		// Friday afternoon
+		{time.Date(2025, time.March, 7, 16, 59, 0, 0, time.Local), true},
+		// Saturday morning
+		{time.Date(2025, time.March, 8, 10, 0, 0, 0, time.Local), false},
+	} {
+		at := prompting.At{
+			Time: testCase.time,
+		}
+		// Being outside of the schedule does not make the entry expire
+		c.Check(entry.Expired(at), Equals, false, Commentf("time: %v", testCase.time))
+		c.Check(entry.Active(at), Equals, testCase.active, Commentf("time: %v", testCase.time))
+	}
+}
+
 func (s *constraintsSuite) TestRulePermissionMapExpired(c *C) {
 	at := prompting.At{
 		Time: time.Now(),
@@ -1106,6 +1240,61 @@ func (s *constraintsSuite) TestRulePermissionEntrySupersedes(c *C) {
 			},
 			expected: false,
 		},
+		{
+			entry: &prompting.RulePermissionEntry{
+				Lifespan:  prompting.LifespanSession,
+				SessionID: prompting.IDType(0x1234),
+			},
+			other: &prompting.RulePermissionEntry{
+				Lifespan:   prompting.LifespanTimespan,
+				Expiration: currTime.Add(time.Hour),
+			},
+			expected: true,
+		},
+		{
+			entry: &prompting.RulePermissionEntry{
+				Lifespan:   prompting.LifespanTimespan,
+				Expiration: currTime.Add(time.Hour),
+			},
+			other: &prompting.RulePermissionEntry{
+				Lifespan:  prompting.LifespanSession,
+				SessionID: prompting.IDType(0x1234),
+			},
+			expected: false,
+		},
+		{
+			entry: &prompting.RulePermissionEntry{
+				Lifespan: prompting.LifespanForever,
+			},
+			other: &prompting.RulePermissionEntry{
+				Lifespan:  prompting.LifespanSession,
+				SessionID: prompting.IDType(0x1234),
+			},
+			expected: true,
+		},
+		{
+			// Entries with conditions never supersede entries without
+			entry: &prompting.RulePermissionEntry{
+				Lifespan: prompting.LifespanForever,
+				MaxUses:  5,
+			},
+			other: &prompting.RulePermissionEntry{
+				Lifespan:   prompting.LifespanTimespan,
+				Expiration: currTime.Add(time.Hour),
+			},
+			expected: false,
+		},
+		{
+			entry: &prompting.RulePermissionEntry{
+				Lifespan:   prompting.LifespanTimespan,
+				Expiration: currTime.Add(time.Hour),
+			},
+			other: &prompting.RulePermissionEntry{
+				Lifespan: prompting.LifespanForever,
+				Schedule: "mon,9:00-10:00",
+			},
+			expected: true,
+		},
 	} {
 		c.Check(testCase.entry.Supersedes(testCase.other), Equals, testCase.expected, Commentf("testCase:\n\tentry: %+v\n\tother: %+v\n\texpected: %v", testCase.entry, testCase.other, testCase.expected))
 	}
@@ -1150,6 +1339,17 @@ func (s *constraintsSuite) TestMarshalRulePermissionEntry(c *C) {
 			},
 			expected: `{"outcome":"deny","lifespan":"timespan","expiration":"2025-02-20T16:00:27.913561089Z"}`,
 		},
+		{
+			entry: prompting.RulePermissionEntry{
+				Outcome:   prompting.OutcomeAllow,
+				Lifespan:  prompting.LifespanSession,
+				SessionID: prompting.IDType(0x1234),
+				MaxUses:   3,
+				Uses:      1,
+				Schedule:  "mon-fri",
+			},
+			expected: `{"outcome":"allow","lifespan":"session","session-id":"0000000000001234","max-uses":3,"uses":1,"schedule":"mon-fri"}`,
+		},
 	} {
 		marshalled, err := json.Marshal(testCase.entry)
 		c.Check(err, IsNil, Commentf("testCase: %+v", testCase))
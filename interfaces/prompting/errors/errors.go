@@ -25,6 +25,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/snapcore/snapd/strutil"
@@ -37,7 +38,8 @@ var (
 	ErrRuleNotAllowed = errors.New("user not allowed to request the rule with the given ID")
 
 	// Validation errors which may be returned over the API
-	ErrPatchedRuleHasNoPerms = errors.New("cannot patch rule to have no permissions")
+	ErrPatchedRuleHasNoPerms   = errors.New("cannot patch rule to have no permissions")
+	ErrNewSessionRuleNoSession = errors.New(`cannot create rule with lifespan "session" when user session is not present`)
 
 	// Validation errors which should never be used directly apart from
 	// checking errors.Is(), and should otherwise always be wrapped in
@@ -181,6 +183,23 @@ func NewInvalidPathPatternError(invalid string, reason string) *ParseError {
 	}
 }
 
+func NewInvalidScheduleError(invalid string, reason string) *ParseError {
+	return &ParseError{
+		Field:   "schedule",
+		Msg:     fmt.Sprintf("invalid schedule: %s: %q", reason, invalid),
+		Invalid: invalid,
+	}
+}
+
+func NewInvalidMaxUsesError(invalid int, reason string) *ParseError {
+	invalidStr := strconv.Itoa(invalid)
+	return &ParseError{
+		Field:   "max-uses",
+		Msg:     fmt.Sprintf("invalid max-uses: %s: %s", reason, invalidStr),
+		Invalid: invalidStr,
+	}
+}
+
 // Validation errors, which are all uniquely defined here
 
 // RequestedPathNotMatchedError stores a path pattern from a reply which doesn't
@@ -147,6 +147,7 @@ func (s *promptingSuite) TestUnmarshalLifespanHappy(c *C) {
 		prompting.LifespanForever,
 		prompting.LifespanSingle,
 		prompting.LifespanTimespan,
+		prompting.LifespanSession,
 	} {
 		var flw1 fakeLifespanWrapper
 		data := []byte(fmt.Sprintf(`{"field1": "%s", "field2": "%s"}`, lifespan, lifespan))
@@ -190,6 +191,7 @@ func (s *promptingSuite) TestValidateExpiration(c *C) {
 	for _, lifespan := range []prompting.LifespanType{
 		prompting.LifespanForever,
 		prompting.LifespanSingle,
+		prompting.LifespanSession,
 	} {
 		err := lifespan.ValidateExpiration(unsetExpiration)
 		c.Check(err, IsNil)
@@ -218,6 +220,7 @@ func (s *promptingSuite) TestParseDuration(c *C) {
 	for _, lifespan := range []prompting.LifespanType{
 		prompting.LifespanForever,
 		prompting.LifespanSingle,
+		prompting.LifespanSession,
 	} {
 		expiration, err := lifespan.ParseDuration(unsetDuration, currTime)
 		c.Check(expiration.IsZero(), Equals, true)
@@ -71,3 +71,7 @@ func (pdb *PromptDB) NextID() (prompting.IDType, error) {
 func MockTimeAfterFunc(f func(d time.Duration, callback func()) timeutil.Timer) (restore func()) {
 	return testutil.Mock(&timeAfterFunc, f)
 }
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	return testutil.Mock(&timeNow, f)
+}
@@ -58,8 +58,60 @@ const (
 	// maxOutstandingPromptsPerUser is an arbitrary limit.
 	// TODO: review this limit after some usage.
 	maxOutstandingPromptsPerUser int = 1000
+	// maxRecentlyExpired is the number of expired prompts which are kept
+	// for inspection.
+	maxRecentlyExpired = 50
 )
 
+// ExpirationPolicy defines how prompts are resolved when they are not
+// answered within a timeout, regardless of client activity.
+type ExpirationPolicy struct {
+	// Timeout is the age after which a prompt is resolved automatically,
+	// if 0 prompts only expire when the clients of their user are
+	// inactive.
+	Timeout time.Duration
+	// AllowInterfaces lists the interfaces for which expired prompts are
+	// allowed rather than denied.
+	AllowInterfaces []string
+}
+
+// outcome returns the outcome of an expired prompt for the given interface.
+func (ep *ExpirationPolicy) outcome(iface string) prompting.OutcomeType {
+	if strutil.ListContains(ep.AllowInterfaces, iface) {
+		return prompting.OutcomeAllow
+	}
+	return prompting.OutcomeDeny
+}
+
+// ExpiredPrompt records a prompt which was resolved automatically because
+// it was not answered in time.
+type ExpiredPrompt struct {
+	ID        prompting.IDType      `json:"id"`
+	User      uint32                `json:"user"`
+	Snap      string                `json:"snap"`
+	Interface string                `json:"interface"`
+	Path      string                `json:"path"`
+	Timestamp time.Time             `json:"timestamp"`
+	Expired   time.Time             `json:"expired"`
+	Outcome   prompting.OutcomeType `json:"outcome"`
+}
+
+// BacklogMetrics describes the outstanding prompts and the prompts which
+// expired without being answered.
+type BacklogMetrics struct {
+	Outstanding int `json:"outstanding"`
+	// PerInterface counts the outstanding prompts by interface.
+	PerInterface map[string]int `json:"per-interface,omitempty"`
+	// OldestAge and AverageAge are the ages of the outstanding prompts,
+	// in seconds.
+	OldestAge  float64 `json:"oldest-age,omitempty"`
+	AverageAge float64 `json:"average-age,omitempty"`
+	// Expired is the number of prompts which expired since snapd started,
+	// the most recent of which are listed in RecentlyExpired.
+	Expired         int             `json:"expired"`
+	RecentlyExpired []ExpiredPrompt `json:"recently-expired,omitempty"`
+}
+
 // Prompt contains information about a request for which a user should be
 // prompted.
 type Prompt struct {
@@ -425,6 +477,12 @@ func (udb *userPromptDB) timeoutCallback(pdb *PromptDB, user uint32) {
 	}
 	pdb.saveRequestIDMap()
 
+	now := timeNow()
+	for _, p := range expiredPrompts {
+		pdb.recordExpired(user, p, prompting.OutcomeDeny, now)
+	}
+	pdb.scheduleExpiration()
+
 	// Unlock now so we can record notices without holding the prompt DB lock
 	pdb.mutex.Unlock()
 	data := map[string]string{"resolved": "expired"}
@@ -464,6 +522,17 @@ type PromptDB struct {
 
 	// The filepath at which the ID map is stored on disk.
 	requestIDMapFilepath string
+	// policy defines how prompts not answered in time are resolved, and
+	// policyTimer fires at policyDeadline, when the oldest prompt reaches
+	// the policy timeout. The deadline is zero if the timer is not armed.
+	policy         ExpirationPolicy
+	policyTimer    timeutil.Timer
+	policyDeadline time.Time
+	// expiredCount and recentlyExpired record the prompts which expired
+	// without being answered.
+	expiredCount    int
+	recentlyExpired []ExpiredPrompt
+
 	// requestIDMap is the mapping from request ID to prompt ID/user ID which
 	// is kept updated on disk and re-read when snapd restarts, so that we can
 	// re-associate each request which is re-received with a prompt with the
@@ -629,6 +698,164 @@ var timeAfterFunc = func(d time.Duration, f func()) timeutil.Timer {
 	return timeutil.AfterFunc(d, f)
 }
 
+var timeNow = time.Now
+
+// SetExpirationPolicy sets how prompts which are not answered within a
+// timeout are resolved.
+func (pdb *PromptDB) SetExpirationPolicy(policy ExpirationPolicy) error {
+	pdb.mutex.Lock()
+	defer pdb.mutex.Unlock()
+
+	if pdb.isClosed() {
+		return prompting_errors.ErrPromptsClosed
+	}
+	pdb.policy = policy
+	pdb.scheduleExpiration()
+	return nil
+}
+
+// scheduleExpiration arms the policy timer to fire when the oldest
+// outstanding prompt reaches the timeout of the expiration policy.
+//
+// The caller must ensure that the DB lock is held.
+func (pdb *PromptDB) scheduleExpiration() {
+	var oldest time.Time
+	if pdb.policy.Timeout > 0 {
+		for _, udb := range pdb.perUser {
+			for _, p := range udb.prompts {
+				if oldest.IsZero() || p.Timestamp.Before(oldest) {
+					oldest = p.Timestamp
+				}
+			}
+		}
+	}
+	if oldest.IsZero() {
+		if pdb.policyTimer != nil {
+			pdb.policyTimer.Stop()
+		}
+		pdb.policyDeadline = time.Time{}
+		return
+	}
+	pdb.policyDeadline = oldest.Add(pdb.policy.Timeout)
+	d := pdb.policyDeadline.Sub(timeNow())
+	if d < 0 {
+		d = 0
+	}
+	if pdb.policyTimer == nil {
+		pdb.policyTimer = timeAfterFunc(d, pdb.expireOverdue)
+	} else {
+		pdb.policyTimer.Reset(d)
+	}
+}
+
+// expireOverdue resolves the prompts which reached the timeout of the
+// expiration policy with the outcome defined by the policy. This method
+// should never be called directly outside of the policy timer.
+func (pdb *PromptDB) expireOverdue() {
+	pdb.mutex.Lock()
+	// We don't defer Unlock() since we record notices and send replies
+	// without holding the DB lock.
+
+	if pdb.isClosed() || pdb.policy.Timeout == 0 {
+		pdb.mutex.Unlock()
+		return
+	}
+
+	type expired struct {
+		user    uint32
+		prompt  *Prompt
+		outcome prompting.OutcomeType
+	}
+	var expiredPrompts []expired
+	now := timeNow()
+	for user, udb := range pdb.perUser {
+		var overdue []prompting.IDType
+		for _, p := range udb.prompts {
+			if now.Sub(p.Timestamp) >= pdb.policy.Timeout {
+				overdue = append(overdue, p.ID)
+			}
+		}
+		for _, id := range overdue {
+			p, _ := udb.remove(id) // cannot fail, the prompt was just found
+			for _, listenerReq := range p.listenerReqs {
+				delete(pdb.requestIDMap, listenerReq.ID)
+			}
+			outcome := pdb.policy.outcome(p.Interface)
+			pdb.recordExpired(user, p, outcome, now)
+			expiredPrompts = append(expiredPrompts, expired{user, p, outcome})
+		}
+	}
+	if len(expiredPrompts) > 0 {
+		pdb.saveRequestIDMap()
+	}
+	pdb.scheduleExpiration()
+
+	// Unlock now so we can record notices without holding the prompt DB lock
+	pdb.mutex.Unlock()
+	for _, e := range expiredPrompts {
+		logger.Debugf("prompt %s for snap %q expired, replying with %s", e.prompt.ID, e.prompt.Snap, e.outcome)
+		pdb.notifyPrompt(e.user, e.prompt.ID, map[string]string{"resolved": "expired", "outcome": string(e.outcome)})
+		e.prompt.sendReply(e.outcome) // ignore any error, should not occur
+	}
+}
+
+// recordExpired records that the given prompt expired with the given
+// outcome.
+//
+// The caller must ensure that the DB lock is held.
+func (pdb *PromptDB) recordExpired(user uint32, p *Prompt, outcome prompting.OutcomeType, now time.Time) {
+	pdb.expiredCount++
+	pdb.recentlyExpired = append(pdb.recentlyExpired, ExpiredPrompt{
+		ID:        p.ID,
+		User:      user,
+		Snap:      p.Snap,
+		Interface: p.Interface,
+		Path:      p.Constraints.path,
+		Timestamp: p.Timestamp,
+		Expired:   now,
+		Outcome:   outcome,
+	})
+	if len(pdb.recentlyExpired) > maxRecentlyExpired {
+		pdb.recentlyExpired = pdb.recentlyExpired[len(pdb.recentlyExpired)-maxRecentlyExpired:]
+	}
+}
+
+// Metrics returns metrics about the outstanding prompts of all users and
+// the prompts which expired without being answered.
+func (pdb *PromptDB) Metrics() (*BacklogMetrics, error) {
+	pdb.mutex.RLock()
+	defer pdb.mutex.RUnlock()
+
+	if pdb.isClosed() {
+		return nil, prompting_errors.ErrPromptsClosed
+	}
+
+	metrics := &BacklogMetrics{
+		Expired:         pdb.expiredCount,
+		RecentlyExpired: append([]ExpiredPrompt(nil), pdb.recentlyExpired...),
+	}
+	now := timeNow()
+	var totalAge time.Duration
+	for _, udb := range pdb.perUser {
+		for _, p := range udb.prompts {
+			if metrics.PerInterface == nil {
+				metrics.PerInterface = make(map[string]int)
+			}
+			metrics.Outstanding++
+			metrics.PerInterface[p.Interface]++
+			age := now.Sub(p.Timestamp)
+			totalAge += age
+			if age.Seconds() > metrics.OldestAge {
+				metrics.OldestAge = age.Seconds()
+			}
+		}
+	}
+	if metrics.Outstanding > 0 {
+		metrics.AverageAge = totalAge.Seconds() / float64(metrics.Outstanding)
+	}
+	return metrics, nil
+}
+
 // AddOrMerge checks if the given prompt contents are identical to an existing
 // prompt and, if so, merges with it by adding the given listenerReq to it.
 // Otherwise, adds a new prompt with the given contents to the prompt DB.
@@ -732,7 +959,7 @@ func (pdb *PromptDB) AddOrMerge(metadata *prompting.Metadata, path string, reque
 		needToSave = true
 	}
 
-	timestamp := time.Now()
+	timestamp := timeNow()
 	prompt := &Prompt{
 		ID:           promptID,
 		Timestamp:    timestamp,
@@ -743,6 +970,10 @@ func (pdb *PromptDB) AddOrMerge(metadata *prompting.Metadata, path string, reque
 		listenerReqs: []*listener.Request{listenerReq},
 	}
 	userEntry.add(prompt)
+	if pdb.policy.Timeout > 0 && pdb.policyDeadline.IsZero() {
+		// the policy timer is otherwise already armed for an older prompt
+		pdb.scheduleExpiration()
+	}
 	pdb.notifyPrompt(metadata.User, promptID, nil)
 	return prompt, false, nil
 }
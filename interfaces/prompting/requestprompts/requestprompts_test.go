@@ -2085,6 +2085,129 @@ func (s *requestpromptsSuite) TestPromptExpirationRace(c *C) {
 	c.Assert(err, Equals, prompting_errors.ErrPromptNotFound)
 }
 
+func (s *requestpromptsSuite) TestExpirationPolicy(c *C) {
+	now := time.Now()
+	restore := requestprompts.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	var timers []*testtime.TestTimer
+	restore = requestprompts.MockTimeAfterFunc(func(d time.Duration, f func()) timeutil.Timer {
+		timer := testtime.AfterFunc(d, f)
+		timers = append(timers, timer)
+		return timer
+	})
+	defer restore()
+
+	replyChan := make(chan notify.FilePermission, 1)
+	restore = requestprompts.MockSendReply(func(listenerReq *listener.Request, allowedPermission notify.AppArmorPermission) error {
+		allowedFilePermission, ok := allowedPermission.(notify.FilePermission)
+		c.Assert(ok, Equals, true)
+		replyChan <- allowedFilePermission
+		return nil
+	})
+	defer restore()
+
+	noticeChan := make(chan noticeInfo, 1)
+	pdb, err := requestprompts.New(func(userID uint32, promptID prompting.IDType, data map[string]string) error {
+		c.Assert(userID, Equals, s.defaultUser)
+		noticeChan <- noticeInfo{
+			promptID: promptID,
+			data:     data,
+		}
+		return nil
+	})
+	c.Assert(err, IsNil)
+	defer pdb.Close()
+
+	c.Assert(pdb.SetExpirationPolicy(requestprompts.ExpirationPolicy{Timeout: 5 * time.Second}), IsNil)
+	// nothing to expire yet
+	c.Check(timers, HasLen, 0)
+
+	metadata := &prompting.Metadata{
+		User:      s.defaultUser,
+		Snap:      "nextcloud",
+		PID:       1234,
+		Interface: "home",
+	}
+	permissions := []string{"read"}
+
+	t0 := now
+	prompt1, merged, err := pdb.AddOrMerge(metadata, "/home/test/1.txt", permissions, permissions, &listener.Request{ID: 1})
+	c.Assert(err, IsNil)
+	c.Assert(merged, Equals, false)
+	checkCurrentNotices(c, noticeChan, prompt1.ID, nil)
+	// the expiration timer of the user and the timer of the policy
+	c.Assert(timers, HasLen, 2)
+	policyTimer := timers[1]
+
+	now = now.Add(3 * time.Second)
+	prompt2, merged, err := pdb.AddOrMerge(metadata, "/home/test/2.txt", permissions, permissions, &listener.Request{ID: 2})
+	c.Assert(err, IsNil)
+	c.Assert(merged, Equals, false)
+	checkCurrentNotices(c, noticeChan, prompt2.ID, nil)
+
+	metrics, err := pdb.Metrics()
+	c.Assert(err, IsNil)
+	c.Check(metrics, DeepEquals, &requestprompts.BacklogMetrics{
+		Outstanding:  2,
+		PerInterface: map[string]int{"home": 2},
+		OldestAge:    3,
+		AverageAge:   1.5,
+	})
+
+	// the first prompt expires and is denied
+	now = now.Add(2 * time.Second)
+	policyTimer.Elapse(5 * time.Second)
+	checkCurrentNotices(c, noticeChan, prompt1.ID, map[string]string{"resolved": "expired", "outcome": "deny"})
+	reply := <-replyChan
+	c.Check(reply&notify.AA_MAY_READ, Equals, notify.FilePermission(0))
+
+	// expired prompts of the home interface are now allowed
+	c.Assert(pdb.SetExpirationPolicy(requestprompts.ExpirationPolicy{
+		Timeout:         5 * time.Second,
+		AllowInterfaces: []string{"home"},
+	}), IsNil)
+	now = now.Add(3 * time.Second)
+	policyTimer.Elapse(3 * time.Second)
+	checkCurrentNotices(c, noticeChan, prompt2.ID, map[string]string{"resolved": "expired", "outcome": "allow"})
+	reply = <-replyChan
+	c.Check(reply&notify.AA_MAY_READ, Equals, notify.AA_MAY_READ)
+
+	prompts, err := pdb.Prompts(s.defaultUser, false)
+	c.Assert(err, IsNil)
+	c.Check(prompts, HasLen, 0)
+	// the ID mappings of the expired prompts were removed
+	s.checkWrittenIDMap(c, map[uint64]requestprompts.IDMapEntry{})
+
+	metrics, err = pdb.Metrics()
+	c.Assert(err, IsNil)
+	c.Check(metrics, DeepEquals, &requestprompts.BacklogMetrics{
+		Expired: 2,
+		RecentlyExpired: []requestprompts.ExpiredPrompt{
+			{
+				ID:        prompt1.ID,
+				User:      s.defaultUser,
+				Snap:      "nextcloud",
+				Interface: "home",
+				Path:      "/home/test/1.txt",
+				Timestamp: t0,
+				Expired:   t0.Add(5 * time.Second),
+				Outcome:   prompting.OutcomeDeny,
+			},
+			{
+				ID:        prompt2.ID,
+				User:      s.defaultUser,
+				Snap:      "nextcloud",
+				Interface: "home",
+				Path:      "/home/test/2.txt",
+				Timestamp: t0.Add(3 * time.Second),
+				Expired:   t0.Add(8 * time.Second),
+				Outcome:   prompting.OutcomeAllow,
+			},
+		},
+	})
+}
+
 func checkCurrentNotices(c *C, noticeChan chan noticeInfo, expectedID prompting.IDType, expectedData map[string]string) {
 	select {
 	case info := <-noticeChan:
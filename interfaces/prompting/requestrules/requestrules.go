@@ -103,6 +103,18 @@ func (e *variantEntry) expired(at prompting.At) bool {
 	return true
 }
 
+// active returns true if any rule permission entry in this variant entry
+// applies at the given point in time, that is, it has not expired and its
+// schedule, if any, includes that point in time.
+func (e *variantEntry) active(at prompting.At) bool {
+	for _, rulePermissionEntry := range e.RuleEntries {
+		if rulePermissionEntry.Active(at) {
+			return true
+		}
+	}
+	return false
+}
+
 // permissionDB stores a map from path pattern variant to the ID of the rule
 // associated with the variant for the permission associated with the permission
 // DB.
@@ -242,12 +254,11 @@ func (rdb *RuleDB) load() (retErr error) {
 	}
 
 	// Use the same point in time for every rule
-	at := prompting.At{
-		Time: time.Now(),
-	}
+	atForUser := rdb.atForUsers(time.Now())
 
 	var errInvalid error
 	for _, rule := range wrapped.Rules {
+		at := atForUser(rule.User)
 		expired, err := rule.validate(at)
 		if err != nil {
 			// we're loading previously saved rules, so this should not happen
@@ -975,6 +986,40 @@ func (rdb *RuleDB) readOrAssignUserSessionID(user uint32) (userSessionID prompti
 	return newID, nil
 }
 
+// atForUser returns the given point in time along with the ID of the current
+// session of the given user. If the user has no active session, the session
+// ID is left unset, so rule permissions with lifespan "session" are treated
+// as expired.
+func (rdb *RuleDB) atForUser(t time.Time, user uint32) prompting.At {
+	at := prompting.At{
+		Time: t,
+	}
+	userSessionID, err := rdb.readOrAssignUserSessionID(user)
+	if err != nil {
+		if !errors.Is(err, errNoUserSession) {
+			logger.Noticef("cannot get user session ID for user %d: %v", user, err)
+		}
+		return at
+	}
+	at.SessionID = userSessionID
+	return at
+}
+
+// atForUsers returns a function which returns the given point in time along
+// with the ID of the current session of a given user, looking up the session
+// ID of each user at most once.
+func (rdb *RuleDB) atForUsers(t time.Time) func(user uint32) prompting.At {
+	ats := make(map[uint32]prompting.At)
+	return func(user uint32) prompting.At {
+		at, ok := ats[user]
+		if !ok {
+			at = rdb.atForUser(t, user)
+			ats[user] = at
+		}
+		return at
+	}
+}
+
 // Creates a rule with the given information and adds it to the rule database.
 // If any of the given parameters are invalid, returns an error. Otherwise,
 // returns the newly-added rule, and saves the database to disk.
@@ -986,9 +1031,7 @@ func (rdb *RuleDB) AddRule(user uint32, snap string, iface string, constraints *
 		return nil, prompting_errors.ErrRulesClosed
 	}
 
-	at := prompting.At{
-		Time: time.Now(),
-	}
+	at := rdb.atForUser(time.Now(), user)
 
 	newRule, err := rdb.makeNewRule(user, snap, iface, constraints, at)
 	if err != nil {
@@ -1039,9 +1082,7 @@ func (rdb *RuleDB) makeNewRule(user uint32, snap string, iface string, constrain
 func (rdb *RuleDB) IsRequestAllowed(user uint32, snap string, iface string, path string, permissions []string) (allowedPerms []string, anyDenied bool, outstandingPerms []string, err error) {
 	allowedPerms = make([]string, 0, len(permissions))
 	outstandingPerms = make([]string, 0, len(permissions))
-	at := prompting.At{
-		Time: time.Now(),
-	}
+	at := rdb.atForUser(time.Now(), user)
 	var errs []error
 	for _, perm := range permissions {
 		allowed, err := isPathPermAllowed(rdb, user, snap, iface, path, perm, at)
@@ -1065,12 +1106,16 @@ var isPathPermAllowed = (*RuleDB).isPathPermAllowed
 
 // isPathPermAllowed checks whether the given path with the given permission is
 // allowed or denied by existing rules for the given user, snap, and interface,
-// at the given point in time.
+// at the given point in time. Rules whose schedule does not include the given
+// point in time are not considered. If the matching rule permission entries
+// have a limited number of uses, the use is recorded.
 //
 // If no rule applies, returns prompting_errors.ErrNoMatchingRule.
 func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, path string, permission string, at prompting.At) (bool, error) {
-	rdb.mutex.RLock()
-	defer rdb.mutex.RUnlock()
+	// The lock must be held for writing, since uses of the matching rule
+	// permission entries may need to be recorded.
+	rdb.mutex.Lock()
+	defer rdb.mutex.Unlock()
 	permissionMap := rdb.permissionDBForUserSnapInterfacePermission(user, snap, iface, permission)
 	if permissionMap == nil {
 		return false, prompting_errors.ErrNoMatchingRule
@@ -1078,7 +1123,7 @@ func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, pat
 	variantMap := permissionMap.VariantEntries
 	var matchingVariants []patterns.PatternVariant
 	for variantStr, variantEntry := range variantMap {
-		if variantEntry.expired(at) {
+		if !variantEntry.active(at) {
 			continue
 		}
 
@@ -1102,9 +1147,43 @@ func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, pat
 		return false, err
 	}
 	matchingEntry := variantMap[highestPrecedenceVariant.String()]
+	rdb.recordUses(&matchingEntry, at)
 	return matchingEntry.Outcome.AsBool()
 }
 
+// recordUses increments the number of uses of the active rule permission
+// entries in the given variant entry which have a limited number of uses, and
+// saves the database if any entry was changed. If an entry has been used up,
+// a notice is recorded for its rule. Rules for which every permission has
+// been used up are left in the database until expired rules are next removed.
+//
+// The caller must ensure that the database lock is held for writing.
+func (rdb *RuleDB) recordUses(entry *variantEntry, at prompting.At) {
+	var changed bool
+	for id, permissionEntry := range entry.RuleEntries {
+		if permissionEntry.MaxUses == 0 || !permissionEntry.Active(at) {
+			continue
+		}
+		permissionEntry.Uses++
+		changed = true
+		if !permissionEntry.Expired(at) {
+			continue
+		}
+		rule, err := rdb.lookupRuleByID(id)
+		if err != nil {
+			// Should not occur, the tree and the rules list are consistent
+			continue
+		}
+		rdb.notifyRule(rule.User, rule.ID, nil)
+	}
+	if !changed {
+		return
+	}
+	if err := rdb.save(); err != nil {
+		logger.Noticef("cannot save rule database after recording rule uses: %v", err)
+	}
+}
+
 // RuleWithID returns the rule with the given ID.
 // If the rule is not found, returns ErrRuleNotFound.
 // If the rule does not apply to the given user, returns
@@ -1135,11 +1214,9 @@ func (rdb *RuleDB) Rules(user uint32) []*Rule {
 // interface, but may apply to multiple permissions.
 func (rdb *RuleDB) rulesInternal(ruleFilter func(rule *Rule) bool) []*Rule {
 	rules := make([]*Rule, 0)
-	at := prompting.At{
-		Time: time.Now(),
-	}
+	atForUser := rdb.atForUsers(time.Now())
 	for _, rule := range rdb.rules {
-		if rule.expired(at) {
+		if rule.expired(atForUser(rule.User)) {
 			// XXX: it would be nice if we pruned expired permissions from a
 			// rule before including it in the rules list, if it's not expired.
 			// Since we don't hold the write lock, we don't want to
@@ -1238,6 +1315,47 @@ func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 	return rule, nil
 }
 
+// RemoveExpiredRules removes every rule for which all permissions have
+// expired, records a notice for each one, and saves the database to disk if
+// any rules were removed.
+func (rdb *RuleDB) RemoveExpiredRules() error {
+	rdb.mutex.Lock()
+	defer rdb.mutex.Unlock()
+
+	if rdb.maxIDMmap.IsClosed() {
+		return prompting_errors.ErrRulesClosed
+	}
+
+	atForUser := rdb.atForUsers(time.Now())
+	var expiredRules []*Rule
+	for _, rule := range rdb.rules {
+		if rule.expired(atForUser(rule.User)) {
+			expiredRules = append(expiredRules, rule)
+		}
+	}
+	if len(expiredRules) == 0 {
+		return nil
+	}
+
+	for _, rule := range expiredRules {
+		rdb.removeRuleByIDFromRulesList(rule.ID)
+	}
+	if err := rdb.save(); err != nil {
+		// Roll back the change by re-adding all removed rules
+		for _, rule := range expiredRules {
+			rdb.addRuleToRulesList(rule)
+		}
+		return err
+	}
+
+	data := map[string]string{"removed": "expired"}
+	for _, rule := range expiredRules {
+		rdb.removeRuleFromTree(rule)
+		rdb.notifyRule(rule.User, rule.ID, data)
+	}
+	return nil
+}
+
 // RemoveRulesForSnap removes all rules pertaining to the given snap for the
 // user with the given user ID.
 func (rdb *RuleDB) RemoveRulesForSnap(user uint32, snap string) ([]*Rule, error) {
@@ -1361,9 +1479,7 @@ func (rdb *RuleDB) PatchRule(user uint32, id prompting.IDType, constraintsPatch
 	// support patching it? Currently, we don't include fully expired rules
 	// in the output of Rules(), should the same be done here?
 
-	at := prompting.At{
-		Time: time.Now(),
-	}
+	at := rdb.atForUser(time.Now(), user)
 
 	if constraintsPatch == nil {
 		constraintsPatch = &prompting.RuleConstraintsPatch{}
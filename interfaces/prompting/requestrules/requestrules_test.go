@@ -2075,6 +2075,165 @@ func (s *requestrulesSuite) TestIsPathPermAllowedExpiration(c *C) {
 	}
 }
 
+func (s *requestrulesSuite) TestIsPathPermAllowedMaxUses(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	user := s.defaultUser
+	snap := "firefox"
+	iface := "home"
+	path := "/home/test/Documents/foo.txt"
+
+	constraints := &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/test/Documents/**"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+				MaxUses:  2,
+			},
+		},
+	}
+	rule, err := rdb.AddRule(user, snap, iface, constraints)
+	c.Assert(err, IsNil)
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{rule})
+	s.checkNewNoticesSimple(c, nil, rule)
+
+	at := prompting.At{
+		Time: time.Now(),
+	}
+
+	// First use is recorded and saved to disk, without a notice
+	allowed, err := rdb.IsPathPermAllowed(user, snap, iface, path, "read", at)
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+	c.Check(rule.Constraints.Permissions["read"].Uses, Equals, 1)
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{rule})
+	s.checkNewNoticesSimple(c, nil)
+
+	// Last use causes the rule to expire, so a notice is recorded
+	allowed, err = rdb.IsPathPermAllowed(user, snap, iface, path, "read", at)
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+	c.Check(rule.Constraints.Permissions["read"].Uses, Equals, 2)
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{rule})
+	s.checkNewNoticesSimple(c, nil, rule)
+
+	// The rule no longer matches
+	_, err = rdb.IsPathPermAllowed(user, snap, iface, path, "read", at)
+	c.Check(err, Equals, prompting_errors.ErrNoMatchingRule)
+	c.Check(rule.Constraints.Permissions["read"].Uses, Equals, 2)
+	s.checkNewNoticesSimple(c, nil)
+	c.Check(rdb.Rules(user), HasLen, 0)
+
+	// Removing expired rules removes it from disk
+	c.Assert(rdb.RemoveExpiredRules(), IsNil)
+	s.checkWrittenRuleDB(c, nil)
+	s.checkNewNoticesSimple(c, map[string]string{"removed": "expired"}, rule)
+
+	// Nothing left to remove
+	c.Assert(rdb.RemoveExpiredRules(), IsNil)
+	s.checkNewNoticesSimple(c, nil)
+}
+
+func (s *requestrulesSuite) TestIsPathPermAllowedSchedule(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	user := s.defaultUser
+	snap := "firefox"
+	iface := "home"
+	path := "/home/test/Documents/foo.txt"
+
+	constraints := &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/test/Documents/**"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+				Schedule: "mon,9:00-17:00",
+			},
+		},
+	}
+	rule, err := rdb.AddRule(user, snap, iface, constraints)
+	c.Assert(err, IsNil)
+	s.checkNewNoticesSimple(c, nil, rule)
+
+	// Within the schedule
+	at := prompting.At{
+		Time: time.Date(2025, time.March, 3, 10, 0, 0, 0, time.Local),
+	}
+	allowed, err := rdb.IsPathPermAllowed(user, snap, iface, path, "read", at)
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+
+	// Outside of the schedule
+	at.Time = time.Date(2025, time.March, 3, 18, 0, 0, 0, time.Local)
+	_, err = rdb.IsPathPermAllowed(user, snap, iface, path, "read", at)
+	c.Check(err, Equals, prompting_errors.ErrNoMatchingRule)
+
+	// The rule is not expired, merely inactive
+	c.Check(rdb.Rules(user), DeepEquals, []*requestrules.Rule{rule})
+	c.Assert(rdb.RemoveExpiredRules(), IsNil)
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{rule})
+	s.checkNewNoticesSimple(c, nil)
+}
+
+func (s *requestrulesSuite) TestAddRuleSessionLifespan(c *C) {
+	_, restore := requestrules.MockUserSessionIDXattr()
+	defer restore()
+
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	user := s.defaultUser
+	snap := "firefox"
+	iface := "home"
+	path := "/home/test/Documents/foo.txt"
+	sessionDir := filepath.Join(dirs.GlobalRootDir, "run/user/1000")
+
+	constraints := &prompting.Constraints{
+		PathPattern: mustParsePathPattern(c, "/home/test/Documents/**"),
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanSession,
+			},
+		},
+	}
+
+	// Without a user session, the rule cannot be added
+	_, err = rdb.AddRule(user, snap, iface, constraints)
+	c.Check(err, Equals, prompting_errors.ErrNewSessionRuleNoSession)
+	s.checkNewNoticesSimple(c, nil)
+
+	c.Assert(os.MkdirAll(sessionDir, 0o700), IsNil)
+	rule, err := rdb.AddRule(user, snap, iface, constraints)
+	c.Assert(err, IsNil)
+	c.Check(rule.Constraints.Permissions["read"].SessionID, Not(Equals), prompting.IDType(0))
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{rule})
+	s.checkNewNoticesSimple(c, nil, rule)
+
+	allowedPerms, anyDenied, outstandingPerms, err := rdb.IsRequestAllowed(user, snap, iface, path, []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(allowedPerms, DeepEquals, []string{"read"})
+	c.Check(anyDenied, Equals, false)
+	c.Check(outstandingPerms, HasLen, 0)
+
+	// A new user session has a new ID, so the rule no longer applies
+	c.Assert(os.Remove(sessionDir), IsNil)
+	c.Assert(os.MkdirAll(sessionDir, 0o700), IsNil)
+	allowedPerms, anyDenied, outstandingPerms, err = rdb.IsRequestAllowed(user, snap, iface, path, []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(allowedPerms, HasLen, 0)
+	c.Check(anyDenied, Equals, false)
+	c.Check(outstandingPerms, DeepEquals, []string{"read"})
+
+	c.Assert(rdb.RemoveExpiredRules(), IsNil)
+	s.checkWrittenRuleDB(c, nil)
+	s.checkNewNoticesSimple(c, map[string]string{"removed": "expired"}, rule)
+}
+
 func (s *requestrulesSuite) TestRuleWithID(c *C) {
 	rdb, _ := requestrules.New(s.defaultNotifyRule)
 
@@ -107,6 +107,7 @@ func (b *Backend) Setup(appSet *interfaces.SnapAppSet, opts interfaces.Confineme
 	}
 
 	udevSpec := spec.(*Specification)
+	udevSpec.AddDeviceAccessRules(opts.DeviceAccessRules)
 	content := b.deriveContent(udevSpec)
 	subsystemTriggers := udevSpec.TriggeredSubsystems()
 
@@ -232,6 +233,51 @@ func (b *Backend) Remove(snapName string) error {
 	return nil
 }
 
+// AppliedDeviceCgroup describes the device cgroup setup currently written
+// out for a snap.
+type AppliedDeviceCgroup struct {
+	// Rules are the udev rules tagging devices for the snap, in the
+	// form they were written.
+	Rules []string
+	// SelfManaged is set when the snap manages its own device cgroup.
+	SelfManaged bool
+	// NonStrict is set when device filtering is disabled because the
+	// snap uses non-strict confinement.
+	NonStrict bool
+}
+
+// SnapAppliedDeviceCgroup returns the device cgroup setup currently written
+// out by the backend for the given snap. It returns an error wrapping
+// fs.ErrNotExist if the backend was not set up for the snap.
+func SnapAppliedDeviceCgroup(snapName string) (*AppliedDeviceCgroup, error) {
+	flags, err := os.ReadFile(snapDeviceCgroupSelfManageFilePath(snapName))
+	if err != nil {
+		return nil, err
+	}
+	applied := &AppliedDeviceCgroup{}
+	for _, line := range strings.Split(string(flags), "\n") {
+		switch strings.TrimSpace(line) {
+		case "self-managed=true":
+			applied.SelfManaged = true
+		case "non-strict=true":
+			applied.NonStrict = true
+		}
+	}
+
+	rules, err := os.ReadFile(snapRulesFilePath(snapName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(rules), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		applied.Rules = append(applied.Rules, line)
+	}
+	return applied, nil
+}
+
 func (b *Backend) deriveContent(spec *Specification) (content []string) {
 	content = append(content, spec.Snippets()...)
 	return content
@@ -21,6 +21,8 @@ package udev_test
 
 import (
 	"bytes"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 
@@ -659,3 +661,48 @@ func (s *backendSuite) TestPreseed(c *C) {
 
 	c.Check(s.udevadmCmd.Calls(), HasLen, 0)
 }
+
+func (s *backendSuite) TestDeviceAccessRules(c *C) {
+	opts := interfaces.ConfinementOptions{
+		DeviceAccessRules: []string{`SUBSYSTEM=="tty", KERNEL=="ttyUSB0"`},
+	}
+	snapInfo := s.InstallSnap(c, opts, "", ifacetest.SambaYamlV1, 0)
+	fname := filepath.Join(dirs.SnapUdevRulesDir, "70-snap.samba.rules")
+	c.Check(fname, testutil.FileEquals, `# This file is automatically generated.
+# device-access
+SUBSYSTEM=="tty", KERNEL=="ttyUSB0", TAG+="snap_samba_smbd"
+TAG=="snap_samba_smbd", SUBSYSTEM!="module", SUBSYSTEM!="subsystem", RUN+="/usr/lib/snapd/snap-device-helper $env{ACTION} snap_samba_smbd $devpath $major:$minor"
+`)
+
+	applied, err := udev.SnapAppliedDeviceCgroup("samba")
+	c.Assert(err, IsNil)
+	c.Check(applied, DeepEquals, &udev.AppliedDeviceCgroup{
+		Rules: []string{
+			`SUBSYSTEM=="tty", KERNEL=="ttyUSB0", TAG+="snap_samba_smbd"`,
+			`TAG=="snap_samba_smbd", SUBSYSTEM!="module", SUBSYSTEM!="subsystem", RUN+="/usr/lib/snapd/snap-device-helper $env{ACTION} snap_samba_smbd $devpath $major:$minor"`,
+		},
+	})
+
+	// once the grant is gone the rules are dropped again
+	s.UpdateSnap(c, snapInfo, interfaces.ConfinementOptions{}, ifacetest.SambaYamlV1, 0)
+	c.Check(fname, testutil.FileAbsent)
+	applied, err = udev.SnapAppliedDeviceCgroup("samba")
+	c.Assert(err, IsNil)
+	c.Check(applied, DeepEquals, &udev.AppliedDeviceCgroup{})
+}
+
+func (s *backendSuite) TestSnapAppliedDeviceCgroupFlags(c *C) {
+	s.Iface.UDevPermanentSlotCallback = func(spec *udev.Specification, slot *snap.SlotInfo) error {
+		spec.SetControlsDeviceCgroup()
+		return nil
+	}
+	s.InstallSnap(c, interfaces.ConfinementOptions{DevMode: true}, "", ifacetest.SambaYamlV1, 0)
+	applied, err := udev.SnapAppliedDeviceCgroup("samba")
+	c.Assert(err, IsNil)
+	c.Check(applied, DeepEquals, &udev.AppliedDeviceCgroup{SelfManaged: true, NonStrict: true})
+}
+
+func (s *backendSuite) TestSnapAppliedDeviceCgroupNotSetUp(c *C) {
+	_, err := udev.SnapAppliedDeviceCgroup("samba")
+	c.Check(errors.Is(err, fs.ErrNotExist), Equals, true)
+}
@@ -123,6 +123,32 @@ func (spec *Specification) TagDevice(snippet string) {
 	}
 }
 
+// deviceAccessIface is used in place of an interface name in the comments of
+// rules tagging devices the snap was granted access to directly.
+const deviceAccessIface = "device-access"
+
+// AddDeviceAccessRules tags the devices described by the given snippets for
+// all the apps and hooks of the snap, to grant them access to the devices
+// outside of any interface.
+func (spec *Specification) AddDeviceAccessRules(snippets []string) {
+	if len(snippets) == 0 {
+		return
+	}
+	runnables := spec.appSet.Runnables()
+	tags := make([]string, 0, len(runnables))
+	for _, r := range runnables {
+		tags = append(tags, r.SecurityTag)
+	}
+	sort.Strings(tags)
+
+	spec.securityTags = tags
+	spec.iface = deviceAccessIface
+	defer func() { spec.securityTags = nil; spec.iface = "" }()
+	for _, snippet := range snippets {
+		spec.TagDevice(snippet)
+	}
+}
+
 type byTagAndSnippet []entry
 
 func (c byTagAndSnippet) Len() int      { return len(c) }
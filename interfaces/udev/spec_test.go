@@ -183,3 +183,23 @@ func (s *specSuite) TestControlsDeviceCgroup(c *C) {
 	s.spec.SetControlsDeviceCgroup()
 	c.Assert(s.spec.ControlsDeviceCgroup(), Equals, true)
 }
+
+func (s *specSuite) TestAddDeviceAccessRules(c *C) {
+	s.spec.AddDeviceAccessRules([]string{`SUBSYSTEM=="tty", KERNEL=="ttyUSB0"`})
+	c.Assert(s.spec.Snippets(), DeepEquals, []string{
+		`# device-access
+SUBSYSTEM=="tty", KERNEL=="ttyUSB0", TAG+="snap_snap1__comp_hook_install"`,
+		`TAG=="snap_snap1__comp_hook_install", SUBSYSTEM!="module", SUBSYSTEM!="subsystem", RUN+="/usr/lib/snapd/snap-device-helper $env{ACTION} snap_snap1__comp_hook_install $devpath $major:$minor"`,
+		`# device-access
+SUBSYSTEM=="tty", KERNEL=="ttyUSB0", TAG+="snap_snap1_foo"`,
+		`TAG=="snap_snap1_foo", SUBSYSTEM!="module", SUBSYSTEM!="subsystem", RUN+="/usr/lib/snapd/snap-device-helper $env{ACTION} snap_snap1_foo $devpath $major:$minor"`,
+		`# device-access
+SUBSYSTEM=="tty", KERNEL=="ttyUSB0", TAG+="snap_snap1_hook_configure"`,
+		`TAG=="snap_snap1_hook_configure", SUBSYSTEM!="module", SUBSYSTEM!="subsystem", RUN+="/usr/lib/snapd/snap-device-helper $env{ACTION} snap_snap1_hook_configure $devpath $major:$minor"`,
+	})
+}
+
+func (s *specSuite) TestAddDeviceAccessRulesNone(c *C) {
+	s.spec.AddDeviceAccessRules(nil)
+	c.Assert(s.spec.Snippets(), HasLen, 0)
+}
@@ -109,6 +109,10 @@ func Debugf(format string, v ...any) {
 	lock.Lock()
 	defer lock.Unlock()
 
+	if debugOverriddenLocked() {
+		logger.NoGuardDebug(msg)
+		return
+	}
 	logger.Debug(msg)
 }
 
@@ -117,6 +121,10 @@ func Debug(msg string) {
 	lock.Lock()
 	defer lock.Unlock()
 
+	if debugOverriddenLocked() {
+		logger.NoGuardDebug(msg)
+		return
+	}
 	logger.Debug(msg)
 }
 
@@ -0,0 +1,144 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package logger
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AllSubsystems is the subsystem name of a debug override applying to all
+// subsystems.
+const AllSubsystems = "all"
+
+// DebugOverride describes a temporary increase of the logging granularity
+// to debug for a subsystem, which is the name of the package emitting the
+// messages, e.g. "store" or "ifacestate".
+type DebugOverride struct {
+	Subsystem string
+	Until     time.Time
+}
+
+// debugOverrides maps subsystems to the time until when debug messages are
+// logged for them, it is protected by lock.
+var debugOverrides map[string]time.Time
+
+// SetDebugOverrides enables debug messages for the given subsystems, or for
+// all of them if none are given, until the given time. Debug messages are
+// then logged irrespective of SNAPD_DEBUG or the logger options.
+func SetDebugOverrides(subsystems []string, until time.Time) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if len(subsystems) == 0 {
+		subsystems = []string{AllSubsystems}
+	}
+	if debugOverrides == nil {
+		debugOverrides = make(map[string]time.Time, len(subsystems))
+	}
+	for _, subsystem := range subsystems {
+		debugOverrides[subsystem] = until
+	}
+}
+
+// ClearDebugOverrides removes the debug overrides of the given subsystems,
+// or all of them if none are given.
+func ClearDebugOverrides(subsystems []string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if len(subsystems) == 0 {
+		debugOverrides = nil
+		return
+	}
+	for _, subsystem := range subsystems {
+		delete(debugOverrides, subsystem)
+	}
+}
+
+// DebugOverrides returns the currently active debug overrides sorted by
+// subsystem.
+func DebugOverrides() []DebugOverride {
+	lock.Lock()
+	defer lock.Unlock()
+
+	expireDebugOverridesLocked()
+	overrides := make([]DebugOverride, 0, len(debugOverrides))
+	for subsystem, until := range debugOverrides {
+		overrides = append(overrides, DebugOverride{Subsystem: subsystem, Until: until})
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Subsystem < overrides[j].Subsystem
+	})
+	return overrides
+}
+
+func expireDebugOverridesLocked() {
+	now := timeNow()
+	for subsystem, until := range debugOverrides {
+		if !now.Before(until) {
+			delete(debugOverrides, subsystem)
+		}
+	}
+}
+
+// debugOverriddenLocked returns whether a debug message emitted by the
+// caller of the package level API function calling it must be logged
+// because of an active debug override.
+func debugOverriddenLocked() bool {
+	if len(debugOverrides) == 0 {
+		return false
+	}
+	expireDebugOverridesLocked()
+	if _, ok := debugOverrides[AllSubsystems]; ok {
+		return true
+	}
+	if len(debugOverrides) == 0 {
+		return false
+	}
+	// single package level API func() + actual caller
+	_, ok := debugOverrides[callerSubsystem(1+1)]
+	return ok
+}
+
+// callerSubsystem returns the name of the package of the function skip
+// frames up the stack, with 0 identifying the caller of callerSubsystem.
+func callerSubsystem(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	// function names look like
+	// github.com/snapcore/snapd/overlord/ifacestate.(*InterfaceManager).doConnect
+	name := fn.Name()
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	if idx := strings.Index(name, "."); idx >= 0 {
+		name = name[:idx]
+	}
+	return name
+}
@@ -0,0 +1,94 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package logger_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/logger"
+)
+
+func (s *LogSuite) TestDebugOverrideForSubsystem(c *C) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	restore := logger.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	defer logger.ClearDebugOverrides(nil)
+
+	logger.SetDebugOverrides([]string{"store"}, now.Add(time.Hour))
+	logger.Debugf("not logged")
+	c.Check(s.logbuf.String(), Equals, "")
+
+	// the tests run in the logger_test package
+	logger.SetDebugOverrides([]string{"logger_test"}, now.Add(time.Hour))
+	logger.Debugf("xyzzy")
+	logger.Debug("plugh")
+	c.Check(s.logbuf.String(), Matches, `(?s).*overrides_test\.go:\d+: DEBUG: xyzzy\n.*overrides_test\.go:\d+: DEBUG: plugh\n`)
+
+	c.Check(logger.DebugOverrides(), DeepEquals, []logger.DebugOverride{
+		{Subsystem: "logger_test", Until: now.Add(time.Hour)},
+		{Subsystem: "store", Until: now.Add(time.Hour)},
+	})
+}
+
+func (s *LogSuite) TestDebugOverrideAllSubsystems(c *C) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	restore := logger.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	defer logger.ClearDebugOverrides(nil)
+
+	logger.SetDebugOverrides(nil, now.Add(time.Minute))
+	logger.Debugf("xyzzy")
+	c.Check(s.logbuf.String(), Matches, `(?m).*overrides_test\.go:\d+: DEBUG: xyzzy`)
+	c.Check(logger.DebugOverrides(), DeepEquals, []logger.DebugOverride{
+		{Subsystem: "all", Until: now.Add(time.Minute)},
+	})
+
+	logger.ClearDebugOverrides(nil)
+	s.logbuf.Reset()
+	logger.Debugf("xyzzy")
+	c.Check(s.logbuf.String(), Equals, "")
+	c.Check(logger.DebugOverrides(), HasLen, 0)
+}
+
+func (s *LogSuite) TestDebugOverrideExpires(c *C) {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	restore := logger.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	defer logger.ClearDebugOverrides(nil)
+
+	logger.SetDebugOverrides([]string{"logger_test", "store"}, start.Add(30*time.Minute))
+	logger.SetDebugOverrides([]string{"ifacestate"}, start.Add(time.Hour))
+	logger.Debugf("xyzzy")
+	c.Check(s.logbuf.String(), Matches, `(?m).*DEBUG: xyzzy`)
+
+	now = start.Add(30 * time.Minute)
+	s.logbuf.Reset()
+	logger.Debugf("xyzzy")
+	c.Check(s.logbuf.String(), Equals, "")
+	c.Check(logger.DebugOverrides(), DeepEquals, []logger.DebugOverride{
+		{Subsystem: "ifacestate", Until: start.Add(time.Hour)},
+	})
+
+	logger.ClearDebugOverrides([]string{"ifacestate"})
+	c.Check(logger.DebugOverrides(), HasLen, 0)
+}
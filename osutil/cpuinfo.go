@@ -0,0 +1,95 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package osutil
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+)
+
+var (
+	procCpuinfo = "/proc/cpuinfo"
+)
+
+// CPUInfo is a summary of the processors of the system as reported by
+// /proc/cpuinfo.
+type CPUInfo struct {
+	// ModelName is the model name of the first processor, if known.
+	ModelName string
+	// Count is the number of processors listed.
+	Count int
+	// Flags is the sorted set of feature flags of the first processor.
+	Flags []string
+}
+
+// ReadCPUInfo returns a summary of the processors of the system.
+//
+// Only the first processor is inspected for the model name and the feature
+// flags, as they are expected to be uniform across processors. Both x86
+// style ("model name", "flags") and arm style ("Model", "Features") keys are
+// understood.
+func ReadCPUInfo() (*CPUInfo, error) {
+	f, err := os.Open(procCpuinfo)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	// lines with flags can be long on modern x86 CPUs
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	info := &CPUInfo{}
+	for s.Scan() {
+		key, value, ok := strings.Cut(s.Text(), ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch key {
+		case "processor":
+			info.Count++
+		case "model name", "Model":
+			if info.ModelName == "" {
+				info.ModelName = value
+			}
+		case "flags", "Features":
+			if info.Flags == nil {
+				info.Flags = strings.Fields(value)
+				sort.Strings(info.Flags)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func MockProcCpuinfo(newPath string) (restore func()) {
+	MustBeTestBinary("mocking can only be done from tests")
+	oldProcCpuinfo := procCpuinfo
+	procCpuinfo = newPath
+	return func() {
+		procCpuinfo = oldProcCpuinfo
+	}
+}
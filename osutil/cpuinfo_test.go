@@ -0,0 +1,95 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package osutil_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/osutil"
+)
+
+type cpuinfoSuite struct{}
+
+var _ = Suite(&cpuinfoSuite{})
+
+const cpuinfoX86Example = `processor	: 0
+vendor_id	: GenuineIntel
+model name	: Intel(R) Core(TM) i7-8650U CPU @ 1.90GHz
+flags		: sse2 fpu vme aes
+bugs		: spectre_v1
+
+processor	: 1
+vendor_id	: GenuineIntel
+model name	: Intel(R) Core(TM) i7-8650U CPU @ 1.90GHz
+flags		: sse2 fpu vme aes
+bugs		: spectre_v1
+`
+
+const cpuinfoArmExample = `processor	: 0
+BogoMIPS	: 108.00
+Features	: fp asimd evtstrm crc32 cpuid
+CPU implementer	: 0x41
+
+processor	: 1
+BogoMIPS	: 108.00
+Features	: fp asimd evtstrm crc32 cpuid
+CPU implementer	: 0x41
+
+Hardware	: BCM2835
+Model		: Raspberry Pi 4 Model B Rev 1.4
+`
+
+func (s *cpuinfoSuite) TestReadCPUInfoX86(c *C) {
+	p := filepath.Join(c.MkDir(), "cpuinfo")
+	c.Assert(os.WriteFile(p, []byte(cpuinfoX86Example), 0644), IsNil)
+	defer osutil.MockProcCpuinfo(p)()
+
+	info, err := osutil.ReadCPUInfo()
+	c.Assert(err, IsNil)
+	c.Check(info, DeepEquals, &osutil.CPUInfo{
+		ModelName: "Intel(R) Core(TM) i7-8650U CPU @ 1.90GHz",
+		Count:     2,
+		Flags:     []string{"aes", "fpu", "sse2", "vme"},
+	})
+}
+
+func (s *cpuinfoSuite) TestReadCPUInfoArm(c *C) {
+	p := filepath.Join(c.MkDir(), "cpuinfo")
+	c.Assert(os.WriteFile(p, []byte(cpuinfoArmExample), 0644), IsNil)
+	defer osutil.MockProcCpuinfo(p)()
+
+	info, err := osutil.ReadCPUInfo()
+	c.Assert(err, IsNil)
+	c.Check(info, DeepEquals, &osutil.CPUInfo{
+		ModelName: "Raspberry Pi 4 Model B Rev 1.4",
+		Count:     2,
+		Flags:     []string{"asimd", "cpuid", "crc32", "evtstrm", "fp"},
+	})
+}
+
+func (s *cpuinfoSuite) TestReadCPUInfoMissing(c *C) {
+	defer osutil.MockProcCpuinfo(filepath.Join(c.MkDir(), "missing"))()
+
+	_, err := osutil.ReadCPUInfo()
+	c.Check(err, ErrorMatches, `open .*/missing: no such file or directory`)
+}
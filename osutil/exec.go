@@ -59,6 +59,13 @@ func KillProcessGroup(cmd *exec.Cmd) error {
 // RunAndWait runs a command for the given argv with the given environ added to
 // os.Environ, killing it if it reaches timeout, or if the tomb is dying.
 func RunAndWait(argv []string, env []string, timeout time.Duration, tomb *tomb.Tomb) ([]byte, error) {
+	return RunAndWaitWithOutput(argv, env, timeout, nil, tomb)
+}
+
+// RunAndWaitWithOutput is like RunAndWait but additionally copies the
+// combined stdout and stderr of the command to output, if not nil, as it
+// is produced.
+func RunAndWaitWithOutput(argv []string, env []string, timeout time.Duration, output io.Writer, tomb *tomb.Tomb) ([]byte, error) {
 	if len(argv) == 0 {
 		return nil, fmt.Errorf("internal error: osutil.RunAndWait needs non-empty argv")
 	}
@@ -79,8 +86,12 @@ func RunAndWait(argv []string, env []string, timeout time.Duration, tomb *tomb.T
 	// Make sure we can obtain stdout and stderror. Same buffer so they're
 	// combined.
 	buffer := strutil.NewLimitedBuffer(100, 10*1024)
-	command.Stdout = buffer
-	command.Stderr = buffer
+	var w io.Writer = buffer
+	if output != nil {
+		w = io.MultiWriter(buffer, output)
+	}
+	command.Stdout = w
+	command.Stderr = w
 
 	// Actually run the command.
 	if err := command.Start(); err != nil {
@@ -60,6 +60,14 @@ func (s *execSuite) TestRunAndWaitRunsSetsEnviron(c *C) {
 	c.Check(string(buf), Equals, "42\n")
 }
 
+func (s *execSuite) TestRunAndWaitWithOutput(c *C) {
+	var output bytes.Buffer
+	buf, err := osutil.RunAndWaitWithOutput([]string{"sh", "-c", "echo hello; echo world >&2"}, nil, time.Second, &output, &tomb.Tomb{})
+	c.Assert(err, IsNil)
+	c.Check(string(buf), Equals, "hello\nworld\n")
+	c.Check(output.String(), Equals, "hello\nworld\n")
+}
+
 func (s *execSuite) TestRunAndWaitRunsAndKillsOnTimeout(c *C) {
 	buf, err := osutil.RunAndWait([]string{"sleep", "1s"}, nil, time.Millisecond, &tomb.Tomb{})
 	c.Check(err, ErrorMatches, "exceeded maximum runtime.*")
@@ -0,0 +1,200 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package hwinfo collects a summary of the hardware of the system.
+package hwinfo
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/bootloader/efi"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// CPU summarizes the processors of the system.
+type CPU struct {
+	Model string
+	// Cores is the number of logical processors.
+	Cores int
+}
+
+// StorageDevice describes a block device backed by hardware.
+type StorageDevice struct {
+	// Name is the kernel name of the device, e.g. "sda" or "nvme0n1".
+	Name       string
+	Model      string
+	Size       uint64
+	Removable  bool
+	Rotational bool
+}
+
+// TPM describes the TPM of the system, if any.
+type TPM struct {
+	Present bool
+	// Version is the major version of the TPM specification
+	// implemented by the device, e.g. "2", when known.
+	Version string
+}
+
+// SecureBoot is the state of UEFI secure boot.
+type SecureBoot string
+
+const (
+	SecureBootUnknown     SecureBoot = ""
+	SecureBootEnabled     SecureBoot = "enabled"
+	SecureBootDisabled    SecureBoot = "disabled"
+	SecureBootUnsupported SecureBoot = "unsupported"
+)
+
+// Info is a summary of the hardware of the system.
+type Info struct {
+	CPU CPU
+	// Memory is the total usable memory in bytes.
+	Memory     uint64
+	Storage    []StorageDevice
+	TPM        TPM
+	SecureBoot SecureBoot
+}
+
+// cacheDuration is how long collected information is reused for.
+var cacheDuration = 10 * time.Minute
+
+var timeNow = time.Now
+
+var (
+	cacheMu   sync.Mutex
+	cached    *Info
+	cachedAt  time.Time
+	collectFn = Collect
+)
+
+// Get returns the hardware summary of the system, reusing the information
+// collected in the last few minutes if any. The result must not be modified.
+func Get() *Info {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	now := timeNow()
+	if cached == nil || now.Sub(cachedAt) >= cacheDuration {
+		cached = collectFn()
+		cachedAt = now
+	}
+	return cached
+}
+
+// Collect probes the hardware of the system. Probing is best effort,
+// details that cannot be determined are left empty.
+func Collect() *Info {
+	info := &Info{
+		Storage:    storageDevices(),
+		TPM:        tpm(),
+		SecureBoot: secureBoot(),
+	}
+
+	if cpuInfo, err := osutil.ReadCPUInfo(); err != nil {
+		logger.Debugf("cannot read cpu information: %v", err)
+	} else {
+		info.CPU = CPU{Model: cpuInfo.ModelName, Cores: cpuInfo.Count}
+	}
+
+	if mem, err := osutil.TotalUsableMemory(); err != nil {
+		logger.Debugf("cannot read memory information: %v", err)
+	} else {
+		info.Memory = mem
+	}
+
+	return info
+}
+
+func readSysfsString(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// storageDevices lists the block devices of the system that are backed by
+// hardware, that is it skips loop, device mapper and similar devices.
+func storageDevices() []StorageDevice {
+	sysBlock := filepath.Join(dirs.GlobalRootDir, "/sys/block")
+	entries, err := os.ReadDir(sysBlock)
+	if err != nil {
+		logger.Debugf("cannot list block devices: %v", err)
+		return nil
+	}
+
+	var devices []StorageDevice
+	for _, entry := range entries {
+		devDir := filepath.Join(sysBlock, entry.Name())
+		// virtual block devices have no backing device
+		if !osutil.FileExists(filepath.Join(devDir, "device")) {
+			continue
+		}
+		dev := StorageDevice{
+			Name:       entry.Name(),
+			Model:      readSysfsString(filepath.Join(devDir, "device/model")),
+			Removable:  readSysfsString(filepath.Join(devDir, "removable")) == "1",
+			Rotational: readSysfsString(filepath.Join(devDir, "queue/rotational")) == "1",
+		}
+		// the size is always expressed in 512 byte sectors
+		if sectors, err := strconv.ParseUint(readSysfsString(filepath.Join(devDir, "size")), 10, 64); err == nil {
+			dev.Size = sectors * 512
+		}
+		devices = append(devices, dev)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+	return devices
+}
+
+func tpm() TPM {
+	matches, err := filepath.Glob(filepath.Join(dirs.GlobalRootDir, "/sys/class/tpm/tpm[0-9]*"))
+	if err != nil || len(matches) == 0 {
+		return TPM{}
+	}
+	sort.Strings(matches)
+	return TPM{
+		Present: true,
+		Version: readSysfsString(filepath.Join(matches[0], "tpm_version_major")),
+	}
+}
+
+func secureBoot() SecureBoot {
+	// 8be4df61-93ca-11d2-aa0d-00e098032b8c is the EFI Global Variable vendor GUID
+	b, _, err := efi.ReadVarBytes("SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c")
+	if err != nil {
+		if err == efi.ErrNoEFISystem {
+			return SecureBootUnsupported
+		}
+		logger.Debugf("cannot read secure boot variable: %v", err)
+		return SecureBootUnknown
+	}
+	if len(b) > 0 && b[0] == 1 {
+		return SecureBootEnabled
+	}
+	return SecureBootDisabled
+}
@@ -0,0 +1,149 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hwinfo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/bootloader/efi"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/hwinfo"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type hwinfoSuite struct {
+	testutil.BaseTest
+}
+
+var _ = Suite(&hwinfoSuite{})
+
+const secureBootVar = "SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+func (s *hwinfoSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+	s.AddCleanup(hwinfo.ResetCache)
+
+	cpuinfo := filepath.Join(c.MkDir(), "cpuinfo")
+	c.Assert(os.WriteFile(cpuinfo, []byte(`processor	: 0
+model name	: Some CPU @ 2.00GHz
+processor	: 1
+model name	: Some CPU @ 2.00GHz
+`), 0644), IsNil)
+	s.AddCleanup(osutil.MockProcCpuinfo(cpuinfo))
+
+	meminfo := filepath.Join(c.MkDir(), "meminfo")
+	c.Assert(os.WriteFile(meminfo, []byte("MemTotal:        2048 kB\n"), 0644), IsNil)
+	s.AddCleanup(osutil.MockProcMeminfo(meminfo))
+
+	s.AddCleanup(efi.MockVars(map[string][]byte{secureBootVar: {1}}, nil))
+}
+
+func (s *hwinfoSuite) mockSysfsFile(c *C, path, content string) {
+	path = filepath.Join(dirs.GlobalRootDir, path)
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), IsNil)
+	c.Assert(os.WriteFile(path, []byte(content+"\n"), 0644), IsNil)
+}
+
+func (s *hwinfoSuite) mockBlockDevice(c *C, name, model, size string, removable, rotational bool) {
+	boolStr := map[bool]string{true: "1", false: "0"}
+	s.mockSysfsFile(c, "/sys/block/"+name+"/size", size)
+	s.mockSysfsFile(c, "/sys/block/"+name+"/removable", boolStr[removable])
+	s.mockSysfsFile(c, "/sys/block/"+name+"/queue/rotational", boolStr[rotational])
+	if model != "" {
+		s.mockSysfsFile(c, "/sys/block/"+name+"/device/model", model)
+	}
+}
+
+func (s *hwinfoSuite) TestCollect(c *C) {
+	s.mockBlockDevice(c, "sda", "Some Disk", "2048", false, true)
+	s.mockBlockDevice(c, "nvme0n1", "Fast Disk", "4096", false, false)
+	s.mockBlockDevice(c, "sdb", "USB Stick", "100", true, false)
+	// virtual devices have no backing device
+	s.mockBlockDevice(c, "loop0", "", "10", false, false)
+	s.mockSysfsFile(c, "/sys/class/tpm/tpm0/tpm_version_major", "2")
+
+	info := hwinfo.Collect()
+	c.Check(info, DeepEquals, &hwinfo.Info{
+		CPU:    hwinfo.CPU{Model: "Some CPU @ 2.00GHz", Cores: 2},
+		Memory: 2048 * 1024,
+		Storage: []hwinfo.StorageDevice{
+			{Name: "nvme0n1", Model: "Fast Disk", Size: 4096 * 512},
+			{Name: "sda", Model: "Some Disk", Size: 2048 * 512, Rotational: true},
+			{Name: "sdb", Model: "USB Stick", Size: 100 * 512, Removable: true},
+		},
+		TPM:        hwinfo.TPM{Present: true, Version: "2"},
+		SecureBoot: hwinfo.SecureBootEnabled,
+	})
+}
+
+func (s *hwinfoSuite) TestCollectBestEffort(c *C) {
+	s.AddCleanup(osutil.MockProcCpuinfo(filepath.Join(c.MkDir(), "missing")))
+	s.AddCleanup(osutil.MockProcMeminfo(filepath.Join(c.MkDir(), "missing")))
+	// not an EFI system
+	s.AddCleanup(efi.MockVars(nil, nil))
+
+	info := hwinfo.Collect()
+	c.Check(info, DeepEquals, &hwinfo.Info{
+		SecureBoot: hwinfo.SecureBootUnsupported,
+	})
+}
+
+func (s *hwinfoSuite) TestCollectSecureBoot(c *C) {
+	for _, tc := range []struct {
+		vars     map[string][]byte
+		expected hwinfo.SecureBoot
+	}{
+		{map[string][]byte{secureBootVar: {1}}, hwinfo.SecureBootEnabled},
+		{map[string][]byte{secureBootVar: {0}}, hwinfo.SecureBootDisabled},
+		{map[string][]byte{}, hwinfo.SecureBootUnknown},
+		{nil, hwinfo.SecureBootUnsupported},
+	} {
+		restore := efi.MockVars(tc.vars, nil)
+		c.Check(hwinfo.Collect().SecureBoot, Equals, tc.expected, Commentf("%v", tc.vars))
+		restore()
+	}
+}
+
+func (s *hwinfoSuite) TestGetCaches(c *C) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.AddCleanup(hwinfo.MockTimeNow(func() time.Time { return now }))
+	calls := 0
+	s.AddCleanup(hwinfo.MockCollect(func() *hwinfo.Info {
+		calls++
+		return &hwinfo.Info{Memory: uint64(calls)}
+	}))
+
+	c.Check(hwinfo.Get().Memory, Equals, uint64(1))
+	now = now.Add(time.Minute)
+	c.Check(hwinfo.Get().Memory, Equals, uint64(1))
+	now = now.Add(10 * time.Minute)
+	c.Check(hwinfo.Get().Memory, Equals, uint64(2))
+	c.Check(calls, Equals, 2)
+}
@@ -0,0 +1,119 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package power reports the power supply and thermal conditions of the
+// system as exposed by the kernel in sysfs.
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/snapcore/snapd/dirs"
+)
+
+// SupplyStatus summarizes the power supplies of the system.
+type SupplyStatus struct {
+	// HasBattery is true if the system is powered by at least one
+	// battery.
+	HasBattery bool
+	// OnBattery is true if the system is currently running off its
+	// batteries, i.e. no external power source is online.
+	OnBattery bool
+	// BatteryCapacity is the lowest remaining capacity, in percent,
+	// of the batteries powering the system, or -1 if it is unknown.
+	BatteryCapacity int
+}
+
+func readSysfsAttr(dir, attr string) string {
+	content, err := os.ReadFile(filepath.Join(dir, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// Supply returns the status of the power supplies of the system, as found
+// under /sys/class/power_supply. This is the same information upower
+// uses. Batteries of peripheral devices, e.g. of a wireless mouse, are
+// ignored.
+func Supply() (*SupplyStatus, error) {
+	supplies, err := filepath.Glob(filepath.Join(dirs.SysfsDir, "class/power_supply/*"))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SupplyStatus{BatteryCapacity: -1}
+	var hasExternal, externalOnline, discharging bool
+	for _, dir := range supplies {
+		if readSysfsAttr(dir, "scope") == "Device" {
+			continue
+		}
+		if readSysfsAttr(dir, "type") != "Battery" {
+			hasExternal = true
+			if readSysfsAttr(dir, "online") == "1" {
+				externalOnline = true
+			}
+			continue
+		}
+		if readSysfsAttr(dir, "present") == "0" {
+			continue
+		}
+		status.HasBattery = true
+		if readSysfsAttr(dir, "status") == "Discharging" {
+			discharging = true
+		}
+		capacity, err := strconv.Atoi(readSysfsAttr(dir, "capacity"))
+		if err != nil {
+			continue
+		}
+		if status.BatteryCapacity < 0 || capacity < status.BatteryCapacity {
+			status.BatteryCapacity = capacity
+		}
+	}
+	status.OnBattery = status.HasBattery && !externalOnline && (hasExternal || discharging)
+	return status, nil
+}
+
+// ThermalThrottled returns true if the kernel is currently throttling the
+// processors of the system to keep them from overheating, which is
+// detected via the state of their cooling devices under
+// /sys/class/thermal.
+func ThermalThrottled() (bool, error) {
+	devices, err := filepath.Glob(filepath.Join(dirs.SysfsDir, "class/thermal/cooling_device*"))
+	if err != nil {
+		return false, err
+	}
+	for _, dir := range devices {
+		kind := readSysfsAttr(dir, "type")
+		if kind != "Processor" && !strings.Contains(kind, "cpufreq") {
+			continue
+		}
+		state, err := strconv.Atoi(readSysfsAttr(dir, "cur_state"))
+		if err != nil {
+			continue
+		}
+		if state > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,107 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package power_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil/power"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type powerSuite struct{}
+
+var _ = Suite(&powerSuite{})
+
+func (s *powerSuite) SetUpTest(c *C) {
+	dirs.SetRootDir(c.MkDir())
+}
+
+func (s *powerSuite) TearDownTest(c *C) {
+	dirs.SetRootDir("")
+}
+
+func (s *powerSuite) mockSysfsDevice(c *C, path string, attrs map[string]string) {
+	dir := filepath.Join(dirs.SysfsDir, path)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	for attr, value := range attrs {
+		c.Assert(os.WriteFile(filepath.Join(dir, attr), []byte(value+"\n"), 0644), IsNil)
+	}
+}
+
+func (s *powerSuite) TestSupplyNoSupplies(c *C) {
+	status, err := power.Supply()
+	c.Assert(err, IsNil)
+	c.Check(status, DeepEquals, &power.SupplyStatus{BatteryCapacity: -1})
+}
+
+func (s *powerSuite) TestSupplyOnBattery(c *C) {
+	s.mockSysfsDevice(c, "class/power_supply/AC", map[string]string{"type": "Mains", "online": "0"})
+	s.mockSysfsDevice(c, "class/power_supply/BAT0", map[string]string{"type": "Battery", "status": "Discharging", "capacity": "42"})
+	s.mockSysfsDevice(c, "class/power_supply/BAT1", map[string]string{"type": "Battery", "status": "Discharging", "capacity": "17"})
+	// batteries of peripherals are not considered
+	s.mockSysfsDevice(c, "class/power_supply/hidpp_battery_0", map[string]string{"type": "Battery", "scope": "Device", "capacity": "5"})
+
+	status, err := power.Supply()
+	c.Assert(err, IsNil)
+	c.Check(status, DeepEquals, &power.SupplyStatus{HasBattery: true, OnBattery: true, BatteryCapacity: 17})
+}
+
+func (s *powerSuite) TestSupplyOnMains(c *C) {
+	s.mockSysfsDevice(c, "class/power_supply/AC", map[string]string{"type": "Mains", "online": "1"})
+	s.mockSysfsDevice(c, "class/power_supply/BAT0", map[string]string{"type": "Battery", "status": "Charging", "capacity": "42"})
+
+	status, err := power.Supply()
+	c.Assert(err, IsNil)
+	c.Check(status, DeepEquals, &power.SupplyStatus{HasBattery: true, OnBattery: false, BatteryCapacity: 42})
+}
+
+func (s *powerSuite) TestSupplyBatteryOnlyDischarging(c *C) {
+	s.mockSysfsDevice(c, "class/power_supply/BAT0", map[string]string{"type": "Battery", "status": "Discharging"})
+	// batteries that are not present are ignored
+	s.mockSysfsDevice(c, "class/power_supply/BAT1", map[string]string{"type": "Battery", "present": "0", "capacity": "3"})
+
+	status, err := power.Supply()
+	c.Assert(err, IsNil)
+	c.Check(status, DeepEquals, &power.SupplyStatus{HasBattery: true, OnBattery: true, BatteryCapacity: -1})
+}
+
+func (s *powerSuite) TestThermalThrottled(c *C) {
+	throttled, err := power.ThermalThrottled()
+	c.Assert(err, IsNil)
+	c.Check(throttled, Equals, false)
+
+	s.mockSysfsDevice(c, "class/thermal/cooling_device0", map[string]string{"type": "Processor", "cur_state": "0"})
+	s.mockSysfsDevice(c, "class/thermal/cooling_device1", map[string]string{"type": "Fan", "cur_state": "3"})
+	throttled, err = power.ThermalThrottled()
+	c.Assert(err, IsNil)
+	c.Check(throttled, Equals, false)
+
+	s.mockSysfsDevice(c, "class/thermal/cooling_device2", map[string]string{"type": "cpufreq-cpu0", "cur_state": "2"})
+	throttled, err = power.ThermalThrottled()
+	c.Assert(err, IsNil)
+	c.Check(throttled, Equals, true)
+}
@@ -395,6 +395,8 @@ func delayedCrossMgrInit() {
 	snapstate.EnforceValidationSets = ApplyEnforcedValidationSets
 	// hook helper for enforcing already existing validation set assertions
 	snapstate.EnforceLocalValidationSets = ApplyLocalEnforcedValidationSets
+	// hook deriving the side info of snaps downloaded from an url
+	snapstate.DeriveSideInfoFromStore = DeriveSideInfoFromStore
 }
 
 // AutoRefreshAssertions tries to refresh all assertions
@@ -444,6 +446,20 @@ func FetchConfdbSchemaAssertion(st *state.State, userID int, account, name strin
 	})
 }
 
+// DeriveSideInfoFromStore fetches from the store the snap-revision
+// assertion matching the given digest of the snap file at snapPath together
+// with its prerequisites (snap-declaration, account etc), adds them to the
+// system database and derives the side info of the snap from them.
+func DeriveSideInfoFromStore(st *state.State, userID int, snapPath, snapSHA3_384 string, snapSize uint64, deviceCtx snapstate.DeviceContext) (*snap.SideInfo, error) {
+	err := doFetch(st, userID, deviceCtx, nil, func(f asserts.Fetcher) error {
+		return snapasserts.FetchSnapAssertions(f, snapSHA3_384, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapasserts.DeriveSideInfoFromDigestAndSize(snapPath, snapSHA3_384, snapSize, deviceCtx.Model(), cachedDB(st))
+}
+
 // refreshConfdbAssertions fetches new revisions for the assertions referenced
 // by the provided confdb schemas. It attempts a bulk refresh and if that
 // fails, it falls back to fetching the assertions one by one.
@@ -5770,6 +5770,58 @@ func (s *assertMgrSuite) TestFetchConfdbAssertion(c *C) {
 	c.Check(confdbAs.Header("name"), Equals, "my-confdb")
 }
 
+func (s *assertMgrSuite) TestDeriveSideInfoFromStore(c *C) {
+	paths, digests := s.prereqSnapAssertions(c, nil, "", 10)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// have a model and the store assertion available
+	storeAs := s.setupModelAndStore(c)
+	err := s.storeSigning.Add(storeAs)
+	c.Assert(err, IsNil)
+	deviceCtx, err := snapstate.DevicePastSeeding(s.state, nil)
+	c.Assert(err, IsNil)
+
+	_, size, err := asserts.SnapFileSHA3_384(paths[10])
+	c.Assert(err, IsNil)
+	si, err := assertstate.DeriveSideInfoFromStore(s.state, 0, paths[10], digests[10], size, deviceCtx)
+	c.Assert(err, IsNil)
+	c.Check(si, DeepEquals, &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "snap-id-1",
+		Revision: snap.R(10),
+	})
+
+	snapRev, err := assertstate.DB(s.state).Find(asserts.SnapRevisionType, map[string]string{
+		"snap-sha3-384": digests[10],
+	})
+	c.Assert(err, IsNil)
+	c.Check(snapRev.(*asserts.SnapRevision).SnapID(), Equals, "snap-id-1")
+
+	// the snap-declaration was fetched as a prerequisite
+	_, err = assertstate.DB(s.state).Find(asserts.SnapDeclarationType, map[string]string{
+		"series":  "16",
+		"snap-id": "snap-id-1",
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *assertMgrSuite) TestDeriveSideInfoFromStoreNotFound(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	storeAs := s.setupModelAndStore(c)
+	err := s.storeSigning.Add(storeAs)
+	c.Assert(err, IsNil)
+	deviceCtx, err := snapstate.DevicePastSeeding(s.state, nil)
+	c.Assert(err, IsNil)
+
+	digest := makeDigest(999)
+	_, err = assertstate.DeriveSideInfoFromStore(s.state, 0, "foo.snap", digest, 1000, deviceCtx)
+	c.Assert(err, testutil.ErrorIs, &asserts.NotFoundError{})
+}
+
 func (s *assertMgrSuite) TestConfdbAssertionsAutoRefreshBulkFetch(c *C) {
 	s.testConfdbAssertionsAutoRefresh(c)
 	c.Check(s.fakeStore.(*fakeStore).opts.Scheduled, Equals, true)
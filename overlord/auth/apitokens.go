@@ -0,0 +1,198 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// apiTokenPrefix is the prefix of the secrets of API tokens, it makes them
+// recognizable.
+const apiTokenPrefix = "snapd-api-token-"
+
+var timeNow = time.Now
+
+// APIToken is a scoped, expiring token granting access to parts of the
+// snapd API, meant for local management components which do not run as
+// root. The token can only be used by the user with the given UID. Only a
+// hash of the secret of the token is kept.
+type APIToken struct {
+	ID      string    `json:"id"`
+	Label   string    `json:"label,omitempty"`
+	Scopes  []string  `json:"scopes"`
+	UID     uint32    `json:"uid"`
+	Hash    string    `json:"hash"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+}
+
+// HasExpired returns true if the current time is past the expiration of
+// the token. Tokens without an expiration are considered expired.
+func (t *APIToken) HasExpired() bool {
+	if t.Expires.IsZero() {
+		return true
+	}
+	return !timeNow().Before(t.Expires)
+}
+
+// ErrInvalidAPIToken is returned when an API token is unknown, revoked or
+// expired.
+var ErrInvalidAPIToken = errors.New("invalid API token")
+
+// NewAPITokenParams describes the token to create with NewAPIToken.
+type NewAPITokenParams struct {
+	Label  string
+	Scopes []string
+	// UID is the user which can use the token.
+	UID uint32
+	// Expires is when the token stops being valid, it must be set.
+	Expires time.Time
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashAPITokenSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+func getAuthState(st *state.State) (*AuthState, error) {
+	var authStateData AuthState
+	err := st.Get("auth", &authStateData)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return &authStateData, nil
+}
+
+// NewAPIToken creates a new API token and returns it together with its
+// secret, which is not stored and cannot be retrieved again.
+func NewAPIToken(st *state.State, params NewAPITokenParams) (tok *APIToken, secret string, err error) {
+	if len(params.Scopes) == 0 {
+		return nil, "", fmt.Errorf("cannot create API token without scopes")
+	}
+	if params.Expires.IsZero() {
+		return nil, "", fmt.Errorf("cannot create API token without expiration")
+	}
+
+	authStateData, err := getAuthState(st)
+	if err != nil {
+		return nil, "", err
+	}
+
+	id, err := randomString(9)
+	if err != nil {
+		return nil, "", err
+	}
+	random, err := randomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+	secret = apiTokenPrefix + random
+
+	token := APIToken{
+		ID:      id,
+		Label:   params.Label,
+		Scopes:  append([]string(nil), params.Scopes...),
+		UID:     params.UID,
+		Hash:    hashAPITokenSecret(secret),
+		Created: timeNow(),
+		Expires: params.Expires,
+	}
+	// drop the expired tokens while at it
+	tokens := authStateData.APITokens[:0]
+	for _, t := range authStateData.APITokens {
+		if !t.HasExpired() {
+			tokens = append(tokens, t)
+		}
+	}
+	authStateData.APITokens = append(tokens, token)
+	st.Set("auth", authStateData)
+
+	return &token, secret, nil
+}
+
+// APITokens returns the API tokens that have not expired.
+func APITokens(st *state.State) ([]*APIToken, error) {
+	authStateData, err := getAuthState(st)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []*APIToken
+	for i := range authStateData.APITokens {
+		t := &authStateData.APITokens[i]
+		if !t.HasExpired() {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken removes the API token with the given ID.
+func RevokeAPIToken(st *state.State, id string) error {
+	authStateData, err := getAuthState(st)
+	if err != nil {
+		return err
+	}
+	for i, t := range authStateData.APITokens {
+		if t.ID == id {
+			authStateData.APITokens = append(authStateData.APITokens[:i], authStateData.APITokens[i+1:]...)
+			st.Set("auth", authStateData)
+			return nil
+		}
+	}
+	return ErrInvalidAPIToken
+}
+
+// CheckAPIToken returns the API token with the given secret, or
+// ErrInvalidAPIToken if there is no such token or it has expired.
+func CheckAPIToken(st *state.State, secret string) (*APIToken, error) {
+	authStateData, err := getAuthState(st)
+	if err != nil {
+		return nil, err
+	}
+	hash := []byte(hashAPITokenSecret(secret))
+	for i := range authStateData.APITokens {
+		t := &authStateData.APITokens[i]
+		if subtle.ConstantTimeCompare([]byte(t.Hash), hash) != 1 {
+			continue
+		}
+		if t.HasExpired() {
+			return nil, ErrInvalidAPIToken
+		}
+		return t, nil
+	}
+	return nil, ErrInvalidAPIToken
+}
@@ -0,0 +1,167 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package auth_test
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/auth"
+)
+
+func (as *authSuite) TestNewAPIToken(c *C) {
+	as.state.Lock()
+	defer as.state.Unlock()
+
+	expires := time.Now().Add(time.Hour)
+	tok, secret, err := auth.NewAPIToken(as.state, auth.NewAPITokenParams{
+		Label:   "fleet-agent",
+		Scopes:  []string{"read:/v2/snaps"},
+		UID:     1000,
+		Expires: expires,
+	})
+	c.Assert(err, IsNil)
+	c.Check(tok.ID, Not(Equals), "")
+	c.Check(tok.Label, Equals, "fleet-agent")
+	c.Check(tok.Scopes, DeepEquals, []string{"read:/v2/snaps"})
+	c.Check(tok.UID, Equals, uint32(1000))
+	c.Check(tok.Expires.Equal(expires), Equals, true)
+	c.Check(strings.HasPrefix(secret, "snapd-api-token-"), Equals, true)
+	// only a hash of the secret is stored
+	c.Check(tok.Hash, Not(Equals), "")
+	c.Check(strings.Contains(tok.Hash, secret), Equals, false)
+
+	tokens, err := auth.APITokens(as.state)
+	c.Assert(err, IsNil)
+	c.Assert(tokens, HasLen, 1)
+	c.Check(tokens[0].ID, Equals, tok.ID)
+
+	checked, err := auth.CheckAPIToken(as.state, secret)
+	c.Assert(err, IsNil)
+	c.Check(checked.ID, Equals, tok.ID)
+}
+
+func (as *authSuite) TestNewAPITokenNoScopes(c *C) {
+	as.state.Lock()
+	defer as.state.Unlock()
+
+	_, _, err := auth.NewAPIToken(as.state, auth.NewAPITokenParams{Label: "foo", Expires: time.Now().Add(time.Hour)})
+	c.Check(err, ErrorMatches, "cannot create API token without scopes")
+}
+
+func (as *authSuite) TestNewAPITokenNoExpiration(c *C) {
+	as.state.Lock()
+	defer as.state.Unlock()
+
+	_, _, err := auth.NewAPIToken(as.state, auth.NewAPITokenParams{Scopes: []string{"read:/v2/snaps"}})
+	c.Check(err, ErrorMatches, "cannot create API token without expiration")
+}
+
+func (as *authSuite) TestNewAPITokenKeepsUsers(c *C) {
+	as.state.Lock()
+	defer as.state.Unlock()
+
+	user, err := auth.NewUser(as.state, auth.NewUserParams{
+		Username:   "username",
+		Email:      "email@test.com",
+		Macaroon:   "macaroon",
+		Discharges: []string{"discharge"},
+	})
+	c.Assert(err, IsNil)
+
+	_, _, err = auth.NewAPIToken(as.state, auth.NewAPITokenParams{Scopes: []string{"read:/v2/snaps"}, Expires: time.Now().Add(time.Hour)})
+	c.Assert(err, IsNil)
+
+	u, err := auth.User(as.state, user.ID)
+	c.Assert(err, IsNil)
+	c.Check(u.Username, Equals, "username")
+}
+
+func (as *authSuite) TestCheckAPITokenInvalid(c *C) {
+	as.state.Lock()
+	defer as.state.Unlock()
+
+	_, err := auth.CheckAPIToken(as.state, "snapd-api-token-unknown")
+	c.Check(err, Equals, auth.ErrInvalidAPIToken)
+
+	_, _, err = auth.NewAPIToken(as.state, auth.NewAPITokenParams{Scopes: []string{"read:/v2/snaps"}, Expires: time.Now().Add(time.Hour)})
+	c.Assert(err, IsNil)
+
+	_, err = auth.CheckAPIToken(as.state, "snapd-api-token-unknown")
+	c.Check(err, Equals, auth.ErrInvalidAPIToken)
+}
+
+func (as *authSuite) TestCheckAPITokenExpired(c *C) {
+	as.state.Lock()
+	defer as.state.Unlock()
+
+	now := time.Now()
+	restore := auth.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	tok, secret, err := auth.NewAPIToken(as.state, auth.NewAPITokenParams{
+		Scopes:  []string{"write:/v2/snaps"},
+		Expires: now.Add(time.Minute),
+	})
+	c.Assert(err, IsNil)
+	_, expiresLater, err := auth.NewAPIToken(as.state, auth.NewAPITokenParams{
+		Scopes:  []string{"read:/v2/snaps"},
+		Expires: now.Add(time.Hour),
+	})
+	c.Assert(err, IsNil)
+
+	_, err = auth.CheckAPIToken(as.state, secret)
+	c.Check(err, IsNil)
+
+	now = now.Add(time.Minute)
+
+	_, err = auth.CheckAPIToken(as.state, secret)
+	c.Check(err, Equals, auth.ErrInvalidAPIToken)
+	_, err = auth.CheckAPIToken(as.state, expiresLater)
+	c.Check(err, IsNil)
+
+	tokens, err := auth.APITokens(as.state)
+	c.Assert(err, IsNil)
+	c.Assert(tokens, HasLen, 1)
+	c.Check(tokens[0].ID, Not(Equals), tok.ID)
+}
+
+func (as *authSuite) TestRevokeAPIToken(c *C) {
+	as.state.Lock()
+	defer as.state.Unlock()
+
+	tok, secret, err := auth.NewAPIToken(as.state, auth.NewAPITokenParams{Scopes: []string{"read:/v2/snaps"}, Expires: time.Now().Add(time.Hour)})
+	c.Assert(err, IsNil)
+
+	err = auth.RevokeAPIToken(as.state, tok.ID)
+	c.Assert(err, IsNil)
+
+	_, err = auth.CheckAPIToken(as.state, secret)
+	c.Check(err, Equals, auth.ErrInvalidAPIToken)
+
+	tokens, err := auth.APITokens(as.state)
+	c.Assert(err, IsNil)
+	c.Check(tokens, HasLen, 0)
+
+	err = auth.RevokeAPIToken(as.state, tok.ID)
+	c.Check(err, Equals, auth.ErrInvalidAPIToken)
+}
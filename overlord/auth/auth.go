@@ -40,6 +40,7 @@ type AuthState struct {
 	Users       []UserState  `json:"users"`
 	Device      *DeviceState `json:"device,omitempty"`
 	MacaroonKey []byte       `json:"macaroon-key,omitempty"`
+	APITokens   []APIToken   `json:"api-tokens,omitempty"`
 }
 
 // DeviceState represents the device's identity and store credentials
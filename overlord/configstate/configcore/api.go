@@ -0,0 +1,48 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func init() {
+	supportedConfigurations["core.api.max-changes"] = true
+	supportedConfigurations["core.api.rate-limit"] = true
+}
+
+func validateAPILimits(tr RunTransaction) error {
+	for _, opt := range []string{"api.max-changes", "api.rate-limit"} {
+		value, err := coreCfg(tr, opt)
+		if err != nil {
+			return err
+		}
+		// reset is fine
+		if value == "" {
+			continue
+		}
+		if n, err := strconv.ParseUint(value, 10, 32); err != nil || n == 0 {
+			return fmt.Errorf("%s must be a positive number, not %q", opt, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type apiSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&apiSuite{})
+
+func (s *apiSuite) TestConfigureAPILimitsHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"api.max-changes": "10",
+			"api.rate-limit":  60,
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *apiSuite) TestConfigureAPILimitsUnset(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"api.max-changes": "",
+			"api.rate-limit":  "",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *apiSuite) TestConfigureAPILimitsInvalid(c *C) {
+	for _, tc := range []struct {
+		opt   string
+		value any
+	}{
+		{"api.max-changes", "0"},
+		{"api.max-changes", "-1"},
+		{"api.max-changes", "lots"},
+		{"api.rate-limit", 0},
+		{"api.rate-limit", "1.5"},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				tc.opt: tc.value,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.opt+` must be a positive number, not ".*"`, Commentf("%v", tc))
+	}
+}
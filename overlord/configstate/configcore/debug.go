@@ -35,8 +35,10 @@ import (
 const (
 	optionDebugSnapdLog            = "debug.snapd.log"
 	optionDebugSystemdLogLevel     = "debug.systemd.log-level"
+	optionDebugSnapdProfiling      = "debug.snapd.profiling"
 	coreOptionDebugSnapdLog        = "core." + optionDebugSnapdLog
 	coreOptionDebugSystemdLogLevel = "core." + optionDebugSystemdLogLevel
+	coreOptionDebugSnapdProfiling  = "core." + optionDebugSnapdProfiling
 )
 
 var loggerSimpleSetup = logger.SimpleSetup
@@ -44,6 +46,7 @@ var loggerSimpleSetup = logger.SimpleSetup
 func init() {
 	supportedConfigurations[coreOptionDebugSnapdLog] = true
 	supportedConfigurations[coreOptionDebugSystemdLogLevel] = true
+	supportedConfigurations[coreOptionDebugSnapdProfiling] = true
 }
 
 func validateDebugSnapdLogSetting(tr RunTransaction) error {
@@ -100,6 +103,13 @@ func handleDebugSnapdLogConfiguration(tr RunTransaction, opts *fsOnlyContext) er
 	return nil
 }
 
+// validateDebugSnapdProfilingSetting validates the option which enables the
+// profiling and tracing endpoints of the snapd API. The option is read
+// directly by the daemon on each request, so no handler is needed.
+func validateDebugSnapdProfilingSetting(tr RunTransaction) error {
+	return validateBoolFlag(tr, optionDebugSnapdProfiling)
+}
+
 func validateDebugSystemdLogLevelSetting(tr RunTransaction) error {
 	value, err := coreCfg(tr, optionDebugSystemdLogLevel)
 	if err != nil {
@@ -121,6 +121,25 @@ func (s *debugSuite) TestConfigureDebugSnapdLogBadVals(c *C) {
 	}
 }
 
+func (s *debugSuite) TestConfigureDebugSnapdProfiling(c *C) {
+	for _, val := range []string{"true", "false", ""} {
+		err := configcore.Run(coreDev, &mockConf{
+			state:   s.state,
+			changes: map[string]any{"debug.snapd.profiling": val},
+		})
+		c.Check(err, IsNil)
+	}
+
+	for _, val := range []string{"1", "foo"} {
+		err := configcore.Run(coreDev, &mockConf{
+			state:   s.state,
+			changes: map[string]any{"debug.snapd.profiling": val},
+		})
+		c.Check(err, ErrorMatches,
+			"debug.snapd.profiling can only be set to 'true' or 'false'")
+	}
+}
+
 func (s *debugSuite) TestConfigureSystemdLogLevelGoodVals(c *C) {
 	var systemctlArgs []string
 	numCalls := 0
@@ -0,0 +1,73 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/snap/naming"
+)
+
+const hookEnvPrefix = "core." + hookstate.HookEnvConfigKey + "."
+
+func isHookEnvChange(key string) bool {
+	return strings.HasPrefix(key, hookEnvPrefix)
+}
+
+// validateHookEnvSettings checks the hooks.env.<snap-name>.<name>
+// options, the actual injection into the hook environment happens when
+// the hooks of the snap are run.
+func validateHookEnvSettings(tr RunTransaction) error {
+	for _, name := range tr.Changes() {
+		if !isHookEnvChange(name) {
+			continue
+		}
+		option := strings.TrimPrefix(name, "core.")
+
+		var value any
+		if err := tr.Get("core", option, &value); err != nil && !config.IsNoOption(err) {
+			return err
+		}
+		if value == nil {
+			// unset
+			continue
+		}
+
+		subkeys := strings.Split(strings.TrimPrefix(name, hookEnvPrefix), ".")
+		if len(subkeys) != 2 {
+			return fmt.Errorf("cannot set %q: expected %s.<snap>.<variable>", option, hookstate.HookEnvConfigKey)
+		}
+		if err := naming.ValidateSnap(subkeys[0]); err != nil {
+			return fmt.Errorf("cannot set %q: %v", option, err)
+		}
+		if _, err := hookstate.HookEnvVarName(subkeys[1]); err != nil {
+			return fmt.Errorf("cannot set %q: %v", option, err)
+		}
+		switch value.(type) {
+		case map[string]any, []any:
+			return fmt.Errorf("cannot set %q: value must be a string, number or boolean", option)
+		}
+	}
+	return nil
+}
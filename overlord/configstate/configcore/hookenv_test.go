@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type hookEnvSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&hookEnvSuite{})
+
+func (s *hookEnvSuite) SetUpTest(c *C) {
+	s.configcoreSuite.SetUpTest(c)
+
+	err := os.MkdirAll(filepath.Join(dirs.GlobalRootDir, "/etc/"), 0755)
+	c.Assert(err, IsNil)
+
+	err = os.WriteFile(filepath.Join(dirs.GlobalRootDir, "/etc/environment"), nil, 0644)
+	c.Assert(err, IsNil)
+}
+
+func (s *hookEnvSuite) TestConfigureHookEnvHappy(c *C) {
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"hooks.env.my-gadget.site-id":    "site-1",
+			"hooks.env.my-gadget.site-index": 2,
+			"hooks.env.other-foo.debug":      true,
+			// unset
+			"hooks.env.my-app": nil,
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *hookEnvSuite) TestConfigureHookEnvInvalid(c *C) {
+	for _, tc := range []struct {
+		key   string
+		value any
+		err   string
+	}{
+		{"hooks.env.my-gadget", "foo", `cannot set "hooks.env.my-gadget": expected hooks.env.<snap>.<variable>`},
+		{"hooks.env.my-gadget.site.id", "foo", `cannot set "hooks.env.my-gadget.site.id": expected hooks.env.<snap>.<variable>`},
+		{"hooks.env.My-Gadget.site-id", "foo", `cannot set "hooks.env.My-Gadget.site-id": invalid snap name: "My-Gadget"`},
+		{"hooks.env.my-gadget_foo.site-id", "foo", `cannot set "hooks.env.my-gadget_foo.site-id": invalid snap name: "my-gadget_foo"`},
+		{"hooks.env.my-gadget.snap-name", "foo", `cannot set "hooks.env.my-gadget.snap-name": cannot use reserved hook environment variable "SNAP_NAME"`},
+		{"hooks.env.my-gadget.path", "foo", `cannot set "hooks.env.my-gadget.path": cannot use reserved hook environment variable "PATH"`},
+		{"hooks.env.my-gadget.site-id", []any{"a"}, `cannot set "hooks.env.my-gadget.site-id": value must be a string, number or boolean`},
+	} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			changes: map[string]any{
+				tc.key: tc.value,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%s", tc.key))
+	}
+}
@@ -107,7 +107,7 @@ func validateCmdlineAppend(c RunTransaction) error {
 				return err
 			}
 		} else { // OptionKernelDangerousCmdlineAppend
-			if devCtx.Model().Grade() != asserts.ModelDangerous {
+			if devicestate.EffectiveModelGrade(st, devCtx) != asserts.ModelDangerous {
 				// TODO we should return an error if this is an API call
 				// and do nothing if setting defaults (so gadget can be
 				// reused with different models).
@@ -181,7 +181,7 @@ func isDangerousModel(st *state.State) (bool, error) {
 		return false, err
 	}
 
-	return devCtx.Model().Grade() == asserts.ModelDangerous, nil
+	return devicestate.EffectiveModelGrade(st, devCtx) == asserts.ModelDangerous, nil
 }
 
 func handleCmdlineAppend(c RunTransaction, opts *fsOnlyContext) error {
@@ -0,0 +1,193 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
+)
+
+const nameResolutionPrefix = "core." + ifacestate.NameResolutionConfigKey + "."
+
+func isNameResolutionChange(key string) bool {
+	return strings.HasPrefix(key, nameResolutionPrefix)
+}
+
+// nameResolutionChangedSnaps returns the snaps whose
+// name-resolution.<instance-name>.* options were changed.
+func nameResolutionChangedSnaps(tr RunTransaction) []string {
+	var snaps []string
+	seen := make(map[string]bool)
+	for _, name := range tr.Changes() {
+		if !isNameResolutionChange(name) {
+			continue
+		}
+		instanceName := strings.Split(strings.TrimPrefix(name, nameResolutionPrefix), ".")[0]
+		if !seen[instanceName] {
+			seen[instanceName] = true
+			snaps = append(snaps, instanceName)
+		}
+	}
+	sort.Strings(snaps)
+	return snaps
+}
+
+func validateNameResolutionSetting(option, setting string, value any) error {
+	if value == nil {
+		// unset
+		return nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("cannot set %q: value must be a string", option)
+	}
+	var err error
+	switch setting {
+	case "nameservers":
+		_, err = ifacestate.ParseNameservers(str)
+	case "hosts":
+		_, err = ifacestate.ParseHosts(str)
+	default:
+		return fmt.Errorf("cannot set %q: unsupported name resolution setting %q", option, setting)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot set %q: %v", option, err)
+	}
+	return nil
+}
+
+// validateNameResolutionSettings checks the
+// name-resolution.<instance-name>.{nameservers,hosts} options, the files
+// bind mounted into the snap are written when its security profiles are
+// set up.
+func validateNameResolutionSettings(tr RunTransaction) error {
+	for _, name := range tr.Changes() {
+		if !isNameResolutionChange(name) {
+			continue
+		}
+		option := strings.TrimPrefix(name, "core.")
+
+		var value any
+		if err := tr.Get("core", option, &value); err != nil && !config.IsNoOption(err) {
+			return err
+		}
+
+		subkeys := strings.Split(strings.TrimPrefix(name, nameResolutionPrefix), ".")
+		if err := naming.ValidateInstance(subkeys[0]); err != nil {
+			return fmt.Errorf("cannot set %q: %v", option, err)
+		}
+		switch len(subkeys) {
+		case 1:
+			if value == nil {
+				// unset
+				continue
+			}
+			settings, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("cannot set %q: value must be a map of name resolution settings", option)
+			}
+			for setting, v := range settings {
+				if err := validateNameResolutionSetting(option+"."+setting, setting, v); err != nil {
+					return err
+				}
+			}
+		case 2:
+			if err := validateNameResolutionSetting(option, subkeys[1], value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("cannot set %q: expected %s.<snap>.{nameservers,hosts}", option, ifacestate.NameResolutionConfigKey)
+		}
+	}
+	return nil
+}
+
+// handleNameResolutionConfiguration sets up again the security profiles of
+// the installed snaps whose name resolution settings changed, once the
+// configuration is committed.
+func handleNameResolutionConfiguration(tr RunTransaction, opts *fsOnlyContext) error {
+	snaps := nameResolutionChangedSnaps(tr)
+	if len(snaps) == 0 {
+		return nil
+	}
+	task := tr.Task()
+	if task == nil {
+		// the settings are applied when the profiles of the snaps
+		// are set up next
+		return nil
+	}
+
+	st := tr.State()
+	st.Lock()
+	defer st.Unlock()
+
+	ts := state.NewTaskSet()
+	var prev *state.Task
+	for _, instanceName := range snaps {
+		var snapst snapstate.SnapState
+		err := snapstate.Get(st, instanceName, &snapst)
+		// not installed, the settings are applied when the snap
+		// gets installed
+		if errors.Is(err, state.ErrNoState) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !snapst.Active {
+			continue
+		}
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			return err
+		}
+
+		setupProfiles := st.NewTask("setup-profiles", fmt.Sprintf(i18n.G("Update snap %q (%s) security profiles"), instanceName, info.Revision))
+		setupProfiles.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo: &snap.SideInfo{
+				RealName: info.SnapName(),
+				Revision: info.Revision,
+			},
+			InstanceKey: info.InstanceKey,
+			Type:        info.Type(),
+			Flags:       snapst.Flags,
+		})
+		if prev != nil {
+			setupProfiles.WaitFor(prev)
+		}
+		ts.AddTask(setupProfiles)
+		prev = setupProfiles
+	}
+	if len(ts.Tasks()) > 0 {
+		snapstate.InjectTasks(task, ts)
+	}
+	return nil
+}
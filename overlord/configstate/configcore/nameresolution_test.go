@@ -0,0 +1,162 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type nameResolutionSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&nameResolutionSuite{})
+
+func (s *nameResolutionSuite) SetUpTest(c *C) {
+	s.configcoreSuite.SetUpTest(c)
+
+	err := os.MkdirAll(filepath.Join(dirs.GlobalRootDir, "/etc/"), 0755)
+	c.Assert(err, IsNil)
+
+	err = os.WriteFile(filepath.Join(dirs.GlobalRootDir, "/etc/environment"), nil, 0644)
+	c.Assert(err, IsNil)
+}
+
+func (s *nameResolutionSuite) TestConfigureNameResolutionHappy(c *C) {
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"name-resolution.foo.nameservers": "10.0.0.1,fd00::1",
+			"name-resolution.foo.hosts":       "db.internal=10.0.0.5",
+			"name-resolution.bar_baz": map[string]any{
+				"nameservers": "10.0.0.2",
+			},
+			// unset
+			"name-resolution.other": nil,
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *nameResolutionSuite) TestConfigureNameResolutionInvalid(c *C) {
+	for _, tc := range []struct {
+		key   string
+		value any
+		err   string
+	}{
+		{"name-resolution.foo", "10.0.0.1", `cannot set "name-resolution.foo": value must be a map of name resolution settings`},
+		{"name-resolution.foo.search", "internal", `cannot set "name-resolution.foo.search": unsupported name resolution setting "search"`},
+		{"name-resolution.foo.hosts.db", "10.0.0.1", `cannot set "name-resolution.foo.hosts.db": expected name-resolution.<snap>.{nameservers,hosts}`},
+		{"name-resolution.Foo.nameservers", "10.0.0.1", `cannot set "name-resolution.Foo.nameservers": invalid snap name: "Foo"`},
+		{"name-resolution.foo.nameservers", "dns.internal", `cannot set "name-resolution.foo.nameservers": invalid nameserver address "dns.internal"`},
+		{"name-resolution.foo.nameservers", 1, `cannot set "name-resolution.foo.nameservers": value must be a string`},
+		{"name-resolution.foo.hosts", "db.internal", `cannot set "name-resolution.foo.hosts": invalid hosts entry "db.internal": expected <name>=<address>`},
+		{"name-resolution.foo", map[string]any{"hosts": "db=x"}, `cannot set "name-resolution.foo.hosts": invalid address "x" for host "db"`},
+	} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			changes: map[string]any{
+				tc.key: tc.value,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%s", tc.key))
+	}
+}
+
+func (s *nameResolutionSuite) TestConfigureNameResolutionSetsUpProfiles(c *C) {
+	si := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1)}
+	snaptest.MockSnap(c, mockSnapWithService, si)
+
+	s.state.Lock()
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  snap.R(1),
+		Active:   true,
+		SnapType: "app",
+		Flags:    snapstate.Flags{DevMode: true},
+	})
+	hook := s.state.NewTask("run-hook", "configure")
+	chg := s.state.NewChange("configure", "...")
+	chg.AddTask(hook)
+	s.state.Unlock()
+
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		task:  hook,
+		changes: map[string]any{
+			"name-resolution.test-snap.nameservers": "10.0.0.1",
+			// not installed
+			"name-resolution.other-snap.nameservers": "10.0.0.1",
+		},
+	})
+	c.Assert(err, IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	tasks := chg.Tasks()
+	c.Assert(tasks, HasLen, 2)
+	setupProfiles := tasks[1]
+	c.Check(setupProfiles.Kind(), Equals, "setup-profiles")
+	c.Check(setupProfiles.Summary(), Equals, `Update snap "test-snap" (1) security profiles`)
+	c.Check(setupProfiles.WaitTasks(), DeepEquals, []*state.Task{hook})
+	snapsup, err := snapstate.TaskSnapSetup(setupProfiles)
+	c.Assert(err, IsNil)
+	c.Check(snapsup.InstanceName(), Equals, "test-snap")
+	c.Check(snapsup.Revision(), Equals, snap.R(1))
+	c.Check(snapsup.DevMode, Equals, true)
+}
+
+func (s *nameResolutionSuite) TestConfigureNameResolutionNoTask(c *C) {
+	si := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1)}
+	snaptest.MockSnap(c, mockSnapWithService, si)
+
+	s.state.Lock()
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  snap.R(1),
+		Active:   true,
+		SnapType: "app",
+	})
+	s.state.Unlock()
+
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"name-resolution.test-snap.nameservers": "10.0.0.1",
+		},
+	})
+	c.Assert(err, IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Tasks(), HasLen, 0)
+}
@@ -0,0 +1,45 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.power-guard.min-battery"] = true
+	supportedConfigurations["core.power-guard.thermal"] = true
+}
+
+func validatePowerGuard(tr RunTransaction) error {
+	minBatteryStr, err := coreCfg(tr, "power-guard.min-battery")
+	if err != nil {
+		return err
+	}
+	if minBatteryStr != "" {
+		if n, err := strconv.ParseUint(minBatteryStr, 10, 8); err != nil || n > 100 {
+			return fmt.Errorf("power-guard.min-battery must be a percentage between 0 and 100, not %q", minBatteryStr)
+		}
+	}
+	return validateBoolFlag(tr, "power-guard.thermal")
+}
@@ -0,0 +1,62 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type powerGuardSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&powerGuardSuite{})
+
+func (s *powerGuardSuite) TestConfigurePowerGuardHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"power-guard.min-battery": "30",
+			"power-guard.thermal":     true,
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *powerGuardSuite) TestConfigurePowerGuardInvalid(c *C) {
+	for _, tc := range []struct {
+		conf map[string]any
+		err  string
+	}{
+		{map[string]any{"power-guard.min-battery": "101"}, `power-guard.min-battery must be a percentage between 0 and 100, not "101"`},
+		{map[string]any{"power-guard.min-battery": "-1"}, `power-guard.min-battery must be a percentage between 0 and 100, not "-1"`},
+		{map[string]any{"power-guard.min-battery": "low"}, `power-guard.min-battery must be a percentage between 0 and 100, not "low"`},
+		{map[string]any{"power-guard.thermal": "yes"}, `power-guard.thermal can only be set to 'true' or 'false'`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  tc.conf,
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.conf))
+	}
+}
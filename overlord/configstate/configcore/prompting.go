@@ -21,11 +21,15 @@
 package configcore
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/features"
+	"github.com/snapcore/snapd/interfaces/prompting"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/restart"
@@ -34,8 +38,16 @@ import (
 	"github.com/snapcore/snapd/overlord/swfeats"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
+func init() {
+	supportedConfigurations["core.prompting.remote-approver.url"] = true
+	supportedConfigurations["core.prompting.remote-approver.public-key"] = true
+	supportedConfigurations["core.prompting.timeout"] = true
+	supportedConfigurations["core.prompting.timeout-allow"] = true
+}
+
 var restartRequest = restart.Request
 
 var servicestateControl = servicestate.Control
@@ -155,3 +167,66 @@ func doExperimentalApparmorPromptingDaemonRestart(c RunTransaction, opts *fsOnly
 
 	return nil
 }
+
+// validatePromptingRemoteApprover validates the options used to forward
+// prompts to a remote approver service. The URL must use https and the public
+// key, used to verify the replies of the approver, must be a base64-encoded
+// ed25519 public key.
+func validatePromptingRemoteApprover(tr RunTransaction) error {
+	approverURL, err := coreCfg(tr, "prompting.remote-approver.url")
+	if err != nil {
+		return err
+	}
+	if approverURL != "" {
+		u, err := url.Parse(approverURL)
+		if err != nil {
+			return fmt.Errorf("prompting.remote-approver.url is invalid: %v", err)
+		}
+		if u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("prompting.remote-approver.url must be an https URL, not %q", approverURL)
+		}
+	}
+
+	encodedKey, err := coreCfg(tr, "prompting.remote-approver.public-key")
+	if err != nil {
+		return err
+	}
+	if encodedKey != "" {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("prompting.remote-approver.public-key must be a base64-encoded ed25519 public key")
+		}
+	}
+	return nil
+}
+
+// validatePromptingTimeout validates the policy resolving prompts which are
+// not answered in time: prompting.timeout is the age after which prompts
+// are denied, unless their interface is listed in the comma-separated
+// prompting.timeout-allow, in which case they are allowed.
+func validatePromptingTimeout(tr RunTransaction) error {
+	timeout, err := coreCfg(tr, "prompting.timeout")
+	if err != nil {
+		return err
+	}
+	if timeout != "" {
+		dur, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("prompting.timeout cannot be parsed: %v", err)
+		}
+		if dur < time.Second {
+			return fmt.Errorf("prompting.timeout must be at least 1s")
+		}
+	}
+
+	allow, err := coreCfg(tr, "prompting.timeout-allow")
+	if err != nil {
+		return err
+	}
+	for _, iface := range strutil.CommaSeparatedList(allow) {
+		if _, err := prompting.AvailablePermissions(iface); err != nil {
+			return fmt.Errorf("prompting.timeout-allow contains unsupported interface %q", iface)
+		}
+	}
+	return nil
+}
@@ -20,6 +20,8 @@
 package configcore_test
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"os"
@@ -647,3 +649,54 @@ plugs:
 
 	s.state.Set("conns", conns)
 }
+
+func (s *promptingSuite) TestValidatePromptingRemoteApprover(c *C) {
+	validKey := base64.StdEncoding.EncodeToString(make([]byte, ed25519.PublicKeySize))
+	for _, tc := range []struct {
+		changes map[string]any
+		err     string
+	}{
+		{map[string]any{"prompting.remote-approver.url": "https://approver.example.com/prompts"}, ""},
+		{map[string]any{"prompting.remote-approver.public-key": validKey}, ""},
+		{map[string]any{"prompting.remote-approver.url": "", "prompting.remote-approver.public-key": ""}, ""},
+		{map[string]any{"prompting.remote-approver.url": "http://approver.example.com"}, `prompting.remote-approver.url must be an https URL, not "http://approver.example.com"`},
+		{map[string]any{"prompting.remote-approver.url": "https://"}, `prompting.remote-approver.url must be an https URL, not "https://"`},
+		{map[string]any{"prompting.remote-approver.url": "https://[::1"}, `prompting.remote-approver.url is invalid: .*`},
+		{map[string]any{"prompting.remote-approver.public-key": "not base64!"}, `prompting.remote-approver.public-key must be a base64-encoded ed25519 public key`},
+		{map[string]any{"prompting.remote-approver.public-key": base64.StdEncoding.EncodeToString([]byte("short"))}, `prompting.remote-approver.public-key must be a base64-encoded ed25519 public key`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state:   s.state,
+			changes: tc.changes,
+		})
+		if tc.err == "" {
+			c.Check(err, IsNil, Commentf("%v", tc.changes))
+		} else {
+			c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.changes))
+		}
+	}
+}
+
+func (s *promptingSuite) TestValidatePromptingTimeout(c *C) {
+	for _, tc := range []struct {
+		changes map[string]any
+		err     string
+	}{
+		{map[string]any{"prompting.timeout": "5m"}, ""},
+		{map[string]any{"prompting.timeout": ""}, ""},
+		{map[string]any{"prompting.timeout": "5m", "prompting.timeout-allow": "home"}, ""},
+		{map[string]any{"prompting.timeout": "forever"}, `prompting.timeout cannot be parsed: .*`},
+		{map[string]any{"prompting.timeout": "500ms"}, `prompting.timeout must be at least 1s`},
+		{map[string]any{"prompting.timeout-allow": "home,camera"}, `prompting.timeout-allow contains unsupported interface "camera"`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state:   s.state,
+			changes: tc.changes,
+		})
+		if tc.err == "" {
+			c.Check(err, IsNil, Commentf("%v", tc.changes))
+		} else {
+			c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.changes))
+		}
+	}
+}
@@ -22,10 +22,15 @@ package configcore
 
 import (
 	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/timeutil"
 )
@@ -33,6 +38,9 @@ import (
 const (
 	minInhibitionDays = 1
 	maxInhibitionDays = 21
+
+	minDownloadConcurrency = 1
+	maxDownloadConcurrency = 16
 )
 
 func init() {
@@ -43,6 +51,13 @@ func init() {
 	supportedConfigurations["core.refresh.retain"] = true
 	supportedConfigurations["core.refresh.rate-limit"] = true
 	supportedConfigurations["core.refresh.max-inhibition-days"] = true
+	supportedConfigurations["core.refresh.policy"] = true
+	supportedConfigurations["core.refresh.maintenance-window"] = true
+	supportedConfigurations["core.refresh.prefetch-window"] = true
+	supportedConfigurations["core.refresh.download-concurrency"] = true
+	supportedConfigurations["core.refresh.webhook.url"] = true
+	supportedConfigurations["core.refresh.webhook.secret"] = true
+	supportedConfigurations["core.refresh.webhook.change-kinds"] = true
 }
 
 func reportOrIgnoreInvalidManageRefreshes(tr RunTransaction, optName string) error {
@@ -58,6 +73,69 @@ func reportOrIgnoreInvalidManageRefreshes(tr RunTransaction, optName string) err
 	return nil
 }
 
+const retainPerSnapPrefix = "core." + snapstate.RetainPerSnapConfigKey + "."
+
+func isRetainPerSnapChange(key string) bool {
+	return key == "core."+snapstate.RetainPerSnapConfigKey || strings.HasPrefix(key, retainPerSnapPrefix)
+}
+
+func validateRetainValue(option string, value any) error {
+	var str string
+	switch v := value.(type) {
+	case nil:
+		// unset
+		return nil
+	case string:
+		str = v
+	default:
+		str = fmt.Sprint(v)
+	}
+	if n, err := strconv.ParseUint(str, 10, 8); err != nil || (n < 2 || n > 20) {
+		return fmt.Errorf("%s must be a number between 2 and 20, not %q", option, str)
+	}
+	return nil
+}
+
+// validateRetainPerSnap checks the refresh.retain-per-snap.<snap-name>
+// options overriding refresh.retain for specific snaps.
+func validateRetainPerSnap(tr RunTransaction) error {
+	for _, name := range tr.Changes() {
+		if !isRetainPerSnapChange(name) {
+			continue
+		}
+		option := strings.TrimPrefix(name, "core.")
+
+		var value any
+		if err := tr.Get("core", option, &value); err != nil && !config.IsNoOption(err) {
+			return err
+		}
+
+		var perSnap map[string]any
+		if option == snapstate.RetainPerSnapConfigKey {
+			if value == nil {
+				continue
+			}
+			m, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("cannot set %q: value must be a map of snap names to the number of revisions to retain", option)
+			}
+			perSnap = m
+		} else {
+			perSnap = map[string]any{strings.TrimPrefix(name, retainPerSnapPrefix): value}
+		}
+		for snapName, v := range perSnap {
+			snapOption := snapstate.RetainPerSnapConfigKey + "." + snapName
+			if err := naming.ValidateSnap(snapName); err != nil {
+				return fmt.Errorf("cannot set %q: %v", snapOption, err)
+			}
+			if err := validateRetainValue(snapOption, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func validateRefreshSchedule(tr RunTransaction) error {
 	maxInhibitionDaysStr, err := coreCfg(tr, "refresh.max-inhibition-days")
 	if err != nil {
@@ -78,6 +156,20 @@ func validateRefreshSchedule(tr RunTransaction) error {
 		}
 	}
 
+	if err := validateRetainPerSnap(tr); err != nil {
+		return err
+	}
+
+	downloadConcurrencyStr, err := coreCfg(tr, "refresh.download-concurrency")
+	if err != nil {
+		return err
+	}
+	if downloadConcurrencyStr != "" {
+		if n, err := strconv.ParseUint(downloadConcurrencyStr, 10, 8); err != nil || (n < minDownloadConcurrency || n > maxDownloadConcurrency) {
+			return fmt.Errorf("download-concurrency must be a number between %d and %d, not %q", minDownloadConcurrency, maxDownloadConcurrency, downloadConcurrencyStr)
+		}
+	}
+
 	refreshHoldStr, err := coreCfg(tr, "refresh.hold")
 	if err != nil {
 		return err
@@ -99,6 +191,37 @@ func validateRefreshSchedule(tr RunTransaction) error {
 		return fmt.Errorf("refresh.metered value %q is invalid", refreshOnMeteredStr)
 	}
 
+	refreshPolicyStr, err := coreCfg(tr, "refresh.policy")
+	if err != nil {
+		return err
+	}
+	switch refreshPolicyStr {
+	case "", "all", "security-only":
+		// noop
+	default:
+		return fmt.Errorf("refresh.policy value %q is invalid", refreshPolicyStr)
+	}
+
+	maintenanceWindowStr, err := coreCfg(tr, "refresh.maintenance-window")
+	if err != nil {
+		return err
+	}
+	if maintenanceWindowStr != "" {
+		if _, err := timeutil.ParseSchedule(maintenanceWindowStr); err != nil {
+			return fmt.Errorf("refresh.maintenance-window cannot be parsed: %v", err)
+		}
+	}
+
+	prefetchWindowStr, err := coreCfg(tr, "refresh.prefetch-window")
+	if err != nil {
+		return err
+	}
+	if prefetchWindowStr != "" {
+		if _, err := timeutil.ParseSchedule(prefetchWindowStr); err != nil {
+			return fmt.Errorf("refresh.prefetch-window cannot be parsed: %v", err)
+		}
+	}
+
 	// check (new) refresh.timer
 	refreshTimerStr, err := coreCfg(tr, "refresh.timer")
 	if err != nil {
@@ -163,3 +286,22 @@ func validateRefreshRateLimit(tr RunTransaction) error {
 	}
 	return nil
 }
+
+func validateRefreshWebhook(tr RunTransaction) error {
+	webhookURL, err := coreCfg(tr, "refresh.webhook.url")
+	if err != nil {
+		return err
+	}
+	// reset is fine
+	if webhookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("cannot parse webhook URL: %v", err)
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("webhook URL must be an absolute http or https URL, not %q", webhookURL)
+	}
+	return nil
+}
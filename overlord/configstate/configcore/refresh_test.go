@@ -181,6 +181,69 @@ func (s *refreshSuite) TestConfigureRefreshHoldOnMeteredHappy(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *refreshSuite) TestConfigureRefreshPolicyHappy(c *C) {
+	for _, policy := range []string{"", "all", "security-only"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"refresh.policy": policy,
+			},
+		})
+		c.Check(err, IsNil, Commentf("policy %q", policy))
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshPolicyInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"refresh.policy": "features-only",
+		},
+	})
+	c.Assert(err, ErrorMatches, `refresh\.policy value "features-only" is invalid`)
+}
+
+func (s *refreshSuite) TestConfigureRefreshMaintenanceWindowHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"refresh.policy":             "security-only",
+			"refresh.maintenance-window": "sat,02:00-04:00",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *refreshSuite) TestConfigureRefreshMaintenanceWindowInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"refresh.maintenance-window": "invalid",
+		},
+	})
+	c.Assert(err, ErrorMatches, `refresh\.maintenance-window cannot be parsed:.*`)
+}
+
+func (s *refreshSuite) TestConfigureRefreshPrefetchWindowHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"refresh.prefetch-window": "mon-fri,01:00-05:00",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *refreshSuite) TestConfigureRefreshPrefetchWindowInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"refresh.prefetch-window": "invalid",
+		},
+	})
+	c.Assert(err, ErrorMatches, `refresh\.prefetch-window cannot be parsed:.*`)
+}
+
 func (s *refreshSuite) TestConfigureRefreshRetainHappy(c *C) {
 	err := configcore.Run(classicDev, &mockConf{
 		state: s.state,
@@ -252,3 +315,113 @@ func (s *refreshSuite) TestConfigureRefreshMaxInhibitionDays(c *C) {
 		}
 	}
 }
+
+func (s *refreshSuite) TestConfigureRefreshWebhookHappy(c *C) {
+	for _, webhookURL := range []string{"", "https://example.com/hook", "http://10.0.0.1:8080/snapd"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"refresh.webhook.url": webhookURL,
+			},
+		})
+		c.Check(err, IsNil, Commentf(webhookURL))
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshWebhookRejected(c *C) {
+	for _, tc := range []struct {
+		url string
+		err string
+	}{
+		{"example.com/hook", `webhook URL must be an absolute http or https URL, not "example.com/hook"`},
+		{"ftp://example.com/hook", `webhook URL must be an absolute http or https URL, not "ftp://example.com/hook"`},
+		{"https://", `webhook URL must be an absolute http or https URL, not "https://"`},
+		{"https://example.com/%zz", `cannot parse webhook URL: .*`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"refresh.webhook.url": tc.url,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf(tc.url))
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshRetainPerSnapHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"refresh.retain-per-snap.pc-kernel":  5,
+			"refresh.retain-per-snap.foo":        "2",
+			"refresh.retain-per-snap.other-snap": nil,
+		},
+	})
+	c.Assert(err, IsNil)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"refresh.retain-per-snap": map[string]any{
+				"pc-kernel": "5",
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *refreshSuite) TestConfigureRefreshRetainPerSnapInvalid(c *C) {
+	for _, tc := range []struct {
+		key   string
+		value any
+		err   string
+	}{
+		{"refresh.retain-per-snap.pc-kernel", "1", `refresh.retain-per-snap.pc-kernel must be a number between 2 and 20, not "1"`},
+		{"refresh.retain-per-snap.pc-kernel", 21, `refresh.retain-per-snap.pc-kernel must be a number between 2 and 20, not "21"`},
+		{"refresh.retain-per-snap.pc-kernel", "many", `refresh.retain-per-snap.pc-kernel must be a number between 2 and 20, not "many"`},
+		{"refresh.retain-per-snap.PC", "3", `cannot set "refresh.retain-per-snap.PC": invalid snap name: "PC"`},
+		{"refresh.retain-per-snap.pc.kernel", "3", `cannot set "refresh.retain-per-snap.pc.kernel": invalid snap name: "pc.kernel"`},
+		{"refresh.retain-per-snap", map[string]any{"foo_bar": "3"}, `cannot set "refresh.retain-per-snap.foo_bar": invalid snap name: "foo_bar"`},
+		{"refresh.retain-per-snap", "3", `cannot set "refresh.retain-per-snap": value must be a map of snap names to the number of revisions to retain`},
+		{"refresh.retain-per-snap", map[string]any{"pc-kernel": "0"}, `refresh.retain-per-snap.pc-kernel must be a number between 2 and 20, not "0"`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			changes: map[string]any{
+				tc.key: tc.value,
+			},
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%s", tc.key))
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshDownloadConcurrency(c *C) {
+	data := []struct {
+		val any
+		err string
+	}{
+		{val: "zzz", err: `download-concurrency must be a number between 1 and 16, not "zzz"`},
+		{val: -1, err: `download-concurrency must be a number between 1 and 16, not "-1"`},
+		{val: 0, err: `download-concurrency must be a number between 1 and 16, not "0"`},
+		{val: "17", err: `download-concurrency must be a number between 1 and 16, not "17"`},
+		// happy cases
+		{val: nil},
+		{val: ""},
+		{val: 1},
+		{val: "8"},
+		{val: 16},
+	}
+	for _, tc := range data {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"refresh.download-concurrency": tc.val,
+			},
+		})
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err)
+		} else {
+			c.Check(err, IsNil)
+		}
+	}
+}
@@ -59,7 +59,20 @@ func init() {
 	validateOnly := &flags{validatedOnlyStateConfig: true}
 	addWithStateHandler(validateRefreshSchedule, nil, validateOnly)
 	addWithStateHandler(validateRefreshRateLimit, nil, validateOnly)
+	addWithStateHandler(validateRefreshWebhook, nil, validateOnly)
 	addWithStateHandler(validateAutomaticSnapshotsExpiration, nil, validateOnly)
+	addWithStateHandler(validateBeforeRefreshSnapshots, nil, validateOnly)
+	addWithStateHandler(validateSnapshotsCompression, nil, validateOnly)
+	addWithStateHandler(validateStatePruning, nil, validateOnly)
+	addWithStateHandler(validateStoreCacheMaxSize, nil, validateOnly)
+	addWithStateHandler(validateTaskRetry, nil, validateOnly)
+	addWithStateHandler(validatePowerGuard, nil, validateOnly)
+	addWithStateHandler(validateAPILimits, nil, validateOnly)
+	addWithStateHandler(validateSafeModeSettings, nil, validateOnly)
+	// hooks.env.<snap>.<variable>
+	addWithStateHandler(validateHookEnvSettings, nil, validateOnly)
+	// name-resolution.<snap>.{nameservers,hosts}
+	addWithStateHandler(validateNameResolutionSettings, handleNameResolutionConfiguration, nil)
 
 	// netplan.*
 	addWithStateHandler(validateNetplanSettings, handleNetplanConfiguration, coreOnly)
@@ -70,9 +83,16 @@ func init() {
 	// debug.snapd.log
 	addWithStateHandler(validateDebugSnapdLogSetting, handleDebugSnapdLogConfiguration, nil)
 
+	// debug.snapd.profiling
+	addWithStateHandler(validateDebugSnapdProfilingSetting, nil, validateOnly)
+
 	// debug.systemd.log-level
 	addWithStateHandler(validateDebugSystemdLogLevelSetting, handleDebugSystemdLogLevelConfiguration, coreOnly)
 
+	// prompting.remote-approver.{url,public-key}
+	addWithStateHandler(validatePromptingRemoteApprover, nil, validateOnly)
+	addWithStateHandler(validatePromptingTimeout, nil, validateOnly)
+
 	// experimental.apparmor-prompting
 	addWithStateHandler(nil, doExperimentalApparmorPromptingDaemonRestart, nil)
 }
@@ -165,6 +185,14 @@ func applyHandlers(dev sysconfig.Device, cfg RunTransaction, handlers []configHa
 			if !validCertOption(k) {
 				return fmt.Errorf("cannot set store ssl certificate under name %q: name must only contain word characters or a dash", k)
 			}
+		case isHookEnvChange(k):
+			// validated by validateHookEnvSettings
+		case isNameResolutionChange(k):
+			// validated by validateNameResolutionSettings
+		case isRetainPerSnapChange(k):
+			// validated by validateRefreshSchedule
+		case isTaskRetryChange(k):
+			// validated by validateTaskRetry
 		case isNetplanChange(k):
 			if release.OnClassic {
 				return fmt.Errorf("cannot set netplan configuration on classic")
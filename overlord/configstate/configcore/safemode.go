@@ -0,0 +1,46 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.safe-mode.disconnect-interfaces"] = true
+}
+
+func validateSafeModeSettings(tr RunTransaction) error {
+	ifaces, err := coreCfg(tr, "safe-mode.disconnect-interfaces")
+	if err != nil {
+		return err
+	}
+	for _, iface := range strutil.CommaSeparatedList(ifaces) {
+		if err := snap.ValidateInterfaceName(iface); err != nil {
+			return fmt.Errorf("cannot set safe-mode.disconnect-interfaces: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type safeModeSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&safeModeSuite{})
+
+func (s *safeModeSuite) TestConfigureSafeModeDisconnectInterfaces(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"safe-mode.disconnect-interfaces": "network,network-bind, network-manager",
+		},
+	})
+	c.Assert(err, IsNil)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"safe-mode.disconnect-interfaces": "",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *safeModeSuite) TestConfigureSafeModeDisconnectInterfacesInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"safe-mode.disconnect-interfaces": "network,Bad_Name",
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot set safe-mode.disconnect-interfaces: invalid interface name: "Bad_Name"`)
+}
@@ -22,12 +22,19 @@ package configcore
 
 import (
 	"fmt"
+	"strconv"
 	"time"
+
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
 )
 
 func init() {
 	// add supported configuration of this module
 	supportedConfigurations["core.snapshots.automatic.retention"] = true
+	supportedConfigurations["core.snapshots.automatic.before-refresh"] = true
+	supportedConfigurations["core.snapshots.automatic.before-refresh-retention"] = true
+	supportedConfigurations["core.snapshots.compression"] = true
+	supportedConfigurations["core.snapshots.compression-level"] = true
 }
 
 func validateAutomaticSnapshotsExpiration(tr RunTransaction) error {
@@ -46,3 +53,48 @@ func validateAutomaticSnapshotsExpiration(tr RunTransaction) error {
 	}
 	return nil
 }
+
+func validateBeforeRefreshSnapshots(tr RunTransaction) error {
+	if err := validateBoolFlag(tr, "snapshots.automatic.before-refresh"); err != nil {
+		return err
+	}
+	expirationStr, err := coreCfg(tr, "snapshots.automatic.before-refresh-retention")
+	if err != nil {
+		return err
+	}
+	if expirationStr != "" {
+		dur, err := time.ParseDuration(expirationStr)
+		if err != nil {
+			return fmt.Errorf("snapshots.automatic.before-refresh-retention cannot be parsed: %v", err)
+		}
+		if dur < time.Hour {
+			return fmt.Errorf("snapshots.automatic.before-refresh-retention must be a value greater than 1 hour")
+		}
+	}
+	return nil
+}
+
+func validateSnapshotsCompression(tr RunTransaction) error {
+	codec, err := coreCfg(tr, "snapshots.compression")
+	if err != nil {
+		return err
+	}
+	if codec == "" {
+		codec = backend.CompressionGzip
+	}
+	levelStr, err := coreCfg(tr, "snapshots.compression-level")
+	if err != nil {
+		return err
+	}
+	var level int
+	if levelStr != "" {
+		level, err = strconv.Atoi(levelStr)
+		if err != nil {
+			return fmt.Errorf("snapshots.compression-level cannot be parsed: %v", err)
+		}
+	}
+	if err := backend.ValidateCompression(codec, level); err != nil {
+		return fmt.Errorf("cannot set snapshots compression: %v", err)
+	}
+	return nil
+}
@@ -21,9 +21,12 @@
 package configcore_test
 
 import (
+	"os"
+
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/testutil"
 )
 
 type snapshotsSuite struct {
@@ -71,3 +74,85 @@ func (s *refreshSuite) TestConfigureAutomaticSnapshotsExpirationInvalid(c *C) {
 	})
 	c.Assert(err, ErrorMatches, `snapshots.automatic.retention cannot be parsed:.*`)
 }
+
+func (s *snapshotsSuite) TestConfigureBeforeRefreshSnapshotsHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"snapshots.automatic.before-refresh":           true,
+			"snapshots.automatic.before-refresh-retention": "72h",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *snapshotsSuite) TestConfigureBeforeRefreshSnapshotsInvalid(c *C) {
+	for _, tc := range []struct {
+		conf map[string]any
+		err  string
+	}{
+		{map[string]any{"snapshots.automatic.before-refresh": "yes"}, `snapshots.automatic.before-refresh can only be set to 'true' or 'false'`},
+		{map[string]any{"snapshots.automatic.before-refresh-retention": "foo"}, `snapshots.automatic.before-refresh-retention cannot be parsed: .*`},
+		{map[string]any{"snapshots.automatic.before-refresh-retention": "10m"}, `snapshots.automatic.before-refresh-retention must be a value greater than 1 hour`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  tc.conf,
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.conf))
+	}
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsCompressionHappy(c *C) {
+	zstd := testutil.MockCommand(c, "zstd", "")
+	defer zstd.Restore()
+
+	for _, conf := range []map[string]any{
+		{"snapshots.compression": "zstd"},
+		{"snapshots.compression": "zstd", "snapshots.compression-level": "19"},
+		{"snapshots.compression": "zstd", "snapshots.compression-level": "0"},
+		{"snapshots.compression": "gzip", "snapshots.compression-level": 1},
+		{"snapshots.compression-level": "9"},
+		{"snapshots.compression": "none"},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  conf,
+		})
+		c.Check(err, IsNil, Commentf("%v", conf))
+	}
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsCompressionNoZstd(c *C) {
+	oldPath := os.Getenv("PATH")
+	defer os.Setenv("PATH", oldPath)
+	os.Setenv("PATH", c.MkDir())
+
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf:  map[string]any{"snapshots.compression": "zstd"},
+	})
+	c.Check(err, ErrorMatches, `cannot set snapshots compression: cannot use zstd compression: zstd is not installed`)
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsCompressionInvalid(c *C) {
+	zstd := testutil.MockCommand(c, "zstd", "")
+	defer zstd.Restore()
+
+	for _, tc := range []struct {
+		conf map[string]any
+		err  string
+	}{
+		{map[string]any{"snapshots.compression": "lz4"}, `cannot set snapshots compression: unsupported snapshot compression "lz4"`},
+		{map[string]any{"snapshots.compression-level": "high"}, `snapshots.compression-level cannot be parsed: .*`},
+		{map[string]any{"snapshots.compression-level": "10"}, `cannot set snapshots compression: gzip compression level must be between 1 and 9`},
+		{map[string]any{"snapshots.compression": "zstd", "snapshots.compression-level": "-1"}, `cannot set snapshots compression: zstd compression level must be between 1 and 19`},
+		{map[string]any{"snapshots.compression": "none", "snapshots.compression-level": "3"}, `cannot set snapshots compression: cannot use a compression level without compression`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  tc.conf,
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.conf))
+	}
+}
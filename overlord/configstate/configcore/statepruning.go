@@ -0,0 +1,87 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxStatePruneChanges and maxStatePruneAge bound how many ready
+	// changes are kept and for how long, so that the state cannot grow
+	// without limit
+	maxStatePruneChanges = 10000
+	maxStatePruneAge     = 90 * 24 * time.Hour
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.state.prune.max-changes"] = true
+	supportedConfigurations["core.state.prune.max-age"] = true
+	supportedConfigurations["core.state.prune.abort-after"] = true
+}
+
+func validateStatePruning(tr RunTransaction) error {
+	maxChanges, err := coreCfg(tr, "state.prune.max-changes")
+	if err != nil {
+		return err
+	}
+	if maxChanges != "" {
+		n, err := strconv.Atoi(maxChanges)
+		if err != nil {
+			return fmt.Errorf("state.prune.max-changes cannot be parsed: %v", err)
+		}
+		if n < 1 || n > maxStatePruneChanges {
+			return fmt.Errorf("state.prune.max-changes must be between 1 and %d", maxStatePruneChanges)
+		}
+	}
+
+	for _, opt := range []struct {
+		name string
+		min  time.Duration
+		desc string
+		max  time.Duration
+	}{
+		{"state.prune.max-age", time.Hour, "1 hour", maxStatePruneAge},
+		{"state.prune.abort-after", 24 * time.Hour, "24 hours", 0},
+	} {
+		value, err := coreCfg(tr, opt.name)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		dur, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s cannot be parsed: %v", opt.name, err)
+		}
+		if dur < opt.min {
+			return fmt.Errorf("%s must be a value greater than %s", opt.name, opt.desc)
+		}
+		if opt.max > 0 && dur > opt.max {
+			return fmt.Errorf("%s cannot be greater than %s", opt.name, opt.max)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type statePruningSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&statePruningSuite{})
+
+func (s *statePruningSuite) TestConfigureStatePruningHappy(c *C) {
+	for _, conf := range []map[string]any{
+		{"state.prune.max-changes": "5000"},
+		{"state.prune.max-changes": 10},
+		{"state.prune.max-age": "2160h"},
+		{"state.prune.max-age": "1h", "state.prune.abort-after": "24h"},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  conf,
+		})
+		c.Check(err, IsNil, Commentf("%v", conf))
+	}
+}
+
+func (s *statePruningSuite) TestConfigureStatePruningInvalid(c *C) {
+	for _, tc := range []struct {
+		conf map[string]any
+		err  string
+	}{
+		{map[string]any{"state.prune.max-changes": "many"}, `state.prune.max-changes cannot be parsed: .*`},
+		{map[string]any{"state.prune.max-changes": 0}, `state.prune.max-changes must be between 1 and 10000`},
+		{map[string]any{"state.prune.max-changes": 10001}, `state.prune.max-changes must be between 1 and 10000`},
+		{map[string]any{"state.prune.max-age": "forever"}, `state.prune.max-age cannot be parsed: .*`},
+		{map[string]any{"state.prune.max-age": "10m"}, `state.prune.max-age must be a value greater than 1 hour`},
+		{map[string]any{"state.prune.max-age": "2161h"}, `state.prune.max-age cannot be greater than 2160h0m0s`},
+		{map[string]any{"state.prune.abort-after": "12h"}, `state.prune.abort-after must be a value greater than 24 hours`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  tc.conf,
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.conf))
+	}
+}
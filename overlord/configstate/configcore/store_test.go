@@ -78,6 +78,28 @@ func (s *storeSuite) TestStoreAccessUnhappy(c *C) {
 	c.Assert(err, ErrorMatches, ".*store access can only be set to 'offline'")
 }
 
+func (s *storeSuite) TestStoreCacheMaxSize(c *C) {
+	for _, maxSize := range []string{"", "0B", "512MB", "2GB"} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			changes: map[string]any{
+				"store.cache.max-size": maxSize,
+			},
+		})
+		c.Check(err, IsNil, Commentf("%q", maxSize))
+	}
+
+	for _, maxSize := range []string{"lots", "-1GB"} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			changes: map[string]any{
+				"store.cache.max-size": maxSize,
+			},
+		})
+		c.Check(err, ErrorMatches, "store.cache.max-size cannot be parsed: .*", Commentf("%q", maxSize))
+	}
+}
+
 func (s *storeSuite) TestFilesystemOnlyApply(c *C) {
 	conf := configcore.PlainCoreConfig(map[string]any{
 		"store.access": "offline",
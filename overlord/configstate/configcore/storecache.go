@@ -0,0 +1,47 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/strutil"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.store.cache.max-size"] = true
+}
+
+func validateStoreCacheMaxSize(tr RunTransaction) error {
+	maxSize, err := coreCfg(tr, "store.cache.max-size")
+	if err != nil {
+		return err
+	}
+	// reset is fine
+	if maxSize == "" {
+		return nil
+	}
+	if _, err := strutil.ParseByteSize(maxSize); err != nil {
+		return fmt.Errorf("store.cache.max-size cannot be parsed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/strutil"
+)
+
+const taskRetryPrefix = "core." + snapstate.TaskRetryConfigKey + "."
+
+func isTaskRetryChange(key string) bool {
+	return key == "core."+snapstate.TaskRetryConfigKey || strings.HasPrefix(key, taskRetryPrefix)
+}
+
+// validateTaskRetry checks the tasks.retry.<kind>.{max-retries,
+// initial-backoff,max-backoff} options tuning the retries of tasks failing
+// with transient network errors.
+func validateTaskRetry(tr RunTransaction) error {
+	for _, name := range tr.Changes() {
+		if !isTaskRetryChange(name) {
+			continue
+		}
+		option := strings.TrimPrefix(name, "core.")
+
+		var value any
+		if err := tr.Get("core", option, &value); err != nil && !config.IsNoOption(err) {
+			return err
+		}
+		if err := validateTaskRetryValue(option, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateTaskRetryValue(option string, value any) error {
+	if value == nil {
+		// unset
+		return nil
+	}
+	parts := strings.Split(option, ".")
+	// tasks.retry.<kind>.<setting>
+	if len(parts) < 4 {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot set %q: value must be a map", option)
+		}
+		if len(parts) == 3 && !strutil.ListContains(snapstate.RetriedTaskKinds, parts[2]) {
+			return fmt.Errorf("cannot set %q: unsupported task kind %q", option, parts[2])
+		}
+		for k, v := range m {
+			if err := validateTaskRetryValue(option+"."+k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(parts) > 4 {
+		return fmt.Errorf("cannot set %q: unsupported system option", option)
+	}
+	if !strutil.ListContains(snapstate.RetriedTaskKinds, parts[2]) {
+		return fmt.Errorf("cannot set %q: unsupported task kind %q", option, parts[2])
+	}
+
+	str := fmt.Sprintf("%v", value)
+	switch parts[3] {
+	case "max-retries":
+		if n, err := strconv.ParseUint(str, 10, 8); err != nil || n > 100 {
+			return fmt.Errorf("%s must be a number between 0 and 100, not %q", option, str)
+		}
+	case "initial-backoff", "max-backoff":
+		dur, err := time.ParseDuration(str)
+		if err != nil {
+			return fmt.Errorf("%s cannot be parsed: %v", option, err)
+		}
+		if dur < time.Second {
+			return fmt.Errorf("%s must be at least 1s", option)
+		}
+	default:
+		return fmt.Errorf("cannot set %q: unsupported system option", option)
+	}
+	return nil
+}
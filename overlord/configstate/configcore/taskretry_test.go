@@ -0,0 +1,72 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type taskRetrySuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&taskRetrySuite{})
+
+func (s *taskRetrySuite) TestConfigureTaskRetryHappy(c *C) {
+	for _, conf := range []map[string]any{
+		{"tasks.retry.download-snap.max-retries": "10"},
+		{"tasks.retry.download-snap.max-retries": 0},
+		{"tasks.retry.pre-download-snap.initial-backoff": "1m", "tasks.retry.pre-download-snap.max-backoff": "1h"},
+		{"tasks.retry.download-component.max-retries": nil},
+		{"tasks.retry.download-snap": map[string]any{"max-retries": 3, "initial-backoff": "10s"}},
+		{"tasks.retry": map[string]any{"download-snap": map[string]any{"max-backoff": "5m"}}},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state:   s.state,
+			changes: conf,
+		})
+		c.Check(err, IsNil, Commentf("%v", conf))
+	}
+}
+
+func (s *taskRetrySuite) TestConfigureTaskRetryInvalid(c *C) {
+	for _, tc := range []struct {
+		conf map[string]any
+		err  string
+	}{
+		{map[string]any{"tasks.retry.download-snap.max-retries": "many"}, `tasks.retry.download-snap.max-retries must be a number between 0 and 100, not "many"`},
+		{map[string]any{"tasks.retry.download-snap.max-retries": 101}, `tasks.retry.download-snap.max-retries must be a number between 0 and 100, not "101"`},
+		{map[string]any{"tasks.retry.download-snap.initial-backoff": "soon"}, `tasks.retry.download-snap.initial-backoff cannot be parsed: .*`},
+		{map[string]any{"tasks.retry.download-snap.max-backoff": "100ms"}, `tasks.retry.download-snap.max-backoff must be at least 1s`},
+		{map[string]any{"tasks.retry.download-snap.jitter": "0.5"}, `cannot set "tasks.retry.download-snap.jitter": unsupported system option`},
+		{map[string]any{"tasks.retry.link-snap.max-retries": 3}, `cannot set "tasks.retry.link-snap.max-retries": unsupported task kind "link-snap"`},
+		{map[string]any{"tasks.retry.link-snap": map[string]any{"max-retries": 3}}, `cannot set "tasks.retry.link-snap": unsupported task kind "link-snap"`},
+		{map[string]any{"tasks.retry.download-snap": "3"}, `cannot set "tasks.retry.download-snap": value must be a map`},
+		{map[string]any{"tasks.retry": map[string]any{"download-snap": map[string]any{"max-retries": -1}}}, `tasks.retry.download-snap.max-retries must be a number between 0 and 100, not "-1"`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state:   s.state,
+			changes: tc.conf,
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.conf))
+	}
+}
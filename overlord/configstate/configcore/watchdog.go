@@ -22,6 +22,7 @@ package configcore
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -32,13 +33,150 @@ import (
 	"github.com/snapcore/snapd/systemd"
 )
 
+const (
+	watchdogDropIn = "10-snapd-watchdog.conf"
+	// watchdogOverrideDropIn is used instead of watchdogDropIn when
+	// watchdog.enabled is set, it sorts after the drop-ins usually
+	// shipped by gadgets so that the settings of snapd take precedence.
+	watchdogOverrideDropIn = "99-snapd-watchdog.conf"
+	// watchdogDropInsGlob matches both drop-ins written by snapd.
+	watchdogDropInsGlob = "*-snapd-watchdog.conf"
+
+	// defaultWatchdogRuntimeTimeout is used when the watchdog is
+	// enabled without an explicit runtime timeout.
+	defaultWatchdogRuntimeTimeout = time.Minute
+)
+
+// watchdogKeys are the systemd manager settings controlling the hardware
+// watchdog.
+var watchdogKeys = []string{
+	"RuntimeWatchdogSec",
+	"RebootWatchdogSec",
+	"ShutdownWatchdogSec",
+	"KExecWatchdogSec",
+	"WatchdogDevice",
+}
+
 func init() {
 	// add supported configuration of this module
+	supportedConfigurations["core.watchdog.enabled"] = true
 	supportedConfigurations["core.watchdog.runtime-timeout"] = true
 	supportedConfigurations["core.watchdog.shutdown-timeout"] = true
 }
 
-func updateWatchdogConfig(config map[string]uint, opts *fsOnlyContext) error {
+// WatchdogState describes the hardware watchdog settings managed by snapd.
+type WatchdogState struct {
+	Enabled         bool
+	RuntimeTimeout  time.Duration
+	ShutdownTimeout time.Duration
+	// ConflictingDropIns lists the other drop-ins, usually shipped by
+	// the gadget, which also set watchdog settings. They are overridden
+	// when watchdog.enabled is set.
+	ConflictingDropIns []string
+}
+
+// CurrentWatchdogState returns the hardware watchdog settings managed by
+// snapd, or nil if the watchdog was never configured through snapd.
+func CurrentWatchdogState(tr ConfGetter) (*WatchdogState, error) {
+	cfg, err := watchdogConfig(tr)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.managed() {
+		return nil, nil
+	}
+	conflicting, err := conflictingWatchdogDropIns(dirs.SnapSystemdConfDir)
+	if err != nil {
+		return nil, err
+	}
+	return &WatchdogState{
+		Enabled:            cfg.enabled != "false",
+		RuntimeTimeout:     cfg.runtime,
+		ShutdownTimeout:    cfg.shutdown,
+		ConflictingDropIns: conflicting,
+	}, nil
+}
+
+type watchdogOptions struct {
+	enabled  string
+	runtime  time.Duration
+	shutdown time.Duration
+}
+
+// managed returns whether snapd is in charge of the watchdog settings.
+func (o *watchdogOptions) managed() bool {
+	return o.enabled != "" || o.runtime > 0 || o.shutdown > 0
+}
+
+// dropIn returns the name of the drop-in holding the settings. Only an
+// explicit watchdog.enabled overrides the other drop-ins, the timeouts on
+// their own are written where they always were.
+func (o *watchdogOptions) dropIn() string {
+	if o.enabled != "" {
+		return watchdogOverrideDropIn
+	}
+	return watchdogDropIn
+}
+
+// settings returns the systemd manager settings matching the options.
+func (o *watchdogOptions) settings() map[string]uint {
+	switch o.enabled {
+	case "false":
+		// explicitly turn the watchdog off, overriding whatever was
+		// set by other drop-ins
+		return map[string]uint{
+			"RuntimeWatchdogSec":  0,
+			"ShutdownWatchdogSec": 0,
+		}
+	case "true":
+		config := map[string]uint{}
+		runtime := o.runtime
+		if runtime == 0 {
+			runtime = defaultWatchdogRuntimeTimeout
+		}
+		config["RuntimeWatchdogSec"] = uint(runtime.Seconds())
+		if o.shutdown > 0 {
+			config["ShutdownWatchdogSec"] = uint(o.shutdown.Seconds())
+		}
+		return config
+	}
+
+	config := map[string]uint{}
+	if o.runtime > 0 {
+		config["RuntimeWatchdogSec"] = uint(o.runtime.Seconds())
+	}
+	if o.shutdown > 0 {
+		config["ShutdownWatchdogSec"] = uint(o.shutdown.Seconds())
+	}
+	return config
+}
+
+func watchdogConfig(tr ConfGetter) (*watchdogOptions, error) {
+	enabled, err := coreCfg(tr, "watchdog.enabled")
+	if err != nil {
+		return nil, err
+	}
+	opts := &watchdogOptions{enabled: enabled}
+	for _, key := range []string{"runtime-timeout", "shutdown-timeout"} {
+		output, err := coreCfg(tr, "watchdog."+key)
+		if err != nil {
+			return nil, err
+		}
+		dur, err := getSystemdConfDuration(output)
+		if err != nil {
+			return nil, fmt.Errorf("cannot set timer to %q: %v", output, err)
+		}
+		switch key {
+		case "runtime-timeout":
+			opts.runtime = dur
+		case "shutdown-timeout":
+			opts.shutdown = dur
+		}
+	}
+	return opts, nil
+}
+
+func updateWatchdogConfig(config map[string]uint, dropIn string, opts *fsOnlyContext) error {
 	var sysd systemd.Systemd
 
 	dir := dirs.SnapSystemdConfDir
@@ -48,14 +186,11 @@ func updateWatchdogConfig(config map[string]uint, opts *fsOnlyContext) error {
 		sysd = systemd.NewUnderRoot(dirs.GlobalRootDir, systemd.SystemMode, &sysdLogger{})
 	}
 
-	name := "10-snapd-watchdog.conf"
 	dirContent := make(map[string]osutil.FileState, 1)
 
 	configStr := []string{}
 	for k, v := range config {
-		if v > 0 {
-			configStr = append(configStr, fmt.Sprintf("%s=%d\n", k, v))
-		}
+		configStr = append(configStr, fmt.Sprintf("%s=%d\n", k, v))
 	}
 	if len(configStr) > 0 {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -65,14 +200,13 @@ func updateWatchdogConfig(config map[string]uint, opts *fsOnlyContext) error {
 		// We order the variables to have predictable output
 		sort.Strings(configStr)
 		content := "[Manager]\n" + strings.Join(configStr, "")
-		dirContent[name] = &osutil.MemoryFileState{
+		dirContent[dropIn] = &osutil.MemoryFileState{
 			Content: []byte(content),
 			Mode:    0644,
 		}
 	}
 
-	glob := name
-	changed, removed, err := osutil.EnsureDirState(dir, glob, dirContent)
+	changed, removed, err := osutil.EnsureDirState(dir, watchdogDropInsGlob, dirContent)
 	if err != nil {
 		return err
 	}
@@ -85,34 +219,59 @@ func updateWatchdogConfig(config map[string]uint, opts *fsOnlyContext) error {
 	return nil
 }
 
-func handleWatchdogConfiguration(_ sysconfig.Device, tr ConfGetter, opts *fsOnlyContext) error {
-	config := map[string]uint{}
-
-	for _, key := range []string{"runtime-timeout", "shutdown-timeout"} {
-		output, err := coreCfg(tr, "watchdog."+key)
-		if err != nil {
-			return err
+// conflictingWatchdogDropIns returns the drop-ins in dir other than the
+// ones of snapd which set any of the watchdog settings.
+func conflictingWatchdogDropIns(dir string) ([]string, error) {
+	dropIns, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+	if err != nil {
+		return nil, err
+	}
+	var conflicting []string
+	for _, path := range dropIns {
+		if matched, _ := filepath.Match(watchdogDropInsGlob, filepath.Base(path)); matched {
+			continue
 		}
-		secs, err := getSystemdConfSeconds(output)
+		sets, err := setsWatchdogKeys(path)
 		if err != nil {
-			return fmt.Errorf("cannot set timer to %q: %v", output, err)
+			return nil, err
 		}
-		switch key {
-		case "runtime-timeout":
-			config["RuntimeWatchdogSec"] = secs
-		case "shutdown-timeout":
-			config["ShutdownWatchdogSec"] = secs
+		if sets {
+			conflicting = append(conflicting, filepath.Base(path))
 		}
 	}
+	return conflicting, nil
+}
+
+func setsWatchdogKeys(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		for _, key := range watchdogKeys {
+			if strings.HasPrefix(line, key+"=") {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func handleWatchdogConfiguration(_ sysconfig.Device, tr ConfGetter, opts *fsOnlyContext) error {
+	cfg, err := watchdogConfig(tr)
+	if err != nil {
+		return err
+	}
 
-	if err := updateWatchdogConfig(config, opts); err != nil {
+	if err := updateWatchdogConfig(cfg.settings(), cfg.dropIn(), opts); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func getSystemdConfSeconds(timeStr string) (uint, error) {
+func getSystemdConfDuration(timeStr string) (time.Duration, error) {
 	if timeStr == "" {
 		return 0, nil
 	}
@@ -125,18 +284,25 @@ func getSystemdConfSeconds(timeStr string) (uint, error) {
 		return 0, fmt.Errorf("cannot use negative duration %q: %v", timeStr, err)
 	}
 
-	return uint(dur.Seconds()), nil
+	return dur, nil
 }
 
 func validateWatchdogOptions(tr ConfGetter) error {
+	if err := validateBoolFlag(tr, "watchdog.enabled"); err != nil {
+		return err
+	}
 	for _, key := range []string{"runtime-timeout", "shutdown-timeout"} {
 		option, err := coreCfg(tr, "watchdog."+key)
 		if err != nil {
 			return err
 		}
-		if _, err = getSystemdConfSeconds(option); err != nil {
+		dur, err := getSystemdConfDuration(option)
+		if err != nil {
 			return err
 		}
+		if dur > 0 && dur < time.Second {
+			return fmt.Errorf("cannot use watchdog.%s of %q: must be at least 1s", key, option)
+		}
 	}
 
 	return nil
@@ -249,3 +249,117 @@ func (s *watchdogSuite) TestFilesystemOnlyApplyValidationFails(c *C) {
 	tmpDir := c.MkDir()
 	c.Assert(configcore.FilesystemOnlyApply(coreDev, tmpDir, conf), ErrorMatches, `cannot parse "foo": time: invalid duration \"?foo\"?`)
 }
+
+func (s *watchdogSuite) TestConfigureWatchdogDisabled(c *C) {
+	err := configcore.FilesystemOnlyRun(coreDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"watchdog.enabled":         false,
+			"watchdog.runtime-timeout": "10s",
+		},
+	})
+	c.Assert(err, IsNil)
+	// the watchdog is explicitly turned off, overriding other drop-ins
+	overrideDropIn := filepath.Join(dirs.SnapSystemdConfDir, "99-snapd-watchdog.conf")
+	c.Check(overrideDropIn, testutil.FileEquals, "[Manager]\nRuntimeWatchdogSec=0\nShutdownWatchdogSec=0\n")
+	c.Check(osutil.FileExists(s.mockEtcEnvironment), Equals, false)
+	c.Check(s.systemctlArgs, DeepEquals, [][]string{
+		{"daemon-reexec"},
+	})
+}
+
+func (s *watchdogSuite) TestConfigureWatchdogEnabledDefaultTimeout(c *C) {
+	err := configcore.FilesystemOnlyRun(coreDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"watchdog.enabled":          true,
+			"watchdog.shutdown-timeout": "5m",
+		},
+	})
+	c.Assert(err, IsNil)
+	overrideDropIn := filepath.Join(dirs.SnapSystemdConfDir, "99-snapd-watchdog.conf")
+	c.Check(overrideDropIn, testutil.FileEquals, "[Manager]\nRuntimeWatchdogSec=60\nShutdownWatchdogSec=300\n")
+}
+
+func (s *watchdogSuite) TestConfigureWatchdogBadEnabled(c *C) {
+	err := configcore.FilesystemOnlyRun(coreDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"watchdog.enabled": "maybe",
+		},
+	})
+	c.Assert(err, ErrorMatches, `watchdog.enabled can only be set to 'true' or 'false'`)
+	c.Check(s.systemctlArgs, HasLen, 0)
+}
+
+func (s *watchdogSuite) TestConfigureWatchdogTooShort(c *C) {
+	err := configcore.FilesystemOnlyRun(coreDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"watchdog.runtime-timeout": "500ms",
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot use watchdog.runtime-timeout of "500ms": must be at least 1s`)
+	c.Check(s.systemctlArgs, HasLen, 0)
+}
+
+func (s *watchdogSuite) TestConfigureWatchdogConflictingDropIns(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapSystemdConfDir, 0755), IsNil)
+	gadgetDropIn := filepath.Join(dirs.SnapSystemdConfDir, "50-gadget-watchdog.conf")
+	c.Assert(os.WriteFile(gadgetDropIn, []byte("[Manager]\nRuntimeWatchdogSec=30\nLogLevel=debug\n"), 0644), IsNil)
+	canary := filepath.Join(dirs.SnapSystemdConfDir, "05-canary.conf")
+	c.Assert(os.WriteFile(canary, []byte("[Manager]\nLogLevel=debug\n"), 0644), IsNil)
+	overrideDropIn := filepath.Join(dirs.SnapSystemdConfDir, "99-snapd-watchdog.conf")
+
+	// the timeouts on their own are written as before, without
+	// overriding the other drop-ins
+	conf := &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"watchdog.runtime-timeout": "10s",
+		},
+	}
+	err := configcore.FilesystemOnlyRun(coreDev, conf)
+	c.Assert(err, IsNil)
+	c.Check(s.mockEtcEnvironment, testutil.FileEquals, "[Manager]\nRuntimeWatchdogSec=10\n")
+	c.Check(osutil.FileExists(overrideDropIn), Equals, false)
+
+	ws, err := configcore.CurrentWatchdogState(conf)
+	c.Assert(err, IsNil)
+	c.Check(ws, DeepEquals, &configcore.WatchdogState{
+		Enabled:            true,
+		RuntimeTimeout:     10 * time.Second,
+		ConflictingDropIns: []string{"50-gadget-watchdog.conf"},
+	})
+
+	// with the watchdog explicitly enabled the settings of snapd go to a
+	// drop-in sorting after the one of the gadget
+	conf.conf["watchdog.enabled"] = true
+	err = configcore.FilesystemOnlyRun(coreDev, conf)
+	c.Assert(err, IsNil)
+	c.Check(overrideDropIn, testutil.FileEquals, "[Manager]\nRuntimeWatchdogSec=10\n")
+	c.Check(osutil.FileExists(s.mockEtcEnvironment), Equals, false)
+
+	// the other drop-ins are left alone
+	c.Check(gadgetDropIn, testutil.FileEquals, "[Manager]\nRuntimeWatchdogSec=30\nLogLevel=debug\n")
+	c.Check(canary, testutil.FileEquals, "[Manager]\nLogLevel=debug\n")
+
+	// once snapd no longer manages the watchdog its drop-ins are gone
+	conf.conf["watchdog.enabled"] = ""
+	conf.conf["watchdog.runtime-timeout"] = ""
+	err = configcore.FilesystemOnlyRun(coreDev, conf)
+	c.Assert(err, IsNil)
+	c.Check(osutil.FileExists(s.mockEtcEnvironment), Equals, false)
+	c.Check(osutil.FileExists(overrideDropIn), Equals, false)
+	c.Check(gadgetDropIn, testutil.FileEquals, "[Manager]\nRuntimeWatchdogSec=30\nLogLevel=debug\n")
+
+	ws, err = configcore.CurrentWatchdogState(conf)
+	c.Assert(err, IsNil)
+	c.Check(ws, IsNil)
+
+	c.Check(s.systemctlArgs, DeepEquals, [][]string{
+		{"daemon-reexec"},
+		{"daemon-reexec"},
+		{"daemon-reexec"},
+	})
+}
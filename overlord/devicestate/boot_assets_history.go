@@ -0,0 +1,144 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// maxBootAssetUpdates is the number of boot asset updates kept in the
+// history, older ones are dropped.
+var maxBootAssetUpdates = 100
+
+// BootAssetUpdate records the update of a boot asset by a gadget assets
+// update, triggered by a refresh of the gadget or of the kernel snap.
+type BootAssetUpdate struct {
+	// Asset is the path of the asset relative to the root of the gadget
+	// structure holding it.
+	Asset string `json:"asset"`
+	// Role is the role of the gadget structure holding the asset.
+	Role string `json:"role,omitempty"`
+	// OldHash is the SHA3-384 hash of the asset before the update, it is
+	// empty for assets that were added.
+	OldHash string `json:"old-hash,omitempty"`
+	// NewHash is the SHA3-384 hash of the asset after the update.
+	NewHash string `json:"new-hash"`
+	// Snap and Revision identify the snap whose refresh updated the
+	// asset.
+	Snap     string        `json:"snap"`
+	Revision snap.Revision `json:"revision"`
+	ChangeID string        `json:"change-id,omitempty"`
+	// Resealed is set if the keys of the encrypted disks were resealed
+	// as part of the update.
+	Resealed bool      `json:"resealed"`
+	Time     time.Time `json:"time"`
+}
+
+// BootAssetsHistory returns the boot asset updates performed by gadget
+// assets updates, from the oldest to the most recent one.
+func BootAssetsHistory(st *state.State) ([]BootAssetUpdate, error) {
+	var history []BootAssetUpdate
+	if err := st.Get("boot-assets-history", &history); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return history, nil
+}
+
+func addBootAssetUpdates(st *state.State, updates []BootAssetUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	history, err := BootAssetsHistory(st)
+	if err != nil {
+		return err
+	}
+	history = append(history, updates...)
+	if len(history) > maxBootAssetUpdates {
+		history = history[len(history)-maxBootAssetUpdates:]
+	}
+	st.Set("boot-assets-history", history)
+	return nil
+}
+
+// bootAssetsRecorder wraps the observer of a gadget assets update, if any,
+// to record the assets the update writes.
+type bootAssetsRecorder struct {
+	observer gadget.ContentUpdateObserver
+	updates  []BootAssetUpdate
+}
+
+func assetHash(path string) string {
+	digest, _, err := osutil.FileDigest(path, crypto.SHA3_384)
+	if err != nil {
+		logger.Noticef("cannot compute the hash of boot asset %q: %v", path, err)
+		return ""
+	}
+	return hex.EncodeToString(digest)
+}
+
+func (r *bootAssetsRecorder) Observe(op gadget.ContentOperation, partRole, targetRootDir, relativeTargetPath string, data *gadget.ContentChange) (gadget.ContentChangeAction, error) {
+	action := gadget.ChangeApply
+	if r.observer != nil {
+		var err error
+		action, err = r.observer.Observe(op, partRole, targetRootDir, relativeTargetPath, data)
+		if err != nil {
+			return action, err
+		}
+	}
+	if action != gadget.ChangeApply || op == gadget.ContentRollback || data == nil {
+		return action, nil
+	}
+
+	update := BootAssetUpdate{
+		Asset:   relativeTargetPath,
+		Role:    partRole,
+		NewHash: assetHash(data.After),
+	}
+	if data.Before != "" {
+		update.OldHash = assetHash(data.Before)
+	}
+	r.updates = append(r.updates, update)
+	return action, nil
+}
+
+func (r *bootAssetsRecorder) BeforeWrite() error {
+	if r.observer == nil {
+		return nil
+	}
+	return r.observer.BeforeWrite()
+}
+
+func (r *bootAssetsRecorder) Canceled() error {
+	// nothing was changed in the end
+	r.updates = nil
+	if r.observer == nil {
+		return nil
+	}
+	return r.observer.Canceled()
+}
@@ -0,0 +1,93 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type bootAssetsHistorySuite struct{}
+
+var _ = Suite(&bootAssetsHistorySuite{})
+
+// SHA3-384 of "foobar"
+const foobarHash = "0fa8abfbdaf924ad307b74dd2ed183b9a4a398891a2f6bac8fd2db7041b77f068580f9c6c66f699b496c2da1cbcc7ed8"
+
+func (s *bootAssetsHistorySuite) TestRecorder(c *C) {
+	d := c.MkDir()
+	before := filepath.Join(d, "before")
+	after := filepath.Join(d, "after")
+	c.Assert(os.WriteFile(before, []byte("old"), 0644), IsNil)
+	c.Assert(os.WriteFile(after, []byte("foobar"), 0644), IsNil)
+
+	recorder := &devicestate.BootAssetsRecorder{}
+	act, err := recorder.Observe(gadget.ContentWrite, gadget.SystemBoot, d, "new-asset", &gadget.ContentChange{After: after})
+	c.Assert(err, IsNil)
+	c.Check(act, Equals, gadget.ChangeApply)
+	act, err = recorder.Observe(gadget.ContentUpdate, gadget.SystemSeed, d, "EFI/boot/grubx64.efi", &gadget.ContentChange{Before: before, After: after})
+	c.Assert(err, IsNil)
+	c.Check(act, Equals, gadget.ChangeApply)
+	c.Assert(recorder.BeforeWrite(), IsNil)
+
+	updates := recorder.Updates()
+	c.Assert(updates, HasLen, 2)
+	c.Check(updates[0], DeepEquals, devicestate.BootAssetUpdate{
+		Asset:   "new-asset",
+		Role:    gadget.SystemBoot,
+		NewHash: foobarHash,
+	})
+	c.Check(updates[1].Asset, Equals, "EFI/boot/grubx64.efi")
+	c.Check(updates[1].Role, Equals, gadget.SystemSeed)
+	c.Check(updates[1].OldHash, Not(Equals), "")
+	c.Check(updates[1].OldHash, Not(Equals), foobarHash)
+	c.Check(updates[1].NewHash, Equals, foobarHash)
+
+	// nothing was changed if the update is canceled
+	c.Assert(recorder.Canceled(), IsNil)
+	c.Check(recorder.Updates(), HasLen, 0)
+}
+
+func (s *bootAssetsHistorySuite) TestHistoryIsCapped(c *C) {
+	defer devicestate.MockMaxBootAssetUpdates(3)()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	history, err := devicestate.BootAssetsHistory(st)
+	c.Assert(err, IsNil)
+	c.Check(history, HasLen, 0)
+
+	c.Assert(devicestate.AddBootAssetUpdates(st, []devicestate.BootAssetUpdate{{Asset: "a"}, {Asset: "b"}}), IsNil)
+	c.Assert(devicestate.AddBootAssetUpdates(st, []devicestate.BootAssetUpdate{{Asset: "c"}, {Asset: "d"}}), IsNil)
+
+	history, err = devicestate.BootAssetsHistory(st)
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 3)
+	c.Check(history[0].Asset, Equals, "b")
+	c.Check(history[2].Asset, Equals, "d")
+}
@@ -0,0 +1,240 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate/internal"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/randutil"
+)
+
+var (
+	// deviceSessionRenewalJitter is the maximum time by which a renewal is
+	// brought forward to spread the renewals of a fleet of devices.
+	deviceSessionRenewalJitter = 24 * time.Hour
+	// deviceSessionRenewalRetryDelay is the time after which a failed
+	// renewal is attempted again.
+	deviceSessionRenewalRetryDelay = time.Hour
+	// deviceSessionRenewalWarningInterval is the minimum time between two
+	// warnings about failed renewals.
+	deviceSessionRenewalWarningInterval = 24 * time.Hour
+)
+
+// DeviceSessionStatus describes the store device session as tracked for its
+// proactive renewal.
+type DeviceSessionStatus struct {
+	// Obtained is when the current session was first seen.
+	Obtained time.Time `json:"obtained,omitzero"`
+	// Expiry is when the current session expires as reported by the
+	// store, it is unset if the store did not report it.
+	Expiry time.Time `json:"expiry,omitzero"`
+	// RenewAfter is when the next renewal is attempted, it is unset if
+	// the session is only renewed once the store rejects it.
+	RenewAfter time.Time `json:"renew-after,omitzero"`
+	// LastRenewal is when the last renewal was attempted.
+	LastRenewal time.Time `json:"last-renewal,omitzero"`
+	// LastRenewalError is the error of the last renewal, if it failed.
+	LastRenewalError string `json:"last-renewal-error,omitempty"`
+	// LastWarning is when a failed renewal was last warned about.
+	LastWarning time.Time `json:"last-warning,omitzero"`
+
+	// SessionDigest identifies the current session so that renewals
+	// triggered by the store rejecting it are also noticed.
+	SessionDigest string `json:"session-digest,omitempty"`
+}
+
+func deviceSessionStatus(st *state.State) (*DeviceSessionStatus, error) {
+	var status DeviceSessionStatus
+	if err := st.Get("device-session-status", &status); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func setDeviceSessionStatus(st *state.State, status *DeviceSessionStatus) {
+	st.Set("device-session-status", status)
+}
+
+// DeviceSessionStatusInfo returns the status of the store device session, or
+// nil if the device has no session.
+func DeviceSessionStatusInfo(st *state.State) (*DeviceSessionStatus, error) {
+	device, err := internal.Device(st)
+	if err != nil {
+		return nil, err
+	}
+	if device.SessionMacaroon == "" {
+		return nil, nil
+	}
+
+	status, err := deviceSessionStatus(st)
+	if err != nil {
+		return nil, err
+	}
+	if status.SessionDigest != deviceSessionDigest(device.SessionMacaroon) {
+		// the session was obtained since the last ensure, it was not
+		// tracked yet
+		return &DeviceSessionStatus{}, nil
+	}
+	return status, nil
+}
+
+func deviceSessionDigest(sessionMacaroon string) string {
+	h := sha256.Sum256([]byte(sessionMacaroon))
+	return hex.EncodeToString(h[:8])
+}
+
+// deviceSessionExpiry returns the expiry of a store device session as
+// reported by the store with an expires caveat of the session macaroon, or
+// the zero time if the store did not report one.
+func deviceSessionExpiry(sessionMacaroon string) time.Time {
+	m, err := auth.MacaroonDeserialize(sessionMacaroon)
+	if err != nil {
+		logger.Debugf("cannot deserialize store device session: %v", err)
+		return time.Time{}
+	}
+	var expiry time.Time
+	for _, caveat := range m.Caveats() {
+		if caveat.Location != "" {
+			// a third-party caveat
+			continue
+		}
+		// first-party caveats of the store are <location>|<name>|<value>
+		parts := strings.SplitN(caveat.Id, "|", 3)
+		if len(parts) != 3 || parts[1] != "expires" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, parts[2])
+		if err != nil {
+			logger.Debugf("cannot parse store device session expiry %q: %v", parts[2], err)
+			continue
+		}
+		if expiry.IsZero() || t.Before(expiry) {
+			expiry = t
+		}
+	}
+	return expiry
+}
+
+func deviceSessionRenewAfter(expiry time.Time) time.Time {
+	if expiry.IsZero() {
+		return time.Time{}
+	}
+	if deviceSessionRenewalJitter <= 0 {
+		return expiry
+	}
+	return expiry.Add(-randutil.RandomDuration(deviceSessionRenewalJitter))
+}
+
+// ensureDeviceSessionRenewed tracks the store device session and renews it
+// before the expiry reported by the store.
+func (m *DeviceManager) ensureDeviceSessionRenewed() error {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	var seeded bool
+	if err := st.Get("seeded", &seeded); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+
+	device, err := m.device()
+	if err != nil {
+		return err
+	}
+	if device.Serial == "" || device.SessionMacaroon == "" {
+		// sessions are obtained on demand by the store
+		return nil
+	}
+
+	status, err := deviceSessionStatus(st)
+	if err != nil {
+		return err
+	}
+
+	now := timeNow()
+	digest := deviceSessionDigest(device.SessionMacaroon)
+	if status.SessionDigest != digest {
+		// a new session, either renewed by us or obtained by the store
+		status.Obtained = now
+		status.Expiry = deviceSessionExpiry(device.SessionMacaroon)
+		status.RenewAfter = deviceSessionRenewAfter(status.Expiry)
+		status.LastRenewalError = ""
+		status.SessionDigest = digest
+		setDeviceSessionStatus(st, status)
+	}
+
+	if status.RenewAfter.IsZero() {
+		// the session is renewed once the store rejects it
+		return nil
+	}
+	if now.Before(status.RenewAfter) {
+		st.EnsureBefore(status.RenewAfter.Sub(now))
+		return nil
+	}
+
+	logger.Trace("ensure", "manager", "DeviceManager", "func", "ensureDeviceSessionRenewed")
+
+	sto := snapstate.Store(st, nil)
+	st.Unlock()
+	err = sto.RenewDeviceSession()
+	st.Lock()
+
+	status.LastRenewal = now
+	if err != nil {
+		logger.Noticef("cannot renew store device session: %v", err)
+		status.LastRenewalError = err.Error()
+		status.RenewAfter = now.Add(deviceSessionRenewalRetryDelay)
+		// renewals are retried often, do not warn about each of them
+		if status.LastWarning.IsZero() || now.Sub(status.LastWarning) >= deviceSessionRenewalWarningInterval {
+			st.Warnf("cannot renew store device session, check the connectivity to the store: %v", err)
+			status.LastWarning = now
+		}
+		setDeviceSessionStatus(st, status)
+		return nil
+	}
+
+	// the renewed session is picked up on the next ensure, until then or
+	// if the store did not hand out a new session the renewal is only
+	// attempted again after the retry delay
+	status.LastRenewalError = ""
+	status.RenewAfter = now.Add(deviceSessionRenewalRetryDelay)
+	setDeviceSessionStatus(st, status)
+
+	device, err = m.device()
+	if err != nil {
+		return err
+	}
+	if deviceSessionDigest(device.SessionMacaroon) != digest {
+		st.EnsureBefore(0)
+	}
+	return nil
+}
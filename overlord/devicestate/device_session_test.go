@@ -0,0 +1,295 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/macaroon.v1"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/store/storetest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type deviceSessionSuite struct {
+	deviceMgrBaseSuite
+
+	now      time.Time
+	renewals int
+	renewErr error
+	// renewedSession is the session the store hands out on renewal
+	renewedSession string
+	// renewNoop makes renewals succeed without a new session
+	renewNoop bool
+}
+
+var _ = Suite(&deviceSessionSuite{})
+
+func (s *deviceSessionSuite) SetUpTest(c *C) {
+	const classic = true
+	s.setupBaseTest(c, classic)
+
+	s.now = time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(devicestate.MockTimeNow(func() time.Time { return s.now }))
+	s.AddCleanup(devicestate.MockDeviceSessionRenewal(0, time.Hour, 24*time.Hour))
+
+	s.renewals = 0
+	s.renewErr = nil
+	s.renewedSession = ""
+	s.renewNoop = false
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", true)
+	snapstate.ReplaceStore(s.state, &sessionStore{s: s})
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand:           "canonical",
+		Model:           "pc-model",
+		Serial:          "serial",
+		SessionMacaroon: sessionMacaroon(c, "session-1", s.now.Add(7*24*time.Hour)),
+	})
+}
+
+func sessionMacaroon(c *C, id string, expiry time.Time) string {
+	m, err := macaroon.New([]byte("key"), id, "snapcraft.io")
+	c.Assert(err, IsNil)
+	c.Assert(m.AddFirstPartyCaveat("snapcraft.io|valid_since|2025-01-01T00:00:00Z"), IsNil)
+	if !expiry.IsZero() {
+		c.Assert(m.AddFirstPartyCaveat("snapcraft.io|expires|"+expiry.Format(time.RFC3339)), IsNil)
+	}
+	serialized, err := auth.MacaroonSerialize(m)
+	c.Assert(err, IsNil)
+	return serialized
+}
+
+type sessionStore struct {
+	storetest.Store
+
+	s *deviceSessionSuite
+}
+
+func (sto *sessionStore) RenewDeviceSession() error {
+	sto.s.renewals++
+	if sto.s.renewErr != nil {
+		return sto.s.renewErr
+	}
+	if sto.s.renewNoop {
+		return nil
+	}
+
+	st := sto.s.state
+	st.Lock()
+	defer st.Unlock()
+	device, err := devicestatetest.Device(st)
+	if err != nil {
+		return err
+	}
+	device.SessionMacaroon = sto.s.renewedSession
+	return devicestatetest.SetDevice(st, device)
+}
+
+func (s *deviceSessionSuite) sessionStatus(c *C) *devicestate.DeviceSessionStatus {
+	s.state.Lock()
+	defer s.state.Unlock()
+	status, err := devicestate.DeviceSessionStatusInfo(s.state)
+	c.Assert(err, IsNil)
+	return status
+}
+
+func (s *deviceSessionSuite) TestEnsureDeviceSessionRenewedTracksSession(c *C) {
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 0)
+
+	status := s.sessionStatus(c)
+	c.Assert(status, NotNil)
+	c.Check(status.Obtained.Equal(s.now), Equals, true)
+	c.Check(status.Expiry.Equal(s.now.Add(7*24*time.Hour)), Equals, true)
+	c.Check(status.RenewAfter.Equal(status.Expiry), Equals, true)
+	c.Check(status.LastRenewal.IsZero(), Equals, true)
+	c.Check(status.LastRenewalError, Equals, "")
+
+	// nothing to do until the session is due for renewal
+	s.now = s.now.Add(24 * time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 0)
+}
+
+func (s *deviceSessionSuite) TestEnsureDeviceSessionRenewed(c *C) {
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	obtained := s.now
+
+	s.now = s.now.Add(7 * 24 * time.Hour)
+	s.renewedSession = sessionMacaroon(c, "session-2", s.now.Add(30*24*time.Hour))
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 1)
+
+	s.state.Lock()
+	device, err := devicestatetest.Device(s.state)
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+	c.Check(device.SessionMacaroon, Equals, s.renewedSession)
+
+	// the renewed session is picked up by the next ensure
+	c.Check(s.sessionStatus(c), DeepEquals, &devicestate.DeviceSessionStatus{})
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 1)
+
+	status := s.sessionStatus(c)
+	c.Check(status.Obtained.After(obtained), Equals, true)
+	c.Check(status.Obtained.Equal(s.now), Equals, true)
+	c.Check(status.LastRenewal.Equal(s.now), Equals, true)
+	c.Check(status.LastRenewalError, Equals, "")
+	// the expiry of the renewed session is the one the store reported
+	c.Check(status.Expiry.Equal(s.now.Add(30*24*time.Hour)), Equals, true)
+	c.Check(status.RenewAfter.Equal(status.Expiry), Equals, true)
+}
+
+func (s *deviceSessionSuite) TestEnsureDeviceSessionRenewedNoExpiry(c *C) {
+	s.state.Lock()
+	device, err := devicestatetest.Device(s.state)
+	c.Assert(err, IsNil)
+	device.SessionMacaroon = sessionMacaroon(c, "session-1", time.Time{})
+	devicestatetest.SetDevice(s.state, device)
+	s.state.Unlock()
+
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	status := s.sessionStatus(c)
+	c.Check(status.Obtained.Equal(s.now), Equals, true)
+	c.Check(status.Expiry.IsZero(), Equals, true)
+	c.Check(status.RenewAfter.IsZero(), Equals, true)
+
+	// the session is left for the store to renew once it is rejected
+	s.now = s.now.Add(365 * 24 * time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 0)
+}
+
+func (s *deviceSessionSuite) TestEnsureDeviceSessionRenewedError(c *C) {
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+
+	s.renewErr = errors.New("network down")
+	s.now = s.now.Add(7 * 24 * time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 1)
+
+	status := s.sessionStatus(c)
+	c.Check(status.LastRenewal.Equal(s.now), Equals, true)
+	c.Check(status.LastRenewalError, Equals, "network down")
+	c.Check(status.RenewAfter.Equal(s.now.Add(time.Hour)), Equals, true)
+
+	s.state.Lock()
+	warnings := s.state.AllWarnings()
+	s.state.Unlock()
+	c.Assert(warnings, HasLen, 1)
+	c.Check(warnings[0].String(), Equals, "cannot renew store device session, check the connectivity to the store: network down")
+
+	c.Check(status.LastWarning.Equal(s.now), Equals, true)
+	warned := s.now
+
+	// not retried before the retry delay
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 1)
+
+	// retried after it, without warning again
+	s.now = s.now.Add(time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 2)
+	c.Check(s.sessionStatus(c).LastWarning.Equal(warned), Equals, true)
+
+	// warned again once the warning interval has passed
+	s.now = warned.Add(24 * time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 3)
+	c.Check(s.sessionStatus(c).LastWarning.Equal(s.now), Equals, true)
+
+	// retried after it and the error is cleared on success
+	s.renewErr = nil
+	s.renewedSession = sessionMacaroon(c, "session-2", s.now.Add(7*24*time.Hour))
+	s.now = s.now.Add(time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 4)
+	c.Check(s.sessionStatus(c), DeepEquals, &devicestate.DeviceSessionStatus{})
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.sessionStatus(c).LastRenewalError, Equals, "")
+}
+
+func (s *deviceSessionSuite) TestEnsureDeviceSessionRenewedNoop(c *C) {
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	obtained := s.now
+
+	// the store has no way to renew the session
+	s.renewNoop = true
+	s.now = s.now.Add(7 * 24 * time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 1)
+
+	status := s.sessionStatus(c)
+	c.Check(status.Obtained.Equal(obtained), Equals, true)
+	c.Check(status.LastRenewal.Equal(s.now), Equals, true)
+	c.Check(status.LastRenewalError, Equals, "")
+	c.Check(status.RenewAfter.Equal(s.now.Add(time.Hour)), Equals, true)
+
+	// not attempted again on every ensure
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 1)
+
+	// but only after the retry delay
+	s.now = s.now.Add(time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 2)
+}
+
+func (s *deviceSessionSuite) TestEnsureDeviceSessionRenewedNoSession(c *C) {
+	s.state.Lock()
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand:  "canonical",
+		Model:  "pc-model",
+		Serial: "serial",
+	})
+	s.state.Unlock()
+
+	s.now = s.now.Add(30 * 24 * time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 0)
+	c.Check(s.sessionStatus(c), IsNil)
+}
+
+func (s *deviceSessionSuite) TestEnsureDeviceSessionRenewedNotSeeded(c *C) {
+	s.state.Lock()
+	s.state.Set("seeded", false)
+	s.state.Unlock()
+
+	s.now = s.now.Add(30 * 24 * time.Hour)
+	c.Assert(devicestate.EnsureDeviceSessionRenewed(s.mgr), IsNil)
+	c.Check(s.renewals, Equals, 0)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	var status devicestate.DeviceSessionStatus
+	c.Check(s.state.Get("device-session-status", &status), testutil.ErrorIs, state.ErrNoState)
+}
@@ -96,6 +96,8 @@ func init() {
 	swfeats.RegisterEnsure("DeviceManager", "ensureTriedRecoverySystem")
 	swfeats.RegisterEnsure("DeviceManager", "ensurePostFactoryReset")
 	swfeats.RegisterEnsure("DeviceManager", "ensureExpiredUsersRemoved")
+	swfeats.RegisterEnsure("DeviceManager", "ensureSystemVolumesRelocked")
+	swfeats.RegisterEnsure("DeviceManager", "ensureDeviceSessionRenewed")
 }
 
 // EarlyConfig is a hook set by configstate that can process early configuration
@@ -255,6 +257,9 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 	runner.AddHandler("finalize-recovery-system", m.doFinalizeTriedRecoverySystem, m.undoFinalizeTriedRecoverySystem)
 	runner.AddCleanup("finalize-recovery-system", m.cleanupRecoverySystem)
 
+	// safe mode
+	runner.AddHandler("set-safe-mode", m.doSetSafeMode, m.undoSetSafeMode)
+
 	// used from the install API
 	// TODO: use better task names that are close to our usual pattern
 	runner.AddHandler("install-finish", m.doInstallFinish, nil)
@@ -1894,6 +1899,14 @@ func (m *DeviceManager) Ensure() error {
 		if err := m.ensureExpiredUsersRemoved(); err != nil {
 			errs = append(errs, err)
 		}
+
+		if err := m.ensureSystemVolumesRelocked(); err != nil {
+			errs = append(errs, err)
+		}
+
+		if err := m.ensureDeviceSessionRenewed(); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	if len(errs) > 0 {
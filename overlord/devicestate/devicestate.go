@@ -288,6 +288,7 @@ func delayedCrossMgrInit() {
 	snapstate.IsOnMeteredConnection = netutil.IsOnMeteredConnection
 	snapstate.DeviceCtx = DeviceCtx
 	snapstate.RemodelingChange = RemodelingChange
+	snapstate.EffectiveModelGrade = EffectiveModelGrade
 }
 
 // proxyStore returns the store assertion for the proxy store if one is set.
@@ -26,6 +26,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 	"gopkg.in/tomb.v2"
@@ -319,14 +320,17 @@ func (s *deviceMgrGadgetSuite) testUpdateGadgetSimple(c *C, grade string, encryp
 		m := st.Mode()
 		c.Assert(m.IsDir(), Equals, true)
 		c.Check(m.Perm(), Equals, os.FileMode(0750))
+		// the update is recorded
+		recorder, ok := observer.(*devicestate.BootAssetsRecorder)
+		c.Assert(ok, Equals, true, Commentf("unexpected type: %T", observer))
 		if grade == "" {
 			// non UC20 model
-			c.Check(observer, IsNil)
+			c.Check(recorder.RecordedObserver(), IsNil)
 		} else {
-			c.Check(observer, NotNil)
+			c.Check(recorder.RecordedObserver(), NotNil)
 			// expecting a very specific observer
-			trustedUpdateObserver, ok := observer.(*boot.TrustedAssetsUpdateObserver)
-			c.Assert(ok, Equals, true, Commentf("unexpected type: %T", observer))
+			trustedUpdateObserver, ok := recorder.RecordedObserver().(*boot.TrustedAssetsUpdateObserver)
+			c.Assert(ok, Equals, true, Commentf("unexpected type: %T", recorder.RecordedObserver()))
 			c.Assert(trustedUpdateObserver, NotNil)
 
 			// check that observer is behaving correctly with
@@ -412,6 +416,25 @@ func (s *deviceMgrGadgetSuite) testUpdateGadgetSimple(c *C, grade string, encryp
 	} else {
 		c.Check(s.restartRequests, DeepEquals, []restart.RestartType{expectedRst})
 	}
+
+	// the updated trusted asset is in the boot assets history
+	history, err := devicestate.BootAssetsHistory(s.state)
+	c.Assert(err, IsNil)
+	if grade == "" {
+		c.Check(history, HasLen, 0)
+	} else {
+		c.Assert(history, HasLen, 1)
+		c.Check(history[0].Time.IsZero(), Equals, false)
+		history[0].Time = time.Time{}
+		c.Check(history[0], DeepEquals, devicestate.BootAssetUpdate{
+			Asset:    "trusted-asset",
+			Role:     gadget.SystemSeed,
+			NewHash:  "88478d8afe6925b348b9cd00085f3535959fde7029a64d7841b031acc39415c690796757afab1852a9e09da913a0151b",
+			Snap:     "foo-gadget",
+			Revision: snap.R(34),
+			ChangeID: chg.ID(),
+		})
+	}
 }
 
 func (s *deviceMgrGadgetSuite) TestUpdateGadgetOnCoreSimple(c *C) {
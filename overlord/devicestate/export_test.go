@@ -695,3 +695,47 @@ func MockFdestateGetKeyslots(f func(st *state.State, keyslotRefs []fdestate.Keys
 func MockSnapstateGadgetInfo(f func(st *state.State, deviceCtx snapstate.DeviceContext) (*snap.Info, error)) (restore func()) {
 	return testutil.Mock(&snapstateGadgetInfo, f)
 }
+
+func EnsureDeviceSessionRenewed(m *DeviceManager) error {
+	return m.ensureDeviceSessionRenewed()
+}
+
+func MockDeviceSessionRenewal(jitter, retryDelay, warningInterval time.Duration) (restore func()) {
+	restore1 := testutil.Mock(&deviceSessionRenewalJitter, jitter)
+	restore2 := testutil.Mock(&deviceSessionRenewalRetryDelay, retryDelay)
+	restore3 := testutil.Mock(&deviceSessionRenewalWarningInterval, warningInterval)
+	return func() {
+		restore3()
+		restore2()
+		restore1()
+	}
+}
+
+func EnsureSystemVolumesRelocked(m *DeviceManager) error {
+	return m.ensureSystemVolumesRelocked()
+}
+
+func MockSystemVolumeMount(f func(what, where string, options ...string) error) (restore func()) {
+	return testutil.Mock(&systemVolumeMount, f)
+}
+
+func MockSystemVolumeUnmount(f func(where string) error) (restore func()) {
+	return testutil.Mock(&systemVolumeUnmount, f)
+}
+
+type BootAssetsRecorder = bootAssetsRecorder
+
+// RecordedObserver returns the observer wrapped by the boot assets recorder.
+func (r *BootAssetsRecorder) RecordedObserver() gadget.ContentUpdateObserver {
+	return r.observer
+}
+
+func (r *BootAssetsRecorder) Updates() []BootAssetUpdate {
+	return r.updates
+}
+
+var AddBootAssetUpdates = addBootAssetUpdates
+
+func MockMaxBootAssetUpdates(n int) (restore func()) {
+	return testutil.Mock(&maxBootAssetUpdates, n)
+}
@@ -191,26 +191,29 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 		updatePolicy = gadget.RemodelUpdatePolicy
 	}
 
+	// record which assets get updated, for diagnostics
+	recorder := &bootAssetsRecorder{}
+	resealed := false
 	err = func() error {
-		var updateObserver gadget.ContentUpdateObserver
 		observeTrustedBootAssets, err := boot.TrustedAssetsUpdateObserverForModel(model, updateData.RootDir)
 		if err != nil && err != boot.ErrObserverNotApplicable {
 			return fmt.Errorf("cannot setup asset update observer: %v", err)
 		}
 		if err == nil {
-			updateObserver = observeTrustedBootAssets
+			recorder.observer = observeTrustedBootAssets
 			defer observeTrustedBootAssets.Done()
 		}
 		// do not release the state lock, the update observer may
 		// attempt to modify modeenv inside, which implicitly is
 		// guarded by the state lock; on top of that we do not expect
 		// the update to be moving large amounts of data
-		if err := gadgetUpdate(model, *currentData, *updateData, snapRollbackDir, updatePolicy, updateObserver); err != nil {
+		if err := gadgetUpdate(model, *currentData, *updateData, snapRollbackDir, updatePolicy, recorder); err != nil {
 			return err
 		}
-		if updateObserver == nil {
+		if recorder.observer == nil {
 			return nil
 		}
+		resealed = observeTrustedBootAssets.Resealed()
 		return observeTrustedBootAssets.UpdateBootEntry()
 	}()
 	if err != nil {
@@ -226,6 +229,19 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 		logger.Noticef("failed to remove gadget update rollback directory %q: %v", snapRollbackDir, err)
 	}
 
+	now := timeNow()
+	for i := range recorder.updates {
+		update := &recorder.updates[i]
+		update.Snap = snapsup.InstanceName()
+		update.Revision = snapsup.Revision()
+		update.ChangeID = t.Change().ID()
+		update.Resealed = resealed
+		update.Time = now
+	}
+	if err := addBootAssetUpdates(st, recorder.updates); err != nil {
+		return err
+	}
+
 	// TODO: consider having the option to do this early via recovery in
 	// core20, have fallback code as well there
 	setGadgetRestartRequired(t)
@@ -295,7 +311,7 @@ func buildAppendedKernelCommandLine(t *state.Task, gd *gadget.GadgetData, device
 	}
 
 	// Dangerous extra cmdline only considered for dangerous models
-	if deviceCtx.Model().Grade() == asserts.ModelDangerous {
+	if EffectiveModelGrade(t.State(), deviceCtx) == asserts.ModelDangerous {
 		cmdlineAppendDanger, err := kernelCommandLineAppendArgs(t, tr,
 			"dangerous-cmdline-append")
 		if err != nil {
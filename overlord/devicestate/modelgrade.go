@@ -0,0 +1,165 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snapdenv"
+)
+
+// GradeBehavior describes a behavior of snapd that depends on the grade of
+// the model.
+type GradeBehavior struct {
+	Name        string
+	Description string
+	// Grades lists the model grades the behavior is active with.
+	Grades []asserts.ModelGrade
+	// Overridable is set if the behavior honors a model grade override,
+	// the other ones happen before the device can be reached.
+	Overridable bool
+}
+
+var gradeBehaviors = []GradeBehavior{{
+	Name:        "unasserted-device-snaps",
+	Description: "gadget and kernel snaps from the store can be replaced by unasserted ones",
+	Grades:      []asserts.ModelGrade{asserts.ModelDangerous},
+	Overridable: true,
+}, {
+	Name:        "dangerous-kernel-cmdline",
+	Description: "system.kernel.dangerous-cmdline-append is added to the kernel command line",
+	Grades:      []asserts.ModelGrade{asserts.ModelDangerous},
+	Overridable: true,
+}, {
+	Name:        "unasserted-seed-snaps",
+	Description: "the seed can contain unasserted snaps and snaps not listed in the model",
+	Grades:      []asserts.ModelGrade{asserts.ModelDangerous},
+}, {
+	Name:        "encryption-required",
+	Description: "installing requires the data partitions to be encrypted",
+	Grades:      []asserts.ModelGrade{asserts.ModelSecured},
+}}
+
+// ModelGradeStatus is the grade of the model, the testing override of it
+// if any and the behaviors of snapd that depend on them.
+type ModelGradeStatus struct {
+	Grade    asserts.ModelGrade
+	Override asserts.ModelGrade
+	// Active lists the names of the behaviors active for the effective
+	// grade.
+	Active    []string
+	Behaviors []GradeBehavior
+}
+
+// ModelGradeOverride returns the grade set to be simulated on this device
+// for testing, if any.
+func ModelGradeOverride(st *state.State) (asserts.ModelGrade, error) {
+	var grade asserts.ModelGrade
+	if err := st.Get("model-grade-override", &grade); err != nil && !errors.Is(err, state.ErrNoState) {
+		return "", err
+	}
+	return grade, nil
+}
+
+// SetModelGradeOverride simulates the given grade instead of the one of the
+// model for the behaviors that allow it. An empty grade removes the
+// override. This is only possible on devices under testing and for models
+// with a grade.
+func SetModelGradeOverride(st *state.State, grade asserts.ModelGrade) error {
+	if !snapdenv.Testing() {
+		return fmt.Errorf("cannot override the model grade outside of testing")
+	}
+	if grade == "" {
+		st.Set("model-grade-override", nil)
+		logger.Noticef("model grade override removed")
+		return nil
+	}
+	switch grade {
+	case asserts.ModelDangerous, asserts.ModelSigned, asserts.ModelSecured:
+	default:
+		return fmt.Errorf("cannot override the model grade with invalid grade %q", grade)
+	}
+
+	deviceCtx, err := DeviceCtx(st, nil, nil)
+	if err != nil {
+		return err
+	}
+	if deviceCtx.Model().Grade() == asserts.ModelGradeUnset {
+		return fmt.Errorf("cannot override the grade of a model without one")
+	}
+
+	st.Set("model-grade-override", grade)
+	logger.Noticef("TESTING: model grade overridden to %q", grade)
+	return nil
+}
+
+// EffectiveModelGrade returns the grade of the model as used by the
+// behaviors that honor a model grade override.
+func EffectiveModelGrade(st *state.State, deviceCtx snapstate.DeviceContext) asserts.ModelGrade {
+	grade := deviceCtx.Model().Grade()
+	if grade == asserts.ModelGradeUnset || deviceCtx.ForRemodeling() || !snapdenv.Testing() {
+		return grade
+	}
+	override, err := ModelGradeOverride(st)
+	if err != nil {
+		logger.Noticef("cannot read model grade override: %v", err)
+		return grade
+	}
+	if override != "" {
+		return override
+	}
+	return grade
+}
+
+// GetModelGradeStatus returns the grade of the model along with the
+// behaviors that depend on it.
+func GetModelGradeStatus(st *state.State) (*ModelGradeStatus, error) {
+	deviceCtx, err := DeviceCtx(st, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ModelGradeStatus{
+		Grade:     deviceCtx.Model().Grade(),
+		Behaviors: gradeBehaviors,
+	}
+	effective := EffectiveModelGrade(st, deviceCtx)
+	if effective != status.Grade {
+		status.Override = effective
+	}
+	for _, b := range gradeBehaviors {
+		grade := status.Grade
+		if b.Overridable {
+			grade = effective
+		}
+		for _, g := range b.Grades {
+			if g == grade {
+				status.Active = append(status.Active, b.Name)
+				break
+			}
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,111 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/snapdenv"
+)
+
+func (s *deviceMgrSuite) TestModelGradeStatus(c *C) {
+	s.setUC20PCModelInState(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	status, err := devicestate.GetModelGradeStatus(s.state)
+	c.Assert(err, IsNil)
+	c.Check(status.Grade, Equals, asserts.ModelDangerous)
+	c.Check(status.Override, Equals, asserts.ModelGrade(""))
+	c.Check(status.Active, DeepEquals, []string{
+		"unasserted-device-snaps",
+		"dangerous-kernel-cmdline",
+		"unasserted-seed-snaps",
+	})
+	c.Check(status.Behaviors, HasLen, 4)
+}
+
+func (s *deviceMgrSuite) TestModelGradeOverride(c *C) {
+	s.setUC20PCModelInState(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(devicestate.SetModelGradeOverride(s.state, asserts.ModelSigned), IsNil)
+
+	override, err := devicestate.ModelGradeOverride(s.state)
+	c.Assert(err, IsNil)
+	c.Check(override, Equals, asserts.ModelSigned)
+
+	deviceCtx, err := devicestate.DeviceCtx(s.state, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(devicestate.EffectiveModelGrade(s.state, deviceCtx), Equals, asserts.ModelSigned)
+
+	status, err := devicestate.GetModelGradeStatus(s.state)
+	c.Assert(err, IsNil)
+	c.Check(status.Grade, Equals, asserts.ModelDangerous)
+	c.Check(status.Override, Equals, asserts.ModelSigned)
+	// behaviors that happen before the device is reachable do not honor
+	// the override
+	c.Check(status.Active, DeepEquals, []string{"unasserted-seed-snaps"})
+
+	// and remove it
+	c.Assert(devicestate.SetModelGradeOverride(s.state, ""), IsNil)
+	c.Check(devicestate.EffectiveModelGrade(s.state, deviceCtx), Equals, asserts.ModelDangerous)
+	override, err = devicestate.ModelGradeOverride(s.state)
+	c.Assert(err, IsNil)
+	c.Check(override, Equals, asserts.ModelGrade(""))
+}
+
+func (s *deviceMgrSuite) TestModelGradeOverrideOnlyWhenTesting(c *C) {
+	s.setUC20PCModelInState(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(devicestate.SetModelGradeOverride(s.state, asserts.ModelSecured), IsNil)
+
+	restore := snapdenv.MockTesting(false)
+	defer restore()
+
+	err := devicestate.SetModelGradeOverride(s.state, asserts.ModelSigned)
+	c.Check(err, ErrorMatches, "cannot override the model grade outside of testing")
+
+	// a leftover override is ignored
+	deviceCtx, err := devicestate.DeviceCtx(s.state, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(devicestate.EffectiveModelGrade(s.state, deviceCtx), Equals, asserts.ModelDangerous)
+}
+
+func (s *deviceMgrSuite) TestModelGradeOverrideErrors(c *C) {
+	s.setPCModelInState(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	err := devicestate.SetModelGradeOverride(s.state, "very-dangerous")
+	c.Check(err, ErrorMatches, `cannot override the model grade with invalid grade "very-dangerous"`)
+
+	err = devicestate.SetModelGradeOverride(s.state, asserts.ModelSigned)
+	c.Check(err, ErrorMatches, "cannot override the grade of a model without one")
+}
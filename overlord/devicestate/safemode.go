@@ -0,0 +1,161 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"errors"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// SafeModeState records what entering safe mode did, so that it can be
+// reported and reverted when leaving safe mode.
+type SafeModeState struct {
+	Active  bool       `json:"active"`
+	Entered *time.Time `json:"entered,omitempty"`
+	Change  string     `json:"change,omitempty"`
+	// StoppedServices are the stopped and disabled services, as
+	// <snap>.<app>.
+	StoppedServices []string `json:"stopped-services,omitempty"`
+	// Disconnected are the IDs of the dropped connections.
+	Disconnected []string `json:"disconnected,omitempty"`
+	// HeldSnaps are the snaps whose refreshes were held.
+	HeldSnaps []string `json:"held-snaps,omitempty"`
+}
+
+// SafeMode returns the current safe mode state of the system.
+func SafeMode(st *state.State) (*SafeModeState, error) {
+	var sm SafeModeState
+	if err := st.Get("safe-mode", &sm); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return &sm, nil
+}
+
+// SetSafeModeTask returns a task recording the given safe mode state once
+// the tasks it is made to wait for are done. Entering safe mode is only
+// reported once the services are stopped and the connections dropped, the
+// refreshes of the snaps in HeldSnaps are held by the same task. Leaving
+// safe mode removes the holds that entering it put in place.
+func SetSafeModeTask(st *state.State, sm *SafeModeState) *state.Task {
+	summary := "Leave safe mode"
+	if sm.Active {
+		summary = "Enter safe mode"
+	}
+	t := st.NewTask("set-safe-mode", summary)
+	t.Set("safe-mode", sm)
+	return t
+}
+
+// holdSafeModeRefreshes holds the refreshes of those of the given snaps
+// that are still installed and returns them.
+func holdSafeModeRefreshes(st *state.State, snaps []string) ([]string, error) {
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return nil, err
+	}
+	var held []string
+	for _, name := range snaps {
+		if _, ok := snapStates[name]; ok {
+			held = append(held, name)
+		}
+	}
+	if len(held) == 0 {
+		return nil, nil
+	}
+	if err := snapstate.HoldRefreshesBySystem(st, snapstate.HoldGeneral, "forever", held); err != nil {
+		return nil, err
+	}
+	return held, nil
+}
+
+// setSafeMode makes sm the current safe mode state, holding or removing
+// the holds on refreshes as needed to go from prev to sm.
+func setSafeMode(t *state.Task, prev, sm *SafeModeState) error {
+	st := t.State()
+	if prev.Active && len(prev.HeldSnaps) > 0 {
+		if err := snapstate.ProceedWithRefresh(st, "system", prev.HeldSnaps); err != nil {
+			return err
+		}
+	}
+	if !sm.Active {
+		st.Set("safe-mode", nil)
+		return nil
+	}
+
+	held, err := holdSafeModeRefreshes(st, sm.HeldSnaps)
+	if err != nil {
+		return err
+	}
+	sm.HeldSnaps = held
+	st.Set("safe-mode", sm)
+	return nil
+}
+
+func (m *DeviceManager) doSetSafeMode(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var sm SafeModeState
+	if err := t.Get("safe-mode", &sm); err != nil {
+		return err
+	}
+	prev, err := SafeMode(st)
+	if err != nil {
+		return err
+	}
+	// remembered for undo
+	t.Set("old-safe-mode", prev)
+
+	if sm.Active {
+		now := timeNow()
+		sm.Entered = &now
+		sm.Change = t.Change().ID()
+	}
+	if err := setSafeMode(t, prev, &sm); err != nil {
+		return err
+	}
+	if sm.Active {
+		// the state is also available with the change
+		t.Change().Set("api-data", map[string]any{"safe-mode": &sm})
+	}
+	return nil
+}
+
+func (m *DeviceManager) undoSetSafeMode(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var prev SafeModeState
+	if err := t.Get("old-safe-mode", &prev); err != nil {
+		return err
+	}
+	sm, err := SafeMode(st)
+	if err != nil {
+		return err
+	}
+	return setSafeMode(t, sm, &prev)
+}
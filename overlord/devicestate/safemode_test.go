@@ -0,0 +1,159 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (s *deviceMgrSuite) mockSafeModeSnap(name string) {
+	si := &snap.SideInfo{RealName: name, Revision: snap.R(1)}
+	lastRefresh := time.Now()
+	snapstate.Set(s.state, name, &snapstate.SnapState{
+		Active:          true,
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:         si.Revision,
+		SnapType:        "app",
+		LastRefreshTime: &lastRefresh,
+	})
+}
+
+func (s *deviceMgrSuite) systemHeldSnaps(c *C) []string {
+	held, err := snapstate.HeldSnaps(s.state, snapstate.HoldGeneral)
+	c.Assert(err, IsNil)
+	var snaps []string
+	for name, holding := range held {
+		for _, h := range holding {
+			if h == "system" {
+				snaps = append(snaps, name)
+			}
+		}
+	}
+	return snaps
+}
+
+func (s *deviceMgrSuite) TestSetSafeModeTask(c *C) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	defer devicestate.MockTimeNow(func() time.Time { return now })()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockSafeModeSnap("foo")
+
+	sm, err := devicestate.SafeMode(s.state)
+	c.Assert(err, IsNil)
+	c.Check(sm.Active, Equals, false)
+
+	chg := s.state.NewChange("enter-safe-mode", "...")
+	stop := s.state.NewTask("stop-services", "...")
+	chg.AddTask(stop)
+	t := devicestate.SetSafeModeTask(s.state, &devicestate.SafeModeState{
+		Active:          true,
+		StoppedServices: []string{"foo.svc"},
+		// snaps removed in the meantime are not held
+		HeldSnaps: []string{"foo", "gone"},
+	})
+	t.WaitFor(stop)
+	chg.AddTask(t)
+
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	// nothing is recorded or held until the services are stopped
+	c.Check(t.Status(), Equals, state.DoStatus)
+	sm, err = devicestate.SafeMode(s.state)
+	c.Assert(err, IsNil)
+	c.Check(sm.Active, Equals, false)
+	c.Check(s.systemHeldSnaps(c), HasLen, 0)
+
+	stop.SetStatus(state.DoneStatus)
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	sm, err = devicestate.SafeMode(s.state)
+	c.Assert(err, IsNil)
+	c.Check(sm, DeepEquals, &devicestate.SafeModeState{
+		Active:          true,
+		Entered:         &now,
+		Change:          chg.ID(),
+		StoppedServices: []string{"foo.svc"},
+		HeldSnaps:       []string{"foo"},
+	})
+	c.Check(s.systemHeldSnaps(c), DeepEquals, []string{"foo"})
+
+	chg = s.state.NewChange("leave-safe-mode", "...")
+	chg.AddTask(devicestate.SetSafeModeTask(s.state, &devicestate.SafeModeState{}))
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	sm, err = devicestate.SafeMode(s.state)
+	c.Assert(err, IsNil)
+	c.Check(sm.Active, Equals, false)
+	c.Check(s.systemHeldSnaps(c), HasLen, 0)
+}
+
+func (s *deviceMgrSuite) TestSetSafeModeTaskUndo(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockSafeModeSnap("foo")
+
+	chg := s.state.NewChange("enter-safe-mode", "...")
+	t := devicestate.SetSafeModeTask(s.state, &devicestate.SafeModeState{
+		Active:    true,
+		HeldSnaps: []string{"foo"},
+	})
+	chg.AddTask(t)
+	terr := s.state.NewTask("error-trigger", "provoking total undo")
+	terr.WaitFor(t)
+	chg.AddTask(terr)
+
+	s.state.Unlock()
+	for i := 0; i < 3; i++ {
+		s.se.Ensure()
+		s.se.Wait()
+	}
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(t.Status(), Equals, state.UndoneStatus)
+	sm, err := devicestate.SafeMode(s.state)
+	c.Assert(err, IsNil)
+	c.Check(sm.Active, Equals, false)
+	// the holds are removed again
+	c.Check(s.systemHeldSnaps(c), HasLen, 0)
+}
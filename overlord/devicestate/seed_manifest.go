@@ -0,0 +1,272 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/sysdb"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/seed"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timings"
+)
+
+// ErrNotSeeded is returned when the seed manifest is requested before the
+// device was seeded.
+var ErrNotSeeded = errors.New("device is not seeded yet")
+
+// SeedManifestSnap describes a snap that was part of the seed the device was
+// seeded from.
+type SeedManifestSnap struct {
+	Name       string
+	SnapID     string
+	Revision   snap.Revision
+	Channel    string
+	Essential  bool
+	Required   bool
+	Components []string
+}
+
+// SeedManifest describes the composition of the seed the device was
+// originally seeded from.
+type SeedManifest struct {
+	// System is the label of the recovery system the device was seeded
+	// from, it is empty for devices without recovery systems.
+	System        string
+	BrandID       string
+	Model         string
+	ModelRevision int
+	Snaps         []SeedManifestSnap
+	Assertions    []*asserts.Ref
+}
+
+// SeedManifest loads the seed the device was seeded from and returns its
+// composition. The state lock is only held while looking up which seed was
+// used, loading the seed itself happens without it.
+func (m *DeviceManager) SeedManifest() (*SeedManifest, error) {
+	m.state.Lock()
+	label, err := seededSystemLabel(m.state)
+	m.state.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	deviceSeed, err := seedOpen(dirs.SnapSeedDir, label)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open seed: %v", err)
+	}
+
+	var refs []*asserts.Ref
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore:       asserts.NewMemoryBackstore(),
+		Trusted:         sysdb.Trusted(),
+		OtherPredefined: sysdb.Generic(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	commitTo := func(b *asserts.Batch) error {
+		return b.CommitToAndObserve(db, func(a asserts.Assertion) {
+			refs = append(refs, a.Ref())
+		}, nil)
+	}
+	if err := deviceSeed.LoadAssertions(db, commitTo); err != nil {
+		return nil, fmt.Errorf("cannot load seed assertions: %v", err)
+	}
+	if err := deviceSeed.LoadMeta(seed.AllModes, nil, timings.New(nil)); err != nil {
+		return nil, fmt.Errorf("cannot load seed metadata: %v", err)
+	}
+
+	model := deviceSeed.Model()
+	manifest := &SeedManifest{
+		System:        label,
+		BrandID:       model.BrandID(),
+		Model:         model.Model(),
+		ModelRevision: model.Revision(),
+	}
+	err = deviceSeed.Iter(func(sn *seed.Snap) error {
+		msn := SeedManifestSnap{
+			Name:      sn.SnapName(),
+			SnapID:    sn.ID(),
+			Revision:  sn.SideInfo.Revision,
+			Channel:   sn.Channel,
+			Essential: sn.Essential,
+			Required:  sn.Required,
+		}
+		for _, comp := range sn.Components {
+			msn.Components = append(msn.Components, comp.CompSideInfo.Component.ComponentName)
+		}
+		manifest.Snaps = append(manifest.Snaps, msn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(manifest.Snaps, func(i, j int) bool {
+		return manifest.Snaps[i].Name < manifest.Snaps[j].Name
+	})
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Type.Name != refs[j].Type.Name {
+			return refs[i].Type.Name < refs[j].Type.Name
+		}
+		return strings.Join(refs[i].PrimaryKey, "/") < strings.Join(refs[j].PrimaryKey, "/")
+	})
+	manifest.Assertions = refs
+
+	return manifest, nil
+}
+
+// seededSystemLabel returns the label of the recovery system used to seed the
+// device, or an empty label for devices without recovery systems.
+func seededSystemLabel(st *state.State) (string, error) {
+	var seeded bool
+	if err := st.Get("seeded", &seeded); err != nil && !errors.Is(err, state.ErrNoState) {
+		return "", err
+	}
+	if !seeded {
+		return "", ErrNotSeeded
+	}
+
+	sys, err := currentSeededSystem(st)
+	if err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			// no recovery systems on this device
+			return "", nil
+		}
+		return "", err
+	}
+	return sys.System, nil
+}
+
+// SeedSnapChange describes how a snap changed compared to the seed.
+type SeedSnapChange string
+
+const (
+	// SeedSnapUnchanged is used for seed snaps with the same revision and
+	// channel as in the seed.
+	SeedSnapUnchanged SeedSnapChange = "unchanged"
+	// SeedSnapRevisionChanged is used for seed snaps whose revision differs
+	// from the one in the seed.
+	SeedSnapRevisionChanged SeedSnapChange = "revision-changed"
+	// SeedSnapChannelChanged is used for seed snaps that are still at the
+	// seed revision but track a different channel.
+	SeedSnapChannelChanged SeedSnapChange = "channel-changed"
+	// SeedSnapRemoved is used for seed snaps that are not installed anymore.
+	SeedSnapRemoved SeedSnapChange = "removed"
+	// SeedSnapAdded is used for installed snaps that were not in the seed.
+	SeedSnapAdded SeedSnapChange = "added"
+)
+
+// SeedSnapDrift describes the difference between a snap in the seed and its
+// current state on the device.
+type SeedSnapDrift struct {
+	Name            string
+	Change          SeedSnapChange
+	SeedRevision    snap.Revision
+	SeedChannel     string
+	CurrentRevision snap.Revision
+	CurrentChannel  string
+}
+
+// SeedDrift describes how far the device has drifted from the seed it was
+// seeded from.
+type SeedDrift struct {
+	// ModelChanged is true if the device model is not the one from the
+	// seed anymore, e.g. after a remodel.
+	ModelChanged bool
+	Snaps        []SeedSnapDrift
+}
+
+// ComputeSeedDrift compares the given seed manifest with the current state
+// of the device.
+func ComputeSeedDrift(st *state.State, manifest *SeedManifest) (*SeedDrift, error) {
+	model, err := findModel(st)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+
+	drift := &SeedDrift{}
+	if model != nil {
+		drift.ModelChanged = model.BrandID() != manifest.BrandID ||
+			model.Model() != manifest.Model ||
+			model.Revision() != manifest.ModelRevision
+	}
+
+	all, err := snapstate.All(st)
+	if err != nil {
+		return nil, err
+	}
+
+	inSeed := make(map[string]bool, len(manifest.Snaps))
+	for _, sn := range manifest.Snaps {
+		inSeed[sn.Name] = true
+
+		d := SeedSnapDrift{
+			Name:         sn.Name,
+			SeedRevision: sn.Revision,
+			SeedChannel:  sn.Channel,
+		}
+		snapst, ok := all[sn.Name]
+		if !ok {
+			d.Change = SeedSnapRemoved
+			drift.Snaps = append(drift.Snaps, d)
+			continue
+		}
+
+		d.CurrentRevision = snapst.Current
+		d.CurrentChannel = snapst.TrackingChannel
+		switch {
+		case snapst.Current != sn.Revision:
+			d.Change = SeedSnapRevisionChanged
+		case sn.Channel != "" && snapst.TrackingChannel != sn.Channel:
+			d.Change = SeedSnapChannelChanged
+		default:
+			d.Change = SeedSnapUnchanged
+		}
+		drift.Snaps = append(drift.Snaps, d)
+	}
+
+	var added []SeedSnapDrift
+	for name, snapst := range all {
+		if inSeed[name] {
+			continue
+		}
+		added = append(added, SeedSnapDrift{
+			Name:            name,
+			Change:          SeedSnapAdded,
+			CurrentRevision: snapst.Current,
+			CurrentChannel:  snapst.TrackingChannel,
+		})
+	}
+	sort.Slice(added, func(i, j int) bool {
+		return added[i].Name < added[j].Name
+	})
+	drift.Snaps = append(drift.Snaps, added...)
+
+	return drift, nil
+}
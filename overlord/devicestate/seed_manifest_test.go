@@ -0,0 +1,288 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/sysdb"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/seed"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type seedManifestSuite struct {
+	deviceMgrBaseSuite
+}
+
+var _ = Suite(&seedManifestSuite{})
+
+func (s *seedManifestSuite) SetUpTest(c *C) {
+	classic := false
+	s.setupBaseTest(c, classic)
+
+	s.AddCleanup(sysdb.InjectTrusted(s.storeSigning.Trusted))
+}
+
+// iterSeed is a fakeSeed that commits the given assertions when loading them
+// and iterates over all of its snaps.
+type iterSeed struct {
+	fakeSeed
+	assertions []asserts.Assertion
+}
+
+func (s *iterSeed) LoadAssertions(db asserts.RODatabase, commitTo func(*asserts.Batch) error) error {
+	b := asserts.NewBatch(nil)
+	for _, a := range s.assertions {
+		if err := b.Add(a); err != nil {
+			return err
+		}
+	}
+	return commitTo(b)
+}
+
+func (s *iterSeed) Iter(f func(sn *seed.Snap) error) error {
+	for _, sn := range s.essentialSnaps {
+		if err := f(sn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *seedManifestSuite) mockSeed(c *C) *asserts.Model {
+	model := s.brands.Model("my-brand", "my-model", map[string]any{
+		"architecture": "amd64",
+		"base":         "core20",
+		"grade":        "dangerous",
+		"snaps": []any{
+			map[string]any{
+				"name":            "pc-kernel",
+				"id":              snaptest.AssertedSnapID("pc-kernel"),
+				"type":            "kernel",
+				"default-channel": "20",
+			},
+			map[string]any{
+				"name":            "pc",
+				"id":              snaptest.AssertedSnapID("pc"),
+				"type":            "gadget",
+				"default-channel": "20",
+			},
+		},
+	})
+
+	seedSnaps := []*seed.Snap{
+		{
+			SideInfo:      &snap.SideInfo{RealName: "pc", Revision: snap.R(1), SnapID: snaptest.AssertedSnapID("pc")},
+			EssentialType: snap.TypeGadget,
+			Essential:     true,
+			Required:      true,
+			Channel:       "20/stable",
+		},
+		{
+			SideInfo:      &snap.SideInfo{RealName: "pc-kernel", Revision: snap.R(2), SnapID: snaptest.AssertedSnapID("pc-kernel")},
+			EssentialType: snap.TypeKernel,
+			Essential:     true,
+			Required:      true,
+			Channel:       "20/stable",
+		},
+		{
+			SideInfo: &snap.SideInfo{RealName: "core20", Revision: snap.R(3), SnapID: snaptest.AssertedSnapID("core20")},
+			Channel:  "latest/stable",
+		},
+		{
+			SideInfo: &snap.SideInfo{RealName: "hello", Revision: snap.R(4)},
+		},
+	}
+
+	assertions := []asserts.Assertion{s.storeSigning.StoreAccountKey("")}
+	assertions = append(assertions, s.brands.AccountsAndKeys("my-brand")...)
+	assertions = append(assertions, model)
+	s.AddCleanup(devicestate.MockSeedOpen(func(seedDir, label string) (seed.Seed, error) {
+		c.Check(label, Equals, "20250101")
+		return &iterSeed{
+			fakeSeed: fakeSeed{
+				essentialSnaps: seedSnaps,
+				model:          model,
+			},
+			assertions: assertions,
+		}, nil
+	}))
+
+	return model
+}
+
+func (s *seedManifestSuite) setSeeded(model *asserts.Model) {
+	s.state.Set("seeded", true)
+	s.state.Set("seeded-systems", []devicestate.SeededSystem{
+		{
+			System:    "20250101",
+			Model:     model.Model(),
+			BrandID:   model.BrandID(),
+			Revision:  model.Revision(),
+			Timestamp: model.Timestamp(),
+		},
+	})
+}
+
+func (s *seedManifestSuite) TestSeedManifest(c *C) {
+	model := s.mockSeed(c)
+
+	s.state.Lock()
+	s.setSeeded(model)
+	s.state.Unlock()
+
+	manifest, err := s.mgr.SeedManifest()
+	c.Assert(err, IsNil)
+	c.Check(manifest.System, Equals, "20250101")
+	c.Check(manifest.BrandID, Equals, "my-brand")
+	c.Check(manifest.Model, Equals, "my-model")
+	c.Check(manifest.ModelRevision, Equals, 0)
+	c.Check(manifest.Snaps, DeepEquals, []devicestate.SeedManifestSnap{
+		{Name: "core20", SnapID: snaptest.AssertedSnapID("core20"), Revision: snap.R(3), Channel: "latest/stable"},
+		{Name: "hello", Revision: snap.R(4)},
+		{Name: "pc", SnapID: snaptest.AssertedSnapID("pc"), Revision: snap.R(1), Channel: "20/stable", Essential: true, Required: true},
+		{Name: "pc-kernel", SnapID: snaptest.AssertedSnapID("pc-kernel"), Revision: snap.R(2), Channel: "20/stable", Essential: true, Required: true},
+	})
+
+	var types []string
+	for _, ref := range manifest.Assertions {
+		types = append(types, ref.Type.Name)
+	}
+	c.Check(types, DeepEquals, []string{"account", "account-key", "account-key", "model"})
+	c.Check(manifest.Assertions[3].PrimaryKey, DeepEquals, []string{"16", "my-brand", "my-model"})
+}
+
+func (s *seedManifestSuite) TestSeedManifestNotSeeded(c *C) {
+	_, err := s.mgr.SeedManifest()
+	c.Assert(err, Equals, devicestate.ErrNotSeeded)
+}
+
+func (s *seedManifestSuite) TestSeedManifestOpenError(c *C) {
+	s.AddCleanup(devicestate.MockSeedOpen(func(seedDir, label string) (seed.Seed, error) {
+		return nil, errors.New("boom")
+	}))
+
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	s.state.Unlock()
+
+	_, err := s.mgr.SeedManifest()
+	c.Assert(err, ErrorMatches, "cannot open seed: boom")
+}
+
+func (s *seedManifestSuite) TestComputeSeedDrift(c *C) {
+	model := s.mockSeed(c)
+
+	s.state.Lock()
+	s.setSeeded(model)
+	s.state.Unlock()
+
+	manifest, err := s.mgr.SeedManifest()
+	c.Assert(err, IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.makeModelAssertionInState(c, "my-brand", "my-model", map[string]any{
+		"architecture": "amd64",
+		"base":         "core20",
+		"grade":        "dangerous",
+		"revision":     "1",
+		"snaps":        model.Header("snaps"),
+	})
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand: "my-brand",
+		Model: "my-model",
+	})
+
+	for _, sn := range []struct {
+		name    string
+		rev     snap.Revision
+		channel string
+	}{
+		// unchanged
+		{"pc", snap.R(1), "20/stable"},
+		// refreshed
+		{"pc-kernel", snap.R(12), "20/stable"},
+		// switched channel, but no refresh yet
+		{"core20", snap.R(3), "latest/edge"},
+		// not part of the seed
+		{"other", snap.R(5), "latest/stable"},
+		// hello was removed
+	} {
+		si := &snap.SideInfo{RealName: sn.name, Revision: sn.rev}
+		snapstate.Set(s.state, sn.name, &snapstate.SnapState{
+			Active:          true,
+			Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+			Current:         sn.rev,
+			TrackingChannel: sn.channel,
+		})
+	}
+
+	drift, err := devicestate.ComputeSeedDrift(s.state, manifest)
+	c.Assert(err, IsNil)
+	c.Check(drift.ModelChanged, Equals, true)
+	c.Check(drift.Snaps, DeepEquals, []devicestate.SeedSnapDrift{
+		{
+			Name:            "core20",
+			Change:          devicestate.SeedSnapChannelChanged,
+			SeedRevision:    snap.R(3),
+			SeedChannel:     "latest/stable",
+			CurrentRevision: snap.R(3),
+			CurrentChannel:  "latest/edge",
+		},
+		{
+			Name:         "hello",
+			Change:       devicestate.SeedSnapRemoved,
+			SeedRevision: snap.R(4),
+		},
+		{
+			Name:            "pc",
+			Change:          devicestate.SeedSnapUnchanged,
+			SeedRevision:    snap.R(1),
+			SeedChannel:     "20/stable",
+			CurrentRevision: snap.R(1),
+			CurrentChannel:  "20/stable",
+		},
+		{
+			Name:            "pc-kernel",
+			Change:          devicestate.SeedSnapRevisionChanged,
+			SeedRevision:    snap.R(2),
+			SeedChannel:     "20/stable",
+			CurrentRevision: snap.R(12),
+			CurrentChannel:  "20/stable",
+		},
+		{
+			Name:            "other",
+			Change:          devicestate.SeedSnapAdded,
+			CurrentRevision: snap.R(5),
+			CurrentChannel:  "latest/stable",
+		},
+	})
+}
@@ -0,0 +1,107 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/storecontext"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// StoreRouting describes the stores snaps resolve to on the device.
+type StoreRouting struct {
+	// DeviceStore is the store of the device, empty for the global
+	// store.
+	DeviceStore string
+	// FriendlyStores are the stores listed as friendly by the store
+	// assertion of the device store, which snaps can be routed to.
+	FriendlyStores []string
+	// Routes are the stores snaps are explicitly routed to, by snap
+	// name.
+	Routes map[string]string
+}
+
+// SnapStore returns the store the given snap resolves to, and whether
+// that is because of an explicit route.
+func (r *StoreRouting) SnapStore(snapName string) (storeID string, routed bool) {
+	if storeID, ok := r.Routes[snapName]; ok {
+		return storeID, true
+	}
+	if r.DeviceStore == "" {
+		return storecontext.GlobalStoreID, false
+	}
+	return r.DeviceStore, false
+}
+
+// SnapStoreRouting returns the store routing of the device.
+func SnapStoreRouting(st *state.State) (*StoreRouting, error) {
+	model, err := findModel(st)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	routing := &StoreRouting{
+		DeviceStore: storecontext.StoreID(model),
+	}
+
+	if routing.DeviceStore != "" {
+		sto, err := assertstate.Store(st, routing.DeviceStore)
+		if err != nil && !errors.Is(err, &asserts.NotFoundError{}) {
+			return nil, err
+		}
+		if sto != nil {
+			routing.FriendlyStores = sto.FriendlyStores()
+		}
+	}
+
+	routing.Routes, err = storecontext.SnapStoreRoutes(st)
+	if err != nil {
+		return nil, err
+	}
+	return routing, nil
+}
+
+// RouteSnapToStore routes the store requests concerning the given snap
+// to the given store, or removes its explicit route if storeID is
+// empty. Snaps can only be routed to the device store, to the stores
+// listed as friendly by the store assertion of the device store, or to
+// the global store using storecontext.GlobalStoreID.
+func RouteSnapToStore(st *state.State, snapName, storeID string) error {
+	if err := naming.ValidateSnap(snapName); err != nil {
+		return err
+	}
+	if storeID == "" {
+		return storecontext.SetSnapStoreRoute(st, snapName, "")
+	}
+
+	routing, err := SnapStoreRouting(st)
+	if err != nil {
+		return err
+	}
+	if storeID != storecontext.GlobalStoreID && storeID != routing.DeviceStore && !strutil.ListContains(routing.FriendlyStores, storeID) {
+		return fmt.Errorf("cannot route snap %q to store %q: store is neither the device store, a friendly store of it, nor the global store", snapName, storeID)
+	}
+	return storecontext.SetSnapStoreRoute(st, snapName, storeID)
+}
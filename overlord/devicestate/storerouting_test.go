@@ -0,0 +1,125 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/overlord/assertstate/assertstatetest"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
+	"github.com/snapcore/snapd/overlord/storecontext"
+)
+
+func (s *deviceMgrSuite) setBrandStoreModelInState(c *C) {
+	operatorAcct := assertstest.NewAccount(s.storeSigning, "brand-operator", nil, "")
+	stoAs, err := s.storeSigning.Sign(asserts.StoreType, map[string]any{
+		"store":           "brand-store",
+		"operator-id":     operatorAcct.AccountID(),
+		"friendly-stores": []any{"friendly-store"},
+		"timestamp":       time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	assertstatetest.AddMany(s.state, operatorAcct, stoAs)
+
+	s.makeModelAssertionInState(c, "canonical", "pc", map[string]any{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+		"store":        "brand-store",
+	})
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand: "canonical",
+		Model: "pc",
+	})
+}
+
+func (s *deviceMgrSuite) TestSnapStoreRouting(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.setBrandStoreModelInState(c)
+
+	c.Assert(devicestate.RouteSnapToStore(s.state, "foo", "friendly-store"), IsNil)
+	c.Assert(devicestate.RouteSnapToStore(s.state, "bar", storecontext.GlobalStoreID), IsNil)
+
+	routing, err := devicestate.SnapStoreRouting(s.state)
+	c.Assert(err, IsNil)
+	c.Check(routing, DeepEquals, &devicestate.StoreRouting{
+		DeviceStore:    "brand-store",
+		FriendlyStores: []string{"friendly-store"},
+		Routes: map[string]string{
+			"foo": "friendly-store",
+			"bar": "global",
+		},
+	})
+
+	for _, t := range []struct {
+		snap    string
+		storeID string
+		routed  bool
+	}{
+		{"foo", "friendly-store", true},
+		{"bar", "global", true},
+		{"baz", "brand-store", false},
+	} {
+		storeID, routed := routing.SnapStore(t.snap)
+		c.Check(storeID, Equals, t.storeID, Commentf(t.snap))
+		c.Check(routed, Equals, t.routed, Commentf(t.snap))
+	}
+
+	// removing the route
+	c.Assert(devicestate.RouteSnapToStore(s.state, "foo", ""), IsNil)
+	routes, err := storecontext.SnapStoreRoutes(s.state)
+	c.Assert(err, IsNil)
+	c.Check(routes, DeepEquals, map[string]string{"bar": "global"})
+}
+
+func (s *deviceMgrSuite) TestRouteSnapToStoreConstraints(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.setBrandStoreModelInState(c)
+
+	err := devicestate.RouteSnapToStore(s.state, "foo", "other-store")
+	c.Check(err, ErrorMatches, `cannot route snap "foo" to store "other-store": store is neither the device store, a friendly store of it, nor the global store`)
+	err = devicestate.RouteSnapToStore(s.state, "Foo!", "friendly-store")
+	c.Check(err, ErrorMatches, `invalid snap name: "Foo!"`)
+
+	routes, err := storecontext.SnapStoreRoutes(s.state)
+	c.Assert(err, IsNil)
+	c.Check(routes, HasLen, 0)
+}
+
+func (s *deviceMgrSuite) TestSnapStoreRoutingGlobalStore(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	routing, err := devicestate.SnapStoreRouting(s.state)
+	c.Assert(err, IsNil)
+	c.Check(routing.DeviceStore, Equals, "")
+	c.Check(routing.FriendlyStores, HasLen, 0)
+	storeID, routed := routing.SnapStore("foo")
+	c.Check(storeID, Equals, "global")
+	c.Check(routed, Equals, false)
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/historystate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
@@ -150,9 +151,23 @@ func RemoveUser(st *state.State, username string, opts *RemoveUserOptions) (*aut
 	if err != nil && err != auth.ErrInvalidUser {
 		return nil, err
 	}
+	recordUserHistory(st, historystate.OperationRemoveUser, fmt.Sprintf("Remove user %q", username), username)
 	return u, nil
 }
 
+// recordUserHistory records a user management operation in the
+// operation history, failures are only logged.
+func recordUserHistory(st *state.State, op, summary, username string) {
+	entry := &historystate.Entry{
+		Operation: op,
+		Summary:   summary,
+		User:      username,
+	}
+	if err := historystate.Record(st, entry); err != nil {
+		logger.Noticef("cannot record %s of user %q in history: %v", op, username, err)
+	}
+}
+
 func getUserDetailsFromStore(st *state.State, theStore snapstate.StoreService, email string) (string, *osutil.AddUserOptions, error) {
 	st.Unlock()
 	defer st.Lock()
@@ -342,6 +357,7 @@ func addUser(state *state.State, username string, email string, expiration time.
 	if err := setupLocalUser(state, username, email, expiration); err != nil {
 		return nil, err
 	}
+	recordUserHistory(state, historystate.OperationCreateUser, fmt.Sprintf("Create user %q", username), username)
 
 	return &CreatedUser{
 		Username: username,
@@ -46,18 +46,9 @@ type VolumeStructureWithKeyslots struct {
 //
 // The state needs to be locked by the caller.
 func GetVolumeStructuresWithKeyslots(st *state.State) ([]VolumeStructureWithKeyslots, error) {
-	deviceCtx, err := DeviceCtx(st, nil, nil)
+	gadgetInfo, err := currentGadgetInfo(st)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get device context: %v", err)
-	}
-	gadgetSnapInfo, err := snapstateGadgetInfo(st, deviceCtx)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get gadget snap info: %v", err)
-	}
-
-	gadgetInfo, err := gadget.ReadInfo(gadgetSnapInfo.MountDir(), deviceCtx.Model())
-	if err != nil {
-		return nil, fmt.Errorf("cannot read gadget: %v", err)
+		return nil, err
 	}
 
 	keyslots, _, err := fdestateGetKeyslots(st, nil)
@@ -81,3 +72,21 @@ func GetVolumeStructuresWithKeyslots(st *state.State) ([]VolumeStructureWithKeys
 
 	return structuresWithKeyslots, nil
 }
+
+// currentGadgetInfo reads the gadget information of the current gadget snap.
+func currentGadgetInfo(st *state.State) (*gadget.Info, error) {
+	deviceCtx, err := DeviceCtx(st, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get device context: %v", err)
+	}
+	gadgetSnapInfo, err := snapstateGadgetInfo(st, deviceCtx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get gadget snap info: %v", err)
+	}
+
+	gadgetInfo, err := gadget.ReadInfo(gadgetSnapInfo.MountDir(), deviceCtx.Model())
+	if err != nil {
+		return nil, fmt.Errorf("cannot read gadget: %v", err)
+	}
+	return gadgetInfo, nil
+}
@@ -0,0 +1,416 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/systemd"
+)
+
+var (
+	// DefaultSystemVolumeRelockAfter is the time after which a system
+	// volume mounted on demand is unmounted again if not specified
+	// otherwise.
+	DefaultSystemVolumeRelockAfter = 10 * time.Minute
+	// MaxSystemVolumeRelockAfter is the maximum time a system volume can
+	// stay mounted on demand.
+	MaxSystemVolumeRelockAfter = time.Hour
+	// maxSystemVolumeUnmountAttempts is the number of times unmounting
+	// a system volume at its relock time is attempted before giving up
+	// on it.
+	maxSystemVolumeUnmountAttempts = 5
+
+	systemVolumeMount = func(what, where string, options ...string) error {
+		return systemd.New(systemd.SystemMode, nil).Mount(what, where, options...)
+	}
+	systemVolumeUnmount = func(where string) error {
+		return systemd.New(systemd.SystemMode, nil).Umount(where)
+	}
+)
+
+// systemVolumesCmdLock serializes the external commands (systemd-mount,
+// umount, cryptsetup) run on system volumes. It is only ever taken with
+// the state unlocked.
+var systemVolumesCmdLock sync.Mutex
+
+// runSystemVolumeCmd runs f with the state unlocked, holding the lock
+// serializing the commands run on system volumes.
+func runSystemVolumeCmd(st *state.State, f func() error) error {
+	st.Unlock()
+	defer st.Lock()
+	systemVolumesCmdLock.Lock()
+	defer systemVolumesCmdLock.Unlock()
+	return f()
+}
+
+type systemVolumesBusyKey struct{}
+
+// systemVolumesBusy returns the set of system volumes which are being
+// mounted or unmounted with the state unlocked.
+func systemVolumesBusy(st *state.State) map[string]bool {
+	busy, _ := st.Cached(systemVolumesBusyKey{}).(map[string]bool)
+	if busy == nil {
+		busy = make(map[string]bool)
+		st.Cache(systemVolumesBusyKey{}, busy)
+	}
+	return busy
+}
+
+// ErrSystemVolumeNotMounted is returned when unmounting a system volume
+// that was not mounted on demand.
+var ErrSystemVolumeNotMounted = errors.New("system volume is not mounted")
+
+// SystemVolumeMount describes a system volume mounted on demand.
+type SystemVolumeMount struct {
+	Name       string    `json:"name"`
+	Role       string    `json:"role,omitempty"`
+	Device     string    `json:"device"`
+	MountPoint string    `json:"mount-point"`
+	ReadOnly   bool      `json:"read-only,omitempty"`
+	RelockTime time.Time `json:"relock-time"`
+	// UnmountAttempts is the number of failed attempts to unmount the
+	// volume once its relock time was reached.
+	UnmountAttempts int `json:"unmount-attempts,omitempty"`
+}
+
+// MountSystemVolumeOptions holds options for MountSystemVolume.
+type MountSystemVolumeOptions struct {
+	// ReadOnly mounts the volume read-only.
+	ReadOnly bool
+	// RelockAfter is the time after which the volume is unmounted
+	// again, DefaultSystemVolumeRelockAfter is used if unset.
+	RelockAfter time.Duration
+}
+
+func systemVolumeMounts(st *state.State) (map[string]*SystemVolumeMount, error) {
+	var mounts map[string]*SystemVolumeMount
+	if err := st.Get("system-volume-mounts", &mounts); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if mounts == nil {
+		mounts = make(map[string]*SystemVolumeMount)
+	}
+	return mounts, nil
+}
+
+func setSystemVolumeMounts(st *state.State, mounts map[string]*SystemVolumeMount) {
+	if len(mounts) == 0 {
+		st.Set("system-volume-mounts", nil)
+		return
+	}
+	st.Set("system-volume-mounts", mounts)
+}
+
+// SystemVolumeMounts returns the system volumes currently mounted on
+// demand, sorted by name.
+//
+// The state needs to be locked by the caller.
+func SystemVolumeMounts(st *state.State) ([]*SystemVolumeMount, error) {
+	mounts, err := systemVolumeMounts(st)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*SystemVolumeMount, 0, len(mounts))
+	for _, m := range mounts {
+		res = append(res, m)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// mountableSystemVolume finds the gadget structure with the given name
+// that can be mounted on demand, that is ubuntu-save.
+func mountableSystemVolume(st *state.State, name string) (*gadget.VolumeStructure, error) {
+	gadgetInfo, err := currentGadgetInfo(st)
+	if err != nil {
+		return nil, err
+	}
+	for _, gv := range gadgetInfo.Volumes {
+		for i := range gv.Structure {
+			gs := &gv.Structure[i]
+			if gs.Name != name {
+				continue
+			}
+			if gs.Role != gadget.SystemSave {
+				return nil, fmt.Errorf("system volume %q cannot be mounted on demand", name)
+			}
+			return gs, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot find system volume %q in gadget", name)
+}
+
+// mountedSystemVolume returns the mount entry of the given device if it
+// is mounted already.
+func mountedSystemVolume(device string) (*osutil.MountInfoEntry, error) {
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := osutil.LoadMountInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.MountSource == device || entry.MountSource == resolved {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// MountSystemVolume mounts the ubuntu-save volume, identified by its gadget
+// structure name. The volume is unmounted again automatically once the
+// relock time is reached. Mounting an already mounted volume extends its
+// relock time. If the volume is mounted already by other means, as is the
+// case for ubuntu-save once the system is installed, it is not mounted
+// again and its existing mount point is returned without a relock time.
+//
+// The state needs to be locked by the caller, it is unlocked while the
+// volume is mounted.
+func MountSystemVolume(st *state.State, name string, opts *MountSystemVolumeOptions) (*SystemVolumeMount, error) {
+	if opts == nil {
+		opts = &MountSystemVolumeOptions{}
+	}
+	relockAfter := opts.RelockAfter
+	if relockAfter == 0 {
+		relockAfter = DefaultSystemVolumeRelockAfter
+	}
+	if relockAfter < 0 || relockAfter > MaxSystemVolumeRelockAfter {
+		return nil, fmt.Errorf("cannot mount system volume %q: relock time must be positive and at most %s", name, MaxSystemVolumeRelockAfter)
+	}
+
+	busy := systemVolumesBusy(st)
+	if busy[name] {
+		return nil, fmt.Errorf("cannot mount system volume %q: another operation is in progress", name)
+	}
+	mounts, err := systemVolumeMounts(st)
+	if err != nil {
+		return nil, err
+	}
+	if m := mounts[name]; m != nil {
+		if m.ReadOnly != opts.ReadOnly {
+			return nil, fmt.Errorf("cannot mount system volume %q: already mounted with different options", name)
+		}
+		m.RelockTime = timeNow().Add(relockAfter)
+		setSystemVolumeMounts(st, mounts)
+		st.EnsureBefore(relockAfter)
+		return m, nil
+	}
+
+	gs, err := mountableSystemVolume(st, name)
+	if err != nil {
+		return nil, err
+	}
+	if gs.Label == "" {
+		return nil, fmt.Errorf("cannot mount system volume %q: no filesystem label", name)
+	}
+	device := filepath.Join(dirs.GlobalRootDir, "/dev/disk/by-label", gs.Label)
+	if !osutil.FileExists(device) {
+		return nil, fmt.Errorf("cannot mount system volume %q: device %s not found", name, device)
+	}
+	existing, err := mountedSystemVolume(device)
+	if err != nil {
+		return nil, fmt.Errorf("cannot mount system volume %q: %v", name, err)
+	}
+	if existing != nil {
+		_, ro := existing.MountOptions["ro"]
+		return &SystemVolumeMount{
+			Name:       name,
+			Role:       gs.Role,
+			Device:     device,
+			MountPoint: existing.MountDir,
+			ReadOnly:   ro,
+		}, nil
+	}
+
+	mountPoint := filepath.Join(dirs.SnapRunDir, "system-volumes", name)
+	if err := os.MkdirAll(mountPoint, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create mount point for system volume %q: %v", name, err)
+	}
+	var options []string
+	if opts.ReadOnly {
+		options = append(options, "--options=ro")
+	}
+	busy[name] = true
+	err = runSystemVolumeCmd(st, func() error {
+		return systemVolumeMount(device, mountPoint, options...)
+	})
+	delete(busy, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot mount system volume %q: %v", name, err)
+	}
+
+	m := &SystemVolumeMount{
+		Name:       name,
+		Role:       gs.Role,
+		Device:     device,
+		MountPoint: mountPoint,
+		ReadOnly:   opts.ReadOnly,
+		RelockTime: timeNow().Add(relockAfter),
+	}
+	// the state was unlocked while mounting
+	mounts, err = systemVolumeMounts(st)
+	if err != nil {
+		return nil, err
+	}
+	mounts[name] = m
+	setSystemVolumeMounts(st, mounts)
+	st.EnsureBefore(relockAfter)
+	return m, nil
+}
+
+// UnmountSystemVolume unmounts a system volume previously mounted with
+// MountSystemVolume. ErrSystemVolumeNotMounted is returned if the volume
+// is not mounted.
+//
+// The state needs to be locked by the caller, it is unlocked while the
+// volume is unmounted.
+func UnmountSystemVolume(st *state.State, name string) error {
+	busy := systemVolumesBusy(st)
+	if busy[name] {
+		return fmt.Errorf("cannot unmount system volume %q: another operation is in progress", name)
+	}
+	mounts, err := systemVolumeMounts(st)
+	if err != nil {
+		return err
+	}
+	m := mounts[name]
+	if m == nil {
+		return ErrSystemVolumeNotMounted
+	}
+	busy[name] = true
+	err = runSystemVolumeCmd(st, func() error {
+		return systemVolumeUnmount(m.MountPoint)
+	})
+	delete(busy, name)
+	if err != nil {
+		return fmt.Errorf("cannot unmount system volume %q: %v", name, err)
+	}
+	// the state was unlocked while unmounting
+	mounts, err = systemVolumeMounts(st)
+	if err != nil {
+		return err
+	}
+	delete(mounts, name)
+	setSystemVolumeMounts(st, mounts)
+	return nil
+}
+
+// ensureSystemVolumesRelocked unmounts the system volumes mounted on
+// demand whose relock time was reached. Volumes which cannot be unmounted
+// are retried on the next ensure, up to maxSystemVolumeUnmountAttempts
+// times, before they are forgotten with a warning.
+func (m *DeviceManager) ensureSystemVolumesRelocked() error {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	mounts, err := systemVolumeMounts(st)
+	if err != nil {
+		return err
+	}
+	if len(mounts) == 0 {
+		return nil
+	}
+
+	logger.Trace("ensure", "manager", "DeviceManager", "func", "ensureSystemVolumesRelocked")
+
+	now := timeNow()
+	var next time.Time
+	var due []*SystemVolumeMount
+	busy := systemVolumesBusy(st)
+	for name, mnt := range mounts {
+		if busy[name] {
+			continue
+		}
+		if mnt.RelockTime.After(now) {
+			if next.IsZero() || mnt.RelockTime.Before(next) {
+				next = mnt.RelockTime
+			}
+			continue
+		}
+		due = append(due, mnt)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Name < due[j].Name })
+
+	unmountErrs := make(map[string]error, len(due))
+	for _, mnt := range due {
+		busy[mnt.Name] = true
+	}
+	runSystemVolumeCmd(st, func() error {
+		for _, mnt := range due {
+			if err := systemVolumeUnmount(mnt.MountPoint); err != nil {
+				unmountErrs[mnt.Name] = err
+			}
+		}
+		return nil
+	})
+	for _, mnt := range due {
+		delete(busy, mnt.Name)
+	}
+
+	// the state was unlocked while unmounting
+	mounts, err = systemVolumeMounts(st)
+	if err != nil {
+		return err
+	}
+	for _, mnt := range due {
+		cur := mounts[mnt.Name]
+		if cur == nil {
+			continue
+		}
+		err := unmountErrs[mnt.Name]
+		if err == nil {
+			delete(mounts, mnt.Name)
+			continue
+		}
+		if mounted, merr := osutil.IsMounted(mnt.MountPoint); merr == nil && !mounted {
+			// gone already
+			delete(mounts, mnt.Name)
+			continue
+		}
+		cur.UnmountAttempts++
+		if cur.UnmountAttempts >= maxSystemVolumeUnmountAttempts {
+			st.Warnf("cannot unmount system volume %q, giving up: %v", mnt.Name, err)
+			delete(mounts, mnt.Name)
+			continue
+		}
+		logger.Noticef("cannot unmount system volume %q: %v", mnt.Name, err)
+		// try again on the next ensure
+	}
+	setSystemVolumeMounts(st, mounts)
+	if !next.IsZero() {
+		st.EnsureBefore(next.Sub(now))
+	}
+	return nil
+}
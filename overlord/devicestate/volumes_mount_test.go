@@ -0,0 +1,389 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type volumesMountSuite struct {
+	deviceMgrBaseSuite
+
+	now     time.Time
+	mounted map[string]string
+}
+
+var _ = Suite(&volumesMountSuite{})
+
+func (s *volumesMountSuite) SetUpTest(c *C) {
+	const classic = true
+	s.setupBaseTest(c, classic)
+
+	const snapYaml = `
+name: canonical-pc
+type: gadget
+version: 0.1
+`
+	var gadgetYaml = `
+volumes:
+  pc:
+    schema: gpt
+    bootloader: grub
+    structure:
+      - name: ubuntu-seed
+        role: system-seed
+        type: 0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        size: 1M
+      - name: ubuntu-boot
+        role: system-boot
+        type: 0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        size: 1M
+      - name: ubuntu-save
+        role: system-save
+        type: 0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        filesystem: ext4
+        filesystem-label: ubuntu-save
+        size: 1M
+      - name: factory-data
+        type: 0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        filesystem: ext4
+        filesystem-label: factory-data
+        size: 1M
+      - name: other-data
+        type: 0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        filesystem: ext4
+        filesystem-label: other-data
+        size: 1M
+      - name: ubuntu-data
+        role: system-data
+        type: 0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        size: 1M
+`
+	si := &snap.SideInfo{
+		RealName: "canonical-pc",
+		Revision: snap.R(14),
+		SnapID:   "ididid",
+	}
+	snapInfo := snaptest.MockSnapWithFiles(c, snapYaml, si, [][]string{
+		{"meta/gadget.yaml", gadgetYaml},
+	})
+
+	s.AddCleanup(devicestate.MockSnapstateGadgetInfo(func(st *state.State, deviceCtx snapstate.DeviceContext) (*snap.Info, error) {
+		return snapInfo, nil
+	}))
+
+	s.now = time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(devicestate.MockTimeNow(func() time.Time { return s.now }))
+
+	s.mounted = make(map[string]string)
+	s.AddCleanup(devicestate.MockSystemVolumeMount(func(what, where string, options ...string) error {
+		s.mounted[where] = what
+		return nil
+	}))
+	s.AddCleanup(devicestate.MockSystemVolumeUnmount(func(where string) error {
+		if _, ok := s.mounted[where]; !ok {
+			return errors.New("not mounted")
+		}
+		delete(s.mounted, where)
+		return nil
+	}))
+
+	s.AddCleanup(osutil.MockMountInfo(""))
+
+	for _, label := range []string{"ubuntu-save", "factory-data", "other-data"} {
+		c.Assert(os.MkdirAll(filepath.Join(dirs.GlobalRootDir, "/dev/disk/by-label"), 0755), IsNil)
+		c.Assert(os.WriteFile(filepath.Join(dirs.GlobalRootDir, "/dev/disk/by-label", label), nil, 0644), IsNil)
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	// mock model for DeviceCtx to work
+	s.makeModelAssertionInState(c, "canonical", "pc-model", map[string]any{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "canonical-pc",
+		"base":         "core24",
+	})
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand:  "canonical",
+		Model:  "pc-model",
+		Serial: "serial",
+	})
+}
+
+func (s *volumesMountSuite) TestMountSystemVolumeSave(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	m, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", nil)
+	c.Assert(err, IsNil)
+	device := filepath.Join(dirs.GlobalRootDir, "/dev/disk/by-label/ubuntu-save")
+	mountPoint := filepath.Join(dirs.SnapRunDir, "system-volumes/ubuntu-save")
+	c.Check(m, DeepEquals, &devicestate.SystemVolumeMount{
+		Name:       "ubuntu-save",
+		Role:       "system-save",
+		Device:     device,
+		MountPoint: mountPoint,
+		RelockTime: s.now.Add(devicestate.DefaultSystemVolumeRelockAfter),
+	})
+	c.Check(s.mounted, DeepEquals, map[string]string{mountPoint: device})
+	for _, p := range []string{mountPoint, filepath.Dir(mountPoint)} {
+		fi, err := os.Stat(p)
+		c.Assert(err, IsNil)
+		c.Check(fi.Mode().Perm(), Equals, os.FileMode(0755))
+	}
+
+	mounts, err := devicestate.SystemVolumeMounts(s.state)
+	c.Assert(err, IsNil)
+	c.Check(mounts, DeepEquals, []*devicestate.SystemVolumeMount{m})
+}
+
+func (s *volumesMountSuite) TestMountSystemVolumeUnlocksState(c *C) {
+	defer devicestate.MockSystemVolumeMount(func(what, where string, options ...string) error {
+		// the state can be locked while mounting
+		s.state.Lock()
+		defer s.state.Unlock()
+		_, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", nil)
+		c.Check(err, ErrorMatches, `cannot mount system volume "ubuntu-save": another operation is in progress`)
+		return nil
+	})()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", nil)
+	c.Assert(err, IsNil)
+	mounts, err := devicestate.SystemVolumeMounts(s.state)
+	c.Assert(err, IsNil)
+	c.Check(mounts, HasLen, 1)
+}
+
+func (s *volumesMountSuite) TestMountSystemVolumeAlreadyMounted(c *C) {
+	device := filepath.Join(dirs.GlobalRootDir, "/dev/disk/by-label/ubuntu-save")
+	defer osutil.MockMountInfo("27 26 8:3 / /run/mnt/ubuntu-save rw,relatime shared:8 - ext4 " + device + " rw\n")()
+	defer devicestate.MockSystemVolumeMount(func(what, where string, options ...string) error {
+		c.Fatalf("unexpected mount")
+		return nil
+	})()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	m, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", nil)
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, &devicestate.SystemVolumeMount{
+		Name:       "ubuntu-save",
+		Role:       "system-save",
+		Device:     device,
+		MountPoint: "/run/mnt/ubuntu-save",
+	})
+	// the existing mount is not relocked
+	mounts, err := devicestate.SystemVolumeMounts(s.state)
+	c.Assert(err, IsNil)
+	c.Check(mounts, HasLen, 0)
+}
+
+func (s *volumesMountSuite) TestMountSystemVolumeReadOnly(c *C) {
+	var mountOptions []string
+	defer devicestate.MockSystemVolumeMount(func(what, where string, options ...string) error {
+		mountOptions = options
+		return nil
+	})()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	m, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", &devicestate.MountSystemVolumeOptions{
+		ReadOnly:    true,
+		RelockAfter: 2 * time.Minute,
+	})
+	c.Assert(err, IsNil)
+	c.Check(m.Role, Equals, "system-save")
+	c.Check(m.ReadOnly, Equals, true)
+	c.Check(m.RelockTime, Equals, s.now.Add(2*time.Minute))
+	c.Check(mountOptions, DeepEquals, []string{"--options=ro"})
+}
+
+func (s *volumesMountSuite) TestMountSystemVolumeAgainExtendsRelock(c *C) {
+	calls := 0
+	defer devicestate.MockSystemVolumeMount(func(what, where string, options ...string) error {
+		calls++
+		return nil
+	})()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", nil)
+	c.Assert(err, IsNil)
+	s.now = s.now.Add(time.Minute)
+	m, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", &devicestate.MountSystemVolumeOptions{RelockAfter: 30 * time.Minute})
+	c.Assert(err, IsNil)
+	c.Check(m.RelockTime, Equals, s.now.Add(30*time.Minute))
+	c.Check(calls, Equals, 1)
+
+	_, err = devicestate.MountSystemVolume(s.state, "ubuntu-save", &devicestate.MountSystemVolumeOptions{ReadOnly: true})
+	c.Check(err, ErrorMatches, `cannot mount system volume "ubuntu-save": already mounted with different options`)
+}
+
+func (s *volumesMountSuite) TestMountSystemVolumeErrors(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := devicestate.MountSystemVolume(s.state, "ubuntu-data", nil)
+	c.Check(err, ErrorMatches, `system volume "ubuntu-data" cannot be mounted on demand`)
+	_, err = devicestate.MountSystemVolume(s.state, "factory-data", nil)
+	c.Check(err, ErrorMatches, `system volume "factory-data" cannot be mounted on demand`)
+	_, err = devicestate.MountSystemVolume(s.state, "other-data", nil)
+	c.Check(err, ErrorMatches, `system volume "other-data" cannot be mounted on demand`)
+	_, err = devicestate.MountSystemVolume(s.state, "missing", nil)
+	c.Check(err, ErrorMatches, `cannot find system volume "missing" in gadget`)
+	_, err = devicestate.MountSystemVolume(s.state, "ubuntu-save", &devicestate.MountSystemVolumeOptions{RelockAfter: 2 * time.Hour})
+	c.Check(err, ErrorMatches, `cannot mount system volume "ubuntu-save": relock time must be positive and at most 1h0m0s`)
+
+	defer devicestate.MockSystemVolumeMount(func(what, where string, options ...string) error {
+		return errors.New("boom")
+	})()
+	_, err = devicestate.MountSystemVolume(s.state, "ubuntu-save", nil)
+	c.Check(err, ErrorMatches, `cannot mount system volume "ubuntu-save": boom`)
+
+	c.Assert(os.Remove(filepath.Join(dirs.GlobalRootDir, "/dev/disk/by-label/ubuntu-save")), IsNil)
+	_, err = devicestate.MountSystemVolume(s.state, "ubuntu-save", nil)
+	c.Check(err, ErrorMatches, `cannot mount system volume "ubuntu-save": device .*/dev/disk/by-label/ubuntu-save not found`)
+
+	mounts, err := devicestate.SystemVolumeMounts(s.state)
+	c.Assert(err, IsNil)
+	c.Check(mounts, HasLen, 0)
+}
+
+func (s *volumesMountSuite) TestUnmountSystemVolume(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", nil)
+	c.Assert(err, IsNil)
+
+	c.Assert(devicestate.UnmountSystemVolume(s.state, "ubuntu-save"), IsNil)
+	c.Check(s.mounted, HasLen, 0)
+	mounts, err := devicestate.SystemVolumeMounts(s.state)
+	c.Assert(err, IsNil)
+	c.Check(mounts, HasLen, 0)
+
+	err = devicestate.UnmountSystemVolume(s.state, "ubuntu-save")
+	c.Check(err, Equals, devicestate.ErrSystemVolumeNotMounted)
+}
+
+func (s *volumesMountSuite) TestEnsureSystemVolumesRelocked(c *C) {
+	s.state.Lock()
+	_, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", &devicestate.MountSystemVolumeOptions{RelockAfter: 5 * time.Minute})
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+
+	c.Assert(devicestate.EnsureSystemVolumesRelocked(s.mgr), IsNil)
+	c.Check(s.mounted, HasLen, 1)
+
+	s.now = s.now.Add(2 * time.Minute)
+	c.Assert(devicestate.EnsureSystemVolumesRelocked(s.mgr), IsNil)
+	c.Check(s.mounted, HasLen, 1)
+
+	s.state.Lock()
+	mounts, err := devicestate.SystemVolumeMounts(s.state)
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+	c.Assert(mounts, HasLen, 1)
+	c.Check(mounts[0].Name, Equals, "ubuntu-save")
+
+	s.now = s.now.Add(5 * time.Minute)
+	c.Assert(devicestate.EnsureSystemVolumesRelocked(s.mgr), IsNil)
+	c.Check(s.mounted, HasLen, 0)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Get("system-volume-mounts", new(any)), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (s *volumesMountSuite) TestEnsureSystemVolumesRelockedGivesUp(c *C) {
+	s.state.Lock()
+	m, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", &devicestate.MountSystemVolumeOptions{RelockAfter: time.Minute})
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+	defer osutil.MockMountInfo("27 26 8:3 / " + m.MountPoint + " rw,relatime shared:8 - ext4 " + m.Device + " rw\n")()
+
+	attempts := 0
+	defer devicestate.MockSystemVolumeUnmount(func(where string) error {
+		attempts++
+		return errors.New("busy")
+	})()
+
+	s.now = s.now.Add(2 * time.Minute)
+	for i := 0; i < 4; i++ {
+		c.Assert(devicestate.EnsureSystemVolumesRelocked(s.mgr), IsNil)
+	}
+	s.state.Lock()
+	mounts, err := devicestate.SystemVolumeMounts(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(mounts, HasLen, 1)
+	c.Check(mounts[0].UnmountAttempts, Equals, 4)
+	s.state.Unlock()
+
+	c.Assert(devicestate.EnsureSystemVolumesRelocked(s.mgr), IsNil)
+	c.Check(attempts, Equals, 5)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Get("system-volume-mounts", new(any)), testutil.ErrorIs, state.ErrNoState)
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `cannot unmount system volume "ubuntu-save", giving up: busy`)
+}
+
+func (s *volumesMountSuite) TestEnsureSystemVolumesRelockedForgetsUnmounted(c *C) {
+	s.state.Lock()
+	_, err := devicestate.MountSystemVolume(s.state, "ubuntu-save", &devicestate.MountSystemVolumeOptions{RelockAfter: time.Minute})
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+
+	defer devicestate.MockSystemVolumeUnmount(func(where string) error {
+		return errors.New("not mounted")
+	})()
+
+	s.now = s.now.Add(2 * time.Minute)
+	c.Assert(devicestate.EnsureSystemVolumesRelocked(s.mgr), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Get("system-volume-mounts", new(any)), testutil.ErrorIs, state.ErrNoState)
+}
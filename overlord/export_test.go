@@ -33,6 +33,7 @@ import (
 
 var (
 	LockWithTimeout = lockWithTimeout
+	PrunePolicy     = prunePolicy
 )
 
 // MockEnsureInterval sets the overlord ensure interval for tests.
@@ -86,6 +87,11 @@ func (o *Overlord) NewStore(devBE storecontext.DeviceBackend) snapstate.StoreSer
 	return o.newStore(devBE)
 }
 
+// DownloadCacheMaxSize exposes downloadCacheMaxSize.
+func (o *Overlord) DownloadCacheMaxSize() int64 {
+	return o.downloadCacheMaxSize()
+}
+
 // MockStoreNew mocks store.New as called by overlord.New.
 func MockStoreNew(new func(*store.Config, store.DeviceAndAuthContext) *store.Store) (restore func()) {
 	storeNew = new
@@ -48,6 +48,18 @@ func checkFDEChangeConflict(st *state.State) error {
 				ChangeKind: chg.Kind(),
 				ChangeID:   chg.ID(),
 			}
+		case "fde-add-recovery-key":
+			return &snapstate.ChangeConflictError{
+				Message:    "adding recovery key in progress, no other FDE changes allowed until this is done",
+				ChangeKind: chg.Kind(),
+				ChangeID:   chg.ID(),
+			}
+		case "fde-remove-keyslots":
+			return &snapstate.ChangeConflictError{
+				Message:    "removing key slots in progress, no other FDE changes allowed until this is done",
+				ChangeKind: chg.Kind(),
+				ChangeID:   chg.ID(),
+			}
 		default:
 			// try to catch changes/tasks that could have been missed
 			// and log a warning.
@@ -22,6 +22,7 @@ import (
 	"crypto"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/asserts"
@@ -32,6 +33,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/secboot"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var (
@@ -533,6 +535,116 @@ func ReplaceRecoveryKey(st *state.State, recoveryKeyID string, keyslotRefs []Key
 	return ts, nil
 }
 
+// reservedKeyslotNames are managed by snapd itself and cannot be
+// added or removed directly.
+var reservedKeyslotNames = []string{"default", "default-fallback", "default-recovery"}
+
+func checkNotReservedKeyslotName(keyslotRef KeyslotRef) error {
+	if strutil.ListContains(reservedKeyslotNames, keyslotRef.Name) || strings.HasPrefix(keyslotRef.Name, tmpKeyslotPrefix) {
+		return fmt.Errorf("invalid key slot reference %s: reserved name", keyslotRef.String())
+	}
+	return nil
+}
+
+// AddRecoveryKey creates a taskset that adds new recovery key slots
+// for the specified target key slots using the recovery key
+// identified by recoveryKeyID.
+//
+// Target key slots must not already exist and must not use one
+// of the names reserved by snapd.
+func AddRecoveryKey(st *state.State, recoveryKeyID string, keyslotRefs []KeyslotRef) (*state.TaskSet, error) {
+	if len(keyslotRefs) == 0 {
+		return nil, errors.New("at least one key slot reference must be provided")
+	}
+
+	for _, keyslotRef := range keyslotRefs {
+		if err := keyslotRef.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid key slot reference %s: %v", keyslotRef.String(), err)
+		}
+		if err := checkNotReservedKeyslotName(keyslotRef); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkFDEChangeConflict(st); err != nil {
+		return nil, err
+	}
+
+	fdemgr := fdeMgr(st)
+
+	if err := checkRecoveryKeyIDExists(fdemgr, recoveryKeyID); err != nil {
+		return nil, fmt.Errorf("invalid recovery key ID: %v", err)
+	}
+
+	currentKeyslots, _, err := fdemgr.GetKeyslots(keyslotRefs)
+	if err != nil {
+		return nil, err
+	}
+	if len(currentKeyslots) != 0 {
+		return nil, fmt.Errorf("key slot reference %s already exists", currentKeyslots[0].Ref().String())
+	}
+
+	ts := state.NewTaskSet()
+
+	addRecoveryKeys := st.NewTask("fde-add-recovery-keys", "Add recovery key slots")
+	addRecoveryKeys.Set("recovery-key-id", recoveryKeyID)
+	addRecoveryKeys.Set("keyslots", keyslotRefs)
+	ts.AddTask(addRecoveryKeys)
+
+	return ts, nil
+}
+
+// RemoveKeyslots creates a taskset that removes the specified
+// target key slots.
+//
+// Only recovery key slots which do not use one of the names
+// reserved by snapd can be removed.
+//
+// If any key slot from keyslotRefs does not exist, a KeyslotRefsNotFoundError is returned.
+func RemoveKeyslots(st *state.State, keyslotRefs []KeyslotRef) (*state.TaskSet, error) {
+	if len(keyslotRefs) == 0 {
+		return nil, errors.New("at least one key slot reference must be provided")
+	}
+
+	for _, keyslotRef := range keyslotRefs {
+		if err := keyslotRef.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid key slot reference %s: %v", keyslotRef.String(), err)
+		}
+		if err := checkNotReservedKeyslotName(keyslotRef); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkFDEChangeConflict(st); err != nil {
+		return nil, err
+	}
+
+	fdemgr := fdeMgr(st)
+
+	currentKeyslots, missing, err := fdemgr.GetKeyslots(keyslotRefs)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) != 0 {
+		return nil, &KeyslotRefsNotFoundError{KeyslotRefs: missing}
+	}
+	for _, keyslot := range currentKeyslots {
+		// TODO:FDEM: relax for platform key slots once it can be checked
+		// that the last key slot able to unlock a container is kept.
+		if keyslot.Type != KeyslotTypeRecovery {
+			return nil, fmt.Errorf("invalid key slot reference %s: unsupported type %q, expected %q", keyslot.Ref().String(), keyslot.Type, KeyslotTypeRecovery)
+		}
+	}
+
+	ts := state.NewTaskSet()
+
+	removeKeys := st.NewTask("fde-remove-keys", "Remove key slots")
+	removeKeys.Set("keyslots", keyslotRefs)
+	ts.AddTask(removeKeys)
+
+	return ts, nil
+}
+
 type changeAuthOptions struct {
 	old, new string
 }
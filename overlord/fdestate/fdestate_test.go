@@ -265,6 +265,157 @@ func (s *fdeMgrSuite) TestReplaceRecoveryKeyErrors(c *C) {
 	c.Check(err, testutil.ErrorIs, &snapstate.ChangeConflictError{})
 }
 
+func (s *fdeMgrSuite) TestAddRecoveryKey(c *C) {
+	s.mockDeviceInState(&asserts.Model{}, "run")
+	s.mockCurrentKeys(c, nil, nil)
+
+	onClassic := true
+	manager := s.startedManager(c, onClassic)
+
+	_, recoveryKeyID, err := manager.GenerateRecoveryKey()
+	c.Assert(err, IsNil)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	keyslots := []fdestate.KeyslotRef{
+		{ContainerRole: "system-data", Name: "extra-recovery"},
+		{ContainerRole: "system-save", Name: "extra-recovery"},
+	}
+	ts, err := fdestate.AddRecoveryKey(s.st, recoveryKeyID, keyslots)
+	c.Assert(err, IsNil)
+	tsks := ts.Tasks()
+	c.Assert(tsks, HasLen, 1)
+
+	c.Check(tsks[0].Summary(), Equals, "Add recovery key slots")
+	c.Check(tsks[0].Kind(), Equals, "fde-add-recovery-keys")
+	var tskRecoveryKeyID string
+	c.Assert(tsks[0].Get("recovery-key-id", &tskRecoveryKeyID), IsNil)
+	c.Check(tskRecoveryKeyID, Equals, recoveryKeyID)
+	var tskKeyslots []fdestate.KeyslotRef
+	c.Assert(tsks[0].Get("keyslots", &tskKeyslots), IsNil)
+	c.Check(tskKeyslots, DeepEquals, keyslots)
+}
+
+func (s *fdeMgrSuite) TestAddRecoveryKeyErrors(c *C) {
+	s.mockDeviceInState(&asserts.Model{}, "run")
+	s.mockCurrentKeys(c, []fdestate.KeyslotRef{
+		{ContainerRole: "system-data", Name: "default-recovery"},
+		{ContainerRole: "system-data", Name: "extra-recovery"},
+	}, nil)
+
+	onClassic := true
+	manager := s.startedManager(c, onClassic)
+
+	_, recoveryKeyID, err := manager.GenerateRecoveryKey()
+	c.Assert(err, IsNil)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	_, err = fdestate.AddRecoveryKey(s.st, recoveryKeyID, nil)
+	c.Assert(err, ErrorMatches, "at least one key slot reference must be provided")
+
+	badKeyslot := fdestate.KeyslotRef{ContainerRole: "", Name: "some-name"}
+	_, err = fdestate.AddRecoveryKey(s.st, recoveryKeyID, []fdestate.KeyslotRef{badKeyslot})
+	c.Assert(err, ErrorMatches, `invalid key slot reference \(container-role: "", name: "some-name"\): container role cannot be empty`)
+
+	for _, name := range []string{"default", "default-fallback", "default-recovery", "snapd-tmp:some-name"} {
+		badKeyslot = fdestate.KeyslotRef{ContainerRole: "system-save", Name: name}
+		_, err = fdestate.AddRecoveryKey(s.st, recoveryKeyID, []fdestate.KeyslotRef{badKeyslot})
+		c.Assert(err, ErrorMatches, `invalid key slot reference \(container-role: "system-save", name: ".*"\): reserved name`)
+	}
+
+	keyslots := []fdestate.KeyslotRef{{ContainerRole: "system-data", Name: "extra-recovery"}}
+
+	_, err = fdestate.AddRecoveryKey(s.st, "bad-key-id", keyslots)
+	c.Assert(err, ErrorMatches, "invalid recovery key ID: .*")
+
+	// already exists
+	_, err = fdestate.AddRecoveryKey(s.st, recoveryKeyID, keyslots)
+	c.Assert(err, ErrorMatches, `key slot reference \(container-role: "system-data", name: "extra-recovery"\) already exists`)
+
+	// change conflict
+	chg := s.st.NewChange("fde-add-recovery-key", "")
+	chg.AddTask(s.st.NewTask("some-fde-task", ""))
+	_, err = fdestate.AddRecoveryKey(s.st, recoveryKeyID, keyslots)
+	c.Assert(err, ErrorMatches, `adding recovery key in progress, no other FDE changes allowed until this is done`)
+	c.Check(err, testutil.ErrorIs, &snapstate.ChangeConflictError{})
+}
+
+func (s *fdeMgrSuite) TestRemoveKeyslots(c *C) {
+	keyslots := []fdestate.KeyslotRef{
+		{ContainerRole: "system-data", Name: "extra-recovery"},
+		{ContainerRole: "system-save", Name: "extra-recovery"},
+	}
+	s.mockCurrentKeys(c, append([]fdestate.KeyslotRef{
+		{ContainerRole: "system-data", Name: "default-recovery"},
+		{ContainerRole: "system-save", Name: "default-recovery"},
+	}, keyslots...), nil)
+
+	onClassic := true
+	s.startedManager(c, onClassic)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	ts, err := fdestate.RemoveKeyslots(s.st, keyslots)
+	c.Assert(err, IsNil)
+	tsks := ts.Tasks()
+	c.Assert(tsks, HasLen, 1)
+
+	c.Check(tsks[0].Summary(), Equals, "Remove key slots")
+	c.Check(tsks[0].Kind(), Equals, "fde-remove-keys")
+	var tskKeyslots []fdestate.KeyslotRef
+	c.Assert(tsks[0].Get("keyslots", &tskKeyslots), IsNil)
+	c.Check(tskKeyslots, DeepEquals, keyslots)
+}
+
+func (s *fdeMgrSuite) TestRemoveKeyslotsErrors(c *C) {
+	s.mockCurrentKeys(c, []fdestate.KeyslotRef{
+		{ContainerRole: "system-data", Name: "default-recovery"},
+		{ContainerRole: "system-data", Name: "extra-recovery"},
+	}, []fdestate.KeyslotRef{
+		{ContainerRole: "system-data", Name: "default"},
+		{ContainerRole: "system-data", Name: "extra-platform"},
+	})
+
+	onClassic := true
+	s.startedManager(c, onClassic)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	_, err := fdestate.RemoveKeyslots(s.st, nil)
+	c.Assert(err, ErrorMatches, "at least one key slot reference must be provided")
+
+	badKeyslot := fdestate.KeyslotRef{ContainerRole: "system-data", Name: ""}
+	_, err = fdestate.RemoveKeyslots(s.st, []fdestate.KeyslotRef{badKeyslot})
+	c.Assert(err, ErrorMatches, `invalid key slot reference \(container-role: "system-data", name: ""\): name cannot be empty`)
+
+	badKeyslot = fdestate.KeyslotRef{ContainerRole: "system-data", Name: "default"}
+	_, err = fdestate.RemoveKeyslots(s.st, []fdestate.KeyslotRef{badKeyslot})
+	c.Assert(err, ErrorMatches, `invalid key slot reference \(container-role: "system-data", name: "default"\): reserved name`)
+
+	badKeyslot = fdestate.KeyslotRef{ContainerRole: "system-data", Name: "extra-platform"}
+	_, err = fdestate.RemoveKeyslots(s.st, []fdestate.KeyslotRef{badKeyslot})
+	c.Assert(err, ErrorMatches, `invalid key slot reference \(container-role: "system-data", name: "extra-platform"\): unsupported type "platform", expected "recovery"`)
+
+	missing := []fdestate.KeyslotRef{{ContainerRole: "system-save", Name: "extra-recovery"}}
+	_, err = fdestate.RemoveKeyslots(s.st, missing)
+	c.Assert(err, ErrorMatches, `key slot reference \(container-role: "system-save", name: "extra-recovery"\) not found`)
+	var notFoundErr *fdestate.KeyslotRefsNotFoundError
+	c.Assert(errors.As(err, &notFoundErr), Equals, true)
+	c.Check(notFoundErr.KeyslotRefs, DeepEquals, missing)
+
+	// change conflict
+	chg := s.st.NewChange("fde-remove-keyslots", "")
+	chg.AddTask(s.st.NewTask("some-fde-task", ""))
+	_, err = fdestate.RemoveKeyslots(s.st, []fdestate.KeyslotRef{{ContainerRole: "system-data", Name: "extra-recovery"}})
+	c.Assert(err, ErrorMatches, `removing key slots in progress, no other FDE changes allowed until this is done`)
+	c.Check(err, testutil.ErrorIs, &snapstate.ChangeConflictError{})
+}
+
 func (s *fdeMgrSuite) TestEnsureLoopLogging(c *C) {
 	testutil.CheckEnsureLoopLogging("fdemgr.go", c, false)
 }
@@ -820,6 +820,33 @@ func (s *fdeMgrSuite) TestDoChangeAuthKeys(c *C) {
 	}
 }
 
+func (s *fdeMgrSuite) TestDoChangeAuthKeysAbandoned(c *C) {
+	const onClassic = true
+	s.startedManager(c, onClassic)
+
+	defer fdestate.MockSecbootReadContainerKeyData(func(devicePath, slotName string) (secboot.KeyData, error) {
+		panic("unexpected")
+	})()
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	task := s.st.NewTask("fde-change-auth", "test")
+	task.Set("keyslots", []fdestate.KeyslotRef{{ContainerRole: "system-data", Name: "default"}})
+	task.Set("auth-mode", device.AuthModePassphrase)
+	chg := s.st.NewChange("sample", "...")
+	chg.AddTask(task)
+	// as left behind by a snapd that stopped while changing the
+	// authentication
+	task.SetStatus(state.DoingStatus)
+
+	s.settle(c)
+
+	c.Check(task.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `cannot perform the following tasks:
+- test \(task abandoned while in progress, undoing\)`)
+}
+
 func (s *fdeMgrSuite) TestDoChangeAuthKeysNoop(c *C) {
 	const onClassic = true
 	s.startedManager(c, onClassic)
@@ -0,0 +1,265 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package historystate keeps a consolidated history of the operations
+// performed on the system, such as snap installs, refreshes and removals,
+// interface connections, configuration changes and user management. The
+// history is kept in the state independently of changes, so it survives
+// change pruning. Refreshes and reverts are taken from the refresh history
+// kept by snapstate instead of being recorded again.
+package historystate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// maxEntries is the number of entries kept in the history, older entries
+// are dropped.
+var maxEntries = 1000
+
+var timeNow = time.Now
+
+// Operations recorded in the history.
+const (
+	OperationInstall    = "install"
+	OperationRefresh    = "refresh"
+	OperationRevert     = "revert"
+	OperationRemove     = "remove"
+	OperationConnect    = "connect"
+	OperationDisconnect = "disconnect"
+	OperationConfigure  = "configure"
+	OperationCreateUser = "create-user"
+	OperationRemoveUser = "remove-user"
+)
+
+// operationByChangeKind maps the kinds of the changes recorded in the
+// history to their operation. Refreshes and reverts are not recorded from
+// changes, they are part of the refresh history.
+var operationByChangeKind = map[string]string{
+	"install-snap":      OperationInstall,
+	"install-component": OperationInstall,
+	"snapctl-install":   OperationInstall,
+	"try-snap":          OperationInstall,
+	"remove-snap":       OperationRemove,
+	"snapctl-remove":    OperationRemove,
+	"connect-snap":      OperationConnect,
+	"disconnect-snap":   OperationDisconnect,
+	"configure-snap":    OperationConfigure,
+}
+
+// Entry describes an operation performed on the system.
+type Entry struct {
+	// Time is when the operation completed.
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Summary   string    `json:"summary"`
+	// Status is the status of the change performing the operation, or
+	// "Done" for operations not performed through a change. Failed
+	// refreshes and reverts have the "Error" status.
+	Status string   `json:"status"`
+	Snaps  []string `json:"snaps,omitempty"`
+	// User is the system user affected by user management operations.
+	User string `json:"user,omitempty"`
+
+	ChangeID   string    `json:"change-id,omitempty"`
+	ChangeKind string    `json:"change-kind,omitempty"`
+	SpawnTime  time.Time `json:"spawn-time"`
+}
+
+func entries(st *state.State) ([]*Entry, error) {
+	var history []*Entry
+	if err := st.Get("operation-history", &history); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return history, nil
+}
+
+// Record adds an entry to the history, for operations that are not
+// performed through changes. The time of the entry is set to the current
+// time if unset.
+//
+// The state needs to be locked by the caller.
+func Record(st *state.State, entry *Entry) error {
+	history, err := entries(st)
+	if err != nil {
+		return err
+	}
+	if entry.Time.IsZero() {
+		entry.Time = timeNow().UTC()
+	}
+	if entry.Status == "" {
+		entry.Status = state.DoneStatus.String()
+	}
+	history = append(history, entry)
+	if len(history) > maxEntries {
+		history = history[len(history)-maxEntries:]
+	}
+	st.Set("operation-history", history)
+	return nil
+}
+
+// refreshEntries returns the refresh history of all snaps as history
+// entries.
+func refreshEntries(st *state.State) ([]*Entry, error) {
+	refreshHistory, err := snapstate.AllRefreshHistory(st)
+	if err != nil {
+		return nil, err
+	}
+	var res []*Entry
+	for name, refreshes := range refreshHistory {
+		for _, r := range refreshes {
+			op, verb := OperationRefresh, "Refresh"
+			if r.Revert {
+				op, verb = OperationRevert, "Revert"
+			}
+			status := state.DoneStatus
+			if r.Outcome != "success" {
+				status = state.ErrorStatus
+			}
+			res = append(res, &Entry{
+				Time:      r.Time.UTC(),
+				Operation: op,
+				Summary:   fmt.Sprintf("%s snap %q from revision %s to %s", verb, name, r.FromRevision, r.ToRevision),
+				Status:    status.String(),
+				Snaps:     []string{name},
+			})
+		}
+	}
+	return res, nil
+}
+
+// Entries returns the history entries of the operations completed in the
+// given period, oldest first. A zero from or to leaves the period open
+// on that side.
+//
+// The state needs to be locked by the caller.
+func Entries(st *state.State, from, to time.Time) ([]*Entry, error) {
+	history, err := entries(st)
+	if err != nil {
+		return nil, err
+	}
+	refreshes, err := refreshEntries(st)
+	if err != nil {
+		return nil, err
+	}
+	history = append(history, refreshes...)
+
+	res := make([]*Entry, 0, len(history))
+	for _, e := range history {
+		if !from.IsZero() && e.Time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && e.Time.After(to) {
+			continue
+		}
+		res = append(res, e)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Time.Before(res[j].Time)
+	})
+	return res, nil
+}
+
+// autoConnectEntries returns the history entries of the connections
+// performed automatically by the given change.
+func autoConnectEntries(chg *state.Change) []*Entry {
+	var res []*Entry
+	for _, t := range chg.Tasks() {
+		if t.Kind() != "connect" || t.Status() != state.DoneStatus {
+			continue
+		}
+		var auto bool
+		if err := t.Get("auto", &auto); err != nil && !errors.Is(err, state.ErrNoState) {
+			logger.Debugf("internal error: cannot get auto flag of task %s: %v", t.ID(), err)
+			continue
+		}
+		if !auto {
+			continue
+		}
+		var plugRef interfaces.PlugRef
+		var slotRef interfaces.SlotRef
+		if err := t.Get("plug", &plugRef); err != nil {
+			logger.Debugf("internal error: cannot get plug of task %s: %v", t.ID(), err)
+			continue
+		}
+		if err := t.Get("slot", &slotRef); err != nil {
+			logger.Debugf("internal error: cannot get slot of task %s: %v", t.ID(), err)
+			continue
+		}
+		snaps := []string{plugRef.Snap}
+		if slotRef.Snap != plugRef.Snap {
+			snaps = append(snaps, slotRef.Snap)
+		}
+		res = append(res, &Entry{
+			Operation: OperationConnect,
+			Summary:   fmt.Sprintf("Connect %s to %s automatically", plugRef, slotRef),
+			Status:    state.DoneStatus.String(),
+			Snaps:     snaps,
+		})
+	}
+	return res
+}
+
+func processChange(chg *state.Change, old, new state.Status) {
+	if old.Ready() || !new.Ready() {
+		return
+	}
+
+	var history []*Entry
+	if op, ok := operationByChangeKind[chg.Kind()]; ok {
+		var snaps []string
+		if err := chg.Get("snap-names", &snaps); err != nil && !errors.Is(err, state.ErrNoState) {
+			logger.Debugf("internal error: cannot get snap names of change %s: %v", chg.ID(), err)
+		}
+		history = append(history, &Entry{
+			Operation: op,
+			Summary:   chg.Summary(),
+			Status:    new.String(),
+			Snaps:     snaps,
+		})
+	}
+	// explicit connections are recorded with their connect-snap change
+	if chg.Kind() != "connect-snap" {
+		history = append(history, autoConnectEntries(chg)...)
+	}
+
+	st := chg.State()
+	for _, entry := range history {
+		entry.ChangeID = chg.ID()
+		entry.ChangeKind = chg.Kind()
+		entry.SpawnTime = chg.SpawnTime().UTC()
+		if err := Record(st, entry); err != nil {
+			logger.Noticef("cannot record operation history of change %s: %v", chg.ID(), err)
+		}
+	}
+}
+
+// Init sets up the recording of the operations performed by changes in
+// the history. It must be called with the state lock held.
+func Init(st *state.State) {
+	st.AddChangeStatusChangedHandler(processChange)
+}
@@ -0,0 +1,271 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package historystate_test
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/historystate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func TestHistoryState(t *testing.T) { TestingT(t) }
+
+type historySuite struct {
+	testutil.BaseTest
+
+	st  *state.State
+	now time.Time
+}
+
+var _ = Suite(&historySuite{})
+
+func (s *historySuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	s.st = state.New(nil)
+	s.now = time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(historystate.MockTimeNow(func() time.Time { return s.now }))
+
+	s.st.Lock()
+	historystate.Init(s.st)
+	s.st.Unlock()
+}
+
+func (s *historySuite) TestRecordsReadyChanges(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	chg := s.st.NewChange("remove-snap", "Remove \"foo\" snap")
+	chg.Set("snap-names", []string{"foo"})
+	t := s.st.NewTask("foo", "...")
+	chg.AddTask(t)
+
+	other := s.st.NewChange("some-change", "not recorded")
+	other.AddTask(s.st.NewTask("bar", "..."))
+
+	t.SetStatus(state.DoingStatus)
+	entries, err := historystate.Entries(s.st, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 0)
+
+	t.SetStatus(state.DoneStatus)
+	for _, t := range other.Tasks() {
+		t.SetStatus(state.DoneStatus)
+	}
+
+	entries, err = historystate.Entries(s.st, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0], DeepEquals, &historystate.Entry{
+		Time:       s.now,
+		Operation:  historystate.OperationRemove,
+		Summary:    `Remove "foo" snap`,
+		Status:     "Done",
+		Snaps:      []string{"foo"},
+		ChangeID:   chg.ID(),
+		ChangeKind: "remove-snap",
+		SpawnTime:  chg.SpawnTime().UTC(),
+	})
+}
+
+func (s *historySuite) TestRecordsFailedChange(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	chg := s.st.NewChange("install-snap", "Install \"foo\" snap")
+	t := s.st.NewTask("foo", "...")
+	chg.AddTask(t)
+	t.SetStatus(state.ErrorStatus)
+
+	entries, err := historystate.Entries(s.st, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0].Operation, Equals, historystate.OperationInstall)
+	c.Check(entries[0].Status, Equals, "Error")
+}
+
+func (s *historySuite) TestRecordsAutoConnects(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	chg := s.st.NewChange("install-snap", "Install \"foo\" snap")
+	chg.Set("snap-names", []string{"foo"})
+	link := s.st.NewTask("link-snap", "...")
+	chg.AddTask(link)
+	auto := s.st.NewTask("connect", "...")
+	auto.Set("plug", interfaces.PlugRef{Snap: "foo", Name: "network"})
+	auto.Set("slot", interfaces.SlotRef{Snap: "snapd", Name: "network"})
+	auto.Set("auto", true)
+	chg.AddTask(auto)
+	failed := s.st.NewTask("connect", "...")
+	failed.Set("plug", interfaces.PlugRef{Snap: "foo", Name: "home"})
+	failed.Set("slot", interfaces.SlotRef{Snap: "snapd", Name: "home"})
+	failed.Set("auto", true)
+	chg.AddTask(failed)
+
+	link.SetStatus(state.DoneStatus)
+	auto.SetStatus(state.DoneStatus)
+	failed.SetStatus(state.UndoneStatus)
+
+	entries, err := historystate.Entries(s.st, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 2)
+	c.Check(entries[0].Operation, Equals, historystate.OperationInstall)
+	c.Check(entries[1], DeepEquals, &historystate.Entry{
+		Time:       s.now,
+		Operation:  historystate.OperationConnect,
+		Summary:    "Connect foo:network to snapd:network automatically",
+		Status:     "Done",
+		Snaps:      []string{"foo", "snapd"},
+		ChangeID:   chg.ID(),
+		ChangeKind: "install-snap",
+		SpawnTime:  chg.SpawnTime().UTC(),
+	})
+}
+
+func (s *historySuite) TestExplicitConnectRecordedOnce(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	chg := s.st.NewChange("connect-snap", "Connect foo:network to snapd:network")
+	t := s.st.NewTask("connect", "...")
+	t.Set("plug", interfaces.PlugRef{Snap: "foo", Name: "network"})
+	t.Set("slot", interfaces.SlotRef{Snap: "snapd", Name: "network"})
+	t.Set("auto", true)
+	chg.AddTask(t)
+	t.SetStatus(state.DoneStatus)
+
+	entries, err := historystate.Entries(s.st, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0].Summary, Equals, "Connect foo:network to snapd:network")
+}
+
+func (s *historySuite) TestEntriesIncludeRefreshHistory(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	// refreshes and reverts are not recorded from their changes
+	chg := s.st.NewChange("refresh-snap", "Refresh \"foo\" snap")
+	t := s.st.NewTask("foo", "...")
+	chg.AddTask(t)
+	t.SetStatus(state.DoneStatus)
+
+	err := historystate.Record(s.st, &historystate.Entry{
+		Operation: historystate.OperationCreateUser,
+		Summary:   "Create user",
+		User:      "user",
+	})
+	c.Assert(err, IsNil)
+
+	s.st.Set("refresh-history", map[string][]*snapstate.RefreshHistoryEntry{
+		"foo": {{
+			Time:         s.now.Add(-time.Hour),
+			FromRevision: snap.R(1),
+			ToRevision:   snap.R(2),
+			Outcome:      "success",
+		}, {
+			Time:         s.now.Add(time.Hour),
+			FromRevision: snap.R(2),
+			ToRevision:   snap.R(1),
+			Outcome:      "failed",
+			Revert:       true,
+		}},
+	})
+
+	entries, err := historystate.Entries(s.st, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 3)
+	c.Check(entries[0], DeepEquals, &historystate.Entry{
+		Time:      s.now.Add(-time.Hour),
+		Operation: historystate.OperationRefresh,
+		Summary:   `Refresh snap "foo" from revision 1 to 2`,
+		Status:    "Done",
+		Snaps:     []string{"foo"},
+	})
+	c.Check(entries[1].Operation, Equals, historystate.OperationCreateUser)
+	c.Check(entries[2], DeepEquals, &historystate.Entry{
+		Time:      s.now.Add(time.Hour),
+		Operation: historystate.OperationRevert,
+		Summary:   `Revert snap "foo" from revision 2 to 1`,
+		Status:    "Error",
+		Snaps:     []string{"foo"},
+	})
+
+	entries, err = historystate.Entries(s.st, s.now, time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 2)
+}
+
+func (s *historySuite) TestRecordAndEntriesPeriod(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	for i := 0; i < 3; i++ {
+		err := historystate.Record(s.st, &historystate.Entry{
+			Operation: historystate.OperationCreateUser,
+			Summary:   "Create user",
+			User:      "user",
+		})
+		c.Assert(err, IsNil)
+		s.now = s.now.Add(time.Hour)
+	}
+
+	entries, err := historystate.Entries(s.st, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 3)
+	c.Check(entries[0].Status, Equals, "Done")
+	c.Check(entries[0].Time, Equals, time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC))
+
+	entries, err = historystate.Entries(s.st, time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC), time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 2)
+
+	entries, err = historystate.Entries(s.st, time.Date(2025, 6, 1, 10, 30, 0, 0, time.UTC), time.Date(2025, 6, 1, 11, 30, 0, 0, time.UTC))
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0].Time, Equals, time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC))
+}
+
+func (s *historySuite) TestRecordIsCapped(c *C) {
+	defer historystate.MockMaxEntries(2)()
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	for _, user := range []string{"one", "two", "three"} {
+		err := historystate.Record(s.st, &historystate.Entry{Operation: historystate.OperationRemoveUser, User: user})
+		c.Assert(err, IsNil)
+	}
+
+	entries, err := historystate.Entries(s.st, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 2)
+	c.Check(entries[0].User, Equals, "two")
+	c.Check(entries[1].User, Equals, "three")
+}
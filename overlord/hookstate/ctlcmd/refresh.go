@@ -37,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var autoRefreshForGatingSnap = snapstate.AutoRefreshForGatingSnap
@@ -50,6 +51,9 @@ type refreshCommand struct {
 	Hold    bool `long:"hold" description:"Do not proceed with potentially disruptive refreshes"`
 
 	PrintInhibitLock bool `long:"show-lock" description:"Show the value of the run inhibit lock held during refreshes (empty means not held)"`
+
+	Quiesce  []string `long:"quiesce" value-name:"<workload>" description:"Report a workload that must be quiesced before the refresh proceeds (can be repeated)"`
+	Quiesced bool     `long:"quiesced" description:"Signal that the reported workloads were quiesced"`
 }
 
 var shortRefreshHelp = i18n.G("The refresh command prints pending refreshes and can hold back disruptive ones.")
@@ -87,6 +91,17 @@ might be held back by other snaps.
 
 To hold refresh for up to 90 days for the calling snap:
     $ snapctl refresh --pending --hold
+
+Snaps managing workloads such as virtual machines or containers can ask,
+from the pre-refresh hook, for the refresh to wait until those workloads
+are quiesced before the snap services are stopped:
+    $ snapctl refresh --quiesce=vm1 --quiesce=vm2
+
+Once the workloads are quiesced, the snap signals so from outside the hook:
+    $ snapctl refresh --quiesced
+
+The refresh waits for a bounded amount of time, after which it proceeds even
+if the workloads were not reported as quiesced.
 `)
 
 func init() {
@@ -102,6 +117,10 @@ func (c *refreshCommand) Execute(args []string) error {
 		return err
 	}
 
+	if len(c.Quiesce) != 0 || c.Quiesced {
+		return c.quiesce()
+	}
+
 	if !context.IsEphemeral() && context.HookName() != "gate-auto-refresh" {
 		return fmt.Errorf("can only be used from gate-auto-refresh hook")
 	}
@@ -372,3 +391,30 @@ func (c *refreshCommand) printInhibitLockHint() error {
 	c.printf("%s", hint)
 	return nil
 }
+
+func (c *refreshCommand) quiesce() error {
+	if len(c.Quiesce) != 0 && c.Quiesced {
+		return fmt.Errorf("cannot use --quiesce and --quiesced together")
+	}
+	if c.Pending || c.Proceed || c.Hold || c.PrintInhibitLock {
+		return fmt.Errorf("cannot use --quiesce or --quiesced with other options")
+	}
+
+	ctx := c.context()
+	ctx.Lock()
+	defer ctx.Unlock()
+	st := ctx.State()
+
+	if c.Quiesced {
+		return snapstate.CompleteWorkloadQuiesce(st, ctx.InstanceName())
+	}
+
+	if ctx.IsEphemeral() || ctx.HookName() != "pre-refresh" {
+		return fmt.Errorf("can only report workloads to quiesce from the pre-refresh hook")
+	}
+	if err := snapstate.RequestWorkloadQuiesce(st, ctx.InstanceName(), ctx.ChangeID(), c.Quiesce); err != nil {
+		return err
+	}
+	ctx.Logf("Workloads %s must be quiesced before the refresh proceeds", strutil.Quoted(c.Quiesce))
+	return nil
+}
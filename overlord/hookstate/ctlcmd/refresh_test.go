@@ -533,3 +533,68 @@ func (s *refreshSuite) TestRefreshPrintInhibitHintEmpty(c *C) {
 	c.Check(string(stdout), Equals, "")
 	c.Check(string(stderr), Equals, "")
 }
+
+func (s *refreshSuite) TestRefreshQuiesce(c *C) {
+	s.st.Lock()
+	chg := s.st.NewChange("refresh-snap", "...")
+	task := s.st.NewTask("run-hook", "...")
+	chg.AddTask(task)
+	setup := &hookstate.HookSetup{Snap: "foo", Revision: snap.R(1), Hook: "pre-refresh"}
+	hookContext, err := hookstate.NewContext(task, s.st, setup, s.mockHandler, "")
+	c.Assert(err, IsNil)
+	// from the snap
+	snapContext, err := hookstate.NewContext(nil, s.st, &hookstate.HookSetup{Snap: "foo", Revision: snap.R(1)}, nil, "")
+	c.Assert(err, IsNil)
+	s.st.Unlock()
+
+	_, _, err = ctlcmd.Run(snapContext, []string{"refresh", "--quiesced"}, 0)
+	c.Check(err, ErrorMatches, `no quiesce of workloads requested for snap "foo"`)
+
+	stdout, stderr, err := ctlcmd.Run(hookContext, []string{"refresh", "--quiesce=vm1", "--quiesce", "vm2"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "")
+	c.Check(string(stderr), Equals, "")
+
+	s.st.Lock()
+	c.Assert(task.Log(), HasLen, 1)
+	c.Check(task.Log()[0], Matches, `.* Workloads "vm1", "vm2" must be quiesced before the refresh proceeds`)
+	var quiesces map[string]map[string]any
+	c.Assert(s.st.Get("workload-quiesce", &quiesces), IsNil)
+	c.Check(quiesces["foo"]["change"], Equals, chg.ID())
+	c.Check(quiesces["foo"]["workloads"], DeepEquals, []any{"vm1", "vm2"})
+	c.Check(quiesces["foo"]["complete"], IsNil)
+	s.st.Unlock()
+
+	_, _, err = ctlcmd.Run(snapContext, []string{"refresh", "--quiesced"}, 0)
+	c.Assert(err, IsNil)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	c.Assert(s.st.Get("workload-quiesce", &quiesces), IsNil)
+	c.Check(quiesces["foo"]["complete"], Equals, true)
+}
+
+func (s *refreshSuite) TestRefreshQuiesceErrors(c *C) {
+	s.st.Lock()
+	task := s.st.NewTask("run-hook", "...")
+	setup := &hookstate.HookSetup{Snap: "foo", Revision: snap.R(1), Hook: "gate-auto-refresh"}
+	gateContext, err := hookstate.NewContext(task, s.st, setup, s.mockHandler, "")
+	c.Assert(err, IsNil)
+	snapContext, err := hookstate.NewContext(nil, s.st, &hookstate.HookSetup{Snap: "foo", Revision: snap.R(1)}, nil, "")
+	c.Assert(err, IsNil)
+	s.st.Unlock()
+
+	for _, tc := range []struct {
+		ctx  *hookstate.Context
+		args []string
+		err  string
+	}{
+		{gateContext, []string{"refresh", "--quiesce=vm1"}, `can only report workloads to quiesce from the pre-refresh hook`},
+		{snapContext, []string{"refresh", "--quiesce=vm1"}, `can only report workloads to quiesce from the pre-refresh hook`},
+		{snapContext, []string{"refresh", "--quiesce=vm1", "--quiesced"}, `cannot use --quiesce and --quiesced together`},
+		{snapContext, []string{"refresh", "--quiesced", "--pending"}, `cannot use --quiesce or --quiesced with other options`},
+	} {
+		_, _, err := ctlcmd.Run(tc.ctx, tc.args, 0)
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.args))
+	}
+}
@@ -38,3 +38,19 @@ func MockDefaultHookTimeout(timeout time.Duration) func() {
 		defaultHookTimeout = oldDefaultTimeout
 	}
 }
+
+func MockHookOutputLimits(lineMax, max int) func() {
+	oldLineMax, oldMax := hookOutputLineMax, hookOutputMax
+	hookOutputLineMax, hookOutputMax = lineMax, max
+	return func() {
+		hookOutputLineMax, hookOutputMax = oldLineMax, oldMax
+	}
+}
+
+func MockHookOutputBatch(max int, delay time.Duration) func() {
+	oldMax, oldDelay := hookOutputBatchMax, hookOutputBatchDelay
+	hookOutputBatchMax, hookOutputBatchDelay = max, delay
+	return func() {
+		hookOutputBatchMax, hookOutputBatchDelay = oldMax, oldDelay
+	}
+}
@@ -0,0 +1,118 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hookstate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// HookEnvConfigKey is the system option under which operators declare
+// the environment injected into the hooks of a snap, in the form
+// hooks.env.<snap-name>.<name>=<value>. Option names cannot contain the
+// underscore of instance names, so the environment applies to all the
+// instances of the snap.
+const HookEnvConfigKey = "hooks.env"
+
+// reservedHookEnvPrefixes and reservedHookEnvNames cannot be set through
+// the system option as they are either set up by snapd itself or could
+// change how the hook is executed.
+var (
+	reservedHookEnvPrefixes = []string{"SNAP", "LD_", "XDG_", "LC_"}
+	reservedHookEnvNames    = []string{"PATH", "HOME", "USER", "SHELL", "TMPDIR", "LANG", "TERM", "IFS"}
+)
+
+// HookEnvVarName returns the name of the environment variable for the
+// given hooks.env option name. Dashes are replaced by underscores and the
+// name is upper-cased, i.e. "site-id" becomes "SITE_ID".
+func HookEnvVarName(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("hook environment variable name cannot be empty")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return "", fmt.Errorf("invalid hook environment variable name %q: only lowercase letters, digits and dashes are allowed", name)
+		}
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		return "", fmt.Errorf("invalid hook environment variable name %q: cannot start with a digit", name)
+	}
+
+	varName := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	for _, prefix := range reservedHookEnvPrefixes {
+		if strings.HasPrefix(varName, prefix) {
+			return "", fmt.Errorf("cannot use reserved hook environment variable %q", varName)
+		}
+	}
+	for _, reserved := range reservedHookEnvNames {
+		if varName == reserved {
+			return "", fmt.Errorf("cannot use reserved hook environment variable %q", varName)
+		}
+	}
+	return varName, nil
+}
+
+// configuredHookEnv returns the environment configured by the operator for
+// the hooks of the given snap instance, sorted by variable name.
+func configuredHookEnv(st *state.State, instanceName string) ([]string, error) {
+	var vars map[string]any
+	tr := config.NewTransaction(st)
+	snapName := snap.InstanceSnap(instanceName)
+	if err := tr.Get("core", HookEnvConfigKey+"."+snapName, &vars); err != nil {
+		if config.IsNoOption(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		varName, err := HookEnvVarName(name)
+		if err != nil {
+			return nil, err
+		}
+		switch value.(type) {
+		case nil, map[string]any, []any:
+			return nil, fmt.Errorf("invalid value for hook environment variable %q", varName)
+		}
+		env = append(env, fmt.Sprintf("%s=%v", varName, value))
+	}
+	sort.Strings(env)
+	return env, nil
+}
+
+// hookSetupEnv returns the environment set up by snapd for the given hook
+// invocation, sorted by variable name.
+func hookSetupEnv(setup *HookSetup) []string {
+	if len(setup.Env) == 0 {
+		return nil
+	}
+	env := make([]string, 0, len(setup.Env))
+	for name, value := range setup.Env {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(env)
+	return env
+}
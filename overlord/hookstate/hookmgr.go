@@ -23,6 +23,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -103,6 +104,10 @@ type HookSetup struct {
 	// ComponentRevision is the revision of the component that the hook is
 	// associated with. Only valid if Component is not empty.
 	ComponentRevision snap.Revision `json:"component-revision"`
+
+	// Env is additional environment set up by snapd for this particular
+	// hook invocation, e.g. the epochs crossed by an epoch migration.
+	Env map[string]string `json:"env,omitempty"`
 }
 
 // Manager returns a new HookManager.
@@ -469,7 +474,31 @@ func (m *HookManager) runHook(context *Context, snapst *snapstate.SnapState, hoo
 }
 
 func runHookImpl(c *Context, tomb *tomb.Tomb) ([]byte, error) {
-	return runHookAndWait(c.HookSource(), c.SnapRevision(), c.HookName(), c.ID(), c.Timeout(), tomb)
+	st := c.State()
+	st.Lock()
+	extraEnv, err := configuredHookEnv(st, c.InstanceName())
+	st.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get configured hook environment: %v", err)
+	}
+	if len(extraEnv) > 0 {
+		names := make([]string, 0, len(extraEnv))
+		for _, kv := range extraEnv {
+			names = append(names, strings.SplitN(kv, "=", 2)[0])
+		}
+		// only log the names, values may be sensitive
+		logger.Noticef("injecting configured environment %s into hook %q of %q", strings.Join(names, ", "), c.HookName(), c.HookSource())
+	}
+	extraEnv = append(extraEnv, hookSetupEnv(c.setup)...)
+
+	var output io.Writer
+	if !c.IsEphemeral() {
+		// stream the output into the task log as the hook runs
+		w := newTaskOutputWriter(c)
+		defer w.Flush()
+		output = w
+	}
+	return runHookAndWait(c.HookSource(), c.SnapRevision(), c.HookName(), c.ID(), c.Timeout(), extraEnv, output, tomb)
 }
 
 var runHook = runHookImpl
@@ -508,7 +537,7 @@ func snapCmd() string {
 
 var defaultHookTimeout = 10 * time.Minute
 
-func runHookAndWait(hookSource string, revision snap.Revision, hookName, hookContext string, timeout time.Duration, tomb *tomb.Tomb) ([]byte, error) {
+func runHookAndWait(hookSource string, revision snap.Revision, hookName, hookContext string, timeout time.Duration, extraEnv []string, output io.Writer, tomb *tomb.Tomb) ([]byte, error) {
 	argv := []string{snapCmd(), "run", "--hook", hookName, "-r", revision.String(), hookSource}
 	if timeout == 0 {
 		timeout = defaultHookTimeout
@@ -523,6 +552,7 @@ func runHookAndWait(hookSource string, revision snap.Revision, hookName, hookCon
 		// hook would fail during transition.
 		fmt.Sprintf("SNAP_CONTEXT=%s", hookContext),
 	}
+	env = append(env, extraEnv...)
 
-	return osutil.RunAndWait(argv, env, timeout, tomb)
+	return osutil.RunAndWaitWithOutput(argv, env, timeout, output, tomb)
 }
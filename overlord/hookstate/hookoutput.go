@@ -0,0 +1,150 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hookstate
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// hookOutputLineMax is the maximum length of a single line of hook
+	// output logged into the task, longer lines are truncated.
+	hookOutputLineMax = 512
+	// hookOutputMax is the maximum amount of hook output logged into the
+	// task over the whole run of the hook.
+	hookOutputMax = 16 * 1024
+	// hookOutputBatchMax is the amount of output after which the collected
+	// lines are logged into the task as a single entry.
+	hookOutputBatchMax = 4 * 1024
+	// hookOutputBatchDelay is the maximum time for which output is
+	// collected before it is logged into the task.
+	hookOutputBatchDelay = time.Second
+)
+
+// taskOutputWriter streams the output of a running hook into the log of
+// the hook task, so that it can be followed through the change while the
+// hook is still running. Lines are logged in batches, by size or by time,
+// so that the output does not push the other entries out of the bounded
+// task log and the state lock is not taken for every line.
+type taskOutputWriter struct {
+	context *Context
+
+	mu        sync.Mutex
+	partial   []byte
+	batch     []string
+	batchSize int
+	timer     *time.Timer
+	logged    int
+	truncated bool
+}
+
+func newTaskOutputWriter(context *Context) *taskOutputWriter {
+	return &taskOutputWriter{context: context}
+}
+
+func (w *taskOutputWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		idx := bytes.IndexByte(w.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		w.addLine(w.partial[:idx])
+		w.partial = w.partial[idx+1:]
+	}
+	// do not buffer an endless line
+	if len(w.partial) > hookOutputLineMax {
+		w.addLine(w.partial)
+		w.partial = nil
+	}
+	return len(p), nil
+}
+
+func (w *taskOutputWriter) addLine(line []byte) {
+	if w.truncated {
+		return
+	}
+	line = bytes.TrimRight(line, "\r")
+	if len(line) > hookOutputLineMax {
+		line = line[:hookOutputLineMax]
+	}
+	if w.logged+len(line) > hookOutputMax {
+		w.truncated = true
+		w.logBatch()
+		w.context.Lock()
+		w.context.Logf("hook output truncated after %d bytes", w.logged)
+		w.context.Unlock()
+		return
+	}
+	w.logged += len(line)
+	w.batch = append(w.batch, string(line))
+	w.batchSize += len(line)
+
+	if w.batchSize >= hookOutputBatchMax {
+		w.logBatch()
+		return
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(hookOutputBatchDelay, w.flushBatch)
+	}
+}
+
+func (w *taskOutputWriter) flushBatch() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logBatch()
+}
+
+// logBatch logs the collected lines as a single entry of the task log, it
+// must be called with mu held.
+func (w *taskOutputWriter) logBatch() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(w.batch) == 0 {
+		return
+	}
+
+	w.context.Lock()
+	defer w.context.Unlock()
+	w.context.Logf("hook output:\n%s", strings.Join(w.batch, "\n"))
+	w.batch = nil
+	w.batchSize = 0
+}
+
+// Flush logs the output collected so far, including any trailing output
+// not terminated by a newline.
+func (w *taskOutputWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.addLine(w.partial)
+		w.partial = nil
+	}
+	w.logBatch()
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
 )
 
 func init() {
@@ -40,8 +41,11 @@ func init() {
 	snapstate.SetupRemoveComponentHook = SetupRemoveComponentHook
 	snapstate.SetupPreRefreshHook = SetupPreRefreshHook
 	snapstate.SetupPostRefreshHook = SetupPostRefreshHook
+	snapstate.SetupPreEpochMigrationHook = SetupPreEpochMigrationHook
+	snapstate.SetupPostEpochMigrationHook = SetupPostEpochMigrationHook
 	snapstate.SetupRemoveHook = SetupRemoveHook
 	snapstate.SetupGateAutoRefreshHook = SetupGateAutoRefreshHook
+	snapstate.SetupRefreshInhibitHook = SetupRefreshInhibitHook
 }
 
 func SetupInstallHook(st *state.State, snapName string) *state.Task {
@@ -138,6 +142,39 @@ func SetupPreRefreshHook(st *state.State, snapName string) *state.Task {
 	return task
 }
 
+// epochMigrationEnv returns the hook environment describing the epoch
+// boundary crossed by a refresh.
+func epochMigrationEnv(from, to snap.Epoch) map[string]string {
+	return map[string]string{
+		"SNAP_EPOCH_FROM": from.String(),
+		"SNAP_EPOCH_TO":   to.String(),
+	}
+}
+
+func SetupPreEpochMigrationHook(st *state.State, snapName string, from, to snap.Epoch) *state.Task {
+	hooksup := &HookSetup{
+		Snap:     snapName,
+		Hook:     "pre-epoch-migration",
+		Optional: true,
+		Env:      epochMigrationEnv(from, to),
+	}
+
+	summary := fmt.Sprintf(i18n.G("Run pre-epoch-migration hook of %q snap if present"), hooksup.Snap)
+	return HookTask(st, summary, hooksup, nil)
+}
+
+func SetupPostEpochMigrationHook(st *state.State, snapName string, from, to snap.Epoch) *state.Task {
+	hooksup := &HookSetup{
+		Snap:     snapName,
+		Hook:     "post-epoch-migration",
+		Optional: true,
+		Env:      epochMigrationEnv(from, to),
+	}
+
+	summary := fmt.Sprintf(i18n.G("Run post-epoch-migration hook of %q snap if present"), hooksup.Snap)
+	return HookTask(st, summary, hooksup, nil)
+}
+
 type gateAutoRefreshHookHandler struct {
 	context             *Context
 	refreshAppAwareness bool
@@ -353,6 +390,21 @@ func SetupGateAutoRefreshHook(st *state.State, snapName string) *state.Task {
 	return task
 }
 
+// SetupRefreshInhibitHook returns a task running the refresh-inhibit hook of
+// the snap, which lets it checkpoint its state while a refresh is postponed
+// because its apps are running.
+func SetupRefreshInhibitHook(st *state.State, snapName string) *state.Task {
+	hooksup := &HookSetup{
+		Snap:        snapName,
+		Hook:        "refresh-inhibit",
+		Optional:    true,
+		IgnoreError: true,
+	}
+
+	summary := fmt.Sprintf(i18n.G("Run refresh-inhibit hook of %q snap if present"), hooksup.Snap)
+	return HookTask(st, summary, hooksup, nil)
+}
+
 type SnapHookHandler struct{}
 
 func (h *SnapHookHandler) Before() error                 { return nil }
@@ -384,6 +436,9 @@ func setupHooks(hookMgr *HookManager) {
 	hookMgr.Register(regexp.MustCompile("^install$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^post-refresh$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^pre-refresh$"), handlerGenerator)
+	hookMgr.Register(regexp.MustCompile("^pre-epoch-migration$"), handlerGenerator)
+	hookMgr.Register(regexp.MustCompile("^post-epoch-migration$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^remove$"), handlerGenerator)
+	hookMgr.Register(regexp.MustCompile("^refresh-inhibit$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^gate-auto-refresh$"), gateAutoRefreshHandlerGenerator)
 }
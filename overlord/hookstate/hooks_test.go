@@ -45,6 +45,7 @@ version: 1
 base: base-snap-a
 hooks:
     gate-auto-refresh:
+    refresh-inhibit:
 `
 
 const snapaBaseYaml = `name: base-snap-a
@@ -537,3 +538,33 @@ func (s *gateAutoRefreshHookSuite) TestGateAutorefreshHookErrorHoldErrorLogged(c
 	c.Check(hint, Equals, runinhibit.HintNotInhibited)
 	c.Check(info, Equals, runinhibit.InhibitInfo{})
 }
+
+func (s *gateAutoRefreshHookSuite) TestRefreshInhibitHookErrorIgnored(c *C) {
+	var invoked int
+	hookInvoke := func(ctx *hookstate.Context, tomb *tomb.Tomb) ([]byte, error) {
+		invoked++
+		c.Check(ctx.HookName(), Equals, "refresh-inhibit")
+		c.Check(ctx.InstanceName(), Equals, "snap-a")
+		return []byte("fail"), fmt.Errorf("boom")
+	}
+	restore := hookstate.MockRunHook(hookInvoke)
+	defer restore()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	task := hookstate.SetupRefreshInhibitHook(st, "snap-a")
+	c.Check(task.Summary(), Equals, `Run refresh-inhibit hook of "snap-a" snap if present`)
+	change := st.NewChange("kind", "summary")
+	change.AddTask(task)
+
+	st.Unlock()
+	s.settle(c)
+	st.Lock()
+
+	c.Check(invoked, Equals, 1)
+	c.Assert(change.Err(), IsNil)
+	c.Assert(change.Status(), Equals, state.DoneStatus)
+	c.Check(strings.Join(task.Log(), ""), Matches, `.*ignoring failure in hook "refresh-inhibit".*`)
+}
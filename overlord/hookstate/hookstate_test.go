@@ -23,8 +23,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -33,7 +35,9 @@ import (
 	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
 	"github.com/snapcore/snapd/overlord/restart"
@@ -613,6 +617,209 @@ func (s *hookManagerSuite) TestHookTaskCorrectlyIncludesContext(c *C) {
 	checkTaskLogContains(c, s.task, `.*SNAP_COOKIE=\S+`)
 }
 
+func (s *hookManagerSuite) TestHookTaskStreamsOutputWhileRunning(c *C) {
+	restore := hookstate.MockHookOutputBatch(4096, 10*time.Millisecond)
+	defer restore()
+
+	flag := filepath.Join(c.MkDir(), "flag")
+	cmd := testutil.MockCommand(
+		c, "snap", fmt.Sprintf("echo started; echo running; while [ ! -e %s ]; do sleep 0.01; done; >&2 printf finished", flag))
+	defer cmd.Restore()
+
+	s.se.Ensure()
+
+	// the output is visible in the task log while the hook is running
+	for i := 0; ; i++ {
+		s.state.Lock()
+		found := false
+		for _, msg := range s.task.Log() {
+			if strings.HasSuffix(msg, "hook output:\nstarted\nrunning") {
+				found = true
+			}
+		}
+		status := s.task.Status()
+		s.state.Unlock()
+		if found {
+			c.Check(status, Equals, state.DoingStatus)
+			break
+		}
+		if i > 500 {
+			c.Fatal("hook output not found in the task log")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(os.WriteFile(flag, nil, 0644), IsNil)
+
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.task.Status(), Equals, state.DoneStatus)
+	// trailing output without a newline is logged as well
+	checkTaskLogContains(c, s.task, `.*hook output:\nfinished$`)
+}
+
+func (s *hookManagerSuite) TestHookTaskOutputIsBatched(c *C) {
+	restore := hookstate.MockHookOutputBatch(10, time.Hour)
+	defer restore()
+
+	cmd := testutil.MockCommand(
+		c, "snap", "echo 01234; echo 56789; echo abcde; echo fghij; echo klm")
+	defer cmd.Restore()
+
+	s.state.Lock()
+	s.task.Logf("before the hook")
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.task.Status(), Equals, state.DoneStatus)
+	var output []string
+	for _, msg := range s.task.Log() {
+		if idx := strings.Index(msg, "hook output"); idx >= 0 {
+			output = append(output, msg[idx:])
+		}
+	}
+	c.Check(output, DeepEquals, []string{
+		"hook output:\n01234\n56789",
+		"hook output:\nabcde\nfghij",
+		"hook output:\nklm",
+	})
+	// the other entries are kept
+	checkTaskLogContains(c, s.task, `.*before the hook$`)
+}
+
+func (s *hookManagerSuite) TestHookTaskOutputIsCapped(c *C) {
+	restore := hookstate.MockHookOutputLimits(5, 12)
+	defer restore()
+
+	cmd := testutil.MockCommand(
+		c, "snap", "echo 0123456789; echo abcde; echo fghij; echo klmno")
+	defer cmd.Restore()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.task.Status(), Equals, state.DoneStatus)
+	var output []string
+	for _, msg := range s.task.Log() {
+		if idx := strings.Index(msg, "hook output"); idx >= 0 {
+			output = append(output, msg[idx:])
+		}
+	}
+	c.Check(output, DeepEquals, []string{
+		"hook output:\n01234\nabcde",
+		"hook output truncated after 10 bytes",
+	})
+}
+
+func (s *hookManagerSuite) TestHookTaskIncludesConfiguredEnv(c *C) {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "hooks.env.test-snap.site-id", "site-1"), IsNil)
+	c.Assert(tr.Set("core", "hooks.env.other-snap.other-id", "other"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	cmd := testutil.MockCommand(
+		c, "snap", ">&2 echo \"SITE_ID=$SITE_ID OTHER_ID=$OTHER_ID\"; exit 1")
+	defer cmd.Restore()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.task.Status(), Equals, state.ErrorStatus)
+	checkTaskLogContains(c, s.task, `.*SITE_ID=site-1 OTHER_ID=$`)
+	// only the names of the variables are logged by the hook manager, the
+	// value above only shows up as part of the hook output
+	c.Check(logbuf.String(), testutil.Contains, `injecting configured environment SITE_ID into hook "configure" of "test-snap"`)
+	c.Check(logbuf.String(), Not(Matches), `(?s).*hookmgr\.go:[0-9]+: [^\n]*site-1.*`)
+}
+
+func (s *hookManagerSuite) TestHookTaskConfiguredEnvError(c *C) {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "hooks.env.test-snap.path", "/foo"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	cmd := testutil.MockCommand(c, "snap", "")
+	defer cmd.Restore()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.task.Status(), Equals, state.ErrorStatus)
+	checkTaskLogContains(c, s.task, `.*cannot get configured hook environment: cannot use reserved hook environment variable "PATH"`)
+	c.Check(cmd.Calls(), HasLen, 0)
+}
+
+func (s *hookManagerSuite) TestHookTaskIncludesSetupEnv(c *C) {
+	s.state.Lock()
+	var hooksup hookstate.HookSetup
+	c.Assert(s.task.Get("hook-setup", &hooksup), IsNil)
+	hooksup.Env = map[string]string{
+		"SNAP_EPOCH_FROM": "0",
+		"SNAP_EPOCH_TO":   "1*",
+	}
+	s.task.Set("hook-setup", &hooksup)
+	s.state.Unlock()
+
+	cmd := testutil.MockCommand(
+		c, "snap", ">&2 echo \"FROM=$SNAP_EPOCH_FROM TO=$SNAP_EPOCH_TO\"; exit 1")
+	defer cmd.Restore()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.task.Status(), Equals, state.ErrorStatus)
+	checkTaskLogContains(c, s.task, `.*FROM=0 TO=1\*`)
+}
+
+func (s *hookManagerSuite) TestHookEnvVarName(c *C) {
+	for _, tc := range []struct {
+		name, varName, err string
+	}{
+		{name: "site-id", varName: "SITE_ID"},
+		{name: "foo2", varName: "FOO2"},
+		{name: "", err: "hook environment variable name cannot be empty"},
+		{name: "Foo", err: `invalid hook environment variable name "Foo": only lowercase letters, digits and dashes are allowed`},
+		{name: "foo_bar", err: `invalid hook environment variable name "foo_bar": only lowercase letters, digits and dashes are allowed`},
+		{name: "2foo", err: `invalid hook environment variable name "2foo": cannot start with a digit`},
+		{name: "snap-foo", err: `cannot use reserved hook environment variable "SNAP_FOO"`},
+		{name: "ld-preload", err: `cannot use reserved hook environment variable "LD_PRELOAD"`},
+		{name: "home", err: `cannot use reserved hook environment variable "HOME"`},
+	} {
+		varName, err := hookstate.HookEnvVarName(tc.name)
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err, Commentf("%q", tc.name))
+			continue
+		}
+		c.Check(err, IsNil)
+		c.Check(varName, Equals, tc.varName)
+	}
+}
+
 func (s *hookManagerSuite) TestHookTaskHandlerBeforeError(c *C) {
 	s.mockHandler.BeforeError = true
 
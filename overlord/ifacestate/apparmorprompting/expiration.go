@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmorprompting
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// expirationPolicyFromConfig returns the policy for prompts which are not
+// answered in time, as configured via the prompting.timeout and
+// prompting.timeout-allow system options.
+//
+// The caller must ensure that the state lock is held.
+func expirationPolicyFromConfig(st *state.State) (requestprompts.ExpirationPolicy, error) {
+	var policy requestprompts.ExpirationPolicy
+	tr := config.NewTransaction(st)
+	var timeout, allow string
+	if err := tr.Get("core", "prompting.timeout", &timeout); err != nil && !config.IsNoOption(err) {
+		return policy, err
+	}
+	if err := tr.Get("core", "prompting.timeout-allow", &allow); err != nil && !config.IsNoOption(err) {
+		return policy, err
+	}
+	if timeout == "" {
+		return policy, nil
+	}
+	dur, err := time.ParseDuration(timeout)
+	if err != nil {
+		return policy, err
+	}
+	policy.Timeout = dur
+	policy.AllowInterfaces = strutil.CommaSeparatedList(allow)
+	return policy, nil
+}
+
+// readExpirationPolicy returns the currently configured expiration policy.
+//
+// The caller must ensure that neither the state lock nor the manager lock
+// is held, as the state lock must never be taken while holding the manager
+// lock.
+func (m *InterfacesRequestsManager) readExpirationPolicy() (requestprompts.ExpirationPolicy, error) {
+	m.state.Lock()
+	defer m.state.Unlock()
+	return expirationPolicyFromConfig(m.state)
+}
+
+// applyExpirationPolicy applies the given expiration policy to the prompt
+// DB, if it differs from the one which was applied last.
+//
+// The caller must ensure that the manager lock is held.
+func (m *InterfacesRequestsManager) applyExpirationPolicy(policy requestprompts.ExpirationPolicy) {
+	if m.policyApplied && reflect.DeepEqual(m.policy, policy) {
+		return
+	}
+	if err := m.prompts.SetExpirationPolicy(policy); err != nil {
+		logger.Noticef("cannot set prompt expiration policy: %v", err)
+		return
+	}
+	m.policy = policy
+	m.policyApplied = true
+}
+
+// UpdateExpirationPolicy applies the currently configured expiration policy
+// to the prompt DB, so that changes of the prompting.timeout and
+// prompting.timeout-allow system options take effect for the outstanding
+// prompts.
+//
+// The caller must ensure that the state lock is not held.
+func (m *InterfacesRequestsManager) UpdateExpirationPolicy() {
+	policy, err := m.readExpirationPolicy()
+	if err != nil {
+		logger.Noticef("cannot read prompt expiration policy: %v", err)
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.applyExpirationPolicy(policy)
+}
+
+// Metrics returns metrics about the backlog of outstanding prompts and the
+// prompts which expired without being answered.
+func (m *InterfacesRequestsManager) Metrics() (*requestprompts.BacklogMetrics, error) {
+	// Wait until the listener has re-sent pending requests and prompts have
+	// been re-created.
+	<-m.ready
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.prompts.Metrics()
+}
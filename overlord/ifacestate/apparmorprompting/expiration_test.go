@@ -0,0 +1,145 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmorprompting_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
+	"github.com/snapcore/snapd/sandbox/apparmor/notify"
+	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
+)
+
+func (s *apparmorpromptingSuite) TestExpirationPolicyFromConfig(c *C) {
+	readyChan, reqChan, replyChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	s.st.Lock()
+	tr := config.NewTransaction(s.st)
+	c.Assert(tr.Set("core", "prompting.timeout", "1s"), IsNil)
+	c.Assert(tr.Set("core", "prompting.timeout-allow", "home"), IsNil)
+	tr.Commit()
+	s.st.Unlock()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	close(readyChan)
+
+	req := &listener.Request{}
+	s.fillInPartialRequest(req)
+	reqChan <- req
+
+	// The prompt is not answered, so it is allowed once the timeout elapses
+	var resp *apparmorprompting.RequestResponse
+	select {
+	case r := <-replyChan:
+		resp = &r
+	case <-time.After(5 * time.Second):
+		c.Fatal("no reply received")
+	}
+	c.Check(resp.Request, Equals, req)
+	aaPerms, err := prompting.AbstractPermissionsToAppArmorPermissions("home", []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(resp.AllowedPermission, Equals, aaPerms)
+
+	metrics, err := mgr.Metrics()
+	c.Assert(err, IsNil)
+	c.Check(metrics.Outstanding, Equals, 0)
+	c.Check(metrics.Expired, Equals, 1)
+	c.Assert(metrics.RecentlyExpired, HasLen, 1)
+	c.Check(metrics.RecentlyExpired[0].Snap, Equals, "firefox")
+	c.Check(metrics.RecentlyExpired[0].Outcome, Equals, prompting.OutcomeAllow)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestUpdateExpirationPolicy(c *C) {
+	readyChan, reqChan, replyChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	close(readyChan)
+
+	req := &listener.Request{}
+	s.fillInPartialRequest(req)
+	reqChan <- req
+	time.Sleep(10 * time.Millisecond)
+
+	prompts, err := mgr.Prompts(s.defaultUser, false)
+	c.Assert(err, IsNil)
+	c.Assert(prompts, HasLen, 1)
+
+	// a timeout configured after the prompt was created applies to it
+	// once the policy is updated, without waiting for a new prompt
+	s.st.Lock()
+	tr := config.NewTransaction(s.st)
+	c.Assert(tr.Set("core", "prompting.timeout", "1s"), IsNil)
+	tr.Commit()
+	s.st.Unlock()
+
+	mgr.UpdateExpirationPolicy()
+
+	select {
+	case resp := <-replyChan:
+		// denied by default
+		c.Check(resp.Request, Equals, req)
+		c.Check(resp.AllowedPermission, Equals, notify.FilePermission(0))
+	case <-time.After(5 * time.Second):
+		c.Fatal("no reply received")
+	}
+
+	metrics, err := mgr.Metrics()
+	c.Assert(err, IsNil)
+	c.Check(metrics.Outstanding, Equals, 0)
+	c.Check(metrics.Expired, Equals, 1)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestMetrics(c *C) {
+	readyChan, reqChan, _, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	close(readyChan)
+
+	req := &listener.Request{}
+	s.fillInPartialRequest(req)
+	reqChan <- req
+	time.Sleep(10 * time.Millisecond)
+
+	prompts, err := mgr.Prompts(s.defaultUser, false)
+	c.Assert(err, IsNil)
+	c.Assert(prompts, HasLen, 1)
+
+	metrics, err := mgr.Metrics()
+	c.Assert(err, IsNil)
+	c.Check(metrics.Outstanding, Equals, 1)
+	c.Check(metrics.PerInterface, DeepEquals, map[string]int{"home": 1})
+	c.Check(metrics.Expired, Equals, 0)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
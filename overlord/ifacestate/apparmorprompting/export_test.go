@@ -20,6 +20,8 @@
 package apparmorprompting
 
 import (
+	"time"
+
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify"
@@ -47,6 +49,18 @@ func MockListenerClose(f func(l *listener.Listener) error) (restore func()) {
 	return testutil.Mock(&listenerClose, f)
 }
 
+func MockRulesRemoveExpired(f func(rdb *requestrules.RuleDB) error) (restore func()) {
+	return testutil.Mock(&rulesRemoveExpired, f)
+}
+
+func MockExpiredRulesGCInterval(interval time.Duration) (restore func()) {
+	return testutil.Mock(&expiredRulesGCInterval, interval)
+}
+
+func MockMaxRemoteApproverRequests(max int) (restore func()) {
+	return testutil.Mock(&maxRemoteApproverRequests, max)
+}
+
 type RequestResponse struct {
 	Request           *listener.Request
 	AllowedPermission notify.AppArmorPermission
@@ -22,7 +22,9 @@ package apparmorprompting
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"sync"
+	"time"
 
 	"gopkg.in/tomb.v2"
 
@@ -53,6 +55,13 @@ var (
 	promptsHandleReadying = (*requestprompts.PromptDB).HandleReadying
 
 	promptingInterfaceFromTagsets = prompting.InterfaceFromTagsets
+
+	rulesRemoveExpired = (*requestrules.RuleDB).RemoveExpiredRules
+
+	// expiredRulesGCInterval is how often rules which have expired, for
+	// example because their user session ended or they were used up, are
+	// removed from the rule database.
+	expiredRulesGCInterval = 10 * time.Minute
 )
 
 // A Manager holds outstanding prompts and mediates their replies, further it
@@ -67,13 +76,15 @@ type Manager interface {
 	RuleWithID(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
 	PatchRule(userID uint32, ruleID prompting.IDType, constraintsPatch *prompting.RuleConstraintsPatch) (*requestrules.Rule, error)
 	RemoveRule(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
+	Metrics() (*requestprompts.BacklogMetrics, error)
 }
 
 // verify that InterfacesRequestsManager implements Manager
 var _ Manager = (*InterfacesRequestsManager)(nil)
 
 type InterfacesRequestsManager struct {
-	tomb tomb.Tomb
+	tomb  tomb.Tomb
+	state *state.State
 	// The lock should be held for writing when acting on the manager in a way
 	// which requires synchronization between the prompts and rules databases,
 	// or when removing those databases. The lock can be held for reading when
@@ -94,8 +105,19 @@ type InterfacesRequestsManager struct {
 	// actually getting the chance to handle the request.
 	ready chan struct{}
 
+	// policy is the expiration policy which was last applied to the
+	// prompt DB, if policyApplied is set.
+	policy        requestprompts.ExpirationPolicy
+	policyApplied bool
+
 	notifyPrompt func(userID uint32, promptID prompting.IDType, data map[string]string) error
 	notifyRule   func(userID uint32, ruleID prompting.IDType, data map[string]string) error
+
+	// approverClient is used to forward prompts to the remote approver, and
+	// approverSlots bounds the number of forwarded prompts waiting for a
+	// reply.
+	approverClient *http.Client
+	approverSlots  chan struct{}
 }
 
 func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
@@ -153,12 +175,16 @@ func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
 	}()
 
 	m = &InterfacesRequestsManager{
+		state:        s,
 		listener:     listenerBackend,
 		prompts:      promptsBackend,
 		rules:        rulesBackend,
 		ready:        make(chan struct{}),
 		notifyPrompt: notifyPrompt,
 		notifyRule:   notifyRule,
+
+		approverClient: newRemoteApproverClient(s),
+		approverSlots:  make(chan struct{}, maxRemoteApproverRequests),
 	}
 
 	m.tomb.Go(m.run)
@@ -191,6 +217,9 @@ func (m *InterfacesRequestsManager) run() error {
 		}
 	}()
 
+	gcTicker := time.NewTicker(expiredRulesGCInterval)
+	defer gcTicker.Stop()
+
 run_loop:
 	for {
 		logger.Debugf("waiting prompt loop")
@@ -236,6 +265,13 @@ run_loop:
 			if err := m.handleListenerReq(req); err != nil {
 				logger.Noticef("error while handling request: %+v", err)
 			}
+		case <-gcTicker.C:
+			m.lock.RLock()
+			err := rulesRemoveExpired(m.rules)
+			m.lock.RUnlock()
+			if err != nil {
+				logger.Noticef("cannot remove expired rules: %v", err)
+			}
 		case <-m.tomb.Dying():
 			logger.Debugf("InterfacesRequestsManager tomb is dying with error %v, disconnecting", m.tomb.Err())
 			break run_loop
@@ -291,6 +327,19 @@ func (m *InterfacesRequestsManager) handleListenerReq(req *listener.Request) err
 		return requestReply(req, nil)
 	}
 
+	// read the configuration now, as the state lock must not be taken
+	// while holding the manager lock
+	m.state.Lock()
+	approver, err := remoteApproverFromConfig(m.state)
+	m.state.Unlock()
+	if err != nil {
+		logger.Noticef("cannot get remote approver configuration: %v", err)
+	}
+	policy, policyErr := m.readExpirationPolicy()
+	if policyErr != nil {
+		logger.Noticef("cannot read prompt expiration policy: %v", policyErr)
+	}
+
 	// we're done with early checks, serious business starts now, and we can
 	// take the lock
 	m.lock.Lock()
@@ -325,6 +374,10 @@ func (m *InterfacesRequestsManager) handleListenerReq(req *listener.Request) err
 		Interface: iface,
 	}
 
+	if policyErr == nil {
+		m.applyExpirationPolicy(policy)
+	}
+
 	newPrompt, merged, err := m.prompts.AddOrMerge(metadata, path, permissions, outstandingPerms, req)
 	if err != nil {
 		logger.Noticef("error while checking request against prompt DB: %v", err)
@@ -342,6 +395,7 @@ func (m *InterfacesRequestsManager) handleListenerReq(req *listener.Request) err
 		logger.Debugf("new prompt merged with identical existing prompt: %+v", newPrompt)
 	} else {
 		logger.Debugf("adding prompt to internal storage: %+v", newPrompt)
+		m.maybeForwardPrompt(approver, userID, newPrompt)
 	}
 
 	return nil
@@ -184,6 +184,35 @@ func (s *apparmorpromptingSuite) TestStop(c *C) {
 	c.Check(err, Equals, prompting_errors.ErrRulesClosed)
 }
 
+func (s *apparmorpromptingSuite) TestExpiredRulesGC(c *C) {
+	_, _, _, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	restore = apparmorprompting.MockExpiredRulesGCInterval(time.Millisecond)
+	defer restore()
+
+	called := make(chan *requestrules.RuleDB, 1)
+	restore = apparmorprompting.MockRulesRemoveExpired(func(rdb *requestrules.RuleDB) error {
+		select {
+		case called <- rdb:
+		default:
+		}
+		return nil
+	})
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+
+	select {
+	case rdb := <-called:
+		c.Check(rdb, Equals, mgr.RuleDB())
+	case <-time.After(5 * time.Second):
+		c.Fatal("expired rules were not removed")
+	}
+}
+
 func (s *apparmorpromptingSuite) TestHandleListenerRequestInterfaceSelection(c *C) {
 	readyChan, reqChan, replyChan, restore := apparmorprompting.MockListener()
 	defer restore()
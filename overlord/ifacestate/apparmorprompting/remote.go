@@ -0,0 +1,259 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmorprompting
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/configstate/proxyconf"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// remoteApproverTimeout is how long to wait for the remote approver to reply
+// to a forwarded prompt. The approver may be waiting on a human decision, so
+// this is deliberately generous.
+var remoteApproverTimeout = 10 * time.Minute
+
+// maxRemoteApproverRequests is the maximum number of prompts forwarded to the
+// remote approver which may be waiting for a reply at the same time. Further
+// prompts are only left for local prompting clients.
+var maxRemoteApproverRequests = 16
+
+// newRemoteApproverClient returns the HTTP client used to talk to the remote
+// approver, which honours the proxy configured for snapd.
+func newRemoteApproverClient(st *state.State) *http.Client {
+	return httputil.NewHTTPClient(&httputil.ClientOptions{
+		Timeout: remoteApproverTimeout,
+		Proxy:   proxyconf.New(st).Conf,
+	})
+}
+
+// remoteApprover holds the configuration of a remote service to which new
+// prompts are forwarded, so that prompts can be answered on devices without a
+// local prompting client.
+type remoteApprover struct {
+	url       string
+	publicKey ed25519.PublicKey
+}
+
+// remoteApproverFromConfig returns the remote approver configured via the
+// prompting.remote-approver.url and prompting.remote-approver.public-key
+// system options, or nil if no remote approver is configured.
+//
+// The caller must ensure that the state lock is held.
+func remoteApproverFromConfig(st *state.State) (*remoteApprover, error) {
+	tr := config.NewTransaction(st)
+	var url, encodedKey string
+	if err := tr.Get("core", "prompting.remote-approver.url", &url); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	if err := tr.Get("core", "prompting.remote-approver.public-key", &encodedKey); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	if url == "" || encodedKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid remote approver public key")
+	}
+	return &remoteApprover{
+		url:       url,
+		publicKey: ed25519.PublicKey(key),
+	}, nil
+}
+
+// remoteApproverRequest is the body sent to the remote approver for each new
+// prompt. The nonce is generated anew for every request, so that a signed
+// reply cannot be replayed for another request.
+type remoteApproverRequest struct {
+	UserID uint32          `json:"user-id"`
+	Nonce  string          `json:"nonce"`
+	Prompt json.RawMessage `json:"prompt"`
+}
+
+// remoteApproverResponse is the body returned by the remote approver when it
+// has a reply for the forwarded prompt. The signature is an ed25519 signature,
+// encoded as standard base64, over the prompt ID, the nonce of the request and
+// the raw bytes of the reply, separated by newlines.
+type remoteApproverResponse struct {
+	Reply     json.RawMessage `json:"reply"`
+	Signature string          `json:"signature"`
+}
+
+// remoteReply holds the contents of a reply from the remote approver, which
+// are the same as those of a reply sent via the prompts API.
+type remoteReply struct {
+	Outcome     prompting.OutcomeType       `json:"outcome"`
+	Lifespan    prompting.LifespanType      `json:"lifespan"`
+	Duration    string                      `json:"duration,omitempty"`
+	Constraints *prompting.ReplyConstraints `json:"constraints"`
+}
+
+func remoteReplySignedData(promptID prompting.IDType, nonce string, reply []byte) []byte {
+	data := make([]byte, 0, len(promptID.String())+1+len(nonce)+1+len(reply))
+	data = append(data, promptID.String()...)
+	data = append(data, '\n')
+	data = append(data, nonce...)
+	data = append(data, '\n')
+	return append(data, reply...)
+}
+
+func newRemoteApproverNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("cannot generate nonce: %v", err)
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+var errRemoteApproverNoReply = errors.New("remote approver did not reply")
+
+// requestReply forwards the given prompt to the remote approver and waits for
+// its signed reply. If the approver declines to handle the prompt, returns
+// errRemoteApproverNoReply.
+func (ra *remoteApprover) requestReply(ctx context.Context, client *http.Client, userID uint32, promptID prompting.IDType, promptJSON []byte) (*remoteReply, error) {
+	nonce, err := newRemoteApproverNonce()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(&remoteApproverRequest{
+		UserID: userID,
+		Nonce:  nonce,
+		Prompt: promptJSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, remoteApproverTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", ra.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+		// handled below
+	case http.StatusNoContent:
+		return nil, errRemoteApproverNoReply
+	default:
+		return nil, fmt.Errorf("unexpected status from remote approver: %s", rsp.Status)
+	}
+
+	var approverRsp remoteApproverResponse
+	dec := json.NewDecoder(io.LimitReader(rsp.Body, 1024*1024))
+	if err := dec.Decode(&approverRsp); err != nil {
+		return nil, fmt.Errorf("cannot decode remote approver response: %v", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(approverRsp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode remote approver signature: %v", err)
+	}
+	if !ed25519.Verify(ra.publicKey, remoteReplySignedData(promptID, nonce, approverRsp.Reply), signature) {
+		return nil, fmt.Errorf("invalid remote approver signature for prompt %s", promptID)
+	}
+
+	var reply remoteReply
+	if err := json.Unmarshal(approverRsp.Reply, &reply); err != nil {
+		return nil, fmt.Errorf("cannot decode remote approver reply: %v", err)
+	}
+	if reply.Constraints == nil {
+		return nil, fmt.Errorf("invalid remote approver reply: missing constraints")
+	}
+	return &reply, nil
+}
+
+// maybeForwardPrompt forwards the given new prompt for the given user to the
+// given remote approver, if any. The reply from the approver is then applied
+// asynchronously, as if it had been sent by a local prompting client, so if
+// the reply has a lifespan other than "single", a new rule is created.
+//
+// The caller must ensure that the manager lock is held.
+func (m *InterfacesRequestsManager) maybeForwardPrompt(approver *remoteApprover, userID uint32, prompt *requestprompts.Prompt) {
+	if approver == nil {
+		return
+	}
+	select {
+	case m.approverSlots <- struct{}{}:
+	default:
+		logger.Noticef("cannot forward prompt %s to remote approver: too many prompts waiting for a reply", prompt.ID)
+		return
+	}
+
+	// Marshal the prompt now, while the lock is held, as the prompt may be
+	// modified by the prompt DB as soon as the lock is released.
+	promptJSON, err := json.Marshal(prompt)
+	if err != nil {
+		<-m.approverSlots
+		logger.Noticef("cannot forward prompt to remote approver: %v", err)
+		return
+	}
+	promptID := prompt.ID
+	m.tomb.Go(func() error {
+		defer func() { <-m.approverSlots }()
+		m.forwardPrompt(approver, userID, promptID, promptJSON)
+		return nil
+	})
+}
+
+// forwardPrompt sends the given prompt to the remote approver and applies its
+// reply. Errors are logged rather than returned, since the prompt remains
+// available to local prompting clients regardless.
+func (m *InterfacesRequestsManager) forwardPrompt(approver *remoteApprover, userID uint32, promptID prompting.IDType, promptJSON []byte) {
+	reply, err := approver.requestReply(m.tomb.Context(nil), m.approverClient, userID, promptID, promptJSON)
+	if err != nil {
+		if errors.Is(err, errRemoteApproverNoReply) {
+			logger.Debugf("remote approver did not reply to prompt %s", promptID)
+			return
+		}
+		logger.Noticef("cannot get reply for prompt %s from remote approver: %v", promptID, err)
+		return
+	}
+
+	clientActivity := false
+	if _, err := m.HandleReply(userID, promptID, reply.Constraints, reply.Outcome, reply.Lifespan, reply.Duration, clientActivity); err != nil {
+		logger.Noticef("cannot apply reply for prompt %s from remote approver: %v", promptID, err)
+	}
+}
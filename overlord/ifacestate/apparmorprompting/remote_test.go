@@ -0,0 +1,266 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmorprompting_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
+	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
+)
+
+type forwardedPrompt struct {
+	UserID uint32 `json:"user-id"`
+	Nonce  string `json:"nonce"`
+	Prompt struct {
+		ID          prompting.IDType `json:"id"`
+		Snap        string           `json:"snap"`
+		Interface   string           `json:"interface"`
+		Constraints struct {
+			Path string `json:"path"`
+		} `json:"constraints"`
+	} `json:"prompt"`
+}
+
+// mockRemoteApprover starts a remote approver service which replies to each
+// forwarded prompt using the given function, signs the reply together with
+// the prompt ID and the nonce of the forwarded prompt with the given private
+// key, and configures it as the remote approver using the given public key.
+func (s *apparmorpromptingSuite) mockRemoteApprover(c *C, pub ed25519.PublicKey, priv ed25519.PrivateKey, replyFor func(fp *forwardedPrompt) (status int, reply string)) chan *forwardedPrompt {
+	forwarded := make(chan *forwardedPrompt, 5)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "POST")
+		var fp forwardedPrompt
+		c.Check(json.NewDecoder(r.Body).Decode(&fp), IsNil)
+		forwarded <- &fp
+
+		status, reply := replyFor(&fp)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		signed := append([]byte(fp.Prompt.ID.String()+"\n"+fp.Nonce+"\n"), reply...)
+		rsp := map[string]any{
+			"reply":     json.RawMessage(reply),
+			"signature": base64.StdEncoding.EncodeToString(ed25519.Sign(priv, signed)),
+		}
+		c.Check(json.NewEncoder(w).Encode(rsp), IsNil)
+	}))
+	s.AddCleanup(server.Close)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	tr := config.NewTransaction(s.st)
+	c.Assert(tr.Set("core", "prompting.remote-approver.url", server.URL), IsNil)
+	c.Assert(tr.Set("core", "prompting.remote-approver.public-key", base64.StdEncoding.EncodeToString(pub)), IsNil)
+	tr.Commit()
+
+	return forwarded
+}
+
+func waitForForwardedPrompt(c *C, forwarded chan *forwardedPrompt) *forwardedPrompt {
+	select {
+	case fp := <-forwarded:
+		return fp
+	case <-time.After(5 * time.Second):
+		c.Fatal("prompt was not forwarded to remote approver")
+	}
+	return nil
+}
+
+func (s *apparmorpromptingSuite) TestRemoteApproverReplyCreatesRule(c *C) {
+	readyChan, reqChan, replyChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+	forwarded := s.mockRemoteApprover(c, pub, priv, func(fp *forwardedPrompt) (int, string) {
+		return http.StatusOK, `{"outcome":"allow","lifespan":"forever","constraints":{"path-pattern":"/home/test/**","permissions":["read"]}}`
+	})
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	close(readyChan)
+
+	req := &listener.Request{}
+	s.fillInPartialRequest(req)
+	reqChan <- req
+
+	fp := waitForForwardedPrompt(c, forwarded)
+	c.Check(fp.UserID, Equals, s.defaultUser)
+	c.Check(fp.Nonce, Matches, "[0-9a-f]{32}")
+	c.Check(fp.Prompt.Snap, Equals, "firefox")
+	c.Check(fp.Prompt.Interface, Equals, "home")
+	c.Check(fp.Prompt.Constraints.Path, Equals, "/home/test/foo")
+
+	// The reply from the approver is sent to the listener
+	var resp *apparmorprompting.RequestResponse
+	select {
+	case r := <-replyChan:
+		resp = &r
+	case <-time.After(5 * time.Second):
+		c.Fatal("no reply received")
+	}
+	c.Check(resp.Request, Equals, req)
+	aaPerms, err := prompting.AbstractPermissionsToAppArmorPermissions("home", []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(resp.AllowedPermission, Equals, aaPerms)
+
+	// And a rule was created from it
+	rules, err := mgr.Rules(s.defaultUser, "firefox", "home")
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Check(rules[0].Constraints.PathPattern.String(), Equals, "/home/test/**")
+	c.Check(rules[0].Constraints.Permissions["read"].Lifespan, Equals, prompting.LifespanForever)
+
+	prompts, err := mgr.Prompts(s.defaultUser, false)
+	c.Assert(err, IsNil)
+	c.Check(prompts, HasLen, 0)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) testRemoteApproverNoReply(c *C, pub ed25519.PublicKey, priv ed25519.PrivateKey, signedNonce string, status int, expectedLog string) {
+	readyChan, reqChan, _, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	forwarded := s.mockRemoteApprover(c, pub, priv, func(fp *forwardedPrompt) (int, string) {
+		if signedNonce != "" {
+			fp.Nonce = signedNonce
+		}
+		return status, `{"outcome":"allow","lifespan":"single","constraints":{"path-pattern":"/home/test/foo","permissions":["read"]}}`
+	})
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	close(readyChan)
+
+	req := &listener.Request{}
+	s.fillInPartialRequest(req)
+	reqChan <- req
+
+	fp := waitForForwardedPrompt(c, forwarded)
+
+	// Wait for the response of the approver to be handled
+	time.Sleep(50 * time.Millisecond)
+
+	// The prompt is left for local clients to reply to
+	prompt, err := mgr.PromptWithID(s.defaultUser, fp.Prompt.ID, false)
+	c.Check(err, IsNil)
+	c.Check(prompt, NotNil)
+
+	logger.WithLoggerLock(func() { c.Check(logbuf.String(), Matches, expectedLog) })
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestRemoteApproverBadSignature(c *C) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+
+	s.testRemoteApproverNoReply(c, pub, otherPriv, "", http.StatusOK, "(?s).*cannot get reply for prompt [0-9A-F]{16} from remote approver: invalid remote approver signature for prompt [0-9A-F]{16}.*")
+}
+
+func (s *apparmorpromptingSuite) TestRemoteApproverDeclines(c *C) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+
+	s.testRemoteApproverNoReply(c, pub, priv, "", http.StatusNoContent, "")
+}
+
+func (s *apparmorpromptingSuite) TestRemoteApproverReplayedReply(c *C) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+
+	// A reply signed for an earlier request of the same prompt is rejected
+	s.testRemoteApproverNoReply(c, pub, priv, "0123456789abcdef0123456789abcdef", http.StatusOK, "(?s).*cannot get reply for prompt [0-9A-F]{16} from remote approver: invalid remote approver signature for prompt [0-9A-F]{16}.*")
+}
+
+func (s *apparmorpromptingSuite) TestRemoteApproverError(c *C) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+
+	s.testRemoteApproverNoReply(c, pub, priv, "", http.StatusInternalServerError, "(?s).*cannot get reply for prompt [0-9A-F]{16} from remote approver: unexpected status from remote approver: 500 Internal Server Error.*")
+}
+
+func (s *apparmorpromptingSuite) TestRemoteApproverTooManyRequests(c *C) {
+	readyChan, reqChan, _, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	restore = apparmorprompting.MockMaxRemoteApproverRequests(0)
+	defer restore()
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+	forwarded := s.mockRemoteApprover(c, pub, priv, func(fp *forwardedPrompt) (int, string) {
+		return http.StatusNoContent, ""
+	})
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	close(readyChan)
+
+	req := &listener.Request{}
+	s.fillInPartialRequest(req)
+	reqChan <- req
+
+	// The prompt is left for local clients to reply to
+	var prompts []*requestprompts.Prompt
+	for i := 0; i < 100; i++ {
+		prompts, err = mgr.Prompts(s.defaultUser, false)
+		c.Assert(err, IsNil)
+		if len(prompts) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(prompts, HasLen, 1)
+
+	select {
+	case <-forwarded:
+		c.Fatal("prompt was unexpectedly forwarded to remote approver")
+	default:
+	}
+	logger.WithLoggerLock(func() {
+		c.Check(logbuf.String(), Matches, "(?s).*cannot forward prompt [0-9A-F]{16} to remote approver: too many prompts waiting for a reply.*")
+	})
+
+	c.Assert(mgr.Stop(), IsNil)
+}
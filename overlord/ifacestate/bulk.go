@@ -0,0 +1,194 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// SkippedPlug describes a plug left alone when connecting the plugs of a
+// snap in bulk.
+type SkippedPlug struct {
+	Plug   interfaces.PlugRef
+	Reason string
+	// Candidates are the matching slots of a plug that could not be
+	// connected unambiguously.
+	Candidates []interfaces.SlotRef
+}
+
+// BulkReport describes the outcome of connecting or disconnecting the plugs
+// of a snap in bulk.
+type BulkReport struct {
+	Connected    []*interfaces.ConnRef
+	Disconnected []*interfaces.ConnRef
+	Skipped      []*SkippedPlug
+}
+
+// AffectedSnaps returns the sorted names of the snaps on both ends of the
+// connections in the report.
+func (r *BulkReport) AffectedSnaps() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, refs := range [][]*interfaces.ConnRef{r.Connected, r.Disconnected} {
+		for _, ref := range refs {
+			for _, name := range []string{ref.PlugRef.Snap, ref.SlotRef.Snap} {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func matchSlotPattern(pattern string, slotRef interfaces.SlotRef) bool {
+	if pattern == "" {
+		return true
+	}
+	// the pattern is validated upfront
+	ok, _ := path.Match(pattern, slotRef.String())
+	return ok
+}
+
+func validateSlotPattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid slot pattern %q: %v", pattern, err)
+	}
+	return nil
+}
+
+// ConnectAll returns the task sets connecting every plug of the given snap
+// that is not connected yet to its only candidate slot, that is the only
+// slot of the same interface. If slotPattern is not empty only the slots
+// whose "<snap>:<slot>" name match the glob pattern are candidates.
+//
+// Plugs with no or multiple candidate slots are reported as skipped. Each
+// connection is made in its own lane so that one failing connection does
+// not undo the others.
+func ConnectAll(st *state.State, repo *interfaces.Repository, plugSnap, slotPattern string) ([]*state.TaskSet, *BulkReport, error) {
+	if err := validateSlotPattern(slotPattern); err != nil {
+		return nil, nil, err
+	}
+
+	report := &BulkReport{}
+	for _, plug := range repo.Plugs(plugSnap) {
+		plugRef := interfaces.PlugRef{Snap: plugSnap, Name: plug.Name}
+		connected, err := repo.Connected(plugSnap, plug.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(connected) > 0 {
+			report.Skipped = append(report.Skipped, &SkippedPlug{Plug: plugRef, Reason: "already connected"})
+			continue
+		}
+
+		var candidates []interfaces.SlotRef
+		for _, slot := range repo.AllSlots(plug.Interface) {
+			slotRef := interfaces.SlotRef{Snap: slot.Snap.InstanceName(), Name: slot.Name}
+			if matchSlotPattern(slotPattern, slotRef) {
+				candidates = append(candidates, slotRef)
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			report.Skipped = append(report.Skipped, &SkippedPlug{Plug: plugRef, Reason: "no candidate slot"})
+		case 1:
+			report.Connected = append(report.Connected, &interfaces.ConnRef{PlugRef: plugRef, SlotRef: candidates[0]})
+		default:
+			sort.Slice(candidates, func(i, j int) bool { return candidates[i].SortsBefore(candidates[j]) })
+			report.Skipped = append(report.Skipped, &SkippedPlug{Plug: plugRef, Reason: "multiple candidate slots", Candidates: candidates})
+		}
+	}
+	if len(report.Connected) == 0 {
+		return nil, report, nil
+	}
+
+	if err := snapstate.CheckChangeConflictMany(st, report.AffectedSnaps(), ""); err != nil {
+		return nil, nil, err
+	}
+
+	tasksets := make([]*state.TaskSet, 0, len(report.Connected))
+	for _, connRef := range report.Connected {
+		ts, err := connect(st, connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name, connectOpts{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot connect %s: %v", connRef.ID(), err)
+		}
+		ts.JoinLane(st.NewLane())
+		tasksets = append(tasksets, ts)
+	}
+	return tasksets, report, nil
+}
+
+// DisconnectAll returns the task sets disconnecting every connected plug of
+// the given snap. If slotPattern is not empty only the connections to the
+// slots whose "<snap>:<slot>" name match the glob pattern are disconnected.
+// Each connection is removed in its own lane.
+func DisconnectAll(st *state.State, repo *interfaces.Repository, plugSnap, slotPattern string) ([]*state.TaskSet, *BulkReport, error) {
+	if err := validateSlotPattern(slotPattern); err != nil {
+		return nil, nil, err
+	}
+
+	connRefs, err := repo.Connections(plugSnap)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(connRefs, func(i, j int) bool { return connRefs[i].SortsBefore(connRefs[j]) })
+
+	report := &BulkReport{}
+	var conns []*interfaces.Connection
+	for _, connRef := range connRefs {
+		// only the plug side of the snap is considered
+		if connRef.PlugRef.Snap != plugSnap || !matchSlotPattern(slotPattern, connRef.SlotRef) {
+			continue
+		}
+		conn, err := repo.Connection(connRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		conns = append(conns, conn)
+		report.Disconnected = append(report.Disconnected, connRef)
+	}
+	if len(conns) == 0 {
+		return nil, report, nil
+	}
+
+	if err := snapstate.CheckChangeConflictMany(st, report.AffectedSnaps(), ""); err != nil {
+		return nil, nil, err
+	}
+
+	tasksets := make([]*state.TaskSet, 0, len(conns))
+	for _, conn := range conns {
+		ts, err := disconnectTasks(st, conn, disconnectOpts{})
+		if err != nil {
+			return nil, nil, err
+		}
+		ts.JoinLane(st.NewLane())
+		tasksets = append(tasksets, ts)
+	}
+	return tasksets, report, nil
+}
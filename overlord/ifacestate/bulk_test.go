@@ -0,0 +1,170 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+func (s *interfaceManagerSuite) mockBulkSnaps(c *C, conns map[string]any, yamls ...string) *ifacestate.InterfaceManager {
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	for _, yaml := range yamls {
+		s.mockSnap(c, yaml)
+	}
+
+	if conns != nil {
+		s.state.Lock()
+		s.state.Set("conns", conns)
+		s.state.Unlock()
+	}
+
+	return s.manager(c)
+}
+
+func connectTaskRefs(c *C, ts *state.TaskSet) (interfaces.PlugRef, interfaces.SlotRef) {
+	var plug interfaces.PlugRef
+	var slot interfaces.SlotRef
+	for _, t := range ts.Tasks() {
+		if t.Kind() == "connect" || t.Kind() == "disconnect" {
+			c.Assert(t.Get("plug", &plug), IsNil)
+			c.Assert(t.Get("slot", &slot), IsNil)
+		}
+	}
+	return plug, slot
+}
+
+func (s *interfaceManagerSuite) TestConnectAll(c *C) {
+	mgr := s.mockBulkSnaps(c, nil, producerYaml, producerOtherIfaceYaml)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tss, report, err := ifacestate.ConnectAll(s.state, mgr.Repository(), "consumer", "")
+	c.Assert(err, IsNil)
+	c.Check(report, DeepEquals, &ifacestate.BulkReport{
+		Connected: []*interfaces.ConnRef{
+			{PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "otherplug"}, SlotRef: interfaces.SlotRef{Snap: "producer4", Name: "slot"}},
+			{PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"}, SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"}},
+		},
+	})
+	c.Check(report.AffectedSnaps(), DeepEquals, []string{"consumer", "producer", "producer4"})
+
+	c.Assert(tss, HasLen, 2)
+	plug, slot := connectTaskRefs(c, tss[0])
+	c.Check(plug, Equals, interfaces.PlugRef{Snap: "consumer", Name: "otherplug"})
+	c.Check(slot, Equals, interfaces.SlotRef{Snap: "producer4", Name: "slot"})
+	plug, slot = connectTaskRefs(c, tss[1])
+	c.Check(plug, Equals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
+	c.Check(slot, Equals, interfaces.SlotRef{Snap: "producer", Name: "slot"})
+
+	// each connection is made in its own lane
+	lanes0 := tss[0].Tasks()[0].Lanes()
+	lanes1 := tss[1].Tasks()[0].Lanes()
+	c.Assert(lanes0, HasLen, 1)
+	c.Assert(lanes1, HasLen, 1)
+	c.Check(lanes0[0], Not(Equals), lanes1[0])
+}
+
+func (s *interfaceManagerSuite) TestConnectAllSkipsAmbiguousAndConnected(c *C) {
+	mgr := s.mockBulkSnaps(c, map[string]any{
+		"consumer:otherplug producer4:slot": map[string]any{"interface": "test2"},
+	}, producerYaml, producer2Yaml, producerOtherIfaceYaml)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tss, report, err := ifacestate.ConnectAll(s.state, mgr.Repository(), "consumer", "")
+	c.Assert(err, IsNil)
+	c.Check(tss, HasLen, 0)
+	c.Check(report, DeepEquals, &ifacestate.BulkReport{
+		Skipped: []*ifacestate.SkippedPlug{
+			{Plug: interfaces.PlugRef{Snap: "consumer", Name: "otherplug"}, Reason: "already connected"},
+			{
+				Plug:   interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+				Reason: "multiple candidate slots",
+				Candidates: []interfaces.SlotRef{
+					{Snap: "producer", Name: "slot"},
+					{Snap: "producer2", Name: "slot"},
+				},
+			},
+		},
+	})
+}
+
+func (s *interfaceManagerSuite) TestConnectAllPattern(c *C) {
+	mgr := s.mockBulkSnaps(c, nil, producerYaml, producer2Yaml, producerOtherIfaceYaml)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tss, report, err := ifacestate.ConnectAll(s.state, mgr.Repository(), "consumer", "producer2:*")
+	c.Assert(err, IsNil)
+	c.Check(report, DeepEquals, &ifacestate.BulkReport{
+		Connected: []*interfaces.ConnRef{
+			{PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"}, SlotRef: interfaces.SlotRef{Snap: "producer2", Name: "slot"}},
+		},
+		Skipped: []*ifacestate.SkippedPlug{
+			{Plug: interfaces.PlugRef{Snap: "consumer", Name: "otherplug"}, Reason: "no candidate slot"},
+		},
+	})
+	c.Assert(tss, HasLen, 1)
+
+	_, _, err = ifacestate.ConnectAll(s.state, mgr.Repository(), "consumer", "producer[")
+	c.Check(err, ErrorMatches, `invalid slot pattern "producer\[": syntax error in pattern`)
+}
+
+func (s *interfaceManagerSuite) TestDisconnectAll(c *C) {
+	mgr := s.mockBulkSnaps(c, map[string]any{
+		"consumer:plug producer:slot":       map[string]any{"interface": "test"},
+		"consumer:otherplug producer4:slot": map[string]any{"interface": "test2"},
+	}, producerYaml, producerOtherIfaceYaml)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tss, report, err := ifacestate.DisconnectAll(s.state, mgr.Repository(), "consumer", "producer4:*")
+	c.Assert(err, IsNil)
+	c.Check(report, DeepEquals, &ifacestate.BulkReport{
+		Disconnected: []*interfaces.ConnRef{
+			{PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "otherplug"}, SlotRef: interfaces.SlotRef{Snap: "producer4", Name: "slot"}},
+		},
+	})
+	c.Assert(tss, HasLen, 1)
+	plug, slot := connectTaskRefs(c, tss[0])
+	c.Check(plug, Equals, interfaces.PlugRef{Snap: "consumer", Name: "otherplug"})
+	c.Check(slot, Equals, interfaces.SlotRef{Snap: "producer4", Name: "slot"})
+
+	tss, report, err = ifacestate.DisconnectAll(s.state, mgr.Repository(), "consumer", "")
+	c.Assert(err, IsNil)
+	c.Check(tss, HasLen, 2)
+	c.Check(report.Disconnected, HasLen, 2)
+
+	// slots of the snap are left alone
+	tss, report, err = ifacestate.DisconnectAll(s.state, mgr.Repository(), "producer", "")
+	c.Assert(err, IsNil)
+	c.Check(tss, HasLen, 0)
+	c.Check(report, DeepEquals, &ifacestate.BulkReport{})
+}
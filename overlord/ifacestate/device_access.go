@@ -0,0 +1,468 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/snap"
+)
+
+// MaxDeviceAccessDuration is the longest time for which access to devices
+// can be granted to a snap outside of its interface connections.
+const MaxDeviceAccessDuration = 24 * time.Hour
+
+var expireDeviceAccessChangeKind = swfeats.RegisterChangeKind("expire-device-access")
+
+var timeNow = time.Now
+
+// DeviceAccessGrant describes access to devices temporarily granted to a
+// snap on top of the access provided by its connected interfaces. It is
+// meant for debugging hardware access issues in the field.
+type DeviceAccessGrant struct {
+	ID   string `json:"id"`
+	Snap string `json:"snap"`
+	// Rule is the udev match rule selecting the devices, like
+	// SUBSYSTEM=="tty", KERNEL=="ttyUSB0".
+	Rule    string    `json:"rule"`
+	Reason  string    `json:"reason,omitempty"`
+	Granted time.Time `json:"granted"`
+	Expires time.Time `json:"expires"`
+	// UserID is the snapd user who requested the access, if any.
+	UserID int `json:"user-id,omitempty"`
+}
+
+// Expired returns whether the grant is expired at the given time.
+func (g *DeviceAccessGrant) Expired(now time.Time) bool {
+	return !g.Expires.After(now)
+}
+
+var deviceMatchKeyRegexp = regexp.MustCompile(`^[A-Z][A-Z_]*(\{[a-zA-Z0-9_.-]+\})?$`)
+
+// ValidateDeviceAccessRule checks that the rule is made of udev match
+// comparisons only, in the form KEY=="value" or KEY!="value" separated by
+// commas, so that it can be used to tag devices for a snap.
+func ValidateDeviceAccessRule(rule string) error {
+	if strings.TrimSpace(rule) == "" {
+		return errors.New("device access rule cannot be empty")
+	}
+	if strings.ContainsAny(rule, "\n\r\\") {
+		return fmt.Errorf("invalid device access rule %q: unexpected character", rule)
+	}
+	for _, match := range strings.Split(rule, ",") {
+		match = strings.TrimSpace(match)
+		op := "=="
+		idx := strings.Index(match, op)
+		if neq := strings.Index(match, "!="); neq >= 0 && (idx < 0 || neq < idx) {
+			op, idx = "!=", neq
+		}
+		if idx < 0 {
+			return fmt.Errorf("invalid device access rule %q: %q is not a match comparison", rule, match)
+		}
+		key, value := match[:idx], match[idx+len(op):]
+		if !deviceMatchKeyRegexp.MatchString(key) {
+			return fmt.Errorf("invalid device access rule %q: invalid match key %q", rule, key)
+		}
+		if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' || strings.Contains(value[1:len(value)-1], `"`) {
+			return fmt.Errorf("invalid device access rule %q: value of %q must be double quoted", rule, key)
+		}
+	}
+	return nil
+}
+
+func getDeviceAccessGrants(st *state.State) (map[string][]*DeviceAccessGrant, error) {
+	var grants map[string][]*DeviceAccessGrant
+	if err := st.Get("device-access-grants", &grants); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if grants == nil {
+		grants = make(map[string][]*DeviceAccessGrant)
+	}
+	return grants, nil
+}
+
+func setDeviceAccessGrants(st *state.State, grants map[string][]*DeviceAccessGrant) {
+	for name, snapGrants := range grants {
+		if len(snapGrants) == 0 {
+			delete(grants, name)
+		}
+	}
+	if len(grants) == 0 {
+		st.Set("device-access-grants", nil)
+		return
+	}
+	st.Set("device-access-grants", grants)
+}
+
+// DeviceAccessGrants returns the device access grants of the given snap
+// which have not expired yet. If instanceName is empty the grants of all
+// snaps are returned.
+func DeviceAccessGrants(st *state.State, instanceName string) ([]*DeviceAccessGrant, error) {
+	grants, err := getDeviceAccessGrants(st)
+	if err != nil {
+		return nil, err
+	}
+	now := timeNow()
+	var active []*DeviceAccessGrant
+	for name, snapGrants := range grants {
+		if instanceName != "" && name != instanceName {
+			continue
+		}
+		for _, g := range snapGrants {
+			if !g.Expired(now) {
+				active = append(active, g)
+			}
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Snap != active[j].Snap {
+			return active[i].Snap < active[j].Snap
+		}
+		return active[i].Granted.Before(active[j].Granted)
+	})
+	return active, nil
+}
+
+// snapDeviceAccessRules returns the rules of the active device access grants
+// of the given snap.
+func snapDeviceAccessRules(st *state.State, instanceName string) ([]string, error) {
+	grants, err := DeviceAccessGrants(st, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	var rules []string
+	for _, g := range grants {
+		rules = append(rules, g.Rule)
+	}
+	return rules, nil
+}
+
+func allocDeviceAccessGrantID(st *state.State) (string, error) {
+	var seq int
+	if err := st.Get("device-access-grant-seq", &seq); err != nil && !errors.Is(err, state.ErrNoState) {
+		return "", err
+	}
+	seq++
+	st.Set("device-access-grant-seq", seq)
+	return strconv.Itoa(seq), nil
+}
+
+func deviceAccessSetupProfilesTask(st *state.State, instanceName string, snapst *snapstate.SnapState) (*state.Task, error) {
+	info, err := snapst.CurrentInfo()
+	if err != nil {
+		return nil, err
+	}
+	setupProfiles := st.NewTask("setup-profiles", fmt.Sprintf(i18n.G("Update snap %q (%s) security profiles"), instanceName, info.Revision))
+	setupProfiles.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: info.SnapName(),
+			Revision: info.Revision,
+		},
+		InstanceKey: info.InstanceKey,
+		Type:        info.Type(),
+		Flags:       snapst.Flags,
+	})
+	return setupProfiles, nil
+}
+
+// GrantDeviceAccess returns a task set granting the given snap access to the
+// devices matching the udev rule for the given duration, without connecting
+// any interface. The access is revoked automatically once it expires.
+func GrantDeviceAccess(st *state.State, instanceName, rule, reason string, duration time.Duration, userID int) (*state.TaskSet, *DeviceAccessGrant, error) {
+	if duration <= 0 || duration > MaxDeviceAccessDuration {
+		return nil, nil, fmt.Errorf("cannot grant device access for %s: duration must be positive and at most %s", duration, MaxDeviceAccessDuration)
+	}
+	if err := ValidateDeviceAccessRule(rule); err != nil {
+		return nil, nil, err
+	}
+
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, instanceName, &snapst); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, nil, err
+	}
+	if !snapst.IsInstalled() {
+		return nil, nil, &snap.NotInstalledError{Snap: instanceName}
+	}
+	if err := snapstate.CheckChangeConflict(st, instanceName, nil); err != nil {
+		return nil, nil, err
+	}
+
+	id, err := allocDeviceAccessGrantID(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := timeNow()
+	grant := &DeviceAccessGrant{
+		ID:      id,
+		Snap:    instanceName,
+		Rule:    rule,
+		Reason:  reason,
+		Granted: now,
+		Expires: now.Add(duration),
+		UserID:  userID,
+	}
+
+	grantTask := st.NewTask("grant-device-access", fmt.Sprintf(i18n.G("Grant snap %q access to devices matching %s"), instanceName, rule))
+	grantTask.Set("device-access-grant", grant)
+	setupProfiles, err := deviceAccessSetupProfilesTask(st, instanceName, &snapst)
+	if err != nil {
+		return nil, nil, err
+	}
+	setupProfiles.WaitFor(grantTask)
+
+	logger.Noticef("granting snap %q access to devices matching %s until %s (user %d): %s",
+		instanceName, rule, grant.Expires.Format(time.RFC3339), userID, reason)
+
+	return state.NewTaskSet(grantTask, setupProfiles), grant, nil
+}
+
+// RevokeDeviceAccess returns a task set revoking the device access grant
+// with the given ID from the given snap.
+func RevokeDeviceAccess(st *state.State, instanceName, id string, userID int) (*state.TaskSet, error) {
+	grants, err := getDeviceAccessGrants(st)
+	if err != nil {
+		return nil, err
+	}
+	var grant *DeviceAccessGrant
+	for _, g := range grants[instanceName] {
+		if g.ID == id {
+			grant = g
+			break
+		}
+	}
+	if grant == nil {
+		return nil, fmt.Errorf("cannot find device access grant %q of snap %q", id, instanceName)
+	}
+	if err := snapstate.CheckChangeConflict(st, instanceName, nil); err != nil {
+		return nil, err
+	}
+
+	ts, err := revokeDeviceAccessTasks(st, instanceName, []*DeviceAccessGrant{grant})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Noticef("revoking access of snap %q to devices matching %s (user %d)", instanceName, grant.Rule, userID)
+
+	return ts, nil
+}
+
+func revokeDeviceAccessTasks(st *state.State, instanceName string, grants []*DeviceAccessGrant) (*state.TaskSet, error) {
+	ts := state.NewTaskSet()
+	var prev *state.Task
+	for _, grant := range grants {
+		t := st.NewTask("revoke-device-access", fmt.Sprintf(i18n.G("Revoke access of snap %q to devices matching %s"), instanceName, grant.Rule))
+		t.Set("device-access-grant", grant)
+		if prev != nil {
+			t.WaitFor(prev)
+		}
+		ts.AddTask(t)
+		prev = t
+	}
+
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, instanceName, &snapst); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	// profiles of snaps which are gone do not need updating
+	if snapst.IsInstalled() {
+		setupProfiles, err := deviceAccessSetupProfilesTask(st, instanceName, &snapst)
+		if err != nil {
+			return nil, err
+		}
+		setupProfiles.WaitAll(ts)
+		ts.AddTask(setupProfiles)
+	}
+	return ts, nil
+}
+
+func addDeviceAccessGrant(st *state.State, grant *DeviceAccessGrant) error {
+	grants, err := getDeviceAccessGrants(st)
+	if err != nil {
+		return err
+	}
+	for _, g := range grants[grant.Snap] {
+		if g.ID == grant.ID {
+			return nil
+		}
+	}
+	grants[grant.Snap] = append(grants[grant.Snap], grant)
+	setDeviceAccessGrants(st, grants)
+	return nil
+}
+
+func removeDeviceAccessGrant(st *state.State, grant *DeviceAccessGrant) error {
+	grants, err := getDeviceAccessGrants(st)
+	if err != nil {
+		return err
+	}
+	snapGrants := grants[grant.Snap]
+	for i, g := range snapGrants {
+		if g.ID == grant.ID {
+			grants[grant.Snap] = append(snapGrants[:i:i], snapGrants[i+1:]...)
+			break
+		}
+	}
+	setDeviceAccessGrants(st, grants)
+	return nil
+}
+
+func deviceAccessGrantFromTask(t *state.Task) (*DeviceAccessGrant, error) {
+	var grant DeviceAccessGrant
+	if err := t.Get("device-access-grant", &grant); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (m *InterfaceManager) doGrantDeviceAccess(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	grant, err := deviceAccessGrantFromTask(t)
+	if err != nil {
+		return err
+	}
+	if err := addDeviceAccessGrant(st, grant); err != nil {
+		return err
+	}
+	t.Logf("Granted access to devices matching %s until %s", grant.Rule, grant.Expires.Format(time.RFC3339))
+	return nil
+}
+
+func (m *InterfaceManager) undoGrantDeviceAccess(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	grant, err := deviceAccessGrantFromTask(t)
+	if err != nil {
+		return err
+	}
+	return removeDeviceAccessGrant(st, grant)
+}
+
+func (m *InterfaceManager) doRevokeDeviceAccess(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	grant, err := deviceAccessGrantFromTask(t)
+	if err != nil {
+		return err
+	}
+	if err := removeDeviceAccessGrant(st, grant); err != nil {
+		return err
+	}
+	t.Logf("Revoked access to devices matching %s", grant.Rule)
+	return nil
+}
+
+func (m *InterfaceManager) undoRevokeDeviceAccess(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	grant, err := deviceAccessGrantFromTask(t)
+	if err != nil {
+		return err
+	}
+	return addDeviceAccessGrant(st, grant)
+}
+
+// expireDeviceAccessGrants creates a change revoking the device access grants
+// which have expired and schedules the next ensure pass for when the next
+// one expires.
+func (m *InterfaceManager) expireDeviceAccessGrants() error {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	grants, err := getDeviceAccessGrants(st)
+	if err != nil {
+		return err
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	for _, chg := range st.Changes() {
+		// wait for the previous expiration to complete
+		if chg.Kind() == expireDeviceAccessChangeKind && !chg.IsReady() {
+			return nil
+		}
+	}
+
+	now := timeNow()
+	var next time.Time
+	var names []string
+	expired := make(map[string][]*DeviceAccessGrant)
+	for name, snapGrants := range grants {
+		for _, g := range snapGrants {
+			if g.Expired(now) {
+				expired[name] = append(expired[name], g)
+			} else if next.IsZero() || g.Expires.Before(next) {
+				next = g.Expires
+			}
+		}
+		if len(expired[name]) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var chg *state.Change
+	for _, name := range names {
+		// retried on a later pass, the profiles already ignore
+		// expired grants
+		if err := snapstate.CheckChangeConflict(st, name, nil); err != nil {
+			continue
+		}
+		ts, err := revokeDeviceAccessTasks(st, name, expired[name])
+		if err != nil {
+			return err
+		}
+		if chg == nil {
+			chg = st.NewChange(expireDeviceAccessChangeKind, i18n.G("Revoke expired device access"))
+		}
+		chg.AddAll(ts)
+		for _, g := range expired[name] {
+			logger.Noticef("access of snap %q to devices matching %s expired at %s", name, g.Rule, g.Expires.Format(time.RFC3339))
+		}
+	}
+
+	if !next.IsZero() {
+		st.EnsureBefore(next.Sub(now))
+	}
+	return nil
+}
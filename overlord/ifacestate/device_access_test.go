@@ -0,0 +1,235 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const testDeviceAccessRule = `SUBSYSTEM=="tty", KERNEL=="ttyUSB0"`
+
+func (s *interfaceManagerSuite) TestValidateDeviceAccessRule(c *C) {
+	for _, rule := range []string{
+		`SUBSYSTEM=="tty"`,
+		`SUBSYSTEM=="tty", KERNEL=="ttyUSB[0-9]*"`,
+		`SUBSYSTEM=="usb",ATTRS{idVendor}=="0403", ATTRS{idProduct}!="6001"`,
+	} {
+		c.Check(ifacestate.ValidateDeviceAccessRule(rule), IsNil, Commentf(rule))
+	}
+
+	for _, t := range []struct {
+		rule string
+		err  string
+	}{
+		{"", "device access rule cannot be empty"},
+		{" ", "device access rule cannot be empty"},
+		{"SUBSYSTEM==\"tty\"\nRUN+=\"/bin/sh\"", `invalid device access rule .*: unexpected character`},
+		{`SUBSYSTEM="tty"`, `invalid device access rule .*: "SUBSYSTEM=\\"tty\\"" is not a match comparison`},
+		{`SUBSYSTEM=="tty", RUN+="/bin/sh"`, `invalid device access rule .*: "RUN\+=\\"/bin/sh\\"" is not a match comparison`},
+		{`SUBSYSTEM=="tty", TAG+="x", KERNEL=="y"`, `invalid device access rule .*: "TAG\+=\\"x\\"" is not a match comparison`},
+		{`subsystem=="tty"`, `invalid device access rule .*: invalid match key "subsystem"`},
+		{`SUBSYSTEM==tty`, `invalid device access rule .*: value of "SUBSYSTEM" must be double quoted`},
+		{`SUBSYSTEM=="t"ty"`, `invalid device access rule .*: value of "SUBSYSTEM" must be double quoted`},
+		{`SUBSYSTEM=="tty",`, `invalid device access rule .*: "" is not a match comparison`},
+	} {
+		c.Check(ifacestate.ValidateDeviceAccessRule(t.rule), ErrorMatches, t.err, Commentf(t.rule))
+	}
+}
+
+func (s *interfaceManagerSuite) grantDeviceAccess(c *C, duration time.Duration) *ifacestate.DeviceAccessGrant {
+	s.state.Lock()
+	ts, grant, err := ifacestate.GrantDeviceAccess(s.state, "snap", testDeviceAccessRule, "debugging", duration, 42)
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("grant-device-access", "")
+	chg.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Assert(chg.Err(), IsNil)
+	c.Assert(chg.Status(), Equals, state.DoneStatus)
+	return grant
+}
+
+func (s *interfaceManagerSuite) TestGrantDeviceAccess(c *C) {
+	now := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	restore := ifacestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	s.MockModel(c, nil)
+	_ = s.manager(c)
+	s.mockSnap(c, sampleSnapYaml)
+
+	grant := s.grantDeviceAccess(c, time.Hour)
+	c.Check(grant, DeepEquals, &ifacestate.DeviceAccessGrant{
+		ID:      "1",
+		Snap:    "snap",
+		Rule:    testDeviceAccessRule,
+		Reason:  "debugging",
+		Granted: now,
+		Expires: now.Add(time.Hour),
+		UserID:  42,
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	grants, err := ifacestate.DeviceAccessGrants(s.state, "snap")
+	c.Assert(err, IsNil)
+	c.Check(grants, DeepEquals, []*ifacestate.DeviceAccessGrant{grant})
+	grants, err = ifacestate.DeviceAccessGrants(s.state, "other-snap")
+	c.Assert(err, IsNil)
+	c.Check(grants, HasLen, 0)
+
+	c.Assert(s.secBackend.SetupCalls, HasLen, 1)
+	c.Check(s.secBackend.SetupCalls[0].AppSet.InstanceName(), Equals, "snap")
+	c.Check(s.secBackend.SetupCalls[0].Options, DeepEquals, interfaces.ConfinementOptions{
+		KernelSnap:        "krnl",
+		DeviceAccessRules: []string{testDeviceAccessRule},
+	})
+}
+
+func (s *interfaceManagerSuite) TestGrantDeviceAccessErrors(c *C) {
+	s.MockModel(c, nil)
+	_ = s.manager(c)
+	s.mockSnap(c, sampleSnapYaml)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, _, err := ifacestate.GrantDeviceAccess(s.state, "snap", testDeviceAccessRule, "", 0, 0)
+	c.Check(err, ErrorMatches, `cannot grant device access for 0s: duration must be positive and at most 24h0m0s`)
+	_, _, err = ifacestate.GrantDeviceAccess(s.state, "snap", testDeviceAccessRule, "", 25*time.Hour, 0)
+	c.Check(err, ErrorMatches, `cannot grant device access for 25h0m0s: duration must be positive and at most 24h0m0s`)
+	_, _, err = ifacestate.GrantDeviceAccess(s.state, "snap", `RUN+="/bin/sh"`, "", time.Hour, 0)
+	c.Check(err, ErrorMatches, `invalid device access rule .*`)
+	_, _, err = ifacestate.GrantDeviceAccess(s.state, "missing", testDeviceAccessRule, "", time.Hour, 0)
+	c.Check(err, FitsTypeOf, &snap.NotInstalledError{})
+
+	_, err = ifacestate.RevokeDeviceAccess(s.state, "snap", "1", 0)
+	c.Check(err, ErrorMatches, `cannot find device access grant "1" of snap "snap"`)
+}
+
+func (s *interfaceManagerSuite) TestRevokeDeviceAccess(c *C) {
+	s.MockModel(c, nil)
+	_ = s.manager(c)
+	s.mockSnap(c, sampleSnapYaml)
+
+	grant := s.grantDeviceAccess(c, time.Hour)
+
+	s.state.Lock()
+	ts, err := ifacestate.RevokeDeviceAccess(s.state, "snap", grant.ID, 42)
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("revoke-device-access", "")
+	chg.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Assert(chg.Status(), Equals, state.DoneStatus)
+
+	grants, err := ifacestate.DeviceAccessGrants(s.state, "")
+	c.Assert(err, IsNil)
+	c.Check(grants, HasLen, 0)
+	c.Check(s.state.Get("device-access-grants", new(any)), testutil.ErrorIs, state.ErrNoState)
+
+	c.Assert(s.secBackend.SetupCalls, HasLen, 2)
+	c.Check(s.secBackend.SetupCalls[1].Options.DeviceAccessRules, HasLen, 0)
+}
+
+func (s *interfaceManagerSuite) TestGrantDeviceAccessUndo(c *C) {
+	s.MockModel(c, nil)
+	_ = s.manager(c)
+	s.mockSnap(c, sampleSnapYaml)
+
+	s.state.Lock()
+	ts, _, err := ifacestate.GrantDeviceAccess(s.state, "snap", testDeviceAccessRule, "", time.Hour, 0)
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("grant-device-access", "")
+	chg.AddAll(ts)
+	terr := s.state.NewTask("error-trigger", "provoking total undo")
+	terr.WaitAll(ts)
+	chg.AddTask(terr)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Assert(chg.Status(), Equals, state.ErrorStatus)
+
+	grants, err := ifacestate.DeviceAccessGrants(s.state, "snap")
+	c.Assert(err, IsNil)
+	c.Check(grants, HasLen, 0)
+}
+
+func (s *interfaceManagerSuite) TestExpireDeviceAccessGrants(c *C) {
+	now := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	restore := ifacestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	s.MockModel(c, nil)
+	_ = s.manager(c)
+	s.mockSnap(c, sampleSnapYaml)
+
+	s.grantDeviceAccess(c, time.Hour)
+
+	// nothing happens before the grant expires
+	now = now.Add(30 * time.Minute)
+	s.settle(c)
+	s.state.Lock()
+	c.Check(s.state.Changes(), HasLen, 1)
+	s.state.Unlock()
+
+	now = now.Add(time.Hour)
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var expireChg *state.Change
+	for _, chg := range s.state.Changes() {
+		if chg.Kind() == "expire-device-access" {
+			expireChg = chg
+		}
+	}
+	c.Assert(expireChg, NotNil)
+	c.Check(expireChg.Status(), Equals, state.DoneStatus)
+	c.Check(expireChg.Tasks(), HasLen, 2)
+
+	grants, err := ifacestate.DeviceAccessGrants(s.state, "")
+	c.Assert(err, IsNil)
+	c.Check(grants, HasLen, 0)
+
+	c.Assert(s.secBackend.SetupCalls, HasLen, 2)
+	c.Check(s.secBackend.SetupCalls[1].Options.DeviceAccessRules, HasLen, 0)
+}
@@ -68,7 +68,7 @@ func journalQuotaLayout(quotaGroup *quota.Group) []snap.Layout {
 // getExtraLayouts helper function to dynamically calculate the extra mount layouts for
 // a snap instance. These are the layouts which can change during the lifetime of a snap
 // like for instance mimicking systemd journal namespace mount layouts.
-func getExtraLayouts(st *state.State, snapInfo *snap.Info) ([]snap.Layout, error) {
+func getExtraLayouts(st *state.State, snapInfo *snap.Info, nameResolution *interfaces.NameResolution) ([]snap.Layout, error) {
 	snapOpts, err := servicestate.SnapServiceOptions(st, snapInfo, nil)
 	if err != nil {
 		return nil, err
@@ -78,16 +78,27 @@ func getExtraLayouts(st *state.State, snapInfo *snap.Info) ([]snap.Layout, error
 	if snapOpts.QuotaGroup != nil {
 		extraLayouts = append(extraLayouts, journalQuotaLayout(snapOpts.QuotaGroup)...)
 	}
+	extraLayouts = append(extraLayouts, nameResolution.Layouts(snapInfo.InstanceName())...)
 
 	return extraLayouts, nil
 }
 
 func (m *InterfaceManager) buildConfinementOptions(st *state.State, task *state.Task, snapInfo *snap.Info, flags snapstate.Flags) (interfaces.ConfinementOptions, error) {
-	extraLayouts, err := getExtraLayouts(st, snapInfo)
+	nameResolution, err := SnapNameResolution(st, snapInfo.InstanceName())
+	if err != nil {
+		return interfaces.ConfinementOptions{}, fmt.Errorf("cannot get name resolution settings of snap %q: %s", snapInfo.InstanceName(), err)
+	}
+
+	extraLayouts, err := getExtraLayouts(st, snapInfo, nameResolution)
 	if err != nil {
 		return interfaces.ConfinementOptions{}, fmt.Errorf("cannot get extra mount layouts of snap %q: %s", snapInfo.InstanceName(), err)
 	}
 
+	deviceAccessRules, err := snapDeviceAccessRules(st, snapInfo.InstanceName())
+	if err != nil {
+		return interfaces.ConfinementOptions{}, fmt.Errorf("cannot get device access grants of snap %q: %s", snapInfo.InstanceName(), err)
+	}
+
 	kernelSnap := ""
 	deviceCtx, err := snapstate.DeviceCtx(st, task, nil)
 	if err == nil {
@@ -101,6 +112,8 @@ func (m *InterfaceManager) buildConfinementOptions(st *state.State, task *state.
 		ExtraLayouts:      extraLayouts,
 		AppArmorPrompting: m.useAppArmorPrompting,
 		KernelSnap:        kernelSnap,
+		NameResolution:    nameResolution,
+		DeviceAccessRules: deviceAccessRules,
 	}, nil
 }
 
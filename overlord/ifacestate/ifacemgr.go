@@ -115,6 +115,8 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 	addHandler("hotplug-remove-slot", m.doHotplugRemoveSlot, nil)
 	addHandler("hotplug-disconnect", m.doHotplugDisconnect, nil)
 	addHandler("regenerate-security-profiles", m.doRegenerateAllSecurityProfiles, nil)
+	addHandler("grant-device-access", m.doGrantDeviceAccess, m.undoGrantDeviceAccess)
+	addHandler("revoke-device-access", m.doRevokeDeviceAccess, m.undoRevokeDeviceAccess)
 
 	// don't block on hotplug-seq-wait task
 	runner.AddHandler("hotplug-seq-wait", m.doHotplugSeqWait, nil)
@@ -275,6 +277,18 @@ func (m *InterfaceManager) Ensure() error {
 		return nil
 	}
 
+	if err := m.expireDeviceAccessGrants(); err != nil {
+		logger.Noticef("cannot revoke expired device access: %v", err)
+	}
+
+	// apply changes of the prompt expiration policy
+	m.interfacesRequestsManagerMu.Lock()
+	irm := m.interfacesRequestsManager
+	m.interfacesRequestsManagerMu.Unlock()
+	if irm != nil {
+		interfacesRequestsManagerUpdateExpirationPolicy(irm)
+	}
+
 	if m.udevMonitorDisabled {
 		return nil
 	}
@@ -335,6 +349,13 @@ var interfacesRequestsManagerStop = func(interfacesRequestsManager *apparmorprom
 	return interfacesRequestsManager.Stop()
 }
 
+// interfacesRequestsManagerUpdateExpirationPolicy calls
+// UpdateExpirationPolicy on the given manager. The state lock must not be
+// held.
+var interfacesRequestsManagerUpdateExpirationPolicy = func(interfacesRequestsManager *apparmorprompting.InterfacesRequestsManager) {
+	interfacesRequestsManager.UpdateExpirationPolicy()
+}
+
 func (m *InterfaceManager) stopInterfacesRequestsManager() {
 	m.interfacesRequestsManagerMu.Lock()
 	defer m.interfacesRequestsManagerMu.Unlock()
@@ -486,16 +486,16 @@ func (s *interfaceManagerSuite) TestConnectTask(c *C) {
 	c.Check(task.Kind(), Equals, "run-hook")
 	var hookSetup, undoHookSetup hookstate.HookSetup
 	c.Assert(task.Get("hook-setup", &hookSetup), IsNil)
-	c.Assert(hookSetup, Equals, hookstate.HookSetup{Snap: "consumer", Hook: "prepare-plug-plug", Optional: true})
+	c.Assert(hookSetup, DeepEquals, hookstate.HookSetup{Snap: "consumer", Hook: "prepare-plug-plug", Optional: true})
 	c.Assert(task.Get("undo-hook-setup", &undoHookSetup), IsNil)
-	c.Assert(undoHookSetup, Equals, hookstate.HookSetup{Snap: "consumer", Hook: "unprepare-plug-plug", Optional: true, IgnoreError: true})
+	c.Assert(undoHookSetup, DeepEquals, hookstate.HookSetup{Snap: "consumer", Hook: "unprepare-plug-plug", Optional: true, IgnoreError: true})
 	i++
 	task = ts.Tasks()[i]
 	c.Check(task.Kind(), Equals, "run-hook")
 	c.Assert(task.Get("hook-setup", &hookSetup), IsNil)
-	c.Assert(hookSetup, Equals, hookstate.HookSetup{Snap: "producer", Hook: "prepare-slot-slot", Optional: true})
+	c.Assert(hookSetup, DeepEquals, hookstate.HookSetup{Snap: "producer", Hook: "prepare-slot-slot", Optional: true})
 	c.Assert(task.Get("undo-hook-setup", &undoHookSetup), IsNil)
-	c.Assert(undoHookSetup, Equals, hookstate.HookSetup{Snap: "producer", Hook: "unprepare-slot-slot", Optional: true, IgnoreError: true})
+	c.Assert(undoHookSetup, DeepEquals, hookstate.HookSetup{Snap: "producer", Hook: "unprepare-slot-slot", Optional: true, IgnoreError: true})
 	i++
 	task = ts.Tasks()[i]
 	c.Assert(task.Kind(), Equals, "connect")
@@ -540,16 +540,16 @@ func (s *interfaceManagerSuite) TestConnectTask(c *C) {
 	task = ts.Tasks()[i]
 	c.Check(task.Kind(), Equals, "run-hook")
 	c.Assert(task.Get("hook-setup", &hs), IsNil)
-	c.Assert(hs, Equals, hookstate.HookSetup{Snap: "producer", Hook: "connect-slot-slot", Optional: true})
+	c.Assert(hs, DeepEquals, hookstate.HookSetup{Snap: "producer", Hook: "connect-slot-slot", Optional: true})
 	c.Assert(task.Get("undo-hook-setup", &undoHookSetup), IsNil)
-	c.Assert(undoHookSetup, Equals, hookstate.HookSetup{Snap: "producer", Hook: "disconnect-slot-slot", Optional: true, IgnoreError: true})
+	c.Assert(undoHookSetup, DeepEquals, hookstate.HookSetup{Snap: "producer", Hook: "disconnect-slot-slot", Optional: true, IgnoreError: true})
 	i++
 	task = ts.Tasks()[i]
 	c.Check(task.Kind(), Equals, "run-hook")
 	c.Assert(task.Get("hook-setup", &hs), IsNil)
-	c.Assert(hs, Equals, hookstate.HookSetup{Snap: "consumer", Hook: "connect-plug-plug", Optional: true})
+	c.Assert(hs, DeepEquals, hookstate.HookSetup{Snap: "consumer", Hook: "connect-plug-plug", Optional: true})
 	c.Assert(task.Get("undo-hook-setup", &undoHookSetup), IsNil)
-	c.Assert(undoHookSetup, Equals, hookstate.HookSetup{Snap: "consumer", Hook: "disconnect-plug-plug", Optional: true, IgnoreError: true})
+	c.Assert(undoHookSetup, DeepEquals, hookstate.HookSetup{Snap: "consumer", Hook: "disconnect-plug-plug", Optional: true, IgnoreError: true})
 
 	// after-connect-hooks task edge is not present
 	_, err = ts.Edge(ifacestate.AfterConnectHooksEdge)
@@ -911,13 +911,13 @@ func (s *interfaceManagerSuite) TestParallelInstallConnectTask(c *C) {
 	var hookSetup hookstate.HookSetup
 	err = task.Get("hook-setup", &hookSetup)
 	c.Assert(err, IsNil)
-	c.Assert(hookSetup, Equals, hookstate.HookSetup{Snap: "consumer_foo", Hook: "prepare-plug-plug", Optional: true})
+	c.Assert(hookSetup, DeepEquals, hookstate.HookSetup{Snap: "consumer_foo", Hook: "prepare-plug-plug", Optional: true})
 	i++
 	task = ts.Tasks()[i]
 	c.Check(task.Kind(), Equals, "run-hook")
 	err = task.Get("hook-setup", &hookSetup)
 	c.Assert(err, IsNil)
-	c.Assert(hookSetup, Equals, hookstate.HookSetup{Snap: "producer", Hook: "prepare-slot-slot", Optional: true})
+	c.Assert(hookSetup, DeepEquals, hookstate.HookSetup{Snap: "producer", Hook: "prepare-slot-slot", Optional: true})
 	i++
 	task = ts.Tasks()[i]
 	c.Assert(task.Kind(), Equals, "connect")
@@ -961,13 +961,13 @@ func (s *interfaceManagerSuite) TestParallelInstallConnectTask(c *C) {
 	c.Check(task.Kind(), Equals, "run-hook")
 	err = task.Get("hook-setup", &hs)
 	c.Assert(err, IsNil)
-	c.Assert(hs, Equals, hookstate.HookSetup{Snap: "producer", Hook: "connect-slot-slot", Optional: true})
+	c.Assert(hs, DeepEquals, hookstate.HookSetup{Snap: "producer", Hook: "connect-slot-slot", Optional: true})
 	i++
 	task = ts.Tasks()[i]
 	c.Check(task.Kind(), Equals, "run-hook")
 	err = task.Get("hook-setup", &hs)
 	c.Assert(err, IsNil)
-	c.Assert(hs, Equals, hookstate.HookSetup{Snap: "consumer_foo", Hook: "connect-plug-plug", Optional: true})
+	c.Assert(hs, DeepEquals, hookstate.HookSetup{Snap: "consumer_foo", Hook: "connect-plug-plug", Optional: true})
 }
 
 func (s *interfaceManagerSuite) TestConnectAlreadyConnected(c *C) {
@@ -1699,17 +1699,17 @@ func (s *interfaceManagerSuite) TestDisconnectTask(c *C) {
 	task := ts.Tasks()[0]
 	c.Assert(task.Kind(), Equals, "run-hook")
 	c.Assert(task.Get("hook-setup", &hookSetup), IsNil)
-	c.Assert(hookSetup, Equals, hookstate.HookSetup{Snap: "producer", Hook: "disconnect-slot-slot", Optional: true, IgnoreError: false})
+	c.Assert(hookSetup, DeepEquals, hookstate.HookSetup{Snap: "producer", Hook: "disconnect-slot-slot", Optional: true, IgnoreError: false})
 	c.Assert(task.Get("undo-hook-setup", &undoHookSetup), IsNil)
-	c.Assert(undoHookSetup, Equals, hookstate.HookSetup{Snap: "producer", Hook: "connect-slot-slot", Optional: true, IgnoreError: false})
+	c.Assert(undoHookSetup, DeepEquals, hookstate.HookSetup{Snap: "producer", Hook: "connect-slot-slot", Optional: true, IgnoreError: false})
 
 	task = ts.Tasks()[1]
 	c.Assert(task.Kind(), Equals, "run-hook")
 	err = task.Get("hook-setup", &hookSetup)
 	c.Assert(err, IsNil)
-	c.Assert(hookSetup, Equals, hookstate.HookSetup{Snap: "consumer", Hook: "disconnect-plug-plug", Optional: true})
+	c.Assert(hookSetup, DeepEquals, hookstate.HookSetup{Snap: "consumer", Hook: "disconnect-plug-plug", Optional: true})
 	c.Assert(task.Get("undo-hook-setup", &undoHookSetup), IsNil)
-	c.Assert(undoHookSetup, Equals, hookstate.HookSetup{Snap: "consumer", Hook: "connect-plug-plug", Optional: true, IgnoreError: false})
+	c.Assert(undoHookSetup, DeepEquals, hookstate.HookSetup{Snap: "consumer", Hook: "connect-plug-plug", Optional: true, IgnoreError: false})
 
 	task = ts.Tasks()[2]
 	c.Assert(task.Kind(), Equals, "disconnect")
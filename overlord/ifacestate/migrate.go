@@ -0,0 +1,168 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// migrationTargetSlot returns the slot of toSnap that takes over the
+// connections of the given slot. If toSlot is empty the slot with the same
+// name is preferred, otherwise the only slot of toSnap with the same
+// interface is used.
+func migrationTargetSlot(repo *interfaces.Repository, from *snap.SlotInfo, toSnap, toSlot string) (*snap.SlotInfo, error) {
+	if toSlot != "" {
+		slot := repo.Slot(toSnap, toSlot)
+		if slot == nil {
+			return nil, fmt.Errorf("snap %q has no slot named %q", toSnap, toSlot)
+		}
+		if slot.Interface != from.Interface {
+			return nil, fmt.Errorf("cannot migrate connections of slot %s:%s to %s:%s: interface %q does not match %q",
+				from.Snap.InstanceName(), from.Name, toSnap, toSlot, slot.Interface, from.Interface)
+		}
+		return slot, nil
+	}
+
+	if slot := repo.Slot(toSnap, from.Name); slot != nil && slot.Interface == from.Interface {
+		return slot, nil
+	}
+	var candidates []*snap.SlotInfo
+	for _, slot := range repo.Slots(toSnap) {
+		if slot.Interface == from.Interface {
+			candidates = append(candidates, slot)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("cannot migrate connections of slot %s:%s: snap %q has no %q slot",
+			from.Snap.InstanceName(), from.Name, toSnap, from.Interface)
+	case 1:
+		return candidates[0], nil
+	default:
+		return nil, fmt.Errorf("cannot migrate connections of slot %s:%s: snap %q has multiple %q slots, the target slot must be specified",
+			from.Snap.InstanceName(), from.Name, toSnap, from.Interface)
+	}
+}
+
+// MigrateConnections returns the task sets that move the connections of the
+// slots of fromSnap over to the corresponding slots of toSnap, e.g. when a
+// snap providing a service is replaced by another one. If fromSlot is not
+// empty only the connections of that slot are migrated and if toSlot is not
+// empty they are all migrated to that slot.
+//
+// Each connection is disconnected from the old provider and connected to
+// the new one in its own lane so that a failure to connect restores the
+// original connection. The returned list contains the names of all
+// affected snaps. No task sets are returned if there is nothing to migrate.
+func MigrateConnections(st *state.State, repo *interfaces.Repository, fromSnap, fromSlot, toSnap, toSlot string) ([]*state.TaskSet, []string, error) {
+	if fromSnap == toSnap {
+		return nil, nil, fmt.Errorf("cannot migrate connections of snap %q to itself", fromSnap)
+	}
+	if fromSlot != "" && repo.Slot(fromSnap, fromSlot) == nil {
+		return nil, nil, fmt.Errorf("snap %q has no slot named %q", fromSnap, fromSlot)
+	}
+
+	connRefs, err := repo.Connections(fromSnap)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(connRefs, func(i, j int) bool { return connRefs[i].ID() < connRefs[j].ID() })
+
+	type migration struct {
+		conn   *interfaces.Connection
+		target *snap.SlotInfo
+	}
+	var migrations []migration
+	affected := map[string]bool{fromSnap: true, toSnap: true}
+	for _, connRef := range connRefs {
+		// only connections of the slots of fromSnap are migrated,
+		// this also skips self-connections
+		if connRef.SlotRef.Snap != fromSnap || connRef.PlugRef.Snap == fromSnap {
+			continue
+		}
+		if fromSlot != "" && connRef.SlotRef.Name != fromSlot {
+			continue
+		}
+		conn, err := repo.Connection(connRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		from := repo.Slot(fromSnap, connRef.SlotRef.Name)
+		if from == nil {
+			return nil, nil, fmt.Errorf("internal error: cannot find slot %s", connRef.SlotRef.String())
+		}
+		target, err := migrationTargetSlot(repo, from, toSnap, toSlot)
+		if err != nil {
+			return nil, nil, err
+		}
+		// check that the plug can be connected to the new slot
+		if _, err := repo.ResolveConnect(connRef.PlugRef.Snap, connRef.PlugRef.Name, toSnap, target.Name); err != nil {
+			return nil, nil, fmt.Errorf("cannot migrate connection %s: %v", connRef.ID(), err)
+		}
+		migrations = append(migrations, migration{conn: conn, target: target})
+		affected[connRef.PlugRef.Snap] = true
+	}
+	if len(migrations) == 0 {
+		return nil, nil, nil
+	}
+
+	affectedSnaps := make([]string, 0, len(affected))
+	for name := range affected {
+		affectedSnaps = append(affectedSnaps, name)
+	}
+	sort.Strings(affectedSnaps)
+	if err := snapstate.CheckChangeConflictMany(st, affectedSnaps, ""); err != nil {
+		return nil, nil, err
+	}
+
+	tasksets := make([]*state.TaskSet, 0, 2*len(migrations))
+	for _, m := range migrations {
+		plugSnap := m.conn.Plug.Snap().InstanceName()
+		plugName := m.conn.Plug.Name()
+
+		disconnectTs, err := disconnectTasks(st, m.conn, disconnectOpts{})
+		if err != nil {
+			return nil, nil, err
+		}
+		lane := st.NewLane()
+		disconnectTs.JoinLane(lane)
+		tasksets = append(tasksets, disconnectTs)
+
+		connectTs, err := connect(st, plugSnap, plugName, toSnap, m.target.Name, connectOpts{})
+		if _, ok := err.(*ErrAlreadyConnected); ok {
+			// the plug is already connected to the new provider
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		connectTs.WaitAll(disconnectTs)
+		connectTs.JoinLane(lane)
+		tasksets = append(tasksets, connectTs)
+	}
+
+	return tasksets, affectedSnaps, nil
+}
@@ -0,0 +1,163 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+var producerMultiSlotsYaml = `
+name: producer3
+version: 1
+slots:
+ slot-a:
+  interface: test
+ slot-b:
+  interface: test
+ other:
+  interface: test2
+`
+
+var producerOtherIfaceYaml = `
+name: producer4
+version: 1
+slots:
+ slot:
+  interface: test2
+`
+
+func (s *interfaceManagerSuite) mockMigrationSnaps(c *C) *ifacestate.InterfaceManager {
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+	s.mockSnap(c, producer2Yaml)
+	s.mockSnap(c, producerMultiSlotsYaml)
+	s.mockSnap(c, producerOtherIfaceYaml)
+
+	s.state.Lock()
+	s.state.Set("conns", map[string]any{
+		"consumer:plug producer:slot": map[string]any{"interface": "test"},
+	})
+	s.state.Unlock()
+
+	return s.manager(c)
+}
+
+func (s *interfaceManagerSuite) TestMigrateConnections(c *C) {
+	mgr := s.mockMigrationSnaps(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tss, affected, err := ifacestate.MigrateConnections(s.state, mgr.Repository(), "producer", "", "producer2", "")
+	c.Assert(err, IsNil)
+	c.Check(affected, DeepEquals, []string{"consumer", "producer", "producer2"})
+	c.Assert(tss, HasLen, 2)
+
+	disconnectTs, connectTs := tss[0], tss[1]
+	var disconnectTask, connectTask *state.Task
+	for _, t := range disconnectTs.Tasks() {
+		if t.Kind() == "disconnect" {
+			disconnectTask = t
+		}
+	}
+	for _, t := range connectTs.Tasks() {
+		if t.Kind() == "connect" {
+			connectTask = t
+		}
+	}
+	c.Assert(disconnectTask, NotNil)
+	c.Assert(connectTask, NotNil)
+
+	var slot interfaces.SlotRef
+	var plug interfaces.PlugRef
+	c.Assert(disconnectTask.Get("slot", &slot), IsNil)
+	c.Check(slot, Equals, interfaces.SlotRef{Snap: "producer", Name: "slot"})
+	c.Assert(connectTask.Get("slot", &slot), IsNil)
+	c.Check(slot, Equals, interfaces.SlotRef{Snap: "producer2", Name: "slot"})
+	c.Assert(connectTask.Get("plug", &plug), IsNil)
+	c.Check(plug, Equals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
+
+	// the new connection is made after the old one is gone, in the same lane
+	disconnectTasks := disconnectTs.Tasks()
+	c.Check(connectTs.Tasks()[0].WaitTasks(), testutil.Contains, disconnectTasks[len(disconnectTasks)-1])
+	c.Check(connectTask.Lanes(), DeepEquals, disconnectTask.Lanes())
+	c.Check(connectTask.Lanes(), HasLen, 1)
+}
+
+func (s *interfaceManagerSuite) TestMigrateConnectionsExplicitSlots(c *C) {
+	mgr := s.mockMigrationSnaps(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tss, _, err := ifacestate.MigrateConnections(s.state, mgr.Repository(), "producer", "slot", "producer3", "slot-b")
+	c.Assert(err, IsNil)
+	c.Assert(tss, HasLen, 2)
+
+	var slot interfaces.SlotRef
+	for _, t := range tss[1].Tasks() {
+		if t.Kind() == "connect" {
+			c.Assert(t.Get("slot", &slot), IsNil)
+		}
+	}
+	c.Check(slot, Equals, interfaces.SlotRef{Snap: "producer3", Name: "slot-b"})
+}
+
+func (s *interfaceManagerSuite) TestMigrateConnectionsNothingToDo(c *C) {
+	mgr := s.mockMigrationSnaps(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tss, affected, err := ifacestate.MigrateConnections(s.state, mgr.Repository(), "producer2", "", "producer", "")
+	c.Assert(err, IsNil)
+	c.Check(tss, HasLen, 0)
+	c.Check(affected, HasLen, 0)
+}
+
+func (s *interfaceManagerSuite) TestMigrateConnectionsErrors(c *C) {
+	mgr := s.mockMigrationSnaps(c)
+	repo := mgr.Repository()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, tc := range []struct {
+		fromSlot, toSnap, toSlot string
+		err                      string
+	}{
+		{"", "producer", "", `cannot migrate connections of snap "producer" to itself`},
+		{"missing", "producer2", "", `snap "producer" has no slot named "missing"`},
+		{"", "producer2", "missing", `snap "producer2" has no slot named "missing"`},
+		{"", "producer3", "", `cannot migrate connections of slot producer:slot: snap "producer3" has multiple "test" slots, the target slot must be specified`},
+		{"", "producer3", "other", `cannot migrate connections of slot producer:slot to producer3:other: interface "test2" does not match "test"`},
+		{"", "producer4", "", `cannot migrate connections of slot producer:slot: snap "producer4" has no "test" slot`},
+	} {
+		_, _, err := ifacestate.MigrateConnections(s.state, repo, "producer", tc.fromSlot, tc.toSnap, tc.toSlot)
+		c.Check(err, ErrorMatches, tc.err)
+	}
+}
@@ -0,0 +1,124 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// NameResolutionConfigKey is the system option under which operators declare
+// custom name resolution settings of a snap, in the form
+// name-resolution.<instance-name>.nameservers=<address>,... and
+// name-resolution.<instance-name>.hosts=<name>=<address>,...
+const NameResolutionConfigKey = "name-resolution"
+
+// maxNameservers is the number of nameservers the resolver of the C library
+// takes into account.
+const maxNameservers = 3
+
+var validHostNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,62})(\.[a-zA-Z0-9-]{1,63})*$`).MatchString
+
+// ParseNameservers parses a comma separated list of nameserver addresses.
+func ParseNameservers(value string) ([]string, error) {
+	nameservers := strutil.CommaSeparatedList(value)
+	if len(nameservers) > maxNameservers {
+		return nil, fmt.Errorf("cannot use more than %d nameservers", maxNameservers)
+	}
+	for _, ns := range nameservers {
+		if net.ParseIP(ns) == nil {
+			return nil, fmt.Errorf("invalid nameserver address %q", ns)
+		}
+	}
+	return nameservers, nil
+}
+
+// ParseHosts parses a comma separated list of <name>=<address> host entries.
+func ParseHosts(value string) (map[string]string, error) {
+	entries := strutil.CommaSeparatedList(value)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	hosts := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, addr, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid hosts entry %q: expected <name>=<address>", entry)
+		}
+		if len(name) > 253 || !validHostNameRegexp(name) {
+			return nil, fmt.Errorf("invalid host name %q", name)
+		}
+		if net.ParseIP(addr) == nil {
+			return nil, fmt.Errorf("invalid address %q for host %q", addr, name)
+		}
+		if _, ok := hosts[name]; ok {
+			return nil, fmt.Errorf("duplicate hosts entry for %q", name)
+		}
+		hosts[name] = addr
+	}
+	return hosts, nil
+}
+
+// SnapNameResolution returns the custom name resolution settings configured
+// for the given snap instance, or nil if the snap uses the ones of the
+// system.
+func SnapNameResolution(st *state.State, instanceName string) (*interfaces.NameResolution, error) {
+	type nameResolutionSettings struct {
+		Nameservers string `json:"nameservers"`
+		Hosts       string `json:"hosts"`
+	}
+	// the settings of all snaps are read at once as the instance key
+	// separator is not allowed in option names
+	var all map[string]nameResolutionSettings
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", NameResolutionConfigKey, &all); err != nil {
+		if config.IsNoOption(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	settings, ok := all[instanceName]
+	if !ok {
+		return nil, nil
+	}
+
+	nameservers, err := ParseNameservers(settings.Nameservers)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := ParseHosts(settings.Hosts)
+	if err != nil {
+		return nil, err
+	}
+	if len(nameservers) == 0 && len(hosts) == 0 {
+		return nil, nil
+	}
+	return &interfaces.NameResolution{
+		Nameservers: nameservers,
+		Hosts:       hosts,
+	}, nil
+}
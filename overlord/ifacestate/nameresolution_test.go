@@ -0,0 +1,130 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type nameResolutionSuite struct {
+	st *state.State
+}
+
+var _ = Suite(&nameResolutionSuite{})
+
+func (s *nameResolutionSuite) SetUpTest(c *C) {
+	s.st = state.New(nil)
+}
+
+func (s *nameResolutionSuite) setConfig(c *C, key string, value any) {
+	tr := config.NewTransaction(s.st)
+	c.Assert(tr.Set("core", key, value), IsNil)
+	tr.Commit()
+}
+
+func (s *nameResolutionSuite) TestSnapNameResolution(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	s.setConfig(c, "name-resolution.foo.nameservers", "10.0.0.1, fd00::1")
+	s.setConfig(c, "name-resolution.foo.hosts", "db.internal=10.0.0.5,api=10.0.0.6")
+
+	nr, err := ifacestate.SnapNameResolution(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(nr, DeepEquals, &interfaces.NameResolution{
+		Nameservers: []string{"10.0.0.1", "fd00::1"},
+		Hosts: map[string]string{
+			"db.internal": "10.0.0.5",
+			"api":         "10.0.0.6",
+		},
+	})
+}
+
+func (s *nameResolutionSuite) TestSnapNameResolutionUnset(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	nr, err := ifacestate.SnapNameResolution(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(nr, IsNil)
+
+	s.setConfig(c, "name-resolution.foo.nameservers", "")
+	nr, err = ifacestate.SnapNameResolution(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(nr, IsNil)
+
+	// settings of other snaps do not matter
+	s.setConfig(c, "name-resolution.bar.nameservers", "10.0.0.1")
+	nr, err = ifacestate.SnapNameResolution(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(nr, IsNil)
+}
+
+func (s *nameResolutionSuite) TestSnapNameResolutionParallelInstance(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	s.setConfig(c, "name-resolution", map[string]any{
+		"foo_bar": map[string]any{"nameservers": "10.0.0.1"},
+	})
+
+	nr, err := ifacestate.SnapNameResolution(s.st, "foo_bar")
+	c.Assert(err, IsNil)
+	c.Check(nr, DeepEquals, &interfaces.NameResolution{
+		Nameservers: []string{"10.0.0.1"},
+	})
+
+	nr, err = ifacestate.SnapNameResolution(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(nr, IsNil)
+}
+
+func (s *nameResolutionSuite) TestParseNameserversErrors(c *C) {
+	for _, tc := range []struct {
+		value, err string
+	}{
+		{"10.0.0.1,foo", `invalid nameserver address "foo"`},
+		{"10.0.0.1,10.0.0.2,10.0.0.3,10.0.0.4", `cannot use more than 3 nameservers`},
+		{"10.0.0.1:53", `invalid nameserver address "10.0.0.1:53"`},
+	} {
+		_, err := ifacestate.ParseNameservers(tc.value)
+		c.Check(err, ErrorMatches, tc.err, Commentf("%q", tc.value))
+	}
+}
+
+func (s *nameResolutionSuite) TestParseHostsErrors(c *C) {
+	for _, tc := range []struct {
+		value, err string
+	}{
+		{"db.internal", `invalid hosts entry "db.internal": expected <name>=<address>`},
+		{"-db=10.0.0.1", `invalid host name "-db"`},
+		{"db..internal=10.0.0.1", `invalid host name "db..internal"`},
+		{"db=10.0.0", `invalid address "10.0.0" for host "db"`},
+		{"db=10.0.0.1,db=10.0.0.2", `duplicate hosts entry for "db"`},
+	} {
+		_, err := ifacestate.ParseHosts(tc.value)
+		c.Check(err, ErrorMatches, tc.err, Commentf("%q", tc.value))
+	}
+}
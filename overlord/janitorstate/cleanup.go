@@ -0,0 +1,310 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package janitorstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// CleanupTarget is a category of data that can be cleaned up to reclaim
+// disk space.
+type CleanupTarget string
+
+const (
+	// OldRevisions are the installed revisions of snaps other than the
+	// current ones.
+	OldRevisions CleanupTarget = "old-revisions"
+	// ExpiredSnapshots are the automatic snapshots past their expiry time.
+	ExpiredSnapshots CleanupTarget = "snapshots-expired"
+	// DownloadCache are the blobs in the download cache that are not used
+	// by any installed revision.
+	DownloadCache CleanupTarget = "download-cache"
+	// ChangesArchive are the changes that are ready, kept in the state
+	// until they get pruned.
+	ChangesArchive CleanupTarget = "changes-archive"
+)
+
+// CleanupTargets are all the cleanup targets, in the order they are
+// cleaned up.
+var CleanupTargets = []CleanupTarget{OldRevisions, ExpiredSnapshots, DownloadCache, ChangesArchive}
+
+// ValidateCleanupTargets checks that the given cleanup targets are known.
+func ValidateCleanupTargets(targets []CleanupTarget) error {
+	for _, target := range targets {
+		known := false
+		for _, t := range CleanupTargets {
+			if t == target {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown cleanup target %q", target)
+		}
+	}
+	return nil
+}
+
+type oldRevision struct {
+	instanceName string
+	revision     snap.Revision
+	size         int64
+}
+
+// oldRevisions returns the installed revisions of snaps other than the
+// current ones, sorted by snap.
+func oldRevisions(st *state.State) ([]oldRevision, error) {
+	all, err := snapstate.All(st)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var revs []oldRevision
+	for _, name := range names {
+		snapst := all[name]
+		for _, si := range snapst.Sequence.SideInfos() {
+			if si.Revision == snapst.Current {
+				continue
+			}
+			rev := oldRevision{instanceName: name, revision: si.Revision}
+			if fi, err := os.Stat(snap.MountFile(name, si.Revision)); err == nil {
+				rev.size = fi.Size()
+			}
+			revs = append(revs, rev)
+		}
+	}
+	return revs, nil
+}
+
+// unusedCacheBlobs returns the blobs in the download cache that are not
+// hard linked from the snaps directory, along with their total size.
+func unusedCacheBlobs() (paths []string, size int64, err error) {
+	entries, err := readDirIfExists(dirs.SnapDownloadCacheDir)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if stat, ok := fi.Sys().(*syscall.Stat_t); !ok || stat.Nlink != 1 {
+			continue
+		}
+		paths = append(paths, filepath.Join(dirs.SnapDownloadCacheDir, entry.Name()))
+		size += fi.Size()
+	}
+	return paths, size, nil
+}
+
+// readyChangesSize estimates how much of the state the changes that are
+// ready take.
+func readyChangesSize(st *state.State) (int64, error) {
+	var size int64
+	for _, chg := range st.Changes() {
+		if !chg.IsReady() {
+			continue
+		}
+		data, err := json.Marshal(chg)
+		if err != nil {
+			return 0, err
+		}
+		size += int64(len(data))
+		for _, t := range chg.Tasks() {
+			data, err := json.Marshal(t)
+			if err != nil {
+				return 0, err
+			}
+			size += int64(len(data))
+		}
+	}
+	return size, nil
+}
+
+// Reclaimable returns how much disk space, in bytes, cleaning up each of
+// the given targets would reclaim.
+func Reclaimable(ctx context.Context, st *state.State, targets []CleanupTarget) (map[CleanupTarget]int64, error) {
+	if err := ValidateCleanupTargets(targets); err != nil {
+		return nil, err
+	}
+
+	reclaimable := make(map[CleanupTarget]int64, len(targets))
+	for _, target := range targets {
+		var size int64
+		switch target {
+		case OldRevisions:
+			revs, err := oldRevisions(st)
+			if err != nil {
+				return nil, err
+			}
+			for _, rev := range revs {
+				size += rev.size
+			}
+		case ExpiredSnapshots:
+			_, setsSize, err := snapshotstate.ExpiredSets(ctx, st)
+			if err != nil {
+				return nil, err
+			}
+			size = setsSize
+		case DownloadCache:
+			_, blobsSize, err := unusedCacheBlobs()
+			if err != nil {
+				return nil, err
+			}
+			size = blobsSize
+		case ChangesArchive:
+			changesSize, err := readyChangesSize(st)
+			if err != nil {
+				return nil, err
+			}
+			size = changesSize
+		}
+		reclaimable[target] = size
+	}
+	return reclaimable, nil
+}
+
+// Cleanup returns the task sets cleaning up the given targets. Snaps and
+// snapshots that are the subject of changes in progress are skipped.
+func Cleanup(ctx context.Context, st *state.State, targets []CleanupTarget) ([]*state.TaskSet, error) {
+	if err := ValidateCleanupTargets(targets); err != nil {
+		return nil, err
+	}
+
+	var tss []*state.TaskSet
+	for _, target := range targets {
+		switch target {
+		case OldRevisions:
+			revsTss, err := removeOldRevisions(st)
+			if err != nil {
+				return nil, err
+			}
+			tss = append(tss, revsTss...)
+		case ExpiredSnapshots:
+			setIDs, _, err := snapshotstate.ExpiredSets(ctx, st)
+			if err != nil {
+				return nil, err
+			}
+			for _, setID := range setIDs {
+				_, ts, err := snapshotstate.Forget(st, setID, nil)
+				if err != nil {
+					logger.Noticef("cannot forget expired snapshot set %d: %v", setID, err)
+					continue
+				}
+				tss = append(tss, ts)
+			}
+		case DownloadCache:
+			t := st.NewTask("janitor-clear-download-cache", "Remove unused blobs from the download cache")
+			tss = append(tss, state.NewTaskSet(t))
+		case ChangesArchive:
+			t := st.NewTask("janitor-prune-changes", "Remove the changes that are ready")
+			tss = append(tss, state.NewTaskSet(t))
+		}
+	}
+	return tss, nil
+}
+
+func removeOldRevisions(st *state.State) ([]*state.TaskSet, error) {
+	revs, err := oldRevisions(st)
+	if err != nil {
+		return nil, err
+	}
+
+	var tss []*state.TaskSet
+	var prev *state.TaskSet
+	for i, rev := range revs {
+		if i > 0 && revs[i-1].instanceName != rev.instanceName {
+			prev = nil
+		}
+		ts, err := snapstate.Remove(st, rev.instanceName, rev.revision, nil)
+		if err != nil {
+			var conflict *snapstate.ChangeConflictError
+			if errors.As(err, &conflict) {
+				logger.Noticef("cannot remove revision %s of snap %q: %v", rev.revision, rev.instanceName, err)
+				continue
+			}
+			return nil, err
+		}
+		// revisions of the same snap are removed one after the other
+		if prev != nil {
+			ts.WaitAll(prev)
+		}
+		prev = ts
+		tss = append(tss, ts)
+	}
+	return tss, nil
+}
+
+func (m *JanitorManager) doClearDownloadCache(t *state.Task, _ *tomb.Tomb) error {
+	paths, _, err := unusedCacheBlobs()
+	if err != nil {
+		return err
+	}
+	var removed int
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("cannot remove %q: %v", p, err)
+		}
+		removed++
+	}
+
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+	t.Logf("Removed %d unused blobs from the download cache", removed)
+	return nil
+}
+
+func (m *JanitorManager) doPruneChanges(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	pruned := st.PruneReadyChanges()
+	t.Logf("Removed %d changes that were ready", len(pruned))
+	return nil
+}
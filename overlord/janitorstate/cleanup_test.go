@@ -0,0 +1,121 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package janitorstate_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/janitorstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *janitorSuite) setUpCleanup(c *C) (usedBlob, unusedBlob string) {
+	// the installed snap has a revision besides the current one
+	si4 := &snap.SideInfo{RealName: "installed", Revision: snap.R(4)}
+	si5 := &snap.SideInfo{RealName: "installed", Revision: snap.R(5)}
+	snapstate.Set(s.st, "installed", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si4, si5}),
+		Current:  si5.Revision,
+	})
+	s.mkfile(c, snap.MountFile("installed", si4.Revision), "1234", s.now)
+	s.mkfile(c, snap.MountFile("installed", si5.Revision), "123456", s.now)
+
+	usedBlob = filepath.Join(dirs.SnapDownloadCacheDir, "used")
+	c.Assert(os.MkdirAll(dirs.SnapDownloadCacheDir, 0755), IsNil)
+	c.Assert(os.Link(snap.MountFile("installed", si5.Revision), usedBlob), IsNil)
+	unusedBlob = filepath.Join(dirs.SnapDownloadCacheDir, "unused")
+	s.mkfile(c, unusedBlob, "12345678", s.now)
+
+	chg := s.st.NewChange("done", "...")
+	t := s.st.NewTask("foo", "...")
+	chg.AddTask(t)
+	t.SetStatus(state.DoneStatus)
+
+	return usedBlob, unusedBlob
+}
+
+func (s *janitorSuite) TestReclaimable(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	s.setUpCleanup(c)
+
+	reclaimable, err := janitorstate.Reclaimable(context.Background(), s.st, janitorstate.CleanupTargets)
+	c.Assert(err, IsNil)
+	c.Check(reclaimable[janitorstate.OldRevisions], Equals, int64(4))
+	c.Check(reclaimable[janitorstate.ExpiredSnapshots], Equals, int64(0))
+	c.Check(reclaimable[janitorstate.DownloadCache], Equals, int64(8))
+	c.Check(reclaimable[janitorstate.ChangesArchive] > 0, Equals, true)
+
+	reclaimable, err = janitorstate.Reclaimable(context.Background(), s.st, []janitorstate.CleanupTarget{janitorstate.DownloadCache})
+	c.Assert(err, IsNil)
+	c.Check(reclaimable, DeepEquals, map[janitorstate.CleanupTarget]int64{
+		janitorstate.DownloadCache: 8,
+	})
+}
+
+func (s *janitorSuite) TestReclaimableUnknownTarget(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	_, err := janitorstate.Reclaimable(context.Background(), s.st, []janitorstate.CleanupTarget{"foo"})
+	c.Check(err, ErrorMatches, `unknown cleanup target "foo"`)
+	_, err = janitorstate.Cleanup(context.Background(), s.st, []janitorstate.CleanupTarget{"foo"})
+	c.Check(err, ErrorMatches, `unknown cleanup target "foo"`)
+}
+
+func (s *janitorSuite) TestCleanupDownloadCacheAndChanges(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	usedBlob, unusedBlob := s.setUpCleanup(c)
+	done := s.st.Changes()[0]
+
+	tss, err := janitorstate.Cleanup(context.Background(), s.st, []janitorstate.CleanupTarget{janitorstate.DownloadCache, janitorstate.ChangesArchive})
+	c.Assert(err, IsNil)
+	c.Assert(tss, HasLen, 2)
+	c.Check(tss[0].Tasks()[0].Kind(), Equals, "janitor-clear-download-cache")
+	c.Check(tss[1].Tasks()[0].Kind(), Equals, "janitor-prune-changes")
+	chg := s.st.NewChange("cleanup", "...")
+	for _, ts := range tss {
+		chg.AddAll(ts)
+	}
+
+	s.st.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.st.Lock()
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus)
+	c.Check(usedBlob, testutil.FilePresent)
+	c.Check(unusedBlob, testutil.FileAbsent)
+	c.Check(s.st.Change(done.ID()), IsNil)
+	c.Check(s.st.Change(chg.ID()), NotNil)
+}
@@ -0,0 +1,519 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package janitorstate finds what is left behind on disk by snaps that are
+// no longer installed, or by operations that did not complete, and removes
+// it once the administrator approves it.
+package janitorstate
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/systemd"
+)
+
+var (
+	timeNow = time.Now
+
+	// scanInterval is how often the system is scanned for leftovers.
+	scanInterval = 24 * time.Hour
+	// minAge is how long the data of a removed snap, or a blob in the
+	// download cache, must have been left untouched before it is
+	// considered a leftover.
+	minAge = 30 * 24 * time.Hour
+
+	removeMountUnit = func(mountPoint string) error {
+		sysd := systemd.New(systemd.SystemMode, progress.Null)
+		return sysd.RemoveMountUnitFile(mountPoint)
+	}
+)
+
+func init() {
+	swfeats.RegisterEnsure("JanitorManager", "ensureScanned")
+}
+
+// LeftoverKind is the kind of a leftover.
+type LeftoverKind string
+
+const (
+	// OrphanedData is the data directory of a snap that is no longer
+	// installed.
+	OrphanedData LeftoverKind = "orphaned-data"
+	// StaleCacheBlob is a blob in the download cache that is not used
+	// by any installed revision.
+	StaleCacheBlob LeftoverKind = "stale-cache-blob"
+	// DanglingMountUnit is the mount unit of a snap revision that is
+	// no longer installed.
+	DanglingMountUnit LeftoverKind = "dangling-mount-unit"
+	// BrokenCurrentSymlink is a current symlink of a snap that is no
+	// longer installed, pointing to a revision that does not exist.
+	BrokenCurrentSymlink LeftoverKind = "broken-current-symlink"
+)
+
+// Leftover is something left on disk that can be removed.
+type Leftover struct {
+	Kind LeftoverKind `json:"kind"`
+	Path string       `json:"path"`
+	// Snap is the name of the snap the leftover belonged to, if known.
+	Snap    string    `json:"snap,omitempty"`
+	ModTime time.Time `json:"mod-time"`
+	// MountPoint is where a dangling mount unit mounts its snap.
+	MountPoint string `json:"mount-point,omitempty"`
+}
+
+// Report is the outcome of a scan for leftovers.
+type Report struct {
+	Time      time.Time   `json:"time"`
+	Leftovers []*Leftover `json:"leftovers"`
+}
+
+// CurrentReport returns the report of the last scan, or nil if the system
+// was never scanned.
+func CurrentReport(st *state.State) (*Report, error) {
+	var report Report
+	if err := st.Get("janitor-report", &report); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Scan looks for leftovers and records the report, which it returns.
+func Scan(st *state.State) (*Report, error) {
+	leftovers, err := findLeftovers(st)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		Time:      timeNow(),
+		Leftovers: leftovers,
+	}
+	st.Set("janitor-report", report)
+	return report, nil
+}
+
+func findLeftovers(st *state.State) ([]*Leftover, error) {
+	installed, err := snapstate.All(st)
+	if err != nil {
+		return nil, err
+	}
+	leftovers := []*Leftover{}
+	for _, find := range []func(map[string]*snapstate.SnapState) ([]*Leftover, error){
+		findOrphanedData,
+		findStaleCacheBlobs,
+		findDanglingMountUnits,
+		findBrokenMountSymlinks,
+	} {
+		found, err := find(installed)
+		if err != nil {
+			return nil, err
+		}
+		leftovers = append(leftovers, found...)
+	}
+
+	// whatever belongs to snaps with changes in progress may be in use
+	// by those changes, e.g. the mount unit of a revision being installed
+	busy, err := snapsWithChangesInProgress(st)
+	if err != nil {
+		return nil, err
+	}
+	filtered := leftovers[:0]
+	for _, l := range leftovers {
+		if l.Snap != "" && busy[l.Snap] {
+			continue
+		}
+		filtered = append(filtered, l)
+	}
+	leftovers = filtered
+
+	sort.Slice(leftovers, func(i, j int) bool {
+		return leftovers[i].Path < leftovers[j].Path
+	})
+	return leftovers, nil
+}
+
+// snapsWithChangesInProgress returns the snaps affected by the changes
+// which are not ready yet.
+func snapsWithChangesInProgress(st *state.State) (map[string]bool, error) {
+	busy := make(map[string]bool)
+	for _, chg := range st.Changes() {
+		if chg.IsReady() {
+			continue
+		}
+		for _, t := range chg.Tasks() {
+			snaps, err := snapstate.SnapsAffectedByTask(t)
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range snaps {
+				busy[name] = true
+			}
+		}
+	}
+	return busy, nil
+}
+
+func readDirIfExists(dir string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("cannot scan %q: %v", dir, err)
+	}
+	return entries, nil
+}
+
+func isOld(fi os.FileInfo) bool {
+	return timeNow().Sub(fi.ModTime()) >= minAge
+}
+
+// brokenCurrentSymlink returns a leftover if dir/current is a symlink
+// pointing to something that does not exist.
+func brokenCurrentSymlink(dir, snapName string) *Leftover {
+	current := filepath.Join(dir, "current")
+	fi, err := os.Lstat(current)
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	if _, err := os.Stat(current); !errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return &Leftover{
+		Kind:    BrokenCurrentSymlink,
+		Path:    current,
+		Snap:    snapName,
+		ModTime: fi.ModTime(),
+	}
+}
+
+func findOrphanedData(installed map[string]*snapstate.SnapState) ([]*Leftover, error) {
+	entries, err := readDirIfExists(dirs.SnapDataDir)
+	if err != nil {
+		return nil, err
+	}
+	var leftovers []*Leftover
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || installed[name] != nil {
+			continue
+		}
+		if err := snap.ValidateInstanceName(name); err != nil {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join(dirs.SnapDataDir, name)
+		if isOld(fi) {
+			leftovers = append(leftovers, &Leftover{
+				Kind:    OrphanedData,
+				Path:    dir,
+				Snap:    name,
+				ModTime: fi.ModTime(),
+			})
+			continue
+		}
+		// the data is too recent to be removed, but a dangling current
+		// symlink only gets in the way of a new installation
+		if l := brokenCurrentSymlink(dir, name); l != nil {
+			leftovers = append(leftovers, l)
+		}
+	}
+	return leftovers, nil
+}
+
+func findStaleCacheBlobs(installed map[string]*snapstate.SnapState) ([]*Leftover, error) {
+	entries, err := readDirIfExists(dirs.SnapDownloadCacheDir)
+	if err != nil {
+		return nil, err
+	}
+	var leftovers []*Leftover
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		// blobs used by installed revisions are hard linked from the
+		// snaps directory
+		if stat, ok := fi.Sys().(*syscall.Stat_t); !ok || stat.Nlink != 1 {
+			continue
+		}
+		if !isOld(fi) {
+			continue
+		}
+		leftovers = append(leftovers, &Leftover{
+			Kind:    StaleCacheBlob,
+			Path:    filepath.Join(dirs.SnapDownloadCacheDir, entry.Name()),
+			ModTime: fi.ModTime(),
+		})
+	}
+	return leftovers, nil
+}
+
+// mountUnitWhere returns the mount point of the given mount unit, unless
+// the unit was created by some snapd backend other than the one mounting
+// the snaps.
+func mountUnitWhere(unitPath string) (string, error) {
+	f, err := os.Open(unitPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var where string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "X-SnapdOrigin=") {
+			return "", nil
+		}
+		if strings.HasPrefix(line, "Where=") {
+			where = strings.TrimPrefix(line, "Where=")
+		}
+	}
+	return where, s.Err()
+}
+
+func findDanglingMountUnits(installed map[string]*snapstate.SnapState) ([]*Leftover, error) {
+	entries, err := readDirIfExists(dirs.SnapServicesDir)
+	if err != nil {
+		return nil, err
+	}
+	mountDir := dirs.StripRootDir(dirs.SnapMountDir)
+	var leftovers []*Leftover
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".mount") {
+			continue
+		}
+		unitPath := filepath.Join(dirs.SnapServicesDir, entry.Name())
+		where, err := mountUnitWhere(unitPath)
+		if err != nil {
+			logger.Noticef("cannot read mount unit %q: %v", unitPath, err)
+			continue
+		}
+		// only consider the mount units of snap revisions, that is of
+		// <snap mount dir>/<snap>/<revision>
+		rel, err := filepath.Rel(mountDir, where)
+		if where == "" || err != nil {
+			continue
+		}
+		parts := strings.Split(rel, "/")
+		if len(parts) != 2 {
+			continue
+		}
+		rev, err := snap.ParseRevision(parts[1])
+		if err != nil {
+			continue
+		}
+		if snapst := installed[parts[0]]; snapst != nil && snapst.LastIndex(rev) >= 0 {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		leftovers = append(leftovers, &Leftover{
+			Kind:       DanglingMountUnit,
+			Path:       unitPath,
+			Snap:       parts[0],
+			ModTime:    fi.ModTime(),
+			MountPoint: where,
+		})
+	}
+	return leftovers, nil
+}
+
+func findBrokenMountSymlinks(installed map[string]*snapstate.SnapState) ([]*Leftover, error) {
+	entries, err := readDirIfExists(dirs.SnapMountDir)
+	if err != nil {
+		return nil, err
+	}
+	var leftovers []*Leftover
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || installed[name] != nil {
+			continue
+		}
+		if l := brokenCurrentSymlink(filepath.Join(dirs.SnapMountDir, name), name); l != nil {
+			leftovers = append(leftovers, l)
+		}
+	}
+	return leftovers, nil
+}
+
+// Clean returns a task set removing the given leftovers, which must have
+// been found by the last scan.
+func Clean(st *state.State, paths []string) (*state.TaskSet, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("cannot clean leftovers: no paths given")
+	}
+	report, err := CurrentReport(st)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	if report != nil {
+		for _, l := range report.Leftovers {
+			known[l.Path] = true
+		}
+	}
+	for _, p := range paths {
+		if !known[p] {
+			return nil, fmt.Errorf("cannot clean %q: not a leftover found by the last scan", p)
+		}
+	}
+
+	t := st.NewTask("janitor-clean", fmt.Sprintf("Remove %s", strutil.Quoted(paths)))
+	t.Set("paths", paths)
+	return state.NewTaskSet(t), nil
+}
+
+// JanitorManager periodically scans the system for leftovers and removes
+// the ones approved by the administrator.
+type JanitorManager struct {
+	state *state.State
+}
+
+// Manager returns a new JanitorManager.
+func Manager(st *state.State, runner *state.TaskRunner) *JanitorManager {
+	m := &JanitorManager{state: st}
+	runner.AddHandler("janitor-clean", m.doClean, nil)
+	runner.AddHandler("janitor-clear-download-cache", m.doClearDownloadCache, nil)
+	runner.AddHandler("janitor-prune-changes", m.doPruneChanges, nil)
+	return m
+}
+
+// Ensure implements StateManager.Ensure.
+func (m *JanitorManager) Ensure() error {
+	return m.ensureScanned()
+}
+
+// ensureScanned scans the system for leftovers if the last scan is older
+// than the scan interval.
+func (m *JanitorManager) ensureScanned() error {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	report, err := CurrentReport(st)
+	if err != nil {
+		return err
+	}
+	if report != nil && timeNow().Sub(report.Time) < scanInterval {
+		return nil
+	}
+
+	logger.Trace("ensure", "manager", "JanitorManager", "func", "ensureScanned")
+
+	report, err = Scan(st)
+	if err != nil {
+		return fmt.Errorf("cannot scan for leftovers: %v", err)
+	}
+	if len(report.Leftovers) > 0 {
+		logger.Noticef("Found %d leftovers that can be removed", len(report.Leftovers))
+	}
+	return nil
+}
+
+func removeLeftover(l *Leftover) error {
+	switch l.Kind {
+	case DanglingMountUnit:
+		return removeMountUnit(filepath.Join(dirs.GlobalRootDir, l.MountPoint))
+	case OrphanedData:
+		return os.RemoveAll(l.Path)
+	default:
+		return os.Remove(l.Path)
+	}
+}
+
+func (m *JanitorManager) doClean(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var paths []string
+	if err := t.Get("paths", &paths); err != nil {
+		return err
+	}
+
+	// things may have changed since the approval, only remove what is
+	// still a leftover
+	leftovers, err := findLeftovers(st)
+	if err != nil {
+		return fmt.Errorf("cannot scan for leftovers: %v", err)
+	}
+	byPath := make(map[string]*Leftover, len(leftovers))
+	for _, l := range leftovers {
+		byPath[l.Path] = l
+	}
+	var toRemove []*Leftover
+	for _, p := range paths {
+		l := byPath[p]
+		if l == nil {
+			t.Logf("Skipping %q: no longer a leftover", p)
+			continue
+		}
+		toRemove = append(toRemove, l)
+	}
+
+	st.Unlock()
+	var errs []string
+	var removed []string
+	for _, l := range toRemove {
+		if err := removeLeftover(l); err != nil {
+			errs = append(errs, fmt.Sprintf("cannot remove %q: %v", l.Path, err))
+			continue
+		}
+		removed = append(removed, l.Path)
+	}
+	st.Lock()
+
+	for _, p := range removed {
+		t.Logf("Removed %q", p)
+	}
+	if _, err := Scan(st); err != nil {
+		logger.Noticef("cannot scan for leftovers: %v", err)
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
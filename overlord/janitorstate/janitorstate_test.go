@@ -0,0 +1,316 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package janitorstate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/janitorstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func TestJanitorState(t *testing.T) { TestingT(t) }
+
+type janitorSuite struct {
+	testutil.BaseTest
+
+	st  *state.State
+	se  *overlord.StateEngine
+	mgr *janitorstate.JanitorManager
+	now time.Time
+	old time.Time
+
+	removedMounts []string
+}
+
+var _ = Suite(&janitorSuite{})
+
+func (s *janitorSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.now = time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.old = s.now.AddDate(0, -2, 0)
+	s.AddCleanup(janitorstate.MockTimeNow(func() time.Time { return s.now }))
+	s.removedMounts = nil
+	s.AddCleanup(janitorstate.MockRemoveMountUnit(func(mountPoint string) error {
+		s.removedMounts = append(s.removedMounts, mountPoint)
+		return nil
+	}))
+
+	s.st = state.New(nil)
+	s.se = overlord.NewStateEngine(s.st)
+	runner := state.NewTaskRunner(s.st)
+	s.mgr = janitorstate.Manager(s.st, runner)
+	s.se.AddManager(s.mgr)
+	s.se.AddManager(runner)
+	c.Assert(s.se.StartUp(), IsNil)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	si := &snap.SideInfo{RealName: "installed", Revision: snap.R(5)}
+	snapstate.Set(s.st, "installed", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+	})
+}
+
+func (s *janitorSuite) mkdir(c *C, dir string, mtime time.Time) {
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	c.Assert(os.Chtimes(dir, mtime, mtime), IsNil)
+}
+
+func (s *janitorSuite) mkfile(c *C, path, content string, mtime time.Time) {
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), IsNil)
+	c.Assert(os.WriteFile(path, []byte(content), 0644), IsNil)
+	c.Assert(os.Chtimes(path, mtime, mtime), IsNil)
+}
+
+func (s *janitorSuite) mkMountUnit(c *C, where, origin string) string {
+	content := "[Unit]\nDescription=Mount unit\n\n[Mount]\nWhere=" + where + "\n"
+	if origin != "" {
+		content += "X-SnapdOrigin=" + origin + "\n"
+	}
+	unit := filepath.Join(dirs.SnapServicesDir, "unit-"+filepath.Base(filepath.Dir(where))+"-"+filepath.Base(where)+".mount")
+	s.mkfile(c, unit, content, s.now)
+	return unit
+}
+
+func (s *janitorSuite) setUpLeftovers(c *C) map[string]string {
+	paths := make(map[string]string)
+
+	// data of installed snaps and recently removed ones is kept
+	s.mkdir(c, filepath.Join(dirs.SnapDataDir, "installed"), s.old)
+	s.mkdir(c, filepath.Join(dirs.SnapDataDir, "recent"), s.now)
+	paths["orphaned"] = filepath.Join(dirs.SnapDataDir, "removed")
+	s.mkdir(c, paths["orphaned"], s.old)
+
+	// the recently removed snap has a broken current symlink
+	paths["data-symlink"] = filepath.Join(dirs.SnapDataDir, "recent", "current")
+	c.Assert(os.Symlink("7", paths["data-symlink"]), IsNil)
+	paths["mount-symlink"] = filepath.Join(dirs.SnapMountDir, "gone", "current")
+	c.Assert(os.MkdirAll(filepath.Dir(paths["mount-symlink"]), 0755), IsNil)
+	c.Assert(os.Symlink("3", paths["mount-symlink"]), IsNil)
+	// but a working one is fine
+	s.mkdir(c, filepath.Join(dirs.SnapMountDir, "other", "1"), s.now)
+	c.Assert(os.Symlink("1", filepath.Join(dirs.SnapMountDir, "other", "current")), IsNil)
+
+	// only old blobs with no other links are stale
+	paths["blob"] = filepath.Join(dirs.SnapDownloadCacheDir, "stale")
+	s.mkfile(c, paths["blob"], "blob", s.old)
+	s.mkfile(c, filepath.Join(dirs.SnapDownloadCacheDir, "fresh"), "blob", s.now)
+	linked := filepath.Join(dirs.SnapDownloadCacheDir, "linked")
+	s.mkfile(c, linked, "blob", s.old)
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	c.Assert(os.Link(linked, filepath.Join(dirs.SnapBlobDir, "installed_5.snap")), IsNil)
+
+	mountDir := dirs.StripRootDir(dirs.SnapMountDir)
+	s.mkMountUnit(c, filepath.Join(mountDir, "installed", "5"), "")
+	paths["mount-unit"] = s.mkMountUnit(c, filepath.Join(mountDir, "installed", "4"), "")
+	// units created by other backends are left alone
+	s.mkMountUnit(c, filepath.Join(mountDir, "removed", "2"), "mount-control")
+
+	return paths
+}
+
+func (s *janitorSuite) TestScan(c *C) {
+	paths := s.setUpLeftovers(c)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	report, err := janitorstate.Scan(s.st)
+	c.Assert(err, IsNil)
+	c.Check(report.Time.Equal(s.now), Equals, true)
+
+	found := make(map[string]*janitorstate.Leftover)
+	for _, l := range report.Leftovers {
+		found[l.Path] = l
+	}
+	c.Assert(found, HasLen, 5)
+	c.Check(found[paths["orphaned"]].Kind, Equals, janitorstate.OrphanedData)
+	c.Check(found[paths["orphaned"]].Snap, Equals, "removed")
+	c.Check(found[paths["data-symlink"]].Kind, Equals, janitorstate.BrokenCurrentSymlink)
+	c.Check(found[paths["data-symlink"]].Snap, Equals, "recent")
+	c.Check(found[paths["mount-symlink"]].Kind, Equals, janitorstate.BrokenCurrentSymlink)
+	c.Check(found[paths["blob"]].Kind, Equals, janitorstate.StaleCacheBlob)
+	c.Check(found[paths["mount-unit"]].Kind, Equals, janitorstate.DanglingMountUnit)
+	c.Check(found[paths["mount-unit"]].Snap, Equals, "installed")
+	c.Check(found[paths["mount-unit"]].MountPoint, Equals, filepath.Join(dirs.StripRootDir(dirs.SnapMountDir), "installed", "4"))
+
+	current, err := janitorstate.CurrentReport(s.st)
+	c.Assert(err, IsNil)
+	c.Check(current.Leftovers, HasLen, 5)
+}
+
+func (s *janitorSuite) TestScanNothing(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	report, err := janitorstate.CurrentReport(s.st)
+	c.Assert(err, IsNil)
+	c.Check(report, IsNil)
+
+	report, err = janitorstate.Scan(s.st)
+	c.Assert(err, IsNil)
+	c.Check(report.Leftovers, HasLen, 0)
+}
+
+func (s *janitorSuite) TestEnsureScansPeriodically(c *C) {
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	s.st.Lock()
+	report, err := janitorstate.CurrentReport(s.st)
+	s.st.Unlock()
+	c.Assert(err, IsNil)
+	c.Assert(report, NotNil)
+	c.Check(report.Leftovers, HasLen, 0)
+
+	s.mkdir(c, filepath.Join(dirs.SnapDataDir, "removed"), s.old)
+
+	// not yet time to scan again
+	s.now = s.now.Add(time.Hour)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.st.Lock()
+	report, err = janitorstate.CurrentReport(s.st)
+	s.st.Unlock()
+	c.Assert(err, IsNil)
+	c.Check(report.Leftovers, HasLen, 0)
+
+	s.now = s.now.Add(24 * time.Hour)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.st.Lock()
+	report, err = janitorstate.CurrentReport(s.st)
+	s.st.Unlock()
+	c.Assert(err, IsNil)
+	c.Check(report.Leftovers, HasLen, 1)
+	c.Check(report.Time.Equal(s.now), Equals, true)
+}
+
+func (s *janitorSuite) TestCleanUnknownPath(c *C) {
+	paths := s.setUpLeftovers(c)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	_, err := janitorstate.Clean(s.st, []string{paths["orphaned"]})
+	c.Check(err, ErrorMatches, `cannot clean ".*/removed": not a leftover found by the last scan`)
+
+	_, err = janitorstate.Scan(s.st)
+	c.Assert(err, IsNil)
+	_, err = janitorstate.Clean(s.st, []string{"/etc"})
+	c.Check(err, ErrorMatches, `cannot clean "/etc": not a leftover found by the last scan`)
+	_, err = janitorstate.Clean(s.st, nil)
+	c.Check(err, ErrorMatches, `cannot clean leftovers: no paths given`)
+}
+
+func (s *janitorSuite) TestClean(c *C) {
+	paths := s.setUpLeftovers(c)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	_, err := janitorstate.Scan(s.st)
+	c.Assert(err, IsNil)
+
+	approved := []string{paths["orphaned"], paths["blob"], paths["mount-unit"], paths["mount-symlink"]}
+	ts, err := janitorstate.Clean(s.st, approved)
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 1)
+	c.Check(ts.Tasks()[0].Kind(), Equals, "janitor-clean")
+	chg := s.st.NewChange("clean-leftovers", "...")
+	chg.AddAll(ts)
+
+	// meanwhile the removed snap is installed again
+	si := &snap.SideInfo{RealName: "removed", Revision: snap.R(1)}
+	snapstate.Set(s.st, "removed", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+	})
+
+	s.st.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.st.Lock()
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus)
+	c.Check(paths["orphaned"], testutil.FilePresent)
+	c.Check(paths["blob"], testutil.FileAbsent)
+	c.Check(paths["mount-symlink"], testutil.FileAbsent)
+	c.Check(s.removedMounts, DeepEquals, []string{filepath.Join(dirs.SnapMountDir, "installed", "4")})
+	c.Check(chg.Tasks()[0].Log(), HasLen, 4)
+
+	// the report was refreshed
+	report, err := janitorstate.CurrentReport(s.st)
+	c.Assert(err, IsNil)
+	var left []string
+	for _, l := range report.Leftovers {
+		left = append(left, l.Path)
+	}
+	// sorted by path
+	c.Check(left, DeepEquals, []string{paths["mount-unit"], paths["data-symlink"]})
+}
+
+func (s *janitorSuite) TestScanSkipsSnapsWithChangesInProgress(c *C) {
+	paths := s.setUpLeftovers(c)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	// revision 4 of installed is being installed, and removed is
+	// being installed again
+	chg := s.st.NewChange("refresh-snap", "...")
+	for _, name := range []string{"installed", "removed"} {
+		t := s.st.NewTask("mount-snap", "...")
+		t.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo: &snap.SideInfo{RealName: name, Revision: snap.R(4)},
+		})
+		chg.AddTask(t)
+	}
+
+	report, err := janitorstate.Scan(s.st)
+	c.Assert(err, IsNil)
+	var found []string
+	for _, l := range report.Leftovers {
+		found = append(found, l.Path)
+	}
+	c.Check(found, DeepEquals, []string{paths["blob"], paths["mount-symlink"], paths["data-symlink"]})
+
+	// nothing is skipped once the change is done
+	chg.SetStatus(state.DoneStatus)
+	report, err = janitorstate.Scan(s.st)
+	c.Assert(err, IsNil)
+	c.Check(report.Leftovers, HasLen, 5)
+}
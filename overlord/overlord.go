@@ -28,6 +28,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -41,12 +42,15 @@ import (
 	"github.com/snapcore/snapd/overlord/cmdstate"
 	"github.com/snapcore/snapd/overlord/confdbstate"
 	"github.com/snapcore/snapd/overlord/configstate"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/configstate/proxyconf"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/fdestate"
 	"github.com/snapcore/snapd/overlord/healthstate"
+	"github.com/snapcore/snapd/overlord/historystate"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/janitorstate"
 	"github.com/snapcore/snapd/overlord/patch"
 	"github.com/snapcore/snapd/overlord/restart"
 	"github.com/snapcore/snapd/overlord/servicestate"
@@ -58,8 +62,10 @@ import (
 	_ "github.com/snapcore/snapd/overlord/snapstate/agentnotify"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/overlord/storecontext"
+	"github.com/snapcore/snapd/overlord/webhookstate"
 	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/timings"
 )
@@ -190,6 +196,8 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 	o.addManager(cmdstate.Manager(s, o.runner))
 	o.addManager(snapshotstate.Manager(s, o.runner))
 	o.addManager(confdbstate.Manager(s, hookMgr, o.runner))
+	o.addManager(webhookstate.Manager(s))
+	o.addManager(janitorstate.Manager(s, o.runner))
 
 	if err := configstateInit(s, hookMgr); err != nil {
 		return nil, err
@@ -201,6 +209,8 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 
 	s.Lock()
 	defer s.Unlock()
+	historystate.Init(s)
+
 	// setting up the store
 	o.proxyConf = proxyconf.New(s).Conf
 	storeCtx := storecontext.New(s, o.deviceMgr.StoreContextBackend())
@@ -288,6 +298,11 @@ func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handl
 	}
 	logger.Noticef("Acquired state lock file")
 
+	restoreErr := applyStagedStateRestore()
+	if restoreErr != nil {
+		logger.Noticef("Cannot restore state backup: %v", restoreErr)
+	}
+
 	curBootID, err := osutil.BootID()
 	if err != nil {
 		return nil, nil, fmt.Errorf("fatal: cannot find current boot id: %v", err)
@@ -308,6 +323,7 @@ func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handl
 			return nil, nil, err
 		}
 		patch.Init(s)
+		warnStateRestoreFailed(s, restoreErr)
 		return s, restartMgr, nil
 	}
 
@@ -338,9 +354,21 @@ func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handl
 	if err != nil {
 		return nil, nil, err
 	}
+	warnStateRestoreFailed(s, restoreErr)
 	return s, restartMgr, nil
 }
 
+// warnStateRestoreFailed adds a warning if restoring the staged state
+// backup failed, as the staged backup is gone by then.
+func warnStateRestoreFailed(s *state.State, err error) {
+	if err == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.Warnf("cannot restore state backup, the previous state was kept: %v", err)
+}
+
 func initRestart(s *state.State, curBootID string, restartHandler restart.Handler) (*restart.RestartManager, error) {
 	s.Lock()
 	defer s.Unlock()
@@ -350,11 +378,39 @@ func initRestart(s *state.State, curBootID string, restartHandler restart.Handle
 func (o *Overlord) newStoreWithContext(storeCtx store.DeviceAndAuthContext) snapstate.StoreService {
 	cfg := store.DefaultConfig()
 	cfg.Proxy = o.proxyConf
+	cfg.CacheMaxSize = o.downloadCacheMaxSize
 	sto := storeNew(cfg, storeCtx)
 	sto.SetCacheDownloads(defaultCachedDownloads)
 	return sto
 }
 
+// downloadCacheMaxSize returns the maximum size of the download cache as
+// configured with the store.cache.max-size system option, zero meaning no
+// limit.
+func (o *Overlord) downloadCacheMaxSize() int64 {
+	st := o.State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	st.Unlock()
+
+	var value string
+	if err := tr.Get("core", "store.cache.max-size", &value); err != nil {
+		if !config.IsNoOption(err) {
+			logger.Noticef("cannot get store.cache.max-size: %v", err)
+		}
+		return 0
+	}
+	if value == "" {
+		return 0
+	}
+	maxSize, err := strutil.ParseByteSize(value)
+	if err != nil {
+		logger.Noticef("cannot parse store.cache.max-size: %v", err)
+		return 0
+	}
+	return maxSize
+}
+
 // newStore can make new stores for use during remodeling.
 // The device backend will tie them to the remodeling device state.
 func (o *Overlord) newStore(devBE storecontext.DeviceBackend) snapstate.StoreService {
@@ -498,13 +554,60 @@ func (o *Overlord) Loop() {
 				}
 				st := o.State()
 				st.Lock()
-				st.Prune(o.startOfOperationTime, pruneWait, abortWait, pruneMaxChanges)
+				maxAge, abortAfter, maxChanges := prunePolicy(st)
+				st.Prune(o.startOfOperationTime, maxAge, abortAfter, maxChanges)
 				st.Unlock()
 			}
 		}
 	})
 }
 
+// prunePolicy returns how long ready changes are kept, after how long
+// changes that are not ready are aborted and how many ready changes are
+// kept at most, as configured with the state.prune.* system options, or
+// the defaults.
+func prunePolicy(st *state.State) (maxAge, abortAfter time.Duration, maxChanges int) {
+	maxAge, abortAfter, maxChanges = pruneWait, abortWait, pruneMaxChanges
+
+	tr := config.NewTransaction(st)
+	for _, opt := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"state.prune.max-age", &maxAge},
+		{"state.prune.abort-after", &abortAfter},
+	} {
+		var value string
+		if err := tr.Get("core", opt.name, &value); err != nil {
+			if !config.IsNoOption(err) {
+				logger.Noticef("cannot get %s: %v", opt.name, err)
+			}
+			continue
+		}
+		dur, err := time.ParseDuration(value)
+		if err != nil {
+			logger.Noticef("cannot parse %s: %v", opt.name, err)
+			continue
+		}
+		*opt.value = dur
+	}
+
+	// the maximum may have been set either as a string or as a number
+	var rawMax any
+	if err := tr.Get("core", "state.prune.max-changes", &rawMax); err != nil {
+		if !config.IsNoOption(err) {
+			logger.Noticef("cannot get state.prune.max-changes: %v", err)
+		}
+		return maxAge, abortAfter, maxChanges
+	}
+	n, err := strconv.Atoi(fmt.Sprintf("%v", rawMax))
+	if err != nil || n < 1 {
+		logger.Noticef("invalid state.prune.max-changes %v", rawMax)
+		return maxAge, abortAfter, maxChanges
+	}
+	return maxAge, abortAfter, n
+}
+
 func (o *Overlord) ensureDidRun() {
 	atomic.StoreInt32(&o.ensureRun, 1)
 }
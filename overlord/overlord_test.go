@@ -41,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/dirs/dirstest"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate"
@@ -166,6 +167,28 @@ func (ovs *overlordSuite) TestNewStore(c *C) {
 	c.Check(sto.(*store.Store).CacheDownloads(), Equals, 5)
 }
 
+func (ovs *overlordSuite) TestDownloadCacheMaxSize(c *C) {
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+	c.Check(o.DownloadCacheMaxSize(), Equals, int64(0))
+
+	st := o.State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "store.cache.max-size", "2GB"), IsNil)
+	tr.Commit()
+	st.Unlock()
+	c.Check(o.DownloadCacheMaxSize(), Equals, int64(2*1000*1000*1000))
+
+	// invalid values are ignored
+	st.Lock()
+	tr = config.NewTransaction(st)
+	c.Assert(tr.Set("core", "store.cache.max-size", "lots"), IsNil)
+	tr.Commit()
+	st.Unlock()
+	c.Check(o.DownloadCacheMaxSize(), Equals, int64(0))
+}
+
 func (ovs *overlordSuite) TestNewWithGoodState(c *C) {
 	// ensure we don't write state load timing in the state on really
 	// slow architectures (e.g. risc-v)
@@ -681,6 +704,72 @@ func (ovs *overlordSuite) TestEnsureLoopPruneRunsMultipleTimes(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (ovs *overlordSuite) TestEnsureLoopPruneConfiguredMaxChanges(c *C) {
+	o := overlord.Mock()
+
+	st := o.State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "state.prune.max-changes", 1), IsNil)
+	tr.Commit()
+	for i := 0; i < 3; i++ {
+		t := st.NewTask("foo", "...")
+		chg := st.NewChange("foo", "...")
+		chg.AddTask(t)
+		t.SetStatus(state.DoneStatus)
+	}
+	st.Unlock()
+
+	w, restoreTicker := fakePruneTicker()
+	defer restoreTicker()
+
+	o.Loop()
+	w.tick(2)
+
+	st.Lock()
+	c.Check(st.Changes(), HasLen, 1)
+	st.Unlock()
+
+	err := o.Stop()
+	c.Assert(err, IsNil)
+}
+
+func (ovs *overlordSuite) TestPrunePolicy(c *C) {
+	restoreIntv := overlord.MockPruneInterval(time.Minute, 24*time.Hour, 72*time.Hour)
+	defer restoreIntv()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	maxAge, abortAfter, maxChanges := overlord.PrunePolicy(st)
+	c.Check(maxAge, Equals, 24*time.Hour)
+	c.Check(abortAfter, Equals, 72*time.Hour)
+	c.Check(maxChanges, Equals, 500)
+
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "state.prune.max-age", "2160h"), IsNil)
+	c.Assert(tr.Set("core", "state.prune.abort-after", "168h"), IsNil)
+	c.Assert(tr.Set("core", "state.prune.max-changes", "5000"), IsNil)
+	tr.Commit()
+
+	maxAge, abortAfter, maxChanges = overlord.PrunePolicy(st)
+	c.Check(maxAge, Equals, 90*24*time.Hour)
+	c.Check(abortAfter, Equals, 7*24*time.Hour)
+	c.Check(maxChanges, Equals, 5000)
+
+	// invalid values are ignored
+	tr = config.NewTransaction(st)
+	c.Assert(tr.Set("core", "state.prune.max-age", "forever"), IsNil)
+	c.Assert(tr.Set("core", "state.prune.max-changes", "many"), IsNil)
+	tr.Commit()
+
+	maxAge, abortAfter, maxChanges = overlord.PrunePolicy(st)
+	c.Check(maxAge, Equals, 24*time.Hour)
+	c.Check(abortAfter, Equals, 7*24*time.Hour)
+	c.Check(maxChanges, Equals, 500)
+}
+
 func (ovs *overlordSuite) TestOverlordStartUpSetsStartOfOperation(c *C) {
 	restoreIntv := overlord.MockPruneInterval(100*time.Millisecond, 1000*time.Millisecond, 1*time.Hour)
 	defer restoreIntv()
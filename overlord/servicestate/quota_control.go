@@ -347,6 +347,57 @@ func UpdateQuota(st *state.State, name string, updateOpts UpdateQuotaOptions) (*
 	return ts, nil
 }
 
+// MoveSnapsToQuota moves the given snaps from the quota groups they are
+// currently in, if any, to the specified quota group. The services of the
+// snaps are rewritten to use the slice of the new group, and only the
+// services that are running are restarted to move them over.
+func MoveSnapsToQuota(st *state.State, name string, snaps []string) (*state.TaskSet, error) {
+	if err := quotaGroupsAvailable(st); err != nil {
+		return nil, err
+	}
+
+	if len(snaps) == 0 {
+		return nil, fmt.Errorf("cannot move snaps to quota group %q: no snaps specified", name)
+	}
+
+	allGrps, err := AllQuotas(st)
+	if err != nil {
+		return nil, err
+	}
+
+	grp, ok := allGrps[name]
+	if !ok {
+		return nil, fmt.Errorf("group %q does not exist", name)
+	}
+
+	srcGrps, err := validateSnapsForMovingToGroup(st, snaps, grp, allGrps)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckQuotaChangeConflictMany(st, append([]string{name}, srcGrps...)); err != nil {
+		return nil, err
+	}
+	if err := snapstate.CheckChangeConflictMany(st, snaps, ""); err != nil {
+		return nil, err
+	}
+
+	qc := QuotaControlAction{
+		Action:    "move",
+		QuotaName: name,
+		AddSnaps:  snaps,
+	}
+
+	ts := state.NewTaskSet()
+
+	summary := fmt.Sprintf("Move snaps to quota group %q", name)
+	task := st.NewTask("quota-control", summary)
+	task.Set("quota-control-actions", []QuotaControlAction{qc})
+	ts.AddTask(task)
+
+	return ts, nil
+}
+
 // remove a string item at index i from the string slice,
 // it maintains the ordering of the original slice.
 func remove(slice []string, i int) []string {
@@ -846,6 +846,54 @@ func (s *quotaControlSuite) TestUpdateQuotaPrecond(c *C) {
 	}
 }
 
+func (s *quotaControlSuite) TestMoveSnapsToQuotaPrecond(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(s.state, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
+
+	quotaConstraints := quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB * 2).Build()
+	err := servicestatetest.MockQuotaInState(st, "foo", "", []string{"test-snap"}, nil, quotaConstraints)
+	c.Assert(err, IsNil)
+	err = servicestatetest.MockQuotaInState(st, "bar", "", nil, nil, quotaConstraints)
+	c.Assert(err, IsNil)
+	err = servicestatetest.MockQuotaInState(st, "baz", "bar", nil, nil, quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build())
+	c.Assert(err, IsNil)
+
+	tests := []struct {
+		name  string
+		snaps []string
+		err   string
+	}{
+		{"what", []string{"test-snap"}, `group "what" does not exist`},
+		{"bar", nil, `cannot move snaps to quota group "bar": no snaps specified`},
+		{"bar", []string{"test-snap"}, `cannot move snaps to group "bar": group has sub-groups`},
+		{"baz", []string{"other-snap"}, `cannot use snap "other-snap" in group "baz": snap "other-snap" is not installed`},
+		{"foo", []string{"test-snap"}, `cannot move snap "test-snap": snap already in quota group "foo"`},
+	}
+
+	for _, t := range tests {
+		_, err := servicestate.MoveSnapsToQuota(st, t.name, t.snaps)
+		c.Check(err, ErrorMatches, t.err)
+	}
+
+	ts, err := servicestate.MoveSnapsToQuota(st, "baz", []string{"test-snap"})
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 1)
+	task := ts.Tasks()[0]
+	c.Check(task.Kind(), Equals, "quota-control")
+	c.Check(task.Summary(), Equals, `Move snaps to quota group "baz"`)
+	var qcs []servicestate.QuotaControlAction
+	c.Assert(task.Get("quota-control-actions", &qcs), IsNil)
+	c.Check(qcs, DeepEquals, []servicestate.QuotaControlAction{{
+		Action:    "move",
+		QuotaName: "baz",
+		AddSnaps:  []string{"test-snap"},
+	}})
+}
+
 func (s *quotaControlSuite) TestRemoveQuotaPrecond(c *C) {
 	st := s.state
 	st.Lock()
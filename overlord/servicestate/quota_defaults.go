@@ -0,0 +1,162 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/snapdenv"
+)
+
+// QuotaAssignmentGadget is the provenance of snaps placed in a quota group
+// because the gadget declares it as their default group.
+const QuotaAssignmentGadget = "gadget"
+
+func init() {
+	snapstate.AddSnapToDefaultQuotaGroup = AddSnapToDefaultQuotaGroup
+}
+
+// gadgetQuotaGroupForSnap returns the quota group the gadget of the device
+// declares for the snap with the given ID, or nil if there is none.
+func gadgetQuotaGroupForSnap(st *state.State, deviceCtx snapstate.DeviceContext, snapID string) (*gadget.QuotaGroup, error) {
+	gadgetSnap, err := snapstate.GadgetInfo(st, deviceCtx)
+	if err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// no constraints enforced: those should have been checked before already
+	gadgetInfo, err := gadget.ReadInfo(gadgetSnap.MountDir(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return gadgetInfo.QuotaGroupForSnap(snapID), nil
+}
+
+func gadgetQuotaResources(grp *gadget.QuotaGroup) quota.Resources {
+	builder := quota.NewResourcesBuilder()
+	if grp.Memory != 0 {
+		builder.WithMemoryLimit(grp.Memory)
+	}
+	if grp.CPUPercentage != 0 {
+		builder.WithCPUPercentage(grp.CPUPercentage)
+	}
+	if grp.Threads != 0 {
+		builder.WithThreadLimit(grp.Threads)
+	}
+	return builder.Build()
+}
+
+// AddSnapToDefaultQuotaGroup returns a task placing the snap being installed
+// in the quota group declared for it by the gadget, creating the group with
+// the declared limits if it does not exist yet. It returns nil if the gadget
+// declares no group for the snap, or if quota groups cannot be used.
+func AddSnapToDefaultQuotaGroup(st *state.State, deviceCtx snapstate.DeviceContext, snapsup *snapstate.SnapSetup) (*state.Task, error) {
+	if snapsup.SideInfo == nil || snapsup.SideInfo.SnapID == "" || snapsup.Type != snap.TypeApp {
+		return nil, nil
+	}
+	if snapdenv.Preseeding() {
+		return nil, nil
+	}
+
+	grp, err := gadgetQuotaGroupForSnap(st, deviceCtx, snapsup.SideInfo.SnapID)
+	if err != nil || grp == nil {
+		return nil, err
+	}
+
+	allGrps, err := AllQuotas(st)
+	if err != nil {
+		return nil, err
+	}
+	limits := gadgetQuotaResources(grp)
+	_, exists := allGrps[grp.Name]
+	if !exists {
+		if err := verifyQuotaRequirements(st, limits); err != nil {
+			logger.Noticef("cannot create default quota group %q for snap %q: %v", grp.Name, snapsup.InstanceName(), err)
+			return nil, nil
+		}
+	}
+
+	if err := CheckQuotaChangeConflictMany(st, []string{grp.Name}); err != nil {
+		return nil, err
+	}
+
+	t := st.NewTask("quota-add-snap", fmt.Sprintf(i18n.G("Add snap %q to default quota group %q"),
+		snapsup.InstanceName(), grp.Name))
+	t.Set("quota-name", grp.Name)
+	t.Set("quota-provenance", QuotaAssignmentGadget)
+	if !exists {
+		t.Set("quota-create-limits", limits)
+	}
+	return t, nil
+}
+
+// QuotaGroupAssignments returns the provenance of the snaps of the given
+// quota group that were not placed in it explicitly, keyed by snap instance
+// name.
+func QuotaGroupAssignments(st *state.State, grp *quota.Group) (map[string]string, error) {
+	var assignments map[string]string
+	if err := st.Get("default-quota-assignments", &assignments); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+
+	var result map[string]string
+	for _, snapName := range grp.Snaps {
+		if assignments[snapName] != grp.Name {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[snapName] = QuotaAssignmentGadget
+	}
+	return result, nil
+}
+
+// setDefaultQuotaAssignment records, or clears if the group is empty, the
+// default quota group the given snap was placed in.
+func setDefaultQuotaAssignment(st *state.State, snapName, group string) error {
+	var assignments map[string]string
+	if err := st.Get("default-quota-assignments", &assignments); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if group == "" {
+		if _, ok := assignments[snapName]; !ok {
+			return nil
+		}
+		delete(assignments, snapName)
+	} else {
+		if assignments == nil {
+			assignments = make(map[string]string)
+		}
+		assignments[snapName] = group
+	}
+	st.Set("default-quota-assignments", assignments)
+	return nil
+}
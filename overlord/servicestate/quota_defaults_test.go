@@ -0,0 +1,189 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+const gadgetWithQuotasYaml = `
+quotas:
+  - name: foo
+    memory: 1G
+    snaps:
+      - testsnapidtestsnapidtestsnapid01
+`
+
+func (s *quotaHandlersSuite) mockGadgetWithQuotas(c *C) {
+	si := &snap.SideInfo{RealName: "pc", Revision: snap.R(1), SnapID: "pc-id"}
+	snaptest.MockSnapWithFiles(c, "name: pc\ntype: gadget\nversion: 1.0", si, [][]string{
+		{"meta/gadget.yaml", gadgetWithQuotasYaml},
+	})
+	snapstate.Set(s.state, "pc", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+		SnapType: "gadget",
+	})
+}
+
+func (s *quotaHandlersSuite) TestAddSnapToDefaultQuotaGroup(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	s.mockGadgetWithQuotas(c)
+	deviceCtx := &snapstatetest.TrivialDeviceContext{DeviceModel: s.uc18Model}
+
+	snapsup := &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1), SnapID: "testsnapidtestsnapidtestsnapid01"},
+		Type:     snap.TypeApp,
+	}
+	t, err := servicestate.AddSnapToDefaultQuotaGroup(st, deviceCtx, snapsup)
+	c.Assert(err, IsNil)
+	c.Assert(t, NotNil)
+	c.Check(t.Kind(), Equals, "quota-add-snap")
+	c.Check(t.Summary(), Equals, `Add snap "test-snap" to default quota group "foo"`)
+
+	var quotaName, provenance string
+	c.Assert(t.Get("quota-name", &quotaName), IsNil)
+	c.Check(quotaName, Equals, "foo")
+	c.Assert(t.Get("quota-provenance", &provenance), IsNil)
+	c.Check(provenance, Equals, "gadget")
+	var limits quota.Resources
+	c.Assert(t.Get("quota-create-limits", &limits), IsNil)
+	c.Check(limits, DeepEquals, quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build())
+
+	// the group is not created again once it exists
+	err = servicestatetest.MockQuotaInState(st, "foo", "", nil, nil, quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build())
+	c.Assert(err, IsNil)
+	t, err = servicestate.AddSnapToDefaultQuotaGroup(st, deviceCtx, snapsup)
+	c.Assert(err, IsNil)
+	c.Assert(t, NotNil)
+	c.Check(t.Has("quota-create-limits"), Equals, false)
+}
+
+func (s *quotaHandlersSuite) TestAddSnapToDefaultQuotaGroupNoGroup(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	s.mockGadgetWithQuotas(c)
+	deviceCtx := &snapstatetest.TrivialDeviceContext{DeviceModel: s.uc18Model}
+
+	for _, snapsup := range []*snapstate.SnapSetup{
+		// not declared by the gadget
+		{SideInfo: &snap.SideInfo{RealName: "other-snap", Revision: snap.R(1), SnapID: "othersnapidothersnapidothersnap1"}, Type: snap.TypeApp},
+		// no snap ID
+		{SideInfo: &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1)}, Type: snap.TypeApp},
+		// not an application
+		{SideInfo: &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1), SnapID: "testsnapidtestsnapidtestsnapid01"}, Type: snap.TypeBase},
+	} {
+		t, err := servicestate.AddSnapToDefaultQuotaGroup(st, deviceCtx, snapsup)
+		c.Assert(err, IsNil)
+		c.Check(t, IsNil, Commentf("%s", snapsup.InstanceName()))
+	}
+}
+
+func (s *quotaHandlersSuite) TestAddSnapToDefaultQuotaGroupUsesDeviceContext(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	s.mockGadgetWithQuotas(c)
+
+	// the model of the device context uses another gadget, which is
+	// not installed
+	otherModel := assertstest.FakeAssertion(map[string]any{
+		"type":         "model",
+		"authority-id": "canonical",
+		"series":       "16",
+		"brand-id":     "canonical",
+		"model":        "other-pc",
+		"gadget":       "other-pc",
+		"kernel":       "kernel",
+		"architecture": "amd64",
+		"base":         "core18",
+	}).(*asserts.Model)
+	deviceCtx := &snapstatetest.TrivialDeviceContext{DeviceModel: otherModel}
+
+	snapsup := &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1), SnapID: "testsnapidtestsnapidtestsnapid01"},
+		Type:     snap.TypeApp,
+	}
+	t, err := servicestate.AddSnapToDefaultQuotaGroup(st, deviceCtx, snapsup)
+	c.Assert(err, IsNil)
+	c.Check(t, IsNil)
+}
+
+func (s *quotaHandlersSuite) TestDoQuotaAddSnapCreatesDefaultGroup(c *C) {
+	r := s.mockSystemctlCalls(c, join(
+		[]expectedSystemctl{{expArgs: []string{"daemon-reload"}}},
+		systemctlCallsForSliceStart("foo"),
+	))
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(s.state, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
+
+	task := st.NewTask("quota-add-snap", "test")
+	task.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "test-snap", Revision: snap.R(42)},
+	})
+	task.Set("quota-name", "foo")
+	task.Set("quota-provenance", "gadget")
+	task.Set("quota-create-limits", quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build())
+
+	st.Unlock()
+	err := s.mgr.DoQuotaAddSnap(task, nil)
+	st.Lock()
+	c.Assert(err, IsNil)
+
+	checkQuotaState(c, st, map[string]quotaGroupState{
+		"foo": {
+			ResourceLimits: quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build(),
+			Snaps:          []string{"test-snap"},
+		},
+	})
+	var created bool
+	c.Assert(task.Get("quota-created", &created), IsNil)
+	c.Check(created, Equals, true)
+
+	grp, err := servicestate.GetQuota(st, "foo")
+	c.Assert(err, IsNil)
+	assignments, err := servicestate.QuotaGroupAssignments(st, grp)
+	c.Assert(err, IsNil)
+	c.Check(assignments, DeepEquals, map[string]string{"test-snap": "gadget"})
+}
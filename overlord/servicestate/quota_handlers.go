@@ -50,11 +50,12 @@ type QuotaControlAction struct {
 	QuotaName string `json:"quota-name,omitempty"`
 
 	// Action is the action being taken on the quota group. It can be either
-	// "create", "update", or "remove".
+	// "create", "update", "move" or "remove".
 	Action string `json:"action,omitempty"`
 
-	// AddSnaps is the set of snaps to add to the quota group, valid for either
-	// the "update" or the "create" actions.
+	// AddSnaps is the set of snaps to add to the quota group, valid for the
+	// "update", "create" and "move" actions. For the latter the snaps are
+	// removed from the groups they are currently in.
 	AddSnaps []string `json:"snaps,omitempty"`
 
 	// AddServices is the set of services to add to the quota group, valid for either
@@ -118,6 +119,8 @@ func (m *ServiceManager) doQuotaControl(t *state.Task, _ *tomb.Tomb) error {
 			grp, allGrps, data.RefreshProfiles, err = quotaRemove(st, qc, allGrps)
 		case "update":
 			grp, allGrps, data.RefreshProfiles, err = quotaUpdate(st, qc, allGrps)
+		case "move":
+			grp, allGrps, data.RefreshProfiles, err = quotaMove(st, qc, allGrps)
 		default:
 			return fmt.Errorf("unknown action %q requested", qc.Action)
 		}
@@ -254,15 +257,43 @@ func (m *ServiceManager) doQuotaAddSnap(t *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
+	// default quota groups declared by the gadget are created along with
+	// their first snap
+	var createLimits *quota.Resources
+	if err := t.Get("quota-create-limits", &createLimits); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+
 	qc := QuotaControlAction{
 		Action:    "update",
 		QuotaName: quotaName,
 		AddSnaps:  []string{snapsup.InstanceName()},
 	}
-	grp, allGrps, _, err := quotaUpdate(st, qc, allGrps)
-	if err != nil {
+	var grp *quota.Group
+	if _, exists := allGrps[quotaName]; !exists && createLimits != nil {
+		qc.Action = "create"
+		qc.ResourceLimits = *createLimits
+		grp, allGrps, _, err = quotaCreate(st, qc, allGrps)
+		if err != nil {
+			return err
+		}
+		t.Set("quota-created", true)
+	} else {
+		grp, allGrps, _, err = quotaUpdate(st, qc, allGrps)
+		if err != nil {
+			return err
+		}
+	}
+
+	var provenance string
+	if err := t.Get("quota-provenance", &provenance); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
 	}
+	if provenance != "" {
+		if err := setDefaultQuotaAssignment(st, snapsup.InstanceName(), quotaName); err != nil {
+			return err
+		}
+	}
 
 	// ensure service and slices on disk and their states are updated
 	opts := &ensureSnapServicesForGroupOptions{
@@ -302,7 +333,39 @@ func (m *ServiceManager) undoQuotaAddSnap(t *state.Task, _ *tomb.Tomb) error {
 	if err := EnsureSnapAbsentFromQuota(st, snapsup.InstanceName()); err != nil {
 		return err
 	}
-	return nil
+	if err := setDefaultQuotaAssignment(st, snapsup.InstanceName(), ""); err != nil {
+		return err
+	}
+
+	var created bool
+	if err := t.Get("quota-created", &created); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if !created {
+		return nil
+	}
+
+	// remove the default quota group created for the snap
+	var quotaName string
+	if err := t.Get("quota-name", &quotaName); err != nil {
+		return fmt.Errorf("internal error: cannot get quota-name: %v", err)
+	}
+	allGrps, err := AllQuotas(st)
+	if err != nil {
+		return err
+	}
+	if grp, ok := allGrps[quotaName]; !ok || len(grp.Snaps) != 0 || len(grp.SubGroups) != 0 {
+		return nil
+	}
+	grp, allGrps, _, err := quotaRemove(st, QuotaControlAction{Action: "remove", QuotaName: quotaName}, allGrps)
+	if err != nil {
+		return err
+	}
+	opts := &ensureSnapServicesForGroupOptions{
+		allGrps: allGrps,
+	}
+	_, err = ensureSnapServicesForGroup(st, t, grp, opts)
+	return err
 }
 
 func quotaCreate(st *state.State, action QuotaControlAction, allGrps map[string]*quota.Group) (*quota.Group, map[string]*quota.Group, bool, error) {
@@ -501,6 +564,54 @@ func quotaUpdate(st *state.State, action QuotaControlAction, allGrps map[string]
 	return grp, allGrps, refreshProfiles, nil
 }
 
+func quotaMove(st *state.State, action QuotaControlAction, allGrps map[string]*quota.Group) (*quota.Group, map[string]*quota.Group, bool, error) {
+	// make sure the group exists
+	grp, ok := allGrps[action.QuotaName]
+	if !ok {
+		return nil, nil, false, fmt.Errorf("group %q does not exist", action.QuotaName)
+	}
+
+	if action.ParentName != "" || len(action.AddServices) != 0 || !action.ResourceLimits.Unset() {
+		return nil, nil, false, fmt.Errorf("internal error, only snaps can be used with move action")
+	}
+
+	if _, err := validateSnapsForMovingToGroup(st, action.AddSnaps, grp, allGrps); err != nil {
+		return nil, nil, false, err
+	}
+
+	// a change of journal namespace requires the mount profiles of the
+	// snaps to be refreshed
+	refreshProfiles := grp.JournalLimit != nil
+	modified := make(map[string]*quota.Group)
+	for _, sn := range action.AddSnaps {
+		for _, src := range allGrps {
+			for idx, name := range src.Snaps {
+				if name != sn {
+					continue
+				}
+				src.Snaps = remove(src.Snaps, idx)
+				modified[src.Name] = src
+				if src.JournalLimit != nil {
+					refreshProfiles = true
+				}
+				break
+			}
+		}
+	}
+	grp.Snaps = append(grp.Snaps, action.AddSnaps...)
+
+	grps := []*quota.Group{grp}
+	for _, src := range modified {
+		grps = append(grps, src)
+	}
+	allGrps, err := internal.PatchQuotas(st, grps...)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	return grp, allGrps, refreshProfiles, nil
+}
+
 type ensureSnapServicesForGroupOptions struct {
 	// allGrps is the updated set of quota groups
 	allGrps map[string]*quota.Group
@@ -678,8 +789,21 @@ func ensureSnapServicesForGroup(st *state.State, t *state.Task, grp *quota.Group
 			// be okay?
 
 		case "journald":
-			// this happens when a journal quota is either added, modified or removed, and
+			// If only the limits of an existing namespace have changed
+			// (i.e old and new not being empty) then restarting the journal
+			// for that namespace is enough for them to apply, the journal
+			// daemon keeps the log streams of the services open across
+			// restarts.
+			if old != "" && new != "" {
+				serviceName := fmt.Sprintf("systemd-journald@%s", grp.JournalNamespaceName())
+				journalsToRestart = append(journalsToRestart, serviceName)
+				return
+			}
+
+			// Otherwise the journal quota is either added or removed, and
 			// in this case we need to restart all services in the quota group
+			// so they log to the right namespace. The journal daemon will be
+			// started or stopped as a part of that.
 			for info := range snapSvcMap {
 				for _, app := range info.Apps {
 					if app.IsService() {
@@ -687,15 +811,6 @@ func ensureSnapServicesForGroup(st *state.State, t *state.Task, grp *quota.Group
 					}
 				}
 			}
-
-			// If the journal has changed (i.e old and new not being empty) then we
-			// need to restart the journal for that namespace. For other cases
-			// this is not necessary, as either the journal daemon will be started or
-			// stopped as a part of removal.
-			if old != "" && new != "" {
-				serviceName := fmt.Sprintf("systemd-journald@%s", grp.JournalNamespaceName())
-				journalsToRestart = append(journalsToRestart, serviceName)
-			}
 		}
 	}
 	if err := wrappers.EnsureSnapServices(snapSvcMap, ensureOpts, collectModifiedUnits, meterLocked); err != nil {
@@ -851,6 +966,48 @@ func validateSnapForAddingToGroup(st *state.State, snaps []string, group string,
 	return nil
 }
 
+// validateSnapsForMovingToGroup checks that the given snaps can be moved
+// into the given group and returns the names of the groups they are
+// currently in.
+func validateSnapsForMovingToGroup(st *state.State, snaps []string, grp *quota.Group, allGrps map[string]*quota.Group) ([]string, error) {
+	if err := groupEnsureOnlySnapsOrServices(snaps, nil, grp); err != nil {
+		return nil, err
+	}
+	if len(grp.SubGroups) > 0 {
+		return nil, fmt.Errorf("cannot move snaps to group %q: group has sub-groups", grp.Name)
+	}
+	if groupIsMixed(allGrps[grp.ParentGroup]) {
+		return nil, fmt.Errorf("cannot move snaps to group %q: only services are allowed in this sub-group", grp.Name)
+	}
+
+	var srcGrps []string
+	for _, name := range snaps {
+		if _, err := snapstate.CurrentInfo(st, name); err != nil {
+			return nil, fmt.Errorf("cannot use snap %q in group %q: %v", name, grp.Name, err)
+		}
+
+		for _, src := range allGrps {
+			if !strutil.ListContains(src.Snaps, name) {
+				continue
+			}
+			if src.Name == grp.Name {
+				return nil, fmt.Errorf("cannot move snap %q: snap already in quota group %q", name, grp.Name)
+			}
+			// services of the snap may have been placed in sub-groups,
+			// which would be left dangling by the move
+			for _, sub := range src.SubGroups {
+				for _, svc := range allGrps[sub].Services {
+					if strings.HasPrefix(svc, name+".") {
+						return nil, fmt.Errorf("cannot move snap %q: its service %q is in sub-group %q", name, svc, sub)
+					}
+				}
+			}
+			srcGrps = append(srcGrps, src.Name)
+		}
+	}
+	return srcGrps, nil
+}
+
 // splitSnapServiceName splits and verifies the snap service reference
 // taken in by the frontend. It expects the format snap.service
 func splitSnapServiceName(name string) (string, string, error) {
@@ -2380,6 +2380,57 @@ SystemMaxUse=16M
 	})
 }
 
+func (s *quotaHandlersSuite) TestUpdateJournalQuotaLimitsNoServiceRestart(c *C) {
+	r := s.mockSystemctlCalls(c, join(
+		// CreateQuota for foo
+		systemctlCallsForCreateQuota("foo", "test-snap"),
+
+		// the update of the limits only restarts the journal of the
+		// namespace, no unit is modified and the services keep running
+		[]expectedSystemctl{
+			{expArgs: []string{"stop", "systemd-journald@snap-foo"}},
+			{
+				expArgs: []string{"show", "--property=ActiveState", "systemd-journald@snap-foo"},
+				output:  "ActiveState=inactive",
+			},
+			{expArgs: []string{"start", "systemd-journald@snap-foo"}},
+		},
+	))
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	// setup the snap so it exists
+	snapstate.Set(s.state, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
+
+	qc := servicestate.QuotaControlAction{
+		Action:         "create",
+		QuotaName:      "foo",
+		ResourceLimits: quota.NewResourcesBuilder().WithJournalSize(16 * quantity.SizeMiB).Build(),
+		AddSnaps:       []string{"test-snap"},
+	}
+	err := s.callDoQuotaControl(&qc)
+	c.Assert(err, IsNil)
+
+	qc = servicestate.QuotaControlAction{
+		Action:         "update",
+		QuotaName:      "foo",
+		ResourceLimits: quota.NewResourcesBuilder().WithJournalSize(64 * quantity.SizeMiB).Build(),
+	}
+	err = s.callDoQuotaControl(&qc)
+	c.Assert(err, IsNil)
+
+	checkQuotaState(c, st, map[string]quotaGroupState{
+		"foo": {
+			ResourceLimits: quota.NewResourcesBuilder().WithJournalSize(64 * quantity.SizeMiB).Build(),
+			Snaps:          []string{"test-snap"},
+		},
+	})
+}
+
 func (s *quotaHandlersSuite) TestRemoveJournalQuota(c *C) {
 	r := s.mockSystemctlCalls(c, join(
 		// RemoveQuota for foo
@@ -2595,6 +2646,85 @@ func (s *quotaHandlersSuite) TestQuotaUpdateAddSnapAlreadyInOtherGroup(c *C) {
 	})
 }
 
+func (s *quotaHandlersSuite) TestQuotaMoveSnap(c *C) {
+	r := s.mockSystemctlCalls(c, join(
+		// CreateQuota for foo
+		systemctlCallsForCreateQuota("foo", "test-snap"),
+
+		// CreateQuota for foo2
+		systemctlCallsForCreateQuota("foo2", "test-snap2"),
+
+		// move of test-snap2 to foo, the slice already exists so only
+		// the services of test-snap2 are restarted
+		[]expectedSystemctl{{expArgs: []string{"daemon-reload"}}},
+		systemctlCallsForServiceRestart("test-snap2"),
+	))
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	// setup test-snap
+	snapstate.Set(s.state, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
+	// and test-snap2
+	si2 := &snap.SideInfo{RealName: "test-snap2", Revision: snap.R(42)}
+	snapst2 := &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si2}),
+		Current:  si2.Revision,
+		Active:   true,
+		SnapType: "app",
+	}
+	snapstate.Set(s.state, "test-snap2", snapst2)
+	snaptest.MockSnapCurrent(c, testYaml2, si2)
+
+	for _, qc := range []servicestate.QuotaControlAction{
+		{
+			Action:         "create",
+			QuotaName:      "foo",
+			ResourceLimits: quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build(),
+			AddSnaps:       []string{"test-snap"},
+		}, {
+			Action:         "create",
+			QuotaName:      "foo2",
+			ResourceLimits: quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build(),
+			AddSnaps:       []string{"test-snap2"},
+		},
+	} {
+		err := s.callDoQuotaControl(&qc)
+		c.Assert(err, IsNil)
+	}
+
+	// moving test-snap to the group it is already in fails
+	qc := servicestate.QuotaControlAction{
+		Action:    "move",
+		QuotaName: "foo",
+		AddSnaps:  []string{"test-snap"},
+	}
+	err := s.callDoQuotaControl(&qc)
+	c.Assert(err, ErrorMatches, `cannot move snap "test-snap": snap already in quota group "foo"`)
+
+	// move test-snap2 from foo2 to foo
+	qc = servicestate.QuotaControlAction{
+		Action:    "move",
+		QuotaName: "foo",
+		AddSnaps:  []string{"test-snap2"},
+	}
+	err = s.callDoQuotaControl(&qc)
+	c.Assert(err, IsNil)
+
+	checkQuotaState(c, st, map[string]quotaGroupState{
+		"foo": {
+			ResourceLimits: quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build(),
+			Snaps:          []string{"test-snap", "test-snap2"},
+		},
+		"foo2": {
+			ResourceLimits: quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build(),
+		},
+	})
+}
+
 func (s *quotaHandlersSuite) TestDoQuotaAddSnap(c *C) {
 	r := s.mockSystemctlCalls(c, join(
 		// CreateQuota for foo
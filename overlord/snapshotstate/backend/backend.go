@@ -301,8 +301,16 @@ func EstimateSnapshotSize(si *snap.Info, usernames []string, dirOpts *dirs.SnapD
 	return total, nil
 }
 
-// Save a snapshot
-func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, dynSnapshotOpts *snap.SnapshotOptions, dirOpts *dirs.SnapDirOptions) (*client.Snapshot, error) {
+// Save a snapshot. The data archives are compressed as described by comp,
+// or with gzip if comp is nil.
+func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, dynSnapshotOpts *snap.SnapshotOptions, dirOpts *dirs.SnapDirOptions, comp *Compression) (*client.Snapshot, error) {
+	if comp == nil {
+		comp = &Compression{Codec: CompressionGzip}
+	}
+	if err := ValidateCompression(comp.Codec, comp.Level); err != nil {
+		return nil, err
+	}
+
 	if err := os.MkdirAll(dirs.SnapshotsDir, 0700); err != nil {
 		return nil, err
 	}
@@ -322,6 +330,7 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, use
 		Size:     0,
 		Conf:     cfg,
 		// Note: Auto is no longer set in the Snapshot.
+		Compression: comp.Codec,
 	}
 
 	snapshotOptions, err := snapReadSnapshotYaml(si)
@@ -346,7 +355,7 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, use
 	defer w.Close() // note this does not close the file descriptor (that's done by hand on the atomic writer, above)
 	savingUserData := false
 	baseDataDir := snap.BaseDataDir(si.InstanceName())
-	if err := addSnapDirToZip(ctx, snapshot, w, "root", archiveName, baseDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
+	if err := addSnapDirToZip(ctx, snapshot, w, comp, "root", archiveName, baseDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
 		return nil, err
 	}
 
@@ -358,7 +367,7 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, use
 	savingUserData = true
 	for _, usr := range users {
 		snapDataDir := filepath.Dir(si.UserDataDir(usr.HomeDir, dirOpts))
-		if err := addSnapDirToZip(ctx, snapshot, w, usr.Username, userArchiveName(usr), snapDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
+		if err := addSnapDirToZip(ctx, snapshot, w, comp, usr.Username, userArchiveName(usr), snapDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
 			return nil, err
 		}
 	}
@@ -399,7 +408,7 @@ var isTesting = snapdenv.Testing()
 // addSnapDirToZip adds the 'common' and the 'rev' revisioned dir under 'snapDir'
 // to the snapshot. If one doesn't exist, it's ignored. If none exists, the
 // operation is skipped.
-func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry, snapDir string, savingUserData bool, excludePaths []string) error {
+func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, comp *Compression, username, entry, snapDir string, savingUserData bool, excludePaths []string) error {
 	paths, err := pathsForSnapshot(snapDir, snapshot)
 	if err != nil {
 		return err
@@ -442,12 +451,12 @@ func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writ
 		expExcludePaths = append(expExcludePaths, expandedPath)
 	}
 
-	return addToZip(ctx, snapshot, w, username, entry, paths, expExcludePaths)
+	return addToZip(ctx, snapshot, w, comp, username, entry, paths, expExcludePaths)
 }
 
 // addToZip adds 'paths' to the snapshot. tar will change into the paths' parent
 // directory before creating the archive so that parent dirs are not added.
-func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry string, paths []string, excludePaths []string) error {
+func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, comp *Compression, username, entry string, paths []string, excludePaths []string) error {
 	archiveWriter, err := w.CreateHeader(&zip.FileHeader{Name: entry})
 	if err != nil {
 		return err
@@ -455,11 +464,12 @@ func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, use
 
 	tarArgs := []string{
 		"--create",
-		"--sparse", "--gzip",
+		"--sparse",
 		"--format", "gnu",
 		"--anchored",
 		"--no-wildcards-match-slash",
 	}
+	tarArgs = append(tarArgs, comp.createArgs()...)
 
 	for _, path := range excludePaths {
 		tarArgs = append(tarArgs, fmt.Sprintf("--exclude=%s", path))
@@ -933,6 +943,10 @@ type SnapshotExport struct {
 
 	// cached size, needs to be calculated with CalculateSize
 	size int64
+
+	// modification time of the entries of the exported archive, fixed
+	// so that the archive can be reproduced byte for byte
+	modTime time.Time
 }
 
 // NewSnapshotExport will return a SnapshotExport structure. It must be
@@ -986,7 +1000,13 @@ func NewSnapshotExport(ctx context.Context, setID uint64) (se *SnapshotExport, e
 	if err != nil {
 		return nil, fmt.Errorf("cannot calculate content hash for snapshot export %v: %v", setID, err)
 	}
-	se = &SnapshotExport{snapshotFiles: snapshotFiles, setID: setID, contentHash: h}
+	se = &SnapshotExport{
+		snapshotFiles: snapshotFiles,
+		setID:         setID,
+		contentHash:   h,
+		// sub-second precision is not kept in the archive
+		modTime: timeNow().UTC().Truncate(time.Second),
+	}
 
 	// ensure we never leak FDs even if the user does not call close
 	runtime.SetFinalizer(se, (*SnapshotExport).Close)
@@ -1018,6 +1038,34 @@ func (se *SnapshotExport) Size() int64 {
 	return se.size
 }
 
+// SessionID identifies the exported archive. Exports of the same snapshot
+// set resumed with the same session ID produce the same archive, byte for
+// byte, which allows to resume interrupted transfers.
+func (se *SnapshotExport) SessionID() string {
+	return fmt.Sprintf("%d-%x", se.modTime.Unix(), se.contentHash)
+}
+
+// ResumeSession makes the export reproduce the archive identified by the
+// given session ID, as returned by SessionID. It fails if the session ID is
+// invalid or if the snapshot set changed since. It must be called before
+// Init.
+func (se *SnapshotExport) ResumeSession(sessionID string) error {
+	i := strings.LastIndex(sessionID, "-")
+	if i < 0 {
+		return fmt.Errorf("cannot resume export of snapshot set %v: invalid session %q", se.setID, sessionID)
+	}
+	secs, hash := sessionID[:i], sessionID[i+1:]
+	unix, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot resume export of snapshot set %v: invalid session %q", se.setID, sessionID)
+	}
+	if hash != fmt.Sprintf("%x", se.contentHash) {
+		return fmt.Errorf("cannot resume export of snapshot set %v: snapshot set changed", se.setID)
+	}
+	se.modTime = time.Unix(unix, 0).UTC()
+	return nil
+}
+
 func (se *SnapshotExport) Close() {
 	for _, f := range se.snapshotFiles {
 		f.Close()
@@ -1045,7 +1093,7 @@ func (se *SnapshotExport) StreamTo(w io.Writer) error {
 		Name:     "content.json",
 		Size:     int64(len(h)),
 		Mode:     0640,
-		ModTime:  timeNow(),
+		ModTime:  se.modTime,
 	}
 	if err := tw.WriteHeader(hdr); err != nil {
 		return err
@@ -1095,7 +1143,7 @@ func (se *SnapshotExport) StreamTo(w io.Writer) error {
 	// validate the archive is complete
 	meta := exportMetadata{
 		Format: 1,
-		Date:   timeNow(),
+		Date:   se.modTime,
 		Files:  files,
 	}
 	metaDataBuf, err := json.Marshal(&meta)
@@ -1107,7 +1155,7 @@ func (se *SnapshotExport) StreamTo(w io.Writer) error {
 		Name:     "export.json",
 		Size:     int64(len(metaDataBuf)),
 		Mode:     0640,
-		ModTime:  timeNow(),
+		ModTime:  se.modTime,
 	}
 	if err := tw.WriteHeader(hdr); err != nil {
 		return err
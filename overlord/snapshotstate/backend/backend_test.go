@@ -67,6 +67,8 @@ type noTestingSuite struct{ snapshotSuite }
 var _ = check.Suite(&isTestingSuite{snapshotSuite{isTesting: true}})
 var _ = check.Suite(&noTestingSuite{snapshotSuite{isTesting: false}})
 
+var gzipCompression = &backend.Compression{Codec: backend.CompressionGzip}
+
 // tie gocheck into testing
 func TestSnapshot(t *testing.T) { check.TestingT(t) }
 
@@ -450,7 +452,7 @@ func (s *snapshotSuite) TestIterSetIDoverride(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	cfg := map[string]any{"some-setting": false}
 
-	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, uint64(12))
 
@@ -568,8 +570,8 @@ func (s *snapshotSuite) TestAddDirToZipBails(c *check.C) {
 	defer restore()
 	savingUserData := false
 	// note as the zip is nil this would panic if it didn't bail
-	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, "", "an/entry", filepath.Join(s.root, "nonexistent"), savingUserData, nil), check.IsNil)
-	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, "", "an/entry", "/etc/passwd", savingUserData, nil), check.IsNil)
+	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, gzipCompression, "", "an/entry", filepath.Join(s.root, "nonexistent"), savingUserData, nil), check.IsNil)
+	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, gzipCompression, "", "an/entry", "/etc/passwd", savingUserData, nil), check.IsNil)
 	c.Check(buf.String(), check.Matches, "(?m).* is does not exist.*")
 }
 
@@ -585,7 +587,7 @@ func (s *snapshotSuite) TestAddDirToZipTarFails(c *check.C) {
 	var buf bytes.Buffer
 	z := zip.NewWriter(&buf)
 	savingUserData := false
-	c.Assert(backend.AddSnapDirToZip(ctx, &client.Snapshot{Revision: rev}, z, "", "an/entry", s.root, savingUserData, nil), check.ErrorMatches, ".* context canceled")
+	c.Assert(backend.AddSnapDirToZip(ctx, &client.Snapshot{Revision: rev}, z, gzipCompression, "", "an/entry", s.root, savingUserData, nil), check.ErrorMatches, ".* context canceled")
 }
 
 func (s *snapshotSuite) TestAddDirToZip(c *check.C) {
@@ -602,7 +604,7 @@ func (s *snapshotSuite) TestAddDirToZip(c *check.C) {
 		Revision: rev,
 	}
 	savingUserData := false
-	c.Assert(backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, savingUserData, nil), check.IsNil)
+	c.Assert(backend.AddSnapDirToZip(context.Background(), snapshot, z, gzipCompression, "", "an/entry", s.root, savingUserData, nil), check.IsNil)
 	z.Close() // write out the central directory
 
 	c.Check(snapshot.SHA3_384, check.HasLen, 1)
@@ -680,14 +682,137 @@ func (s *snapshotSuite) TestAddDirToZipExclusions(c *check.C) {
 	} {
 		testLabel := check.Commentf("%s/%v", testData.excludes, testData.savingUserData)
 
-		err := backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, testData.savingUserData, testData.excludes)
+		err := backend.AddSnapDirToZip(context.Background(), snapshot, z, gzipCompression, "", "an/entry", s.root, testData.savingUserData, testData.excludes)
 		c.Check(err, check.ErrorMatches, "tar failed.*")
 		c.Check(tarArgs, check.DeepEquals, testData.expectedArgs, testLabel)
 	}
 }
 
+func (s *snapshotSuite) TestAddDirToZipCompression(c *check.C) {
+	rev := snap.R(5)
+	c.Assert(os.MkdirAll(filepath.Join(s.root, rev.String()), 0755), check.IsNil)
+
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	defer z.Close()
+	snapshot := &client.Snapshot{
+		SHA3_384: map[string]string{},
+		Revision: rev,
+	}
+
+	var compArgs []string
+	restore := backend.MockTarAsUser(func(username string, args ...string) *exec.Cmd {
+		// only the compression arguments matter in this test
+		compArgs = nil
+		for _, arg := range args {
+			if arg == "--gzip" || arg == "--zstd" || strings.HasPrefix(arg, "--use-compress-program=") {
+				compArgs = append(compArgs, arg)
+			}
+		}
+		return exec.Command("false")
+	})
+	defer restore()
+
+	for _, tc := range []struct {
+		comp         *backend.Compression
+		expectedArgs []string
+	}{
+		{gzipCompression, []string{"--gzip"}},
+		{&backend.Compression{Codec: backend.CompressionGzip, Level: 1}, []string{"--use-compress-program=gzip -1"}},
+		{&backend.Compression{Codec: backend.CompressionZstd}, []string{"--zstd"}},
+		{&backend.Compression{Codec: backend.CompressionZstd, Level: 19}, []string{"--use-compress-program=zstd -19"}},
+		{&backend.Compression{Codec: backend.CompressionNone}, nil},
+	} {
+		err := backend.AddSnapDirToZip(context.Background(), snapshot, z, tc.comp, "", "an/entry", s.root, false, nil)
+		c.Check(err, check.ErrorMatches, "tar failed.*")
+		c.Check(compArgs, check.DeepEquals, tc.expectedArgs, check.Commentf("%+v", tc.comp))
+	}
+}
+
+func (s *snapshotSuite) TestValidateCompression(c *check.C) {
+	defer backend.MockExecLookPath(func(cmd string) (string, error) {
+		return "/usr/bin/" + cmd, nil
+	})()
+
+	for _, tc := range []struct {
+		codec string
+		level int
+		err   string
+	}{
+		{backend.CompressionGzip, 0, ""},
+		{backend.CompressionGzip, 9, ""},
+		{backend.CompressionZstd, 19, ""},
+		{backend.CompressionNone, 0, ""},
+		{backend.CompressionGzip, 10, "gzip compression level must be between 1 and 9"},
+		{backend.CompressionZstd, -1, "zstd compression level must be between 1 and 19"},
+		{backend.CompressionZstd, 20, "zstd compression level must be between 1 and 19"},
+		{backend.CompressionNone, 3, "cannot use a compression level without compression"},
+		{"lz4", 0, `unsupported snapshot compression "lz4"`},
+	} {
+		err := backend.ValidateCompression(tc.codec, tc.level)
+		if tc.err == "" {
+			c.Check(err, check.IsNil)
+		} else {
+			c.Check(err, check.ErrorMatches, tc.err)
+		}
+	}
+}
+
+func (s *snapshotSuite) TestValidateCompressionNoZstd(c *check.C) {
+	defer backend.MockExecLookPath(func(cmd string) (string, error) {
+		c.Check(cmd, check.Equals, "zstd")
+		return "", exec.ErrNotFound
+	})()
+
+	err := backend.ValidateCompression(backend.CompressionZstd, 0)
+	c.Check(err, check.ErrorMatches, "cannot use zstd compression: zstd is not installed")
+	c.Check(backend.ValidateCompression(backend.CompressionGzip, 0), check.IsNil)
+}
+
+func (s *snapshotSuite) TestSaveInvalidCompression(c *check.C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+
+	_, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, &backend.Compression{Codec: "lz4"})
+	c.Assert(err, check.ErrorMatches, `unsupported snapshot compression "lz4"`)
+	c.Check(filepath.Join(dirs.SnapshotsDir, "12_hello-snap_v1.33_42.zip"), testutil.FileAbsent)
+}
+
+func (s *snapshotSuite) TestRestoreUnsupportedCompression(c *check.C) {
+	if os.Geteuid() == 0 {
+		c.Skip("this test cannot run as root (runuser will fail)")
+	}
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+
+	shw, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+
+	// as if written by a newer snapd
+	shr.Compression = "lz4"
+	_, err = shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+	c.Assert(err, check.ErrorMatches, `cannot restore snapshot ".*": unsupported snapshot compression "lz4"`)
+}
+
 func (s *snapshotSuite) TestHappyRoundtrip(c *check.C) {
-	s.testHappyRoundtrip(c, "marker")
+	s.testHappyRoundtrip(c, "marker", nil)
+}
+
+func (s *snapshotSuite) TestHappyRoundtripNoCompression(c *check.C) {
+	s.testHappyRoundtrip(c, "marker", &backend.Compression{Codec: backend.CompressionNone})
+}
+
+func (s *snapshotSuite) TestHappyRoundtripGzipLevel(c *check.C) {
+	s.testHappyRoundtrip(c, "marker", &backend.Compression{Codec: backend.CompressionGzip, Level: 9})
+}
+
+func (s *snapshotSuite) TestHappyRoundtripZstd(c *check.C) {
+	if !osutil.ExecutableExists("zstd") {
+		c.Skip("zstd is not available")
+	}
+	s.testHappyRoundtrip(c, "marker", &backend.Compression{Codec: backend.CompressionZstd, Level: 3})
 }
 
 func (s *snapshotSuite) TestHappyRoundtripNoCommon(c *check.C) {
@@ -696,7 +821,7 @@ func (s *snapshotSuite) TestHappyRoundtripNoCommon(c *check.C) {
 			c.Assert(os.RemoveAll(t.dir), check.IsNil)
 		}
 	}
-	s.testHappyRoundtrip(c, "marker")
+	s.testHappyRoundtrip(c, "marker", nil)
 }
 
 func (s *snapshotSuite) TestHappyRoundtripNoRev(c *check.C) {
@@ -705,10 +830,10 @@ func (s *snapshotSuite) TestHappyRoundtripNoRev(c *check.C) {
 			c.Assert(os.RemoveAll(t.dir), check.IsNil)
 		}
 	}
-	s.testHappyRoundtrip(c, "../common/marker")
+	s.testHappyRoundtrip(c, "../common/marker", nil)
 }
 
-func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
+func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string, comp *backend.Compression) {
 	if os.Geteuid() == 0 {
 		c.Skip("this test cannot run as root (runuser will fail)")
 	}
@@ -732,8 +857,13 @@ func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
 		return statSnapshotOpts, nil
 	})()
 
-	shw, err := backend.Save(context.TODO(), shID, info, cfg, []string{"snapuser"}, dynSnapshotOpts, nil)
+	shw, err := backend.Save(context.TODO(), shID, info, cfg, []string{"snapuser"}, dynSnapshotOpts, nil, comp)
 	c.Assert(err, check.IsNil)
+	expectedCodec := backend.CompressionGzip
+	if comp != nil {
+		expectedCodec = comp.Codec
+	}
+	c.Check(shw.Compression, check.Equals, expectedCodec)
 	c.Check(shw.SetID, check.Equals, shID)
 	c.Check(shw.Snap, check.Equals, info.InstanceName())
 	c.Check(shw.SnapID, check.Equals, info.SnapID)
@@ -769,6 +899,7 @@ func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
 		c.Check(sh.SHA3_384, check.DeepEquals, shw.SHA3_384, comm)
 		c.Check(sh.Auto, check.Equals, false)
 		c.Check(sh.Options, check.DeepEquals, dynSnapshotOpts)
+		c.Check(sh.Compression, check.Equals, expectedCodec, comm)
 	}
 	c.Check(shr.Name(), check.Equals, filepath.Join(dirs.SnapshotsDir, "12_hello-snap_v1.33_42.zip"))
 	c.Check(shr.Check(context.TODO(), nil), check.IsNil)
@@ -810,7 +941,7 @@ func (s *snapshotSuite) TestOpenSetIDoverride(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	cfg := map[string]any{"some-setting": false}
 
-	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, uint64(12))
 
@@ -834,7 +965,7 @@ func (s *snapshotSuite) TestRestoreRoundtripDifferentRevision(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	shID := uint64(12)
 
-	shw, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.Revision, check.Equals, info.Revision)
 
@@ -1109,7 +1240,7 @@ func (s *snapshotSuite) TestImportDuplicated(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	shID := uint64(12)
 
-	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
@@ -1139,7 +1270,7 @@ func (s *snapshotSuite) TestImportExportRoundtrip(c *check.C) {
 	cfg := map[string]any{"some-setting": false}
 	shID := uint64(12)
 
-	shw, err := backend.Save(ctx, shID, info, cfg, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(ctx, shID, info, cfg, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, shID)
 
@@ -1300,7 +1431,7 @@ func (s *snapshotSuite) TestExportTwice(c *check.C) {
 	}
 	// create a snapshot
 	shID := uint64(12)
-	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil)
+	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	// content.json + num_files + export.json + footer
@@ -1340,6 +1471,50 @@ func (s *snapshotSuite) TestExportTwice(c *check.C) {
 	c.Check(buf.Len(), check.Equals, int(expectedSize))
 }
 
+func (s *snapshotSuite) TestExportResumeSession(c *check.C) {
+	info := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "hello-snap",
+			Revision: snap.R(42),
+			SnapID:   "hello-id",
+		},
+		Version: "v1.33",
+	}
+	shID := uint64(12)
+	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	ctx := context.Background()
+	restore := backend.MockTimeNow(func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 600, time.UTC) })
+	defer restore()
+	se, err := backend.NewSnapshotExport(ctx, shID)
+	c.Assert(err, check.IsNil)
+	c.Assert(se.Init(), check.IsNil)
+	session := se.SessionID()
+	c.Check(session, check.Matches, fmt.Sprintf("%d-[0-9a-f]{64}", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).Unix()))
+	buf1 := bytes.NewBuffer(nil)
+	c.Assert(se.StreamTo(buf1), check.IsNil)
+
+	// an export created later and resuming the session is identical
+	restore = backend.MockTimeNow(func() time.Time { return time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC) })
+	defer restore()
+	se2, err := backend.NewSnapshotExport(ctx, shID)
+	c.Assert(err, check.IsNil)
+	c.Check(se2.SessionID(), check.Not(check.Equals), session)
+	c.Assert(se2.ResumeSession(session), check.IsNil)
+	c.Check(se2.SessionID(), check.Equals, session)
+	c.Assert(se2.Init(), check.IsNil)
+	c.Check(se2.Size(), check.Equals, se.Size())
+	buf2 := bytes.NewBuffer(nil)
+	c.Assert(se2.StreamTo(buf2), check.IsNil)
+	c.Check(buf2.Bytes(), check.DeepEquals, buf1.Bytes())
+
+	// invalid sessions
+	c.Check(se2.ResumeSession("foo"), check.ErrorMatches, `cannot resume export of snapshot set 12: invalid session "foo"`)
+	c.Check(se2.ResumeSession("foo-bar"), check.ErrorMatches, `cannot resume export of snapshot set 12: invalid session "foo-bar"`)
+	c.Check(se2.ResumeSession("1234-abcd"), check.ErrorMatches, `cannot resume export of snapshot set 12: snapshot set changed`)
+}
+
 func (s *snapshotSuite) TestExportUnhappy(c *check.C) {
 	se, err := backend.NewSnapshotExport(context.Background(), 5)
 	c.Assert(err, check.ErrorMatches, "no snapshot data found for 5")
@@ -1647,7 +1822,7 @@ func (s *snapshotSuite) TestSnapshotExportContentHash(c *check.C) {
 		Version: "v1.33",
 	}
 	shID := uint64(12)
-	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Check(err, check.IsNil)
 
 	// now export it
@@ -1669,7 +1844,7 @@ func (s *snapshotSuite) TestSnapshotExportContentHash(c *check.C) {
 		},
 		Version: "v1.33",
 	}
-	shw, err = backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err = backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Check(err, check.IsNil)
 
 	export3, err := backend.NewSnapshotExport(ctx, shw.SetID)
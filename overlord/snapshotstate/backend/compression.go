@@ -0,0 +1,121 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"fmt"
+)
+
+const (
+	// CompressionGzip is the codec used by snapshots that do not
+	// record one in their metadata.
+	CompressionGzip = "gzip"
+	// CompressionZstd compresses the data archives with zstd.
+	CompressionZstd = "zstd"
+	// CompressionNone stores the data archives uncompressed, which is
+	// useful for data that is already compressed.
+	CompressionNone = "none"
+)
+
+// Compression describes how the data archives of a snapshot are
+// compressed.
+type Compression struct {
+	// Codec is one of CompressionGzip, CompressionZstd or
+	// CompressionNone.
+	Codec string
+	// Level is the codec specific compression level, 0 meaning the
+	// default level of the codec.
+	Level int
+}
+
+// compressionMaxLevel maps the supported codecs to their highest
+// compression level.
+var compressionMaxLevel = map[string]int{
+	CompressionGzip: 9,
+	CompressionZstd: 19,
+	CompressionNone: 0,
+}
+
+// ValidateCompression checks that the given codec and level can be used
+// for snapshots on this system, level 0 meaning the default level of the
+// codec.
+func ValidateCompression(codec string, level int) error {
+	maxLevel, ok := compressionMaxLevel[codec]
+	if !ok {
+		return fmt.Errorf("unsupported snapshot compression %q", codec)
+	}
+	if maxLevel == 0 {
+		if level != 0 {
+			return fmt.Errorf("cannot use a compression level without compression")
+		}
+		return nil
+	}
+	if level < 0 || level > maxLevel {
+		return fmt.Errorf("%s compression level must be between 1 and %d", codec, maxLevel)
+	}
+	if codec == CompressionZstd {
+		// tar runs zstd to compress the archives
+		if _, err := execLookPath("zstd"); err != nil {
+			return fmt.Errorf("cannot use zstd compression: zstd is not installed")
+		}
+	}
+	return nil
+}
+
+// snapshotCodec returns the codec used for the archives of a snapshot
+// given the codec recorded in its metadata.
+func snapshotCodec(recorded string) string {
+	if recorded == "" {
+		// snapshots from before the codec was recorded
+		return CompressionGzip
+	}
+	return recorded
+}
+
+// createArgs returns the tar arguments to compress the created archive.
+func (comp *Compression) createArgs() []string {
+	switch comp.Codec {
+	case CompressionGzip:
+		if comp.Level == 0 {
+			return []string{"--gzip"}
+		}
+		return []string{fmt.Sprintf("--use-compress-program=gzip -%d", comp.Level)}
+	case CompressionZstd:
+		if comp.Level == 0 {
+			return []string{"--zstd"}
+		}
+		return []string{fmt.Sprintf("--use-compress-program=zstd -%d", comp.Level)}
+	}
+	return nil
+}
+
+// extractArgs returns the tar arguments to decompress an archive
+// compressed with the given codec.
+func extractArgs(codec string) ([]string, error) {
+	switch codec {
+	case CompressionGzip:
+		return []string{"--gunzip"}, nil
+	case CompressionZstd:
+		return []string{"--zstd"}, nil
+	case CompressionNone:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported snapshot compression %q", codec)
+}
@@ -217,6 +217,11 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 		curdir = current.String()
 	}
 
+	decompressArgs, err := extractArgs(snapshotCodec(r.Compression))
+	if err != nil {
+		return rs, fmt.Errorf("cannot restore snapshot %q: %v", r.Name(), err)
+	}
+
 	for entry := range r.SHA3_384 {
 		if err := ctx.Err(); err != nil {
 			return rs, err
@@ -322,10 +327,12 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 		// resist the temptation of using archive/tar unless it's proven
 		// that calling out to tar has issues -- there are a lot of
 		// special cases we'd need to consider otherwise
-		cmd := tarAsUser(username,
+		tarArgs := []string{
 			"--extract",
-			"--preserve-permissions", "--preserve-order", "--gunzip",
-			"--directory", tempdir)
+			"--preserve-permissions", "--preserve-order",
+			"--directory", tempdir,
+		}
+		cmd := tarAsUser(username, append(tarArgs, decompressArgs...)...)
 		cmd.Env = []string{}
 		cmd.Stdin = tr
 		matchCounter := &strutil.MatchCounter{N: 1}
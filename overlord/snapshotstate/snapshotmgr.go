@@ -74,6 +74,10 @@ func Manager(st *state.State, runner *state.TaskRunner) *SnapshotManager {
 	runner.AddHandler("check-snapshot", doCheck, nil)
 	runner.AddHandler("restore-snapshot", doRestore, undoRestore)
 	runner.AddHandler("cleanup-after-restore", doCleanupAfterRestore, nil)
+	// a restore interrupted half way leaves the data of the snap partly
+	// restored, running it again on top of that could lose the data that
+	// was moved aside, leave it for inspection instead
+	runner.SetAbandonedPolicy("restore-snapshot", state.AbandonedHold)
 
 	manager := &SnapshotManager{
 		state: st,
@@ -170,6 +174,9 @@ type snapshotSetup struct {
 	Filename string                `json:"filename,omitempty"`
 	Current  snap.Revision         `json:"current"`
 	Auto     bool                  `json:"auto,omitempty"`
+	// BeforeRefresh is set for automatic snapshots taken before a
+	// refresh, which follow their own retention
+	BeforeRefresh bool `json:"before-refresh,omitempty"`
 }
 
 func filename(setID uint64, si *snap.Info) string {
@@ -207,7 +214,11 @@ func prepareSave(task *state.Task) (snapshot *snapshotSetup, cur *snap.Info, cfg
 
 	// this should be done last because of it modifies the state and the caller needs to undo this if other operation fails.
 	if snapshot.Auto {
-		expiration, err := AutomaticSnapshotExpiration(st)
+		expirationFn := AutomaticSnapshotExpiration
+		if snapshot.BeforeRefresh {
+			expirationFn = BeforeRefreshSnapshotExpiration
+		}
+		expiration, err := expirationFn(st)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -228,12 +239,17 @@ func doSave(task *state.Task, tomb *tomb.Tomb) error {
 
 	st.Lock()
 	opts, err := getSnapDirOpts(st, snapshot.Snap)
+	if err != nil {
+		st.Unlock()
+		return err
+	}
+	comp, err := snapshotCompression(st)
 	st.Unlock()
 	if err != nil {
 		return err
 	}
 
-	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users, snapshot.Options, opts)
+	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users, snapshot.Options, opts, comp)
 	if err != nil {
 		st.Lock()
 		defer st.Unlock()
@@ -465,10 +481,11 @@ func delayedCrossMgrInit() {
 	// hook automatic snapshots into snapstate logic
 	snapstate.AutomaticSnapshot = AutomaticSnapshot
 	snapstate.AutomaticSnapshotExpiration = AutomaticSnapshotExpiration
+	snapstate.BeforeRefreshSnapshot = BeforeRefreshSnapshot
 	snapstate.EstimateSnapshotSize = EstimateSnapshotSize
 }
 
-func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]any, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions) (*client.Snapshot, error)) (restore func()) {
+func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]any, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions, *backend.Compression) (*client.Snapshot, error)) (restore func()) {
 	old := backendSave
 	backendSave = f
 	return func() {
@@ -35,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapshotstate"
 	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
 	"github.com/snapcore/snapd/overlord/state"
@@ -58,6 +59,8 @@ func (snapshotSuite) TestManager(c *check.C) {
 		"restore-snapshot",
 		"save-snapshot",
 	})
+	c.Check(runner.AbandonedPolicyFor("restore-snapshot"), check.Equals, state.AbandonedHold)
+	c.Check(runner.AbandonedPolicyFor("save-snapshot"), check.Equals, state.AbandonedRetry)
 }
 
 func mockFakeSnapshot(c *check.C) (restore func()) {
@@ -282,7 +285,7 @@ func (snapshotSuite) TestDoSave(c *check.C) {
 
 	expectedOptions := &snap.SnapshotOptions{}
 	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string,
-		options *snap.SnapshotOptions, _ *dirs.SnapDirOptions) (*client.Snapshot, error) {
+		options *snap.SnapshotOptions, _ *dirs.SnapDirOptions, _ *backend.Compression) (*client.Snapshot, error) {
 		c.Check(id, check.Equals, uint64(42))
 		c.Check(si, check.DeepEquals, &snapInfo)
 		c.Check(cfg, check.DeepEquals, map[string]any{"hello": "there"})
@@ -319,6 +322,60 @@ func (snapshotSuite) TestDoSave(c *check.C) {
 	}
 }
 
+func (snapshotSuite) TestDoSaveCompression(c *check.C) {
+	snapInfo := snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "a-snap",
+			Revision: snap.R(-1),
+		},
+		Version: "1.33",
+	}
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snapInfo, nil
+	})()
+	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) {
+		return nil, nil
+	})()
+
+	var comp *backend.Compression
+	defer snapshotstate.MockBackendSave(func(_ context.Context, _ uint64, _ *snap.Info, _ map[string]any, _ []string, _ *snap.SnapshotOptions, _ *dirs.SnapDirOptions, saveComp *backend.Compression) (*client.Snapshot, error) {
+		comp = saveComp
+		return nil, nil
+	})()
+
+	for _, tc := range []struct {
+		conf     map[string]any
+		expected *backend.Compression
+	}{
+		{nil, &backend.Compression{Codec: backend.CompressionGzip}},
+		{map[string]any{"snapshots.compression": "zstd"}, &backend.Compression{Codec: backend.CompressionZstd}},
+		{map[string]any{"snapshots.compression": "zstd", "snapshots.compression-level": "7"}, &backend.Compression{Codec: backend.CompressionZstd, Level: 7}},
+		{map[string]any{"snapshots.compression-level": 2}, &backend.Compression{Codec: backend.CompressionGzip, Level: 2}},
+		{map[string]any{"snapshots.compression": "none"}, &backend.Compression{Codec: backend.CompressionNone}},
+		// invalid configuration falls back to the default
+		{map[string]any{"snapshots.compression": "none", "snapshots.compression-level": "3"}, &backend.Compression{Codec: backend.CompressionGzip}},
+	} {
+		st := state.New(nil)
+		st.Lock()
+		tr := config.NewTransaction(st)
+		for k, v := range tc.conf {
+			tr.Set("core", k, v)
+		}
+		tr.Commit()
+		task := st.NewTask("save-snapshot", "...")
+		task.Set("snapshot-setup", map[string]any{
+			"set-id": 42,
+			"snap":   "a-snap",
+		})
+		st.Unlock()
+
+		comp = nil
+		err := snapshotstate.DoSave(task, &tomb.Tomb{})
+		c.Assert(err, check.IsNil)
+		c.Check(comp, check.DeepEquals, tc.expected, check.Commentf("%v", tc.conf))
+	}
+}
+
 func (snapshotSuite) TestDoSaveGetsSnapDirOpts(c *check.C) {
 	restore := snapshotstate.MockGetSnapDirOptions(func(*state.State, string) (*dirs.SnapDirOptions, error) {
 		return &dirs.SnapDirOptions{HiddenSnapDataDir: true}, nil
@@ -338,7 +395,7 @@ func (snapshotSuite) TestDoSaveGetsSnapDirOpts(c *check.C) {
 	})()
 
 	var checkOpts bool
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, opts *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, opts *dirs.SnapDirOptions, _ *backend.Compression) (*client.Snapshot, error) {
 		c.Check(opts.HiddenSnapDataDir, check.Equals, true)
 		checkOpts = true
 		return nil, nil
@@ -362,7 +419,7 @@ func (snapshotSuite) TestDoSaveFailsWithNoSnap(c *check.C) {
 		return nil, errors.New("bzzt")
 	})()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.Compression) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -389,7 +446,7 @@ func (snapshotSuite) TestDoSaveFailsWithNoSnapshot(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.Compression) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -413,7 +470,7 @@ func (snapshotSuite) TestDoSaveFailsBackendError(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.Compression) (*client.Snapshot, error) {
 		return nil, errors.New("bzzt")
 	})()
 
@@ -442,7 +499,7 @@ func (snapshotSuite) TestDoSaveFailsConfigError(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) {
 		return nil, errors.New("bzzt")
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.Compression) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -473,7 +530,7 @@ func (snapshotSuite) TestDoSaveFailsBadConfig(c *check.C) {
 		buf := json.RawMessage(`"hello-there"`)
 		return &buf, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.Compression) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -506,7 +563,7 @@ func (snapshotSuite) TestDoSaveFailureRemovesStateEntry(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(_ *state.State, snapname string) (*json.RawMessage, error) {
 		return nil, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.Compression) (*client.Snapshot, error) {
 		var expirations map[uint64]any
 		st.Lock()
 		defer st.Unlock()
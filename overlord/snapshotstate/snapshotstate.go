@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/snapcore/snapd/client"
@@ -49,6 +50,9 @@ var (
 
 	// Default expiration time for automatic snapshots, if not set by the user
 	defaultAutomaticSnapshotExpiration = time.Hour * 24 * 31
+	// Default expiration time for snapshots taken before a refresh, if not
+	// set by the user
+	defaultBeforeRefreshSnapshotExpiration = time.Hour * 24 * 7
 )
 
 type snapshotState struct {
@@ -151,6 +155,67 @@ func AutomaticSnapshotExpiration(st *state.State) (time.Duration, error) {
 	return defaultAutomaticSnapshotExpiration, nil
 }
 
+// BeforeRefreshSnapshotExpiration returns for how long the snapshots taken
+// before a refresh are kept, or 0 if they are disabled.
+func BeforeRefreshSnapshotExpiration(st *state.State) (time.Duration, error) {
+	tr := config.NewTransaction(st)
+	var enabled any
+	if err := tr.Get("core", "snapshots.automatic.before-refresh", &enabled); err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if fmt.Sprintf("%v", enabled) != "true" {
+		return 0, nil
+	}
+	var expirationStr string
+	err := tr.Get("core", "snapshots.automatic.before-refresh-retention", &expirationStr)
+	if err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if err == nil {
+		dur, err := time.ParseDuration(expirationStr)
+		if err == nil {
+			return dur, nil
+		}
+		logger.Noticef("snapshots.automatic.before-refresh-retention cannot be parsed: %v", err)
+	}
+	return defaultBeforeRefreshSnapshotExpiration, nil
+}
+
+// snapshotCompression returns how the data archives of new snapshots are
+// compressed, as configured by the snapshots.compression and
+// snapshots.compression-level core options. It falls back to gzip with its
+// default level if the configuration is unset or invalid.
+func snapshotCompression(st *state.State) (*backend.Compression, error) {
+	tr := config.NewTransaction(st)
+	var codec string
+	if err := tr.Get("core", "snapshots.compression", &codec); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	// the level may have been set either as a string or as a number
+	var rawLevel any
+	if err := tr.Get("core", "snapshots.compression-level", &rawLevel); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+
+	comp := &backend.Compression{Codec: backend.CompressionGzip}
+	if codec != "" {
+		comp.Codec = codec
+	}
+	if rawLevel != nil {
+		level, err := strconv.Atoi(fmt.Sprintf("%v", rawLevel))
+		if err != nil {
+			logger.Noticef("snapshots.compression-level cannot be parsed: %v", err)
+			return &backend.Compression{Codec: backend.CompressionGzip}, nil
+		}
+		comp.Level = level
+	}
+	if err := backend.ValidateCompression(comp.Codec, comp.Level); err != nil {
+		logger.Noticef("invalid snapshot compression configuration: %v", err)
+		return &backend.Compression{Codec: backend.CompressionGzip}, nil
+	}
+	return comp, nil
+}
+
 // saveExpiration saves expiration date of the given snapshot set, in the state.
 // The state needs to be locked by the caller.
 func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
@@ -215,6 +280,42 @@ func expiredSnapshotSets(st *state.State, cutoffTime time.Time) (map[uint64]bool
 	return expired, nil
 }
 
+// ExpiredSets returns the IDs of the automatic snapshot sets that have
+// expired and are still on disk, along with the size of their files.
+// The state needs to be locked by the caller.
+func ExpiredSets(ctx context.Context, st *state.State) (setIDs []uint64, size int64, err error) {
+	sets, err := expiredSnapshotSets(st, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(sets) == 0 {
+		return nil, 0, nil
+	}
+
+	found := make(map[uint64]bool, len(sets))
+	err = backendIter(ctx, func(r *backend.Reader) error {
+		if !sets[r.SetID] {
+			return nil
+		}
+		fi, err := r.Stat()
+		if err != nil {
+			return err
+		}
+		size += fi.Size()
+		found[r.SetID] = true
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot list expired snapshots: %v", err)
+	}
+
+	for setID := range found {
+		setIDs = append(setIDs, setID)
+	}
+	sort.Slice(setIDs, func(i, j int) bool { return setIDs[i] < setIDs[j] })
+	return setIDs, size, nil
+}
+
 // snapshotSnapSummaries are used internally to get useful data from a
 // snapshot set when deciding whether to check/forget/restore it.
 type snapshotSnapSummaries []*snapshotSnapSummary
@@ -467,6 +568,34 @@ func AutomaticSnapshot(st *state.State, snapName string) (ts *state.TaskSet, err
 	return ts, nil
 }
 
+// BeforeRefreshSnapshot returns a task set saving the data of the given snap
+// ahead of a refresh, if enabled with snapshots.automatic.before-refresh.
+func BeforeRefreshSnapshot(st *state.State, snapName string) (ts *state.TaskSet, err error) {
+	expiration, err := BeforeRefreshSnapshotExpiration(st)
+	if err != nil {
+		return nil, err
+	}
+	if expiration == 0 {
+		return nil, snapstate.ErrNothingToDo
+	}
+	setID, err := newSnapshotSetID(st)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := fmt.Sprintf("Save data of snap %q before refresh in automatic snapshot set #%d", snapName, setID)
+	task := st.NewTask("save-snapshot", desc)
+	snapshot := snapshotSetup{
+		SetID:         setID,
+		Snap:          snapName,
+		Auto:          true,
+		BeforeRefresh: true,
+	}
+	task.Set("snapshot-setup", &snapshot)
+
+	return state.NewTaskSet(task), nil
+}
+
 // Restore creates a taskset for restoring a snapshot's data.
 // Note that the state must be locked by the caller.
 func Restore(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error) {
@@ -1261,7 +1261,7 @@ func testRestoreIntegration(c *check.C, snapDataDir string, opts *dirs.SnapDirOp
 			c.Assert(os.MkdirAll(filepath.Join(home, snapDataDir, name, "common", "common-"+name), 0755), check.IsNil)
 		}
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"}, nil, opts)
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"}, nil, opts, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1340,7 +1340,7 @@ func (snapshotSuite) TestRestoreIntegrationFails(c *check.C) {
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, fmt.Sprint(i+1), "canary-"+name), 0755), check.IsNil)
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, "common", "common-"+name), 0755), check.IsNil)
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"}, nil, nil)
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"}, nil, nil, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1685,6 +1685,32 @@ func (snapshotSuite) TestExpiredSnapshotSets(c *check.C) {
 	c.Check(expired, check.DeepEquals, map[uint64]bool{13: true})
 }
 
+func (snapshotSuite) TestExpiredSets(c *check.C) {
+	restore := mockFakeSnapshot(c)
+	defer restore()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	setIDs, size, err := snapshotstate.ExpiredSets(context.Background(), st)
+	c.Assert(err, check.IsNil)
+	c.Check(setIDs, check.HasLen, 0)
+	c.Check(size, check.Equals, int64(0))
+
+	// set 3 is expired but has no snapshot on disk anymore
+	st.Set("snapshots", map[uint64]any{
+		1: map[string]any{"expiry-time": "2001-03-11T11:24:00Z"},
+		2: map[string]any{"expiry-time": "2037-02-12T12:50:00Z"},
+		3: map[string]any{"expiry-time": "2001-03-11T11:24:00Z"},
+	})
+
+	setIDs, size, err = snapshotstate.ExpiredSets(context.Background(), st)
+	c.Assert(err, check.IsNil)
+	c.Check(setIDs, check.DeepEquals, []uint64{1})
+	c.Check(size, check.Equals, int64(0))
+}
+
 func (snapshotSuite) TestAutomaticSnapshotDisabled(c *check.C) {
 	st := state.New(nil)
 	st.Lock()
@@ -1748,6 +1774,72 @@ func (snapshotSuite) TestAutomaticSnapshotDefaultUbuntuCore(c *check.C) {
 	c.Assert(du, check.Equals, time.Duration(0))
 }
 
+func (snapshotSuite) TestBeforeRefreshSnapshotDisabledByDefault(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, err := snapshotstate.BeforeRefreshSnapshot(st, "foo")
+	c.Assert(err, check.Equals, snapstate.ErrNothingToDo)
+}
+
+func (snapshotSuite) TestBeforeRefreshSnapshot(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.automatic.before-refresh", true)
+	tr.Commit()
+
+	ts, err := snapshotstate.BeforeRefreshSnapshot(st, "foo")
+	c.Assert(err, check.IsNil)
+
+	tasks := ts.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "save-snapshot")
+	c.Check(tasks[0].Summary(), check.Equals, `Save data of snap "foo" before refresh in automatic snapshot set #1`)
+	var snapshot map[string]any
+	c.Check(tasks[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot, check.DeepEquals, map[string]any{
+		"set-id":         1.,
+		"snap":           "foo",
+		"current":        "unset",
+		"auto":           true,
+		"before-refresh": true,
+	})
+}
+
+func (snapshotSuite) TestBeforeRefreshSnapshotExpiration(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.automatic.before-refresh", "true")
+	tr.Commit()
+
+	du, err := snapshotstate.BeforeRefreshSnapshotExpiration(st)
+	c.Assert(err, check.IsNil)
+	c.Check(du, check.Equals, snapshotstate.DefaultBeforeRefreshSnapshotExpiration)
+
+	tr = config.NewTransaction(st)
+	tr.Set("core", "snapshots.automatic.before-refresh-retention", "48h")
+	tr.Commit()
+
+	du, err = snapshotstate.BeforeRefreshSnapshotExpiration(st)
+	c.Assert(err, check.IsNil)
+	c.Check(du, check.Equals, 48*time.Hour)
+
+	tr = config.NewTransaction(st)
+	tr.Set("core", "snapshots.automatic.before-refresh", false)
+	tr.Commit()
+
+	du, err = snapshotstate.BeforeRefreshSnapshotExpiration(st)
+	c.Assert(err, check.IsNil)
+	c.Check(du, check.Equals, time.Duration(0))
+}
+
 func (snapshotSuite) TestListError(c *check.C) {
 	restore := snapshotstate.MockBackendList(func(context.Context, uint64, []string) ([]client.SnapshotSet, error) {
 		return nil, fmt.Errorf("boom")
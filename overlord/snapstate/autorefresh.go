@@ -78,6 +78,17 @@ var (
 var (
 	autoRefreshChangeKind = swfeats.RegisterChangeKind("auto-refresh")
 	preDownloadChangeKind = swfeats.RegisterChangeKind("pre-download")
+
+	refreshInhibitHookChangeKind = swfeats.RegisterChangeKind("refresh-inhibit-hook")
+)
+
+var (
+	_ = swfeats.DescribeChangeKind(autoRefreshChangeKind, "Refresh snaps automatically",
+		"conditional-auto-refresh", "check-rerefresh")
+	_ = swfeats.DescribeChangeKind(preDownloadChangeKind, "Download snaps ahead of an inhibited refresh",
+		"pre-download-snap")
+	_ = swfeats.DescribeChangeKind(refreshInhibitHookChangeKind, "Run the refresh-inhibit hook of a snap whose refresh is inhibited",
+		"run-hook")
 )
 
 func init() {
@@ -96,6 +107,9 @@ type refreshCandidate struct {
 	// Monitored signals whether this snap is currently being monitored for closure
 	// so its auto-refresh can be continued.
 	Monitored bool `json:"monitored,omitempty"`
+	// Prefetched signals whether the blob of this candidate was already
+	// downloaded ahead of the refresh, see refresh.prefetch-window.
+	Prefetched bool `json:"prefetched,omitempty"`
 }
 
 func (rc *refreshCandidate) Type() snap.Type {
@@ -355,12 +369,21 @@ func (m *autoRefresh) Ensure() (err error) {
 
 	logger.Trace("ensure", "manager", "SnapManager", "func", "autoRefresh.Ensure")
 
+	cal, err := MaintenanceCalendarOf(m.state)
+	if err != nil {
+		return err
+	}
+	maxDelay := maxPostponement
+	if cal != nil {
+		maxDelay = maxCalendarPostponement
+	}
+
 	now := time.Now()
 	// compute next refresh attempt time (if needed)
 	if m.nextRefresh.IsZero() {
 		// store attempts in memory so that we can backoff
 		if !lastRefresh.IsZero() {
-			delta := timeutil.Next(refreshSchedule, lastRefresh, maxPostponement)
+			delta := timeutil.Next(refreshSchedule, lastRefresh, maxDelay)
 			now = time.Now()
 			m.nextRefresh = now.Add(delta)
 		} else {
@@ -385,7 +408,7 @@ func (m *autoRefresh) Ensure() (err error) {
 			m.clearRefreshHold()
 			if m.nextRefresh.Before(holdTime) {
 				// next refresh is obsolete, compute the next one
-				delta := timeutil.Next(refreshSchedule, holdTime, maxPostponement)
+				delta := timeutil.Next(refreshSchedule, holdTime, maxDelay)
 				now = time.Now()
 				m.nextRefresh = now.Add(delta)
 			}
@@ -397,6 +420,10 @@ func (m *autoRefresh) Ensure() (err error) {
 		// before now, and the next refresh is equal to now without requiring an
 		// or operation
 		if !m.nextRefresh.After(now) {
+			if cal != nil && !m.insideMaintenanceWindow(cal, refreshSchedule, now) {
+				return nil
+			}
+
 			var can bool
 			can, err = m.canRefreshRespectingMetered(now, lastRefresh)
 			if err != nil {
@@ -544,6 +571,19 @@ func (m *autoRefresh) refreshScheduleWithDefaultsFallback() (sched []*timeutil.S
 		return nil, "managed", legacy, nil
 	}
 
+	// a maintenance calendar takes precedence over refresh.timer
+	cal, err := MaintenanceCalendarOf(m.state)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if cal != nil {
+		sched, err := cal.schedule()
+		if err == nil {
+			return sched, cal.timer(), false, nil
+		}
+		logger.Noticef("cannot use maintenance calendar: %v", err)
+	}
+
 	if scheduleConf == "" {
 		return defaultRefreshSchedule, defaultRefreshScheduleStr, false, nil
 	}
@@ -567,6 +607,26 @@ func (m *autoRefresh) refreshScheduleWithDefaultsFallback() (sched []*timeutil.S
 	return sched, scheduleConf, legacy, nil
 }
 
+// insideMaintenanceWindow returns whether an auto-refresh can happen now
+// according to the maintenance calendar. If not, the next refresh is
+// postponed to the end of the current blackout period or to the next
+// window.
+func (m *autoRefresh) insideMaintenanceWindow(cal *MaintenanceCalendar, sched []*timeutil.Schedule, now time.Time) bool {
+	if end, ok := cal.blackoutEnd(now); ok {
+		m.nextRefresh = end
+		logger.Debugf("Auto-refresh postponed by the maintenance calendar until the end of the blackout period at %s.", end.Format(time.RFC3339))
+		return false
+	}
+	if !timeutil.Includes(sched, now) {
+		// now is outside of all windows so this gives the start of the
+		// next one
+		m.nextRefresh = now.Add(timeutil.Next(sched, now, maxCalendarPostponement))
+		logger.Debugf("Auto-refresh postponed by the maintenance calendar until %s.", m.nextRefresh.Format(time.RFC3339))
+		return false
+	}
+	return true
+}
+
 func autoRefreshSummary(updated []string) string {
 	var msg string
 	switch len(updated) {
@@ -668,6 +728,8 @@ func (m *autoRefresh) launchAutoRefresh() error {
 	}
 
 	chg := m.state.NewChange(autoRefreshChangeKind, msg)
+	// do not hold back changes requested explicitly
+	chg.SetPriority(state.PriorityLow)
 	for _, ts := range updateTss.Refresh {
 		chg.AddAll(ts)
 	}
@@ -694,6 +756,7 @@ func createPreDownloadChange(st *state.State, updateTss *UpdateTaskSets) (bool,
 
 		chgSummary := fmt.Sprintf(i18n.G("Pre-download %s for auto-refresh"), strutil.Quoted(snapNames))
 		preDlChg := st.NewChange(preDownloadChangeKind, chgSummary)
+		preDlChg.SetPriority(state.PriorityBackground)
 		for _, ts := range updateTss.PreDownload {
 			preDlChg.AddAll(ts)
 		}
@@ -828,6 +891,9 @@ func inhibitRefresh(st *state.State, snapst *SnapState, snapsup *SnapSetup, info
 		snapst.RefreshInhibitedTime = &now
 		busyErr.timeRemaining = (maxInhibitionDurationValue - now.Sub(*snapst.RefreshInhibitedTime)).Truncate(time.Second)
 		Set(st, info.InstanceName(), snapst)
+		// give the snap a chance to checkpoint its state while the refresh
+		// is postponed
+		maybeRunRefreshInhibitHook(st, info)
 	case now.Sub(*snapst.RefreshInhibitedTime) < maxInhibitionDurationValue:
 		// If we are still in the allowed window then just return the error but
 		// don't change the snap state again.
@@ -846,9 +912,97 @@ func inhibitRefresh(st *state.State, snapst *SnapState, snapsup *SnapSetup, info
 		return true, nil
 	}
 
+	if err := addSnapRefreshInhibitNotice(st, info.InstanceName(), *snapst.RefreshInhibitedTime, busyErr.timeRemaining); err != nil {
+		logger.Noticef("Cannot record %q notice for snap %q: %v", state.RefreshInhibitNotice, info.InstanceName(), err)
+	}
+
 	return false, busyErr
 }
 
+const refreshInhibitHookName = "refresh-inhibit"
+
+// maybeRunRefreshInhibitHook runs the refresh-inhibit hook of the snap, if it
+// has one, in a change of its own as the refresh which got inhibited does not
+// proceed.
+func maybeRunRefreshInhibitHook(st *state.State, info *snap.Info) {
+	if info.Hooks[refreshInhibitHookName] == nil {
+		return
+	}
+
+	summary := fmt.Sprintf(i18n.G("Run refresh-inhibit hook of %q snap"), info.InstanceName())
+	chg := st.NewChange(refreshInhibitHookChangeKind, summary)
+	chg.AddTask(SetupRefreshInhibitHook(st, info.InstanceName()))
+	chg.Set("snap-names", []string{info.InstanceName()})
+	st.EnsureBefore(0)
+}
+
+// addSnapRefreshInhibitNotice records a refresh-inhibit notice keyed by the
+// snap, carrying when its refresh was first inhibited and how long running
+// apps can still postpone it.
+func addSnapRefreshInhibitNotice(st *state.State, instanceName string, inhibitedSince time.Time, timeRemaining time.Duration) error {
+	opts := &state.AddNoticeOptions{
+		Data: map[string]string{
+			"inhibited-since": inhibitedSince.UTC().Format(time.RFC3339),
+			"proceed-time":    time.Now().Add(timeRemaining).UTC().Format(time.RFC3339),
+			"time-remaining":  timeRemaining.String(),
+		},
+	}
+	_, err := st.AddNotice(nil, state.RefreshInhibitNotice, instanceName, opts)
+	return err
+}
+
+// ProceedWithInhibitedRefresh ends the grace period of an auto-refresh of the
+// given snap that is inhibited by its running apps and returns the task sets
+// to carry out the refresh right away, regardless of the running apps.
+func ProceedWithInhibitedRefresh(st *state.State, instanceName string) ([]*state.TaskSet, error) {
+	var snapst SnapState
+	if err := Get(st, instanceName, &snapst); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil, &snap.NotInstalledError{Snap: instanceName}
+		}
+		return nil, err
+	}
+	if snapst.RefreshInhibitedTime == nil {
+		return nil, fmt.Errorf("cannot proceed with refresh of snap %q: refresh is not inhibited", instanceName)
+	}
+
+	var refreshHints map[string]*refreshCandidate
+	if err := st.Get("refresh-candidates", &refreshHints); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, fmt.Errorf("cannot get refresh-candidates: %v", err)
+	}
+	hint, ok := refreshHints[instanceName]
+	if !ok {
+		return nil, fmt.Errorf("cannot proceed with refresh of snap %q: no pending refresh", instanceName)
+	}
+
+	if err := CheckChangeConflict(st, instanceName, nil); err != nil {
+		return nil, err
+	}
+
+	// move the start of the inhibition back so that the grace period is over
+	// and the refresh is forced despite the running apps
+	oldInhibitedTime := snapst.RefreshInhibitedTime
+	inhibitedTime := time.Now().Add(-maxInhibitionDuration(st))
+	snapst.RefreshInhibitedTime = &inhibitedTime
+	Set(st, instanceName, &snapst)
+
+	flags := &Flags{IsAutoRefresh: true, IsContinuedAutoRefresh: true}
+	updateTss, err := autoRefreshPhase2(st, []*refreshCandidate{hint}, flags, "")
+	if err == nil && len(updateTss.Refresh) == 0 {
+		err = fmt.Errorf("cannot proceed with refresh of snap %q: nothing to refresh", instanceName)
+	}
+	if err != nil {
+		snapst.RefreshInhibitedTime = oldInhibitedTime
+		Set(st, instanceName, &snapst)
+		return nil, err
+	}
+
+	// the refresh no longer waits for the snap to close
+	abortMonitoring(st, instanceName)
+
+	return updateTss.Refresh, nil
+}
+
 // IsSnapMonitored checks if there's already a goroutine waiting for this snap to close.
 func IsSnapMonitored(st *state.State, snapName string) bool {
 	return monitoringAbort(st, snapName) != nil
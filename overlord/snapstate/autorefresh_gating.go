@@ -183,6 +183,10 @@ func HoldRefreshesBySystem(st *state.State, level HoldLevel, holdTime string, ho
 		}
 
 		holdDuration = holdTime.Sub(timeNow())
+		// a zero duration would mean holding forever in HoldRefresh
+		if holdDuration <= 0 {
+			return fmt.Errorf("cannot hold refreshes until %s: time is not in the future", holdTime.Format(time.RFC3339))
+		}
 	}
 
 	_, err = HoldRefresh(st, level, "system", holdDuration, holdSnaps...)
@@ -824,6 +828,7 @@ func AutoRefreshForGatingSnap(st *state.State, gatingSnap string) error {
 	// is not treated as a full auto-refresh.
 
 	chg := st.NewChange(autoRefreshChangeKind, msg)
+	chg.SetPriority(state.PriorityLow)
 	for _, ts := range tasksets {
 		chg.AddAll(ts)
 	}
@@ -3252,3 +3252,52 @@ func (s *validationSetsSuite) TestAutoRefreshPhase1WithValidationSets(c *C) {
 		revno: snap.R(11),
 	})
 }
+
+func (s *autorefreshGatingSuite) TestHoldRefreshesBySystemUntil(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	restore := snapstate.MockTimeNow(func() time.Time {
+		t, err := time.Parse(time.RFC3339, "2021-05-10T10:00:00Z")
+		c.Assert(err, IsNil)
+		return t
+	})
+	defer restore()
+
+	mockInstalledSnap(c, st, snapAyaml, false)
+
+	err := snapstate.HoldRefreshesBySystem(st, snapstate.HoldAutoRefresh, "2021-06-10T10:00:00Z", []string{"snap-a"})
+	c.Assert(err, IsNil)
+
+	var gating map[string]map[string]*snapstate.HoldState
+	c.Assert(st.Get("snaps-hold", &gating), IsNil)
+	c.Check(gating, DeepEquals, map[string]map[string]*snapstate.HoldState{
+		"snap-a": {
+			"system": snapstate.MockHoldState("2021-05-10T10:00:00Z", "2021-06-10T10:00:00Z"),
+		},
+	})
+}
+
+func (s *autorefreshGatingSuite) TestHoldRefreshesBySystemTimeNotInFuture(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	restore := snapstate.MockTimeNow(func() time.Time {
+		t, err := time.Parse(time.RFC3339, "2021-05-10T10:00:00Z")
+		c.Assert(err, IsNil)
+		return t
+	})
+	defer restore()
+
+	mockInstalledSnap(c, st, snapAyaml, false)
+
+	for _, holdTime := range []string{"2021-05-10T10:00:00Z", "2021-05-01T10:00:00Z"} {
+		err := snapstate.HoldRefreshesBySystem(st, snapstate.HoldGeneral, holdTime, []string{"snap-a"})
+		c.Check(err, ErrorMatches, fmt.Sprintf(`cannot hold refreshes until %s: time is not in the future`, holdTime))
+	}
+
+	var gating map[string]map[string]*snapstate.HoldState
+	c.Check(st.Get("snaps-hold", &gating), testutil.ErrorIs, state.ErrNoState)
+}
@@ -1095,6 +1095,164 @@ func (s *autoRefreshTestSuite) TestInhibitNoNotificationOnManualRefresh(c *C) {
 	c.Check(inhibitionTimeout, Equals, false)
 }
 
+func (s *autoRefreshTestSuite) TestInitialInhibitRefreshRunsRefreshInhibitHook(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := snapstate.MockAsyncPendingRefreshNotification(func(ctx context.Context, refreshInfo *userclient.PendingSnapRefreshInfo) {})
+	defer restore()
+
+	var hookCalls int
+	restore = testutil.Mock(&snapstate.SetupRefreshInhibitHook, func(st *state.State, snapName string) *state.Task {
+		hookCalls++
+		c.Check(snapName, Equals, "pkg")
+		return st.NewTask("run-hook", "refresh-inhibit hook")
+	})
+	defer restore()
+
+	si := &snap.SideInfo{RealName: "pkg", Revision: snap.R(1)}
+	info := &snap.Info{SideInfo: *si}
+	info.Hooks = map[string]*snap.HookInfo{
+		"refresh-inhibit": {Snap: info, Name: "refresh-inhibit"},
+	}
+	snapst := &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+	}
+	snapsup := &snapstate.SnapSetup{Flags: snapstate.Flags{IsAutoRefresh: true}}
+
+	restore = snapstate.MockRefreshAppsCheck(func(si *snap.Info) error {
+		return snapstate.NewBusySnapError(si, []int{123}, nil, nil)
+	})
+	defer restore()
+
+	_, err := snapstate.InhibitRefresh(s.state, snapst, snapsup, info)
+	c.Assert(err, ErrorMatches, `snap "pkg" has running apps or hooks, pids: 123`)
+	c.Check(hookCalls, Equals, 1)
+
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Kind(), Equals, "refresh-inhibit-hook")
+	c.Check(chgs[0].Summary(), Equals, `Run refresh-inhibit hook of "pkg" snap`)
+	c.Assert(chgs[0].Tasks(), HasLen, 1)
+	c.Check(chgs[0].Tasks()[0].Kind(), Equals, "run-hook")
+
+	// the hook is only run when the refresh is first inhibited
+	_, err = snapstate.InhibitRefresh(s.state, snapst, snapsup, info)
+	c.Assert(err, ErrorMatches, `snap "pkg" has running apps or hooks, pids: 123`)
+	c.Check(hookCalls, Equals, 1)
+	c.Check(s.state.Changes(), HasLen, 1)
+}
+
+func (s *autoRefreshTestSuite) TestInhibitRefreshRecordsSnapRefreshInhibitNotice(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := snapstate.MockAsyncPendingRefreshNotification(func(ctx context.Context, refreshInfo *userclient.PendingSnapRefreshInfo) {})
+	defer restore()
+
+	pastInstant := time.Now().Add(-snapstate.MaxInhibitionDuration(s.state) / 2).Truncate(time.Second)
+
+	si := &snap.SideInfo{RealName: "pkg", Revision: snap.R(1)}
+	info := &snap.Info{SideInfo: *si}
+	snapst := &snapstate.SnapState{
+		Sequence:             snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:              si.Revision,
+		RefreshInhibitedTime: &pastInstant,
+	}
+	snapsup := &snapstate.SnapSetup{Flags: snapstate.Flags{IsAutoRefresh: true}}
+
+	restore = snapstate.MockRefreshAppsCheck(func(si *snap.Info) error {
+		return snapstate.NewBusySnapError(si, []int{123}, nil, nil)
+	})
+	defer restore()
+
+	_, err := snapstate.InhibitRefresh(s.state, snapst, snapsup, info)
+	c.Assert(err, ErrorMatches, `snap "pkg" has running apps or hooks, pids: 123`)
+
+	// no hook to run
+	c.Check(s.state.Changes(), HasLen, 0)
+
+	notices := s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.RefreshInhibitNotice}, Keys: []string{"pkg"}})
+	c.Assert(notices, HasLen, 1)
+	n := noticeToMap(c, notices[0])
+	c.Check(n["key"], Equals, "pkg")
+	c.Check(n["user-id"], IsNil)
+	data := n["last-data"].(map[string]any)
+	c.Check(data["inhibited-since"], Equals, pastInstant.UTC().Format(time.RFC3339))
+	remaining, err := time.ParseDuration(data["time-remaining"].(string))
+	c.Assert(err, IsNil)
+	c.Check(remaining > 0 && remaining <= snapstate.MaxInhibitionDuration(s.state)/2, Equals, true)
+	proceedTime, err := time.Parse(time.RFC3339, data["proceed-time"].(string))
+	c.Assert(err, IsNil)
+	c.Check(proceedTime.After(time.Now()), Equals, true)
+}
+
+func (s *autoRefreshTestSuite) TestInhibitRefreshNoSnapRefreshInhibitNoticeWhenOverdue(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := snapstate.MockAsyncPendingRefreshNotification(func(ctx context.Context, refreshInfo *userclient.PendingSnapRefreshInfo) {})
+	defer restore()
+
+	pastInstant := time.Now().Add(-snapstate.MaxInhibitionDuration(s.state) * 2)
+
+	si := &snap.SideInfo{RealName: "pkg", Revision: snap.R(1)}
+	info := &snap.Info{SideInfo: *si}
+	snapst := &snapstate.SnapState{
+		Sequence:             snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:              si.Revision,
+		RefreshInhibitedTime: &pastInstant,
+	}
+	snapsup := &snapstate.SnapSetup{Flags: snapstate.Flags{IsAutoRefresh: true}}
+
+	restore = snapstate.MockRefreshAppsCheck(func(si *snap.Info) error {
+		return &snapstate.BusySnapError{SnapInfo: si}
+	})
+	defer restore()
+
+	inhibitionTimeout, err := snapstate.InhibitRefresh(s.state, snapst, snapsup, info)
+	c.Assert(err, IsNil)
+	c.Check(inhibitionTimeout, Equals, true)
+
+	notices := s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.RefreshInhibitNotice}, Keys: []string{"pkg"}})
+	c.Check(notices, HasLen, 0)
+}
+
+func (s *autoRefreshTestSuite) TestProceedWithInhibitedRefreshNotInstalled(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := snapstate.ProceedWithInhibitedRefresh(s.state, "unknown-snap")
+	c.Assert(err, ErrorMatches, `snap "unknown-snap" is not installed`)
+}
+
+func (s *autoRefreshTestSuite) TestProceedWithInhibitedRefreshNotInhibited(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := snapstate.ProceedWithInhibitedRefresh(s.state, "some-snap")
+	c.Assert(err, ErrorMatches, `cannot proceed with refresh of snap "some-snap": refresh is not inhibited`)
+}
+
+func (s *autoRefreshTestSuite) TestProceedWithInhibitedRefreshNoCandidate(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	inhibitedTime := time.Now().Add(-time.Hour)
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	snapst.RefreshInhibitedTime = &inhibitedTime
+	snapstate.Set(s.state, "some-snap", &snapst)
+
+	_, err := snapstate.ProceedWithInhibitedRefresh(s.state, "some-snap")
+	c.Assert(err, ErrorMatches, `cannot proceed with refresh of snap "some-snap": no pending refresh`)
+
+	// the inhibition is left alone
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.RefreshInhibitedTime.Equal(inhibitedTime), Equals, true)
+}
+
 func (s *autoRefreshTestSuite) TestBlockedAutoRefreshCreatesPreDownloads(c *C) {
 	s.addRefreshableSnap("foo")
 
@@ -1144,6 +1302,7 @@ func (s *autoRefreshTestSuite) TestAutoRefreshCreatesBothRefreshAndPreDownload(c
 	checkPreDownloadChange(c, chgs[1], "foo", snap.R(8))
 
 	c.Assert(chgs[0].Kind(), Equals, "auto-refresh")
+	c.Check(chgs[0].Priority(), Equals, state.PriorityLow)
 	var names []string
 	err = chgs[0].Get("snap-names", &names)
 	c.Assert(err, IsNil)
@@ -1153,6 +1312,7 @@ func (s *autoRefreshTestSuite) TestAutoRefreshCreatesBothRefreshAndPreDownload(c
 func checkPreDownloadChange(c *C, chg *state.Change, name string, rev snap.Revision) {
 	c.Assert(chg.Kind(), Equals, "pre-download")
 	c.Assert(chg.Summary(), Equals, fmt.Sprintf(`Pre-download "%s" for auto-refresh`, name))
+	c.Check(chg.Priority(), Equals, state.PriorityBackground)
 	c.Assert(chg.Tasks(), HasLen, 1)
 	task := chg.Tasks()[0]
 	c.Assert(task.Kind(), Equals, "pre-download-snap")
@@ -96,3 +96,16 @@ func discardSnapIcon(snapID string) error {
 	}
 	return nil
 }
+
+// DiscardScaledSnapIcons removes the scaled copies of the icons of the
+// given snap instance, which are cached for clients asking for icons of
+// specific sizes.
+func DiscardScaledSnapIcons(instanceName string) error {
+	if instanceName == "" {
+		return nil
+	}
+	if err := os.RemoveAll(filepath.Join(dirs.SnapIconsScaledDir, instanceName)); err != nil {
+		return fmt.Errorf("cannot remove scaled snap icons: %w", err)
+	}
+	return nil
+}
@@ -132,3 +132,20 @@ func (s *iconSuite) TestSnapIconLinkUnlinkDiscardPermutations(c *C) {
 		}
 	}
 }
+
+func (s *iconSuite) TestDiscardScaledSnapIcons(c *C) {
+	scaled := filepath.Join(dirs.SnapIconsScaledDir, "foo_bar", "0123.png")
+	c.Assert(os.MkdirAll(filepath.Dir(scaled), 0o755), IsNil)
+	c.Assert(os.WriteFile(scaled, nil, 0o644), IsNil)
+	other := filepath.Join(dirs.SnapIconsScaledDir, "foo", "4567.png")
+	c.Assert(os.MkdirAll(filepath.Dir(other), 0o755), IsNil)
+	c.Assert(os.WriteFile(other, nil, 0o644), IsNil)
+
+	c.Assert(backend.DiscardScaledSnapIcons("foo_bar"), IsNil)
+	c.Check(osutil.IsDirectory(filepath.Dir(scaled)), Equals, false)
+	c.Check(osutil.FileExists(other), Equals, true)
+
+	// nothing to remove
+	c.Assert(backend.DiscardScaledSnapIcons("foo_bar"), IsNil)
+	c.Assert(backend.DiscardScaledSnapIcons(""), IsNil)
+}
@@ -493,6 +493,8 @@ func (f *fakeStore) lookupRefresh(cand refreshCand) (*snap.Info, error) {
 	case "some-epoch-snap-id":
 		name = "some-epoch-snap"
 		epoch = snap.E("42")
+	case "snap-with-empty-epoch-id":
+		name = "snap-with-empty-epoch"
 	case "some-snap-now-classic-id":
 		name = "some-snap-now-classic"
 	case "some-snap-was-classic-id":
@@ -922,9 +924,14 @@ func (f *fakeStore) Download(ctx context.Context, name, targetFn string, snapInf
 	if user != nil {
 		macaroon = user.StoreMacaroon
 	}
-	// only add the options if they contain anything interesting
-	if dlOpts != nil && *dlOpts == (store.DownloadOptions{}) {
-		dlOpts = nil
+	// only add the options if they contain anything interesting, snap
+	// downloads are always resumable
+	if dlOpts != nil {
+		opts := *dlOpts
+		opts.Resumable = false
+		if opts == (store.DownloadOptions{}) {
+			dlOpts = nil
+		}
 	}
 	f.appendDownload(&fakeDownload{
 		macaroon: macaroon,
@@ -1210,7 +1217,10 @@ func (f *fakeSnappyBackend) ReadInfo(name string, si *snap.SideInfo) (*snap.Info
 	}
 	switch snapName {
 	case "snap-with-empty-epoch":
-		info.Epoch = snap.Epoch{}
+		// the revision the fake store offers moved to epoch 1*
+		if si.Revision != snap.R(11) {
+			info.Epoch = snap.Epoch{}
+		}
 	case "some-epoch-snap":
 		info.Epoch = snap.E("13")
 	case "some-snap-with-base":
@@ -1301,6 +1311,8 @@ apps:
 	if err != nil {
 		panic(err)
 	}
+	// like the revisions the fake store offers
+	info.Epoch = snap.E("1*")
 
 	f.infos[name] = info
 }
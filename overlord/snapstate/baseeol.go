@@ -0,0 +1,127 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/strutil"
+)
+
+func init() {
+	swfeats.RegisterEnsure("SnapManager", "ensureEOLBasesWarned")
+}
+
+// eolBases maps base snaps to the date their support ended.
+var eolBases = map[string]time.Time{
+	"core18": time.Date(2023, time.May, 31, 0, 0, 0, 0, time.UTC),
+	"core20": time.Date(2025, time.May, 31, 0, 0, 0, 0, time.UTC),
+}
+
+// BaseEOL returns the date on which the given base reached its end of
+// life, and whether it did so already.
+func BaseEOL(base string) (eol time.Time, ok bool) {
+	eol, ok = eolBases[base]
+	if !ok || timeNow().Before(eol) {
+		return time.Time{}, false
+	}
+	return eol, true
+}
+
+// SnapsOnEOLBases returns the names of the installed snaps whose
+// current revision is built on a base that reached its end of life,
+// keyed by base. Snap names are sorted.
+func SnapsOnEOLBases(st *state.State) (map[string][]string, error) {
+	snapStates, err := All(st)
+	if err != nil {
+		return nil, err
+	}
+
+	byBase := make(map[string][]string)
+	for name, snapst := range snapStates {
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			// broken snaps are reported elsewhere
+			continue
+		}
+		if _, ok := BaseEOL(info.Base); !ok {
+			continue
+		}
+		byBase[info.Base] = append(byBase[info.Base], name)
+	}
+	for _, names := range byBase {
+		sort.Strings(names)
+	}
+	return byBase, nil
+}
+
+// ensureEOLBasesWarned warns once per run of snapd about installed
+// snaps that are built on bases that reached their end of life.
+func (m *SnapManager) ensureEOLBasesWarned() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	if m.ensuredEOLBasesWarned {
+		return nil
+	}
+
+	// only run after we are seeded
+	var seeded bool
+	err := m.state.Get("seeded", &seeded)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+
+	logger.Trace("ensure", "manager", "SnapManager", "func", "ensureEOLBasesWarned")
+
+	byBase, err := SnapsOnEOLBases(m.state)
+	if err != nil {
+		return err
+	}
+	bases := make([]string, 0, len(byBase))
+	for base := range byBase {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+	for _, base := range bases {
+		eol, _ := BaseEOL(base)
+		m.state.Warnf("%s built on base %q which reached its end of life on %s: check for channels built on a newer base",
+			snapsPhrase(byBase[base]), base, eol.Format("2006-01-02"))
+	}
+
+	m.ensuredEOLBasesWarned = true
+
+	return nil
+}
+
+func snapsPhrase(names []string) string {
+	if len(names) == 1 {
+		return "snap " + strutil.Quoted(names) + " is"
+	}
+	return "snaps " + strutil.Quoted(names) + " are"
+}
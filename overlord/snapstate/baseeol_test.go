@@ -0,0 +1,151 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (s *snapmgrTestSuite) mockSnapWithBase(c *C, instanceName string) {
+	name, instanceKey := snap.SplitInstanceName(instanceName)
+	snapstate.Set(s.state, instanceName, &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: name, SnapID: name + "-id", Revision: snap.R(1)},
+		}),
+		Current:     snap.R(1),
+		SnapType:    "app",
+		InstanceKey: instanceKey,
+	})
+}
+
+func (s *snapmgrTestSuite) TestBaseEOL(c *C) {
+	restore := snapstate.MockTimeNow(func() time.Time {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	})
+	defer restore()
+
+	eol, ok := snapstate.BaseEOL("core18")
+	c.Check(ok, Equals, true)
+	c.Check(eol, Equals, time.Date(2023, time.May, 31, 0, 0, 0, 0, time.UTC))
+
+	// not yet
+	_, ok = snapstate.BaseEOL("core20")
+	c.Check(ok, Equals, false)
+	// unknown
+	_, ok = snapstate.BaseEOL("core24")
+	c.Check(ok, Equals, false)
+	_, ok = snapstate.BaseEOL("")
+	c.Check(ok, Equals, false)
+}
+
+func (s *snapmgrTestSuite) TestSnapsOnEOLBases(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockSnapWithBase(c, "some-snap-with-base")
+	s.mockSnapWithBase(c, "some-snap")
+
+	byBase, err := snapstate.SnapsOnEOLBases(s.state)
+	c.Assert(err, IsNil)
+	c.Check(byBase, DeepEquals, map[string][]string{
+		"core18": {"some-snap-with-base"},
+	})
+}
+
+func (s *snapmgrTestSuite) TestEnsureEOLBasesWarned(c *C) {
+	restore := snapstate.MockEnsuredEOLBasesWarned(s.snapmgr, false)
+	defer restore()
+
+	s.state.Lock()
+	s.mockSnapWithBase(c, "some-snap-with-base")
+	s.mockSnapWithBase(c, "some-snap")
+	s.state.Unlock()
+
+	// simulate ensure called many times
+	for i := 0; i < 3; i++ {
+		c.Check(s.snapmgr.Ensure(), IsNil)
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	warnings := s.state.AllWarnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Check(warnings[0].String(), Equals, `snap "some-snap-with-base" is built on base "core18" which reached its end of life on 2023-05-31: check for channels built on a newer base`)
+}
+
+func (s *snapmgrTestSuite) TestEnsureEOLBasesWarnedMultipleSnaps(c *C) {
+	restore := snapstate.MockEnsuredEOLBasesWarned(s.snapmgr, false)
+	defer restore()
+
+	s.state.Lock()
+	s.mockSnapWithBase(c, "some-snap-with-base")
+	s.mockSnapWithBase(c, "some-snap-with-base_instance")
+	s.state.Unlock()
+
+	c.Check(s.snapmgr.Ensure(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	warnings := s.state.AllWarnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Check(warnings[0].String(), Equals, `snaps "some-snap-with-base", "some-snap-with-base_instance" are built on base "core18" which reached its end of life on 2023-05-31: check for channels built on a newer base`)
+}
+
+func (s *snapmgrTestSuite) TestEnsureEOLBasesWarnedNotYetEOL(c *C) {
+	restore := snapstate.MockEnsuredEOLBasesWarned(s.snapmgr, false)
+	defer restore()
+	restore = snapstate.MockEOLBases(map[string]time.Time{
+		"core18": time.Now().Add(24 * time.Hour),
+	})
+	defer restore()
+
+	s.state.Lock()
+	s.mockSnapWithBase(c, "some-snap-with-base")
+	s.state.Unlock()
+
+	c.Check(s.snapmgr.Ensure(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.AllWarnings(), HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestEnsureEOLBasesWarnedBlockedOnSeeding(c *C) {
+	restore := snapstate.MockEnsuredEOLBasesWarned(s.snapmgr, false)
+	defer restore()
+
+	s.state.Lock()
+	s.state.Set("seeded", false)
+	s.mockSnapWithBase(c, "some-snap-with-base")
+	s.state.Unlock()
+
+	c.Check(s.snapmgr.Ensure(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.AllWarnings(), HasLen, 0)
+}
@@ -253,7 +253,7 @@ func (bs *bootedSuite) TestUpdateBootRevisionsKernelErrorsEarly(c *C) {
 
 	bs.bootloader.SetBootKernel("canonical-pc-linux_99.snap")
 	err := snapstate.UpdateBootRevisions(st)
-	c.Assert(err, ErrorMatches, `cannot find revision 99 for snap "canonical-pc-linux"`)
+	c.Assert(err, ErrorMatches, `cannot find revision 99 for snap "canonical-pc-linux": retained revisions are .*`)
 }
 
 func (bs *bootedSuite) TestUpdateBootRevisionsOSErrorsEarly(c *C) {
@@ -265,7 +265,7 @@ func (bs *bootedSuite) TestUpdateBootRevisionsOSErrorsEarly(c *C) {
 
 	bs.bootloader.SetBootBase("core_99.snap")
 	err := snapstate.UpdateBootRevisions(st)
-	c.Assert(err, ErrorMatches, `cannot find revision 99 for snap "core"`)
+	c.Assert(err, ErrorMatches, `cannot find revision 99 for snap "core": retained revisions are .*`)
 }
 
 func (bs *bootedSuite) TestUpdateBootRevisionsOSErrorsLate(c *C) {
@@ -162,6 +162,17 @@ func (e *SnapNotClassicError) Error() string {
 	return fmt.Sprintf("snap %q is not a classic confined snap", e.Snap)
 }
 
+// MissingBaseError is returned when the base required by a snap is not
+// installed.
+type MissingBaseError struct {
+	Snap string
+	Base string
+}
+
+func (e *MissingBaseError) Error() string {
+	return fmt.Sprintf("cannot find required base %q", e.Base)
+}
+
 // determine whether the flags (and system overrides thereof) are
 // compatible with the given *snap.Info
 func validateFlagsForInfo(info *snap.Info, snapst *SnapState, flags Flags) error {
@@ -211,8 +222,8 @@ func validateInfoAndFlags(info *snap.Info, snapst *SnapState, flags Flags) error
 	}
 
 	// verify we have a valid architecture
-	if !arch.IsSupportedArchitecture(info.Architectures) {
-		return fmt.Errorf("snap %q supported architectures (%s) are incompatible with this system (%s)", info.InstanceName(), strings.Join(info.Architectures, ", "), arch.DpkgArchitecture())
+	if err := validateArchitecture(info); err != nil {
+		return err
 	}
 
 	// check assumes
@@ -228,6 +239,27 @@ func validateInfoAndFlags(info *snap.Info, snapst *SnapState, flags Flags) error
 	return nil
 }
 
+// validateArchitecture checks that the snap supports the architecture of the
+// system or, for a foreign architecture revision, the one it was requested
+// for. Foreign architecture revisions are only data for other snaps to
+// consume, so they cannot carry apps or hooks that would run on the system.
+func validateArchitecture(info *snap.Info) error {
+	if info.Architecture == "" || info.Architecture == arch.DpkgArchitecture() {
+		if !arch.IsSupportedArchitecture(info.Architectures) {
+			return fmt.Errorf("snap %q supported architectures (%s) are incompatible with this system (%s)", info.InstanceName(), strings.Join(info.Architectures, ", "), arch.DpkgArchitecture())
+		}
+		return nil
+	}
+
+	if !strutil.ListContains(info.Architectures, info.Architecture) && !strutil.ListContains(info.Architectures, "all") {
+		return fmt.Errorf("snap %q supported architectures (%s) do not include the requested architecture (%s)", info.InstanceName(), strings.Join(info.Architectures, ", "), info.Architecture)
+	}
+	if len(info.Apps) != 0 || len(info.Hooks) != 0 {
+		return fmt.Errorf("cannot install snap %q for foreign architecture %q: snap has apps or hooks", info.InstanceName(), info.Architecture)
+	}
+	return nil
+}
+
 var openSnapFile = backend.OpenSnapFile
 
 func validateContainer(c snap.Container, s *snap.Info, logf func(format string, v ...any)) error {
@@ -383,7 +415,7 @@ func checkGadgetOrKernel(st *state.State, snapInfo, curInfo *snap.Info, snapf sn
 		return fmt.Errorf("cannot find original %s snap: %v", kind, err)
 	}
 
-	if currentSnap.SnapID != "" && snapInfo.SnapID == "" && deviceCtx.Model().Grade() != asserts.ModelDangerous {
+	if currentSnap.SnapID != "" && snapInfo.SnapID == "" && EffectiveModelGrade(st, deviceCtx) != asserts.ModelDangerous {
 		return fmt.Errorf("cannot replace signed %s snap with an unasserted one", kind)
 	}
 
@@ -432,7 +464,7 @@ func checkBases(st *state.State, snapInfo, curInfo *snap.Info, _ snap.Container,
 		}
 	}
 
-	return fmt.Errorf("cannot find required base %q", snapInfo.Base)
+	return &MissingBaseError{Snap: snapInfo.InstanceName(), Base: snapInfo.Base}
 }
 
 func checkEpochs(_ *state.State, snapInfo, curInfo *snap.Info, _ snap.Container, _ Flags, deviceCtx DeviceContext) error {
@@ -469,6 +501,24 @@ func earlyEpochCheck(info *snap.Info, snapst *SnapState) error {
 	return checkEpochs(nil, info, cur, nil, Flags{}, nil)
 }
 
+// epochMigrationFor returns the epoch boundary crossed when moving the snap
+// installed in the system (via snapst) to info, or nil if the epoch stays
+// the same or the snap is not installed.
+func epochMigrationFor(info *snap.Info, snapst *SnapState) *EpochMigration {
+	if snapst == nil || !snapst.IsInstalled() {
+		return nil
+	}
+	cur, err := snapst.CurrentInfo()
+	if err != nil {
+		// earlyEpochCheck already dealt with any error that matters
+		return nil
+	}
+	if cur.Epoch.Equal(&info.Epoch) {
+		return nil
+	}
+	return &EpochMigration{From: cur.Epoch, To: info.Epoch}
+}
+
 func earlyChecks(st *state.State, snapst *SnapState, update *snap.Info, comps []snap.ComponentSideInfo, flags Flags) (Flags, error) {
 	flags, err := ensureInstallPreconditions(st, update, flags, snapst)
 	if err != nil {
@@ -94,6 +94,41 @@ architectures:
 	c.Assert(err.Error(), Equals, errorMsg)
 }
 
+func (s *checkSnapSuite) TestCheckSnapForeignArchitecture(c *C) {
+	oldArch := arch.DpkgArchitecture()
+	defer arch.SetArchitecture(arch.ArchitectureType(oldArch))
+	arch.SetArchitecture("amd64")
+
+	for _, t := range []struct {
+		yaml string
+		err  string
+	}{{
+		yaml: "name: hello-data\nversion: 1\narchitectures: [armhf]\n",
+	}, {
+		yaml: "name: hello-data\nversion: 1\narchitectures: [arm64]\n",
+		err:  `snap "hello-data" supported architectures \(arm64\) do not include the requested architecture \(armhf\)`,
+	}, {
+		yaml: "name: hello-data\nversion: 1\narchitectures: [armhf]\napps:\n  hello:\n    command: bin/hello\n",
+		err:  `cannot install snap "hello-data" for foreign architecture "armhf": snap has apps or hooks`,
+	}} {
+		si := &snap.SideInfo{RealName: "hello-data", Revision: snap.R(1), Architecture: "armhf"}
+		info, err := snap.InfoFromSnapYaml([]byte(t.yaml))
+		c.Assert(err, IsNil)
+		info.SideInfo = *si
+
+		restore := snapstate.MockOpenSnapFile(func(path string, si *snap.SideInfo) (*snap.Info, snap.Container, error) {
+			return info, emptyContainer(c), nil
+		})
+		err = snapstate.CheckSnap(s.st, "snap-path", "hello-data", si, nil, snapstate.Flags{}, nil)
+		restore()
+		if t.err == "" {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, t.err)
+		}
+	}
+}
+
 var assumesTests = []struct {
 	version string
 	assumes string
@@ -894,6 +929,7 @@ base: some-base
 	err = snapstate.CheckSnap(st, "snap-path", "requires-base", nil, nil, snapstate.Flags{}, nil)
 	st.Lock()
 	c.Check(err, ErrorMatches, "cannot find required base \"some-base\"")
+	c.Check(err, DeepEquals, &snapstate.MissingBaseError{Snap: "requires-base", Base: "some-base"})
 }
 
 func (s *checkSnapSuite) TestCheckSnapBasesNoneHappy(c *C) {
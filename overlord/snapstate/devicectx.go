@@ -61,6 +61,12 @@ var (
 	RemodelingChange func(st *state.State) *state.Change
 )
 
+// Hook setup by devicestate to get the grade of the model taking into
+// account any override set for testing.
+var EffectiveModelGrade = func(st *state.State, deviceCtx DeviceContext) asserts.ModelGrade {
+	return deviceCtx.Model().Grade()
+}
+
 // ModelFromTask returns a model assertion through the device context for the task.
 func ModelFromTask(task *state.Task) (*asserts.Model, error) {
 	deviceCtx, err := DeviceCtx(task.State(), task, nil)
@@ -0,0 +1,183 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"context"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+)
+
+// downloadedRefresh records a refresh revision that was downloaded ahead of
+// time into the blob directory.
+type downloadedRefresh struct {
+	Revision snap.Revision `json:"revision"`
+	Channel  string        `json:"channel,omitempty"`
+}
+
+// DownloadRefresh returns a set of tasks that download and validate the
+// revision a refresh of the given installed snap would install, without
+// installing it. The blob is kept in the blob directory until the snap is
+// refreshed, so that the refresh does not need to download it again.
+func DownloadRefresh(ctx context.Context, st *state.State, instanceName string, revOpts *RevisionOptions, userID int) (*state.TaskSet, *snap.Info, error) {
+	var snapst SnapState
+	if err := Get(st, instanceName, &snapst); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, nil, err
+	}
+	if !snapst.IsInstalled() {
+		return nil, nil, &snap.NotInstalledError{Snap: instanceName}
+	}
+
+	if err := CheckChangeConflict(st, instanceName, nil); err != nil {
+		return nil, nil, err
+	}
+
+	var opts RevisionOptions
+	if revOpts != nil {
+		opts = *revOpts
+	}
+	if opts.Channel == "" {
+		opts.Channel = snapst.TrackingChannel
+	}
+	if opts.CohortKey == "" && opts.Revision.Unset() && !opts.LeaveCohort {
+		opts.CohortKey = snapst.CohortKey
+	}
+	opts.LeaveCohort = false
+	if !opts.Revision.Unset() && opts.Revision == snapst.Current {
+		return nil, nil, store.ErrNoUpdateAvailable
+	}
+	if opts.ValidationSets == nil {
+		vsets, err := EnforcedValidationSets(st)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts.ValidationSets = vsets
+	}
+
+	ts, info, err := Download(ctx, st, instanceName, nil, dirs.SnapBlobDir, opts, Options{UserID: userID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Revision == snapst.Current {
+		return nil, nil, store.ErrNoUpdateAvailable
+	}
+
+	mark := st.NewTask("mark-snap-downloaded", fmt.Sprintf(i18n.G("Record download of snap %q (%s)"), instanceName, info.Revision))
+	snapsupTask := ts.MaybeEdge(SnapSetupEdge)
+	if snapsupTask == nil {
+		return nil, nil, errors.New("internal error: cannot find snap setup task in download task set")
+	}
+	mark.Set("snap-setup-task", snapsupTask.ID())
+	mark.WaitAll(ts)
+	ts.AddTask(mark)
+
+	return ts, info, nil
+}
+
+func (m *SnapManager) doMarkSnapDownloaded(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	snapsup, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+
+	var downloaded map[string]*downloadedRefresh
+	if err := st.Get("downloaded-refreshes", &downloaded); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if downloaded == nil {
+		downloaded = make(map[string]*downloadedRefresh)
+	}
+	downloaded[snapsup.InstanceName()] = &downloadedRefresh{
+		Revision: snapsup.Revision(),
+		Channel:  snapsup.Channel,
+	}
+	st.Set("downloaded-refreshes", downloaded)
+
+	return addSnapDownloadedNotice(st, snapsup)
+}
+
+// addSnapDownloadedNotice records a snap-downloaded notice for the snap
+// revision described by the given snap setup.
+func addSnapDownloadedNotice(st *state.State, snapsup *SnapSetup) error {
+	data := map[string]string{
+		"revision": snapsup.Revision().String(),
+	}
+	if snapsup.Channel != "" {
+		data["channel"] = snapsup.Channel
+	}
+	_, err := st.AddNotice(nil, state.SnapDownloadedNotice, snapsup.InstanceName(), &state.AddNoticeOptions{
+		Data: data,
+	})
+	return err
+}
+
+// downloadedRefreshesToKeep returns the blob file names of the revisions
+// downloaded ahead of time which were not installed yet.
+func downloadedRefreshesToKeep(st *state.State, snapStates map[string]*SnapState) ([]string, error) {
+	var downloaded map[string]*downloadedRefresh
+	if err := st.Get("downloaded-refreshes", &downloaded); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, fmt.Errorf("cannot get downloaded refreshes: %v", err)
+	}
+
+	var keep []string
+	for name, dl := range downloaded {
+		snapst, ok := snapStates[name]
+		if !ok || snapst.Current == dl.Revision {
+			continue
+		}
+		keep = append(keep, fmt.Sprintf("%s_%s.snap", name, dl.Revision))
+	}
+	return keep, nil
+}
+
+// blobAlreadyDownloaded returns whether the blob at the given path was
+// already downloaded, e.g. by a download action, and matches the expected
+// digest of the download.
+func blobAlreadyDownloaded(path string, downloadInfo *snap.DownloadInfo) bool {
+	if downloadInfo == nil || downloadInfo.Sha3_384 == "" {
+		return false
+	}
+	if !osutil.FileExists(path) {
+		return false
+	}
+	digest, size, err := osutil.FileDigest(path, crypto.SHA3_384)
+	if err != nil {
+		return false
+	}
+	if downloadInfo.Size != 0 && int64(size) != downloadInfo.Size {
+		return false
+	}
+	return hex.EncodeToString(digest) == downloadInfo.Sha3_384
+}
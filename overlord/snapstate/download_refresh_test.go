@@ -0,0 +1,150 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"context"
+	"crypto"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapmgrTestSuite) mockInstalledForDownload(c *C) {
+	si := &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(1)}
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:          true,
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:         si.Revision,
+		TrackingChannel: "latest/stable",
+		SnapType:        "app",
+	})
+}
+
+func (s *snapmgrTestSuite) TestDownloadRefresh(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockInstalledForDownload(c)
+
+	ts, info, err := snapstate.DownloadRefresh(context.Background(), s.state, "some-snap", nil, 0)
+	c.Assert(err, IsNil)
+	c.Check(info.Revision, Not(Equals), snap.R(1))
+
+	var kinds []string
+	for _, t := range ts.Tasks() {
+		kinds = append(kinds, t.Kind())
+	}
+	c.Check(kinds, DeepEquals, []string{"download-snap", "validate-snap", "mark-snap-downloaded"})
+
+	chg := s.state.NewChange("download-snap", "...")
+	chg.AddAll(ts)
+
+	s.settle(c)
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	blob := filepath.Join(dirs.SnapBlobDir, "some-snap_"+info.Revision.String()+".snap")
+	c.Check(s.fakeStore.downloads, DeepEquals, []fakeDownload{{
+		name:   "some-snap",
+		target: blob,
+	}})
+
+	notices := s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.SnapDownloadedNotice}})
+	c.Assert(notices, HasLen, 1)
+	n := noticeToMap(c, notices[0])
+	c.Check(n["key"], Equals, "some-snap")
+	c.Check(n["last-data"], DeepEquals, map[string]any{
+		"revision": info.Revision.String(),
+		"channel":  "latest/stable",
+	})
+
+	// the downloaded blob survives the cleanup of unused downloads
+	restore := snapstate.MockMaxUnusedDownloadRetention(0)
+	defer restore()
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	c.Assert(os.WriteFile(blob, nil, 0644), IsNil)
+	c.Assert(snapstate.CleanDownloads(s.state), IsNil)
+	c.Check(blob, testutil.FilePresent)
+}
+
+func (s *snapmgrTestSuite) TestDownloadRefreshNotInstalled(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, _, err := snapstate.DownloadRefresh(context.Background(), s.state, "some-snap", nil, 0)
+	c.Check(err, DeepEquals, &snap.NotInstalledError{Snap: "some-snap"})
+}
+
+func (s *snapmgrTestSuite) TestDownloadRefreshCurrentRevision(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockInstalledForDownload(c)
+
+	_, _, err := snapstate.DownloadRefresh(context.Background(), s.state, "some-snap", &snapstate.RevisionOptions{Revision: snap.R(1)}, 0)
+	c.Check(err, Equals, store.ErrNoUpdateAvailable)
+}
+
+func (s *snapmgrTestSuite) TestDownloadRefreshConflict(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockInstalledForDownload(c)
+
+	chg := s.state.NewChange("other", "...")
+	t := s.state.NewTask("link-snap", "...")
+	t.Set("snap-setup", &snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: "some-snap"}})
+	chg.AddTask(t)
+
+	_, _, err := snapstate.DownloadRefresh(context.Background(), s.state, "some-snap", nil, 0)
+	c.Check(err, ErrorMatches, `snap "some-snap" has "other" change in progress`)
+}
+
+func (s *snapmgrTestSuite) TestBlobAlreadyDownloaded(c *C) {
+	blob := filepath.Join(c.MkDir(), "some-snap_2.snap")
+	c.Check(snapstate.BlobAlreadyDownloaded(blob, &snap.DownloadInfo{Sha3_384: "abcd"}), Equals, false)
+
+	c.Assert(os.WriteFile(blob, []byte("blob-content"), 0644), IsNil)
+	digest, size, err := osutil.FileDigest(blob, crypto.SHA3_384)
+	c.Assert(err, IsNil)
+	sha3 := hex.EncodeToString(digest)
+
+	c.Check(snapstate.BlobAlreadyDownloaded(blob, &snap.DownloadInfo{Sha3_384: sha3, Size: int64(size)}), Equals, true)
+	c.Check(snapstate.BlobAlreadyDownloaded(blob, &snap.DownloadInfo{Sha3_384: sha3}), Equals, true)
+	// size mismatch
+	c.Check(snapstate.BlobAlreadyDownloaded(blob, &snap.DownloadInfo{Sha3_384: sha3, Size: int64(size) + 1}), Equals, false)
+	// digest mismatch
+	c.Check(snapstate.BlobAlreadyDownloaded(blob, &snap.DownloadInfo{Sha3_384: "abcd"}), Equals, false)
+	// no digest to compare with
+	c.Check(snapstate.BlobAlreadyDownloaded(blob, &snap.DownloadInfo{}), Equals, false)
+	c.Check(snapstate.BlobAlreadyDownloaded(blob, nil), Equals, false)
+}
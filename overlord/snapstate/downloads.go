@@ -0,0 +1,113 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// defaultDownloadConcurrency is the number of snap downloads run in parallel
+// unless configured otherwise via the refresh.download-concurrency option.
+const defaultDownloadConcurrency = 4
+
+// downloadConcurrency returns the maximum number of snap downloads that are
+// allowed to run in parallel.
+func downloadConcurrency(st *state.State) int {
+	var val any
+	if err := config.NewTransaction(st).Get("core", "refresh.download-concurrency", &val); err != nil {
+		return defaultDownloadConcurrency
+	}
+	var concurrency int
+	var err error
+	switch v := val.(type) {
+	case json.Number:
+		concurrency, err = strconv.Atoi(string(v))
+	case string:
+		concurrency, err = strconv.Atoi(v)
+	default:
+		err = fmt.Errorf("unexpected type %T", v)
+	}
+	if err != nil || concurrency < 1 {
+		return defaultDownloadConcurrency
+	}
+	return concurrency
+}
+
+// ensureDownloadConcurrency reads the number of snap downloads that are
+// allowed to run in parallel once per Ensure, so that blockedTask does not
+// need to read the configuration for every candidate task.
+func (m *SnapManager) ensureDownloadConcurrency() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	logger.Trace("ensure", "manager", "SnapManager", "func", "ensureDownloadConcurrency")
+	m.downloadConcurrency = downloadConcurrency(m.state)
+	return nil
+}
+
+// downloadBlocked returns true if the given download task must wait for
+// some of the running downloads to finish before it can start.
+func downloadBlocked(cand *state.Task, running []*state.Task, concurrency int) bool {
+	if cand.Kind() != "download-snap" {
+		return false
+	}
+	if concurrency < 1 {
+		concurrency = defaultDownloadConcurrency
+	}
+	downloading := 0
+	for _, t := range running {
+		if t.Kind() == "download-snap" && t.Status() == state.DoingStatus {
+			downloading++
+		}
+	}
+	return downloading >= concurrency
+}
+
+// sharedDownloadRate returns the rate limit of the download of the given
+// task so that all the downloads of the system which can run in parallel
+// do not exceed the given rate limit together.
+func sharedDownloadRate(t *state.Task, rate int64) int64 {
+	if rate <= 0 {
+		return rate
+	}
+	pending := 0
+	for _, other := range t.State().Tasks() {
+		if other.Kind() != "download-snap" {
+			continue
+		}
+		if status := other.Status(); status == state.DoStatus || status == state.DoingStatus {
+			pending++
+		}
+	}
+	parallel := downloadConcurrency(t.State())
+	if pending < parallel {
+		parallel = pending
+	}
+	if parallel <= 1 {
+		return rate
+	}
+	return rate / int64(parallel)
+}
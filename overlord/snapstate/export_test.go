@@ -21,10 +21,12 @@ package snapstate
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/osutil/power"
 	"github.com/snapcore/snapd/overlord/snapstate/backend"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
@@ -52,6 +54,11 @@ type (
 
 var ComponentSetupTask = componentSetupTask
 
+var (
+	DownloadBlocked    = downloadBlocked
+	SharedDownloadRate = sharedDownloadRate
+)
+
 const (
 	None         = none
 	Full         = full
@@ -202,6 +209,8 @@ var (
 	CleanDownloads     = cleanDownloads
 )
 
+var BlobAlreadyDownloaded = blobAlreadyDownloaded
+
 func MockMaxUnusedDownloadRetention(t time.Duration) func() {
 	old := maxUnusedDownloadRetention
 	maxUnusedDownloadRetention = t
@@ -363,6 +372,30 @@ func MockEnsuredDownloadsCleaned(m *SnapManager, ensured bool) (restore func())
 	}
 }
 
+func (m *SnapManager) EnsureRefreshesPrefetched() error {
+	return m.ensureRefreshesPrefetched()
+}
+
+func (m *SnapManager) EnsureDownloadConcurrency() error {
+	return m.ensureDownloadConcurrency()
+}
+
+func (m *SnapManager) BlockedTask(cand *state.Task, running []*state.Task) bool {
+	return m.blockedTask(cand, running)
+}
+
+func MockEnsuredEOLBasesWarned(m *SnapManager, ensured bool) (restore func()) {
+	old := m.ensuredEOLBasesWarned
+	m.ensuredEOLBasesWarned = ensured
+	return func() {
+		m.ensuredEOLBasesWarned = old
+	}
+}
+
+func MockEOLBases(bases map[string]time.Time) (restore func()) {
+	return testutil.Mock(&eolBases, bases)
+}
+
 func MockPidsOfSnap(f func(instanceName string) (map[string][]int, error)) func() {
 	old := pidsOfSnap
 	pidsOfSnap = f
@@ -600,3 +633,47 @@ type CustomInstallGoal struct {
 func (c *CustomInstallGoal) toInstall(ctx context.Context, st *state.State, opts Options) ([]Target, error) {
 	return c.ToInstall(ctx, st, opts)
 }
+
+func MockWorkloadQuiesceTimeout(timeout time.Duration) (restore func()) {
+	return testutil.Mock(&workloadQuiesceTimeout, timeout)
+}
+
+func MockMaxRefreshHistoryEntries(n int) (restore func()) {
+	return testutil.Mock(&maxRefreshHistoryEntries, n)
+}
+
+var AddRefreshHistoryEntry = addRefreshHistoryEntry
+
+var MaintenanceCalendarBlackoutEnd = (*MaintenanceCalendar).blackoutEnd
+
+func MockPowerGuard(supply func() (*power.SupplyStatus, error), throttled func() (bool, error), largeSnapSize int64) (restore func()) {
+	r1 := testutil.Mock(&powerSupply, supply)
+	r2 := testutil.Mock(&powerThermalThrottled, throttled)
+	r3 := testutil.Mock(&powerGuardLargeSnapSize, largeSnapSize)
+	return func() {
+		r3()
+		r2()
+		r1()
+	}
+}
+
+var (
+	TaskRetryPolicy         = taskRetryPolicy
+	IsTransientNetworkError = isTransientNetworkError
+)
+
+func MockURLDownloadHTTPClient(cli *http.Client) (restore func()) {
+	return testutil.Mock(&urlDownloadHTTPClient, func(*state.State) *http.Client {
+		// a copy, as the redirect policy is set on it
+		c := *cli
+		return &c
+	})
+}
+
+func MockMaxURLSnapSize(size int64) (restore func()) {
+	return testutil.Mock(&maxURLSnapSize, size)
+}
+
+func MockDeriveSideInfoFromStore(f func(st *state.State, userID int, snapPath, snapSHA3_384 string, snapSize uint64, deviceCtx DeviceContext) (*snap.SideInfo, error)) (restore func()) {
+	return testutil.Mock(&DeriveSideInfoFromStore, f)
+}
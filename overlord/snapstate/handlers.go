@@ -262,11 +262,17 @@ func (m *SnapManager) doPrerequisites(t *state.Task, _ *tomb.Tomb) error {
 	defer perfTimings.Save(st)
 
 	// check if we need to inject tasks to install core
-	snapsup, _, err := snapSetupAndState(t)
+	snapsup, snapst, err := snapSetupAndState(t)
 	if err != nil {
 		return err
 	}
 
+	// defer heavy operations while the device is low on battery or
+	// overheating, if so configured
+	if err := checkPowerGuard(t, snapsup, snapst); err != nil {
+		return err
+	}
+
 	// os/base/kernel/gadget cannot have prerequisites other
 	// than the models default base (or core) which is installed anyway
 	switch snapsup.Type {
@@ -700,6 +706,37 @@ func (m *SnapManager) undoPrepareSnap(t *state.Task, _ *tomb.Tomb) error {
 	return nil
 }
 
+func (m *SnapManager) undoDownloadSnap(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	snapsup, err := TaskSnapSetup(t)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+	// the download will not be resumed
+	if err := store.RemovePartialDownload(snapsup.BlobPath()); err != nil {
+		logger.Noticef("cannot remove partial download of %q: %v", snapsup.InstanceName(), err)
+	}
+	return m.undoPrepareSnap(t, nil)
+}
+
+// removePartialDownloadIfAborted removes the partial download of a task
+// whose download failed because the task was aborted, as opposed to e.g.
+// snapd stopping in which case the download is resumed later.
+func removePartialDownloadIfAborted(t *state.Task, targetFn string) {
+	st := t.State()
+	st.Lock()
+	aborted := t.Status() == state.AbortStatus
+	st.Unlock()
+	if !aborted {
+		return
+	}
+	if err := store.RemovePartialDownload(targetFn); err != nil {
+		logger.Noticef("cannot remove partial download %q: %v", targetFn, err)
+	}
+}
+
 func sendOneInstallActionUnlocked(ctx context.Context, st *state.State, snaps StoreSnap, opts Options) (store.SnapActionResult, error) {
 	st.Lock()
 	defer st.Unlock()
@@ -754,7 +791,7 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	snapsup, theStore, user, err := downloadSnapParams(st, t)
 	if snapsup != nil && snapsup.IsAutoRefresh {
 		// NOTE rate is never negative
-		rate = autoRefreshRateLimited(st)
+		rate = sharedDownloadRate(t, autoRefreshRateLimited(st))
 	}
 	st.Unlock()
 	if err != nil {
@@ -773,6 +810,8 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	dlOpts := &store.DownloadOptions{
 		Scheduled: snapsup.IsAutoRefresh,
 		RateLimit: rate,
+		// snaps can be big, do not start over if snapd restarts
+		Resumable: true,
 	}
 	if snapsup.DownloadInfo == nil {
 		vsets, err := EnforcedValidationSets(st)
@@ -802,15 +841,22 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 		})
 		snapsup.SideInfo = &result.SideInfo
 		if err != nil {
+			removePartialDownloadIfAborted(t, targetFn)
 			return err
 		}
 	} else {
 		ctx := tomb.Context(nil) // XXX: should this be a real context?
-		timings.Run(perfTimings, "download", fmt.Sprintf("download snap %q", snapsup.SnapName()), func(timings.Measurer) {
-			err = theStore.Download(ctx, snapsup.SnapName(), targetFn, snapsup.DownloadInfo, meter, user, dlOpts)
-		})
-		if err != nil {
-			return err
+		if blobAlreadyDownloaded(targetFn, snapsup.DownloadInfo) {
+			// downloaded ahead of time, e.g. by a download action
+			logger.Debugf("reusing already downloaded blob %q for snap %q", targetFn, snapsup.InstanceName())
+		} else {
+			timings.Run(perfTimings, "download", fmt.Sprintf("download snap %q", snapsup.SnapName()), func(timings.Measurer) {
+				err = theStore.Download(ctx, snapsup.SnapName(), targetFn, snapsup.DownloadInfo, meter, user, dlOpts)
+			})
+			if err != nil {
+				removePartialDownloadIfAborted(t, targetFn)
+				return err
+			}
 		}
 		// Snap download succeeded, now try to download the snap icon
 		if iconURL == "" {
@@ -840,12 +886,17 @@ func waitForPreDownload(task *state.Task, snapsup *SnapSetup) error {
 	st.Lock()
 	defer st.Unlock()
 
-	tasks, err := findTasksMatchingKindAndSnap(st, "pre-download-snap", snapsup.InstanceName(), snapsup.Revision())
-	if err != nil {
-		return err
+	var tasks []*state.Task
+	for _, kind := range []string{"pre-download-snap", "prefetch-snap"} {
+		kindTasks, err := findTasksMatchingKindAndSnap(st, kind, snapsup.InstanceName(), snapsup.Revision())
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, kindTasks...)
 	}
 
-	// if there is a pre-download task for the same snap, wait for it to finish
+	// if there is a pre-download or prefetch task for the same snap, wait
+	// for it to finish
 	for _, preTask := range tasks {
 		if preTask.Status() != state.DoingStatus {
 			continue
@@ -884,6 +935,7 @@ func (m *SnapManager) doPreDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 		// pre-downloads are only triggered in auto-refreshes
 		Scheduled: true,
 		RateLimit: autoRefreshRateLimited(st),
+		Resumable: true,
 	}
 
 	perfTimings := state.TimingsForTask(t)
@@ -1111,6 +1163,7 @@ func continueInhibitedAutoRefresh(st *state.State, snapName string) error {
 		snaps := []string{snapName}
 		msg := autoRefreshSummary(snaps)
 		chg := st.NewChange(autoRefreshChangeKind, msg)
+		chg.SetPriority(state.PriorityLow)
 		for _, ts := range tss.Refresh {
 			chg.AddAll(ts)
 		}
@@ -3353,6 +3406,19 @@ func (m *SnapManager) stopSnapServices(t *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
+	var stopReason snap.ServiceStopReason
+	if err := t.Get("stop-reason", &stopReason); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+
+	if stopReason == snap.StopReasonRefresh {
+		// workloads reported by the pre-refresh hook must be
+		// quiesced before stopping the services
+		if err := waitWorkloadQuiesce(t, snapsup.InstanceName()); err != nil {
+			return err
+		}
+	}
+
 	currentInfo, err := snapst.CurrentInfo()
 	if err != nil {
 		return err
@@ -3362,11 +3428,6 @@ func (m *SnapManager) stopSnapServices(t *state.Task, _ *tomb.Tomb) error {
 		return nil
 	}
 
-	var stopReason snap.ServiceStopReason
-	if err := t.Get("stop-reason", &stopReason); err != nil && !errors.Is(err, state.ErrNoState) {
-		return err
-	}
-
 	pb := NewTaskProgressAdapterUnlocked(t)
 	st.Unlock()
 	defer st.Lock()
@@ -3437,6 +3498,12 @@ func (m *SnapManager) undoStopSnapServices(t *state.Task, _ *tomb.Tomb) error {
 	if err != nil {
 		return err
 	}
+	// the refresh is not going ahead anymore
+	if chg := t.Change(); chg != nil {
+		if err := clearWorkloadQuiesce(st, snapsup.InstanceName(), chg.ID()); err != nil {
+			return err
+		}
+	}
 	currentInfo, err := snapst.CurrentInfo()
 	if err != nil {
 		return err
@@ -3869,6 +3936,9 @@ func (m *SnapManager) doDiscardSnap(t *state.Task, _ *tomb.Tomb) error {
 		if err := backend.DiscardStoreMetadata(snapsup.SideInfo.SnapID, otherInstances); err != nil {
 			logger.Noticef("cannot remove store metadata for %q: %v", snapsup.InstanceName(), err)
 		}
+		if err := backend.DiscardScaledSnapIcons(snapsup.InstanceName()); err != nil {
+			logger.Noticef("cannot remove scaled icons of %q: %v", snapsup.InstanceName(), err)
+		}
 
 		// XXX: also remove sequence files?
 
@@ -20,6 +20,7 @@
 package snapstate_test
 
 import (
+	"os"
 	"path/filepath"
 	"time"
 
@@ -457,6 +458,12 @@ func (s *downloadSnapSuite) TestDoUndoDownloadSnap(c *C) {
 	terr.WaitFor(t)
 	chg.AddTask(terr)
 
+	// left behind by an earlier attempt
+	partial := filepath.Join(dirs.SnapBlobDir, "foo_33.snap.partial")
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	c.Assert(os.WriteFile(partial, nil, 0644), IsNil)
+	c.Assert(os.WriteFile(partial+".state", nil, 0644), IsNil)
+
 	s.state.Unlock()
 
 	for i := 0; i < 3; i++ {
@@ -469,6 +476,9 @@ func (s *downloadSnapSuite) TestDoUndoDownloadSnap(c *C) {
 
 	// task was undone
 	c.Check(t.Status(), Equals, state.UndoneStatus)
+	// the download will not be resumed
+	c.Check(partial, testutil.FileAbsent)
+	c.Check(partial+".state", testutil.FileAbsent)
 
 	// and nothing is in the state for "foo"
 	var snapst snapstate.SnapState
@@ -516,8 +526,104 @@ func (s *downloadSnapSuite) TestDoDownloadRateLimitedIntegration(c *C) {
 			opts: &store.DownloadOptions{
 				RateLimit: 1234,
 				Scheduled: true,
+				Resumable: true,
 			},
 		},
 	})
 
 }
+
+func (s *downloadSnapSuite) TestDownloadBlocked(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var running []*state.Task
+	for i := 0; i < 2; i++ {
+		t := s.state.NewTask("download-snap", "test")
+		t.SetStatus(state.DoingStatus)
+		running = append(running, t)
+	}
+	// running tasks of other kinds do not count
+	other := s.state.NewTask("link-snap", "test")
+	other.SetStatus(state.DoingStatus)
+	running = append(running, other)
+
+	cand := s.state.NewTask("download-snap", "test")
+	// below the default concurrency
+	c.Check(snapstate.DownloadBlocked(cand, running, 0), Equals, false)
+	// other kinds are never blocked
+	c.Check(snapstate.DownloadBlocked(other, running, 1), Equals, false)
+
+	c.Check(snapstate.DownloadBlocked(cand, running, 2), Equals, true)
+	c.Check(snapstate.DownloadBlocked(cand, running, 3), Equals, false)
+}
+
+func (s *downloadSnapSuite) TestBlockedTaskDownloadConcurrency(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var running []*state.Task
+	for i := 0; i < 2; i++ {
+		t := s.state.NewTask("download-snap", "test")
+		t.SetStatus(state.DoingStatus)
+		running = append(running, t)
+	}
+	cand := s.state.NewTask("download-snap", "test")
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.download-concurrency", 2)
+	tr.Commit()
+
+	// the configuration is only read on Ensure
+	c.Check(s.snapmgr.BlockedTask(cand, running), Equals, false)
+
+	s.state.Unlock()
+	err := s.snapmgr.EnsureDownloadConcurrency()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(s.snapmgr.BlockedTask(cand, running), Equals, true)
+
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "refresh.download-concurrency", "3")
+	tr.Commit()
+
+	s.state.Unlock()
+	err = s.snapmgr.EnsureDownloadConcurrency()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(s.snapmgr.BlockedTask(cand, running), Equals, false)
+}
+
+func (s *downloadSnapSuite) TestSharedDownloadRate(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// the downloads of all the changes share the rate limit
+	var tasks []*state.Task
+	for i := 0; i < 3; i++ {
+		chg := s.state.NewChange("sample", "...")
+		for j := 0; j < 2; j++ {
+			t := s.state.NewTask("download-snap", "test")
+			chg.AddTask(t)
+			tasks = append(tasks, t)
+		}
+	}
+	tasks[5].SetStatus(state.DoneStatus)
+	// tasks of other kinds do not count
+	s.state.NewTask("link-snap", "test")
+
+	// no rate limit
+	c.Check(snapstate.SharedDownloadRate(tasks[0], 0), Equals, int64(0))
+	// 5 pending downloads, but only 4 can run in parallel by default
+	c.Check(snapstate.SharedDownloadRate(tasks[0], 1000), Equals, int64(250))
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.download-concurrency", 8)
+	tr.Commit()
+	c.Check(snapstate.SharedDownloadRate(tasks[0], 1000), Equals, int64(200))
+
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "refresh.download-concurrency", 1)
+	tr.Commit()
+	c.Check(snapstate.SharedDownloadRate(tasks[0], 1000), Equals, int64(1000))
+}
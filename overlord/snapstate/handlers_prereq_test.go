@@ -32,6 +32,8 @@ import (
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/power"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/sequence"
@@ -1120,3 +1122,136 @@ func (s *prereqSuite) TestDoPrereqSkipDuringRemodel(c *C) {
 	err := snapstate.Get(s.state, "core18", &snapst)
 	c.Check(err, testutil.ErrorIs, state.ErrNoState)
 }
+
+func (s *prereqSuite) setupPowerGuardRefresh(c *C, minBattery int) (*state.Change, *state.Task) {
+	// install snapd so that prerequisites handler won't try to install it
+	snapstate.Set(s.state, "snapd", &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "snapd", Revision: snap.R(1)},
+		}),
+		Current: snap.R(1),
+	})
+	snapstate.Set(s.state, "foo", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "foo", Revision: snap.R(1)},
+		}),
+		Current: snap.R(1),
+	})
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "power-guard.min-battery", minBattery)
+	tr.Commit()
+
+	t := s.state.NewTask("prerequisites", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(33),
+		},
+		DownloadInfo: &snap.DownloadInfo{Size: 2000},
+		Base:         "none",
+	})
+	chg := s.state.NewChange("refresh-snap", "...")
+	chg.AddTask(t)
+	return chg, t
+}
+
+func (s *prereqSuite) TestDoPrereqPowerGuardDefersLargeRefresh(c *C) {
+	restore := snapstate.MockPowerGuard(func() (*power.SupplyStatus, error) {
+		return &power.SupplyStatus{HasBattery: true, OnBattery: true, BatteryCapacity: 15}, nil
+	}, func() (bool, error) {
+		return false, nil
+	}, 1000)
+	defer restore()
+
+	s.state.Lock()
+	chg, t := s.setupPowerGuardRefresh(c, 30)
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.DoingStatus)
+	var reason string
+	c.Assert(chg.Get("power-guard-deferral", &reason), IsNil)
+	c.Check(reason, Equals, "running on battery at 15%, below the 30% threshold")
+	c.Assert(t.Log(), HasLen, 1)
+	c.Check(t.Log()[0], Matches, `.* Deferred by power guard: running on battery at 15%, below the 30% threshold`)
+}
+
+func (s *prereqSuite) TestDoPrereqPowerGuardProceeds(c *C) {
+	supply := &power.SupplyStatus{HasBattery: true, OnBattery: false, BatteryCapacity: 15}
+	restore := snapstate.MockPowerGuard(func() (*power.SupplyStatus, error) {
+		return supply, nil
+	}, func() (bool, error) {
+		return false, nil
+	}, 1000)
+	defer restore()
+
+	s.state.Lock()
+	chg, t := s.setupPowerGuardRefresh(c, 30)
+	// previously deferred
+	chg.Set("power-guard-deferral", "running on battery at 10%, below the 30% threshold")
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.DoneStatus)
+	var reason string
+	c.Check(chg.Get("power-guard-deferral", &reason), testutil.ErrorIs, state.ErrNoState)
+	c.Assert(t.Log(), HasLen, 1)
+	c.Check(t.Log()[0], Matches, `.* Power guard conditions cleared, proceeding`)
+}
+
+func (s *prereqSuite) TestDoPrereqPowerGuardThermal(c *C) {
+	restore := snapstate.MockPowerGuard(func() (*power.SupplyStatus, error) {
+		return &power.SupplyStatus{BatteryCapacity: -1}, nil
+	}, func() (bool, error) {
+		return true, nil
+	}, 1000)
+	defer restore()
+
+	s.state.Lock()
+	chg, t := s.setupPowerGuardRefresh(c, 0)
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "power-guard.thermal", true)
+	tr.Commit()
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.DoingStatus)
+	var reason string
+	c.Assert(chg.Get("power-guard-deferral", &reason), IsNil)
+	c.Check(reason, Equals, "system is thermally throttled")
+}
+
+func (s *prereqSuite) TestDoPrereqPowerGuardIgnoresSmallRefresh(c *C) {
+	restore := snapstate.MockPowerGuard(func() (*power.SupplyStatus, error) {
+		return &power.SupplyStatus{HasBattery: true, OnBattery: true, BatteryCapacity: 15}, nil
+	}, func() (bool, error) {
+		return false, nil
+	}, 1000*1000)
+	defer restore()
+
+	s.state.Lock()
+	chg, t := s.setupPowerGuardRefresh(c, 30)
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.DoneStatus)
+	c.Check(chg.Has("power-guard-deferral"), Equals, false)
+}
@@ -0,0 +1,164 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+// maxCalendarPostponement bounds how far ahead auto-refresh looks for the
+// next window of a maintenance calendar. Unlike with refresh.timer,
+// refreshes are never forced outside of the windows of the calendar.
+const maxCalendarPostponement = 2 * 365 * 24 * time.Hour
+
+const blackoutDateLayout = "2006-01-02"
+
+// BlackoutPeriod is a range of days, in local time, during which no
+// auto-refresh happens even inside of a maintenance window.
+type BlackoutPeriod struct {
+	// Start is the first day of the period as YYYY-MM-DD.
+	Start string `json:"start"`
+	// End is the last day of the period as YYYY-MM-DD, if empty the period
+	// is only the Start day.
+	End string `json:"end,omitempty"`
+}
+
+func (p *BlackoutPeriod) bounds(loc *time.Location) (start, end time.Time, err error) {
+	start, err = time.ParseInLocation(blackoutDateLayout, p.Start, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("cannot parse blackout start %q: expected YYYY-MM-DD", p.Start)
+	}
+	end = start
+	if p.End != "" {
+		end, err = time.ParseInLocation(blackoutDateLayout, p.End, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("cannot parse blackout end %q: expected YYYY-MM-DD", p.End)
+		}
+		if end.Before(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("blackout end %s is before its start %s", p.End, p.Start)
+		}
+	}
+	// the end day is included
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+// MaintenanceCalendar restricts auto-refreshes to a set of maintenance
+// windows, with optional blackout periods. When set it takes precedence
+// over refresh.timer.
+type MaintenanceCalendar struct {
+	// Windows are schedules in the refresh.timer format, eg.
+	// "tue2,02:00-04:00" for the second Tuesday of every month between
+	// 2 and 4 AM.
+	Windows   []string         `json:"windows"`
+	Blackouts []BlackoutPeriod `json:"blackouts,omitempty"`
+}
+
+// Validate checks that the windows and blackout periods of the calendar
+// can be parsed.
+func (cal *MaintenanceCalendar) Validate() error {
+	if len(cal.Windows) == 0 {
+		return errors.New("maintenance calendar must have at least one window")
+	}
+	if _, err := cal.schedule(); err != nil {
+		return err
+	}
+	for _, p := range cal.Blackouts {
+		if _, _, err := p.bounds(time.Local); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cal *MaintenanceCalendar) schedule() ([]*timeutil.Schedule, error) {
+	var sched []*timeutil.Schedule
+	for _, window := range cal.Windows {
+		s, err := timeutil.ParseSchedule(window)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse maintenance window %q: %v", window, err)
+		}
+		for _, one := range s {
+			for _, span := range one.ClockSpans {
+				// a single time is not a window refreshes can
+				// be confined to
+				if span.Start == span.End {
+					return nil, fmt.Errorf("cannot use maintenance window %q: times must be ranges", window)
+				}
+			}
+		}
+		sched = append(sched, s...)
+	}
+	return sched, nil
+}
+
+// timer returns the windows of the calendar as a single refresh.timer
+// like string.
+func (cal *MaintenanceCalendar) timer() string {
+	return strings.Join(cal.Windows, ",,")
+}
+
+// blackoutEnd returns when the blackout period including t ends, if any.
+func (cal *MaintenanceCalendar) blackoutEnd(t time.Time) (end time.Time, ok bool) {
+	for _, p := range cal.Blackouts {
+		start, pend, err := p.bounds(t.Location())
+		if err != nil {
+			// validated when set
+			continue
+		}
+		if !t.Before(start) && t.Before(pend) && pend.After(end) {
+			end = pend
+			ok = true
+		}
+	}
+	return end, ok
+}
+
+// MaintenanceCalendarOf returns the maintenance calendar, or nil if none is
+// set.
+func MaintenanceCalendarOf(st *state.State) (*MaintenanceCalendar, error) {
+	var cal MaintenanceCalendar
+	if err := st.Get("maintenance-calendar", &cal); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cal, nil
+}
+
+// SetMaintenanceCalendar sets the maintenance calendar used to schedule
+// auto-refreshes, a nil calendar removes it.
+func SetMaintenanceCalendar(st *state.State, cal *MaintenanceCalendar) error {
+	if cal == nil {
+		st.Set("maintenance-calendar", nil)
+		return nil
+	}
+	if err := cal.Validate(); err != nil {
+		return err
+	}
+	st.Set("maintenance-calendar", cal)
+	return nil
+}
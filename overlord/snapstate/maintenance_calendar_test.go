@@ -0,0 +1,215 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"fmt"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type maintenanceCalendarSuite struct{}
+
+var _ = Suite(&maintenanceCalendarSuite{})
+
+func (s *maintenanceCalendarSuite) TestValidate(c *C) {
+	for _, t := range []struct {
+		cal *snapstate.MaintenanceCalendar
+		err string
+	}{
+		{&snapstate.MaintenanceCalendar{
+			Windows:   []string{"tue2,02:00-04:00", "sat5,22:00-1:00"},
+			Blackouts: []snapstate.BlackoutPeriod{{Start: "2026-12-20", End: "2027-01-03"}, {Start: "2026-07-04"}},
+		}, ""},
+		{&snapstate.MaintenanceCalendar{}, `maintenance calendar must have at least one window`},
+		{&snapstate.MaintenanceCalendar{Windows: []string{"tue2,25:00-26:00"}}, `cannot parse maintenance window "tue2,25:00-26:00": .*`},
+		{&snapstate.MaintenanceCalendar{Windows: []string{"tue2,02:00"}}, `cannot use maintenance window "tue2,02:00": times must be ranges`},
+		{&snapstate.MaintenanceCalendar{
+			Windows:   []string{"02:00-04:00"},
+			Blackouts: []snapstate.BlackoutPeriod{{Start: "20/12/2026"}},
+		}, `cannot parse blackout start "20/12/2026": expected YYYY-MM-DD`},
+		{&snapstate.MaintenanceCalendar{
+			Windows:   []string{"02:00-04:00"},
+			Blackouts: []snapstate.BlackoutPeriod{{Start: "2026-12-20", End: "soon"}},
+		}, `cannot parse blackout end "soon": expected YYYY-MM-DD`},
+		{&snapstate.MaintenanceCalendar{
+			Windows:   []string{"02:00-04:00"},
+			Blackouts: []snapstate.BlackoutPeriod{{Start: "2026-12-20", End: "2026-12-19"}},
+		}, `blackout end 2026-12-19 is before its start 2026-12-20`},
+	} {
+		err := t.cal.Validate()
+		if t.err == "" {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, t.err)
+		}
+	}
+}
+
+func (s *maintenanceCalendarSuite) TestBlackoutEnd(c *C) {
+	cal := &snapstate.MaintenanceCalendar{
+		Windows: []string{"02:00-04:00"},
+		Blackouts: []snapstate.BlackoutPeriod{
+			{Start: "2026-12-20", End: "2027-01-03"},
+			{Start: "2027-01-02", End: "2027-01-05"},
+			{Start: "2027-03-01"},
+		},
+	}
+
+	date := func(s string) time.Time {
+		t, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		c.Assert(err, IsNil)
+		return t
+	}
+
+	for _, t := range []struct {
+		at  string
+		end string
+	}{
+		{"2026-12-19 23:59", ""},
+		{"2026-12-20 00:00", "2027-01-04 00:00"},
+		{"2026-12-31 12:00", "2027-01-04 00:00"},
+		// the periods overlap, the latest end wins
+		{"2027-01-02 12:00", "2027-01-06 00:00"},
+		{"2027-01-06 00:00", ""},
+		{"2027-03-01 23:59", "2027-03-02 00:00"},
+		{"2027-03-02 00:00", ""},
+	} {
+		end, ok := snapstate.MaintenanceCalendarBlackoutEnd(cal, date(t.at))
+		if t.end == "" {
+			c.Check(ok, Equals, false, Commentf(t.at))
+		} else {
+			c.Check(ok, Equals, true, Commentf(t.at))
+			c.Check(end.Equal(date(t.end)), Equals, true, Commentf("%s: %s", t.at, end))
+		}
+	}
+}
+
+func (s *maintenanceCalendarSuite) TestSetMaintenanceCalendar(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	cal, err := snapstate.MaintenanceCalendarOf(st)
+	c.Assert(err, IsNil)
+	c.Check(cal, IsNil)
+
+	err = snapstate.SetMaintenanceCalendar(st, &snapstate.MaintenanceCalendar{})
+	c.Assert(err, ErrorMatches, `maintenance calendar must have at least one window`)
+
+	expected := &snapstate.MaintenanceCalendar{
+		Windows:   []string{"tue2,02:00-04:00"},
+		Blackouts: []snapstate.BlackoutPeriod{{Start: "2026-12-20", End: "2027-01-03"}},
+	}
+	err = snapstate.SetMaintenanceCalendar(st, expected)
+	c.Assert(err, IsNil)
+
+	cal, err = snapstate.MaintenanceCalendarOf(st)
+	c.Assert(err, IsNil)
+	c.Check(cal, DeepEquals, expected)
+
+	err = snapstate.SetMaintenanceCalendar(st, nil)
+	c.Assert(err, IsNil)
+	cal, err = snapstate.MaintenanceCalendarOf(st)
+	c.Assert(err, IsNil)
+	c.Check(cal, IsNil)
+}
+
+// clockRange returns a refresh.timer time range from now+from to now+to,
+// split at midnight if needed as ranges cannot cross it.
+func clockRange(from, to time.Duration) string {
+	now := time.Now()
+	start := now.Add(from)
+	end := now.Add(to)
+	if start.YearDay() != end.YearDay() {
+		return fmt.Sprintf("%02d:%02d-24:00,00:00-%02d:%02d", start.Hour(), start.Minute(), end.Hour(), end.Minute())
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", start.Hour(), start.Minute(), end.Hour(), end.Minute())
+}
+
+func (s *autoRefreshTestSuite) TestMaintenanceCalendarRefreshInsideWindow(c *C) {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	// the calendar takes precedence
+	tr.Set("core", "refresh.timer", clockRange(2*time.Hour, 3*time.Hour))
+	tr.Commit()
+	window := clockRange(-time.Hour, time.Hour)
+	err := snapstate.SetMaintenanceCalendar(s.state, &snapstate.MaintenanceCalendar{
+		Windows: []string{window},
+	})
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+
+	af := snapstate.NewAutoRefresh(s.state)
+	err = af.Ensure()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+
+	s.state.Lock()
+	refreshScheduleStr, legacy, err := af.RefreshSchedule()
+	s.state.Unlock()
+	c.Check(err, IsNil)
+	c.Check(refreshScheduleStr, Equals, window)
+	c.Check(legacy, Equals, false)
+}
+
+func (s *autoRefreshTestSuite) TestMaintenanceCalendarNoRefreshOutsideWindow(c *C) {
+	s.state.Lock()
+	err := snapstate.SetMaintenanceCalendar(s.state, &snapstate.MaintenanceCalendar{
+		Windows: []string{clockRange(2*time.Hour, 3*time.Hour)},
+	})
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+
+	af := snapstate.NewAutoRefresh(s.state)
+	err = af.Ensure()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+
+	// postponed to the start of the window
+	next := af.NextRefresh()
+	c.Check(next.After(time.Now().Add(time.Hour)), Equals, true, Commentf("%s", next))
+	c.Check(next.Before(time.Now().Add(3*time.Hour)), Equals, true, Commentf("%s", next))
+}
+
+func (s *autoRefreshTestSuite) TestMaintenanceCalendarNoRefreshDuringBlackout(c *C) {
+	now := time.Now()
+	s.state.Lock()
+	err := snapstate.SetMaintenanceCalendar(s.state, &snapstate.MaintenanceCalendar{
+		Windows:   []string{clockRange(-time.Hour, time.Hour)},
+		Blackouts: []snapstate.BlackoutPeriod{{Start: now.Format("2006-01-02")}},
+	})
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+
+	af := snapstate.NewAutoRefresh(s.state)
+	err = af.Ensure()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+
+	// postponed to the end of the blackout
+	y, m, d := now.Date()
+	c.Check(af.NextRefresh().Equal(time.Date(y, m, d+1, 0, 0, 0, 0, time.Local)), Equals, true, Commentf("%s", af.NextRefresh()))
+}
@@ -0,0 +1,130 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil/power"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var (
+	powerSupply           = power.Supply
+	powerThermalThrottled = power.ThermalThrottled
+
+	// refreshes of snaps at least this big are subject to the power guard
+	powerGuardLargeSnapSize int64 = 100 * 1024 * 1024
+	// how often deferred changes check again whether they can proceed
+	powerGuardRetryDelay = 10 * time.Minute
+)
+
+// powerGuardDeferralKey is the change data under which the reason for
+// deferring a heavy change because of the power guard is recorded.
+const powerGuardDeferralKey = "power-guard-deferral"
+
+// powerGuardDeferral returns why heavy changes should be deferred according
+// to the power guard policy set via the power-guard.min-battery and
+// power-guard.thermal system options, or an empty string if they can
+// proceed.
+func powerGuardDeferral(st *state.State) (string, error) {
+	tr := config.NewTransaction(st)
+	var minBatteryStr json.Number
+	if err := tr.GetMaybe("core", "power-guard.min-battery", &minBatteryStr); err != nil && !errors.Is(err, state.ErrNoState) {
+		return "", err
+	}
+	var thermal bool
+	if err := tr.GetMaybe("core", "power-guard.thermal", &thermal); err != nil && !errors.Is(err, state.ErrNoState) {
+		return "", err
+	}
+
+	if minBatteryStr != "" {
+		minBattery, err := strconv.Atoi(string(minBatteryStr))
+		if err != nil {
+			return "", fmt.Errorf("cannot parse power-guard.min-battery: %v", err)
+		}
+		if minBattery > 0 {
+			supply, err := powerSupply()
+			if err != nil {
+				logger.Noticef("cannot determine the power supply status: %v", err)
+			} else if supply.OnBattery && supply.BatteryCapacity >= 0 && supply.BatteryCapacity < minBattery {
+				return fmt.Sprintf("running on battery at %d%%, below the %d%% threshold", supply.BatteryCapacity, minBattery), nil
+			}
+		}
+	}
+
+	if thermal {
+		throttled, err := powerThermalThrottled()
+		if err != nil {
+			logger.Noticef("cannot determine whether the system is thermally throttled: %v", err)
+		} else if throttled {
+			return "system is thermally throttled", nil
+		}
+	}
+
+	return "", nil
+}
+
+// isHeavySnapOperation returns whether the operation on the snap performed
+// by the change of the given task should be subject to the power guard,
+// which is the case for refreshes of large snaps and for remodels.
+func isHeavySnapOperation(t *state.Task, snapsup *SnapSetup, snapst *SnapState) bool {
+	if chg := t.Change(); chg != nil && chg.Kind() == "remodel" {
+		return true
+	}
+	return snapst.IsInstalled() && snapsup.DownloadInfo != nil && snapsup.DownloadInfo.Size >= powerGuardLargeSnapSize
+}
+
+// checkPowerGuard returns a state.Retry error if the heavy operation the
+// task is part of must be deferred because of the power guard policy. The
+// reason for the deferral is recorded in the change and logged in the task.
+func checkPowerGuard(t *state.Task, snapsup *SnapSetup, snapst *SnapState) error {
+	if !isHeavySnapOperation(t, snapsup, snapst) {
+		return nil
+	}
+	reason, err := powerGuardDeferral(t.State())
+	if err != nil {
+		return err
+	}
+
+	chg := t.Change()
+	var prevReason string
+	if err := chg.Get(powerGuardDeferralKey, &prevReason); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if reason == "" {
+		if prevReason != "" {
+			chg.Set(powerGuardDeferralKey, nil)
+			t.Logf("Power guard conditions cleared, proceeding")
+		}
+		return nil
+	}
+	if reason != prevReason {
+		chg.Set(powerGuardDeferralKey, reason)
+		t.Logf("Deferred by power guard: %s", reason)
+	}
+	return &state.Retry{After: powerGuardRetryDelay, Reason: reason}
+}
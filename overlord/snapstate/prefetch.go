@@ -0,0 +1,244 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
+	"github.com/snapcore/snapd/timings"
+)
+
+var prefetchRefreshesChangeKind = swfeats.RegisterChangeKind("prefetch-refreshes")
+
+var _ = swfeats.DescribeChangeKind(prefetchRefreshesChangeKind, "Download pending refreshes ahead of the next auto-refresh",
+	"prefetch-snap")
+
+func init() {
+	swfeats.RegisterEnsure("SnapManager", "ensureRefreshesPrefetched")
+}
+
+// refreshPrefetchRetryDelay is the minimum time between two attempts at
+// prefetching the pending refreshes.
+var refreshPrefetchRetryDelay = time.Hour
+
+// refreshPrefetchWindow returns the schedule configured with
+// refresh.prefetch-window, or nil if prefetching is disabled.
+func refreshPrefetchWindow(st *state.State) ([]*timeutil.Schedule, error) {
+	tr := config.NewTransaction(st)
+	var window string
+	if err := tr.GetMaybe("core", "refresh.prefetch-window", &window); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if window == "" {
+		return nil, nil
+	}
+	sched, err := timeutil.ParseSchedule(window)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse refresh.prefetch-window: %v", err)
+	}
+	return sched, nil
+}
+
+// canPrefetchRefreshes returns whether downloads ahead of refreshes are
+// allowed now with respect to the store and network state.
+func canPrefetchRefreshes(st *state.State) (bool, error) {
+	online, err := isStoreOnline(st)
+	if err != nil || !online {
+		return false, err
+	}
+
+	// see refreshHints.Ensure
+	if CanAutoRefresh == nil {
+		return false, nil
+	}
+	if ok, err := CanAutoRefresh(st); err != nil || !ok {
+		return false, err
+	}
+
+	can, err := canRefreshOnMeteredConnection(st)
+	if err != nil {
+		return false, err
+	}
+	if !can && IsOnMeteredConnection != nil {
+		// ignore errors as done for auto-refreshes
+		if metered, _ := IsOnMeteredConnection(); metered {
+			logger.Debugf("Prefetching of refreshes disabled on metered connections")
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func prefetchInFlight(st *state.State) bool {
+	for _, chg := range st.Changes() {
+		if chg.Kind() == prefetchRefreshesChangeKind && !chg.IsReady() {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureRefreshesPrefetched downloads the pending refreshes recorded in
+// refresh-candidates while inside of the refresh.prefetch-window, so that
+// the later auto-refresh only needs to install them.
+func (m *SnapManager) ensureRefreshesPrefetched() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	window, err := refreshPrefetchWindow(m.state)
+	if err != nil || window == nil {
+		return err
+	}
+	now := timeNow()
+	if !timeutil.Includes(window, now) {
+		return nil
+	}
+
+	lastPrefetch, err := getTime(m.state, "last-refresh-prefetch")
+	if err != nil {
+		return err
+	}
+	if now.Sub(lastPrefetch) < refreshPrefetchRetryDelay || prefetchInFlight(m.state) {
+		return nil
+	}
+
+	if ok, err := canPrefetchRefreshes(m.state); err != nil || !ok {
+		return err
+	}
+
+	var candidates map[string]*refreshCandidate
+	if err := m.state.Get("refresh-candidates", &candidates); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+
+	var names []string
+	for name, cand := range candidates {
+		if cand.Prefetched || cand.DownloadInfo == nil {
+			continue
+		}
+		// pre-downloads of inhibited refreshes are already on it
+		preDownloads, err := findTasksMatchingKindAndSnap(m.state, "pre-download-snap", name, cand.Revision())
+		if err != nil {
+			return err
+		}
+		if len(preDownloads) > 0 {
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	logger.Trace("ensure", "manager", "SnapManager", "func", "ensureRefreshesPrefetched")
+
+	m.state.Set("last-refresh-prefetch", now)
+
+	chg := m.state.NewChange(prefetchRefreshesChangeKind, fmt.Sprintf(i18n.G("Prefetch %s ahead of auto-refresh"), strutil.Quoted(names)))
+	chg.SetPriority(state.PriorityBackground)
+	for _, name := range names {
+		snapsup := &candidates[name].SnapSetup
+		revisionStr := fmt.Sprintf(" (%s)", snapsup.Revision())
+		t := m.state.NewTask("prefetch-snap", fmt.Sprintf(i18n.G("Prefetch snap %q%s from channel %q"), name, revisionStr, snapsup.Channel))
+		t.Set("snap-setup", snapsup)
+		chg.AddTask(t)
+	}
+
+	return nil
+}
+
+// doPrefetchSnap downloads the blob of a refresh candidate and records it as
+// prefetched. Unlike pre-download-snap it never continues into a refresh.
+func (m *SnapManager) doPrefetchSnap(t *state.Task, tomb *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	snapsup, theStore, user, err := downloadSnapParams(st, t)
+	if err != nil {
+		return err
+	}
+
+	targetFn := snapsup.BlobPath()
+	dlOpts := &store.DownloadOptions{
+		// prefetches are only triggered ahead of auto-refreshes
+		Scheduled: true,
+		RateLimit: autoRefreshRateLimited(st),
+	}
+
+	perfTimings := state.TimingsForTask(t)
+	st.Unlock()
+	timings.Run(perfTimings, "prefetch", fmt.Sprintf("prefetch snap %q", snapsup.SnapName()), func(timings.Measurer) {
+		err = theStore.Download(tomb.Context(nil), snapsup.SnapName(), targetFn, snapsup.DownloadInfo, nil, user, dlOpts)
+	})
+	st.Lock()
+	if err != nil {
+		return err
+	}
+	perfTimings.Save(st)
+
+	// unblock any download tasks that waited for this one
+	var waitingTasks []string
+	if err := t.Get("waiting-tasks", &waitingTasks); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	for _, taskID := range waitingTasks {
+		st.Task(taskID).At(time.Time{})
+	}
+	if len(waitingTasks) > 0 {
+		st.EnsureBefore(0)
+	}
+
+	if err := markRefreshCandidatePrefetched(st, snapsup); err != nil {
+		return err
+	}
+	return addSnapDownloadedNotice(st, snapsup)
+}
+
+// markRefreshCandidatePrefetched records that the blob of the refresh
+// candidate matching the given snap setup was downloaded.
+func markRefreshCandidatePrefetched(st *state.State, snapsup *SnapSetup) error {
+	var candidates map[string]*refreshCandidate
+	if err := st.Get("refresh-candidates", &candidates); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	cand, ok := candidates[snapsup.InstanceName()]
+	if !ok || cand.Revision() != snapsup.Revision() {
+		// the candidate changed in the meantime
+		return nil
+	}
+	cand.Prefetched = true
+	st.Set("refresh-candidates", candidates)
+	return nil
+}
@@ -0,0 +1,166 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+)
+
+func (s *snapmgrTestSuite) mockPrefetchCandidates(c *C, window string) {
+	snapstate.CanAutoRefresh = func(*state.State) (bool, error) { return true, nil }
+
+	restore := snapstate.MockTimeNow(func() time.Time {
+		// a Tuesday
+		return time.Date(2025, 3, 4, 2, 30, 0, 0, time.UTC)
+	})
+	s.AddCleanup(restore)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.prefetch-window", window)
+	tr.Commit()
+
+	candidates := make(map[string]*snapstate.RefreshCandidate)
+	for _, name := range []string{"some-snap", "other-snap"} {
+		si := &snap.SideInfo{RealName: name, SnapID: name + "-id", Revision: snap.R(1)}
+		snapstate.Set(s.state, name, &snapstate.SnapState{
+			Active:   true,
+			Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+			Current:  si.Revision,
+		})
+		candidates[name] = &snapstate.RefreshCandidate{
+			SnapSetup: snapstate.SnapSetup{
+				SideInfo:     &snap.SideInfo{RealName: name, SnapID: name + "-id", Revision: snap.R(2)},
+				DownloadInfo: &snap.DownloadInfo{DownloadURL: "https://example.com/" + name},
+				Channel:      "stable",
+			},
+		}
+	}
+	candidates["other-snap"].Prefetched = true
+	s.state.Set("refresh-candidates", candidates)
+}
+
+func (s *snapmgrTestSuite) TestEnsureRefreshesPrefetched(c *C) {
+	s.mockPrefetchCandidates(c, "mon-fri,01:00-05:00")
+
+	c.Assert(s.snapmgr.EnsureRefreshesPrefetched(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg := findChange(s.state, "prefetch-refreshes")
+	c.Assert(chg, NotNil)
+	c.Check(chg.Summary(), Equals, `Prefetch "some-snap" ahead of auto-refresh`)
+	c.Check(chg.Priority(), Equals, state.PriorityBackground)
+	tasks := chg.Tasks()
+	c.Assert(tasks, HasLen, 1)
+	c.Check(tasks[0].Kind(), Equals, "prefetch-snap")
+	c.Check(tasks[0].Summary(), Equals, `Prefetch snap "some-snap" (2) from channel "stable"`)
+
+	// keep the rest of the managers from auto-refreshing
+	snapstate.CanAutoRefresh = nil
+	s.settle(c)
+
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(s.fakeStore.downloads, DeepEquals, []fakeDownload{{
+		name:   "some-snap",
+		target: filepath.Join(dirs.SnapBlobDir, "some-snap_2.snap"),
+		opts:   &store.DownloadOptions{Scheduled: true},
+	}})
+
+	var candidates map[string]*snapstate.RefreshCandidate
+	c.Assert(s.state.Get("refresh-candidates", &candidates), IsNil)
+	c.Check(candidates["some-snap"].Prefetched, Equals, true)
+	c.Check(candidates["other-snap"].Prefetched, Equals, true)
+
+	notices := s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.SnapDownloadedNotice}})
+	c.Assert(notices, HasLen, 1)
+	n := noticeToMap(c, notices[0])
+	c.Check(n["key"], Equals, "some-snap")
+	c.Check(n["last-data"], DeepEquals, map[string]any{"revision": "2", "channel": "stable"})
+}
+
+func (s *snapmgrTestSuite) TestEnsureRefreshesPrefetchedOnlyOncePerDelay(c *C) {
+	s.mockPrefetchCandidates(c, "mon-fri,01:00-05:00")
+
+	c.Assert(s.snapmgr.EnsureRefreshesPrefetched(), IsNil)
+
+	s.state.Lock()
+	c.Assert(s.state.Changes(), HasLen, 1)
+	// the first attempt failed
+	s.state.Changes()[0].Abort()
+	s.state.Changes()[0].SetStatus(state.ErrorStatus)
+	s.state.Unlock()
+
+	c.Assert(s.snapmgr.EnsureRefreshesPrefetched(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 1)
+}
+
+func (s *snapmgrTestSuite) TestEnsureRefreshesPrefetchedOutsideOfWindow(c *C) {
+	s.mockPrefetchCandidates(c, "sat-sun,01:00-05:00")
+
+	c.Assert(s.snapmgr.EnsureRefreshesPrefetched(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestEnsureRefreshesPrefetchedDisabled(c *C) {
+	s.mockPrefetchCandidates(c, "")
+
+	c.Assert(s.snapmgr.EnsureRefreshesPrefetched(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestEnsureRefreshesPrefetchedOffline(c *C) {
+	s.mockPrefetchCandidates(c, "mon-fri,01:00-05:00")
+
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "store.access", "offline")
+	tr.Commit()
+	s.state.Unlock()
+
+	c.Assert(s.snapmgr.EnsureRefreshesPrefetched(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 0)
+}
@@ -0,0 +1,138 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// queuedOpRetryInterval is how often a queued operation checks whether
+// the changes it conflicted with are done.
+var queuedOpRetryInterval = 10 * time.Second
+
+// QueuedOp describes a snap operation run by a run-queued-op task, once
+// the changes it conflicted with are done or once the scheduled time of
+// its change is reached.
+type QueuedOp struct {
+	// Action is the operation, one of "install", "refresh" or "remove".
+	Action string `json:"action"`
+	// Snaps are the snaps to operate on, an empty list means all the
+	// snaps relevant to the action.
+	Snaps []string `json:"snaps,omitempty"`
+	// Components are the components to operate on, by snap.
+	Components map[string][]string `json:"components,omitempty"`
+	// Many is set for operations requested for a list of snaps rather
+	// than for a single snap.
+	Many   bool `json:"many,omitempty"`
+	UserID int  `json:"user-id,omitempty"`
+
+	Channel        string        `json:"channel,omitempty"`
+	Revision       snap.Revision `json:"revision"`
+	CohortKey      string        `json:"cohort-key,omitempty"`
+	LeaveCohort    bool          `json:"leave-cohort,omitempty"`
+	Architecture   string        `json:"architecture,omitempty"`
+	ValidationSets []string      `json:"validation-sets,omitempty"`
+
+	Flags                  Flags `json:"flags"`
+	Purge                  bool  `json:"purge,omitempty"`
+	Terminate              bool  `json:"terminate,omitempty"`
+	SystemRestartImmediate bool  `json:"system-restart-immediate,omitempty"`
+}
+
+// QueuedOpTasks creates the task sets of the given operation carried by a
+// run-queued-op task. It is set by the daemon, which creates the task sets
+// of the operations requested through the API.
+var QueuedOpTasks = func(t *state.Task, op *QueuedOp) ([]*state.TaskSet, error) {
+	panic("internal error: snapstate.QueuedOpTasks is unset")
+}
+
+// NewQueuedOpTask returns a task for the given operation, which conflicted
+// with changes in progress or is scheduled for later. Once the conflicts
+// clear, the task adds the task sets of the operation, as created by
+// QueuedOpTasks, to its change.
+func NewQueuedOpTask(st *state.State, summary string, op *QueuedOp) *state.Task {
+	t := st.NewTask("run-queued-op", summary)
+	t.Set("queued-op", op)
+	return t
+}
+
+// queuedOpConflict checks whether the snaps of the operation are still
+// affected by other changes. It only looks at the state, unlike creating
+// the tasks of the operation which can reach out to the store.
+func queuedOpConflict(st *state.State, op *QueuedOp, ignoreChangeID string) error {
+	names := op.Snaps
+	if len(names) == 0 {
+		all, err := All(st)
+		if err != nil {
+			return err
+		}
+		for name := range all {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	return CheckChangeConflictMany(st, names, ignoreChangeID)
+}
+
+// retryOnConflict turns conflicts of a queued operation into a retry.
+func retryOnConflict(err error) error {
+	if errors.Is(err, &ChangeConflictError{}) {
+		return &state.Retry{After: queuedOpRetryInterval, Reason: err.Error()}
+	}
+	return err
+}
+
+func (m *SnapManager) doRunQueuedOp(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var op QueuedOp
+	if err := t.Get("queued-op", &op); err != nil {
+		return err
+	}
+	chg := t.Change()
+
+	if err := queuedOpConflict(st, &op, chg.ID()); err != nil {
+		return retryOnConflict(err)
+	}
+	// conflicts not visible from the snaps alone, e.g. with the
+	// prerequisites of a snap, are only found when creating the tasks
+	tss, err := QueuedOpTasks(t, &op)
+	if err != nil {
+		return retryOnConflict(err)
+	}
+
+	for _, ts := range tss {
+		ts.WaitFor(t)
+		chg.AddAll(ts)
+	}
+
+	t.SetStatus(state.DoneStatus)
+	st.EnsureBefore(0)
+	return nil
+}
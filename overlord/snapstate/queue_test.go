@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapmgrTestSuite) TestRunQueuedOp(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var ops []*snapstate.QueuedOp
+	defer testutil.Mock(&snapstate.QueuedOpTasks, func(t *state.Task, op *snapstate.QueuedOp) ([]*state.TaskSet, error) {
+		ops = append(ops, op)
+		return []*state.TaskSet{state.NewTaskSet(t.State().NewTask("nop", "do nothing"))}, nil
+	})()
+
+	// a change in progress for the snap
+	other := s.state.NewChange("refresh-snap", "...")
+	inProgress := s.state.NewTask("unknown-kind", "...")
+	inProgress.Set("snap-setup", &snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: "foo"}})
+	other.AddTask(inProgress)
+
+	op := &snapstate.QueuedOp{
+		Action: "remove",
+		Snaps:  []string{"foo"},
+		Flags:  snapstate.Flags{Transaction: client.TransactionAllSnaps},
+		Purge:  true,
+	}
+	chg := s.state.NewChange("remove-snap", "Queued remove of \"foo\"")
+	t := snapstate.NewQueuedOpTask(s.state, "Queued remove of \"foo\"", op)
+	chg.AddTask(t)
+
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	// the conflict is still there, so the task is retried later without
+	// creating the tasks of the operation
+	c.Check(ops, HasLen, 0)
+	c.Check(t.Status(), Equals, state.DoingStatus)
+	c.Check(t.AtTime().IsZero(), Equals, false)
+	c.Check(chg.Tasks(), HasLen, 1)
+
+	// once the conflict clears, the tasks of the operation are added
+	inProgress.SetStatus(state.DoneStatus)
+	t.At(time.Time{})
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	c.Assert(ops, HasLen, 1)
+	c.Check(ops[0], DeepEquals, op)
+	c.Check(t.Status(), Equals, state.DoneStatus)
+	tasks := chg.Tasks()
+	c.Assert(tasks, HasLen, 2)
+	c.Check(tasks[1].Kind(), Equals, "nop")
+	c.Check(tasks[1].WaitTasks(), DeepEquals, []*state.Task{t})
+}
+
+func (s *snapmgrTestSuite) TestRunQueuedOpConflictWhenCreatingTasks(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	calls := 0
+	defer testutil.Mock(&snapstate.QueuedOpTasks, func(t *state.Task, op *snapstate.QueuedOp) ([]*state.TaskSet, error) {
+		calls++
+		return nil, &snapstate.ChangeConflictError{Snap: "core", ChangeKind: "refresh"}
+	})()
+
+	chg := s.state.NewChange("install-snap", "Queued install of \"foo\"")
+	t := snapstate.NewQueuedOpTask(s.state, "Queued install of \"foo\"", &snapstate.QueuedOp{
+		Action: "install",
+		Snaps:  []string{"foo"},
+	})
+	chg.AddTask(t)
+
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	c.Check(calls, Equals, 1)
+	c.Check(t.Status(), Equals, state.DoingStatus)
+	c.Check(t.AtTime().IsZero(), Equals, false)
+	c.Check(chg.Tasks(), HasLen, 1)
+}
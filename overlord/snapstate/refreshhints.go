@@ -27,6 +27,7 @@ import (
 
 	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/state"
@@ -212,6 +213,7 @@ func refreshHintsFromUpdatePlan(st *state.State, plan updatePlan, deviceCtx Devi
 			SnapSetup:  snapsup,
 			Components: compsups,
 			Monitored:  IsSnapMonitored(st, info.InstanceName()),
+			Prefetched: osutil.FileExists(snapsup.BlobPath()),
 		}
 	}
 	return hints, nil
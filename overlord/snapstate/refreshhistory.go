@@ -0,0 +1,148 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// maxRefreshHistoryEntries is the number of refresh history entries
+// kept per snap, older entries are dropped.
+var maxRefreshHistoryEntries = 32
+
+// RefreshHistoryEntry records a change of the current revision of a snap.
+// The history is kept in the state independently of the changes that
+// caused the refreshes, so it survives change pruning.
+type RefreshHistoryEntry struct {
+	// Time is when the change performing the refresh became ready.
+	Time         time.Time     `json:"time"`
+	FromRevision snap.Revision `json:"from-revision"`
+	ToRevision   snap.Revision `json:"to-revision"`
+	Channel      string        `json:"channel,omitempty"`
+	// Initiator is "auto" for auto-refreshes and "user" otherwise.
+	Initiator string `json:"initiator"`
+	// Outcome is "success" or "failed" if the new revision was undone.
+	Outcome string `json:"outcome"`
+	// Duration is the time taken by the change performing the refresh.
+	Duration time.Duration `json:"duration"`
+	// Revert is set if the revision was changed by reverting the snap.
+	Revert bool `json:"revert,omitempty"`
+}
+
+func refreshHistory(st *state.State) (map[string][]*RefreshHistoryEntry, error) {
+	var history map[string][]*RefreshHistoryEntry
+	if err := st.Get("refresh-history", &history); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if history == nil {
+		history = make(map[string][]*RefreshHistoryEntry)
+	}
+	return history, nil
+}
+
+// RefreshHistory returns the recorded refresh history of the given snap,
+// oldest first.
+func RefreshHistory(st *state.State, instanceName string) ([]*RefreshHistoryEntry, error) {
+	history, err := refreshHistory(st)
+	if err != nil {
+		return nil, err
+	}
+	return history[instanceName], nil
+}
+
+// AllRefreshHistory returns the recorded refresh history of all snaps,
+// keyed by instance name.
+func AllRefreshHistory(st *state.State) (map[string][]*RefreshHistoryEntry, error) {
+	return refreshHistory(st)
+}
+
+func addRefreshHistoryEntry(st *state.State, instanceName string, entry *RefreshHistoryEntry) error {
+	history, err := refreshHistory(st)
+	if err != nil {
+		return err
+	}
+	entries := append(history[instanceName], entry)
+	if len(entries) > maxRefreshHistoryEntries {
+		entries = entries[len(entries)-maxRefreshHistoryEntries:]
+	}
+	history[instanceName] = entries
+	st.Set("refresh-history", history)
+	return nil
+}
+
+// processRefreshHistory records the revision changes performed by a
+// change in the refresh history once the change is ready.
+func processRefreshHistory(chg *state.Change, old, new state.Status) {
+	if old.Ready() || !new.Ready() {
+		return
+	}
+
+	now := timeNow()
+	for _, t := range chg.Tasks() {
+		if t.Kind() != "link-snap" {
+			continue
+		}
+		var outcome string
+		switch t.Status() {
+		case state.DoneStatus:
+			outcome = "success"
+		case state.UndoneStatus, state.ErrorStatus:
+			outcome = "failed"
+		default:
+			continue
+		}
+		var oldCurrent snap.Revision
+		if err := t.Get("old-current", &oldCurrent); err != nil || oldCurrent.Unset() {
+			// not a refresh or the link was never attempted
+			continue
+		}
+		snapsup, err := TaskSnapSetup(t)
+		if err != nil {
+			logger.Debugf("internal error: failed to get snap associated with task %s: %v", t.ID(), err)
+			continue
+		}
+		if snapsup.Revision() == oldCurrent {
+			continue
+		}
+
+		initiator := "user"
+		if snapsup.IsAutoRefresh {
+			initiator = "auto"
+		}
+		entry := &RefreshHistoryEntry{
+			Time:         now,
+			FromRevision: oldCurrent,
+			ToRevision:   snapsup.Revision(),
+			Channel:      snapsup.Channel,
+			Initiator:    initiator,
+			Outcome:      outcome,
+			Duration:     now.Sub(chg.SpawnTime()),
+			Revert:       snapsup.Revert,
+		}
+		if err := addRefreshHistoryEntry(chg.State(), snapsup.InstanceName(), entry); err != nil {
+			logger.Noticef("cannot record refresh history of snap %q: %v", snapsup.InstanceName(), err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"context"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+func (s *snapmgrTestSuite) setupRefreshHistorySnap(c *C) {
+	si := snap.SideInfo{
+		RealName: "services-snap",
+		Revision: snap.R(7),
+		SnapID:   "services-snap-id",
+	}
+	snaptest.MockSnap(c, `name: services-snap`, &si)
+	si2 := snap.SideInfo{
+		RealName: "services-snap",
+		Revision: snap.R(11),
+		SnapID:   "services-snap-id",
+	}
+	snapstate.Set(s.state, "services-snap", &snapstate.SnapState{
+		Active:          true,
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{&si, &si2}),
+		Current:         si.Revision,
+		SnapType:        "app",
+		TrackingChannel: "latest/stable",
+	})
+}
+
+func (s *snapmgrTestSuite) TestRefreshHistoryRecordsRefresh(c *C) {
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	defer snapstate.MockTimeNow(func() time.Time { return now })()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupRefreshHistorySnap(c)
+
+	chg := s.state.NewChange("refresh", "refresh a snap")
+	ts, err := snapstate.Update(s.state, "services-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.settle(c)
+	c.Assert(chg.Err(), IsNil)
+
+	history, err := snapstate.RefreshHistory(s.state, "services-snap")
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 1)
+	c.Check(history[0], DeepEquals, &snapstate.RefreshHistoryEntry{
+		Time:         now,
+		FromRevision: snap.R(7),
+		ToRevision:   snap.R(11),
+		Channel:      "some-channel",
+		Initiator:    "user",
+		Outcome:      "success",
+		Duration:     now.Sub(chg.SpawnTime()),
+	})
+
+	// the history survives the change being pruned
+	s.state.Prune(time.Now(), 0, 0, 0)
+	c.Assert(s.state.Change(chg.ID()), IsNil)
+	history, err = snapstate.RefreshHistory(s.state, "services-snap")
+	c.Assert(err, IsNil)
+	c.Check(history, HasLen, 1)
+}
+
+func (s *snapmgrTestSuite) TestRefreshHistoryRecordsFailedRefresh(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupRefreshHistorySnap(c)
+
+	chg := s.state.NewChange("refresh", "refresh a snap")
+	// auto-refreshes are only performed through UpdateMany
+	_, tss, err := snapstate.UpdateMany(context.Background(), s.state, []string{"services-snap"}, nil, s.user.ID, &snapstate.Flags{IsAutoRefresh: true})
+	c.Assert(err, IsNil)
+	for _, ts := range tss {
+		chg.AddAll(ts)
+	}
+
+	tasks := tss[0].Tasks()
+	last := tasks[len(tasks)-1]
+	terr := s.state.NewTask("error-trigger", "provoking total undo")
+	terr.WaitFor(last)
+	for _, lane := range last.Lanes() {
+		terr.JoinLane(lane)
+	}
+	chg.AddTask(terr)
+
+	s.settle(c)
+	c.Assert(chg.Err(), NotNil)
+
+	history, err := snapstate.RefreshHistory(s.state, "services-snap")
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 1)
+	c.Check(history[0].FromRevision, Equals, snap.R(7))
+	c.Check(history[0].ToRevision, Equals, snap.R(11))
+	c.Check(history[0].Initiator, Equals, "auto")
+	c.Check(history[0].Outcome, Equals, "failed")
+}
+
+func (s *snapmgrTestSuite) TestRefreshHistoryRecordsRevert(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupRefreshHistorySnap(c)
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "services-snap", &snapst), IsNil)
+	snapst.Current = snap.R(11)
+	snapstate.Set(s.state, "services-snap", &snapst)
+
+	chg := s.state.NewChange("revert", "revert a snap")
+	ts, err := snapstate.Revert(s.state, "services-snap", snapstate.Flags{}, "")
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.settle(c)
+	c.Assert(chg.Err(), IsNil)
+
+	history, err := snapstate.RefreshHistory(s.state, "services-snap")
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 1)
+	c.Check(history[0].FromRevision, Equals, snap.R(11))
+	c.Check(history[0].ToRevision, Equals, snap.R(7))
+	c.Check(history[0].Revert, Equals, true)
+}
+
+func (s *snapmgrTestSuite) TestRefreshHistoryIgnoresInstall(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg := s.state.NewChange("install", "install a snap")
+	ts, err := snapstate.Install(context.Background(), s.state, "some-snap", nil, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.settle(c)
+	c.Assert(chg.Err(), IsNil)
+
+	history, err := snapstate.RefreshHistory(s.state, "some-snap")
+	c.Assert(err, IsNil)
+	c.Check(history, HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestRefreshHistoryIsCapped(c *C) {
+	defer snapstate.MockMaxRefreshHistoryEntries(2)()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for i := 1; i <= 3; i++ {
+		err := snapstate.AddRefreshHistoryEntry(s.state, "foo", &snapstate.RefreshHistoryEntry{
+			FromRevision: snap.R(i),
+			ToRevision:   snap.R(i + 1),
+		})
+		c.Assert(err, IsNil)
+	}
+
+	history, err := snapstate.RefreshHistory(s.state, "foo")
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 2)
+	c.Check(history[0].FromRevision, Equals, snap.R(2))
+	c.Check(history[1].FromRevision, Equals, snap.R(3))
+}
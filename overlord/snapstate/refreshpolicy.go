@@ -0,0 +1,142 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+// RefreshClassification describes the kind of an available update.
+type RefreshClassification string
+
+const (
+	// RefreshSecurity is an update flagged as security relevant by one of
+	// the enforced validation sets.
+	RefreshSecurity RefreshClassification = "security"
+	// RefreshFeature is any other update.
+	RefreshFeature RefreshClassification = "feature"
+)
+
+const refreshPolicySecurityOnly = "security-only"
+
+// securityOnlyRefreshesNow returns whether auto-refreshes are currently
+// limited to security updates, that is refresh.policy is set to
+// security-only and we are outside of the configured
+// refresh.maintenance-window.
+func securityOnlyRefreshesNow(st *state.State) (bool, error) {
+	tr := config.NewTransaction(st)
+	var policy string
+	if err := tr.GetMaybe("core", "refresh.policy", &policy); err != nil && !errors.Is(err, state.ErrNoState) {
+		return false, err
+	}
+	if policy != refreshPolicySecurityOnly {
+		return false, nil
+	}
+
+	var window string
+	if err := tr.GetMaybe("core", "refresh.maintenance-window", &window); err != nil && !errors.Is(err, state.ErrNoState) {
+		return false, err
+	}
+	if window == "" {
+		// without a maintenance window feature updates are only
+		// applied on explicit request
+		return true, nil
+	}
+	sched, err := timeutil.ParseSchedule(window)
+	if err != nil {
+		return false, fmt.Errorf("cannot parse refresh.maintenance-window: %v", err)
+	}
+	return !timeutil.Includes(sched, timeNow()), nil
+}
+
+// classifyRefresh classifies the update of the snap with the given id from
+// the current to the target revision. The update is a security update if
+// any of the enforced validation sets lists a security revision newer than
+// current and not newer than target.
+func classifyRefresh(vsets *snapasserts.ValidationSets, snapID string, current, target snap.Revision) RefreshClassification {
+	if vsets == nil || snapID == "" {
+		return RefreshFeature
+	}
+	for _, vs := range vsets.Sets() {
+		for _, sn := range vs.Snaps() {
+			if sn.SnapID != snapID {
+				continue
+			}
+			for _, rev := range sn.SecurityRevisions {
+				if rev > current.N && rev <= target.N {
+					return RefreshSecurity
+				}
+			}
+		}
+	}
+	return RefreshFeature
+}
+
+// filterByRefreshPolicy removes from an auto-refresh plan the updates that
+// must wait for the maintenance window according to refresh.policy.
+func filterByRefreshPolicy(st *state.State, plan *updatePlan) error {
+	securityOnly, err := securityOnlyRefreshesNow(st)
+	if err != nil {
+		return err
+	}
+	if !securityOnly {
+		return nil
+	}
+
+	vsets, err := EnforcedValidationSets(st)
+	if err != nil {
+		return err
+	}
+
+	return plan.filter(func(t target) (bool, error) {
+		if classifyRefresh(vsets, t.info.SnapID, t.snapst.Current, t.info.Revision) == RefreshSecurity {
+			return true, nil
+		}
+		logger.Debugf("auto-refresh of %q postponed until the maintenance window: not a security update", t.info.InstanceName())
+		return false, nil
+	})
+}
+
+// ClassifyRefreshes returns the classification of the given available
+// updates keyed by instance name.
+func ClassifyRefreshes(st *state.State, updates []*snap.Info) (map[string]RefreshClassification, error) {
+	vsets, err := EnforcedValidationSets(st)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make(map[string]RefreshClassification, len(updates))
+	for _, info := range updates {
+		var snapst SnapState
+		if err := Get(st, info.InstanceName(), &snapst); err != nil && !errors.Is(err, state.ErrNoState) {
+			return nil, err
+		}
+		classes[info.InstanceName()] = classifyRefresh(vsets, info.SnapID, snapst.Current, info.Revision)
+	}
+	return classes, nil
+}
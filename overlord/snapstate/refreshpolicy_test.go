@@ -0,0 +1,172 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"context"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+const securitySnapID = "yOqKhntON3vR7kwEbVPsILm7bUViPDzx"
+
+func (s *validationSetsSuite) mockSecurityRevisions(c *C, revs ...any) {
+	restore := snapstate.MockEnforcedValidationSets(func(st *state.State, extraVss ...*asserts.ValidationSet) (*snapasserts.ValidationSets, error) {
+		vs := snapasserts.NewValidationSets()
+		someSnap := map[string]any{
+			"id":                 securitySnapID,
+			"name":               "some-snap",
+			"presence":           "optional",
+			"security-revisions": revs,
+		}
+		vsa1 := s.mockValidationSetAssert(c, "bar", "1", someSnap)
+		c.Assert(vs.Add(vsa1.(*asserts.ValidationSet)), IsNil)
+		return vs, nil
+	})
+	s.AddCleanup(restore)
+}
+
+func (s *validationSetsSuite) setupSecurityRefreshSnaps(c *C) {
+	s.fakeStore.registerID("some-snap", securitySnapID)
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "some-snap", SnapID: securitySnapID, Revision: snap.R(1)},
+		}),
+		Current:  snap.R(1),
+		SnapType: "app",
+	})
+	snapstate.Set(s.state, "some-other-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "some-other-snap", SnapID: "some-other-snap-id", Revision: snap.R(1)},
+		}),
+		Current:  snap.R(1),
+		SnapType: "app",
+	})
+}
+
+func (s *validationSetsSuite) setRefreshPolicy(c *C, policy, window string) {
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "refresh.policy", policy), IsNil)
+	c.Assert(tr.Set("core", "refresh.maintenance-window", window), IsNil)
+	tr.Commit()
+}
+
+func (s *validationSetsSuite) TestAutoRefreshPolicyAll(c *C) {
+	s.mockSecurityRevisions(c, "5")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupSecurityRefreshSnaps(c)
+	s.setRefreshPolicy(c, "all", "")
+
+	names, _, err := snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"some-other-snap", "some-snap"})
+}
+
+func (s *validationSetsSuite) TestAutoRefreshPolicySecurityOnly(c *C) {
+	s.mockSecurityRevisions(c, "5")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupSecurityRefreshSnaps(c)
+	s.setRefreshPolicy(c, "security-only", "")
+
+	// only some-snap has a security revision between the current (1)
+	// and the available (11) revisions
+	names, _, err := snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"some-snap"})
+}
+
+func (s *validationSetsSuite) TestAutoRefreshPolicySecurityOnlyNoSecurityUpdates(c *C) {
+	// the security fix is beyond the available revision
+	s.mockSecurityRevisions(c, "12")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupSecurityRefreshSnaps(c)
+	s.setRefreshPolicy(c, "security-only", "")
+
+	names, _, err := snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, HasLen, 0)
+}
+
+func (s *validationSetsSuite) TestAutoRefreshPolicySecurityOnlyMaintenanceWindow(c *C) {
+	s.mockSecurityRevisions(c, "12")
+
+	// a Saturday
+	now := time.Date(2025, time.March, 8, 3, 0, 0, 0, time.Local)
+	restore := snapstate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupSecurityRefreshSnaps(c)
+	s.setRefreshPolicy(c, "security-only", "sat,02:00-04:00")
+
+	// inside the maintenance window all updates are applied
+	names, _, err := snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"some-other-snap", "some-snap"})
+
+	// outside of it feature updates wait
+	now = now.Add(2 * time.Hour)
+	names, _, err = snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, HasLen, 0)
+}
+
+func (s *validationSetsSuite) TestClassifyRefreshes(c *C) {
+	s.mockSecurityRevisions(c, "3", "20")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupSecurityRefreshSnaps(c)
+
+	updates := []*snap.Info{
+		{SideInfo: snap.SideInfo{RealName: "some-snap", SnapID: securitySnapID, Revision: snap.R(11)}},
+		{SideInfo: snap.SideInfo{RealName: "some-other-snap", SnapID: "some-other-snap-id", Revision: snap.R(11)}},
+	}
+	classes, err := snapstate.ClassifyRefreshes(s.state, updates)
+	c.Assert(err, IsNil)
+	c.Check(classes, DeepEquals, map[string]snapstate.RefreshClassification{
+		"some-snap":       snapstate.RefreshSecurity,
+		"some-other-snap": snapstate.RefreshFeature,
+	})
+}
@@ -82,6 +82,11 @@ type SnapManager struct {
 	ensuredMountsUpdated       bool
 	ensuredDesktopFilesUpdated bool
 	ensuredDownloadsCleaned    bool
+	ensuredEOLBasesWarned      bool
+
+	// downloadConcurrency is the number of snap downloads allowed to
+	// run in parallel, as read by the last Ensure
+	downloadConcurrency int
 
 	changeCallbackID int
 }
@@ -179,6 +184,19 @@ type SnapSetup struct {
 	// ComponentExclusiveOperation is set if this SnapSetup exists only to deal with
 	// components, and not the snap itself.
 	ComponentExclusiveOperation bool `json:"component-exclusive-operation,omitempty"`
+
+	// EpochMigration is set if the snap is refreshed or reverted to a
+	// revision with a different epoch, in which case the epoch migration
+	// hooks of that revision are run once its data is in place.
+	EpochMigration *EpochMigration `json:"epoch-migration,omitempty"`
+}
+
+// EpochMigration describes the epoch boundary crossed by a refresh.
+type EpochMigration struct {
+	// From is the epoch of the revision being refreshed or reverted from.
+	From snap.Epoch `json:"from"`
+	// To is the epoch of the revision being refreshed or reverted to.
+	To snap.Epoch `json:"to"`
 }
 
 // ConfdbSchemaID identifies a confdb schema.
@@ -616,6 +634,10 @@ var snapReadInfo = snap.ReadInfo
 // AutomaticSnapshot allows to hook snapshot manager's AutomaticSnapshot.
 var AutomaticSnapshot func(st *state.State, instanceName string) (ts *state.TaskSet, err error)
 var AutomaticSnapshotExpiration func(st *state.State) (time.Duration, error)
+
+// BeforeRefreshSnapshot allows to hook snapshot manager's BeforeRefreshSnapshot.
+var BeforeRefreshSnapshot func(st *state.State, instanceName string) (ts *state.TaskSet, err error)
+
 var EstimateSnapshotSize func(st *state.State, instanceName string, users []string) (uint64, error)
 
 func readInfo(name string, si *snap.SideInfo, flags int) (*snap.Info, error) {
@@ -816,6 +838,7 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 		ensuredMountsUpdated:       false,
 		ensuredDesktopFilesUpdated: false,
 		ensuredDownloadsCleaned:    false,
+		ensuredEOLBasesWarned:      false,
 	}
 	if preseed {
 		m.backend = backend.NewForPreseedMode()
@@ -842,7 +865,8 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 	// remove anything that is not referenced anymore
 	runner.AddHandler("prerequisites", m.doPrerequisites, nil)
 	runner.AddHandler("prepare-snap", m.doPrepareSnap, m.undoPrepareSnap)
-	runner.AddHandler("download-snap", m.doDownloadSnap, m.undoPrepareSnap)
+	runner.AddHandler("download-snap", m.doDownloadSnap, m.undoDownloadSnap)
+	runner.AddHandler("download-snap-from-url", m.doDownloadSnapFromURL, m.undoDownloadSnapFromURL)
 	runner.AddHandler("mount-snap", m.doMountSnap, m.undoMountSnap)
 	runner.AddHandler("unlink-current-snap", m.doUnlinkCurrentSnap, m.undoUnlinkCurrentSnap)
 	runner.AddHandler("copy-snap-data", m.doCopySnapData, m.undoCopySnapData)
@@ -853,6 +877,7 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 	runner.AddHandler("toggle-snap-flags", m.doToggleSnapFlags, nil)
 	runner.AddHandler("check-rerefresh", m.doCheckReRefresh, nil)
 	runner.AddHandler("conditional-auto-refresh", m.doConditionalAutoRefresh, nil)
+	runner.AddHandler("run-queued-op", m.doRunQueuedOp, nil)
 
 	// specific set-up for the kernel snap
 	runner.AddHandler("prepare-kernel-snap", m.doPrepareKernelSnap, m.undoPrepareKernelSnap)
@@ -888,6 +913,13 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 	// no undo for now since it's last task in valset auto-resolution change
 	runner.AddHandler("enforce-validation-sets", m.doEnforceValidationSets, nil)
 	runner.AddHandler("pre-download-snap", m.doPreDownloadSnap, nil)
+	runner.AddHandler("prefetch-snap", m.doPrefetchSnap, nil)
+	runner.AddHandler("mark-snap-downloaded", m.doMarkSnapDownloaded, nil)
+
+	// retry the tasks talking to the store on flaky networks
+	for _, kind := range RetriedTaskKinds {
+		runner.SetRetryPolicy(kind, isTransientNetworkError, taskRetryPolicy)
+	}
 
 	// component tasks
 	runner.AddHandler("prepare-component", m.doPrepareComponent, nil)
@@ -905,6 +937,7 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 	runner.AddBlocked(m.blockedTask)
 
 	RegisterAffectedSnapsByKind("conditional-auto-refresh", conditionalAutoRefreshAffectedSnaps)
+	RegisterAffectedSnapsByKind("download-snap-from-url", urlSnapAffectedSnaps)
 
 	return m, nil
 }
@@ -924,6 +957,10 @@ func (m *SnapManager) StartUp() error {
 		processInhibitedAutoRefresh(chg, old, new)
 		// This handler implements marks failed snaps auto-refresh attempts for backoff.
 		processFailedAutoRefresh(chg, old, new)
+		// This handler records revision changes in the per-snap refresh history.
+		processRefreshHistory(chg, old, new)
+		// This handler drops the workload quiesce requests of finished changes.
+		processWorkloadQuiesce(chg, old, new)
 	})
 
 	if CheckExpectedRestart(m.state) == ErrUnexpectedRuntimeRestart {
@@ -983,6 +1020,13 @@ func (m *SnapManager) blockedTask(cand *state.Task, running []*state.Task) bool
 		}
 	}
 
+	// Limit the number of snap downloads running in parallel, this only
+	// affects downloads so the ordering of the link and unlink phases is
+	// unchanged.
+	if downloadBlocked(cand, running, m.downloadConcurrency) {
+		return true
+	}
+
 	return false
 }
 
@@ -1320,10 +1364,15 @@ func (m *SnapManager) atSeed() error {
 var (
 	localInstallCleanupWait = time.Duration(24 * time.Hour)
 	localInstallLastCleanup time.Time
+
+	// partialDownloadMaxAge is how long the partial file of an
+	// interrupted download is kept to resume the download
+	partialDownloadMaxAge = 7 * 24 * time.Hour
 )
 
 // localInstallCleanup removes files that might've been left behind by an
-// old aborted local install.
+// old aborted local install, and the partial files of downloads that
+// were not resumed for a long time.
 //
 // They're usually cleaned up, but if they're created and then snapd
 // stops before writing the change to disk (killed, light cut, etc)
@@ -1358,10 +1407,17 @@ func (m *SnapManager) localInstallCleanup() error {
 		// fis is nil if err isn't
 		for _, fi := range fis {
 			name := fi.Name()
-			if !strings.HasPrefix(name, dirs.LocalInstallBlobTempPrefix) {
-				continue
-			}
-			if fi.ModTime().After(cutoff) {
+			switch {
+			case strings.HasPrefix(name, dirs.LocalInstallBlobTempPrefix):
+				if fi.ModTime().After(cutoff) {
+					continue
+				}
+			case store.IsPartialDownload(name):
+				// downloads in progress checkpoint regularly
+				if fi.ModTime().After(now.Add(-partialDownloadMaxAge)) {
+					continue
+				}
+			default:
 				continue
 			}
 			filenames = append(filenames, name)
@@ -1550,12 +1606,15 @@ func (m *SnapManager) Ensure() error {
 		// considering issuing a hint only refresh request
 		m.autoRefresh.Ensure(),
 		m.refreshHints.Ensure(),
+		m.ensureRefreshesPrefetched(),
 		m.catalogRefresh.Ensure(),
 		m.localInstallCleanup(),
 		m.ensureVulnerableSnapConfineVersionsRemovedOnClassic(),
 		m.ensureMountsUpdated(),
 		m.ensureDesktopFilesUpdated(),
 		m.ensureDownloadsCleaned(),
+		m.ensureEOLBasesWarned(),
+		m.ensureDownloadConcurrency(),
 	}
 
 	//FIXME: use firstErr helper
@@ -81,6 +81,9 @@ const (
 	MaybeRebootWaitEdge              = state.TaskSetEdge("maybe-reboot-wait")
 	LastBeforeLocalModificationsEdge = state.TaskSetEdge("last-before-local-modifications")
 	EndEdge                          = state.TaskSetEdge("end")
+	// RemoveDataEdge marks the first task of a removal that cannot be
+	// undone, removing snap data and revisions.
+	RemoveDataEdge = state.TaskSetEdge("remove-data")
 )
 
 // userDaemonsOverrides lists by snap-id a set of well-known snaps for which we
@@ -194,13 +197,20 @@ func isParallelInstallable(snapsup *SnapSetup) error {
 	return fmt.Errorf("cannot install snap of type %v as %q", snapsup.Type, snapsup.InstanceName())
 }
 
-// refreshRetain returns refresh.retain value if set, or the default value (different for core and classic).
+// RetainPerSnapConfigKey is the system option under which operators override
+// refresh.retain for specific snaps, in the form
+// refresh.retain-per-snap.<snap-name>=<count>. Instance keys cannot be part
+// of option names, so the count applies to all instances of the snap.
+const RetainPerSnapConfigKey = "refresh.retain-per-snap"
+
+// retainOption returns the number of revisions to retain set in the given
+// option, or 0 if it is not set.
 // It deals with potentially wrong type due to lax validation.
-func refreshRetain(st *state.State) int {
+func retainOption(tr *config.Transaction, option string) int {
 	var val any
 	// due to lax validation of refresh.retain on set we might end up having a string representing a number here; handle it gracefully
 	// for backwards compatibility.
-	err := config.NewTransaction(st).Get("core", "refresh.retain", &val)
+	err := tr.Get("core", option, &val)
 	var retain int
 	if err == nil {
 		switch v := val.(type) {
@@ -215,14 +225,28 @@ func refreshRetain(st *state.State) int {
 		case string:
 			retain, err = strconv.Atoi(v)
 		default:
-			logger.Noticef("internal error: refresh.retain system option has unexpected type: %T", v)
+			logger.Noticef("internal error: %s system option has unexpected type: %T", option, v)
 		}
 	}
 
 	// this covers error from Get() and strconv above.
 	if err != nil && !config.IsNoOption(err) {
-		logger.Noticef("internal error: refresh.retain system option is not valid: %v", err)
+		logger.Noticef("internal error: %s system option is not valid: %v", option, err)
+	}
+	return retain
+}
+
+// refreshRetain returns the number of revisions of the given snap to retain,
+// that is refresh.retain-per-snap.<snap-name> if set, otherwise
+// refresh.retain if set, or the default value (different for core and
+// classic).
+func refreshRetain(st *state.State, instanceName string) int {
+	tr := config.NewTransaction(st)
+	snapName := snap.InstanceSnap(instanceName)
+	if retain := retainOption(tr, RetainPerSnapConfigKey+"."+snapName); retain > 0 {
+		return retain
 	}
+	retain := retainOption(tr, "refresh.retain")
 
 	// not set, use default value
 	if retain == 0 {
@@ -554,6 +578,17 @@ func doInstall(st *state.State, snapst *SnapState, snapsup SnapSetup, compsups [
 		stop.Set("stop-reason", snap.StopReasonRefresh)
 		addTask(stop)
 
+		// save the data of the current revision, so that it can be
+		// recovered if the new revision migrates it badly
+		if runRefreshHooks && snapsup.Type == snap.TypeApp && BeforeRefreshSnapshot != nil {
+			ts, err := BeforeRefreshSnapshot(st, snapsup.InstanceName())
+			if err == nil {
+				addTasksFromTaskSet(ts)
+			} else if err != ErrNothingToDo {
+				return nil, err
+			}
+		}
+
 		removeAliases := st.NewTask("remove-aliases", fmt.Sprintf(i18n.G("Remove aliases for snap %q"), snapsup.InstanceName()))
 		removeAliases.Set("remove-reason", removeAliasesReasonRefresh)
 		addTask(removeAliases)
@@ -674,10 +709,21 @@ func doInstall(st *state.State, snapst *SnapState, snapsup SnapSetup, compsups [
 		addTask(bootConfigUpdate)
 	}
 
+	// the epoch migration hooks of the new revision run once its data
+	// is copied, undoing the change leaves the data of the previous
+	// revision untouched, reverts across an epoch run them too
+	mig := snapsup.EpochMigration
+	runEpochMigrationHooks := mig != nil && snapst.IsInstalled() && !componentOnlyUpdate
+	if runEpochMigrationHooks {
+		addTask(SetupPreEpochMigrationHook(st, snapsup.InstanceName(), mig.From, mig.To))
+	}
 	if runRefreshHooks {
 		postRefreshHook := SetupPostRefreshHook(st, snapsup.InstanceName())
 		addTask(postRefreshHook)
 	}
+	if runEpochMigrationHooks {
+		addTask(SetupPostEpochMigrationHook(st, snapsup.InstanceName(), mig.From, mig.To))
+	}
 
 	var installHook *state.Task
 	// only run install hook if installing the snap for the first time
@@ -718,6 +764,14 @@ func doInstall(st *state.State, snapst *SnapState, snapsup SnapSetup, compsups [
 			return nil, err
 		}
 		addTask(quotaAddSnapTask)
+	} else if !snapst.IsInstalled() && AddSnapToDefaultQuotaGroup != nil {
+		quotaAddSnapTask, err := AddSnapToDefaultQuotaGroup(st, deviceCtx, &snapsup)
+		if err != nil {
+			return nil, err
+		}
+		if quotaAddSnapTask != nil {
+			addTask(quotaAddSnapTask)
+		}
 	}
 
 	// only run default-configure hook if installing the snap for the first time and
@@ -738,7 +792,7 @@ func doInstall(st *state.State, snapst *SnapState, snapsup SnapSetup, compsups [
 	// Do not do that if we are reverting to a local revision
 	var cleanupTask *state.Task
 	if snapst.IsInstalled() && !snapsup.Flags.Revert {
-		retain := refreshRetain(st)
+		retain := refreshRetain(st, snapsup.InstanceName())
 
 		// if we're not using an already present revision, account for the one being added
 		if snapst.LastIndex(targetRevision) == -1 {
@@ -994,6 +1048,14 @@ var SetupPostRefreshHook = func(st *state.State, snapName string) *state.Task {
 	panic("internal error: snapstate.SetupPostRefreshHook is unset")
 }
 
+var SetupPreEpochMigrationHook = func(st *state.State, snapName string, from, to snap.Epoch) *state.Task {
+	panic("internal error: snapstate.SetupPreEpochMigrationHook is unset")
+}
+
+var SetupPostEpochMigrationHook = func(st *state.State, snapName string, from, to snap.Epoch) *state.Task {
+	panic("internal error: snapstate.SetupPostEpochMigrationHook is unset")
+}
+
 var SetupRemoveHook = func(st *state.State, snapName string) *state.Task {
 	panic("internal error: snapstate.SetupRemoveHook is unset")
 }
@@ -1006,10 +1068,18 @@ var SetupGateAutoRefreshHook = func(st *state.State, snapName string) *state.Tas
 	panic("internal error: snapstate.SetupAutoRefreshGatingHook is unset")
 }
 
+var SetupRefreshInhibitHook = func(st *state.State, snapName string) *state.Task {
+	panic("internal error: snapstate.SetupRefreshInhibitHook is unset")
+}
+
 var AddSnapToQuotaGroup = func(st *state.State, snapName string, quotaGroup string) (*state.Task, error) {
 	panic("internal error: snapstate.AddSnapToQuotaGroup is unset")
 }
 
+// AddSnapToDefaultQuotaGroup returns a task placing a snap being installed in
+// the quota group declared for it by the gadget, or nil if there is none.
+var AddSnapToDefaultQuotaGroup func(st *state.State, deviceCtx DeviceContext, snapsup *SnapSetup) (*state.Task, error)
+
 var HasActiveConnection = func(st *state.State, iface string) (bool, error) {
 	panic("internal error: snapstate.HasActiveConnection is unset")
 }
@@ -2947,6 +3017,9 @@ type RevisionOptions struct {
 	ValidationSets *snapasserts.ValidationSets
 	CohortKey      string
 	LeaveCohort    bool
+	// Architecture, if set, requests the revision for an architecture
+	// other than the one of the system.
+	Architecture string
 }
 
 func (r *RevisionOptions) setChannelIfUnset(channel string) {
@@ -3922,6 +3995,13 @@ type RemoveFlags struct {
 	Purge bool
 	// Kill running snap apps and services
 	Terminate bool
+	// Transaction is the transaction type of a RemoveMany, with
+	// "all-snaps" the removals are undone together if any of them fails.
+	Transaction client.TransactionType
+	// Lane is the lane to use with an "all-snaps" transaction, so that
+	// removals can be part of a larger transaction. A new lane is used if
+	// unset.
+	Lane int
 }
 
 // Remove returns a set of tasks for removing snap.
@@ -4130,6 +4210,14 @@ func removeTasks(st *state.State, name string, revision snap.Revision, flags *Re
 		addNext(state.NewTaskSet(tasks...))
 	}
 
+	// everything from here on cannot be undone
+	addIrreversible := func(ts *state.TaskSet) {
+		if removeTs.MaybeEdge(RemoveDataEdge) == nil {
+			removeTs.MarkEdge(ts.Tasks()[0], RemoveDataEdge)
+		}
+		addNext(ts)
+	}
+
 	if removeAll {
 		si := snapst.Sequence.SideInfos()
 		currentIndex := snapst.LastIndex(snapst.Current)
@@ -4141,7 +4229,7 @@ func removeTasks(st *state.State, name string, revision snap.Revision, flags *Re
 				if err != nil {
 					return nil, 0, err
 				}
-				addNext(ts)
+				addIrreversible(ts)
 			}
 		}
 		// add tasks for removing the current revision last,
@@ -4152,7 +4240,7 @@ func removeTasks(st *state.State, name string, revision snap.Revision, flags *Re
 			if err != nil {
 				return nil, 0, err
 			}
-			addNext(ts)
+			addIrreversible(ts)
 		}
 	} else {
 		ts, err := removeInactiveRevision(st, &snapst, name, info.SnapID, revision,
@@ -4160,7 +4248,7 @@ func removeTasks(st *state.State, name string, revision snap.Revision, flags *Re
 		if err != nil {
 			return nil, 0, err
 		}
-		addNext(ts)
+		addIrreversible(ts)
 	}
 
 	return removeTs, snapshotSize, nil
@@ -4241,6 +4329,19 @@ func RemoveMany(st *state.State, names []string, flags *RemoveFlags) ([]string,
 		return nil, nil, err
 	}
 
+	var transactionLane int
+	if flags != nil {
+		switch {
+		case flags.Transaction == client.TransactionAllSnaps:
+			transactionLane = flags.Lane
+			if transactionLane == 0 {
+				transactionLane = st.NewLane()
+			}
+		case flags.Lane != 0:
+			return nil, nil, errors.New("cannot specify a lane without setting transaction to \"all-snaps\"")
+		}
+	}
+
 	removed := make([]string, 0, len(names))
 	tasksets := make([]*state.TaskSet, 0, len(names))
 
@@ -4258,7 +4359,11 @@ func RemoveMany(st *state.State, names []string, flags *RemoveFlags) ([]string,
 		}
 		totalSnapshotsSize += snapshotSize
 		removed = append(removed, name)
-		ts.JoinLane(st.NewLane())
+		if transactionLane != 0 {
+			ts.JoinLane(transactionLane)
+		} else {
+			ts.JoinLane(st.NewLane())
+		}
 		tasksets = append(tasksets, ts)
 	}
 
@@ -4278,9 +4383,60 @@ func RemoveMany(st *state.State, names []string, flags *RemoveFlags) ([]string,
 		}
 	}
 
+	if transactionLane != 0 {
+		HoldIrreversibleRemovals(tasksets)
+	}
+
 	return removed, tasksets, nil
 }
 
+// HoldIrreversibleRemovals makes the parts of the removals in tss that cannot
+// be undone, from their RemoveDataEdge on, wait for all the other tasks in
+// tss. With all of tss in a single lane this makes a batch mixing installs,
+// refreshes and removals all-or-nothing: whatever fails, the removed snaps
+// still have their data and revisions and are restored when undoing.
+func HoldIrreversibleRemovals(tss []*state.TaskSet) {
+	irreversible := make(map[*state.Task]bool)
+	var firsts []*state.Task
+	for _, ts := range tss {
+		first := ts.MaybeEdge(RemoveDataEdge)
+		if first == nil {
+			continue
+		}
+		firsts = append(firsts, first)
+
+		inTs := make(map[*state.Task]bool, len(ts.Tasks()))
+		for _, t := range ts.Tasks() {
+			inTs[t] = true
+		}
+		// the tasks of the removal following the first irreversible one
+		queue := []*state.Task{first}
+		for len(queue) > 0 {
+			t := queue[0]
+			queue = queue[1:]
+			if irreversible[t] {
+				continue
+			}
+			irreversible[t] = true
+			for _, next := range t.HaltTasks() {
+				if inTs[next] {
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+
+	for _, first := range firsts {
+		for _, ts := range tss {
+			for _, t := range ts.Tasks() {
+				if !irreversible[t] {
+					first.WaitFor(t)
+				}
+			}
+		}
+	}
+}
+
 func validateSnapNames(names []string) error {
 	var invalidNames []string
 
@@ -4302,7 +4458,10 @@ func validateSnapNames(names []string) error {
 func Revert(st *state.State, name string, flags Flags, fromChange string) (*state.TaskSet, error) {
 	var snapst SnapState
 	err := Get(st, name, &snapst)
-	if err != nil && !errors.Is(err, state.ErrNoState) {
+	if errors.Is(err, state.ErrNoState) {
+		return nil, &snap.NotInstalledError{Snap: name}
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -4314,10 +4473,15 @@ func Revert(st *state.State, name string, flags Flags, fromChange string) (*stat
 	return RevertToRevision(st, name, pi.Revision, flags, fromChange)
 }
 
+// RevertToRevision returns a set of tasks for reverting the snap to the
+// given revision, which can be any revision of the snap still on disk.
 func RevertToRevision(st *state.State, name string, rev snap.Revision, flags Flags, fromChange string) (*state.TaskSet, error) {
 	var snapst SnapState
 	err := Get(st, name, &snapst)
-	if err != nil && !errors.Is(err, state.ErrNoState) {
+	if errors.Is(err, state.ErrNoState) {
+		return nil, &snap.NotInstalledError{Snap: name}
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -4330,7 +4494,16 @@ func RevertToRevision(st *state.State, name string, rev snap.Revision, flags Fla
 	}
 	i := snapst.LastIndex(rev)
 	if i < 0 {
-		return nil, fmt.Errorf("cannot find revision %s for snap %q", rev, name)
+		retained := make([]string, 0, len(snapst.Sequence.Revisions))
+		for _, si := range snapst.Sequence.SideInfos() {
+			if si.Revision != snapst.Current {
+				retained = append(retained, si.Revision.String())
+			}
+		}
+		if len(retained) == 0 {
+			return nil, fmt.Errorf("cannot find revision %s for snap %q: no other revision is retained", rev, name)
+		}
+		return nil, fmt.Errorf("cannot find revision %s for snap %q: retained revisions are %s", rev, name, strings.Join(retained, ", "))
 	}
 
 	flags.Revert = true
@@ -4361,6 +4534,9 @@ func RevertToRevision(st *state.State, name string, rev snap.Revision, flags Fla
 		Version:     info.Version,
 		PlugsOnly:   len(info.Slots) == 0,
 		InstanceKey: snapst.InstanceKey,
+		// the data of the revision reverted to is in the format of
+		// its own epoch
+		EpochMigration: epochMigrationFor(info, &snapst),
 	}
 
 	components := snapst.Sequence.ComponentsForRevision(rev)
@@ -4886,6 +5062,18 @@ func downloadsToKeep(st *state.State) (map[string]bool, error) {
 		keep(snapName, hint.Revision())
 	}
 
+	// keep revisions downloaded ahead of a refresh
+	downloaded, err := downloadedRefreshesToKeep(st, snapStates)
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range downloaded {
+		if downloadsToKeep == nil {
+			downloadsToKeep = make(map[string]bool)
+		}
+		downloadsToKeep[fn] = true
+	}
+
 	// keep revisions pointed to by a download task in an ongoing change
 	for _, chg := range st.Changes() {
 		if chg.IsReady() {
@@ -1944,6 +1944,37 @@ func (s *snapmgrTestSuite) TestInstallUndoRunThroughJustOneSnap(c *C) {
 	c.Assert(s.fakeBackend.ops, DeepEquals, expected)
 }
 
+func (s *snapmgrTestSuite) TestInstallForeignArchitectureRunThrough(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg := s.state.NewChange("install", "install a snap")
+	opts := &snapstate.RevisionOptions{Channel: "some-channel", Architecture: "armhf"}
+	ts, err := snapstate.Install(context.Background(), s.state, "some-snap", opts, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.settle(c)
+
+	c.Assert(chg.Err(), IsNil)
+	c.Assert(chg.IsReady(), Equals, true)
+
+	op := s.fakeBackend.ops.First("storesvc-snap-action:action")
+	c.Assert(op, NotNil)
+	c.Check(op.action, DeepEquals, store.SnapAction{
+		Action:       "install",
+		InstanceName: "some-snap",
+		Channel:      "some-channel",
+		Architecture: "armhf",
+	})
+
+	// the foreign architecture is tracked in the sequence
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Assert(snapst.CurrentSideInfo(), NotNil)
+	c.Check(snapst.CurrentSideInfo().Architecture, Equals, "armhf")
+}
+
 func (s *snapmgrTestSuite) TestInstallWithCohortRunThrough(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -5240,6 +5271,36 @@ func (s *snapmgrTestSuite) TestInstallQuotaGroup(c *C) {
 	c.Check(quotaWasCalled, Equals, true)
 }
 
+func (s *snapmgrTestSuite) TestInstallDefaultQuotaGroup(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var defaultQuotaCalls []string
+	restore := testutil.Mock(&snapstate.AddSnapToDefaultQuotaGroup, func(st *state.State, deviceCtx snapstate.DeviceContext, snapsup *snapstate.SnapSetup) (*state.Task, error) {
+		defaultQuotaCalls = append(defaultQuotaCalls, snapsup.InstanceName())
+		t := st.NewTask("quota-add-snap", "...")
+		t.Set("quota-name", "default-group")
+		return t, nil
+	})
+	defer restore()
+
+	ts, err := snapstate.Install(context.Background(), s.state, "some-snap", nil, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	c.Check(defaultQuotaCalls, DeepEquals, []string{"some-snap"})
+	quotaTask := findKindInTaskSet(ts, "quota-add-snap")
+	c.Assert(quotaTask, NotNil)
+	var quotaName string
+	c.Assert(quotaTask.Get("quota-name", &quotaName), IsNil)
+	c.Check(quotaName, Equals, "default-group")
+
+	// an explicitly requested quota group takes precedence
+	defaultQuotaCalls = nil
+	ts, err = snapstate.Install(context.Background(), s.state, "some-other-snap", nil, s.user.ID, snapstate.Flags{QuotaGroupName: "foo"})
+	c.Assert(err, IsNil)
+	c.Check(defaultQuotaCalls, HasLen, 0)
+	c.Check(tasksWithKind(ts, "quota-add-snap"), HasLen, 1)
+}
+
 func (s *snapmgrTestSuite) TestInstallUndoQuotaGroup(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
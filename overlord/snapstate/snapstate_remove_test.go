@@ -31,6 +31,7 @@ import (
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/assertstate"
@@ -1398,6 +1399,117 @@ func (s *snapmgrTestSuite) TestRemoveMany(c *C) {
 	}
 }
 
+func (s *snapmgrTestSuite) TestRemoveManyTransactionally(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, name := range []string{"one", "two"} {
+		snapstate.Set(s.state, name, &snapstate.SnapState{
+			Active: true,
+			Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+				{RealName: name, SnapID: name + "-id", Revision: snap.R(1)},
+			}),
+			Current: snap.R(1),
+		})
+	}
+
+	removed, tss, err := snapstate.RemoveMany(s.state, []string{"one", "two"},
+		&snapstate.RemoveFlags{Transaction: client.TransactionAllSnaps})
+	c.Assert(err, IsNil)
+	c.Assert(tss, HasLen, 2)
+	c.Check(removed, DeepEquals, []string{"one", "two"})
+
+	// all tasks are in the same lane
+	for _, ts := range tss {
+		for _, t := range ts.Tasks() {
+			c.Assert(t.Lanes(), DeepEquals, []int{1})
+		}
+	}
+
+	// removing data only starts once all the snaps are unlinked
+	for i, ts := range tss {
+		clearSnap := ts.MaybeEdge(snapstate.RemoveDataEdge)
+		c.Assert(clearSnap, NotNil)
+		c.Check(clearSnap.Kind(), Equals, "clear-snap")
+		other := tss[1-i]
+		for _, t := range other.Tasks() {
+			switch t.Kind() {
+			case "clear-snap", "discard-snap":
+				c.Check(clearSnap.WaitTasks(), Not(testutil.Contains), t)
+			default:
+				c.Check(clearSnap.WaitTasks(), testutil.Contains, t)
+			}
+		}
+	}
+}
+
+func (s *snapmgrTestSuite) TestRemoveManyLaneWithoutTransaction(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, _, err := snapstate.RemoveMany(s.state, []string{"one"}, &snapstate.RemoveFlags{Lane: s.state.NewLane()})
+	c.Assert(err, ErrorMatches, `cannot specify a lane without setting transaction to "all-snaps"`)
+}
+
+func (s *snapmgrTestSuite) TestRemoveManyTransactionallyUndoRestoresAll(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, name := range []string{"one", "two"} {
+		snapstate.Set(s.state, name, &snapstate.SnapState{
+			Active: true,
+			Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+				{RealName: name, SnapID: name + "-id", Revision: snap.R(1)},
+			}),
+			Current:  snap.R(1),
+			SnapType: "app",
+		})
+		// the data is still there when undoing
+		c.Assert(os.MkdirAll(snap.DataDir(name, snap.R(1)), 0755), IsNil)
+		c.Assert(os.MkdirAll(snap.CommonDataDir(name), 0755), IsNil)
+	}
+
+	lane := s.state.NewLane()
+	_, tss, err := snapstate.RemoveMany(s.state, []string{"one", "two"},
+		&snapstate.RemoveFlags{Transaction: client.TransactionAllSnaps, Lane: lane})
+	c.Assert(err, IsNil)
+
+	// another member of the batch fails once the snaps are unlinked
+	terr := s.state.NewTask("error-trigger", "provoking total undo")
+	terr.JoinLane(lane)
+	for _, ts := range tss {
+		for _, t := range ts.Tasks() {
+			if t.Kind() == "unlink-snap" {
+				terr.WaitFor(t)
+			}
+		}
+	}
+	tss = append(tss, state.NewTaskSet(terr))
+	snapstate.HoldIrreversibleRemovals(tss)
+
+	chg := s.state.NewChange("batch", "...")
+	for _, ts := range tss {
+		chg.AddAll(ts)
+	}
+
+	s.settle(c)
+
+	c.Assert(chg.Status(), Equals, state.ErrorStatus)
+	isUndone(c, chg.Tasks(), "unlink-snap", 2)
+	for _, t := range chg.Tasks() {
+		switch t.Kind() {
+		case "clear-snap", "discard-snap":
+			c.Check(t.Status(), Equals, state.HoldStatus)
+		}
+	}
+	for _, name := range []string{"one", "two"} {
+		var snapst snapstate.SnapState
+		c.Assert(snapstate.Get(s.state, name, &snapst), IsNil)
+		c.Check(snapst.Active, Equals, true)
+		c.Check(snapst.Current, Equals, snap.R(1))
+	}
+}
+
 func (s *snapmgrTestSuite) testRemoveManyDiskSpaceCheck(c *C, featureFlag, automaticSnapshot, freeSpaceCheckFail bool) error {
 	s.state.Lock()
 	defer s.state.Unlock()
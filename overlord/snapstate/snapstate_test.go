@@ -201,6 +201,8 @@ func (s *snapmgrBaseTest) SetUpTest(c *C) {
 	oldSetupRemoveComponentHook := snapstate.SetupRemoveComponentHook
 	oldSetupPreRefreshHook := snapstate.SetupPreRefreshHook
 	oldSetupPostRefreshHook := snapstate.SetupPostRefreshHook
+	oldSetupPreEpochMigrationHook := snapstate.SetupPreEpochMigrationHook
+	oldSetupPostEpochMigrationHook := snapstate.SetupPostEpochMigrationHook
 	oldSetupRemoveHook := snapstate.SetupRemoveHook
 	oldSnapServiceOptions := snapstate.SnapServiceOptions
 	oldEnsureSnapAbsentFromQuotaGroup := snapstate.EnsureSnapAbsentFromQuotaGroup
@@ -210,6 +212,8 @@ func (s *snapmgrBaseTest) SetUpTest(c *C) {
 	snapstate.SetupPreRefreshComponentHook = hookstate.SetupPreRefreshComponentHook
 	snapstate.SetupPreRefreshHook = hookstate.SetupPreRefreshHook
 	snapstate.SetupPostRefreshHook = hookstate.SetupPostRefreshHook
+	snapstate.SetupPreEpochMigrationHook = hookstate.SetupPreEpochMigrationHook
+	snapstate.SetupPostEpochMigrationHook = hookstate.SetupPostEpochMigrationHook
 	snapstate.SetupRemoveHook = hookstate.SetupRemoveHook
 	snapstate.SnapServiceOptions = servicestate.SnapServiceOptions
 	snapstate.EnsureSnapAbsentFromQuotaGroup = servicestate.EnsureSnapAbsentFromQuota
@@ -251,6 +255,8 @@ func (s *snapmgrBaseTest) SetUpTest(c *C) {
 		snapstate.SetupRemoveComponentHook = oldSetupRemoveComponentHook
 		snapstate.SetupPreRefreshHook = oldSetupPreRefreshHook
 		snapstate.SetupPostRefreshHook = oldSetupPostRefreshHook
+		snapstate.SetupPreEpochMigrationHook = oldSetupPreEpochMigrationHook
+		snapstate.SetupPostEpochMigrationHook = oldSetupPostEpochMigrationHook
 		snapstate.SetupRemoveHook = oldSetupRemoveHook
 		snapstate.SnapServiceOptions = oldSnapServiceOptions
 		snapstate.EnsureSnapAbsentFromQuotaGroup = oldEnsureSnapAbsentFromQuotaGroup
@@ -800,6 +806,60 @@ func (s *snapmgrTestSuite) TestRevertTasksClassic(c *C) {
 	s.testRevertTasks(snapstate.Flags{Classic: true}, c)
 }
 
+func (s *snapmgrTestSuite) TestRevertTasksEpochMigration(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// revision 7 has an empty epoch, while revision 11 has epoch 1*
+	snapstate.Set(s.state, "snap-with-empty-epoch", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "snap-with-empty-epoch", Revision: snap.R(7)},
+			{RealName: "snap-with-empty-epoch", Revision: snap.R(11)},
+		}),
+		Current:  snap.R(11),
+		SnapType: "app",
+	})
+
+	ts, err := snapstate.Revert(s.state, "snap-with-empty-epoch", snapstate.Flags{}, "")
+	c.Assert(err, IsNil)
+
+	snapsup, err := snapstate.TaskSnapSetup(ts.Tasks()[0])
+	c.Assert(err, IsNil)
+	c.Check(snapsup.EpochMigration, DeepEquals, &snapstate.EpochMigration{
+		From: snap.E("1*"),
+		To:   snap.E("0"),
+	})
+
+	// the hooks of the revision reverted to run once it is linked
+	kinds := taskKinds(ts.Tasks())
+	c.Check(kinds, DeepEquals, []string{
+		"prerequisites",
+		"prepare-snap",
+		"stop-snap-services",
+		"remove-aliases",
+		"unlink-current-snap",
+		"setup-profiles",
+		"link-snap",
+		"auto-connect",
+		"set-auto-aliases",
+		"setup-aliases",
+		"run-hook[pre-epoch-migration]",
+		"run-hook[post-epoch-migration]",
+		"start-snap-services",
+		"run-hook[configure]",
+		"run-hook[check-health]",
+	})
+	for _, t := range ts.Tasks()[10:12] {
+		var hooksup hookstate.HookSetup
+		c.Assert(t.Get("hook-setup", &hooksup), IsNil)
+		c.Check(hooksup.Env, DeepEquals, map[string]string{
+			"SNAP_EPOCH_FROM": "1*",
+			"SNAP_EPOCH_TO":   "0",
+		})
+	}
+}
+
 func (s *snapmgrTestSuite) TestRevertCreatesNoGCTasks(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -1582,7 +1642,40 @@ func (s *snapmgrTestSuite) TestRevertToRevisionNoValidVersion(c *C) {
 	})
 
 	ts, err := snapstate.RevertToRevision(s.state, "some-snap", snap.R("99"), snapstate.Flags{}, "")
-	c.Assert(err, ErrorMatches, `cannot find revision 99 for snap "some-snap"`)
+	c.Assert(err, ErrorMatches, `cannot find revision 99 for snap "some-snap": retained revisions are 7`)
+	c.Assert(ts, IsNil)
+}
+
+func (s *snapmgrTestSuite) TestRevertToRevisionNoOtherRevision(c *C) {
+	si := snap.SideInfo{
+		RealName: "some-snap",
+		Revision: snap.R(7),
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{&si}),
+		Current:  snap.R(7),
+	})
+
+	ts, err := snapstate.RevertToRevision(s.state, "some-snap", snap.R("5"), snapstate.Flags{}, "")
+	c.Assert(err, ErrorMatches, `cannot find revision 5 for snap "some-snap": no other revision is retained`)
+	c.Assert(ts, IsNil)
+}
+
+func (s *snapmgrTestSuite) TestRevertNotInstalled(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	ts, err := snapstate.Revert(s.state, "some-snap", snapstate.Flags{}, "")
+	c.Assert(err, testutil.ErrorIs, &snap.NotInstalledError{Snap: "some-snap"})
+	c.Assert(ts, IsNil)
+
+	ts, err = snapstate.RevertToRevision(s.state, "some-snap", snap.R(7), snapstate.Flags{}, "")
+	c.Assert(err, testutil.ErrorIs, &snap.NotInstalledError{Snap: "some-snap"})
 	c.Assert(ts, IsNil)
 }
 
@@ -3885,6 +3978,46 @@ func (s *snapmgrTestSuite) TestEsnureCleansOldSideloads(c *C) {
 	c.Assert(filenames(), DeepEquals, []string{s0})
 }
 
+func (s *snapmgrTestSuite) TestEnsureCleansOldPartialDownloads(c *C) {
+	// prevent removing snap file
+	defer snapstate.MockEnsuredDownloadsCleaned(s.snapmgr, true)()
+	defer snapstate.MockLocalInstallLastCleanup(time.Time{})()
+
+	now := time.Now()
+	defer snapstate.MockTimeNow(func() time.Time { return now })()
+
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0700), IsNil)
+	old := now.Add(-8 * 24 * time.Hour)
+	recent := now.Add(-time.Hour)
+	var stale, kept []string
+	for _, name := range []string{"foo_1.snap.partial", "foo_1.snap.partial.state", "bar_2.snap.partial", "some.snap"} {
+		p := filepath.Join(dirs.SnapBlobDir, name)
+		c.Assert(os.WriteFile(p, nil, 0600), IsNil)
+		mtime := recent
+		switch name {
+		case "foo_1.snap.partial", "foo_1.snap.partial.state":
+			mtime = old
+			stale = append(stale, p)
+		case "some.snap":
+			// only partial downloads are considered
+			mtime = old
+			kept = append(kept, p)
+		default:
+			kept = append(kept, p)
+		}
+		c.Assert(os.Chtimes(p, mtime, mtime), IsNil)
+	}
+
+	s.snapmgr.Ensure()
+
+	for _, p := range stale {
+		c.Check(p, testutil.FileAbsent)
+	}
+	for _, p := range kept {
+		c.Check(p, testutil.FilePresent)
+	}
+}
+
 func (s *snapmgrTestSuite) verifyRefreshLast(c *C) {
 	var lastRefresh time.Time
 
@@ -5372,11 +5505,11 @@ func (s *snapmgrTestSuite) TestRefreshRetain(c *C) {
 	defer restore()
 
 	// default value for classic
-	c.Assert(snapstate.RefreshRetain(st), Equals, 2)
+	c.Assert(snapstate.RefreshRetain(st, "some-snap"), Equals, 2)
 
 	release.MockOnClassic(false)
 	// default value for core
-	c.Assert(snapstate.RefreshRetain(st), Equals, 3)
+	c.Assert(snapstate.RefreshRetain(st, "some-snap"), Equals, 3)
 
 	buf, restoreLogger := logger.MockLogger()
 	defer restoreLogger()
@@ -5395,12 +5528,57 @@ func (s *snapmgrTestSuite) TestRefreshRetain(c *C) {
 		tr := config.NewTransaction(s.state)
 		tr.Set("core", "refresh.retain", val.input)
 		tr.Commit()
-		c.Assert(snapstate.RefreshRetain(st), Equals, val.expected, Commentf("#%d", i))
+		c.Assert(snapstate.RefreshRetain(st, "some-snap"), Equals, val.expected, Commentf("#%d", i))
 		c.Assert(buf.String(), Matches, val.msg, Commentf("#%d", i))
 		buf.Reset()
 	}
 }
 
+func (s *snapmgrTestSuite) TestRefreshRetainPerSnap(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	restore := release.MockOnClassic(true)
+	defer restore()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.retain", 3)
+	tr.Set("core", "refresh.retain-per-snap.pc-kernel", 5)
+	tr.Set("core", "refresh.retain-per-snap.some-snap", "4")
+	tr.Commit()
+
+	c.Check(snapstate.RefreshRetain(st, "pc-kernel"), Equals, 5)
+	c.Check(snapstate.RefreshRetain(st, "some-snap"), Equals, 4)
+	// the count applies to all instances of the snap
+	c.Check(snapstate.RefreshRetain(st, "some-snap_instance"), Equals, 4)
+	// others use refresh.retain
+	c.Check(snapstate.RefreshRetain(st, "other-snap"), Equals, 3)
+
+	buf, restoreLogger := logger.MockLogger()
+	defer restoreLogger()
+
+	// invalid per snap values fall back to refresh.retain
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "refresh.retain-per-snap.pc-kernel", "invalid")
+	tr.Commit()
+	c.Check(snapstate.RefreshRetain(st, "pc-kernel"), Equals, 3)
+	c.Check(buf.String(), Matches, `.*internal error: refresh.retain-per-snap.pc-kernel system option is not valid: .*\n`)
+}
+
+func (s *snapmgrTestSuite) TestSeqRetainPerSnapConf(c *C) {
+	revseq := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.retain", 2)
+	tr.Set("core", "refresh.retain-per-snap.some-snap", 5)
+	tr.Commit()
+	s.state.Unlock()
+
+	s.testUpdateSequence(c, &opSeqOpts{before: revseq[:9], current: 9, via: 10, after: revseq[5:]})
+}
+
 func (s *snapmgrTestSuite) TestSnapStateLocalRevision(c *C) {
 	si7 := snap.SideInfo{
 		RealName: "some-snap",
@@ -56,6 +56,7 @@ import (
 	// So it registers Configure.
 	_ "github.com/snapcore/snapd/overlord/configstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
 	"github.com/snapcore/snapd/overlord/restart"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -775,6 +776,116 @@ func (s *snapmgrTestSuite) TestUpdateTasks(c *C) {
 	c.Assert(err, IsNil)
 
 	c.Check(snapsup.Channel, Equals, "some-channel")
+	c.Check(snapsup.EpochMigration, IsNil)
+}
+
+func (s *snapmgrTestSuite) TestUpdateTasksEpochMigration(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// the installed revision has an empty epoch, while the store offers
+	// a revision with epoch 1*
+	snapstate.Set(s.state, "snap-with-empty-epoch", &snapstate.SnapState{
+		Active:          true,
+		TrackingChannel: "latest/edge",
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{{RealName: "snap-with-empty-epoch", SnapID: "snap-with-empty-epoch-id", Revision: snap.R(7)}}),
+		Current:         snap.R(7),
+		SnapType:        "app",
+	})
+
+	ts, err := snapstate.Update(s.state, "snap-with-empty-epoch", nil, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+
+	var snapsup snapstate.SnapSetup
+	err = ts.Tasks()[0].Get("snap-setup", &snapsup)
+	c.Assert(err, IsNil)
+	c.Check(snapsup.EpochMigration, DeepEquals, &snapstate.EpochMigration{
+		From: snap.E("0"),
+		To:   snap.E("1*"),
+	})
+
+	// the hooks of the new revision run once its data is copied
+	kinds := taskKinds(ts.Tasks())
+	index := func(kind string) int {
+		for i, k := range kinds {
+			if k == kind {
+				return i
+			}
+		}
+		c.Fatalf("no %s task in %v", kind, kinds)
+		return -1
+	}
+	pre := index("run-hook[pre-epoch-migration]")
+	post := index("run-hook[post-epoch-migration]")
+	c.Check(index("copy-snap-data") < pre, Equals, true)
+	c.Check(index("link-snap") < pre, Equals, true)
+	c.Check(kinds[pre+1], Equals, "run-hook[post-refresh]")
+	c.Check(kinds[post-1], Equals, "run-hook[post-refresh]")
+
+	for _, i := range []int{pre, post} {
+		var hooksup hookstate.HookSetup
+		c.Assert(ts.Tasks()[i].Get("hook-setup", &hooksup), IsNil)
+		c.Check(hooksup.Optional, Equals, true)
+		c.Check(hooksup.Env, DeepEquals, map[string]string{
+			"SNAP_EPOCH_FROM": "0",
+			"SNAP_EPOCH_TO":   "1*",
+		})
+	}
+}
+
+func (s *snapmgrTestSuite) TestUpdateTasksBeforeRefreshSnapshot(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:          true,
+		TrackingChannel: "latest/edge",
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(7)}}),
+		Current:         snap.R(7),
+		SnapType:        "app",
+	})
+
+	var snapshotted []string
+	restore := testutil.Mock(&snapstate.BeforeRefreshSnapshot, func(st *state.State, instanceName string) (*state.TaskSet, error) {
+		snapshotted = append(snapshotted, instanceName)
+		return state.NewTaskSet(st.NewTask("save-snapshot", "...")), nil
+	})
+	defer restore()
+
+	ts, err := snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	c.Check(snapshotted, DeepEquals, []string{"some-snap"})
+
+	stop := findKindInTaskSet(ts, "stop-snap-services")
+	c.Assert(stop, NotNil)
+	save := findKindInTaskSet(ts, "save-snapshot")
+	c.Assert(save, NotNil)
+	c.Check(save.WaitTasks(), DeepEquals, []*state.Task{stop})
+	removeAliases := findKindInTaskSet(ts, "remove-aliases")
+	c.Assert(removeAliases, NotNil)
+	c.Check(removeAliases.WaitTasks(), DeepEquals, []*state.Task{save})
+}
+
+func (s *snapmgrTestSuite) TestUpdateTasksBeforeRefreshSnapshotDisabled(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:          true,
+		TrackingChannel: "latest/edge",
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(7)}}),
+		Current:         snap.R(7),
+		SnapType:        "app",
+	})
+
+	restore := testutil.Mock(&snapstate.BeforeRefreshSnapshot, func(st *state.State, instanceName string) (*state.TaskSet, error) {
+		return nil, snapstate.ErrNothingToDo
+	})
+	defer restore()
+
+	ts, err := snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	verifyUpdateTasks(c, snap.TypeApp, doesReRefresh, 0, ts)
 }
 
 func (s *snapmgrTestSuite) TestUpdateAmendRunThrough(c *C) {
@@ -1284,6 +1395,7 @@ func (s *snapmgrTestSuite) testUpdateRunThrough(c *C, refreshAppAwarenessUX bool
 			Transaction: client.TransactionPerSnap,
 		},
 		PreUpdateKernelModuleComponents: []*snap.ComponentSideInfo{},
+		EpochMigration:                  &snapstate.EpochMigration{From: snap.E("0"), To: snap.E("1*")},
 	})
 	c.Assert(snapsup.SideInfo, DeepEquals, &snap.SideInfo{
 		RealName: "services-snap",
@@ -1295,8 +1407,8 @@ func (s *snapmgrTestSuite) testUpdateRunThrough(c *C, refreshAppAwarenessUX bool
 	// verify services stop reason
 	verifyStopReason(c, ts, "refresh")
 
-	// check post-refresh hook
-	task = ts.Tasks()[14]
+	// check post-refresh hook, it follows the pre-epoch-migration hook
+	task = ts.Tasks()[15]
 	c.Assert(task.Kind(), Equals, "run-hook")
 	c.Assert(task.Summary(), Matches, `Run post-refresh hook of "services-snap" snap if present`)
 
@@ -1662,6 +1774,7 @@ func (s *snapmgrTestSuite) testParallelInstanceUpdateRunThrough(c *C, refreshApp
 			Transaction: client.TransactionPerSnap,
 		},
 		PreUpdateKernelModuleComponents: []*snap.ComponentSideInfo{},
+		EpochMigration:                  &snapstate.EpochMigration{From: snap.E("0"), To: snap.E("1*")},
 	})
 	c.Assert(snapsup.SideInfo, DeepEquals, &snap.SideInfo{
 		RealName: "services-snap",
@@ -1673,8 +1786,8 @@ func (s *snapmgrTestSuite) testParallelInstanceUpdateRunThrough(c *C, refreshApp
 	// verify services stop reason
 	verifyStopReason(c, ts, "refresh")
 
-	// check post-refresh hook
-	task = ts.Tasks()[14]
+	// check post-refresh hook, it follows the pre-epoch-migration hook
+	task = ts.Tasks()[15]
 	c.Assert(task.Kind(), Equals, "run-hook")
 	c.Assert(task.Summary(), Matches, `Run post-refresh hook of "services-snap_instance" snap if present`)
 
@@ -11580,7 +11693,8 @@ func checkLastRecordedInhibitedSnaps(c *C, st *state.State, snaps []string) {
 }
 
 func checkRefreshInhibitNotice(c *C, st *state.State, occurrences int) {
-	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.RefreshInhibitNotice}})
+	// the notices of the single inhibited snaps are checked separately
+	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.RefreshInhibitNotice}, Keys: []string{"-"}})
 	if occurrences == 0 {
 		c.Assert(notices, HasLen, 0)
 		return
@@ -12283,6 +12397,75 @@ func (s *snapmgrTestSuite) TestRefreshForcedOnRefreshInhibitionTimeout(c *C) {
 	c.Check(check["some-other-snap"], Equals, 2)
 }
 
+func (s *snapmgrTestSuite) TestProceedWithInhibitedRefresh(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	inhibitedTime := time.Now().Add(-time.Hour)
+	si := &snap.SideInfo{
+		RealName: "some-snap",
+		SnapID:   "some-snap-id",
+		Revision: snap.R(1),
+	}
+	snaptest.MockSnap(c, `name: some-snap`, si)
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:               true,
+		Sequence:             snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:              si.Revision,
+		TrackingChannel:      "latest/stable",
+		RefreshInhibitedTime: &inhibitedTime,
+	})
+	s.state.Set("refresh-candidates", map[string]*snapstate.RefreshCandidate{
+		"some-snap": {
+			SnapSetup: snapstate.SnapSetup{
+				Type:         snap.TypeApp,
+				SideInfo:     &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(11)},
+				DownloadInfo: &snap.DownloadInfo{DownloadURL: "https://example.com/some-snap"},
+				Channel:      "latest/stable",
+			},
+		},
+	})
+
+	var aborted bool
+	s.state.Cache("monitored-snaps", map[string]context.CancelFunc{
+		"some-snap": func() { aborted = true },
+	})
+
+	restore := snapstate.MockAsyncPendingRefreshNotification(func(context.Context, *userclient.PendingSnapRefreshInfo) {})
+	defer restore()
+	// the apps of the snap keep running
+	restore = snapstate.MockRefreshAppsCheck(func(info *snap.Info) error {
+		return snapstate.NewBusySnapError(info, []int{123}, nil, nil)
+	})
+	defer restore()
+
+	tss, err := snapstate.ProceedWithInhibitedRefresh(s.state, "some-snap")
+	c.Assert(err, IsNil)
+	c.Assert(tss, Not(HasLen), 0)
+	c.Check(aborted, Equals, true)
+
+	chg := s.state.NewChange("auto-refresh", "...")
+	var linkTask *state.Task
+	for _, ts := range tss {
+		chg.AddAll(ts)
+		if t := findKindInTaskSet(ts, "link-snap"); t != nil {
+			linkTask = t
+		}
+	}
+	c.Assert(linkTask, NotNil)
+	snapsup, err := snapstate.TaskSnapSetup(linkTask)
+	c.Assert(err, IsNil)
+	c.Check(snapsup.Revision(), Equals, snap.R(11))
+	c.Check(snapsup.IsAutoRefresh, Equals, true)
+	c.Check(snapsup.IsContinuedAutoRefresh, Equals, true)
+
+	// the grace period is over
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Assert(snapst.RefreshInhibitedTime, NotNil)
+	c.Check(time.Since(*snapst.RefreshInhibitedTime) >= snapstate.MaxInhibitionDuration(s.state), Equals, true)
+}
+
 func (s *snapmgrTestSuite) TestRefreshForcedOnRefreshInhibitionTimeoutError(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -366,12 +366,7 @@ func storeUpdatePlan(ctx context.Context, st *state.State, allSnaps map[string]*
 	}
 
 	var oldHints map[string]*refreshCandidate
-	if err := st.Get("refresh-candidates", &oldHints); err != nil {
-		if errors.Is(err, &state.NoStateError{}) {
-			// do nothing
-			return plan, nil
-		}
-
+	if err := st.Get("refresh-candidates", &oldHints); err != nil && !errors.Is(err, &state.NoStateError{}) {
 		return updatePlan{}, fmt.Errorf("cannot get refresh-candidates: %v", err)
 	}
 
@@ -421,6 +416,11 @@ func storeUpdatePlan(ctx context.Context, st *state.State, allSnaps map[string]*
 		plan.targets = append(plan.targets, extraPlan.targets...)
 	}
 
+	// auto-refreshes are subject to the configured refresh policy
+	if err := filterByRefreshPolicy(st, &plan); err != nil {
+		return updatePlan{}, err
+	}
+
 	return plan, nil
 }
 
@@ -546,6 +546,8 @@ func storeUpdatePlanCore(
 		// compTargets will be filtered down to only the components that appear
 		// in the action result, meaning that we might install fewer components
 		// than we have installed right now
+		sar.Info.Architecture = currentArchitecture(snapst)
+
 		compTargets, err := componentTargetsFromActionResult("refresh", sar, compNames)
 		if err != nil {
 			return updatePlan{}, fmt.Errorf("cannot extract components from snap resources: %w", err)
@@ -679,6 +681,16 @@ func currentComponentsAvailableInRevision(snapst *SnapState, info *snap.Info) ([
 	return intersection, nil
 }
 
+// currentArchitecture returns the foreign architecture the current revision
+// of the snap was installed for, if any.
+func currentArchitecture(snapst *SnapState) string {
+	si := snapst.CurrentSideInfo()
+	if si == nil {
+		return ""
+	}
+	return si.Architecture
+}
+
 // ignoreValidationSetsForRefresh returns a boolean indicating whether or not we
 // should ignore validation sets when refreshing this snap. There are two cases
 // to consider, the single refresh case and the refresh-all case. During a
@@ -748,6 +760,8 @@ func collectCurrentSnapsAndActions(
 		if err := completeStoreAction(action, req.RevOpts, ignoreValidation); err != nil {
 			return err
 		}
+		// refreshes keep to the architecture the snap was installed for
+		action.Architecture = currentArchitecture(snapst)
 
 		// if we already have the requested revision installed, we don't need to
 		// consider this snap for a store update, but we still should return it
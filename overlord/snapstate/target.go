@@ -168,6 +168,7 @@ func (t *target) setups(st *state.State, opts Options) (SnapSetup, []ComponentSe
 		InstanceKey:        t.info.InstanceKey,
 		ExpectedProvenance: t.info.SnapProvenance,
 		PluggedConfdbIDs:   confdbSchemaIDs,
+		EpochMigration:     epochMigrationFor(t.info, &t.snapst),
 		AuxStoreInfo: backend.AuxStoreInfo{
 			Media:    t.info.Media,
 			StoreURL: t.info.StoreURL,
@@ -296,6 +297,10 @@ func (s *storeInstallGoal) toInstall(ctx context.Context, st *state.State, opts
 			channel = "stable"
 		}
 
+		// a foreign architecture revision is tracked as such in the
+		// sequence, so that it keeps being refreshed for it
+		r.Info.Architecture = sn.RevOpts.Architecture
+
 		comps, err := componentTargetsFromActionResult("install", r, sn.Components)
 		if err != nil {
 			return nil, fmt.Errorf("cannot extract components from snap resources: %w", err)
@@ -504,6 +509,7 @@ func completeStoreAction(action *store.SnapAction, revOpts RevisionOptions, igno
 	action.Channel = revOpts.Channel
 	action.CohortKey = revOpts.CohortKey
 	action.Revision = revOpts.Revision
+	action.Architecture = revOpts.Architecture
 
 	// caller requested that we ignore validation sets, nothing to do
 	if ignoreValidation {
@@ -0,0 +1,113 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// TaskRetryConfigKey is the system option under which the retries of tasks
+// failing with transient network errors are tuned per task kind, in the
+// form tasks.retry.<kind>.{max-retries,initial-backoff,max-backoff}.
+const TaskRetryConfigKey = "tasks.retry"
+
+// RetriedTaskKinds are the kinds of tasks talking to the store that are
+// retried when they fail with a transient network error.
+var RetriedTaskKinds = []string{
+	"download-snap",
+	"pre-download-snap",
+	"download-component",
+}
+
+// defaultTaskRetryPolicy is kept short as the store already retries
+// these errors within each attempt, the retries of the task are there to
+// get over a network outage of a few minutes.
+var defaultTaskRetryPolicy = state.RetryPolicy{
+	MaxRetries:     2,
+	InitialBackoff: time.Minute,
+	MaxBackoff:     2 * time.Minute,
+	Jitter:         0.2,
+}
+
+// isTransientNetworkError returns whether err, or any error it wraps, is
+// a network error which is likely to go away by itself.
+func isTransientNetworkError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if httputil.ShouldRetryError(err) || httputil.NoNetwork(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// taskRetryPolicy returns the retry policy for the kind of the task,
+// taking the tasks.retry.<kind>.* system options into account.
+func taskRetryPolicy(t *state.Task) state.RetryPolicy {
+	policy := defaultTaskRetryPolicy
+
+	tr := config.NewTransaction(t.State())
+	var opts map[string]any
+	option := TaskRetryConfigKey + "." + t.Kind()
+	if err := tr.Get("core", option, &opts); err != nil {
+		if !config.IsNoOption(err) {
+			logger.Noticef("cannot get %s system option, using the default retry policy: %v", option, err)
+		}
+		return policy
+	}
+
+	if v, ok := opts["max-retries"]; ok {
+		n, err := strconv.Atoi(fmt.Sprintf("%v", v))
+		if err != nil || n < 0 {
+			logger.Noticef("ignoring invalid %s.max-retries system option %q", option, v)
+		} else {
+			policy.MaxRetries = n
+		}
+	}
+	for _, backoff := range []struct {
+		name string
+		dur  *time.Duration
+	}{
+		{"initial-backoff", &policy.InitialBackoff},
+		{"max-backoff", &policy.MaxBackoff},
+	} {
+		v, ok := opts[backoff.name]
+		if !ok {
+			continue
+		}
+		dur, err := time.ParseDuration(fmt.Sprintf("%v", v))
+		if err != nil || dur <= 0 {
+			logger.Noticef("ignoring invalid %s.%s system option %q", option, backoff.name, v)
+			continue
+		}
+		*backoff.dur = dur
+	}
+	if policy.MaxBackoff < policy.InitialBackoff {
+		policy.MaxBackoff = policy.InitialBackoff
+	}
+	return policy
+}
@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type taskRetrySuite struct {
+	st *state.State
+}
+
+var _ = Suite(&taskRetrySuite{})
+
+func (s *taskRetrySuite) SetUpTest(c *C) {
+	s.st = state.New(nil)
+}
+
+func (s *taskRetrySuite) TestIsTransientNetworkError(c *C) {
+	connReset := &net.OpError{Op: "read", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}}
+	c.Check(snapstate.IsTransientNetworkError(connReset), Equals, true)
+	c.Check(snapstate.IsTransientNetworkError(fmt.Errorf("cannot download: %w", connReset)), Equals, true)
+	c.Check(snapstate.IsTransientNetworkError(io.ErrUnexpectedEOF), Equals, true)
+
+	c.Check(snapstate.IsTransientNetworkError(errors.New("checksum mismatch")), Equals, false)
+	c.Check(snapstate.IsTransientNetworkError(nil), Equals, false)
+}
+
+func (s *taskRetrySuite) TestTaskRetryPolicyDefault(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	t := s.st.NewTask("download-snap", "...")
+	c.Check(snapstate.TaskRetryPolicy(t), Equals, state.RetryPolicy{
+		MaxRetries:     2,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     2 * time.Minute,
+		Jitter:         0.2,
+	})
+}
+
+func (s *taskRetrySuite) TestTaskRetryPolicyConfigured(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	tr := config.NewTransaction(s.st)
+	c.Assert(tr.Set("core", "tasks.retry.download-snap.max-retries", 10), IsNil)
+	c.Assert(tr.Set("core", "tasks.retry.download-snap.initial-backoff", "1m"), IsNil)
+	c.Assert(tr.Set("core", "tasks.retry.download-snap.max-backoff", "30m"), IsNil)
+	c.Assert(tr.Set("core", "tasks.retry.pre-download-snap.max-retries", "0"), IsNil)
+	tr.Commit()
+
+	t := s.st.NewTask("download-snap", "...")
+	c.Check(snapstate.TaskRetryPolicy(t), Equals, state.RetryPolicy{
+		MaxRetries:     10,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     30 * time.Minute,
+		Jitter:         0.2,
+	})
+
+	// other kinds are configured separately
+	t = s.st.NewTask("pre-download-snap", "...")
+	c.Check(snapstate.TaskRetryPolicy(t).MaxRetries, Equals, 0)
+	t = s.st.NewTask("download-component", "...")
+	c.Check(snapstate.TaskRetryPolicy(t).MaxRetries, Equals, 2)
+}
+
+func (s *taskRetrySuite) TestTaskRetryPolicyInvalidIgnored(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	tr := config.NewTransaction(s.st)
+	c.Assert(tr.Set("core", "tasks.retry.download-snap.max-retries", "many"), IsNil)
+	c.Assert(tr.Set("core", "tasks.retry.download-snap.initial-backoff", "20m"), IsNil)
+	c.Assert(tr.Set("core", "tasks.retry.download-snap.max-backoff", "soon"), IsNil)
+	tr.Commit()
+
+	t := s.st.NewTask("download-snap", "...")
+	c.Check(snapstate.TaskRetryPolicy(t), Equals, state.RetryPolicy{
+		MaxRetries:     2,
+		InitialBackoff: 20 * time.Minute,
+		// raised to the initial backoff
+		MaxBackoff: 20 * time.Minute,
+		Jitter:     0.2,
+	})
+}
@@ -0,0 +1,262 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/configstate/proxyconf"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+)
+
+// DeriveSideInfoFromStore is set by assertstate, it fetches from the store
+// the assertions matching the given digest and size of the snap file at
+// snapPath and derives the side info of the snap from them.
+var DeriveSideInfoFromStore func(st *state.State, userID int, snapPath, snapSHA3_384 string, snapSize uint64, deviceCtx DeviceContext) (*snap.SideInfo, error)
+
+var (
+	// urlDownloadHTTPClient returns the client used to download snaps
+	// from an arbitrary https url, it goes through the configured proxy
+	// like the store does
+	urlDownloadHTTPClient = func(st *state.State) *http.Client {
+		return httputil.NewHTTPClient(&httputil.ClientOptions{
+			Proxy: proxyconf.New(st).Conf,
+			ExtraSSLCerts: &httputil.ExtraSSLCertsFromDir{
+				Dir: dirs.SnapdStoreSSLCertsDir,
+			},
+		})
+	}
+
+	// maxURLSnapSize is the maximum size of a snap downloaded from an
+	// url, the actual size is only known from the snap-revision
+	// assertion once the snap is downloaded
+	maxURLSnapSize int64 = 8 << 30
+)
+
+// maxURLRedirects is the maximum number of redirects followed when
+// downloading a snap from an url.
+const maxURLRedirects = 10
+
+// urlSnapSetup describes the install of a snap downloaded from an url.
+type urlSnapSetup struct {
+	InstanceName string `json:"instance-name"`
+	URL          string `json:"url"`
+	UserID       int    `json:"user-id,omitempty"`
+	Flags        Flags  `json:"flags"`
+}
+
+// InstallFromURL returns a set of tasks for installing a snap downloaded
+// from an arbitrary https url. Unlike sideloading, the downloaded file must
+// always be covered by store assertions, which are fetched based on the
+// digest of the file. The tasks installing the snap are only added to the
+// change once the snap is downloaded and its side info derived from the
+// assertions.
+//
+// Note that the state must be locked by the caller.
+func InstallFromURL(st *state.State, instanceName, rawURL string, userID int, flags Flags) (*state.TaskSet, error) {
+	if _, err := DevicePastSeeding(st, nil); err != nil {
+		return nil, err
+	}
+	if err := CheckChangeConflict(st, instanceName, nil); err != nil {
+		return nil, err
+	}
+
+	t := st.NewTask("download-snap-from-url", fmt.Sprintf(i18n.G("Download snap %q from %q"), instanceName, rawURL))
+	t.Set("url-snap-setup", &urlSnapSetup{
+		InstanceName: instanceName,
+		URL:          rawURL,
+		UserID:       userID,
+		Flags:        flags,
+	})
+	return state.NewTaskSet(t), nil
+}
+
+func urlSnapAffectedSnaps(t *state.Task) ([]string, error) {
+	var setup urlSnapSetup
+	if err := t.Get("url-snap-setup", &setup); err != nil {
+		return nil, err
+	}
+	return []string{setup.InstanceName}, nil
+}
+
+// checkURLRedirect only follows redirects to other https urls, so that
+// the download is never downgraded to plain http.
+func checkURLRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxURLRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxURLRedirects)
+	}
+	if req.URL.Scheme != "https" {
+		return fmt.Errorf("cannot follow redirect to non-https url %q", req.URL)
+	}
+	return nil
+}
+
+// downloadSnapFromURL downloads the snap at the given url into w,
+// reporting the progress to meter.
+//
+// It must be called without holding the state lock.
+func downloadSnapFromURL(ctx context.Context, st *state.State, rawURL string, w io.Writer, meter progress.Meter) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %v", err)
+	}
+	cli := urlDownloadHTTPClient(st)
+	cli.CheckRedirect = checkURLRedirect
+	resp, err := cli.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot download snap: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot download snap from %q: unexpected status %q", rawURL, resp.Status)
+	}
+	if resp.ContentLength > maxURLSnapSize {
+		return fmt.Errorf("cannot download snap from %q: size %d exceeds the maximum of %d bytes", rawURL, resp.ContentLength, maxURLSnapSize)
+	}
+
+	meter.Start(rawURL, float64(resp.ContentLength))
+	defer meter.Finished()
+	n, err := io.Copy(io.MultiWriter(w, meter), io.LimitReader(resp.Body, maxURLSnapSize+1))
+	if err != nil {
+		return fmt.Errorf("cannot download snap from %q: %v", rawURL, err)
+	}
+	if n > maxURLSnapSize {
+		return fmt.Errorf("cannot download snap from %q: size exceeds the maximum of %d bytes", rawURL, maxURLSnapSize)
+	}
+	return nil
+}
+
+func (m *SnapManager) doDownloadSnapFromURL(t *state.Task, tomb *tomb.Tomb) (err error) {
+	st := t.State()
+	st.Lock()
+	var setup urlSnapSetup
+	err = t.Get("url-snap-setup", &setup)
+	var oldPath string
+	if err == nil {
+		err = t.Get("snap-path", &oldPath)
+		if errors.Is(err, state.ErrNoState) {
+			err = nil
+		}
+	}
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+	if oldPath != "" {
+		// the download was interrupted by a restart, start over
+		os.Remove(oldPath)
+	}
+
+	f, err := os.CreateTemp(dirs.SnapBlobDir, dirs.LocalInstallBlobTempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file: %v", err)
+	}
+	path := f.Name()
+	removePath := true
+	defer func() {
+		if removePath {
+			os.Remove(path)
+		}
+	}()
+	st.Lock()
+	t.Set("snap-path", path)
+	st.Unlock()
+
+	meter := NewTaskProgressAdapterUnlocked(t)
+	err = downloadSnapFromURL(tomb.Context(nil), st, setup.URL, f, meter)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	digest, size, err := asserts.SnapFileSHA3_384(path)
+	if err != nil {
+		return fmt.Errorf("cannot compute digest of downloaded snap: %v", err)
+	}
+
+	st.Lock()
+	defer st.Unlock()
+
+	deviceCtx, err := DeviceCtx(st, t, nil)
+	if err != nil {
+		return err
+	}
+	si, err := DeriveSideInfoFromStore(st, setup.UserID, path, digest, size, deviceCtx)
+	if err != nil {
+		if errors.Is(err, &asserts.NotFoundError{}) {
+			return fmt.Errorf("cannot find signatures with metadata for snap downloaded from %q", setup.URL)
+		}
+		return err
+	}
+	if snapName := snap.InstanceSnap(setup.InstanceName); si.RealName != snapName {
+		return fmt.Errorf("snap downloaded from %q is %q, not %q", setup.URL, si.RealName, snapName)
+	}
+
+	// the downloaded file is handed off to the install tasks
+	flags := setup.Flags
+	flags.RemoveSnapPath = true
+	chg := t.Change()
+	ts, err := InstallPathWithDeviceContext(st, si, path, setup.InstanceName, nil, setup.UserID, flags, nil, deviceCtx, chg.ID())
+	if err != nil {
+		return err
+	}
+	removePath = false
+	ts.WaitFor(t)
+	chg.AddAll(ts)
+
+	t.SetStatus(state.DoneStatus)
+	st.EnsureBefore(0)
+	return nil
+}
+
+func (m *SnapManager) undoDownloadSnapFromURL(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var path string
+	if err := t.Get("snap-path", &path); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	// the install tasks remove the file once it is mounted, it is only
+	// left behind if they did not get that far
+	if path != "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
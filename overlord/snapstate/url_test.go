@@ -0,0 +1,278 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type urlSnapSuite struct {
+	baseHandlerSuite
+
+	snapBytes []byte
+	digest    string
+	size      uint64
+	derives   int
+
+	srv *httptest.Server
+}
+
+var _ = Suite(&urlSnapSuite{})
+
+func (s *urlSnapSuite) SetUpTest(c *C) {
+	s.baseHandlerSuite.SetUpTest(c)
+
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+
+	fooSnap := makeTestSnap(c, "name: foo\nversion: 1")
+	var err error
+	s.digest, s.size, err = asserts.SnapFileSHA3_384(fooSnap)
+	c.Assert(err, IsNil)
+	s.snapBytes, err = os.ReadFile(fooSnap)
+	c.Assert(err, IsNil)
+
+	s.srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/foo.snap":
+			w.Write(s.snapBytes)
+		case "/redirect":
+			http.Redirect(w, r, "/foo.snap", http.StatusFound)
+		case "/redirect-http":
+			http.Redirect(w, r, "http://"+r.Host+"/foo.snap", http.StatusFound)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	s.AddCleanup(s.srv.Close)
+	s.AddCleanup(snapstate.MockURLDownloadHTTPClient(s.srv.Client()))
+
+	s.derives = 0
+	s.AddCleanup(snapstate.MockDeriveSideInfoFromStore(func(st *state.State, userID int, snapPath, digest string, size uint64, deviceCtx snapstate.DeviceContext) (*snap.SideInfo, error) {
+		s.derives++
+		c.Check(userID, Equals, 1)
+		c.Check(digest, Equals, s.digest)
+		c.Check(size, Equals, s.size)
+		c.Check(snapPath, testutil.FileEquals, s.snapBytes)
+		return &snap.SideInfo{RealName: "foo", SnapID: "foo-id", Revision: snap.R(41)}, nil
+	}))
+
+	s.AddCleanup(snapstatetest.UseFallbackDeviceModel())
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	ifacerepo.Replace(s.state, interfaces.NewRepository())
+	s.state.Unlock()
+}
+
+func (s *urlSnapSuite) blobs(c *C) []string {
+	blobs, err := filepath.Glob(filepath.Join(dirs.SnapBlobDir, dirs.LocalInstallBlobTempPrefix+"*"))
+	c.Assert(err, IsNil)
+	return blobs
+}
+
+// runInstallFromURL runs the download task of an install from the given
+// url, leaving the install tasks it adds alone.
+func (s *urlSnapSuite) runInstallFromURL(c *C, name, url string) (*state.Change, *state.Task) {
+	s.state.Lock()
+	ts, err := snapstate.InstallFromURL(s.state, name, url, 1, snapstate.Flags{DevMode: true})
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 1)
+	t := ts.Tasks()[0]
+	c.Check(t.Kind(), Equals, "download-snap-from-url")
+	chg := s.state.NewChange("install-snap", "...")
+	chg.AddAll(ts)
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+	return chg, t
+}
+
+func (s *urlSnapSuite) TestInstallFromURL(c *C) {
+	url := s.srv.URL + "/foo.snap"
+	chg, t := s.runInstallFromURL(c, "foo", url)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(t.Status(), Equals, state.DoneStatus, Commentf("%v", t.Log()))
+	c.Check(t.Summary(), Equals, fmt.Sprintf(`Download snap "foo" from %q`, url))
+	c.Check(s.derives, Equals, 1)
+	label, done, total := t.Progress()
+	c.Check(label, Equals, url)
+	c.Check(done, Equals, len(s.snapBytes))
+	c.Check(total, Equals, len(s.snapBytes))
+
+	// the install tasks were added to the change once the snap was
+	// verified
+	tasks := chg.Tasks()
+	c.Assert(len(tasks) > 1, Equals, true)
+	c.Check(tasks[0], Equals, t)
+	c.Check(tasks[1].WaitTasks(), DeepEquals, []*state.Task{t})
+	snapsup, err := snapstate.TaskSnapSetup(tasks[1])
+	c.Assert(err, IsNil)
+	c.Check(snapsup.SideInfo, DeepEquals, &snap.SideInfo{RealName: "foo", SnapID: "foo-id", Revision: snap.R(41)})
+	c.Check(snapsup.UserID, Equals, 1)
+	c.Check(snapsup.DevMode, Equals, true)
+	// the downloaded file is removed once installed
+	c.Check(snapsup.RemoveSnapPath, Equals, true)
+	c.Check(filepath.Dir(snapsup.SnapPath), Equals, dirs.SnapBlobDir)
+	c.Check(snapsup.SnapPath, testutil.FileEquals, s.snapBytes)
+}
+
+func (s *urlSnapSuite) TestInstallFromURLStartsOver(c *C) {
+	// a partial download from before a restart
+	partial := filepath.Join(dirs.SnapBlobDir, dirs.LocalInstallBlobTempPrefix+"partial")
+	c.Assert(os.WriteFile(partial, []byte("snap"), 0644), IsNil)
+
+	s.state.Lock()
+	ts, err := snapstate.InstallFromURL(s.state, "foo", s.srv.URL+"/foo.snap", 1, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	t := ts.Tasks()[0]
+	t.Set("snap-path", partial)
+	s.state.NewChange("install-snap", "...").AddAll(ts)
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.DoneStatus, Commentf("%v", t.Log()))
+	c.Check(partial, testutil.FileAbsent)
+	var path string
+	c.Assert(t.Get("snap-path", &path), IsNil)
+	c.Check(path, testutil.FileEquals, s.snapBytes)
+}
+
+func (s *urlSnapSuite) TestInstallFromURLRedirect(c *C) {
+	_, t := s.runInstallFromURL(c, "foo", s.srv.URL+"/redirect")
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.DoneStatus, Commentf("%v", t.Log()))
+	c.Check(s.derives, Equals, 1)
+}
+
+func (s *urlSnapSuite) testInstallFromURLError(c *C, name, url, errMatch string) {
+	chg, t := s.runInstallFromURL(c, name, url)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(t.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*\n- Download snap "`+name+`" from .* \(`+errMatch+`\)`)
+	c.Check(chg.Tasks(), HasLen, 1)
+	// the downloaded blob was cleaned up
+	c.Check(s.blobs(c), HasLen, 0)
+}
+
+func (s *urlSnapSuite) TestInstallFromURLDownloadError(c *C) {
+	url := s.srv.URL + "/missing.snap"
+	s.testInstallFromURLError(c, "foo", url, fmt.Sprintf(`cannot download snap from %q: unexpected status "404 Not Found"`, url))
+	c.Check(s.derives, Equals, 0)
+}
+
+func (s *urlSnapSuite) TestInstallFromURLRedirectToHTTP(c *C) {
+	s.testInstallFromURLError(c, "foo", s.srv.URL+"/redirect-http", `cannot download snap: .*cannot follow redirect to non-https url "http://.*/foo.snap"`)
+	c.Check(s.derives, Equals, 0)
+}
+
+func (s *urlSnapSuite) TestInstallFromURLTooBig(c *C) {
+	defer snapstate.MockMaxURLSnapSize(int64(len(s.snapBytes) - 1))()
+
+	url := s.srv.URL + "/foo.snap"
+	s.testInstallFromURLError(c, "foo", url, fmt.Sprintf(`cannot download snap from %q: size .*exceeds the maximum of %d bytes`, url, len(s.snapBytes)-1))
+	c.Check(s.derives, Equals, 0)
+}
+
+func (s *urlSnapSuite) TestInstallFromURLNoSignatures(c *C) {
+	defer snapstate.MockDeriveSideInfoFromStore(func(*state.State, int, string, string, uint64, snapstate.DeviceContext) (*snap.SideInfo, error) {
+		return nil, &asserts.NotFoundError{Type: asserts.SnapRevisionType}
+	})()
+
+	url := s.srv.URL + "/foo.snap"
+	s.testInstallFromURLError(c, "foo", url, fmt.Sprintf("cannot find signatures with metadata for snap downloaded from %q", url))
+}
+
+func (s *urlSnapSuite) TestInstallFromURLNameMismatch(c *C) {
+	url := s.srv.URL + "/foo.snap"
+	s.testInstallFromURLError(c, "bar", url, fmt.Sprintf(`snap downloaded from %q is "foo", not "bar"`, url))
+}
+
+func (s *urlSnapSuite) TestInstallFromURLConflict(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	ts, err := snapstate.InstallFromURL(s.state, "foo", s.srv.URL+"/foo.snap", 0, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("install-snap", "...")
+	chg.AddAll(ts)
+
+	// the snap is considered affected while it is downloaded
+	_, err = snapstate.InstallFromURL(s.state, "foo", s.srv.URL+"/foo.snap", 0, snapstate.Flags{})
+	c.Check(err, testutil.ErrorIs, &snapstate.ChangeConflictError{})
+	c.Check(snapstate.CheckChangeConflict(s.state, "foo", nil), testutil.ErrorIs, &snapstate.ChangeConflictError{})
+	c.Check(snapstate.CheckChangeConflict(s.state, "bar", nil), IsNil)
+}
+
+func (s *urlSnapSuite) TestInstallFromURLNotSeeded(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", false)
+
+	_, err := snapstate.InstallFromURL(s.state, "foo", s.srv.URL+"/foo.snap", 0, snapstate.Flags{})
+	c.Check(err, ErrorMatches, "too early for operation, device not yet seeded or device model not acknowledged")
+}
+
+func (s *urlSnapSuite) TestUndoInstallFromURL(c *C) {
+	chg, t := s.runInstallFromURL(c, "foo", s.srv.URL+"/foo.snap")
+
+	s.state.Lock()
+	c.Assert(t.Status(), Equals, state.DoneStatus)
+	c.Check(s.blobs(c), HasLen, 1)
+	// the install is aborted before the install tasks ran
+	chg.Abort()
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.UndoneStatus)
+	// the downloaded blob was cleaned up
+	c.Check(s.blobs(c), HasLen, 0)
+}
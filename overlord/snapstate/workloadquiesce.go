@@ -0,0 +1,217 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// workloadQuiesceTimeout bounds how long a refresh waits for the
+// workloads reported by the pre-refresh hook to be quiesced.
+var workloadQuiesceTimeout = 5 * time.Minute
+
+// workloadQuiesce tracks the workloads of a snap, such as VMs or
+// containers, that its pre-refresh hook reported as needing to be quiesced
+// before the services of the snap are stopped for a refresh.
+type workloadQuiesce struct {
+	// Change is the ID of the refresh change the request belongs to.
+	Change      string    `json:"change"`
+	Workloads   []string  `json:"workloads"`
+	RequestTime time.Time `json:"request-time"`
+	Complete    bool      `json:"complete,omitempty"`
+	// Task is the ID of the task waiting for the workloads to be
+	// quiesced, it is woken up on completion.
+	Task string `json:"task,omitempty"`
+}
+
+func getWorkloadQuiesces(st *state.State) (map[string]*workloadQuiesce, error) {
+	var quiesces map[string]*workloadQuiesce
+	if err := st.Get("workload-quiesce", &quiesces); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if quiesces == nil {
+		quiesces = make(map[string]*workloadQuiesce)
+	}
+	return quiesces, nil
+}
+
+func setWorkloadQuiesces(st *state.State, quiesces map[string]*workloadQuiesce) {
+	if len(quiesces) == 0 {
+		st.Set("workload-quiesce", nil)
+		return
+	}
+	st.Set("workload-quiesce", quiesces)
+}
+
+// RequestWorkloadQuiesce records that the given workloads of the snap must
+// be quiesced before the refresh carried by the given change stops the snap
+// services. The refresh waits, for a bounded time, until the snap signals
+// completion with CompleteWorkloadQuiesce. Workloads reported more than once
+// for the same change accumulate.
+func RequestWorkloadQuiesce(st *state.State, instanceName, changeID string, workloads []string) error {
+	if len(workloads) == 0 {
+		return fmt.Errorf("cannot request quiesce of snap %q workloads: no workloads given", instanceName)
+	}
+	quiesces, err := getWorkloadQuiesces(st)
+	if err != nil {
+		return err
+	}
+	// drop requests of changes that are gone or finished
+	for name, q := range quiesces {
+		if chg := st.Change(q.Change); chg == nil || chg.IsReady() {
+			delete(quiesces, name)
+		}
+	}
+
+	q := quiesces[instanceName]
+	if q == nil || q.Change != changeID {
+		q = &workloadQuiesce{
+			Change:      changeID,
+			RequestTime: timeNow(),
+		}
+		quiesces[instanceName] = q
+	}
+	q.Workloads = strutil.Deduplicate(append(q.Workloads, workloads...))
+	q.Complete = false
+	setWorkloadQuiesces(st, quiesces)
+	return nil
+}
+
+// CompleteWorkloadQuiesce signals that the workloads of the snap reported
+// with RequestWorkloadQuiesce were quiesced and the refresh can proceed.
+func CompleteWorkloadQuiesce(st *state.State, instanceName string) error {
+	quiesces, err := getWorkloadQuiesces(st)
+	if err != nil {
+		return err
+	}
+	q := quiesces[instanceName]
+	if q == nil {
+		return fmt.Errorf("no quiesce of workloads requested for snap %q", instanceName)
+	}
+	q.Complete = true
+	setWorkloadQuiesces(st, quiesces)
+	wakeWorkloadQuiesceTask(st, q)
+	return nil
+}
+
+// wakeWorkloadQuiesceTask runs the task waiting for the quiesce of the
+// workloads again right away, if any.
+func wakeWorkloadQuiesceTask(st *state.State, q *workloadQuiesce) {
+	if t := st.Task(q.Task); t != nil {
+		t.At(time.Time{})
+		st.EnsureBefore(0)
+	}
+}
+
+// clearWorkloadQuiesce drops the quiesce request of the snap made for the
+// given change, if any.
+func clearWorkloadQuiesce(st *state.State, instanceName, changeID string) error {
+	quiesces, err := getWorkloadQuiesces(st)
+	if err != nil {
+		return err
+	}
+	if q := quiesces[instanceName]; q == nil || q.Change != changeID {
+		return nil
+	}
+	delete(quiesces, instanceName)
+	setWorkloadQuiesces(st, quiesces)
+	return nil
+}
+
+// processWorkloadQuiesce drops the quiesce requests made for a change once
+// it is aborted or ready, so that they do not outlive an aborted or failed
+// refresh. The task waiting for an aborted request is woken up so that it
+// is undone right away.
+func processWorkloadQuiesce(chg *state.Change, old, new state.Status) {
+	aborted := new == state.AbortStatus
+	if !aborted && (old.Ready() || !new.Ready()) {
+		return
+	}
+	st := chg.State()
+	quiesces, err := getWorkloadQuiesces(st)
+	if err != nil {
+		logger.Noticef("cannot get workload quiesce requests: %v", err)
+		return
+	}
+	changed := false
+	for name, q := range quiesces {
+		if q.Change != chg.ID() {
+			continue
+		}
+		if aborted {
+			wakeWorkloadQuiesceTask(st, q)
+		}
+		delete(quiesces, name)
+		changed = true
+	}
+	if changed {
+		setWorkloadQuiesces(st, quiesces)
+	}
+}
+
+// waitWorkloadQuiesce returns a *state.Retry error while the workloads of
+// the snap reported by the pre-refresh hook of the change of the task are
+// not yet quiesced and the wait did not time out. The task is scheduled
+// for the timeout and run again earlier once the snap signals completion.
+// It records the progress of the negotiation in the task log.
+func waitWorkloadQuiesce(t *state.Task, instanceName string) error {
+	st := t.State()
+	chg := t.Change()
+	if chg == nil {
+		return nil
+	}
+	quiesces, err := getWorkloadQuiesces(st)
+	if err != nil {
+		return err
+	}
+	q := quiesces[instanceName]
+	if q == nil || q.Change != chg.ID() {
+		return nil
+	}
+
+	workloads := strutil.Quoted(q.Workloads)
+	deadline := q.RequestTime.Add(workloadQuiesceTimeout)
+	switch {
+	case q.Complete:
+		t.Logf("Workloads %s of snap %q were quiesced", workloads, instanceName)
+	case !timeNow().Before(deadline):
+		t.Logf("Timed out waiting for workloads %s of snap %q to be quiesced, proceeding", workloads, instanceName)
+	default:
+		if q.Task != t.ID() {
+			t.Logf("Waiting up to %v for workloads %s of snap %q to be quiesced", workloadQuiesceTimeout, workloads, instanceName)
+			q.Task = t.ID()
+			setWorkloadQuiesces(st, quiesces)
+		}
+		// scheduled here rather than with Retry.After so that
+		// CompleteWorkloadQuiesce can reschedule the task
+		t.At(deadline)
+		return &state.Retry{}
+	}
+
+	delete(quiesces, instanceName)
+	setWorkloadQuiesces(st, quiesces)
+	return nil
+}
@@ -0,0 +1,208 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapmgrTestSuite) setupStopServicesForRefresh(c *C) (*state.Change, *state.Task) {
+	snapstate.Set(s.state, "services-snap", &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "services-snap", Revision: snap.R(11)},
+		}),
+		Current: snap.R(11),
+		Active:  true,
+	})
+
+	chg := s.state.NewChange("refresh-snap", "refresh the snap")
+	t := s.state.NewTask("stop-snap-services", "...")
+	t.Set("stop-reason", snap.StopReasonRefresh)
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: "services-snap",
+			Revision: snap.R(11),
+			SnapID:   "services-snap-id",
+		},
+	})
+	chg.AddTask(t)
+	return chg, t
+}
+
+func (s *snapmgrTestSuite) runEnsure() {
+	s.state.Unlock()
+	defer s.state.Lock()
+	s.se.Ensure()
+	s.se.Wait()
+}
+
+func (s *snapmgrTestSuite) TestRequestWorkloadQuiesceErrors(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	err := snapstate.RequestWorkloadQuiesce(s.state, "services-snap", "1", nil)
+	c.Check(err, ErrorMatches, `cannot request quiesce of snap "services-snap" workloads: no workloads given`)
+
+	err = snapstate.CompleteWorkloadQuiesce(s.state, "services-snap")
+	c.Check(err, ErrorMatches, `no quiesce of workloads requested for snap "services-snap"`)
+}
+
+func (s *snapmgrTestSuite) TestStopSnapServicesWaitsForWorkloadQuiesce(c *C) {
+	restore := snapstate.MockWorkloadQuiesceTimeout(time.Hour)
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg, t := s.setupStopServicesForRefresh(c)
+
+	c.Assert(snapstate.RequestWorkloadQuiesce(s.state, "services-snap", chg.ID(), []string{"vm1"}), IsNil)
+	// workloads accumulate
+	c.Assert(snapstate.RequestWorkloadQuiesce(s.state, "services-snap", chg.ID(), []string{"vm2", "vm1"}), IsNil)
+
+	s.runEnsure()
+	s.runEnsure()
+
+	c.Check(t.Status(), Equals, state.DoingStatus)
+	c.Check(s.fakeBackend.ops.Count("stop-snap-services:refresh"), Equals, 0)
+	c.Assert(t.Log(), HasLen, 1)
+	c.Check(t.Log()[0], Matches, `.* Waiting up to 1h0m0s for workloads "vm1", "vm2" of snap "services-snap" to be quiesced`)
+	// the task is not polling, it is only scheduled for the timeout
+	c.Check(t.AtTime().After(time.Now().Add(50*time.Minute)), Equals, true)
+
+	c.Assert(snapstate.CompleteWorkloadQuiesce(s.state, "services-snap"), IsNil)
+	// completion wakes the task up
+	c.Check(t.AtTime().After(time.Now()), Equals, false)
+
+	s.settle(c)
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(t.Status(), Equals, state.DoneStatus)
+	c.Check(s.fakeBackend.ops.Count("stop-snap-services:refresh"), Equals, 1)
+	c.Assert(len(t.Log()) >= 2, Equals, true)
+	c.Check(t.Log()[1], Matches, `.* Workloads "vm1", "vm2" of snap "services-snap" were quiesced`)
+
+	// the request is gone
+	c.Check(s.state.Get("workload-quiesce", new(any)), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (s *snapmgrTestSuite) TestStopSnapServicesWorkloadQuiesceTimeout(c *C) {
+	restore := snapstate.MockWorkloadQuiesceTimeout(time.Minute)
+	defer restore()
+
+	now := time.Now()
+	restore = snapstate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg, t := s.setupStopServicesForRefresh(c)
+
+	c.Assert(snapstate.RequestWorkloadQuiesce(s.state, "services-snap", chg.ID(), []string{"vm1"}), IsNil)
+
+	s.runEnsure()
+	c.Check(t.Status(), Equals, state.DoingStatus)
+	c.Check(t.AtTime().Equal(now.Add(time.Minute)), Equals, true)
+
+	// the snap never signals completion, the task runs again at the
+	// timeout
+	now = now.Add(2 * time.Minute)
+	t.At(time.Time{})
+
+	s.settle(c)
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(t.Status(), Equals, state.DoneStatus)
+	c.Check(s.fakeBackend.ops.Count("stop-snap-services:refresh"), Equals, 1)
+	c.Assert(len(t.Log()) >= 2, Equals, true)
+	c.Check(t.Log()[1], Matches, `.* Timed out waiting for workloads "vm1" of snap "services-snap" to be quiesced, proceeding`)
+}
+
+func (s *snapmgrTestSuite) TestStopSnapServicesWorkloadQuiesceOtherChange(c *C) {
+	restore := snapstate.MockWorkloadQuiesceTimeout(time.Hour)
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// a leftover request of a change that is gone
+	c.Assert(snapstate.RequestWorkloadQuiesce(s.state, "services-snap", "999", []string{"vm1"}), IsNil)
+
+	chg, t := s.setupStopServicesForRefresh(c)
+
+	// the request of another change does not block this one
+	s.settle(c)
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(t.Status(), Equals, state.DoneStatus)
+	for _, l := range t.Log() {
+		c.Check(l, Not(Matches), ".* workloads .*")
+	}
+}
+
+func (s *snapmgrTestSuite) TestWorkloadQuiesceClearedOnAbort(c *C) {
+	restore := snapstate.MockWorkloadQuiesceTimeout(time.Hour)
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg, t := s.setupStopServicesForRefresh(c)
+
+	c.Assert(snapstate.RequestWorkloadQuiesce(s.state, "services-snap", chg.ID(), []string{"vm1"}), IsNil)
+
+	s.runEnsure()
+	c.Check(t.Status(), Equals, state.DoingStatus)
+
+	chg.Abort()
+	s.settle(c)
+
+	c.Check(t.Status(), Equals, state.UndoneStatus)
+	c.Check(s.fakeBackend.ops.Count("stop-snap-services:refresh"), Equals, 0)
+	// the request is gone
+	c.Check(s.state.Get("workload-quiesce", new(any)), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (s *snapmgrTestSuite) TestWorkloadQuiesceClearedWhenChangeReady(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg := s.state.NewChange("refresh-snap", "...")
+	t := s.state.NewTask("foo", "...")
+	chg.AddTask(t)
+	c.Assert(snapstate.RequestWorkloadQuiesce(s.state, "services-snap", chg.ID(), []string{"vm1"}), IsNil)
+	c.Assert(snapstate.RequestWorkloadQuiesce(s.state, "other-snap", "other", []string{"vm1"}), IsNil)
+
+	t.SetStatus(state.ErrorStatus)
+
+	var quiesces map[string]any
+	c.Assert(s.state.Get("workload-quiesce", &quiesces), IsNil)
+	c.Check(quiesces, HasLen, 1)
+	c.Check(quiesces["other-snap"], NotNil)
+}
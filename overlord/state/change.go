@@ -75,6 +75,11 @@ const (
 	// kernel snap update).
 	WaitStatus Status = 10
 
+	// ScheduledStatus means the change was requested to start at a later
+	// time and none of its tasks will run before then. It is only ever
+	// set on changes, see Change.ScheduleAt.
+	ScheduledStatus Status = 11
+
 	nStatuses = iota
 )
 
@@ -112,6 +117,8 @@ func (s Status) String() string {
 		return "Hold"
 	case ErrorStatus:
 		return "Error"
+	case ScheduledStatus:
+		return "Scheduled"
 	}
 	panic(fmt.Sprintf("internal error: unknown task status code: %d", s))
 }
@@ -153,8 +160,29 @@ type Change struct {
 
 	spawnTime time.Time
 	readyTime time.Time
+	deadline  time.Time
+	priority  ChangePriority
+
+	scheduledTime time.Time
 }
 
+// ChangePriority expresses how urgently the tasks of a change should run
+// compared to the tasks of other changes.
+type ChangePriority int
+
+const (
+	// PriorityBackground is for housekeeping changes which can wait for
+	// everything else.
+	PriorityBackground ChangePriority = -2
+	// PriorityLow is for changes started automatically by the system.
+	PriorityLow ChangePriority = -1
+	// PriorityNormal is the default priority, used for changes requested
+	// explicitly.
+	PriorityNormal ChangePriority = 0
+	// PriorityHigh is for changes which should go ahead of all others.
+	PriorityHigh ChangePriority = 1
+)
+
 type byReadyTime []*Change
 
 func (a byReadyTime) Len() int           { return len(a) }
@@ -186,6 +214,11 @@ type marshalledChange struct {
 
 	SpawnTime time.Time  `json:"spawn-time"`
 	ReadyTime *time.Time `json:"ready-time,omitempty"`
+	Deadline  *time.Time `json:"deadline,omitempty"`
+
+	Priority ChangePriority `json:"priority,omitempty"`
+
+	ScheduledTime *time.Time `json:"scheduled-time,omitempty"`
 
 	LastRecordedNoticeStatus Status `json:"last-recorded-notice-status,omitempty"`
 }
@@ -197,6 +230,14 @@ func (c *Change) MarshalJSON() ([]byte, error) {
 	if !c.readyTime.IsZero() {
 		readyTime = &c.readyTime
 	}
+	var deadline *time.Time
+	if !c.deadline.IsZero() {
+		deadline = &c.deadline
+	}
+	var scheduledTime *time.Time
+	if !c.scheduledTime.IsZero() {
+		scheduledTime = &c.scheduledTime
+	}
 	return json.Marshal(marshalledChange{
 		ID:      c.id,
 		Kind:    c.kind,
@@ -208,6 +249,11 @@ func (c *Change) MarshalJSON() ([]byte, error) {
 
 		SpawnTime: c.spawnTime,
 		ReadyTime: readyTime,
+		Deadline:  deadline,
+
+		Priority: c.priority,
+
+		ScheduledTime: scheduledTime,
 
 		LastRecordedNoticeStatus: c.lastRecordedNoticeStatus,
 	})
@@ -239,6 +285,13 @@ func (c *Change) UnmarshalJSON(data []byte) error {
 	if unmarshalled.ReadyTime != nil {
 		c.readyTime = *unmarshalled.ReadyTime
 	}
+	if unmarshalled.Deadline != nil {
+		c.deadline = *unmarshalled.Deadline
+	}
+	c.priority = unmarshalled.Priority
+	if unmarshalled.ScheduledTime != nil {
+		c.scheduledTime = *unmarshalled.ScheduledTime
+	}
 	c.lastRecordedNoticeStatus = unmarshalled.LastRecordedNoticeStatus
 	return nil
 }
@@ -291,6 +344,7 @@ var statusOrder = []Status{
 	UndoStatus,
 	DoingStatus,
 	DoStatus,
+	ScheduledStatus,
 	WaitStatus,
 	ErrorStatus,
 	UndoneStatus,
@@ -566,6 +620,89 @@ func (c *Change) ReadyTime() time.Time {
 	return c.readyTime
 }
 
+// LastActivityTime returns the most recent time any of the change tasks
+// changed status, reported progress or logged a message, or the time the
+// change was created if none of that happened yet. It allows to tell apart
+// changes that are slow but alive from hung ones.
+func (c *Change) LastActivityTime() time.Time {
+	c.state.reading()
+	last := c.spawnTime
+	for _, tid := range c.taskIDs {
+		if t := c.state.tasks[tid].lastActivityTime; t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// SetDeadline sets the time after which the change is aborted and undone
+// if it is not ready yet. A zero time means no deadline.
+func (c *Change) SetDeadline(deadline time.Time) {
+	c.state.writing()
+	c.deadline = deadline
+	c.state.trackTimedChange(c)
+}
+
+// Deadline returns the time after which the change is aborted if it is
+// not ready yet, or a zero time if the change has no deadline.
+func (c *Change) Deadline() time.Time {
+	c.state.reading()
+	return c.deadline
+}
+
+// SetPriority sets the priority of the change, tasks of changes with a
+// higher priority are run ahead of those of changes with a lower one.
+func (c *Change) SetPriority(priority ChangePriority) {
+	c.state.writing()
+	c.priority = priority
+}
+
+// Priority returns the priority of the change.
+func (c *Change) Priority() ChangePriority {
+	c.state.reading()
+	return c.priority
+}
+
+// ScheduleAt defers the start of the change until the given time, the
+// change is in ScheduledStatus until then.
+func (c *Change) ScheduleAt(when time.Time) {
+	c.state.writing()
+	c.scheduledTime = when
+	c.status = ScheduledStatus
+	c.state.trackTimedChange(c)
+	c.notifyStatusChange(c.Status())
+}
+
+// ScheduledTime returns the time at which the change was scheduled to
+// start, or a zero time if it was not scheduled.
+func (c *Change) ScheduledTime() time.Time {
+	c.state.reading()
+	return c.scheduledTime
+}
+
+// IsScheduled returns whether the change is waiting for its scheduled time
+// to start.
+func (c *Change) IsScheduled() bool {
+	c.state.reading()
+	return c.status == ScheduledStatus
+}
+
+// startScheduled lets a scheduled change start, its status is derived from
+// its tasks from now on.
+func (c *Change) startScheduled() {
+	if c.status != ScheduledStatus {
+		return
+	}
+	c.status = DefaultStatus
+	c.notifyStatusChange(c.Status())
+}
+
+// deadlineExceeded returns whether the change has a deadline that passed
+// before now and is not ready yet.
+func (c *Change) deadlineExceeded(now time.Time) bool {
+	return !c.deadline.IsZero() && now.After(c.deadline) && !c.IsReady()
+}
+
 // changeError holds a set of task errors.
 type changeError struct {
 	errors []taskError
@@ -677,6 +814,8 @@ func (c *Change) LaneTasks(lanes ...int) []*Task {
 // Cancellation will proceed at the next ensure pass.
 func (c *Change) Abort() {
 	c.state.writing()
+	// an aborted scheduled change never starts
+	c.startScheduled()
 	tasks := make([]*Task, len(c.taskIDs))
 	for i, tid := range c.taskIDs {
 		tasks[i] = c.state.tasks[tid]
@@ -696,6 +835,7 @@ func (c *Change) AbortLanes(lanes []int) {
 // a ready lane is one in which all tasks are ready.
 func (c *Change) AbortUnreadyLanes() {
 	c.state.writing()
+	c.startScheduled()
 	c.abortUnreadyLanes()
 }
 
@@ -20,6 +20,7 @@
 package state_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -78,8 +79,121 @@ func (cs *changeSuite) TestReadyTime(c *C) {
 	c.Check(t.Before(now.Add(5*time.Second)), Equals, true)
 }
 
+func (cs *changeSuite) TestLastActivityTime(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	now := time.Now()
+	restore := state.MockTime(now)
+	defer restore()
+
+	chg := st.NewChange("install", "summary...")
+	// no tasks yet
+	c.Check(chg.LastActivityTime().Equal(now), Equals, true)
+
+	t1 := st.NewTask("download", "1...")
+	t2 := st.NewTask("install", "2...")
+	chg.AddTask(t1)
+	chg.AddTask(t2)
+	c.Check(chg.LastActivityTime().Equal(now), Equals, true)
+	c.Check(t1.LastActivityTime().IsZero(), Equals, true)
+
+	state.MockTime(now.Add(time.Minute))
+	t1.SetStatus(state.DoingStatus)
+	c.Check(t1.LastActivityTime().Equal(now.Add(time.Minute)), Equals, true)
+	c.Check(chg.LastActivityTime().Equal(now.Add(time.Minute)), Equals, true)
+
+	state.MockTime(now.Add(2 * time.Minute))
+	t1.SetProgress("downloading", 1, 10)
+	c.Check(chg.LastActivityTime().Equal(now.Add(2*time.Minute)), Equals, true)
+
+	state.MockTime(now.Add(3 * time.Minute))
+	t1.Logf("still going")
+	c.Check(chg.LastActivityTime().Equal(now.Add(3*time.Minute)), Equals, true)
+	c.Check(t2.LastActivityTime().IsZero(), Equals, true)
+}
+
+func (cs *changeSuite) TestDeadline(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("install", "summary...")
+	c.Check(chg.Deadline().IsZero(), Equals, true)
+
+	deadline := time.Now().Add(time.Hour).UTC().Round(time.Second)
+	chg.SetDeadline(deadline)
+	c.Check(chg.Deadline().Equal(deadline), Equals, true)
+
+	// survives a round trip through serialization
+	data, err := json.Marshal(st)
+	c.Assert(err, IsNil)
+	st2, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st2.Lock()
+	defer st2.Unlock()
+	c.Check(st2.Change(chg.ID()).Deadline().Equal(deadline), Equals, true)
+}
+
+func (cs *changeSuite) TestPriority(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("auto-refresh", "summary...")
+	c.Check(chg.Priority(), Equals, state.PriorityNormal)
+
+	chg.SetPriority(state.PriorityLow)
+	c.Check(chg.Priority(), Equals, state.PriorityLow)
+
+	// survives a round trip through serialization
+	data, err := json.Marshal(st)
+	c.Assert(err, IsNil)
+	st2, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st2.Lock()
+	defer st2.Unlock()
+	c.Check(st2.Change(chg.ID()).Priority(), Equals, state.PriorityLow)
+}
+
+func (cs *changeSuite) TestScheduleAt(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("refresh-snap", "summary...")
+	chg.AddTask(st.NewTask("download", "1"))
+	c.Check(chg.ScheduledTime().IsZero(), Equals, true)
+	c.Check(chg.IsScheduled(), Equals, false)
+
+	when := time.Now().Add(time.Hour).UTC().Round(time.Second)
+	chg.ScheduleAt(when)
+	c.Check(chg.ScheduledTime().Equal(when), Equals, true)
+	c.Check(chg.IsScheduled(), Equals, true)
+	c.Check(chg.Status(), Equals, state.ScheduledStatus)
+	c.Check(chg.IsReady(), Equals, false)
+
+	// survives a round trip through serialization
+	data, err := json.Marshal(st)
+	c.Assert(err, IsNil)
+	st2, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st2.Lock()
+	chg2 := st2.Change(chg.ID())
+	c.Check(chg2.ScheduledTime().Equal(when), Equals, true)
+	c.Check(chg2.Status(), Equals, state.ScheduledStatus)
+	st2.Unlock()
+
+	// aborting the change means it never starts
+	chg.Abort()
+	c.Check(chg.IsScheduled(), Equals, false)
+	c.Check(chg.Status(), Equals, state.HoldStatus)
+	c.Check(chg.IsReady(), Equals, true)
+}
+
 func (cs *changeSuite) TestStatusString(c *C) {
-	for s := state.Status(0); s < state.WaitStatus+1; s++ {
+	for s := state.Status(0); s < state.ScheduledStatus+1; s++ {
 		c.Assert(s.String(), Matches, ".+")
 	}
 }
@@ -76,3 +76,21 @@ func (s *State) NumNotices() int {
 func (s *State) GetLastNoticeTimestamp() time.Time {
 	return s.getLastNoticeTimestamp()
 }
+
+func MockRandFloat64(f func() float64) (restore func()) {
+	old := randFloat64
+	randFloat64 = f
+	return func() {
+		randFloat64 = old
+	}
+}
+
+func MockMaxPriorityWait(d time.Duration) (restore func()) {
+	old := maxPriorityWait
+	maxPriorityWait = d
+	return func() {
+		maxPriorityWait = old
+	}
+}
+
+var TasksByPriority = tasksByPriority
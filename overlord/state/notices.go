@@ -21,6 +21,8 @@ import (
 	"sort"
 	"strconv"
 	"time"
+
+	"github.com/snapcore/snapd/snap/naming"
 )
 
 const (
@@ -242,6 +244,9 @@ const (
 	WarningNotice NoticeType = "warning"
 
 	// Recorded whenever an auto-refresh is inhibited for one or more snaps.
+	// The key is "-" when the set of inhibited snaps changes, or the snap
+	// instance name with the data carrying the remaining grace period of
+	// the inhibited snap.
 	RefreshInhibitNotice NoticeType = "refresh-inhibit"
 
 	// Recorded by "snap run" command when it is inhibited from running a
@@ -256,11 +261,16 @@ const (
 	// expired. The key for interfaces-requests-rule-update notices is the
 	// rule ID.
 	InterfacesRequestsRuleUpdateNotice NoticeType = "interfaces-requests-rule-update"
+
+	// Recorded whenever a snap revision was downloaded ahead of a refresh,
+	// either by a download action or by prefetching. The key is the snap
+	// instance name.
+	SnapDownloadedNotice NoticeType = "snap-downloaded"
 )
 
 func (t NoticeType) Valid() bool {
 	switch t {
-	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice:
+	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice, SnapDownloadedNotice:
 		return true
 	}
 	return false
@@ -376,8 +386,8 @@ func ValidateNotice(noticeType NoticeType, key string, options *AddNoticeOptions
 	if len(key) > maxNoticeKeyLength {
 		return fmt.Errorf("cannot add %s notice with invalid key: key must be %d bytes or less", noticeType, maxNoticeKeyLength)
 	}
-	if noticeType == RefreshInhibitNotice && key != "-" {
-		return fmt.Errorf(`cannot add %s notice with invalid key %q: only "-" key is supported`, noticeType, key)
+	if noticeType == RefreshInhibitNotice && key != "-" && naming.ValidateInstance(key) != nil {
+		return fmt.Errorf(`cannot add %s notice with invalid key %q: key must be "-" or a snap instance name`, noticeType, key)
 	}
 	return nil
 }
@@ -921,8 +921,13 @@ func (s *noticesSuite) TestValidateNotice(c *C) {
 
 	// Unxpected key for refresh-inhibit notice
 	id, err = st.AddNotice(nil, state.RefreshInhibitNotice, "123", nil)
-	c.Check(err, ErrorMatches, `internal error: cannot add refresh-inhibit notice with invalid key "123": only "-" key is supported`)
+	c.Check(err, ErrorMatches, `internal error: cannot add refresh-inhibit notice with invalid key "123": key must be "-" or a snap instance name`)
 	c.Check(id, Equals, "")
+
+	// refresh-inhibit notices of a single snap
+	id, err = st.AddNotice(nil, state.RefreshInhibitNotice, "some-snap_instance", nil)
+	c.Check(err, IsNil)
+	c.Check(id, Not(Equals), "")
 }
 
 func (s *noticesSuite) TestNextNoticeTimestamp(c *C) {
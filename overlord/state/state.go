@@ -122,6 +122,10 @@ type State struct {
 
 	pendingChangeByAttr map[string]func(*Change) bool
 
+	// timedChanges are the changes with a deadline or a scheduled start,
+	// the task runner needs to come back for them in time
+	timedChanges map[string]*Change
+
 	// task/changes observing
 	taskHandlers   map[int]func(t *Task, old, new Status) (remove bool)
 	changeHandlers map[int]func(chg *Change, old, new Status)
@@ -142,6 +146,7 @@ func New(backend Backend) *State {
 		modified:            true,
 		cache:               make(map[any]any),
 		pendingChangeByAttr: make(map[string]func(*Change) bool),
+		timedChanges:        make(map[string]*Change),
 		taskHandlers:        make(map[int]func(t *Task, old Status, new Status) bool),
 		changeHandlers:      make(map[int]func(chg *Change, old Status, new Status)),
 	}
@@ -251,9 +256,13 @@ func (s *State) UnmarshalJSON(data []byte) error {
 	for _, t := range s.tasks {
 		t.state = s
 	}
+	s.timedChanges = make(map[string]*Change)
 	for _, chg := range s.changes {
 		chg.state = s
 		chg.finishUnmarshal()
+		if !chg.deadline.IsZero() || chg.status == ScheduledStatus {
+			s.timedChanges[chg.id] = chg
+		}
 	}
 	return nil
 }
@@ -518,6 +527,11 @@ NextChange:
 		if spawnTime.Before(startOfOperation) {
 			spawnTime = startOfOperation
 		}
+		// scheduled changes are only considered started at their
+		// scheduled time
+		if scheduledTime := chg.ScheduledTime(); spawnTime.Before(scheduledTime) {
+			spawnTime = scheduledTime
+		}
 		if readyTime.IsZero() {
 			if spawnTime.Before(pruneLimit) && len(chg.Tasks()) == 0 {
 				chg.Abort()
@@ -552,6 +566,37 @@ NextChange:
 	}
 }
 
+// trackTimedChange makes sure the task runner comes back in time for the
+// change if it has a deadline or a scheduled start.
+func (s *State) trackTimedChange(chg *Change) {
+	if chg.deadline.IsZero() && chg.status != ScheduledStatus {
+		delete(s.timedChanges, chg.id)
+		return
+	}
+	s.timedChanges[chg.id] = chg
+}
+
+// PruneReadyChanges removes the changes that are ready, along with their
+// tasks, regardless of how long ago they became ready. It returns the IDs of
+// the removed changes.
+func (s *State) PruneReadyChanges() []string {
+	s.reading()
+	var pruned []string
+	for id, chg := range s.changes {
+		if chg.ReadyTime().IsZero() {
+			continue
+		}
+		s.writing()
+		for _, t := range chg.Tasks() {
+			delete(s.tasks, t.ID())
+		}
+		delete(s.changes, id)
+		pruned = append(pruned, id)
+	}
+	sort.Strings(pruned)
+	return pruned
+}
+
 func (s *State) pruneWarnings(now time.Time) {
 	s.warningsMu.Lock()
 	defer s.warningsMu.Unlock()
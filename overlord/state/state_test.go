@@ -579,6 +579,7 @@ func (ss *stateSuite) TestEmptyStateDataAndCheckpointReadAndSet(c *C) {
 		"notices",
 		"cache",
 		"pendingChangeByAttr",
+		"timedChanges",
 		"taskHandlers",
 		"changeHandlers",
 	})
@@ -932,6 +933,52 @@ func (ss *stateSuite) TestPruneEmptyChange(c *C) {
 	c.Assert(st.Change(chg.ID()), IsNil)
 }
 
+func (ss *stateSuite) TestPruneReadyChanges(c *C) {
+	st := state.New(&fakeStateBackend{})
+	st.Lock()
+	defer st.Unlock()
+
+	done := st.NewChange("done", "...")
+	t1 := st.NewTask("foo", "...")
+	done.AddTask(t1)
+	t1.SetStatus(state.DoneStatus)
+
+	pending := st.NewChange("pending", "...")
+	t2 := st.NewTask("foo", "...")
+	pending.AddTask(t2)
+
+	c.Check(st.PruneReadyChanges(), DeepEquals, []string{done.ID()})
+	c.Check(st.Change(done.ID()), IsNil)
+	c.Check(st.Task(t1.ID()), IsNil)
+	c.Check(st.Change(pending.ID()), NotNil)
+	c.Check(st.Task(t2.ID()), NotNil)
+
+	c.Check(st.PruneReadyChanges(), HasLen, 0)
+}
+
+func (ss *stateSuite) TestPruneScheduledChange(c *C) {
+	st := state.New(&fakeStateBackend{})
+	st.Lock()
+	defer st.Unlock()
+
+	now := time.Now()
+	pruneWait := 1 * time.Hour
+	abortWait := 3 * time.Hour
+
+	chg := st.NewChange("refresh-snap", "...")
+	t := st.NewTask("foo", "...")
+	chg.AddTask(t)
+	state.MockChangeTimes(chg, now.Add(-2*abortWait), time.Time{})
+	// the change was queued long ago but only starts later
+	chg.ScheduleAt(now.Add(time.Hour))
+
+	past := time.Now().AddDate(-1, 0, 0)
+	st.Prune(past, pruneWait, abortWait, 100)
+	c.Assert(st.Change(chg.ID()), NotNil)
+	c.Check(t.Status(), Equals, state.DoStatus)
+	c.Check(chg.Status(), Equals, state.ScheduledStatus)
+}
+
 func (ss *stateSuite) TestPruneMaxChangesHappy(c *C) {
 	st := state.New(&fakeStateBackend{})
 	st.Lock()
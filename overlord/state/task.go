@@ -59,12 +59,19 @@ type Task struct {
 	readyTime time.Time
 
 	// TODO: add:
-	// {,Un}DoingRetries - number of retries
 	// Retry{,Un}DoingTimes - time spend to figure out a retry is needed
 	doingTime   time.Duration
 	undoingTime time.Duration
 
 	atTime time.Time
+
+	// retries is the number of times the task was retried after a
+	// transient error, see TaskRunner.SetRetryPolicy.
+	retries int
+
+	// lastActivityTime is the last time the task changed status,
+	// reported progress, logged a message or its handler returned.
+	lastActivityTime time.Time
 }
 
 func newTask(state *State, id, kind, summary string) *Task {
@@ -101,6 +108,10 @@ type marshalledTask struct {
 	UndoingTime time.Duration `json:"undoing-time,omitempty"`
 
 	AtTime *time.Time `json:"at-time,omitempty"`
+
+	Retries int `json:"retries,omitempty"`
+
+	LastActivityTime *time.Time `json:"last-activity-time,omitempty"`
 }
 
 // MarshalJSON makes Task a json.Marshaller
@@ -114,6 +125,10 @@ func (t *Task) MarshalJSON() ([]byte, error) {
 	if !t.atTime.IsZero() {
 		atTime = &t.atTime
 	}
+	var lastActivityTime *time.Time
+	if !t.lastActivityTime.IsZero() {
+		lastActivityTime = &t.lastActivityTime
+	}
 	return json.Marshal(marshalledTask{
 		ID:           t.id,
 		Kind:         t.kind,
@@ -136,6 +151,10 @@ func (t *Task) MarshalJSON() ([]byte, error) {
 		UndoingTime: t.undoingTime,
 
 		AtTime: atTime,
+
+		Retries: t.retries,
+
+		LastActivityTime: lastActivityTime,
 	})
 }
 
@@ -179,6 +198,10 @@ func (t *Task) UnmarshalJSON(data []byte) error {
 	if unmarshalled.AtTime != nil {
 		t.atTime = *unmarshalled.AtTime
 	}
+	if unmarshalled.LastActivityTime != nil {
+		t.lastActivityTime = *unmarshalled.LastActivityTime
+	}
+	t.retries = unmarshalled.Retries
 	t.doingTime = unmarshalled.DoingTime
 	t.undoingTime = unmarshalled.UndoingTime
 	return nil
@@ -248,8 +271,9 @@ func (t *Task) changeStatus(old, new Status) {
 	}
 	logger.Trace("task-status-change", "task-name", t.kind, "id", t.id, "status", new.String())
 	t.status = new
+	t.lastActivityTime = timeNow()
 	if !old.Ready() && new.Ready() {
-		t.readyTime = timeNow()
+		t.readyTime = t.lastActivityTime
 	}
 	chg := t.Change()
 	if chg != nil {
@@ -366,6 +390,7 @@ func (t *Task) SetProgress(label string, done, total int) {
 	} else {
 		t.progress = &progress{Label: label, Done: done, Total: total}
 	}
+	t.lastActivityTime = timeNow()
 }
 
 // SpawnTime returns the time when the change was created.
@@ -380,12 +405,31 @@ func (t *Task) ReadyTime() time.Time {
 	return t.readyTime
 }
 
+// LastActivityTime returns the last time the task changed status, reported
+// progress, logged a message or returned from its handler. A zero time means
+// there was no activity since the task was created.
+func (t *Task) LastActivityTime() time.Time {
+	t.state.reading()
+	return t.lastActivityTime
+}
+
 // AtTime returns the time at which the task is scheduled to run. A zero time means no special schedule, i.e. run as soon as prerequisites are met.
 func (t *Task) AtTime() time.Time {
 	t.state.reading()
 	return t.atTime
 }
 
+// Retries returns the number of times the task was retried after its
+// handler failed with a transient error.
+func (t *Task) Retries() int {
+	t.state.reading()
+	return t.retries
+}
+
+func (t *Task) markActive() {
+	t.lastActivityTime = timeNow()
+}
+
 func (t *Task) accumulateDoingTime(duration time.Duration) {
 	t.state.writing()
 	t.doingTime += duration
@@ -427,7 +471,8 @@ func (t *Task) addLog(kind, format string, args []any) {
 		t.log = t.log[:9]
 	}
 
-	tstr := timeNow().Format(time.RFC3339)
+	t.lastActivityTime = timeNow()
+	tstr := t.lastActivityTime.Format(time.RFC3339)
 	msg := tstr + " " + kind + " " + fmt.Sprintf(format, args...)
 	t.log = append(t.log, msg)
 	logger.Debug(msg)
@@ -20,6 +20,8 @@
 package state
 
 import (
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -65,6 +67,68 @@ func (r *Wait) Error() string {
 
 type blockedFunc func(t *Task, running []*Task) bool
 
+// RetryPolicy describes how the runner retries a task whose handler
+// failed with a transient error, see TaskRunner.SetRetryPolicy.
+type RetryPolicy struct {
+	// MaxRetries is the number of times the task is retried before it
+	// is failed with the error.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, it is doubled
+	// for each following retry up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter is the fraction, between 0 and 1, of the backoff by which
+	// the delay is randomly shortened or extended, so that tasks failing
+	// together are not retried together.
+	Jitter float64
+}
+
+var randFloat64 = rand.Float64
+
+// maxPriorityWait is how long a task is left for later because tasks of
+// changes with a higher priority are running, after that it runs
+// alongside them.
+var maxPriorityWait = 10 * time.Minute
+
+// backoff returns the delay before the given retry, counted from 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d += time.Duration(float64(d) * p.Jitter * (2*randFloat64() - 1))
+	}
+	return d
+}
+
+type retryPolicy struct {
+	transient func(err error) bool
+	policy    func(t *Task) RetryPolicy
+}
+
+// AbandonedPolicy defines what the runner does with a task it finds in
+// DoingStatus or UndoingStatus without having run it, as left behind
+// when snapd stopped abruptly while the task handler was running.
+type AbandonedPolicy int
+
+const (
+	// AbandonedRetry runs the handler of the task again, it is the
+	// default.
+	AbandonedRetry AbandonedPolicy = iota
+	// AbandonedUndo fails a task found in DoingStatus, undoing its
+	// lanes. A task found in UndoingStatus is undone again.
+	AbandonedUndo
+	// AbandonedHold leaves a task found in DoingStatus where it is
+	// without running it and records a warning, so that its change can
+	// be inspected and aborted. A task found in UndoingStatus cannot be
+	// aborted and is undone again.
+	AbandonedHold
+)
+
 // TaskRunner controls the running of goroutines to execute known task kinds.
 type TaskRunner struct {
 	state *State
@@ -79,6 +143,19 @@ type TaskRunner struct {
 	blocked     []blockedFunc
 	someBlocked bool
 
+	abandonedPolicies map[string]AbandonedPolicy
+	retryPolicies     map[string]retryPolicy
+	// started holds the tasks run by this runner that are not yet
+	// done or undone, tasks in DoingStatus or UndoingStatus not in it
+	// were abandoned
+	started map[string]bool
+	// held holds the abandoned tasks kept in DoingStatus as per
+	// AbandonedHold
+	held map[string]bool
+	// deferred holds when tasks were first left for later because tasks
+	// of changes with a higher priority were running
+	deferred map[string]time.Time
+
 	// optional callback executed on task errors
 	taskErrorCallback func(err error)
 
@@ -102,6 +179,12 @@ func NewTaskRunner(s *State) *TaskRunner {
 		handlers: make(map[string]handlerPair),
 		cleanups: make(map[string]HandlerFunc),
 		tombs:    make(map[string]*tomb.Tomb),
+
+		abandonedPolicies: make(map[string]AbandonedPolicy),
+		retryPolicies:     make(map[string]retryPolicy),
+		started:           make(map[string]bool),
+		held:              make(map[string]bool),
+		deferred:          make(map[string]time.Time),
 	}
 }
 
@@ -167,6 +250,82 @@ func (r *TaskRunner) AddCleanup(kind string, cleanup HandlerFunc) {
 	r.cleanups[kind] = cleanup
 }
 
+// SetAbandonedPolicy sets what to do with abandoned tasks of the given
+// kind, see AbandonedPolicy. Handlers of kinds without a policy must be
+// safe to run again after being interrupted at any point.
+func (r *TaskRunner) SetAbandonedPolicy(kind string, policy AbandonedPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.abandonedPolicies[kind] = policy
+}
+
+// SetRetryPolicy sets how tasks of the given kind are retried when their
+// handler fails with an error for which transient returns true. The
+// policy function is called with the state locked each time such an error
+// happens, which allows the policy to be configurable.
+func (r *TaskRunner) SetRetryPolicy(kind string, transient func(err error) bool, policy func(t *Task) RetryPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retryPolicies[kind] = retryPolicy{transient: transient, policy: policy}
+}
+
+// retryTransient schedules the task to be run again if err is transient
+// and the retry policy of its kind allows it, it returns whether it did.
+func (r *TaskRunner) retryTransient(t *Task, err error) bool {
+	rp, ok := r.retryPolicies[t.Kind()]
+	if !ok || t.Status() == AbortStatus || !rp.transient(err) {
+		return false
+	}
+	policy := rp.policy(t)
+	if t.retries >= policy.MaxRetries {
+		if t.retries > 0 {
+			t.Logf("giving up after %d retries", t.retries)
+		}
+		return false
+	}
+	t.retries++
+	after := policy.backoff(t.retries)
+	t.Logf("transient error, retry %d of %d in %s: %v", t.retries, policy.MaxRetries, after.Round(time.Second), err)
+	t.At(timeNow().Add(after))
+	return true
+}
+
+// AbandonedPolicyFor returns what is done with abandoned tasks of the
+// given kind.
+func (r *TaskRunner) AbandonedPolicyFor(kind string) AbandonedPolicy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.abandonedPolicies[kind]
+}
+
+// handleAbandoned applies the abandoned policy of its kind to the task
+// and returns whether the task can be run now.
+func (r *TaskRunner) handleAbandoned(t *Task) (run bool) {
+	policy := r.abandonedPolicies[t.Kind()]
+	chg := t.Change()
+
+	switch {
+	case policy == AbandonedUndo && t.Status() == DoingStatus:
+		logger.Noticef("Change %s task (%s) was abandoned in status %s, undoing", chg.ID(), t.Summary(), t.Status())
+		t.Errorf("task abandoned while in progress, undoing")
+		r.abortLanes(chg, t.Lanes())
+		t.SetStatus(ErrorStatus)
+		r.state.EnsureBefore(0)
+		return false
+	case policy == AbandonedHold && t.Status() == DoingStatus:
+		logger.Noticef("Change %s task (%s) was abandoned in status %s, holding", chg.ID(), t.Summary(), t.Status())
+		t.Logf("task abandoned while in progress, holding it")
+		r.held[t.ID()] = true
+		r.state.Warnf("change %s was held as its task %q was interrupted: abort the change once inspected", chg.ID(), t.Summary())
+		return false
+	}
+	logger.Debugf("Change %s task (%s) was abandoned in status %s, running it again", chg.ID(), t.Summary(), t.Status())
+	return true
+}
+
 // SetBlocked sets a predicate function to decide whether to block a task from running based on the current running tasks. It can be used to control task serialisation.
 func (r *TaskRunner) SetBlocked(pred func(t *Task, running []*Task) bool) {
 	r.mu.Lock()
@@ -212,6 +371,7 @@ func (r *TaskRunner) run(t *Task) {
 	t.At(time.Time{}) // clear schedule
 	tomb := &tomb.Tomb{}
 	r.tombs[t.ID()] = tomb
+	r.started[t.ID()] = true
 	tomb.Go(func() error {
 		// Capture the error result with tomb.Kill so we can
 		// use tomb.Err uniformly to consider both it or a
@@ -226,8 +386,14 @@ func (r *TaskRunner) run(t *Task) {
 		r.state.Lock()
 		defer r.state.Unlock()
 		accuRuntime(t1.Sub(t0))
+		t.markActive()
 
 		delete(r.tombs, t.ID())
+		defer func() {
+			if status := t.Status(); status != DoingStatus && status != UndoingStatus {
+				delete(r.started, t.ID())
+			}
+		}()
 
 		// some tasks were blocked, now there's chance the
 		// blocked predicate will change its value
@@ -292,6 +458,9 @@ func (r *TaskRunner) run(t *Task) {
 				r.state.EnsureBefore(0)
 			}
 		default:
+			if r.retryTransient(t, err) {
+				break
+			}
 			r.abortLanes(t.Change(), t.Lanes())
 			t.SetStatus(ErrorStatus)
 			t.Errorf("%s", err)
@@ -399,8 +568,48 @@ func (r *TaskRunner) Ensure() error {
 
 	ensureTime := timeNow()
 	nextTaskTime := time.Time{}
+
+	// start scheduled changes whose time has come, abort changes that
+	// went past their deadline, and make sure to come back in time for
+	// the others; only the changes with such times are looked at
+	for id, chg := range r.state.timedChanges {
+		if r.state.changes[id] != chg {
+			// pruned
+			delete(r.state.timedChanges, id)
+			continue
+		}
+		if chg.status == ScheduledStatus {
+			if ensureTime.Before(chg.scheduledTime) {
+				if nextTaskTime.IsZero() || nextTaskTime.After(chg.scheduledTime) {
+					nextTaskTime = chg.scheduledTime
+				}
+				continue
+			}
+			chg.startScheduled()
+		}
+
+		deadline := chg.deadline
+		if deadline.IsZero() || chg.IsReady() {
+			delete(r.state.timedChanges, id)
+			continue
+		}
+		if chg.deadlineExceeded(ensureTime) {
+			r.abortPastDeadline(chg)
+			continue
+		}
+		if nextTaskTime.IsZero() || nextTaskTime.After(deadline) {
+			nextTaskTime = deadline
+		}
+	}
+
+	// tasks of changes with a higher priority are considered first and
+	// tasks of changes with a lower priority than any running task are
+	// left for later, up to maxPriorityWait
+	tasks := tasksByPriority(r.state.Tasks())
+	runningPriority := highestTaskPriority(running)
+
 ConsiderTasks:
-	for _, t := range r.state.Tasks() {
+	for _, t := range tasks {
 		handlers := r.handlerPair(t)
 		if handlers.do == nil {
 			// Handled by a different runner instance.
@@ -422,8 +631,14 @@ ConsiderTasks:
 			continue
 		}
 
+		if chg := t.Change(); chg != nil && chg.status == ScheduledStatus {
+			// The change is not due yet.
+			continue
+		}
+
 		status := t.Status()
 		if status.Ready() {
+			delete(r.deferred, t.ID())
 			if !t.IsClean() {
 				r.clean(t)
 			}
@@ -434,6 +649,20 @@ ConsiderTasks:
 			continue
 		}
 
+		if r.held[t.ID()] {
+			if status == DoingStatus {
+				// left alone until its change is aborted
+				continue
+			}
+			delete(r.held, t.ID())
+		}
+
+		if (status == DoingStatus || status == UndoingStatus) && !r.started[t.ID()] {
+			if !r.handleAbandoned(t) {
+				continue
+			}
+		}
+
 		if mustWait(t) {
 			// Dependencies still unhandled.
 			continue
@@ -468,9 +697,31 @@ ConsiderTasks:
 			}
 		}
 
+		priority := taskPriority(t)
+		if len(running) > 0 && priority < runningPriority {
+			since, ok := r.deferred[t.ID()]
+			if !ok {
+				since = ensureTime
+				r.deferred[t.ID()] = since
+			}
+			if until := since.Add(maxPriorityWait); ensureTime.Before(until) {
+				// come back once the tasks with higher priority are
+				// done or the task waited long enough
+				r.someBlocked = true
+				if nextTaskTime.IsZero() || nextTaskTime.After(until) {
+					nextTaskTime = until
+				}
+				continue
+			}
+		}
+		delete(r.deferred, t.ID())
+
 		logger.Debugf("Running task %s on %s: %s", t.ID(), t.Status(), t.Summary())
 		r.run(t)
 
+		if len(running) == 0 || priority > runningPriority {
+			runningPriority = priority
+		}
 		running = append(running, t)
 	}
 
@@ -482,6 +733,75 @@ ConsiderTasks:
 	return nil
 }
 
+// taskPriority returns the priority of the change of the task.
+func taskPriority(t *Task) ChangePriority {
+	if chg := t.Change(); chg != nil {
+		return chg.priority
+	}
+	return PriorityNormal
+}
+
+// tasksByPriority returns the tasks ordered by the priority of their
+// changes, highest first, tasks of the same priority keep their order.
+func tasksByPriority(tasks []*Task) []*Task {
+	var byPriority map[ChangePriority][]*Task
+	var priorities []ChangePriority
+	for i, t := range tasks {
+		p := taskPriority(t)
+		if byPriority == nil {
+			if p == PriorityNormal {
+				continue
+			}
+			// only group the tasks once some need reordering
+			byPriority = map[ChangePriority][]*Task{PriorityNormal: tasks[:i:i]}
+			priorities = append(priorities, PriorityNormal)
+		}
+		if _, ok := byPriority[p]; !ok {
+			priorities = append(priorities, p)
+		}
+		byPriority[p] = append(byPriority[p], t)
+	}
+	if byPriority == nil {
+		return tasks
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] > priorities[j] })
+	ordered := make([]*Task, 0, len(tasks))
+	for _, p := range priorities {
+		ordered = append(ordered, byPriority[p]...)
+	}
+	return ordered
+}
+
+// highestTaskPriority returns the highest priority among the given tasks.
+func highestTaskPriority(tasks []*Task) ChangePriority {
+	highest := PriorityNormal
+	for i, t := range tasks {
+		if p := taskPriority(t); i == 0 || p > highest {
+			highest = p
+		}
+	}
+	return highest
+}
+
+// abortPastDeadline aborts the change which is past its deadline, recording
+// the reason in the tasks that did not complete yet.
+func (r *TaskRunner) abortPastDeadline(chg *Change) {
+	aborted := false
+	for _, t := range chg.Tasks() {
+		switch t.Status() {
+		case DoStatus, DoingStatus, WaitStatus:
+			t.Errorf("change %s exceeded its deadline %s", chg.ID(), chg.deadline.Format(time.RFC3339))
+			aborted = true
+		}
+	}
+	if !aborted {
+		// already aborted or undoing
+		return
+	}
+	logger.Noticef("Aborting change %s (%s) as it exceeded its deadline %s", chg.ID(), chg.Kind(), chg.deadline.Format(time.RFC3339))
+	chg.Abort()
+}
+
 // mustWait returns whether task t must wait for other tasks to be done.
 func mustWait(t *Task) bool {
 	switch t.Status() {
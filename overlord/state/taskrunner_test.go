@@ -20,6 +20,8 @@
 package state_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
@@ -578,6 +580,284 @@ func (ts *taskRunnerSuite) TestExternalAbort(c *C) {
 	ensureChange(c, r, sb, chg)
 }
 
+func (ts *taskRunnerSuite) TestChangeDeadline(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	ch := make(chan bool)
+	r.AddHandler("blocking", func(t *state.Task, tb *tomb.Tomb) error {
+		ch <- true
+		<-tb.Dying()
+		// stopped in flight
+		return &state.Retry{}
+	}, nil)
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("blocking", "1")
+	t2 := st.NewTask("blocking", "2")
+	t2.WaitFor(t1)
+	chg.AddTask(t1)
+	chg.AddTask(t2)
+	st.Unlock()
+
+	tock := time.Now()
+	restore := state.MockTime(tock)
+	defer restore()
+
+	st.Lock()
+	chg.SetDeadline(tock.Add(time.Minute))
+	st.Unlock()
+
+	sb.ensureBefore = time.Hour
+	r.Ensure()
+	<-ch
+	// the next ensure is scheduled for the deadline
+	c.Check(sb.ensureBefore, Equals, time.Minute)
+
+	// not yet
+	state.MockTime(tock.Add(30 * time.Second))
+	r.Ensure()
+	st.Lock()
+	c.Check(t1.Status(), Equals, state.DoingStatus)
+	c.Check(t2.Status(), Equals, state.DoStatus)
+	st.Unlock()
+
+	state.MockTime(tock.Add(2 * time.Minute))
+	// the deadline passed so the change gets aborted and the running task
+	// is killed, or this will never end
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	// no undo handler, so the task stopped in flight is held
+	c.Check(t1.Status(), Equals, state.HoldStatus)
+	c.Check(t2.Status(), Equals, state.HoldStatus)
+	c.Assert(t1.Log(), HasLen, 1)
+	c.Check(t1.Log()[0], Matches, `.* ERROR change [0-9]+ exceeded its deadline .*`)
+}
+
+func (ts *taskRunnerSuite) TestScheduledChange(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	r.AddHandler("noop", func(t *state.Task, tb *tomb.Tomb) error {
+		return nil
+	}, nil)
+
+	tock := time.Now()
+	restore := state.MockTime(tock)
+	defer restore()
+
+	st.Lock()
+	chg := st.NewChange("refresh-snap", "...")
+	t1 := st.NewTask("noop", "1")
+	chg.AddTask(t1)
+	chg.ScheduleAt(tock.Add(time.Hour))
+	st.Unlock()
+
+	sb.ensureBefore = 2 * time.Hour
+	r.Ensure()
+	// the next ensure is scheduled for when the change is due
+	c.Check(sb.ensureBefore, Equals, time.Hour)
+
+	st.Lock()
+	c.Check(chg.Status(), Equals, state.ScheduledStatus)
+	c.Check(t1.Status(), Equals, state.DoStatus)
+	st.Unlock()
+
+	state.MockTime(tock.Add(time.Hour + time.Second))
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(chg.IsScheduled(), Equals, false)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(t1.Status(), Equals, state.DoneStatus)
+}
+
+func (ts *taskRunnerSuite) TestChangeDeadlineAfterRestart(c *C) {
+	tock := time.Now()
+	restore := state.MockTime(tock)
+	defer restore()
+
+	st := state.New(nil)
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	chg.AddTask(st.NewTask("noop", "1"))
+	chg.SetDeadline(tock.Add(time.Minute))
+	data, err := json.Marshal(st)
+	st.Unlock()
+	c.Assert(err, IsNil)
+
+	sb := &stateBackend{}
+	st2, err := state.ReadState(sb, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	r := state.NewTaskRunner(st2)
+	defer r.Stop()
+	r.AddHandler("noop", func(t *state.Task, tb *tomb.Tomb) error {
+		return &state.Retry{After: time.Hour}
+	}, nil)
+
+	sb.ensureBefore = 2 * time.Hour
+	r.Ensure()
+	r.Wait()
+	// the deadline of the loaded change is still honoured
+	c.Check(sb.ensureBefore, Equals, time.Minute)
+}
+
+func (ts *taskRunnerSuite) TestChangePriority(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	started := make(chan string, 2)
+	release := make(chan bool)
+	r.AddHandler("blocking", func(t *state.Task, tb *tomb.Tomb) error {
+		t.State().Lock()
+		summary := t.Summary()
+		t.State().Unlock()
+		started <- summary
+		<-release
+		return nil
+	}, nil)
+
+	st.Lock()
+	low := st.NewChange("auto-refresh", "...")
+	low.SetPriority(state.PriorityLow)
+	tLow := st.NewTask("blocking", "low")
+	low.AddTask(tLow)
+	normal := st.NewChange("install", "...")
+	tNormal := st.NewTask("blocking", "normal")
+	normal.AddTask(tNormal)
+	st.Unlock()
+
+	r.Ensure()
+	c.Check(<-started, Equals, "normal")
+
+	// the task of the change with a lower priority is left for later
+	r.Ensure()
+	st.Lock()
+	c.Check(tNormal.Status(), Equals, state.DoingStatus)
+	c.Check(tLow.Status(), Equals, state.DoStatus)
+	st.Unlock()
+
+	close(release)
+	ensureChange(c, r, sb, normal)
+	ensureChange(c, r, sb, low)
+	c.Check(<-started, Equals, "low")
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(tNormal.Status(), Equals, state.DoneStatus)
+	c.Check(tLow.Status(), Equals, state.DoneStatus)
+}
+
+func (ts *taskRunnerSuite) TestChangePriorityMaxWait(c *C) {
+	restore := state.MockMaxPriorityWait(time.Minute)
+	defer restore()
+	now := time.Now()
+	restore = state.MockTime(now)
+	defer restore()
+
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	started := make(chan string, 3)
+	release := make(chan bool)
+	r.AddHandler("blocking", func(t *state.Task, tb *tomb.Tomb) error {
+		t.State().Lock()
+		summary := t.Summary()
+		t.State().Unlock()
+		started <- summary
+		<-release
+		return nil
+	}, nil)
+
+	st.Lock()
+	var tasks []*state.Task
+	for _, priority := range []state.ChangePriority{state.PriorityBackground, state.PriorityLow, state.PriorityNormal} {
+		chg := st.NewChange("change", "...")
+		chg.SetPriority(priority)
+		t := st.NewTask("blocking", fmt.Sprintf("priority %d", priority))
+		chg.AddTask(t)
+		tasks = append(tasks, t)
+	}
+	tBackground, tLow, tNormal := tasks[0], tasks[1], tasks[2]
+	st.Unlock()
+
+	r.Ensure()
+	c.Check(<-started, Equals, "priority 0")
+
+	// the tasks of the changes with a lower priority are left for later,
+	// but not beyond the maximum wait
+	sb.ensureBefore = time.Hour
+	r.Ensure()
+	c.Check(sb.ensureBefore, Equals, time.Minute)
+	st.Lock()
+	c.Check(tNormal.Status(), Equals, state.DoingStatus)
+	c.Check(tLow.Status(), Equals, state.DoStatus)
+	c.Check(tBackground.Status(), Equals, state.DoStatus)
+	st.Unlock()
+
+	state.MockTime(now.Add(30 * time.Second))
+	r.Ensure()
+	st.Lock()
+	c.Check(tLow.Status(), Equals, state.DoStatus)
+	c.Check(tBackground.Status(), Equals, state.DoStatus)
+	st.Unlock()
+
+	// once waited long enough they run alongside the running task
+	state.MockTime(now.Add(time.Minute))
+	r.Ensure()
+	c.Check([]string{<-started, <-started}, testutil.DeepUnsortedMatches, []string{"priority -1", "priority -2"})
+
+	close(release)
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	for _, t := range tasks {
+		c.Check(t.Status(), Equals, state.DoneStatus)
+	}
+}
+
+func (ts *taskRunnerSuite) TestTasksByPriority(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	newTask := func(priority state.ChangePriority) *state.Task {
+		chg := st.NewChange("change", "...")
+		chg.SetPriority(priority)
+		t := st.NewTask("task", "...")
+		chg.AddTask(t)
+		return t
+	}
+	n1 := newTask(state.PriorityNormal)
+	n2 := newTask(state.PriorityNormal)
+	// tasks of changes with the default priority are kept as they are
+	tasks := []*state.Task{n1, n2}
+	c.Check(state.TasksByPriority(tasks), DeepEquals, tasks)
+
+	l1 := newTask(state.PriorityLow)
+	h1 := newTask(state.PriorityHigh)
+	b1 := newTask(state.PriorityBackground)
+	n3 := newTask(state.PriorityNormal)
+	l2 := newTask(state.PriorityLow)
+	lone := st.NewTask("task", "...")
+	c.Check(state.TasksByPriority([]*state.Task{n1, l1, h1, n2, b1, n3, l2, lone}), DeepEquals, []*state.Task{
+		h1, n1, n2, n3, lone, l1, l2, b1,
+	})
+}
+
 func (ts *taskRunnerSuite) TestUndoSingleLane(c *C) {
 	sb := &stateBackend{}
 	st := state.New(sb)
@@ -1328,3 +1608,332 @@ func (ts *taskRunnerSuite) TestErrorCallbackNotCalled(c *C) {
 	c.Check(t1.Status(), Equals, state.DoneStatus)
 	c.Check(called, Equals, false)
 }
+
+func (ts *taskRunnerSuite) setupAbandoned(c *C, policy *state.AbandonedPolicy, status state.Status) (r *state.TaskRunner, sb *stateBackend, chg *state.Change, calls *[]string) {
+	sb = &stateBackend{}
+	st := state.New(sb)
+	r = state.NewTaskRunner(st)
+
+	calls = &[]string{}
+	handler := func(name string) state.HandlerFunc {
+		return func(t *state.Task, tomb *tomb.Tomb) error {
+			st.Lock()
+			defer st.Unlock()
+			*calls = append(*calls, name+":"+t.Summary())
+			return nil
+		}
+	}
+	r.AddHandler("foo", handler("do"), handler("undo"))
+	if policy != nil {
+		r.SetAbandonedPolicy("foo", *policy)
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	chg = st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	t2 := st.NewTask("foo", "t2")
+	t2.WaitFor(t1)
+	chg.AddTask(t1)
+	chg.AddTask(t2)
+	// as left behind by a previous snapd
+	t1.SetStatus(status)
+	if status == state.UndoingStatus {
+		t2.SetStatus(state.HoldStatus)
+	}
+	return r, sb, chg, calls
+}
+
+func (ts *taskRunnerSuite) TestAbandonedRetriedByDefault(c *C) {
+	r, sb, chg, calls := ts.setupAbandoned(c, nil, state.DoingStatus)
+	defer r.Stop()
+
+	ensureChange(c, r, sb, chg)
+
+	st := chg.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(*calls, DeepEquals, []string{"do:t1", "do:t2"})
+	c.Check(st.AllWarnings(), HasLen, 0)
+}
+
+func (ts *taskRunnerSuite) TestAbandonedUndo(c *C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	policy := state.AbandonedUndo
+	r, sb, chg, calls := ts.setupAbandoned(c, &policy, state.DoingStatus)
+	defer r.Stop()
+
+	ensureChange(c, r, sb, chg)
+
+	st := chg.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(*calls, HasLen, 0)
+	tasks := chg.Tasks()
+	c.Check(tasks[0].Status(), Equals, state.ErrorStatus)
+	c.Check(strings.Join(tasks[0].Log(), ""), Matches, `.*task abandoned while in progress, undoing`)
+	c.Check(tasks[1].Status(), Equals, state.HoldStatus)
+	c.Check(logbuf.String(), Matches, `(?s).*Change 1 task \(t1\) was abandoned in status Doing, undoing.*`)
+}
+
+func (ts *taskRunnerSuite) TestAbandonedUndoWhileUndoing(c *C) {
+	policy := state.AbandonedUndo
+	r, sb, chg, calls := ts.setupAbandoned(c, &policy, state.UndoingStatus)
+	defer r.Stop()
+
+	ensureChange(c, r, sb, chg)
+
+	st := chg.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(chg.Tasks()[0].Status(), Equals, state.UndoneStatus)
+	c.Check(*calls, DeepEquals, []string{"undo:t1"})
+}
+
+func (ts *taskRunnerSuite) TestAbandonedHold(c *C) {
+	policy := state.AbandonedHold
+	r, sb, chg, calls := ts.setupAbandoned(c, &policy, state.DoingStatus)
+	defer r.Stop()
+
+	r.Ensure()
+	r.Wait()
+
+	st := chg.State()
+	st.Lock()
+	t1 := chg.Tasks()[0]
+	c.Check(t1.Status(), Equals, state.DoingStatus)
+	c.Check(chg.Status(), Equals, state.DoingStatus)
+	c.Check(strings.Join(t1.Log(), ""), Matches, `.*task abandoned while in progress, holding it`)
+	c.Check(*calls, HasLen, 0)
+	warns := st.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `change 1 was held as its task "t1" was interrupted: abort the change once inspected`)
+
+	// running again does not touch the held task
+	st.Unlock()
+	r.Ensure()
+	r.Wait()
+	st.Lock()
+	c.Check(t1.Status(), Equals, state.DoingStatus)
+	c.Check(*calls, HasLen, 0)
+	c.Check(st.AllWarnings(), HasLen, 1)
+
+	// aborting the change undoes the held task
+	chg.Abort()
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(t1.Status(), Equals, state.UndoneStatus)
+	c.Check(*calls, DeepEquals, []string{"undo:t1"})
+}
+
+func (ts *taskRunnerSuite) TestAbandonedHoldWhileUndoing(c *C) {
+	policy := state.AbandonedHold
+	r, sb, chg, calls := ts.setupAbandoned(c, &policy, state.UndoingStatus)
+	defer r.Stop()
+
+	ensureChange(c, r, sb, chg)
+
+	st := chg.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(chg.Tasks()[0].Status(), Equals, state.UndoneStatus)
+	c.Check(*calls, DeepEquals, []string{"undo:t1"})
+	c.Check(st.AllWarnings(), HasLen, 0)
+}
+
+func (ts *taskRunnerSuite) TestRetriedTaskIsNotAbandoned(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	retried := false
+	r.AddHandler("foo", func(t *state.Task, tomb *tomb.Tomb) error {
+		if !retried {
+			retried = true
+			return &state.Retry{}
+		}
+		return nil
+	}, nil)
+	r.SetAbandonedPolicy("foo", state.AbandonedHold)
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	chg.AddTask(t1)
+	st.Unlock()
+
+	r.Ensure()
+	r.Wait()
+	st.Lock()
+	c.Check(t1.Status(), Equals, state.DoingStatus)
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(t1.Status(), Equals, state.DoneStatus)
+	c.Check(st.AllWarnings(), HasLen, 0)
+}
+
+var errTransient = errors.New("transient")
+
+func (ts *taskRunnerSuite) TestRetryPolicy(c *C) {
+	now := time.Now()
+	restore := state.MockTime(now)
+	defer restore()
+	restore = state.MockRandFloat64(func() float64 { return 0.5 })
+	defer restore()
+
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var calls int
+	r.AddHandler("download", func(t *state.Task, tb *tomb.Tomb) error {
+		calls++
+		if calls == 4 {
+			return fmt.Errorf("permanent")
+		}
+		return errTransient
+	}, nil)
+	r.SetRetryPolicy("download", func(err error) bool {
+		return errors.Is(err, errTransient)
+	}, func(t *state.Task) state.RetryPolicy {
+		return state.RetryPolicy{
+			MaxRetries:     5,
+			InitialBackoff: time.Minute,
+			MaxBackoff:     3 * time.Minute,
+			Jitter:         0.1,
+		}
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("download", "1")
+	chg.AddTask(t)
+	st.Unlock()
+
+	for i, backoff := range []time.Duration{time.Minute, 2 * time.Minute} {
+		r.Ensure()
+		r.Wait()
+
+		st.Lock()
+		c.Check(t.Status(), Equals, state.DoingStatus)
+		c.Check(t.Retries(), Equals, i+1)
+		// no jitter with a random value of 0.5
+		c.Check(t.AtTime().Equal(now.Add(backoff)), Equals, true, Commentf("retry %d", i+1))
+		st.Unlock()
+
+		// not retried before the backoff is over
+		r.Ensure()
+		r.Wait()
+		c.Check(calls, Equals, i+1)
+
+		now = now.Add(backoff)
+		restore := state.MockTime(now)
+		defer restore()
+	}
+
+	// the third retry would be after 4 minutes, capped
+	r.Ensure()
+	r.Wait()
+	st.Lock()
+	c.Check(t.Retries(), Equals, 3)
+	c.Check(t.AtTime().Equal(now.Add(3*time.Minute)), Equals, true)
+	st.Unlock()
+
+	// a permanent error is not retried
+	now = now.Add(3 * time.Minute)
+	restore = state.MockTime(now)
+	defer restore()
+	r.Ensure()
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(calls, Equals, 4)
+	c.Check(t.Status(), Equals, state.ErrorStatus)
+	c.Check(t.Retries(), Equals, 3)
+	c.Check(strings.Join(t.Log(), "\n"), Matches, `(?s).*transient error, retry 1 of 5 in 1m0s: transient.*retry 3 of 5 in 3m0s.*ERROR permanent`)
+}
+
+func (ts *taskRunnerSuite) TestRetryPolicyGivesUp(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var calls int
+	r.AddHandler("download", func(t *state.Task, tb *tomb.Tomb) error {
+		calls++
+		return errTransient
+	}, nil)
+	r.SetRetryPolicy("download", func(err error) bool {
+		return errors.Is(err, errTransient)
+	}, func(t *state.Task) state.RetryPolicy {
+		return state.RetryPolicy{MaxRetries: 2}
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("download", "1")
+	chg.AddTask(t)
+	st.Unlock()
+
+	for i := 0; i < 3; i++ {
+		r.Ensure()
+		r.Wait()
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(calls, Equals, 3)
+	c.Check(t.Status(), Equals, state.ErrorStatus)
+	c.Check(t.Retries(), Equals, 2)
+	c.Check(strings.Join(t.Log(), "\n"), Matches, `(?s).*giving up after 2 retries.*ERROR transient`)
+}
+
+func (ts *taskRunnerSuite) TestRetryPolicyAbortedNotRetried(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var t *state.Task
+	r.AddHandler("download", func(_ *state.Task, tb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		t.Change().Abort()
+		return errTransient
+	}, nil)
+	r.SetRetryPolicy("download", func(err error) bool {
+		return true
+	}, func(t *state.Task) state.RetryPolicy {
+		return state.RetryPolicy{MaxRetries: 2}
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t = st.NewTask("download", "1")
+	chg.AddTask(t)
+	st.Unlock()
+
+	r.Ensure()
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(t.Status(), Equals, state.ErrorStatus)
+	c.Check(t.Retries(), Equals, 0)
+}
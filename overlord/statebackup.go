@@ -0,0 +1,401 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package overlord
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/patch"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snapdtool"
+	"github.com/snapcore/snapd/systemd"
+)
+
+// StateBackupFormat is the version of the format of the archives written
+// by ExportState.
+const StateBackupFormat = 1
+
+const (
+	stateBackupMetaName  = "meta.json"
+	stateBackupStateName = "state.json"
+	stateBackupAssertDir = "assertions"
+)
+
+// StateBackupMeta describes a state backup.
+type StateBackupMeta struct {
+	Format       int       `json:"format"`
+	Time         time.Time `json:"time"`
+	SnapdVersion string    `json:"snapd-version"`
+	Brand        string    `json:"brand,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	Serial       string    `json:"serial,omitempty"`
+}
+
+func stateRestoreDir() string {
+	return filepath.Join(filepath.Dir(dirs.SnapStateFile), "state-restore")
+}
+
+func deviceOf(st *state.State) (*auth.DeviceState, error) {
+	var authState auth.AuthState
+	if err := st.Get("auth", &authState); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if authState.Device == nil {
+		return &auth.DeviceState{}, nil
+	}
+	return authState.Device, nil
+}
+
+func addToTar(tw *tar.Writer, name string, mode int64, modTime time.Time, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if data == nil {
+		hdr.Typeflag = tar.TypeDir
+		hdr.Name += "/"
+	} else {
+		hdr.Typeflag = tar.TypeReg
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// ExportState writes a tar archive with the state and the assertion
+// database to w. The state is locked while doing so, as both are only
+// modified with the state locked, so that they are consistent.
+func ExportState(st *state.State, w io.Writer) error {
+	st.Lock()
+	defer st.Unlock()
+
+	stateData, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("cannot export state: %v", err)
+	}
+	device, err := deviceOf(st)
+	if err != nil {
+		return fmt.Errorf("cannot export state: %v", err)
+	}
+	now := time.Now()
+	meta := &StateBackupMeta{
+		Format:       StateBackupFormat,
+		Time:         now,
+		SnapdVersion: snapdtool.Version,
+		Brand:        device.Brand,
+		Model:        device.Model,
+		Serial:       device.Serial,
+	}
+	metaData, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("cannot export state: %v", err)
+	}
+
+	tw := tar.NewWriter(w)
+	if err := addToTar(tw, stateBackupMetaName, 0644, now, metaData); err != nil {
+		return fmt.Errorf("cannot export state: %v", err)
+	}
+	if err := addToTar(tw, stateBackupStateName, 0600, now, stateData); err != nil {
+		return fmt.Errorf("cannot export state: %v", err)
+	}
+	err = filepath.Walk(dirs.SnapAssertsDBDir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && p == dirs.SnapAssertsDBDir {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dirs.SnapAssertsDBDir, p)
+		if err != nil {
+			return err
+		}
+		name := path.Join(stateBackupAssertDir, filepath.ToSlash(rel))
+		switch {
+		case fi.IsDir():
+			return addToTar(tw, name, 0755, fi.ModTime(), nil)
+		case fi.Mode().IsRegular():
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return addToTar(tw, name, int64(fi.Mode().Perm()), fi.ModTime(), data)
+		default:
+			return fmt.Errorf("unexpected file type of %q", p)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("cannot export assertions: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("cannot export state: %v", err)
+	}
+	return nil
+}
+
+// cleanBackupEntry returns the cleaned name of an archive entry, it
+// refuses names escaping the archive.
+func cleanBackupEntry(name string) (string, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid entry %q", name)
+	}
+	return clean, nil
+}
+
+// unpackStateBackup unpacks the archive into dir, checking that it is a
+// state backup that can be restored on this device.
+func unpackStateBackup(r io.Reader, dir string, current *auth.DeviceState) (*StateBackupMeta, error) {
+	var meta *StateBackupMeta
+	var backupState *state.State
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name, err := cleanBackupEntry(hdr.Name)
+		if err != nil {
+			return nil, err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if name != stateBackupAssertDir && !strings.HasPrefix(name, stateBackupAssertDir+"/") {
+				return nil, fmt.Errorf("unexpected directory %q", hdr.Name)
+			}
+			if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+				return nil, err
+			}
+			continue
+		case tar.TypeReg:
+			// handled below
+		default:
+			return nil, fmt.Errorf("unexpected entry %q", hdr.Name)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		mode := os.FileMode(0644)
+		switch {
+		case name == stateBackupMetaName:
+			if err := json.Unmarshal(data, &meta); err != nil {
+				return nil, fmt.Errorf("cannot decode %s: %v", stateBackupMetaName, err)
+			}
+		case name == stateBackupStateName:
+			backupState, err = state.ReadState(nil, bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			mode = 0600
+		case strings.HasPrefix(name, stateBackupAssertDir+"/"):
+			if _, err := asserts.Decode(data); err != nil {
+				return nil, fmt.Errorf("cannot decode assertion %q: %v", hdr.Name, err)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected file %q", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, data, mode); err != nil {
+			return nil, err
+		}
+	}
+
+	if meta == nil {
+		return nil, fmt.Errorf("missing %s", stateBackupMetaName)
+	}
+	if meta.Format != StateBackupFormat {
+		return nil, fmt.Errorf("unsupported format %d", meta.Format)
+	}
+	if backupState == nil {
+		return nil, fmt.Errorf("missing %s", stateBackupStateName)
+	}
+
+	backupState.Lock()
+	defer backupState.Unlock()
+	device, err := deviceOf(backupState)
+	if err != nil {
+		return nil, err
+	}
+	if device.Brand != meta.Brand || device.Model != meta.Model || device.Serial != meta.Serial {
+		return nil, fmt.Errorf("device in %s does not match the one in %s", stateBackupStateName, stateBackupMetaName)
+	}
+	if current.Model != "" && (device.Brand != current.Brand || device.Model != current.Model) {
+		return nil, fmt.Errorf("backup of a %s/%s device cannot be restored on a %s/%s device", device.Brand, device.Model, current.Brand, current.Model)
+	}
+	if current.Serial != "" && device.Serial != "" && device.Serial != current.Serial {
+		return nil, fmt.Errorf("backup of device %q cannot be restored on device %q", device.Serial, current.Serial)
+	}
+	if err := checkBackupPatchLevel(backupState); err != nil {
+		return nil, err
+	}
+	if err := checkBackupSnapRevisions(backupState); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// checkBackupPatchLevel checks that the running snapd can read the state
+// in the backup, which it cannot if the state was patched by a newer snapd.
+func checkBackupPatchLevel(backupState *state.State) error {
+	var level int
+	if err := backupState.Get("patch-level", &level); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if level > patch.Level {
+		return fmt.Errorf("backup has patch level %d, newer than the supported %d", level, patch.Level)
+	}
+	return nil
+}
+
+// checkBackupSnapRevisions checks that all the snap revisions known to the
+// backup are still present on the system, either as a snap file or as a
+// mount unit.
+func checkBackupSnapRevisions(backupState *state.State) error {
+	snapStates, err := snapstate.All(backupState)
+	if err != nil {
+		return err
+	}
+	for instanceName, snapst := range snapStates {
+		for _, si := range snapst.Sequence.SideInfos() {
+			if osutil.FileExists(snap.MountFile(instanceName, si.Revision)) {
+				continue
+			}
+			if osutil.FileExists(systemd.MountUnitPath(snap.MountDir(instanceName, si.Revision))) {
+				continue
+			}
+			return fmt.Errorf("revision %s of snap %q in the backup is not present on the system", si.Revision, instanceName)
+		}
+	}
+	return nil
+}
+
+// StageStateRestore checks the state backup read from r and stages it to
+// replace the state and the assertion database the next time snapd starts.
+// The backup must come from the same device, and no change can be in
+// progress.
+func StageStateRestore(st *state.State, r io.Reader) (*StateBackupMeta, error) {
+	st.Lock()
+	defer st.Unlock()
+
+	for _, chg := range st.Changes() {
+		if !chg.IsReady() {
+			return nil, fmt.Errorf("cannot restore state backup: change %s in progress", chg.ID())
+		}
+	}
+	current, err := deviceOf(st)
+	if err != nil {
+		return nil, fmt.Errorf("cannot restore state backup: %v", err)
+	}
+
+	dir := stateRestoreDir()
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("cannot restore state backup: %v", err)
+	}
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return nil, fmt.Errorf("cannot restore state backup: %v", err)
+	}
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return nil, fmt.Errorf("cannot restore state backup: %v", err)
+	}
+	meta, err := unpackStateBackup(r, tmpDir, current)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("cannot restore state backup: %v", err)
+	}
+	// only a complete backup is ever staged
+	if err := os.Rename(tmpDir, dir); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("cannot restore state backup: %v", err)
+	}
+	return meta, nil
+}
+
+// applyStagedStateRestore replaces the state and the assertion database
+// with the staged backup, if any. The replaced ones are kept aside with a
+// .pre-restore suffix. It must be called with the state file lock held
+// and before the state is read.
+func applyStagedStateRestore() error {
+	dir := stateRestoreDir()
+	if !osutil.IsDirectory(dir) {
+		return nil
+	}
+	// whatever happens, the restore is attempted only once
+	defer os.RemoveAll(dir)
+
+	type move struct{ from, to string }
+	var moves []move
+	for _, p := range []string{dirs.SnapStateFile, dirs.SnapAssertsDBDir} {
+		if !osutil.FileExists(p) {
+			continue
+		}
+		aside := p + ".pre-restore"
+		if err := os.RemoveAll(aside); err != nil {
+			return err
+		}
+		moves = append(moves, move{p, aside})
+	}
+	moves = append(moves, move{filepath.Join(dir, stateBackupStateName), dirs.SnapStateFile})
+	if staged := filepath.Join(dir, stateBackupAssertDir); osutil.IsDirectory(staged) {
+		moves = append(moves, move{staged, dirs.SnapAssertsDBDir})
+	}
+
+	for i, m := range moves {
+		if err := os.Rename(m.from, m.to); err != nil {
+			// put back what was moved already
+			for j := i - 1; j >= 0; j-- {
+				if err := os.Rename(moves[j].to, moves[j].from); err != nil {
+					logger.Noticef("cannot undo restoring %q: %v", moves[j].from, err)
+				}
+			}
+			return err
+		}
+	}
+	logger.Noticef("Restored state backup")
+	return nil
+}
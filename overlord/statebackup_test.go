@@ -0,0 +1,287 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package overlord_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/patch"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snapdtool"
+	"github.com/snapcore/snapd/systemd"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (ovs *overlordSuite) mockStateToBackUp(c *C, st *state.State) []byte {
+	st.Lock()
+	st.Set("patch-level", patch.Level)
+	st.Set("patch-sublevel", patch.Sublevel)
+	st.Set("patch-sublevel-last-version", snapdtool.Version)
+	st.Set("refresh-privacy-key", "0123456789ABCDEF")
+	st.Set("some", "data")
+	st.Set("auth", &auth.AuthState{Device: &auth.DeviceState{Brand: "my-brand", Model: "my-model", Serial: "1234"}})
+	st.Unlock()
+
+	storeStack := assertstest.NewStoreStack("can0nical", nil)
+	encoded := asserts.Encode(storeStack.TrustedAccount)
+	p := filepath.Join(dirs.SnapAssertsDBDir, "asserts-v0", "account", "can0nical", "active")
+	c.Assert(os.MkdirAll(filepath.Dir(p), 0755), IsNil)
+	c.Assert(os.WriteFile(p, encoded, 0644), IsNil)
+	return encoded
+}
+
+func untarEntries(c *C, data []byte) map[string][]byte {
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		content, err := io.ReadAll(tr)
+		c.Assert(err, IsNil)
+		entries[hdr.Name] = content
+	}
+	return entries
+}
+
+func (ovs *overlordSuite) TestExportState(c *C) {
+	st := state.New(nil)
+	encoded := ovs.mockStateToBackUp(c, st)
+
+	var buf bytes.Buffer
+	c.Assert(overlord.ExportState(st, &buf), IsNil)
+
+	entries := untarEntries(c, buf.Bytes())
+	var meta overlord.StateBackupMeta
+	c.Assert(json.Unmarshal(entries["meta.json"], &meta), IsNil)
+	c.Check(meta.Time.IsZero(), Equals, false)
+	meta.Time = time.Time{}
+	c.Check(meta, DeepEquals, overlord.StateBackupMeta{
+		Format:       1,
+		SnapdVersion: snapdtool.Version,
+		Brand:        "my-brand",
+		Model:        "my-model",
+		Serial:       "1234",
+	})
+	c.Check(entries["assertions/asserts-v0/account/can0nical/active"], DeepEquals, encoded)
+	_, ok := entries["assertions/asserts-v0/"]
+	c.Check(ok, Equals, true)
+
+	backupState, err := state.ReadState(nil, bytes.NewReader(entries["state.json"]))
+	c.Assert(err, IsNil)
+	backupState.Lock()
+	defer backupState.Unlock()
+	var some string
+	c.Assert(backupState.Get("some", &some), IsNil)
+	c.Check(some, Equals, "data")
+}
+
+func (ovs *overlordSuite) TestStageStateRestoreAppliedOnStartup(c *C) {
+	st := state.New(nil)
+	encoded := ovs.mockStateToBackUp(c, st)
+	var buf bytes.Buffer
+	c.Assert(overlord.ExportState(st, &buf), IsNil)
+
+	// what is in place when snapd starts again
+	c.Assert(os.WriteFile(dirs.SnapStateFile, []byte("corrupted"), 0600), IsNil)
+	c.Assert(os.RemoveAll(dirs.SnapAssertsDBDir), IsNil)
+	c.Assert(os.MkdirAll(dirs.SnapAssertsDBDir, 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapAssertsDBDir, "old"), nil, 0644), IsNil)
+
+	meta, err := overlord.StageStateRestore(st, &buf)
+	c.Assert(err, IsNil)
+	c.Check(meta.Format, Equals, overlord.StateBackupFormat)
+	c.Check(meta.Model, Equals, "my-model")
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+	o.InterfaceManager().DisableUDevMonitor()
+
+	restored := o.State()
+	restored.Lock()
+	var some string
+	c.Check(restored.Get("some", &some), IsNil)
+	restored.Unlock()
+	c.Check(some, Equals, "data")
+
+	c.Check(dirs.SnapStateFile+".pre-restore", testutil.FileEquals, "corrupted")
+	c.Check(filepath.Join(dirs.SnapAssertsDBDir+".pre-restore", "old"), testutil.FilePresent)
+	c.Check(filepath.Join(dirs.SnapAssertsDBDir, "old"), testutil.FileAbsent)
+	c.Check(filepath.Join(dirs.SnapAssertsDBDir, "asserts-v0", "account", "can0nical", "active"), testutil.FileEquals, string(encoded))
+	// the restore is done
+	c.Check(filepath.Join(filepath.Dir(dirs.SnapStateFile), "state-restore"), testutil.FileAbsent)
+}
+
+func mockTar(c *C, entries map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range entries {
+		c.Assert(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}), IsNil)
+		_, err := tw.Write([]byte(content))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(tw.Close(), IsNil)
+	return &buf
+}
+
+func (ovs *overlordSuite) TestStageStateRestoreErrors(c *C) {
+	st := state.New(nil)
+	ovs.mockStateToBackUp(c, st)
+	var buf bytes.Buffer
+	c.Assert(overlord.ExportState(st, &buf), IsNil)
+	backup := buf.Bytes()
+	entries := untarEntries(c, backup)
+
+	busy := state.New(nil)
+	busy.Lock()
+	chg := busy.NewChange("foo", "...")
+	chg.AddTask(busy.NewTask("bar", "..."))
+	busy.Unlock()
+	_, err := overlord.StageStateRestore(busy, bytes.NewReader(backup))
+	c.Check(err, ErrorMatches, `cannot restore state backup: change 1 in progress`)
+
+	other := state.New(nil)
+	other.Lock()
+	other.Set("auth", &auth.AuthState{Device: &auth.DeviceState{Brand: "other-brand", Model: "other-model"}})
+	other.Unlock()
+	_, err = overlord.StageStateRestore(other, bytes.NewReader(backup))
+	c.Check(err, ErrorMatches, `cannot restore state backup: backup of a my-brand/my-model device cannot be restored on a other-brand/other-model device`)
+
+	for _, tc := range []struct {
+		entries map[string]string
+		err     string
+	}{{
+		entries: map[string]string{"state.json": string(entries["state.json"])},
+		err:     `missing meta.json`,
+	}, {
+		entries: map[string]string{"meta.json": string(entries["meta.json"])},
+		err:     `missing state.json`,
+	}, {
+		entries: map[string]string{"meta.json": `{"format": 2}`, "state.json": string(entries["state.json"])},
+		err:     `unsupported format 2`,
+	}, {
+		entries: map[string]string{"meta.json": `{"format": 1}`, "state.json": string(entries["state.json"])},
+		err:     `device in state.json does not match the one in meta.json`,
+	}, {
+		entries: map[string]string{"../escape": "foo"},
+		err:     `invalid entry "../escape"`,
+	}, {
+		entries: map[string]string{"other": "foo"},
+		err:     `unexpected file "other"`,
+	}, {
+		entries: map[string]string{"assertions/asserts-v0/bad": "foo"},
+		err:     `cannot decode assertion "assertions/asserts-v0/bad": .*`,
+	}} {
+		_, err := overlord.StageStateRestore(st, mockTar(c, tc.entries))
+		c.Check(err, ErrorMatches, "cannot restore state backup: "+tc.err, Commentf("%v", tc.entries))
+	}
+	c.Check(filepath.Join(filepath.Dir(dirs.SnapStateFile), "state-restore"), testutil.FileAbsent)
+	c.Check(filepath.Join(filepath.Dir(dirs.SnapStateFile), "state-restore.tmp"), testutil.FileAbsent)
+}
+
+func (ovs *overlordSuite) TestStageStateRestoreNewerPatchLevel(c *C) {
+	st := state.New(nil)
+	ovs.mockStateToBackUp(c, st)
+	st.Lock()
+	st.Set("patch-level", patch.Level+1)
+	st.Unlock()
+	var buf bytes.Buffer
+	c.Assert(overlord.ExportState(st, &buf), IsNil)
+
+	_, err := overlord.StageStateRestore(state.New(nil), &buf)
+	c.Check(err, ErrorMatches, `cannot restore state backup: backup has patch level \d+, newer than the supported \d+`)
+	c.Check(filepath.Join(filepath.Dir(dirs.SnapStateFile), "state-restore"), testutil.FileAbsent)
+}
+
+func (ovs *overlordSuite) TestStageStateRestoreMissingSnapRevisions(c *C) {
+	st := state.New(nil)
+	ovs.mockStateToBackUp(c, st)
+	st.Lock()
+	snapstate.Set(st, "foo", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "foo", Revision: snap.R(1)},
+			{RealName: "foo", Revision: snap.R(2)},
+		}),
+		Current: snap.R(2),
+	})
+	st.Unlock()
+	var buf bytes.Buffer
+	c.Assert(overlord.ExportState(st, &buf), IsNil)
+	backup := buf.Bytes()
+
+	// revision 1 is only present as a mount unit
+	c.Assert(os.MkdirAll(dirs.SnapServicesDir, 0755), IsNil)
+	c.Assert(os.WriteFile(systemd.MountUnitPath(snap.MountDir("foo", snap.R(1))), nil, 0644), IsNil)
+
+	_, err := overlord.StageStateRestore(state.New(nil), bytes.NewReader(backup))
+	c.Check(err, ErrorMatches, `cannot restore state backup: revision 2 of snap "foo" in the backup is not present on the system`)
+
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	c.Assert(os.WriteFile(snap.MountFile("foo", snap.R(2)), nil, 0644), IsNil)
+	_, err = overlord.StageStateRestore(state.New(nil), bytes.NewReader(backup))
+	c.Check(err, IsNil)
+}
+
+func (ovs *overlordSuite) TestStateRestoreFailureWarns(c *C) {
+	st := state.New(nil)
+	ovs.mockStateToBackUp(c, st)
+	st.Lock()
+	data, err := json.Marshal(st)
+	st.Unlock()
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(dirs.SnapStateFile, data, 0600), IsNil)
+
+	// a staged backup without the state cannot be applied
+	c.Assert(os.MkdirAll(filepath.Join(filepath.Dir(dirs.SnapStateFile), "state-restore"), 0700), IsNil)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+	o.InterfaceManager().DisableUDevMonitor()
+
+	restored := o.State()
+	restored.Lock()
+	defer restored.Unlock()
+	var some string
+	c.Check(restored.Get("some", &some), IsNil)
+	c.Check(some, Equals, "data")
+	warns := restored.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Matches, `cannot restore state backup, the previous state was kept: .*`)
+	c.Check(filepath.Join(filepath.Dir(dirs.SnapStateFile), "state-restore"), testutil.FileAbsent)
+}
@@ -196,6 +196,27 @@ func (sc *storeContext) StoreID(fallback string) (string, error) {
 	return fallback, nil
 }
 
+// SnapStoreID returns the store id requests concerning the given snap
+// are routed to, which is the store id according to system state unless
+// the snap has an explicit route.
+func (sc *storeContext) SnapStoreID(snapName, fallback string) (string, error) {
+	sc.state.Lock()
+	routes, err := SnapStoreRoutes(sc.state)
+	sc.state.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	if storeID, ok := routes[snapName]; ok {
+		if storeID == GlobalStoreID {
+			return "", nil
+		}
+		return storeID, nil
+	}
+
+	return sc.StoreID(fallback)
+}
+
 type DeviceSessionRequestParams = store.DeviceSessionRequestParams
 
 // DeviceSessionRequestParams produces a device-session-request with the given nonce, together with other required parameters, the device serial and model assertions. It returns store.ErrNoSerial if the device serial is not yet initialized.
@@ -36,6 +36,7 @@ import (
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/overlord/storecontext"
 	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
 )
 
 func Test(t *testing.T) { TestingT(t) }
@@ -220,6 +221,40 @@ func (s *storeCtxSuite) TestStoreIDFromEnv(c *C) {
 	c.Check(storeID, Equals, "env-store-id")
 }
 
+func (s *storeCtxSuite) TestSnapStoreID(c *C) {
+	storeCtx := storecontext.New(s.state, &testBackend{nothing: true})
+
+	s.state.Lock()
+	c.Assert(storecontext.SetSnapStoreRoute(s.state, "foo", "friendly-store"), IsNil)
+	c.Assert(storecontext.SetSnapStoreRoute(s.state, "bar", storecontext.GlobalStoreID), IsNil)
+	s.state.Unlock()
+
+	storeID, err := storeCtx.SnapStoreID("foo", "fallback")
+	c.Assert(err, IsNil)
+	c.Check(storeID, Equals, "friendly-store")
+
+	// the global store is the one without a store id
+	storeID, err = storeCtx.SnapStoreID("bar", "fallback")
+	c.Assert(err, IsNil)
+	c.Check(storeID, Equals, "")
+
+	// other snaps use the device store
+	storeID, err = storeCtx.SnapStoreID("baz", "fallback")
+	c.Assert(err, IsNil)
+	c.Check(storeID, Equals, "fallback")
+
+	s.state.Lock()
+	c.Assert(storecontext.SetSnapStoreRoute(s.state, "foo", ""), IsNil)
+	c.Assert(storecontext.SetSnapStoreRoute(s.state, "bar", ""), IsNil)
+	var routes map[string]string
+	c.Check(s.state.Get("store-routing", &routes), testutil.ErrorIs, state.ErrNoState)
+	s.state.Unlock()
+
+	storeID, err = storeCtx.SnapStoreID("foo", "fallback")
+	c.Assert(err, IsNil)
+	c.Check(storeID, Equals, "fallback")
+}
+
 func (s *storeCtxSuite) TestCloudInfo(c *C) {
 	storeCtx := storecontext.New(s.state, &testBackend{nothing: true})
 
@@ -0,0 +1,64 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package storecontext
+
+import (
+	"errors"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// GlobalStoreID is the id used to route snaps to the global store,
+// regardless of the store of the device.
+const GlobalStoreID = "global"
+
+// SnapStoreRoutes returns the stores snaps are explicitly routed to, by
+// snap name.
+func SnapStoreRoutes(st *state.State) (map[string]string, error) {
+	var routes map[string]string
+	if err := st.Get("store-routing", &routes); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// SetSnapStoreRoute routes the requests concerning the given snap to the
+// given store, or removes its route if storeID is empty. The store is
+// not checked against the constraints of the device.
+func SetSnapStoreRoute(st *state.State, snapName, storeID string) error {
+	routes, err := SnapStoreRoutes(st)
+	if err != nil {
+		return err
+	}
+	if storeID == "" {
+		delete(routes, snapName)
+	} else {
+		if routes == nil {
+			routes = make(map[string]string)
+		}
+		routes[snapName] = storeID
+	}
+	if len(routes) == 0 {
+		st.Set("store-routing", nil)
+	} else {
+		st.Set("store-routing", routes)
+	}
+	return nil
+}
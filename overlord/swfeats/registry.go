@@ -23,6 +23,7 @@ package swfeats
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -36,10 +37,19 @@ var (
 // a template
 type ChangeKindRegistry struct {
 	changes map[string][]string
+	details map[string]changeKindDetails
+}
+
+type changeKindDetails struct {
+	description string
+	tasks       []string
 }
 
 func newChangeKindRegistry() *ChangeKindRegistry {
-	return &ChangeKindRegistry{changes: make(map[string][]string)}
+	return &ChangeKindRegistry{
+		changes: make(map[string][]string),
+		details: make(map[string]changeKindDetails),
+	}
 }
 
 // Add a change kind string to the registry
@@ -81,6 +91,63 @@ func KnownChangeKinds() []string {
 	return kinds
 }
 
+// DescribeChangeKind attaches a description and the typical sequence
+// of task kinds of the change to the already registered change kind,
+// or change kind template. If the change kind is not present, then
+// the method fails and returns false
+func DescribeChangeKind(kind, description string, tasks ...string) bool {
+	if _, ok := changeReg.changes[kind]; !ok {
+		return false
+	}
+	changeReg.details[kind] = changeKindDetails{
+		description: description,
+		tasks:       tasks,
+	}
+	return true
+}
+
+// ChangeKind describes a registered change kind
+type ChangeKind struct {
+	Kind string `json:"kind"`
+	// Template is the change kind template the kind is a variant of,
+	// if any
+	Template    string `json:"template,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Tasks is the typical sequence of task kinds of the change,
+	// changes can have more or fewer tasks depending on the
+	// circumstances
+	Tasks []string `json:"tasks,omitempty"`
+}
+
+// ChangeKinds retrieves the descriptions of all registered change
+// kinds, including their variants, if present, sorted by kind
+func ChangeKinds() []ChangeKind {
+	kinds := make([]ChangeKind, 0, len(changeReg.changes))
+	for key, values := range changeReg.changes {
+		details := changeReg.details[key]
+		if len(values) == 0 {
+			kinds = append(kinds, ChangeKind{
+				Kind:        key,
+				Description: details.description,
+				Tasks:       details.tasks,
+			})
+			continue
+		}
+		for _, value := range values {
+			kinds = append(kinds, ChangeKind{
+				Kind:        fmt.Sprintf(key, value),
+				Template:    key,
+				Description: details.description,
+				Tasks:       details.tasks,
+			})
+		}
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].Kind < kinds[j].Kind
+	})
+	return kinds
+}
+
 // EnsureRegistry contains the set of all ensure helper
 // functions and their manager
 type EnsureRegistry struct {
@@ -93,6 +93,28 @@ func (s *swfeatsSuite) TestNewChangeTemplateKnown(c *C) {
 	c.Assert(kinds, testutil.Contains, "my-change-3")
 }
 
+func (s *swfeatsSuite) TestDescribeChangeKind(c *C) {
+	registry := swfeats.NewChangeKindRegistry()
+	restore := swfeats.MockChangeKindRegistry(registry)
+	defer restore()
+
+	myChange := swfeats.RegisterChangeKind("my-change")
+	swfeats.RegisterChangeKind("another-change")
+	myTemplate := swfeats.RegisterChangeKind("my-template-%s")
+	c.Assert(swfeats.AddChangeKindVariants(myTemplate, []string{"b", "a"}), Equals, true)
+
+	c.Check(swfeats.DescribeChangeKind(myChange, "Do my change", "task-1", "task-2"), Equals, true)
+	c.Check(swfeats.DescribeChangeKind(myTemplate, "Do my template"), Equals, true)
+	c.Check(swfeats.DescribeChangeKind("unknown", "Unknown"), Equals, false)
+
+	c.Check(swfeats.ChangeKinds(), DeepEquals, []swfeats.ChangeKind{
+		{Kind: "another-change"},
+		{Kind: "my-change", Description: "Do my change", Tasks: []string{"task-1", "task-2"}},
+		{Kind: "my-template-a", Template: "my-template-%s", Description: "Do my template"},
+		{Kind: "my-template-b", Template: "my-template-%s", Description: "Do my template"},
+	})
+}
+
 func (s *swfeatsSuite) TestAddEnsure(c *C) {
 	registry := swfeats.NewEnsureRegistry()
 	restore := swfeats.MockEnsureRegistry(registry)
@@ -0,0 +1,63 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package webhookstate
+
+import (
+	"time"
+
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+var RetryDelay = retryDelay
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	return testutil.Mock(&timeNow, f)
+}
+
+func MockMaxAttempts(n int) (restore func()) {
+	return testutil.Mock(&maxAttempts, n)
+}
+
+func MockMaxPending(n int) (restore func()) {
+	return testutil.Mock(&maxPending, n)
+}
+
+type PendingDelivery struct {
+	Notification *Notification
+	Attempts     int
+	NextAttempt  time.Time
+}
+
+func PendingDeliveries(st *state.State) ([]PendingDelivery, error) {
+	pending, err := pendingDeliveries(st)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]PendingDelivery, 0, len(pending))
+	for _, d := range pending {
+		res = append(res, PendingDelivery{
+			Notification: d.Notification,
+			Attempts:     d.Attempts,
+			NextAttempt:  d.NextAttempt,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,428 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package webhookstate pushes notifications about the outcome of
+// auto-refreshes, and of failed changes of configured kinds, to a webhook
+// configured by the administrator. It complements the notices, which need
+// to be pulled, for fleets without a pull-based monitor.
+package webhookstate
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/configstate/proxyconf"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	timeNow = time.Now
+
+	// initialRetryDelay is the delay before retrying a failed delivery,
+	// it doubles with every further attempt up to maxRetryDelay.
+	initialRetryDelay = 30 * time.Second
+	maxRetryDelay     = time.Hour
+	// maxAttempts is how many times the delivery of a notification is
+	// attempted before it is dropped.
+	maxAttempts = 8
+	// maxPending is how many notifications are kept waiting for delivery,
+	// the oldest ones are dropped first.
+	maxPending = 100
+
+	requestTimeout = 30 * time.Second
+)
+
+// SignatureHeader is the header carrying the hex encoded HMAC-SHA256 of the
+// body of the notification, keyed with the configured secret.
+const SignatureHeader = "X-Snapd-Signature"
+
+func init() {
+	swfeats.RegisterEnsure("WebhookManager", "ensureDelivered")
+}
+
+// Notification is the JSON summary of a change posted to the webhook.
+type Notification struct {
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	ChangeID   string    `json:"change-id"`
+	ChangeKind string    `json:"change-kind"`
+	Summary    string    `json:"summary"`
+	Status     string    `json:"status"`
+	Snaps      []string  `json:"snaps,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	SpawnTime  time.Time `json:"spawn-time"`
+	ReadyTime  time.Time `json:"ready-time"`
+}
+
+// delivery is a notification waiting to be delivered, it is kept in the
+// state so that it survives restarts.
+type delivery struct {
+	Notification *Notification `json:"notification"`
+	Attempts     int           `json:"attempts,omitempty"`
+	NextAttempt  time.Time     `json:"next-attempt"`
+}
+
+type webhookConfig struct {
+	url         string
+	secret      string
+	changeKinds []string
+}
+
+func getConfig(st *state.State) (*webhookConfig, error) {
+	tr := config.NewTransaction(st)
+	var cfg webhookConfig
+	var kinds string
+	for _, opt := range []struct {
+		name  string
+		value *string
+	}{
+		{"refresh.webhook.url", &cfg.url},
+		{"refresh.webhook.secret", &cfg.secret},
+		{"refresh.webhook.change-kinds", &kinds},
+	} {
+		if err := tr.Get("core", opt.name, opt.value); err != nil && !config.IsNoOption(err) {
+			return nil, err
+		}
+	}
+	cfg.changeKinds = strutil.CommaSeparatedList(kinds)
+	return &cfg, nil
+}
+
+func pendingDeliveries(st *state.State) ([]*delivery, error) {
+	var pending []*delivery
+	if err := st.Get("webhook-deliveries", &pending); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return pending, nil
+}
+
+func setPendingDeliveries(st *state.State, pending []*delivery) {
+	if len(pending) == 0 {
+		st.Set("webhook-deliveries", nil)
+		return
+	}
+	st.Set("webhook-deliveries", pending)
+}
+
+// WebhookManager delivers notifications about changes to the configured
+// webhook, retrying with backoff when the delivery fails.
+type WebhookManager struct {
+	state  *state.State
+	client *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// delivering is set, with the state locked, while a delivery is
+	// ongoing
+	delivering bool
+	deliveries sync.WaitGroup
+}
+
+// Manager returns a new WebhookManager.
+func Manager(st *state.State) *WebhookManager {
+	m := &WebhookManager{
+		state: st,
+		client: httputil.NewHTTPClient(&httputil.ClientOptions{
+			Timeout: requestTimeout,
+			Proxy:   proxyconf.New(st).Conf,
+		}),
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+
+	st.Lock()
+	defer st.Unlock()
+	st.AddChangeStatusChangedHandler(m.changeStatusChanged)
+
+	return m
+}
+
+func (m *WebhookManager) changeStatusChanged(chg *state.Change, old, new state.Status) {
+	if old.Ready() || !new.Ready() {
+		return
+	}
+
+	st := chg.State()
+	cfg, err := getConfig(st)
+	if err != nil {
+		logger.Noticef("cannot get webhook configuration: %v", err)
+		return
+	}
+	if cfg.url == "" {
+		return
+	}
+	if chg.Kind() != "auto-refresh" && !(new == state.ErrorStatus && strutil.ListContains(cfg.changeKinds, chg.Kind())) {
+		return
+	}
+
+	if err := queue(st, notificationFor(chg, new)); err != nil {
+		logger.Noticef("cannot queue webhook notification for change %s: %v", chg.ID(), err)
+		return
+	}
+	st.EnsureBefore(0)
+}
+
+func notificationFor(chg *state.Change, status state.Status) *Notification {
+	var snaps []string
+	if err := chg.Get("snap-names", &snaps); err != nil && !errors.Is(err, state.ErrNoState) {
+		logger.Debugf("internal error: cannot get snap names of change %s: %v", chg.ID(), err)
+	}
+	n := &Notification{
+		ID:         fmt.Sprintf("change-%s", chg.ID()),
+		Time:       timeNow(),
+		ChangeID:   chg.ID(),
+		ChangeKind: chg.Kind(),
+		Summary:    chg.Summary(),
+		Status:     status.String(),
+		Snaps:      snaps,
+		SpawnTime:  chg.SpawnTime(),
+		ReadyTime:  chg.ReadyTime(),
+	}
+	if status == state.ErrorStatus {
+		if err := chg.Err(); err != nil {
+			n.Error = err.Error()
+		}
+	}
+	return n
+}
+
+func queue(st *state.State, n *Notification) error {
+	pending, err := pendingDeliveries(st)
+	if err != nil {
+		return err
+	}
+	for _, d := range pending {
+		if d.Notification.ID == n.ID {
+			// the change became ready again
+			return nil
+		}
+	}
+	pending = append(pending, &delivery{Notification: n, NextAttempt: n.Time})
+	if len(pending) > maxPending {
+		for _, d := range pending[:len(pending)-maxPending] {
+			logger.Noticef("dropping webhook notification %s, too many pending notifications", d.Notification.ID)
+		}
+		pending = pending[len(pending)-maxPending:]
+	}
+	setPendingDeliveries(st, pending)
+	return nil
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := initialRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// Ensure implements StateManager.Ensure.
+func (m *WebhookManager) Ensure() error {
+	return m.ensureDelivered()
+}
+
+// Wait implements StateWaiter.Wait. It waits for an ongoing delivery to
+// finish.
+func (m *WebhookManager) Wait() {
+	m.deliveries.Wait()
+}
+
+// Stop implements StateStopper. It cancels an ongoing delivery and waits
+// for it to finish.
+func (m *WebhookManager) Stop() {
+	m.cancel()
+	m.deliveries.Wait()
+}
+
+// ensureDelivered starts the delivery of the pending notifications that are
+// due to the webhook. The notifications are posted from a separate goroutine
+// so that a slow or unreachable webhook does not hold up the other managers.
+func (m *WebhookManager) ensureDelivered() error {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	if m.delivering {
+		return nil
+	}
+
+	pending, err := pendingDeliveries(st)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	logger.Trace("ensure", "manager", "WebhookManager", "func", "ensureDelivered")
+
+	cfg, err := getConfig(st)
+	if err != nil {
+		return err
+	}
+	if cfg.url == "" {
+		// the webhook was unset in the meantime
+		logger.Noticef("dropping %d webhook notifications, no webhook is configured", len(pending))
+		setPendingDeliveries(st, nil)
+		return nil
+	}
+
+	now := timeNow()
+	var next time.Time
+	var due []*Notification
+	for _, d := range pending {
+		if d.NextAttempt.After(now) {
+			if next.IsZero() || d.NextAttempt.Before(next) {
+				next = d.NextAttempt
+			}
+			continue
+		}
+		due = append(due, d.Notification)
+	}
+	if len(due) == 0 {
+		st.EnsureBefore(next.Sub(now))
+		return nil
+	}
+
+	m.delivering = true
+	m.deliveries.Add(1)
+	go m.deliver(cfg, due)
+	return nil
+}
+
+// deliver posts the given notifications to the webhook and then records
+// the outcome, scheduling the retries of the failed ones.
+func (m *WebhookManager) deliver(cfg *webhookConfig, due []*Notification) {
+	defer m.deliveries.Done()
+
+	attempted := make(map[string]bool, len(due))
+	failed := make(map[string]error)
+	for _, n := range due {
+		if m.ctx.Err() != nil {
+			// stopping, the remaining ones are attempted after the restart
+			break
+		}
+		attempted[n.ID] = true
+		if err := m.post(m.ctx, cfg, n); err != nil {
+			failed[n.ID] = err
+		}
+	}
+
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	m.delivering = false
+
+	// notifications might have been queued while the state was unlocked
+	pending, err := pendingDeliveries(st)
+	if err != nil {
+		logger.Noticef("cannot record the webhook deliveries: %v", err)
+		return
+	}
+
+	now := timeNow()
+	var next time.Time
+	remaining := make([]*delivery, 0, len(pending))
+	for _, d := range pending {
+		id := d.Notification.ID
+		if attempted[id] {
+			err, ok := failed[id]
+			if !ok {
+				// delivered
+				continue
+			}
+			if m.ctx.Err() != nil {
+				// the attempt was interrupted, it does not count
+				remaining = append(remaining, d)
+				continue
+			}
+			d.Attempts++
+			if d.Attempts >= maxAttempts {
+				logger.Noticef("cannot deliver webhook notification %s, giving up after %d attempts: %v", id, d.Attempts, err)
+				continue
+			}
+			logger.Debugf("cannot deliver webhook notification %s (attempt %d): %v", id, d.Attempts, err)
+			d.NextAttempt = now.Add(retryDelay(d.Attempts))
+		}
+		if next.IsZero() || d.NextAttempt.Before(next) {
+			next = d.NextAttempt
+		}
+		remaining = append(remaining, d)
+	}
+	setPendingDeliveries(st, remaining)
+
+	if !next.IsZero() && m.ctx.Err() == nil {
+		delay := next.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		st.EnsureBefore(delay)
+	}
+}
+
+func (m *WebhookManager) post(ctx context.Context, cfg *webhookConfig, n *Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(cfg.secret, body))
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body keyed with secret, as
+// sent in the SignatureHeader of the notifications.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,309 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package webhookstate_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/webhookstate"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func TestWebhookState(t *testing.T) { TestingT(t) }
+
+type webhookSuite struct {
+	testutil.BaseTest
+
+	st  *state.State
+	mgr *webhookstate.WebhookManager
+	now time.Time
+
+	server   *httptest.Server
+	status   int
+	received []*http.Request
+	bodies   [][]byte
+	block    chan struct{}
+}
+
+var _ = Suite(&webhookSuite{})
+
+func (s *webhookSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	s.st = state.New(nil)
+	s.now = time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(webhookstate.MockTimeNow(func() time.Time { return s.now }))
+
+	s.status = 200
+	s.received = nil
+	s.bodies = nil
+	s.block = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		c.Check(err, IsNil)
+		s.received = append(s.received, r)
+		s.bodies = append(s.bodies, body)
+		if s.block != nil {
+			select {
+			case <-s.block:
+			case <-r.Context().Done():
+			}
+		}
+		w.WriteHeader(s.status)
+	}))
+	s.AddCleanup(s.server.Close)
+
+	s.mgr = webhookstate.Manager(s.st)
+}
+
+func (s *webhookSuite) configure(c *C, opts map[string]string) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	tr := config.NewTransaction(s.st)
+	for k, v := range opts {
+		c.Assert(tr.Set("core", k, v), IsNil)
+	}
+	tr.Commit()
+}
+
+func (s *webhookSuite) makeReadyChange(c *C, kind string, status state.Status) *state.Change {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	chg := s.st.NewChange(kind, "summary of "+kind)
+	chg.Set("snap-names", []string{"foo", "bar"})
+	t := s.st.NewTask("foo", "...")
+	chg.AddTask(t)
+	if status == state.ErrorStatus {
+		t.Errorf("boom")
+	}
+	t.SetStatus(status)
+	return chg
+}
+
+func (s *webhookSuite) pending(c *C) []webhookstate.PendingDelivery {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	pending, err := webhookstate.PendingDeliveries(s.st)
+	c.Assert(err, IsNil)
+	return pending
+}
+
+func (s *webhookSuite) TestAutoRefreshNotificationDelivered(c *C) {
+	s.configure(c, map[string]string{
+		"refresh.webhook.url":    s.server.URL + "/hook",
+		"refresh.webhook.secret": "s3cr3t",
+	})
+
+	chg := s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+	c.Assert(s.pending(c), HasLen, 1)
+
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Wait()
+	c.Assert(s.received, HasLen, 1)
+	req := s.received[0]
+	c.Check(req.Method, Equals, "POST")
+	c.Check(req.URL.Path, Equals, "/hook")
+	c.Check(req.Header.Get("Content-Type"), Equals, "application/json")
+	c.Check(req.Header.Get(webhookstate.SignatureHeader), Equals, "sha256="+webhookstate.Sign("s3cr3t", s.bodies[0]))
+
+	var n webhookstate.Notification
+	c.Assert(json.Unmarshal(s.bodies[0], &n), IsNil)
+	s.st.Lock()
+	c.Check(n.SpawnTime.Equal(chg.SpawnTime()), Equals, true)
+	c.Check(n.ReadyTime.Equal(chg.ReadyTime()), Equals, true)
+	s.st.Unlock()
+	c.Check(n.Time.Equal(s.now), Equals, true)
+	n.SpawnTime, n.ReadyTime, n.Time = time.Time{}, time.Time{}, time.Time{}
+	c.Check(n, DeepEquals, webhookstate.Notification{
+		ID:         "change-" + chg.ID(),
+		ChangeID:   chg.ID(),
+		ChangeKind: "auto-refresh",
+		Summary:    "summary of auto-refresh",
+		Status:     "Done",
+		Snaps:      []string{"foo", "bar"},
+	})
+
+	// delivered notifications are not kept
+	c.Check(s.pending(c), HasLen, 0)
+}
+
+func (s *webhookSuite) TestDeliveryHonoursProxy(c *C) {
+	// the test server acts as the proxy
+	s.configure(c, map[string]string{
+		"refresh.webhook.url": "http://webhook.invalid/hook",
+		"proxy.http":          s.server.URL,
+	})
+
+	s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Wait()
+	c.Assert(s.received, HasLen, 1)
+	c.Check(s.received[0].URL.String(), Equals, "http://webhook.invalid/hook")
+	c.Check(s.pending(c), HasLen, 0)
+}
+
+func (s *webhookSuite) TestFailedChangesOfConfiguredKinds(c *C) {
+	s.configure(c, map[string]string{
+		"refresh.webhook.url":          s.server.URL,
+		"refresh.webhook.change-kinds": "install-snap,remove-snap",
+	})
+
+	s.makeReadyChange(c, "install-snap", state.DoneStatus)
+	s.makeReadyChange(c, "refresh-snap", state.ErrorStatus)
+	chg := s.makeReadyChange(c, "remove-snap", state.ErrorStatus)
+
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Wait()
+	c.Assert(s.received, HasLen, 1)
+	// no secret, no signature
+	c.Check(s.received[0].Header.Get(webhookstate.SignatureHeader), Equals, "")
+
+	var n webhookstate.Notification
+	c.Assert(json.Unmarshal(s.bodies[0], &n), IsNil)
+	c.Check(n.ChangeID, Equals, chg.ID())
+	c.Check(n.Status, Equals, "Error")
+	c.Check(n.Error, Matches, `(?s).*boom.*`)
+}
+
+func (s *webhookSuite) TestNoWebhookConfigured(c *C) {
+	s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+	c.Check(s.pending(c), HasLen, 0)
+
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Wait()
+	c.Check(s.received, HasLen, 0)
+}
+
+func (s *webhookSuite) TestDeliveryRetriedWithBackoff(c *C) {
+	s.AddCleanup(webhookstate.MockMaxAttempts(3))
+	s.configure(c, map[string]string{"refresh.webhook.url": s.server.URL})
+	s.status = 503
+
+	s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Wait()
+	c.Check(s.received, HasLen, 1)
+	pending := s.pending(c)
+	c.Assert(pending, HasLen, 1)
+	c.Check(pending[0].Attempts, Equals, 1)
+	c.Check(pending[0].NextAttempt.Equal(s.now.Add(30*time.Second)), Equals, true)
+
+	// not due yet
+	s.now = s.now.Add(10 * time.Second)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Wait()
+	c.Check(s.received, HasLen, 1)
+
+	s.now = s.now.Add(20 * time.Second)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Wait()
+	c.Check(s.received, HasLen, 2)
+	pending = s.pending(c)
+	c.Assert(pending, HasLen, 1)
+	c.Check(pending[0].Attempts, Equals, 2)
+	c.Check(pending[0].NextAttempt.Equal(s.now.Add(time.Minute)), Equals, true)
+
+	// the last attempt fails too and the notification is dropped
+	s.now = s.now.Add(time.Minute)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Wait()
+	c.Check(s.received, HasLen, 3)
+	c.Check(s.pending(c), HasLen, 0)
+}
+
+func (s *webhookSuite) TestDeliveryDoesNotBlockEnsure(c *C) {
+	s.configure(c, map[string]string{"refresh.webhook.url": s.server.URL})
+	s.block = make(chan struct{})
+
+	s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+
+	c.Assert(s.mgr.Ensure(), IsNil)
+	// the state is not held while posting
+	c.Check(s.pending(c), HasLen, 1)
+	// and a delivery is not started twice
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	close(s.block)
+	s.mgr.Wait()
+	c.Check(s.received, HasLen, 1)
+	c.Check(s.pending(c), HasLen, 0)
+}
+
+func (s *webhookSuite) TestStopInterruptsDelivery(c *C) {
+	s.configure(c, map[string]string{"refresh.webhook.url": s.server.URL})
+	s.block = make(chan struct{})
+	defer close(s.block)
+
+	s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Stop()
+
+	// the interrupted attempt does not count
+	pending := s.pending(c)
+	c.Assert(pending, HasLen, 1)
+	c.Check(pending[0].Attempts, Equals, 0)
+}
+
+func (s *webhookSuite) TestPendingDroppedWhenWebhookUnset(c *C) {
+	s.configure(c, map[string]string{"refresh.webhook.url": s.server.URL})
+	s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+	c.Assert(s.pending(c), HasLen, 1)
+
+	s.configure(c, map[string]string{"refresh.webhook.url": ""})
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.mgr.Wait()
+	c.Check(s.received, HasLen, 0)
+	c.Check(s.pending(c), HasLen, 0)
+}
+
+func (s *webhookSuite) TestMaxPending(c *C) {
+	s.AddCleanup(webhookstate.MockMaxPending(2))
+	s.configure(c, map[string]string{"refresh.webhook.url": s.server.URL})
+
+	s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+	chg2 := s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+	chg3 := s.makeReadyChange(c, "auto-refresh", state.DoneStatus)
+
+	pending := s.pending(c)
+	c.Assert(pending, HasLen, 2)
+	c.Check(pending[0].Notification.ChangeID, Equals, chg2.ID())
+	c.Check(pending[1].Notification.ChangeID, Equals, chg3.ID())
+}
+
+func (s *webhookSuite) TestRetryDelay(c *C) {
+	c.Check(webhookstate.RetryDelay(1), Equals, 30*time.Second)
+	c.Check(webhookstate.RetryDelay(2), Equals, time.Minute)
+	c.Check(webhookstate.RetryDelay(4), Equals, 4*time.Minute)
+	c.Check(webhookstate.RetryDelay(20), Equals, time.Hour)
+}
@@ -31,6 +31,8 @@ var supportedHooks = []*HookType{
 	NewHookType(regexp.MustCompile("^install$")),
 	NewHookType(regexp.MustCompile("^pre-refresh$")),
 	NewHookType(regexp.MustCompile("^post-refresh$")),
+	NewHookType(regexp.MustCompile("^pre-epoch-migration$")),
+	NewHookType(regexp.MustCompile("^post-epoch-migration$")),
 	NewHookType(regexp.MustCompile("^remove$")),
 	NewHookType(regexp.MustCompile("^prepare-(?:plug|slot)-[-a-z0-9]+$")),
 	NewHookType(regexp.MustCompile("^unprepare-(?:plug|slot)-[-a-z0-9]+$")),
@@ -39,6 +41,7 @@ var supportedHooks = []*HookType{
 	NewHookType(regexp.MustCompile("^check-health$")),
 	NewHookType(regexp.MustCompile("^fde-setup$")),
 	NewHookType(regexp.MustCompile("^gate-auto-refresh$")),
+	NewHookType(regexp.MustCompile("^refresh-inhibit$")),
 	NewHookType(regexp.MustCompile("^change-view-.+$")),
 	NewHookType(regexp.MustCompile("^save-view-.+$")),
 	NewHookType(regexp.MustCompile("^query-view-.+$")),
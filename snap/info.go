@@ -356,6 +356,10 @@ type SideInfo struct {
 	EditedDescription   string `json:"description,omitempty"`
 	Private             bool   `json:"private,omitempty"`
 	Paid                bool   `json:"paid,omitempty"`
+	// Architecture is set for revisions installed for an architecture
+	// other than the one of the system, e.g. content snaps carrying
+	// binaries for an emulated architecture.
+	Architecture string `json:"architecture,omitempty"`
 }
 
 // Info provides information about snaps.
@@ -499,6 +503,7 @@ type ChannelSnapInfo struct {
 	Epoch       Epoch           `json:"epoch"`
 	Size        int64           `json:"size"`
 	ReleasedAt  time.Time       `json:"released-at"`
+	Base        string          `json:"base,omitempty"`
 }
 
 // Provenance returns the provenance of the snap, this is a label set
@@ -0,0 +1,171 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package launchpolicy implements the local policy restricting which snap
+// applications can be launched via "snap run" and by whom.
+package launchpolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// Mode controls what happens when the policy denies launching an app.
+type Mode string
+
+const (
+	// ModeAudit logs the decisions of the policy without denying any launch.
+	ModeAudit Mode = "audit"
+	// ModeEnforce logs the decisions of the policy and denies launching
+	// apps which are not allowed.
+	ModeEnforce Mode = "enforce"
+)
+
+// Policy is a list of rules describing which apps can be launched. An app
+// can be launched if at least one rule allows it.
+type Policy struct {
+	Mode  Mode   `json:"mode"`
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// Rule allows launching the given app by the listed users and members of the
+// listed groups. A rule with no users and groups applies to everyone.
+type Rule struct {
+	// App is either a snap name, which matches all the apps of the snap,
+	// or a <snap>.<app> name.
+	App    string   `json:"app"`
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Validate checks that the policy is well formed.
+func (p *Policy) Validate() error {
+	switch p.Mode {
+	case ModeAudit, ModeEnforce:
+	default:
+		return fmt.Errorf("invalid launch policy mode %q", p.Mode)
+	}
+	for _, rule := range p.Rules {
+		snapName, appName := snap.SplitSnapApp(rule.App)
+		if err := naming.ValidateInstance(snapName); err != nil {
+			return fmt.Errorf("invalid launch policy rule for %q: %v", rule.App, err)
+		}
+		if appName != snapName {
+			if err := naming.ValidateApp(appName); err != nil {
+				return fmt.Errorf("invalid launch policy rule for %q: %v", rule.App, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Rule) matchesApp(snapApp string) bool {
+	if r.App == snapApp {
+		return true
+	}
+	// a rule for a snap name matches all its apps
+	snapName, _ := snap.SplitSnapApp(snapApp)
+	return r.App == snapName
+}
+
+func (r *Rule) matchesUser(username string, groups []string) bool {
+	if len(r.Users) == 0 && len(r.Groups) == 0 {
+		return true
+	}
+	if strutil.ListContains(r.Users, username) {
+		return true
+	}
+	for _, group := range groups {
+		if strutil.ListContains(r.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed returns whether the given user, member of the given groups, is
+// allowed to launch snapApp according to the policy, regardless of its mode.
+// The root user is always allowed.
+func (p *Policy) Allowed(snapApp, username string, groups []string) bool {
+	if username == "root" {
+		return true
+	}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.matchesApp(snapApp) && rule.matchesUser(username, groups) {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads the launch policy of the system. It returns nil without an
+// error if no policy is in place.
+func Load() (*Policy, error) {
+	data, err := os.ReadFile(dirs.SnapLaunchPolicyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read launch policy: %v", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("cannot decode launch policy: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Save writes the given launch policy, replacing the current one. Passing a
+// nil policy removes the policy in place, if any.
+func Save(p *Policy) error {
+	if p == nil {
+		if err := os.Remove(dirs.SnapLaunchPolicyFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cannot remove launch policy: %v", err)
+		}
+		return nil
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("cannot encode launch policy: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dirs.SnapLaunchPolicyFile), 0755); err != nil {
+		return fmt.Errorf("cannot write launch policy: %v", err)
+	}
+	// the policy must be readable by "snap run" running as any user
+	if err := osutil.AtomicWriteFile(dirs.SnapLaunchPolicyFile, data, 0644, 0); err != nil {
+		return fmt.Errorf("cannot write launch policy: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package launchpolicy_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/snap/launchpolicy"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type launchPolicySuite struct {
+	testutil.BaseTest
+}
+
+var _ = Suite(&launchPolicySuite{})
+
+func (s *launchPolicySuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+}
+
+func (s *launchPolicySuite) TestAllowed(c *C) {
+	p := &launchpolicy.Policy{
+		Mode: launchpolicy.ModeEnforce,
+		Rules: []launchpolicy.Rule{
+			{App: "kiosk"},
+			{App: "editor.edit", Users: []string{"alice"}},
+			{App: "admin-tool", Groups: []string{"admins"}},
+		},
+	}
+
+	for _, t := range []struct {
+		app     string
+		user    string
+		groups  []string
+		allowed bool
+	}{
+		// rule for all apps of a snap, for everyone
+		{"kiosk", "bob", nil, true},
+		{"kiosk.browser", "bob", nil, true},
+		// rule for a single app and a user
+		{"editor.edit", "alice", nil, true},
+		{"editor.edit", "bob", nil, false},
+		{"editor.other", "alice", nil, false},
+		{"editor", "alice", nil, false},
+		// rule for a group
+		{"admin-tool.run", "bob", []string{"users", "admins"}, true},
+		{"admin-tool.run", "bob", []string{"users"}, false},
+		// no matching rule
+		{"other", "alice", []string{"admins"}, false},
+		// root is always allowed
+		{"other", "root", nil, true},
+	} {
+		c.Check(p.Allowed(t.app, t.user, t.groups), Equals, t.allowed, Commentf("%s run by %s", t.app, t.user))
+	}
+}
+
+func (s *launchPolicySuite) TestValidate(c *C) {
+	for _, t := range []struct {
+		policy launchpolicy.Policy
+		err    string
+	}{
+		{launchpolicy.Policy{Mode: "audit"}, ""},
+		{launchpolicy.Policy{Mode: "enforce", Rules: []launchpolicy.Rule{{App: "foo"}, {App: "foo_bar.baz"}}}, ""},
+		{launchpolicy.Policy{}, `invalid launch policy mode ""`},
+		{launchpolicy.Policy{Mode: "maybe"}, `invalid launch policy mode "maybe"`},
+		{launchpolicy.Policy{Mode: "audit", Rules: []launchpolicy.Rule{{App: "Foo"}}}, `invalid launch policy rule for "Foo": .*`},
+		{launchpolicy.Policy{Mode: "audit", Rules: []launchpolicy.Rule{{App: "foo.-bar"}}}, `invalid launch policy rule for "foo.-bar": .*`},
+	} {
+		err := t.policy.Validate()
+		if t.err == "" {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, t.err)
+		}
+	}
+}
+
+func (s *launchPolicySuite) TestSaveLoad(c *C) {
+	p, err := launchpolicy.Load()
+	c.Assert(err, IsNil)
+	c.Check(p, IsNil)
+
+	p = &launchpolicy.Policy{
+		Mode:  launchpolicy.ModeAudit,
+		Rules: []launchpolicy.Rule{{App: "foo.bar", Users: []string{"alice"}}},
+	}
+	c.Assert(launchpolicy.Save(p), IsNil)
+	c.Check(dirs.SnapLaunchPolicyFile, testutil.FileEquals, `{"mode":"audit","rules":[{"app":"foo.bar","users":["alice"]}]}`)
+	st, err := os.Stat(dirs.SnapLaunchPolicyFile)
+	c.Assert(err, IsNil)
+	c.Check(st.Mode().Perm(), Equals, os.FileMode(0644))
+
+	loaded, err := launchpolicy.Load()
+	c.Assert(err, IsNil)
+	c.Check(loaded, DeepEquals, p)
+
+	// removing the policy
+	c.Assert(launchpolicy.Save(nil), IsNil)
+	c.Check(dirs.SnapLaunchPolicyFile, testutil.FileAbsent)
+	loaded, err = launchpolicy.Load()
+	c.Assert(err, IsNil)
+	c.Check(loaded, IsNil)
+
+	// removing again is fine
+	c.Assert(launchpolicy.Save(nil), IsNil)
+}
+
+func (s *launchPolicySuite) TestSaveInvalid(c *C) {
+	err := launchpolicy.Save(&launchpolicy.Policy{Mode: "maybe"})
+	c.Check(err, ErrorMatches, `invalid launch policy mode "maybe"`)
+	c.Check(dirs.SnapLaunchPolicyFile, testutil.FileAbsent)
+}
+
+func (s *launchPolicySuite) TestLoadInvalid(c *C) {
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapLaunchPolicyFile), 0755), IsNil)
+	c.Assert(os.WriteFile(dirs.SnapLaunchPolicyFile, []byte("{"), 0644), IsNil)
+	_, err := launchpolicy.Load()
+	c.Check(err, ErrorMatches, `cannot decode launch policy: .*`)
+
+	c.Assert(os.WriteFile(dirs.SnapLaunchPolicyFile, []byte(`{"mode":"other"}`), 0644), IsNil)
+	_, err = launchpolicy.Load()
+	c.Check(err, ErrorMatches, `invalid launch policy mode "other"`)
+}
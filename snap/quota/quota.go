@@ -24,6 +24,7 @@ package quota
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -244,6 +245,23 @@ func (grp *Group) CurrentTaskUsage() (int, error) {
 	return int(count), nil
 }
 
+// CurrentCPUUsage returns the total CPU time consumed by the quota group
+// since its slice was started. For quota groups which do not yet have a
+// backing systemd slice on the system, the CPU usage is reported as 0.
+func (grp *Group) CurrentCPUUsage() (time.Duration, error) {
+	sysd := systemd.New(systemd.SystemMode, progress.Null)
+
+	isActive, err := sysd.IsActive(grp.SliceFileName())
+	if err != nil {
+		return 0, err
+	}
+	if !isActive {
+		return 0, nil
+	}
+
+	return sysd.CurrentCPUUsage(grp.SliceFileName())
+}
+
 // SliceFileName returns the name of the slice file that should be used for this
 // quota group. This name will include all of the group's parents in the name.
 // For example, a group named "bar" that is a child of the "foo" group will have
@@ -298,6 +316,35 @@ func (grp *Group) JournalNamespaceName() string {
 	return fmt.Sprintf("snap-%s", grp.Name)
 }
 
+// CurrentJournalUsage returns the disk space used by the journal files of
+// the namespace of the quota group, both persistent and volatile ones.
+// For quota groups without a journal namespace, or whose namespace has
+// not logged anything yet, the usage is reported as 0.
+func (grp *Group) CurrentJournalUsage() (quantity.Size, error) {
+	var usage quantity.Size
+	for _, logDir := range []string{"/var/log/journal", "/run/log/journal"} {
+		// journald places the files of a namespace in a directory
+		// named <machine-id>.<namespace>
+		pattern := filepath.Join(dirs.GlobalRootDir, logDir, "*."+grp.JournalNamespaceName(), "*.journal*")
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return 0, err
+		}
+		for _, f := range files {
+			fi, err := os.Stat(f)
+			if err != nil {
+				if os.IsNotExist(err) {
+					// rotated away in the meantime
+					continue
+				}
+				return 0, err
+			}
+			usage += quantity.Size(fi.Size())
+		}
+	}
+	return usage, nil
+}
+
 // JournalConfFileName returns the name of the journal configuration file that should
 // be used for this quota group. As an example, a group named "foo" will return a name
 // of journald@snap-foo.conf
@@ -22,11 +22,14 @@ package quota_test
 import (
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/systemd"
@@ -374,6 +377,36 @@ func (ts *quotaTestSuite) TestJournalNamespaceNameSubgroupInherit(c *C) {
 	c.Check(sub.JournalNamespaceName(), Equals, "snap-foo")
 }
 
+func (ts *quotaTestSuite) TestCurrentJournalUsage(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+
+	grp, err := quota.NewGroup("foo", quota.NewResourcesBuilder().WithJournalSize(quantity.SizeMiB).Build())
+	c.Assert(err, IsNil)
+
+	// nothing logged yet
+	usage, err := grp.CurrentJournalUsage()
+	c.Assert(err, IsNil)
+	c.Check(usage, Equals, quantity.Size(0))
+
+	for path, size := range map[string]int{
+		"/var/log/journal/1234.snap-foo/system.journal":       100,
+		"/var/log/journal/1234.snap-foo/system@0001.journal~": 20,
+		"/run/log/journal/1234.snap-foo/system.journal":       3,
+		// other namespaces and the default one are not counted
+		"/var/log/journal/1234.snap-bar/system.journal": 1000,
+		"/var/log/journal/1234/system.journal":          1000,
+	} {
+		p := filepath.Join(dirs.GlobalRootDir, path)
+		c.Assert(os.MkdirAll(filepath.Dir(p), 0755), IsNil)
+		c.Assert(os.WriteFile(p, make([]byte, size), 0644), IsNil)
+	}
+
+	usage, err = grp.CurrentJournalUsage()
+	c.Assert(err, IsNil)
+	c.Check(usage, Equals, quantity.Size(123))
+}
+
 func (ts *quotaTestSuite) TestJournalConfFileName(c *C) {
 	grp, err := quota.NewGroup("foo", quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeMiB).Build())
 	c.Assert(err, IsNil)
@@ -1041,6 +1074,40 @@ func (ts *quotaTestSuite) TestCurrentMemoryUsage(c *C) {
 	c.Assert(currentMem, Equals, sixteenExb)
 }
 
+func (ts *quotaTestSuite) TestCurrentCPUUsage(c *C) {
+	systemctlCalls := 0
+	r := systemd.MockSystemctl(func(args ...string) ([]byte, error) {
+		systemctlCalls++
+		switch systemctlCalls {
+		case 1:
+			// inactive slice, no usage
+			c.Assert(args, DeepEquals, []string{"is-active", "snap.group.slice"})
+			return []byte("inactive"), systemctlInactiveServiceError{}
+		case 2:
+			c.Assert(args, DeepEquals, []string{"is-active", "snap.group.slice"})
+			return []byte("active"), nil
+		case 3:
+			c.Assert(args, DeepEquals, []string{"show", "--property", "CPUUsageNSec", "snap.group.slice"})
+			return []byte("CPUUsageNSec=2000000000"), nil
+		default:
+			c.Errorf("too many systemctl calls (%d) (current call is %+v)", systemctlCalls, args)
+			return []byte("broken test"), fmt.Errorf("broken test")
+		}
+	})
+	defer r()
+
+	grp1, err := quota.NewGroup("group", quota.NewResourcesBuilder().WithCPUCount(1).WithCPUPercentage(50).Build())
+	c.Assert(err, IsNil)
+
+	usage, err := grp1.CurrentCPUUsage()
+	c.Assert(err, IsNil)
+	c.Check(usage, Equals, time.Duration(0))
+
+	usage, err = grp1.CurrentCPUUsage()
+	c.Assert(err, IsNil)
+	c.Check(usage, Equals, 2*time.Second)
+}
+
 func (ts *quotaTestSuite) TestCurrentTaskUsage(c *C) {
 	systemctlCalls := 0
 	r := systemd.MockSystemctl(func(args ...string) ([]byte, error) {
@@ -132,6 +132,24 @@ func (a *deviceAuthorizer) EnsureDeviceSession(dauthCtx DeviceAndAuthContext, cl
 	return a.refreshDeviceSession(device, dauthCtx, client)
 }
 
+// RenewDeviceSession renews the device session, if there is one, even if it
+// was not yet rejected by the store.
+func (a *deviceAuthorizer) RenewDeviceSession(dauthCtx DeviceAndAuthContext, client *http.Client) error {
+	if dauthCtx == nil {
+		return fmt.Errorf("internal error: no authContext")
+	}
+
+	device, err := dauthCtx.Device()
+	if err != nil {
+		return err
+	}
+
+	if device.Serial == "" {
+		return ErrNoSerial
+	}
+	return a.refreshDeviceSession(device, dauthCtx, client)
+}
+
 // refreshDeviceSession will set or refresh the device session in the state
 func (a *deviceAuthorizer) refreshDeviceSession(device *auth.DeviceState, dauthCtx DeviceAndAuthContext, client *http.Client) error {
 	a.sessionMu.Lock()
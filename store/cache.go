@@ -68,6 +68,9 @@ func (s changesByMtime) Less(i, j int) bool { return s[i].ModTime().Before(s[j].
 type CacheManager struct {
 	cacheDir string
 	maxItems int
+	// maxSize, if set, returns the maximum total size in bytes of the
+	// entries only referenced by the cache, zero meaning no limit
+	maxSize func() int64
 }
 
 // NewCacheManager returns a new CacheManager with the given cacheDir
@@ -79,8 +82,12 @@ type CacheManager struct {
 //     return success
 //  3. If not found, download the snap
 //  4. On success, hardlink into $cacheDir/<digest>
-//  5. If cache dir has more than maxItems entries, remove oldest mtimes
-//     until it has maxItems
+//  5. If cache dir has more than maxItems entries, or the entries only
+//     referenced by the cache take more than the maximum size, remove
+//     oldest mtimes until it has maxItems and fits the maximum size
+//
+// As the mtime is updated on every cache hit, entries are evicted in least
+// recently used order.
 //
 // The caching part is done here, the downloading happens in the store.go
 // code.
@@ -91,6 +98,21 @@ func NewCacheManager(cacheDir string, maxItems int) *CacheManager {
 	}
 }
 
+// SetMaxSize sets the function returning the maximum total size in bytes
+// of the entries only referenced by the cache. A zero size means no limit.
+func (cm *CacheManager) SetMaxSize(maxSize func() int64) {
+	cm.maxSize = maxSize
+}
+
+// currentMaxSize returns the maximum total size of the entries only
+// referenced by the cache, or zero if there is no limit
+func (cm *CacheManager) currentMaxSize() int64 {
+	if cm.maxSize == nil {
+		return 0
+	}
+	return cm.maxSize()
+}
+
 // GetPath returns the full path of the given content in the cache
 // or empty string
 func (cm *CacheManager) GetPath(cacheKey string) string {
@@ -158,20 +180,23 @@ func (cm *CacheManager) path(cacheKey string) string {
 	return filepath.Join(cm.cacheDir, cacheKey)
 }
 
-// cleanup ensures that only maxItems are stored in the cache
+// cleanup ensures that only maxItems are stored in the cache, and that
+// the entries only referenced by the cache fit the maximum size
 func (cm *CacheManager) cleanup() error {
 	entries, err := os.ReadDir(cm.cacheDir)
 	if err != nil {
 		return err
 	}
 
-	if len(entries) <= cm.maxItems {
+	maxSize := cm.currentMaxSize()
+	if len(entries) <= cm.maxItems && maxSize <= 0 {
 		return nil
 	}
 
 	// most of the entries will have more than one hardlink, but a minority may
 	// be referenced only the cache and thus be a candidate for pruning
 	pruneCandidates := make([]os.FileInfo, 0, len(entries)/5)
+	var ownedSize int64
 
 	for _, entry := range entries {
 		fi, err := entry.Info()
@@ -187,10 +212,14 @@ func (cm *CacheManager) cleanup() error {
 		// is "free" so skip it.
 		if n <= 1 {
 			pruneCandidates = append(pruneCandidates, fi)
+			ownedSize += fi.Size()
 		}
 	}
 
-	if len(pruneCandidates) <= cm.maxItems {
+	fits := func(numOwned int, ownedSize int64) bool {
+		return numOwned <= cm.maxItems && (maxSize <= 0 || ownedSize <= maxSize)
+	}
+	if fits(len(pruneCandidates), ownedSize) {
 		// nothing to prune
 		return nil
 	}
@@ -211,7 +240,8 @@ func (cm *CacheManager) cleanup() error {
 			continue
 		}
 		deleted++
-		if numOwned-deleted <= cm.maxItems {
+		ownedSize -= fi.Size()
+		if fits(numOwned-deleted, ownedSize) {
 			break
 		}
 	}
@@ -155,6 +155,38 @@ func (s *cacheSuite) TestCleanup(c *C) {
 	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[len(cacheKeys)-1])), Equals, true)
 }
 
+func (s *cacheSuite) TestCleanupMaxSize(c *C) {
+	maxSize := int64(0)
+	s.cm.SetMaxSize(func() int64 { return maxSize })
+
+	// each of the files takes one byte
+	cacheKeys, testFiles := s.makeTestFiles(c, s.maxItems)
+	for _, p := range testFiles[:s.maxItems-1] {
+		c.Assert(os.Remove(p), IsNil)
+	}
+
+	// no limit
+	c.Assert(s.cm.Cleanup(), IsNil)
+	c.Check(s.cm.Count(), Equals, s.maxItems)
+
+	// reading an entry makes it the most recently used one
+	time.Sleep(10 * time.Millisecond)
+	c.Check(s.cm.Get(cacheKeys[0], filepath.Join(s.tmp, "target")), Equals, true)
+	c.Assert(os.Remove(filepath.Join(s.tmp, "target")), IsNil)
+
+	maxSize = 2
+	c.Assert(s.cm.Cleanup(), IsNil)
+
+	// the least recently used entries are removed
+	for _, cacheKey := range cacheKeys[1:3] {
+		c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKey)), Equals, false)
+	}
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[0])), Equals, true)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[3])), Equals, true)
+	// the entry still used elsewhere does not count against the size
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[4])), Equals, true)
+}
+
 func (s *cacheSuite) TestCleanupContinuesOnError(c *C) {
 	cacheKeys, testFiles := s.makeTestFiles(c, s.maxItems+2)
 	for _, p := range testFiles {
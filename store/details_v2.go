@@ -164,6 +164,7 @@ func infoFromStoreInfo(si *storeInfo) (*snap.Info, error) {
 			Epoch:       s.Epoch,
 			Size:        s.Download.Size,
 			ReleasedAt:  ch.ReleasedAt.UTC(),
+			Base:        s.Base,
 		}
 		if !seen[ch.Track] {
 			seen[ch.Track] = true
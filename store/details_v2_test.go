@@ -426,6 +426,7 @@ func (s *detailsV2Suite) TestInfoFromStoreSnap(c *C) {
 		"Tracks",   // handled at a different level (see TestInfo)
 		"Layout",
 		"SideInfo.Channel",
+		"SideInfo.Architecture", // only set for foreign architecture installs
 		"LegacyWebsite",
 	}
 	var checker func(string, reflect.Value)
@@ -36,6 +36,9 @@ type DeviceAndAuthContext interface {
 	UpdateUserAuth(user *auth.UserState, discharges []string) (actual *auth.UserState, err error)
 
 	StoreID(fallback string) (string, error)
+	// SnapStoreID returns the id of the store requests concerning the
+	// given snap are sent to, which is usually the same as StoreID.
+	SnapStoreID(snapName, fallback string) (string, error)
 
 	DeviceSessionRequestParams(nonce string) (*DeviceSessionRequestParams, error)
 	ProxyStoreParams(defaultURL *url.URL) (proxyStoreID string, proxySroreURL *url.URL, err error)
@@ -154,6 +154,10 @@ func MockDownload(f func(ctx context.Context, name, sha3_384, downloadURL string
 	}
 }
 
+func MockDownloadCheckpointInterval(interval int64) (restore func()) {
+	return testutil.Mock(&downloadCheckpointInterval, interval)
+}
+
 func MockMaxIconFilesize(maxSize int64) (restore func()) {
 	return testutil.Mock(&maxIconFilesize, maxSize)
 }
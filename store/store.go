@@ -111,6 +111,10 @@ type Config struct {
 
 	// CacheDownloads is the number of downloads that should be cached
 	CacheDownloads int
+	// CacheMaxSize, if set, returns the maximum total size in bytes of
+	// the cached downloads not used by installed snaps, zero meaning no
+	// limit
+	CacheMaxSize func() int64
 
 	// Proxy returns the HTTP proxy to use when talking to the store
 	Proxy func(*http.Request) (*url.URL, error)
@@ -550,18 +554,40 @@ func (s *Store) EnsureDeviceSession() error {
 	return nil
 }
 
-func (s *Store) setStoreID(r *http.Request, apiLevel apiLevel) (customStore bool) {
+// RenewDeviceSession proactively renews the device session of the store.
+// Expects the store to have an AuthContext.
+func (s *Store) RenewDeviceSession() error {
+	if a, ok := s.auth.(*deviceAuthorizer); ok {
+		return a.RenewDeviceSession(s.dauthCtx, s.client)
+	}
+	return nil
+}
+
+// storeID returns the id of the store that requests concerning the given
+// snap, or the device in general if snapName is empty, are sent to.
+func (s *Store) storeID(snapName string) string {
 	storeID := s.fallbackStoreID
-	if s.dauthCtx != nil {
-		cand, err := s.dauthCtx.StoreID(storeID)
-		if err != nil {
-			logger.Debugf("cannot get store ID from state: %v", err)
-		} else {
-			storeID = cand
-		}
+	if s.dauthCtx == nil {
+		return storeID
 	}
+	var cand string
+	var err error
+	if snapName != "" {
+		cand, err = s.dauthCtx.SnapStoreID(snapName, storeID)
+	} else {
+		cand, err = s.dauthCtx.StoreID(storeID)
+	}
+	if err != nil {
+		logger.Debugf("cannot get store ID from state: %v", err)
+		return storeID
+	}
+	return cand
+}
+
+func (s *Store) setStoreID(r *http.Request, reqOptions *requestOptions) (customStore bool) {
+	storeID := s.storeID(reqOptions.SnapName)
 	if storeID != "" {
-		r.Header.Set(hdrSnapDeviceStore[apiLevel], storeID)
+		r.Header.Set(hdrSnapDeviceStore[reqOptions.APILevel], storeID)
 		return true
 	}
 	return false
@@ -605,6 +631,10 @@ type requestOptions struct {
 	//  - deviceAuthCustomStoreOnly: should be provided only in case
 	//    of a custom store
 	DeviceAuthNeed deviceAuthNeed
+
+	// SnapName is the snap the request is about, if any, used to
+	// send the request to the store the snap is routed to.
+	SnapName string
 }
 
 func (r *requestOptions) addHeader(k, v string) {
@@ -830,7 +860,7 @@ func (s *Store) newRequest(ctx context.Context, reqOptions *requestOptions, user
 		return nil, err
 	}
 
-	customStore := s.setStoreID(req, reqOptions.APILevel)
+	customStore := s.setStoreID(req, reqOptions)
 	authOpts := AuthorizeOptions{apiLevel: reqOptions.APILevel}
 	authOpts.deviceAuth = customStore || reqOptions.DeviceAuthNeed != deviceAuthCustomStoreOnly
 	if authOpts.deviceAuth {
@@ -1032,6 +1062,7 @@ func (s *Store) snapInfo(ctx context.Context, snapName string, fields string, us
 		Method:   "GET",
 		URL:      u,
 		APILevel: apiV2Endps,
+		SnapName: snapName,
 	}
 
 	var remote storeInfo
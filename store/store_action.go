@@ -51,6 +51,13 @@ type RefreshOptions struct {
 	// IncludeResources indicates to the store that resources should be included
 	// in the response.
 	IncludeResources bool
+
+	// routeSnap is the snap whose store routing is used for the request,
+	// if any.
+	routeSnap string
+	// architecture, if set, is the architecture the request is made for
+	// instead of the device one.
+	architecture string
 }
 
 // snap action: install/refresh
@@ -123,6 +130,9 @@ type SnapAction struct {
 	// ValidationSets is an optional array of validation set primary keys
 	// (relevant for install and refresh actions).
 	ValidationSets []snapasserts.ValidationSetKey
+	// Architecture, if set, is the architecture the snap is requested
+	// for, when it differs from the one of the device.
+	Architecture string
 }
 
 func isValidAction(action string) bool {
@@ -254,6 +264,14 @@ func (s *Store) SnapAction(ctx context.Context, currentSnaps []*CurrentSnap, act
 		return nil, nil, &SnapActionError{NoResults: true}
 	}
 
+	deviceActions, routed := s.routeSnapActions(actions)
+	if len(routed) == 0 {
+		return s.snapActionWithAuthRefresh(ctx, currentSnaps, actions, assertQuery, toResolve, toResolveSeq, user, opts)
+	}
+	return s.routedSnapAction(ctx, currentSnaps, deviceActions, routed, assertQuery, toResolve, toResolveSeq, user, opts)
+}
+
+func (s *Store) snapActionWithAuthRefresh(ctx context.Context, currentSnaps []*CurrentSnap, actions []*SnapAction, assertQuery AssertionQuery, toResolve map[asserts.Grouping][]*asserts.AtRevision, toResolveSeq map[asserts.Grouping][]*asserts.AtSequence, user *auth.UserState, opts *RefreshOptions) ([]SnapActionResult, []AssertionResult, error) {
 	authRefreshes := 0
 	for {
 		sars, ars, err := s.snapAction(ctx, currentSnaps, actions, assertQuery, toResolve, toResolveSeq, user, opts, 0)
@@ -596,6 +614,7 @@ func (s *Store) snapAction(ctx context.Context, currentSnaps []*CurrentSnap, act
 		ContentType: jsonContentType,
 		Data:        jsonData,
 		APILevel:    apiV2Endps,
+		SnapName:    opts.routeSnap,
 	}
 
 	if opts.Scheduled {
@@ -610,6 +629,9 @@ func (s *Store) snapAction(ctx context.Context, currentSnaps []*CurrentSnap, act
 	if opts.RefreshManaged {
 		reqOptions.addHeader("Snap-Refresh-Managed", "true")
 	}
+	if opts.architecture != "" {
+		reqOptions.addHeader(hdrSnapDeviceArchitecture[apiV2Endps], opts.architecture)
+	}
 
 	var results snapActionResultList
 	resp, err := s.retryRequestDecodeJSON(ctx, reqOptions, user, &results, nil)
@@ -32,6 +32,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -186,6 +187,10 @@ type DownloadOptions struct {
 	RateLimit           int64
 	Scheduled           bool
 	LeavePartialOnError bool
+	// Resumable keeps the partial download and periodic checkpoints of its
+	// progress on error, so that downloading the same blob again, also
+	// after a restart, resumes from the last checkpoint.
+	Resumable bool
 }
 
 // Download downloads the snap addressed by download info and returns its
@@ -234,7 +239,19 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 	if err != nil {
 		return err
 	}
-	resume, err := w.Seek(0, io.SeekEnd)
+	// dlw is what the download writes to, it is the partial file itself
+	// unless the download is resumable
+	var dlw io.ReadWriteSeeker = w
+	var rf *resumableFile
+	var resume int64
+	if dlOpts != nil && dlOpts.Resumable {
+		rf, resume, err = openResumableFile(w, downloadInfo.Sha3_384)
+		if err == nil {
+			dlw = rf
+		}
+	} else {
+		resume, err = w.Seek(0, io.SeekEnd)
+	}
 	if err != nil {
 		return err
 	}
@@ -246,8 +263,12 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		if err == nil {
 			return
 		}
-		if dlOpts == nil || !dlOpts.LeavePartialOnError || fi == nil || fi.Size() == 0 {
+		leavePartial := dlOpts != nil && (dlOpts.LeavePartialOnError || dlOpts.Resumable)
+		if !leavePartial || fi == nil || fi.Size() == 0 {
 			os.Remove(w.Name())
+			if rf != nil {
+				rf.removeCheckpoint()
+			}
 		}
 	}()
 	if resume > 0 {
@@ -258,7 +279,7 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 
 	url := downloadInfo.DownloadURL
 	if downloadInfo.Size == 0 || resume < downloadInfo.Size {
-		err = download(ctx, name, downloadInfo.Sha3_384, url, user, s, w, resume, pbar, dlOpts)
+		err = download(ctx, name, downloadInfo.Sha3_384, url, user, s, dlw, resume, pbar, dlOpts)
 		if err != nil {
 			logger.Debugf("download of %q failed: %#v", url, err)
 		}
@@ -280,15 +301,18 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 	if _, ok := err.(HashError); ok {
 		logger.Debugf("Hashsum error on download: %v", err.Error())
 		logger.Debugf("Truncating and trying again from scratch.")
-		err = w.Truncate(0)
-		if err != nil {
-			return err
+		if rf != nil {
+			err = rf.reset()
+		} else {
+			err = w.Truncate(0)
+			if err == nil {
+				_, err = w.Seek(0, io.SeekStart)
+			}
 		}
-		_, err = w.Seek(0, io.SeekStart)
 		if err != nil {
 			return err
 		}
-		err = download(ctx, name, downloadInfo.Sha3_384, url, user, s, w, 0, pbar, nil)
+		err = download(ctx, name, downloadInfo.Sha3_384, url, user, s, dlw, 0, pbar, nil)
 		if err != nil {
 			logger.Debugf("download of %q failed: %#v", url, err)
 		}
@@ -301,6 +325,11 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 	if err := os.Rename(w.Name(), targetPath); err != nil {
 		return err
 	}
+	if rf != nil {
+		if err := rf.removeCheckpoint(); err != nil {
+			logger.Noticef("Cannot clean up after download of %s: %v", name, err)
+		}
+	}
 
 	if err := w.Sync(); err != nil {
 		return err
@@ -526,6 +555,9 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 
 	tc, downloadCtx := NewTransferSpeedMonitoringWriterAndContext(ctx, downloadSpeedMeasureWindow, downloadSpeedMin)
 
+	// rf is set for resumable downloads
+	rf, _ := w.(*resumableFile)
+
 	var finalErr error
 	var dlSize float64
 	startTime := time.Now()
@@ -538,16 +570,22 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 
 		if resume > 0 {
 			reqOptions.ExtraHeaders["Range"] = fmt.Sprintf("bytes=%d-", resume)
-			// seed the sha3 with the already local file
-			if _, err := w.Seek(0, io.SeekStart); err != nil {
-				return err
-			}
-			n, err := io.Copy(h, w)
-			if err != nil {
-				return err
+			if rf != nil && rf.etag != "" {
+				// the server sends the whole blob if it changed
+				reqOptions.ExtraHeaders["If-Range"] = rf.etag
 			}
-			if n != resume {
-				return fmt.Errorf("resume offset wrong: %d != %d", resume, n)
+			if rf == nil || !rf.restoreHash(h, resume) {
+				// seed the sha3 with the already local file
+				if _, err := w.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+				n, err := io.Copy(h, w)
+				if err != nil {
+					return err
+				}
+				if n != resume {
+					return fmt.Errorf("resume offset wrong: %d != %d", resume, n)
+				}
 			}
 		}
 
@@ -577,9 +615,16 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 			}
 			break
 		}
-		if resume > 0 && resp.StatusCode != 206 {
+		// resumable downloads keep their progress over error responses
+		keepProgress := rf != nil && resp.StatusCode != 200
+		if resume > 0 && resp.StatusCode != 206 && !keepProgress {
 			logger.Debugf("server does not support resume")
-			if _, err := w.Seek(0, io.SeekStart); err != nil {
+			if rf != nil {
+				// also drop any leftover data past what is downloaded now
+				if err := rf.reset(); err != nil {
+					return err
+				}
+			} else if _, err := w.Seek(0, io.SeekStart); err != nil {
 				return err
 			}
 			h = crypto.SHA3_384.New()
@@ -612,7 +657,14 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 			logger.Debugf("Download size for %s: %d", downloadURL, resp.ContentLength)
 		}
 		pbar.Start(name, dlSize)
-		mw := io.MultiWriter(w, h, pbar, tc)
+		writers := []io.Writer{w, h, pbar, tc}
+		if rf != nil {
+			if etag := resp.Header.Get("ETag"); etag != "" || resp.StatusCode == 200 {
+				rf.etag = etag
+			}
+			writers = append(writers, &checkpointWriter{rf: rf, h: h})
+		}
+		mw := io.MultiWriter(writers...)
 		var limiter io.Reader
 		limiter = resp.Body
 		if limit := dlOpts.RateLimit; limit > 0 {
@@ -625,6 +677,14 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 		close(stopMonitorCh)
 		pbar.Finished()
 
+		if rf != nil {
+			// record what made it so far, the download might be
+			// interrupted for good
+			if err := rf.checkpoint(h); err != nil {
+				logger.Debugf("Cannot checkpoint download of %q: %v", name, err)
+			}
+		}
+
 		if err := tc.Err(); err != nil {
 			return err
 		}
@@ -846,9 +906,23 @@ var applyDelta = func(s *Store, name string, deltaPath string, deltaInfo *snap.D
 	return s.applyDeltaImpl(name, deltaPath, deltaInfo, targetPath, targetSha3_384)
 }
 
+// deltaSourcePath returns the path of the blob of the revision the delta
+// applies to. The target path carries the instance name of the snap, which
+// is used first so that deltas also apply to parallel installs of a snap.
+func deltaSourcePath(name string, deltaInfo *snap.DeltaInfo, targetPath string) string {
+	targetSuffix := fmt.Sprintf("_%d.snap", deltaInfo.ToRevision)
+	if targetBase := filepath.Base(targetPath); strings.HasSuffix(targetBase, targetSuffix) {
+		instanceName := strings.TrimSuffix(targetBase, targetSuffix)
+		snapPath := filepath.Join(dirs.SnapBlobDir, fmt.Sprintf("%s_%d.snap", instanceName, deltaInfo.FromRevision))
+		if instanceName != name && osutil.FileExists(snapPath) {
+			return snapPath
+		}
+	}
+	return filepath.Join(dirs.SnapBlobDir, fmt.Sprintf("%s_%d.snap", name, deltaInfo.FromRevision))
+}
+
 func (s *Store) applyDeltaImpl(name string, deltaPath string, deltaInfo *snap.DeltaInfo, targetPath string, targetSha3_384 string) error {
-	snapBase := fmt.Sprintf("%s_%d.snap", name, deltaInfo.FromRevision)
-	snapPath := filepath.Join(dirs.SnapBlobDir, snapBase)
+	snapPath := deltaSourcePath(name, deltaInfo, targetPath)
 
 	if !osutil.FileExists(snapPath) {
 		return fmt.Errorf("snap %q revision %d not found at %s", name, deltaInfo.FromRevision, snapPath)
@@ -939,7 +1013,9 @@ func (s *Store) CacheDownloads() int {
 func (s *Store) SetCacheDownloads(fileCount int) {
 	s.cfg.CacheDownloads = fileCount
 	if fileCount > 0 {
-		s.cacher = NewCacheManager(dirs.SnapDownloadCacheDir, fileCount)
+		cm := NewCacheManager(dirs.SnapDownloadCacheDir, fileCount)
+		cm.SetMaxSize(s.cfg.CacheMaxSize)
+		s.cacher = cm
 	} else {
 		s.cacher = &nullCache{}
 	}
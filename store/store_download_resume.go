@@ -0,0 +1,228 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// downloadCheckpointInterval is how many bytes are downloaded between two
+// checkpoints of a resumable download.
+var downloadCheckpointInterval int64 = 16 * 1024 * 1024
+
+// downloadCheckpoint records the progress of a resumable download, the data
+// of the partial file up to Offset is known to be synced to disk.
+type downloadCheckpoint struct {
+	Sha3_384 string `json:"sha3-384"`
+	Offset   int64  `json:"offset"`
+	ETag     string `json:"etag,omitempty"`
+	// HashState is the marshalled state of the hash of the first Offset
+	// bytes, if the hash implementation supports it.
+	HashState []byte `json:"hash-state,omitempty"`
+}
+
+// resumableFile is the partial file of a resumable download, it
+// periodically records checkpoints next to the partial file so that the
+// download can be resumed after a restart of snapd from the last block
+// known to be on disk.
+type resumableFile struct {
+	*os.File
+
+	checkpointPath string
+	sha3_384       string
+	etag           string
+	// hashState is the hash state loaded from the checkpoint, it is only
+	// used once when the download is resumed.
+	hashState []byte
+}
+
+func checkpointPathFor(partialPath string) string {
+	return partialPath + ".state"
+}
+
+// RemovePartialDownload removes what is left of an interrupted resumable
+// download of the blob at targetPath, that is the partial file and its
+// checkpoint.
+func RemovePartialDownload(targetPath string) error {
+	partialPath := targetPath + ".partial"
+	for _, p := range []string{checkpointPathFor(partialPath), partialPath} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsPartialDownload returns whether the given file name is the partial
+// file, or its checkpoint, of an interrupted download.
+func IsPartialDownload(name string) bool {
+	return strings.HasSuffix(name, ".partial") || strings.HasSuffix(name, ".partial.state")
+}
+
+// openResumableFile wraps the partial file f of a download of a blob with
+// the given hash. If a checkpoint for the same blob exists the partial file
+// is truncated to the last checkpointed offset, dropping any data that
+// might not have reached the disk, and that offset is returned. Otherwise
+// the current size of the partial file is returned.
+func openResumableFile(f *os.File, sha3_384 string) (rf *resumableFile, resume int64, err error) {
+	rf = &resumableFile{
+		File:           f,
+		checkpointPath: checkpointPathFor(f.Name()),
+		sha3_384:       sha3_384,
+	}
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var cp downloadCheckpoint
+	data, err := os.ReadFile(rf.checkpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return rf, size, nil
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &cp)
+	}
+	if err != nil || cp.Sha3_384 != sha3_384 || cp.Offset > size || cp.Offset < 0 {
+		// the checkpoint cannot be trusted, start over
+		logger.Debugf("Ignoring invalid download checkpoint %q.", rf.checkpointPath)
+		if err := rf.reset(); err != nil {
+			return nil, 0, err
+		}
+		return rf, 0, nil
+	}
+
+	if err := f.Truncate(cp.Offset); err != nil {
+		return nil, 0, err
+	}
+	if _, err := f.Seek(cp.Offset, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	rf.etag = cp.ETag
+	rf.hashState = cp.HashState
+	return rf, cp.Offset, nil
+}
+
+// restoreHash restores into h the hash state of the first resume bytes of
+// the partial file from the checkpoint, it returns false if that is not
+// possible and the data must be hashed again.
+func (rf *resumableFile) restoreHash(h hash.Hash, resume int64) bool {
+	hashState := rf.hashState
+	rf.hashState = nil
+	if len(hashState) == 0 {
+		return false
+	}
+	u, ok := h.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return false
+	}
+	if err := u.UnmarshalBinary(hashState); err != nil {
+		logger.Debugf("Cannot restore download hash state: %v", err)
+		return false
+	}
+	if _, err := rf.Seek(resume, io.SeekStart); err != nil {
+		return false
+	}
+	return true
+}
+
+// checkpoint syncs the partial file and records its current size, etag
+// and the state of h, which must cover the whole partial file.
+func (rf *resumableFile) checkpoint(h hash.Hash) error {
+	if err := rf.Sync(); err != nil {
+		return err
+	}
+	offset, err := rf.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	cp := downloadCheckpoint{
+		Sha3_384: rf.sha3_384,
+		Offset:   offset,
+		ETag:     rf.etag,
+	}
+	if m, ok := h.(encoding.BinaryMarshaler); ok {
+		// not fatal, the data is hashed again when resuming
+		if state, err := m.MarshalBinary(); err == nil {
+			cp.HashState = state
+		}
+	}
+	data, err := json.Marshal(&cp)
+	if err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(rf.checkpointPath, data, 0600, 0)
+}
+
+// reset truncates the partial file and drops its checkpoint, for when the
+// download needs to start over.
+func (rf *resumableFile) reset() error {
+	rf.etag = ""
+	rf.hashState = nil
+	if err := rf.removeCheckpoint(); err != nil {
+		return err
+	}
+	if err := rf.Truncate(0); err != nil {
+		return err
+	}
+	_, err := rf.Seek(0, io.SeekStart)
+	return err
+}
+
+func (rf *resumableFile) removeCheckpoint() error {
+	if err := os.Remove(rf.checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot remove download checkpoint: %v", err)
+	}
+	return nil
+}
+
+// checkpointWriter checkpoints a resumable download every
+// downloadCheckpointInterval bytes written to it. It must come after the
+// partial file and the hash in a io.MultiWriter so that both are up to date
+// when it is called.
+type checkpointWriter struct {
+	rf      *resumableFile
+	h       hash.Hash
+	pending int64
+}
+
+func (cw *checkpointWriter) Write(p []byte) (int, error) {
+	cw.pending += int64(len(p))
+	if cw.pending >= downloadCheckpointInterval {
+		cw.pending = 0
+		if err := cw.rf.checkpoint(cw.h); err != nil {
+			// not fatal, the download is resumed from an earlier
+			// checkpoint if interrupted
+			logger.Debugf("Cannot checkpoint download of %q: %v", cw.rf.Name(), err)
+		}
+	}
+	return len(p), nil
+}
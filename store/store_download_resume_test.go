@@ -0,0 +1,218 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/sha3"
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func resumableDownloadContent() []byte {
+	buf := make([]byte, 50000)
+	for i := range buf {
+		buf[i] = byte('a' + i%26)
+	}
+	return buf
+}
+
+func readDownloadCheckpoint(c *C, partialPath string) map[string]any {
+	data, err := os.ReadFile(partialPath + ".state")
+	c.Assert(err, IsNil)
+	var cp map[string]any
+	c.Assert(json.Unmarshal(data, &cp), IsNil)
+	return cp
+}
+
+func (s *storeDownloadSuite) TestDownloadResumableAcrossRestarts(c *C) {
+	restore := store.MockDownloadCheckpointInterval(1000)
+	defer restore()
+
+	buf := resumableDownloadContent()
+	sha3_384 := fmt.Sprintf("%x", sha3.Sum384(buf))
+
+	var mockServer *httptest.Server
+	n := 0
+	broken := true
+	mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.Header().Set("ETag", `"blob-etag"`)
+		if broken {
+			if n == 1 {
+				c.Check(r.Header.Get("Range"), Equals, "")
+				w.Header().Set("Content-Length", fmt.Sprintf("%d", len(buf)))
+				w.Write(buf[:30000])
+				mockServer.CloseClientConnections()
+				return
+			}
+			// the store stays unavailable
+			w.WriteHeader(500)
+			return
+		}
+		c.Check(r.Header.Get("Range"), Equals, "bytes=30000-")
+		c.Check(r.Header.Get("If-Range"), Equals, `"blob-etag"`)
+		w.WriteHeader(206)
+		w.Write(buf[30000:])
+	}))
+	defer mockServer.Close()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(buf))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	partialFn := targetFn + ".partial"
+	dlOpts := &store.DownloadOptions{Resumable: true}
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, dlOpts)
+	c.Assert(err, ErrorMatches, `.*\(500\).*`)
+
+	// the partial download and its checkpoint are kept
+	c.Check(partialFn, testutil.FileEquals, buf[:30000])
+	cp := readDownloadCheckpoint(c, partialFn)
+	c.Check(cp["sha3-384"], Equals, sha3_384)
+	c.Check(cp["offset"], Equals, 30000.0)
+	c.Check(cp["etag"], Equals, `"blob-etag"`)
+
+	// and the download resumes from there
+	broken = false
+	n = 0
+	err = s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, dlOpts)
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 1)
+	c.Check(targetFn, testutil.FileEquals, buf)
+	c.Check(partialFn, testutil.FileAbsent)
+	c.Check(partialFn+".state", testutil.FileAbsent)
+}
+
+func (s *storeDownloadSuite) TestDownloadResumableDropsDataPastCheckpoint(c *C) {
+	buf := resumableDownloadContent()
+	sha3_384 := fmt.Sprintf("%x", sha3.Sum384(buf))
+
+	n := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Header.Get("Range"), Equals, "bytes=20000-")
+		c.Check(r.Header.Get("If-Range"), Equals, `"blob-etag"`)
+		w.WriteHeader(206)
+		w.Write(buf[20000:])
+	}))
+	defer mockServer.Close()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = sha3_384
+	snap.Size = int64(len(buf))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	partialFn := targetFn + ".partial"
+	// data past the checkpoint might not have made it to the disk
+	partial := append(append([]byte(nil), buf[:20000]...), []byte("torn write")...)
+	c.Assert(os.WriteFile(partialFn, partial, 0600), IsNil)
+	c.Assert(os.WriteFile(partialFn+".state", []byte(fmt.Sprintf(`{"sha3-384":%q,"offset":20000,"etag":"\"blob-etag\""}`, sha3_384)), 0600), IsNil)
+
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Resumable: true})
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 1)
+	c.Check(targetFn, testutil.FileEquals, buf)
+	c.Check(partialFn+".state", testutil.FileAbsent)
+}
+
+func (s *storeDownloadSuite) TestDownloadResumableIgnoresCheckpointOfOtherBlob(c *C) {
+	buf := resumableDownloadContent()
+
+	n := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Header.Get("Range"), Equals, "")
+		w.Write(buf)
+	}))
+	defer mockServer.Close()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(buf))
+	snap.Size = int64(len(buf))
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	partialFn := targetFn + ".partial"
+	c.Assert(os.WriteFile(partialFn, []byte("other blob"), 0600), IsNil)
+	c.Assert(os.WriteFile(partialFn+".state", []byte(`{"sha3-384":"other","offset":5}`), 0600), IsNil)
+
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Resumable: true})
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 1)
+	c.Check(targetFn, testutil.FileEquals, buf)
+	c.Check(partialFn+".state", testutil.FileAbsent)
+}
+
+func (s *storeDownloadSuite) TestDownloadNotResumableRemovesPartial(c *C) {
+	n := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.WriteHeader(500)
+	}))
+	defer mockServer.Close()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.DownloadURL = mockServer.URL
+	snap.Sha3_384 = "abcdabcd"
+	snap.Size = 100
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	partialFn := targetFn + ".partial"
+	c.Assert(os.WriteFile(partialFn, []byte("partial"), 0600), IsNil)
+
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, ErrorMatches, `.*\(500\).*`)
+	c.Check(partialFn, testutil.FileAbsent)
+	c.Check(partialFn+".state", testutil.FileAbsent)
+}
+
+func (s *storeDownloadSuite) TestRemovePartialDownload(c *C) {
+	target := filepath.Join(c.MkDir(), "foo_1.snap")
+	c.Assert(os.WriteFile(target+".partial", nil, 0644), IsNil)
+	c.Assert(os.WriteFile(target+".partial.state", nil, 0644), IsNil)
+
+	c.Assert(store.RemovePartialDownload(target), IsNil)
+	c.Check(target+".partial", testutil.FileAbsent)
+	c.Check(target+".partial.state", testutil.FileAbsent)
+
+	// nothing to remove
+	c.Assert(store.RemovePartialDownload(target), IsNil)
+
+	c.Check(store.IsPartialDownload("foo_1.snap.partial"), Equals, true)
+	c.Check(store.IsPartialDownload("foo_1.snap.partial.state"), Equals, true)
+	c.Check(store.IsPartialDownload("foo_1.snap"), Equals, false)
+}
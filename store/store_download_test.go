@@ -708,6 +708,28 @@ func (s *storeDownloadSuite) TestApplyDelta(c *C) {
 	}
 }
 
+func (s *storeDownloadSuite) TestApplyDeltaParallelInstance(c *C) {
+	deltaInfo := &snap.DeltaInfo{Format: "xdelta3", FromRevision: 24, ToRevision: 26}
+	// only the blob of the parallel instance is around
+	currentSnapPath := filepath.Join(dirs.SnapBlobDir, "foo_bar_24.snap")
+	targetSnapPath := filepath.Join(dirs.SnapBlobDir, "foo_bar_26.snap")
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+	c.Assert(os.WriteFile(currentSnapPath, nil, 0644), IsNil)
+	deltaPath := filepath.Join(dirs.SnapBlobDir, "the.delta")
+	c.Assert(os.WriteFile(deltaPath, nil, 0644), IsNil)
+	// simulate the result of xdelta3
+	c.Assert(os.WriteFile(targetSnapPath+".partial", nil, 0644), IsNil)
+
+	sto := &store.Store{}
+	err := store.ApplyDelta(sto, "foo", deltaPath, deltaInfo, targetSnapPath, "")
+	c.Assert(err, IsNil)
+	c.Check(s.mockXDelta.Calls(), DeepEquals, [][]string{
+		{"xdelta3", "config"},
+		{"xdelta3", "-d", "-s", currentSnapPath, deltaPath, targetSnapPath + ".partial"},
+	})
+	c.Check(targetSnapPath, testutil.FilePresent)
+}
+
 type cacheObserver struct {
 	inCache map[string]bool
 
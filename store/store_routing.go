@@ -0,0 +1,158 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/snap"
+)
+
+// snapRoute identifies a request snap actions are sent with, to the store
+// their snaps are routed to and for the architecture they are requested for.
+type snapRoute struct {
+	storeID      string
+	architecture string
+}
+
+// routeSnapActions splits the actions into those for snaps resolved
+// through the device store for the device architecture and the others,
+// grouped by store id and architecture.
+func (s *Store) routeSnapActions(actions []*SnapAction) (deviceActions []*SnapAction, routed map[snapRoute][]*SnapAction) {
+	deviceStoreID := s.storeID("")
+	for _, a := range actions {
+		storeID := deviceStoreID
+		if s.dauthCtx != nil {
+			storeID = s.storeID(snap.InstanceSnap(a.InstanceName))
+		}
+		foreignArch := a.Architecture != "" && a.Architecture != s.architecture
+		if storeID == deviceStoreID && !foreignArch {
+			deviceActions = append(deviceActions, a)
+			continue
+		}
+		route := snapRoute{storeID: storeID}
+		if foreignArch {
+			route.architecture = a.Architecture
+		}
+		if routed == nil {
+			routed = make(map[snapRoute][]*SnapAction)
+		}
+		routed[route] = append(routed[route], a)
+	}
+	return deviceActions, routed
+}
+
+// routedSnapAction performs the actions with one request to the device
+// store, which also resolves the assertions, and one request for each of
+// the stores some of the snaps are routed to and each of the foreign
+// architectures some of the snaps are requested for, merging the results.
+func (s *Store) routedSnapAction(ctx context.Context, currentSnaps []*CurrentSnap, deviceActions []*SnapAction, routed map[snapRoute][]*SnapAction, assertQuery AssertionQuery, toResolve map[asserts.Grouping][]*asserts.AtRevision, toResolveSeq map[asserts.Grouping][]*asserts.AtSequence, user *auth.UserState, opts *RefreshOptions) ([]SnapActionResult, []AssertionResult, error) {
+	var sars []SnapActionResult
+	var ars []AssertionResult
+	merged := &SnapActionError{NoResults: true}
+	hasErrors := false
+	merge := func(res []SnapActionResult, err error) error {
+		sars = append(sars, res...)
+		if len(res) > 0 {
+			merged.NoResults = false
+		}
+		if err == nil {
+			return nil
+		}
+		saErr, ok := err.(*SnapActionError)
+		if !ok {
+			return err
+		}
+		if !saErr.NoResults {
+			merged.NoResults = false
+		}
+		merged.Refresh = mergeSnapErrors(merged.Refresh, saErr.Refresh)
+		merged.Install = mergeSnapErrors(merged.Install, saErr.Install)
+		merged.Download = mergeSnapErrors(merged.Download, saErr.Download)
+		merged.Other = append(merged.Other, saErr.Other...)
+		hasErrors = hasErrors || len(saErr.Refresh)+len(saErr.Install)+len(saErr.Download)+len(saErr.Other) > 0
+		return nil
+	}
+
+	if len(deviceActions) > 0 || len(toResolve) > 0 || len(toResolveSeq) > 0 {
+		res, assertRes, err := s.snapActionWithAuthRefresh(ctx, currentSnaps, deviceActions, assertQuery, toResolve, toResolveSeq, user, opts)
+		if err := merge(res, err); err != nil {
+			return nil, nil, err
+		}
+		ars = assertRes
+	}
+
+	routes := make([]snapRoute, 0, len(routed))
+	for route := range routed {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].storeID != routes[j].storeID {
+			return routes[i].storeID < routes[j].storeID
+		}
+		return routes[i].architecture < routes[j].architecture
+	})
+	for _, route := range routes {
+		actions := routed[route]
+		routedOpts := *opts
+		routedOpts.routeSnap = snap.InstanceSnap(actions[0].InstanceName)
+		routedOpts.architecture = route.architecture
+		res, _, err := s.snapActionWithAuthRefresh(ctx, currentSnapsForActions(currentSnaps, actions), actions, nil, nil, nil, user, &routedOpts)
+		if err := merge(res, err); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if hasErrors || merged.NoResults {
+		return sars, ars, merged
+	}
+	return sars, ars, nil
+}
+
+func mergeSnapErrors(dst, src map[string]error) map[string]error {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]error, len(src))
+	}
+	for name, err := range src {
+		dst[name] = err
+	}
+	return dst
+}
+
+// currentSnapsForActions returns the current snaps the actions refer to.
+func currentSnapsForActions(currentSnaps []*CurrentSnap, actions []*SnapAction) []*CurrentSnap {
+	names := make(map[string]bool, len(actions))
+	for _, a := range actions {
+		names[a.InstanceName] = true
+	}
+	var curSnaps []*CurrentSnap
+	for _, cur := range currentSnaps {
+		if names[cur.InstanceName] {
+			curSnaps = append(curSnaps, cur)
+		}
+	}
+	return curSnaps
+}
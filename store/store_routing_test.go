@@ -0,0 +1,236 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2025 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/store"
+)
+
+func (s *storeActionSuite) TestSnapActionRoutedSnaps(c *C) {
+	var mu sync.Mutex
+	var seen []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertRequest(c, r, "POST", snapActionPath)
+
+		jsonReq, err := io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		var req struct {
+			Actions []map[string]any `json:"actions"`
+		}
+		c.Assert(json.Unmarshal(jsonReq, &req), IsNil)
+		c.Assert(req.Actions, HasLen, 1)
+		name := req.Actions[0]["name"].(string)
+
+		mu.Lock()
+		seen = append(seen, fmt.Sprintf("%s@%s", name, r.Header.Get("Snap-Device-Store")))
+		mu.Unlock()
+
+		fmt.Fprintf(w, `{
+  "results": [{
+     "result": "install",
+     "instance-key": "install-1",
+     "snap-id": "%[1]s-id",
+     "name": "%[1]s",
+     "snap": {
+       "snap-id": "%[1]s-id",
+       "name": "%[1]s",
+       "revision": 1,
+       "version": "1.0",
+       "publisher": {"id": "pub", "username": "pub", "display-name": "Pub"}
+     }
+  }]
+}`, name)
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	mockServerURL, _ := url.Parse(mockServer.URL)
+	cfg := store.Config{
+		StoreBaseURL: mockServerURL,
+	}
+	dauthCtx := &testDauthContext{
+		c:       c,
+		device:  s.device,
+		storeID: "brand-store",
+		snapStoreIDs: map[string]string{
+			"friendly-snap": "friendly-store",
+			"global-snap":   "",
+		},
+	}
+	sto := store.New(&cfg, dauthCtx)
+
+	results, _, err := sto.SnapAction(s.ctx, nil, []*store.SnapAction{
+		{Action: "install", InstanceName: "brand-snap", Channel: "stable"},
+		{Action: "install", InstanceName: "friendly-snap", Channel: "stable"},
+		{Action: "install", InstanceName: "global-snap", Channel: "stable"},
+	}, nil, nil, nil)
+	c.Assert(err, IsNil)
+
+	var names []string
+	for _, res := range results {
+		names = append(names, res.InstanceName())
+	}
+	sort.Strings(names)
+	c.Check(names, DeepEquals, []string{"brand-snap", "friendly-snap", "global-snap"})
+
+	// one request per store
+	sort.Strings(seen)
+	c.Check(seen, DeepEquals, []string{
+		"brand-snap@brand-store",
+		"friendly-snap@friendly-store",
+		"global-snap@",
+	})
+}
+
+func (s *storeActionSuite) TestSnapActionRoutedSnapsErrors(c *C) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertRequest(c, r, "POST", snapActionPath)
+		if r.Header.Get("Snap-Device-Store") == "friendly-store" {
+			io.WriteString(w, `{
+  "results": [{
+     "result": "error",
+     "instance-key": "install-1",
+     "name": "friendly-snap",
+     "error": {"code": "name-not-found", "message": "not found"}
+  }]
+}`)
+			return
+		}
+		io.WriteString(w, `{
+  "results": [{
+     "result": "install",
+     "instance-key": "install-1",
+     "snap-id": "brand-snap-id",
+     "name": "brand-snap",
+     "snap": {
+       "snap-id": "brand-snap-id",
+       "name": "brand-snap",
+       "revision": 1,
+       "version": "1.0",
+       "publisher": {"id": "pub", "username": "pub", "display-name": "Pub"}
+     }
+  }]
+}`)
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	mockServerURL, _ := url.Parse(mockServer.URL)
+	cfg := store.Config{
+		StoreBaseURL: mockServerURL,
+	}
+	dauthCtx := &testDauthContext{
+		c:            c,
+		device:       s.device,
+		storeID:      "brand-store",
+		snapStoreIDs: map[string]string{"friendly-snap": "friendly-store"},
+	}
+	sto := store.New(&cfg, dauthCtx)
+
+	results, _, err := sto.SnapAction(s.ctx, nil, []*store.SnapAction{
+		{Action: "install", InstanceName: "brand-snap", Channel: "stable"},
+		{Action: "install", InstanceName: "friendly-snap", Channel: "stable"},
+	}, nil, nil, nil)
+	c.Assert(results, HasLen, 1)
+	c.Check(results[0].InstanceName(), Equals, "brand-snap")
+	saErr, ok := err.(*store.SnapActionError)
+	c.Assert(ok, Equals, true)
+	c.Check(saErr.NoResults, Equals, false)
+	c.Check(saErr.Install, DeepEquals, map[string]error{
+		"friendly-snap": store.ErrSnapNotFound,
+	})
+}
+
+func (s *storeActionSuite) TestSnapActionForeignArchitecture(c *C) {
+	var mu sync.Mutex
+	var seen []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertRequest(c, r, "POST", snapActionPath)
+
+		jsonReq, err := io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		var req struct {
+			Actions []map[string]any `json:"actions"`
+		}
+		c.Assert(json.Unmarshal(jsonReq, &req), IsNil)
+
+		var names, results []string
+		for _, action := range req.Actions {
+			name := action["name"].(string)
+			names = append(names, name)
+			results = append(results, fmt.Sprintf(`{
+     "result": "install",
+     "instance-key": %[2]q,
+     "snap-id": "%[1]s-id",
+     "name": "%[1]s",
+     "snap": {
+       "snap-id": "%[1]s-id",
+       "name": "%[1]s",
+       "revision": 1,
+       "version": "1.0",
+       "publisher": {"id": "pub", "username": "pub", "display-name": "Pub"}
+     }
+  }`, name, action["instance-key"]))
+		}
+		sort.Strings(names)
+
+		mu.Lock()
+		seen = append(seen, fmt.Sprintf("%s@%s", strings.Join(names, ","), r.Header.Get("Snap-Device-Architecture")))
+		mu.Unlock()
+
+		fmt.Fprintf(w, `{"results": [%s]}`, strings.Join(results, ","))
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	mockServerURL, _ := url.Parse(mockServer.URL)
+	cfg := store.Config{
+		StoreBaseURL: mockServerURL,
+		Architecture: "amd64",
+	}
+	sto := store.New(&cfg, nil)
+
+	results, _, err := sto.SnapAction(s.ctx, nil, []*store.SnapAction{
+		{Action: "install", InstanceName: "native-snap", Channel: "stable"},
+		{Action: "install", InstanceName: "also-native-snap", Channel: "stable", Architecture: "amd64"},
+		{Action: "install", InstanceName: "foreign-snap", Channel: "stable", Architecture: "armhf"},
+	}, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(results, HasLen, 3)
+
+	// the foreign architecture snap is requested separately
+	sort.Strings(seen)
+	c.Check(seen, DeepEquals, []string{
+		"also-native-snap,native-snap@amd64",
+		"foreign-snap@armhf",
+	})
+}
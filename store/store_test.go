@@ -244,6 +244,8 @@ type testDauthContext struct {
 	proxyStoreURL *url.URL
 
 	storeID string
+	// snapStoreIDs routes snaps to stores other than the device one
+	snapStoreIDs map[string]string
 
 	storeOffline bool
 
@@ -287,6 +289,13 @@ func (dac *testDauthContext) StoreID(fallback string) (string, error) {
 	return fallback, nil
 }
 
+func (dac *testDauthContext) SnapStoreID(snapName, fallback string) (string, error) {
+	if storeID, ok := dac.snapStoreIDs[snapName]; ok {
+		return storeID, nil
+	}
+	return dac.StoreID(fallback)
+}
+
 func (dac *testDauthContext) DeviceSessionRequestParams(nonce string) (*store.DeviceSessionRequestParams, error) {
 	model, err := asserts.Decode([]byte(exModel))
 	if err != nil {
@@ -675,6 +684,52 @@ func (s *storeTestSuite) TestEnsureDeviceSession(c *C) {
 	c.Check(deviceSessionRequested, Equals, 1)
 }
 
+func (s *storeTestSuite) TestRenewDeviceSession(c *C) {
+	deviceSessionRequested := 0
+	// mock store response
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.UserAgent(), Equals, userAgent)
+
+		switch r.URL.Path {
+		case authNoncesPath:
+			io.WriteString(w, `{"nonce": "1234567890:9876543210"}`)
+		case authSessionPath:
+			// the current session is passed along for renewal
+			authorization := r.Header.Get("X-Device-Authorization")
+			c.Check(authorization, Equals, `Macaroon root="device-macaroon"`)
+			deviceSessionRequested++
+			io.WriteString(w, `{"macaroon": "renewed-session-macaroon"}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	mockServerURL, _ := url.Parse(mockServer.URL)
+
+	s.device.SessionMacaroon = "device-macaroon"
+	dauthCtx := &testDauthContext{c: c, device: s.device}
+	sto := store.New(&store.Config{
+		StoreBaseURL: mockServerURL,
+	}, dauthCtx)
+
+	err := sto.RenewDeviceSession()
+	c.Assert(err, IsNil)
+
+	c.Check(s.device.SessionMacaroon, Equals, "renewed-session-macaroon")
+	c.Check(deviceSessionRequested, Equals, 1)
+}
+
+func (s *storeTestSuite) TestRenewDeviceSessionNoSerial(c *C) {
+	s.device.Serial = ""
+	dauthCtx := &testDauthContext{c: c, device: s.device}
+	sto := store.New(&store.Config{}, dauthCtx)
+
+	err := sto.RenewDeviceSession()
+	c.Assert(err, Equals, store.ErrNoSerial)
+}
+
 func (s *storeTestSuite) TestEnsureDeviceSessionSerialisation(c *C) {
 	var deviceSessionRequested int32
 	// mock store response
@@ -1610,6 +1665,7 @@ func (s *storeTestSuite) TestInfoAndChannels(c *C) {
 			Size:        20480,
 			Epoch:       snap.E("0"),
 			ReleasedAt:  time.Date(2019, 4, 17, 16, 47, 59, 117114000, time.UTC),
+			Base:        "bogus-base",
 		},
 		"latest/candidate": {
 			Revision:    snap.R(29),
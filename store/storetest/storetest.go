@@ -47,6 +47,10 @@ func (Store) EnsureDeviceSession() error {
 	panic("Store.EnsureDeviceSession not expected")
 }
 
+func (Store) RenewDeviceSession() error {
+	panic("Store.RenewDeviceSession not expected")
+}
+
 func (Store) SnapInfo(context.Context, store.SnapSpec, *auth.UserState) (*snap.Info, error) {
 	panic("Store.SnapInfo not expected")
 }
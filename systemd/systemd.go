@@ -449,6 +449,9 @@ type Systemd interface {
 	// threads if enabled, etc) part of the unit, which can be a service or a
 	// slice.
 	CurrentTasksCount(unit string) (uint64, error)
+	// CurrentCPUUsage returns the total CPU time consumed by the specified
+	// unit, which can be a service or a slice.
+	CurrentCPUUsage(unit string) (time.Duration, error)
 	// Run a command
 	Run(command []string, opts *RunOptions) ([]byte, error)
 	// Set log level for the system
@@ -921,6 +924,19 @@ func (s *systemd) CurrentTasksCount(unit string) (uint64, error) {
 	return tasksCount, nil
 }
 
+func (s *systemd) CurrentCPUUsage(unit string) (time.Duration, error) {
+	nsecs, err := s.getPropertyUintValue(unit, "CPUUsageNSec")
+	if err != nil && err != errNotSet {
+		return 0, err
+	}
+
+	if err == errNotSet {
+		return 0, fmt.Errorf("cpu usage unavailable")
+	}
+
+	return time.Duration(nsecs), nil
+}
+
 func (s *systemd) CurrentMemoryUsage(unit string) (quantity.Size, error) {
 	memBytes, err := s.getPropertyUintValue(unit, "MemoryCurrent")
 	if err != nil && err != errNotSet {